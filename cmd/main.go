@@ -17,16 +17,23 @@ limitations under the License.
 package main
 
 import (
-	"openebs.io/metac/controller/generic"
-	"openebs.io/metac/start"
+	"flag"
+	"os"
+	"strings"
 
-	"mayadata.io/cstorpoolauto/controller/blockdevice"
+	"github.com/golang/glog"
+
+	"mayadata.io/cstorpoolauto/common/adoption"
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	"mayadata.io/cstorpoolauto/controller"
 	"mayadata.io/cstorpoolauto/controller/cstorclusterconfig"
-	"mayadata.io/cstorpoolauto/controller/cstorclusterplan"
 	"mayadata.io/cstorpoolauto/controller/cstorclusterstorageset"
-	"mayadata.io/cstorpoolauto/controller/cstorpoolcluster"
-	"mayadata.io/cstorpoolauto/controller/localdevice"
-	localdevicev1alpha1 "mayadata.io/cstorpoolauto/controller/localdevice/v1alpha1"
+	"mayadata.io/cstorpoolauto/pkg/faultinject"
+	"mayadata.io/cstorpoolauto/pkg/features"
+	"mayadata.io/cstorpoolauto/pkg/lock"
+	sb "mayadata.io/cstorpoolauto/pkg/supportbundle"
+	"mayadata.io/cstorpoolauto/pkg/tracing"
+	"mayadata.io/cstorpoolauto/start"
 )
 
 // main function is the entry point of this binary.
@@ -43,16 +50,202 @@ import (
 // NOTE:
 //	One can consider each registered function as an independent
 // kubernetes controller & this project as the operator.
+//
+// NOTE:
+//	Attachments of these controllers can be customised via
+// --watch-attachment & --unwatch-attachment flags.
+//
+// NOTE:
+//	Controllers can be enabled selectively via --enable-controllers
+// flag. All the controllers are enabled by default.
+//
+// NOTE:
+//	Resync intervals of controllers can be overridden via
+// --resync-after flag or a file e.g. a mounted ConfigMap set via
+// --resync-after-config-path flag.
+//
+// NOTE:
+//	Flags can be set via a YAML file e.g. a mounted ConfigMap set via
+// --config flag. Flags set via the command line take precedence over
+// this file. Log level, resync intervals & max API throttle level are
+// reloaded whenever this file changes.
+//
+// NOTE:
+//	Block devices with any of the --reserved-device-keys as a label
+// or annotation are never used to build pools.
+//
+// NOTE:
+//	Controllers that select block devices are serialized per
+// CStorClusterConfig & per node. Their total concurrency can be
+// bounded via --max-concurrent-reconciles flag.
+//
+// NOTE:
+//	Namespace & labels of the Storages can be set via
+// --storage-namespace & --storage-labels flags. These can be
+// overridden per CStorClusterConfig.
+//
+// NOTE:
+//	pprof & expvar endpoints are served at --debug-addr only if
+// this flag is set explicitly. Goroutine count & sync durations of
+// every controller are then logged every --debug-log-interval.
+//
+// NOTE:
+//	Support bundles collected on request include the latest
+// --support-bundle-decision-limit sync decisions of controllers.
+//
+// NOTE:
+//	Children whose CStorClusterConfig or CStorClusterPlan no longer
+// exists are audited every --orphan-audit-interval in the background.
+// These are reported or deleted based on --orphan-audit-policy.
+//
+// NOTE:
+//	Every CStorClusterConfig of the cluster is listed along with its
+// nodes, CStorPoolCluster(s), capacity & health into the singleton
+// CStorPoolAutoInventory every --inventory-interval.
+//
+// NOTE:
+//	Faults are injected into device selection, plan computation &
+// CStorPoolCluster build phases of reconcilers only if
+// --fault-injection is set. This is meant for chaos tests only.
+//
+// NOTE:
+//	Features can be enabled or disabled per cluster via
+// --feature-gates. Settings of CStorClusterConfig(s) that need a
+// disabled feature fail their validation.
+//
+// NOTE:
+//	Every sync stops once it runs past --sync-timeout & is retried
+// soon after with a TimeoutError. Syncs have no deadline by default.
+//
+// NOTE:
+//	CStorClusterPlan(s), CStorClusterStorageSet(s) & CStorPoolCluster(s)
+// annotated by an older release are adopted if their legacy annotation
+// keys are mapped to the current keys via --legacy-annotation-keys.
+//
+// NOTE:
+//	'validate -f <manifest>' validates the CStorClusterConfig(s) of
+// the manifest & exits without starting any controllers.
+//
+// NOTE:
+//	'export -f <manifest> --format helm|kustomize' renders the
+// CStorPoolCluster(s) of the manifest to stdout & exits without
+// starting any controllers.
 func main() {
-	generic.AddToInlineRegistry("sync/cstorclusterconfig", cstorclusterconfig.Sync)
-	generic.AddToInlineRegistry("sync/cstorclusterplan", cstorclusterplan.Sync)
-	generic.AddToInlineRegistry("sync/cstorclusterstorageset", cstorclusterstorageset.Sync)
-	generic.AddToInlineRegistry("sync/blockdevice", blockdevice.Sync)
-	generic.AddToInlineRegistry("sync/cstorpoolcluster", cstorpoolcluster.Sync)
-	generic.AddToInlineRegistry("sync/localdevicev1alpha1", localdevicev1alpha1.Sync)
-	generic.AddToInlineRegistry("finalize/localdevicev1alpha1", localdevicev1alpha1.Finalize)
-	generic.AddToInlineRegistry("sync/localdevice", localdevice.Sync)
-	generic.AddToInlineRegistry("finalize/localdevice", localdevice.Finalize)
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(start.RunValidate(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		os.Exit(start.RunExport(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	flag.IntVar(
+		&cstorclusterconfig.RevisionHistoryLimit,
+		"plan-revision-history-limit",
+		cstorclusterconfig.DefaultRevisionHistoryLimit,
+		"Number of CStorClusterPlanRevision(s) to retain per CStorClusterPlan",
+	)
+
+	maxConcurrentReconciles := flag.Int(
+		"max-concurrent-reconciles",
+		0,
+		"Maximum number of block device selecting reconciliations that run concurrently; 0 implies no limit",
+	)
+
+	reservedDeviceKeys := flag.String(
+		"reserved-device-keys",
+		strings.Join(bd.DefaultReservedKeys, ","),
+		"Comma separated label or annotation keys that reserve a block device for other consumers; empty disables reservations",
+	)
+
+	storageNamespace := flag.String(
+		"storage-namespace",
+		"",
+		"Namespace where Storages are created; defaults to the target namespace of the storage set",
+	)
+
+	storageLabels := flag.String(
+		"storage-labels",
+		"",
+		"Comma separated key=value labels that are set against every Storage",
+	)
+
+	decisionLimit := flag.Int(
+		"support-bundle-decision-limit",
+		sb.DefaultDecisionLimit,
+		"Number of the latest sync decisions retained to be collected into support bundles; 0 disables the recording",
+	)
+
+	faultInjection := flag.String(
+		"fault-injection",
+		"",
+		"Comma separated <phase>=fail:<percent> or <phase>=delay:<percent>:<duration> rules that inject faults into reconcilers for chaos tests; empty disables the injection",
+	)
+
+	syncTimeout := flag.Duration(
+		"sync-timeout",
+		0,
+		"Deadline of every controller sync e.g. 20s; a sync that runs past this is retried with a TimeoutError; 0 implies no deadline",
+	)
+
+	legacyAnnotationKeys := flag.String(
+		"legacy-annotation-keys",
+		"",
+		"Comma separated <legacy-key>=<current-key> ownership annotation keys of resources generated by an older release; these resources are adopted into the current keys",
+	)
+
+	featureGates := flag.String(
+		"feature-gates",
+		"",
+		"Comma separated <feature>=<true|false> gates that enable or disable features e.g. HybridDisks=false; features without a gate use their defaults",
+	)
+
+	enabledControllers := flag.String(
+		"enable-controllers",
+		strings.Join(controller.Names(), ","),
+		"Comma separated names of the controllers to be enabled",
+	)
+	// flags are parsed before registering the hooks since the
+	// hooks of disabled controllers are not registered
+	flag.Parse()
+	// settings of the operator config file are applied as the values
+	// of the flags that were not set via the command line
+	err := start.LoadOperatorConfig()
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	lock.SetMaxConcurrentReconciles(*maxConcurrentReconciles)
+	bd.SetReservedKeys(*reservedDeviceKeys)
+	sb.SetDecisionLimit(*decisionLimit)
+	tracing.SetSyncTimeout(*syncTimeout)
+	cstorclusterstorageset.SetStorageNamespace(*storageNamespace)
+	err = cstorclusterstorageset.SetStorageLabels(*storageLabels)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	err = adoption.SetLegacyKeys(*legacyAnnotationKeys)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	err = faultinject.SetRules(*faultInjection)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	if *faultInjection != "" {
+		glog.Warningf("Fault injection is enabled: %s", *faultInjection)
+	}
+	err = features.SetGates(*featureGates)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	glog.Infof("Feature gates: %s", features.String())
+
+	enabled := start.ParseControllerNames(*enabledControllers)
+	err = start.RegisterControllers(controller.All, enabled)
+	if err != nil {
+		glog.Fatal(err)
+	}
+	glog.Infof("Enabled controllers: %s", strings.Join(enabled, ","))
 
 	start.Start()
 }
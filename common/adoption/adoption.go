@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package adoption lets the controllers adopt the resources that
+// were generated by an older release of this operator with different
+// ownership annotation keys.
+//
+// NOTE:
+//	Controllers match their children e.g. CStorClusterPlan(s),
+// CStorClusterStorageSet(s) & CStorPoolCluster(s) via ownership
+// annotations. Children annotated with legacy keys are otherwise not
+// matched & get created again. Such children are adopted by copying
+// the values of legacy keys to the current keys before matching. The
+// children are then applied with the current keys in the same sync.
+package adoption
+
+import (
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// currentKeys are the ownership annotation keys that legacy keys
+// can be migrated to
+var currentKeys = map[string]bool{
+	types.AnnKeyCStorClusterConfigUID:            true,
+	types.AnnKeyCStorClusterConfigNamespacedName: true,
+	types.AnnKeyCStorClusterPlanUID:              true,
+	types.AnnKeyCStorClusterStorageSetUID:        true,
+}
+
+// LegacyKeys maps the legacy ownership annotation keys to the current
+// ones. This is set via --legacy-annotation-keys flag.
+var LegacyKeys map[string]string
+
+// SetLegacyKeys sets the legacy keys from the given comma separated
+// <legacy-key>=<current-key> pairs. Resources are not adopted if no
+// pairs are given.
+func SetLegacyKeys(value string) error {
+	keys := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return errors.Errorf(
+				"Invalid legacy annotation keys %q: Want <legacy-key>=<current-key>", pair,
+			)
+		}
+		legacy, current := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if legacy == "" || legacy == current {
+			return errors.Errorf(
+				"Invalid legacy annotation keys %q: Invalid legacy key %q", pair, legacy,
+			)
+		}
+		if !currentKeys[current] {
+			return errors.Errorf(
+				"Invalid legacy annotation keys %q: Unsupported current key %q", pair, current,
+			)
+		}
+		keys[legacy] = current
+	}
+	if len(keys) == 0 {
+		keys = nil
+	}
+	LegacyKeys = keys
+	return nil
+}
+
+// Adopt copies the values of the legacy annotation keys of the given
+// resource to their current keys & removes the legacy keys. Current
+// keys that are already set are retained. It returns true if the
+// annotations of the resource were changed.
+func Adopt(obj *unstructured.Unstructured, legacyKeys map[string]string) bool {
+	if obj == nil || len(legacyKeys) == 0 {
+		return false
+	}
+	annotations := obj.GetAnnotations()
+	var isAdopted bool
+	for legacy, current := range legacyKeys {
+		value, found := annotations[legacy]
+		if !found {
+			continue
+		}
+		if _, found := annotations[current]; !found {
+			annotations[current] = value
+			glog.V(2).Infof(
+				"Will adopt %s %q / %q: Annotation %q migrated to %q",
+				obj.GetKind(), obj.GetNamespace(), obj.GetName(), legacy, current,
+			)
+		}
+		delete(annotations, legacy)
+		isAdopted = true
+	}
+	if isAdopted {
+		obj.SetAnnotations(annotations)
+	}
+	return isAdopted
+}
+
+// AdoptAll adopts each of the given resources in place & returns
+// the count of resources that were adopted
+func AdoptAll(objs []*unstructured.Unstructured, legacyKeys map[string]string) int {
+	var count int
+	for _, obj := range objs {
+		if Adopt(obj, legacyKeys) {
+			count++
+		}
+	}
+	return count
+}
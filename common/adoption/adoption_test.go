@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adoption
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+const legacyConfigUIDKey = "dao.mayadata.io/cstor-cluster-config-uid"
+
+func newPlan(annotations map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{},
+	}
+	obj.SetKind(string(types.KindCStorClusterPlan))
+	obj.SetNamespace("openebs")
+	obj.SetName("my-plan")
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestSetLegacyKeys(t *testing.T) {
+	var tests = map[string]struct {
+		value  string
+		expect map[string]string
+		isErr  bool
+	}{
+		"no keys": {},
+		"blank keys": {
+			value: " , ",
+		},
+		"valid keys": {
+			value: legacyConfigUIDKey + "=" + types.AnnKeyCStorClusterConfigUID +
+				", old/plan-uid = " + types.AnnKeyCStorClusterPlanUID,
+			expect: map[string]string{
+				legacyConfigUIDKey: types.AnnKeyCStorClusterConfigUID,
+				"old/plan-uid":     types.AnnKeyCStorClusterPlanUID,
+			},
+		},
+		"missing current key": {
+			value: legacyConfigUIDKey,
+			isErr: true,
+		},
+		"missing legacy key": {
+			value: "=" + types.AnnKeyCStorClusterConfigUID,
+			isErr: true,
+		},
+		"same legacy & current key": {
+			value: types.AnnKeyCStorClusterConfigUID + "=" + types.AnnKeyCStorClusterConfigUID,
+			isErr: true,
+		},
+		"unsupported current key": {
+			value: legacyConfigUIDKey + "=" + types.AnnKeyPinPool,
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			defer func() { LegacyKeys = nil }()
+			err := SetLegacyKeys(mock.value)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expect, LegacyKeys); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	legacyKeys := map[string]string{
+		legacyConfigUIDKey: types.AnnKeyCStorClusterConfigUID,
+	}
+	var tests = map[string]struct {
+		obj               *unstructured.Unstructured
+		legacyKeys        map[string]string
+		expectAnnotations map[string]string
+		isAdopted         bool
+	}{
+		"nil resource": {
+			legacyKeys: legacyKeys,
+		},
+		"no legacy keys": {
+			obj: newPlan(map[string]string{
+				legacyConfigUIDKey: "config-101",
+			}),
+			expectAnnotations: map[string]string{
+				legacyConfigUIDKey: "config-101",
+			},
+		},
+		"resource without legacy key": {
+			obj: newPlan(map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "config-101",
+			}),
+			legacyKeys: legacyKeys,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "config-101",
+			},
+		},
+		"resource with legacy key": {
+			obj: newPlan(map[string]string{
+				legacyConfigUIDKey: "config-101",
+				"app":              "cstor",
+			}),
+			legacyKeys: legacyKeys,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "config-101",
+				"app":                             "cstor",
+			},
+			isAdopted: true,
+		},
+		"resource with legacy & current keys": {
+			obj: newPlan(map[string]string{
+				legacyConfigUIDKey:                "config-old",
+				types.AnnKeyCStorClusterConfigUID: "config-101",
+			}),
+			legacyKeys: legacyKeys,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "config-101",
+			},
+			isAdopted: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := Adopt(mock.obj, mock.legacyKeys)
+			if got != mock.isAdopted {
+				t.Fatalf("Expected adopted %t got %t", mock.isAdopted, got)
+			}
+			if mock.obj == nil {
+				return
+			}
+			if diff := cmp.Diff(mock.expectAnnotations, mock.obj.GetAnnotations()); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdoptAll(t *testing.T) {
+	legacyKeys := map[string]string{
+		legacyConfigUIDKey: types.AnnKeyCStorClusterConfigUID,
+	}
+	objs := []*unstructured.Unstructured{
+		newPlan(map[string]string{legacyConfigUIDKey: "config-101"}),
+		newPlan(map[string]string{types.AnnKeyCStorClusterConfigUID: "config-101"}),
+		nil,
+	}
+	got := AdoptAll(objs, legacyKeys)
+	if got != 1 {
+		t.Fatalf("Expected adopted count 1 got %d", got)
+	}
+	for _, obj := range objs[:2] {
+		uid := obj.GetAnnotations()[types.AnnKeyCStorClusterConfigUID]
+		if uid != "config-101" {
+			t.Fatalf("Expected config uid %q got %q", "config-101", uid)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package autopolicy defaults the fields of a CStorClusterConfig
+// that are not set from the CStorPoolAutoPolicy that selects it.
+//
+// NOTE:
+//	Fields that are set in CStorClusterConfig are never overridden.
+// Hence a config can opt out of any default of its policy by setting
+// the corresponding field.
+package autopolicy
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// Default is a field of CStorClusterConfig that was set from its
+// CStorPoolAutoPolicy
+type Default struct {
+	// Path of the field relative to the spec of CStorClusterConfig
+	Path []string
+
+	// Value of the field
+	Value interface{}
+}
+
+// Select returns the CStorPoolAutoPolicy that is the most specific
+// amongst the policies that select the given config. Nil is returned
+// if no policy selects this config.
+func Select(
+	config *unstructured.Unstructured, resources []*unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	var selected *unstructured.Unstructured
+	var selectedSpecificity int
+	var conflicts []string
+	for _, res := range resources {
+		if res == nil || res.GetKind() != string(types.KindCStorPoolAutoPolicy) {
+			continue
+		}
+		var policy types.CStorPoolAutoPolicy
+		err := unstruct.UnstructToTyped(res, &policy)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't select CStorPoolAutoPolicy %q", res.GetName(),
+			)
+		}
+		selector := labels.SelectorFromSet(policy.Spec.ConfigSelector)
+		if !selector.Matches(labels.Set(config.GetLabels())) {
+			continue
+		}
+		specificity := len(policy.Spec.ConfigSelector)
+		if selected == nil || specificity > selectedSpecificity {
+			selected, selectedSpecificity = res, specificity
+			conflicts = []string{res.GetName()}
+			continue
+		}
+		if specificity == selectedSpecificity {
+			conflicts = append(conflicts, res.GetName())
+		}
+	}
+	if len(conflicts) > 1 {
+		sort.Strings(conflicts)
+		return nil, errs.ValidationErrorf(
+			"Can't select CStorPoolAutoPolicy: Policies %v select CStorClusterConfig %q / %q with %d label(s) each",
+			conflicts, config.GetNamespace(), config.GetName(), selectedSpecificity,
+		)
+	}
+	return selected, nil
+}
+
+// Apply returns a copy of the given config whose fields that are not
+// set are set from the given policy. Defaults that were set are
+// returned as well. The given config is returned as is if there is
+// no policy.
+func Apply(
+	config *unstructured.Unstructured, policy *unstructured.Unstructured,
+) (*unstructured.Unstructured, []Default, error) {
+	if policy == nil {
+		return config, nil, nil
+	}
+	a := &applier{
+		config: config.DeepCopy(),
+		policy: policy,
+	}
+	fns := []func() error{
+		a.applyRAIDType,
+		a.applyZFSProperties,
+		a.applyReservePerNode,
+		a.applyNaming,
+		a.applyReclaimPolicy,
+	}
+	for _, fn := range fns {
+		err := fn()
+		if err != nil {
+			return nil, nil, errors.Wrapf(
+				err, "Can't apply CStorPoolAutoPolicy %q", policy.GetName(),
+			)
+		}
+	}
+	return a.config, a.defaults, nil
+}
+
+// applier sets the defaults of a policy against a config
+type applier struct {
+	config   *unstructured.Unstructured
+	policy   *unstructured.Unstructured
+	defaults []Default
+}
+
+// isSet returns true if the given field of config's spec is set
+func (a *applier) isSet(path ...string) (bool, error) {
+	val, found, err := unstructured.NestedFieldNoCopy(
+		a.config.Object, append([]string{"spec"}, path...)...,
+	)
+	if err != nil {
+		return false, err
+	}
+	return found && val != nil && val != "", nil
+}
+
+// setIfNotSet sets the given field of config's spec to the value at
+// the given field of policy's spec unless either is not set
+func (a *applier) setIfNotSet(configPath []string, policyPath ...string) error {
+	val, found, err := unstructured.NestedFieldNoCopy(
+		a.policy.Object, append([]string{"spec"}, policyPath...)...,
+	)
+	if err != nil || !found || val == nil || val == "" {
+		return err
+	}
+	isSet, err := a.isSet(configPath...)
+	if err != nil || isSet {
+		return err
+	}
+	err = unstructured.SetNestedField(
+		a.config.Object, val, append([]string{"spec"}, configPath...)...,
+	)
+	if err != nil {
+		return err
+	}
+	a.defaults = append(a.defaults, Default{Path: configPath, Value: val})
+	return nil
+}
+
+func (a *applier) applyRAIDType() error {
+	return a.setIfNotSet([]string{"poolConfig", "raidType"}, "raidType")
+}
+
+// applyZFSProperties sets each ZFS property of the policy that is
+// not set in the config
+func (a *applier) applyZFSProperties() error {
+	properties, _, err := unstructured.NestedStringMap(
+		a.policy.Object, "spec", "zfsProperties",
+	)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		err := a.setIfNotSet(
+			[]string{"poolConfig", "zfsProperties", name}, "zfsProperties", name,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyReservePerNode sets the reserve against local disk configs
+// only
+func (a *applier) applyReservePerNode() error {
+	isLocal, err := a.isSet("diskConfig", "local")
+	if err != nil || !isLocal {
+		return err
+	}
+	return a.setIfNotSet([]string{"diskConfig", "reservePerNode"}, "reservePerNode")
+}
+
+// applyNaming sets the naming as a whole since its options are
+// meaningful only together
+func (a *applier) applyNaming() error {
+	return a.setIfNotSet([]string{"naming"}, "naming")
+}
+
+// applyReclaimPolicy sets the reclaim policy against external disk
+// configs only
+func (a *applier) applyReclaimPolicy() error {
+	isExternal, err := a.isSet("diskConfig", "external")
+	if err != nil || !isExternal {
+		return err
+	}
+	return a.setIfNotSet(
+		[]string{"diskConfig", "external", "reclaimPolicy"}, "reclaimPolicy",
+	)
+}
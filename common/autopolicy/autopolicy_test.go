@@ -0,0 +1,277 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package autopolicy
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newPolicy(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+			"kind":       string(types.KindCStorPoolAutoPolicy),
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func newConfig(labels map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	config := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+			},
+			"spec": spec,
+		},
+	}
+	config.SetLabels(labels)
+	return config
+}
+
+func TestSelect(t *testing.T) {
+	orgWide := newPolicy("org-wide", map[string]interface{}{})
+	team := newPolicy("team", map[string]interface{}{
+		"configSelector": map[string]interface{}{"team": "storage"},
+	})
+	tier := newPolicy("tier", map[string]interface{}{
+		"configSelector": map[string]interface{}{"tier": "gold"},
+	})
+	var tests = map[string]struct {
+		labels    map[string]string
+		resources []*unstructured.Unstructured
+		expect    string
+		isErr     bool
+	}{
+		"no policies": {
+			resources: []*unstructured.Unstructured{
+				{Object: map[string]interface{}{"kind": string(types.KindNode)}},
+			},
+		},
+		"policy without selector selects all configs": {
+			resources: []*unstructured.Unstructured{orgWide},
+			expect:    "org-wide",
+		},
+		"policy does not select config without labels": {
+			resources: []*unstructured.Unstructured{team},
+		},
+		"most specific policy wins": {
+			labels:    map[string]string{"team": "storage"},
+			resources: []*unstructured.Unstructured{orgWide, team},
+			expect:    "team",
+		},
+		"equally specific policies conflict": {
+			labels:    map[string]string{"team": "storage", "tier": "gold"},
+			resources: []*unstructured.Unstructured{team, orgWide, tier},
+			isErr:     true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Select(
+				newConfig(mock.labels, map[string]interface{}{}), mock.resources,
+			)
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotName string
+			if got != nil {
+				gotName = got.GetName()
+			}
+			if gotName != mock.expect {
+				t.Fatalf("Expected policy %q got %q", mock.expect, gotName)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	policy := newPolicy("org-wide", map[string]interface{}{
+		"raidType": "mirror",
+		"zfsProperties": map[string]interface{}{
+			"compression": "lz4",
+			"recordsize":  "128k",
+		},
+		"reservePerNode": "20%",
+		"naming": map[string]interface{}{
+			"prefix": "org",
+		},
+		"reclaimPolicy": "Delete",
+	})
+	var tests = map[string]struct {
+		policy         *unstructured.Unstructured
+		spec           map[string]interface{}
+		expectSpec     map[string]interface{}
+		expectDefaults []Default
+	}{
+		"no policy": {
+			spec:       map[string]interface{}{},
+			expectSpec: map[string]interface{}{},
+		},
+		"external disk config": {
+			policy: policy,
+			spec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"external": map[string]interface{}{
+						"storageClassName": "csi-gce-pd",
+					},
+				},
+			},
+			expectSpec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"external": map[string]interface{}{
+						"reclaimPolicy":    "Delete",
+						"storageClassName": "csi-gce-pd",
+					},
+				},
+				"naming": map[string]interface{}{
+					"prefix": "org",
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "mirror",
+					"zfsProperties": map[string]interface{}{
+						"compression": "lz4",
+						"recordsize":  "128k",
+					},
+				},
+			},
+			expectDefaults: []Default{
+				{Path: []string{"poolConfig", "raidType"}, Value: "mirror"},
+				{Path: []string{"poolConfig", "zfsProperties", "compression"}, Value: "lz4"},
+				{Path: []string{"poolConfig", "zfsProperties", "recordsize"}, Value: "128k"},
+				{Path: []string{"naming"}, Value: map[string]interface{}{"prefix": "org"}},
+				{Path: []string{"diskConfig", "external", "reclaimPolicy"}, Value: "Delete"},
+			},
+		},
+		"fields set in config are retained": {
+			policy: policy,
+			spec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local":          map[string]interface{}{},
+					"reservePerNode": "100Gi",
+				},
+				"naming": map[string]interface{}{
+					"suffix": "pools",
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "raidz",
+					"zfsProperties": map[string]interface{}{
+						"recordsize": "64k",
+					},
+				},
+			},
+			expectSpec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local":          map[string]interface{}{},
+					"reservePerNode": "100Gi",
+				},
+				"naming": map[string]interface{}{
+					"suffix": "pools",
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "raidz",
+					"zfsProperties": map[string]interface{}{
+						"compression": "lz4",
+						"recordsize":  "64k",
+					},
+				},
+			},
+			expectDefaults: []Default{
+				{Path: []string{"poolConfig", "zfsProperties", "compression"}, Value: "lz4"},
+			},
+		},
+		"reserve is set against local disk config only": {
+			policy: policy,
+			spec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{},
+				},
+				"naming": map[string]interface{}{
+					"prefix": "team",
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "stripe",
+					"zfsProperties": map[string]interface{}{
+						"compression": "off",
+						"recordsize":  "64k",
+					},
+				},
+			},
+			expectSpec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local":          map[string]interface{}{},
+					"reservePerNode": "20%",
+				},
+				"naming": map[string]interface{}{
+					"prefix": "team",
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "stripe",
+					"zfsProperties": map[string]interface{}{
+						"compression": "off",
+						"recordsize":  "64k",
+					},
+				},
+			},
+			expectDefaults: []Default{
+				{Path: []string{"diskConfig", "reservePerNode"}, Value: "20%"},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			config := newConfig(nil, mock.spec)
+			observed := config.DeepCopy()
+			got, gotDefaults, err := Apply(config, mock.policy)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(observed, config); diff != "" {
+				t.Fatalf("Expected given config to be unchanged got\n%s", diff)
+			}
+			gotSpec, _, _ := unstructured.NestedMap(got.Object, "spec")
+			if diff := cmp.Diff(mock.expectSpec, gotSpec); diff != "" {
+				t.Fatalf("Expected no diff in spec got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectDefaults, gotDefaults); diff != "" {
+				t.Fatalf("Expected no diff in defaults got\n%s", diff)
+			}
+		})
+	}
+}
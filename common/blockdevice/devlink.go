@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"path"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// GetDevLinks returns the links of the given block device mapped
+// by their kind e.g. by-id, by-uuid, by-path
+func GetDevLinks(obj unstructured.Unstructured) (map[string][]string, error) {
+	if obj.GetKind() != string(types.KindBlockDevice) {
+		return nil, errors.Errorf("Can not get devlinks: Expected kind %q got %q",
+			types.KindBlockDevice, obj.GetKind())
+	}
+	devlinks, _, err := unstructured.NestedSlice(obj.Object, "spec", "devlinks")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can not get devlinks: BlockDevice %q", obj.GetName())
+	}
+	kindToLinks := map[string][]string{}
+	for idx, devlink := range devlinks {
+		devlinkMap, ok := devlink.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf(
+				"Can not get devlinks: Invalid spec.devlinks[%d]: BlockDevice %q",
+				idx, obj.GetName(),
+			)
+		}
+		kind, _, err := unstructured.NestedString(devlinkMap, "kind")
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can not get spec.devlinks[%d].kind: BlockDevice %q", idx, obj.GetName(),
+			)
+		}
+		links, _, err := unstructured.NestedStringSlice(devlinkMap, "links")
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can not get spec.devlinks[%d].links: BlockDevice %q", idx, obj.GetName(),
+			)
+		}
+		if kind == "" || len(links) == 0 {
+			continue
+		}
+		kindToLinks[kind] = append(kindToLinks[kind], links...)
+	}
+	return kindToLinks, nil
+}
+
+// GetStableDevLink returns the link of the given block device that
+// remains same across node restarts. Links of by-id kind are
+// preferred over by-uuid. spec.path is returned if the device does
+// not have any stable links.
+//
+// NOTE:
+//	spec.path e.g. /dev/sdb may refer to a different device after
+// the node restarts.
+func GetStableDevLink(obj unstructured.Unstructured) (string, error) {
+	kindToLinks, err := GetDevLinks(obj)
+	if err != nil {
+		return "", err
+	}
+	for _, kind := range types.StableDevLinkKinds {
+		if links := kindToLinks[string(kind)]; len(links) != 0 {
+			return links[0], nil
+		}
+	}
+	devPath, _, err := unstructured.NestedString(obj.Object, "spec", "path")
+	if err != nil {
+		return "", errors.Wrapf(err, "Can not get spec.path: BlockDevice %q", obj.GetName())
+	}
+	return devPath, nil
+}
+
+// NewSelectionView returns a copy of the given block device whose
+// devlinks can be evaluated by selector terms
+//
+// NOTE:
+//	spec.devlinks of the copy is a map of kind to the base names of
+// its links e.g. spec.devlinks.by-id: [wwn-0x5000c500a1b2c3d4]. This
+// lets matchSlice terms select devices by their stable identifiers.
+// Base names are used since selector values must be valid label
+// values. In addition, the copy is set with spec.stableDevLink that
+// can be used by matchFields terms.
+func NewSelectionView(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	kindToLinks, err := GetDevLinks(*obj)
+	if err != nil {
+		return nil, err
+	}
+	stableDevLink, err := GetStableDevLink(*obj)
+	if err != nil {
+		return nil, err
+	}
+	devlinks := map[string]interface{}{}
+	for kind, links := range kindToLinks {
+		var linkList []interface{}
+		for _, link := range links {
+			linkList = append(linkList, path.Base(link))
+		}
+		devlinks[kind] = linkList
+	}
+	view := obj.DeepCopy()
+	err = unstructured.SetNestedField(view.Object, devlinks, "spec", "devlinks")
+	if err != nil {
+		return nil, err
+	}
+	if stableDevLink != "" {
+		err = unstructured.SetNestedField(
+			view.Object, stableDevLink, "spec", "stableDevLink",
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return view, nil
+}
+
+// SelectAll evaluates the selector terms against the selection
+// view of each of the given block devices. Matches & nomatches
+// are the given block devices & not their views.
+func SelectAll(
+	terms types.BlockDeviceSelector, devices []*unstructured.Unstructured,
+) (matches, nomatches []*unstructured.Unstructured, err error) {
+	var views []*unstructured.Unstructured
+	viewToDevice := map[*unstructured.Unstructured]*unstructured.Unstructured{}
+	for _, device := range devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			// accept only non nil instances
+			continue
+		}
+		view, err := NewSelectionView(device)
+		if err != nil {
+			return nil, nil, err
+		}
+		views = append(views, view)
+		viewToDevice[view] = device
+	}
+	// devices are evaluated in parallel to keep the sync latency
+	// bounded in clusters with large number of block devices
+	viewMatches, viewNomatches, err := unstruct.MatchAllParallel(
+		func(view *unstructured.Unstructured) (bool, error) {
+			return IsSelectorMatch(terms, view)
+		},
+		views,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, view := range viewMatches {
+		matches = append(matches, viewToDevice[view])
+	}
+	for _, view := range viewNomatches {
+		nomatches = append(nomatches, viewToDevice[view])
+	}
+	return matches, nomatches, nil
+}
@@ -0,0 +1,284 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// newTestDevice returns a BlockDevice with the given path &
+// devlinks
+func newTestDevice(name, path string, devlinks ...interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	if path != "" {
+		spec["path"] = path
+	}
+	if len(devlinks) != 0 {
+		spec["devlinks"] = devlinks
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": spec,
+		},
+	}
+}
+
+// newTestDevLink returns a devlink of the given kind & links
+func newTestDevLink(kind string, links ...interface{}) interface{} {
+	return map[string]interface{}{
+		"kind":  kind,
+		"links": links,
+	}
+}
+
+func TestGetDevLinks(t *testing.T) {
+	var tests = map[string]struct {
+		device *unstructured.Unstructured
+		expect map[string][]string
+		isErr  bool
+	}{
+		"kind mismatch": {
+			device: &unstructured.Unstructured{
+				Object: map[string]interface{}{"kind": "test"},
+			},
+			isErr: true,
+		},
+		"no devlinks": {
+			device: newTestDevice("bd-1", "/dev/sdb"),
+			expect: map[string][]string{},
+		},
+		"devlinks of multiple kinds": {
+			device: newTestDevice(
+				"bd-1", "/dev/sdb",
+				newTestDevLink("by-id", "/dev/disk/by-id/ata-1", "/dev/disk/by-id/wwn-1"),
+				newTestDevLink("by-path", "/dev/disk/by-path/pci-1"),
+				newTestDevLink("by-uuid"),
+			),
+			expect: map[string][]string{
+				"by-id":   []string{"/dev/disk/by-id/ata-1", "/dev/disk/by-id/wwn-1"},
+				"by-path": []string{"/dev/disk/by-path/pci-1"},
+			},
+		},
+		"invalid devlink": {
+			device: newTestDevice("bd-1", "/dev/sdb", "by-id"),
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := GetDevLinks(*mock.device)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetStableDevLink(t *testing.T) {
+	var tests = map[string]struct {
+		device *unstructured.Unstructured
+		expect string
+	}{
+		"no devlinks": {
+			device: newTestDevice("bd-1", "/dev/sdb"),
+			expect: "/dev/sdb",
+		},
+		"no devlinks && no path": {
+			device: newTestDevice("bd-1", ""),
+			expect: "",
+		},
+		"by-path devlink": {
+			device: newTestDevice(
+				"bd-1", "/dev/sdb",
+				newTestDevLink("by-path", "/dev/disk/by-path/pci-1"),
+			),
+			expect: "/dev/sdb",
+		},
+		"by-uuid devlink": {
+			device: newTestDevice(
+				"bd-1", "/dev/sdb",
+				newTestDevLink("by-path", "/dev/disk/by-path/pci-1"),
+				newTestDevLink("by-uuid", "/dev/disk/by-uuid/1234"),
+			),
+			expect: "/dev/disk/by-uuid/1234",
+		},
+		"by-id devlink is preferred": {
+			device: newTestDevice(
+				"bd-1", "/dev/sdb",
+				newTestDevLink("by-uuid", "/dev/disk/by-uuid/1234"),
+				newTestDevLink("by-id", "/dev/disk/by-id/ata-1", "/dev/disk/by-id/wwn-1"),
+			),
+			expect: "/dev/disk/by-id/ata-1",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := GetStableDevLink(*mock.device)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestSelectAll(t *testing.T) {
+	bd1 := newTestDevice(
+		"bd-1", "/dev/sdb",
+		newTestDevLink("by-id", "/dev/disk/by-id/ata-1", "/dev/disk/by-id/wwn-1"),
+	)
+	bd2 := newTestDevice(
+		"bd-2", "/dev/sdc",
+		newTestDevLink("by-id", "/dev/disk/by-id/ata-2"),
+	)
+	bd3 := newTestDevice("bd-3", "/dev/sdd")
+	var tests = map[string]struct {
+		terms         metac.ResourceSelector
+		expectMatches []string
+		isErr         bool
+	}{
+		"match by-id devlinks": {
+			terms: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					&metac.SelectorTerm{
+						MatchSliceExpressions: []metac.SliceSelectorRequirement{
+							metac.SliceSelectorRequirement{
+								Key:      "spec.devlinks.by-id",
+								Operator: metac.SliceSelectorOpIn,
+								Values:   []string{"wwn-1"},
+							},
+						},
+					},
+				},
+			},
+			expectMatches: []string{"bd-1"},
+		},
+		"match by-id devlinks that are not found": {
+			terms: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					&metac.SelectorTerm{
+						MatchSliceExpressions: []metac.SliceSelectorRequirement{
+							metac.SliceSelectorRequirement{
+								Key:      "spec.devlinks.by-id",
+								Operator: metac.SliceSelectorOpNotIn,
+								Values:   []string{"ata-1"},
+							},
+						},
+					},
+				},
+			},
+			expectMatches: []string{"bd-2", "bd-3"},
+		},
+		"match stable devlink": {
+			terms: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					&metac.SelectorTerm{
+						MatchFields: map[string]string{
+							"spec.stableDevLink": "/dev/disk/by-id/ata-2",
+						},
+					},
+				},
+			},
+			expectMatches: []string{"bd-2"},
+		},
+		"match stable devlink that falls back to path": {
+			terms: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					&metac.SelectorTerm{
+						MatchFields: map[string]string{
+							"spec.stableDevLink": "/dev/sdd",
+						},
+					},
+				},
+			},
+			expectMatches: []string{"bd-3"},
+		},
+		"match path": {
+			terms: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					&metac.SelectorTerm{
+						MatchFields: map[string]string{
+							"spec.path": "/dev/sdb",
+						},
+					},
+				},
+			},
+			expectMatches: []string{"bd-1"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			devices := []*unstructured.Unstructured{bd1, nil, bd2, bd3}
+			matches, nomatches, err := SelectAll(
+				types.NewBlockDeviceSelector(mock.terms), devices,
+			)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotMatches []string
+			for _, device := range matches {
+				gotMatches = append(gotMatches, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expectMatches, gotMatches); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+			if len(matches)+len(nomatches) != 3 {
+				t.Fatalf(
+					"Expected 3 devices got %d matches & %d nomatches",
+					len(matches), len(nomatches),
+				)
+			}
+			for _, device := range append(matches, nomatches...) {
+				// devices are returned as is & not their views
+				if device != bd1 && device != bd2 && device != bd3 {
+					t.Fatalf("Expected given device got %v", device)
+				}
+				_, found, _ := unstructured.NestedString(device.Object, "spec", "stableDevLink")
+				if found {
+					t.Fatalf("Expected device without stable devlink got %v", device)
+				}
+			}
+		})
+	}
+}
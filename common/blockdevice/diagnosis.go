@@ -0,0 +1,397 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+const (
+	// MaxDiagnosedSelectorTerms is the maximum number of selector
+	// terms that are reported by a selection diagnosis
+	MaxDiagnosedSelectorTerms int = 10
+
+	// MaxDiagnosedRejectReasons is the maximum number of reject
+	// reasons that are reported per selector term
+	MaxDiagnosedRejectReasons int = 5
+)
+
+// requirement is a selector term with a single match requirement
+// along with the reason to be reported if it does not match
+type requirement struct {
+	reason string
+	term   *types.BlockDeviceSelectorTerm
+}
+
+// sortedKeys returns the keys of the given map in sorted order
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitSelectorTerm splits the given term into terms of single
+// requirements. Requirements are ordered by their type & key so
+// that a rejected device is always reported with the same reason.
+func splitSelectorTerm(term *types.BlockDeviceSelectorTerm) []requirement {
+	var reqs []requirement
+	for _, key := range sortedKeys(term.MatchFields) {
+		reqs = append(reqs, requirement{
+			reason: "field " + key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchFields: map[string]string{key: term.MatchFields[key]},
+				},
+			},
+		})
+	}
+	for _, expr := range term.MatchFieldExpressions {
+		reqs = append(reqs, requirement{
+			reason: "field " + expr.Key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchFieldExpressions: []metav1.LabelSelectorRequirement{expr},
+				},
+			},
+		})
+	}
+	for _, key := range sortedKeys(term.MatchLabels) {
+		reqs = append(reqs, requirement{
+			reason: "label " + key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchLabels: map[string]string{key: term.MatchLabels[key]},
+				},
+			},
+		})
+	}
+	for _, expr := range term.MatchLabelExpressions {
+		reqs = append(reqs, requirement{
+			reason: "label " + expr.Key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchLabelExpressions: []metav1.LabelSelectorRequirement{expr},
+				},
+			},
+		})
+	}
+	for _, key := range sortedKeys(term.MatchAnnotations) {
+		reqs = append(reqs, requirement{
+			reason: "annotation " + key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchAnnotations: map[string]string{key: term.MatchAnnotations[key]},
+				},
+			},
+		})
+	}
+	for _, expr := range term.MatchAnnotationExpressions {
+		reqs = append(reqs, requirement{
+			reason: "annotation " + expr.Key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchAnnotationExpressions: []metav1.LabelSelectorRequirement{expr},
+				},
+			},
+		})
+	}
+	var sliceKeys []string
+	for key := range term.MatchSlice {
+		sliceKeys = append(sliceKeys, key)
+	}
+	sort.Strings(sliceKeys)
+	for _, key := range sliceKeys {
+		reqs = append(reqs, requirement{
+			reason: "slice " + key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchSlice: map[string][]string{key: term.MatchSlice[key]},
+				},
+			},
+		})
+	}
+	for _, expr := range term.MatchSliceExpressions {
+		reqs = append(reqs, requirement{
+			reason: "slice " + expr.Key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchSliceExpressions: []metac.SliceSelectorRequirement{expr},
+				},
+			},
+		})
+	}
+	for _, key := range term.MatchReference {
+		reqs = append(reqs, requirement{
+			reason: "reference " + key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchReference: []string{key},
+				},
+			},
+		})
+	}
+	for _, expr := range term.MatchReferenceExpressions {
+		reqs = append(reqs, requirement{
+			reason: "reference " + expr.Key,
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchReferenceExpressions: []metac.ReferenceSelectorRequirement{expr},
+				},
+			},
+		})
+	}
+	for _, expr := range term.MatchExpressions {
+		reqs = append(reqs, requirement{
+			reason: "expression " + expr.Key,
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{expr},
+			},
+		})
+	}
+	for _, rng := range term.MatchNumericRange {
+		reqs = append(reqs, requirement{
+			reason: "range " + rng.Key,
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{rng},
+			},
+		})
+	}
+	return reqs
+}
+
+// SelectionDiagnosis explains why the block devices were not
+// selected by a local disk config
+type SelectionDiagnosis struct {
+	// Selector whose terms are diagnosed
+	Selector types.BlockDeviceSelector
+
+	// Exclude drops the devices that match the selector
+	Exclude types.BlockDeviceSelector
+
+	// ReservedKeys drop the devices that match the selector &
+	// are not excluded
+	ReservedKeys []string
+
+	// Devices that were considered for selection
+	Devices []*unstructured.Unstructured
+}
+
+// Report returns a bounded report with a line per selector term
+// e.g. "term 1 rejected 12 of 12 devices: 8 by field spec.path,
+// 4 by label app"
+//
+// NOTE:
+//	A rejected device is reported against the first requirement of
+// the term that it does not match. Requirements are ordered by their
+// type i.e. field, label, annotation, slice, reference, expression &
+// range followed by their key.
+func (d SelectionDiagnosis) Report() ([]string, error) {
+	var views []*unstructured.Unstructured
+	for _, device := range d.Devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			// accept only non nil instances
+			continue
+		}
+		view, err := NewSelectionView(device)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, view)
+	}
+	if len(views) == 0 {
+		return []string{"no block devices were observed"}, nil
+	}
+	var report []string
+	for index, term := range d.Selector.SelectorTerms {
+		if term == nil {
+			continue
+		}
+		if index == MaxDiagnosedSelectorTerms {
+			report = append(report, fmt.Sprintf(
+				"%d more terms were not diagnosed",
+				len(d.Selector.SelectorTerms)-MaxDiagnosedSelectorTerms,
+			))
+			break
+		}
+		line, err := d.diagnoseTerm(index+1, term, views)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, line)
+	}
+	if len(d.Exclude.SelectorTerms) != 0 {
+		line, err := d.diagnoseExclude(views)
+		if err != nil {
+			return nil, err
+		}
+		if line != "" {
+			report = append(report, line)
+		}
+	}
+	if len(d.ReservedKeys) != 0 {
+		line, err := d.diagnoseReservation(views)
+		if err != nil {
+			return nil, err
+		}
+		if line != "" {
+			report = append(report, line)
+		}
+	}
+	return report, nil
+}
+
+// diagnoseTerm returns the report of the given selector term
+func (d SelectionDiagnosis) diagnoseTerm(
+	position int, term *types.BlockDeviceSelectorTerm, views []*unstructured.Unstructured,
+) (string, error) {
+	reqs := splitSelectorTerm(term)
+	reasonToCount := map[string]int{}
+	var rejectCount int
+	for _, view := range views {
+		for _, req := range reqs {
+			match, err := IsSelectorTermMatch(req.term, view)
+			if err != nil {
+				return "", err
+			}
+			if !match {
+				reasonToCount[req.reason]++
+				rejectCount++
+				break
+			}
+		}
+	}
+	line := fmt.Sprintf(
+		"term %d rejected %d of %d devices", position, rejectCount, len(views),
+	)
+	if rejectCount == 0 {
+		return line, nil
+	}
+	return line + ": " + formatReasons(reasonToCount), nil
+}
+
+// diagnoseExclude returns the report of the devices that match
+// the selector but were dropped by the exclude terms
+func (d SelectionDiagnosis) diagnoseExclude(
+	views []*unstructured.Unstructured,
+) (string, error) {
+	var matchCount, excludeCount int
+	for _, view := range views {
+		match, err := IsSelectorMatch(d.Selector, view)
+		if err != nil {
+			return "", err
+		}
+		if !match {
+			continue
+		}
+		matchCount++
+		exclude, err := IsSelectorMatch(d.Exclude, view)
+		if err != nil {
+			return "", err
+		}
+		if exclude {
+			excludeCount++
+		}
+	}
+	if matchCount == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf(
+		"exclude terms rejected %d of %d matching devices",
+		excludeCount, matchCount,
+	), nil
+}
+
+// diagnoseReservation returns the report of the devices that match
+// the selector & are not excluded but were dropped since these were
+// reserved
+func (d SelectionDiagnosis) diagnoseReservation(
+	views []*unstructured.Unstructured,
+) (string, error) {
+	keyToCount := map[string]int{}
+	var matchCount, reserveCount int
+	for _, view := range views {
+		match, err := IsSelectorMatch(d.Selector, view)
+		if err != nil {
+			return "", err
+		}
+		if !match {
+			continue
+		}
+		if len(d.Exclude.SelectorTerms) != 0 {
+			exclude, err := IsSelectorMatch(d.Exclude, view)
+			if err != nil {
+				return "", err
+			}
+			if exclude {
+				continue
+			}
+		}
+		matchCount++
+		key := GetReservedKey(*view, d.ReservedKeys)
+		if key != "" {
+			keyToCount["reserved key "+key]++
+			reserveCount++
+		}
+	}
+	if matchCount == 0 {
+		return "", nil
+	}
+	line := fmt.Sprintf(
+		"reservations rejected %d of %d matching devices", reserveCount, matchCount,
+	)
+	if reserveCount == 0 {
+		return line, nil
+	}
+	return line + ": " + formatReasons(keyToCount), nil
+}
+
+// formatReasons returns the reasons ordered by their count in
+// descending order followed by their name
+func formatReasons(reasonToCount map[string]int) string {
+	var reasons []string
+	for reason := range reasonToCount {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if reasonToCount[reasons[i]] != reasonToCount[reasons[j]] {
+			return reasonToCount[reasons[i]] > reasonToCount[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	var parts []string
+	for index, reason := range reasons {
+		if index == MaxDiagnosedRejectReasons {
+			parts = append(parts, fmt.Sprintf(
+				"%d more reasons", len(reasons)-MaxDiagnosedRejectReasons,
+			))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d by %s", reasonToCount[reason], reason))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestSelectionDiagnosisReport(t *testing.T) {
+	newDevice := func(name, path, app string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"app": app,
+					},
+				},
+				"spec": map[string]interface{}{
+					"path": path,
+				},
+			},
+		}
+	}
+	var devices = []*unstructured.Unstructured{
+		newDevice("bd1", "/dev/sda", "db"),
+		newDevice("bd2", "/dev/sdb", "db"),
+		newDevice("bd3", "/dev/sdb", "web"),
+		newDevice("bd4", "/dev/sdc", "web"),
+	}
+	var reservedDevice = newDevice("bd5", "/dev/sdd", "db")
+	reservedDevice.SetAnnotations(map[string]string{"ndm.io/reserved": "true"})
+	var manyTerms []*metac.SelectorTerm
+	for i := 0; i < MaxDiagnosedSelectorTerms+2; i++ {
+		manyTerms = append(manyTerms, &metac.SelectorTerm{
+			MatchFields: map[string]string{
+				"spec.path": fmt.Sprintf("/dev/sd%d", i),
+			},
+		})
+	}
+	var manyLabels = map[string]string{}
+	for i := 0; i < MaxDiagnosedRejectReasons+1; i++ {
+		manyLabels[fmt.Sprintf("label-%d", i)] = "none"
+	}
+	var tests = map[string]struct {
+		selector     metac.ResourceSelector
+		exclude      metac.ResourceSelector
+		reservedKeys []string
+		devices      []*unstructured.Unstructured
+		expect       []string
+	}{
+		"no devices": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchFields: map[string]string{
+							"spec.path": "/dev/sdz",
+						},
+					},
+				},
+			},
+			expect: []string{"no block devices were observed"},
+		},
+		"single term rejects all devices by field": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchFields: map[string]string{
+							"spec.path": "/dev/sdz",
+						},
+					},
+				},
+			},
+			devices: devices,
+			expect: []string{
+				"term 1 rejected 4 of 4 devices: 4 by field spec.path",
+			},
+		},
+		"single term rejects devices by field & label": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchFields: map[string]string{
+							"spec.path": "/dev/sdb",
+						},
+						MatchLabels: map[string]string{
+							"app": "cache",
+						},
+					},
+				},
+			},
+			devices: devices,
+			expect: []string{
+				"term 1 rejected 4 of 4 devices: 2 by field spec.path, 2 by label app",
+			},
+		},
+		"multiple terms": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchLabels: map[string]string{
+							"app": "cache",
+						},
+					},
+					{
+						MatchFieldExpressions: []metav1.LabelSelectorRequirement{
+							{
+								Key:      "spec.path",
+								Operator: metav1.LabelSelectorOpIn,
+								Values:   []string{"/dev/sdy", "/dev/sdz"},
+							},
+						},
+					},
+				},
+			},
+			devices: devices,
+			expect: []string{
+				"term 1 rejected 4 of 4 devices: 4 by label app",
+				"term 2 rejected 4 of 4 devices: 4 by field spec.path",
+			},
+		},
+		"exclude terms reject matching devices": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchLabels: map[string]string{
+							"app": "db",
+						},
+					},
+				},
+			},
+			exclude: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchLabels: map[string]string{
+							"app": "db",
+						},
+					},
+				},
+			},
+			devices: devices,
+			expect: []string{
+				"term 1 rejected 2 of 4 devices: 2 by label app",
+				"exclude terms rejected 2 of 2 matching devices",
+			},
+		},
+		"reservations reject matching devices": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchLabels: map[string]string{
+							"app": "db",
+						},
+					},
+				},
+			},
+			reservedKeys: DefaultReservedKeys,
+			devices:      append([]*unstructured.Unstructured{reservedDevice}, devices...),
+			expect: []string{
+				"term 1 rejected 2 of 5 devices: 2 by label app",
+				"reservations rejected 1 of 3 matching devices: 1 by reserved key ndm.io/reserved",
+			},
+		},
+		"terms are bounded": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: manyTerms,
+			},
+			devices: devices[:1],
+			expect: []string{
+				"term 1 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 2 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 3 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 4 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 5 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 6 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 7 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 8 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 9 rejected 1 of 1 devices: 1 by field spec.path",
+				"term 10 rejected 1 of 1 devices: 1 by field spec.path",
+				"2 more terms were not diagnosed",
+			},
+		},
+		"reasons are bounded": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchLabels: manyLabels,
+					},
+				},
+			},
+			devices: devices,
+			expect: []string{
+				"term 1 rejected 4 of 4 devices: 4 by label label-0",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := SelectionDiagnosis{
+				Selector:     types.NewBlockDeviceSelector(mock.selector),
+				Exclude:      types.NewBlockDeviceSelector(mock.exclude),
+				ReservedKeys: mock.reservedKeys,
+				Devices:      mock.devices,
+			}.Report()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectionDiagnosisReportWithExpressions(t *testing.T) {
+	newDevice := func(name, deviceType string, storage int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{
+					"capacity": map[string]interface{}{
+						"storage": storage,
+					},
+					"details": map[string]interface{}{
+						"deviceType": deviceType,
+					},
+				},
+			},
+		}
+	}
+	min := resource.MustParse("10Gi")
+	got, err := SelectionDiagnosis{
+		Selector: types.BlockDeviceSelector{
+			SelectorTerms: []*types.BlockDeviceSelectorTerm{
+				{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{
+							Key:      "spec.details.deviceType",
+							Operator: metav1.LabelSelectorOpIn,
+							Values:   []string{"disk"},
+						},
+					},
+					MatchNumericRange: []types.NumericRangeRequirement{
+						{Key: "spec.capacity.storage", Min: &min},
+					},
+				},
+			},
+		},
+		Devices: []*unstructured.Unstructured{
+			newDevice("bd1", "partition", 1<<40),
+			newDevice("bd2", "disk", 1<<30),
+			newDevice("bd3", "disk", 1<<40),
+		},
+	}.Report()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	expect := []string{
+		"term 1 rejected 2 of 3 devices: 1 by expression spec.details.deviceType, 1 by range spec.capacity.storage",
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("Expected no diff got \n%s", diff)
+	}
+}
+
+func TestFormatReasons(t *testing.T) {
+	var tests = map[string]struct {
+		reasonToCount map[string]int
+		expect        string
+	}{
+		"ordered by count": {
+			reasonToCount: map[string]int{
+				"label app":        4,
+				"field spec.path":  8,
+				"annotation owner": 4,
+			},
+			expect: "8 by field spec.path, 4 by annotation owner, 4 by label app",
+		},
+		"bounded reasons": {
+			reasonToCount: map[string]int{
+				"label a": 1,
+				"label b": 2,
+				"label c": 3,
+				"label d": 4,
+				"label e": 5,
+				"label f": 6,
+				"label g": 7,
+			},
+			expect: "7 by label g, 6 by label f, 5 by label e, 4 by label d, " +
+				"3 by label c, 2 more reasons",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := formatReasons(mock.reasonToCount)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// ExplicitMapCheck selects the block devices that are pinned to the
+// pools of specific nodes in place of the devices of these nodes that
+// were selected via selector terms
+type ExplicitMapCheck struct {
+	// Mappings of node names to their pinned block device names
+	Mappings []types.ExplicitDeviceMapping
+
+	// Devices that are observed
+	Devices []*unstructured.Unstructured
+
+	// OwnedDeviceNames are names of the devices that are claimed
+	// or used by the pools of the config
+	OwnedDeviceNames map[string]bool
+
+	// IgnoreMissing when true ignores the pinned devices that are
+	// not observed e.g. when only the devices of a failure domain
+	// are observed
+	IgnoreMissing bool
+}
+
+// isOnNode returns true if the given device is attached to the node
+// with the given name. Node name is matched against the node name as
+// well as the host name of the device.
+func isOnNode(device *unstructured.Unstructured, nodeName string) bool {
+	if name, _ := GetNodeName(*device); name == nodeName {
+		return true
+	}
+	hostName, _ := GetHostName(*device)
+	return hostName == nodeName
+}
+
+// Validate returns a validation error if any of the pinned devices
+// is not observed, is attached to a different node or is claimed by
+// some other consumer
+func (c ExplicitMapCheck) Validate() error {
+	nameToDevice := map[string]*unstructured.Unstructured{}
+	for _, device := range c.Devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			continue
+		}
+		nameToDevice[device.GetName()] = device
+	}
+	for _, mapping := range c.Mappings {
+		for _, deviceName := range mapping.BlockDeviceNames {
+			device, found := nameToDevice[deviceName]
+			if !found {
+				if c.IgnoreMissing {
+					continue
+				}
+				return errs.ValidationErrorf(
+					"Invalid explicit device map: Node %q: Block device %q not found",
+					mapping.NodeName, deviceName,
+				)
+			}
+			if !isOnNode(device, mapping.NodeName) {
+				nodeName, _ := GetNodeName(*device)
+				return errs.ValidationErrorf(
+					"Invalid explicit device map: Node %q: Block device %q is attached to node %q",
+					mapping.NodeName, deviceName, nodeName,
+				)
+			}
+			if c.OwnedDeviceNames[deviceName] {
+				continue
+			}
+			claimState, _, _ := unstructured.NestedString(
+				device.Object, "status", "claimState",
+			)
+			if claimState != "" && claimState != string(types.BlockDeviceUnclaimed) {
+				return errs.ValidationErrorf(
+					"Invalid explicit device map: Node %q: Block device %q is used elsewhere: Claim state %q",
+					mapping.NodeName, deviceName, claimState,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply returns the given selected devices that are not attached
+// to any of the mapped nodes followed by the pinned devices of the
+// mapped nodes
+//
+// NOTE:
+//	Pinned devices retain the order of the mappings
+func (c ExplicitMapCheck) Apply(
+	selected []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	if len(c.Mappings) == 0 {
+		return selected, nil
+	}
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	var result []*unstructured.Unstructured
+	for _, device := range selected {
+		if device == nil || device.UnstructuredContent() == nil {
+			continue
+		}
+		isMapped := false
+		for _, mapping := range c.Mappings {
+			if isOnNode(device, mapping.NodeName) {
+				isMapped = true
+				break
+			}
+		}
+		if !isMapped {
+			// selector terms continue to apply to other nodes
+			result = append(result, device)
+		}
+	}
+	nameToDevice := map[string]*unstructured.Unstructured{}
+	for _, device := range c.Devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			continue
+		}
+		nameToDevice[device.GetName()] = device
+	}
+	for _, mapping := range c.Mappings {
+		for _, deviceName := range mapping.BlockDeviceNames {
+			if device, found := nameToDevice[deviceName]; found {
+				result = append(result, device)
+			}
+		}
+	}
+	return result, nil
+}
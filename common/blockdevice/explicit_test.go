@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestExplicitMapCheckApply(t *testing.T) {
+	newDevice := func(name, nodeName, claimState string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname": nodeName + "-host",
+					},
+				},
+				"spec": map[string]interface{}{
+					"nodeAttributes": map[string]interface{}{
+						"nodeName": nodeName,
+					},
+				},
+				"status": map[string]interface{}{
+					"claimState": claimState,
+				},
+			},
+		}
+	}
+	var devices = []*unstructured.Unstructured{
+		newDevice("bd1", "node-1", "Unclaimed"),
+		newDevice("bd2", "node-1", "Unclaimed"),
+		newDevice("bd3", "node-2", "Unclaimed"),
+		newDevice("bd4", "node-2", "Claimed"),
+		newDevice("bd5", "node-3", "Unclaimed"),
+	}
+	var tests = map[string]struct {
+		mappings         []types.ExplicitDeviceMapping
+		selected         []string
+		ownedDeviceNames map[string]bool
+		ignoreMissing    bool
+		expect           []string
+		isErr            bool
+	}{
+		"no mappings": {
+			selected: []string{"bd1", "bd3"},
+			expect:   []string{"bd1", "bd3"},
+		},
+		"pinned devices replace selected devices of mapped node": {
+			mappings: []types.ExplicitDeviceMapping{
+				{NodeName: "node-1", BlockDeviceNames: []string{"bd2"}},
+			},
+			selected: []string{"bd1", "bd3", "bd5"},
+			expect:   []string{"bd3", "bd5", "bd2"},
+		},
+		"node is matched via host name": {
+			mappings: []types.ExplicitDeviceMapping{
+				{NodeName: "node-1-host", BlockDeviceNames: []string{"bd1", "bd2"}},
+			},
+			selected: []string{"bd1", "bd3"},
+			expect:   []string{"bd3", "bd1", "bd2"},
+		},
+		"device on wrong node": {
+			mappings: []types.ExplicitDeviceMapping{
+				{NodeName: "node-1", BlockDeviceNames: []string{"bd3"}},
+			},
+			isErr: true,
+		},
+		"device used elsewhere": {
+			mappings: []types.ExplicitDeviceMapping{
+				{NodeName: "node-2", BlockDeviceNames: []string{"bd4"}},
+			},
+			isErr: true,
+		},
+		"claimed device owned by config": {
+			mappings: []types.ExplicitDeviceMapping{
+				{NodeName: "node-2", BlockDeviceNames: []string{"bd3", "bd4"}},
+			},
+			ownedDeviceNames: map[string]bool{"bd4": true},
+			selected:         []string{"bd1"},
+			expect:           []string{"bd1", "bd3", "bd4"},
+		},
+		"missing device": {
+			mappings: []types.ExplicitDeviceMapping{
+				{NodeName: "node-1", BlockDeviceNames: []string{"bd9"}},
+			},
+			isErr: true,
+		},
+		"missing device is ignored": {
+			mappings: []types.ExplicitDeviceMapping{
+				{NodeName: "node-1", BlockDeviceNames: []string{"bd1", "bd9"}},
+			},
+			ignoreMissing: true,
+			selected:      []string{"bd2", "bd5"},
+			expect:        []string{"bd5", "bd1"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			nameToDevice := map[string]*unstructured.Unstructured{}
+			for _, device := range devices {
+				nameToDevice[device.GetName()] = device
+			}
+			var selected []*unstructured.Unstructured
+			for _, name := range mock.selected {
+				selected = append(selected, nameToDevice[name])
+			}
+			got, err := ExplicitMapCheck{
+				Mappings:         mock.mappings,
+				Devices:          devices,
+				OwnedDeviceNames: mock.ownedDeviceNames,
+				IgnoreMissing:    mock.ignoreMissing,
+			}.Apply(selected)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			var gotNames []string
+			for _, device := range got {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
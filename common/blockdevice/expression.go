@@ -0,0 +1,253 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+	"openebs.io/metac/controller/common/selector"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// ValidateSelector returns a validation error if any of the match
+// expressions or numeric ranges of the given selector is invalid
+func ValidateSelector(sel types.BlockDeviceSelector) error {
+	for index, term := range sel.SelectorTerms {
+		if term == nil {
+			continue
+		}
+		for _, expr := range term.MatchExpressions {
+			err := validateExpression(expr)
+			if err != nil {
+				return errs.ValidationErrorf(
+					"Invalid selector term %d: %s", index+1, err.Error(),
+				)
+			}
+		}
+		for _, rng := range term.MatchNumericRange {
+			err := validateNumericRange(rng)
+			if err != nil {
+				return errs.ValidationErrorf(
+					"Invalid selector term %d: %s", index+1, err.Error(),
+				)
+			}
+		}
+	}
+	return nil
+}
+
+func validateExpression(expr metav1.LabelSelectorRequirement) error {
+	if expr.Key == "" {
+		return errors.Errorf("Missing key: Match expression")
+	}
+	switch expr.Operator {
+	case metav1.LabelSelectorOpIn, metav1.LabelSelectorOpNotIn:
+		if len(expr.Values) == 0 {
+			return errors.Errorf(
+				"Missing values: Match expression %q %s", expr.Key, expr.Operator,
+			)
+		}
+	case metav1.LabelSelectorOpExists, metav1.LabelSelectorOpDoesNotExist:
+		if len(expr.Values) != 0 {
+			return errors.Errorf(
+				"Values not allowed: Match expression %q %s", expr.Key, expr.Operator,
+			)
+		}
+	default:
+		return errors.Errorf(
+			"Unsupported operator %q: Match expression %q", expr.Operator, expr.Key,
+		)
+	}
+	return nil
+}
+
+func validateNumericRange(rng types.NumericRangeRequirement) error {
+	if rng.Key == "" {
+		return errors.Errorf("Missing key: Numeric range")
+	}
+	if rng.Min == nil && rng.Max == nil {
+		return errors.Errorf("Missing min & max: Numeric range %q", rng.Key)
+	}
+	if rng.Min != nil && rng.Max != nil && rng.Min.Cmp(*rng.Max) > 0 {
+		return errors.Errorf(
+			"Min %s is more than max %s: Numeric range %q",
+			rng.Min.String(), rng.Max.String(), rng.Key,
+		)
+	}
+	return nil
+}
+
+// getField returns the value at the given dot separated path of
+// the given object
+func getField(obj *unstructured.Unstructured, key string) (interface{}, bool) {
+	val, found, err := unstructured.NestedFieldNoCopy(obj.Object, strings.Split(key, ".")...)
+	if err != nil || !found || val == nil {
+		return nil, false
+	}
+	return val, true
+}
+
+// formatScalar returns the given scalar as a string. False is
+// returned if the given value is not a scalar.
+func formatScalar(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// isExpressionMatch returns true if the given target matches the
+// given match expression
+func isExpressionMatch(
+	expr metav1.LabelSelectorRequirement, target *unstructured.Unstructured,
+) (bool, error) {
+	val, found := getField(target, expr.Key)
+	switch expr.Operator {
+	case metav1.LabelSelectorOpExists:
+		return found, nil
+	case metav1.LabelSelectorOpDoesNotExist:
+		return !found, nil
+	case metav1.LabelSelectorOpIn, metav1.LabelSelectorOpNotIn:
+		str, isScalar := formatScalar(val)
+		var isIn bool
+		if found && isScalar {
+			for _, want := range expr.Values {
+				if str == want {
+					isIn = true
+					break
+				}
+			}
+		}
+		if expr.Operator == metav1.LabelSelectorOpIn {
+			return isIn, nil
+		}
+		return !isIn, nil
+	}
+	return false, errors.Errorf(
+		"Unsupported operator %q: Match expression %q", expr.Operator, expr.Key,
+	)
+}
+
+// toQuantity returns the given numeric value as a quantity. False
+// is returned if the given value is not numeric.
+func toQuantity(val interface{}) (resource.Quantity, bool) {
+	switch v := val.(type) {
+	case int64:
+		return *resource.NewQuantity(v, resource.DecimalSI), true
+	case int:
+		return *resource.NewQuantity(int64(v), resource.DecimalSI), true
+	case float64:
+		q, err := resource.ParseQuantity(strconv.FormatFloat(v, 'f', -1, 64))
+		return q, err == nil
+	case string:
+		q, err := resource.ParseQuantity(v)
+		return q, err == nil
+	}
+	return resource.Quantity{}, false
+}
+
+// isNumericRangeMatch returns true if the given target has a numeric
+// value within the bounds of the given range
+func isNumericRangeMatch(
+	rng types.NumericRangeRequirement, target *unstructured.Unstructured,
+) bool {
+	val, found := getField(target, rng.Key)
+	if !found {
+		return false
+	}
+	q, isNumeric := toQuantity(val)
+	if !isNumeric {
+		return false
+	}
+	if rng.Min != nil && q.Cmp(*rng.Min) < 0 {
+		return false
+	}
+	if rng.Max != nil && q.Cmp(*rng.Max) > 0 {
+		return false
+	}
+	return true
+}
+
+// IsSelectorTermMatch returns true if the given target matches all
+// the requirements of the given term
+func IsSelectorTermMatch(
+	term *types.BlockDeviceSelectorTerm, target *unstructured.Unstructured,
+) (bool, error) {
+	if term == nil {
+		return false, nil
+	}
+	if target == nil {
+		return false, errors.Errorf("Evaluation failed: Nil target")
+	}
+	match, err := selector.Evaluation{
+		Target: target,
+		Terms:  []*metac.SelectorTerm{&term.SelectorTerm},
+	}.RunMatch()
+	if err != nil || !match {
+		return false, err
+	}
+	for _, expr := range term.MatchExpressions {
+		match, err := isExpressionMatch(expr, target)
+		if err != nil || !match {
+			return false, err
+		}
+	}
+	for _, rng := range term.MatchNumericRange {
+		if !isNumericRangeMatch(rng, target) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// IsSelectorMatch returns true if the given target matches any of
+// the terms of the given selector. A selector without terms matches
+// all the targets.
+func IsSelectorMatch(
+	sel types.BlockDeviceSelector, target *unstructured.Unstructured,
+) (bool, error) {
+	if len(sel.SelectorTerms) == 0 {
+		return true, nil
+	}
+	for _, term := range sel.SelectorTerms {
+		match, err := IsSelectorTermMatch(term, target)
+		if err != nil {
+			return false, err
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,550 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newExpressionTestDevice() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": "bd-1",
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname": "node-1",
+				},
+			},
+			"spec": map[string]interface{}{
+				"capacity": map[string]interface{}{
+					"storage":           int64(107374182400),
+					"logicalSectorSize": int64(512),
+				},
+				"details": map[string]interface{}{
+					"deviceType": "disk",
+					"model":      "PersistentDisk",
+				},
+				"partitioned": "No",
+				"path":        "/dev/sdb",
+				"ratio":       float64(1.5),
+				"isSSD":       true,
+				"quantity":    "1Gi",
+			},
+		},
+	}
+}
+
+func quantity(val string) *resource.Quantity {
+	q := resource.MustParse(val)
+	return &q
+}
+
+func TestIsSelectorTermMatch(t *testing.T) {
+	var tests = map[string]struct {
+		term    *types.BlockDeviceSelectorTerm
+		isMatch bool
+		isErr   bool
+	}{
+		"nil term": {
+			isMatch: false,
+		},
+		"empty term": {
+			term:    &types.BlockDeviceSelectorTerm{},
+			isMatch: true,
+		},
+		"In matches string": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.details.deviceType",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"partition", "disk"},
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"In matches int": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.capacity.logicalSectorSize",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"512", "4096"},
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"In matches bool": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.isSSD",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"true"},
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"In does not match other values": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.details.deviceType",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"partition"},
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"In does not match missing field": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.details.vendor",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"Google"},
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"In does not match non scalar": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.details",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"disk"},
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"NotIn matches other values": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.path",
+						Operator: metav1.LabelSelectorOpNotIn,
+						Values:   []string{"/dev/sda"},
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"NotIn matches missing field": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.details.vendor",
+						Operator: metav1.LabelSelectorOpNotIn,
+						Values:   []string{"Google"},
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"NotIn does not match given values": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.path",
+						Operator: metav1.LabelSelectorOpNotIn,
+						Values:   []string{"/dev/sda", "/dev/sdb"},
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"Exists & DoesNotExist": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.details.model",
+						Operator: metav1.LabelSelectorOpExists,
+					},
+					{
+						Key:      "spec.filesystem.fsType",
+						Operator: metav1.LabelSelectorOpDoesNotExist,
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"Exists does not match missing field": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.filesystem.fsType",
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"unsupported operator": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.path",
+						Operator: "Gt",
+						Values:   []string{"1"},
+					},
+				},
+			},
+			isErr: true,
+		},
+		"numeric range matches capacity": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.storage",
+						Min: quantity("100Gi"),
+						Max: quantity("1Ti"),
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"numeric range bounds are inclusive": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.logicalSectorSize",
+						Min: quantity("512"),
+						Max: quantity("512"),
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"numeric range below min": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.logicalSectorSize",
+						Min: quantity("4096"),
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"numeric range above max": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.storage",
+						Max: quantity("50Gi"),
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"numeric range matches float": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.ratio",
+						Min: quantity("1"),
+						Max: quantity("2"),
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"numeric range matches quantity string": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.quantity",
+						Min: quantity("1024Mi"),
+					},
+				},
+			},
+			isMatch: true,
+		},
+		"numeric range does not match non numeric": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.path",
+						Min: quantity("1"),
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"numeric range does not match missing field": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.physicalSectorSize",
+						Min: quantity("1"),
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"all requirements are AND-ed": {
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchLabels: map[string]string{
+						"kubernetes.io/hostname": "node-1",
+					},
+				},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.partitioned",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"No"},
+					},
+				},
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.storage",
+						Min: quantity("200Gi"),
+					},
+				},
+			},
+			isMatch: false,
+		},
+		"metac requirements are evaluated": {
+			term: &types.BlockDeviceSelectorTerm{
+				SelectorTerm: metac.SelectorTerm{
+					MatchLabels: map[string]string{
+						"kubernetes.io/hostname": "node-2",
+					},
+				},
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.storage",
+						Min: quantity("1Gi"),
+					},
+				},
+			},
+			isMatch: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := IsSelectorTermMatch(mock.term, newExpressionTestDevice())
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.isMatch {
+				t.Fatalf("Expected match %t got %t", mock.isMatch, got)
+			}
+		})
+	}
+}
+
+func TestIsSelectorMatch(t *testing.T) {
+	ssd := &types.BlockDeviceSelectorTerm{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      "spec.isSSD",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{"true"},
+			},
+		},
+	}
+	small := &types.BlockDeviceSelectorTerm{
+		MatchNumericRange: []types.NumericRangeRequirement{
+			{
+				Key: "spec.capacity.storage",
+				Max: quantity("10Gi"),
+			},
+		},
+	}
+	var tests = map[string]struct {
+		selector types.BlockDeviceSelector
+		isMatch  bool
+	}{
+		"no terms": {
+			isMatch: true,
+		},
+		"terms are OR-ed": {
+			selector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{small, ssd},
+			},
+			isMatch: true,
+		},
+		"no term matches": {
+			selector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{small, nil},
+			},
+			isMatch: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := IsSelectorMatch(mock.selector, newExpressionTestDevice())
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.isMatch {
+				t.Fatalf("Expected match %t got %t", mock.isMatch, got)
+			}
+		})
+	}
+}
+
+func TestValidateSelector(t *testing.T) {
+	var tests = map[string]struct {
+		term  *types.BlockDeviceSelectorTerm
+		isErr bool
+	}{
+		"nil term": {},
+		"valid term": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.path",
+						Operator: metav1.LabelSelectorOpNotIn,
+						Values:   []string{"/dev/sda"},
+					},
+					{
+						Key:      "spec.filesystem.fsType",
+						Operator: metav1.LabelSelectorOpDoesNotExist,
+					},
+				},
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.storage",
+						Min: quantity("10Gi"),
+						Max: quantity("10Gi"),
+					},
+				},
+			},
+		},
+		"expression without key": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Operator: metav1.LabelSelectorOpExists,
+					},
+				},
+			},
+			isErr: true,
+		},
+		"In without values": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.path",
+						Operator: metav1.LabelSelectorOpIn,
+					},
+				},
+			},
+			isErr: true,
+		},
+		"Exists with values": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.path",
+						Operator: metav1.LabelSelectorOpExists,
+						Values:   []string{"/dev/sda"},
+					},
+				},
+			},
+			isErr: true,
+		},
+		"unsupported operator": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.path",
+						Operator: "Equals",
+						Values:   []string{"/dev/sda"},
+					},
+				},
+			},
+			isErr: true,
+		},
+		"range without key": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Min: quantity("1"),
+					},
+				},
+			},
+			isErr: true,
+		},
+		"range without bounds": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.storage",
+					},
+				},
+			},
+			isErr: true,
+		},
+		"range with min more than max": {
+			term: &types.BlockDeviceSelectorTerm{
+				MatchNumericRange: []types.NumericRangeRequirement{
+					{
+						Key: "spec.capacity.storage",
+						Min: quantity("1Ti"),
+						Max: quantity("1Gi"),
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := ValidateSelector(types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{mock.term},
+			})
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr && errs.TypeOf(err) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// GetSMARTCheckFailure returns the reason due to which the given
+// block device failed SMART checks. Empty reason is returned if the
+// block device is active & has passed SMART checks or burn-in.
+func GetSMARTCheckFailure(obj unstructured.Unstructured) (string, error) {
+	isActive, err := IsActive(obj)
+	if err != nil {
+		return "", err
+	}
+	if !isActive {
+		return "Device is not active", nil
+	}
+	status := obj.GetAnnotations()[types.AnnKeyBlockDeviceSMARTStatus]
+	switch status {
+	case types.BlockDeviceSMARTStatusPassed:
+		return "", nil
+	case "":
+		return fmt.Sprintf("Missing %q annotation", types.AnnKeyBlockDeviceSMARTStatus), nil
+	default:
+		return fmt.Sprintf("SMART status is %q", status), nil
+	}
+}
+
+// SMARTCheck splits block devices into the ones that passed SMART
+// checks & the ones that were rejected
+type SMARTCheck struct {
+	// Devices that should be checked
+	Devices []*unstructured.Unstructured
+
+	// InUseDeviceNames are names of the devices that are used by
+	// an existing CStorPoolCluster
+	InUseDeviceNames map[string]bool
+}
+
+// Apply returns the block devices that passed SMART checks along
+// with the ones that were rejected
+//
+// NOTE:
+//	Devices that are in use are never rejected. Pools can't give
+// up their devices & hence these are retained as is.
+func (c SMARTCheck) Apply() (
+	[]*unstructured.Unstructured,
+	[]types.CStorClusterConfigRejectedBlockDevice,
+	error,
+) {
+	var passed []*unstructured.Unstructured
+	var rejected []types.CStorClusterConfigRejectedBlockDevice
+	for _, device := range c.Devices {
+		if c.InUseDeviceNames[device.GetName()] {
+			passed = append(passed, device)
+			continue
+		}
+		reason, err := GetSMARTCheckFailure(*device)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reason == "" {
+			passed = append(passed, device)
+			continue
+		}
+		hostName, _ := GetHostName(*device)
+		stableDevLink, err := GetStableDevLink(*device)
+		if err != nil {
+			return nil, nil, err
+		}
+		rejected = append(rejected, types.CStorClusterConfigRejectedBlockDevice{
+			Name:          device.GetName(),
+			HostName:      hostName,
+			Reason:        reason,
+			StableDevLink: stableDevLink,
+		})
+	}
+	return passed, rejected, nil
+}
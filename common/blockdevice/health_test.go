@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestGetSMARTCheckFailure(t *testing.T) {
+	newDevice := func(kind, state string, annotations map[string]interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": kind,
+				"metadata": map[string]interface{}{
+					"name":        "bd1",
+					"annotations": annotations,
+				},
+				"status": map[string]interface{}{
+					"state": state,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		device    unstructured.Unstructured
+		isHealthy bool
+		isErr     bool
+	}{
+		"invalid kind": {
+			device: newDevice("Junk", "Active", nil),
+			isErr:  true,
+		},
+		"inactive device": {
+			device: newDevice(string(types.KindBlockDevice), "Inactive", map[string]interface{}{
+				types.AnnKeyBlockDeviceSMARTStatus: types.BlockDeviceSMARTStatusPassed,
+			}),
+		},
+		"active device without smart status": {
+			device: newDevice(string(types.KindBlockDevice), "Active", nil),
+		},
+		"active device with failed smart status": {
+			device: newDevice(string(types.KindBlockDevice), "Active", map[string]interface{}{
+				types.AnnKeyBlockDeviceSMARTStatus: types.BlockDeviceSMARTStatusFailed,
+			}),
+		},
+		"active device with passed smart status": {
+			device: newDevice(string(types.KindBlockDevice), "Active", map[string]interface{}{
+				types.AnnKeyBlockDeviceSMARTStatus: types.BlockDeviceSMARTStatusPassed,
+			}),
+			isHealthy: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			reason, err := GetSMARTCheckFailure(mock.device)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if mock.isHealthy && reason != "" {
+				t.Fatalf("Expected healthy device got reason %q", reason)
+			}
+			if !mock.isHealthy && reason == "" {
+				t.Fatalf("Expected unhealthy device got no reason")
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package blockdevice
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// getStorageBytes returns the capacity of the given block device
+// in bytes. Device without capacity is considered to be of zero
+// bytes.
+func getStorageBytes(device *unstructured.Unstructured) int64 {
+	bytes, _, _ :=
+		unstructured.NestedInt64(device.Object, "spec", "capacity", "storage")
+	return bytes
+}
+
+// getPath returns the spec.path of the given block device
+func getPath(device *unstructured.Unstructured) string {
+	path, _, _ := unstructured.NestedString(device.Object, "spec", "path")
+	return path
+}
+
+// newPreferenceCompare returns the function that compares block
+// devices as per the given preference. A negative result implies
+// the first device is preferred over the second.
+func newPreferenceCompare(
+	preference types.DevicePreference,
+) (func(a, b *unstructured.Unstructured) int, error) {
+	if preference == "" {
+		preference = types.DevicePreferenceDefault
+	}
+	switch preference {
+	case types.DevicePreferenceLargestFirst:
+		return func(a, b *unstructured.Unstructured) int {
+			return compareInt64(getStorageBytes(b), getStorageBytes(a))
+		}, nil
+	case types.DevicePreferenceSmallestFirst:
+		return func(a, b *unstructured.Unstructured) int {
+			return compareInt64(getStorageBytes(a), getStorageBytes(b))
+		}, nil
+	case types.DevicePreferenceByPath:
+		return func(a, b *unstructured.Unstructured) int {
+			return compareString(getPath(a), getPath(b))
+		}, nil
+	case types.DevicePreferenceByAge:
+		return func(a, b *unstructured.Unstructured) int {
+			ta, tb := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+			return compareInt64(ta.UnixNano(), tb.UnixNano())
+		}, nil
+	default:
+		return nil, errs.ValidationErrorf("Invalid device preference %q", preference)
+	}
+}
+
+func compareInt64(a, b int64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func compareString(a, b string) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// sortByPreference returns a copy of the given block devices sorted
+// as per the given preference. Least preferred devices come first
+// if reverse is true.
+//
+// NOTE:
+//	Names break the ties in ascending order irrespective of reverse.
+// This sorts the devices the same way irrespective of the order
+// these were observed.
+func sortByPreference(
+	devices []*unstructured.Unstructured,
+	preference types.DevicePreference,
+	reverse bool,
+) ([]*unstructured.Unstructured, error) {
+	compare, err := newPreferenceCompare(preference)
+	if err != nil {
+		return nil, err
+	}
+	var sorted []*unstructured.Unstructured
+	for _, device := range devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			// accept only non nil instances
+			continue
+		}
+		sorted = append(sorted, device)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		cmp := compare(sorted[i], sorted[j])
+		if reverse {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp < 0
+		}
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+	return sorted, nil
+}
+
+// SortByPreference returns a copy of the given block devices sorted
+// from the most preferred to the least preferred device as per the
+// given preference. Empty preference implies the default preference.
+func SortByPreference(
+	devices []*unstructured.Unstructured, preference types.DevicePreference,
+) ([]*unstructured.Unstructured, error) {
+	return sortByPreference(devices, preference, false)
+}
+
+// SortByLeastPreferred returns a copy of the given block devices
+// sorted from the least preferred to the most preferred device as
+// per the given preference. Empty preference implies the default
+// preference.
+func SortByLeastPreferred(
+	devices []*unstructured.Unstructured, preference types.DevicePreference,
+) ([]*unstructured.Unstructured, error) {
+	return sortByPreference(devices, preference, true)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package blockdevice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newPreferenceTestDevice(
+	name, path string, bytes int64, age time.Duration,
+) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"path": path,
+				"capacity": map[string]interface{}{
+					"storage": bytes,
+				},
+			},
+		},
+	}
+	now := time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+	obj.SetCreationTimestamp(metav1.NewTime(now.Add(-age)))
+	return obj
+}
+
+func TestSortByPreference(t *testing.T) {
+	devices := []*unstructured.Unstructured{
+		newPreferenceTestDevice("bd-1", "/dev/sdc", 200, time.Hour),
+		nil,
+		newPreferenceTestDevice("bd-2", "/dev/sdb", 100, 3*time.Hour),
+		newPreferenceTestDevice("bd-3", "/dev/sdd", 300, 2*time.Hour),
+		newPreferenceTestDevice("bd-0", "/dev/sde", 100, time.Hour),
+	}
+	var tests = map[string]struct {
+		preference       types.DevicePreference
+		expect           []string
+		expectLeastFirst []string
+		isErr            bool
+	}{
+		"default preference": {
+			expect:           []string{"bd-0", "bd-2", "bd-1", "bd-3"},
+			expectLeastFirst: []string{"bd-3", "bd-1", "bd-0", "bd-2"},
+		},
+		"smallest first": {
+			preference:       types.DevicePreferenceSmallestFirst,
+			expect:           []string{"bd-0", "bd-2", "bd-1", "bd-3"},
+			expectLeastFirst: []string{"bd-3", "bd-1", "bd-0", "bd-2"},
+		},
+		"largest first": {
+			preference:       types.DevicePreferenceLargestFirst,
+			expect:           []string{"bd-3", "bd-1", "bd-0", "bd-2"},
+			expectLeastFirst: []string{"bd-0", "bd-2", "bd-1", "bd-3"},
+		},
+		"by path": {
+			preference:       types.DevicePreferenceByPath,
+			expect:           []string{"bd-2", "bd-1", "bd-3", "bd-0"},
+			expectLeastFirst: []string{"bd-0", "bd-3", "bd-1", "bd-2"},
+		},
+		"by age": {
+			preference:       types.DevicePreferenceByAge,
+			expect:           []string{"bd-2", "bd-3", "bd-0", "bd-1"},
+			expectLeastFirst: []string{"bd-0", "bd-1", "bd-3", "bd-2"},
+		},
+		"invalid preference": {
+			preference: "Random",
+			isErr:      true,
+		},
+	}
+	for name, mock := range tests {
+		name, mock := name, mock
+		t.Run(name, func(t *testing.T) {
+			got, err := SortByPreference(devices, mock.preference)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			leastFirst, err := SortByLeastPreferred(devices, mock.preference)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			toNames := func(list []*unstructured.Unstructured) []string {
+				var names []string
+				for _, device := range list {
+					names = append(names, device.GetName())
+				}
+				return names
+			}
+			if diff := cmp.Diff(mock.expect, toNames(got)); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectLeastFirst, toNames(leastFirst)); diff != "" {
+				t.Fatalf("Expected no diff in least preferred first got\n%s", diff)
+			}
+			// given devices are not reordered
+			if devices[0].GetName() != "bd-1" {
+				t.Fatalf("Expected given devices to retain their order")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultReservedKeys are the well known label & annotation keys
+// that reserve a block device for other consumers e.g. NDM
+var DefaultReservedKeys = []string{
+	"ndm.io/reserved",
+	"openebs.io/block-device-tag",
+}
+
+// ReservedKeys are the label & annotation keys that reserve a block
+// device. A block device that has any of these keys is never used to
+// build cstor pools. This is set via --reserved-device-keys flag.
+var ReservedKeys = DefaultReservedKeys
+
+// SetReservedKeys sets the reserved keys from the given comma
+// separated keys. Reservations are not honoured if no keys are
+// given.
+func SetReservedKeys(value string) {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	ReservedKeys = keys
+}
+
+// GetReservedKey returns the first of the given keys that is set as
+// a label or as an annotation of the given block device. Empty key
+// is returned if the device is not reserved.
+func GetReservedKey(obj unstructured.Unstructured, keys []string) string {
+	labels := obj.GetLabels()
+	annotations := obj.GetAnnotations()
+	for _, key := range keys {
+		if _, found := labels[key]; found {
+			return key
+		}
+		if _, found := annotations[key]; found {
+			return key
+		}
+	}
+	return ""
+}
+
+// ReservationCheck drops the block devices that are reserved for
+// other consumers
+type ReservationCheck struct {
+	// Keys that reserve a device
+	Keys []string
+
+	// Devices that should be checked
+	Devices []*unstructured.Unstructured
+
+	// InUseDeviceNames are names of the devices that are used by
+	// an existing CStorPoolCluster
+	InUseDeviceNames map[string]bool
+}
+
+// Apply returns the block devices that are not reserved. Selected
+// devices retain the order of the checked devices.
+//
+// NOTE:
+//	Devices that are in use are always selected. Pools can't give
+// up their devices even if these devices were reserved later.
+func (c ReservationCheck) Apply() []*unstructured.Unstructured {
+	if len(c.Keys) == 0 {
+		return c.Devices
+	}
+	var selected []*unstructured.Unstructured
+	for _, device := range c.Devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			// accept only non nil instances
+			continue
+		}
+		if c.InUseDeviceNames[device.GetName()] ||
+			GetReservedKey(*device, c.Keys) == "" {
+			selected = append(selected, device)
+		}
+	}
+	return selected
+}
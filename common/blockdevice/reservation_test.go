@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestSetReservedKeys(t *testing.T) {
+	defer func() { ReservedKeys = DefaultReservedKeys }()
+	var tests = map[string]struct {
+		value  string
+		expect []string
+	}{
+		"empty": {
+			value: "",
+		},
+		"single key": {
+			value:  "ndm.io/reserved",
+			expect: []string{"ndm.io/reserved"},
+		},
+		"keys with spaces": {
+			value:  " ndm.io/reserved , ,example.io/reserved",
+			expect: []string{"ndm.io/reserved", "example.io/reserved"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			SetReservedKeys(mock.value)
+			if diff := cmp.Diff(mock.expect, ReservedKeys); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReservationCheckApply(t *testing.T) {
+	newDevice := func(name string, labels, annotations map[string]string) *unstructured.Unstructured {
+		device := &unstructured.Unstructured{}
+		device.SetKind(string(types.KindBlockDevice))
+		device.SetName(name)
+		device.SetLabels(labels)
+		device.SetAnnotations(annotations)
+		return device
+	}
+	var devices = []*unstructured.Unstructured{
+		newDevice("bd1", nil, nil),
+		newDevice("bd2", map[string]string{"ndm.io/reserved": "true"}, nil),
+		newDevice("bd3", nil, map[string]string{"ndm.io/reserved": ""}),
+		newDevice("bd4", map[string]string{"openebs.io/block-device-tag": "mysql"}, nil),
+	}
+	var tests = map[string]struct {
+		keys         []string
+		inUseDevices map[string]bool
+		expect       []string
+	}{
+		"no keys": {
+			expect: []string{"bd1", "bd2", "bd3", "bd4"},
+		},
+		"default keys": {
+			keys:   DefaultReservedKeys,
+			expect: []string{"bd1"},
+		},
+		"custom key": {
+			keys:   []string{"openebs.io/block-device-tag"},
+			expect: []string{"bd1", "bd2", "bd3"},
+		},
+		"default keys && in use devices": {
+			keys: DefaultReservedKeys,
+			inUseDevices: map[string]bool{
+				"bd3": true,
+			},
+			expect: []string{"bd1", "bd3"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := ReservationCheck{
+				Keys:             mock.keys,
+				Devices:          devices,
+				InUseDeviceNames: mock.inUseDevices,
+			}.Apply()
+			var gotNames []string
+			for _, device := range got {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// SelectAllCheck selects the block devices of a local disk config
+// that is set to select all the block devices
+type SelectAllCheck struct {
+	// Selector is evaluated against the devices that are not owned
+	Selector types.BlockDeviceSelector
+
+	// Devices that should be checked
+	Devices []*unstructured.Unstructured
+
+	// AllowedHostNames are the host names of the nodes that are
+	// allowed to form pools. All hosts are allowed if this is nil.
+	AllowedHostNames map[string]bool
+
+	// OwnedDeviceNames are names of the devices that are claimed
+	// or used by the pools of the config
+	OwnedDeviceNames map[string]bool
+}
+
+// Apply returns the block devices that are selected. Selected
+// devices retain the order of the checked devices.
+//
+// NOTE:
+//	Owned devices are always selected. These devices are no longer
+// unclaimed & hence would not match the selector otherwise.
+func (c SelectAllCheck) Apply() ([]*unstructured.Unstructured, error) {
+	var candidates []*unstructured.Unstructured
+	for _, device := range c.Devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			// accept only non nil instances
+			continue
+		}
+		if c.OwnedDeviceNames[device.GetName()] {
+			continue
+		}
+		if c.AllowedHostNames != nil {
+			// devices without host name are not allowed
+			hostName, _ := GetHostName(*device)
+			if !c.AllowedHostNames[hostName] {
+				continue
+			}
+		}
+		candidates = append(candidates, device)
+	}
+	matches, _, err := SelectAll(c.Selector, candidates)
+	if err != nil {
+		return nil, err
+	}
+	isMatch := map[*unstructured.Unstructured]bool{}
+	for _, device := range matches {
+		isMatch[device] = true
+	}
+	var selected []*unstructured.Unstructured
+	for _, device := range c.Devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			continue
+		}
+		if c.OwnedDeviceNames[device.GetName()] || isMatch[device] {
+			selected = append(selected, device)
+		}
+	}
+	return selected, nil
+}
+
+// SelectorCheck selects the block devices that match the selector
+// terms but neither match the exclude terms nor are reserved for
+// other consumers
+//
+// NOTE:
+//	This is the filter pipeline shared by the local disk configs &
+// the dry runs of block device selectors.
+type SelectorCheck struct {
+	// Selector terms are not evaluated if there are none i.e. all
+	// the devices are candidates
+	Selector types.BlockDeviceSelector
+
+	// Exclude terms drop the devices that match the selector
+	Exclude types.BlockDeviceSelector
+
+	// ReservedKeys are the label or annotation keys that reserve
+	// a device for other consumers
+	ReservedKeys []string
+
+	// Devices that should be checked
+	Devices []*unstructured.Unstructured
+
+	// InUseDeviceNames are names of the devices that are used by
+	// an existing CStorPoolCluster. These are never dropped as
+	// reserved.
+	InUseDeviceNames map[string]bool
+}
+
+// Apply returns the block devices that are selected. Selected
+// devices retain the order of the checked devices.
+func (c SelectorCheck) Apply() ([]*unstructured.Unstructured, error) {
+	var selected = c.Devices
+	var err error
+	if len(c.Selector.SelectorTerms) != 0 {
+		// terms can refer to stable devlinks e.g. spec.devlinks.by-id
+		// since spec.path may refer to a different device after reboot
+		selected, _, err = SelectAll(c.Selector, selected)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(c.Exclude.SelectorTerms) != 0 {
+		// exclude terms are evaluated after the selector terms
+		// i.e. only the devices that did not match are retained
+		_, selected, err = SelectAll(c.Exclude, selected)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ReservationCheck{
+		Keys:             c.ReservedKeys,
+		Devices:          selected,
+		InUseDeviceNames: c.InUseDeviceNames,
+	}.Apply(), nil
+}
+
+// CapacityCheck selects the block devices whose capacity is within
+// the configured bounds
+type CapacityCheck struct {
+	// Min capacity of a device. No lower bound is applied if nil.
+	Min *resource.Quantity
+
+	// Max capacity of a device. No upper bound is applied if nil.
+	Max *resource.Quantity
+
+	// Devices that should be checked
+	Devices []*unstructured.Unstructured
+
+	// InUseDeviceNames are names of the devices that are used by
+	// an existing CStorPoolCluster
+	InUseDeviceNames map[string]bool
+}
+
+// Apply returns the block devices that are selected. Selected
+// devices retain the order of the checked devices.
+//
+// NOTE:
+//	Devices that are in use are always selected. Pools can't give
+// up their devices even if the bounds were changed later.
+func (c CapacityCheck) Apply() ([]*unstructured.Unstructured, error) {
+	if c.Min == nil && c.Max == nil {
+		return c.Devices, nil
+	}
+	var selected []*unstructured.Unstructured
+	for _, device := range c.Devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			// accept only non nil instances
+			continue
+		}
+		if c.InUseDeviceNames[device.GetName()] {
+			selected = append(selected, device)
+			continue
+		}
+		capacity, err := GetCapacity(*device)
+		if err != nil {
+			return nil, err
+		}
+		if c.Min != nil && capacity.Cmp(*c.Min) < 0 {
+			continue
+		}
+		if c.Max != nil && capacity.Cmp(*c.Max) > 0 {
+			continue
+		}
+		selected = append(selected, device)
+	}
+	return selected, nil
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestSelectAllCheckApply(t *testing.T) {
+	newDevice := func(name, hostName, state, claimState string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname": hostName,
+					},
+				},
+				"status": map[string]interface{}{
+					"state":      state,
+					"claimState": claimState,
+				},
+			},
+		}
+	}
+	var selector = metac.ResourceSelector{
+		SelectorTerms: []*metac.SelectorTerm{
+			{
+				MatchFields: map[string]string{
+					"status.state":      "Active",
+					"status.claimState": "Unclaimed",
+				},
+			},
+		},
+	}
+	var devices = []*unstructured.Unstructured{
+		newDevice("bd1", "node-1", "Active", "Unclaimed"),
+		newDevice("bd2", "node-1", "Active", "Claimed"),
+		newDevice("bd3", "node-2", "Inactive", "Unclaimed"),
+		newDevice("bd4", "node-2", "Active", "Unclaimed"),
+		newDevice("bd5", "node-3", "Active", "Claimed"),
+	}
+	var tests = map[string]struct {
+		allowedHostNames map[string]bool
+		ownedDeviceNames map[string]bool
+		expect           []string
+	}{
+		"all hosts && no owned devices": {
+			expect: []string{"bd1", "bd4"},
+		},
+		"all hosts && owned devices": {
+			ownedDeviceNames: map[string]bool{
+				"bd2": true,
+				"bd5": true,
+			},
+			expect: []string{"bd1", "bd2", "bd4", "bd5"},
+		},
+		"allowed hosts && no owned devices": {
+			allowedHostNames: map[string]bool{
+				"node-2": true,
+			},
+			expect: []string{"bd4"},
+		},
+		"allowed hosts && owned device of disallowed host": {
+			allowedHostNames: map[string]bool{
+				"node-2": true,
+			},
+			ownedDeviceNames: map[string]bool{
+				"bd2": true,
+			},
+			expect: []string{"bd2", "bd4"},
+		},
+		"no allowed hosts": {
+			allowedHostNames: map[string]bool{},
+			expect:           nil,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := SelectAllCheck{
+				Selector:         types.NewBlockDeviceSelector(selector),
+				Devices:          devices,
+				AllowedHostNames: mock.allowedHostNames,
+				OwnedDeviceNames: mock.ownedDeviceNames,
+			}.Apply()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotNames []string
+			for _, device := range got {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSelectorCheckApply(t *testing.T) {
+	newDevice := func(name, state string, labels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name":   name,
+					"labels": labels,
+				},
+				"status": map[string]interface{}{
+					"state": state,
+				},
+			},
+		}
+	}
+	var devices = []*unstructured.Unstructured{
+		newDevice("bd1", "Active", map[string]interface{}{}),
+		newDevice("bd2", "Active", map[string]interface{}{"tier": "cold"}),
+		newDevice("bd3", "Inactive", map[string]interface{}{}),
+		newDevice("bd4", "Active", map[string]interface{}{"reserved-by": "etcd"}),
+	}
+	var active = types.NewBlockDeviceSelector(metac.ResourceSelector{
+		SelectorTerms: []*metac.SelectorTerm{
+			{
+				MatchFields: map[string]string{
+					"status.state": "Active",
+				},
+			},
+		},
+	})
+	var cold = types.NewBlockDeviceSelector(metac.ResourceSelector{
+		SelectorTerms: []*metac.SelectorTerm{
+			{
+				MatchLabels: map[string]string{
+					"tier": "cold",
+				},
+			},
+		},
+	})
+	var tests = map[string]struct {
+		check  SelectorCheck
+		expect []string
+	}{
+		"no selector terms": {
+			check:  SelectorCheck{},
+			expect: []string{"bd1", "bd2", "bd3", "bd4"},
+		},
+		"selector terms": {
+			check:  SelectorCheck{Selector: active},
+			expect: []string{"bd1", "bd2", "bd4"},
+		},
+		"selector & exclude terms": {
+			check:  SelectorCheck{Selector: active, Exclude: cold},
+			expect: []string{"bd1", "bd4"},
+		},
+		"selector & exclude terms & reserved keys": {
+			check: SelectorCheck{
+				Selector:     active,
+				Exclude:      cold,
+				ReservedKeys: []string{"reserved-by"},
+			},
+			expect: []string{"bd1"},
+		},
+		"reserved device in use": {
+			check: SelectorCheck{
+				Selector:         active,
+				ReservedKeys:     []string{"reserved-by"},
+				InUseDeviceNames: map[string]bool{"bd4": true},
+			},
+			expect: []string{"bd1", "bd2", "bd4"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			check := mock.check
+			check.Devices = devices
+			got, err := check.Apply()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotNames []string
+			for _, device := range got {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCapacityCheckApply(t *testing.T) {
+	newDevice := func(name string, capacity int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{
+					"capacity": map[string]interface{}{
+						"storage": capacity,
+					},
+				},
+			},
+		}
+	}
+	qty := func(val string) *resource.Quantity {
+		q := resource.MustParse(val)
+		return &q
+	}
+	var devices = []*unstructured.Unstructured{
+		newDevice("bd1", 1073741824),
+		newDevice("bd2", 10737418240),
+		newDevice("bd3", 107374182400),
+		newDevice("bd4", 1099511627776),
+	}
+	var tests = map[string]struct {
+		min          *resource.Quantity
+		max          *resource.Quantity
+		inUseDevices map[string]bool
+		devices      []*unstructured.Unstructured
+		expect       []string
+		isErr        bool
+	}{
+		"no bounds": {
+			expect: []string{"bd1", "bd2", "bd3", "bd4"},
+		},
+		"min bound": {
+			min:    qty("10Gi"),
+			expect: []string{"bd2", "bd3", "bd4"},
+		},
+		"max bound": {
+			max:    qty("100Gi"),
+			expect: []string{"bd1", "bd2", "bd3"},
+		},
+		"min & max bounds": {
+			min:    qty("5Gi"),
+			max:    qty("500Gi"),
+			expect: []string{"bd2", "bd3"},
+		},
+		"min & max bounds && in use devices": {
+			min: qty("5Gi"),
+			max: qty("500Gi"),
+			inUseDevices: map[string]bool{
+				"bd4": true,
+			},
+			expect: []string{"bd2", "bd3", "bd4"},
+		},
+		"no device within bounds": {
+			min:    qty("2Ti"),
+			expect: nil,
+		},
+		"device without capacity": {
+			min: qty("5Gi"),
+			devices: []*unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"kind": string(types.KindBlockDevice),
+						"metadata": map[string]interface{}{
+							"name": "bd1",
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			checked := devices
+			if mock.devices != nil {
+				checked = mock.devices
+			}
+			got, err := CapacityCheck{
+				Min:              mock.min,
+				Max:              mock.max,
+				Devices:          checked,
+				InUseDeviceNames: mock.inUseDevices,
+			}.Apply()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotNames []string
+			for _, device := range got {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
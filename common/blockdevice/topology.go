@@ -21,6 +21,7 @@ import (
 
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"mayadata.io/cstorpoolauto/types"
 )
@@ -42,6 +43,11 @@ type MetaInfo struct {
 	HostName string
 	Identity *types.Reference
 	Capacity *resource.Quantity
+
+	// Path & CreationTimestamp let the devices be ordered by
+	// their device preference
+	Path              string
+	CreationTimestamp metav1.Time
 }
 
 /*
@@ -142,6 +148,8 @@ func GetTopologyMapGroupByDeviceTypeAndBlockSize(
 				APIVersion: bd.GetAPIVersion(),
 				UID:        bd.GetUID(),
 			},
+			Path:              getPath(&bd),
+			CreationTimestamp: bd.GetCreationTimestamp(),
 		}
 
 		// deviceTypeList contains top level keys in this topology
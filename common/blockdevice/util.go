@@ -257,15 +257,14 @@ func GetDriveType(obj unstructured.Unstructured) (string, error) {
 }
 
 // IsReserved checks if block device is resrved or not
-// using openebs.io/block-device-tag label
+// using the labels & annotations of ReservedKeys
 func IsReserved(obj unstructured.Unstructured) (bool, error) {
 	if obj.GetKind() != string(types.KindBlockDevice) {
 		return false,
 			errors.Errorf("Can not check block device is reserved or not: Expected kind %q got %q",
 				types.KindBlockDevice, obj.GetKind())
 	}
-	_, ok := obj.GetLabels()["openebs.io/block-device-tag"]
-	return ok, nil
+	return GetReservedKey(obj, ReservedKeys) != "", nil
 }
 
 // IsEligibleForCStorPool checks eligibility criteria to create a cStor pool.
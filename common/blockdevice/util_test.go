@@ -1071,6 +1071,20 @@ func TestIsReserved(t *testing.T) {
 			isErr:      false,
 			isReserved: true,
 		},
+		"reserved bd by ndm annotation": {
+			src: unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindBlockDevice),
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							"ndm.io/reserved": "true",
+						},
+					},
+				},
+			},
+			isErr:      false,
+			isReserved: true,
+		},
 		"non reserved bd": {
 			src: unstructured.Unstructured{
 				Object: map[string]interface{}{
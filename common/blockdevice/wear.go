@@ -0,0 +1,258 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// wearRank orders the devices by how much their wear is known
+type wearRank int
+
+const (
+	// wearRankLow is the rank of devices whose wear is below the
+	// high wear percent
+	wearRankLow wearRank = iota
+
+	// wearRankUnknown is the rank of devices without any wear
+	wearRankUnknown
+
+	// wearRankHigh is the rank of devices whose wear is at or
+	// beyond the high wear percent
+	wearRankHigh
+)
+
+// WearScorer scores block devices by their wear as reported at the
+// configured field paths of BlockDevice
+type WearScorer struct {
+	// fieldPaths are the parsed field paths in the order these
+	// are looked up
+	fieldPaths [][]string
+
+	// HighWearPercent is the wear at or beyond which a device is
+	// considered highly worn
+	HighWearPercent int64
+}
+
+// parseWearFieldPath parses the given field path into its keys. The
+// last key can be put in square brackets if it has dots e.g.
+// metadata.annotations[example.io/ssd-wear].
+func parseWearFieldPath(path string) ([]string, error) {
+	var bracketed string
+	if idx := strings.Index(path, "["); idx >= 0 {
+		if !strings.HasSuffix(path, "]") || idx == len(path)-2 {
+			return nil, errs.ValidationErrorf(
+				"Invalid wear field path %q: Want <key>.<key>[<key>]", path,
+			)
+		}
+		bracketed = path[idx+1 : len(path)-1]
+		path = path[:idx]
+	}
+	keys := strings.Split(path, ".")
+	for _, key := range keys {
+		if key == "" {
+			return nil, errs.ValidationErrorf(
+				"Invalid wear field path %q: Empty key", path,
+			)
+		}
+	}
+	if bracketed != "" {
+		keys = append(keys, bracketed)
+	}
+	return keys, nil
+}
+
+// NewWearScorer returns the scorer of the given wear leveling. Nil
+// is returned if wear leveling is not configured.
+func NewWearScorer(config *types.DeviceWearLeveling) (*WearScorer, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if len(config.FieldPaths) == 0 {
+		return nil, errs.ValidationErrorf(
+			"Invalid wear leveling: Missing field paths",
+		)
+	}
+	scorer := &WearScorer{HighWearPercent: types.DefaultHighWearPercent}
+	if config.HighWearPercent != nil {
+		scorer.HighWearPercent = *config.HighWearPercent
+	}
+	if scorer.HighWearPercent <= 0 || scorer.HighWearPercent > 100 {
+		return nil, errs.ValidationErrorf(
+			"Invalid wear leveling: High wear percent %d: Want 1 to 100",
+			scorer.HighWearPercent,
+		)
+	}
+	for _, path := range config.FieldPaths {
+		keys, err := parseWearFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		scorer.fieldPaths = append(scorer.fieldPaths, keys)
+	}
+	return scorer, nil
+}
+
+// GetWear returns the wear percent of the given block device read
+// from the first field path that is set. False is returned if none
+// of the field paths has a valid wear.
+//
+// NOTE:
+//	Wear can be a number or a string e.g. "23" or "23%". Invalid
+// values are logged & are considered as unknown wear.
+func (s *WearScorer) GetWear(device *unstructured.Unstructured) (float64, bool) {
+	for _, keys := range s.fieldPaths {
+		val, found, err := unstructured.NestedFieldNoCopy(device.Object, keys...)
+		if err != nil || !found {
+			continue
+		}
+		var wear float64
+		switch typed := val.(type) {
+		case int64:
+			wear = float64(typed)
+		case float64:
+			wear = typed
+		case string:
+			wear, err = strconv.ParseFloat(
+				strings.TrimSuffix(strings.TrimSpace(typed), "%"), 64,
+			)
+		default:
+			err = errs.ValidationErrorf("Unsupported type %T", val)
+		}
+		if err != nil || wear < 0 {
+			glog.V(2).Infof(
+				"Ignoring wear of BlockDevice %q: Field path %q: Invalid value %v",
+				device.GetName(), strings.Join(keys, "."), val,
+			)
+			continue
+		}
+		return wear, true
+	}
+	return 0, false
+}
+
+// rank returns the rank & the wear of the given block device
+func (s *WearScorer) rank(device *unstructured.Unstructured) (wearRank, float64) {
+	wear, found := s.GetWear(device)
+	if !found {
+		return wearRankUnknown, 0
+	}
+	if wear >= float64(s.HighWearPercent) {
+		return wearRankHigh, wear
+	}
+	return wearRankLow, wear
+}
+
+// IsHighWear returns true if the given block device is highly worn
+func (s *WearScorer) IsHighWear(device *unstructured.Unstructured) bool {
+	rank, _ := s.rank(device)
+	return rank == wearRankHigh
+}
+
+// compare compares the given block devices by their wear. A negative
+// result implies the first device is less worn than the second.
+//
+// NOTE:
+//	Devices without any wear come after the devices whose wear is
+// below the high wear percent & before the highly worn devices.
+func (s *WearScorer) compare(a, b *unstructured.Unstructured) int {
+	rankA, wearA := s.rank(a)
+	rankB, wearB := s.rank(b)
+	if rankA != rankB {
+		return compareInt64(int64(rankA), int64(rankB))
+	}
+	if wearA < wearB {
+		return -1
+	}
+	if wearA > wearB {
+		return 1
+	}
+	return 0
+}
+
+// SortByLeastWorn returns a copy of the given block devices sorted
+// from the least worn to the most worn device. Devices with the
+// same wear retain their given order.
+func (s *WearScorer) SortByLeastWorn(
+	devices []*unstructured.Unstructured,
+) []*unstructured.Unstructured {
+	sorted := append([]*unstructured.Unstructured{}, devices...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return s.compare(sorted[i], sorted[j]) < 0
+	})
+	return sorted
+}
+
+// SortByMostWorn returns a copy of the given block devices sorted
+// from the most worn to the least worn device. Devices with the
+// same wear retain their given order.
+func (s *WearScorer) SortByMostWorn(
+	devices []*unstructured.Unstructured,
+) []*unstructured.Unstructured {
+	sorted := append([]*unstructured.Unstructured{}, devices...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return s.compare(sorted[i], sorted[j]) > 0
+	})
+	return sorted
+}
+
+// ArrangeRAIDGroups returns a copy of the given block devices
+// arranged such that every consecutive groupDeviceCount devices form
+// a raid group with the least possible wear. Most worn devices that
+// can't form a complete raid group are placed last.
+//
+// NOTE:
+//	Every raid group gets one of the least worn devices before any
+// raid group gets a second device. Remaining devices are handed out
+// from the most worn to the least worn. Hence, a mirror never pairs
+// two highly worn devices unless most devices are highly worn.
+func (s *WearScorer) ArrangeRAIDGroups(
+	devices []*unstructured.Unstructured, groupDeviceCount int64,
+) []*unstructured.Unstructured {
+	sorted := s.SortByLeastWorn(devices)
+	groupCount := 0
+	if groupDeviceCount > 0 {
+		groupCount = len(sorted) / int(groupDeviceCount)
+	}
+	if groupCount == 0 || groupDeviceCount == 1 {
+		return sorted
+	}
+	grouped := sorted[:groupCount*int(groupDeviceCount)]
+	leftovers := sorted[len(grouped):]
+	groups := make([][]*unstructured.Unstructured, groupCount)
+	for idx := 0; idx < groupCount; idx++ {
+		groups[idx] = append(groups[idx], grouped[idx])
+	}
+	// remaining devices from the most worn to the least worn
+	for idx := len(grouped) - 1; idx >= groupCount; idx-- {
+		slot := (len(grouped) - 1 - idx) % groupCount
+		groups[slot] = append(groups[slot], grouped[idx])
+	}
+	var arranged []*unstructured.Unstructured
+	for _, group := range groups {
+		arranged = append(arranged, group...)
+	}
+	return append(arranged, leftovers...)
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevice
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+const testWearAnnKey = "example.io/ssd-wear"
+
+// newWearTestDevice returns a block device whose wear is set at
+// status.wear.percentUsed. Wear is not set if it is nil.
+func newWearTestDevice(name string, wear interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+		},
+	}
+	if wear != nil {
+		obj.Object["status"] = map[string]interface{}{
+			"wear": map[string]interface{}{"percentUsed": wear},
+		}
+	}
+	return obj
+}
+
+func newTestWearScorer(t *testing.T) *WearScorer {
+	scorer, err := NewWearScorer(&types.DeviceWearLeveling{
+		FieldPaths: []string{
+			"metadata.annotations[" + testWearAnnKey + "]",
+			"status.wear.percentUsed",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	return scorer
+}
+
+func deviceNames(devices []*unstructured.Unstructured) []string {
+	var names []string
+	for _, device := range devices {
+		names = append(names, device.GetName())
+	}
+	return names
+}
+
+func TestNewWearScorer(t *testing.T) {
+	var zero, tooHigh int64 = 0, 101
+	var tests = map[string]struct {
+		config   *types.DeviceWearLeveling
+		isNil    bool
+		isErr    bool
+		expectHW int64
+	}{
+		"not configured": {
+			isNil: true,
+		},
+		"default high wear": {
+			config:   &types.DeviceWearLeveling{FieldPaths: []string{"status.wear"}},
+			expectHW: types.DefaultHighWearPercent,
+		},
+		"missing field paths": {
+			config: &types.DeviceWearLeveling{},
+			isErr:  true,
+		},
+		"zero high wear": {
+			config: &types.DeviceWearLeveling{
+				FieldPaths:      []string{"status.wear"},
+				HighWearPercent: &zero,
+			},
+			isErr: true,
+		},
+		"too high wear": {
+			config: &types.DeviceWearLeveling{
+				FieldPaths:      []string{"status.wear"},
+				HighWearPercent: &tooHigh,
+			},
+			isErr: true,
+		},
+		"empty key": {
+			config: &types.DeviceWearLeveling{FieldPaths: []string{"status..wear"}},
+			isErr:  true,
+		},
+		"unterminated bracket": {
+			config: &types.DeviceWearLeveling{
+				FieldPaths: []string{"metadata.annotations[example.io/wear"},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewWearScorer(mock.config)
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isNil {
+				if got != nil {
+					t.Fatalf("Expected nil scorer got %+v", got)
+				}
+				return
+			}
+			if got.HighWearPercent != mock.expectHW {
+				t.Fatalf(
+					"Expected high wear %d got %d", mock.expectHW, got.HighWearPercent,
+				)
+			}
+		})
+	}
+}
+
+func TestWearScorerGetWear(t *testing.T) {
+	annotated := newWearTestDevice("bd-1", int64(10))
+	annotated.SetAnnotations(map[string]string{testWearAnnKey: "35%"})
+	invalid := newWearTestDevice("bd-2", "worn")
+	var tests = map[string]struct {
+		device      *unstructured.Unstructured
+		expect      float64
+		expectFound bool
+	}{
+		"first path wins": {
+			device:      annotated,
+			expect:      35,
+			expectFound: true,
+		},
+		"integer": {
+			device:      newWearTestDevice("bd-3", int64(12)),
+			expect:      12,
+			expectFound: true,
+		},
+		"float": {
+			device:      newWearTestDevice("bd-4", 12.5),
+			expect:      12.5,
+			expectFound: true,
+		},
+		"invalid value": {
+			device: invalid,
+		},
+		"not set": {
+			device: newWearTestDevice("bd-5", nil),
+		},
+	}
+	scorer := newTestWearScorer(t)
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, found := scorer.GetWear(mock.device)
+			if found != mock.expectFound || got != mock.expect {
+				t.Fatalf(
+					"Expected wear %v found %t got %v found %t",
+					mock.expect, mock.expectFound, got, found,
+				)
+			}
+		})
+	}
+}
+
+func TestWearScorerSort(t *testing.T) {
+	devices := []*unstructured.Unstructured{
+		newWearTestDevice("bd-1", int64(90)),
+		newWearTestDevice("bd-2", nil),
+		newWearTestDevice("bd-3", int64(5)),
+		newWearTestDevice("bd-4", int64(40)),
+		newWearTestDevice("bd-5", int64(5)),
+	}
+	scorer := newTestWearScorer(t)
+	gotLeast := deviceNames(scorer.SortByLeastWorn(devices))
+	expectLeast := []string{"bd-3", "bd-5", "bd-4", "bd-2", "bd-1"}
+	if diff := cmp.Diff(expectLeast, gotLeast); diff != "" {
+		t.Fatalf("Expected no diff in least worn got\n%s", diff)
+	}
+	gotMost := deviceNames(scorer.SortByMostWorn(devices))
+	expectMost := []string{"bd-1", "bd-2", "bd-4", "bd-3", "bd-5"}
+	if diff := cmp.Diff(expectMost, gotMost); diff != "" {
+		t.Fatalf("Expected no diff in most worn got\n%s", diff)
+	}
+}
+
+func TestWearScorerArrangeRAIDGroups(t *testing.T) {
+	var tests = map[string]struct {
+		wears            []interface{}
+		groupDeviceCount int64
+		expect           []string
+	}{
+		"mirror pairs worn with fresh devices": {
+			wears:            []interface{}{int64(85), int64(2), int64(95), int64(10)},
+			groupDeviceCount: 2,
+			expect:           []string{"bd-1", "bd-2", "bd-3", "bd-0"},
+		},
+		"most worn leftover is placed last": {
+			wears:            []interface{}{int64(85), int64(2), int64(95)},
+			groupDeviceCount: 2,
+			expect:           []string{"bd-1", "bd-0", "bd-2"},
+		},
+		"raidz spreads worn devices": {
+			wears: []interface{}{
+				int64(1), int64(2), int64(3), int64(81), int64(82), int64(83),
+			},
+			groupDeviceCount: 3,
+			expect:           []string{"bd-0", "bd-5", "bd-3", "bd-1", "bd-4", "bd-2"},
+		},
+		"stripe is sorted": {
+			wears:            []interface{}{int64(50), nil, int64(20)},
+			groupDeviceCount: 1,
+			expect:           []string{"bd-2", "bd-0", "bd-1"},
+		},
+	}
+	scorer := newTestWearScorer(t)
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var devices []*unstructured.Unstructured
+			for idx, wear := range mock.wears {
+				devices = append(
+					devices, newWearTestDevice(fmt.Sprintf("bd-%d", idx), wear),
+				)
+			}
+			got := deviceNames(scorer.ArrangeRAIDGroups(devices, mock.groupDeviceCount))
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
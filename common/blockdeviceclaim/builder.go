@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdeviceclaim
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/metadata"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// Builder helps in building the desired state of a
+// BlockDeviceClaim
+type Builder struct {
+	// name of the blockdevice to be claimed
+	BlockDeviceName string
+
+	// namespace of the blockdevice to be claimed
+	Namespace string
+
+	// hostname of the blockdevice to be claimed
+	HostName string
+
+	// name of the CStorPoolCluster that uses the claimed device
+	CStorPoolClusterName string
+
+	// annotations that should be used during building the desired
+	// BlockDeviceClaim
+	DesiredAnnotations map[string]string
+
+	// labels & annotations provided by the user
+	ChildMetadata *types.ChildMetadata
+}
+
+func (b *Builder) validate() error {
+	if b.BlockDeviceName == "" {
+		return errors.Errorf("Can't build desired BlockDeviceClaim: Missing device name")
+	}
+	if b.Namespace == "" {
+		return errors.Errorf(
+			"Can't build desired BlockDeviceClaim: Missing namespace: Device %q",
+			b.BlockDeviceName,
+		)
+	}
+	if b.HostName == "" {
+		return errors.Errorf(
+			"Can't build desired BlockDeviceClaim: Missing hostname: Device %q",
+			b.BlockDeviceName,
+		)
+	}
+	if b.CStorPoolClusterName == "" {
+		return errors.Errorf(
+			"Can't build desired BlockDeviceClaim: Missing cspc name: Device %q",
+			b.BlockDeviceName,
+		)
+	}
+	return nil
+}
+
+// BuildDesiredState returns the desired state of BlockDeviceClaim
+//
+// NOTE:
+//	The returned instance is idempotent and hence can be used during
+// create & update operations
+func (b *Builder) BuildDesiredState() (*unstructured.Unstructured, error) {
+	err := b.validate()
+	if err != nil {
+		return nil, err
+	}
+	claim := &unstructured.Unstructured{}
+	claim.SetUnstructuredContent(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      MakeName(b.BlockDeviceName),
+			"namespace": b.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"blockDeviceName": b.BlockDeviceName,
+			"blockDeviceNodeAttributes": map[string]interface{}{
+				"hostName": b.HostName,
+			},
+		},
+	})
+	claim.SetAnnotations(b.DesiredAnnotations)
+	// cstor operator makes use of this label to verify if the
+	// claimed device can be used by the CStorPoolCluster
+	claim.SetLabels(map[string]string{
+		types.LblKeyCStorPoolClusterName: b.CStorPoolClusterName,
+	})
+	// user provided labels & annotations if any
+	metadata.Propagate(claim, b.ChildMetadata)
+	// below is the right way to set APIVersion & Kind
+	claim.SetAPIVersion(string(types.APIVersionOpenEBSV1Alpha1))
+	claim.SetKind(string(types.KindBlockDeviceClaim))
+	return claim, nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdeviceclaim
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestBuilderBuildDesiredState(t *testing.T) {
+	var tests = map[string]struct {
+		builder *Builder
+		expect  *unstructured.Unstructured
+		isErr   bool
+	}{
+		"missing device name": {
+			builder: &Builder{
+				Namespace:            "openebs",
+				HostName:             "node-1",
+				CStorPoolClusterName: "my-cspc",
+			},
+			isErr: true,
+		},
+		"missing hostname": {
+			builder: &Builder{
+				BlockDeviceName:      "bd-1",
+				Namespace:            "openebs",
+				CStorPoolClusterName: "my-cspc",
+			},
+			isErr: true,
+		},
+		"missing cspc name": {
+			builder: &Builder{
+				BlockDeviceName: "bd-1",
+				Namespace:       "openebs",
+				HostName:        "node-1",
+			},
+			isErr: true,
+		},
+		"valid builder with child metadata": {
+			builder: &Builder{
+				BlockDeviceName:      "bd-1",
+				Namespace:            "openebs",
+				HostName:             "node-1",
+				CStorPoolClusterName: "my-cspc",
+				DesiredAnnotations: map[string]string{
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				},
+				ChildMetadata: &types.ChildMetadata{
+					Labels: map[string]string{
+						"team": "storage",
+						// owned label can't be overridden
+						types.LblKeyCStorPoolClusterName: "junk",
+					},
+				},
+			},
+			expect: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": types.APIVersionOpenEBSV1Alpha1,
+					"kind":       string(types.KindBlockDeviceClaim),
+					"metadata": map[string]interface{}{
+						"name":      "bdc-bd-1",
+						"namespace": "openebs",
+						"annotations": map[string]interface{}{
+							types.AnnKeyCStorClusterConfigUID: "ccc-1",
+						},
+						"labels": map[string]interface{}{
+							types.LblKeyCStorPoolClusterName: "my-cspc",
+							"team":                           "storage",
+						},
+					},
+					"spec": map[string]interface{}{
+						"blockDeviceName": "bd-1",
+						"blockDeviceNodeAttributes": map[string]interface{}{
+							"hostName": "node-1",
+						},
+					},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := mock.builder.BuildDesiredState()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdeviceclaim
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	cspc "mayadata.io/cstorpoolauto/common/cstorpoolcluster"
+	cspcv1alpha1 "mayadata.io/cstorpoolauto/common/cstorpoolcluster/v1alpha1"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+/*
+Find sample bdc here -
+
+```yaml
+apiVersion: openebs.io/v1alpha1
+kind: BlockDeviceClaim
+metadata:
+  name: bdc-blockdevice-06589a357bcab6605efcb6d8846872d6
+  namespace: openebs
+  annotations:
+    dao.mayadata.io/cstorclusterconfig-uid: 1aa4462b-911c-11e9-8d30-42010a8000ec
+  labels:
+    openebs.io/cstor-pool-cluster: my-cluster
+spec:
+  blockDeviceName: blockdevice-06589a357bcab6605efcb6d8846872d6
+  blockDeviceNodeAttributes:
+    hostName: gke-maya-staging-clu-maya-default-sta-5eed5be5-8vtt
+status:
+  phase: Bound
+```
+*/
+
+// NamePrefix is the prefix used to name the BlockDeviceClaim
+// of a BlockDevice
+const NamePrefix string = "bdc-"
+
+// MakeName returns the name of the BlockDeviceClaim that claims
+// the given BlockDevice
+//
+// NOTE:
+//	Name is derived from the device name. This ensures a single
+// claim per device.
+func MakeName(deviceName string) string {
+	return NamePrefix + deviceName
+}
+
+// Helper exposes utility methods w.r.t BlockDeviceClaim
+// unstructured instance
+type Helper struct {
+	BlockDeviceClaim *unstructured.Unstructured
+
+	err error
+}
+
+// NewHelper returns a new instance of Helper
+func NewHelper(claim *unstructured.Unstructured) *Helper {
+	var err error
+	if claim == nil || claim.Object == nil {
+		err = errors.Errorf(
+			"Can't init claim helper: Nil object",
+		)
+	} else if claim.GetKind() != string(types.KindBlockDeviceClaim) {
+		err = errors.Errorf(
+			"Can't init claim helper: Invalid kind: Want %q got %q",
+			types.KindBlockDeviceClaim, claim.GetKind(),
+		)
+	}
+	if err != nil {
+		return &Helper{
+			err: err,
+		}
+	}
+	return &Helper{
+		BlockDeviceClaim: claim,
+	}
+}
+
+// GetBlockDeviceName returns the name of the claimed blockdevice
+func (h *Helper) GetBlockDeviceName() (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	return unstruct.GetStringOrError(
+		h.BlockDeviceClaim, "spec", "blockDeviceName",
+	)
+}
+
+// IsBound returns true if the claim is bound to its blockdevice
+func (h *Helper) IsBound() (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	phase, _, err := unstructured.NestedString(
+		h.BlockDeviceClaim.Object, "status", "phase",
+	)
+	if err != nil {
+		return false, err
+	}
+	return phase == string(types.BlockDeviceClaimBound), nil
+}
+
+// GetBoundBlockDeviceNames returns the names of the blockdevices
+// whose claims are bound
+func GetBoundBlockDeviceNames(claims []*unstructured.Unstructured) ([]string, error) {
+	var names []string
+	for _, claim := range claims {
+		h := NewHelper(claim)
+		isBound, err := h.IsBound()
+		if err != nil {
+			return nil, err
+		}
+		if !isBound {
+			continue
+		}
+		name, err := h.GetBlockDeviceName()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// GetCStorPoolClusterDeviceNames returns the names of the
+// blockdevices that are used in the given CStorPoolCluster
+//
+// NOTE:
+//	Both openebs.io/v1alpha1 & cstor.openebs.io/v1 versions
+// of CStorPoolCluster are supported
+func GetCStorPoolClusterDeviceNames(obj *unstructured.Unstructured) ([]string, error) {
+	if obj == nil {
+		// CStorPoolCluster might not have been created yet
+		return nil, nil
+	}
+	var hostNameToDeviceNames map[string][]string
+	var err error
+	if obj.GetAPIVersion() == types.APIVersionCStorOpenEBSV1 {
+		hostNameToDeviceNames, err =
+			cspc.NewHelper(obj).GroupBlockDeviceNamesByHostName()
+	} else {
+		hostNameToDeviceNames, err =
+			cspcv1alpha1.NewHelper(obj).GroupBlockDeviceNamesByHostName()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, deviceNames := range hostNameToDeviceNames {
+		names = append(names, deviceNames...)
+	}
+	return names, nil
+}
+
+// GetOwnedDeviceNames returns the names of the blockdevices that
+// are either claimed by the given claims or used in the given
+// CStorPoolCluster
+func GetOwnedDeviceNames(
+	claims []*unstructured.Unstructured,
+	observedCStorPoolCluster *unstructured.Unstructured,
+) (map[string]bool, error) {
+	owned := map[string]bool{}
+	for _, claim := range claims {
+		name, err := NewHelper(claim).GetBlockDeviceName()
+		if err != nil {
+			return nil, err
+		}
+		owned[name] = true
+	}
+	inUse, err := GetCStorPoolClusterDeviceNames(observedCStorPoolCluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range inUse {
+		owned[name] = true
+	}
+	return owned, nil
+}
+
+// GetHeldDeviceNames returns the names of the blockdevices that are
+// either claimed by the claims or used in the CStorPoolClusters found
+// in the given resources. Resources of other kinds are ignored.
+//
+// NOTE:
+//	Claims that do not name their blockdevice are ignored
+func GetHeldDeviceNames(objs []*unstructured.Unstructured) ([]string, error) {
+	var names []string
+	for _, obj := range objs {
+		switch obj.GetKind() {
+		case string(types.KindBlockDeviceClaim):
+			name, _, err := unstructured.NestedString(
+				obj.Object, "spec", "blockDeviceName",
+			)
+			if err != nil {
+				return nil, err
+			}
+			if name != "" {
+				names = append(names, name)
+			}
+		case string(types.KindCStorPoolCluster):
+			inUse, err := GetCStorPoolClusterDeviceNames(obj)
+			if err != nil {
+				return nil, err
+			}
+			names = append(names, inUse...)
+		}
+	}
+	return names, nil
+}
+
+// FilterPendingDeviceNames returns the device names that can't
+// be used to form a CStorPoolCluster yet. A device is pending if
+// its claim is not bound & is not already used by the observed
+// CStorPoolCluster.
+//
+// NOTE:
+//	Devices that are already used by the observed CStorPoolCluster
+// are not considered as pending. This avoids disruptions to the
+// pools that were formed before claims were introduced.
+func FilterPendingDeviceNames(
+	deviceNames []string,
+	claims []*unstructured.Unstructured,
+	observedCStorPoolCluster *unstructured.Unstructured,
+) ([]string, error) {
+	bound, err := GetBoundBlockDeviceNames(claims)
+	if err != nil {
+		return nil, err
+	}
+	inUse, err := GetCStorPoolClusterDeviceNames(observedCStorPoolCluster)
+	if err != nil {
+		return nil, err
+	}
+	ready := map[string]bool{}
+	for _, name := range append(bound, inUse...) {
+		ready[name] = true
+	}
+	var pending []string
+	for _, name := range deviceNames {
+		if !ready[name] {
+			pending = append(pending, name)
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,329 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdeviceclaim
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestClaim(deviceName string, phase types.BlockDeviceClaimPhase) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDeviceClaim),
+			"spec": map[string]interface{}{
+				"blockDeviceName": deviceName,
+			},
+			"status": map[string]interface{}{
+				"phase": string(phase),
+			},
+		},
+	}
+}
+
+func TestNewHelper(t *testing.T) {
+	var tests = map[string]struct {
+		claim *unstructured.Unstructured
+		isErr bool
+	}{
+		"nil claim": {
+			isErr: true,
+		},
+		"nil claim object": {
+			claim: &unstructured.Unstructured{},
+			isErr: true,
+		},
+		"invalid claim kind": {
+			claim: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindBlockDevice),
+				},
+			},
+			isErr: true,
+		},
+		"valid claim kind": {
+			claim: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindBlockDeviceClaim),
+				},
+			},
+			isErr: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			h := NewHelper(mock.claim)
+			if mock.isErr && h.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && h.err != nil {
+				t.Fatalf("Expected no error got [%+v]", h.err)
+			}
+		})
+	}
+}
+
+func TestGetCStorPoolClusterDeviceNames(t *testing.T) {
+	var tests = map[string]struct {
+		cspc   *unstructured.Unstructured
+		expect []string
+		isErr  bool
+	}{
+		"nil cspc": {},
+		"v1alpha1 cspc": {
+			cspc: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": types.APIVersionOpenEBSV1Alpha1,
+					"kind":       string(types.KindCStorPoolCluster),
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-1",
+								},
+								"raidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd-1",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expect: []string{"bd-1"},
+		},
+		"v1 cspc": {
+			cspc: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": types.APIVersionCStorOpenEBSV1,
+					"kind":       string(types.KindCStorPoolCluster),
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-1",
+								},
+								"dataRaidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd-1",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expect: []string{"bd-1"},
+		},
+		"v1 cspc with v1alpha1 raid groups": {
+			cspc: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": types.APIVersionCStorOpenEBSV1,
+					"kind":       string(types.KindCStorPoolCluster),
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-1",
+								},
+								"raidGroups": []interface{}{},
+							},
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := GetCStorPoolClusterDeviceNames(mock.cspc)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilterPendingDeviceNames(t *testing.T) {
+	cspc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionOpenEBSV1Alpha1,
+			"kind":       string(types.KindCStorPoolCluster),
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"nodeSelector": map[string]interface{}{
+							"kubernetes.io/hostname": "node-1",
+						},
+						"raidGroups": []interface{}{
+							map[string]interface{}{
+								"blockDevices": []interface{}{
+									map[string]interface{}{
+										"blockDeviceName": "bd-in-use",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	var tests = map[string]struct {
+		deviceNames []string
+		claims      []*unstructured.Unstructured
+		cspc        *unstructured.Unstructured
+		expect      []string
+	}{
+		"no claims": {
+			deviceNames: []string{"bd-1", "bd-2"},
+			expect:      []string{"bd-1", "bd-2"},
+		},
+		"all claims are bound": {
+			deviceNames: []string{"bd-1", "bd-2"},
+			claims: []*unstructured.Unstructured{
+				newTestClaim("bd-1", types.BlockDeviceClaimBound),
+				newTestClaim("bd-2", types.BlockDeviceClaimBound),
+			},
+		},
+		"one claim is pending": {
+			deviceNames: []string{"bd-1", "bd-2"},
+			claims: []*unstructured.Unstructured{
+				newTestClaim("bd-1", types.BlockDeviceClaimBound),
+				newTestClaim("bd-2", types.BlockDeviceClaimPending),
+			},
+			expect: []string{"bd-2"},
+		},
+		"device in use by cspc is not pending": {
+			deviceNames: []string{"bd-1", "bd-in-use"},
+			claims: []*unstructured.Unstructured{
+				newTestClaim("bd-1", types.BlockDeviceClaimBound),
+			},
+			cspc: cspc,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := FilterPendingDeviceNames(
+				mock.deviceNames, mock.claims, mock.cspc,
+			)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetHeldDeviceNames(t *testing.T) {
+	cspc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionOpenEBSV1Alpha1,
+			"kind":       string(types.KindCStorPoolCluster),
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"nodeSelector": map[string]interface{}{
+							"kubernetes.io/hostname": "node-1",
+						},
+						"raidGroups": []interface{}{
+							map[string]interface{}{
+								"blockDevices": []interface{}{
+									map[string]interface{}{
+										"blockDeviceName": "bd-in-use",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	device := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": "bd-9",
+			},
+		},
+	}
+	var tests = map[string]struct {
+		objs   []*unstructured.Unstructured
+		expect []string
+	}{
+		"no resources": {},
+		"claims & cspc": {
+			objs: []*unstructured.Unstructured{
+				newTestClaim("bd-1", types.BlockDeviceClaimPending),
+				cspc,
+				newTestClaim("bd-2", types.BlockDeviceClaimBound),
+			},
+			expect: []string{"bd-1", "bd-in-use", "bd-2"},
+		},
+		"claim without device name & other kinds are ignored": {
+			objs: []*unstructured.Unstructured{
+				newTestClaim("", types.BlockDeviceClaimPending),
+				device,
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := GetHeldDeviceNames(mock.objs)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
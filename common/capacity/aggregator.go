@@ -0,0 +1,266 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// LabelKeyCStorPoolCluster is the label set by cstor operators
+// against a CStorPoolInstance to refer to its CStorPoolCluster
+const LabelKeyCStorPoolCluster string = "openebs.io/cstor-pool-cluster"
+
+// Aggregator aggregates the capacity of the pool instances &
+// block devices that are managed by a CStorClusterConfig
+type Aggregator struct {
+	// CStorPoolInstances belonging to this CStorPoolCluster are
+	// considered for aggregation
+	CStorPoolClusterName      string
+	CStorPoolClusterNamespace string
+
+	CStorPoolInstances []*unstructured.Unstructured
+	BlockDevices       []*unstructured.Unstructured
+
+	// HostNameToPoolDeviceNames maps the host name to the names
+	// of block devices that are part of the pool
+	HostNameToPoolDeviceNames map[string][]string
+}
+
+// isPoolInstanceOfCluster returns true if the given instance
+// belongs to the CStorPoolCluster of this aggregator
+func (a *Aggregator) isPoolInstanceOfCluster(obj *unstructured.Unstructured) bool {
+	if obj == nil || obj.GetKind() != string(types.KindCStorPoolInstance) {
+		return false
+	}
+	if obj.GetNamespace() != a.CStorPoolClusterNamespace {
+		return false
+	}
+	name, _ := unstruct.GetValueForKey(obj.GetLabels(), LabelKeyCStorPoolCluster)
+	return name != "" && name == a.CStorPoolClusterName
+}
+
+// getPoolInstanceHostName returns the host name of the given
+// pool instance
+func getPoolInstanceHostName(obj *unstructured.Unstructured) string {
+	hostName, _, _ := unstructured.NestedString(obj.Object, "spec", "hostName")
+	if hostName != "" {
+		return hostName
+	}
+	hostName, _ = unstruct.GetValueForKey(obj.GetLabels(), "kubernetes.io/hostname")
+	return hostName
+}
+
+// getObservableQuantity returns the quantity found at the given
+// field path. It returns false if the quantity is not observable.
+func getObservableQuantity(
+	obj *unstructured.Unstructured, fields ...string,
+) (resource.Quantity, bool) {
+	val, found, err := unstructured.NestedString(obj.Object, fields...)
+	if err != nil || !found || val == "" {
+		return resource.Quantity{}, false
+	}
+	quantity, err := resource.ParseQuantity(val)
+	if err != nil {
+		return resource.Quantity{}, false
+	}
+	return quantity, true
+}
+
+// aggregatePools sets the capacity of each pool instance & the
+// total capacity against the given capacity
+func (a *Aggregator) aggregatePools(capacity *types.CStorClusterConfigCapacity) {
+	for _, cspi := range a.CStorPoolInstances {
+		if !a.isPoolInstanceOfCluster(cspi) {
+			continue
+		}
+		pool := types.CStorClusterConfigPoolCapacity{
+			Name:     cspi.GetName(),
+			HostName: getPoolInstanceHostName(cspi),
+		}
+		if total, found := getObservableQuantity(
+			cspi, "status", "capacity", "total",
+		); found {
+			pool.Total = total
+			capacity.Total.Add(total)
+		}
+		if used, found := getObservableQuantity(
+			cspi, "status", "capacity", "used",
+		); found {
+			pool.Used = used
+			capacity.Used.Add(used)
+		}
+		if free, found := getObservableQuantity(
+			cspi, "status", "capacity", "free",
+		); found {
+			pool.Free = free
+			capacity.Free.Add(free)
+		}
+		capacity.Pools = append(capacity.Pools, pool)
+	}
+	// sort to keep the status idempotent across reconciliations
+	sort.Slice(capacity.Pools, func(i, j int) bool {
+		return capacity.Pools[i].Name < capacity.Pools[j].Name
+	})
+}
+
+// aggregateNodes sets the block device counts of each node
+// against the given capacity
+func (a *Aggregator) aggregateNodes(capacity *types.CStorClusterConfigCapacity) {
+	hostNameToNode := map[string]*types.CStorClusterConfigNodeCapacity{}
+	getNode := func(hostName string) *types.CStorClusterConfigNodeCapacity {
+		if hostNameToNode[hostName] == nil {
+			hostNameToNode[hostName] = &types.CStorClusterConfigNodeCapacity{
+				HostName: hostName,
+			}
+		}
+		return hostNameToNode[hostName]
+	}
+	for _, device := range a.BlockDevices {
+		hostName, err := bd.NewHelper(device).GetHostName()
+		if err != nil || hostName == "" {
+			// devices without host name can't be counted
+			continue
+		}
+		getNode(hostName).BlockDeviceCount++
+	}
+	for hostName, deviceNames := range a.HostNameToPoolDeviceNames {
+		if hostName == "" {
+			continue
+		}
+		getNode(hostName).PoolBlockDeviceCount += int64(len(deviceNames))
+	}
+	for _, node := range hostNameToNode {
+		node.UtilizationPercent = getUtilizationPercent(node)
+		capacity.Nodes = append(capacity.Nodes, *node)
+	}
+	// sort to keep the status idempotent across reconciliations
+	sort.Slice(capacity.Nodes, func(i, j int) bool {
+		return capacity.Nodes[i].HostName < capacity.Nodes[j].HostName
+	})
+	// first of the sorted nodes wins the tie
+	for _, node := range capacity.Nodes {
+		if node.UtilizationPercent > capacity.MaxNodeUtilizationPercent {
+			capacity.MaxNodeUtilizationPercent = node.UtilizationPercent
+			capacity.MaxUtilizedHostName = node.HostName
+		}
+	}
+}
+
+// getUtilizationPercent returns the percentage of block devices of
+// the given node that are part of the pool
+//
+// NOTE:
+//	Pool devices that are no longer observed are counted as
+// available devices as well. Hence utilization never exceeds 100.
+func getUtilizationPercent(node *types.CStorClusterConfigNodeCapacity) int64 {
+	available := node.BlockDeviceCount
+	if node.PoolBlockDeviceCount > available {
+		available = node.PoolBlockDeviceCount
+	}
+	if available == 0 {
+		return 0
+	}
+	return node.PoolBlockDeviceCount * 100 / available
+}
+
+// Aggregate returns the capacity aggregated from the pool
+// instances & block devices
+func (a *Aggregator) Aggregate() *types.CStorClusterConfigCapacity {
+	capacity := &types.CStorClusterConfigCapacity{}
+	a.aggregatePools(capacity)
+	a.aggregateNodes(capacity)
+	return capacity
+}
+
+// Merge returns the capacity that sums up the given capacities e.g.
+// of the CStorPoolClusters of different failure domains
+//
+// NOTE:
+//	Nodes are expected to be reported by one of the given capacities
+// only
+func Merge(capacities ...*types.CStorClusterConfigCapacity) *types.CStorClusterConfigCapacity {
+	merged := &types.CStorClusterConfigCapacity{}
+	for _, c := range capacities {
+		if c == nil {
+			continue
+		}
+		merged.Total.Add(c.Total)
+		merged.Used.Add(c.Used)
+		merged.Free.Add(c.Free)
+		merged.Pools = append(merged.Pools, c.Pools...)
+		merged.Nodes = append(merged.Nodes, c.Nodes...)
+	}
+	// sort to keep the status idempotent across reconciliations
+	sort.Slice(merged.Pools, func(i, j int) bool {
+		return merged.Pools[i].Name < merged.Pools[j].Name
+	})
+	sort.Slice(merged.Nodes, func(i, j int) bool {
+		return merged.Nodes[i].HostName < merged.Nodes[j].HostName
+	})
+	// first of the sorted nodes wins the tie
+	for _, node := range merged.Nodes {
+		if node.UtilizationPercent > merged.MaxNodeUtilizationPercent {
+			merged.MaxNodeUtilizationPercent = node.UtilizationPercent
+			merged.MaxUtilizedHostName = node.HostName
+		}
+	}
+	return merged
+}
+
+// MakeStatusWithCapacity returns a copy of the given object's
+// status with the given capacity set against it
+func MakeStatusWithCapacity(
+	obj *unstructured.Unstructured, capacity *types.CStorClusterConfigCapacity,
+) (map[string]interface{}, error) {
+	if obj == nil {
+		return nil, errors.Errorf("Can't set capacity: Nil object")
+	}
+	status, _, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"Can't set capacity: Invalid status: Kind %q: Name %q / %q",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(),
+		)
+	}
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if capacity == nil {
+		delete(status, "capacity")
+		return status, nil
+	}
+	capacityMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(capacity)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"Can't set capacity: Kind %q: Name %q / %q",
+			obj.GetKind(), obj.GetNamespace(), obj.GetName(),
+		)
+	}
+	status["capacity"] = capacityMap
+	return status, nil
+}
@@ -0,0 +1,318 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newCSPI(name, cspcName, hostName string, capacity map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolInstance),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+				"labels": map[string]interface{}{
+					LabelKeyCStorPoolCluster: cspcName,
+				},
+			},
+			"spec": map[string]interface{}{
+				"hostName": hostName,
+			},
+			"status": map[string]interface{}{
+				"capacity": capacity,
+			},
+		},
+	}
+}
+
+func newBlockDevice(name, hostName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname": hostName,
+				},
+			},
+		},
+	}
+}
+
+func TestAggregatorAggregate(t *testing.T) {
+	var tests = map[string]struct {
+		aggregator *Aggregator
+		expect     *types.CStorClusterConfigCapacity
+	}{
+		"no pool instances && no block devices": {
+			aggregator: &Aggregator{},
+			expect:     &types.CStorClusterConfigCapacity{},
+		},
+		"pool instances of other cluster are ignored": {
+			aggregator: &Aggregator{
+				CStorPoolClusterName:      "my-cspc",
+				CStorPoolClusterNamespace: "openebs",
+				CStorPoolInstances: []*unstructured.Unstructured{
+					newCSPI("cspi-1", "other-cspc", "node-1", map[string]interface{}{
+						"total": "10Gi",
+					}),
+				},
+			},
+			expect: &types.CStorClusterConfigCapacity{},
+		},
+		"pool instances with observable & non observable capacity": {
+			aggregator: &Aggregator{
+				CStorPoolClusterName:      "my-cspc",
+				CStorPoolClusterNamespace: "openebs",
+				CStorPoolInstances: []*unstructured.Unstructured{
+					newCSPI("cspi-2", "my-cspc", "node-2", map[string]interface{}{
+						"total": "10Gi",
+						"used":  "4Gi",
+						"free":  "6Gi",
+					}),
+					newCSPI("cspi-1", "my-cspc", "node-1", map[string]interface{}{
+						"total": "20Gi",
+						"used":  "junk",
+					}),
+				},
+			},
+			expect: &types.CStorClusterConfigCapacity{
+				Total: resource.MustParse("30Gi"),
+				Used:  resource.MustParse("4Gi"),
+				Free:  resource.MustParse("6Gi"),
+				Pools: []types.CStorClusterConfigPoolCapacity{
+					{
+						Name:     "cspi-1",
+						HostName: "node-1",
+						Total:    resource.MustParse("20Gi"),
+					},
+					{
+						Name:     "cspi-2",
+						HostName: "node-2",
+						Total:    resource.MustParse("10Gi"),
+						Used:     resource.MustParse("4Gi"),
+						Free:     resource.MustParse("6Gi"),
+					},
+				},
+			},
+		},
+		"block devices grouped by node": {
+			aggregator: &Aggregator{
+				BlockDevices: []*unstructured.Unstructured{
+					newBlockDevice("bd-1", "node-1"),
+					newBlockDevice("bd-2", "node-1"),
+					newBlockDevice("bd-3", "node-2"),
+					newBlockDevice("bd-4", ""),
+				},
+				HostNameToPoolDeviceNames: map[string][]string{
+					"node-1": {"bd-1"},
+					"node-3": {"bd-5"},
+				},
+			},
+			expect: &types.CStorClusterConfigCapacity{
+				Nodes: []types.CStorClusterConfigNodeCapacity{
+					{
+						HostName:             "node-1",
+						BlockDeviceCount:     2,
+						PoolBlockDeviceCount: 1,
+						UtilizationPercent:   50,
+					},
+					{
+						HostName:         "node-2",
+						BlockDeviceCount: 1,
+					},
+					{
+						HostName:             "node-3",
+						PoolBlockDeviceCount: 1,
+						UtilizationPercent:   100,
+					},
+				},
+				MaxNodeUtilizationPercent: 100,
+				MaxUtilizedHostName:       "node-3",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.aggregator.Aggregate()
+			if got.Total.Cmp(mock.expect.Total) != 0 ||
+				got.Used.Cmp(mock.expect.Used) != 0 ||
+				got.Free.Cmp(mock.expect.Free) != 0 {
+				t.Fatalf(
+					"Expected total %s used %s free %s got total %s used %s free %s",
+					mock.expect.Total.String(), mock.expect.Used.String(), mock.expect.Free.String(),
+					got.Total.String(), got.Used.String(), got.Free.String(),
+				)
+			}
+			if len(got.Pools) != len(mock.expect.Pools) {
+				t.Fatalf(
+					"Expected pool count %d got %d", len(mock.expect.Pools), len(got.Pools),
+				)
+			}
+			for i, pool := range got.Pools {
+				want := mock.expect.Pools[i]
+				if pool.Name != want.Name || pool.HostName != want.HostName ||
+					pool.Total.Cmp(want.Total) != 0 ||
+					pool.Used.Cmp(want.Used) != 0 ||
+					pool.Free.Cmp(want.Free) != 0 {
+					t.Fatalf("Expected pool %+v got %+v", want, pool)
+				}
+			}
+			if diff := cmp.Diff(mock.expect.Nodes, got.Nodes); diff != "" {
+				t.Fatalf("Expected no diff in nodes got\n%s", diff)
+			}
+			if got.MaxNodeUtilizationPercent != mock.expect.MaxNodeUtilizationPercent ||
+				got.MaxUtilizedHostName != mock.expect.MaxUtilizedHostName {
+				t.Fatalf(
+					"Expected max utilization %d%% at %q got %d%% at %q",
+					mock.expect.MaxNodeUtilizationPercent, mock.expect.MaxUtilizedHostName,
+					got.MaxNodeUtilizationPercent, got.MaxUtilizedHostName,
+				)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	got := Merge(
+		&types.CStorClusterConfigCapacity{
+			Total: resource.MustParse("10Gi"),
+			Used:  resource.MustParse("4Gi"),
+			Free:  resource.MustParse("6Gi"),
+			Pools: []types.CStorClusterConfigPoolCapacity{
+				{Name: "pool-b", HostName: "node-2"},
+			},
+			Nodes: []types.CStorClusterConfigNodeCapacity{
+				{HostName: "node-2", UtilizationPercent: 50},
+			},
+		},
+		nil,
+		&types.CStorClusterConfigCapacity{
+			Total: resource.MustParse("20Gi"),
+			Used:  resource.MustParse("5Gi"),
+			Free:  resource.MustParse("15Gi"),
+			Pools: []types.CStorClusterConfigPoolCapacity{
+				{Name: "pool-a", HostName: "node-1"},
+			},
+			Nodes: []types.CStorClusterConfigNodeCapacity{
+				{HostName: "node-1", UtilizationPercent: 50},
+			},
+		},
+	)
+	if got.Total.Cmp(resource.MustParse("30Gi")) != 0 ||
+		got.Used.Cmp(resource.MustParse("9Gi")) != 0 ||
+		got.Free.Cmp(resource.MustParse("21Gi")) != 0 {
+		t.Fatalf(
+			"Expected total 30Gi used 9Gi free 21Gi got total %s used %s free %s",
+			got.Total.String(), got.Used.String(), got.Free.String(),
+		)
+	}
+	if len(got.Pools) != 2 || got.Pools[0].Name != "pool-a" {
+		t.Fatalf("Expected 2 pools sorted by name got %+v", got.Pools)
+	}
+	if len(got.Nodes) != 2 || got.Nodes[0].HostName != "node-1" {
+		t.Fatalf("Expected 2 nodes sorted by host name got %+v", got.Nodes)
+	}
+	if got.MaxNodeUtilizationPercent != 50 || got.MaxUtilizedHostName != "node-1" {
+		t.Fatalf(
+			"Expected max utilization 50%% at node-1 got %d%% at %q",
+			got.MaxNodeUtilizationPercent, got.MaxUtilizedHostName,
+		)
+	}
+}
+
+func TestMakeStatusWithCapacity(t *testing.T) {
+	var tests = map[string]struct {
+		obj      *unstructured.Unstructured
+		capacity *types.CStorClusterConfigCapacity
+		expect   map[string]interface{}
+		isErr    bool
+	}{
+		"nil object": {
+			isErr: true,
+		},
+		"nil status && nil capacity": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{},
+			},
+			expect: map[string]interface{}{},
+		},
+		"existing status is retained": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"status": map[string]interface{}{
+						"phase": "Online",
+					},
+				},
+			},
+			capacity: &types.CStorClusterConfigCapacity{
+				Total: resource.MustParse("10Gi"),
+				Nodes: []types.CStorClusterConfigNodeCapacity{
+					{
+						HostName:         "node-1",
+						BlockDeviceCount: 2,
+					},
+				},
+			},
+			expect: map[string]interface{}{
+				"phase": "Online",
+				"capacity": map[string]interface{}{
+					"total": "10Gi",
+					"used":  "0",
+					"free":  "0",
+					"nodes": []interface{}{
+						map[string]interface{}{
+							"hostName":             "node-1",
+							"blockDeviceCount":     int64(2),
+							"poolBlockDeviceCount": int64(0),
+							"utilizationPercent":   int64(0),
+						},
+					},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := MakeStatusWithCapacity(mock.obj, mock.capacity)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	capacitymath "mayadata.io/cstorpoolauto/pkg/capacity"
+	"mayadata.io/cstorpoolauto/pkg/index"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// GetReservedBytes returns the bytes that need to be reserved
+// out of the given total bytes. Reserve is either a quantity
+// e.g. 100Gi or a percentage e.g. 20% of the total.
+//
+// NOTE:
+//	Percentage is rounded up to reserve at least the requested
+// capacity.
+func GetReservedBytes(reserve *intstr.IntOrString, total int64) (int64, error) {
+	if reserve == nil {
+		return 0, nil
+	}
+	if reserve.Type == intstr.Int {
+		if reserve.IntVal < 0 {
+			return 0, errors.Errorf("Invalid reserve %d: Negative value", reserve.IntVal)
+		}
+		return int64(reserve.IntVal), nil
+	}
+	value := strings.TrimSpace(reserve.StrVal)
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseInt(strings.TrimSuffix(value, "%"), 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Invalid reserve %q", value)
+		}
+		if percent < 0 || percent > 100 {
+			return 0, errors.Errorf("Invalid reserve %q: Want 0%% to 100%%", value)
+		}
+		percentOfTotal, err := capacitymath.Multiply(total, percent)
+		if err != nil {
+			return 0, errors.Wrapf(err, "Invalid reserve %q", value)
+		}
+		return capacitymath.DivideCeil(percentOfTotal, 100)
+	}
+	qty, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Invalid reserve %q", value)
+	}
+	if qty.Sign() < 0 {
+		return 0, errors.Errorf("Invalid reserve %q: Negative value", value)
+	}
+	return qty.Value(), nil
+}
+
+// getDeviceBytes returns the capacity of the given block device
+// in bytes. Device without capacity is considered to be of zero
+// bytes.
+func getDeviceBytes(device *unstructured.Unstructured) int64 {
+	bytes, _, _ :=
+		unstructured.NestedInt64(device.Object, "spec", "capacity", "storage")
+	return bytes
+}
+
+// Reservation leaves a part of the raw capacity of every node
+// unselected by dropping some of the selected block devices
+type Reservation struct {
+	// ReservePerNode is the capacity that should not be selected
+	// on any node
+	ReservePerNode *intstr.IntOrString
+
+	// ObservedBlockDevices are used to compute the total raw
+	// capacity of each node
+	ObservedBlockDevices []*unstructured.Unstructured
+
+	// SelectedBlockDevices are the devices from which some get
+	// dropped to honour the reservation
+	SelectedBlockDevices []*unstructured.Unstructured
+
+	// InUseDeviceNames are never dropped since these are already
+	// used by the pools
+	InUseDeviceNames []string
+
+	// GroupDeviceCount is the number of devices per RAID group. The
+	// retained count of devices on a node is a multiple of this count
+	// unless in use devices prevent this.
+	GroupDeviceCount int64
+
+	// Preference decides the devices that get consumed first. Least
+	// preferred devices are dropped first. Defaults to SmallestFirst.
+	Preference types.DevicePreference
+
+	// Wear when set drops the most worn devices first. Preference
+	// then breaks the ties between devices of the same wear.
+	Wear *bd.WearScorer
+}
+
+// Apply returns the selected devices that remain after honouring
+// the reservation on every node. Order of the selected devices
+// is preserved.
+//
+// NOTE:
+//	Least preferred devices are dropped first. With the default
+// preference i.e. SmallestFirst, devices with largest capacity are
+// dropped first. This leaves the reserved capacity unselected with
+// the least number of devices.
+func (r Reservation) Apply() ([]*unstructured.Unstructured, error) {
+	if r.ReservePerNode == nil {
+		return r.SelectedBlockDevices, nil
+	}
+	isInUse := map[string]bool{}
+	for _, name := range r.InUseDeviceNames {
+		isInUse[name] = true
+	}
+	observed := index.NewBlockDeviceIndex(r.ObservedBlockDevices)
+	selected := index.NewBlockDeviceIndex(r.SelectedBlockDevices)
+	isDropped := map[string]bool{}
+	for _, node := range selected.NodeNames() {
+		var total, used int64
+		for _, device := range observed.ByNode(node) {
+			sum, err := capacitymath.Add(total, getDeviceBytes(device))
+			if err != nil {
+				return nil, errors.Wrapf(err, "Can't compute capacity of node %q", node)
+			}
+			total = sum
+		}
+		reserved, err := GetReservedBytes(r.ReservePerNode, total)
+		if err != nil {
+			return nil, err
+		}
+		var droppable []*unstructured.Unstructured
+		for _, device := range selected.ByNode(node) {
+			used += getDeviceBytes(device)
+			if !isInUse[device.GetName()] {
+				droppable = append(droppable, device)
+			}
+		}
+		retainedCount := int64(len(selected.ByNode(node)))
+		// least preferred devices are dropped first
+		droppable, err = bd.SortByLeastPreferred(droppable, r.Preference)
+		if err != nil {
+			return nil, err
+		}
+		if r.Wear != nil {
+			droppable = r.Wear.SortByMostWorn(droppable)
+		}
+		for _, device := range droppable {
+			isGroupAligned := r.GroupDeviceCount <= 0 ||
+				retainedCount%r.GroupDeviceCount == 0
+			if total-used >= reserved && isGroupAligned {
+				break
+			}
+			isDropped[device.GetName()] = true
+			used -= getDeviceBytes(device)
+			retainedCount--
+		}
+		if total-used < reserved {
+			glog.V(3).Infof(
+				"Can't reserve %d bytes on node %q: Unselected %d bytes: Devices in use %v",
+				reserved, node, total-used, r.InUseDeviceNames,
+			)
+		}
+	}
+	var retained []*unstructured.Unstructured
+	for _, device := range r.SelectedBlockDevices {
+		if device == nil || isDropped[device.GetName()] {
+			continue
+		}
+		retained = append(retained, device)
+	}
+	return retained, nil
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newSizedBlockDevice(name, hostName string, bytes int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname": hostName,
+				},
+			},
+			"spec": map[string]interface{}{
+				"capacity": map[string]interface{}{
+					"storage": bytes,
+				},
+			},
+		},
+	}
+}
+
+func TestGetReservedBytes(t *testing.T) {
+	str := intstr.FromString
+	var tests = map[string]struct {
+		reserve *intstr.IntOrString
+		total   int64
+		expect  int64
+		isErr   bool
+	}{
+		"nil reserve": {
+			total: 100,
+		},
+		"int reserve": {
+			reserve: &intstr.IntOrString{Type: intstr.Int, IntVal: 50},
+			total:   100,
+			expect:  50,
+		},
+		"quantity reserve": {
+			reserve: func() *intstr.IntOrString { v := str("1Ki"); return &v }(),
+			total:   4096,
+			expect:  1024,
+		},
+		"percentage reserve is rounded up": {
+			reserve: func() *intstr.IntOrString { v := str("10%"); return &v }(),
+			total:   1001,
+			expect:  101,
+		},
+		"percentage reserve of ti boundary": {
+			reserve: func() *intstr.IntOrString { v := str("50%"); return &v }(),
+			total:   1<<40 + 1,
+			expect:  1<<39 + 1,
+		},
+		"percentage reserve that overflows": {
+			reserve: func() *intstr.IntOrString { v := str("50%"); return &v }(),
+			total:   math.MaxInt64 / 10,
+			isErr:   true,
+		},
+		"invalid percentage": {
+			reserve: func() *intstr.IntOrString { v := str("110%"); return &v }(),
+			total:   100,
+			isErr:   true,
+		},
+		"invalid quantity": {
+			reserve: func() *intstr.IntOrString { v := str("junk"); return &v }(),
+			total:   100,
+			isErr:   true,
+		},
+		"negative quantity": {
+			reserve: func() *intstr.IntOrString { v := str("-1Gi"); return &v }(),
+			total:   100,
+			isErr:   true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := GetReservedBytes(mock.reserve, mock.total)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.expect != got {
+				t.Fatalf("Expected %d got %d", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestReservationApply(t *testing.T) {
+	observed := []*unstructured.Unstructured{
+		newSizedBlockDevice("bd-1", "node-1", 100),
+		newSizedBlockDevice("bd-2", "node-1", 200),
+		newSizedBlockDevice("bd-3", "node-1", 100),
+		newSizedBlockDevice("bd-4", "node-1", 100),
+		newSizedBlockDevice("bd-5", "node-2", 100),
+		newSizedBlockDevice("bd-6", "node-2", 100),
+	}
+	var worn []*unstructured.Unstructured
+	for idx, wear := range []string{"90", "10", "20", "30"} {
+		device := observed[idx].DeepCopy()
+		device.SetAnnotations(map[string]string{"example.io/ssd-wear": wear})
+		worn = append(worn, device)
+	}
+	wear, err := bd.NewWearScorer(&types.DeviceWearLeveling{
+		FieldPaths: []string{"metadata.annotations[example.io/ssd-wear]"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	reserve := func(value string) *intstr.IntOrString {
+		v := intstr.FromString(value)
+		return &v
+	}
+	var tests = map[string]struct {
+		reservation Reservation
+		expect      []string
+		isErr       bool
+	}{
+		"no reservation": {
+			reservation: Reservation{
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed,
+			},
+			expect: []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+		},
+		"reservation is already honoured": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("100"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: []*unstructured.Unstructured{
+					observed[0], observed[4],
+				},
+			},
+			expect: []string{"bd-1", "bd-5"},
+		},
+		"largest device is dropped first": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("150"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed[:4],
+			},
+			expect: []string{"bd-1", "bd-3", "bd-4"},
+		},
+		"smallest device is dropped first if largest is preferred": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("150"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed[:4],
+				Preference:           types.DevicePreferenceLargestFirst,
+			},
+			expect: []string{"bd-2", "bd-4"},
+		},
+		"most worn device is dropped first": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("100"),
+				ObservedBlockDevices: worn,
+				SelectedBlockDevices: worn,
+				Wear:                 wear,
+			},
+			expect: []string{"bd-2", "bd-3", "bd-4"},
+		},
+		"invalid preference": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("150"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed[:4],
+				Preference:           "Random",
+			},
+			isErr: true,
+		},
+		"devices are dropped in raid groups": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("150"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed[:4],
+				GroupDeviceCount:     2,
+			},
+			expect: []string{"bd-3", "bd-4"},
+		},
+		"percentage is reserved per node": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("50%"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed,
+			},
+			expect: []string{"bd-3", "bd-4", "bd-6"},
+		},
+		"in use devices are never dropped": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("100%"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed,
+				InUseDeviceNames:     []string{"bd-2", "bd-5"},
+			},
+			expect: []string{"bd-2", "bd-5"},
+		},
+		"invalid reservation": {
+			reservation: Reservation{
+				ReservePerNode:       reserve("junk"),
+				ObservedBlockDevices: observed,
+				SelectedBlockDevices: observed,
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := mock.reservation.Apply()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotNames []string
+			for _, device := range got {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cspcexport renders the generated CStorPoolCluster(s) as
+// Helm values & Kustomize patch for the teams that apply these via
+// their own tooling.
+//
+// NOTE:
+//	A CStorClusterConfig with Export output mode gets its generated
+// CStorPoolCluster written into a ConfigMap named
+// <cspc-name>-cspc-export in the namespace of this config. Both the
+// formats are written into this ConfigMap. CStorPoolCluster that
+// was already applied is retained as is & is no longer updated.
+package cspcexport
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// Format represents the format a CStorPoolCluster is rendered in
+type Format string
+
+const (
+	// FormatHelm renders a CStorPoolCluster as Helm values
+	FormatHelm Format = "helm"
+
+	// FormatKustomize renders a CStorPoolCluster as Kustomize patch
+	FormatKustomize Format = "kustomize"
+)
+
+// FileNames maps every format to the key of the exported ConfigMap's
+// data that holds the CStorPoolCluster rendered in this format
+var FileNames = map[Format]string{
+	FormatHelm:      "values.yaml",
+	FormatKustomize: "cspc-patch.yaml",
+}
+
+// HelmValuesKey is the key of Helm values that holds the rendered
+// CStorPoolCluster
+const HelmValuesKey string = "cstorPoolCluster"
+
+// Name returns the name of the ConfigMap that exports the given
+// CStorPoolCluster
+func Name(cspcName string) string {
+	return cspcName + "-cspc-export"
+}
+
+// IsExportOf returns true if the given object exports a
+// CStorPoolCluster of the given config
+func IsExportOf(obj, config *unstructured.Unstructured) bool {
+	if obj == nil || config == nil || obj.GetKind() != "ConfigMap" {
+		return false
+	}
+	annotations := obj.GetAnnotations()
+	if annotations[types.AnnKeyCStorClusterConfigUID] != string(config.GetUID()) {
+		return false
+	}
+	return annotations[types.AnnKeyCStorPoolClusterExport] != ""
+}
+
+// IsExport returns true if the given object is a ConfigMap that
+// exports a CStorPoolCluster
+func IsExport(obj *unstructured.Unstructured) bool {
+	if obj == nil || obj.GetKind() != "ConfigMap" {
+		return false
+	}
+	return obj.GetAnnotations()[types.AnnKeyCStorPoolClusterExport] != ""
+}
+
+// sanitize returns the given CStorPoolCluster without the fields
+// that are set by the API server
+func sanitize(cspc *unstructured.Unstructured) *unstructured.Unstructured {
+	clean := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": cspc.GetAPIVersion(),
+			"kind":       cspc.GetKind(),
+			"metadata":   map[string]interface{}{},
+		},
+	}
+	clean.SetName(cspc.GetName())
+	clean.SetNamespace(cspc.GetNamespace())
+	if len(cspc.GetLabels()) != 0 {
+		clean.SetLabels(cspc.GetLabels())
+	}
+	if len(cspc.GetAnnotations()) != 0 {
+		clean.SetAnnotations(cspc.GetAnnotations())
+	}
+	if spec, found := cspc.Object["spec"]; found {
+		clean.Object["spec"] = spec
+	}
+	return clean
+}
+
+// Render returns the given CStorPoolCluster rendered in the given
+// format
+//
+// NOTE:
+//	Helm values hold the CStorPoolCluster against the
+// cstorPoolCluster key. Kustomize patch is the CStorPoolCluster
+// itself & is applied as a strategic merge patch.
+func Render(cspc *unstructured.Unstructured, format Format) ([]byte, error) {
+	if cspc == nil {
+		return nil, errors.Errorf("Can't render CStorPoolCluster: Nil object")
+	}
+	if cspc.GetKind() != string(types.KindCStorPoolCluster) {
+		return nil, errs.ValidationErrorf(
+			"Can't render %s %q: Want %s",
+			cspc.GetKind(), cspc.GetName(), types.KindCStorPoolCluster,
+		)
+	}
+	clean := sanitize(cspc)
+	var obj interface{}
+	switch format {
+	case FormatHelm:
+		obj = map[string]interface{}{HelmValuesKey: clean.Object}
+	case FormatKustomize:
+		obj = clean.Object
+	default:
+		return nil, errs.ValidationErrorf(
+			"Can't render CStorPoolCluster %q: Unsupported format %q: Supports %q or %q",
+			cspc.GetName(), format, FormatHelm, FormatKustomize,
+		)
+	}
+	raw, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err, "Can't render CStorPoolCluster %q as %s", cspc.GetName(), format,
+		)
+	}
+	return raw, nil
+}
+
+// Exporter exports the desired CStorPoolCluster(s) of a config
+// instead of applying them
+type Exporter struct {
+	ClusterConfig *unstructured.Unstructured
+
+	// CStorPoolCluster(s) of the config that were applied earlier
+	ObservedCStorPoolClusters []*unstructured.Unstructured
+}
+
+// Export returns the ConfigMaps that export the given desired
+// CStorPoolCluster(s) followed by the observed CStorPoolCluster(s)
+// that are retained as is
+func (e Exporter) Export(desired []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	if e.ClusterConfig == nil {
+		return nil, errors.Errorf("Can't export CStorPoolCluster: Nil CStorClusterConfig")
+	}
+	var attachments []*unstructured.Unstructured
+	for _, cspc := range desired {
+		if cspc == nil {
+			continue
+		}
+		exported, err := e.newConfigMap(cspc)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, exported)
+	}
+	for _, observed := range e.ObservedCStorPoolClusters {
+		if observed == nil {
+			continue
+		}
+		attachments = append(attachments, observed)
+	}
+	return attachments, nil
+}
+
+// newConfigMap returns the ConfigMap that exports the given
+// CStorPoolCluster in all the supported formats
+//
+// NOTE:
+//	ConfigMap is owned by the config & hence gets garbage collected
+// when this config is deleted.
+func (e Exporter) newConfigMap(cspc *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	data := map[string]interface{}{}
+	for format, fileName := range FileNames {
+		raw, err := Render(cspc, format)
+		if err != nil {
+			return nil, err
+		}
+		data[fileName] = string(raw)
+	}
+	config := e.ClusterConfig
+	exported := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       data,
+		},
+	}
+	exported.SetName(Name(cspc.GetName()))
+	exported.SetNamespace(config.GetNamespace())
+	exported.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID:  string(config.GetUID()),
+		types.AnnKeyCStorPoolClusterExport: cspc.GetName(),
+	})
+	exported.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: config.GetAPIVersion(),
+			Kind:       config.GetKind(),
+			Name:       config.GetName(),
+			UID:        config.GetUID(),
+		},
+	})
+	return exported, nil
+}
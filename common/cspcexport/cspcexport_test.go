@@ -0,0 +1,228 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cspcexport
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestCStorPoolCluster(name string) *unstructured.Unstructured {
+	cspc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindCStorPoolCluster),
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"nodeSelector": map[string]interface{}{
+							"kubernetes.io/hostname": "node-1",
+						},
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"phase": "Online",
+			},
+		},
+	}
+	cspc.SetName(name)
+	cspc.SetNamespace("openebs")
+	cspc.SetResourceVersion("101")
+	cspc.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: "config-1",
+	})
+	return cspc
+}
+
+func newTestClusterConfig() *unstructured.Unstructured {
+	config := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+			"kind":       string(types.KindCStorClusterConfig),
+		},
+	}
+	config.SetName("my-config")
+	config.SetNamespace("storage")
+	config.SetUID("config-1")
+	return config
+}
+
+func TestRender(t *testing.T) {
+	var tests = map[string]struct {
+		cspc   *unstructured.Unstructured
+		format Format
+		expect string
+		isErr  bool
+	}{
+		"helm values": {
+			cspc:   newTestCStorPoolCluster("my-cspc"),
+			format: FormatHelm,
+			expect: `cstorPoolCluster:
+  apiVersion: openebs.io/v1alpha1
+  kind: CStorPoolCluster
+  metadata:
+    annotations:
+      dao.mayadata.io/cstorclusterconfig-uid: config-1
+    name: my-cspc
+    namespace: openebs
+  spec:
+    pools:
+    - nodeSelector:
+        kubernetes.io/hostname: node-1
+`,
+		},
+		"kustomize patch": {
+			cspc:   newTestCStorPoolCluster("my-cspc"),
+			format: FormatKustomize,
+			expect: `apiVersion: openebs.io/v1alpha1
+kind: CStorPoolCluster
+metadata:
+  annotations:
+    dao.mayadata.io/cstorclusterconfig-uid: config-1
+  name: my-cspc
+  namespace: openebs
+spec:
+  pools:
+  - nodeSelector:
+      kubernetes.io/hostname: node-1
+`,
+		},
+		"unsupported format": {
+			cspc:   newTestCStorPoolCluster("my-cspc"),
+			format: "jsonnet",
+			isErr:  true,
+		},
+		"not a cstor pool cluster": {
+			cspc: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": "ConfigMap",
+				},
+			},
+			format: FormatHelm,
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Render(mock.cspc, mock.format)
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, string(got)); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExporterExport(t *testing.T) {
+	observed := newTestCStorPoolCluster("my-cspc")
+	var tests = map[string]struct {
+		observed    []*unstructured.Unstructured
+		desired     []*unstructured.Unstructured
+		expectNames []string
+		expectKinds []string
+	}{
+		"nothing to export": {},
+		"desired cspc is exported": {
+			desired:     []*unstructured.Unstructured{newTestCStorPoolCluster("my-cspc")},
+			expectNames: []string{"my-cspc-cspc-export"},
+			expectKinds: []string{"ConfigMap"},
+		},
+		"observed cspc is retained": {
+			observed: []*unstructured.Unstructured{nil, observed},
+			desired:  []*unstructured.Unstructured{newTestCStorPoolCluster("my-cspc")},
+			expectNames: []string{
+				"my-cspc-cspc-export", "my-cspc",
+			},
+			expectKinds: []string{
+				"ConfigMap", string(types.KindCStorPoolCluster),
+			},
+		},
+		"every sharded cspc is exported": {
+			desired: []*unstructured.Unstructured{
+				newTestCStorPoolCluster("my-cspc-zone-a"),
+				newTestCStorPoolCluster("my-cspc-zone-b"),
+			},
+			expectNames: []string{
+				"my-cspc-zone-a-cspc-export", "my-cspc-zone-b-cspc-export",
+			},
+			expectKinds: []string{"ConfigMap", "ConfigMap"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			config := newTestClusterConfig()
+			e := Exporter{
+				ClusterConfig:             config,
+				ObservedCStorPoolClusters: mock.observed,
+			}
+			got, err := e.Export(mock.desired)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotNames, gotKinds []string
+			for _, obj := range got {
+				gotNames = append(gotNames, obj.GetName())
+				gotKinds = append(gotKinds, obj.GetKind())
+				if obj.GetKind() != "ConfigMap" {
+					continue
+				}
+				if !IsExportOf(obj, config) {
+					t.Fatalf("Expected export of config got %+v", obj)
+				}
+				if obj.GetNamespace() != config.GetNamespace() {
+					t.Fatalf(
+						"Expected namespace %q got %q",
+						config.GetNamespace(), obj.GetNamespace(),
+					)
+				}
+				data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+				for _, fileName := range FileNames {
+					if data[fileName] == "" {
+						t.Fatalf("Expected data %q got none", fileName)
+					}
+				}
+			}
+			if diff := cmp.Diff(mock.expectNames, gotNames); diff != "" {
+				t.Fatalf("Expected no diff in names got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectKinds, gotKinds); diff != "" {
+				t.Fatalf("Expected no diff in kinds got\n%s", diff)
+			}
+		})
+	}
+}
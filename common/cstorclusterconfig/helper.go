@@ -17,8 +17,22 @@ limitations under the License.
 package cstorclusterconfig
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	"mayadata.io/cstorpoolauto/common/disksource"
+	"mayadata.io/cstorpoolauto/common/ignorefields"
+	"mayadata.io/cstorpoolauto/common/naming"
+	"mayadata.io/cstorpoolauto/common/overlay"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/features"
+	"mayadata.io/cstorpoolauto/pkg/zfsproperty"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 
@@ -27,6 +41,8 @@ import (
 
 var nilselector = metac.ResourceSelector{}
 
+var nilBlockDeviceSelector = types.BlockDeviceSelector{}
+
 // Helper provides all utility methods against a CStorClusterConfig
 // unstructured instance
 type Helper struct {
@@ -54,32 +70,131 @@ func NewHelper(obj *unstructured.Unstructured) *Helper {
 	return h
 }
 
+// DefaultLocalBlockDeviceSelector selects the block devices that
+// are active & are not claimed. This is used when local disk config
+// is set to select all the block devices.
+var DefaultLocalBlockDeviceSelector = types.BlockDeviceSelector{
+	SelectorTerms: []*types.BlockDeviceSelectorTerm{
+		{
+			SelectorTerm: metac.SelectorTerm{
+				MatchFields: map[string]string{
+					"status.state":      string(types.BlockDeviceActive),
+					"status.claimState": string(types.BlockDeviceUnclaimed),
+				},
+			},
+		},
+	},
+}
+
 // IsLocalBlockDiskConfig returns true if provided CStorClusterConfig
 // is set with local block disk configuration
+//
+// NOTE:
+//	A local disk config that neither sets selector terms nor opts
+// to select all the block devices results in validation error
 func (h *Helper) IsLocalBlockDiskConfig() (bool, error) {
 	if h.err != nil {
 		return false, h.err
 	}
-	localDiskSelectTerms, found, err := unstructured.NestedSlice(
+	localDiskConfObj, found, err := unstructured.NestedFieldNoCopy(
 		h.ClusterConfig.Object,
 		"spec",
 		"diskConfig",
 		"local",
+	)
+	if err != nil {
+		return false, err
+	}
+	if !found || localDiskConfObj == nil {
+		return false, nil
+	}
+	localDiskConf, ok := localDiskConfObj.(map[string]interface{})
+	if !ok {
+		return false, errors.Errorf(
+			"Invalid local disk config: Want map got %T", localDiskConfObj,
+		)
+	}
+	isSelectAll, _, err := unstructured.NestedBool(localDiskConf, "selectAll")
+	if err != nil {
+		return false, err
+	}
+	localDiskSelectTerms, _, err := unstructured.NestedSlice(
+		localDiskConf,
 		"blockDeviceSelector",
 		"selectorTerms",
 	)
 	if err != nil {
 		return false, err
 	}
-	if !found || len(localDiskSelectTerms) == 0 {
-		return false, nil
+	if !isSelectAll && len(localDiskSelectTerms) == 0 {
+		return false, errs.ValidationErrorf(
+			"Invalid local disk config: Either blockDeviceSelector.selectorTerms or selectAll must be set",
+		)
 	}
 	return true, nil
 }
 
+// IsLocalBlockDeviceSelectAll returns true if local disk config is
+// set to select all the block devices
+func (h *Helper) IsLocalBlockDeviceSelectAll() (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	isSelectAll, _, err := unstructured.NestedBool(
+		h.ClusterConfig.Object,
+		"spec",
+		"diskConfig",
+		"local",
+		"selectAll",
+	)
+	if err != nil {
+		return false, err
+	}
+	return isSelectAll, nil
+}
+
 // GetLocalBlockDeviceSelector returns block disk selector that has been
-// configured to match against any block device(s)
-func (h *Helper) GetLocalBlockDeviceSelector() (metac.ResourceSelector, error) {
+// configured to match against any block device(s). Default selector
+// is returned if local disk config is set to select all the block
+// devices.
+func (h *Helper) GetLocalBlockDeviceSelector() (types.BlockDeviceSelector, error) {
+	if h.err != nil {
+		return nilBlockDeviceSelector, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nilBlockDeviceSelector, err
+	}
+	localDiskConf := cstorClusterConfigTyped.Spec.DiskConfig.LocalDiskConfig
+	if localDiskConf == nil {
+		return nilBlockDeviceSelector,
+			errors.Errorf(
+				"Can't get disk selector: Nil LocalDiskConfig",
+			)
+	}
+	if !localDiskConf.SelectAll {
+		err = bd.ValidateSelector(localDiskConf.BlockDeviceSelector)
+		if err != nil {
+			return nilBlockDeviceSelector, err
+		}
+		return localDiskConf.BlockDeviceSelector, nil
+	}
+	if len(localDiskConf.BlockDeviceSelector.SelectorTerms) != 0 {
+		return nilBlockDeviceSelector,
+			errs.ValidationErrorf(
+				"Invalid local disk config: Both blockDeviceSelector.selectorTerms & selectAll can't be set",
+			)
+	}
+	return DefaultLocalBlockDeviceSelector, nil
+}
+
+// GetAllowedNodeSelector returns the selector that has been
+// configured to match the nodes eligible to form the pools
+func (h *Helper) GetAllowedNodeSelector() (metac.ResourceSelector, error) {
 	if h.err != nil {
 		return nilselector, h.err
 	}
@@ -91,14 +206,530 @@ func (h *Helper) GetLocalBlockDeviceSelector() (metac.ResourceSelector, error) {
 	if err != nil {
 		return nilselector, err
 	}
+	return cstorClusterConfigTyped.Spec.AllowedNodes.ResourceSelector, nil
+}
+
+// GetLocalBlockDeviceExclude returns block disk selector that has been
+// configured to exclude block device(s). An empty selector is returned
+// if no exclude terms were configured.
+func (h *Helper) GetLocalBlockDeviceExclude() (types.BlockDeviceSelector, error) {
+	if h.err != nil {
+		return nilBlockDeviceSelector, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nilBlockDeviceSelector, err
+	}
 	localDiskConf := cstorClusterConfigTyped.Spec.DiskConfig.LocalDiskConfig
 	if localDiskConf == nil {
-		return nilselector,
+		return nilBlockDeviceSelector,
 			errors.Errorf(
-				"Can't get disk selector: Nil LocalDiskConfig",
+				"Can't get disk exclude: Nil LocalDiskConfig",
+			)
+	}
+	if localDiskConf.BlockDeviceExclude == nil {
+		return nilBlockDeviceSelector, nil
+	}
+	err = bd.ValidateSelector(*localDiskConf.BlockDeviceExclude)
+	if err != nil {
+		return nilBlockDeviceSelector, err
+	}
+	return *localDiskConf.BlockDeviceExclude, nil
+}
+
+// GetLocalFailureDomainKey returns the node label key that shards
+// the pools of local disks by failure domain. An empty key implies
+// all the pools belong to a single CStorPoolCluster.
+func (h *Helper) GetLocalFailureDomainKey() (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return "", errs.AsValidationError(
+			errors.Wrapf(err, "Invalid failure domain key"),
+		)
+	}
+	localDiskConf := cstorClusterConfigTyped.Spec.DiskConfig.LocalDiskConfig
+	if localDiskConf == nil || localDiskConf.FailureDomainKey == "" {
+		return "", nil
+	}
+	key := localDiskConf.FailureDomainKey
+	if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+		return "", errs.ValidationErrorf(
+			"Invalid local disk config: failureDomainKey %q: %s",
+			key, strings.Join(msgs, ", "),
+		)
+	}
+	if !features.Enabled(features.TopologySpread) {
+		return "", features.NewDisabledError(
+			features.TopologySpread, "Invalid local disk config: failureDomainKey",
+		)
+	}
+	return key, nil
+}
+
+// GetLocalExplicitDeviceMap returns the block devices that are
+// pinned to the pools of specific nodes
+//
+// NOTE:
+//	A node can be listed only once. Similarly, a block device can
+// be pinned to only one node.
+func (h *Helper) GetLocalExplicitDeviceMap() ([]types.ExplicitDeviceMapping, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, errs.AsValidationError(
+			errors.Wrapf(err, "Invalid explicit device map"),
+		)
+	}
+	localDiskConf := cstorClusterConfigTyped.Spec.DiskConfig.LocalDiskConfig
+	if localDiskConf == nil {
+		return nil, nil
+	}
+	nodeNames := map[string]bool{}
+	deviceToNodeName := map[string]string{}
+	for _, mapping := range localDiskConf.ExplicitDeviceMap {
+		if mapping.NodeName == "" {
+			return nil, errs.ValidationErrorf(
+				"Invalid local disk config: explicitDeviceMap: Missing nodeName",
+			)
+		}
+		if nodeNames[mapping.NodeName] {
+			return nil, errs.ValidationErrorf(
+				"Invalid local disk config: explicitDeviceMap: Duplicate node %q",
+				mapping.NodeName,
+			)
+		}
+		nodeNames[mapping.NodeName] = true
+		if len(mapping.BlockDeviceNames) == 0 {
+			return nil, errs.ValidationErrorf(
+				"Invalid local disk config: explicitDeviceMap: Node %q: Missing blockDeviceNames",
+				mapping.NodeName,
+			)
+		}
+		for _, deviceName := range mapping.BlockDeviceNames {
+			if nodeName, found := deviceToNodeName[deviceName]; found {
+				return nil, errs.ValidationErrorf(
+					"Invalid local disk config: explicitDeviceMap: Block device %q is pinned to nodes %q & %q",
+					deviceName, nodeName, mapping.NodeName,
+				)
+			}
+			deviceToNodeName[deviceName] = mapping.NodeName
+		}
+	}
+	return localDiskConf.ExplicitDeviceMap, nil
+}
+
+// GetLocalDeviceCapacityBounds returns the min & max capacities
+// a local block device should have to participate in building cstor
+// pool instances. Nil is returned for a bound that was not configured.
+func (h *Helper) GetLocalDeviceCapacityBounds() (
+	*resource.Quantity, *resource.Quantity, error,
+) {
+	if h.err != nil {
+		return nil, nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, nil, errs.AsValidationError(
+			errors.Wrapf(err, "Invalid local device capacity"),
+		)
+	}
+	localDiskConf := cstorClusterConfigTyped.Spec.DiskConfig.LocalDiskConfig
+	if localDiskConf == nil {
+		return nil, nil,
+			errors.Errorf(
+				"Can't get device capacity bounds: Nil LocalDiskConfig",
 			)
 	}
-	return localDiskConf.BlockDeviceSelector, nil
+	minCapacity := localDiskConf.MinDeviceCapacity
+	maxCapacity := localDiskConf.MaxDeviceCapacity
+	if minCapacity != nil && minCapacity.Sign() < 0 {
+		return nil, nil, errs.ValidationErrorf(
+			"Invalid local disk config: Negative minDeviceCapacity %q", minCapacity.String(),
+		)
+	}
+	if maxCapacity != nil && maxCapacity.Sign() <= 0 {
+		return nil, nil, errs.ValidationErrorf(
+			"Invalid local disk config: Non positive maxDeviceCapacity %q", maxCapacity.String(),
+		)
+	}
+	if minCapacity != nil && maxCapacity != nil && minCapacity.Cmp(*maxCapacity) > 0 {
+		return nil, nil, errs.ValidationErrorf(
+			"Invalid local disk config: minDeviceCapacity %q is more than maxDeviceCapacity %q",
+			minCapacity.String(), maxCapacity.String(),
+		)
+	}
+	return minCapacity, maxCapacity, nil
+}
+
+// GetReservePerNode returns the raw disk capacity that should be
+// left unclaimed on every node. Nil is returned if no reservation
+// was configured.
+func (h *Helper) GetReservePerNode() (*intstr.IntOrString, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cstorClusterConfigTyped.Spec.DiskConfig.ReservePerNode, nil
+}
+
+// GetWearScorer returns the scorer that ranks the local disks by
+// their wear. Nil is returned if no wear leveling was configured.
+func (h *Helper) GetWearScorer() (*bd.WearScorer, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return bd.NewWearScorer(cstorClusterConfigTyped.Spec.DiskConfig.WearLeveling)
+}
+
+// IsRequireSMARTPass returns true if only those block devices that
+// have passed SMART checks can participate in building cstor pools
+func (h *Helper) IsRequireSMARTPass() (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	require, _, err := unstructured.NestedBool(
+		h.ClusterConfig.Object,
+		"spec", "diskConfig", "healthCheck", "requireSMARTPass",
+	)
+	if err != nil {
+		return false, errors.Wrapf(err, "Invalid health check")
+	}
+	return require, nil
+}
+
+// GetDriftPolicy returns the drift policy of this CStorClusterConfig
+// instance. Default policy is returned if none was configured.
+func (h *Helper) GetDriftPolicy() (types.DriftPolicy, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	policy, _, err := unstructured.NestedString(
+		h.ClusterConfig.Object, "spec", "driftPolicy",
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid drift policy")
+	}
+	if policy == "" {
+		return types.DriftPolicyDefault, nil
+	}
+	if !types.SupportedDriftPolicies[types.DriftPolicy(policy)] {
+		return "", errs.ValidationErrorf("Invalid drift policy %q", policy)
+	}
+	return types.DriftPolicy(policy), nil
+}
+
+// GetOutputMode returns the output mode of this CStorClusterConfig
+// instance. Default mode is returned if none was configured.
+func (h *Helper) GetOutputMode() (types.OutputMode, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	mode, _, err := unstructured.NestedString(
+		h.ClusterConfig.Object, "spec", "outputMode",
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid output mode")
+	}
+	if mode == "" {
+		return types.OutputModeDefault, nil
+	}
+	if !types.SupportedOutputModes[types.OutputMode(mode)] {
+		return "", errs.ValidationErrorf("Invalid output mode %q", mode)
+	}
+	return types.OutputMode(mode), nil
+}
+
+// GetIgnoreFields returns the field paths of CStorPoolCluster that
+// are ignored from reconciliation
+func (h *Helper) GetIgnoreFields() ([]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	paths, _, err := unstructured.NestedStringSlice(
+		h.ClusterConfig.Object, "spec", "reconcile", "ignoreFields",
+	)
+	if err != nil {
+		return nil, errs.AsValidationError(
+			errors.Wrapf(err, "Invalid reconcile: ignoreFields"),
+		)
+	}
+	err = ignorefields.Validate(paths)
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// GetDevicePreference returns the order in which the matching local
+// disks get consumed. Default preference is returned if none was
+// configured.
+func (h *Helper) GetDevicePreference() (types.DevicePreference, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	preference, _, err := unstructured.NestedString(
+		h.ClusterConfig.Object, "spec", "diskConfig", "devicePreference",
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid device preference")
+	}
+	if preference == "" {
+		return types.DevicePreferenceDefault, nil
+	}
+	if !types.SupportedDevicePreferences[types.DevicePreference(preference)] {
+		return "", errs.ValidationErrorf("Invalid device preference %q", preference)
+	}
+	return types.DevicePreference(preference), nil
+}
+
+// GetPartialPlacementPolicy returns the handling of nodes whose block
+// devices do not satisfy the raid type. Default policy is returned if
+// none was configured.
+func (h *Helper) GetPartialPlacementPolicy() (types.PartialPlacementPolicy, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	policy, _, err := unstructured.NestedString(
+		h.ClusterConfig.Object, "spec", "poolConfig", "partialPlacementPolicy",
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid partial placement policy")
+	}
+	if policy == "" {
+		return types.PartialPlacementPolicyDefault, nil
+	}
+	if !types.SupportedPartialPlacementPolicies[types.PartialPlacementPolicy(policy)] {
+		return "", errs.ValidationErrorf("Invalid partial placement policy %q", policy)
+	}
+	return types.PartialPlacementPolicy(policy), nil
+}
+
+// GetMinReadyNodesBeforeCreate returns the number of nodes that need
+// to have all their block devices ready before the CStorPoolCluster
+// is created. Zero is returned if none was configured.
+func (h *Helper) GetMinReadyNodesBeforeCreate() (int64, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return 0, err
+	}
+	count := cstorClusterConfigTyped.Spec.PoolConfig.MinReadyNodesBeforeCreate
+	if count < 0 {
+		return 0, errs.ValidationErrorf("Invalid min ready nodes before create %d", count)
+	}
+	return count, nil
+}
+
+// GetRemediation returns the remediation of unhealthy pool instances
+// of this CStorClusterConfig instance with its defaults resolved.
+// Nil is returned if no remediation was configured.
+func (h *Helper) GetRemediation() (*types.Remediation, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, errs.AsValidationError(
+			errors.Wrapf(err, "Invalid remediation"),
+		)
+	}
+	if cstorClusterConfigTyped.Spec.Remediation == nil {
+		return nil, nil
+	}
+	remediation := cstorClusterConfigTyped.Spec.Remediation.DeepCopy()
+	if remediation.Timeout == nil {
+		remediation.Timeout = types.DefaultRemediationTimeout.DeepCopy()
+	}
+	if remediation.Timeout.Duration <= 0 {
+		return nil, errs.ValidationErrorf(
+			"Invalid remediation timeout %q", remediation.Timeout.Duration,
+		)
+	}
+	if remediation.Policy == "" {
+		remediation.Policy = types.RemediationPolicyDefault
+	}
+	if !types.SupportedRemediationPolicies[remediation.Policy] {
+		return nil, errs.ValidationErrorf(
+			"Invalid remediation policy %q", remediation.Policy,
+		)
+	}
+	return remediation, nil
+}
+
+// GetRebalance returns the rebalance options of the pool instances
+// of this CStorClusterConfig instance with its defaults resolved.
+// Nil is returned if no rebalance was configured.
+func (h *Helper) GetRebalance() (*types.Rebalance, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, errs.AsValidationError(
+			errors.Wrapf(err, "Invalid rebalance"),
+		)
+	}
+	if cstorClusterConfigTyped.Spec.Rebalance == nil {
+		return nil, nil
+	}
+	rebalance := cstorClusterConfigTyped.Spec.Rebalance.DeepCopy()
+	if rebalance.MaxRAIDGroupSkew == 0 {
+		rebalance.MaxRAIDGroupSkew = types.DefaultMaxRAIDGroupSkew
+	}
+	if rebalance.MaxRAIDGroupSkew < 0 {
+		return nil, errs.ValidationErrorf(
+			"Invalid rebalance max raid group skew %d", rebalance.MaxRAIDGroupSkew,
+		)
+	}
+	if rebalance.Policy == "" {
+		rebalance.Policy = types.RebalancePolicyDefault
+	}
+	if !types.SupportedRebalancePolicies[rebalance.Policy] {
+		return nil, errs.ValidationErrorf(
+			"Invalid rebalance policy %q", rebalance.Policy,
+		)
+	}
+	return rebalance, nil
+}
+
+// GetTargetNamespace returns the namespace where the children of
+// this CStorClusterConfig instance should be created
+func (h *Helper) GetTargetNamespace() (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	namespace, _, err := unstructured.NestedString(
+		h.ClusterConfig.Object, "spec", "targetNamespace",
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Invalid target namespace")
+	}
+	if namespace == "" {
+		return types.DefaultTargetNamespace, nil
+	}
+	if msgs := validation.IsDNS1123Label(namespace); len(msgs) != 0 {
+		return "", errs.ValidationErrorf(
+			"Invalid target namespace %q: %s", namespace, strings.Join(msgs, ", "),
+		)
+	}
+	return namespace, nil
+}
+
+// GetTargetNamespaceOrObserved returns the namespace of the given
+// observed child if available. Otherwise target namespace of this
+// CStorClusterConfig instance is returned.
+//
+// NOTE:
+//	Observed children are never moved to a different namespace
+// since this would delete & re-create them
+func (h *Helper) GetTargetNamespaceOrObserved(
+	observed *unstructured.Unstructured,
+) (string, error) {
+	if observed != nil && observed.GetNamespace() != "" {
+		return observed.GetNamespace(), nil
+	}
+	return h.GetTargetNamespace()
+}
+
+// IsPersistDefaults returns true if the resolved defaults should be
+// written back to the spec of this CStorClusterConfig instance
+func (h *Helper) IsPersistDefaults() (bool, error) {
+	if h.err != nil {
+		return false, h.err
+	}
+	val, found := h.ClusterConfig.GetAnnotations()[types.AnnKeyCStorClusterConfigPersistDefaults]
+	if !found || val == "" {
+		return false, nil
+	}
+	isPersist, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, errs.AsValidationError(errors.Wrapf(
+			err,
+			"Invalid annotation %q",
+			types.AnnKeyCStorClusterConfigPersistDefaults,
+		))
+	}
+	return isPersist, nil
+}
+
+// GetRAIDGroupConfig returns the raid group config of this
+// CStorClusterConfig instance after validating it against the raid
+// type. Nil is returned if no raid group config was set.
+func (h *Helper) GetRAIDGroupConfig() (*types.PoolRAIDGroupConfig, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, err
+	}
+	config := cstorClusterConfigTyped.Spec.PoolConfig.RAIDGroupConfig
+	if config == nil {
+		return nil, nil
+	}
+	raid, err := h.GetRAIDTypeOrCached()
+	if err != nil {
+		return nil, err
+	}
+	err = types.ValidateRAIDGroupConfig(raid, config)
+	if err != nil {
+		return nil, errs.AsValidationError(err)
+	}
+	return config, nil
 }
 
 // IsDiskCountMatchRAIDType returns true if given count
@@ -120,16 +751,16 @@ func (h *Helper) IsDiskCountMatchRAIDType(count int64) (bool, error) {
 		return false, err
 	}
 	h.raidType = raid
-	// check if remainder is zero
-	if count%types.RAIDTypeToDefaultMinDiskCount[h.raidType] == 0 {
-		return true, nil
+	groupConfig, err := h.GetRAIDGroupConfig()
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return types.IsDiskCountValidForRAIDGroup(h.raidType, groupConfig, count), nil
 }
 
 func (h *Helper) validateRAIDType(raidType types.PoolRAIDType) error {
 	if !types.SupportedRAIDTypes[raidType] {
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"Invalid RAID type %q",
 			raidType,
 		)
@@ -174,3 +805,167 @@ func (h *Helper) GetRAIDTypeOrCached() (types.PoolRAIDType, error) {
 	}
 	return h.GetRAIDType()
 }
+
+// GetZFSProperties returns the ZFS properties to be set against the
+// pools of this CStorClusterConfig instance. Nil is returned if no
+// properties were configured.
+func (h *Helper) GetZFSProperties() (map[string]string, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	properties, _, err := unstructured.NestedStringMap(
+		h.ClusterConfig.Object, "spec", "poolConfig", "zfsProperties",
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid zfs properties")
+	}
+	err = zfsproperty.Validate(properties)
+	if err != nil {
+		return nil, err
+	}
+	if len(properties) == 0 {
+		return nil, nil
+	}
+	return properties, nil
+}
+
+// GetNodeSelectorKey returns the node label used by the pools of this
+// CStorClusterConfig instance to select their nodes. Default key is
+// returned if no key was configured.
+func (h *Helper) GetNodeSelectorKey() (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	key, _, err := unstructured.NestedString(
+		h.ClusterConfig.Object, "spec", "poolConfig", "nodeSelectorKey",
+	)
+	if err != nil {
+		return "", errs.AsValidationError(
+			errors.Wrapf(err, "Invalid node selector key"),
+		)
+	}
+	if key == "" {
+		return types.DefaultNodeSelectorKey, nil
+	}
+	if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+		return "", errs.ValidationErrorf(
+			"Invalid pool config: nodeSelectorKey %q: %s",
+			key, strings.Join(msgs, ", "),
+		)
+	}
+	return key, nil
+}
+
+// GetHybridDiskNodeOverrides returns the node overrides of the disk
+// config & true if this CStorClusterConfig instance builds the pools
+// of some nodes from local disks & the pools of other nodes from
+// external disks
+func (h *Helper) GetHybridDiskNodeOverrides() ([]types.DiskNodeOverride, bool, error) {
+	if h.err != nil {
+		return nil, false, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, false, errs.AsValidationError(
+			errors.Wrapf(err, "Invalid disk config"),
+		)
+	}
+	diskConfig := cstorClusterConfigTyped.Spec.DiskConfig
+	err = disksource.Validate(diskConfig)
+	if err != nil {
+		return nil, false, err
+	}
+	return diskConfig.NodeOverrides, disksource.IsHybrid(diskConfig), nil
+}
+
+// GetChildMetadata returns the labels & annotations that should be
+// propagated to the children of this CStorClusterConfig instance
+func (h *Helper) GetChildMetadata() (*types.ChildMetadata, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cstorClusterConfigTyped.Spec.ChildMetadata, nil
+}
+
+// GetOverlays returns the validated patches that should be applied
+// to the children of this CStorClusterConfig instance
+func (h *Helper) GetOverlays() ([]types.Overlay, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = overlay.Validate(cstorClusterConfigTyped.Spec.Overlays)
+	if err != nil {
+		return nil, err
+	}
+	return cstorClusterConfigTyped.Spec.Overlays, nil
+}
+
+// GetNaming returns the options to derive the names of the children
+// of this CStorClusterConfig instance
+func (h *Helper) GetNaming() (*types.Naming, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	var cstorClusterConfigTyped = types.CStorClusterConfig{}
+	err := unstruct.UnstructToTyped(
+		h.ClusterConfig,
+		&cstorClusterConfigTyped,
+	)
+	if err != nil {
+		return nil, err
+	}
+	err = naming.Validate(cstorClusterConfigTyped.Spec.Naming)
+	if err != nil {
+		return nil, err
+	}
+	return cstorClusterConfigTyped.Spec.Naming, nil
+}
+
+// GetChildName returns the name of the CStorPoolCluster &
+// StorageClass of this CStorClusterConfig instance. Given parts
+// e.g. failure domain are added to the name.
+func (h *Helper) GetChildName(parts ...string) (string, error) {
+	options, err := h.GetNaming()
+	if err != nil {
+		return "", err
+	}
+	return naming.Name(
+		h.ClusterConfig.GetNamespace(), h.ClusterConfig.GetName(), options, parts...,
+	), nil
+}
+
+// GetChildNameOrObserved returns the name of the given observed
+// child if available. Otherwise the name derived from the naming
+// options of this CStorClusterConfig instance is returned.
+//
+// NOTE:
+//	Observed children are never renamed since this would delete &
+// re-create them
+func (h *Helper) GetChildNameOrObserved(
+	observed *unstructured.Unstructured, parts ...string,
+) (string, error) {
+	if observed != nil && observed.GetName() != "" {
+		return observed.GetName(), nil
+	}
+	return h.GetChildName(parts...)
+}
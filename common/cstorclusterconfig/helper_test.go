@@ -22,6 +22,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"mayadata.io/cstorpoolauto/types"
 	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
 )
@@ -153,6 +154,54 @@ func TestHelperIsLocalDisk(t *testing.T) {
 			isLocal: true,
 			isErr:   false,
 		},
+		"cstor cluster config && valid kind && empty local disk": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{},
+						},
+					},
+				},
+			},
+			isLocal: false,
+			isErr:   true,
+		},
+		"cstor cluster config && valid kind && local disk with 0 select terms": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{
+								"blockDeviceSelector": map[string]interface{}{
+									"selectorTerms": []interface{}{},
+								},
+							},
+						},
+					},
+				},
+			},
+			isLocal: false,
+			isErr:   true,
+		},
+		"cstor cluster config && valid kind && local disk with select all": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{
+								"selectAll": true,
+							},
+						},
+					},
+				},
+			},
+			isLocal: true,
+			isErr:   false,
+		},
 	}
 	for name, mock := range tests {
 		name := name
@@ -744,19 +793,19 @@ func TestHelperGetRAIDTypeOrCached(t *testing.T) {
 func TestHelperGetLocalDiskSelector(t *testing.T) {
 	var tests = map[string]struct {
 		cstorClusterConfig *unstructured.Unstructured
-		expectSelector     metac.ResourceSelector
+		expectSelector     types.BlockDeviceSelector
 		isErr              bool
 	}{
 		"nil cstor cluster config": {
 			cstorClusterConfig: nil,
-			expectSelector:     nilselector,
+			expectSelector:     nilBlockDeviceSelector,
 			isErr:              true,
 		},
 		"nil cstor cluster config object": {
 			cstorClusterConfig: &unstructured.Unstructured{
 				Object: nil,
 			},
-			expectSelector: nilselector,
+			expectSelector: nilBlockDeviceSelector,
 			isErr:          true,
 		},
 		"invalid cstor cluster config": {
@@ -765,7 +814,7 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					"kind": "Junk",
 				},
 			},
-			expectSelector: nilselector,
+			expectSelector: nilBlockDeviceSelector,
 			isErr:          true,
 		},
 		"cstor cluster config with valid kind": {
@@ -774,7 +823,7 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					"kind": string(types.KindCStorClusterConfig),
 				},
 			},
-			expectSelector: nilselector,
+			expectSelector: nilBlockDeviceSelector,
 			isErr:          true,
 		},
 		"cstor cluster config && valid kind && remote disk": {
@@ -788,7 +837,7 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: nilselector,
+			expectSelector: nilBlockDeviceSelector,
 			isErr:          true,
 		},
 		"cstor cluster config && valid kind && nil local disk": {
@@ -802,7 +851,7 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: nilselector,
+			expectSelector: nilBlockDeviceSelector,
 			isErr:          true,
 		},
 		"cstor cluster config && valid kind && nil selector terms": {
@@ -820,7 +869,7 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: metac.ResourceSelector{
+			expectSelector: types.BlockDeviceSelector{
 				SelectorTerms: nil,
 			},
 			isErr: false,
@@ -840,8 +889,8 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: metac.ResourceSelector{
-				SelectorTerms: []*metac.SelectorTerm{},
+			expectSelector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{},
 			},
 			isErr: false,
 		},
@@ -862,8 +911,8 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: metac.ResourceSelector{
-				SelectorTerms: []*metac.SelectorTerm{{}},
+			expectSelector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{{}},
 			},
 			isErr: false,
 		},
@@ -886,10 +935,12 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: metac.ResourceSelector{
-				SelectorTerms: []*metac.SelectorTerm{
-					&metac.SelectorTerm{
-						MatchSlice: map[string][]string{},
+			expectSelector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{
+					&types.BlockDeviceSelectorTerm{
+						SelectorTerm: metac.SelectorTerm{
+							MatchSlice: map[string][]string{},
+						},
 					},
 				},
 			},
@@ -914,10 +965,12 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: metac.ResourceSelector{
-				SelectorTerms: []*metac.SelectorTerm{
-					&metac.SelectorTerm{
-						MatchSlice: nil,
+			expectSelector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{
+					&types.BlockDeviceSelectorTerm{
+						SelectorTerm: metac.SelectorTerm{
+							MatchSlice: nil,
+						},
 					},
 				},
 			},
@@ -944,11 +997,13 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: metac.ResourceSelector{
-				SelectorTerms: []*metac.SelectorTerm{
-					&metac.SelectorTerm{
-						MatchSlice: map[string][]string{
-							"pool.items": []string{"p0"},
+			expectSelector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{
+					&types.BlockDeviceSelectorTerm{
+						SelectorTerm: metac.SelectorTerm{
+							MatchSlice: map[string][]string{
+								"pool.items": []string{"p0"},
+							},
 						},
 					},
 				},
@@ -980,14 +1035,16 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 					},
 				},
 			},
-			expectSelector: metac.ResourceSelector{
-				SelectorTerms: []*metac.SelectorTerm{
-					&metac.SelectorTerm{
-						MatchSliceExpressions: []metac.SliceSelectorRequirement{
-							metac.SliceSelectorRequirement{
-								Key:      "pool.items",
-								Operator: metac.SliceSelectorOpEquals,
-								Values:   []string{"p0"},
+			expectSelector: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{
+					&types.BlockDeviceSelectorTerm{
+						SelectorTerm: metac.SelectorTerm{
+							MatchSliceExpressions: []metac.SliceSelectorRequirement{
+								metac.SliceSelectorRequirement{
+									Key:      "pool.items",
+									Operator: metac.SliceSelectorOpEquals,
+									Values:   []string{"p0"},
+								},
 							},
 						},
 					},
@@ -995,6 +1052,74 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 			},
 			isErr: false,
 		},
+		"cstor cluster config && valid kind && select all": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{
+								"selectAll": true,
+							},
+						},
+					},
+				},
+			},
+			expectSelector: DefaultLocalBlockDeviceSelector,
+			isErr:          false,
+		},
+		"cstor cluster config && valid kind && invalid match expression": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{
+								"blockDeviceSelector": map[string]interface{}{
+									"selectorTerms": []interface{}{
+										map[string]interface{}{
+											"matchExpressions": []interface{}{
+												map[string]interface{}{
+													"key":      "spec.details.deviceType",
+													"operator": "In",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectSelector: nilBlockDeviceSelector,
+			isErr:          true,
+		},
+		"cstor cluster config && valid kind && select all && selector terms": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{
+								"selectAll": true,
+								"blockDeviceSelector": map[string]interface{}{
+									"selectorTerms": []interface{}{
+										map[string]interface{}{
+											"matchLabels": map[string]interface{}{
+												"kubernetes.io/hostname": "node-1",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectSelector: nilBlockDeviceSelector,
+			isErr:          true,
+		},
 	}
 	for name, mock := range tests {
 		name := name
@@ -1016,3 +1141,1230 @@ func TestHelperGetLocalDiskSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestHelperGetLocalBlockDeviceExclude(t *testing.T) {
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectExclude      types.BlockDeviceSelector
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			cstorClusterConfig: nil,
+			expectExclude:      nilBlockDeviceSelector,
+			isErr:              true,
+		},
+		"cstor cluster config && valid kind && nil local disk": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": nil,
+						},
+					},
+				},
+			},
+			expectExclude: nilBlockDeviceSelector,
+			isErr:         true,
+		},
+		"cstor cluster config && valid kind && no exclude": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{
+								"blockDeviceSelector": map[string]interface{}{},
+							},
+						},
+					},
+				},
+			},
+			expectExclude: nilBlockDeviceSelector,
+			isErr:         false,
+		},
+		"cstor cluster config && valid kind && 1 matchlabels in exclude terms": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"local": map[string]interface{}{
+								"blockDeviceExclude": map[string]interface{}{
+									"selectorTerms": []interface{}{
+										map[string]interface{}{
+											"matchLabels": map[string]interface{}{
+												"reserved": "true",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectExclude: types.BlockDeviceSelector{
+				SelectorTerms: []*types.BlockDeviceSelectorTerm{
+					&types.BlockDeviceSelectorTerm{
+						SelectorTerm: metac.SelectorTerm{
+							MatchLabels: map[string]string{
+								"reserved": "true",
+							},
+						},
+					},
+				},
+			},
+			isErr: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			h := NewHelper(mock.cstorClusterConfig)
+			got, err := h.GetLocalBlockDeviceExclude()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if !reflect.DeepEqual(got, mock.expectExclude) {
+				t.Fatalf("Expected no diff got\n%s",
+					cmp.Diff(got, mock.expectExclude),
+				)
+			}
+		})
+	}
+}
+
+func TestHelperGetReservePerNode(t *testing.T) {
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectReserve      *intstr.IntOrString
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			cstorClusterConfig: nil,
+			isErr:              true,
+		},
+		"cstor cluster config && no reserve": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{},
+					},
+				},
+			},
+			isErr: false,
+		},
+		"cstor cluster config && percentage reserve": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"reservePerNode": "20%",
+						},
+					},
+				},
+			},
+			expectReserve: &intstr.IntOrString{Type: intstr.String, StrVal: "20%"},
+			isErr:         false,
+		},
+		"cstor cluster config && bytes reserve": {
+			cstorClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{
+							"reservePerNode": int64(1024),
+						},
+					},
+				},
+			},
+			expectReserve: &intstr.IntOrString{Type: intstr.Int, IntVal: 1024},
+			isErr:         false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			h := NewHelper(mock.cstorClusterConfig)
+			got, err := h.GetReservePerNode()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if !reflect.DeepEqual(got, mock.expectReserve) {
+				t.Fatalf("Expected no diff got\n%s",
+					cmp.Diff(got, mock.expectReserve),
+				)
+			}
+		})
+	}
+}
+
+func TestHelperGetLocalDeviceCapacityBounds(t *testing.T) {
+	newConfig := func(local map[string]interface{}) *unstructured.Unstructured {
+		diskConfig := map[string]interface{}{}
+		if local != nil {
+			diskConfig["local"] = local
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": diskConfig,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectMin          string
+		expectMax          string
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			cstorClusterConfig: nil,
+			isErr:              true,
+		},
+		"cstor cluster config && nil local disk": {
+			cstorClusterConfig: newConfig(nil),
+			isErr:              true,
+		},
+		"cstor cluster config && no bounds": {
+			cstorClusterConfig: newConfig(map[string]interface{}{}),
+		},
+		"cstor cluster config && min bound": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"minDeviceCapacity": "10Gi",
+			}),
+			expectMin: "10Gi",
+		},
+		"cstor cluster config && min & max bounds": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"minDeviceCapacity": "10Gi",
+				"maxDeviceCapacity": "2Ti",
+			}),
+			expectMin: "10Gi",
+			expectMax: "2Ti",
+		},
+		"cstor cluster config && min more than max": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"minDeviceCapacity": "2Ti",
+				"maxDeviceCapacity": "10Gi",
+			}),
+			isErr: true,
+		},
+		"cstor cluster config && negative min": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"minDeviceCapacity": "-1Gi",
+			}),
+			isErr: true,
+		},
+		"cstor cluster config && zero max": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"maxDeviceCapacity": "0",
+			}),
+			isErr: true,
+		},
+		"cstor cluster config && invalid min": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"minDeviceCapacity": "junk",
+			}),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			h := NewHelper(mock.cstorClusterConfig)
+			gotMin, gotMax, err := h.GetLocalDeviceCapacityBounds()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotMinStr, gotMaxStr string
+			if gotMin != nil {
+				gotMinStr = gotMin.String()
+			}
+			if gotMax != nil {
+				gotMaxStr = gotMax.String()
+			}
+			if gotMinStr != mock.expectMin || gotMaxStr != mock.expectMax {
+				t.Fatalf(
+					"Expected min %q & max %q got %q & %q",
+					mock.expectMin, mock.expectMax, gotMinStr, gotMaxStr,
+				)
+			}
+		})
+	}
+}
+
+func TestHelperGetDriftPolicy(t *testing.T) {
+	newConfig := func(policy string) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if policy != "" {
+			spec["driftPolicy"] = policy
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": spec,
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectPolicy       types.DriftPolicy
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no drift policy": {
+			cstorClusterConfig: newConfig(""),
+			expectPolicy:       types.DriftPolicyEnforce,
+		},
+		"warn drift policy": {
+			cstorClusterConfig: newConfig("Warn"),
+			expectPolicy:       types.DriftPolicyWarn,
+		},
+		"ignore drift policy": {
+			cstorClusterConfig: newConfig("Ignore"),
+			expectPolicy:       types.DriftPolicyIgnore,
+		},
+		"invalid drift policy": {
+			cstorClusterConfig: newConfig("junk"),
+			isErr:              true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetDriftPolicy()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectPolicy {
+				t.Fatalf("Expected policy %q got %q", mock.expectPolicy, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetOutputMode(t *testing.T) {
+	newConfig := func(mode string) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if mode != "" {
+			spec["outputMode"] = mode
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": spec,
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectMode         types.OutputMode
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no output mode": {
+			cstorClusterConfig: newConfig(""),
+			expectMode:         types.OutputModeApply,
+		},
+		"export output mode": {
+			cstorClusterConfig: newConfig("Export"),
+			expectMode:         types.OutputModeExport,
+		},
+		"invalid output mode": {
+			cstorClusterConfig: newConfig("Print"),
+			isErr:              true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetOutputMode()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectMode {
+				t.Fatalf("Expected mode %q got %q", mock.expectMode, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetDevicePreference(t *testing.T) {
+	newConfig := func(preference string) *unstructured.Unstructured {
+		diskConfig := map[string]interface{}{}
+		if preference != "" {
+			diskConfig["devicePreference"] = preference
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": diskConfig,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectPreference   types.DevicePreference
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no device preference": {
+			cstorClusterConfig: newConfig(""),
+			expectPreference:   types.DevicePreferenceSmallestFirst,
+		},
+		"largest first": {
+			cstorClusterConfig: newConfig("LargestFirst"),
+			expectPreference:   types.DevicePreferenceLargestFirst,
+		},
+		"by path": {
+			cstorClusterConfig: newConfig("ByPath"),
+			expectPreference:   types.DevicePreferenceByPath,
+		},
+		"invalid device preference": {
+			cstorClusterConfig: newConfig("Random"),
+			isErr:              true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetDevicePreference()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectPreference {
+				t.Fatalf("Expected preference %q got %q", mock.expectPreference, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetPartialPlacementPolicy(t *testing.T) {
+	newConfig := func(policy string) *unstructured.Unstructured {
+		poolConfig := map[string]interface{}{}
+		if policy != "" {
+			poolConfig["partialPlacementPolicy"] = policy
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"poolConfig": poolConfig,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectPolicy       types.PartialPlacementPolicy
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no partial placement policy": {
+			cstorClusterConfig: newConfig(""),
+			expectPolicy:       types.PartialPlacementPolicyStrict,
+		},
+		"best effort": {
+			cstorClusterConfig: newConfig("BestEffort"),
+			expectPolicy:       types.PartialPlacementPolicyBestEffort,
+		},
+		"invalid partial placement policy": {
+			cstorClusterConfig: newConfig("Quorum"),
+			isErr:              true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetPartialPlacementPolicy()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectPolicy {
+				t.Fatalf("Expected policy %q got %q", mock.expectPolicy, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetMinReadyNodesBeforeCreate(t *testing.T) {
+	newConfig := func(count interface{}) *unstructured.Unstructured {
+		poolConfig := map[string]interface{}{}
+		if count != nil {
+			poolConfig["minReadyNodesBeforeCreate"] = count
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"poolConfig": poolConfig,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectCount        int64
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no min ready nodes": {
+			cstorClusterConfig: newConfig(nil),
+		},
+		"min ready nodes": {
+			cstorClusterConfig: newConfig(int64(3)),
+			expectCount:        3,
+		},
+		"negative min ready nodes": {
+			cstorClusterConfig: newConfig(int64(-1)),
+			isErr:              true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetMinReadyNodesBeforeCreate()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectCount {
+				t.Fatalf("Expected count %d got %d", mock.expectCount, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetLocalFailureDomainKey(t *testing.T) {
+	newConfig := func(key string) *unstructured.Unstructured {
+		local := map[string]interface{}{}
+		if key != "" {
+			local["failureDomainKey"] = key
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"local": local,
+					},
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectKey          string
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no failure domain key": {
+			cstorClusterConfig: newConfig(""),
+		},
+		"zone label key": {
+			cstorClusterConfig: newConfig("topology.kubernetes.io/zone"),
+			expectKey:          "topology.kubernetes.io/zone",
+		},
+		"invalid label key": {
+			cstorClusterConfig: newConfig("rack id"),
+			isErr:              true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetLocalFailureDomainKey()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectKey {
+				t.Fatalf("Expected key %q got %q", mock.expectKey, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetLocalExplicitDeviceMap(t *testing.T) {
+	newConfig := func(mappings ...interface{}) *unstructured.Unstructured {
+		local := map[string]interface{}{}
+		if len(mappings) != 0 {
+			local["explicitDeviceMap"] = mappings
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"local": local,
+					},
+				},
+			},
+		}
+	}
+	newMapping := func(nodeName string, deviceNames ...interface{}) interface{} {
+		return map[string]interface{}{
+			"nodeName":         nodeName,
+			"blockDeviceNames": deviceNames,
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectMap          []types.ExplicitDeviceMapping
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no explicit device map": {
+			cstorClusterConfig: newConfig(),
+		},
+		"valid explicit device map": {
+			cstorClusterConfig: newConfig(
+				newMapping("node-1", "bd-1", "bd-2"),
+				newMapping("node-2", "bd-3"),
+			),
+			expectMap: []types.ExplicitDeviceMapping{
+				{NodeName: "node-1", BlockDeviceNames: []string{"bd-1", "bd-2"}},
+				{NodeName: "node-2", BlockDeviceNames: []string{"bd-3"}},
+			},
+		},
+		"missing node name": {
+			cstorClusterConfig: newConfig(newMapping("", "bd-1")),
+			isErr:              true,
+		},
+		"missing block device names": {
+			cstorClusterConfig: newConfig(newMapping("node-1")),
+			isErr:              true,
+		},
+		"duplicate node": {
+			cstorClusterConfig: newConfig(
+				newMapping("node-1", "bd-1"),
+				newMapping("node-1", "bd-2"),
+			),
+			isErr: true,
+		},
+		"device pinned to two nodes": {
+			cstorClusterConfig: newConfig(
+				newMapping("node-1", "bd-1"),
+				newMapping("node-2", "bd-1"),
+			),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetLocalExplicitDeviceMap()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if !reflect.DeepEqual(got, mock.expectMap) {
+				t.Fatalf("Expected no diff got\n%s", cmp.Diff(mock.expectMap, got))
+			}
+		})
+	}
+}
+
+func TestHelperGetRebalance(t *testing.T) {
+	newConfig := func(rebalance map[string]interface{}) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if rebalance != nil {
+			spec["rebalance"] = rebalance
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": spec,
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectRebalance    *types.Rebalance
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no rebalance": {
+			cstorClusterConfig: newConfig(nil),
+		},
+		"rebalance with defaults": {
+			cstorClusterConfig: newConfig(map[string]interface{}{}),
+			expectRebalance: &types.Rebalance{
+				MaxRAIDGroupSkew: 1,
+				Policy:           types.RebalancePolicyRecommend,
+			},
+		},
+		"rebalance with refuse policy": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"maxRaidGroupSkew": int64(2),
+				"policy":           "Refuse",
+			}),
+			expectRebalance: &types.Rebalance{
+				MaxRAIDGroupSkew: 2,
+				Policy:           types.RebalancePolicyRefuse,
+			},
+		},
+		"negative max raid group skew": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"maxRaidGroupSkew": int64(-1),
+			}),
+			isErr: true,
+		},
+		"invalid rebalance policy": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"policy": "junk",
+			}),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetRebalance()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expectRebalance, got); diff != "" {
+				t.Fatalf("Expected no rebalance diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHelperGetTargetNamespaceOrObserved(t *testing.T) {
+	newConfig := func(namespace string) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if namespace != "" {
+			spec["targetNamespace"] = namespace
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": spec,
+			},
+		}
+	}
+	newObserved := func(namespace string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":      "my-cspc",
+					"namespace": namespace,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		observed           *unstructured.Unstructured
+		expectNamespace    string
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no target namespace": {
+			cstorClusterConfig: newConfig(""),
+			expectNamespace:    types.DefaultTargetNamespace,
+		},
+		"target namespace": {
+			cstorClusterConfig: newConfig("storage"),
+			expectNamespace:    "storage",
+		},
+		"invalid target namespace": {
+			cstorClusterConfig: newConfig("My_Namespace"),
+			isErr:              true,
+		},
+		"target namespace && observed in a different namespace": {
+			cstorClusterConfig: newConfig("storage"),
+			observed:           newObserved("legacy"),
+			expectNamespace:    "legacy",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).
+				GetTargetNamespaceOrObserved(mock.observed)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectNamespace {
+				t.Fatalf("Expected namespace %q got %q", mock.expectNamespace, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetChildNameOrObserved(t *testing.T) {
+	newConfig := func(naming map[string]interface{}) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if naming != nil {
+			spec["naming"] = naming
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      "my-config",
+					"namespace": "openebs",
+				},
+				"spec": spec,
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		observed           *unstructured.Unstructured
+		expectName         string
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no naming": {
+			cstorClusterConfig: newConfig(nil),
+			expectName:         "my-config",
+		},
+		"naming with prefix & suffix": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"prefix": "team",
+				"suffix": "pool",
+			}),
+			expectName: "team-my-config-pool",
+		},
+		"invalid naming prefix": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"prefix": "Team_A",
+			}),
+			isErr: true,
+		},
+		"naming && observed with a different name": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"prefix": "team",
+			}),
+			observed: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "my-config",
+					},
+				},
+			},
+			expectName: "my-config",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).
+				GetChildNameOrObserved(mock.observed)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectName {
+				t.Fatalf("Expected name %q got %q", mock.expectName, got)
+			}
+		})
+	}
+}
+
+func TestHelperIsPersistDefaults(t *testing.T) {
+	newConfig := func(annotations map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"annotations": annotations,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		isPersist          bool
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no annotation": {
+			cstorClusterConfig: newConfig(nil),
+		},
+		"persist defaults": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				types.AnnKeyCStorClusterConfigPersistDefaults: "true",
+			}),
+			isPersist: true,
+		},
+		"don't persist defaults": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				types.AnnKeyCStorClusterConfigPersistDefaults: "false",
+			}),
+		},
+		"invalid annotation value": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				types.AnnKeyCStorClusterConfigPersistDefaults: "junk",
+			}),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).IsPersistDefaults()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.isPersist {
+				t.Fatalf("Expected persist %t got %t", mock.isPersist, got)
+			}
+		})
+	}
+}
+
+func TestHelperIsRequireSMARTPass(t *testing.T) {
+	newConfig := func(healthCheck interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"healthCheck": healthCheck,
+					},
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		isRequire          bool
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no health check": {
+			cstorClusterConfig: newConfig(nil),
+		},
+		"require smart pass": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"requireSMARTPass": true,
+			}),
+			isRequire: true,
+		},
+		"don't require smart pass": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"requireSMARTPass": false,
+			}),
+		},
+		"invalid require smart pass": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"requireSMARTPass": "junk",
+			}),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).IsRequireSMARTPass()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.isRequire {
+				t.Fatalf("Expected require %t got %t", mock.isRequire, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetZFSProperties(t *testing.T) {
+	newConfig := func(properties map[string]interface{}) *unstructured.Unstructured {
+		poolConfig := map[string]interface{}{}
+		if properties != nil {
+			poolConfig["zfsProperties"] = properties
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"poolConfig": poolConfig,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectProperties   map[string]string
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no zfs properties": {
+			cstorClusterConfig: newConfig(nil),
+		},
+		"whitelisted zfs properties": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"compression": "lz4",
+				"atime":       "off",
+			}),
+			expectProperties: map[string]string{
+				"compression": "lz4",
+				"atime":       "off",
+			},
+		},
+		"unsupported zfs property": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"quota": "10G",
+			}),
+			isErr: true,
+		},
+		"non string zfs property": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"recordsize": int64(131072),
+			}),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetZFSProperties()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expectProperties, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHelperGetNodeSelectorKey(t *testing.T) {
+	newConfig := func(key interface{}) *unstructured.Unstructured {
+		poolConfig := map[string]interface{}{}
+		if key != nil {
+			poolConfig["nodeSelectorKey"] = key
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"poolConfig": poolConfig,
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectKey          string
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"no node selector key": {
+			cstorClusterConfig: newConfig(nil),
+			expectKey:          types.DefaultNodeSelectorKey,
+		},
+		"custom node selector key": {
+			cstorClusterConfig: newConfig("example.com/hostname"),
+			expectKey:          "example.com/hostname",
+		},
+		"invalid node selector key": {
+			cstorClusterConfig: newConfig("example.com/host name"),
+			isErr:              true,
+		},
+		"non string node selector key": {
+			cstorClusterConfig: newConfig(int64(1)),
+			isErr:              true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NewHelper(mock.cstorClusterConfig).GetNodeSelectorKey()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expectKey {
+				t.Fatalf("Expected key %q got %q", mock.expectKey, got)
+			}
+		})
+	}
+}
+
+func TestHelperGetHybridDiskNodeOverrides(t *testing.T) {
+	newConfig := func(diskConfig map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": diskConfig,
+				},
+			},
+		}
+	}
+	external := map[string]interface{}{
+		"csiAttacherName":  "ebs.csi.aws.com",
+		"storageClassName": "gp2",
+	}
+	local := map[string]interface{}{
+		"selectAll": true,
+	}
+	localOverride := map[string]interface{}{
+		"nodeSelector": map[string]interface{}{},
+		"source":       "Local",
+	}
+	var tests = map[string]struct {
+		cstorClusterConfig *unstructured.Unstructured
+		expectOverrides    int
+		expectHybrid       bool
+		isErr              bool
+	}{
+		"nil cstor cluster config": {
+			isErr: true,
+		},
+		"external disk config": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"external": external,
+			}),
+		},
+		"hybrid disk config without overrides": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"external": external,
+				"local":    local,
+			}),
+			expectHybrid: true,
+		},
+		"hybrid disk config with overrides": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"external":      external,
+				"local":         local,
+				"nodeOverrides": []interface{}{localOverride},
+			}),
+			expectOverrides: 1,
+			expectHybrid:    true,
+		},
+		"overrides without hybrid disk config": {
+			cstorClusterConfig: newConfig(map[string]interface{}{
+				"local":         local,
+				"nodeOverrides": []interface{}{localOverride},
+			}),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, isHybrid, err :=
+				NewHelper(mock.cstorClusterConfig).GetHybridDiskNodeOverrides()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if len(got) != mock.expectOverrides {
+				t.Fatalf("Expected %d overrides got %d", mock.expectOverrides, len(got))
+			}
+			if isHybrid != mock.expectHybrid {
+				t.Fatalf("Expected hybrid %t got %t", mock.expectHybrid, isHybrid)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// SetRejectedBlockDevices sets the given rejected block devices
+// against the given status of a CStorClusterConfig. Rejected block
+// devices are removed from the status if none are given.
+func SetRejectedBlockDevices(
+	status map[string]interface{},
+	rejected []types.CStorClusterConfigRejectedBlockDevice,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if len(rejected) == 0 {
+		delete(status, "rejectedBlockDevices")
+		return status, nil
+	}
+	var devices []interface{}
+	for _, device := range rejected {
+		device := device
+		deviceMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&device)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't set rejected block device %q", device.Name,
+			)
+		}
+		devices = append(devices, deviceMap)
+	}
+	status["rejectedBlockDevices"] = devices
+	return status, nil
+}
+
+// SetPoolTopology sets the given pool topology against the given
+// status of a CStorClusterConfig. Pool topology is removed from the
+// status if none is given.
+func SetPoolTopology(
+	status map[string]interface{},
+	topology *types.CStorClusterConfigPoolTopology,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if topology == nil {
+		delete(status, "poolTopology")
+		return status, nil
+	}
+	topologyMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(topology)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err, "Can't set pool topology of %q", topology.CStorPoolClusterName,
+		)
+	}
+	status["poolTopology"] = topologyMap
+	return status, nil
+}
+
+// SetBlockDeviceSelectionReport sets the given block device
+// selection report against the given status of a CStorClusterConfig.
+// Report is removed from the status if none is given.
+func SetBlockDeviceSelectionReport(
+	status map[string]interface{}, report []string,
+) map[string]interface{} {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if len(report) == 0 {
+		delete(status, "blockDeviceSelectionReport")
+		return status
+	}
+	var lines []interface{}
+	for _, line := range report {
+		lines = append(lines, line)
+	}
+	status["blockDeviceSelectionReport"] = lines
+	return status
+}
+
+// SetSkippedNodes sets the given skipped nodes against the given
+// status of a CStorClusterConfig. Skipped nodes are removed from the
+// status if none are given.
+func SetSkippedNodes(
+	status map[string]interface{},
+	skipped []types.CStorClusterConfigSkippedNode,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if len(skipped) == 0 {
+		delete(status, "skippedNodes")
+		return status, nil
+	}
+	var nodes []interface{}
+	for _, node := range skipped {
+		node := node
+		nodeMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&node)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't set skipped node %q", node.HostName,
+			)
+		}
+		nodes = append(nodes, nodeMap)
+	}
+	status["skippedNodes"] = nodes
+	return status, nil
+}
+
+// SetBlockDeviceClaims sets the given state of block device claims
+// against the given status of a CStorClusterConfig. This state is
+// removed from the status if none is given.
+func SetBlockDeviceClaims(
+	status map[string]interface{},
+	claims *types.CStorClusterConfigBlockDeviceClaims,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if claims == nil {
+		delete(status, "blockDeviceClaims")
+		return status, nil
+	}
+	claimsMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(claims)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set block device claims")
+	}
+	status["blockDeviceClaims"] = claimsMap
+	return status, nil
+}
+
+// SetRejectedNodes sets the given rejected nodes against the given
+// status of a CStorClusterConfig. Only the first MaxRejectedNodes
+// nodes are set while their total count is set as well. Rejected
+// nodes are removed from the status if none are given.
+func SetRejectedNodes(
+	status map[string]interface{},
+	rejected []types.CStorClusterConfigRejectedNode,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if len(rejected) == 0 {
+		delete(status, "rejectedNodes")
+		delete(status, "rejectedNodeCount")
+		return status, nil
+	}
+	bounded := rejected
+	if len(bounded) > types.MaxRejectedNodes {
+		bounded = bounded[:types.MaxRejectedNodes]
+	}
+	var nodes []interface{}
+	for _, node := range bounded {
+		node := node
+		nodeMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&node)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't set rejected node %q", node.Name,
+			)
+		}
+		nodes = append(nodes, nodeMap)
+	}
+	status["rejectedNodes"] = nodes
+	status["rejectedNodeCount"] = int64(len(rejected))
+	return status, nil
+}
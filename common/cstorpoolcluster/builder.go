@@ -18,11 +18,13 @@ package cstorpoolcluster
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	stringcommon "mayadata.io/cstorpoolauto/common/string"
+	"mayadata.io/cstorpoolauto/pkg/cspc"
 	"mayadata.io/cstorpoolauto/types"
 )
 
@@ -60,6 +62,19 @@ type Builder struct {
 	// CStorPoolCluster
 	DesiredRAIDType types.PoolRAIDType
 
+	// raid group config that sizes the raid groups of the desired
+	// CStorPoolCluster; raid groups are sized as per the raid type
+	// if this is not set
+	DesiredRAIDGroupConfig *types.PoolRAIDGroupConfig
+
+	// ZFS properties that should be set against every pool of the
+	// desired CStorPoolCluster
+	DesiredZFSProperties map[string]string
+
+	// node label used by the pools of the desired CStorPoolCluster
+	// to select their nodes. Host names are the values of this label.
+	DesiredNodeSelectorKey string
+
 	// ordered and eligible hosts that will participate in formation
 	// of CStorPoolCluster
 	desiredOrderedHostNames []string
@@ -115,11 +130,12 @@ func (b *Builder) setOrderedHostNamesFromDesiredIfNotSet() {
 	// HostNameToDesiredDeviceNames
 	//
 	// NOTE:
-	//	Order is not guaranteed to be same everytime
-	// since this slice is populated from a map
+	//	Hostnames are sorted since this slice is populated
+	// from a map
 	for hostname := range b.HostNameToDesiredDeviceNames {
 		b.OrderedHostNames = append(b.OrderedHostNames, hostname)
 	}
+	sort.Strings(b.OrderedHostNames)
 }
 
 func (b *Builder) setDesiredOrderedHostNamesIfNotSet() {
@@ -137,6 +153,20 @@ func (b *Builder) setDesiredOrderedHostNamesIfNotSet() {
 		b.desiredOrderedHostNames =
 			append(b.desiredOrderedHostNames, hostName)
 	}
+	// desired hostnames that are not ordered yet are appended in
+	// a sorted order
+	isOrdered := map[string]bool{}
+	for _, hostName := range b.OrderedHostNames {
+		isOrdered[hostName] = true
+	}
+	var newHostNames []string
+	for hostName, deviceNames := range b.HostNameToDesiredDeviceNames {
+		if !isOrdered[hostName] && len(deviceNames) != 0 {
+			newHostNames = append(newHostNames, hostName)
+		}
+	}
+	sort.Strings(newHostNames)
+	b.desiredOrderedHostNames = append(b.desiredOrderedHostNames, newHostNames...)
 }
 
 func (b *Builder) mapHostNameToFinalDeviceNamesIfNotSet() {
@@ -181,10 +211,10 @@ func (b *Builder) validate() {
 
 func (b *Builder) validateDiskCount() {
 	var errMsgs []string
-	diskCountByRAIDType :=
-		int(types.RAIDTypeToDefaultMinDiskCount[b.DesiredRAIDType])
 	for hostName, devices := range b.hostNameToFinalDeviceNames {
-		if len(devices)%diskCountByRAIDType != 0 {
+		if !types.IsDiskCountValidForRAIDGroup(
+			b.DesiredRAIDType, b.DesiredRAIDGroupConfig, int64(len(devices)),
+		) {
 			errMsgs = append(errMsgs, fmt.Sprintf(
 				"Invalid disk count %d w.r.t RAID %q on host %q",
 				len(devices), b.DesiredRAIDType, hostName,
@@ -196,90 +226,6 @@ func (b *Builder) validateDiskCount() {
 	}
 }
 
-// buildDesiredRAIDGroupsByHostName builds that fragment of the
-// CStorPoolCluster spec that deals with raid groups.
-// The resulting fragment is based on the given node name.
-func (b *Builder) buildDesiredRAIDGroupsByHostName(nodeName string) []interface{} {
-	// local function to build blockdevice sections per raid group
-	buildBlockDevices := func(deviceNames []string) []interface{} {
-		var blockDevices []interface{}
-		for _, deviceName := range deviceNames {
-			blockDevices = append(
-				blockDevices, map[string]interface{}{
-					"blockDeviceName": deviceName,
-				},
-			)
-		}
-		return blockDevices
-	}
-	// local function to build a raid group
-	buildSingleRAIDGroup := func(deviceNames []string) interface{} {
-		return map[string]interface{}{
-			"blockDevices": buildBlockDevices(deviceNames),
-		}
-	}
-	// local function to build all raid groups within a node/pool
-	buildAllRAIDGroupsPerHost := func(deviceNames []string) []interface{} {
-		var raidGroupList []interface{}
-		var raidGroup []string
-
-		// Stripe pool implies only one raid group that contains all the disks
-		if b.DesiredRAIDType == types.PoolRAIDTypeStripe {
-			raidGroupList = append(raidGroupList, buildSingleRAIDGroup(deviceNames))
-			return raidGroupList
-		}
-
-		diskCountByRAIDType :=
-			int(types.RAIDTypeToDefaultMinDiskCount[b.DesiredRAIDType])
-		for idx, deviceName := range deviceNames {
-			raidGroup = append(raidGroup, deviceName)
-			// Following logic takes care of distributing disks based
-			// on RAID type.
-			//
-			// NOTE:
-			//	Disks get distributed as follows:
-			// 	- Mirror has 2 disks per raid group
-			//	- RAIDZ has 3 disks per raid group
-			//  - RAIDZ2 has 6 disks per raid group
-			if (idx+1)%diskCountByRAIDType == 0 {
-				raidGroupList = append(raidGroupList, buildSingleRAIDGroup(raidGroup))
-				// reset the raidGroup to make way to build next raidGroup for this pool
-				raidGroup = nil
-			}
-		}
-		return raidGroupList
-	}
-	return buildAllRAIDGroupsPerHost(b.hostNameToFinalDeviceNames[nodeName])
-}
-
-// buildDesiredPoolByHostName builds that fragment of CStorPoolCluster
-// that deals with specifying a single pool instance. The resulting
-// fragment is based on the given node name.
-func (b *Builder) buildDesiredPoolByHostName(hostName string) interface{} {
-	return map[string]interface{}{
-		"nodeSelector": map[string]interface{}{
-			"kubernetes.io/hostname": hostName,
-		},
-		"dataRaidGroups": b.buildDesiredRAIDGroupsByHostName(hostName),
-		"poolConfig": map[string]interface{}{
-			"dataRaidGroupType": string(b.DesiredRAIDType),
-			"thickProvision":    false,
-			"compression":       "off",
-		},
-	}
-}
-
-// buildDesiredPools builds that fragment of CStorPoolCluster
-// that deals with specifying all the desired pool instances.
-func (b *Builder) buildDesiredPools() []interface{} {
-	var pools []interface{}
-	for _, hostName := range b.desiredOrderedHostNames {
-		pool := b.buildDesiredPoolByHostName(hostName)
-		pools = append(pools, pool)
-	}
-	return pools
-}
-
 // BuildDesiredState builds the desired CStorPoolCluster based on
 // observed & desired block device names
 func (b *Builder) BuildDesiredState() (*unstructured.Unstructured, error) {
@@ -291,25 +237,23 @@ func (b *Builder) BuildDesiredState() (*unstructured.Unstructured, error) {
 	if b.err != nil {
 		return nil, b.err
 	}
-	// start constructing the desired state
-	cspc := &unstructured.Unstructured{}
-	cspc.SetUnstructuredContent(map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"name":      b.Name,
-			"namespace": b.Namespace,
-		},
-		"spec": map[string]interface{}{
-			"pools": b.buildDesiredPools(),
-		},
-	})
-	if len(b.DesiredAnnotations) != 0 {
-		cspc.SetAnnotations(b.DesiredAnnotations)
-	}
-	if len(b.DesiredLabels) != 0 {
-		cspc.SetLabels(b.DesiredLabels)
+	// pools follow the order of the desired host names
+	builder := cspc.NewBuilder().
+		WithSchema(cspc.SchemaV1).
+		WithName(b.Name).
+		WithNamespace(b.Namespace).
+		WithAnnotations(b.DesiredAnnotations).
+		WithLabels(b.DesiredLabels).
+		WithRAIDType(b.DesiredRAIDType).
+		WithRAIDGroupDeviceCount(
+			types.GetRAIDGroupDiskCount(b.DesiredRAIDType, b.DesiredRAIDGroupConfig),
+		).
+		WithZFSProperties(b.DesiredZFSProperties).
+		WithNodeSelectorKey(b.DesiredNodeSelectorKey)
+	for _, hostName := range b.desiredOrderedHostNames {
+		builder.
+			WithPool(hostName).
+			WithDevices(b.hostNameToFinalDeviceNames[hostName]...)
 	}
-	// below is the right way to set APIVersion & Kind
-	cspc.SetAPIVersion(string(types.APIVersionCStorOpenEBSV1))
-	cspc.SetKind(string(types.KindCStorPoolCluster))
-	return cspc, nil
+	return builder.Build()
 }
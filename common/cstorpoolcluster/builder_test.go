@@ -24,6 +24,7 @@ import (
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
 )
 
 func TestNewBuilder(t *testing.T) {
@@ -123,6 +124,17 @@ func TestBuilderBuildDesiredState(t *testing.T) {
 			},
 			isErr: true,
 		},
+		"striped mirror with 1 host & 2 disks": {
+			builder: &Builder{
+				Name:            "test",
+				Namespace:       "test",
+				DesiredRAIDType: types.PoolRAIDTypeStripedMirror,
+				HostNameToDesiredDeviceNames: map[string][]string{
+					"node-001": {"bd1", "bd2"},
+				},
+			},
+			isErr: true,
+		},
 		"raidz with 1 host & 1 disks": {
 			builder: &Builder{
 				Name:            "test",
@@ -247,6 +259,63 @@ func TestBuilderBuildDesiredState(t *testing.T) {
 			},
 			isErr: false,
 		},
+		"1 node && striped mirror && 4 desired block devices": {
+			builder: &Builder{
+				Name:            "test",
+				Namespace:       "test",
+				DesiredRAIDType: types.PoolRAIDTypeStripedMirror,
+				HostNameToDesiredDeviceNames: map[string][]string{
+					"node-001": {"bd1", "bd2", "bd3", "bd4"},
+				},
+			},
+			expect: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":       string(types.KindCStorPoolCluster),
+					"apiVersion": string(types.APIVersionCStorOpenEBSV1),
+					"metadata": map[string]interface{}{
+						"name":      "test",
+						"namespace": "test",
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"poolConfig": map[string]interface{}{
+									"dataRaidGroupType": "mirror",
+									"thickProvision":    false,
+									"compression":       "off",
+								},
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-001",
+								},
+								"dataRaidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd1",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd2",
+											},
+										},
+									},
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd3",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd4",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			isErr: false,
+		},
 		"1 node && stripe && 1 new block device && 1 observed cspc device": {
 			builder: &Builder{
 				Name:            "test",
@@ -784,267 +853,64 @@ func TestBuilderBuildDesiredState(t *testing.T) {
 	}
 }
 
-func TestBuildDesiredRAIDGroupsByHostName(t *testing.T) {
+func TestBuilderBuildDesiredStateIsStable(t *testing.T) {
+	getHostNames := func(obj *unstructured.Unstructured) []string {
+		var hostNames []string
+		for _, pool := range unstruct.MustGetNestedSlice(obj, "spec", "pools") {
+			hostName, _, _ := unstructured.NestedString(
+				pool.(map[string]interface{}), "nodeSelector", "kubernetes.io/hostname",
+			)
+			hostNames = append(hostNames, hostName)
+		}
+		return hostNames
+	}
 	var tests = map[string]struct {
-		builder *Builder
-		expect  interface{}
+		orderedHostNames []string
+		expectHostNames  []string
 	}{
-		"stripe with  n disks where n=1 disks": {
-			builder: &Builder{
-				DesiredRAIDType: types.PoolRAIDTypeStripe,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-					},
-				},
-			},
-		},
-		"stripe with  n disks where n>1 disks": {
-			builder: &Builder{
-				DesiredRAIDType: types.PoolRAIDTypeStripe,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1", "bd2", "bd3"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd2",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd3",
-						},
-					},
-				},
-			},
-		},
-		"mirror with  2n disks where n=1 disks": {
-			builder: &Builder{
-				Name:            "test",
-				Namespace:       "test",
-				DesiredRAIDType: types.PoolRAIDTypeMirror,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1", "bd2"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd2",
-						},
-					},
-				},
-			},
+		"without ordered host names": {
+			expectHostNames: []string{"node-1", "node-2", "node-3", "node-4"},
 		},
-		"mirror with  2n disks where n>1 disks": {
-			builder: &Builder{
-				Name:            "test",
-				Namespace:       "test",
-				DesiredRAIDType: types.PoolRAIDTypeMirror,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1", "bd2", "bd3", "bd4"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd2",
-						},
-					},
-				},
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd3",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd4",
-						},
-					},
-				},
-			},
-		},
-		"raidz with 2n+1 disks where n=1 disks": {
-			builder: &Builder{
-				Name:            "test",
-				Namespace:       "test",
-				DesiredRAIDType: types.PoolRAIDTypeRAIDZ,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1", "bd2", "bd3"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd2",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd3",
-						},
-					},
-				},
-			},
-		},
-		"raidz with 2n+1 disks where n>1 disks": {
-			builder: &Builder{
-				Name:            "test",
-				Namespace:       "test",
-				DesiredRAIDType: types.PoolRAIDTypeRAIDZ,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1", "bd2", "bd3", "bd4", "bd5", "bd6"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd2",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd3",
-						},
-					},
-				},
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd4",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd5",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd6",
-						},
-					},
-				},
-			},
-		},
-		"raidz2 with 2n+2 disks where n=2 disks": {
-			builder: &Builder{
-				Name:            "test",
-				Namespace:       "test",
-				DesiredRAIDType: types.PoolRAIDTypeRAIDZ2,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1", "bd2", "bd3", "bd4", "bd5", "bd6"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd2",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd3",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd4",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd5",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd6",
-						},
-					},
-				},
-			},
-		},
-		"raidz2 with 2n+2 disks where n>2 disks": {
-			builder: &Builder{
-				Name:            "test",
-				Namespace:       "test",
-				DesiredRAIDType: types.PoolRAIDTypeRAIDZ2,
-				hostNameToFinalDeviceNames: map[string][]string{
-					"node-001": {"bd1", "bd2", "bd3", "bd4", "bd5", "bd6", "bd7", "bd8", "bd9", "bd10", "bd11", "bd12"},
-				},
-			},
-			expect: []interface{}{
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd1",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd2",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd3",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd4",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd5",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd6",
-						},
-					},
-				},
-				map[string]interface{}{
-					"blockDevices": []interface{}{
-						map[string]interface{}{
-							"blockDeviceName": "bd7",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd8",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd9",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd10",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd11",
-						},
-						map[string]interface{}{
-							"blockDeviceName": "bd12",
-						},
-					},
-				},
-			},
+		"with ordered host names": {
+			orderedHostNames: []string{"node-3", "node-1"},
+			expectHostNames:  []string{"node-3", "node-1", "node-2", "node-4"},
 		},
 	}
 	for name, mock := range tests {
 		name := name
 		mock := mock
 		t.Run(name, func(t *testing.T) {
-			b := mock.builder
-			got := b.buildDesiredRAIDGroupsByHostName("node-001")
-			if !reflect.DeepEqual(got, mock.expect) {
-				t.Fatalf("Expected no diff got\n%s", cmp.Diff(got, mock.expect))
+			var first *unstructured.Unstructured
+			for i := 0; i < 10; i++ {
+				b := &Builder{
+					Name:             "test",
+					Namespace:        "test",
+					DesiredRAIDType:  types.PoolRAIDTypeStripe,
+					OrderedHostNames: append([]string{}, mock.orderedHostNames...),
+					HostNameToDesiredDeviceNames: map[string][]string{
+						"node-4": {"bd4"},
+						"node-2": {"bd2"},
+						"node-3": {"bd3"},
+						"node-1": {"bd1"},
+					},
+				}
+				got, err := b.BuildDesiredState()
+				if err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+				if first == nil {
+					first = got
+					if !reflect.DeepEqual(getHostNames(got), mock.expectHostNames) {
+						t.Fatalf(
+							"Expected host names %v got %v",
+							mock.expectHostNames, getHostNames(got),
+						)
+					}
+					continue
+				}
+				if !reflect.DeepEqual(first, got) {
+					t.Fatalf("Expected same cspc across runs: Run %d", i)
+				}
 			}
 		})
 	}
@@ -23,6 +23,37 @@ import (
 	"mayadata.io/cstorpoolauto/unstruct"
 )
 
+// GetNodeSelectorValue returns the host name of the given pool of a
+// CStorPoolCluster i.e. the value of its node selector
+//
+// NOTE:
+//	A pool selects its node by a single label. This label is
+// kubernetes.io/hostname by default & is configurable per
+// CStorClusterConfig. Hence, the value is read irrespective of the
+// key if the default key is not found.
+func GetNodeSelectorValue(pool *unstructured.Unstructured) (string, error) {
+	selector, _, err := unstructured.NestedStringMap(
+		pool.Object, "spec", "nodeSelector",
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "Can't get node selector")
+	}
+	if value := selector[types.DefaultNodeSelectorKey]; value != "" {
+		return value, nil
+	}
+	if len(selector) != 1 {
+		return "", errors.Errorf(
+			"Can't get node selector: Want 1 label got %d", len(selector),
+		)
+	}
+	for _, value := range selector {
+		if value != "" {
+			return value, nil
+		}
+	}
+	return "", errors.Errorf("Can't get node selector: Empty value")
+}
+
 // Helper exposes utility methods w.r.t BlockDevice unstructured
 // instance
 type Helper struct {
@@ -64,10 +95,7 @@ func (h *Helper) GetOrderedHostNames() ([]string, error) {
 	var allHostNames []string
 	// local function to get the host name
 	getNodeName := func(obj *unstructured.Unstructured) error {
-		hostname, err :=
-			unstruct.GetStringOrError(
-				obj, "spec", "nodeSelector", "kubernetes.io/hostname",
-			)
+		hostname, err := GetNodeSelectorValue(obj)
 		if err != nil {
 			return err
 		}
@@ -116,8 +144,7 @@ func (h *Helper) GroupBlockDeviceNamesByHostName() (map[string][]string, error)
 
 	// local function to get the host name
 	getNodeName := func(obj *unstructured.Unstructured) (err error) {
-		currentNodeName, err =
-			unstruct.GetStringOrError(obj, "spec", "nodeSelector", "kubernetes.io/hostname")
+		currentNodeName, err = GetNodeSelectorValue(obj)
 		return
 	}
 	// local function to get the blockdevice name
@@ -193,3 +220,73 @@ func (h *Helper) GroupBlockDeviceNamesByHostNameOrCached() (map[string][]string,
 	// return the cached copy
 	return h.hostNameToBlockDeviceNames, nil
 }
+
+// GetPoolTopology returns the layout of pools of this
+// CStorPoolCluster in the same order they are found at its specs
+func (h *Helper) GetPoolTopology() (*types.CStorClusterConfigPoolTopology, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	topology := &types.CStorClusterConfigPoolTopology{
+		CStorPoolClusterName: h.CStorPoolCluster.GetName(),
+	}
+	// local function to get the layout of a raid group
+	getRAIDGroup := func(obj *unstructured.Unstructured) error {
+		blockDevices, err := unstruct.GetSliceOrError(obj, "spec", "blockDevices")
+		if err != nil {
+			return err
+		}
+		var raidGroup types.CStorClusterConfigPoolTopologyRAIDGroup
+		err = unstruct.SliceIterator(blockDevices).ForEach(
+			func(obj *unstructured.Unstructured) error {
+				deviceName, err := unstruct.GetStringOrError(obj, "spec", "blockDeviceName")
+				if err != nil {
+					return err
+				}
+				raidGroup.BlockDeviceNames = append(raidGroup.BlockDeviceNames, deviceName)
+				return nil
+			},
+		)
+		if err != nil {
+			return err
+		}
+		last := &topology.Pools[len(topology.Pools)-1]
+		last.RAIDGroups = append(last.RAIDGroups, raidGroup)
+		return nil
+	}
+	// local function to get the layout of a pool
+	getPool := func(obj *unstructured.Unstructured) error {
+		hostName, err := GetNodeSelectorValue(obj)
+		if err != nil {
+			return err
+		}
+		raidGroupType, _, err :=
+			unstructured.NestedString(obj.Object, "spec", "poolConfig", "dataRaidGroupType")
+		if err != nil {
+			return err
+		}
+		topology.Pools = append(topology.Pools, types.CStorClusterConfigPoolTopologyPool{
+			HostName:      hostName,
+			RAIDGroupType: raidGroupType,
+		})
+		raidGroups, err := unstruct.GetSliceOrError(obj, "spec", "dataRaidGroups")
+		if err != nil {
+			return err
+		}
+		// iterate through each raidgroup
+		return unstruct.SliceIterator(raidGroups).ForEach(getRAIDGroup)
+	}
+	// logic starts here
+	pools, err := unstruct.GetSliceOrError(h.CStorPoolCluster, "spec", "pools")
+	if err != nil {
+		return nil, err
+	}
+	err = unstruct.SliceIterator(pools).ForEach(getPool)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err, "Can't get pool topology: CStorPoolCluster %q / %q",
+			h.CStorPoolCluster.GetNamespace(), h.CStorPoolCluster.GetName(),
+		)
+	}
+	return topology, nil
+}
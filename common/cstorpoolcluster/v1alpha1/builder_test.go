@@ -123,6 +123,17 @@ func TestBuilderBuildDesiredState(t *testing.T) {
 			},
 			isErr: true,
 		},
+		"striped mirror with 1 host & 2 disks": {
+			builder: &Builder{
+				Name:            "test",
+				Namespace:       "test",
+				DesiredRAIDType: types.PoolRAIDTypeStripedMirror,
+				HostNameToDesiredDeviceNames: map[string][]string{
+					"node-001": {"bd1", "bd2"},
+				},
+			},
+			isErr: true,
+		},
 		"raidz with 1 host & 1 disks": {
 			builder: &Builder{
 				Name:            "test",
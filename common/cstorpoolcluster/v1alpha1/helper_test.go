@@ -19,6 +19,7 @@ package cstorpoolcluster
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	stringcommon "mayadata.io/cstorpoolauto/common/string"
 	"mayadata.io/cstorpoolauto/types"
@@ -496,3 +497,147 @@ func TestHelperGroupBlockDeviceNamesByHostNameOrCached(t *testing.T) {
 		})
 	}
 }
+
+func TestHelperGetPoolTopology(t *testing.T) {
+	var tests = map[string]struct {
+		obj    *unstructured.Unstructured
+		expect *types.CStorClusterConfigPoolTopology
+		isErr  bool
+	}{
+		"nil cspc": {
+			isErr: true,
+		},
+		"cspc without pools": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorPoolCluster),
+					"metadata": map[string]interface{}{
+						"name": "my-cspc",
+					},
+				},
+			},
+			isErr: true,
+		},
+		"cspc - 2 pools": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorPoolCluster),
+					"metadata": map[string]interface{}{
+						"name": "my-cspc",
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"poolConfig": map[string]interface{}{
+									"defaultRaidGroupType": "mirror",
+								},
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-001",
+								},
+								"raidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd11",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd12",
+											},
+										},
+									},
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd13",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd14",
+											},
+										},
+									},
+								},
+							},
+							map[string]interface{}{
+								"poolConfig": map[string]interface{}{
+									"defaultRaidGroupType": "mirror",
+								},
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-002",
+								},
+								"raidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd21",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd22",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expect: &types.CStorClusterConfigPoolTopology{
+				CStorPoolClusterName: "my-cspc",
+				Pools: []types.CStorClusterConfigPoolTopologyPool{
+					{
+						HostName:      "node-001",
+						RAIDGroupType: "mirror",
+						RAIDGroups: []types.CStorClusterConfigPoolTopologyRAIDGroup{
+							{BlockDeviceNames: []string{"bd11", "bd12"}},
+							{BlockDeviceNames: []string{"bd13", "bd14"}},
+						},
+					},
+					{
+						HostName:      "node-002",
+						RAIDGroupType: "mirror",
+						RAIDGroups: []types.CStorClusterConfigPoolTopologyRAIDGroup{
+							{BlockDeviceNames: []string{"bd21", "bd22"}},
+						},
+					},
+				},
+			},
+		},
+		"cspc - pool without raid groups": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorPoolCluster),
+					"metadata": map[string]interface{}{
+						"name": "my-cspc",
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-001",
+								},
+							},
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			h := NewHelper(mock.obj)
+			got, err := h.GetPoolTopology()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disksource
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	bdcommon "mayadata.io/cstorpoolauto/common/blockdevice"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/features"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// IsHybrid returns true if the given disk config builds the pools
+// of some nodes from local disks & the pools of other nodes from
+// external disks
+func IsHybrid(config types.DiskConfig) bool {
+	return config.LocalDiskConfig != nil && config.ExternalDiskConfig != nil
+}
+
+// Validate returns a validation error if the node overrides of the
+// given disk config are invalid
+//
+// NOTE:
+//	Hybrid config is invalid if its feature gate is disabled
+func Validate(config types.DiskConfig) error {
+	if IsHybrid(config) && !features.Enabled(features.HybridDisks) {
+		return features.NewDisabledError(
+			features.HybridDisks, "Invalid disk config: Hybrid config",
+		)
+	}
+	if len(config.NodeOverrides) != 0 && !IsHybrid(config) {
+		return errs.ValidationErrorf(
+			"Invalid disk config: Node overrides need both local & external configs",
+		)
+	}
+	for idx, override := range config.NodeOverrides {
+		if override.Source != types.DiskSourceLocal &&
+			override.Source != types.DiskSourceExternal {
+			return errs.ValidationErrorf(
+				"Invalid disk config: Node override %d: Unsupported source %q: Supports %q or %q",
+				idx, override.Source, types.DiskSourceLocal, types.DiskSourceExternal,
+			)
+		}
+	}
+	if IsHybrid(config) && config.LocalDiskConfig.FailureDomainKey != "" {
+		return errs.ValidationErrorf(
+			"Invalid disk config: Hybrid config doesn't support failure domain key %q",
+			config.LocalDiskConfig.FailureDomainKey,
+		)
+	}
+	return nil
+}
+
+// Resolver resolves the source of disks of the nodes of a hybrid
+// disk config
+type Resolver struct {
+	Overrides []types.DiskNodeOverride
+
+	// Nodes are used to resolve the node of a block device
+	Nodes []*unstructured.Unstructured
+}
+
+// GetSource returns the source of disks of the given node. First
+// override that matches the node decides its source. Node uses
+// external disks if none of the overrides match.
+func (r Resolver) GetSource(node *unstructured.Unstructured) (types.DiskSource, error) {
+	for _, override := range r.Overrides {
+		if len(override.NodeSelector.SelectorTerms) == 0 {
+			return override.Source, nil
+		}
+		isMatch, err :=
+			unstruct.Selector(override.NodeSelector, node).IsMatchOrError()
+		if err != nil {
+			return "", errors.Wrapf(
+				err, "Failed to resolve disk source: Node %q", node.GetName(),
+			)
+		}
+		if isMatch {
+			return override.Source, nil
+		}
+	}
+	return types.DiskSourceExternal, nil
+}
+
+// SplitNodes returns the given nodes that use local disks followed
+// by the nodes that use external disks
+func (r Resolver) SplitNodes(
+	nodes []*unstructured.Unstructured,
+) (local, external []*unstructured.Unstructured, err error) {
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		source, err := r.GetSource(node)
+		if err != nil {
+			return nil, nil, err
+		}
+		if source == types.DiskSourceLocal {
+			local = append(local, node)
+		} else {
+			external = append(external, node)
+		}
+	}
+	return local, external, nil
+}
+
+// SplitBlockDevices returns the given devices that are candidates
+// for local disk selection followed by the devices that were
+// provisioned for the given CStorClusterPlan
+//
+// NOTE:
+//	Local candidates are the devices of nodes that use local disks
+// & that were not provisioned for any plan. Planned devices are the
+// Active & Claimed or Unclaimed devices of nodes that use external
+// disks. Devices whose node is not observed are considered to be
+// of a node that uses external disks.
+func (r Resolver) SplitBlockDevices(
+	devices []*unstructured.Unstructured, planUID string,
+) (local, planned []*unstructured.Unstructured, err error) {
+	localNodes, _, err := r.SplitNodes(r.Nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	localHostNames := map[string]bool{}
+	for _, node := range localNodes {
+		localHostNames[nodecommon.GetHostName(node)] = true
+	}
+	for _, device := range devices {
+		if device == nil || device.UnstructuredContent() == nil {
+			continue
+		}
+		hostName, _ := bdcommon.GetHostName(*device)
+		// TODO (@amitkumardas):
+		//	We are using labels since there might be a bug
+		// in metac to merge annotations. Use of labels is a
+		// workaround that needs to be changed to annotations
+		// once metac fixes this bug.
+		uid, _ := unstruct.GetValueForKey(
+			device.GetLabels(), types.AnnKeyCStorClusterPlanUID,
+		)
+		if localHostNames[hostName] {
+			if uid == "" {
+				local = append(local, device)
+			}
+			continue
+		}
+		if planUID == "" || uid != planUID || !isPlannedDeviceUsable(device) {
+			continue
+		}
+		planned = append(planned, device)
+	}
+	return local, planned, nil
+}
+
+// isPlannedDeviceUsable returns true if the given provisioned device
+// can be used to build a pool
+func isPlannedDeviceUsable(device *unstructured.Unstructured) bool {
+	state, _, _ := unstructured.NestedString(device.Object, "status", "state")
+	if state != string(types.BlockDeviceActive) {
+		return false
+	}
+	claimState, _, _ :=
+		unstructured.NestedString(device.Object, "status", "claimState")
+	return claimState == string(types.BlockDeviceClaimed) ||
+		claimState == string(types.BlockDeviceUnclaimed)
+}
@@ -0,0 +1,300 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disksource
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/features"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestNode(name string, labels map[string]string) *unstructured.Unstructured {
+	node := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindNode),
+		},
+	}
+	node.SetName(name)
+	all := map[string]string{"kubernetes.io/hostname": name}
+	for k, v := range labels {
+		all[k] = v
+	}
+	node.SetLabels(all)
+	return node
+}
+
+func newTestDevice(
+	name, hostName, planUID, state, claimState string,
+) *unstructured.Unstructured {
+	device := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"status": map[string]interface{}{
+				"state":      state,
+				"claimState": claimState,
+			},
+		},
+	}
+	device.SetName(name)
+	labels := map[string]string{"kubernetes.io/hostname": hostName}
+	if planUID != "" {
+		labels[types.AnnKeyCStorClusterPlanUID] = planUID
+	}
+	device.SetLabels(labels)
+	return device
+}
+
+var nvmeOverride = types.DiskNodeOverride{
+	NodeSelector: metac.ResourceSelector{
+		SelectorTerms: []*metac.SelectorTerm{
+			&metac.SelectorTerm{
+				MatchLabels: map[string]string{
+					"disk-type": "nvme",
+				},
+			},
+		},
+	},
+	Source: types.DiskSourceLocal,
+}
+
+func TestValidate(t *testing.T) {
+	var tests = map[string]struct {
+		config       types.DiskConfig
+		featureGates string
+		isErr        bool
+	}{
+		"local config": {
+			config: types.DiskConfig{
+				LocalDiskConfig: &types.LocalDiskConfig{SelectAll: true},
+			},
+		},
+		"hybrid config with overrides": {
+			config: types.DiskConfig{
+				LocalDiskConfig:    &types.LocalDiskConfig{SelectAll: true},
+				ExternalDiskConfig: &types.ExternalDiskConfig{},
+				NodeOverrides:      []types.DiskNodeOverride{nvmeOverride},
+			},
+		},
+		"hybrid config with its feature disabled": {
+			config: types.DiskConfig{
+				LocalDiskConfig:    &types.LocalDiskConfig{SelectAll: true},
+				ExternalDiskConfig: &types.ExternalDiskConfig{},
+				NodeOverrides:      []types.DiskNodeOverride{nvmeOverride},
+			},
+			featureGates: "HybridDisks=false",
+			isErr:        true,
+		},
+		"local config with hybrid feature disabled": {
+			config: types.DiskConfig{
+				LocalDiskConfig: &types.LocalDiskConfig{SelectAll: true},
+			},
+			featureGates: "HybridDisks=false",
+		},
+		"overrides without hybrid config": {
+			config: types.DiskConfig{
+				ExternalDiskConfig: &types.ExternalDiskConfig{},
+				NodeOverrides:      []types.DiskNodeOverride{nvmeOverride},
+			},
+			isErr: true,
+		},
+		"override with unsupported source": {
+			config: types.DiskConfig{
+				LocalDiskConfig:    &types.LocalDiskConfig{SelectAll: true},
+				ExternalDiskConfig: &types.ExternalDiskConfig{},
+				NodeOverrides: []types.DiskNodeOverride{
+					{Source: "Cloud"},
+				},
+			},
+			isErr: true,
+		},
+		"hybrid config with failure domain key": {
+			config: types.DiskConfig{
+				LocalDiskConfig: &types.LocalDiskConfig{
+					SelectAll:        true,
+					FailureDomainKey: "rack",
+				},
+				ExternalDiskConfig: &types.ExternalDiskConfig{},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := features.SetGates(mock.featureGates)
+			if err != nil {
+				t.Fatalf("Can't set feature gates: %+v", err)
+			}
+			defer features.SetGates("")
+			err = Validate(mock.config)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if mock.isErr && errs.TypeOf(err) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", err)
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestResolverGetSource(t *testing.T) {
+	nvmeNode := newTestNode("node-1", map[string]string{"disk-type": "nvme"})
+	otherNode := newTestNode("node-2", nil)
+	var tests = map[string]struct {
+		overrides []types.DiskNodeOverride
+		node      *unstructured.Unstructured
+		expect    types.DiskSource
+	}{
+		"no overrides": {
+			node:   nvmeNode,
+			expect: types.DiskSourceExternal,
+		},
+		"matching override": {
+			overrides: []types.DiskNodeOverride{nvmeOverride},
+			node:      nvmeNode,
+			expect:    types.DiskSourceLocal,
+		},
+		"non matching override": {
+			overrides: []types.DiskNodeOverride{nvmeOverride},
+			node:      otherNode,
+			expect:    types.DiskSourceExternal,
+		},
+		"empty selector matches all nodes": {
+			overrides: []types.DiskNodeOverride{
+				{Source: types.DiskSourceLocal},
+			},
+			node:   otherNode,
+			expect: types.DiskSourceLocal,
+		},
+		"first matching override wins": {
+			overrides: []types.DiskNodeOverride{
+				{
+					NodeSelector: nvmeOverride.NodeSelector,
+					Source:       types.DiskSourceExternal,
+				},
+				{Source: types.DiskSourceLocal},
+			},
+			node:   nvmeNode,
+			expect: types.DiskSourceExternal,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Resolver{Overrides: mock.overrides}.GetSource(mock.node)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expect {
+				t.Fatalf("Expected source %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestResolverSplitBlockDevices(t *testing.T) {
+	nodes := []*unstructured.Unstructured{
+		newTestNode("node-1", map[string]string{"disk-type": "nvme"}),
+		newTestNode("node-2", nil),
+	}
+	var tests = map[string]struct {
+		devices       []*unstructured.Unstructured
+		planUID       string
+		expectLocal   []string
+		expectPlanned []string
+	}{
+		"no devices": {
+			planUID: "plan-1",
+		},
+		"devices of local & external nodes": {
+			devices: []*unstructured.Unstructured{
+				newTestDevice("bd-1", "node-1", "", "Active", "Unclaimed"),
+				newTestDevice("bd-2", "node-2", "", "Active", "Unclaimed"),
+				newTestDevice("bd-3", "node-2", "plan-1", "Active", "Claimed"),
+			},
+			planUID:       "plan-1",
+			expectLocal:   []string{"bd-1"},
+			expectPlanned: []string{"bd-3"},
+		},
+		"planned devices of local nodes are not used": {
+			devices: []*unstructured.Unstructured{
+				newTestDevice("bd-1", "node-1", "plan-1", "Active", "Claimed"),
+			},
+			planUID: "plan-1",
+		},
+		"devices of other plans are not used": {
+			devices: []*unstructured.Unstructured{
+				newTestDevice("bd-1", "node-2", "plan-2", "Active", "Claimed"),
+			},
+			planUID: "plan-1",
+		},
+		"inactive or released planned devices are not used": {
+			devices: []*unstructured.Unstructured{
+				newTestDevice("bd-1", "node-2", "plan-1", "Inactive", "Claimed"),
+				newTestDevice("bd-2", "node-2", "plan-1", "Active", "Released"),
+				newTestDevice("bd-3", "node-2", "plan-1", "Active", "Unclaimed"),
+			},
+			planUID:       "plan-1",
+			expectPlanned: []string{"bd-3"},
+		},
+		"no planned devices without plan": {
+			devices: []*unstructured.Unstructured{
+				newTestDevice("bd-1", "node-1", "", "Active", "Unclaimed"),
+				newTestDevice("bd-2", "node-2", "", "Active", "Unclaimed"),
+			},
+			expectLocal: []string{"bd-1"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := Resolver{
+				Overrides: []types.DiskNodeOverride{nvmeOverride},
+				Nodes:     nodes,
+			}
+			local, planned, err := r.SplitBlockDevices(mock.devices, mock.planUID)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotLocal, gotPlanned []string
+			for _, device := range local {
+				gotLocal = append(gotLocal, device.GetName())
+			}
+			for _, device := range planned {
+				gotPlanned = append(gotPlanned, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expectLocal, gotLocal); diff != "" {
+				t.Fatalf("Expected no diff in local devices got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectPlanned, gotPlanned); diff != "" {
+				t.Fatalf("Expected no diff in planned devices got\n%s", diff)
+			}
+		})
+	}
+}
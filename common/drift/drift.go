@@ -0,0 +1,294 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	dynamicapply "openebs.io/metac/dynamic/apply"
+
+	cspc "mayadata.io/cstorpoolauto/common/cstorpoolcluster"
+	cspcv1alpha1 "mayadata.io/cstorpoolauto/common/cstorpoolcluster/v1alpha1"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// lastAppliedAnnKeySuffix is the suffix of the annotation that is
+// set by metac against every attachment it applies. This annotation
+// holds the last applied state of the attachment & is prefixed with
+// the UID of the watch.
+const lastAppliedAnnKeySuffix = "/gctl-last-applied"
+
+// Result is the outcome of resolving the drift between the observed
+// & desired CStorPoolCluster
+type Result struct {
+	// CStorPoolCluster that should be applied
+	CStorPoolCluster *unstructured.Unstructured
+
+	// IsDrifted is true if the pools of observed CStorPoolCluster
+	// were edited manually
+	IsDrifted bool
+
+	// Reason explains the drift if any
+	Reason string
+}
+
+// Resolver resolves the drift between the observed & desired
+// CStorPoolCluster based on the drift policy
+//
+// NOTE:
+//	Drift is the difference between the pools of the observed
+// CStorPoolCluster & the pools that were last applied by the watch.
+// Only the block devices of every node are compared. This avoids
+// false drifts due to the defaults set by cstor operator.
+type Resolver struct {
+	Policy types.DriftPolicy
+
+	// UID of the watch that applies the CStorPoolCluster
+	WatchUID string
+
+	ObservedCStorPoolCluster *unstructured.Unstructured
+	DesiredCStorPoolCluster  *unstructured.Unstructured
+}
+
+// Resolve returns the CStorPoolCluster that should be applied
+//
+// NOTE:
+//	Desired CStorPoolCluster is returned as is for Enforce policy.
+// Warn policy retains the observed pools while drift exists & marks
+// the CStorPoolCluster with drift annotation. This annotation makes
+// the drift stick till the observed pools match the desired ones or
+// till someone removes this annotation. Ignore policy always retains
+// the observed pools.
+func (r *Resolver) Resolve() (Result, error) {
+	if r.DesiredCStorPoolCluster == nil {
+		return Result{}, errors.Errorf("Can't resolve drift: Nil desired CStorPoolCluster")
+	}
+	if r.ObservedCStorPoolCluster == nil {
+		// nothing can drift before CStorPoolCluster is created
+		return Result{CStorPoolCluster: r.DesiredCStorPoolCluster}, nil
+	}
+	policy := r.Policy
+	if policy == "" {
+		policy = types.DriftPolicyDefault
+	}
+	switch policy {
+	case types.DriftPolicyEnforce:
+		return r.resolveByEnforce()
+	case types.DriftPolicyWarn:
+		return r.resolveByWarn()
+	case types.DriftPolicyIgnore:
+		return r.resolveByIgnore()
+	default:
+		return Result{}, errors.Errorf("Can't resolve drift: Invalid policy %q", policy)
+	}
+}
+
+func (r *Resolver) resolveByEnforce() (Result, error) {
+	isDrifted, reason, err := r.isLastAppliedDrifted()
+	if err != nil {
+		return Result{}, err
+	}
+	if isDrifted {
+		glog.V(2).Infof(
+			"Will revert drift in CStorPoolCluster %q / %q: %s",
+			r.ObservedCStorPoolCluster.GetNamespace(),
+			r.ObservedCStorPoolCluster.GetName(),
+			reason,
+		)
+	}
+	// manual edits are reverted & hence not reported as a drift
+	return Result{CStorPoolCluster: r.DesiredCStorPoolCluster}, nil
+}
+
+func (r *Resolver) resolveByWarn() (Result, error) {
+	isDrifted, reason, err := r.isLastAppliedDrifted()
+	if err != nil {
+		return Result{}, err
+	}
+	_, isMarked := r.ObservedCStorPoolCluster.GetAnnotations()[types.AnnKeyCStorPoolClusterDriftDetected]
+	if !isDrifted && isMarked {
+		// drift continues till observed pools match the desired pools
+		isDrifted, reason, err = isPoolsDrifted(
+			r.ObservedCStorPoolCluster, r.DesiredCStorPoolCluster,
+		)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	if !isDrifted {
+		return Result{CStorPoolCluster: r.DesiredCStorPoolCluster}, nil
+	}
+	glog.Warningf(
+		"Will retain drift in CStorPoolCluster %q / %q: %s",
+		r.ObservedCStorPoolCluster.GetNamespace(),
+		r.ObservedCStorPoolCluster.GetName(),
+		reason,
+	)
+	final, err := r.withObservedPools()
+	if err != nil {
+		return Result{}, err
+	}
+	annotations := final.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[types.AnnKeyCStorPoolClusterDriftDetected] = "true"
+	final.SetAnnotations(annotations)
+	return Result{
+		CStorPoolCluster: final,
+		IsDrifted:        true,
+		Reason:           reason,
+	}, nil
+}
+
+func (r *Resolver) resolveByIgnore() (Result, error) {
+	final, err := r.withObservedPools()
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{CStorPoolCluster: final}, nil
+}
+
+// withObservedPools returns a copy of desired CStorPoolCluster
+// whose pools are set from the observed CStorPoolCluster
+func (r *Resolver) withObservedPools() (*unstructured.Unstructured, error) {
+	final := r.DesiredCStorPoolCluster.DeepCopy()
+	pools, found, err := unstructured.NestedSlice(
+		r.ObservedCStorPoolCluster.Object, "spec", "pools",
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't get observed pools")
+	}
+	if !found {
+		unstructured.RemoveNestedField(final.Object, "spec", "pools")
+		return final, nil
+	}
+	err = unstructured.SetNestedSlice(final.Object, pools, "spec", "pools")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set observed pools")
+	}
+	return final, nil
+}
+
+// isLastAppliedDrifted returns true if the observed pools are not
+// same as the pools that were last applied by the watch
+func (r *Resolver) isLastAppliedDrifted() (bool, string, error) {
+	lastApplied, err := dynamicapply.GetLastAppliedByAnnKey(
+		r.ObservedCStorPoolCluster, r.WatchUID+lastAppliedAnnKeySuffix,
+	)
+	if err != nil {
+		return false, "", err
+	}
+	if len(lastApplied) == 0 {
+		// CStorPoolCluster was never applied by this watch
+		return false, "", nil
+	}
+	return isPoolsDrifted(
+		r.ObservedCStorPoolCluster, &unstructured.Unstructured{Object: lastApplied},
+	)
+}
+
+// isPoolsDrifted returns true if the block devices of any node
+// differ between the given CStorPoolClusters
+func isPoolsDrifted(observed, expected *unstructured.Unstructured) (bool, string, error) {
+	observedDevices, err := groupDeviceNamesByHostName(observed)
+	if err != nil {
+		return false, "", err
+	}
+	expectedDevices, err := groupDeviceNamesByHostName(expected)
+	if err != nil {
+		return false, "", err
+	}
+	var driftedHostNames []string
+	for hostName, deviceNames := range observedDevices {
+		if !reflect.DeepEqual(deviceNames, expectedDevices[hostName]) {
+			driftedHostNames = append(driftedHostNames, hostName)
+		}
+	}
+	for hostName := range expectedDevices {
+		if _, found := observedDevices[hostName]; !found {
+			driftedHostNames = append(driftedHostNames, hostName)
+		}
+	}
+	if len(driftedHostNames) == 0 {
+		return false, "", nil
+	}
+	// sorted to keep the reason same across syncs
+	sort.Strings(driftedHostNames)
+	return true, fmt.Sprintf("Pools were edited for node(s) %v", driftedHostNames), nil
+}
+
+// groupDeviceNamesByHostName maps host name to block device names
+// of the given CStorPoolCluster
+//
+// NOTE:
+//	Both openebs.io/v1alpha1 & cstor.openebs.io/v1 versions of
+// CStorPoolCluster are supported
+func groupDeviceNamesByHostName(obj *unstructured.Unstructured) (map[string][]string, error) {
+	pools, _, err := unstructured.NestedSlice(obj.Object, "spec", "pools")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't get pools")
+	}
+	if len(pools) == 0 {
+		// all pools might have been removed
+		return map[string][]string{}, nil
+	}
+	var hostNameToDeviceNames map[string][]string
+	if obj.GetAPIVersion() == types.APIVersionCStorOpenEBSV1 {
+		hostNameToDeviceNames, err = cspc.NewHelper(obj).GroupBlockDeviceNamesByHostName()
+	} else {
+		hostNameToDeviceNames, err = cspcv1alpha1.NewHelper(obj).GroupBlockDeviceNamesByHostName()
+	}
+	if err != nil {
+		return nil, err
+	}
+	// re-ordering of block devices is not a drift
+	for _, deviceNames := range hostNameToDeviceNames {
+		sort.Strings(deviceNames)
+	}
+	return hostNameToDeviceNames, nil
+}
+
+// SetCondition sets the DriftDetected condition against the given
+// status based on the given result
+//
+// NOTE:
+//	Existing condition is retained as is if neither its status nor
+// its reason changed. This keeps the status same across syncs.
+func SetCondition(status map[string]interface{}, result Result) (map[string]interface{}, error) {
+	status, err := conditions.SetCondition(
+		status, types.MakeCStorPoolClusterDriftDetectedCond(result.IsDrifted, result.Reason),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set drift condition")
+	}
+	return status, nil
+}
+
+// HasCondition returns true if DriftDetected condition is set
+// against the given object
+func HasCondition(obj *unstructured.Unstructured) bool {
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	_, found, _ := conditions.GetCondition(status, types.CStorPoolClusterDriftDetectedCondition)
+	return found
+}
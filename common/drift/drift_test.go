@@ -0,0 +1,353 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	dynamicapply "openebs.io/metac/dynamic/apply"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// newTestCSPC returns a v1alpha1 CStorPoolCluster with one stripe
+// pool per node having the given block devices
+func newTestCSPC(hostNameToDeviceNames map[string][]string) *unstructured.Unstructured {
+	var pools []interface{}
+	for hostName, deviceNames := range hostNameToDeviceNames {
+		var devices []interface{}
+		for _, name := range deviceNames {
+			devices = append(devices, map[string]interface{}{
+				"blockDeviceName": name,
+			})
+		}
+		pools = append(pools, map[string]interface{}{
+			"nodeSelector": map[string]interface{}{
+				"kubernetes.io/hostname": hostName,
+			},
+			"raidGroups": []interface{}{
+				map[string]interface{}{
+					"type":         "stripe",
+					"blockDevices": devices,
+				},
+			},
+		})
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name":      "my-cspc",
+				"namespace": "openebs",
+			},
+			"spec": map[string]interface{}{
+				"pools": pools,
+			},
+		},
+	}
+}
+
+// withLastApplied sets the given last applied CStorPoolCluster
+// against the given observed CStorPoolCluster
+func withLastApplied(
+	t *testing.T, observed, lastApplied *unstructured.Unstructured,
+) *unstructured.Unstructured {
+	err := dynamicapply.SetLastAppliedByAnnKey(
+		observed, lastApplied.UnstructuredContent(), "watch-1"+lastAppliedAnnKeySuffix,
+	)
+	if err != nil {
+		t.Fatalf("Can't set last applied: %+v", err)
+	}
+	return observed
+}
+
+// withDriftAnnotation marks the given CStorPoolCluster as drifted
+func withDriftAnnotation(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[types.AnnKeyCStorPoolClusterDriftDetected] = "true"
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestResolverResolve(t *testing.T) {
+	desiredDevices := map[string][]string{
+		"node-1": []string{"bd-1"},
+		"node-2": []string{"bd-2"},
+	}
+	editedDevices := map[string][]string{
+		"node-1": []string{"bd-1", "bd-11"},
+		"node-2": []string{"bd-2"},
+	}
+	var tests = map[string]struct {
+		policy        types.DriftPolicy
+		observed      func(t *testing.T) *unstructured.Unstructured
+		expectDevices map[string][]string
+		isDrifted     bool
+		isMarked      bool
+		isErr         bool
+	}{
+		"nil observed": {
+			policy:        types.DriftPolicyWarn,
+			observed:      func(t *testing.T) *unstructured.Unstructured { return nil },
+			expectDevices: desiredDevices,
+		},
+		"enforce - no drift": {
+			policy: types.DriftPolicyEnforce,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t, newTestCSPC(desiredDevices), newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: desiredDevices,
+		},
+		"enforce - drift is reverted": {
+			policy: types.DriftPolicyEnforce,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t, newTestCSPC(editedDevices), newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: desiredDevices,
+		},
+		"default policy - drift is reverted": {
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t, newTestCSPC(editedDevices), newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: desiredDevices,
+		},
+		"warn - no drift": {
+			policy: types.DriftPolicyWarn,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t, newTestCSPC(desiredDevices), newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: desiredDevices,
+		},
+		"warn - re-ordered devices is not a drift": {
+			policy: types.DriftPolicyWarn,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t,
+					newTestCSPC(map[string][]string{"node-1": []string{"bd-2", "bd-1"}}),
+					newTestCSPC(map[string][]string{"node-1": []string{"bd-1", "bd-2"}}),
+				)
+			},
+			expectDevices: desiredDevices,
+		},
+		"warn - drift is retained": {
+			policy: types.DriftPolicyWarn,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t, newTestCSPC(editedDevices), newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: editedDevices,
+			isDrifted:     true,
+			isMarked:      true,
+		},
+		"warn - marked drift is retained": {
+			policy: types.DriftPolicyWarn,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				// last applied is the retained drift
+				return withLastApplied(
+					t,
+					withDriftAnnotation(newTestCSPC(editedDevices)),
+					newTestCSPC(editedDevices),
+				)
+			},
+			expectDevices: editedDevices,
+			isDrifted:     true,
+			isMarked:      true,
+		},
+		"warn - marked drift is cleared once pools match": {
+			policy: types.DriftPolicyWarn,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t,
+					withDriftAnnotation(newTestCSPC(desiredDevices)),
+					newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: desiredDevices,
+		},
+		"warn - all pools removed is a drift": {
+			policy: types.DriftPolicyWarn,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t, newTestCSPC(nil), newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: map[string][]string{},
+			isDrifted:     true,
+			isMarked:      true,
+		},
+		"ignore - drift is retained silently": {
+			policy: types.DriftPolicyIgnore,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return withLastApplied(
+					t, newTestCSPC(editedDevices), newTestCSPC(desiredDevices),
+				)
+			},
+			expectDevices: editedDevices,
+		},
+		"ignore - without last applied": {
+			policy: types.DriftPolicyIgnore,
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return newTestCSPC(editedDevices)
+			},
+			expectDevices: editedDevices,
+		},
+		"invalid policy": {
+			policy: types.DriftPolicy("Junk"),
+			observed: func(t *testing.T) *unstructured.Unstructured {
+				return newTestCSPC(desiredDevices)
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Resolver{
+				Policy:                   mock.policy,
+				WatchUID:                 "watch-1",
+				ObservedCStorPoolCluster: mock.observed(t),
+				DesiredCStorPoolCluster:  newTestCSPC(desiredDevices),
+			}
+			got, err := r.Resolve()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if got.IsDrifted != mock.isDrifted {
+				t.Fatalf("Expected drift %t got %t", mock.isDrifted, got.IsDrifted)
+			}
+			if mock.isDrifted && got.Reason == "" {
+				t.Fatalf("Expected drift reason got none")
+			}
+			_, isMarked :=
+				got.CStorPoolCluster.GetAnnotations()[types.AnnKeyCStorPoolClusterDriftDetected]
+			if isMarked != mock.isMarked {
+				t.Fatalf("Expected drift annotation %t got %t", mock.isMarked, isMarked)
+			}
+			gotDevices, err := groupDeviceNamesByHostName(got.CStorPoolCluster)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expectDevices, gotDevices); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	oldCond := map[string]interface{}{
+		"type":             string(types.CStorPoolClusterDriftDetectedCondition),
+		"status":           string(types.ConditionIsPresent),
+		"reason":           "Pools were edited for node(s) [node-1]",
+		"lastObservedTime": "2020-01-01T00:00:00Z",
+	}
+	otherCond := map[string]interface{}{
+		"type":   "Other",
+		"status": string(types.ConditionIsAbsent),
+	}
+	var tests = map[string]struct {
+		status       map[string]interface{}
+		result       Result
+		expectCount  int
+		expectStatus types.ConditionState
+		isRetained   bool
+	}{
+		"nil status": {
+			result:       Result{},
+			expectCount:  1,
+			expectStatus: types.ConditionIsAbsent,
+		},
+		"condition is appended": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{otherCond},
+			},
+			result:       Result{IsDrifted: true, Reason: "drift"},
+			expectCount:  2,
+			expectStatus: types.ConditionIsPresent,
+		},
+		"same condition is retained": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{otherCond, oldCond},
+			},
+			result: Result{
+				IsDrifted: true,
+				Reason:    "Pools were edited for node(s) [node-1]",
+			},
+			expectCount:  2,
+			expectStatus: types.ConditionIsPresent,
+			isRetained:   true,
+		},
+		"changed condition is replaced": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{otherCond, oldCond},
+			},
+			result:       Result{},
+			expectCount:  2,
+			expectStatus: types.ConditionIsAbsent,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := SetCondition(mock.status, mock.result)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			conds := got["conditions"].([]interface{})
+			if len(conds) != mock.expectCount {
+				t.Fatalf("Expected condition count %d got %d", mock.expectCount, len(conds))
+			}
+			var driftCond map[string]interface{}
+			for _, cond := range conds {
+				condMap := cond.(map[string]interface{})
+				if condMap["type"] == string(types.CStorPoolClusterDriftDetectedCondition) {
+					driftCond = condMap
+				}
+			}
+			if driftCond["status"] != string(mock.expectStatus) {
+				t.Fatalf("Expected status %q got %v", mock.expectStatus, driftCond["status"])
+			}
+			isRetained := driftCond["lastObservedTime"] == "2020-01-01T00:00:00Z"
+			if isRetained != mock.isRetained {
+				t.Fatalf("Expected retained %t got %t", mock.isRetained, isRetained)
+			}
+		})
+	}
+}
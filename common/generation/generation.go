@@ -0,0 +1,163 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package generation lets the controllers report the generation of
+// their watch that was processed & skip the syncs that have nothing
+// new to process.
+//
+// NOTE:
+//	Custom resources of this project do not enable status as a
+// subresource since some controllers update the status of their
+// attachments. Hence every status update increments the generation
+// of the watch. Observed generation takes this increment into
+// account to avoid a never ending hot loop.
+package generation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GetObservedGeneration returns the observed generation set against
+// the status of the given object
+func GetObservedGeneration(obj *unstructured.Unstructured) int64 {
+	if obj == nil {
+		return 0
+	}
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	return observed
+}
+
+// SetObservedGeneration sets the generation of the given watch as
+// observedGeneration against the given status
+//
+// NOTE:
+//	Status is updated only if it differs from the watch's status.
+// This update increments the generation of the watch. Hence the
+// incremented generation is set as the observed generation in this
+// case. The resulting status remains same across syncs if nothing
+// changed.
+//
+// NOTE:
+//	Nil status is returned as is since metac retains the watch's
+// status in this case.
+func SetObservedGeneration(
+	watch *unstructured.Unstructured, status map[string]interface{},
+) map[string]interface{} {
+	if watch == nil || status == nil {
+		return status
+	}
+	generation := watch.GetGeneration()
+	status["observedGeneration"] = generation
+	observed, _, _ := unstructured.NestedFieldNoCopy(watch.Object, "status")
+	if !reflect.DeepEqual(observed, status) {
+		status["observedGeneration"] = generation + 1
+	}
+	return status
+}
+
+// objectState is the part of an object that decides its resolved
+// state
+type objectState struct {
+	UID             string `json:"uid"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// watchState is the part of a watch & its attachments that decides
+// the resolved state of a sync
+type watchState struct {
+	Generation  int64             `json:"generation"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Attachments []objectState     `json:"attachments,omitempty"`
+}
+
+// ResolvedStateHash returns the hash of the state a sync of the
+// given watch resolves with
+//
+// NOTE:
+//	This state is made of the watch's generation, labels &
+// annotations along with the resource versions of the attachments.
+// Annotation with the given key is excluded since this holds the
+// hash itself. Watch is excluded from the attachments since its
+// resource version changes with every update of its status.
+func ResolvedStateHash(
+	watch *unstructured.Unstructured,
+	attachments []*unstructured.Unstructured,
+	annKey string,
+) (string, error) {
+	if watch == nil {
+		return "", errors.Errorf("Can't hash resolved state: Nil watch")
+	}
+	state := watchState{
+		Generation: watch.GetGeneration(),
+		Labels:     watch.GetLabels(),
+	}
+	for key, value := range watch.GetAnnotations() {
+		if key == annKey {
+			continue
+		}
+		if state.Annotations == nil {
+			state.Annotations = map[string]string{}
+		}
+		state.Annotations[key] = value
+	}
+	for _, attachment := range attachments {
+		if attachment == nil || attachment.GetUID() == watch.GetUID() {
+			continue
+		}
+		state.Attachments = append(state.Attachments, objectState{
+			UID:             string(attachment.GetUID()),
+			ResourceVersion: attachment.GetResourceVersion(),
+		})
+	}
+	// attachments are sorted since their order is not guaranteed
+	sort.Slice(state.Attachments, func(i, j int) bool {
+		return state.Attachments[i].UID < state.Attachments[j].UID
+	})
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return "", errors.Wrapf(
+			err,
+			"Can't hash resolved state: %q / %q",
+			watch.GetNamespace(), watch.GetName(),
+		)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// IsProcessed returns true if the latest generation of the given
+// watch was processed & the given resolved state hash matches the
+// hash annotated against the watch with the given key
+func IsProcessed(watch *unstructured.Unstructured, hash, annKey string) bool {
+	if watch == nil || hash == "" {
+		return false
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(
+		watch.Object, "status", "observedGeneration",
+	); !found {
+		return false
+	}
+	return GetObservedGeneration(watch) == watch.GetGeneration() &&
+		watch.GetAnnotations()[annKey] == hash
+}
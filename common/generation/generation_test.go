@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generation
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const testAnnKey = "dao.mayadata.io/test-resolved-state"
+
+func makeObj(uid, rv string, generation int64, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetUID(types.UID(uid))
+	obj.SetResourceVersion(rv)
+	obj.SetGeneration(generation)
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func TestSetObservedGeneration(t *testing.T) {
+	var tests = map[string]struct {
+		watch  *unstructured.Unstructured
+		status map[string]interface{}
+		expect map[string]interface{}
+	}{
+		"nil status": {
+			watch: makeObj("w1", "1", 2, nil),
+		},
+		"watch without status": {
+			watch:  makeObj("w1", "1", 2, nil),
+			status: map[string]interface{}{"phase": "Online"},
+			expect: map[string]interface{}{
+				"phase":              "Online",
+				"observedGeneration": int64(3),
+			},
+		},
+		"status is unchanged": {
+			watch: makeObj("w1", "1", 3, map[string]interface{}{
+				"phase":              "Online",
+				"observedGeneration": int64(3),
+			}),
+			status: map[string]interface{}{"phase": "Online"},
+			expect: map[string]interface{}{
+				"phase":              "Online",
+				"observedGeneration": int64(3),
+			},
+		},
+		"spec is edited": {
+			watch: makeObj("w1", "1", 4, map[string]interface{}{
+				"phase":              "Online",
+				"observedGeneration": int64(3),
+			}),
+			status: map[string]interface{}{"phase": "Online"},
+			expect: map[string]interface{}{
+				"phase":              "Online",
+				"observedGeneration": int64(5),
+			},
+		},
+		"status is changed": {
+			watch: makeObj("w1", "1", 3, map[string]interface{}{
+				"phase":              "Online",
+				"observedGeneration": int64(3),
+			}),
+			status: map[string]interface{}{"phase": "Error"},
+			expect: map[string]interface{}{
+				"phase":              "Error",
+				"observedGeneration": int64(4),
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := SetObservedGeneration(mock.watch, mock.status)
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolvedStateHash(t *testing.T) {
+	watch := makeObj("w1", "1", 2, nil)
+	watch.SetAnnotations(map[string]string{"app": "cstor"})
+	bd1 := makeObj("bd1", "10", 0, nil)
+	bd2 := makeObj("bd2", "20", 0, nil)
+	hash, err := ResolvedStateHash(watch, []*unstructured.Unstructured{bd1, bd2}, testAnnKey)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+
+	// order of attachments & the watch as an attachment don't matter
+	got, _ := ResolvedStateHash(
+		watch, []*unstructured.Unstructured{bd2, watch, bd1}, testAnnKey,
+	)
+	if got != hash {
+		t.Fatalf("Expected same hash for reordered attachments")
+	}
+
+	// annotation that holds the hash doesn't matter
+	annotated := watch.DeepCopy()
+	annotated.SetAnnotations(map[string]string{"app": "cstor", testAnnKey: hash})
+	annotated.SetResourceVersion("2")
+	got, _ = ResolvedStateHash(annotated, []*unstructured.Unstructured{bd1, bd2}, testAnnKey)
+	if got != hash {
+		t.Fatalf("Expected same hash for annotated watch")
+	}
+
+	// changes to attachments, labels & generation matter
+	bd2Updated := makeObj("bd2", "21", 0, nil)
+	got, _ = ResolvedStateHash(watch, []*unstructured.Unstructured{bd1, bd2Updated}, testAnnKey)
+	if got == hash {
+		t.Fatalf("Expected new hash for updated attachment")
+	}
+	labelled := watch.DeepCopy()
+	labelled.SetLabels(map[string]string{"tier": "gold"})
+	got, _ = ResolvedStateHash(labelled, []*unstructured.Unstructured{bd1, bd2}, testAnnKey)
+	if got == hash {
+		t.Fatalf("Expected new hash for labelled watch")
+	}
+	edited := watch.DeepCopy()
+	edited.SetGeneration(3)
+	got, _ = ResolvedStateHash(edited, []*unstructured.Unstructured{bd1, bd2}, testAnnKey)
+	if got == hash {
+		t.Fatalf("Expected new hash for edited watch")
+	}
+
+	_, err = ResolvedStateHash(nil, nil, testAnnKey)
+	if err == nil {
+		t.Fatalf("Expected error for nil watch got none")
+	}
+}
+
+func TestIsProcessed(t *testing.T) {
+	var tests = map[string]struct {
+		watch  *unstructured.Unstructured
+		hash   string
+		expect bool
+	}{
+		"never synced": {
+			watch: makeObj("w1", "1", 1, nil),
+			hash:  "abc",
+		},
+		"generation is not observed": {
+			watch: func() *unstructured.Unstructured {
+				obj := makeObj("w1", "1", 2, map[string]interface{}{
+					"observedGeneration": int64(1),
+				})
+				obj.SetAnnotations(map[string]string{testAnnKey: "abc"})
+				return obj
+			}(),
+			hash: "abc",
+		},
+		"resolved state changed": {
+			watch: func() *unstructured.Unstructured {
+				obj := makeObj("w1", "1", 2, map[string]interface{}{
+					"observedGeneration": int64(2),
+				})
+				obj.SetAnnotations(map[string]string{testAnnKey: "abc"})
+				return obj
+			}(),
+			hash: "def",
+		},
+		"processed": {
+			watch: func() *unstructured.Unstructured {
+				obj := makeObj("w1", "1", 2, map[string]interface{}{
+					"observedGeneration": int64(2),
+				})
+				obj.SetAnnotations(map[string]string{testAnnKey: "abc"})
+				return obj
+			}(),
+			hash:   "abc",
+			expect: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsProcessed(mock.watch, mock.hash, testAnnKey)
+			if got != mock.expect {
+				t.Fatalf("Expected %t got %t", mock.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ignorefields retains the fields of an observed
+// CStorPoolCluster that are ignored from reconciliation. This lets
+// users tune fields like the resources of pools without these being
+// overwritten by the desired state.
+//
+// NOTE:
+//	A field path is made of dot separated keys starting with spec
+// e.g. spec.pools[*].poolConfig.resources. A key suffixed with [*]
+// refers to every item of its list. Pools are matched by their node
+// selectors. Items of other lists are matched by their index.
+package ignorefields
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+// listSuffix marks a key that refers to every item of its list
+const listSuffix = "[*]"
+
+// keyRegex matches a single key of a field path
+var keyRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*(\[\*\])?$`)
+
+// protectedPaths are the structural fields of CStorPoolCluster that
+// can never be ignored. Neither these fields nor their parents nor
+// their children can be ignored.
+var protectedPaths = []string{
+	"spec.pools[*].nodeSelector",
+	"spec.pools[*].raidGroups",
+	"spec.pools[*].dataRaidGroups",
+	"spec.pools[*].poolConfig.defaultRaidGroupType",
+	"spec.pools[*].poolConfig.dataRaidGroupType",
+}
+
+// parse returns the keys of the given field path without their [*]
+// suffixes
+func parse(path string) ([]string, error) {
+	keys := strings.Split(path, ".")
+	if len(keys) < 2 || keys[0] != "spec" {
+		return nil, errs.ValidationErrorf(
+			"Invalid ignore field %q: Want a path under spec", path,
+		)
+	}
+	var parsed []string
+	for _, key := range keys {
+		if !keyRegex.MatchString(key) {
+			return nil, errs.ValidationErrorf(
+				"Invalid ignore field %q: Invalid key %q", path, key,
+			)
+		}
+		parsed = append(parsed, strings.TrimSuffix(key, listSuffix))
+	}
+	return parsed, nil
+}
+
+// isRelated returns true if one of the given paths is same as or is
+// a parent of the other
+func isRelated(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+}
+
+// normalize returns the given path with lists referred to by [*]
+// e.g. spec.pools.nodeSelector is same as spec.pools[*].nodeSelector
+func normalize(path string) string {
+	return strings.ReplaceAll(path, listSuffix, "")
+}
+
+// Validate returns a validation error if any of the given field
+// paths is invalid or refers to a structural field of
+// CStorPoolCluster
+func Validate(paths []string) error {
+	for _, path := range paths {
+		_, err := parse(path)
+		if err != nil {
+			return err
+		}
+		for _, protected := range protectedPaths {
+			if isRelated(normalize(path), normalize(protected)) {
+				return errs.ValidationErrorf(
+					"Invalid ignore field %q: Structural field %q can't be ignored",
+					path, protected,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// Retain sets the values of the given field paths of the observed
+// CStorPoolCluster against the desired CStorPoolCluster. A field
+// that is not observed is removed from the desired state.
+//
+// NOTE:
+//	Desired state is left as is if CStorPoolCluster is yet to be
+// created. Pools that are yet to be created are left as is as well.
+func Retain(paths []string, observed, desired *unstructured.Unstructured) error {
+	if observed == nil || desired == nil {
+		return nil
+	}
+	for _, path := range paths {
+		keys, err := parse(path)
+		if err != nil {
+			return err
+		}
+		retain(keys, observed.UnstructuredContent(), desired.UnstructuredContent())
+	}
+	return nil
+}
+
+// retain sets the value of the given keys of the observed map
+// against the desired map
+func retain(keys []string, observed, desired map[string]interface{}) {
+	key := keys[0]
+	if len(keys) == 1 {
+		observedVal, found := observed[key]
+		if !found {
+			delete(desired, key)
+			return
+		}
+		desired[key] = runtime.DeepCopyJSONValue(observedVal)
+		return
+	}
+	if desiredMap, ok := desired[key].(map[string]interface{}); ok {
+		observedMap, ok := observed[key].(map[string]interface{})
+		if !ok {
+			// parent is not observed & hence none of its fields
+			observedMap = map[string]interface{}{}
+		}
+		retain(keys[1:], observedMap, desiredMap)
+		return
+	}
+	// lists are walked even if the key is not suffixed with [*]
+	observedList, _ := observed[key].([]interface{})
+	desiredList, _ := desired[key].([]interface{})
+	for idx, desiredItem := range desiredList {
+		desiredMap, ok := desiredItem.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		observedMap := findItem(observedList, idx, desiredMap)
+		if observedMap == nil {
+			// item is yet to be created
+			continue
+		}
+		retain(keys[1:], observedMap, desiredMap)
+	}
+}
+
+// findItem returns the observed item that matches the given desired
+// item. Items with node selectors i.e. pools are matched by their
+// node selectors. Other items are matched by their index.
+func findItem(
+	observedList []interface{}, idx int, desired map[string]interface{},
+) map[string]interface{} {
+	if nodeSelector, found := desired["nodeSelector"]; found {
+		for _, item := range observedList {
+			itemMap, ok := item.(map[string]interface{})
+			if ok && reflect.DeepEqual(itemMap["nodeSelector"], nodeSelector) {
+				return itemMap
+			}
+		}
+		return nil
+	}
+	if idx >= len(observedList) {
+		return nil
+	}
+	itemMap, _ := observedList[idx].(map[string]interface{})
+	return itemMap
+}
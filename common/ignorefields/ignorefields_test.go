@@ -0,0 +1,188 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignorefields
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+func TestValidate(t *testing.T) {
+	var tests = map[string]struct {
+		paths []string
+		isErr bool
+	}{
+		"no paths": {},
+		"pool config fields": {
+			paths: []string{
+				"spec.pools[*].poolConfig.resources",
+				"spec.pools[*].poolConfig.roThresholdLimit",
+				"spec.resources",
+			},
+		},
+		"path outside spec": {
+			paths: []string{"metadata.labels"},
+			isErr: true,
+		},
+		"spec itself": {
+			paths: []string{"spec"},
+			isErr: true,
+		},
+		"invalid key": {
+			paths: []string{"spec.pools[0].poolConfig"},
+			isErr: true,
+		},
+		"empty key": {
+			paths: []string{"spec..resources"},
+			isErr: true,
+		},
+		"node selector": {
+			paths: []string{"spec.pools[*].nodeSelector"},
+			isErr: true,
+		},
+		"child of raid groups": {
+			paths: []string{"spec.pools[*].raidGroups[*].blockDevices"},
+			isErr: true,
+		},
+		"parent of raid type": {
+			paths: []string{"spec.pools[*].poolConfig"},
+			isErr: true,
+		},
+		"pools without list suffix": {
+			paths: []string{"spec.pools.dataRaidGroups"},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := Validate(mock.paths)
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+// newTestPool returns a pool of the given host with the given pool
+// config
+func newTestPool(hostName string, poolConfig map[string]interface{}) interface{} {
+	return map[string]interface{}{
+		"nodeSelector": map[string]interface{}{
+			"kubernetes.io/hostname": hostName,
+		},
+		"poolConfig": poolConfig,
+	}
+}
+
+func newTestCStorPoolCluster(pools ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cstor.openebs.io/v1",
+			"kind":       "CStorPoolCluster",
+			"spec": map[string]interface{}{
+				"pools": pools,
+			},
+		},
+	}
+}
+
+func TestRetain(t *testing.T) {
+	resources := map[string]interface{}{
+		"limits": map[string]interface{}{"memory": "4Gi"},
+	}
+	var tests = map[string]struct {
+		paths    []string
+		observed *unstructured.Unstructured
+		desired  *unstructured.Unstructured
+		expect   *unstructured.Unstructured
+	}{
+		"not yet created": {
+			paths: []string{"spec.pools[*].poolConfig.compression"},
+			desired: newTestCStorPoolCluster(
+				newTestPool("node-1", map[string]interface{}{"compression": "off"}),
+			),
+			expect: newTestCStorPoolCluster(
+				newTestPool("node-1", map[string]interface{}{"compression": "off"}),
+			),
+		},
+		"observed values are retained by node": {
+			paths: []string{
+				"spec.pools[*].poolConfig.compression",
+				"spec.pools[*].poolConfig.resources",
+			},
+			observed: newTestCStorPoolCluster(
+				newTestPool("node-2", map[string]interface{}{
+					"compression": "lz4",
+				}),
+				newTestPool("node-1", map[string]interface{}{
+					"compression": "gzip",
+					"resources":   resources,
+				}),
+			),
+			desired: newTestCStorPoolCluster(
+				newTestPool("node-1", map[string]interface{}{"compression": "off"}),
+				newTestPool("node-2", map[string]interface{}{"compression": "off"}),
+				newTestPool("node-3", map[string]interface{}{"compression": "off"}),
+			),
+			expect: newTestCStorPoolCluster(
+				newTestPool("node-1", map[string]interface{}{
+					"compression": "gzip",
+					"resources":   resources,
+				}),
+				newTestPool("node-2", map[string]interface{}{"compression": "lz4"}),
+				newTestPool("node-3", map[string]interface{}{"compression": "off"}),
+			),
+		},
+		"field removed by user is not set": {
+			paths: []string{"spec.pools.poolConfig.compression"},
+			observed: newTestCStorPoolCluster(
+				newTestPool("node-1", map[string]interface{}{}),
+			),
+			desired: newTestCStorPoolCluster(
+				newTestPool("node-1", map[string]interface{}{"compression": "off"}),
+			),
+			expect: newTestCStorPoolCluster(
+				newTestPool("node-1", map[string]interface{}{}),
+			),
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := Retain(mock.paths, mock.observed, mock.desired)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, mock.desired); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
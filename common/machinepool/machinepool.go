@@ -0,0 +1,205 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package machinepool resolves the nodes & replicas of a MachineSet
+// or a MachinePool that is referred to by the allowed nodes of a
+// CStorClusterConfig.
+//
+// NOTE:
+//	Machine resources are observed as attachments. These attachments
+// are set via --watch-attachment flag since machine resources are not
+// available in every Kubernetes cluster.
+package machinepool
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// KindMachine is the kind of the machines owned by a MachineSet
+const KindMachine = "Machine"
+
+// Validate returns a validation error if the given reference is
+// invalid
+func Validate(ref types.MachinePoolReference) error {
+	if !types.SupportedMachinePoolKinds[ref.Kind] {
+		return errs.ValidationErrorf(
+			"Invalid machine pool: Unsupported kind %q: Supports %q or %q",
+			ref.Kind, types.MachinePoolKindMachineSet, types.MachinePoolKindMachinePool,
+		)
+	}
+	if ref.APIVersion == "" {
+		return errs.ValidationErrorf(
+			"Invalid machine pool: Missing apiVersion: Kind %q", ref.Kind,
+		)
+	}
+	if ref.Name == "" {
+		return errs.ValidationErrorf(
+			"Invalid machine pool: Missing name: Kind %q", ref.Kind,
+		)
+	}
+	return nil
+}
+
+// group returns the API group of the given API version
+func group(apiVersion string) string {
+	if idx := strings.LastIndex(apiVersion, "/"); idx != -1 {
+		return apiVersion[:idx]
+	}
+	// core API group
+	return ""
+}
+
+// isMatch returns true if the given resource is the one referred to
+// by the given reference
+func isMatch(ref types.MachinePoolReference, obj *unstructured.Unstructured) bool {
+	return obj.GetAPIVersion() == ref.APIVersion &&
+		obj.GetKind() == string(ref.Kind) &&
+		obj.GetName() == ref.Name &&
+		(ref.Namespace == "" || obj.GetNamespace() == ref.Namespace)
+}
+
+// Find returns the machine resource referred to by the given
+// reference from the given resources
+func Find(
+	ref types.MachinePoolReference, resources []*unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	for _, obj := range resources {
+		if obj == nil || obj.UnstructuredContent() == nil {
+			continue
+		}
+		if isMatch(ref, obj) {
+			return obj, nil
+		}
+	}
+	return nil, errs.NotEnoughResourcesErrorf(
+		"Machine pool not found: %s %q: Namespace %q: Is it set as an attachment?",
+		ref.Kind, ref.Name, ref.Namespace,
+	)
+}
+
+// Replicas returns the desired replica count of the machine
+// resource referred to by the given reference
+func Replicas(
+	ref types.MachinePoolReference, resources []*unstructured.Unstructured,
+) (int64, error) {
+	obj, err := Find(ref, resources)
+	if err != nil {
+		return 0, err
+	}
+	replicas, found, err :=
+		unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return 0, errors.Wrapf(
+			err, "Failed to get replicas: %s %q", ref.Kind, ref.Name,
+		)
+	}
+	if !found {
+		// replicas defaults to 1 for MachineSet as well as MachinePool
+		return 1, nil
+	}
+	return replicas, nil
+}
+
+// NodeNames returns the names of the nodes that belong to the machine
+// resource referred to by the given reference
+func NodeNames(
+	ref types.MachinePoolReference, resources []*unstructured.Unstructured,
+) (map[string]bool, error) {
+	obj, err := Find(ref, resources)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Kind == types.MachinePoolKindMachinePool {
+		return poolNodeNames(obj)
+	}
+	return machineSetNodeNames(obj, resources)
+}
+
+// poolNodeNames returns the names of the nodes set in the status of
+// the given MachinePool
+func poolNodeNames(pool *unstructured.Unstructured) (map[string]bool, error) {
+	refs, _, err :=
+		unstructured.NestedSlice(pool.Object, "status", "nodeRefs")
+	if err != nil {
+		return nil, errors.Wrapf(
+			err, "Failed to get node refs: MachinePool %q", pool.GetName(),
+		)
+	}
+	names := map[string]bool{}
+	for _, ref := range refs {
+		refMap, ok := ref.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(refMap, "name")
+		if name != "" {
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// machineSetNodeNames returns the names of the nodes of the machines
+// owned by the given MachineSet
+func machineSetNodeNames(
+	set *unstructured.Unstructured, resources []*unstructured.Unstructured,
+) (map[string]bool, error) {
+	names := map[string]bool{}
+	for _, obj := range resources {
+		if obj == nil || obj.UnstructuredContent() == nil {
+			continue
+		}
+		if obj.GetKind() != KindMachine ||
+			group(obj.GetAPIVersion()) != group(set.GetAPIVersion()) ||
+			obj.GetNamespace() != set.GetNamespace() ||
+			!isOwnedBy(obj, set) {
+			continue
+		}
+		name, _, err :=
+			unstructured.NestedString(obj.Object, "status", "nodeRef", "name")
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Failed to get node ref: Machine %q", obj.GetName(),
+			)
+		}
+		if name != "" {
+			// machines that are yet to get a node are skipped
+			names[name] = true
+		}
+	}
+	return names, nil
+}
+
+// isOwnedBy returns true if the given owner is set as an owner
+// reference of the given object
+func isOwnedBy(obj, owner *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind != owner.GetKind() || ref.Name != owner.GetName() {
+			continue
+		}
+		if ref.UID != "" && owner.GetUID() != "" && ref.UID != owner.GetUID() {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package machinepool
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+const testAPIVersion = "machine.openshift.io/v1beta1"
+
+var machineSetRef = types.MachinePoolReference{
+	APIVersion: testAPIVersion,
+	Kind:       types.MachinePoolKindMachineSet,
+	Name:       "storage",
+	Namespace:  "openshift-machine-api",
+}
+
+func newTestMachineSet(name string, replicas interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{}
+	if replicas != nil {
+		spec["replicas"] = replicas
+	}
+	set := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": testAPIVersion,
+			"kind":       string(types.MachinePoolKindMachineSet),
+			"spec":       spec,
+		},
+	}
+	set.SetName(name)
+	set.SetNamespace("openshift-machine-api")
+	return set
+}
+
+func newTestMachine(name, ownerName, nodeName string) *unstructured.Unstructured {
+	machine := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": testAPIVersion,
+			"kind":       KindMachine,
+		},
+	}
+	if nodeName != "" {
+		machine.Object["status"] = map[string]interface{}{
+			"nodeRef": map[string]interface{}{
+				"kind": "Node",
+				"name": nodeName,
+			},
+		}
+	}
+	machine.SetName(name)
+	machine.SetNamespace("openshift-machine-api")
+	machine.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: testAPIVersion,
+			Kind:       string(types.MachinePoolKindMachineSet),
+			Name:       ownerName,
+		},
+	})
+	return machine
+}
+
+func TestValidate(t *testing.T) {
+	var tests = map[string]struct {
+		ref   types.MachinePoolReference
+		isErr bool
+	}{
+		"valid machine set": {
+			ref: machineSetRef,
+		},
+		"unsupported kind": {
+			ref: types.MachinePoolReference{
+				APIVersion: testAPIVersion,
+				Kind:       "MachineDeployment",
+				Name:       "storage",
+			},
+			isErr: true,
+		},
+		"missing apiVersion": {
+			ref: types.MachinePoolReference{
+				Kind: types.MachinePoolKindMachinePool,
+				Name: "storage",
+			},
+			isErr: true,
+		},
+		"missing name": {
+			ref: types.MachinePoolReference{
+				APIVersion: testAPIVersion,
+				Kind:       types.MachinePoolKindMachineSet,
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := Validate(mock.ref)
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestNodeNames(t *testing.T) {
+	pool := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "cluster.x-k8s.io/v1alpha3",
+			"kind":       string(types.MachinePoolKindMachinePool),
+			"status": map[string]interface{}{
+				"nodeRefs": []interface{}{
+					map[string]interface{}{"name": "node-1"},
+					map[string]interface{}{"name": "node-2"},
+				},
+			},
+		},
+	}
+	pool.SetName("storage")
+	pool.SetNamespace("default")
+
+	var tests = map[string]struct {
+		ref       types.MachinePoolReference
+		resources []*unstructured.Unstructured
+		expect    map[string]bool
+		isErr     bool
+	}{
+		"machine set is not observed": {
+			ref: machineSetRef,
+			resources: []*unstructured.Unstructured{
+				newTestMachine("m-1", "storage", "node-1"),
+			},
+			isErr: true,
+		},
+		"machines of machine set": {
+			ref: machineSetRef,
+			resources: []*unstructured.Unstructured{
+				newTestMachineSet("storage", int64(3)),
+				newTestMachine("m-1", "storage", "node-1"),
+				newTestMachine("m-2", "storage", "node-2"),
+				newTestMachine("m-3", "storage", ""),
+				newTestMachine("m-4", "workers", "node-4"),
+			},
+			expect: map[string]bool{"node-1": true, "node-2": true},
+		},
+		"machine set of other namespace": {
+			ref: types.MachinePoolReference{
+				APIVersion: testAPIVersion,
+				Kind:       types.MachinePoolKindMachineSet,
+				Name:       "storage",
+				Namespace:  "default",
+			},
+			resources: []*unstructured.Unstructured{
+				newTestMachineSet("storage", int64(3)),
+			},
+			isErr: true,
+		},
+		"node refs of machine pool": {
+			ref: types.MachinePoolReference{
+				APIVersion: "cluster.x-k8s.io/v1alpha3",
+				Kind:       types.MachinePoolKindMachinePool,
+				Name:       "storage",
+			},
+			resources: []*unstructured.Unstructured{pool},
+			expect:    map[string]bool{"node-1": true, "node-2": true},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NodeNames(mock.ref, mock.resources)
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeNotEnoughResources {
+					t.Fatalf("Expected not enough resources error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReplicas(t *testing.T) {
+	var tests = map[string]struct {
+		set    *unstructured.Unstructured
+		expect int64
+		isErr  bool
+	}{
+		"replicas is set": {
+			set:    newTestMachineSet("storage", int64(5)),
+			expect: 5,
+		},
+		"replicas defaults to 1": {
+			set:    newTestMachineSet("storage", nil),
+			expect: 1,
+		},
+		"invalid replicas": {
+			set:   newTestMachineSet("storage", "five"),
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Replicas(
+				machineSetRef, []*unstructured.Unstructured{mock.set},
+			)
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expect {
+				t.Fatalf("Expected replicas %d got %d", mock.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// MergeAsOwned merges the extra pairs into the owned pairs. Owned
+// pairs take precedence over extra pairs in case of same keys.
+func MergeAsOwned(owned, extra map[string]string) map[string]string {
+	if len(owned) == 0 && len(extra) == 0 {
+		return owned
+	}
+	merged := map[string]string{}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	// owned keys are set last so that they can't be overridden
+	for k, v := range owned {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Propagate sets the given child metadata against the provided
+// object
+//
+// NOTE:
+//	Labels & annotations that are already set against the object
+// are considered to be owned by the controller & are preserved.
+func Propagate(obj *unstructured.Unstructured, child *types.ChildMetadata) {
+	if obj == nil || obj.Object == nil || child == nil {
+		return
+	}
+	if len(child.Labels) != 0 {
+		obj.SetLabels(MergeAsOwned(obj.GetLabels(), child.Labels))
+	}
+	if len(child.Annotations) != 0 {
+		obj.SetAnnotations(MergeAsOwned(obj.GetAnnotations(), child.Annotations))
+	}
+}
+
+// PropagateAll sets the given child metadata against each of the
+// provided objects
+func PropagateAll(objs []*unstructured.Unstructured, child *types.ChildMetadata) {
+	for _, obj := range objs {
+		Propagate(obj, child)
+	}
+}
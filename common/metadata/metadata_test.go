@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metadata
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestMergeAsOwned(t *testing.T) {
+	var tests = map[string]struct {
+		owned  map[string]string
+		extra  map[string]string
+		expect map[string]string
+	}{
+		"nil owned && nil extra": {},
+		"nil owned && extra": {
+			extra:  map[string]string{"team": "storage"},
+			expect: map[string]string{"team": "storage"},
+		},
+		"owned && nil extra": {
+			owned:  map[string]string{"dao.mayadata.io/cstorclusterconfig-uid": "101"},
+			expect: map[string]string{"dao.mayadata.io/cstorclusterconfig-uid": "101"},
+		},
+		"owned wins over extra": {
+			owned: map[string]string{
+				"dao.mayadata.io/cstorclusterconfig-uid": "101",
+			},
+			extra: map[string]string{
+				"dao.mayadata.io/cstorclusterconfig-uid": "junk",
+				"cost-center":                            "c1",
+			},
+			expect: map[string]string{
+				"dao.mayadata.io/cstorclusterconfig-uid": "101",
+				"cost-center":                            "c1",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := MergeAsOwned(mock.owned, mock.extra)
+			if len(got) == 0 && len(mock.expect) == 0 {
+				return
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPropagate(t *testing.T) {
+	var tests = map[string]struct {
+		obj        *unstructured.Unstructured
+		child      *types.ChildMetadata
+		expectLbls map[string]string
+		expectAnns map[string]string
+	}{
+		"nil child metadata": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{
+							"app": "cstor",
+						},
+					},
+				},
+			},
+			expectLbls: map[string]string{"app": "cstor"},
+		},
+		"child metadata does not override owned pairs": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							types.AnnKeyCStorClusterConfigUID: "101",
+						},
+					},
+				},
+			},
+			child: &types.ChildMetadata{
+				Labels: map[string]string{
+					"team": "storage",
+				},
+				Annotations: map[string]string{
+					types.AnnKeyCStorClusterConfigUID: "junk",
+					"cost-center":                     "c1",
+				},
+			},
+			expectLbls: map[string]string{
+				"team": "storage",
+			},
+			expectAnns: map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "101",
+				"cost-center":                     "c1",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			Propagate(mock.obj, mock.child)
+			if diff := cmp.Diff(mock.expectLbls, mock.obj.GetLabels()); diff != "" {
+				t.Fatalf("Expected no diff in labels got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectAnns, mock.obj.GetAnnotations()); diff != "" {
+				t.Fatalf("Expected no diff in annotations got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package migration lets the controllers migrate the resources they
+// generate when the schema of these resources changes across the
+// releases of this operator.
+//
+// NOTE:
+//	Generated resources are applied by metac via a 3-way merge of
+// the observed state, the last applied state & the desired state.
+// This merge fails if a field of the observed state is a map or a
+// list while the same field of the desired state is not. Resources
+// generated by an older release are hence migrated in two syncs.
+// First sync drops such conflicting fields from the desired state
+// which lets metac remove these from the observed state. Next sync
+// applies the desired state as is & stamps the current schema
+// version.
+package migration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// knownMergeKeys are the keys that are tried in the given order to
+// match the items of a list of maps. These are the keys that metac
+// uses to merge such lists.
+var knownMergeKeys = []string{
+	"containerPort",
+	"port",
+	"name",
+	"uid",
+	"ip",
+}
+
+// GetSchemaVersion returns the schema version of the given resource.
+// Resources without schema version annotation are considered to be
+// of legacy schema version.
+func GetSchemaVersion(obj *unstructured.Unstructured) string {
+	if obj == nil {
+		return ""
+	}
+	version := obj.GetAnnotations()[types.AnnKeySchemaVersion]
+	if version == "" {
+		return types.SchemaVersionLegacy
+	}
+	return version
+}
+
+// setSchemaVersion sets the given schema version against the given
+// resource. Annotation is not set for legacy schema version.
+func setSchemaVersion(obj *unstructured.Unstructured, version string) {
+	if version == types.SchemaVersionLegacy {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[types.AnnKeySchemaVersion] = version
+	obj.SetAnnotations(annotations)
+}
+
+// Migrate returns the desired resource that can be applied against
+// the given observed resource
+//
+// NOTE:
+//	Desired resource is stamped with the current schema version if
+// observed resource is nil or is of the current schema version or
+// does not conflict with the desired resource. Otherwise the fields
+// that conflict are dropped from the desired resource & observed
+// schema version is retained till the next sync.
+func Migrate(observed, desired *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if desired == nil {
+		return nil, errors.Errorf("Can't migrate schema: Nil desired resource")
+	}
+	migrated := desired.DeepCopy()
+	if observed == nil || GetSchemaVersion(observed) == types.SchemaVersionCurrent {
+		setSchemaVersion(migrated, types.SchemaVersionCurrent)
+		return migrated, nil
+	}
+	conflicts := findConflicts(nil, observed.Object, migrated.Object)
+	if len(conflicts) == 0 {
+		glog.V(2).Infof(
+			"Will migrate %s %q / %q from schema version %q to %q",
+			observed.GetKind(), observed.GetNamespace(), observed.GetName(),
+			GetSchemaVersion(observed), types.SchemaVersionCurrent,
+		)
+		setSchemaVersion(migrated, types.SchemaVersionCurrent)
+		return migrated, nil
+	}
+	var paths []string
+	for _, conflict := range conflicts {
+		unstructured.RemoveNestedField(migrated.Object, conflict...)
+		paths = append(paths, strings.Join(conflict, "."))
+	}
+	glog.V(2).Infof(
+		"Will migrate %s %q / %q from schema version %q: Dropped conflicting fields %v",
+		observed.GetKind(), observed.GetNamespace(), observed.GetName(),
+		GetSchemaVersion(observed), paths,
+	)
+	setSchemaVersion(migrated, GetSchemaVersion(observed))
+	return migrated, nil
+}
+
+// MigrateAll migrates each of the given desired resources against
+// the observed resource of the same kind, namespace & name
+func MigrateAll(
+	observed []*unstructured.Unstructured, desired []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	observedByKey := map[string]*unstructured.Unstructured{}
+	for _, obj := range observed {
+		if obj == nil {
+			continue
+		}
+		observedByKey[toKey(obj)] = obj
+	}
+	var migrated []*unstructured.Unstructured
+	for _, obj := range desired {
+		final, err := Migrate(observedByKey[toKey(obj)], obj)
+		if err != nil {
+			return nil, err
+		}
+		migrated = append(migrated, final)
+	}
+	return migrated, nil
+}
+
+// toKey returns the kind, namespace & name of the given resource
+func toKey(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// findConflicts returns the paths of the desired fields whose type
+// conflicts with the observed fields at the same path
+//
+// NOTE:
+//	A field conflicts if the observed field is a map or a list while
+// the desired field is of some other type. A conflict within the
+// items of a list is reported against the list itself.
+func findConflicts(fieldPath []string, observed, desired interface{}) [][]string {
+	if desired == nil {
+		return nil
+	}
+	switch observedVal := observed.(type) {
+	case map[string]interface{}:
+		desiredVal, ok := desired.(map[string]interface{})
+		if !ok {
+			return [][]string{fieldPath}
+		}
+		// keys are sorted to report the conflicts in same order
+		var keys []string
+		for key := range desiredVal {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var conflicts [][]string
+		for _, key := range keys {
+			childPath := append(append([]string{}, fieldPath...), key)
+			conflicts = append(
+				conflicts, findConflicts(childPath, observedVal[key], desiredVal[key])...,
+			)
+		}
+		return conflicts
+	case []interface{}:
+		desiredVal, ok := desired.([]interface{})
+		if !ok || hasListConflicts(observedVal, desiredVal) {
+			return [][]string{fieldPath}
+		}
+	}
+	return nil
+}
+
+// hasListConflicts returns true if any of the desired items conflict
+// with the observed item having the same merge key
+//
+// NOTE:
+//	Lists without a common merge key are replaced as a whole during
+// the merge & hence never conflict
+func hasListConflicts(observed, desired []interface{}) bool {
+	mergeKey := detectMergeKey(observed, desired)
+	if mergeKey == "" {
+		return false
+	}
+	observedItems := map[string]interface{}{}
+	for _, item := range observed {
+		key := fmt.Sprintf("%v", item.(map[string]interface{})[mergeKey])
+		observedItems[key] = item
+	}
+	for _, item := range desired {
+		key := fmt.Sprintf("%v", item.(map[string]interface{})[mergeKey])
+		if len(findConflicts(nil, observedItems[key], item)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// detectMergeKey returns the first known merge key that is set
+// against all the items of the given lists. Empty string is returned
+// if any of the items is not a map.
+func detectMergeKey(lists ...[]interface{}) string {
+	for _, mergeKey := range knownMergeKeys {
+		isCommon := true
+		for _, list := range lists {
+			for _, item := range list {
+				itemMap, ok := item.(map[string]interface{})
+				if !ok {
+					return ""
+				}
+				if _, found := itemMap[mergeKey]; !found {
+					isCommon = false
+				}
+			}
+		}
+		if isCommon {
+			return mergeKey
+		}
+	}
+	return ""
+}
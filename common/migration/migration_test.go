@@ -0,0 +1,233 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newPlan(version string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": spec,
+		},
+	}
+	obj.SetKind(string(types.KindCStorClusterPlan))
+	obj.SetNamespace("openebs")
+	obj.SetName("my-plan")
+	if version != "" {
+		obj.SetAnnotations(map[string]string{
+			types.AnnKeySchemaVersion: version,
+		})
+	}
+	return obj
+}
+
+func TestGetSchemaVersion(t *testing.T) {
+	var tests = map[string]struct {
+		obj    *unstructured.Unstructured
+		expect string
+	}{
+		"nil resource": {},
+		"resource without annotation": {
+			obj:    newPlan("", nil),
+			expect: types.SchemaVersionLegacy,
+		},
+		"resource with annotation": {
+			obj:    newPlan("2", nil),
+			expect: "2",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := GetSchemaVersion(mock.obj)
+			if got != mock.expect {
+				t.Fatalf("Expected version %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	var tests = map[string]struct {
+		observed      *unstructured.Unstructured
+		desired       *unstructured.Unstructured
+		expectSpec    map[string]interface{}
+		expectVersion string
+		isErr         bool
+	}{
+		"nil desired": {
+			isErr: true,
+		},
+		"nil observed": {
+			desired: newPlan("", map[string]interface{}{
+				"zone": "zone-a",
+			}),
+			expectSpec: map[string]interface{}{
+				"zone": "zone-a",
+			},
+			expectVersion: types.SchemaVersionCurrent,
+		},
+		"observed of current version": {
+			observed: newPlan(types.SchemaVersionCurrent, map[string]interface{}{
+				"zone": map[string]interface{}{"name": "zone-a"},
+			}),
+			desired: newPlan("", map[string]interface{}{
+				"zone": "zone-a",
+			}),
+			expectSpec: map[string]interface{}{
+				"zone": "zone-a",
+			},
+			expectVersion: types.SchemaVersionCurrent,
+		},
+		"legacy observed without conflicts": {
+			observed: newPlan("", map[string]interface{}{
+				"nodes": []interface{}{
+					map[string]interface{}{"name": "node-1", "uid": "node-101"},
+				},
+			}),
+			desired: newPlan("", map[string]interface{}{
+				"nodes": []interface{}{
+					map[string]interface{}{"name": "node-1", "uid": "node-101", "zone": "zone-a"},
+				},
+				"zone": "zone-a",
+			}),
+			expectSpec: map[string]interface{}{
+				"nodes": []interface{}{
+					map[string]interface{}{"name": "node-1", "uid": "node-101", "zone": "zone-a"},
+				},
+				"zone": "zone-a",
+			},
+			expectVersion: types.SchemaVersionCurrent,
+		},
+		"legacy observed with map changed to scalar": {
+			observed: newPlan("", map[string]interface{}{
+				"zone": map[string]interface{}{"name": "zone-a"},
+				"size": int64(3),
+			}),
+			desired: newPlan("", map[string]interface{}{
+				"zone": "zone-a",
+				"size": int64(3),
+			}),
+			expectSpec: map[string]interface{}{
+				"size": int64(3),
+			},
+			expectVersion: types.SchemaVersionLegacy,
+		},
+		"legacy observed with list changed to map": {
+			observed: newPlan("1", map[string]interface{}{
+				"disks": []interface{}{"disk-1"},
+			}),
+			desired: newPlan("", map[string]interface{}{
+				"disks": map[string]interface{}{"count": int64(1)},
+			}),
+			expectSpec:    map[string]interface{}{},
+			expectVersion: types.SchemaVersionLegacy,
+		},
+		"legacy observed with conflicting list items": {
+			observed: newPlan("", map[string]interface{}{
+				"nodes": []interface{}{
+					map[string]interface{}{
+						"name": "node-1",
+						"zone": map[string]interface{}{"name": "zone-a"},
+					},
+				},
+			}),
+			desired: newPlan("", map[string]interface{}{
+				"nodes": []interface{}{
+					map[string]interface{}{"name": "node-1", "zone": "zone-a"},
+				},
+			}),
+			expectSpec:    map[string]interface{}{},
+			expectVersion: types.SchemaVersionLegacy,
+		},
+		"legacy observed with scalar list items": {
+			observed: newPlan("", map[string]interface{}{
+				"nodes": []interface{}{"node-1"},
+			}),
+			desired: newPlan("", map[string]interface{}{
+				"nodes": []interface{}{"node-2"},
+			}),
+			expectSpec: map[string]interface{}{
+				"nodes": []interface{}{"node-2"},
+			},
+			expectVersion: types.SchemaVersionCurrent,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Migrate(mock.observed, mock.desired)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			spec, _, _ := unstructured.NestedMap(got.Object, "spec")
+			if diff := cmp.Diff(mock.expectSpec, spec); diff != "" {
+				t.Fatalf("Expected no spec diff got\n%s", diff)
+			}
+			if GetSchemaVersion(got) != mock.expectVersion {
+				t.Fatalf("Expected version %q got %q", mock.expectVersion, GetSchemaVersion(got))
+			}
+		})
+	}
+}
+
+func TestMigrateAll(t *testing.T) {
+	observed := newPlan("", map[string]interface{}{
+		"zone": map[string]interface{}{"name": "zone-a"},
+	})
+	other := newPlan("", map[string]interface{}{
+		"zone": "zone-b",
+	})
+	other.SetName("other-plan")
+	got, err := MigrateAll(
+		[]*unstructured.Unstructured{observed, nil},
+		[]*unstructured.Unstructured{
+			newPlan("", map[string]interface{}{"zone": "zone-a"}),
+			other,
+		},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 resources got %d", len(got))
+	}
+	if GetSchemaVersion(got[0]) != types.SchemaVersionLegacy {
+		t.Fatalf("Expected legacy version got %q", GetSchemaVersion(got[0]))
+	}
+	if GetSchemaVersion(got[1]) != types.SchemaVersionCurrent {
+		t.Fatalf("Expected current version got %q", GetSchemaVersion(got[1]))
+	}
+	if other.GetAnnotations()[types.AnnKeySchemaVersion] != "" {
+		t.Fatalf("Expected desired resource to be left as is")
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package naming derives the names of the children of a
+// CStorClusterConfig.
+//
+// NOTE:
+//	Names are deterministic & never exceed 63 characters. Name of
+// CStorPoolCluster is used as a label value by cstor operator &
+// hence is limited to 63 characters.
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// MaxNameLength is the max length of a derived name
+const MaxNameLength int = 63
+
+// hashLength is the length of the hashes that are part of the
+// derived names
+const hashLength int = 8
+
+// separator joins the elements of a derived name
+const separator string = "-"
+
+// Hash returns a short hash of the given value
+func Hash(val string) string {
+	sum := sha256.Sum256([]byte(val))
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// trim drops the characters that are not allowed at the end of a
+// name
+func trim(name string) string {
+	return strings.TrimRight(name, "-.")
+}
+
+// Truncate returns the given name as is if it fits MaxNameLength.
+// Otherwise the name is cut & suffixed with a hash of the full name.
+func Truncate(name string) string {
+	if len(name) <= MaxNameLength {
+		return name
+	}
+	kept := trim(name[:MaxNameLength-hashLength-len(separator)])
+	if kept == "" {
+		return Hash(name)
+	}
+	return kept + separator + Hash(name)
+}
+
+// Part returns the given value as an element of a derived name e.g.
+// the value of a node label. A value that is not a valid DNS-1123
+// label is sanitised & suffixed with its hash to keep the names of
+// different values apart.
+func Part(value string) string {
+	if len(validation.IsDNS1123Label(value)) == 0 {
+		return value
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('-')
+		}
+	}
+	sanitised := b.String()
+	if budget := MaxNameLength - hashLength - len(separator); len(sanitised) > budget {
+		sanitised = sanitised[:budget]
+	}
+	sanitised = strings.Trim(sanitised, "-")
+	if sanitised == "" {
+		return Hash(value)
+	}
+	return sanitised + separator + Hash(value)
+}
+
+// Validate returns a validation error if the given naming options
+// can't form valid names
+func Validate(naming *types.Naming) error {
+	if naming == nil {
+		return nil
+	}
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"prefix", naming.Prefix},
+		{"suffix", naming.Suffix},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if msgs := validation.IsDNS1123Label(field.value); len(msgs) != 0 {
+			return errs.ValidationErrorf(
+				"Invalid naming %s %q: %s", field.name, field.value, strings.Join(msgs, ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// Name returns the name of a child of the CStorClusterConfig with
+// the given namespace & name. Given parts e.g. zone are added after
+// the config name.
+//
+// NOTE:
+//	Only the config name is shortened if the derived name exceeds
+// MaxNameLength. This retains the prefix, parts & suffix that tell
+// these children apart. The whole name is truncated if these
+// retained elements are too long themselves.
+func Name(namespace, name string, naming *types.Naming, parts ...string) string {
+	var head, tail []string
+	if naming != nil && naming.Prefix != "" {
+		head = append(head, naming.Prefix)
+	}
+	if naming != nil && naming.IncludeNamespaceHash {
+		tail = append(tail, Hash(namespace))
+	}
+	for _, part := range parts {
+		if part != "" {
+			tail = append(tail, part)
+		}
+	}
+	if naming != nil && naming.Suffix != "" {
+		tail = append(tail, naming.Suffix)
+	}
+	join := func(mid string) string {
+		var elems []string
+		elems = append(elems, head...)
+		elems = append(elems, mid)
+		elems = append(elems, tail...)
+		return strings.Join(elems, separator)
+	}
+	full := join(name)
+	if len(full) <= MaxNameLength {
+		return full
+	}
+	// room left for the shortened config name & its hash
+	budget := MaxNameLength - (len(full) - len(name)) - hashLength - len(separator)
+	if budget <= 0 {
+		return Truncate(full)
+	}
+	kept := trim(name[:budget])
+	if kept == "" {
+		return join(Hash(full))
+	}
+	return join(kept + separator + Hash(full))
+}
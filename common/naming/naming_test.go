@@ -0,0 +1,209 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package naming
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestTruncate(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	var tests = map[string]struct {
+		name   string
+		expect string
+	}{
+		"short name": {
+			name:   "my-config",
+			expect: "my-config",
+		},
+		"max length name": {
+			name:   strings.Repeat("a", MaxNameLength),
+			expect: strings.Repeat("a", MaxNameLength),
+		},
+		"long name": {
+			name:   long,
+			expect: strings.Repeat("a", 54) + "-" + Hash(long),
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := Truncate(mock.name)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestPart(t *testing.T) {
+	var tests = map[string]struct {
+		value  string
+		expect string
+	}{
+		"valid label": {
+			value:  "rack-1",
+			expect: "rack-1",
+		},
+		"upper case & underscore": {
+			value:  "Rack_1",
+			expect: "rack-1-" + Hash("Rack_1"),
+		},
+		"only invalid characters": {
+			value:  "__",
+			expect: Hash("__"),
+		},
+		"long value": {
+			value:  strings.Repeat("A", 70),
+			expect: strings.Repeat("a", 54) + "-" + Hash(strings.Repeat("A", 70)),
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := Part(mock.value)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+			if msgs := validation.IsDNS1123Label(got); len(msgs) != 0 {
+				t.Fatalf("Expected valid label got %q: %v", got, msgs)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var tests = map[string]struct {
+		naming *types.Naming
+		isErr  bool
+	}{
+		"nil naming": {},
+		"empty naming": {
+			naming: &types.Naming{},
+		},
+		"valid prefix & suffix": {
+			naming: &types.Naming{Prefix: "team-a", Suffix: "pool"},
+		},
+		"invalid prefix": {
+			naming: &types.Naming{Prefix: "Team_A"},
+			isErr:  true,
+		},
+		"invalid suffix": {
+			naming: &types.Naming{Suffix: "-pool"},
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := Validate(mock.naming)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestName(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	var tests = map[string]struct {
+		name         string
+		naming       *types.Naming
+		parts        []string
+		expect       string
+		expectPrefix string
+		expectSuffix string
+	}{
+		"nil naming": {
+			name:   "my-config",
+			expect: "my-config",
+		},
+		"nil naming && zone": {
+			name:   "my-config",
+			parts:  []string{"zone-a"},
+			expect: "my-config-zone-a",
+		},
+		"empty part is ignored": {
+			name:   "my-config",
+			parts:  []string{""},
+			expect: "my-config",
+		},
+		"prefix & suffix": {
+			name:   "my-config",
+			naming: &types.Naming{Prefix: "team", Suffix: "pool"},
+			parts:  []string{"zone-a"},
+			expect: "team-my-config-zone-a-pool",
+		},
+		"namespace hash": {
+			name:   "my-config",
+			naming: &types.Naming{IncludeNamespaceHash: true},
+			expect: "my-config-" + Hash("openebs"),
+		},
+		"long name": {
+			name:         long,
+			expectPrefix: strings.Repeat("a", 54),
+		},
+		"long name && prefix, zone & suffix are retained": {
+			name:         long,
+			naming:       &types.Naming{Prefix: "team", Suffix: "pool"},
+			parts:        []string{"zone-a"},
+			expectPrefix: "team-aaa",
+			expectSuffix: "-zone-a-pool",
+		},
+		"long suffix": {
+			name:         "my-config",
+			naming:       &types.Naming{Suffix: strings.Repeat("b", 60)},
+			expectPrefix: "my-config-bbb",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := Name("openebs", mock.name, mock.naming, mock.parts...)
+			if got != Name("openebs", mock.name, mock.naming, mock.parts...) {
+				t.Fatalf("Expected deterministic name got %q", got)
+			}
+			if len(got) > MaxNameLength {
+				t.Fatalf("Expected max length %d got %d: %q", MaxNameLength, len(got), got)
+			}
+			if msgs := validation.IsDNS1123Label(got); len(msgs) != 0 {
+				t.Fatalf("Expected valid name got %q: %v", got, msgs)
+			}
+			if mock.expect != "" && got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+			if !strings.HasPrefix(got, mock.expectPrefix) {
+				t.Fatalf("Expected prefix %q got %q", mock.expectPrefix, got)
+			}
+			if !strings.HasSuffix(got, mock.expectSuffix) {
+				t.Fatalf("Expected suffix %q got %q", mock.expectSuffix, got)
+			}
+		})
+	}
+}
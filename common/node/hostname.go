@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// HostNameResolver maps the name of a node to the value of its
+// kubernetes.io/hostname label & vice versa
+//
+// NOTE:
+//	Node name & hostname label of a node may differ based on the
+// cloud provider. CStorPoolCluster selects the node of a pool by
+// hostname whereas CStorClusterPlan refers to a node by its name.
+type HostNameResolver struct {
+	nodeNameToHostName map[string]string
+	hostNameToNodeName map[string]string
+}
+
+// NewHostNameResolver returns a new instance of HostNameResolver
+// built from the given nodes. Resources other than nodes are
+// ignored.
+func NewHostNameResolver(nodes []*unstructured.Unstructured) *HostNameResolver {
+	return NewHostNameResolverWithKey(nodes, types.DefaultNodeSelectorKey)
+}
+
+// NewHostNameResolverWithKey returns a new instance of
+// HostNameResolver that resolves the hostnames from the given label
+// of the given nodes instead of kubernetes.io/hostname
+//
+// NOTE:
+//	This is used when pools select their nodes by a custom label
+func NewHostNameResolverWithKey(
+	nodes []*unstructured.Unstructured, key string,
+) *HostNameResolver {
+	r := &HostNameResolver{
+		nodeNameToHostName: map[string]string{},
+		hostNameToNodeName: map[string]string{},
+	}
+	for _, node := range nodes {
+		if node == nil || node.GetKind() != string(types.KindNode) {
+			continue
+		}
+		hostName := GetNodeSelectorValue(node, key)
+		r.nodeNameToHostName[node.GetName()] = hostName
+		r.hostNameToNodeName[hostName] = node.GetName()
+	}
+	return r
+}
+
+// GetHostName returns the hostname of the node with the given name.
+// Given name is returned if this node is not known.
+func (r *HostNameResolver) GetHostName(nodeName string) string {
+	if r == nil {
+		return nodeName
+	}
+	if hostName := r.nodeNameToHostName[nodeName]; hostName != "" {
+		return hostName
+	}
+	return nodeName
+}
+
+// GetNodeName returns the name of the node with the given hostname.
+// Given hostname is returned if this node is not known.
+func (r *HostNameResolver) GetNodeName(hostName string) string {
+	if r == nil {
+		return hostName
+	}
+	if nodeName := r.hostNameToNodeName[hostName]; nodeName != "" {
+		return nodeName
+	}
+	return hostName
+}
+
+// NodeNameToHostName returns the mapping of node name to hostname
+// of all the known nodes
+func (r *HostNameResolver) NodeNameToHostName() map[string]string {
+	if r == nil {
+		return nil
+	}
+	return r.nodeNameToHostName
+}
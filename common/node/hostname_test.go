@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestNode(name, hostName string) *unstructured.Unstructured {
+	node := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindNode),
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+		},
+	}
+	if hostName != "" {
+		node.SetLabels(map[string]string{"kubernetes.io/hostname": hostName})
+	}
+	return node
+}
+
+func TestHostNameResolver(t *testing.T) {
+	resolver := NewHostNameResolver([]*unstructured.Unstructured{
+		newTestNode("ip-10-0-0-1.ec2.internal", "ip-10-0-0-1"),
+		newTestNode("node-2", ""),
+		nil,
+	})
+	customNode := newTestNode("node-4", "node-4")
+	customNode.SetLabels(map[string]string{
+		"kubernetes.io/hostname": "node-4",
+		"example.com/hostname":   "host-4",
+	})
+	customResolver := NewHostNameResolverWithKey(
+		[]*unstructured.Unstructured{customNode, newTestNode("node-5", "node-5")},
+		"example.com/hostname",
+	)
+	var tests = map[string]struct {
+		resolver       *HostNameResolver
+		nodeName       string
+		expectHostName string
+		hostName       string
+		expectNodeName string
+	}{
+		"mismatched names": {
+			resolver:       resolver,
+			nodeName:       "ip-10-0-0-1.ec2.internal",
+			expectHostName: "ip-10-0-0-1",
+			hostName:       "ip-10-0-0-1",
+			expectNodeName: "ip-10-0-0-1.ec2.internal",
+		},
+		"node without hostname label": {
+			resolver:       resolver,
+			nodeName:       "node-2",
+			expectHostName: "node-2",
+			hostName:       "node-2",
+			expectNodeName: "node-2",
+		},
+		"unknown node": {
+			resolver:       resolver,
+			nodeName:       "node-3",
+			expectHostName: "node-3",
+			hostName:       "host-3",
+			expectNodeName: "host-3",
+		},
+		"custom key": {
+			resolver:       customResolver,
+			nodeName:       "node-4",
+			expectHostName: "host-4",
+			hostName:       "host-4",
+			expectNodeName: "node-4",
+		},
+		"node without custom key": {
+			resolver:       customResolver,
+			nodeName:       "node-5",
+			expectHostName: "node-5",
+			hostName:       "node-5",
+			expectNodeName: "node-5",
+		},
+		"nil resolver": {
+			nodeName:       "node-1",
+			expectHostName: "node-1",
+			hostName:       "host-1",
+			expectNodeName: "host-1",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.resolver.GetHostName(mock.nodeName)
+			if got != mock.expectHostName {
+				t.Fatalf("Expected hostname %q got %q", mock.expectHostName, got)
+			}
+			got = mock.resolver.GetNodeName(mock.hostName)
+			if got != mock.expectNodeName {
+				t.Fatalf("Expected node name %q got %q", mock.expectNodeName, got)
+			}
+		})
+	}
+}
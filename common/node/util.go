@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// IsPoolDecommissionRequested returns true if the given node is
+// annotated to decommission the cstor pool running on it
+func IsPoolDecommissionRequested(obj *unstructured.Unstructured) bool {
+	if obj == nil || obj.GetKind() != string(types.KindNode) {
+		return false
+	}
+	val, _ := unstruct.GetValueForKey(
+		obj.GetAnnotations(), types.AnnKeyNodeDecommissionPool,
+	)
+	return val == "true"
+}
+
+// IsCordoned returns true if the given node is marked unschedulable
+// e.g. when it is cordoned or drained for maintenance
+func IsCordoned(obj *unstructured.Unstructured) bool {
+	if obj == nil || obj.GetKind() != string(types.KindNode) {
+		return false
+	}
+	unschedulable, _, _ := unstructured.NestedBool(obj.Object, "spec", "unschedulable")
+	return unschedulable
+}
+
+// controlPlaneKeys are the label & taint keys that mark a node as
+// a control plane node
+var controlPlaneKeys = []string{
+	types.LblKeyNodeRoleControlPlane,
+	types.LblKeyNodeRoleMaster,
+}
+
+// IsControlPlane returns true if the given node is a control plane
+// node i.e. it has a control plane label or taint. Values of these
+// labels & taints are not evaluated.
+func IsControlPlane(obj *unstructured.Unstructured) bool {
+	if obj == nil || obj.GetKind() != string(types.KindNode) {
+		return false
+	}
+	labels := obj.GetLabels()
+	for _, key := range controlPlaneKeys {
+		if _, found := labels[key]; found {
+			return true
+		}
+	}
+	taints, _, _ := unstructured.NestedSlice(obj.Object, "spec", "taints")
+	for _, taint := range taints {
+		taintMap, ok := taint.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range controlPlaneKeys {
+			if taintMap["key"] == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetCordonedHostNames returns the host names of the given nodes
+// that are cordoned
+func GetCordonedHostNames(nodes []*unstructured.Unstructured) map[string]bool {
+	hostNames := map[string]bool{}
+	for _, node := range nodes {
+		if IsCordoned(node) {
+			hostNames[GetHostName(node)] = true
+		}
+	}
+	return hostNames
+}
+
+// GetHostName returns the hostname of the given node. Node name
+// is returned if hostname label is not set against this node.
+func GetHostName(obj *unstructured.Unstructured) string {
+	return GetNodeSelectorValue(obj, types.DefaultNodeSelectorKey)
+}
+
+// GetNodeSelectorValue returns the value of the given label of the
+// given node that is used by the pools to select this node. Node name
+// is returned if this label is not set against this node.
+func GetNodeSelectorValue(obj *unstructured.Unstructured, key string) string {
+	if obj == nil {
+		return ""
+	}
+	value, _ := unstruct.GetValueForKey(obj.GetLabels(), key)
+	if value != "" {
+		return value
+	}
+	return obj.GetName()
+}
+
+// GetZone returns the topology zone of the given node. An empty
+// value is returned if zone labels are not set against this node.
+func GetZone(obj *unstructured.Unstructured) string {
+	if obj == nil {
+		return ""
+	}
+	for _, key := range []string{types.LblKeyZone, types.LblKeyZoneDeprecated} {
+		zone, _ := unstruct.GetValueForKey(obj.GetLabels(), key)
+		if zone != "" {
+			return zone
+		}
+	}
+	return ""
+}
+
+// GetAllowedHostNames returns the host names of the given nodes
+// that match the given selector. Nodes marked for pool decommission
+// are never allowed.
+func GetAllowedHostNames(
+	selector metac.ResourceSelector, nodes []*unstructured.Unstructured,
+) (map[string]bool, error) {
+	var candidates []*unstructured.Unstructured
+	for _, node := range nodes {
+		if node == nil || IsPoolDecommissionRequested(node) {
+			continue
+		}
+		candidates = append(candidates, node)
+	}
+	allowed := candidates
+	if len(selector.SelectorTerms) != 0 {
+		var err error
+		allowed, _, err = unstruct.SelectAllParallel(selector, candidates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	hostNames := map[string]bool{}
+	for _, node := range allowed {
+		hostNames[GetHostName(node)] = true
+	}
+	return hostNames, nil
+}
+
+// IsStable returns true if the given node was present & ready for
+// at least the given window. Readiness is evaluated from the Ready
+// condition of the node. A node that does not report this condition
+// is evaluated by its presence alone.
+func IsStable(obj *unstructured.Unstructured, window time.Duration, now time.Time) bool {
+	if obj == nil {
+		return false
+	}
+	if window <= 0 {
+		return true
+	}
+	created := obj.GetCreationTimestamp()
+	if !created.IsZero() && now.Sub(created.Time) < window {
+		return false
+	}
+	conds, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, cond := range conds {
+		condMap, ok := cond.(map[string]interface{})
+		if !ok || condMap["type"] != "Ready" {
+			continue
+		}
+		if condMap["status"] != "True" {
+			return false
+		}
+		transition, _ := condMap["lastTransitionTime"].(string)
+		since, err := time.Parse(time.RFC3339, transition)
+		if err != nil {
+			// readiness is not debounced without a valid
+			// transition time
+			return true
+		}
+		return now.Sub(since) >= window
+	}
+	return true
+}
@@ -0,0 +1,503 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestIsPoolDecommissionRequested(t *testing.T) {
+	var tests = map[string]struct {
+		node   *unstructured.Unstructured
+		expect bool
+	}{
+		"nil node": {
+			expect: false,
+		},
+		"node without annotation": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindNode),
+				},
+			},
+			expect: false,
+		},
+		"node with annotation set to false": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindNode),
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							types.AnnKeyNodeDecommissionPool: "false",
+						},
+					},
+				},
+			},
+			expect: false,
+		},
+		"node with annotation set to true": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindNode),
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							types.AnnKeyNodeDecommissionPool: "true",
+						},
+					},
+				},
+			},
+			expect: true,
+		},
+		"non node with annotation set to true": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindBlockDevice),
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							types.AnnKeyNodeDecommissionPool: "true",
+						},
+					},
+				},
+			},
+			expect: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsPoolDecommissionRequested(mock.node)
+			if got != mock.expect {
+				t.Fatalf("Expected %t got %t", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestIsCordoned(t *testing.T) {
+	var tests = map[string]struct {
+		node   *unstructured.Unstructured
+		expect bool
+	}{
+		"nil node": {
+			expect: false,
+		},
+		"schedulable node": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindNode),
+				},
+			},
+			expect: false,
+		},
+		"cordoned node": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindNode),
+					"spec": map[string]interface{}{
+						"unschedulable": true,
+					},
+				},
+			},
+			expect: true,
+		},
+		"uncordoned node": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindNode),
+					"spec": map[string]interface{}{
+						"unschedulable": false,
+					},
+				},
+			},
+			expect: false,
+		},
+		"non node marked unschedulable": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindBlockDevice),
+					"spec": map[string]interface{}{
+						"unschedulable": true,
+					},
+				},
+			},
+			expect: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsCordoned(mock.node)
+			if got != mock.expect {
+				t.Fatalf("Expected %t got %t", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestIsControlPlane(t *testing.T) {
+	newNode := func(labels map[string]string, taints ...string) *unstructured.Unstructured {
+		node := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+			},
+		}
+		node.SetLabels(labels)
+		var taintList []interface{}
+		for _, key := range taints {
+			taintList = append(taintList, map[string]interface{}{
+				"key":    key,
+				"effect": "NoSchedule",
+			})
+		}
+		if len(taintList) != 0 {
+			node.Object["spec"] = map[string]interface{}{"taints": taintList}
+		}
+		return node
+	}
+	var tests = map[string]struct {
+		node   *unstructured.Unstructured
+		expect bool
+	}{
+		"nil node": {
+			expect: false,
+		},
+		"worker node": {
+			node: newNode(
+				map[string]string{"node-role.kubernetes.io/worker": ""},
+				"example.io/dedicated",
+			),
+			expect: false,
+		},
+		"control plane label": {
+			node: newNode(
+				map[string]string{types.LblKeyNodeRoleControlPlane: ""},
+			),
+			expect: true,
+		},
+		"master label": {
+			node: newNode(
+				map[string]string{types.LblKeyNodeRoleMaster: "true"},
+			),
+			expect: true,
+		},
+		"control plane taint": {
+			node:   newNode(nil, "example.io/dedicated", types.LblKeyNodeRoleControlPlane),
+			expect: true,
+		},
+		"master taint": {
+			node:   newNode(nil, types.LblKeyNodeRoleMaster),
+			expect: true,
+		},
+		"non node with control plane label": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindBlockDevice),
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{
+							types.LblKeyNodeRoleControlPlane: "",
+						},
+					},
+				},
+			},
+			expect: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsControlPlane(mock.node)
+			if got != mock.expect {
+				t.Fatalf("Expected %t got %t", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestGetCordonedHostNames(t *testing.T) {
+	newNode := func(name string, unschedulable bool) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname": name + "-host",
+					},
+				},
+				"spec": map[string]interface{}{
+					"unschedulable": unschedulable,
+				},
+			},
+		}
+	}
+	got := GetCordonedHostNames([]*unstructured.Unstructured{
+		nil,
+		newNode("node-1", false),
+		newNode("node-2", true),
+	})
+	if diff := cmp.Diff(map[string]bool{"node-2-host": true}, got); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+}
+
+func TestGetHostName(t *testing.T) {
+	var tests = map[string]struct {
+		node   *unstructured.Unstructured
+		expect string
+	}{
+		"nil node": {
+			expect: "",
+		},
+		"node with hostname label": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "node-1",
+						"labels": map[string]interface{}{
+							"kubernetes.io/hostname": "host-1",
+						},
+					},
+				},
+			},
+			expect: "host-1",
+		},
+		"node without hostname label": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "node-1",
+					},
+				},
+			},
+			expect: "node-1",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := GetHostName(mock.node)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestGetZone(t *testing.T) {
+	var tests = map[string]struct {
+		node   *unstructured.Unstructured
+		expect string
+	}{
+		"nil node": {
+			expect: "",
+		},
+		"node with zone label": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "node-1",
+						"labels": map[string]interface{}{
+							types.LblKeyZone:           "zone-a",
+							types.LblKeyZoneDeprecated: "zone-old",
+						},
+					},
+				},
+			},
+			expect: "zone-a",
+		},
+		"node with deprecated zone label": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "node-1",
+						"labels": map[string]interface{}{
+							types.LblKeyZoneDeprecated: "zone-old",
+						},
+					},
+				},
+			},
+			expect: "zone-old",
+		},
+		"node without zone label": {
+			node: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name": "node-1",
+					},
+				},
+			},
+			expect: "",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := GetZone(mock.node)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestGetAllowedHostNames(t *testing.T) {
+	newNode := func(name, role string, annotations map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname": name,
+						"role":                   role,
+					},
+					"annotations": annotations,
+				},
+			},
+		}
+	}
+	var nodes = []*unstructured.Unstructured{
+		newNode("node-1", "storage", nil),
+		newNode("node-2", "compute", nil),
+		newNode("node-3", "storage", map[string]interface{}{
+			types.AnnKeyNodeDecommissionPool: "true",
+		}),
+	}
+	var tests = map[string]struct {
+		selector metac.ResourceSelector
+		expect   map[string]bool
+	}{
+		"no selector terms": {
+			expect: map[string]bool{
+				"node-1": true,
+				"node-2": true,
+			},
+		},
+		"selector terms": {
+			selector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					{
+						MatchLabels: map[string]string{
+							"role": "storage",
+						},
+					},
+				},
+			},
+			expect: map[string]bool{
+				"node-1": true,
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := GetAllowedHostNames(mock.selector, nodes)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
+
+func TestIsStable(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	window := 5 * time.Minute
+	newNode := func(created string, readyStatus string, transition string) *unstructured.Unstructured {
+		node := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+				"metadata": map[string]interface{}{
+					"name":              "node-1",
+					"creationTimestamp": created,
+				},
+			},
+		}
+		if readyStatus != "" {
+			node.Object["status"] = map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":               "Ready",
+						"status":             readyStatus,
+						"lastTransitionTime": transition,
+					},
+				},
+			}
+		}
+		return node
+	}
+	var tests = map[string]struct {
+		node   *unstructured.Unstructured
+		window time.Duration
+		expect bool
+	}{
+		"nil node": {
+			window: window,
+		},
+		"disabled window": {
+			node:   newNode("2020-01-01T09:59:00Z", "False", "2020-01-01T09:59:00Z"),
+			expect: true,
+		},
+		"old node without ready condition": {
+			node:   newNode("2020-01-01T09:00:00Z", "", ""),
+			window: window,
+			expect: true,
+		},
+		"recently created node": {
+			node:   newNode("2020-01-01T09:58:00Z", "True", "2020-01-01T09:58:00Z"),
+			window: window,
+		},
+		"not ready node": {
+			node:   newNode("2020-01-01T09:00:00Z", "False", "2020-01-01T09:00:00Z"),
+			window: window,
+		},
+		"recently ready node": {
+			node:   newNode("2020-01-01T09:00:00Z", "True", "2020-01-01T09:57:00Z"),
+			window: window,
+		},
+		"ready node beyond window": {
+			node:   newNode("2020-01-01T09:00:00Z", "True", "2020-01-01T09:50:00Z"),
+			window: window,
+			expect: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsStable(mock.node, mock.window, now)
+			if got != mock.expect {
+				t.Fatalf("Expected %t got %t", mock.expect, got)
+			}
+		})
+	}
+}
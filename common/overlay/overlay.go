@@ -0,0 +1,174 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package overlay applies the patches set by users against the
+// children generated by this operator. This is an escape hatch to
+// set the fields that are not modelled by CStorClusterConfig.
+//
+// NOTE:
+//	Strategic patches of PersistentVolumeClaim(s) are merged as per
+// the merge keys of their schema. Custom resources have no such
+// schema & are hence merged as per RFC 7386 i.e. lists are replaced
+// as a whole.
+package overlay
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/yaml"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// getPatchType returns the patch type of the given overlay
+func getPatchType(given types.Overlay) types.OverlayPatchType {
+	if given.PatchType == "" {
+		return types.OverlayPatchTypeDefault
+	}
+	return given.PatchType
+}
+
+// parse returns the JSON form of the patch of the given overlay
+func parse(index int, given types.Overlay) ([]byte, error) {
+	if !types.SupportedOverlayTargetKinds[given.TargetKind] {
+		return nil, errs.ValidationErrorf(
+			"Invalid overlay %d: Unsupported target kind %q", index, given.TargetKind,
+		)
+	}
+	patchType := getPatchType(given)
+	if !types.SupportedOverlayPatchTypes[patchType] {
+		return nil, errs.ValidationErrorf(
+			"Invalid overlay %d: Unsupported patch type %q", index, patchType,
+		)
+	}
+	patch, err := yaml.YAMLToJSON([]byte(given.Patch))
+	if err != nil {
+		return nil, errs.ValidationErrorf(
+			"Invalid overlay %d: Invalid patch: %v", index, err,
+		)
+	}
+	switch patchType {
+	case types.OverlayPatchTypeStrategic:
+		var obj map[string]interface{}
+		if json.Unmarshal(patch, &obj) != nil || len(obj) == 0 {
+			return nil, errs.ValidationErrorf(
+				"Invalid overlay %d: Want a non empty object for %s patch", index, patchType,
+			)
+		}
+	case types.OverlayPatchTypeJSON:
+		ops, err := jsonpatch.DecodePatch(patch)
+		if err != nil || len(ops) == 0 {
+			return nil, errs.ValidationErrorf(
+				"Invalid overlay %d: Want a non empty list of operations for %s patch",
+				index, patchType,
+			)
+		}
+	}
+	return patch, nil
+}
+
+// Validate verifies if the given overlays have supported target
+// kinds & patch types & if their patches can be parsed
+func Validate(overlays []types.Overlay) error {
+	for index, given := range overlays {
+		_, err := parse(index, given)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Apply patches the given object with each of the given overlays
+// that target its kind. Overlays are applied in the given order.
+//
+// NOTE:
+//	An overlay that changes the apiVersion, kind, name or namespace
+// of the object is rejected since metac would otherwise treat the
+// patched object as a different child.
+func Apply(obj *unstructured.Unstructured, overlays []types.Overlay) error {
+	if obj == nil || obj.Object == nil {
+		return nil
+	}
+	for index, given := range overlays {
+		if string(given.TargetKind) != obj.GetKind() {
+			continue
+		}
+		patch, err := parse(index, given)
+		if err != nil {
+			return err
+		}
+		original, err := obj.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		var patched []byte
+		switch getPatchType(given) {
+		case types.OverlayPatchTypeJSON:
+			ops, _ := jsonpatch.DecodePatch(patch)
+			patched, err = ops.Apply(original)
+		default:
+			if given.TargetKind == types.OverlayTargetKindPersistentVolumeClaim {
+				patched, err = strategicpatch.StrategicMergePatch(
+					original, patch, corev1.PersistentVolumeClaim{},
+				)
+			} else {
+				patched, err = jsonpatch.MergePatch(original, patch)
+			}
+		}
+		if err != nil {
+			return errs.ValidationErrorf(
+				"Can't apply overlay %d to %s %q / %q: %v",
+				index, obj.GetKind(), obj.GetNamespace(), obj.GetName(), err,
+			)
+		}
+		result := &unstructured.Unstructured{}
+		err = result.UnmarshalJSON(patched)
+		if err != nil {
+			return errs.ValidationErrorf(
+				"Can't apply overlay %d to %s %q / %q: %v",
+				index, obj.GetKind(), obj.GetNamespace(), obj.GetName(), err,
+			)
+		}
+		if result.GetAPIVersion() != obj.GetAPIVersion() ||
+			result.GetKind() != obj.GetKind() ||
+			result.GetName() != obj.GetName() ||
+			result.GetNamespace() != obj.GetNamespace() {
+			return errs.ValidationErrorf(
+				"Can't apply overlay %d to %s %q / %q: Can't change apiVersion, kind, name or namespace",
+				index, obj.GetKind(), obj.GetNamespace(), obj.GetName(),
+			)
+		}
+		obj.Object = result.Object
+	}
+	return nil
+}
+
+// ApplyAll patches each of the given objects with the given overlays
+func ApplyAll(objs []*unstructured.Unstructured, overlays []types.Overlay) error {
+	for _, obj := range objs {
+		err := Apply(obj, overlays)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
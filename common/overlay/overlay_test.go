@@ -0,0 +1,326 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package overlay
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newCSPC() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       "CStorPoolCluster",
+			"metadata": map[string]interface{}{
+				"name":      "my-cspc",
+				"namespace": "openebs",
+			},
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"nodeSelector": map[string]interface{}{
+							"kubernetes.io/hostname": "node-1",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newPVC() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata": map[string]interface{}{
+				"name":      "my-pvc",
+				"namespace": "openebs",
+				"finalizers": []interface{}{
+					"kubernetes.io/pvc-protection",
+				},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var tests = map[string]struct {
+		overlays []types.Overlay
+		isErr    bool
+	}{
+		"no overlays": {},
+		"valid strategic overlay": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					Patch:      "spec:\n  pools: []",
+				},
+			},
+		},
+		"valid json overlay": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindPersistentVolumeClaim,
+					PatchType:  types.OverlayPatchTypeJSON,
+					Patch:      `[{"op":"add","path":"/metadata/labels","value":{}}]`,
+				},
+			},
+		},
+		"unsupported target kind": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: "StorageClass",
+					Patch:      "metadata: {}",
+				},
+			},
+			isErr: true,
+		},
+		"unsupported patch type": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					PatchType:  "merge",
+					Patch:      "metadata: {}",
+				},
+			},
+			isErr: true,
+		},
+		"invalid yaml": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					Patch:      "spec: [",
+				},
+			},
+			isErr: true,
+		},
+		"empty strategic patch": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+				},
+			},
+			isErr: true,
+		},
+		"list as strategic patch": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					Patch:      "- spec",
+				},
+			},
+			isErr: true,
+		},
+		"object as json patch": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					PatchType:  types.OverlayPatchTypeJSON,
+					Patch:      "spec: {}",
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := Validate(mock.overlays)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr && errs.TypeOf(err) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	var tests = map[string]struct {
+		obj      *unstructured.Unstructured
+		overlays []types.Overlay
+		expect   *unstructured.Unstructured
+		isErr    bool
+	}{
+		"nil object": {
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					Patch:      "spec: {}",
+				},
+			},
+		},
+		"overlay of other kind": {
+			obj: newCSPC(),
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorClusterStorageSet,
+					Patch:      "spec:\n  pools: []",
+				},
+			},
+			expect: newCSPC(),
+		},
+		"strategic patch of custom resource": {
+			obj: newCSPC(),
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					Patch:      "spec:\n  pools:\n  - nodeSelector:\n      zone: a\n  priorityClassName: high",
+				},
+			},
+			expect: func() *unstructured.Unstructured {
+				obj := newCSPC()
+				obj.Object["spec"] = map[string]interface{}{
+					"pools": []interface{}{
+						map[string]interface{}{
+							"nodeSelector": map[string]interface{}{
+								"zone": "a",
+							},
+						},
+					},
+					"priorityClassName": "high",
+				}
+				return obj
+			}(),
+		},
+		"strategic patch of pvc merges lists by merge keys": {
+			obj: newPVC(),
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindPersistentVolumeClaim,
+					Patch:      `{"metadata":{"finalizers":["example.com/backup"]}}`,
+				},
+			},
+			expect: func() *unstructured.Unstructured {
+				obj := newPVC()
+				unstructured.SetNestedStringSlice(
+					obj.Object,
+					[]string{"example.com/backup", "kubernetes.io/pvc-protection"},
+					"metadata", "finalizers",
+				)
+				return obj
+			}(),
+		},
+		"json patch": {
+			obj: newCSPC(),
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					PatchType:  types.OverlayPatchTypeJSON,
+					Patch:      `[{"op":"add","path":"/spec/pools/0/tolerations","value":[]}]`,
+				},
+			},
+			expect: func() *unstructured.Unstructured {
+				obj := newCSPC()
+				pools, _, _ := unstructured.NestedSlice(obj.Object, "spec", "pools")
+				pools[0].(map[string]interface{})["tolerations"] = []interface{}{}
+				unstructured.SetNestedSlice(obj.Object, pools, "spec", "pools")
+				return obj
+			}(),
+		},
+		"overlays are applied in order": {
+			obj: newCSPC(),
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					Patch:      "metadata:\n  labels:\n    team: db",
+				},
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					PatchType:  types.OverlayPatchTypeJSON,
+					Patch:      `[{"op":"replace","path":"/metadata/labels/team","value":"web"}]`,
+				},
+			},
+			expect: func() *unstructured.Unstructured {
+				obj := newCSPC()
+				obj.SetLabels(map[string]string{"team": "web"})
+				return obj
+			}(),
+		},
+		"json patch of missing path": {
+			obj: newCSPC(),
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					PatchType:  types.OverlayPatchTypeJSON,
+					Patch:      `[{"op":"replace","path":"/spec/pools/4/tolerations","value":[]}]`,
+				},
+			},
+			isErr: true,
+		},
+		"patch that renames": {
+			obj: newCSPC(),
+			overlays: []types.Overlay{
+				{
+					TargetKind: types.OverlayTargetKindCStorPoolCluster,
+					Patch:      "metadata:\n  name: other",
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := Apply(mock.obj, mock.overlays)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr || mock.obj == nil {
+				return
+			}
+			if diff := cmp.Diff(mock.expect.Object, mock.obj.Object); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyAll(t *testing.T) {
+	objs := []*unstructured.Unstructured{newPVC(), newCSPC()}
+	err := ApplyAll(objs, []types.Overlay{
+		{
+			TargetKind: types.OverlayTargetKindPersistentVolumeClaim,
+			Patch:      "metadata:\n  labels:\n    team: db",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if objs[0].GetLabels()["team"] != "db" {
+		t.Fatalf("Expected pvc to be patched got labels %v", objs[0].GetLabels())
+	}
+	if len(objs[1].GetLabels()) != 0 {
+		t.Fatalf("Expected cspc not to be patched got labels %v", objs[1].GetLabels())
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package owner arbitrates the ownership of the spec of the
+// CStorPoolCluster(s) of a CStorClusterConfig.
+//
+// NOTE:
+//	CStorPoolCluster is built by localdevice controller if the disk
+// config is local & by cstorpoolcluster controller otherwise. Both
+// the controllers may end up applying the same CStorPoolCluster once
+// the disk config is switched. Owner of a CStorPoolCluster is hence
+// recorded against it & the other controller skips its syncs till
+// the switch of ownership is approved.
+package owner
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+const (
+	// LocalDevice owns the CStorPoolCluster built from local disks
+	LocalDevice string = "LocalDevice"
+
+	// CStorPoolCluster owns the CStorPoolCluster built from the
+	// storage sets of a CStorClusterPlan
+	CStorPoolCluster string = "CStorPoolCluster"
+)
+
+// Get returns the owner of the given CStorPoolCluster
+//
+// NOTE:
+//	Owner of a CStorPoolCluster that was created before the owner
+// annotation was introduced is inferred from the annotations set by
+// its controller. Empty value implies the CStorPoolCluster is not
+// owned by any controller e.g. a manually created one.
+func Get(cspc *unstructured.Unstructured) string {
+	if cspc == nil {
+		return ""
+	}
+	annotations := cspc.GetAnnotations()
+	if owner, _ := unstruct.GetValueForKey(
+		annotations, types.AnnKeyCStorPoolClusterOwner,
+	); owner != "" {
+		return owner
+	}
+	if isLocal, _ := unstruct.GetValueForKey(
+		annotations, types.AnnKeyCStorClusterConfigLocalDisk,
+	); isLocal == "true" {
+		return LocalDevice
+	}
+	if planUID, _ := unstruct.GetValueForKey(
+		annotations, types.AnnKeyCStorClusterPlanUID,
+	); planUID != "" {
+		return CStorPoolCluster
+	}
+	return ""
+}
+
+// Set records the given owner against the given CStorPoolCluster
+func Set(cspc *unstructured.Unstructured, owner string) {
+	if cspc == nil {
+		return
+	}
+	annotations := cspc.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[types.AnnKeyCStorPoolClusterOwner] = owner
+	cspc.SetAnnotations(annotations)
+}
+
+// Result is the outcome of arbitrating the ownership of the
+// CStorPoolCluster(s) of a CStorClusterConfig
+type Result struct {
+	// IsOwner is true if the controller may apply the
+	// CStorPoolCluster(s) of the config
+	IsOwner bool
+
+	// Reason explains why the controller is not the owner
+	Reason string
+
+	// TakenOver has the CStorPoolCluster(s) that were owned by
+	// another controller & whose switch of ownership was approved
+	TakenOver []*unstructured.Unstructured
+}
+
+// Arbitrate decides if the given controller owns the given
+// CStorPoolCluster(s) of the given CStorClusterConfig
+//
+// NOTE:
+//	CStorPoolCluster(s) owned by another controller are taken over
+// only if the config approves the switch of ownership to the given
+// controller
+func Arbitrate(
+	controller string,
+	config *unstructured.Unstructured,
+	cspcs []*unstructured.Unstructured,
+) Result {
+	var approved string
+	if config != nil {
+		approved, _ = unstruct.GetValueForKey(
+			config.GetAnnotations(), types.AnnKeyCStorClusterConfigApproveOwnerSwitch,
+		)
+	}
+	var takenOver []*unstructured.Unstructured
+	for _, cspc := range cspcs {
+		current := Get(cspc)
+		if current == "" || current == controller {
+			continue
+		}
+		if approved != controller {
+			return Result{
+				Reason: fmt.Sprintf(
+					"CStorPoolCluster %q / %q is owned by %s: Annotate CStorClusterConfig with %s=%s to switch",
+					cspc.GetNamespace(),
+					cspc.GetName(),
+					current,
+					types.AnnKeyCStorClusterConfigApproveOwnerSwitch,
+					controller,
+				),
+			}
+		}
+		glog.V(2).Infof(
+			"Will switch owner of CStorPoolCluster %q / %q from %s to %s",
+			cspc.GetNamespace(), cspc.GetName(), current, controller,
+		)
+		takenOver = append(takenOver, cspc)
+	}
+	return Result{IsOwner: true, TakenOver: takenOver}
+}
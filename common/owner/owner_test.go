@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package owner
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newCSPC(annotations map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name":        "my-cspc",
+				"namespace":   "openebs",
+				"annotations": annotations,
+			},
+		},
+	}
+}
+
+func TestGet(t *testing.T) {
+	var tests = map[string]struct {
+		cspc   *unstructured.Unstructured
+		expect string
+	}{
+		"nil cspc": {},
+		"manually created cspc": {
+			cspc: newCSPC(nil),
+		},
+		"owner annotation": {
+			cspc: newCSPC(map[string]interface{}{
+				types.AnnKeyCStorPoolClusterOwner:       CStorPoolCluster,
+				types.AnnKeyCStorClusterConfigLocalDisk: "true",
+			}),
+			expect: CStorPoolCluster,
+		},
+		"legacy local disk cspc": {
+			cspc: newCSPC(map[string]interface{}{
+				types.AnnKeyCStorClusterConfigLocalDisk: "true",
+			}),
+			expect: LocalDevice,
+		},
+		"legacy planned cspc": {
+			cspc: newCSPC(map[string]interface{}{
+				types.AnnKeyCStorClusterPlanUID: "plan-101",
+			}),
+			expect: CStorPoolCluster,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := Get(mock.cspc)
+			if got != mock.expect {
+				t.Fatalf("Expected owner %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	cspc := newCSPC(nil)
+	Set(cspc, LocalDevice)
+	if Get(cspc) != LocalDevice {
+		t.Fatalf("Expected owner %q got %q", LocalDevice, Get(cspc))
+	}
+	Set(cspc, CStorPoolCluster)
+	if Get(cspc) != CStorPoolCluster {
+		t.Fatalf("Expected owner %q got %q", CStorPoolCluster, Get(cspc))
+	}
+	// nil cspc is ignored
+	Set(nil, LocalDevice)
+}
+
+func TestArbitrate(t *testing.T) {
+	newConfig := func(approved string) *unstructured.Unstructured {
+		config := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+			},
+		}
+		if approved != "" {
+			config.SetAnnotations(map[string]string{
+				types.AnnKeyCStorClusterConfigApproveOwnerSwitch: approved,
+			})
+		}
+		return config
+	}
+	localCSPC := newCSPC(map[string]interface{}{
+		types.AnnKeyCStorClusterConfigLocalDisk: "true",
+	})
+	plannedCSPC := newCSPC(map[string]interface{}{
+		types.AnnKeyCStorPoolClusterOwner: CStorPoolCluster,
+	})
+	var tests = map[string]struct {
+		config          *unstructured.Unstructured
+		cspcs           []*unstructured.Unstructured
+		isOwner         bool
+		expectTakenOver int
+	}{
+		"no cspc": {
+			config:  newConfig(""),
+			isOwner: true,
+		},
+		"nil config": {
+			cspcs:   []*unstructured.Unstructured{plannedCSPC},
+			isOwner: false,
+		},
+		"manually created cspc": {
+			config:  newConfig(""),
+			cspcs:   []*unstructured.Unstructured{newCSPC(nil)},
+			isOwner: true,
+		},
+		"own cspc": {
+			config:  newConfig(""),
+			cspcs:   []*unstructured.Unstructured{localCSPC},
+			isOwner: true,
+		},
+		"cspc of other owner": {
+			config:  newConfig(""),
+			cspcs:   []*unstructured.Unstructured{plannedCSPC},
+			isOwner: false,
+		},
+		"cspc of other owner && switch approved to other": {
+			config:  newConfig(CStorPoolCluster),
+			cspcs:   []*unstructured.Unstructured{plannedCSPC},
+			isOwner: false,
+		},
+		"cspc of other owner && switch approved": {
+			config:          newConfig(LocalDevice),
+			cspcs:           []*unstructured.Unstructured{localCSPC, plannedCSPC},
+			isOwner:         true,
+			expectTakenOver: 1,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := Arbitrate(LocalDevice, mock.config, mock.cspcs)
+			if got.IsOwner != mock.isOwner {
+				t.Fatalf("Expected owner %t got %t: %s", mock.isOwner, got.IsOwner, got.Reason)
+			}
+			if !got.IsOwner && got.Reason == "" {
+				t.Fatalf("Expected reason got none")
+			}
+			if len(got.TakenOver) != mock.expectTakenOver {
+				t.Fatalf(
+					"Expected taken over count %d got %d",
+					mock.expectTakenOver, len(got.TakenOver),
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pause lets the controllers skip their reconciliations
+// while the automation of a CStorClusterConfig is paused.
+//
+// NOTE:
+//	Automation is paused by annotating the CStorClusterConfig with
+// dao.mayadata.io/paused=true. Every controller that watches this
+// config or the resources derived from it reports the Paused
+// condition against its watch & skips the reconciliation of its
+// attachments. Resources are neither created, updated nor deleted
+// while the automation is paused.
+package pause
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/common/skip"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// IsPaused returns true if automation is paused for the given
+// CStorClusterConfig
+func IsPaused(config *unstructured.Unstructured) (bool, error) {
+	if config == nil {
+		return false, nil
+	}
+	val := config.GetAnnotations()[types.AnnKeyCStorClusterConfigPaused]
+	if val == "" {
+		return false, nil
+	}
+	isPaused, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, errs.AsValidationError(errors.Wrapf(
+			err,
+			"Invalid annotation %q: CStorClusterConfig %q / %q",
+			types.AnnKeyCStorClusterConfigPaused,
+			config.GetNamespace(),
+			config.GetName(),
+		))
+	}
+	return isPaused, nil
+}
+
+// HasCondition returns true if Paused condition with status True
+// is set against the given object
+func HasCondition(obj *unstructured.Unstructured) bool {
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	return conditions.IsConditionTrue(status, types.CStorClusterConfigPausedCondition)
+}
+
+// SetCondition sets the Paused condition against the given status
+//
+// NOTE:
+//	Existing condition is retained as is if its status did not
+// change. This keeps the status same across syncs.
+func SetCondition(status map[string]interface{}, isPaused bool) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	newCond := types.MakeCStorClusterConfigPausedCond(isPaused)
+	oldCond, found, err := conditions.GetCondition(
+		status, types.CStorClusterConfigPausedCondition,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set paused condition")
+	}
+	if found && oldCond["status"] == newCond["status"] {
+		return status, nil
+	}
+	status, err = conditions.SetCondition(status, newCond)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set paused condition")
+	}
+	return status, nil
+}
+
+// Skip sets the given response to skip the reconciliation of the
+// given controller if the automation of the given CStorClusterConfig
+// is paused. It returns true if the reconciliation was skipped.
+//
+// NOTE:
+//	The sync that follows a resume is skipped as well after setting
+// the Paused condition to False against the watch. This update of
+// the watch results in a fresh sync that reconciles as usual.
+func Skip(
+	controller string,
+	config, watch *unstructured.Unstructured,
+	response *generic.SyncHookResponse,
+) (bool, error) {
+	isPaused, err := IsPaused(config)
+	if err != nil {
+		return false, err
+	}
+	if !isPaused && !HasCondition(watch) {
+		// nothing to do since automation was never paused
+		return false, nil
+	}
+	// status is copied to avoid modifying the watch
+	status, _, err := unstructured.NestedMap(watch.Object, "status")
+	if err != nil {
+		return false, errors.Wrapf(
+			err,
+			"Can't set paused condition: Watch %q - %q / %q",
+			watch.GetKind(),
+			watch.GetNamespace(),
+			watch.GetName(),
+		)
+	}
+	response.Status, err = SetCondition(status, isPaused)
+	if err != nil {
+		return false, err
+	}
+	reason, message := skip.ReasonResumed, "Automation resumed"
+	if isPaused {
+		reason, message = skip.ReasonPaused, "Automation paused"
+	}
+	err = skip.Skip(controller, watch, response, reason, message)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pause
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// newTestConfig returns a CStorClusterConfig with the given
+// value of paused annotation
+func newTestConfig(paused string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(string(types.APIVersionDAOMayaDataV1Alpha1))
+	obj.SetKind(string(types.KindCStorClusterConfig))
+	obj.SetNamespace("openebs")
+	obj.SetName("my-config")
+	if paused != "" {
+		obj.SetAnnotations(map[string]string{
+			types.AnnKeyCStorClusterConfigPaused: paused,
+		})
+	}
+	return obj
+}
+
+// newTestWatch returns a watch with the given conditions
+func newTestWatch(conds ...interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": string(types.APIVersionDAOMayaDataV1Alpha1),
+			"kind":       string(types.KindCStorClusterPlan),
+			"metadata": map[string]interface{}{
+				"namespace": "openebs",
+				"name":      "my-plan",
+			},
+		},
+	}
+	if len(conds) != 0 {
+		obj.Object["status"] = map[string]interface{}{
+			"conditions": conds,
+		}
+	}
+	return obj
+}
+
+func TestIsPaused(t *testing.T) {
+	var tests = map[string]struct {
+		config   *unstructured.Unstructured
+		isPaused bool
+		isErr    bool
+	}{
+		"nil config": {
+			config: nil,
+		},
+		"no annotation": {
+			config: newTestConfig(""),
+		},
+		"paused": {
+			config:   newTestConfig("true"),
+			isPaused: true,
+		},
+		"not paused": {
+			config: newTestConfig("false"),
+		},
+		"invalid annotation": {
+			config: newTestConfig("yes please"),
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := IsPaused(mock.config)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.isPaused {
+				t.Fatalf("Expected paused %t got %t", mock.isPaused, got)
+			}
+		})
+	}
+}
+
+func TestSkip(t *testing.T) {
+	pausedCond := map[string]interface{}{
+		"type":             string(types.CStorClusterConfigPausedCondition),
+		"status":           string(types.ConditionIsPresent),
+		"lastObservedTime": "2020-01-01T00:00:00Z",
+	}
+	resumedCond := map[string]interface{}{
+		"type":             string(types.CStorClusterConfigPausedCondition),
+		"status":           string(types.ConditionIsAbsent),
+		"lastObservedTime": "2020-01-01T00:00:00Z",
+	}
+	otherCond := map[string]interface{}{
+		"type":   "Other",
+		"status": string(types.ConditionIsAbsent),
+	}
+	var tests = map[string]struct {
+		config       *unstructured.Unstructured
+		watch        *unstructured.Unstructured
+		isSkip       bool
+		expectCount  int
+		expectStatus types.ConditionState
+		isRetained   bool
+		isErr        bool
+	}{
+		"never paused": {
+			config: newTestConfig(""),
+			watch:  newTestWatch(otherCond),
+		},
+		"already resumed": {
+			config: newTestConfig("false"),
+			watch:  newTestWatch(otherCond, resumedCond),
+		},
+		"paused": {
+			config:       newTestConfig("true"),
+			watch:        newTestWatch(otherCond),
+			isSkip:       true,
+			expectCount:  2,
+			expectStatus: types.ConditionIsPresent,
+		},
+		"paused without status": {
+			config:       newTestConfig("true"),
+			watch:        newTestWatch(),
+			isSkip:       true,
+			expectCount:  1,
+			expectStatus: types.ConditionIsPresent,
+		},
+		"still paused": {
+			config:       newTestConfig("true"),
+			watch:        newTestWatch(otherCond, pausedCond),
+			isSkip:       true,
+			expectCount:  2,
+			expectStatus: types.ConditionIsPresent,
+			isRetained:   true,
+		},
+		"paused after resume": {
+			config:       newTestConfig("true"),
+			watch:        newTestWatch(resumedCond),
+			isSkip:       true,
+			expectCount:  1,
+			expectStatus: types.ConditionIsPresent,
+		},
+		"resumed": {
+			config:       newTestConfig(""),
+			watch:        newTestWatch(otherCond, pausedCond),
+			isSkip:       true,
+			expectCount:  2,
+			expectStatus: types.ConditionIsAbsent,
+		},
+		"invalid annotation": {
+			config: newTestConfig("yes please"),
+			watch:  newTestWatch(),
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			response := &generic.SyncHookResponse{}
+			got, err := Skip("Test", mock.config, mock.watch, response)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.isSkip {
+				t.Fatalf("Expected skip %t got %t", mock.isSkip, got)
+			}
+			if response.SkipReconcile != mock.isSkip {
+				t.Fatalf(
+					"Expected response skip %t got %t",
+					mock.isSkip, response.SkipReconcile,
+				)
+			}
+			if !mock.isSkip {
+				if response.Status != nil {
+					t.Fatalf("Expected nil status got %v", response.Status)
+				}
+				return
+			}
+			conds := response.Status["conditions"].([]interface{})
+			if len(conds) != mock.expectCount {
+				t.Fatalf("Expected condition count %d got %d", mock.expectCount, len(conds))
+			}
+			var pausedCond map[string]interface{}
+			for _, cond := range conds {
+				condMap := cond.(map[string]interface{})
+				if condMap["type"] == string(types.CStorClusterConfigPausedCondition) {
+					pausedCond = condMap
+				}
+			}
+			if pausedCond["status"] != string(mock.expectStatus) {
+				t.Fatalf("Expected status %q got %v", mock.expectStatus, pausedCond["status"])
+			}
+			isRetained := pausedCond["lastObservedTime"] == "2020-01-01T00:00:00Z"
+			if isRetained != mock.isRetained {
+				t.Fatalf("Expected retained %t got %t", mock.isRetained, isRetained)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package raidchange orchestrates the change of raid type of the
+// pools of a CStorPoolCluster.
+//
+// NOTE:
+//	Raid type of an existing pool can not be changed in place. The
+// pool is removed from CStorPoolCluster & is added back with the new
+// raid type once its pool instance is deleted. Data on this pool is
+// lost. Hence the change is made only after it is approved & only
+// one node at a time.
+package raidchange
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	cspc "mayadata.io/cstorpoolauto/common/cstorpoolcluster"
+	cspcv1alpha1 "mayadata.io/cstorpoolauto/common/cstorpoolcluster/v1alpha1"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// Result is the outcome of orchestrating the raid type change of
+// the pools of a CStorPoolCluster
+type Result struct {
+	// CStorPoolCluster that should be applied
+	CStorPoolCluster *unstructured.Unstructured
+
+	// IsRequested is true if the raid type of any observed pool
+	// differs from the desired raid type or if any pool is being
+	// re-created
+	IsRequested bool
+
+	// Reason explains the progress of the raid type change if any
+	Reason string
+}
+
+// Orchestrator re-creates the pools of a CStorPoolCluster whose raid
+// type differs from the desired raid type
+//
+// NOTE:
+//	Observed pools are retained till the change is approved. Once
+// approved, a single pool is removed at a time & only if all the
+// pool instances are online. Pool of a node is added back only after
+// its earlier pool instance is deleted.
+type Orchestrator struct {
+	// DesiredRAIDType is the raid type set in CStorClusterConfig
+	DesiredRAIDType types.PoolRAIDType
+
+	// ApprovedRAIDType is the raid type the change to which was
+	// approved via the approval annotation
+	ApprovedRAIDType types.PoolRAIDType
+
+	ObservedCStorPoolCluster   *unstructured.Unstructured
+	DesiredCStorPoolCluster    *unstructured.Unstructured
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+}
+
+// Orchestrate returns the CStorPoolCluster that should be applied
+func (o *Orchestrator) Orchestrate() (Result, error) {
+	if o.DesiredCStorPoolCluster == nil {
+		return Result{}, errors.Errorf(
+			"Can't orchestrate raid type change: Nil desired CStorPoolCluster",
+		)
+	}
+	if o.ObservedCStorPoolCluster == nil {
+		// nothing to change before CStorPoolCluster is created
+		return Result{CStorPoolCluster: o.DesiredCStorPoolCluster}, nil
+	}
+	observedTypes, err := getHostNameToRAIDGroupType(o.ObservedCStorPoolCluster)
+	if err != nil {
+		return Result{}, err
+	}
+	desiredTypes, err := getHostNameToRAIDGroupType(o.DesiredCStorPoolCluster)
+	if err != nil {
+		return Result{}, err
+	}
+	hostNameToPhase := o.getHostNameToPoolInstancePhase()
+	var changedHostNames, deletingHostNames []string
+	for hostName, desiredType := range desiredTypes {
+		observedType, found := observedTypes[hostName]
+		if !found {
+			if _, isDeleting := hostNameToPhase[hostName]; isDeleting {
+				// earlier pool instance of this node is not yet deleted
+				deletingHostNames = append(deletingHostNames, hostName)
+			}
+			continue
+		}
+		if observedType != "" && observedType != desiredType {
+			changedHostNames = append(changedHostNames, hostName)
+		}
+	}
+	if len(changedHostNames) == 0 && len(deletingHostNames) == 0 {
+		return Result{CStorPoolCluster: o.DesiredCStorPoolCluster}, nil
+	}
+	// sorted to re-create the pools in the same order across syncs
+	sort.Strings(changedHostNames)
+	sort.Strings(deletingHostNames)
+	retain := map[string]bool{}
+	for _, hostName := range changedHostNames {
+		retain[hostName] = true
+	}
+	remove := map[string]bool{}
+	for _, hostName := range deletingHostNames {
+		remove[hostName] = true
+	}
+	var reasons []string
+	if len(deletingHostNames) != 0 {
+		reasons = append(reasons, fmt.Sprintf(
+			"Waiting for pool instances of node(s) %v to be deleted", deletingHostNames,
+		))
+	}
+	if len(changedHostNames) != 0 {
+		reason := o.checkSafety(observedTypes, hostNameToPhase, deletingHostNames)
+		if reason == "" {
+			// pool of the first node is removed & re-created later
+			hostName := changedHostNames[0]
+			delete(retain, hostName)
+			remove[hostName] = true
+			reason = fmt.Sprintf(
+				"Re-creating pool of node %q with raid type %q: Pending node(s) %v",
+				hostName, o.DesiredRAIDType, changedHostNames[1:],
+			)
+			glog.Warningf(
+				"Will remove pool of node %q from CStorPoolCluster %q / %q: Raid type changed to %q",
+				hostName,
+				o.ObservedCStorPoolCluster.GetNamespace(),
+				o.ObservedCStorPoolCluster.GetName(),
+				o.DesiredRAIDType,
+			)
+		}
+		reasons = append(reasons, reason)
+	}
+	final, err := o.withPools(retain, remove)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{
+		CStorPoolCluster: final,
+		IsRequested:      true,
+		Reason:           strings.Join(reasons, ": "),
+	}, nil
+}
+
+// checkSafety returns the reason that holds back the re-creation of
+// a pool. Empty reason implies a pool can be re-created.
+func (o *Orchestrator) checkSafety(
+	observedTypes map[string]string,
+	hostNameToPhase map[string]string,
+	deletingHostNames []string,
+) string {
+	if o.ApprovedRAIDType != o.DesiredRAIDType {
+		return fmt.Sprintf(
+			"Raid type change to %q needs approval: Annotate CStorClusterConfig with %s=%s",
+			o.DesiredRAIDType,
+			types.AnnKeyCStorClusterConfigApproveRAIDTypeChange,
+			o.DesiredRAIDType,
+		)
+	}
+	if len(observedTypes) < 2 {
+		return fmt.Sprintf(
+			"Raid type change to %q is on hold: Only pool can't be re-created",
+			o.DesiredRAIDType,
+		)
+	}
+	if len(deletingHostNames) != 0 {
+		return fmt.Sprintf(
+			"Raid type change to %q is on hold: Another pool is being re-created",
+			o.DesiredRAIDType,
+		)
+	}
+	// replicas of the re-created pool are rebuilt from the other
+	// pools & hence all of them should be online
+	var notOnlineHostNames []string
+	for hostName := range observedTypes {
+		if !strings.EqualFold(hostNameToPhase[hostName], "online") {
+			notOnlineHostNames = append(notOnlineHostNames, hostName)
+		}
+	}
+	if len(notOnlineHostNames) != 0 {
+		sort.Strings(notOnlineHostNames)
+		return fmt.Sprintf(
+			"Raid type change to %q is on hold: Pool instances of node(s) %v are not online",
+			o.DesiredRAIDType, notOnlineHostNames,
+		)
+	}
+	return ""
+}
+
+// getHostNameToPoolInstancePhase maps host name to the phase of the
+// pool instances of the observed CStorPoolCluster
+func (o *Orchestrator) getHostNameToPoolInstancePhase() map[string]string {
+	hostNameToPhase := map[string]string{}
+	for _, cspi := range o.ObservedCStorPoolInstances {
+		if cspi == nil ||
+			cspi.GetKind() != string(types.KindCStorPoolInstance) ||
+			cspi.GetNamespace() != o.ObservedCStorPoolCluster.GetNamespace() {
+			continue
+		}
+		name, _ := unstruct.GetValueForKey(cspi.GetLabels(), capacity.LabelKeyCStorPoolCluster)
+		if name == "" || name != o.ObservedCStorPoolCluster.GetName() {
+			continue
+		}
+		hostName, _, _ := unstructured.NestedString(cspi.Object, "spec", "hostName")
+		if hostName == "" {
+			hostName, _ = unstruct.GetValueForKey(cspi.GetLabels(), "kubernetes.io/hostname")
+		}
+		phase, _, _ := unstructured.NestedString(cspi.Object, "status", "phase")
+		hostNameToPhase[hostName] = phase
+	}
+	return hostNameToPhase
+}
+
+// withPools returns a copy of desired CStorPoolCluster whose pools
+// of the given nodes are either retained from the observed
+// CStorPoolCluster or are removed
+func (o *Orchestrator) withPools(retain, remove map[string]bool) (*unstructured.Unstructured, error) {
+	observedPools, _, err := unstructured.NestedSlice(
+		o.ObservedCStorPoolCluster.Object, "spec", "pools",
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't get observed pools")
+	}
+	hostNameToObservedPool := map[string]interface{}{}
+	for _, pool := range observedPools {
+		hostNameToObservedPool[getPoolHostName(pool)] = pool
+	}
+	final := o.DesiredCStorPoolCluster.DeepCopy()
+	desiredPools, _, err := unstructured.NestedSlice(final.Object, "spec", "pools")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't get desired pools")
+	}
+	var pools []interface{}
+	for _, pool := range desiredPools {
+		hostName := getPoolHostName(pool)
+		if remove[hostName] {
+			continue
+		}
+		if retain[hostName] {
+			pool = hostNameToObservedPool[hostName]
+		}
+		pools = append(pools, pool)
+	}
+	if len(pools) == 0 {
+		unstructured.RemoveNestedField(final.Object, "spec", "pools")
+		return final, nil
+	}
+	err = unstructured.SetNestedSlice(final.Object, pools, "spec", "pools")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set pools")
+	}
+	return final, nil
+}
+
+// getPoolHostName returns the host name of the given pool of a
+// CStorPoolCluster
+func getPoolHostName(pool interface{}) string {
+	poolMap, ok := pool.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	hostName, _ := cspc.GetNodeSelectorValue(
+		&unstructured.Unstructured{Object: map[string]interface{}{"spec": poolMap}},
+	)
+	return hostName
+}
+
+// getHostNameToRAIDGroupType maps host name to the raid group type
+// of its pool in the given CStorPoolCluster
+//
+// NOTE:
+//	Both openebs.io/v1alpha1 & cstor.openebs.io/v1 versions of
+// CStorPoolCluster are supported
+func getHostNameToRAIDGroupType(obj *unstructured.Unstructured) (map[string]string, error) {
+	pools, _, err := unstructured.NestedSlice(obj.Object, "spec", "pools")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't get pools")
+	}
+	hostNameToType := map[string]string{}
+	if len(pools) == 0 {
+		return hostNameToType, nil
+	}
+	var topology *types.CStorClusterConfigPoolTopology
+	if obj.GetAPIVersion() == types.APIVersionCStorOpenEBSV1 {
+		topology, err = cspc.NewHelper(obj).GetPoolTopology()
+	} else {
+		topology, err = cspcv1alpha1.NewHelper(obj).GetPoolTopology()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range topology.Pools {
+		hostNameToType[pool.HostName] = pool.RAIDGroupType
+	}
+	return hostNameToType, nil
+}
+
+// SetCondition sets the RaidTypeChangeRequested condition against
+// the given status based on the given result
+//
+// NOTE:
+//	Condition is reported only after a change is requested. It is
+// marked absent once all the pools are re-created. Existing
+// condition is retained as is if neither its status nor its reason
+// changed.
+func SetCondition(status map[string]interface{}, result Result) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	_, found, err := conditions.GetCondition(
+		status, types.CStorPoolClusterRAIDTypeChangeRequestedCondition,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set raid type change condition")
+	}
+	if !found && !result.IsRequested {
+		return status, nil
+	}
+	status, err = conditions.SetCondition(
+		status,
+		types.MakeCStorPoolClusterRAIDTypeChangeRequestedCond(
+			result.IsRequested, result.Reason,
+		),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set raid type change condition")
+	}
+	return status, nil
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package raidchange
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	"mayadata.io/cstorpoolauto/pkg/cspc"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func buildCSPC(t *testing.T, hostNameToRAIDType map[string]types.PoolRAIDType) *unstructured.Unstructured {
+	// pools are built in the order of host names
+	var hostNames []string
+	for _, hostName := range []string{"host-1", "host-2", "host-3"} {
+		if _, found := hostNameToRAIDType[hostName]; found {
+			hostNames = append(hostNames, hostName)
+		}
+	}
+	var pools []interface{}
+	for _, hostName := range hostNames {
+		obj, err := cspc.NewBuilder().
+			WithSchema(cspc.SchemaV1Alpha1).
+			WithName("my-cspc").
+			WithNamespace("openebs").
+			WithRAIDType(hostNameToRAIDType[hostName]).
+			WithPool(hostName).
+			WithDevices(hostName+"-bd-1", hostName+"-bd-2").
+			Build()
+		if err != nil {
+			t.Fatalf("Expected no error got [%+v]", err)
+		}
+		built, _, _ := unstructured.NestedSlice(obj.Object, "spec", "pools")
+		pools = append(pools, built...)
+	}
+	obj, err := cspc.NewBuilder().
+		WithSchema(cspc.SchemaV1Alpha1).
+		WithName("my-cspc").
+		WithNamespace("openebs").
+		WithRAIDType(types.PoolRAIDTypeMirror).
+		Build()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if len(pools) != 0 {
+		err = unstructured.SetNestedSlice(obj.Object, pools, "spec", "pools")
+		if err != nil {
+			t.Fatalf("Expected no error got [%+v]", err)
+		}
+	}
+	return obj
+}
+
+func buildCSPI(hostName, phase string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"hostName": hostName,
+		},
+		"status": map[string]interface{}{
+			"phase": phase,
+		},
+	}}
+	obj.SetKind(string(types.KindCStorPoolInstance))
+	obj.SetName("my-cspc-" + hostName)
+	obj.SetNamespace("openebs")
+	obj.SetLabels(map[string]string{capacity.LabelKeyCStorPoolCluster: "my-cspc"})
+	return obj
+}
+
+func TestOrchestratorOrchestrate(t *testing.T) {
+	var tests = map[string]struct {
+		approved        types.PoolRAIDType
+		observed        map[string]types.PoolRAIDType
+		isNilObserved   bool
+		desired         map[string]types.PoolRAIDType
+		poolInstances   []*unstructured.Unstructured
+		expectPools     map[string]types.PoolRAIDType
+		expectRequest   bool
+		expectReasonHas string
+	}{
+		"nil observed cspc": {
+			isNilObserved: true,
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+			},
+		},
+		"raid type is not changed": {
+			observed: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+		},
+		"change is not approved": {
+			observed: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+				"host-2": types.PoolRAIDTypeStripe,
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			expectRequest:   true,
+			expectReasonHas: "needs approval",
+		},
+		"approval of another raid type": {
+			approved: types.PoolRAIDTypeRAIDZ,
+			observed: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+				"host-2": types.PoolRAIDTypeStripe,
+			},
+			poolInstances: []*unstructured.Unstructured{
+				buildCSPI("host-1", "ONLINE"),
+				buildCSPI("host-2", "ONLINE"),
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			expectRequest:   true,
+			expectReasonHas: "needs approval",
+		},
+		"approved change re-creates first pool": {
+			approved: types.PoolRAIDTypeStripe,
+			observed: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+				"host-2": types.PoolRAIDTypeStripe,
+			},
+			poolInstances: []*unstructured.Unstructured{
+				buildCSPI("host-1", "ONLINE"),
+				buildCSPI("host-2", "ONLINE"),
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			expectRequest:   true,
+			expectReasonHas: `Re-creating pool of node "host-1"`,
+		},
+		"approved change is on hold due to offline pool": {
+			approved: types.PoolRAIDTypeStripe,
+			observed: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+				"host-2": types.PoolRAIDTypeStripe,
+			},
+			poolInstances: []*unstructured.Unstructured{
+				buildCSPI("host-1", "ONLINE"),
+				buildCSPI("host-2", "OFFLINE"),
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+				"host-2": types.PoolRAIDTypeMirror,
+			},
+			expectRequest:   true,
+			expectReasonHas: "[host-2] are not online",
+		},
+		"approved change is on hold for the only pool": {
+			approved: types.PoolRAIDTypeStripe,
+			observed: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+			},
+			poolInstances: []*unstructured.Unstructured{
+				buildCSPI("host-1", "ONLINE"),
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeMirror,
+			},
+			expectRequest:   true,
+			expectReasonHas: "Only pool can't be re-created",
+		},
+		"removed pool waits for its pool instance to be deleted": {
+			approved: types.PoolRAIDTypeStripe,
+			observed: map[string]types.PoolRAIDType{
+				"host-2": types.PoolRAIDTypeMirror,
+				"host-3": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+				"host-2": types.PoolRAIDTypeStripe,
+				"host-3": types.PoolRAIDTypeStripe,
+			},
+			poolInstances: []*unstructured.Unstructured{
+				buildCSPI("host-1", "ONLINE"),
+				buildCSPI("host-2", "ONLINE"),
+				buildCSPI("host-3", "ONLINE"),
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-2": types.PoolRAIDTypeMirror,
+				"host-3": types.PoolRAIDTypeMirror,
+			},
+			expectRequest:   true,
+			expectReasonHas: "Another pool is being re-created",
+		},
+		"removed pool is added back & next pool is re-created": {
+			approved: types.PoolRAIDTypeStripe,
+			observed: map[string]types.PoolRAIDType{
+				"host-2": types.PoolRAIDTypeMirror,
+				"host-3": types.PoolRAIDTypeMirror,
+			},
+			desired: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+				"host-2": types.PoolRAIDTypeStripe,
+				"host-3": types.PoolRAIDTypeStripe,
+			},
+			poolInstances: []*unstructured.Unstructured{
+				buildCSPI("host-2", "ONLINE"),
+				buildCSPI("host-3", "ONLINE"),
+			},
+			expectPools: map[string]types.PoolRAIDType{
+				"host-1": types.PoolRAIDTypeStripe,
+				"host-3": types.PoolRAIDTypeMirror,
+			},
+			expectRequest:   true,
+			expectReasonHas: `Re-creating pool of node "host-2" with raid type "stripe": Pending node(s) [host-3]`,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			o := &Orchestrator{
+				DesiredRAIDType:            types.PoolRAIDTypeStripe,
+				ApprovedRAIDType:           mock.approved,
+				DesiredCStorPoolCluster:    buildCSPC(t, mock.desired),
+				ObservedCStorPoolInstances: mock.poolInstances,
+			}
+			if !mock.isNilObserved {
+				o.ObservedCStorPoolCluster = buildCSPC(t, mock.observed)
+			}
+			got, err := o.Orchestrate()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			gotPools, err := getHostNameToRAIDGroupType(got.CStorPoolCluster)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			expectPools := map[string]string{}
+			for hostName, raidType := range mock.expectPools {
+				expectPools[hostName] = string(types.RAIDTypeToRAIDGroupType[raidType])
+			}
+			if diff := cmp.Diff(expectPools, gotPools); diff != "" {
+				t.Fatalf("Expected no pools diff got\n%s", diff)
+			}
+			if got.IsRequested != mock.expectRequest {
+				t.Fatalf("Expected requested %t got %t", mock.expectRequest, got.IsRequested)
+			}
+			if !strings.Contains(got.Reason, mock.expectReasonHas) {
+				t.Fatalf("Expected reason with %q got %q", mock.expectReasonHas, got.Reason)
+			}
+		})
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	status, err := SetCondition(nil, Result{})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if _, found := status["conditions"]; found {
+		t.Fatalf("Expected no conditions got %v", status)
+	}
+	status, err = SetCondition(status, Result{IsRequested: true, Reason: "changed"})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	conds, _, _ := unstructured.NestedSlice(status, "conditions")
+	if len(conds) != 1 ||
+		conds[0].(map[string]interface{})["status"] != string(types.ConditionIsPresent) {
+		t.Fatalf("Expected 1 present condition got %v", conds)
+	}
+	status, err = SetCondition(status, Result{})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	conds, _, _ = unstructured.NestedSlice(status, "conditions")
+	if len(conds) != 1 ||
+		conds[0].(map[string]interface{})["status"] != string(types.ConditionIsAbsent) {
+		t.Fatalf("Expected 1 absent condition got %v", conds)
+	}
+}
@@ -0,0 +1,371 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remediation tracks the pool instances of a CStorPoolCluster
+// that stay offline or degraded & remediates them based on the
+// remediation policy of their CStorClusterConfig.
+package remediation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	"mayadata.io/cstorpoolauto/pkg/features"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+const (
+	// EventReasonPoolUnhealthy is the reason of the event emitted
+	// when a pool instance stays unhealthy beyond the timeout
+	EventReasonPoolUnhealthy string = "PoolUnhealthy"
+
+	// EventReasonPoolRebuilt is the reason of the event emitted
+	// when the block devices of an unhealthy pool are replaced
+	EventReasonPoolRebuilt string = "PoolRebuilt"
+
+	// EventReasonPoolNotRebuilt is the reason of the event emitted
+	// when an unhealthy pool can not be rebuilt
+	EventReasonPoolNotRebuilt string = "PoolNotRebuilt"
+
+	// EventReasonPoolRebuildPostponed is the reason of the event
+	// emitted when the rebuild of an unhealthy pool is postponed
+	// since its node is cordoned
+	EventReasonPoolRebuildPostponed string = "PoolRebuildPostponed"
+
+	// eventSourceComponent is the component that emits the events
+	eventSourceComponent string = "cstorpoolauto"
+)
+
+// unhealthyPhases are the phases of an unhealthy pool instance in
+// lower case
+var unhealthyPhases = map[string]bool{
+	"offline":  true,
+	"degraded": true,
+}
+
+// GetUnhealthyPhase returns the phase of the given pool instance if
+// this instance is either offline or degraded
+func GetUnhealthyPhase(obj *unstructured.Unstructured) (string, bool) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	return phase, unhealthyPhases[strings.ToLower(phase)]
+}
+
+// getHostName returns the host name of the given pool instance
+func getHostName(obj *unstructured.Unstructured) string {
+	hostName, _, _ := unstructured.NestedString(obj.Object, "spec", "hostName")
+	if hostName != "" {
+		return hostName
+	}
+	hostName, _ = unstruct.GetValueForKey(obj.GetLabels(), "kubernetes.io/hostname")
+	return hostName
+}
+
+// NewEvent returns an event against the given pool instance
+//
+// NOTE:
+//	Name of the event is derived from the pool instance & reason.
+// This lets the same event be applied on every reconciliation.
+func NewEvent(
+	cspi *unstructured.Unstructured, eventType, reason, message, timestamp string,
+) *unstructured.Unstructured {
+	event := &unstructured.Unstructured{}
+	event.SetUnstructuredContent(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      cspi.GetName() + "." + strings.ToLower(reason),
+			"namespace": cspi.GetNamespace(),
+		},
+		"involvedObject": map[string]interface{}{
+			"apiVersion": cspi.GetAPIVersion(),
+			"kind":       cspi.GetKind(),
+			"name":       cspi.GetName(),
+			"namespace":  cspi.GetNamespace(),
+			"uid":        string(cspi.GetUID()),
+		},
+		"type":    eventType,
+		"reason":  reason,
+		"message": message,
+		"source": map[string]interface{}{
+			"component": eventSourceComponent,
+		},
+		"firstTimestamp": timestamp,
+		"lastTimestamp":  timestamp,
+		"count":          int64(1),
+	})
+	event.SetAPIVersion("v1")
+	event.SetKind(string(types.KindEvent))
+	return event
+}
+
+// Result is the outcome of remediating the pool instances of a
+// CStorPoolCluster
+type Result struct {
+	// UnhealthyPools are the pool instances that are currently
+	// offline or degraded
+	UnhealthyPools []types.CStorClusterPlanUnhealthyPool
+
+	// ReplacedBlockDeviceNames are the block devices that should
+	// no longer be used by pools
+	ReplacedBlockDeviceNames []string
+
+	// IsDegraded is true if any pool instance stayed unhealthy
+	// beyond the timeout
+	IsDegraded bool
+
+	// Reason explains the degradation if any
+	Reason string
+
+	// Events report the pool instances that stayed unhealthy
+	// beyond the timeout & their rebuilds if any
+	Events []*unstructured.Unstructured
+}
+
+// Remediator remediates the pool instances of a CStorPoolCluster
+// that stay offline or degraded beyond the remediation timeout
+//
+// NOTE:
+//	Rebuild policy replaces all the block devices of an unhealthy
+// pool with the spare block devices of its node. Spare block devices
+// are available to the pools of the node but are not used by any of
+// them. The pool is rebuilt only if there are enough spare devices.
+// Data on the replaced block devices is not migrated.
+//
+// NOTE:
+//	Rebuild of a pool whose node is cordoned is postponed till this
+// node is uncordoned. Pool of a node under maintenance is expected
+// to be unhealthy.
+type Remediator struct {
+	// Remediation options; nothing is remediated if this is nil
+	Remediation *types.Remediation
+
+	// Now is the time of this remediation
+	Now time.Time
+
+	ObservedCStorPoolCluster   *unstructured.Unstructured
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+
+	// unhealthy pools & replaced block devices as reported by the
+	// previous remediation
+	ObservedUnhealthyPools           []types.CStorClusterPlanUnhealthyPool
+	ObservedReplacedBlockDeviceNames []string
+
+	// HostNameToPoolDeviceNames maps the host name to the block
+	// devices used by its pool
+	HostNameToPoolDeviceNames map[string][]string
+
+	// HostNameToDeviceNames maps the host name to the block devices
+	// that are available to its pool
+	HostNameToDeviceNames map[string][]string
+
+	// CordonedHostNames are the host names of the nodes that are
+	// cordoned
+	CordonedHostNames map[string]bool
+
+	result Result
+}
+
+// Remediate tracks the unhealthy pool instances & remediates the
+// ones that stayed unhealthy beyond the timeout
+func (r *Remediator) Remediate() (Result, error) {
+	r.result = Result{}
+	r.retainReplacedBlockDeviceNames()
+	if r.Remediation == nil || r.ObservedCStorPoolCluster == nil {
+		// tracking stops when remediation is disabled
+		return r.result, nil
+	}
+	if r.Remediation.Timeout == nil {
+		return Result{}, errors.Errorf("Can't remediate: Nil timeout")
+	}
+	r.trackUnhealthyPools()
+	var reasons []string
+	for idx := range r.result.UnhealthyPools {
+		pool := &r.result.UnhealthyPools[idx]
+		cspi := r.findPoolInstance(pool.Name)
+		since, err := time.Parse(time.RFC3339, pool.Since)
+		if err != nil {
+			return Result{}, errors.Wrapf(
+				err, "Can't remediate %q: Invalid since %q", pool.Name, pool.Since,
+			)
+		}
+		if r.Now.Sub(since) < r.Remediation.Timeout.Duration {
+			continue
+		}
+		message := fmt.Sprintf(
+			"Pool instance %s on node %s is %s since %s",
+			pool.Name, pool.HostName, pool.Phase, pool.Since,
+		)
+		reasons = append(reasons, message)
+		r.result.Events = append(r.result.Events, NewEvent(
+			cspi, "Warning", EventReasonPoolUnhealthy, message, pool.Since,
+		))
+		// unhealthy pools are only reported if the feature gate of
+		// rebuild is disabled
+		if r.Remediation.Policy == types.RemediationPolicyRebuild &&
+			features.Enabled(features.AutoDeviceReplacement) {
+			r.rebuild(pool, cspi)
+		}
+	}
+	if len(reasons) != 0 {
+		r.result.IsDegraded = true
+		r.result.Reason = strings.Join(reasons, ", ")
+	}
+	return r.result, nil
+}
+
+// retainReplacedBlockDeviceNames retains the replaced block devices
+// that are still available to the pools
+func (r *Remediator) retainReplacedBlockDeviceNames() {
+	isAvailable := map[string]bool{}
+	for _, names := range r.HostNameToDeviceNames {
+		for _, name := range names {
+			isAvailable[name] = true
+		}
+	}
+	for _, name := range r.ObservedReplacedBlockDeviceNames {
+		if isAvailable[name] {
+			r.result.ReplacedBlockDeviceNames =
+				append(r.result.ReplacedBlockDeviceNames, name)
+		}
+	}
+}
+
+// isPoolInstanceOfCluster returns true if the given instance belongs
+// to the observed CStorPoolCluster
+func (r *Remediator) isPoolInstanceOfCluster(obj *unstructured.Unstructured) bool {
+	if obj == nil || obj.GetKind() != string(types.KindCStorPoolInstance) {
+		return false
+	}
+	if obj.GetNamespace() != r.ObservedCStorPoolCluster.GetNamespace() {
+		return false
+	}
+	name, _ := unstruct.GetValueForKey(obj.GetLabels(), capacity.LabelKeyCStorPoolCluster)
+	return name != "" && name == r.ObservedCStorPoolCluster.GetName()
+}
+
+// findPoolInstance returns the pool instance with the given name
+func (r *Remediator) findPoolInstance(name string) *unstructured.Unstructured {
+	for _, cspi := range r.ObservedCStorPoolInstances {
+		if r.isPoolInstanceOfCluster(cspi) && cspi.GetName() == name {
+			return cspi
+		}
+	}
+	return nil
+}
+
+// trackUnhealthyPools builds the unhealthy pools from the observed
+// pool instances. Pools that were unhealthy previously retain the
+// time since when they are unhealthy.
+func (r *Remediator) trackUnhealthyPools() {
+	nameToObserved := map[string]types.CStorClusterPlanUnhealthyPool{}
+	for _, pool := range r.ObservedUnhealthyPools {
+		nameToObserved[pool.Name] = pool
+	}
+	for _, cspi := range r.ObservedCStorPoolInstances {
+		if !r.isPoolInstanceOfCluster(cspi) {
+			continue
+		}
+		phase, isUnhealthy := GetUnhealthyPhase(cspi)
+		if !isUnhealthy {
+			continue
+		}
+		pool, found := nameToObserved[cspi.GetName()]
+		if !found {
+			pool = types.CStorClusterPlanUnhealthyPool{
+				Name:  cspi.GetName(),
+				Since: r.Now.UTC().Format(time.RFC3339),
+			}
+		}
+		pool.HostName = getHostName(cspi)
+		pool.Phase = phase
+		r.result.UnhealthyPools = append(r.result.UnhealthyPools, pool)
+	}
+	sort.Slice(r.result.UnhealthyPools, func(i, j int) bool {
+		return r.result.UnhealthyPools[i].Name < r.result.UnhealthyPools[j].Name
+	})
+}
+
+// rebuild replaces the block devices of the given unhealthy pool
+// with the spare block devices of its node
+func (r *Remediator) rebuild(
+	pool *types.CStorClusterPlanUnhealthyPool, cspi *unstructured.Unstructured,
+) {
+	poolDeviceNames := r.HostNameToPoolDeviceNames[pool.HostName]
+	if pool.IsRebuilt {
+		r.result.Events = append(r.result.Events, NewEvent(
+			cspi, "Normal", EventReasonPoolRebuilt,
+			fmt.Sprintf(
+				"Pool instance %s on node %s was rebuilt with block devices %s",
+				pool.Name, pool.HostName, strings.Join(poolDeviceNames, ", "),
+			),
+			pool.Since,
+		))
+		return
+	}
+	if r.CordonedHostNames[pool.HostName] {
+		r.result.Events = append(r.result.Events, NewEvent(
+			cspi, "Normal", EventReasonPoolRebuildPostponed,
+			fmt.Sprintf(
+				"Will rebuild pool instance %s on node %s once this node is uncordoned",
+				pool.Name, pool.HostName,
+			),
+			pool.Since,
+		))
+		return
+	}
+	isUsed := map[string]bool{}
+	for _, name := range poolDeviceNames {
+		isUsed[name] = true
+	}
+	for _, name := range r.result.ReplacedBlockDeviceNames {
+		isUsed[name] = true
+	}
+	var spareDeviceNames []string
+	for _, name := range r.HostNameToDeviceNames[pool.HostName] {
+		if !isUsed[name] {
+			spareDeviceNames = append(spareDeviceNames, name)
+		}
+	}
+	if len(poolDeviceNames) == 0 || len(spareDeviceNames) < len(poolDeviceNames) {
+		r.result.Events = append(r.result.Events, NewEvent(
+			cspi, "Warning", EventReasonPoolNotRebuilt,
+			fmt.Sprintf(
+				"Can't rebuild pool instance %s on node %s: Want %d spare block devices: Got %d",
+				pool.Name, pool.HostName, len(poolDeviceNames), len(spareDeviceNames),
+			),
+			pool.Since,
+		))
+		return
+	}
+	pool.IsRebuilt = true
+	r.result.ReplacedBlockDeviceNames =
+		append(r.result.ReplacedBlockDeviceNames, poolDeviceNames...)
+	r.result.Events = append(r.result.Events, NewEvent(
+		cspi, "Normal", EventReasonPoolRebuilt,
+		fmt.Sprintf(
+			"Pool instance %s on node %s is rebuilt: Replaced block devices %s with %s",
+			pool.Name, pool.HostName,
+			strings.Join(poolDeviceNames, ", "),
+			strings.Join(spareDeviceNames[:len(poolDeviceNames)], ", "),
+		),
+		pool.Since,
+	))
+}
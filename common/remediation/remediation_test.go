@@ -0,0 +1,332 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remediation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+var testNow = time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+// newTestCSPI returns a pool instance of my-cspc on the given node
+func newTestCSPI(name, hostName, phase string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindCStorPoolInstance),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+				"labels": map[string]interface{}{
+					capacity.LabelKeyCStorPoolCluster: "my-cspc",
+				},
+			},
+			"spec": map[string]interface{}{
+				"hostName": hostName,
+			},
+			"status": map[string]interface{}{
+				"phase": phase,
+			},
+		},
+	}
+}
+
+func newTestCSPC() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name":      "my-cspc",
+				"namespace": "openebs",
+			},
+		},
+	}
+}
+
+func eventReasons(events []*unstructured.Unstructured) []string {
+	var reasons []string
+	for _, event := range events {
+		reason, _, _ := unstructured.NestedString(event.Object, "reason")
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+func TestRemediatorRemediate(t *testing.T) {
+	timeout := &metav1.Duration{Duration: 15 * time.Minute}
+	longBack := testNow.Add(-time.Hour).Format(time.RFC3339)
+	var tests = map[string]struct {
+		remediation      *types.Remediation
+		observedCSPIs    []*unstructured.Unstructured
+		observedPools    []types.CStorClusterPlanUnhealthyPool
+		observedReplaced []string
+		poolDevices      map[string][]string
+		devices          map[string][]string
+		cordoned         map[string]bool
+		expectPools      []types.CStorClusterPlanUnhealthyPool
+		expectReplaced   []string
+		expectReasons    []string
+		isDegraded       bool
+		isErr            bool
+	}{
+		"nil remediation": {
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Offline"),
+			},
+		},
+		"healthy pool instances": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyReport,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Online"),
+			},
+		},
+		"newly unhealthy pool instance": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyReport,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Offline"),
+			},
+			expectPools: []types.CStorClusterPlanUnhealthyPool{
+				{
+					Name:     "cspi-1",
+					HostName: "node-1",
+					Phase:    "Offline",
+					Since:    testNow.Format(time.RFC3339),
+				},
+			},
+		},
+		"pool instance of other cluster": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyReport,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				func() *unstructured.Unstructured {
+					cspi := newTestCSPI("cspi-1", "node-1", "Offline")
+					cspi.SetLabels(map[string]string{
+						capacity.LabelKeyCStorPoolCluster: "other-cspc",
+					})
+					return cspi
+				}(),
+			},
+		},
+		"report pool instance unhealthy beyond timeout": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyReport,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Degraded"),
+			},
+			observedPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", HostName: "node-1", Phase: "Offline", Since: longBack},
+			},
+			expectPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", HostName: "node-1", Phase: "Degraded", Since: longBack},
+			},
+			expectReasons: []string{EventReasonPoolUnhealthy},
+			isDegraded:    true,
+		},
+		"rebuild pool instance with spare devices": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyRebuild,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Offline"),
+			},
+			observedPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", HostName: "node-1", Phase: "Offline", Since: longBack},
+			},
+			poolDevices: map[string][]string{"node-1": []string{"bd-1"}},
+			devices:     map[string][]string{"node-1": []string{"bd-1", "bd-2"}},
+			expectPools: []types.CStorClusterPlanUnhealthyPool{
+				{
+					Name:      "cspi-1",
+					HostName:  "node-1",
+					Phase:     "Offline",
+					Since:     longBack,
+					IsRebuilt: true,
+				},
+			},
+			expectReplaced: []string{"bd-1"},
+			expectReasons:  []string{EventReasonPoolUnhealthy, EventReasonPoolRebuilt},
+			isDegraded:     true,
+		},
+		"rebuild pool instance without spare devices": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyRebuild,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Offline"),
+			},
+			observedPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", HostName: "node-1", Phase: "Offline", Since: longBack},
+			},
+			poolDevices: map[string][]string{"node-1": []string{"bd-1"}},
+			devices:     map[string][]string{"node-1": []string{"bd-1"}},
+			expectPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", HostName: "node-1", Phase: "Offline", Since: longBack},
+			},
+			expectReasons: []string{EventReasonPoolUnhealthy, EventReasonPoolNotRebuilt},
+			isDegraded:    true,
+		},
+		"postpone rebuild on cordoned node": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyRebuild,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Offline"),
+			},
+			observedPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", HostName: "node-1", Phase: "Offline", Since: longBack},
+			},
+			poolDevices: map[string][]string{"node-1": []string{"bd-1"}},
+			devices:     map[string][]string{"node-1": []string{"bd-1", "bd-2"}},
+			cordoned:    map[string]bool{"node-1": true},
+			expectPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", HostName: "node-1", Phase: "Offline", Since: longBack},
+			},
+			expectReasons: []string{EventReasonPoolUnhealthy, EventReasonPoolRebuildPostponed},
+			isDegraded:    true,
+		},
+		"retain replaced devices after recovery": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyRebuild,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Online"),
+			},
+			observedReplaced: []string{"bd-1", "bd-gone"},
+			devices:          map[string][]string{"node-1": []string{"bd-1", "bd-2"}},
+			expectReplaced:   []string{"bd-1"},
+		},
+		"invalid since": {
+			remediation: &types.Remediation{
+				Timeout: timeout, Policy: types.RemediationPolicyReport,
+			},
+			observedCSPIs: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "node-1", "Offline"),
+			},
+			observedPools: []types.CStorClusterPlanUnhealthyPool{
+				{Name: "cspi-1", Since: "junk"},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Remediator{
+				Remediation:                      mock.remediation,
+				Now:                              testNow,
+				ObservedCStorPoolCluster:         newTestCSPC(),
+				ObservedCStorPoolInstances:       mock.observedCSPIs,
+				ObservedUnhealthyPools:           mock.observedPools,
+				ObservedReplacedBlockDeviceNames: mock.observedReplaced,
+				HostNameToPoolDeviceNames:        mock.poolDevices,
+				HostNameToDeviceNames:            mock.devices,
+				CordonedHostNames:                mock.cordoned,
+			}
+			got, err := r.Remediate()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expectPools, got.UnhealthyPools); diff != "" {
+				t.Fatalf("Unhealthy pools mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectReplaced, got.ReplacedBlockDeviceNames); diff != "" {
+				t.Fatalf("Replaced devices mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectReasons, eventReasons(got.Events)); diff != "" {
+				t.Fatalf("Event reasons mismatch (-want +got):\n%s", diff)
+			}
+			if got.IsDegraded != mock.isDegraded {
+				t.Fatalf("Expected degraded %t got %t", mock.isDegraded, got.IsDegraded)
+			}
+		})
+	}
+}
+
+func TestSetStatus(t *testing.T) {
+	var tests = map[string]struct {
+		status      map[string]interface{}
+		result      Result
+		expectCond  bool
+		expectState string
+	}{
+		"healthy without condition": {
+			status: map[string]interface{}{"phase": "Online"},
+		},
+		"degraded": {
+			status:      map[string]interface{}{"phase": "Online"},
+			result:      Result{IsDegraded: true, Reason: "cspi-1 is offline"},
+			expectCond:  true,
+			expectState: string(types.ConditionIsPresent),
+		},
+		"recovered": {
+			status: map[string]interface{}{
+				"phase": "Online",
+				"conditions": []interface{}{
+					types.MakeCStorClusterPlanDegradedCond(true, "cspi-1 is offline"),
+				},
+			},
+			expectCond:  true,
+			expectState: string(types.ConditionIsAbsent),
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := SetStatus(mock.status, mock.result)
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			conds, _, _ := unstructured.NestedSlice(got, "conditions")
+			if !mock.expectCond {
+				if len(conds) != 0 {
+					t.Fatalf("Expected no conditions got %v", conds)
+				}
+				return
+			}
+			if len(conds) != 1 {
+				t.Fatalf("Expected 1 condition got %v", conds)
+			}
+			state := conds[0].(map[string]interface{})["status"]
+			if state != mock.expectState {
+				t.Fatalf("Expected status %q got %q", mock.expectState, state)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remediation
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// SetStatus sets the unhealthy pools, replaced block devices &
+// Degraded condition of the given result against the given status
+// of a CStorClusterPlan
+//
+// NOTE:
+//	Degraded condition is reported only after a pool instance
+// stays unhealthy beyond the timeout. It is marked absent once
+// there are no such pool instances.
+func SetStatus(
+	status map[string]interface{}, result Result,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if len(result.UnhealthyPools) == 0 {
+		delete(status, "unhealthyPools")
+	} else {
+		var pools []interface{}
+		for _, pool := range result.UnhealthyPools {
+			pool := pool
+			poolMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pool)
+			if err != nil {
+				return nil, errors.Wrapf(
+					err, "Can't set unhealthy pool %q", pool.Name,
+				)
+			}
+			pools = append(pools, poolMap)
+		}
+		status["unhealthyPools"] = pools
+	}
+	if len(result.ReplacedBlockDeviceNames) == 0 {
+		delete(status, "replacedBlockDeviceNames")
+	} else {
+		var names []interface{}
+		for _, name := range result.ReplacedBlockDeviceNames {
+			names = append(names, name)
+		}
+		status["replacedBlockDeviceNames"] = names
+	}
+	_, found, err := conditions.GetCondition(status, types.CStorClusterPlanDegradedCondition)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set degraded condition")
+	}
+	if !found && !result.IsDegraded {
+		return status, nil
+	}
+	status, err = conditions.SetCondition(
+		status, types.MakeCStorClusterPlanDegradedCond(result.IsDegraded, result.Reason),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set degraded condition")
+	}
+	return status, nil
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package skip lets the controllers skip their reconciliations
+// with a machine readable reason.
+//
+// NOTE:
+//	Every skip is logged with its reason code. In addition, the
+// ReconcileSkipped condition of the controller is set against the
+// status of the watch if the watch is a custom resource of this
+// operator. This condition is removed once the controller reconciles
+// the watch.
+package skip
+
+import (
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	"mayadata.io/cstorpoolauto/pkg/supportbundle"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// Reason is the machine readable code of a skipped reconciliation
+type Reason string
+
+const (
+	// ReasonNilAttachments is set when the watch is synced without
+	// any attachments
+	ReasonNilAttachments Reason = "NilAttachments"
+
+	// ReasonPaused is set while the automation of CStorClusterConfig
+	// is paused
+	ReasonPaused Reason = "Paused"
+
+	// ReasonResumed is set for the sync that follows a resume of the
+	// automation of CStorClusterConfig
+	ReasonResumed Reason = "Resumed"
+
+	// ReasonNothingChanged is set when neither the watch nor its
+	// attachments changed since the last successful sync
+	ReasonNothingChanged Reason = "NothingChanged"
+
+	// ReasonNotLocalDisk is set when CStorClusterConfig does not use
+	// local disks
+	ReasonNotLocalDisk Reason = "NotLocalDisk"
+
+	// ReasonNotEnabled is set when the feature managed by the
+	// controller is not enabled in the watch
+	ReasonNotEnabled Reason = "NotEnabled"
+
+	// ReasonNotMarkedForDecommission is set when the node is not
+	// marked for pool decommission
+	ReasonNotMarkedForDecommission Reason = "NotMarkedForDecommission"
+
+	// ReasonExternalDiskConfigNotFound is set when CStorClusterConfig
+	// neither refers to local disks nor to external disks
+	ReasonExternalDiskConfigNotFound Reason = "ExternalDiskConfigNotFound"
+
+	// ReasonBlockDeviceClaimsPending is set when some of the selected
+	// block devices are not claimed yet
+	ReasonBlockDeviceClaimsPending Reason = "BlockDeviceClaimsPending"
+
+	// ReasonPVCNotFound is set when the PersistentVolumeClaim of a
+	// Storage is not observed yet
+	ReasonPVCNotFound Reason = "PVCNotFound"
+
+	// ReasonPVCPendingDeletion is set when the PersistentVolumeClaim
+	// of a Storage is being deleted
+	ReasonPVCPendingDeletion Reason = "PVCPendingDeletion"
+
+	// ReasonAssociationPending is set when a Storage is not yet
+	// associated with a BlockDevice
+	ReasonAssociationPending Reason = "AssociationPending"
+
+	// ReasonClusterNotReady is set when the cluster is not ready to
+	// form a CStorPoolCluster
+	ReasonClusterNotReady Reason = "ClusterNotReady"
+
+	// ReasonNotOwner is set when the CStorPoolCluster is owned by
+	// another controller
+	ReasonNotOwner Reason = "NotOwner"
+
+	// ReasonWaitingForReadyNodes is set when the CStorPoolCluster is
+	// not created since fewer nodes than the min ready nodes have all
+	// their block devices ready
+	ReasonWaitingForReadyNodes Reason = "WaitingForReadyNodes"
+)
+
+// isQuiet returns true if the given reason is only logged
+//
+// NOTE:
+//	Syncs that find nothing changed follow every successful sync.
+// Setting a condition for these would update the watch after every
+// successful sync. Pause & resume are reported by the Paused
+// condition instead. Watches that are not meant for the controller
+// are skipped by design & are not worth a condition.
+func isQuiet(reason Reason) bool {
+	switch reason {
+	case ReasonNothingChanged,
+		ReasonPaused,
+		ReasonResumed,
+		ReasonNotLocalDisk,
+		ReasonNotEnabled,
+		ReasonNotMarkedForDecommission,
+		ReasonExternalDiskConfigNotFound:
+		return true
+	}
+	return false
+}
+
+// isStatusOwned returns true if the given watch is a custom resource
+// of this operator & hence accepts the ReconcileSkipped condition
+func isStatusOwned(watch *unstructured.Unstructured) bool {
+	return watch.GroupVersionKind().Group == types.GroupDAOMayaDataIO
+}
+
+// HasCondition returns true if ReconcileSkipped condition of the
+// given controller is set against the given object
+func HasCondition(obj *unstructured.Unstructured, controller string) bool {
+	if obj == nil {
+		return false
+	}
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	_, found, _ := conditions.GetControllerCondition(
+		status, types.ReconcileSkippedCondition, controller,
+	)
+	return found
+}
+
+// SetCondition sets the ReconcileSkipped condition of the given
+// controller against the given status
+//
+// NOTE:
+//	Existing condition is retained as is if its reason & message
+// did not change. This keeps the status same across syncs.
+func SetCondition(
+	status map[string]interface{}, controller string, reason Reason, message string,
+) (map[string]interface{}, error) {
+	status, err := conditions.SetCondition(
+		status, types.MakeReconcileSkippedCond(controller, string(reason), message),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err, "Can't set reconcile skipped condition: Controller %q", controller,
+		)
+	}
+	return status, nil
+}
+
+// RemoveCondition removes the ReconcileSkipped condition of the
+// given controller from the given status
+func RemoveCondition(
+	status map[string]interface{}, controller string,
+) (map[string]interface{}, error) {
+	status, err := conditions.RemoveControllerCondition(
+		status, types.ReconcileSkippedCondition, controller,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(
+			err, "Can't remove reconcile skipped condition: Controller %q", controller,
+		)
+	}
+	return status, nil
+}
+
+// getResponseStatus returns the status of the given response. Status
+// of the given watch is copied if the response has no status.
+func getResponseStatus(
+	watch *unstructured.Unstructured, response *generic.SyncHookResponse,
+) (map[string]interface{}, error) {
+	if response.Status != nil {
+		return response.Status, nil
+	}
+	// status is copied to avoid modifying the watch
+	status, _, err := unstructured.NestedMap(watch.Object, "status")
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"Invalid status: Watch %q - %q / %q",
+			watch.GetKind(),
+			watch.GetNamespace(),
+			watch.GetName(),
+		)
+	}
+	return status, nil
+}
+
+// Skip sets the given response to skip the reconciliation of the
+// given watch & logs the given reason against the given controller
+//
+// NOTE:
+//	Status already set in the response is retained & is extended
+// with the ReconcileSkipped condition.
+func Skip(
+	controller string,
+	watch *unstructured.Unstructured,
+	response *generic.SyncHookResponse,
+	reason Reason,
+	message string,
+) error {
+	if watch == nil {
+		return errors.Errorf("Can't skip reconciliation: Nil watch: Controller %q", controller)
+	}
+	// skips of features that are not enabled are logged at a
+	// higher verbosity since these happen for most of the watches
+	level := glog.Level(3)
+	if reason == ReasonNotEnabled || reason == ReasonNotMarkedForDecommission {
+		level = 4
+	}
+	glog.V(level).Infof(
+		"Will skip %s sync: Reason %s: %s: Watch %q - %q / %q",
+		controller,
+		reason,
+		message,
+		watch.GetKind(),
+		watch.GetNamespace(),
+		watch.GetName(),
+	)
+	response.SkipReconcile = true
+	// reason is recorded as the sync decision for support bundles
+	if reason == ReasonNothingChanged {
+		supportbundle.Ignore(response)
+	} else {
+		supportbundle.NoteSkip(response, string(reason), message)
+	}
+	if isQuiet(reason) || !isStatusOwned(watch) {
+		return nil
+	}
+	status, err := getResponseStatus(watch, response)
+	if err != nil {
+		return err
+	}
+	response.Status, err = SetCondition(status, controller, reason, message)
+	return err
+}
+
+// Clear removes the ReconcileSkipped condition of the given
+// controller if this was set against the given watch
+//
+// NOTE:
+//	This should be invoked after the status of the response is
+// built by the controller.
+func Clear(
+	controller string,
+	watch *unstructured.Unstructured,
+	response *generic.SyncHookResponse,
+) error {
+	if !HasCondition(watch, controller) {
+		// nothing to do since reconciliation was never skipped
+		return nil
+	}
+	status, err := getResponseStatus(watch, response)
+	if err != nil {
+		return err
+	}
+	response.Status, err = RemoveCondition(status, controller)
+	return err
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package skip
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// newTestWatch returns a watch of the given api version with the
+// given conditions
+func newTestWatch(apiVersion string, conds ...interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "Some",
+			"metadata": map[string]interface{}{
+				"namespace": "openebs",
+				"name":      "my-watch",
+			},
+		},
+	}
+	if len(conds) != 0 {
+		obj.Object["status"] = map[string]interface{}{
+			"conditions": conds,
+		}
+	}
+	return obj
+}
+
+// findConds returns the ReconcileSkipped conditions of the given
+// controller from the given status
+func findConds(status map[string]interface{}, controller string) []map[string]interface{} {
+	conds, _, _ := unstructured.NestedSlice(status, "conditions")
+	var found []map[string]interface{}
+	for _, cond := range conds {
+		condMap, ok := cond.(map[string]interface{})
+		if ok &&
+			condMap["type"] == string(types.ReconcileSkippedCondition) &&
+			condMap["controller"] == controller {
+			found = append(found, condMap)
+		}
+	}
+	return found
+}
+
+func TestSkip(t *testing.T) {
+	daoVersion := string(types.APIVersionDAOMayaDataV1Alpha1)
+	oldCond := map[string]interface{}{
+		"type":             string(types.ReconcileSkippedCondition),
+		"status":           string(types.ConditionIsPresent),
+		"controller":       "Test",
+		"reason":           string(ReasonClusterNotReady),
+		"message":          "not ready",
+		"lastObservedTime": "2020-01-01T00:00:00Z",
+	}
+	otherCond := map[string]interface{}{
+		"type":       string(types.ReconcileSkippedCondition),
+		"status":     string(types.ConditionIsPresent),
+		"controller": "Other",
+		"reason":     string(ReasonNilAttachments),
+	}
+	var tests = map[string]struct {
+		watch          *unstructured.Unstructured
+		status         map[string]interface{}
+		reason         Reason
+		message        string
+		isNilStatus    bool
+		expectCount    int
+		expectReason   Reason
+		isRetained     bool
+		expectOtherKey bool
+		isErr          bool
+	}{
+		"nil watch": {
+			reason: ReasonClusterNotReady,
+			isErr:  true,
+		},
+		"not owned watch": {
+			watch:       newTestWatch("v1"),
+			reason:      ReasonClusterNotReady,
+			isNilStatus: true,
+		},
+		"quiet reason": {
+			watch:       newTestWatch(daoVersion),
+			reason:      ReasonNothingChanged,
+			isNilStatus: true,
+		},
+		"new condition": {
+			watch:        newTestWatch(daoVersion, otherCond),
+			reason:       ReasonClusterNotReady,
+			message:      "not ready",
+			expectCount:  1,
+			expectReason: ReasonClusterNotReady,
+		},
+		"same condition is retained": {
+			watch:        newTestWatch(daoVersion, otherCond, oldCond),
+			reason:       ReasonClusterNotReady,
+			message:      "not ready",
+			expectCount:  1,
+			expectReason: ReasonClusterNotReady,
+			isRetained:   true,
+		},
+		"changed reason": {
+			watch:        newTestWatch(daoVersion, oldCond),
+			reason:       ReasonNilAttachments,
+			message:      "No attachments",
+			expectCount:  1,
+			expectReason: ReasonNilAttachments,
+		},
+		"changed message": {
+			watch:        newTestWatch(daoVersion, oldCond),
+			reason:       ReasonClusterNotReady,
+			message:      "still not ready",
+			expectCount:  1,
+			expectReason: ReasonClusterNotReady,
+		},
+		"response status is extended": {
+			watch:          newTestWatch(daoVersion),
+			status:         map[string]interface{}{"phase": "Online"},
+			reason:         ReasonClusterNotReady,
+			message:        "not ready",
+			expectCount:    1,
+			expectReason:   ReasonClusterNotReady,
+			expectOtherKey: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			response := &generic.SyncHookResponse{Status: mock.status}
+			err := Skip("Test", mock.watch, response, mock.reason, mock.message)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if !response.SkipReconcile {
+				t.Fatalf("Expected skip reconcile got none")
+			}
+			if mock.isNilStatus {
+				if response.Status != nil {
+					t.Fatalf("Expected nil status got %v", response.Status)
+				}
+				return
+			}
+			conds := findConds(response.Status, "Test")
+			if len(conds) != mock.expectCount {
+				t.Fatalf("Expected condition count %d got %d", mock.expectCount, len(conds))
+			}
+			if conds[0]["reason"] != string(mock.expectReason) {
+				t.Fatalf("Expected reason %q got %v", mock.expectReason, conds[0]["reason"])
+			}
+			if conds[0]["message"] != mock.message {
+				t.Fatalf("Expected message %q got %v", mock.message, conds[0]["message"])
+			}
+			isRetained := conds[0]["lastObservedTime"] == "2020-01-01T00:00:00Z"
+			if isRetained != mock.isRetained {
+				t.Fatalf("Expected retained %t got %t", mock.isRetained, isRetained)
+			}
+			if mock.expectOtherKey && response.Status["phase"] != "Online" {
+				t.Fatalf("Expected response status to be retained got %v", response.Status)
+			}
+		})
+	}
+}
+
+func TestClear(t *testing.T) {
+	daoVersion := string(types.APIVersionDAOMayaDataV1Alpha1)
+	testCond := map[string]interface{}{
+		"type":       string(types.ReconcileSkippedCondition),
+		"status":     string(types.ConditionIsPresent),
+		"controller": "Test",
+		"reason":     string(ReasonClusterNotReady),
+	}
+	otherCond := map[string]interface{}{
+		"type":       string(types.ReconcileSkippedCondition),
+		"status":     string(types.ConditionIsPresent),
+		"controller": "Other",
+		"reason":     string(ReasonNilAttachments),
+	}
+	var tests = map[string]struct {
+		watch       *unstructured.Unstructured
+		status      map[string]interface{}
+		isNilStatus bool
+		expectCount int
+	}{
+		"never skipped": {
+			watch:       newTestWatch(daoVersion, otherCond),
+			isNilStatus: true,
+		},
+		"skipped earlier": {
+			watch:       newTestWatch(daoVersion, otherCond, testCond),
+			expectCount: 1,
+		},
+		"skipped earlier with response status": {
+			watch: newTestWatch(daoVersion, otherCond, testCond),
+			status: map[string]interface{}{
+				"conditions": []interface{}{otherCond, testCond},
+			},
+			expectCount: 1,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			response := &generic.SyncHookResponse{Status: mock.status}
+			err := Clear("Test", mock.watch, response)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isNilStatus {
+				if response.Status != nil {
+					t.Fatalf("Expected nil status got %v", response.Status)
+				}
+				return
+			}
+			if len(findConds(response.Status, "Test")) != 0 {
+				t.Fatalf("Expected no condition got %v", response.Status)
+			}
+			conds, _, _ := unstructured.NestedSlice(response.Status, "conditions")
+			if len(conds) != mock.expectCount {
+				t.Fatalf("Expected condition count %d got %d", mock.expectCount, len(conds))
+			}
+			if !HasCondition(mock.watch, "Test") {
+				t.Fatalf("Expected watch to be unmodified")
+			}
+		})
+	}
+}
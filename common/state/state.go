@@ -0,0 +1,270 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package state lets the controllers remember things across their
+// syncs & the restarts of this operator.
+//
+// NOTE:
+//	State of a component for a CStorClusterConfig is persisted in a
+// ConfigMap named <config-name>-<component>-state in the namespace
+// of this config. This ConfigMap is observed as an attachment, is
+// loaded at the start of a sync & is saved by returning its desired
+// state as an attachment. Memory held in the controller process
+// instead would vanish when this operator restarts.
+//
+// NOTE:
+//	Every component gets its own ConfigMap since the controllers
+// that watch the same config share the last applied annotation of
+// their attachments.
+package state
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// Name returns the name of the ConfigMap that persists the state of
+// the given component for the given config
+func Name(configName, component string) string {
+	return configName + "-" + component + "-state"
+}
+
+// IsStateOf returns true if the given object persists the state of
+// the given component for the given config
+func IsStateOf(obj, config *unstructured.Unstructured, component string) bool {
+	if obj == nil || config == nil || obj.GetKind() != "ConfigMap" {
+		return false
+	}
+	annotations := obj.GetAnnotations()
+	return annotations[types.AnnKeyCStorClusterConfigUID] == string(config.GetUID()) &&
+		annotations[types.AnnKeyStateComponent] == component
+}
+
+// Store provides typed access to the state of a component for a
+// CStorClusterConfig
+type Store struct {
+	Config    *unstructured.Unstructured
+	Component string
+
+	// observed is the ConfigMap this state was loaded from; nil if
+	// the state was never saved
+	observed *unstructured.Unstructured
+	data     map[string]string
+}
+
+// Load returns the state of the given component for the given config
+// from the given attachments
+func Load(
+	config *unstructured.Unstructured,
+	component string,
+	attachments []*unstructured.Unstructured,
+) (*Store, error) {
+	if config == nil {
+		return nil, errors.Errorf("Can't load state: Nil CStorClusterConfig")
+	}
+	if component == "" {
+		return nil, errors.Errorf(
+			"Can't load state: Missing component: CStorClusterConfig %q / %q",
+			config.GetNamespace(), config.GetName(),
+		)
+	}
+	s := &Store{
+		Config:    config,
+		Component: component,
+		data:      map[string]string{},
+	}
+	for _, attachment := range attachments {
+		if !IsStateOf(attachment, config, component) {
+			continue
+		}
+		data, _, err := unstructured.NestedStringMap(attachment.Object, "data")
+		if err != nil {
+			return nil, errs.AsValidationError(errors.Wrapf(
+				err,
+				"Can't load state: Invalid ConfigMap %q / %q",
+				attachment.GetNamespace(), attachment.GetName(),
+			))
+		}
+		for key, value := range data {
+			s.data[key] = value
+		}
+		s.observed = attachment
+		break
+	}
+	return s, nil
+}
+
+// Keys returns the sorted keys of this state
+func (s *Store) Keys() []string {
+	var keys []string
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Delete removes the given key from this state
+func (s *Store) Delete(key string) {
+	delete(s.data, key)
+}
+
+// GetString returns the value of the given key; empty if not set
+func (s *Store) GetString(key string) string {
+	return s.data[key]
+}
+
+// SetString sets the given value against the given key
+//
+// NOTE:
+//	Empty value removes the key
+func (s *Store) SetString(key, value string) {
+	if value == "" {
+		s.Delete(key)
+		return
+	}
+	s.data[key] = value
+}
+
+// GetInt64 returns the value of the given key as an int64; false if
+// not set
+func (s *Store) GetInt64(key string) (int64, bool, error) {
+	val := s.data[key]
+	if val == "" {
+		return 0, false, nil
+	}
+	num, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, false, s.wrapInvalid(err, key)
+	}
+	return num, true, nil
+}
+
+// SetInt64 sets the given int64 against the given key
+func (s *Store) SetInt64(key string, value int64) {
+	s.data[key] = strconv.FormatInt(value, 10)
+}
+
+// GetTime returns the value of the given key as a time; false if not
+// set
+func (s *Store) GetTime(key string) (time.Time, bool, error) {
+	val := s.data[key]
+	if val == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, false, s.wrapInvalid(err, key)
+	}
+	return t, true, nil
+}
+
+// SetTime sets the given time against the given key in RFC3339
+// format
+//
+// NOTE:
+//	Zero time removes the key
+func (s *Store) SetTime(key string, value time.Time) {
+	if value.IsZero() {
+		s.Delete(key)
+		return
+	}
+	s.data[key] = value.UTC().Format(time.RFC3339)
+}
+
+// GetJSON unmarshals the value of the given key into the given
+// target; false if not set
+func (s *Store) GetJSON(key string, target interface{}) (bool, error) {
+	val := s.data[key]
+	if val == "" {
+		return false, nil
+	}
+	err := json.Unmarshal([]byte(val), target)
+	if err != nil {
+		return false, s.wrapInvalid(err, key)
+	}
+	return true, nil
+}
+
+// SetJSON sets the given value as JSON against the given key
+func (s *Store) SetJSON(key string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"Can't set state %q: Component %q: CStorClusterConfig %q / %q",
+			key, s.Component, s.Config.GetNamespace(), s.Config.GetName(),
+		)
+	}
+	s.data[key] = string(raw)
+	return nil
+}
+
+func (s *Store) wrapInvalid(err error, key string) error {
+	return errs.AsValidationError(errors.Wrapf(
+		err,
+		"Invalid state %q: ConfigMap %q / %q",
+		key, s.Config.GetNamespace(), Name(s.Config.GetName(), s.Component),
+	))
+}
+
+// Desired returns the ConfigMap that saves this state; nil if there
+// is nothing to save
+//
+// NOTE:
+//	ConfigMap is owned by the config & hence gets garbage collected
+// when this config is deleted. Once saved, ConfigMap is returned
+// even if the state is empty to avoid its deletion & re-creation.
+func (s *Store) Desired() *unstructured.Unstructured {
+	if s.observed == nil && len(s.data) == 0 {
+		return nil
+	}
+	data := map[string]interface{}{}
+	for key, value := range s.data {
+		data[key] = value
+	}
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data":       data,
+		},
+	}
+	desired.SetName(Name(s.Config.GetName(), s.Component))
+	desired.SetNamespace(s.Config.GetNamespace())
+	desired.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: string(s.Config.GetUID()),
+		types.AnnKeyStateComponent:        s.Component,
+	})
+	desired.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: s.Config.GetAPIVersion(),
+			Kind:       s.Config.GetKind(),
+			Name:       s.Config.GetName(),
+			UID:        s.Config.GetUID(),
+		},
+	})
+	return desired
+}
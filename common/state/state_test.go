@@ -0,0 +1,208 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestConfig(uid string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "dao.mayadata.io/v1alpha1",
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"uid":       uid,
+			},
+		},
+	}
+}
+
+func newTestConfigMap(
+	configUID, component string, data map[string]interface{},
+) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      Name("my-config", component),
+				"namespace": "openebs",
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterConfigUID: configUID,
+					types.AnnKeyStateComponent:        component,
+				},
+			},
+			"data": data,
+		},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	var tests = map[string]struct {
+		config      *unstructured.Unstructured
+		component   string
+		attachments []*unstructured.Unstructured
+		expectKeys  []string
+		isErr       bool
+	}{
+		"nil config": {
+			component: "autoscaler",
+			isErr:     true,
+		},
+		"missing component": {
+			config: newTestConfig("config-1"),
+			isErr:  true,
+		},
+		"never saved": {
+			config:    newTestConfig("config-1"),
+			component: "autoscaler",
+		},
+		"state of other configs & components are ignored": {
+			config:    newTestConfig("config-1"),
+			component: "autoscaler",
+			attachments: []*unstructured.Unstructured{
+				newTestConfigMap("config-2", "autoscaler", map[string]interface{}{
+					"a": "1",
+				}),
+				newTestConfigMap("config-1", "migration", map[string]interface{}{
+					"b": "1",
+				}),
+				newTestConfigMap("config-1", "autoscaler", map[string]interface{}{
+					"c": "1",
+					"d": "2",
+				}),
+			},
+			expectKeys: []string{"c", "d"},
+		},
+		"invalid data": {
+			config:    newTestConfig("config-1"),
+			component: "autoscaler",
+			attachments: []*unstructured.Unstructured{
+				newTestConfigMap("config-1", "autoscaler", map[string]interface{}{
+					"c": int64(1),
+				}),
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Load(mock.config, mock.component, mock.attachments)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expectKeys, got.Keys()); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStoreAccessors(t *testing.T) {
+	s, err := Load(newTestConfig("config-1"), "autoscaler", nil)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if s.Desired() != nil {
+		t.Fatalf("Expected nil desired state for empty state")
+	}
+
+	now := time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+	s.SetTime("since", now)
+	s.SetInt64("count", 3)
+	s.SetString("phase", "Migrating")
+	err = s.SetJSON("nodes", []string{"node-1", "node-2"})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+
+	// saved state is loaded back as is
+	desired := s.Desired()
+	if desired.GetName() != "my-config-autoscaler-state" ||
+		desired.GetNamespace() != "openebs" {
+		t.Fatalf(
+			"Expected ConfigMap openebs/my-config-autoscaler-state got %s/%s",
+			desired.GetNamespace(), desired.GetName(),
+		)
+	}
+	if len(desired.GetOwnerReferences()) != 1 ||
+		desired.GetOwnerReferences()[0].UID != "config-1" {
+		t.Fatalf("Expected config as owner got %+v", desired.GetOwnerReferences())
+	}
+	loaded, err := Load(
+		newTestConfig("config-1"), "autoscaler", []*unstructured.Unstructured{desired},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	since, found, err := loaded.GetTime("since")
+	if err != nil || !found || !since.Equal(now) {
+		t.Fatalf("Expected time %s got %s: found %t: err %v", now, since, found, err)
+	}
+	count, found, err := loaded.GetInt64("count")
+	if err != nil || !found || count != 3 {
+		t.Fatalf("Expected count 3 got %d: found %t: err %v", count, found, err)
+	}
+	if loaded.GetString("phase") != "Migrating" {
+		t.Fatalf("Expected phase Migrating got %q", loaded.GetString("phase"))
+	}
+	var nodes []string
+	found, err = loaded.GetJSON("nodes", &nodes)
+	if err != nil || !found {
+		t.Fatalf("Expected nodes got none: err %v", err)
+	}
+	if diff := cmp.Diff([]string{"node-1", "node-2"}, nodes); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+
+	// empty values remove the keys
+	loaded.SetTime("since", time.Time{})
+	loaded.SetString("phase", "")
+	loaded.Delete("count")
+	loaded.Delete("nodes")
+	if len(loaded.Keys()) != 0 {
+		t.Fatalf("Expected no keys got %v", loaded.Keys())
+	}
+	// saved state is retained even if empty
+	if loaded.Desired() == nil {
+		t.Fatalf("Expected desired state for saved state got nil")
+	}
+
+	// invalid values are errors
+	loaded.SetString("since", "yesterday")
+	_, _, err = loaded.GetTime("since")
+	if err == nil {
+		t.Fatalf("Expected error for invalid time got none")
+	}
+}
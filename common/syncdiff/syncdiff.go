@@ -0,0 +1,330 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package syncdiff renders the field paths that change when a
+// desired attachment is applied against its observed state.
+//
+// NOTE:
+//	Desired state is merged with the observed state the same way as
+// metac does i.e. via a 3-way merge that considers the last applied
+// state. Hence, the rendered diff is the change metac applies.
+package syncdiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/common"
+
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// LastAppliedAnnKeySuffix is the suffix of the annotation that is
+// set by metac against every attachment it applies. This is prefixed
+// with the UID of the watch.
+const LastAppliedAnnKeySuffix = "/gctl-last-applied"
+
+const (
+	// MaxSummaryLength is the max length of the summary set in
+	// LastSyncDiff condition
+	MaxSummaryLength = 512
+
+	// maxValueLength is the max length of any rendered value
+	maxValueLength = 64
+
+	// redacted replaces the values of sensitive fields
+	redacted = "<redacted>"
+)
+
+// sensitiveKeyParts are the parts of field names whose values are
+// never rendered
+var sensitiveKeyParts = []string{"password", "secret", "token", "credential"}
+
+// Operation is the type of change made to a field path
+type Operation string
+
+const (
+	// OperationAdd adds a field that was not observed
+	OperationAdd Operation = "add"
+
+	// OperationRemove removes an observed field
+	OperationRemove Operation = "remove"
+
+	// OperationChange changes the value of an observed field
+	OperationChange Operation = "change"
+)
+
+// Change is a change made to a single field path
+type Change struct {
+	Path      string
+	Operation Operation
+
+	// Old & New are the rendered values that are truncated or
+	// redacted if required
+	Old string
+	New string
+}
+
+// String renders this change as a single line
+func (c Change) String() string {
+	switch c.Operation {
+	case OperationAdd:
+		return fmt.Sprintf("+%s=%s", c.Path, c.New)
+	case OperationRemove:
+		return fmt.Sprintf("-%s=%s", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("~%s: %s -> %s", c.Path, c.Old, c.New)
+	}
+}
+
+// Result is the outcome of comparing the desired & observed states
+type Result struct {
+	// HasDiff is true if applying the desired state changes the
+	// observed state
+	HasDiff bool
+
+	// Changes are sorted by their field paths
+	Changes []Change
+}
+
+// Render returns every change of this result one per line
+func (r Result) Render() string {
+	var lines []string
+	for _, change := range r.Changes {
+		lines = append(lines, change.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary returns the changes of this result on a single line that
+// is truncated to MaxSummaryLength
+func (r Result) Summary() string {
+	if !r.HasDiff {
+		return ""
+	}
+	var summary string
+	for idx, change := range r.Changes {
+		item := change.String()
+		if idx > 0 {
+			item = "; " + item
+		}
+		more := fmt.Sprintf("; +%d more", len(r.Changes)-idx)
+		if len(summary)+len(item) > MaxSummaryLength-len(more) {
+			return summary + more
+		}
+		summary += item
+	}
+	return summary
+}
+
+// Compute returns the changes that metac makes to the observed
+// state when the desired state is applied. Last applied state is
+// read from the annotation of the given watch UID.
+func Compute(
+	watchUID string, observed, desired *unstructured.Unstructured,
+) (Result, error) {
+	if observed == nil || desired == nil {
+		// nothing to compare if desired state is yet to be created
+		return Result{}, nil
+	}
+	lastAppliedAnnKey := watchUID + LastAppliedAnnKeySuffix
+	// merge sanitizes the desired state & hence is given a copy
+	apply := common.NewApplyFromAnnKey(lastAppliedAnnKey)
+	merged, err := apply.Merge(observed.DeepCopy(), desired.DeepCopy())
+	if err != nil {
+		return Result{}, errors.Wrapf(
+			err, "Failed to compute sync diff: %s %q / %q",
+			desired.GetKind(), desired.GetNamespace(), desired.GetName(),
+		)
+	}
+	hasDiff, err := apply.HasMergeDiff()
+	if err != nil {
+		return Result{}, err
+	}
+	if !hasDiff {
+		return Result{}, nil
+	}
+	var changes []Change
+	walk("", observed.UnstructuredContent(), merged.UnstructuredContent(), &changes)
+	var filtered []Change
+	for _, change := range changes {
+		if strings.HasSuffix(change.Path, LastAppliedAnnKeySuffix+"]") {
+			// last applied state is set by metac & changes
+			// whenever desired state changes
+			continue
+		}
+		filtered = append(filtered, change)
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].Path < filtered[j].Path
+	})
+	return Result{HasDiff: len(filtered) != 0, Changes: filtered}, nil
+}
+
+// walk appends the changes between the observed & merged values of
+// the given field path
+func walk(path string, from, to interface{}, changes *[]Change) {
+	if reflect.DeepEqual(from, to) {
+		return
+	}
+	oldMap, isOldMap := from.(map[string]interface{})
+	newMap, isNewMap := to.(map[string]interface{})
+	if isOldMap && isNewMap {
+		for key, oldVal := range oldMap {
+			newVal, found := newMap[key]
+			if !found {
+				*changes = append(*changes, Change{
+					Path:      joinKey(path, key),
+					Operation: OperationRemove,
+					Old:       render(key, oldVal),
+				})
+				continue
+			}
+			walk(joinKey(path, key), oldVal, newVal, changes)
+		}
+		for key, newVal := range newMap {
+			if _, found := oldMap[key]; !found {
+				*changes = append(*changes, Change{
+					Path:      joinKey(path, key),
+					Operation: OperationAdd,
+					New:       render(key, newVal),
+				})
+			}
+		}
+		return
+	}
+	oldList, isOldList := from.([]interface{})
+	newList, isNewList := to.([]interface{})
+	if isOldList && isNewList {
+		for idx := 0; idx < len(oldList) || idx < len(newList); idx++ {
+			itemPath := fmt.Sprintf("%s[%d]", path, idx)
+			switch {
+			case idx >= len(newList):
+				*changes = append(*changes, Change{
+					Path:      itemPath,
+					Operation: OperationRemove,
+					Old:       render(path, oldList[idx]),
+				})
+			case idx >= len(oldList):
+				*changes = append(*changes, Change{
+					Path:      itemPath,
+					Operation: OperationAdd,
+					New:       render(path, newList[idx]),
+				})
+			default:
+				walk(itemPath, oldList[idx], newList[idx], changes)
+			}
+		}
+		return
+	}
+	*changes = append(*changes, Change{
+		Path:      path,
+		Operation: OperationChange,
+		Old:       render(path, from),
+		New:       render(path, to),
+	})
+}
+
+// joinKey returns the field path of the given key. Keys with dots
+// or slashes e.g. annotation keys are quoted.
+func joinKey(path, key string) string {
+	if strings.ContainsAny(key, "./") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isSensitive returns true if the given field path or key refers
+// to a value that should never be rendered
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// render returns the given value as compact JSON that is redacted
+// if its key is sensitive & truncated if it is too long
+func render(key string, val interface{}) string {
+	if isSensitive(key) || hasSensitiveKey(val) {
+		return redacted
+	}
+	raw, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	rendered := string(raw)
+	if len(rendered) > maxValueLength {
+		return rendered[:maxValueLength] + "..."
+	}
+	return rendered
+}
+
+// hasSensitiveKey returns true if any key nested within the given
+// value is sensitive
+func hasSensitiveKey(val interface{}) bool {
+	switch typed := val.(type) {
+	case map[string]interface{}:
+		for key, nested := range typed {
+			if isSensitive(key) || hasSensitiveKey(nested) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, nested := range typed {
+			if hasSensitiveKey(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetCondition sets the LastSyncDiff condition against the given
+// status based on the given result
+//
+// NOTE:
+//	Existing condition is retained as is if neither its status nor
+// its reason changed. This keeps the status same across syncs.
+func SetCondition(status map[string]interface{}, result Result) (map[string]interface{}, error) {
+	status, err := conditions.SetCondition(
+		status, types.MakeCStorPoolClusterLastSyncDiffCond(result.HasDiff, result.Summary()),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set last sync diff condition")
+	}
+	return status, nil
+}
+
+// HasCondition returns true if LastSyncDiff condition is set
+// against the given object
+func HasCondition(obj *unstructured.Unstructured) bool {
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	_, found, _ := conditions.GetCondition(status, types.CStorPoolClusterLastSyncDiffCondition)
+	return found
+}
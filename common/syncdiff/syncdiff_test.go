@@ -0,0 +1,247 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncdiff
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+const testWatchUID = "plan-1"
+
+// newTestCStorPoolCluster returns a CStorPoolCluster whose single
+// pool uses the given devices
+func newTestCStorPoolCluster(
+	annotations map[string]string, deviceNames ...string,
+) *unstructured.Unstructured {
+	var devices []interface{}
+	for _, name := range deviceNames {
+		devices = append(devices, map[string]interface{}{"blockDeviceName": name})
+	}
+	cspc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindCStorPoolCluster),
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"raidGroups": []interface{}{
+							map[string]interface{}{
+								"blockDevices": devices,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	cspc.SetName("my-cspc")
+	cspc.SetNamespace("openebs")
+	if annotations != nil {
+		cspc.SetAnnotations(annotations)
+	}
+	return cspc
+}
+
+// withLastApplied sets the given state as the last applied state of
+// the given observed state
+func withLastApplied(
+	t *testing.T, observed, lastApplied *unstructured.Unstructured,
+) *unstructured.Unstructured {
+	raw, err := json.Marshal(lastApplied.UnstructuredContent())
+	if err != nil {
+		t.Fatalf("Can't marshal last applied: %+v", err)
+	}
+	annotations := observed.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[testWatchUID+LastAppliedAnnKeySuffix] = string(raw)
+	observed.SetAnnotations(annotations)
+	return observed
+}
+
+func TestCompute(t *testing.T) {
+	var tests = map[string]struct {
+		observed      *unstructured.Unstructured
+		desired       *unstructured.Unstructured
+		expectChanges []Change
+	}{
+		"not yet created": {
+			desired: newTestCStorPoolCluster(nil, "bd-1"),
+		},
+		"no diff": {
+			observed: withLastApplied(
+				t,
+				newTestCStorPoolCluster(nil, "bd-1"),
+				newTestCStorPoolCluster(nil, "bd-1"),
+			),
+			desired: newTestCStorPoolCluster(nil, "bd-1"),
+		},
+		"device is added & replaced": {
+			observed: withLastApplied(
+				t,
+				newTestCStorPoolCluster(nil, "bd-1"),
+				newTestCStorPoolCluster(nil, "bd-1"),
+			),
+			desired: newTestCStorPoolCluster(nil, "bd-2", "bd-3"),
+			expectChanges: []Change{
+				{
+					Path:      "spec.pools[0].raidGroups[0].blockDevices[0].blockDeviceName",
+					Operation: OperationChange,
+					Old:       `"bd-1"`,
+					New:       `"bd-2"`,
+				},
+				{
+					Path:      "spec.pools[0].raidGroups[0].blockDevices[1]",
+					Operation: OperationAdd,
+					New:       `{"blockDeviceName":"bd-3"}`,
+				},
+			},
+		},
+		"annotation is removed & secret is redacted": {
+			observed: withLastApplied(
+				t,
+				newTestCStorPoolCluster(map[string]string{
+					"team":          "storage",
+					"example.io/id": "1",
+				}, "bd-1"),
+				newTestCStorPoolCluster(map[string]string{
+					"team":          "storage",
+					"example.io/id": "1",
+				}, "bd-1"),
+			),
+			desired: newTestCStorPoolCluster(map[string]string{
+				"team":              "storage",
+				"example.io/secret": "s3cr3t",
+			}, "bd-1"),
+			expectChanges: []Change{
+				{
+					Path:      "metadata.annotations[example.io/id]",
+					Operation: OperationRemove,
+					Old:       `"1"`,
+				},
+				{
+					Path:      "metadata.annotations[example.io/secret]",
+					Operation: OperationAdd,
+					New:       redacted,
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Compute(testWatchUID, mock.observed, mock.desired)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got.HasDiff != (len(mock.expectChanges) != 0) {
+				t.Fatalf("Expected diff %t got %t", len(mock.expectChanges) != 0, got.HasDiff)
+			}
+			if diff := cmp.Diff(mock.expectChanges, got.Changes); diff != "" {
+				t.Fatalf("Expected no diff in changes got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResultSummary(t *testing.T) {
+	var changes []Change
+	for i := 0; i < 50; i++ {
+		changes = append(changes, Change{
+			Path:      "spec.pools[0].raidGroups[0].blockDevices[0].blockDeviceName",
+			Operation: OperationChange,
+			Old:       `"bd-1"`,
+			New:       `"bd-2"`,
+		})
+	}
+	var tests = map[string]struct {
+		result       Result
+		expect       string
+		expectSuffix string
+	}{
+		"no diff": {},
+		"single change": {
+			result: Result{HasDiff: true, Changes: changes[:1]},
+			expect: `~spec.pools[0].raidGroups[0].blockDevices[0].blockDeviceName: "bd-1" -> "bd-2"`,
+		},
+		"truncated changes": {
+			result:       Result{HasDiff: true, Changes: changes},
+			expectSuffix: "; +44 more",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.result.Summary()
+			if len(got) > MaxSummaryLength {
+				t.Fatalf("Expected summary within %d got %d", MaxSummaryLength, len(got))
+			}
+			if mock.expectSuffix != "" {
+				if !strings.HasSuffix(got, mock.expectSuffix) {
+					t.Fatalf("Expected suffix %q got %q", mock.expectSuffix, got)
+				}
+				return
+			}
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	status, err := SetCondition(nil, Result{
+		HasDiff: true,
+		Changes: []Change{{Path: "spec.x", Operation: OperationAdd, New: "1"}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{"status": status},
+	}
+	if !HasCondition(obj) {
+		t.Fatalf("Expected last sync diff condition got none")
+	}
+	conds, _, _ := unstructured.NestedSlice(status, "conditions")
+	cond := conds[0].(map[string]interface{})
+	if cond["status"] != string(types.ConditionIsPresent) || cond["reason"] != "+spec.x=1" {
+		t.Fatalf("Expected present condition with reason got %v", cond)
+	}
+	status, err = SetCondition(status, Result{})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	conds, _, _ = unstructured.NestedSlice(status, "conditions")
+	if len(conds) != 1 {
+		t.Fatalf("Expected 1 condition got %d", len(conds))
+	}
+	cond = conds[0].(map[string]interface{})
+	if cond["status"] != string(types.ConditionIsAbsent) {
+		t.Fatalf("Expected absent condition got %v", cond)
+	}
+}
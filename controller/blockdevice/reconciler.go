@@ -23,12 +23,23 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"openebs.io/metac/controller/generic"
 
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
 	"mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/skip"
 	stringcommon "mayadata.io/cstorpoolauto/common/string"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/index"
+	"mayadata.io/cstorpoolauto/pkg/lock"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 )
 
+// controllerName is reported against the skipped associations of
+// Storage with BlockDevice
+const controllerName = "BlockDevice"
+
 type reconcileErrHandler struct {
 	storage      *unstructured.Unstructured
 	hookResponse *generic.SyncHookResponse
@@ -45,11 +56,7 @@ func (h *reconcileErrHandler) handle(err error) {
 		h.storage.GetNamespace(), h.storage.GetName(), err,
 	)
 
-	conds, mergeErr :=
-		unstruct.MergeStatusConditions(
-			h.storage,
-			types.MakeStorageToBlockDeviceAssociationErrCond(err),
-		)
+	conds, mergeErr := conditions.MergeCondition(h.storage, types.MakeStorageToBlockDeviceAssociationErrCond(err))
 	if mergeErr != nil {
 		glog.Errorf(
 			"Can't set status conditions on Storage %s %s: %+v",
@@ -71,6 +78,10 @@ func (h *reconcileErrHandler) handle(err error) {
 
 	// stop further reconciliation since there was an error
 	h.hookResponse.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(err) {
+		h.hookResponse.ResyncAfterSeconds = errs.ResyncAfterSeconds(err)
+	}
 }
 
 // Sync implements the idempotent logic to reconcile
@@ -88,6 +99,11 @@ func (h *reconcileErrHandler) handle(err error) {
 // w.r.t the watched resource.
 //
 // NOTE:
+//	Associated BlockDevice is claimed by the BlockDeviceClaim
+// controller since it selects the devices labelled with the UID of
+// CStorClusterPlan.
+//
+// NOTE:
 //	Returning error will panic this process. We would rather want this
 // controller to run continuously. Hence, the errors are logged and at
 // the same time, these errors are posted against Storage's status field.
@@ -115,6 +131,7 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 
 	var cstorClusterStoragetSet *unstructured.Unstructured
 	var pvc *unstructured.Unstructured
+	var cstorClusterConfigs []*unstructured.Unstructured
 	for _, attachment := range request.Attachments.List() {
 		if attachment.GetKind() == string(types.KindBlockDevice) {
 			// No need to add BlockDevices to response now
@@ -142,27 +159,79 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 				pvc = attachment
 			}
 		}
+		if attachment.GetKind() == string(types.KindCStorClusterConfig) {
+			// config is verified after finding the storage set
+			cstorClusterConfigs = append(cstorClusterConfigs, attachment)
+		}
 		// add attachments as-is if they are not of kind BlockDevice
 		response.Attachments = append(response.Attachments, attachment)
 	}
 
 	if cstorClusterStoragetSet == nil {
-		errHandler.handle(errors.Errorf("CStorClusterStorageSet instance is missing"))
+		// storage set may not be observed yet
+		errHandler.handle(errs.TransientErrorf("CStorClusterStorageSet instance is missing"))
+		return nil
+	}
+
+	// config is found via the storage set of this Storage
+	var cstorClusterConfig *unstructured.Unstructured
+	configUID, _ := unstruct.GetValueForKey(
+		cstorClusterStoragetSet.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+	)
+	for _, config := range cstorClusterConfigs {
+		if string(config.GetUID()) == configUID {
+			cstorClusterConfig = config
+		}
+	}
+	isPaused, err := pause.Skip(controllerName, cstorClusterConfig, request.Watch, response)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if isPaused {
 		return nil
 	}
 
 	if pvc == nil {
-		glog.V(3).Infof("Will skip association of BlockDevice with Storage: Missing PVC")
-		response.SkipReconcile = true
+		err = skip.Skip(
+			controllerName, request.Watch, response,
+			skip.ReasonPVCNotFound, "PersistentVolumeClaim is not observed yet",
+		)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
 		response.ResyncAfterSeconds = 3
 		return nil
 	}
 
+	if pvc.GetDeletionTimestamp() != nil {
+		// reclaim policy of the storage set deletes this claim
+		err = skip.Skip(
+			controllerName, request.Watch, response,
+			skip.ReasonPVCPendingDeletion, "PersistentVolumeClaim is being deleted",
+		)
+		if err != nil {
+			errHandler.handle(err)
+		}
+		return nil
+	}
+
+	// serialize with other reconciliations that select block
+	// devices of this node
+	nodeName, _ := unstruct.GetString(request.Watch, "spec", "nodeName")
+	unlock := lock.Reconcile(lock.NodeKey(nodeName))
+	defer unlock()
+
+	// devices are reserved per Storage since every Storage is
+	// associated with its own device
+	storageUID := string(request.Watch.GetUID())
 	reconciler := &Reconciler{
-		Storage:           request.Watch,
-		PVC:               pvc,
-		StorageSet:        cstorClusterStoragetSet,
-		ObservedResources: request.Attachments.List(),
+		Storage:                  request.Watch,
+		PVC:                      pvc,
+		StorageSet:               cstorClusterStoragetSet,
+		ObservedResources:        request.Attachments.List(),
+		ReservedBlockDeviceNames: lock.DevicesReservedByOthers(storageUID),
 	}
 	op, err := reconciler.Reconcile()
 	if err != nil {
@@ -171,10 +240,28 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 	}
 	// check if association ever happened in this attempt
 	if op.isSkipAssociation {
-		response.SkipReconcile = true
+		err = skip.Skip(
+			controllerName, request.Watch, response,
+			skip.ReasonAssociationPending, "BlockDevice is not associated yet",
+		)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
 		response.ResyncAfterSeconds = 3
 	} else {
 		response.Attachments = append(response.Attachments, op.DesiredBlockDevices...)
+		// associated devices stay reserved till these are claimed
+		var names []string
+		for _, device := range op.DesiredBlockDevices {
+			names = append(names, device.GetName())
+		}
+		lock.ReserveDevices(storageUID, names...)
+		err = skip.Clear(controllerName, request.Watch, response)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
 	}
 
 	// TODO (@amitkumardas):
@@ -199,6 +286,11 @@ type Reconciler struct {
 	Storage           *unstructured.Unstructured
 	PVC               *unstructured.Unstructured
 	ObservedResources []*unstructured.Unstructured
+
+	// ReservedBlockDeviceNames are the block devices associated by
+	// other reconciliations that are yet to be claimed. These are
+	// not associated.
+	ReservedBlockDeviceNames map[string]bool
 }
 
 // ReconcileResponse forms the response due to reconciliation of
@@ -222,6 +314,9 @@ func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
 		StorageSet:        r.StorageSet,
 		PVC:               r.PVC,
 		ObservedResources: r.ObservedResources,
+		// devices are indexed once per sync
+		BlockDeviceIndex:         index.NewBlockDeviceIndexFromAttachments(r.ObservedResources),
+		ReservedBlockDeviceNames: r.ReservedBlockDeviceNames,
 	}
 	desiredBlockDevices, isAssociate, err := associator.Associate()
 	if err != nil {
@@ -257,7 +352,7 @@ func (r *Reconciler) getStorageStatusAsNoError() (map[string]interface{}, error)
 		return nil, errors.Errorf("Invalid storage: Can't find status.phase")
 	}
 	// get updated conditions
-	conds, err := unstruct.MergeStatusConditions(
+	conds, err := conditions.MergeCondition(
 		r.Storage,
 		map[string]interface{}{
 			"type":             types.StorageToBlockDeviceAssociationErrorCondition,
@@ -281,6 +376,14 @@ type StorageToBlockDeviceAssociator struct {
 	StorageSet        *unstructured.Unstructured
 	PVC               *unstructured.Unstructured
 	ObservedResources []*unstructured.Unstructured
+
+	// BlockDeviceIndex is the index of observed BlockDevice(s)
+	// & is built from observed resources if not set
+	BlockDeviceIndex *index.BlockDeviceIndex
+
+	// ReservedBlockDeviceNames are the block devices that are not
+	// associated since these are reserved by other reconciliations
+	ReservedBlockDeviceNames map[string]bool
 }
 
 // Associate will first filter the matching BlockDevice(s
@@ -346,8 +449,27 @@ func (p *StorageToBlockDeviceAssociator) Associate() ([]*unstructured.Unstructur
 		// return observedBlockDevices, nil
 		return []*unstructured.Unstructured{}, false, nil
 	}
+	matchingBlockDevices, err = p.removeExcludedBlockDevices(matchingBlockDevices)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(matchingBlockDevices) == 0 {
+		glog.V(3).Infof(
+			"Will skip BlockDevice association: BlockDevice for PV %s is excluded: Storage %s %s",
+			pvName, p.Storage.GetNamespace(), p.Storage.GetName(),
+		)
+		return []*unstructured.Unstructured{}, false, nil
+	}
+	matchingBlockDevices = p.removeReservedBlockDevices(matchingBlockDevices)
+	if len(matchingBlockDevices) == 0 {
+		glog.V(3).Infof(
+			"Will skip BlockDevice association: BlockDevice for PV %s is reserved: Storage %s %s",
+			pvName, p.Storage.GetNamespace(), p.Storage.GetName(),
+		)
+		return []*unstructured.Unstructured{}, false, nil
+	}
 	if len(matchingBlockDevices) > 1 {
-		return nil, false, errors.Errorf(
+		return nil, false, errs.ConflictErrorf(
 			"Found %d BlockDevices with PV %s: Want exactly one BlockDevice",
 			len(matchingBlockDevices), pvName,
 		)
@@ -364,14 +486,49 @@ func (p *StorageToBlockDeviceAssociator) Associate() ([]*unstructured.Unstructur
 	return append(final, annotated...), true, nil
 }
 
-func (p *StorageToBlockDeviceAssociator) getObservedBlockDevices() []*unstructured.Unstructured {
-	var blockDevices []*unstructured.Unstructured
-	for _, resource := range p.ObservedResources {
-		if resource.GetKind() == string(types.KindBlockDevice) {
-			blockDevices = append(blockDevices, resource)
+// removeExcludedBlockDevices returns the given block devices after
+// removing the ones that match the exclude terms set in StorageSet
+func (p *StorageToBlockDeviceAssociator) removeExcludedBlockDevices(
+	blockDevices []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	if p.StorageSet == nil {
+		return blockDevices, nil
+	}
+	var storageSetTyped types.CStorClusterStorageSet
+	err := unstruct.UnstructToTyped(p.StorageSet, &storageSetTyped)
+	if err != nil {
+		return nil, err
+	}
+	exclude := storageSetTyped.Spec.BlockDeviceExclude
+	if exclude == nil || len(exclude.SelectorTerms) == 0 {
+		return blockDevices, nil
+	}
+	// exclude terms can refer to stable devlinks e.g. spec.devlinks.by-id
+	_, nomatches, err := bd.SelectAll(*exclude, blockDevices)
+	return nomatches, err
+}
+
+// removeReservedBlockDevices returns the given block devices after
+// removing the ones reserved by other reconciliations
+func (p *StorageToBlockDeviceAssociator) removeReservedBlockDevices(
+	blockDevices []*unstructured.Unstructured,
+) []*unstructured.Unstructured {
+	var available []*unstructured.Unstructured
+	for _, device := range blockDevices {
+		if p.ReservedBlockDeviceNames[device.GetName()] {
+			continue
 		}
+		available = append(available, device)
+	}
+	return available
+}
+
+func (p *StorageToBlockDeviceAssociator) getObservedBlockDevices() []*unstructured.Unstructured {
+	if p.BlockDeviceIndex == nil {
+		p.BlockDeviceIndex =
+			index.NewBlockDeviceIndexFromAttachments(p.ObservedResources)
 	}
-	return blockDevices
+	return p.BlockDeviceIndex.List()
 }
 
 func (p *StorageToBlockDeviceAssociator) filterBlockDevicesWithPVName(
@@ -450,6 +607,8 @@ func (p *StorageToBlockDeviceAssociator) annotateBlockDevicesIfUnclaimed(
 			return nil, err
 		}
 		if !isUnclaimed {
+			// claimed device is no longer reserved
+			lock.ReleaseDevices(device.GetName())
 			// TODO (@amitkumardas):
 			// 	Read previous notes on bug & enhancement required
 			// at metac
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdeviceclaim
+
+import (
+	"github.com/golang/glog"
+	"openebs.io/metac/controller/generic"
+
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+)
+
+// FinalizeResyncAfterSeconds is the interval after which the
+// finalize gets reconciled again while CStorPoolCluster is
+// being deleted
+var FinalizeResyncAfterSeconds float64 = 5
+
+type finalizer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	attachments attachments
+
+	fatal error
+}
+
+func (f *finalizer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	f.fatal = metaccommon.ValidateGenericControllerArgs(f.request, f.response)
+}
+
+func (f *finalizer) logFinalizeStart() {
+	glog.V(3).Infof(
+		"Started BlockDeviceClaim finalize: Watch %q - %q / %q",
+		f.request.Watch.GetKind(),
+		f.request.Watch.GetNamespace(),
+		f.request.Watch.GetName(),
+	)
+}
+
+func (f *finalizer) registerAttachments() {
+	if f.request.Attachments == nil {
+		return
+	}
+	f.response.Attachments = append(
+		f.response.Attachments,
+		f.attachments.register(f.request.Watch, f.request.Attachments.List())...,
+	)
+}
+
+// releaseClaimsIfNoCStorPoolCluster releases the claims only after
+// the CStorPoolCluster is deleted. This ensures the devices are not
+// handed over to others while they are still used by the pools.
+func (f *finalizer) releaseClaimsIfNoCStorPoolCluster() {
+	if f.attachments.cstorPoolCluster != nil {
+		// retain the claims by adding them to response
+		f.response.Attachments = append(
+			f.response.Attachments, f.attachments.blockDeviceClaims...,
+		)
+		f.response.ResyncAfterSeconds = FinalizeResyncAfterSeconds
+		return
+	}
+	// claims not added to response get deleted by metac
+	//
+	// NOTE:
+	//	Finalize completes only after all the claims are deleted
+	f.response.Finalized = len(f.attachments.blockDeviceClaims) == 0
+}
+
+func (f *finalizer) logFinalizeFinish() {
+	glog.V(2).Infof(
+		"Finished BlockDeviceClaim finalize: Finalized %t: Watch %q - %q / %q: %s",
+		f.response.Finalized,
+		f.request.Watch.GetKind(),
+		f.request.Watch.GetNamespace(),
+		f.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(f.response),
+	)
+}
+
+func (f *finalizer) finalize() error {
+	fns := []func(){
+		f.validateArgs,
+		f.logFinalizeStart,
+		f.registerAttachments,
+		f.releaseClaimsIfNoCStorPoolCluster,
+		f.logFinalizeFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if f.fatal != nil {
+			// this panics
+			return f.fatal
+		}
+	}
+	return nil
+}
+
+// Finalize implements the idempotent logic to release the
+// BlockDeviceClaim(s) of a CStorClusterConfig. This gets triggered
+// only when CStorClusterConfig is being deleted.
+//
+// NOTE:
+// 	Finalize hook automatically sets a finalizer against the watch.
+// This finalizer is removed when hookresponse's Finalized field
+// is set to true.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Finalize(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	f := &finalizer{
+		request:  request,
+		response: response,
+	}
+	return f.finalize()
+}
@@ -0,0 +1,952 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdeviceclaim
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"openebs.io/metac/controller/generic"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	bdc "mayadata.io/cstorpoolauto/common/blockdeviceclaim"
+	"mayadata.io/cstorpoolauto/common/capacity"
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	"mayadata.io/cstorpoolauto/common/disksource"
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/naming"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/skip"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/tracing"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// controllerName is reported against the skipped reconciliations
+// of this controller
+const controllerName = "BlockDeviceClaim"
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	attachments attachments
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+// attachments holds the attachments of CStorClusterConfig that
+// are of interest to BlockDeviceClaim management
+type attachments struct {
+	blockDevices      []*unstructured.Unstructured
+	blockDeviceClaims []*unstructured.Unstructured
+	cstorClusterPlan  *unstructured.Unstructured
+	cstorPoolCluster  *unstructured.Unstructured
+	nodes             []*unstructured.Unstructured
+
+	// more than one CStorPoolCluster is observed if the pools are
+	// sharded by failure domain
+	cstorPoolClusters []*unstructured.Unstructured
+}
+
+// register filters the given attachments of the watch. The ones
+// that are not managed by this controller are returned.
+//
+// NOTE:
+//	Attachments that are not managed by this controller should
+// be added to response as is. Otherwise metac will delete the
+// ones that were created due to this watch.
+func (a *attachments) register(
+	watch *unstructured.Unstructured, observed []*unstructured.Unstructured,
+) (unmanaged []*unstructured.Unstructured) {
+	belongsToWatch := func(obj *unstructured.Unstructured) bool {
+		uid, _ := unstruct.GetValueForKey(
+			obj.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+		)
+		return string(watch.GetUID()) == uid
+	}
+	for _, attachment := range observed {
+		switch attachment.GetKind() {
+		case string(types.KindBlockDevice):
+			a.blockDevices = append(a.blockDevices, attachment)
+		case string(types.KindBlockDeviceClaim):
+			if belongsToWatch(attachment) {
+				a.blockDeviceClaims = append(a.blockDeviceClaims, attachment)
+				// claims are added to response after reconciliation
+				continue
+			}
+		case string(types.KindCStorClusterPlan):
+			if belongsToWatch(attachment) {
+				a.cstorClusterPlan = attachment
+			}
+		case string(types.KindCStorPoolCluster):
+			if belongsToWatch(attachment) {
+				// cspc is only observed & is never modified
+				a.cstorPoolCluster = attachment
+				a.cstorPoolClusters = append(a.cstorPoolClusters, attachment)
+			}
+		case string(types.KindNode):
+			// nodes restrict the devices if all devices are selected
+			a.nodes = append(a.nodes, attachment)
+		}
+		unmanaged = append(unmanaged, attachment)
+	}
+	return unmanaged
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) skipIfPaused() {
+	_, s.err = pause.Skip(
+		controllerName, s.request.Watch, s.request.Watch, s.response,
+	)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started BlockDeviceClaim sync: Watch %q - %q / %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+	)
+}
+
+func (s *syncer) registerAttachments() {
+	if s.request.Attachments == nil {
+		return
+	}
+	s.response.Attachments = append(
+		s.response.Attachments,
+		s.attachments.register(s.request.Watch, s.request.Attachments.List())...,
+	)
+}
+
+// mergeCStorPoolClusters returns a copy of the first of the given
+// CStorPoolClusters that has the pools of all of them
+//
+// NOTE:
+//	CStorPoolClusters are sharded by failure domain. The merged
+// CStorPoolCluster is only observed to find the devices in use &
+// is never applied.
+func mergeCStorPoolClusters(
+	cspcs []*unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	if len(cspcs) <= 1 {
+		return nil, errors.Errorf(
+			"Can't merge CStorPoolClusters: Want more than 1 got %d", len(cspcs),
+		)
+	}
+	var pools []interface{}
+	for _, obj := range cspcs {
+		observed, _, err := unstructured.NestedSlice(obj.Object, "spec", "pools")
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't merge CStorPoolCluster %q / %q",
+				obj.GetNamespace(), obj.GetName(),
+			)
+		}
+		pools = append(pools, observed...)
+	}
+	merged := cspcs[0].DeepCopy()
+	err := unstructured.SetNestedSlice(merged.Object, pools, "spec", "pools")
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func (s *syncer) reconcile() {
+	observedCStorPoolCluster := s.attachments.cstorPoolCluster
+	if len(s.attachments.cstorPoolClusters) > 1 {
+		observedCStorPoolCluster, s.err =
+			mergeCStorPoolClusters(s.attachments.cstorPoolClusters)
+		if s.err != nil {
+			return
+		}
+	}
+	reconciler := &Reconciler{
+		ObservedCStorClusterConfig: s.request.Watch,
+		ObservedCStorClusterPlan:   s.attachments.cstorClusterPlan,
+		ObservedCStorPoolCluster:   observedCStorPoolCluster,
+		ObservedCStorPoolClusters:  s.attachments.cstorPoolClusters,
+		ObservedBlockDevices:       s.attachments.blockDevices,
+		ObservedBlockDeviceClaims:  s.attachments.blockDeviceClaims,
+		ObservedNodes:              s.attachments.nodes,
+		Context:                    tracing.ContextOf(s.request),
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+	if s.err != nil {
+		return
+	}
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.BlockDeviceClaims...,
+	)
+}
+
+// reportClaimStatus sets the state of the claims against the
+// watch's status
+//
+// NOTE:
+//	Status is copied from the watch since other controllers set
+// their own fields against the same status. This status changes
+// only when the claims change their state.
+func (s *syncer) reportClaimStatus() {
+	var status map[string]interface{}
+	status, _, s.err = unstructured.NestedMap(s.request.Watch.Object, "status")
+	if s.err != nil {
+		return
+	}
+	s.response.Status, s.err = ccc.SetBlockDeviceClaims(
+		status, s.reconcileResponse.ClaimStatus,
+	)
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished BlockDeviceClaim sync: Pending claims %d: Watch %q - %q / %q: %s",
+		len(s.reconcileResponse.PendingDeviceNames),
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// clearSkipCondition removes the condition that was set when this
+// controller skipped an earlier sync
+func (s *syncer) clearSkipCondition() {
+	s.err = skip.Clear(controllerName, s.request.Watch, s.response)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync BlockDeviceClaim: Watch %q - %q / %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	tracing.RecordError(tracing.ContextOf(s.request), s.err)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.skipIfPaused,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.reconcile,
+		s.reportClaimStatus,
+		s.clearSkipCondition,
+		s.logSyncFinish,
+	}
+	// each step is traced as a phase of this sync
+	ctx := tracing.ContextOf(s.request)
+	for _, fn := range fns {
+		end := tracing.StartPhase(ctx, fn)
+		fn()
+		end()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to claim the BlockDevice(s)
+// that are selected to form the cstor pools of a CStorClusterConfig.
+// A BlockDeviceClaim is applied for each selected BlockDevice. The
+// claim is released once its device is neither selected nor used
+// by the CStorPoolCluster.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses CStorClusterConfig as the watched resource.
+// SyncHookResponse has the resources that forms the desired state
+// w.r.t the watched resource.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// Reconciler enables reconciliation of BlockDeviceClaim(s) of
+// a CStorClusterConfig instance
+type Reconciler struct {
+	ObservedCStorClusterConfig *unstructured.Unstructured
+	ObservedCStorClusterPlan   *unstructured.Unstructured
+	ObservedCStorPoolCluster   *unstructured.Unstructured
+	ObservedBlockDevices       []*unstructured.Unstructured
+	ObservedNodes              []*unstructured.Unstructured
+
+	// claims that belong to the observed CStorClusterConfig
+	ObservedBlockDeviceClaims []*unstructured.Unstructured
+
+	// ObservedCStorPoolClusters are set if the pools are sharded by
+	// failure domain. ObservedCStorPoolCluster has the pools of all
+	// of them in this case.
+	ObservedCStorPoolClusters []*unstructured.Unstructured
+
+	// Context is the context of the traced sync if any. Each step
+	// of reconciliation is traced as a phase of this sync.
+	Context context.Context
+
+	cccHelper *ccc.Helper
+
+	isDiskLocal          bool
+	childMetadata        *types.ChildMetadata
+	cstorPoolClusterName string
+
+	// devices provisioned for the observed CStorClusterPlan if the
+	// disk config is hybrid
+	isHybrid            bool
+	plannedBlockDevices []*unstructured.Unstructured
+
+	// name of the CStorPoolCluster of each host if the pools are
+	// sharded by failure domain
+	hostNameToCStorPoolClusterName map[string]string
+
+	selectedBlockDevices []*unstructured.Unstructured
+	inUseDeviceNames     map[string]bool
+	desiredClaims        []*unstructured.Unstructured
+	desiredDeviceNames   map[string]bool
+	pendingDeviceNames   []string
+	skippedBlockDevices  []types.CStorClusterConfigRejectedBlockDevice
+	err                  error
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	BlockDeviceClaims []*unstructured.Unstructured
+
+	// names of the devices whose claims are not bound
+	PendingDeviceNames []string
+
+	// ClaimStatus is the state of the claims to be reported against
+	// the CStorClusterConfig
+	ClaimStatus *types.CStorClusterConfigBlockDeviceClaims
+}
+
+// NilReconcileResponse is used to represent a nil
+// ReconcileResponse value
+var NilReconcileResponse = ReconcileResponse{}
+
+func (r *Reconciler) init() {
+	r.cccHelper = ccc.NewHelper(r.ObservedCStorClusterConfig)
+	r.inUseDeviceNames = map[string]bool{}
+	r.desiredDeviceNames = map[string]bool{}
+}
+
+func (r *Reconciler) setIsDiskLocal() {
+	r.isDiskLocal, r.err = r.cccHelper.IsLocalBlockDiskConfig()
+}
+
+// splitBlockDevicesByDiskSource restricts the observed block devices
+// to the devices of the nodes that use local disks if the disk config
+// is hybrid. Devices provisioned for the observed CStorClusterPlan
+// are claimed along with the selected local devices.
+//
+// NOTE:
+//	Devices are split the same way the LocalDevice controller splits
+// them
+func (r *Reconciler) splitBlockDevicesByDiskSource() {
+	var overrides []types.DiskNodeOverride
+	overrides, r.isHybrid, r.err = r.cccHelper.GetHybridDiskNodeOverrides()
+	if r.err != nil || !r.isHybrid {
+		return
+	}
+	var planUID string
+	if r.ObservedCStorClusterPlan != nil {
+		planUID = string(r.ObservedCStorClusterPlan.GetUID())
+	}
+	resolver := disksource.Resolver{
+		Overrides: overrides,
+		Nodes:     r.ObservedNodes,
+	}
+	r.ObservedBlockDevices, r.plannedBlockDevices, r.err =
+		resolver.SplitBlockDevices(r.ObservedBlockDevices, planUID)
+}
+
+func (r *Reconciler) setChildMetadata() {
+	// labels & annotations to be propagated to BlockDeviceClaim(s)
+	r.childMetadata, r.err = r.cccHelper.GetChildMetadata()
+}
+
+func (r *Reconciler) setCStorPoolClusterName() {
+	// claims refer to the observed CStorPoolCluster if any
+	r.cstorPoolClusterName, r.err =
+		r.cccHelper.GetChildNameOrObserved(r.ObservedCStorPoolCluster)
+}
+
+// setHostNameToCStorPoolClusterName maps each host to the name of
+// the CStorPoolCluster of its failure domain if the pools are
+// sharded by failure domain
+//
+// NOTE:
+//	This name is derived the same way the LocalDevice controller
+// derives the name of the CStorPoolCluster of a failure domain
+func (r *Reconciler) setHostNameToCStorPoolClusterName() {
+	var key string
+	key, r.err = r.cccHelper.GetLocalFailureDomainKey()
+	if r.err != nil || key == "" {
+		return
+	}
+	domainToName := map[string]string{}
+	for _, observed := range r.ObservedCStorPoolClusters {
+		domain :=
+			observed.GetAnnotations()[types.AnnKeyCStorPoolClusterFailureDomain]
+		if domain != "" {
+			domainToName[domain] = observed.GetName()
+		}
+	}
+	r.hostNameToCStorPoolClusterName = map[string]string{}
+	for _, node := range r.ObservedNodes {
+		domain := node.GetLabels()[key]
+		if domain == "" {
+			continue
+		}
+		name, found := domainToName[domain]
+		if !found {
+			// observed CStorPoolCluster is never renamed
+			name, r.err = r.cccHelper.GetChildName(naming.Part(domain))
+			if r.err != nil {
+				return
+			}
+		}
+		r.hostNameToCStorPoolClusterName[nodecommon.GetHostName(node)] = name
+	}
+}
+
+// selectLocalBlockDevices selects the observed blockdevices based
+// on local disk selector terms & thereafter drops the ones matching
+// local disk exclude terms
+func (r *Reconciler) selectLocalBlockDevices() {
+	var selector, exclude types.BlockDeviceSelector
+	selector, r.err = r.cccHelper.GetLocalBlockDeviceSelector()
+	if r.err != nil {
+		return
+	}
+	var explicitDeviceMap []types.ExplicitDeviceMapping
+	explicitDeviceMap, r.err = r.cccHelper.GetLocalExplicitDeviceMap()
+	if r.err != nil {
+		return
+	}
+	if len(selector.SelectorTerms) == 0 && len(explicitDeviceMap) == 0 {
+		r.err = errs.ValidationErrorf(
+			"Invalid CStorClusterConfig: No block device selector found",
+		)
+		return
+	}
+	exclude, r.err = r.cccHelper.GetLocalBlockDeviceExclude()
+	if r.err != nil {
+		return
+	}
+	var isSelectAll bool
+	isSelectAll, r.err = r.cccHelper.IsLocalBlockDeviceSelectAll()
+	if r.err != nil {
+		return
+	}
+	if isSelectAll {
+		r.selectedBlockDevices, r.err = r.selectAllBlockDevices(selector)
+	} else if len(selector.SelectorTerms) != 0 {
+		// terms can refer to stable devlinks e.g. spec.devlinks.by-id
+		r.selectedBlockDevices, _, r.err =
+			bd.SelectAll(selector, r.ObservedBlockDevices)
+	}
+	if r.err != nil {
+		return
+	}
+	if len(exclude.SelectorTerms) != 0 {
+		_, r.selectedBlockDevices, r.err =
+			bd.SelectAll(exclude, r.selectedBlockDevices)
+		if r.err != nil {
+			return
+		}
+	}
+	r.selectedBlockDevices, r.err =
+		r.dropReservedBlockDevices(r.selectedBlockDevices)
+	if r.err != nil {
+		return
+	}
+	// pinned devices are selected the same way the LocalDevice
+	// controller selects them
+	r.selectedBlockDevices, r.err =
+		r.applyExplicitDeviceMap(explicitDeviceMap, r.selectedBlockDevices)
+	if r.err != nil {
+		return
+	}
+	r.selectedBlockDevices, r.err =
+		r.selectBlockDevicesWithinCapacityBounds(r.selectedBlockDevices)
+}
+
+// applyExplicitDeviceMap replaces the given block devices of the
+// nodes listed in the given explicit device map with their pinned
+// block devices
+func (r *Reconciler) applyExplicitDeviceMap(
+	mappings []types.ExplicitDeviceMapping,
+	devices []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	if len(mappings) == 0 {
+		return devices, nil
+	}
+	owned, err := bdc.GetOwnedDeviceNames(
+		r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return bd.ExplicitMapCheck{
+		Mappings:         mappings,
+		Devices:          r.ObservedBlockDevices,
+		OwnedDeviceNames: owned,
+	}.Apply(devices)
+}
+
+// dropReservedBlockDevices drops the given block devices that are
+// reserved for other consumers. Devices used by the observed
+// CStorPoolCluster are never dropped.
+func (r *Reconciler) dropReservedBlockDevices(
+	devices []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	inUseDeviceNames, err :=
+		bdc.GetCStorPoolClusterDeviceNames(r.ObservedCStorPoolCluster)
+	if err != nil {
+		return nil, err
+	}
+	check := bd.ReservationCheck{
+		Keys:             bd.ReservedKeys,
+		Devices:          devices,
+		InUseDeviceNames: map[string]bool{},
+	}
+	for _, name := range inUseDeviceNames {
+		check.InUseDeviceNames[name] = true
+	}
+	return check.Apply(), nil
+}
+
+// selectBlockDevicesWithinCapacityBounds drops the given block
+// devices whose capacity is outside the configured min & max device
+// capacities. Devices used by the observed CStorPoolCluster are
+// never dropped.
+func (r *Reconciler) selectBlockDevicesWithinCapacityBounds(
+	devices []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	minCapacity, maxCapacity, err := r.cccHelper.GetLocalDeviceCapacityBounds()
+	if err != nil || (minCapacity == nil && maxCapacity == nil) {
+		return devices, err
+	}
+	inUseDeviceNames, err :=
+		bdc.GetCStorPoolClusterDeviceNames(r.ObservedCStorPoolCluster)
+	if err != nil {
+		return nil, err
+	}
+	check := bd.CapacityCheck{
+		Min:              minCapacity,
+		Max:              maxCapacity,
+		Devices:          devices,
+		InUseDeviceNames: map[string]bool{},
+	}
+	for _, name := range inUseDeviceNames {
+		check.InUseDeviceNames[name] = true
+	}
+	return check.Apply()
+}
+
+// selectAllBlockDevices selects the active & unclaimed block devices
+// of the allowed nodes along with the devices claimed by this config
+//
+// NOTE:
+//	Devices are restricted to the allowed nodes only if nodes
+// are observed
+func (r *Reconciler) selectAllBlockDevices(
+	selector types.BlockDeviceSelector,
+) ([]*unstructured.Unstructured, error) {
+	owned, err := bdc.GetOwnedDeviceNames(
+		r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+	if err != nil {
+		return nil, err
+	}
+	check := bd.SelectAllCheck{
+		Selector:         selector,
+		Devices:          r.ObservedBlockDevices,
+		OwnedDeviceNames: owned,
+	}
+	if len(r.ObservedNodes) != 0 {
+		allowedNodeSelector, err := r.cccHelper.GetAllowedNodeSelector()
+		if err != nil {
+			return nil, err
+		}
+		check.AllowedHostNames, err =
+			nodecommon.GetAllowedHostNames(allowedNodeSelector, r.ObservedNodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return check.Apply()
+}
+
+// selectPlannedBlockDevices selects the observed blockdevices that
+// were associated with the observed CStorClusterPlan
+func (r *Reconciler) selectPlannedBlockDevices() {
+	if r.ObservedCStorClusterPlan == nil {
+		// nothing to select since plan is not available yet
+		return
+	}
+	for _, device := range r.ObservedBlockDevices {
+		// TODO (@amitkumardas):
+		//	We are using labels since there might be a bug
+		// in metac to merge annotations. Use of labels is a
+		// workaround that needs to be changed to annotations
+		// once metac fixes this bug.
+		uid, _ := unstruct.GetValueForKey(
+			device.GetLabels(), types.AnnKeyCStorClusterPlanUID,
+		)
+		if string(r.ObservedCStorClusterPlan.GetUID()) == uid {
+			r.selectedBlockDevices = append(r.selectedBlockDevices, device)
+		}
+	}
+}
+
+func (r *Reconciler) selectBlockDevices() {
+	if r.isDiskLocal {
+		r.selectLocalBlockDevices()
+	} else {
+		r.selectPlannedBlockDevices()
+	}
+}
+
+// setInUseDeviceNames sets the names of the devices that are
+// used by the observed CStorPoolCluster
+func (r *Reconciler) setInUseDeviceNames() {
+	var names []string
+	names, r.err = bdc.GetCStorPoolClusterDeviceNames(r.ObservedCStorPoolCluster)
+	if r.err != nil {
+		return
+	}
+	for _, name := range names {
+		r.inUseDeviceNames[name] = true
+	}
+}
+
+// rejectUnhealthyBlockDevices drops the selected local block devices
+// that failed SMART checks if the CStorClusterConfig requires so
+//
+// NOTE:
+//	Devices used by the observed CStorPoolCluster are never dropped.
+// Rejected devices are reported by the localdevice controller.
+func (r *Reconciler) rejectUnhealthyBlockDevices() {
+	if !r.isDiskLocal {
+		return
+	}
+	var isRequireSMARTPass bool
+	isRequireSMARTPass, r.err = r.cccHelper.IsRequireSMARTPass()
+	if r.err != nil || !isRequireSMARTPass {
+		return
+	}
+	r.selectedBlockDevices, _, r.err = bd.SMARTCheck{
+		Devices:          r.selectedBlockDevices,
+		InUseDeviceNames: r.inUseDeviceNames,
+	}.Apply()
+}
+
+// reserveCapacityPerNode drops some of the selected local block
+// devices to leave the reserved raw capacity unclaimed on every node
+//
+// NOTE:
+//	Devices used by the observed CStorPoolCluster are never dropped.
+// Planned block devices are not considered since their selection is
+// done by CStorClusterPlan.
+func (r *Reconciler) reserveCapacityPerNode() {
+	if !r.isDiskLocal {
+		return
+	}
+	var reservePerNode *intstr.IntOrString
+	reservePerNode, r.err = r.cccHelper.GetReservePerNode()
+	if r.err != nil || reservePerNode == nil {
+		return
+	}
+	var raidType types.PoolRAIDType
+	raidType, r.err = r.cccHelper.GetRAIDTypeOrCached()
+	if r.err != nil {
+		return
+	}
+	var raidGroupConfig *types.PoolRAIDGroupConfig
+	raidGroupConfig, r.err = r.cccHelper.GetRAIDGroupConfig()
+	if r.err != nil {
+		return
+	}
+	var preference types.DevicePreference
+	preference, r.err = r.cccHelper.GetDevicePreference()
+	if r.err != nil {
+		return
+	}
+	var wear *bd.WearScorer
+	wear, r.err = r.cccHelper.GetWearScorer()
+	if r.err != nil {
+		return
+	}
+	var inUseDeviceNames []string
+	for name := range r.inUseDeviceNames {
+		inUseDeviceNames = append(inUseDeviceNames, name)
+	}
+	reservation := capacity.Reservation{
+		ReservePerNode:       reservePerNode,
+		ObservedBlockDevices: r.ObservedBlockDevices,
+		SelectedBlockDevices: r.selectedBlockDevices,
+		InUseDeviceNames:     inUseDeviceNames,
+		GroupDeviceCount:     types.GetRAIDGroupDiskCount(raidType, raidGroupConfig),
+		Preference:           preference,
+		Wear:                 wear,
+	}
+	r.selectedBlockDevices, r.err = reservation.Apply()
+}
+
+// addPlannedBlockDevices adds the block devices provisioned for the
+// nodes that use external disks to the selected block devices if the
+// disk config is hybrid
+//
+// NOTE:
+//	This is done after local devices are dropped for health checks
+// & reservations since these apply to local devices only
+func (r *Reconciler) addPlannedBlockDevices() {
+	if !r.isHybrid {
+		return
+	}
+	r.selectedBlockDevices =
+		append(r.selectedBlockDevices, r.plannedBlockDevices...)
+}
+
+func (r *Reconciler) buildDesiredClaim(
+	deviceName, namespace, hostName string,
+) (*unstructured.Unstructured, error) {
+	cstorPoolClusterName := r.cstorPoolClusterName
+	if name := r.hostNameToCStorPoolClusterName[hostName]; name != "" {
+		// claim refers to the CStorPoolCluster of its failure domain
+		cstorPoolClusterName = name
+	}
+	b := &bdc.Builder{
+		BlockDeviceName:      deviceName,
+		Namespace:            namespace,
+		HostName:             hostName,
+		CStorPoolClusterName: cstorPoolClusterName,
+		DesiredAnnotations: map[string]string{
+			types.AnnKeyCStorClusterConfigUID: string(r.ObservedCStorClusterConfig.GetUID()),
+		},
+		ChildMetadata: r.childMetadata,
+	}
+	return b.BuildDesiredState()
+}
+
+// buildDesiredClaimsOfSelectedDevices builds a claim for each
+// selected blockdevice
+//
+// NOTE:
+//	Selected devices that are claimed elsewhere are skipped. A
+// device claimed by an observed claim of this config or used by the
+// observed CStorPoolCluster is not skipped.
+func (r *Reconciler) buildDesiredClaimsOfSelectedDevices() {
+	owned, err := bdc.GetOwnedDeviceNames(
+		r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+	if err != nil {
+		r.err = err
+		return
+	}
+	for _, device := range r.selectedBlockDevices {
+		hostName, err := bd.NewHelper(device).GetHostName()
+		if err != nil {
+			r.err = err
+			return
+		}
+		claimState, _, _ := unstructured.NestedString(
+			device.Object, "status", "claimState",
+		)
+		if !owned[device.GetName()] &&
+			claimState != "" && claimState != string(types.BlockDeviceUnclaimed) {
+			glog.V(3).Infof(
+				"Skipped claim of BlockDevice %s %s: Claim state %q: CStorClusterConfig %s %s",
+				device.GetNamespace(), device.GetName(), claimState,
+				r.ObservedCStorClusterConfig.GetNamespace(),
+				r.ObservedCStorClusterConfig.GetName(),
+			)
+			r.skippedBlockDevices = append(
+				r.skippedBlockDevices,
+				types.CStorClusterConfigRejectedBlockDevice{
+					Name:     device.GetName(),
+					HostName: hostName,
+					Reason:   fmt.Sprintf("Claimed elsewhere: Claim state %q", claimState),
+				},
+			)
+			continue
+		}
+		claim, err := r.buildDesiredClaim(
+			device.GetName(), device.GetNamespace(), hostName,
+		)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.desiredClaims = append(r.desiredClaims, claim)
+		r.desiredDeviceNames[device.GetName()] = true
+	}
+}
+
+// retainClaimsOfInUseDevices retains the observed claims whose
+// devices are no longer selected but are still used by the
+// observed CStorPoolCluster
+//
+// NOTE:
+//	These claims get released once their devices are removed
+// from the CStorPoolCluster
+func (r *Reconciler) retainClaimsOfInUseDevices() {
+	for _, observed := range r.ObservedBlockDeviceClaims {
+		deviceName, err := bdc.NewHelper(observed).GetBlockDeviceName()
+		if err != nil {
+			r.err = err
+			return
+		}
+		if r.desiredDeviceNames[deviceName] || !r.inUseDeviceNames[deviceName] {
+			continue
+		}
+		hostName, err := unstruct.GetStringOrError(
+			observed, "spec", "blockDeviceNodeAttributes", "hostName",
+		)
+		if err != nil {
+			r.err = err
+			return
+		}
+		claim, err := r.buildDesiredClaim(
+			deviceName, observed.GetNamespace(), hostName,
+		)
+		if err != nil {
+			r.err = err
+			return
+		}
+		r.desiredClaims = append(r.desiredClaims, claim)
+		r.desiredDeviceNames[deviceName] = true
+	}
+}
+
+// setPendingDeviceNames sets the names of the desired devices
+// whose claims are yet to be bound
+func (r *Reconciler) setPendingDeviceNames() {
+	var names []string
+	for name := range r.desiredDeviceNames {
+		names = append(names, name)
+	}
+	// sorted names keep the response deterministic
+	sort.Strings(names)
+	r.pendingDeviceNames, r.err = bdc.FilterPendingDeviceNames(
+		names, r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	This logic be idempotent. In other words, it behaves the same
+// for every reconcile action i.e. add, update, even no change in
+// state.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedCStorClusterConfig == nil {
+		return NilReconcileResponse,
+			errors.Errorf("Can't reconcile: Nil CStorClusterConfig")
+	}
+	r.init()
+	fns := []func(){
+		r.setIsDiskLocal,
+		r.splitBlockDevicesByDiskSource,
+		r.setChildMetadata,
+		r.setCStorPoolClusterName,
+		r.setHostNameToCStorPoolClusterName,
+		r.selectBlockDevices,
+		r.setInUseDeviceNames,
+		r.rejectUnhealthyBlockDevices,
+		r.reserveCapacityPerNode,
+		r.addPlannedBlockDevices,
+		r.buildDesiredClaimsOfSelectedDevices,
+		r.retainClaimsOfInUseDevices,
+		r.setPendingDeviceNames,
+	}
+	for _, fn := range fns {
+		// remaining steps are not run past the deadline of this sync
+		if r.err = errs.CheckContext(r.Context); r.err == nil {
+			end := tracing.StartPhase(r.Context, fn)
+			fn()
+			end()
+		}
+		// post operation checks
+		if r.err != nil {
+			return NilReconcileResponse, r.err
+		}
+	}
+	return ReconcileResponse{
+		BlockDeviceClaims:  r.desiredClaims,
+		PendingDeviceNames: r.pendingDeviceNames,
+		ClaimStatus: &types.CStorClusterConfigBlockDeviceClaims{
+			DesiredCount:        len(r.desiredDeviceNames),
+			ReadyCount:          len(r.desiredDeviceNames) - len(r.pendingDeviceNames),
+			PendingBlockDevices: r.pendingDeviceNames,
+			SkippedBlockDevices: r.skippedBlockDevices,
+		},
+	}, nil
+}
@@ -0,0 +1,708 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdeviceclaim
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/common"
+	"openebs.io/metac/controller/generic"
+
+	bdc "mayadata.io/cstorpoolauto/common/blockdeviceclaim"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestLocalClusterConfig() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"uid":       "ccc-1",
+			},
+			"spec": map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{
+						"blockDeviceSelector": map[string]interface{}{
+							"selectorTerms": []interface{}{
+								map[string]interface{}{
+									"matchLabels": map[string]interface{}{
+										"app": "cstor",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newTestReservedLocalClusterConfig(reservePerNode string) *unstructured.Unstructured {
+	config := newTestLocalClusterConfig()
+	_ = unstructured.SetNestedField(
+		config.Object, reservePerNode, "spec", "diskConfig", "reservePerNode",
+	)
+	_ = unstructured.SetNestedField(
+		config.Object, string(types.PoolRAIDTypeStripe), "spec", "poolConfig", "raidType",
+	)
+	return config
+}
+
+func newTestSizedDevice(name string, bytes int64) *unstructured.Unstructured {
+	device := newTestDevice(name, map[string]interface{}{"app": "cstor"})
+	_ = unstructured.SetNestedField(
+		device.Object, bytes, "spec", "capacity", "storage",
+	)
+	return device
+}
+
+func newTestExternalClusterConfig() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"uid":       "ccc-1",
+			},
+			"spec": map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"external": map[string]interface{}{
+						"csiAttacherName": "pd.csi.storage.gke.io",
+					},
+				},
+			},
+		},
+	}
+}
+
+// newTestHybridClusterConfig returns a config whose node-1 uses
+// local disks while other nodes use external disks
+func newTestHybridClusterConfig() *unstructured.Unstructured {
+	config := newTestLocalClusterConfig()
+	_ = unstructured.SetNestedMap(
+		config.Object,
+		map[string]interface{}{
+			"csiAttacherName":  "pd.csi.storage.gke.io",
+			"storageClassName": "csi-gce-pd",
+		},
+		"spec", "diskConfig", "external",
+	)
+	_ = unstructured.SetNestedSlice(
+		config.Object,
+		[]interface{}{
+			map[string]interface{}{
+				"nodeSelector": map[string]interface{}{
+					"selectorTerms": []interface{}{
+						map[string]interface{}{
+							"matchLabels": map[string]interface{}{
+								"kubernetes.io/hostname": "node-1",
+							},
+						},
+					},
+				},
+				"source": string(types.DiskSourceLocal),
+			},
+		},
+		"spec", "diskConfig", "nodeOverrides",
+	)
+	return config
+}
+
+func newTestNode(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindNode),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname": name,
+				},
+			},
+		},
+	}
+}
+
+// newTestDeviceOfHost returns an active & claimed device of the given
+// host
+func newTestDeviceOfHost(
+	name, hostName string, labels map[string]interface{},
+) *unstructured.Unstructured {
+	device := newTestDevice(name, labels)
+	_ = unstructured.SetNestedField(
+		device.Object, hostName, "metadata", "labels", "kubernetes.io/hostname",
+	)
+	_ = unstructured.SetNestedMap(
+		device.Object,
+		map[string]interface{}{
+			"state":      string(types.BlockDeviceActive),
+			"claimState": string(types.BlockDeviceClaimed),
+		},
+		"status",
+	)
+	return device
+}
+
+// newTestUnclaimedDeviceOfHost returns an active & unclaimed device
+// of the given host
+func newTestUnclaimedDeviceOfHost(
+	name, hostName string, labels map[string]interface{},
+) *unstructured.Unstructured {
+	device := newTestDeviceOfHost(name, hostName, labels)
+	_ = unstructured.SetNestedField(
+		device.Object, string(types.BlockDeviceUnclaimed), "status", "claimState",
+	)
+	return device
+}
+
+func newTestDevice(name string, labels map[string]interface{}) *unstructured.Unstructured {
+	if labels == nil {
+		labels = map[string]interface{}{}
+	}
+	labels["kubernetes.io/hostname"] = "node-1"
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+				"labels":    labels,
+			},
+		},
+	}
+}
+
+func newTestClaim(deviceName string, phase types.BlockDeviceClaimPhase) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDeviceClaim),
+			"metadata": map[string]interface{}{
+				"name":      "bdc-" + deviceName,
+				"namespace": "openebs",
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				},
+			},
+			"spec": map[string]interface{}{
+				"blockDeviceName": deviceName,
+				"blockDeviceNodeAttributes": map[string]interface{}{
+					"hostName": "node-1",
+				},
+			},
+			"status": map[string]interface{}{
+				"phase": string(phase),
+			},
+		},
+	}
+}
+
+func newTestCSPC(deviceNames ...string) *unstructured.Unstructured {
+	var devices []interface{}
+	for _, name := range deviceNames {
+		devices = append(devices, map[string]interface{}{
+			"blockDeviceName": name,
+		})
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionOpenEBSV1Alpha1,
+			"kind":       string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				},
+			},
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"nodeSelector": map[string]interface{}{
+							"kubernetes.io/hostname": "node-1",
+						},
+						"raidGroups": []interface{}{
+							map[string]interface{}{
+								"blockDevices": devices,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		reconciler    *Reconciler
+		expectClaims  []string
+		expectPending []string
+		expectSkipped []types.CStorClusterConfigRejectedBlockDevice
+		isErr         bool
+	}{
+		"nil cluster config": {
+			reconciler: &Reconciler{},
+			isErr:      true,
+		},
+		"local disk - claims for selected devices": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestLocalClusterConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDevice("bd-1", map[string]interface{}{"app": "cstor"}),
+					newTestDevice("bd-2", map[string]interface{}{"app": "cstor"}),
+					newTestDevice("bd-3", nil),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1", types.BlockDeviceClaimBound),
+				},
+			},
+			expectClaims:  []string{"bdc-bd-1", "bdc-bd-2"},
+			expectPending: []string{"bd-2"},
+		},
+		"local disk - device claimed elsewhere is skipped": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestLocalClusterConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDeviceOfHost("bd-1", "node-1", map[string]interface{}{"app": "cstor"}),
+					newTestUnclaimedDeviceOfHost("bd-2", "node-1", map[string]interface{}{"app": "cstor"}),
+				},
+			},
+			expectClaims:  []string{"bdc-bd-2"},
+			expectPending: []string{"bd-2"},
+			expectSkipped: []types.CStorClusterConfigRejectedBlockDevice{
+				{
+					Name:     "bd-1",
+					HostName: "node-1",
+					Reason:   `Claimed elsewhere: Claim state "Claimed"`,
+				},
+			},
+		},
+		"local disk - device claimed by own claim is not skipped": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestLocalClusterConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDeviceOfHost("bd-1", "node-1", map[string]interface{}{"app": "cstor"}),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1", types.BlockDeviceClaimBound),
+				},
+			},
+			expectClaims: []string{"bdc-bd-1"},
+		},
+		"local disk - claim is retained while device is in use": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestLocalClusterConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDevice("bd-1", map[string]interface{}{"app": "cstor"}),
+					newTestDevice("bd-2", nil),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1", types.BlockDeviceClaimBound),
+					newTestClaim("bd-2", types.BlockDeviceClaimBound),
+				},
+				ObservedCStorPoolCluster: newTestCSPC("bd-1", "bd-2"),
+			},
+			expectClaims: []string{"bdc-bd-1", "bdc-bd-2"},
+		},
+		"local disk - claim is released when device is not in use": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestLocalClusterConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDevice("bd-1", map[string]interface{}{"app": "cstor"}),
+					newTestDevice("bd-2", nil),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1", types.BlockDeviceClaimBound),
+					newTestClaim("bd-2", types.BlockDeviceClaimBound),
+				},
+				ObservedCStorPoolCluster: newTestCSPC("bd-1"),
+			},
+			expectClaims: []string{"bdc-bd-1"},
+		},
+		"local disk - reserved capacity is not claimed": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestReservedLocalClusterConfig("50%"),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestSizedDevice("bd-1", 1024),
+					newTestSizedDevice("bd-2", 2048),
+					newTestSizedDevice("bd-3", 1024),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1", types.BlockDeviceClaimBound),
+					newTestClaim("bd-2", types.BlockDeviceClaimBound),
+					newTestClaim("bd-3", types.BlockDeviceClaimBound),
+				},
+			},
+			expectClaims: []string{"bdc-bd-1", "bdc-bd-3"},
+		},
+		"local disk - in use device is claimed despite reserve": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestReservedLocalClusterConfig("100%"),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestSizedDevice("bd-1", 1024),
+					newTestSizedDevice("bd-2", 2048),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1", types.BlockDeviceClaimBound),
+				},
+				ObservedCStorPoolCluster: newTestCSPC("bd-1"),
+			},
+			expectClaims: []string{"bdc-bd-1"},
+		},
+		"local disk - claims for pinned devices": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: func() *unstructured.Unstructured {
+					config := newTestLocalClusterConfig()
+					_ = unstructured.SetNestedSlice(
+						config.Object,
+						[]interface{}{
+							map[string]interface{}{
+								"nodeName":         "node-1",
+								"blockDeviceNames": []interface{}{"bd-3"},
+							},
+						},
+						"spec", "diskConfig", "local", "explicitDeviceMap",
+					)
+					return config
+				}(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDevice("bd-1", map[string]interface{}{"app": "cstor"}),
+					newTestDevice("bd-3", nil),
+				},
+			},
+			expectClaims:  []string{"bdc-bd-3"},
+			expectPending: []string{"bd-3"},
+		},
+		"external disk - no plan": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestExternalClusterConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDevice("bd-1", map[string]interface{}{
+						types.AnnKeyCStorClusterPlanUID: "plan-1",
+					}),
+				},
+			},
+		},
+		"external disk - claims for planned devices": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestExternalClusterConfig(),
+				ObservedCStorClusterPlan: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": string(types.KindCStorClusterPlan),
+						"metadata": map[string]interface{}{
+							"uid": "plan-1",
+						},
+					},
+				},
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDevice("bd-1", map[string]interface{}{
+						types.AnnKeyCStorClusterPlanUID: "plan-1",
+					}),
+					newTestDevice("bd-2", map[string]interface{}{
+						types.AnnKeyCStorClusterPlanUID: "plan-2",
+					}),
+				},
+			},
+			expectClaims:  []string{"bdc-bd-1"},
+			expectPending: []string{"bd-1"},
+		},
+		"hybrid disk - claims for local & planned devices": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestHybridClusterConfig(),
+				ObservedCStorClusterPlan: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": string(types.KindCStorClusterPlan),
+						"metadata": map[string]interface{}{
+							"uid": "plan-1",
+						},
+					},
+				},
+				ObservedNodes: []*unstructured.Unstructured{
+					newTestNode("node-1"), newTestNode("node-2"),
+				},
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestDevice("bd-1", map[string]interface{}{"app": "cstor"}),
+					newTestUnclaimedDeviceOfHost("bd-2", "node-2", map[string]interface{}{
+						types.AnnKeyCStorClusterPlanUID: "plan-1",
+					}),
+					newTestDeviceOfHost("bd-3", "node-2", map[string]interface{}{
+						"app": "cstor",
+					}),
+				},
+			},
+			expectClaims:  []string{"bdc-bd-1", "bdc-bd-2"},
+			expectPending: []string{"bd-1", "bd-2"},
+		},
+		"device without hostname": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestLocalClusterConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindBlockDevice),
+							"metadata": map[string]interface{}{
+								"name":      "bd-1",
+								"namespace": "openebs",
+								"labels": map[string]interface{}{
+									"app": "cstor",
+								},
+							},
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := mock.reconciler.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotClaims []string
+			for _, claim := range got.BlockDeviceClaims {
+				gotClaims = append(gotClaims, claim.GetName())
+			}
+			sort.Strings(gotClaims)
+			if diff := cmp.Diff(mock.expectClaims, gotClaims); diff != "" {
+				t.Fatalf("Expected no diff in claims got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectPending, got.PendingDeviceNames); diff != "" {
+				t.Fatalf("Expected no diff in pending devices got\n%s", diff)
+			}
+			expectClaimStatus := &types.CStorClusterConfigBlockDeviceClaims{
+				DesiredCount:        len(mock.expectClaims),
+				ReadyCount:          len(mock.expectClaims) - len(mock.expectPending),
+				PendingBlockDevices: mock.expectPending,
+				SkippedBlockDevices: mock.expectSkipped,
+			}
+			if diff := cmp.Diff(expectClaimStatus, got.ClaimStatus); diff != "" {
+				t.Fatalf("Expected no diff in claim status got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeCStorPoolClusters(t *testing.T) {
+	var tests = map[string]struct {
+		cspcs             []*unstructured.Unstructured
+		expectName        string
+		expectDeviceNames []string
+		isErr             bool
+	}{
+		"single cspc": {
+			cspcs: []*unstructured.Unstructured{newTestCSPC("bd-1")},
+			isErr: true,
+		},
+		"pools of all cspcs": {
+			cspcs: []*unstructured.Unstructured{
+				newTestCSPC("bd-1", "bd-2"),
+				newTestCSPC("bd-3"),
+			},
+			expectName:        "my-config",
+			expectDeviceNames: []string{"bd-1", "bd-2", "bd-3"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := mergeCStorPoolClusters(mock.cspcs)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if got.GetName() != mock.expectName {
+				t.Fatalf("Expected name %q got %q", mock.expectName, got.GetName())
+			}
+			gotDeviceNames, err := bdc.GetCStorPoolClusterDeviceNames(got)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			sort.Strings(gotDeviceNames)
+			if diff := cmp.Diff(mock.expectDeviceNames, gotDeviceNames); diff != "" {
+				t.Fatalf("Expected no diff in device names got\n%s", diff)
+			}
+			pools, _, _ := unstructured.NestedSlice(mock.cspcs[0].Object, "spec", "pools")
+			if len(pools) != 1 {
+				t.Fatalf("Expected observed cspc to be unchanged got %d pools", len(pools))
+			}
+		})
+	}
+}
+
+func TestReconcilerSetHostNameToCStorPoolClusterName(t *testing.T) {
+	newTestNode := func(hostName, rack string) *unstructured.Unstructured {
+		labels := map[string]interface{}{
+			"kubernetes.io/hostname": hostName,
+		}
+		if rack != "" {
+			labels["rack"] = rack
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+				"metadata": map[string]interface{}{
+					"name":   hostName,
+					"labels": labels,
+				},
+			},
+		}
+	}
+	shardedConfig := newTestLocalClusterConfig()
+	_ = unstructured.SetNestedField(
+		shardedConfig.Object, "rack", "spec", "diskConfig", "local", "failureDomainKey",
+	)
+	observedCSPC := newTestCSPC("bd-1")
+	observedCSPC.SetName("old-rack-a")
+	observedCSPC.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID:         "ccc-1",
+		types.AnnKeyCStorPoolClusterFailureDomain: "rack-a",
+	})
+	nodes := []*unstructured.Unstructured{
+		newTestNode("node-1", "rack-a"),
+		newTestNode("node-2", "rack-b"),
+		newTestNode("node-3", ""),
+	}
+	var tests = map[string]struct {
+		reconciler *Reconciler
+		expect     map[string]string
+	}{
+		"not sharded": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newTestLocalClusterConfig(),
+				ObservedNodes:              nodes,
+			},
+		},
+		"sharded": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: shardedConfig,
+				ObservedNodes:              nodes,
+			},
+			expect: map[string]string{
+				"node-1": "my-config-rack-a",
+				"node-2": "my-config-rack-b",
+			},
+		},
+		"sharded with observed cspc": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: shardedConfig,
+				ObservedCStorPoolClusters:  []*unstructured.Unstructured{observedCSPC},
+				ObservedNodes:              nodes,
+			},
+			expect: map[string]string{
+				"node-1": "old-rack-a",
+				"node-2": "my-config-rack-b",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.init()
+			r.setHostNameToCStorPoolClusterName()
+			if r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if diff := cmp.Diff(mock.expect, r.hostNameToCStorPoolClusterName); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFinalizerFinalize(t *testing.T) {
+	var tests = map[string]struct {
+		attachments  []*unstructured.Unstructured
+		expectClaims int
+		isFinalized  bool
+	}{
+		"no attachments": {
+			isFinalized: true,
+		},
+		"cspc is present": {
+			attachments: []*unstructured.Unstructured{
+				newTestCSPC("bd-1"),
+				newTestClaim("bd-1", types.BlockDeviceClaimBound),
+			},
+			expectClaims: 1,
+			isFinalized:  false,
+		},
+		"cspc is deleted": {
+			attachments: []*unstructured.Unstructured{
+				newTestClaim("bd-1", types.BlockDeviceClaimBound),
+			},
+			expectClaims: 0,
+			isFinalized:  false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			f := &finalizer{
+				request: &generic.SyncHookRequest{
+					Watch:       newTestLocalClusterConfig(),
+					Attachments: common.MakeAnyUnstructRegistry(mock.attachments),
+				},
+				response: &generic.SyncHookResponse{},
+			}
+			err := f.finalize()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isFinalized != f.response.Finalized {
+				t.Fatalf(
+					"Expected finalized %t got %t",
+					mock.isFinalized,
+					f.response.Finalized,
+				)
+			}
+			var gotClaims int
+			for _, attachment := range f.response.Attachments {
+				if attachment.GetKind() == string(types.KindBlockDeviceClaim) {
+					gotClaims++
+				}
+			}
+			if mock.expectClaims != gotClaims {
+				t.Fatalf("Expected claims %d got %d", mock.expectClaims, gotClaims)
+			}
+		})
+	}
+}
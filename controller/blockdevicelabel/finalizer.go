@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevicelabel
+
+import (
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+)
+
+type finalizer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (f *finalizer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	f.fatal = metaccommon.ValidateGenericControllerArgs(f.request, f.response)
+}
+
+func (f *finalizer) logFinalizeStart() {
+	glog.V(3).Infof(
+		"Started BlockDeviceLabel finalize: Watch %q - %q / %q",
+		f.request.Watch.GetKind(),
+		f.request.Watch.GetNamespace(),
+		f.request.Watch.GetName(),
+	)
+}
+
+// removeLabels returns every BlockDevice labelled by the watch
+// without these labels
+//
+// NOTE:
+//	Claims, plans & CStorPoolClusters of the watch are not observed.
+// Hence, none of the BlockDevices are selected & all the labelled
+// BlockDevices are returned without labels.
+func (f *finalizer) removeLabels() {
+	var devices []*unstructured.Unstructured
+	devices, _, _, _ = register(f.request, f.response)
+	reconciler := &Reconciler{
+		ObservedClusterConfig: f.request.Watch,
+		ObservedBlockDevices:  devices,
+	}
+	f.reconcileResponse, f.err = reconciler.Reconcile()
+	if f.err != nil {
+		return
+	}
+	f.response.Attachments = append(
+		f.response.Attachments, f.reconcileResponse.DesiredBlockDevices...,
+	)
+}
+
+// markFinalized lets metac remove the finalizer of the watch
+//
+// NOTE:
+//	Metac applies the response before removing the finalizer.
+// Hence, labels are removed in the same finalize request.
+func (f *finalizer) markFinalized() {
+	f.response.Finalized = true
+}
+
+func (f *finalizer) logFinalizeFinish() {
+	glog.V(2).Infof(
+		"Completed BlockDeviceLabel finalize: Unlabelled %d: Watch %q - %q / %q: %s",
+		f.reconcileResponse.UnlabelledCount,
+		f.request.Watch.GetKind(),
+		f.request.Watch.GetNamespace(),
+		f.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(f.response),
+	)
+}
+
+// handleError logs the error if any
+func (f *finalizer) handleError() {
+	if f.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to finalize BlockDeviceLabel: Watch %q - %q / %q: %+v",
+		f.request.Watch.GetKind(),
+		f.request.Watch.GetNamespace(),
+		f.request.Watch.GetName(),
+		f.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	f.response.SkipReconcile = true
+}
+
+func (f *finalizer) finalize() error {
+	fns := []func(){
+		f.validateArgs,
+		f.logFinalizeStart,
+		f.removeLabels,
+		f.markFinalized,
+		f.logFinalizeFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if f.fatal != nil {
+			// this panics
+			return f.fatal
+		}
+		if f.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			f.handleError()
+		}
+		if f.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Finalize implements the idempotent logic to remove the labels
+// that were set against the BlockDevices of a CStorClusterConfig.
+// This gets triggered only when CStorClusterConfig is being deleted.
+//
+// NOTE:
+// 	Finalize hook automatically sets a finalizer against the watch.
+// This finalizer is removed when hookresponse's Finalized field
+// is set to true.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Finalize(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	f := &finalizer{
+		request:  request,
+		response: response,
+	}
+	return f.finalize()
+}
@@ -0,0 +1,437 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevicelabel
+
+import (
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"openebs.io/metac/controller/generic"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/pause"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// controllerName is reported against the skipped reconciliations
+// of this controller
+const controllerName = "BlockDeviceLabel"
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	blockDevices      []*unstructured.Unstructured
+	blockDeviceClaims []*unstructured.Unstructured
+	clusterPlans      []*unstructured.Unstructured
+	cstorPoolClusters []*unstructured.Unstructured
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) skipIfPaused() {
+	_, s.err = pause.Skip(
+		controllerName, s.request.Watch, s.request.Watch, s.response,
+	)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started BlockDeviceLabel sync: Watch %q - %q / %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+	)
+}
+
+func (s *syncer) registerAttachments() {
+	s.blockDevices, s.blockDeviceClaims, s.clusterPlans, s.cstorPoolClusters =
+		register(s.request, s.response)
+}
+
+func (s *syncer) reconcile() {
+	reconciler := &Reconciler{
+		ObservedClusterConfig:     s.request.Watch,
+		ObservedBlockDevices:      s.blockDevices,
+		ObservedBlockDeviceClaims: s.blockDeviceClaims,
+		ObservedClusterPlans:      s.clusterPlans,
+		ObservedCStorPoolClusters: s.cstorPoolClusters,
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+	if s.err != nil {
+		return
+	}
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredBlockDevices...,
+	)
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished BlockDeviceLabel sync: Labelled %d: Unlabelled %d: Watch %q - %q / %q: %s",
+		s.reconcileResponse.LabelledCount,
+		s.reconcileResponse.UnlabelledCount,
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync BlockDeviceLabel: Watch %q - %q / %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.skipIfPaused,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.reconcile,
+		s.logSyncFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to label the BlockDevices
+// selected for the pools of a CStorClusterConfig. These labels
+// make it possible to audit the devices of a config via
+// 'kubectl get bd -l'.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses CStorClusterConfig as the watched resource.
+// SyncHookResponse has the BlockDevices with only the labels of
+// this controller. Metac merges these labels into the BlockDevices
+// in the cluster.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// register groups the attachments of the given request that are
+// used to label the BlockDevices. Attachments other than
+// BlockDevices are added to the given response as is.
+//
+// NOTE:
+//	BlockDevices are never added as is. Only the BlockDevices that
+// need a change in labels are added after reconciliation.
+func register(
+	request *generic.SyncHookRequest, response *generic.SyncHookResponse,
+) (devices, claims, plans, cspcs []*unstructured.Unstructured) {
+	if request.Attachments == nil {
+		return
+	}
+	for _, attachment := range request.Attachments.List() {
+		uid, _ := unstruct.GetValueForKey(
+			attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+		)
+		isOwned := string(request.Watch.GetUID()) == uid
+		switch attachment.GetKind() {
+		case string(types.KindBlockDevice):
+			devices = append(devices, attachment)
+			continue
+		case string(types.KindBlockDeviceClaim):
+			if isOwned {
+				claims = append(claims, attachment)
+			}
+		case string(types.KindCStorClusterPlan):
+			if isOwned {
+				plans = append(plans, attachment)
+			}
+		case string(types.KindCStorPoolCluster):
+			if isOwned {
+				cspcs = append(cspcs, attachment)
+			}
+		}
+		response.Attachments = append(response.Attachments, attachment)
+	}
+	return
+}
+
+// Reconciler labels the BlockDevices used by the observed
+// CStorClusterConfig with this config's UID, the UID of its node's
+// plan, its node & its pool role
+//
+// NOTE:
+//	BlockDevices used in a RAID group of the config's
+// CStorPoolCluster(s) have Data role. BlockDevices claimed by the
+// config but not yet used in a CStorPoolCluster have Spare role.
+// This applies to local as well as external BlockDevices.
+type Reconciler struct {
+	ObservedClusterConfig     *unstructured.Unstructured
+	ObservedBlockDevices      []*unstructured.Unstructured
+	ObservedBlockDeviceClaims []*unstructured.Unstructured
+	ObservedClusterPlans      []*unstructured.Unstructured
+	ObservedCStorPoolClusters []*unstructured.Unstructured
+
+	// maps namespace/name of a selected BlockDevice to its role
+	deviceToRole map[string]string
+
+	// maps node name to the UID of the plan that has this node
+	nodeToPlanUID map[string]string
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	// DesiredBlockDevices have only the labels set by this controller
+	DesiredBlockDevices []*unstructured.Unstructured
+
+	// LabelledCount is the number of BlockDevices that are labelled
+	LabelledCount int
+
+	// UnlabelledCount is the number of BlockDevices whose labels
+	// are removed since these are no longer used by the config
+	UnlabelledCount int
+}
+
+func deviceKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// getRAIDGroups returns the RAID groups of the given pool of a
+// CStorPoolCluster
+//
+// NOTE:
+//	CStorPoolCluster of openebs.io/v1alpha1 version names these
+// dataRaidGroups while its later versions name these raidGroups.
+func getRAIDGroups(pool map[string]interface{}) ([]interface{}, error) {
+	for _, key := range []string{"dataRaidGroups", "raidGroups"} {
+		groups, found, err := unstructured.NestedSlice(pool, key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Can't get %s", key)
+		}
+		if found {
+			return groups, nil
+		}
+	}
+	return nil, nil
+}
+
+// setDeviceRolesFromCStorPoolClusters sets Data role against the
+// BlockDevices used in the pools of observed CStorPoolCluster(s)
+func (r *Reconciler) setDeviceRolesFromCStorPoolClusters() error {
+	for _, cspc := range r.ObservedCStorPoolClusters {
+		pools, _, err := unstructured.NestedSlice(cspc.Object, "spec", "pools")
+		if err != nil {
+			return errors.Wrapf(
+				err,
+				"Can't get pools: CStorPoolCluster %q / %q",
+				cspc.GetNamespace(), cspc.GetName(),
+			)
+		}
+		for _, pool := range pools {
+			poolMap, ok := pool.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			groups, err := getRAIDGroups(poolMap)
+			if err != nil {
+				return errors.Wrapf(
+					err,
+					"CStorPoolCluster %q / %q",
+					cspc.GetNamespace(), cspc.GetName(),
+				)
+			}
+			for _, group := range groups {
+				groupMap, ok := group.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				devices, _, _ := unstructured.NestedSlice(groupMap, "blockDevices")
+				for _, device := range devices {
+					deviceMap, ok := device.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					name, _, _ := unstructured.NestedString(deviceMap, "blockDeviceName")
+					if name == "" {
+						continue
+					}
+					r.deviceToRole[deviceKey(cspc.GetNamespace(), name)] =
+						types.PoolRoleData
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setDeviceRolesFromClaims sets Spare role against the BlockDevices
+// claimed by the observed claims that are not used in a pool
+func (r *Reconciler) setDeviceRolesFromClaims() {
+	for _, claim := range r.ObservedBlockDeviceClaims {
+		name, _, _ :=
+			unstructured.NestedString(claim.Object, "spec", "blockDeviceName")
+		if name == "" {
+			// claim is not yet bound to a device
+			continue
+		}
+		key := deviceKey(claim.GetNamespace(), name)
+		if r.deviceToRole[key] == "" {
+			r.deviceToRole[key] = types.PoolRoleSpare
+		}
+	}
+}
+
+// setPlanUIDsByNode maps the nodes of observed plans to the UID of
+// their plan
+//
+// NOTE:
+//	A node is part of exactly one plan of a config. Pools that are
+// planned per zone have a plan per zone.
+func (r *Reconciler) setPlanUIDsByNode() error {
+	for _, plan := range r.ObservedClusterPlans {
+		var planTyped types.CStorClusterPlan
+		err := unstruct.UnstructToTyped(plan, &planTyped)
+		if err != nil {
+			return err
+		}
+		for _, node := range planTyped.Spec.Nodes {
+			r.nodeToPlanUID[node.Name] = string(plan.GetUID())
+		}
+	}
+	return nil
+}
+
+// getDesiredLabels returns the labels of the given selected device
+func (r *Reconciler) getDesiredLabels(
+	device *unstructured.Unstructured, role string,
+) map[string]string {
+	labels := map[string]string{
+		types.LblKeyPoolCStorClusterConfigUID: string(r.ObservedClusterConfig.GetUID()),
+		types.LblKeyPoolRole:                  role,
+	}
+	hostName, _ := bd.NewHelper(device).GetHostName()
+	if hostName == "" {
+		// device without a node is labelled with its config & role
+		return labels
+	}
+	if len(validation.IsValidLabelValue(hostName)) == 0 {
+		labels[types.LblKeyPoolNode] = hostName
+	}
+	if planUID := r.nodeToPlanUID[hostName]; planUID != "" {
+		labels[types.LblKeyPoolCStorClusterPlanUID] = planUID
+	}
+	return labels
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	Only the labels of this controller are set against the desired
+// BlockDevices. Metac merges these into the observed labels. A
+// BlockDevice that was labelled earlier but is no longer used by
+// the config is returned without these labels. This lets metac
+// remove the labels it applied earlier.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedClusterConfig == nil {
+		return ReconcileResponse{},
+			errors.Errorf("Can't reconcile: Nil CStorClusterConfig")
+	}
+	r.deviceToRole = map[string]string{}
+	r.nodeToPlanUID = map[string]string{}
+	err := r.setDeviceRolesFromCStorPoolClusters()
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	r.setDeviceRolesFromClaims()
+	err = r.setPlanUIDsByNode()
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	configUID := string(r.ObservedClusterConfig.GetUID())
+	var response ReconcileResponse
+	for _, device := range r.ObservedBlockDevices {
+		desired := &unstructured.Unstructured{}
+		desired.SetAPIVersion(device.GetAPIVersion())
+		desired.SetKind(device.GetKind())
+		desired.SetNamespace(device.GetNamespace())
+		desired.SetName(device.GetName())
+
+		role := r.deviceToRole[deviceKey(device.GetNamespace(), device.GetName())]
+		if role != "" {
+			desired.SetLabels(r.getDesiredLabels(device, role))
+			response.LabelledCount++
+		} else if device.GetLabels()[types.LblKeyPoolCStorClusterConfigUID] == configUID {
+			// device is no longer used by this config
+			response.UnlabelledCount++
+		} else {
+			// device was never labelled by this config
+			continue
+		}
+		response.DesiredBlockDevices = append(response.DesiredBlockDevices, desired)
+	}
+	return response, nil
+}
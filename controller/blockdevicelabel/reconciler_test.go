@@ -0,0 +1,347 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package blockdevicelabel
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"openebs.io/metac/controller/common"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestConfig() *unstructured.Unstructured {
+	config := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+			"kind":       string(types.KindCStorClusterConfig),
+		},
+	}
+	config.SetName("my-config")
+	config.SetNamespace("openebs")
+	config.SetUID("config-1")
+	return config
+}
+
+func newTestBlockDevice(name, hostName string, labels map[string]string) *unstructured.Unstructured {
+	device := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindBlockDevice),
+		},
+	}
+	device.SetName(name)
+	device.SetNamespace("openebs")
+	all := map[string]string{}
+	if hostName != "" {
+		all["kubernetes.io/hostname"] = hostName
+	}
+	for key, value := range labels {
+		all[key] = value
+	}
+	device.SetLabels(all)
+	return device
+}
+
+func newTestClaim(deviceName string) *unstructured.Unstructured {
+	claim := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindBlockDeviceClaim),
+			"spec": map[string]interface{}{
+				"blockDeviceName": deviceName,
+			},
+		},
+	}
+	claim.SetName("bdc-" + deviceName)
+	claim.SetNamespace("openebs")
+	claim.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: "config-1",
+	})
+	return claim
+}
+
+func newTestPlan(uid string, nodeNames ...string) *unstructured.Unstructured {
+	var nodes []interface{}
+	for _, name := range nodeNames {
+		nodes = append(nodes, map[string]interface{}{"name": name})
+	}
+	plan := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+			"kind":       string(types.KindCStorClusterPlan),
+			"spec": map[string]interface{}{
+				"nodes": nodes,
+			},
+		},
+	}
+	plan.SetName("plan-" + uid)
+	plan.SetNamespace("openebs")
+	plan.SetUID(k8stypes.UID(uid))
+	plan.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: "config-1",
+	})
+	return plan
+}
+
+// newTestCStorPoolCluster returns a CStorPoolCluster whose single
+// pool uses the given devices in the RAID groups set against the
+// given key
+func newTestCStorPoolCluster(raidGroupsKey string, deviceNames ...string) *unstructured.Unstructured {
+	var devices []interface{}
+	for _, name := range deviceNames {
+		devices = append(devices, map[string]interface{}{"blockDeviceName": name})
+	}
+	cspc := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindCStorPoolCluster),
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"nodeSelector": map[string]interface{}{
+							"kubernetes.io/hostname": "node-1",
+						},
+						raidGroupsKey: []interface{}{
+							map[string]interface{}{
+								"blockDevices": devices,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	cspc.SetName("my-cspc")
+	cspc.SetNamespace("openebs")
+	cspc.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: "config-1",
+	})
+	return cspc
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	labelledByConfig := map[string]string{
+		types.LblKeyPoolCStorClusterConfigUID: "config-1",
+		types.LblKeyPoolRole:                  types.PoolRoleData,
+	}
+	var tests = map[string]struct {
+		reconciler      *Reconciler
+		expectLabels    map[string]map[string]string
+		expectLabelled  int
+		expectUnlabeled int
+		isErr           bool
+	}{
+		"nil config": {
+			reconciler: &Reconciler{},
+			isErr:      true,
+		},
+		"no device is selected": {
+			reconciler: &Reconciler{
+				ObservedClusterConfig: newTestConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-1", "node-1", nil),
+				},
+			},
+			expectLabels: map[string]map[string]string{},
+		},
+		"local devices of v1alpha1 cspc & claims": {
+			reconciler: &Reconciler{
+				ObservedClusterConfig: newTestConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-1", "node-1", nil),
+					newTestBlockDevice("bd-2", "node-1", nil),
+					newTestBlockDevice("bd-3", "node-1", nil),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1"),
+					newTestClaim("bd-2"),
+				},
+				ObservedClusterPlans: []*unstructured.Unstructured{
+					newTestPlan("plan-1", "node-1"),
+				},
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					newTestCStorPoolCluster("dataRaidGroups", "bd-1"),
+				},
+			},
+			expectLabels: map[string]map[string]string{
+				"bd-1": {
+					types.LblKeyPoolCStorClusterConfigUID: "config-1",
+					types.LblKeyPoolCStorClusterPlanUID:   "plan-1",
+					types.LblKeyPoolNode:                  "node-1",
+					types.LblKeyPoolRole:                  types.PoolRoleData,
+				},
+				"bd-2": {
+					types.LblKeyPoolCStorClusterConfigUID: "config-1",
+					types.LblKeyPoolCStorClusterPlanUID:   "plan-1",
+					types.LblKeyPoolNode:                  "node-1",
+					types.LblKeyPoolRole:                  types.PoolRoleSpare,
+				},
+			},
+			expectLabelled: 2,
+		},
+		"external devices of v1 cspc": {
+			reconciler: &Reconciler{
+				ObservedClusterConfig: newTestConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-1", "node-2", map[string]string{
+						types.AnnKeyCStorClusterPlanUID:       "plan-2",
+						types.AnnKeyCStorClusterStorageSetUID: "set-1",
+					}),
+				},
+				ObservedClusterPlans: []*unstructured.Unstructured{
+					newTestPlan("plan-1", "node-1"),
+					newTestPlan("plan-2", "node-2"),
+				},
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					newTestCStorPoolCluster("raidGroups", "bd-1"),
+				},
+			},
+			expectLabels: map[string]map[string]string{
+				"bd-1": {
+					types.LblKeyPoolCStorClusterConfigUID: "config-1",
+					types.LblKeyPoolCStorClusterPlanUID:   "plan-2",
+					types.LblKeyPoolNode:                  "node-2",
+					types.LblKeyPoolRole:                  types.PoolRoleData,
+				},
+			},
+			expectLabelled: 1,
+		},
+		"device without node": {
+			reconciler: &Reconciler{
+				ObservedClusterConfig: newTestConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-1", "", nil),
+				},
+				ObservedBlockDeviceClaims: []*unstructured.Unstructured{
+					newTestClaim("bd-1"),
+				},
+			},
+			expectLabels: map[string]map[string]string{
+				"bd-1": {
+					types.LblKeyPoolCStorClusterConfigUID: "config-1",
+					types.LblKeyPoolRole:                  types.PoolRoleSpare,
+				},
+			},
+			expectLabelled: 1,
+		},
+		"device no longer used by config": {
+			reconciler: &Reconciler{
+				ObservedClusterConfig: newTestConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-1", "node-1", labelledByConfig),
+				},
+			},
+			expectLabels: map[string]map[string]string{
+				"bd-1": nil,
+			},
+			expectUnlabeled: 1,
+		},
+		"device labelled by other config": {
+			reconciler: &Reconciler{
+				ObservedClusterConfig: newTestConfig(),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-1", "node-1", map[string]string{
+						types.LblKeyPoolCStorClusterConfigUID: "config-2",
+					}),
+				},
+			},
+			expectLabels: map[string]map[string]string{},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := mock.reconciler.Reconcile()
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			gotLabels := map[string]map[string]string{}
+			for _, device := range got.DesiredBlockDevices {
+				if device.GetKind() != string(types.KindBlockDevice) {
+					t.Fatalf("Expected BlockDevice got %q", device.GetKind())
+				}
+				gotLabels[device.GetName()] = device.GetLabels()
+			}
+			if diff := cmp.Diff(mock.expectLabels, gotLabels); diff != "" {
+				t.Fatalf("Expected no diff in labels got\n%s", diff)
+			}
+			if got.LabelledCount != mock.expectLabelled {
+				t.Fatalf(
+					"Expected labelled count %d got %d",
+					mock.expectLabelled, got.LabelledCount,
+				)
+			}
+			if got.UnlabelledCount != mock.expectUnlabeled {
+				t.Fatalf(
+					"Expected unlabelled count %d got %d",
+					mock.expectUnlabeled, got.UnlabelledCount,
+				)
+			}
+		})
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	labelled := newTestBlockDevice("bd-1", "node-1", map[string]string{
+		types.LblKeyPoolCStorClusterConfigUID: "config-1",
+		types.LblKeyPoolRole:                  types.PoolRoleData,
+	})
+	attachments := common.AnyUnstructRegistry{}
+	attachments.Insert(labelled)
+	attachments.Insert(newTestBlockDevice("bd-2", "node-1", nil))
+	attachments.Insert(newTestCStorPoolCluster("dataRaidGroups", "bd-1"))
+
+	response := &generic.SyncHookResponse{}
+	err := Finalize(
+		&generic.SyncHookRequest{
+			Watch:       newTestConfig(),
+			Attachments: attachments,
+		},
+		response,
+	)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if !response.Finalized {
+		t.Fatalf("Expected finalized got none")
+	}
+	var gotDevices []string
+	for _, attachment := range response.Attachments {
+		if attachment.GetKind() != string(types.KindBlockDevice) {
+			continue
+		}
+		if len(attachment.GetLabels()) != 0 {
+			t.Fatalf("Expected no labels got %v", attachment.GetLabels())
+		}
+		gotDevices = append(gotDevices, attachment.GetName())
+	}
+	if diff := cmp.Diff([]string{"bd-1"}, gotDevices); diff != "" {
+		t.Fatalf("Expected no diff in devices got\n%s", diff)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controller lists the controllers of this project. Both
+// cmd/main.go & the integration tests register the hooks of these
+// controllers from this list & hence run the same hooks.
+package controller
+
+import (
+	"time"
+
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/controller/blockdevice"
+	"mayadata.io/cstorpoolauto/controller/blockdeviceclaim"
+	"mayadata.io/cstorpoolauto/controller/blockdevicelabel"
+	"mayadata.io/cstorpoolauto/controller/cstorclusterconfig"
+	"mayadata.io/cstorpoolauto/controller/cstorclusterplan"
+	"mayadata.io/cstorpoolauto/controller/cstorclusterstorageset"
+	"mayadata.io/cstorpoolauto/controller/cstorpoolcluster"
+	"mayadata.io/cstorpoolauto/controller/localdevice"
+	localdevicev1alpha1 "mayadata.io/cstorpoolauto/controller/localdevice/v1alpha1"
+	"mayadata.io/cstorpoolauto/controller/placementhint"
+	"mayadata.io/cstorpoolauto/controller/poolautoscaler"
+	"mayadata.io/cstorpoolauto/controller/pooldecommission"
+	"mayadata.io/cstorpoolauto/controller/selectordryrun"
+	"mayadata.io/cstorpoolauto/controller/storageclass"
+	"mayadata.io/cstorpoolauto/controller/supportbundle"
+	"mayadata.io/cstorpoolauto/start"
+)
+
+// All is the list of all the controllers of this project along
+// with their inline hooks
+//
+// NOTE:
+//	Resync intervals follow how often the watched resources change.
+// Block devices change far more often than CStorClusterConfig(s).
+// Controllers without a resync interval set their own.
+var All = []start.Controller{
+	{
+		Name: "cstorclusterconfig",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/cstorclusterconfig": cstorclusterconfig.Sync,
+		},
+		ResyncAfter: 10 * time.Minute,
+	},
+	{
+		Name: "cstorclusterplan",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/cstorclusterplan": cstorclusterplan.Sync,
+		},
+		ResyncAfter: 5 * time.Minute,
+	},
+	{
+		Name: "cstorclusterstorageset",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/cstorclusterstorageset":     cstorclusterstorageset.Sync,
+			"finalize/cstorclusterstorageset": cstorclusterstorageset.Finalize,
+		},
+		ResyncAfter: 2 * time.Minute,
+	},
+	{
+		Name: "blockdevice",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/blockdevice": blockdevice.Sync,
+		},
+		ResyncAfter: 30 * time.Second,
+	},
+	{
+		Name: "blockdeviceclaim",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/blockdeviceclaim":     blockdeviceclaim.Sync,
+			"finalize/blockdeviceclaim": blockdeviceclaim.Finalize,
+		},
+		ResyncAfter: time.Minute,
+	},
+	{
+		Name: "blockdevicelabel",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/blockdevicelabel":     blockdevicelabel.Sync,
+			"finalize/blockdevicelabel": blockdevicelabel.Finalize,
+		},
+		ResyncAfter: 30 * time.Second,
+	},
+	{
+		Name: "cstorpoolcluster",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/cstorpoolcluster": cstorpoolcluster.Sync,
+		},
+		ResyncAfter: 2 * time.Minute,
+	},
+	{
+		Name: "localdevicev1alpha1",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/localdevicev1alpha1":     localdevicev1alpha1.Sync,
+			"finalize/localdevicev1alpha1": localdevicev1alpha1.Finalize,
+		},
+		ResyncAfter: time.Minute,
+	},
+	{
+		Name: "localdevice",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/localdevice":     localdevice.Sync,
+			"finalize/localdevice": localdevice.Finalize,
+		},
+		ResyncAfter: time.Minute,
+	},
+	{
+		Name: "pooldecommission",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/pooldecommission": pooldecommission.Sync,
+		},
+	},
+	{
+		Name: "poolautoscaler",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/poolautoscaler": poolautoscaler.Sync,
+		},
+	},
+	{
+		Name: "placementhint",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/placementhint": placementhint.Sync,
+		},
+	},
+	{
+		Name: "selectordryrun",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/selectordryrun": selectordryrun.Sync,
+		},
+	},
+	{
+		Name: "storageclass",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/storageclass": storageclass.Sync,
+		},
+		ResyncAfter: 10 * time.Minute,
+	},
+	{
+		Name: "supportbundle",
+		Hooks: map[string]generic.InlineInvokeFn{
+			"sync/supportbundle": supportbundle.Sync,
+		},
+	},
+}
+
+// Names returns the names of all the controllers
+func Names() []string {
+	var names []string
+	for _, ctl := range All {
+		names = append(names, ctl.Name)
+	}
+	return names
+}
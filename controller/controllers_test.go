@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"io/ioutil"
+	"regexp"
+	"testing"
+)
+
+var funcNameRegex = regexp.MustCompile(`funcName:\s*(\S+)`)
+
+func TestAllHasHooksOfMetacConfigs(t *testing.T) {
+	hooks := map[string]bool{}
+	for _, ctl := range All {
+		for funcName := range ctl.Hooks {
+			hooks[funcName] = true
+		}
+	}
+	for _, path := range []string{
+		"../config/metac.yaml",
+		"../config/localdevice/metac.yaml",
+		"../config/localdevice/v1alpha1/metac.yaml",
+	} {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Can't read %q: %+v", path, err)
+		}
+		for _, match := range funcNameRegex.FindAllStringSubmatch(string(content), -1) {
+			if !hooks[match[1]] {
+				t.Fatalf("Expected hook %q of %q to be registered", match[1], path)
+			}
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	if len(names) != len(All) {
+		t.Fatalf("Expected %d names got %d", len(All), len(names))
+	}
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			t.Fatalf("Expected unique controller names got %q twice", name)
+		}
+		seen[name] = true
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// getAutoscaledPoolCount returns the pool count set by the pool
+// autoscaler. This count is bounded by min & max pool counts. It
+// returns false if autoscale is not enabled or if the pool count
+// is yet to be set.
+//
+// NOTE:
+//	Autoscale is not applicable if pools are planned per zone
+func (r *Reconciler) getAutoscaledPoolCount() (int64, bool, error) {
+	if r.ClusterConfig == nil ||
+		r.ClusterConfig.Spec.Autoscale == nil ||
+		r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC {
+		return 0, false, nil
+	}
+	val, _ := unstruct.GetValueForKey(
+		r.ClusterConfig.GetAnnotations(),
+		types.AnnKeyCStorClusterConfigAutoscalePoolCount,
+	)
+	if val == "" {
+		return 0, false, nil
+	}
+	poolCount, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, false, errs.AsValidationError(errors.Wrapf(
+			err,
+			"Invalid annotation %q",
+			types.AnnKeyCStorClusterConfigAutoscalePoolCount,
+		))
+	}
+	if poolCount < r.minPoolCount {
+		poolCount = r.minPoolCount
+	}
+	if poolCount > r.maxPoolCount {
+		poolCount = r.maxPoolCount
+	}
+	return poolCount, true, nil
+}
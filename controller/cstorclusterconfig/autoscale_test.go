@@ -0,0 +1,118 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	autotypes "mayadata.io/cstorpoolauto/types"
+)
+
+func TestReconcilerGetAutoscaledPoolCount(t *testing.T) {
+	var tests = map[string]struct {
+		autoscale        *autotypes.Autoscale
+		isPerZone        bool
+		annotations      map[string]string
+		expectCount      int64
+		expectAutoscaled bool
+		isErr            bool
+	}{
+		"autoscale not enabled": {
+			annotations: map[string]string{
+				autotypes.AnnKeyCStorClusterConfigAutoscalePoolCount: "4",
+			},
+		},
+		"autoscale enabled && pool count not set": {
+			autoscale: &autotypes.Autoscale{ScaleUpThreshold: 80},
+		},
+		"autoscale enabled && pool count set": {
+			autoscale: &autotypes.Autoscale{ScaleUpThreshold: 80},
+			annotations: map[string]string{
+				autotypes.AnnKeyCStorClusterConfigAutoscalePoolCount: "4",
+			},
+			expectCount:      4,
+			expectAutoscaled: true,
+		},
+		"autoscale enabled && pool count above max": {
+			autoscale: &autotypes.Autoscale{ScaleUpThreshold: 80},
+			annotations: map[string]string{
+				autotypes.AnnKeyCStorClusterConfigAutoscalePoolCount: "10",
+			},
+			expectCount:      5,
+			expectAutoscaled: true,
+		},
+		"autoscale enabled && pool count below min": {
+			autoscale: &autotypes.Autoscale{ScaleUpThreshold: 80},
+			annotations: map[string]string{
+				autotypes.AnnKeyCStorClusterConfigAutoscalePoolCount: "1",
+			},
+			expectCount:      3,
+			expectAutoscaled: true,
+		},
+		"autoscale enabled && invalid pool count": {
+			autoscale: &autotypes.Autoscale{ScaleUpThreshold: 80},
+			annotations: map[string]string{
+				autotypes.AnnKeyCStorClusterConfigAutoscalePoolCount: "four",
+			},
+			isErr: true,
+		},
+		"autoscale enabled && per zone": {
+			autoscale: &autotypes.Autoscale{ScaleUpThreshold: 80},
+			isPerZone: true,
+			annotations: map[string]string{
+				autotypes.AnnKeyCStorClusterConfigAutoscalePoolCount: "4",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &autotypes.CStorClusterConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        "my-config",
+						Annotations: mock.annotations,
+					},
+					Spec: autotypes.CStorClusterConfigSpec{
+						Autoscale: mock.autoscale,
+						PoolConfig: autotypes.PoolConfig{
+							PerZoneCSPC: mock.isPerZone,
+						},
+					},
+				},
+				minPoolCount: 3,
+				maxPoolCount: 5,
+			}
+			gotCount, gotAutoscaled, err := r.getAutoscaledPoolCount()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if gotCount != mock.expectCount {
+				t.Fatalf("Expected count %d got %d", mock.expectCount, gotCount)
+			}
+			if gotAutoscaled != mock.expectAutoscaled {
+				t.Fatalf("Expected autoscaled %t got %t", mock.expectAutoscaled, gotAutoscaled)
+			}
+		})
+	}
+}
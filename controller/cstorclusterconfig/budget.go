@@ -0,0 +1,214 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/state"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// ChangeBudgetStateComponent is the name of the component whose
+// state holds the changes made against the change budget
+const ChangeBudgetStateComponent string = "changebudget"
+
+const (
+	// StateKeyPoolsAddedAt is the key of the state that holds the
+	// times at which pools were added in the last hour
+	StateKeyPoolsAddedAt string = "poolsAddedAt"
+
+	// StateKeyPoolsRemovedAt is the key of the state that holds the
+	// times at which pools were removed in the last hour
+	StateKeyPoolsRemovedAt string = "poolsRemovedAt"
+)
+
+// ChangeBudgetPeriod is the period over which the change budget is
+// evaluated
+const ChangeBudgetPeriod = time.Hour
+
+// ChangeBudgetPlanner defers the addition & removal of nodes that
+// are beyond the change budget of CStorClusterConfig
+//
+// NOTE:
+//	Changes are recorded in the given state & hence the budget is
+// shared by all the CStorClusterPlan(s) of this config
+type ChangeBudgetPlanner struct {
+	Budget *types.ChangeBudget
+	State  *state.Store
+	Now    time.Time
+
+	// nodes that were planned during previous reconciliations
+	ObservedNodes []types.CStorClusterPlanNode
+
+	// nodes that are planned in the current reconciliation
+	DesiredNodes []types.CStorClusterPlanNode
+
+	// AllNodes is used to find the planned nodes that no longer
+	// exist
+	AllNodes []*unstructured.Unstructured
+}
+
+// ChangeBudgetResult is the result of planning the nodes within the
+// change budget
+type ChangeBudgetResult struct {
+	// nodes that are planned within the change budget
+	Nodes []types.CStorClusterPlanNode
+
+	// nodes whose addition & removal were deferred
+	DeferredAdds    []types.CStorClusterPlanNode
+	DeferredRemoves []types.CStorClusterPlanNode
+
+	// ResyncAfter is the duration after which the budget is
+	// available again; zero if nothing was deferred
+	ResyncAfter time.Duration
+}
+
+// Plan returns the desired nodes limited by the change budget
+//
+// NOTE:
+//	Initial plan, replacement of re-created nodes & removal of
+// nodes that no longer exist are not limited
+func (p *ChangeBudgetPlanner) Plan() (ChangeBudgetResult, error) {
+	result := ChangeBudgetResult{Nodes: p.DesiredNodes}
+	if p.Budget == nil || p.State == nil || len(p.ObservedNodes) == 0 {
+		return result, nil
+	}
+	addedAt, err := p.getRecentChanges(StateKeyPoolsAddedAt)
+	if err != nil {
+		return ChangeBudgetResult{}, err
+	}
+	removedAt, err := p.getRecentChanges(StateKeyPoolsRemovedAt)
+	if err != nil {
+		return ChangeBudgetResult{}, err
+	}
+	result.Nodes = nil
+	observedList := types.CStorClusterPlanNodeList(p.ObservedNodes)
+	for _, desired := range p.DesiredNodes {
+		if observedList.Contains(desired.Name, desired.UID) ||
+			isReplaced(desired.Name, p.ObservedNodes) {
+			result.Nodes = append(result.Nodes, desired)
+			continue
+		}
+		if !isWithinBudget(p.Budget.MaxAddPerHour, addedAt) {
+			result.DeferredAdds = append(result.DeferredAdds, desired)
+			continue
+		}
+		addedAt = append(addedAt, p.Now)
+		result.Nodes = append(result.Nodes, desired)
+	}
+	desiredList := types.CStorClusterPlanNodeList(p.DesiredNodes)
+	for _, observed := range p.ObservedNodes {
+		if desiredList.Contains(observed.Name, observed.UID) ||
+			isReplaced(observed.Name, p.DesiredNodes) ||
+			!NodeList(p.AllNodes).Contains(observed.Name, observed.UID) {
+			continue
+		}
+		if !isWithinBudget(p.Budget.MaxRemovePerHour, removedAt) {
+			// node is retained till the budget is available
+			result.DeferredRemoves = append(result.DeferredRemoves, observed)
+			result.Nodes = append(result.Nodes, observed)
+			continue
+		}
+		removedAt = append(removedAt, p.Now)
+	}
+	if len(result.DeferredAdds) != 0 {
+		result.ResyncAfter = p.getAvailableAfter(addedAt)
+	}
+	if len(result.DeferredRemoves) != 0 {
+		after := p.getAvailableAfter(removedAt)
+		if result.ResyncAfter == 0 || (after > 0 && after < result.ResyncAfter) {
+			result.ResyncAfter = after
+		}
+	}
+	err = p.setRecentChanges(StateKeyPoolsAddedAt, addedAt)
+	if err != nil {
+		return ChangeBudgetResult{}, err
+	}
+	err = p.setRecentChanges(StateKeyPoolsRemovedAt, removedAt)
+	if err != nil {
+		return ChangeBudgetResult{}, err
+	}
+	return result, nil
+}
+
+// isWithinBudget returns true if one more change can be made given
+// the changes made in the last hour
+func isWithinBudget(max *int64, changedAt []time.Time) bool {
+	return max == nil || int64(len(changedAt)) < *max
+}
+
+// getAvailableAfter returns the duration after which the oldest of
+// the given changes falls out of the budget period
+func (p *ChangeBudgetPlanner) getAvailableAfter(changedAt []time.Time) time.Duration {
+	if len(changedAt) == 0 {
+		// budget is never available if it is set to 0
+		return 0
+	}
+	oldest := changedAt[0]
+	for _, at := range changedAt[1:] {
+		if at.Before(oldest) {
+			oldest = at
+		}
+	}
+	after := oldest.Add(ChangeBudgetPeriod).Sub(p.Now)
+	if after < time.Second {
+		after = time.Second
+	}
+	return after
+}
+
+// getRecentChanges returns the times of the changes recorded against
+// the given key that were made in the last hour
+func (p *ChangeBudgetPlanner) getRecentChanges(key string) ([]time.Time, error) {
+	var raw []string
+	_, err := p.State.GetJSON(key, &raw)
+	if err != nil {
+		return nil, err
+	}
+	var recent []time.Time
+	for _, val := range raw {
+		at, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return nil, errs.AsValidationError(errors.Wrapf(
+				err, "Invalid state %q: Component %q", key, ChangeBudgetStateComponent,
+			))
+		}
+		if p.Now.Sub(at) < ChangeBudgetPeriod {
+			recent = append(recent, at)
+		}
+	}
+	return recent, nil
+}
+
+// setRecentChanges records the times of the given changes against
+// the given key
+func (p *ChangeBudgetPlanner) setRecentChanges(key string, changedAt []time.Time) error {
+	if len(changedAt) == 0 {
+		p.State.Delete(key)
+		return nil
+	}
+	var raw []string
+	for _, at := range changedAt {
+		raw = append(raw, at.UTC().Format(time.RFC3339))
+	}
+	return p.State.SetJSON(key, raw)
+}
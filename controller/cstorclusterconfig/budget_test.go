@@ -0,0 +1,218 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/state"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	autotypes "mayadata.io/cstorpoolauto/types"
+)
+
+func TestChangeBudgetPlannerPlan(t *testing.T) {
+	now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	n1 := autotypes.CStorClusterPlanNode{Name: "node-1", UID: "n1"}
+	n2 := autotypes.CStorClusterPlanNode{Name: "node-2", UID: "n2"}
+	n3 := autotypes.CStorClusterPlanNode{Name: "node-3", UID: "n3"}
+	n4 := autotypes.CStorClusterPlanNode{Name: "node-4", UID: "n4"}
+	n1New := autotypes.CStorClusterPlanNode{Name: "node-1", UID: "n1-new"}
+	allNodes := []*unstructured.Unstructured{
+		newRevisionTestNode("node-1", "n1", nil),
+		newRevisionTestNode("node-2", "n2", nil),
+		newRevisionTestNode("node-3", "n3", nil),
+		newRevisionTestNode("node-4", "n4", nil),
+	}
+	one, zero := int64(1), int64(0)
+	var tests = map[string]struct {
+		budget          *autotypes.ChangeBudget
+		state           map[string]interface{}
+		observed        []autotypes.CStorClusterPlanNode
+		desired         []autotypes.CStorClusterPlanNode
+		expectNodes     []autotypes.CStorClusterPlanNode
+		expectDeferAdd  []autotypes.CStorClusterPlanNode
+		expectDeferRem  []autotypes.CStorClusterPlanNode
+		expectResync    time.Duration
+		expectAddedAt   string
+		expectRemovedAt string
+		isErr           bool
+	}{
+		"no budget": {
+			observed:    []autotypes.CStorClusterPlanNode{n1},
+			desired:     []autotypes.CStorClusterPlanNode{n1, n2, n3},
+			expectNodes: []autotypes.CStorClusterPlanNode{n1, n2, n3},
+		},
+		"initial plan is not limited": {
+			budget:      &autotypes.ChangeBudget{MaxAddPerHour: &one},
+			desired:     []autotypes.CStorClusterPlanNode{n1, n2, n3},
+			expectNodes: []autotypes.CStorClusterPlanNode{n1, n2, n3},
+		},
+		"adds within budget": {
+			budget:        &autotypes.ChangeBudget{MaxAddPerHour: &one},
+			observed:      []autotypes.CStorClusterPlanNode{n1},
+			desired:       []autotypes.CStorClusterPlanNode{n1, n2},
+			expectNodes:   []autotypes.CStorClusterPlanNode{n1, n2},
+			expectAddedAt: `["2020-05-01T10:00:00Z"]`,
+		},
+		"adds beyond budget are deferred": {
+			budget:         &autotypes.ChangeBudget{MaxAddPerHour: &one},
+			observed:       []autotypes.CStorClusterPlanNode{n1},
+			desired:        []autotypes.CStorClusterPlanNode{n1, n2, n3},
+			expectNodes:    []autotypes.CStorClusterPlanNode{n1, n2},
+			expectDeferAdd: []autotypes.CStorClusterPlanNode{n3},
+			expectResync:   time.Hour,
+			expectAddedAt:  `["2020-05-01T10:00:00Z"]`,
+		},
+		"adds made in the last hour use up the budget": {
+			budget: &autotypes.ChangeBudget{MaxAddPerHour: &one},
+			state: map[string]interface{}{
+				StateKeyPoolsAddedAt: `["2020-05-01T09:20:00Z"]`,
+			},
+			observed:       []autotypes.CStorClusterPlanNode{n1},
+			desired:        []autotypes.CStorClusterPlanNode{n1, n2},
+			expectNodes:    []autotypes.CStorClusterPlanNode{n1},
+			expectDeferAdd: []autotypes.CStorClusterPlanNode{n2},
+			expectResync:   20 * time.Minute,
+			expectAddedAt:  `["2020-05-01T09:20:00Z"]`,
+		},
+		"adds older than an hour are pruned": {
+			budget: &autotypes.ChangeBudget{MaxAddPerHour: &one},
+			state: map[string]interface{}{
+				StateKeyPoolsAddedAt: `["2020-05-01T08:59:00Z"]`,
+			},
+			observed:      []autotypes.CStorClusterPlanNode{n1},
+			desired:       []autotypes.CStorClusterPlanNode{n1, n2},
+			expectNodes:   []autotypes.CStorClusterPlanNode{n1, n2},
+			expectAddedAt: `["2020-05-01T10:00:00Z"]`,
+		},
+		"removes beyond budget are deferred": {
+			budget:          &autotypes.ChangeBudget{MaxRemovePerHour: &one},
+			observed:        []autotypes.CStorClusterPlanNode{n1, n2, n3},
+			desired:         []autotypes.CStorClusterPlanNode{n1},
+			expectNodes:     []autotypes.CStorClusterPlanNode{n1, n3},
+			expectDeferRem:  []autotypes.CStorClusterPlanNode{n3},
+			expectResync:    time.Hour,
+			expectRemovedAt: `["2020-05-01T10:00:00Z"]`,
+		},
+		"removes of nodes that no longer exist are not limited": {
+			budget:      &autotypes.ChangeBudget{MaxRemovePerHour: &zero},
+			observed:    []autotypes.CStorClusterPlanNode{n1, {Name: "node-5", UID: "n5"}},
+			desired:     []autotypes.CStorClusterPlanNode{n1},
+			expectNodes: []autotypes.CStorClusterPlanNode{n1},
+		},
+		"re-created nodes are not limited": {
+			budget: &autotypes.ChangeBudget{
+				MaxAddPerHour:    &zero,
+				MaxRemovePerHour: &zero,
+			},
+			observed:    []autotypes.CStorClusterPlanNode{n1, n2},
+			desired:     []autotypes.CStorClusterPlanNode{n1New, n2},
+			expectNodes: []autotypes.CStorClusterPlanNode{n1New, n2},
+		},
+		"zero budget defers without resync": {
+			budget: &autotypes.ChangeBudget{
+				MaxAddPerHour:    &zero,
+				MaxRemovePerHour: &zero,
+			},
+			observed:       []autotypes.CStorClusterPlanNode{n1, n2},
+			desired:        []autotypes.CStorClusterPlanNode{n1, n4},
+			expectNodes:    []autotypes.CStorClusterPlanNode{n1, n2},
+			expectDeferAdd: []autotypes.CStorClusterPlanNode{n4},
+			expectDeferRem: []autotypes.CStorClusterPlanNode{n2},
+		},
+		"invalid state": {
+			budget: &autotypes.ChangeBudget{MaxAddPerHour: &one},
+			state: map[string]interface{}{
+				StateKeyPoolsAddedAt: `["yesterday"]`,
+			},
+			observed: []autotypes.CStorClusterPlanNode{n1},
+			desired:  []autotypes.CStorClusterPlanNode{n1, n2},
+			isErr:    true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			config := &unstructured.Unstructured{}
+			config.SetName("my-config")
+			config.SetNamespace("openebs")
+			config.SetUID("config-1")
+			var observedStates []*unstructured.Unstructured
+			if mock.state != nil {
+				cm := &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "ConfigMap",
+						"data": mock.state,
+					},
+				}
+				cm.SetAnnotations(map[string]string{
+					autotypes.AnnKeyCStorClusterConfigUID: "config-1",
+					autotypes.AnnKeyStateComponent:        ChangeBudgetStateComponent,
+				})
+				observedStates = append(observedStates, cm)
+			}
+			store, err := state.Load(config, ChangeBudgetStateComponent, observedStates)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			p := &ChangeBudgetPlanner{
+				Budget:        mock.budget,
+				State:         store,
+				Now:           now,
+				ObservedNodes: mock.observed,
+				DesiredNodes:  mock.desired,
+				AllNodes:      allNodes,
+			}
+			got, err := p.Plan()
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expectNodes, got.Nodes); diff != "" {
+				t.Fatalf("Expected no diff in nodes got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectDeferAdd, got.DeferredAdds); diff != "" {
+				t.Fatalf("Expected no diff in deferred adds got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectDeferRem, got.DeferredRemoves); diff != "" {
+				t.Fatalf("Expected no diff in deferred removes got\n%s", diff)
+			}
+			if got.ResyncAfter != mock.expectResync {
+				t.Fatalf("Expected resync after %s got %s", mock.expectResync, got.ResyncAfter)
+			}
+			if got := store.GetString(StateKeyPoolsAddedAt); got != mock.expectAddedAt {
+				t.Fatalf("Expected added at %q got %q", mock.expectAddedAt, got)
+			}
+			if got := store.GetString(StateKeyPoolsRemovedAt); got != mock.expectRemovedAt {
+				t.Fatalf("Expected removed at %q got %q", mock.expectRemovedAt, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// ExplainPlanner explains the planning of CStorClusterPlan nodes
+// based on the observed & desired nodes
+type ExplainPlanner struct {
+	// nodes that were planned during previous reconciliations
+	ObservedNodes []types.CStorClusterPlanNode
+
+	// nodes that are planned in the current reconciliation
+	DesiredNodes []types.CStorClusterPlanNode
+
+	// number of allowed nodes that can be planned in
+	EligibleNodeCount int64
+
+	// pool counts used to plan the desired nodes
+	MinPoolCount int64
+	MaxPoolCount int64
+
+	// RevisionPlanner finds the reason behind addition & removal
+	// of nodes
+	RevisionPlanner *RevisionPlanner
+
+	// nodes whose addition & removal were deferred by the change
+	// budget
+	DeferredAdds    []types.CStorClusterPlanNode
+	DeferredRemoves []types.CStorClusterPlanNode
+}
+
+// Plan returns the explanation of the desired nodes
+//
+// NOTE:
+//	Observed nodes that are still desired are kept either because
+// these are allowed, cordoned or because these went missing recently. Reasons
+// of added & removed nodes are same as those recorded in
+// CStorClusterPlanRevision(s). Nodes whose removal was deferred by
+// the change budget are kept as well.
+func (p *ExplainPlanner) Plan() types.CStorClusterPlanExplain {
+	explain := types.CStorClusterPlanExplain{
+		EligibleNodeCount: p.EligibleNodeCount,
+		MinPoolCount:      p.MinPoolCount,
+		MaxPoolCount:      p.MaxPoolCount,
+	}
+	desiredList := types.CStorClusterPlanNodeList(p.DesiredNodes)
+	allowedList := NodeList(p.RevisionPlanner.AllowedNodes)
+	deferredRemoveList := types.CStorClusterPlanNodeList(p.DeferredRemoves)
+	for _, observed := range p.ObservedNodes {
+		explain.ObservedNodes = append(explain.ObservedNodes, observed.Name)
+		if !desiredList.Contains(observed.Name, observed.UID) {
+			continue
+		}
+		reason := types.PlanExplainReasonNodeAllowed
+		allowed := allowedList.FindByNameAndUID(observed.Name, observed.UID)
+		if deferredRemoveList.Contains(observed.Name, observed.UID) {
+			reason = types.PlanExplainReasonRemoveDeferred
+		} else if allowed == nil {
+			reason = types.PlanExplainReasonNodeMissing
+		} else if ClassifyNode(allowed) == NodeStateCordoned {
+			reason = types.PlanExplainReasonNodeCordoned
+		}
+		explain.Kept = append(explain.Kept, types.CStorClusterPlanExplainNode{
+			Name:   observed.Name,
+			Reason: reason,
+		})
+	}
+	for _, change := range p.RevisionPlanner.getChanges() {
+		node := types.CStorClusterPlanExplainNode{
+			Name:   change.Node.Name,
+			Reason: change.Reason,
+		}
+		if change.Action == types.CStorClusterPlanNodeActionAdd {
+			explain.Added = append(explain.Added, node)
+		} else {
+			explain.Removed = append(explain.Removed, node)
+		}
+	}
+	for _, deferred := range p.DeferredAdds {
+		explain.Deferred = append(explain.Deferred, types.CStorClusterPlanExplainNode{
+			Name:   deferred.Name,
+			Reason: types.PlanExplainReasonAddDeferred,
+		})
+	}
+	return explain
+}
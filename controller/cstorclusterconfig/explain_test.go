@@ -0,0 +1,216 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	autotypes "mayadata.io/cstorpoolauto/types"
+)
+
+func TestExplainPlannerPlan(t *testing.T) {
+	n1 := autotypes.CStorClusterPlanNode{Name: "node-1", UID: "n1"}
+	n2 := autotypes.CStorClusterPlanNode{Name: "node-2", UID: "n2"}
+	n3 := autotypes.CStorClusterPlanNode{Name: "node-3", UID: "n3"}
+	var tests = map[string]struct {
+		planner       *ExplainPlanner
+		expectExplain autotypes.CStorClusterPlanExplain
+	}{
+		"initial plan": {
+			planner: &ExplainPlanner{
+				DesiredNodes:      []autotypes.CStorClusterPlanNode{n1, n2},
+				EligibleNodeCount: 3,
+				MinPoolCount:      2,
+				MaxPoolCount:      3,
+				RevisionPlanner: &RevisionPlanner{
+					DesiredNodes: []autotypes.CStorClusterPlanNode{n1, n2},
+				},
+			},
+			expectExplain: autotypes.CStorClusterPlanExplain{
+				EligibleNodeCount: 3,
+				MinPoolCount:      2,
+				MaxPoolCount:      3,
+				Added: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-1", Reason: autotypes.PlanRevisionReasonInitialPlan},
+					{Name: "node-2", Reason: autotypes.PlanRevisionReasonInitialPlan},
+				},
+			},
+		},
+		"no change in nodes": {
+			planner: &ExplainPlanner{
+				ObservedNodes:     []autotypes.CStorClusterPlanNode{n1},
+				DesiredNodes:      []autotypes.CStorClusterPlanNode{n1},
+				EligibleNodeCount: 1,
+				MinPoolCount:      1,
+				MaxPoolCount:      1,
+				RevisionPlanner: &RevisionPlanner{
+					ObservedNodes: []autotypes.CStorClusterPlanNode{n1},
+					DesiredNodes:  []autotypes.CStorClusterPlanNode{n1},
+					AllowedNodes: []*unstructured.Unstructured{
+						newRevisionTestNode("node-1", "n1", nil),
+					},
+				},
+			},
+			expectExplain: autotypes.CStorClusterPlanExplain{
+				EligibleNodeCount: 1,
+				MinPoolCount:      1,
+				MaxPoolCount:      1,
+				ObservedNodes:     []string{"node-1"},
+				Kept: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-1", Reason: autotypes.PlanExplainReasonNodeAllowed},
+				},
+			},
+		},
+		"cordoned node is kept": {
+			planner: &ExplainPlanner{
+				ObservedNodes:     []autotypes.CStorClusterPlanNode{n1},
+				DesiredNodes:      []autotypes.CStorClusterPlanNode{n1},
+				EligibleNodeCount: 0,
+				MinPoolCount:      1,
+				MaxPoolCount:      1,
+				RevisionPlanner: &RevisionPlanner{
+					ObservedNodes: []autotypes.CStorClusterPlanNode{n1},
+					DesiredNodes:  []autotypes.CStorClusterPlanNode{n1},
+					AllowedNodes: []*unstructured.Unstructured{
+						func() *unstructured.Unstructured {
+							node := newRevisionTestNode("node-1", "n1", nil)
+							node.Object["spec"] = map[string]interface{}{
+								"unschedulable": true,
+							}
+							return node
+						}(),
+					},
+				},
+			},
+			expectExplain: autotypes.CStorClusterPlanExplain{
+				MinPoolCount:  1,
+				MaxPoolCount:  1,
+				ObservedNodes: []string{"node-1"},
+				Kept: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-1", Reason: autotypes.PlanExplainReasonNodeCordoned},
+				},
+			},
+		},
+		"missing node is kept & decommissioned node is replaced": {
+			planner: &ExplainPlanner{
+				ObservedNodes:     []autotypes.CStorClusterPlanNode{n1, n2},
+				DesiredNodes:      []autotypes.CStorClusterPlanNode{n2, n3},
+				EligibleNodeCount: 1,
+				MinPoolCount:      2,
+				MaxPoolCount:      2,
+				RevisionPlanner: &RevisionPlanner{
+					ObservedNodes: []autotypes.CStorClusterPlanNode{n1, n2},
+					DesiredNodes:  []autotypes.CStorClusterPlanNode{n2, n3},
+					AllNodes: []*unstructured.Unstructured{
+						newRevisionTestNode("node-1", "n1", map[string]interface{}{
+							autotypes.AnnKeyNodeDecommissionPool: "true",
+						}),
+						newRevisionTestNode("node-3", "n3", nil),
+					},
+					AllowedNodes: []*unstructured.Unstructured{
+						newRevisionTestNode("node-3", "n3", nil),
+					},
+				},
+			},
+			expectExplain: autotypes.CStorClusterPlanExplain{
+				EligibleNodeCount: 1,
+				MinPoolCount:      2,
+				MaxPoolCount:      2,
+				ObservedNodes:     []string{"node-1", "node-2"},
+				Kept: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-2", Reason: autotypes.PlanExplainReasonNodeMissing},
+				},
+				Added: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-3", Reason: autotypes.PlanRevisionReasonBelowMinPoolCount},
+				},
+				Removed: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-1", Reason: autotypes.PlanRevisionReasonPoolDecommission},
+				},
+			},
+		},
+		"changes deferred by change budget": {
+			planner: &ExplainPlanner{
+				ObservedNodes:     []autotypes.CStorClusterPlanNode{n1, n2},
+				DesiredNodes:      []autotypes.CStorClusterPlanNode{n1, n2},
+				EligibleNodeCount: 2,
+				MinPoolCount:      2,
+				MaxPoolCount:      2,
+				RevisionPlanner: &RevisionPlanner{
+					ObservedNodes: []autotypes.CStorClusterPlanNode{n1, n2},
+					DesiredNodes:  []autotypes.CStorClusterPlanNode{n1, n2},
+					AllowedNodes: []*unstructured.Unstructured{
+						newRevisionTestNode("node-1", "n1", nil),
+						newRevisionTestNode("node-3", "n3", nil),
+					},
+				},
+				DeferredAdds:    []autotypes.CStorClusterPlanNode{n3},
+				DeferredRemoves: []autotypes.CStorClusterPlanNode{n2},
+			},
+			expectExplain: autotypes.CStorClusterPlanExplain{
+				EligibleNodeCount: 2,
+				MinPoolCount:      2,
+				MaxPoolCount:      2,
+				ObservedNodes:     []string{"node-1", "node-2"},
+				Kept: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-1", Reason: autotypes.PlanExplainReasonNodeAllowed},
+					{Name: "node-2", Reason: autotypes.PlanExplainReasonRemoveDeferred},
+				},
+				Deferred: []autotypes.CStorClusterPlanExplainNode{
+					{Name: "node-3", Reason: autotypes.PlanExplainReasonAddDeferred},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.planner.Plan()
+			if diff := cmp.Diff(mock.expectExplain, got); diff != "" {
+				t.Fatalf("Explain mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcilerGetDesiredClusterPlanExplain(t *testing.T) {
+	r := &Reconciler{
+		ClusterConfig: &autotypes.CStorClusterConfig{},
+		planExplain: &autotypes.CStorClusterPlanExplain{
+			EligibleNodeCount: 1,
+			MinPoolCount:      1,
+			MaxPoolCount:      1,
+			Added: []autotypes.CStorClusterPlanExplainNode{
+				{Name: "node-1", Reason: autotypes.PlanRevisionReasonInitialPlan},
+			},
+		},
+	}
+	plan := r.getDesiredClusterPlan(nil)
+	raw := plan.GetAnnotations()[autotypes.AnnKeyCStorClusterPlanExplain]
+	var got autotypes.CStorClusterPlanExplain
+	err := json.Unmarshal([]byte(raw), &got)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if diff := cmp.Diff(*r.planExplain, got); diff != "" {
+		t.Fatalf("Explain mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"mayadata.io/cstorpoolauto/common/machinepool"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+// validateMachinePool verifies the MachineSet or MachinePool that
+// is referred to by the allowed nodes if any
+func (r *Reconciler) validateMachinePool() error {
+	ref := r.ClusterConfig.Spec.AllowedNodes.MachinePool
+	if ref == nil {
+		return nil
+	}
+	err := machinepool.Validate(*ref)
+	if err != nil {
+		return err
+	}
+	if !ref.FollowReplicas {
+		return nil
+	}
+	if r.ClusterConfig.Spec.Autoscale != nil {
+		return errs.ValidationErrorf(
+			"Invalid machine pool: followReplicas can't be set with autoscale",
+		)
+	}
+	if r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC {
+		return errs.ValidationErrorf(
+			"Invalid machine pool: followReplicas can't be set with perZoneCSPC",
+		)
+	}
+	return nil
+}
+
+// getMachinePoolReplicaCount returns the replica count of the
+// machine pool that is referred to by the allowed nodes. This count
+// is bounded by min & max pool counts. It returns false if the pool
+// count need not follow the replicas.
+//
+// NOTE:
+//	Following replicas is not applicable if pools are planned per
+// zone
+func (r *Reconciler) getMachinePoolReplicaCount() (int64, bool, error) {
+	if r.ClusterConfig == nil ||
+		r.ClusterConfig.Spec.AllowedNodes.MachinePool == nil ||
+		!r.ClusterConfig.Spec.AllowedNodes.MachinePool.FollowReplicas ||
+		r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC {
+		return 0, false, nil
+	}
+	replicas, err := machinepool.Replicas(
+		*r.ClusterConfig.Spec.AllowedNodes.MachinePool, r.Resources,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+	if replicas < r.minPoolCount {
+		replicas = r.minPoolCount
+	}
+	if replicas > r.maxPoolCount {
+		replicas = r.maxPoolCount
+	}
+	return replicas, true, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	autotypes "mayadata.io/cstorpoolauto/types"
+)
+
+func newTestMachineSet(replicas int64) *unstructured.Unstructured {
+	set := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "machine.openshift.io/v1beta1",
+			"kind":       string(autotypes.MachinePoolKindMachineSet),
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+			},
+		},
+	}
+	set.SetName("storage")
+	return set
+}
+
+func TestReconcilerValidateMachinePool(t *testing.T) {
+	var tests = map[string]struct {
+		machinePool *autotypes.MachinePoolReference
+		autoscale   *autotypes.Autoscale
+		isPerZone   bool
+		isErr       bool
+	}{
+		"no machine pool": {},
+		"valid machine pool": {
+			machinePool: &autotypes.MachinePoolReference{
+				APIVersion:     "machine.openshift.io/v1beta1",
+				Kind:           autotypes.MachinePoolKindMachineSet,
+				Name:           "storage",
+				FollowReplicas: true,
+			},
+		},
+		"unsupported kind": {
+			machinePool: &autotypes.MachinePoolReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "storage",
+			},
+			isErr: true,
+		},
+		"follow replicas with autoscale": {
+			machinePool: &autotypes.MachinePoolReference{
+				APIVersion:     "machine.openshift.io/v1beta1",
+				Kind:           autotypes.MachinePoolKindMachineSet,
+				Name:           "storage",
+				FollowReplicas: true,
+			},
+			autoscale: &autotypes.Autoscale{ScaleUpThreshold: 80},
+			isErr:     true,
+		},
+		"follow replicas per zone": {
+			machinePool: &autotypes.MachinePoolReference{
+				APIVersion:     "machine.openshift.io/v1beta1",
+				Kind:           autotypes.MachinePoolKindMachineSet,
+				Name:           "storage",
+				FollowReplicas: true,
+			},
+			isPerZone: true,
+			isErr:     true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &autotypes.CStorClusterConfig{
+					Spec: autotypes.CStorClusterConfigSpec{
+						AllowedNodes: autotypes.AllowedNodes{
+							MachinePool: mock.machinePool,
+						},
+						Autoscale: mock.autoscale,
+						PoolConfig: autotypes.PoolConfig{
+							PerZoneCSPC: mock.isPerZone,
+						},
+					},
+				},
+			}
+			err := r.validateMachinePool()
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestReconcilerGetMachinePoolReplicaCount(t *testing.T) {
+	storageSet := func(followReplicas bool) *autotypes.MachinePoolReference {
+		return &autotypes.MachinePoolReference{
+			APIVersion:     "machine.openshift.io/v1beta1",
+			Kind:           autotypes.MachinePoolKindMachineSet,
+			Name:           "storage",
+			FollowReplicas: followReplicas,
+		}
+	}
+	var tests = map[string]struct {
+		machinePool    *autotypes.MachinePoolReference
+		resources      []*unstructured.Unstructured
+		expectCount    int64
+		expectFollowed bool
+		isErr          bool
+	}{
+		"no machine pool": {
+			resources: []*unstructured.Unstructured{newTestMachineSet(4)},
+		},
+		"follow replicas not set": {
+			machinePool: storageSet(false),
+			resources:   []*unstructured.Unstructured{newTestMachineSet(4)},
+		},
+		"replicas within min & max": {
+			machinePool:    storageSet(true),
+			resources:      []*unstructured.Unstructured{newTestMachineSet(4)},
+			expectCount:    4,
+			expectFollowed: true,
+		},
+		"replicas above max": {
+			machinePool:    storageSet(true),
+			resources:      []*unstructured.Unstructured{newTestMachineSet(9)},
+			expectCount:    5,
+			expectFollowed: true,
+		},
+		"replicas below min": {
+			machinePool:    storageSet(true),
+			resources:      []*unstructured.Unstructured{newTestMachineSet(0)},
+			expectCount:    3,
+			expectFollowed: true,
+		},
+		"machine set not observed": {
+			machinePool: storageSet(true),
+			isErr:       true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &autotypes.CStorClusterConfig{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-config"},
+					Spec: autotypes.CStorClusterConfigSpec{
+						AllowedNodes: autotypes.AllowedNodes{
+							MachinePool: mock.machinePool,
+						},
+					},
+				},
+				Resources:    mock.resources,
+				minPoolCount: 3,
+				maxPoolCount: 5,
+			}
+			gotCount, gotFollowed, err := r.getMachinePoolReplicaCount()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if gotCount != mock.expectCount {
+				t.Fatalf("Expected count %d got %d", mock.expectCount, gotCount)
+			}
+			if gotFollowed != mock.expectFollowed {
+				t.Fatalf("Expected followed %t got %t", mock.expectFollowed, gotFollowed)
+			}
+		})
+	}
+}
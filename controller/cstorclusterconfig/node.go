@@ -17,17 +17,25 @@ limitations under the License.
 package cstorclusterconfig
 
 import (
+	"context"
 	"sort"
+	"strings"
+	"time"
 
+	"mayadata.io/cstorpoolauto/common/disksource"
+	"mayadata.io/cstorpoolauto/common/machinepool"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
 	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
 
+	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
-	"openebs.io/metac/controller/common/selector"
 )
 
 // ByCreationTime implements sort.Interface based on the
@@ -107,6 +115,16 @@ func (l NodeList) FindByNameAndUID(name string, uid k8stypes.UID) *unstructured.
 	return nil
 }
 
+// FindByName returns the node instance based on the given name
+func (l NodeList) FindByName(name string) *unstructured.Unstructured {
+	for _, node := range l {
+		if node.GetName() == name {
+			return node
+		}
+	}
+	return nil
+}
+
 // Contains returns true if the given node name & uid
 // is available in this list
 func (l NodeList) Contains(name string, uid k8stypes.UID) bool {
@@ -119,9 +137,12 @@ func (l NodeList) Contains(name string, uid k8stypes.UID) bool {
 // NOTE:
 //	This logic returns older nodes by removing the recently created
 // nodes. The number of nodes that are removed is based on the
-// given count
+// given count. Pinned nodes are never removed. These are the nodes
+// annotated with pin-pool set to true & the given pinned node names.
 func (l NodeList) RemoveRecentByCountFromPlannedNodes(
-	removeCount int64, given []types.CStorClusterPlanNode,
+	removeCount int64,
+	given []types.CStorClusterPlanNode,
+	pinnedNodeNames map[string]bool,
 ) ([]types.CStorClusterPlanNode, error) {
 	var plannedNodes []*unstructured.Unstructured
 	// convert the given nodes to list of unstructured instances
@@ -134,15 +155,82 @@ func (l NodeList) RemoveRecentByCountFromPlannedNodes(
 		}
 		plannedNodes = append(plannedNodes, uNode)
 	}
-	actualCount := int64(len(plannedNodes))
 	sort.Sort(ByCreationTime(plannedNodes))
 	// remove the recently created ones i.e newest nodes based on the
 	// removal count
-	var updatedList []*unstructured.Unstructured
-	newList := NodeList(append(updatedList, plannedNodes[:actualCount-removeCount]...))
+	isRemoved := map[string]bool{}
+	var removedCount int64
+	var pinned []string
+	for idx := len(plannedNodes) - 1; idx >= 0 && removedCount < removeCount; idx-- {
+		node := plannedNodes[idx]
+		if isPinned(node, pinnedNodeNames) {
+			pinned = append(pinned, node.GetName())
+			continue
+		}
+		isRemoved[node.GetName()] = true
+		removedCount++
+	}
+	if removedCount < removeCount {
+		sort.Strings(pinned)
+		return nil, errs.ValidationErrorf(
+			"Can't remove %d of %d planned nodes: Nodes %v are pinned via %q",
+			removeCount, len(plannedNodes), pinned, types.AnnKeyPinPool,
+		)
+	}
+	var newList NodeList
+	for _, node := range plannedNodes {
+		if !isRemoved[node.GetName()] {
+			newList = append(newList, node)
+		}
+	}
 	return newList.AsCStorClusterPlanNodes(), nil
 }
 
+// isPinned returns true if the pool of the given node should never
+// be removed
+func isPinned(node *unstructured.Unstructured, pinnedNodeNames map[string]bool) bool {
+	return pinnedNodeNames[node.GetName()] ||
+		node.GetAnnotations()[types.AnnKeyPinPool] == "true"
+}
+
+// getPinnedNodeNames returns the names of the nodes that are pinned
+// via the pin-pool annotation of the given CStorClusterPlan
+//
+// NOTE:
+//	Value true pins all the planned nodes similar to this annotation
+// against a Node. Any other value is a comma separated list of node
+// names.
+func getPinnedNodeNames(plan *types.CStorClusterPlan) map[string]bool {
+	if plan == nil {
+		return nil
+	}
+	value := plan.GetAnnotations()[types.AnnKeyPinPool]
+	if strings.TrimSpace(value) == "true" {
+		return getPlannedNodeNames(plan)
+	}
+	pinned := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			pinned[name] = true
+		}
+	}
+	return pinned
+}
+
+// getPlannedNodeNames returns the names of the nodes of the given
+// CStorClusterPlan
+func getPlannedNodeNames(plan *types.CStorClusterPlan) map[string]bool {
+	if plan == nil {
+		return nil
+	}
+	planned := map[string]bool{}
+	for _, node := range plan.Spec.Nodes {
+		planned[node.Name] = true
+	}
+	return planned
+}
+
 // PickByCountAndNotInPlannedNodes returns a list of nodes
 // as per the given count & are not part of the provided
 // nodes
@@ -174,7 +262,7 @@ func (l NodeList) PickByCountAndNotInPlannedNodes(
 		}
 	}
 	return nil,
-		errors.Errorf("Can't find eligible nodes: Want %d Got %d", desiredCount, fillCount)
+		errs.NotEnoughResourcesErrorf("Can't find eligible nodes: Want %d Got %d", desiredCount, fillCount)
 }
 
 // PickByCountAndIncludeAllPlannedNodes returns a list of nodes
@@ -194,7 +282,7 @@ func (l NodeList) PickByCountAndIncludeAllPlannedNodes(
 
 	includeCount = int64(len(include))
 	if count < includeCount {
-		return nil, errors.Errorf(
+		return nil, errs.ValidationErrorf(
 			"Can't pick node(s): Desired count %d must be >= include count %d",
 			count, includeCount,
 		)
@@ -227,6 +315,24 @@ func (l NodeList) AsCStorClusterPlanNodes() []types.CStorClusterPlanNode {
 	return planNodes
 }
 
+// NodeState is the state of a node as classified by the node
+// planner
+type NodeState string
+
+const (
+	// NodeStateActive is the state of a node that is present &
+	// schedulable
+	NodeStateActive NodeState = "Active"
+
+	// NodeStateCordoned is the state of a node that is present but
+	// is cordoned e.g. for maintenance
+	NodeStateCordoned NodeState = "Cordoned"
+
+	// NodeStateGone is the state of a node that is no longer
+	// present
+	NodeStateGone NodeState = "Gone"
+)
+
 // NodePlanner determines the eligible nodes fit to
 // form CStorPoolCluster based on node selector terms
 // as well as current observed state.
@@ -234,9 +340,63 @@ type NodePlanner struct {
 	NodeSelector metac.ResourceSelector
 	Resources    []*unstructured.Unstructured
 
+	// Zone when set allows only the nodes of this topology zone
+	Zone string
+
+	// MachinePool when set allows only the nodes of this MachineSet
+	// or MachinePool. Machine resources are looked up from Resources.
+	MachinePool *types.MachinePoolReference
+
+	// AllowControlPlaneNodes when true allows the control plane
+	// nodes as well. Control plane nodes that are planned already
+	// i.e. are part of PlannedNodeNames are allowed regardless.
+	AllowControlPlaneNodes bool
+
+	// PlannedNodeNames are the nodes of the observed CStorClusterPlan
+	PlannedNodeNames map[string]bool
+
+	// StabilityWindow is the duration for which a node needs to be
+	// present & ready before it is planned in. A planned node that
+	// is no longer allowed is retained for this duration. Nodes are
+	// not debounced if this is zero.
+	StabilityWindow time.Duration
+
+	// Now is the time of this planning
+	Now time.Time
+
+	// ObservedMissingSince maps the planned nodes that are no
+	// longer allowed to the RFC3339 time since when these are
+	// missing
+	ObservedMissingSince map[string]string
+
+	// PinnedNodeNames are the nodes whose pools are never removed
+	// while scaling down the pool count
+	PinnedNodeNames map[string]bool
+
+	// DiskSources is set if the disk config is hybrid. Nodes that
+	// use local disks are not allowed since their pools are built
+	// by LocalDevice controller.
+	DiskSources *disksource.Resolver
+
+	// Context is the context of the sync if any. Nodes are not
+	// evaluated past the deadline of this context.
+	Context context.Context
+
 	// nodes that match the node selector terms
 	allowedNodes []*unstructured.Unstructured
 
+	// nodes that match the node selector terms but use local disks
+	// of a hybrid disk config
+	localDiskNodes []*unstructured.Unstructured
+
+	// planned nodes that are retained though these are no longer
+	// allowed mapped to the time since when these are missing
+	missingSince map[string]string
+
+	// nodes that are not eligible for pools mapped to the reason
+	// of their rejection
+	rejectedNodes map[string]string
+
 	// functions that make it easy to mock this structure
 	planFn                func(conf NodePlannerConfig) ([]types.CStorClusterPlanNode, error)
 	getAllNodeCountFn     func() int64
@@ -266,6 +426,31 @@ func (s *NodePlanner) GetAllNodes() []*unstructured.Unstructured {
 	return nodes
 }
 
+// ClassifyNode returns the state of the given node. A nil node is
+// considered gone.
+func ClassifyNode(node *unstructured.Unstructured) NodeState {
+	if node == nil {
+		return NodeStateGone
+	}
+	if nodecommon.IsCordoned(node) {
+		return NodeStateCordoned
+	}
+	return NodeStateActive
+}
+
+// GetNodeState classifies the node with the given name & uid
+//
+// NOTE:
+//	Node is considered gone if it is not found or if it was
+// re-created with a different uid
+func (s *NodePlanner) GetNodeState(name string, uid k8stypes.UID) NodeState {
+	node := NodeList(s.allowedNodes).FindByNameAndUID(name, uid)
+	if node == nil {
+		node = NodeList(s.GetAllNodes()).FindByNameAndUID(name, uid)
+	}
+	return ClassifyNode(node)
+}
+
 // GetAllNodeCount returns the number of nodes from the list
 // of resources
 func (s *NodePlanner) GetAllNodeCount() int64 {
@@ -282,29 +467,118 @@ func (s *NodePlanner) GetAllNodeCount() int64 {
 //	This caches the resulting eligible nodes which is
 // helpful for GetEligibleNodesOrCached invocations.
 func (s *NodePlanner) GetAllowedNodes() ([]*unstructured.Unstructured, error) {
-	var allowed []*unstructured.Unstructured
-	allnodes := s.GetAllNodes()
+	var poolNodeNames map[string]bool
+	if s.MachinePool != nil {
+		var err error
+		poolNodeNames, err = machinepool.NodeNames(*s.MachinePool, s.Resources)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.rejectedNodes = map[string]string{}
+	var allnodes []*unstructured.Unstructured
+	for _, node := range s.GetAllNodes() {
+		if err := errs.CheckContext(s.Context); err != nil {
+			return nil, err
+		}
+		if nodecommon.IsPoolDecommissionRequested(node) {
+			// nodes marked for pool decommission are never allowed
+			s.reject(node, types.RejectedNodeReasonPoolDecommission)
+			continue
+		}
+		if s.Zone != "" && nodecommon.GetZone(node) != s.Zone {
+			// nodes of other zones are not allowed
+			s.reject(node, types.RejectedNodeReasonOtherZone)
+			continue
+		}
+		if poolNodeNames != nil && !poolNodeNames[node.GetName()] {
+			// nodes of other machines are not allowed
+			s.reject(node, types.RejectedNodeReasonOtherMachinePool)
+			continue
+		}
+		if !s.AllowControlPlaneNodes && nodecommon.IsControlPlane(node) &&
+			!s.PlannedNodeNames[node.GetName()] {
+			// control plane nodes are not allowed unless their pools
+			// were planned already
+			s.reject(node, types.RejectedNodeReasonControlPlane)
+			continue
+		}
+		allnodes = append(allnodes, node)
+	}
 	if len(s.NodeSelector.SelectorTerms) == 0 {
 		// all nodes are allowed since there is no preference
 		// i.e. no selector terms were specified
 		s.allowedNodes = allnodes
-		return s.allowedNodes, nil
+		return s.allowedNodes, s.excludeLocalDiskNodes()
 	}
-	for _, node := range allnodes {
-		eval := selector.Evaluation{
-			Target: node,
-			Terms:  s.NodeSelector.SelectorTerms,
-		}
-		match, err := eval.RunMatch()
-		if err != nil {
-			return nil, err
-		}
-		if match {
-			allowed = append(allowed, node)
-		}
+	// nodes are evaluated in parallel to keep the sync latency
+	// bounded in clusters with large number of nodes
+	allowed, nomatches, err := unstruct.SelectAllParallel(s.NodeSelector, allnodes)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nomatches {
+		s.reject(node, types.RejectedNodeReasonSelectorMismatch)
 	}
 	s.allowedNodes = allowed
-	return s.allowedNodes, nil
+	return s.allowedNodes, s.excludeLocalDiskNodes()
+}
+
+// reject records the given reason of rejection of the given node
+func (s *NodePlanner) reject(node *unstructured.Unstructured, reason string) {
+	if s.rejectedNodes == nil {
+		s.rejectedNodes = map[string]string{}
+	}
+	s.rejectedNodes[node.GetName()] = reason
+}
+
+// GetRejectedNodes returns the nodes that are not eligible for pools
+// along with the reason of their rejection sorted by node name
+//
+// NOTE:
+//	Nodes are rejected as they are evaluated by GetAllowedNodes &
+// GetStableAllowedNodes. Planned nodes that keep their pools though
+// these are cordoned or unstable are not rejected.
+func (s *NodePlanner) GetRejectedNodes() []types.CStorClusterConfigRejectedNode {
+	if s == nil || len(s.rejectedNodes) == 0 {
+		return nil
+	}
+	var rejected []types.CStorClusterConfigRejectedNode
+	for name, reason := range s.rejectedNodes {
+		rejected = append(rejected, types.CStorClusterConfigRejectedNode{
+			Name:   name,
+			Reason: reason,
+		})
+	}
+	sort.Slice(rejected, func(i, j int) bool {
+		return rejected[i].Name < rejected[j].Name
+	})
+	return rejected
+}
+
+// excludeLocalDiskNodes drops the allowed nodes that use local disks
+// of a hybrid disk config
+func (s *NodePlanner) excludeLocalDiskNodes() error {
+	if s.DiskSources == nil {
+		return nil
+	}
+	var err error
+	s.localDiskNodes, s.allowedNodes, err =
+		s.DiskSources.SplitNodes(s.allowedNodes)
+	for _, node := range s.localDiskNodes {
+		s.reject(node, types.RejectedNodeReasonLocalDisks)
+	}
+	return err
+}
+
+// GetLocalDiskNodeCountOrCached returns the count of nodes that match
+// the node selector terms but use local disks of a hybrid disk config
+func (s *NodePlanner) GetLocalDiskNodeCountOrCached() (int64, error) {
+	_, err := s.GetAllowedNodesOrCached()
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(s.localDiskNodes)), nil
 }
 
 // GetAllowedNodesOrCached filters the allowed nodes based on
@@ -330,6 +604,107 @@ func (s *NodePlanner) GetAllowedNodeCountOrCached() (int64, error) {
 	return int64(len(allowedNodes)), nil
 }
 
+// GetStableAllowedNodes returns the allowed nodes that were present
+// & ready for the stability window
+//
+// NOTE:
+//	Cordoned nodes are not returned since new pools should not be
+// planned on nodes that are under maintenance
+func (s *NodePlanner) GetStableAllowedNodes() ([]*unstructured.Unstructured, error) {
+	allowedNodes, err := s.GetAllowedNodesOrCached()
+	if err != nil {
+		return nil, err
+	}
+	var stable []*unstructured.Unstructured
+	for _, node := range allowedNodes {
+		// planned nodes keep their pools & are hence not rejected
+		isPlanned := s.PlannedNodeNames[node.GetName()]
+		if ClassifyNode(node) != NodeStateActive {
+			if !isPlanned {
+				s.reject(node, types.RejectedNodeReasonUnschedulable)
+			}
+			continue
+		}
+		if nodecommon.IsStable(node, s.StabilityWindow, s.Now) {
+			stable = append(stable, node)
+		} else if !isPlanned {
+			s.reject(node, types.RejectedNodeReasonUnstable)
+		}
+	}
+	return stable, nil
+}
+
+// GetMissingSince returns the planned nodes that were retained by
+// the last planning though these are no longer allowed. These are
+// mapped to the RFC3339 time since when these are missing.
+func (s *NodePlanner) GetMissingSince() map[string]string {
+	if s == nil {
+		return nil
+	}
+	return s.missingSince
+}
+
+// replaceRecreatedNodes returns the given planned nodes with the
+// uid of every re-created node set to the uid of its new instance
+//
+// NOTE:
+//	A planned node is considered re-created if its name & uid are
+// not found but an allowed node with the same name & a different uid
+// is found. Pools of the old instance continue on the new instance
+// instead of being planned out & planned in again.
+func (s *NodePlanner) replaceRecreatedNodes(
+	planned []types.CStorClusterPlanNode, allowedNodeList NodeList,
+) []types.CStorClusterPlanNode {
+	allNodeList := NodeList(s.GetAllNodes())
+	plannedList := types.CStorClusterPlanNodeList(planned)
+	var result []types.CStorClusterPlanNode
+	for _, plannedNode := range planned {
+		if allNodeList.Contains(plannedNode.Name, plannedNode.UID) {
+			result = append(result, plannedNode)
+			continue
+		}
+		recreated := allowedNodeList.FindByName(plannedNode.Name)
+		if recreated == nil ||
+			plannedList.Contains(recreated.GetName(), recreated.GetUID()) {
+			// node is gone or its new instance is planned already
+			result = append(result, plannedNode)
+			continue
+		}
+		glog.V(2).Infof(
+			"Node %s was re-created: Replacing planned uid %s with %s",
+			plannedNode.Name, plannedNode.UID, recreated.GetUID(),
+		)
+		result = append(result, types.CStorClusterPlanNode{
+			Name: plannedNode.Name,
+			UID:  recreated.GetUID(),
+		})
+	}
+	return result
+}
+
+// retainIfRecentlyMissing returns true if the given planned node
+// went missing within the stability window
+func (s *NodePlanner) retainIfRecentlyMissing(node types.CStorClusterPlanNode) bool {
+	if s.StabilityWindow <= 0 {
+		return false
+	}
+	since := s.Now
+	if val := s.ObservedMissingSince[node.Name]; val != "" {
+		observed, err := time.Parse(time.RFC3339, val)
+		if err == nil {
+			since = observed
+		}
+	}
+	if s.Now.Sub(since) >= s.StabilityWindow {
+		return false
+	}
+	if s.missingSince == nil {
+		s.missingSince = map[string]string{}
+	}
+	s.missingSince[node.Name] = since.UTC().Format(time.RFC3339)
+	return true
+}
+
 // Plan runs through node planner config to determine
 // the latest desired nodes that should form the CStorPoolCluster
 func (s *NodePlanner) Plan(conf NodePlannerConfig) ([]types.CStorClusterPlanNode, error) {
@@ -343,31 +718,58 @@ func (s *NodePlanner) Plan(conf NodePlannerConfig) ([]types.CStorClusterPlanNode
 // the latest desired nodes that should form the
 // CStorPoolCluster
 func (s *NodePlanner) plan(conf NodePlannerConfig) ([]types.CStorClusterPlanNode, error) {
+	s.missingSince = nil
 	allowedNodes, err := s.GetAllowedNodesOrCached()
 	if err != nil {
 		return nil, err
 	}
 	allowedNodeList := NodeList(allowedNodes)
+	// only the stable nodes are planned in
+	stableNodes, err := s.GetStableAllowedNodes()
+	if err != nil {
+		return nil, err
+	}
+	stableNodeList := NodeList(stableNodes)
 	if len(conf.ObservedNodes) == 0 {
 		// this is the first time desired nodes are getting evaluated
-		desired := allowedNodeList.TryPickUptoCount(conf.MinPoolCount.Value())
+		desired := stableNodeList.TryPickUptoCount(conf.MinPoolCount.Value())
 		return NodeList(desired).AsCStorClusterPlanNodes(), nil
 	}
 	// logic for observed nodes i.e. these nodes were evaluated
 	// to be fit to form cstor pool cluster during previous
 	// reconciliations
 	var includes []types.CStorClusterPlanNode
+	var retains []types.CStorClusterPlanNode
 	var includeCount int64
-	for _, observedNode := range conf.ObservedNodes {
-		if allowedNodeList.Contains(observedNode.Name, observedNode.UID) {
+	observedNodes := s.replaceRecreatedNodes(conf.ObservedNodes, allowedNodeList)
+	for _, observedNode := range observedNodes {
+		state := s.GetNodeState(observedNode.Name, observedNode.UID)
+		if state != NodeStateGone &&
+			allowedNodeList.Contains(observedNode.Name, observedNode.UID) {
 			// observed node is still eligible
 			// include this once again to make the cluster re-building
 			// less disruptive; its best to avoid cluster rebuild if its
 			// not required
+			//
+			// NOTE:
+			//	Cordoned nodes are included as well since their pools
+			// should survive the maintenance
 			includes = append(includes, observedNode)
 			includeCount++
+		} else if s.retainIfRecentlyMissing(observedNode) {
+			// observed node is no longer eligible but is retained
+			// till it stays missing for the stability window
+			retains = append(retains, observedNode)
 		}
 	}
+	if len(retains) != 0 {
+		if includeCount+int64(len(retains)) <= conf.MaxPoolCount.Value() {
+			return s.planWithRetainedNodes(conf, stableNodeList, includes, retains)
+		}
+		// retained nodes are removed rather than exceeding the
+		// max pool count
+		s.missingSince = nil
+	}
 	if includeCount >= conf.MinPoolCount.Value() &&
 		includeCount <= conf.MaxPoolCount.Value() {
 		// We have the desired nodes
@@ -386,6 +788,7 @@ func (s *NodePlanner) plan(conf NodePlannerConfig) ([]types.CStorClusterPlanNode
 		return allowedNodeList.RemoveRecentByCountFromPlannedNodes(
 			includeCount-conf.MaxPoolCount.Value(),
 			includes,
+			s.PinnedNodeNames,
 		)
 	}
 	// At this point, we need more nodes than what we have. We
@@ -396,8 +799,36 @@ func (s *NodePlanner) plan(conf NodePlannerConfig) ([]types.CStorClusterPlanNode
 	// NOTE:
 	//	This logic ensures the total desired nodes is exactly
 	// equal to the min pool count.
-	return allowedNodeList.PickByCountAndIncludeAllPlannedNodes(
+	return stableNodeList.PickByCountAndIncludeAllPlannedNodes(
 		conf.MinPoolCount.Value(),
 		includes,
 	)
 }
+
+// planWithRetainedNodes determines the desired nodes when some of
+// the observed nodes are retained though these are no longer allowed
+//
+// NOTE:
+//	Retained nodes are neither replaced nor removed since these may
+// come back. New nodes are planned in only if the eligible & retained
+// nodes are less than min pool count.
+func (s *NodePlanner) planWithRetainedNodes(
+	conf NodePlannerConfig,
+	stableNodeList NodeList,
+	includes []types.CStorClusterPlanNode,
+	retains []types.CStorClusterPlanNode,
+) ([]types.CStorClusterPlanNode, error) {
+	totalCount := int64(len(includes) + len(retains))
+	if totalCount >= conf.MinPoolCount.Value() {
+		return append(includes, retains...), nil
+	}
+	// retained nodes are excluded while picking the new nodes
+	picks, err := stableNodeList.PickByCountAndNotInPlannedNodes(
+		conf.MinPoolCount.Value()-totalCount,
+		append(append([]types.CStorClusterPlanNode{}, includes...), retains...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return append(append(includes, retains...), picks...), nil
+}
@@ -19,12 +19,16 @@ package cstorclusterconfig
 import (
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 
+	"mayadata.io/cstorpoolauto/common/disksource"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
 	autotypes "mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
@@ -1187,7 +1191,7 @@ func TestNodeListRemoveRecentByCountFromPlannedNodes(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			l := NodeList(mock.nodes)
 			got, err :=
-				l.RemoveRecentByCountFromPlannedNodes(mock.removeCount, mock.planNodes)
+				l.RemoveRecentByCountFromPlannedNodes(mock.removeCount, mock.planNodes, nil)
 			if mock.isErr && err == nil {
 				t.Fatalf("Expected error got none")
 			}
@@ -1218,6 +1222,134 @@ func TestNodeListRemoveRecentByCountFromPlannedNodes(t *testing.T) {
 	}
 }
 
+func TestNodeListRemoveRecentByCountFromPlannedNodesWithPins(t *testing.T) {
+	newNode := func(name, creationTimestamp string, isPinned bool) *unstructured.Unstructured {
+		node := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":              name,
+					"uid":               name,
+					"creationTimestamp": creationTimestamp,
+				},
+			},
+		}
+		if isPinned {
+			node.SetAnnotations(map[string]string{autotypes.AnnKeyPinPool: "true"})
+		}
+		return node
+	}
+	planNodes := []autotypes.CStorClusterPlanNode{
+		{Name: "node-1", UID: "node-1"},
+		{Name: "node-2", UID: "node-2"},
+		{Name: "node-3", UID: "node-3"},
+	}
+	var tests = map[string]struct {
+		nodes       []*unstructured.Unstructured
+		pinned      map[string]bool
+		removeCount int64
+		expect      []autotypes.CStorClusterPlanNode
+		isErr       bool
+	}{
+		"newest node is pinned via node annotation": {
+			nodes: []*unstructured.Unstructured{
+				newNode("node-1", "2006-01-01T15:04:05Z", false),
+				newNode("node-2", "2006-01-02T15:04:05Z", false),
+				newNode("node-3", "2006-01-03T15:04:05Z", true),
+			},
+			removeCount: 1,
+			expect: []autotypes.CStorClusterPlanNode{
+				{Name: "node-1", UID: "node-1"},
+				{Name: "node-3", UID: "node-3"},
+			},
+		},
+		"newest node is pinned via plan annotation": {
+			nodes: []*unstructured.Unstructured{
+				newNode("node-1", "2006-01-01T15:04:05Z", false),
+				newNode("node-2", "2006-01-02T15:04:05Z", false),
+				newNode("node-3", "2006-01-03T15:04:05Z", false),
+			},
+			pinned:      map[string]bool{"node-3": true},
+			removeCount: 2,
+			expect: []autotypes.CStorClusterPlanNode{
+				{Name: "node-3", UID: "node-3"},
+			},
+		},
+		"pinned nodes can't be removed": {
+			nodes: []*unstructured.Unstructured{
+				newNode("node-1", "2006-01-01T15:04:05Z", true),
+				newNode("node-2", "2006-01-02T15:04:05Z", false),
+				newNode("node-3", "2006-01-03T15:04:05Z", false),
+			},
+			pinned:      map[string]bool{"node-3": true},
+			removeCount: 2,
+			isErr:       true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := NodeList(mock.nodes).RemoveRecentByCountFromPlannedNodes(
+				mock.removeCount, planNodes, mock.pinned,
+			)
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetPinnedNodeNames(t *testing.T) {
+	var tests = map[string]struct {
+		annotation string
+		expect     map[string]bool
+	}{
+		"node names": {
+			annotation: "node-1, node-2,,",
+			expect:     map[string]bool{"node-1": true, "node-2": true},
+		},
+		"true pins all planned nodes": {
+			annotation: "true",
+			expect:     map[string]bool{"node-1": true, "node-3": true},
+		},
+		"no annotation": {
+			expect: map[string]bool{},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			plan := &autotypes.CStorClusterPlan{}
+			plan.Spec.Nodes = []autotypes.CStorClusterPlanNode{
+				{Name: "node-1"},
+				{Name: "node-3"},
+			}
+			if mock.annotation != "" {
+				plan.SetAnnotations(map[string]string{
+					autotypes.AnnKeyPinPool: mock.annotation,
+				})
+			}
+			got := getPinnedNodeNames(plan)
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+	if got := getPinnedNodeNames(nil); got != nil {
+		t.Fatalf("Expected no pinned nodes got %v", got)
+	}
+}
+
 func TestNodePickByCountAndNotInPlannedNodes(t *testing.T) {
 	var tests = map[string]struct {
 		allNodes     []*unstructured.Unstructured
@@ -1882,6 +2014,7 @@ func TestNodePlannerGetAllowedNodes(t *testing.T) {
 	var tests = map[string]struct {
 		resources    []*unstructured.Unstructured
 		nodeSelector metac.ResourceSelector
+		zone         string
 		expect       []*unstructured.Unstructured
 		isErr        bool
 	}{
@@ -2288,6 +2421,87 @@ func TestNodePlannerGetAllowedNodes(t *testing.T) {
 				},
 			},
 		},
+		"0 node selector && 2 nodes && 1 node marked for pool decommission": {
+			resources: []*unstructured.Unstructured{
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Node",
+						"metadata": map[string]interface{}{
+							"name": "node-101",
+						},
+					},
+				},
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Node",
+						"metadata": map[string]interface{}{
+							"name": "node-201",
+							"annotations": map[string]interface{}{
+								autotypes.AnnKeyNodeDecommissionPool: "true",
+							},
+						},
+					},
+				},
+			},
+			expect: []*unstructured.Unstructured{
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Node",
+						"metadata": map[string]interface{}{
+							"name": "node-101",
+						},
+					},
+				},
+			},
+		},
+		"0 node selector && zone && 3 nodes across 2 zones": {
+			resources: []*unstructured.Unstructured{
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Node",
+						"metadata": map[string]interface{}{
+							"name": "node-101",
+							"labels": map[string]interface{}{
+								autotypes.LblKeyZone: "zone-a",
+							},
+						},
+					},
+				},
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Node",
+						"metadata": map[string]interface{}{
+							"name": "node-201",
+							"labels": map[string]interface{}{
+								autotypes.LblKeyZone: "zone-b",
+							},
+						},
+					},
+				},
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Node",
+						"metadata": map[string]interface{}{
+							"name": "node-301",
+						},
+					},
+				},
+			},
+			zone: "zone-a",
+			expect: []*unstructured.Unstructured{
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "Node",
+						"metadata": map[string]interface{}{
+							"name": "node-101",
+							"labels": map[string]interface{}{
+								autotypes.LblKeyZone: "zone-a",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for name, mock := range tests {
 		name := name
@@ -2296,6 +2510,7 @@ func TestNodePlannerGetAllowedNodes(t *testing.T) {
 			p := &NodePlanner{
 				Resources:    mock.resources,
 				NodeSelector: mock.nodeSelector,
+				Zone:         mock.zone,
 			}
 			_, err := p.GetAllowedNodes()
 			got := p.allowedNodes
@@ -2308,6 +2523,9 @@ func TestNodePlannerGetAllowedNodes(t *testing.T) {
 			if mock.isErr {
 				return
 			}
+			if len(got) != len(mock.expect) {
+				t.Fatalf("Expected count %d got %d", len(mock.expect), len(got))
+			}
 			if !unstruct.NewListing(got).ContainsAll(mock.expect) {
 				t.Fatalf("Expected no diff got \n%s", cmp.Diff(got, mock.expect))
 			}
@@ -2315,6 +2533,180 @@ func TestNodePlannerGetAllowedNodes(t *testing.T) {
 	}
 }
 
+func TestNodePlannerGetAllowedNodesWithControlPlaneNodes(t *testing.T) {
+	newNode := func(name string, labels map[string]string, taints ...string) *unstructured.Unstructured {
+		node := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": "Node",
+			},
+		}
+		node.SetName(name)
+		node.SetLabels(labels)
+		var taintList []interface{}
+		for _, key := range taints {
+			taintList = append(taintList, map[string]interface{}{
+				"key":    key,
+				"effect": "NoSchedule",
+			})
+		}
+		if len(taintList) != 0 {
+			node.Object["spec"] = map[string]interface{}{"taints": taintList}
+		}
+		return node
+	}
+	resources := []*unstructured.Unstructured{
+		newNode("cp-1", map[string]string{autotypes.LblKeyNodeRoleControlPlane: ""}),
+		newNode("cp-2", nil, autotypes.LblKeyNodeRoleMaster),
+		newNode("worker-1", nil),
+		newNode("worker-2", map[string]string{"node-role.kubernetes.io/worker": ""}),
+	}
+	var tests = map[string]struct {
+		allowControlPlaneNodes bool
+		plannedNodeNames       map[string]bool
+		expect                 []string
+	}{
+		"control plane nodes are excluded by default": {
+			expect: []string{"worker-1", "worker-2"},
+		},
+		"control plane nodes are allowed": {
+			allowControlPlaneNodes: true,
+			expect:                 []string{"cp-1", "cp-2", "worker-1", "worker-2"},
+		},
+		"planned control plane nodes are retained": {
+			plannedNodeNames: map[string]bool{"cp-2": true, "worker-1": true},
+			expect:           []string{"cp-2", "worker-1", "worker-2"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			p := &NodePlanner{
+				Resources:              resources,
+				AllowControlPlaneNodes: mock.allowControlPlaneNodes,
+				PlannedNodeNames:       mock.plannedNodeNames,
+			}
+			got, err := p.GetAllowedNodes()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotNames []string
+			for _, node := range got {
+				gotNames = append(gotNames, node.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNodePlannerGetRejectedNodes(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	newNode := func(name string, labels, annotations map[string]string) *unstructured.Unstructured {
+		node := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(autotypes.KindNode),
+			},
+		}
+		node.SetName(name)
+		node.SetLabels(labels)
+		node.SetAnnotations(annotations)
+		node.SetCreationTimestamp(metav1.NewTime(now.Add(-time.Hour)))
+		return node
+	}
+	zoneA := map[string]string{autotypes.LblKeyZone: "zone-a"}
+	resources := []*unstructured.Unstructured{
+		newNode("node-1", zoneA, nil),
+		newNode("node-2", map[string]string{autotypes.LblKeyZone: "zone-b"}, nil),
+		newNode("node-3", zoneA, map[string]string{
+			autotypes.AnnKeyNodeDecommissionPool: "true",
+		}),
+		newNode("node-4", map[string]string{
+			autotypes.LblKeyZone:                 "zone-a",
+			autotypes.LblKeyNodeRoleControlPlane: "",
+		}, nil),
+		newNode("node-5", zoneA, nil),
+		func() *unstructured.Unstructured {
+			node := newNode("node-6", zoneA, nil)
+			node.Object["spec"] = map[string]interface{}{"unschedulable": true}
+			return node
+		}(),
+		func() *unstructured.Unstructured {
+			node := newNode("node-7", zoneA, nil)
+			node.SetCreationTimestamp(metav1.NewTime(now.Add(-time.Minute)))
+			return node
+		}(),
+	}
+	var tests = map[string]struct {
+		nodeSelector     metac.ResourceSelector
+		zone             string
+		plannedNodeNames map[string]bool
+		expect           []autotypes.CStorClusterConfigRejectedNode
+	}{
+		"nodes rejected by zone, decommission, control plane, cordon & stability": {
+			zone: "zone-a",
+			expect: []autotypes.CStorClusterConfigRejectedNode{
+				{Name: "node-2", Reason: autotypes.RejectedNodeReasonOtherZone},
+				{Name: "node-3", Reason: autotypes.RejectedNodeReasonPoolDecommission},
+				{Name: "node-4", Reason: autotypes.RejectedNodeReasonControlPlane},
+				{Name: "node-6", Reason: autotypes.RejectedNodeReasonUnschedulable},
+				{Name: "node-7", Reason: autotypes.RejectedNodeReasonUnstable},
+			},
+		},
+		"nodes rejected by selector terms": {
+			nodeSelector: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					&metac.SelectorTerm{
+						MatchFields: map[string]string{
+							"metadata.name": "node-1",
+						},
+					},
+				},
+			},
+			plannedNodeNames: map[string]bool{"node-4": true},
+			expect: []autotypes.CStorClusterConfigRejectedNode{
+				{Name: "node-2", Reason: autotypes.RejectedNodeReasonSelectorMismatch},
+				{Name: "node-3", Reason: autotypes.RejectedNodeReasonPoolDecommission},
+				{Name: "node-4", Reason: autotypes.RejectedNodeReasonSelectorMismatch},
+				{Name: "node-5", Reason: autotypes.RejectedNodeReasonSelectorMismatch},
+				{Name: "node-6", Reason: autotypes.RejectedNodeReasonSelectorMismatch},
+				{Name: "node-7", Reason: autotypes.RejectedNodeReasonSelectorMismatch},
+			},
+		},
+		"planned nodes are not rejected though cordoned or unstable": {
+			zone:             "zone-a",
+			plannedNodeNames: map[string]bool{"node-6": true, "node-7": true},
+			expect: []autotypes.CStorClusterConfigRejectedNode{
+				{Name: "node-2", Reason: autotypes.RejectedNodeReasonOtherZone},
+				{Name: "node-3", Reason: autotypes.RejectedNodeReasonPoolDecommission},
+				{Name: "node-4", Reason: autotypes.RejectedNodeReasonControlPlane},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			p := &NodePlanner{
+				Resources:        resources,
+				NodeSelector:     mock.nodeSelector,
+				Zone:             mock.zone,
+				PlannedNodeNames: mock.plannedNodeNames,
+				StabilityWindow:  5 * time.Minute,
+				Now:              now,
+			}
+			_, err := p.GetStableAllowedNodes()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, p.GetRejectedNodes()); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestNodePlannerGetAllowedNodesOrCached(t *testing.T) {
 	var tests = map[string]struct {
 		cached    []*unstructured.Unstructured
@@ -2963,3 +3355,588 @@ func TestNodePlannerPlan(t *testing.T) {
 		})
 	}
 }
+
+func TestNodePlannerPlanWithStabilityWindow(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+	newNode := func(name, created string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(autotypes.KindNode),
+				"metadata": map[string]interface{}{
+					"name":              name,
+					"uid":               name,
+					"creationTimestamp": created,
+				},
+			},
+		}
+	}
+	planNode := func(name string) autotypes.CStorClusterPlanNode {
+		return autotypes.CStorClusterPlanNode{Name: name, UID: types.UID(name)}
+	}
+	var tests = map[string]struct {
+		resources            []*unstructured.Unstructured
+		observedNodes        []autotypes.CStorClusterPlanNode
+		observedMissingSince map[string]string
+		minPoolCount         int64
+		maxPoolCount         int64
+		expectNodes          []autotypes.CStorClusterPlanNode
+		expectMissingSince   map[string]string
+		isErr                bool
+	}{
+		"recently created node is not planned in": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "2020-01-01T09:00:00Z"),
+				newNode("node-2", "2020-01-01T09:58:00Z"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"),
+			},
+		},
+		"recently missing node is retained": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "2020-01-01T09:00:00Z"),
+				newNode("node-3", "2020-01-01T09:00:00Z"),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-2"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-2"),
+			},
+			expectMissingSince: map[string]string{
+				"node-2": "2020-01-01T10:00:00Z",
+			},
+		},
+		"node missing beyond window is replaced": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "2020-01-01T09:00:00Z"),
+				newNode("node-3", "2020-01-01T09:00:00Z"),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-2"),
+			},
+			observedMissingSince: map[string]string{
+				"node-2": "2020-01-01T09:50:00Z",
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-3"),
+			},
+		},
+		"retained node does not exceed max pool count": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "2020-01-01T09:00:00Z"),
+				newNode("node-3", "2020-01-01T09:00:00Z"),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-2"), planNode("node-3"),
+			},
+			minPoolCount: 1,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-3"),
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			planner := &NodePlanner{
+				Resources:            mock.resources,
+				StabilityWindow:      5 * time.Minute,
+				Now:                  now,
+				ObservedMissingSince: mock.observedMissingSince,
+			}
+			got, err := planner.Plan(NodePlannerConfig{
+				ObservedNodes: mock.observedNodes,
+				MinPoolCount:  *resource.NewQuantity(mock.minPoolCount, resource.DecimalExponent),
+				MaxPoolCount:  *resource.NewQuantity(mock.maxPoolCount, resource.DecimalExponent),
+			})
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if diff := cmp.Diff(mock.expectNodes, got); diff != "" {
+				t.Fatalf("Nodes mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectMissingSince, planner.GetMissingSince()); diff != "" {
+				t.Fatalf("Missing since mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNodePlannerPlanWithCordonedNodes(t *testing.T) {
+	newNode := func(name string, unschedulable bool) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(autotypes.KindNode),
+				"metadata": map[string]interface{}{
+					"name":              name,
+					"uid":               name,
+					"creationTimestamp": "2020-01-01T09:00:00Z",
+				},
+				"spec": map[string]interface{}{
+					"unschedulable": unschedulable,
+				},
+			},
+		}
+	}
+	planNode := func(name string) autotypes.CStorClusterPlanNode {
+		return autotypes.CStorClusterPlanNode{Name: name, UID: types.UID(name)}
+	}
+	var tests = map[string]struct {
+		resources     []*unstructured.Unstructured
+		observedNodes []autotypes.CStorClusterPlanNode
+		minPoolCount  int64
+		maxPoolCount  int64
+		expectNodes   []autotypes.CStorClusterPlanNode
+		isErr         bool
+	}{
+		"cordoned node is not planned in initially": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", true),
+				newNode("node-2", false),
+				newNode("node-3", false),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-2"), planNode("node-3"),
+			},
+		},
+		"planned node that is cordoned is kept": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", true),
+				newNode("node-2", false),
+				newNode("node-3", false),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-2"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-2"),
+			},
+		},
+		"cordoned node is not added to reach min pool count": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", false),
+				newNode("node-2", true),
+				newNode("node-3", false),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 3,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"), planNode("node-3"),
+			},
+		},
+		"only cordoned nodes are left to add": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", false),
+				newNode("node-2", true),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			isErr:        true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			planner := &NodePlanner{
+				Resources: mock.resources,
+			}
+			got, err := planner.Plan(NodePlannerConfig{
+				ObservedNodes: mock.observedNodes,
+				MinPoolCount:  *resource.NewQuantity(mock.minPoolCount, resource.DecimalExponent),
+				MaxPoolCount:  *resource.NewQuantity(mock.maxPoolCount, resource.DecimalExponent),
+			})
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if diff := cmp.Diff(mock.expectNodes, got); diff != "" {
+				t.Fatalf("Nodes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNodePlannerPlanWithRecreatedNodes(t *testing.T) {
+	newNode := func(name, uid string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(autotypes.KindNode),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"uid":  uid,
+				},
+			},
+		}
+	}
+	planNode := func(name, uid string) autotypes.CStorClusterPlanNode {
+		return autotypes.CStorClusterPlanNode{Name: name, UID: types.UID(uid)}
+	}
+	var tests = map[string]struct {
+		resources     []*unstructured.Unstructured
+		observedNodes []autotypes.CStorClusterPlanNode
+		minPoolCount  int64
+		maxPoolCount  int64
+		expectNodes   []autotypes.CStorClusterPlanNode
+	}{
+		"re-created node replaces its old uid": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "uid-1"),
+				newNode("node-2", "uid-2-new"),
+				newNode("node-3", "uid-3"),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2-new"),
+			},
+		},
+		"re-created node that is planned already is not duplicated": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "uid-1"),
+				newNode("node-2", "uid-2-new"),
+				newNode("node-3", "uid-3"),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"),
+				planNode("node-2", "uid-2"),
+				planNode("node-2", "uid-2-new"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 3,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2-new"),
+			},
+		},
+		"gone node is not replaced by other node": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "uid-1"),
+				newNode("node-3", "uid-3"),
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2"),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 2,
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-3", "uid-3"),
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			planner := &NodePlanner{
+				Resources: mock.resources,
+			}
+			got, err := planner.Plan(NodePlannerConfig{
+				ObservedNodes: mock.observedNodes,
+				MinPoolCount:  *resource.NewQuantity(mock.minPoolCount, resource.DecimalExponent),
+				MaxPoolCount:  *resource.NewQuantity(mock.maxPoolCount, resource.DecimalExponent),
+			})
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if diff := cmp.Diff(mock.expectNodes, got); diff != "" {
+				t.Fatalf("Nodes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNodePlannerPlanWithHybridDiskConfig(t *testing.T) {
+	newNode := func(name, uid, diskType string) *unstructured.Unstructured {
+		node := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(autotypes.KindNode),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"uid":  uid,
+				},
+			},
+		}
+		if diskType != "" {
+			node.SetLabels(map[string]string{"disk-type": diskType})
+		}
+		return node
+	}
+	planNode := func(name, uid string) autotypes.CStorClusterPlanNode {
+		return autotypes.CStorClusterPlanNode{Name: name, UID: types.UID(uid)}
+	}
+	resources := []*unstructured.Unstructured{
+		newNode("node-1", "uid-1", "nvme"),
+		newNode("node-2", "uid-2", ""),
+		newNode("node-3", "uid-3", ""),
+	}
+	localNVMe := autotypes.DiskNodeOverride{
+		NodeSelector: metac.ResourceSelector{
+			SelectorTerms: []*metac.SelectorTerm{
+				&metac.SelectorTerm{
+					MatchLabels: map[string]string{
+						"disk-type": "nvme",
+					},
+				},
+			},
+		},
+		Source: autotypes.DiskSourceLocal,
+	}
+	var tests = map[string]struct {
+		diskSources      *disksource.Resolver
+		observedNodes    []autotypes.CStorClusterPlanNode
+		expectNodes      []autotypes.CStorClusterPlanNode
+		expectLocalCount int64
+	}{
+		"not hybrid": {
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2"),
+			},
+		},
+		"nodes that use local disks are not planned": {
+			diskSources: &disksource.Resolver{
+				Overrides: []autotypes.DiskNodeOverride{localNVMe},
+			},
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-2", "uid-2"), planNode("node-3", "uid-3"),
+			},
+			expectLocalCount: 1,
+		},
+		"all nodes use external disks by default": {
+			diskSources: &disksource.Resolver{},
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2"),
+			},
+		},
+		"node switched to local disks is replaced": {
+			diskSources: &disksource.Resolver{
+				Overrides: []autotypes.DiskNodeOverride{localNVMe},
+			},
+			observedNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2"),
+			},
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-2", "uid-2"), planNode("node-3", "uid-3"),
+			},
+			expectLocalCount: 1,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			planner := &NodePlanner{
+				Resources:   resources,
+				DiskSources: mock.diskSources,
+			}
+			got, err := planner.Plan(NodePlannerConfig{
+				ObservedNodes: mock.observedNodes,
+				MinPoolCount:  *resource.NewQuantity(2, resource.DecimalExponent),
+				MaxPoolCount:  *resource.NewQuantity(2, resource.DecimalExponent),
+			})
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].Name < got[j].Name })
+			if diff := cmp.Diff(mock.expectNodes, got); diff != "" {
+				t.Fatalf("Nodes mismatch (-want +got):\n%s", diff)
+			}
+			localCount, err := planner.GetLocalDiskNodeCountOrCached()
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if localCount != mock.expectLocalCount {
+				t.Fatalf(
+					"Expected local disk node count %d got %d",
+					mock.expectLocalCount, localCount,
+				)
+			}
+		})
+	}
+}
+
+func TestNodePlannerPlanWithMachinePool(t *testing.T) {
+	newNode := func(name, uid string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(autotypes.KindNode),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"uid":  uid,
+				},
+			},
+		}
+	}
+	newMachine := func(name, setName, nodeName string) *unstructured.Unstructured {
+		machine := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "machine.openshift.io/v1beta1",
+				"kind":       "Machine",
+				"status": map[string]interface{}{
+					"nodeRef": map[string]interface{}{
+						"name": nodeName,
+					},
+				},
+			},
+		}
+		machine.SetName(name)
+		machine.SetOwnerReferences([]metav1.OwnerReference{
+			{Kind: string(autotypes.MachinePoolKindMachineSet), Name: setName},
+		})
+		return machine
+	}
+	machineSet := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "machine.openshift.io/v1beta1",
+			"kind":       string(autotypes.MachinePoolKindMachineSet),
+		},
+	}
+	machineSet.SetName("storage")
+	planNode := func(name, uid string) autotypes.CStorClusterPlanNode {
+		return autotypes.CStorClusterPlanNode{Name: name, UID: types.UID(uid)}
+	}
+	storageSet := &autotypes.MachinePoolReference{
+		APIVersion: "machine.openshift.io/v1beta1",
+		Kind:       autotypes.MachinePoolKindMachineSet,
+		Name:       "storage",
+	}
+	var tests = map[string]struct {
+		machinePool *autotypes.MachinePoolReference
+		resources   []*unstructured.Unstructured
+		expectNodes []autotypes.CStorClusterPlanNode
+		isErr       bool
+	}{
+		"no machine pool": {
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "uid-1"),
+				newNode("node-2", "uid-2"),
+			},
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-2", "uid-2"),
+			},
+		},
+		"only the nodes of machine set": {
+			machinePool: storageSet,
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "uid-1"),
+				newNode("node-2", "uid-2"),
+				newNode("node-3", "uid-3"),
+				machineSet,
+				newMachine("m-1", "storage", "node-1"),
+				newMachine("m-3", "storage", "node-3"),
+				newMachine("m-2", "workers", "node-2"),
+			},
+			expectNodes: []autotypes.CStorClusterPlanNode{
+				planNode("node-1", "uid-1"), planNode("node-3", "uid-3"),
+			},
+		},
+		"machine set not observed": {
+			machinePool: storageSet,
+			resources: []*unstructured.Unstructured{
+				newNode("node-1", "uid-1"),
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			planner := &NodePlanner{
+				Resources:   mock.resources,
+				MachinePool: mock.machinePool,
+			}
+			got, err := planner.Plan(NodePlannerConfig{
+				MinPoolCount: *resource.NewQuantity(2, resource.DecimalExponent),
+				MaxPoolCount: *resource.NewQuantity(2, resource.DecimalExponent),
+			})
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].Name < got[j].Name })
+			if diff := cmp.Diff(mock.expectNodes, got); diff != "" {
+				t.Fatalf("Nodes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNodePlannerGetNodeState(t *testing.T) {
+	planner := &NodePlanner{
+		Resources: []*unstructured.Unstructured{
+			&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(autotypes.KindNode),
+					"metadata": map[string]interface{}{
+						"name": "node-1",
+						"uid":  "node-1",
+					},
+				},
+			},
+			&unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(autotypes.KindNode),
+					"metadata": map[string]interface{}{
+						"name": "node-2",
+						"uid":  "node-2",
+					},
+					"spec": map[string]interface{}{
+						"unschedulable": true,
+					},
+				},
+			},
+		},
+	}
+	var tests = map[string]struct {
+		name   string
+		uid    types.UID
+		expect NodeState
+	}{
+		"active node":     {name: "node-1", uid: "node-1", expect: NodeStateActive},
+		"cordoned node":   {name: "node-2", uid: "node-2", expect: NodeStateCordoned},
+		"deleted node":    {name: "node-3", uid: "node-3", expect: NodeStateGone},
+		"re-created node": {name: "node-1", uid: "node-1-new", expect: NodeStateGone},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := planner.GetNodeState(mock.name, mock.uid)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
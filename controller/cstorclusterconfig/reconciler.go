@@ -17,14 +17,40 @@ limitations under the License.
 package cstorclusterconfig
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"openebs.io/metac/controller/generic"
 
+	"mayadata.io/cstorpoolauto/common/adoption"
+	"mayadata.io/cstorpoolauto/common/autopolicy"
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	"mayadata.io/cstorpoolauto/common/disksource"
+	"mayadata.io/cstorpoolauto/common/ignorefields"
 	"mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/metadata"
+	"mayadata.io/cstorpoolauto/common/migration"
+	"mayadata.io/cstorpoolauto/common/naming"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	"mayadata.io/cstorpoolauto/common/overlay"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/skip"
+	"mayadata.io/cstorpoolauto/common/state"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/faultinject"
+	"mayadata.io/cstorpoolauto/pkg/features"
+	"mayadata.io/cstorpoolauto/pkg/tracing"
+	"mayadata.io/cstorpoolauto/pkg/zfsproperty"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 )
@@ -43,12 +69,19 @@ const (
 	DefaultMinPoolCount int64 = 3
 )
 
+// controllerName is reported against the skipped reconciliations
+// of CStorClusterConfig
+const controllerName = "CStorClusterConfig"
+
 // DefaultMinDiskCapacity is the default min disk capacity
 var DefaultMinDiskCapacity resource.Quantity = resource.MustParse("100Gi")
 
 type reconcileErrHandler struct {
 	clusterConfig *unstructured.Unstructured
 	hookResponse  *generic.SyncHookResponse
+
+	// context of the traced sync if any
+	ctx context.Context
 }
 
 func (h *reconcileErrHandler) handle(err error) {
@@ -56,10 +89,15 @@ func (h *reconcileErrHandler) handle(err error) {
 		"Failed to reconcile CStorClusterConfig %q / %q: %+v",
 		h.clusterConfig.GetNamespace(), h.clusterConfig.GetName(), err,
 	)
+	tracing.RecordError(h.ctx, err)
 
 	// this will stop further reconciliation at metac since there was
 	// an error
 	h.hookResponse.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(err) {
+		h.hookResponse.ResyncAfterSeconds = errs.ResyncAfterSeconds(err)
+	}
 }
 
 // Sync implements the idempotent logic to set CStorClusterConfig
@@ -93,12 +131,21 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 	// NOTE:
 	// 	It is expected to have CStorClusterConfig as an attachment
 	// resource as well as the resource under watch.
+	// construct the error handler
+	errHandler := &reconcileErrHandler{
+		clusterConfig: request.Watch,
+		hookResponse:  response,
+		ctx:           tracing.ContextOf(request),
+	}
+
 	if request.Attachments == nil || request.Attachments.IsEmpty() {
-		glog.V(3).Infof(
-			"Will skip reconciliation: Nil attachments: CStorClusterConfig %q / %q",
-			request.Watch.GetNamespace(), request.Watch.GetName(),
+		err := skip.Skip(
+			controllerName, request.Watch, response,
+			skip.ReasonNilAttachments, "No attachments",
 		)
-		response.SkipReconcile = true
+		if err != nil {
+			errHandler.handle(err)
+		}
 		return nil
 	}
 
@@ -107,14 +154,23 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 		request.Watch.GetNamespace(), request.Watch.GetName(),
 	)
 
-	// construct the error handler
-	errHandler := &reconcileErrHandler{
-		clusterConfig: request.Watch,
-		hookResponse:  response,
+	isPaused, err := pause.Skip(controllerName, request.Watch, request.Watch, response)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
 	}
+	if isPaused {
+		return nil
+	}
+
+	// resources annotated by an older release are adopted before
+	// these are matched against the watch
+	adoption.Adopt(request.Watch, adoption.LegacyKeys)
+	adoption.AdoptAll(request.Attachments.List(), adoption.LegacyKeys)
 
 	var cstorClusterConfigObj *unstructured.Unstructured
 	var cstorClusterPlanObj *unstructured.Unstructured
+	var observedPlans []*unstructured.Unstructured
 	for _, attachment := range request.Attachments.List() {
 		// this watch resource must be present in the list of attachments
 		if request.Watch.GetUID() == attachment.GetUID() &&
@@ -131,11 +187,32 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
 			)
 			if string(request.Watch.GetUID()) == uid {
-				// this is the desired CStorClusterPlan
-				cstorClusterPlanObj = attachment
+				observedPlans = append(observedPlans, attachment)
+				zone, _, _ :=
+					unstructured.NestedString(attachment.Object, "spec", "zone")
+				if zone == "" {
+					// this is the desired CStorClusterPlan
+					cstorClusterPlanObj = attachment
+				}
+				// plans of this watch are added to the response after
+				// reconciliation
+				continue
+			}
+		}
+		if attachment.GetKind() == string(types.KindCStorClusterPlanRevision) {
+			uid, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+			)
+			if string(request.Watch.GetUID()) == uid {
+				// revisions of this watch are added to the response
+				// after reconciliation to retain only the recent ones
 				continue
 			}
 		}
+		if state.IsStateOf(attachment, request.Watch, ChangeBudgetStateComponent) {
+			// state is added to the response after reconciliation
+			continue
+		}
 		response.Attachments = append(response.Attachments, attachment)
 	}
 
@@ -158,6 +235,8 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 		errHandler.handle(err)
 		return nil
 	}
+	reconciler.Context = tracing.ContextOf(request)
+	reconciler.NodePlanner.Context = reconciler.Context
 	op, err := reconciler.Reconcile()
 	if err != nil {
 		errHandler.handle(err)
@@ -165,17 +244,53 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 	}
 	if op.SkipReconcile {
 		// skip reconciliation at metac
-		response.SkipReconcile = true
-		glog.V(3).Infof(
-			"Will skip reconciliation: %s: CStorClusterConfig %q / %q",
-			op.SkipReason, request.Watch.GetNamespace(), request.Watch.GetName(),
+		err = skip.Skip(
+			controllerName, request.Watch, response, op.SkipCode, op.SkipReason,
 		)
+		if err != nil {
+			errHandler.handle(err)
+		}
+		return nil
+	}
+
+	desiredPlans := op.CStorClusterPlans
+	if op.CStorClusterPlan != nil {
+		desiredPlans = append(
+			[]*unstructured.Unstructured{op.CStorClusterPlan}, desiredPlans...,
+		)
+	}
+	// plans generated by an older release are migrated to the
+	// current schema
+	desiredPlans, err = migration.MigrateAll(observedPlans, desiredPlans)
+	if err != nil {
+		errHandler.handle(err)
 		return nil
 	}
 
-	// add updated CStorClusterConfig & CStorClusterConfigPlan to response
+	// add updated CStorClusterConfig & CStorClusterConfigPlan(s) to response
 	response.Attachments = append(response.Attachments, op.CStorClusterConfig)
-	response.Attachments = append(response.Attachments, op.CStorClusterPlan)
+	response.Attachments = append(response.Attachments, desiredPlans...)
+	response.Attachments = append(response.Attachments, op.CStorClusterPlanRevisions...)
+	if op.ChangeBudgetState != nil {
+		response.Attachments = append(response.Attachments, op.ChangeBudgetState)
+	}
+	if op.ResyncAfter > 0 {
+		// deferred changes are planned once the budget is available
+		response.ResyncAfterSeconds = op.ResyncAfter.Seconds()
+	}
+	// report the nodes that are not eligible for pools
+	status, _, _ := unstructured.NestedMap(request.Watch.Object, "status")
+	response.Status, err = ccc.SetRejectedNodes(status, op.RejectedNodes)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+
+	err = skip.Clear(controllerName, request.Watch, response)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
 
 	glog.V(2).Infof(
 		"CStorClusterConfig %s %s reconciled successfully: %s",
@@ -192,6 +307,14 @@ type Reconciler struct {
 	Resources     []*unstructured.Unstructured
 	NodePlanner   *NodePlanner
 
+	// Context is the context of the traced sync if any. Each step
+	// of reconciliation is traced as a phase of this sync.
+	Context context.Context
+
+	// Zone is the topology zone of ClusterPlan if pools are
+	// planned per zone
+	Zone string
+
 	// values that get validated / defaulted before finally get into
 	// the desired state
 	minPoolCount    int64
@@ -200,17 +323,59 @@ type Reconciler struct {
 	minDiskCount    int64
 	minDiskCapacity int64
 
+	// fields of CStorClusterConfig that were defaulted from its
+	// CStorPoolAutoPolicy
+	policyDefaults []autopolicy.Default
+
+	// duration for which nodes are debounced before these are
+	// planned in or removed
+	nodeStabilityWindow time.Duration
+
 	// nodes that form the desired CStorClusterPlan
 	desiredNodes []types.CStorClusterPlanNode
+
+	// true if desired nodes were planned to change the pool
+	// count as set by the pool autoscaler
+	isAutoscaled bool
+
+	// explains the planning of desired nodes
+	planExplain *types.CStorClusterPlanExplain
+
+	// nodes that are not eligible for pools
+	rejectedNodes []types.CStorClusterConfigRejectedNode
+
+	// CStorClusterPlan(s) that are planned per zone
+	desiredPlans []*unstructured.Unstructured
+
+	// revisions that record changes made to CStorClusterPlan
+	revisionHistoryLimit int
+	desiredRevisions     []*unstructured.Unstructured
+
+	// changes made against the change budget; this is shared by
+	// the reconcilers of all zones
+	changeBudgetState *state.Store
+
+	// nodes whose changes were deferred by the change budget
+	deferredAdds    []types.CStorClusterPlanNode
+	deferredRemoves []types.CStorClusterPlanNode
+
+	// duration after which the deferred changes can be planned
+	resyncAfter time.Duration
 }
 
 // ReconcileResponse is a helper struct used to form the response
 // of a successful reconciliation
 type ReconcileResponse struct {
-	CStorClusterConfig *unstructured.Unstructured
-	CStorClusterPlan   *unstructured.Unstructured
-	SkipReconcile      bool
-	SkipReason         string
+	CStorClusterConfig        *unstructured.Unstructured
+	CStorClusterPlan          *unstructured.Unstructured
+	CStorClusterPlans         []*unstructured.Unstructured
+	CStorClusterPlanRevisions []*unstructured.Unstructured
+	ChangeBudgetState         *unstructured.Unstructured
+	RejectedNodes             []types.CStorClusterConfigRejectedNode
+	ResyncAfter               time.Duration
+	SkipReconcile             bool
+	SkipCode                  skip.Reason
+	SkipReason                string
 }
 
 // NewReconciler returns a new instance of Reconciler
@@ -223,19 +388,48 @@ func NewReconciler(
 		Resources: resources,
 		NodePlanner: &NodePlanner{
 			Resources: resources,
+			Now:       time.Now(),
 		},
+		revisionHistoryLimit: RevisionHistoryLimit,
+	}
+
+	// fields that are not set are defaulted from the policy that
+	// selects this config
+	policy, err := autopolicy.Select(clusterConfig, resources)
+	if err != nil {
+		return nil, err
+	}
+	clusterConfig, r.policyDefaults, err = autopolicy.Apply(clusterConfig, policy)
+	if err != nil {
+		return nil, err
 	}
 
 	// transform CStorClusterConfig from unstructured to typed
 	var clusterConfigTyped types.CStorClusterConfig
-	err := unstruct.UnstructToTyped(clusterConfig, &clusterConfigTyped)
+	err = unstruct.UnstructToTyped(clusterConfig, &clusterConfigTyped)
 	if err != nil {
 		return nil, err
 	}
 
 	// update the reconciler instance with config & related fields
 	r.ClusterConfig = &clusterConfigTyped
-	r.NodePlanner.NodeSelector = r.ClusterConfig.Spec.AllowedNodes
+	r.NodePlanner.NodeSelector = r.ClusterConfig.Spec.AllowedNodes.ResourceSelector
+	r.NodePlanner.MachinePool = r.ClusterConfig.Spec.AllowedNodes.MachinePool
+	r.NodePlanner.AllowControlPlaneNodes = r.ClusterConfig.Spec.AllowControlPlaneNodes
+	if disksource.IsHybrid(r.ClusterConfig.Spec.DiskConfig) {
+		// pools of the nodes that use local disks are not planned
+		r.NodePlanner.DiskSources = &disksource.Resolver{
+			Overrides: r.ClusterConfig.Spec.DiskConfig.NodeOverrides,
+		}
+	}
+
+	// state is loaded even if change budget is not set to retain
+	// its ConfigMap
+	r.changeBudgetState, err =
+		state.Load(clusterConfig, ChangeBudgetStateComponent, resources)
+	if err != nil {
+		return nil, err
+	}
 
 	// transform CStorClusterPlan from unstructured to typed
 	if clusterPlan != nil {
@@ -246,6 +440,7 @@ func NewReconciler(
 		}
 		// update the reconciler instance with typed CStorClusterPlan
 		r.ClusterPlan = &clusterPlanTyped
+		r.NodePlanner.PlannedNodeNames = getPlannedNodeNames(r.ClusterPlan)
 	}
 
 	return r, nil
@@ -260,15 +455,39 @@ func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
 		// this controller is meant for external disk config only
 		return ReconcileResponse{
 			SkipReconcile: true,
+			SkipCode:      skip.ReasonExternalDiskConfigNotFound,
 			SkipReason:    "External disk config not found",
 		}, nil
 	}
+	if r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC {
+		return r.reconcilePerZone()
+	}
 	syncFns := []func() error{
 		r.syncClusterConfig,
+		r.validateNaming,
+		r.validateStorageClass,
+		r.validateReclaimPolicy,
+		r.validateZFSProperties,
+		r.validateNodeSelectorKey,
+		r.validateChangeBudget,
+		r.validateMinReadyNodesBeforeCreate,
+		r.validateMachinePool,
+		r.validateIgnoreFields,
+		r.validateOverlays,
+		r.validateClusterPlans,
 		r.syncClusterPlan,
+		r.syncClusterPlanRevisions,
+		r.syncRejectedNodes,
 	}
 	for _, syncFn := range syncFns {
-		err := syncFn()
+		// remaining steps are not run past the deadline of this sync
+		err := errs.CheckContext(r.Context)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+		end := tracing.StartPhase(r.Context, syncFn)
+		err = syncFn()
+		end()
 		if err != nil {
 			return ReconcileResponse{}, err
 		}
@@ -281,7 +500,22 @@ func (r *Reconciler) makeReconcileResponse() ReconcileResponse {
 	return ReconcileResponse{
 		CStorClusterConfig: r.getDesiredClusterConfig(),
 		CStorClusterPlan:   r.getDesiredClusterPlan(r.desiredNodes),
+
+		CStorClusterPlanRevisions: r.desiredRevisions,
+
+		ChangeBudgetState: r.getDesiredChangeBudgetState(),
+		RejectedNodes:     r.rejectedNodes,
+		ResyncAfter:       r.resyncAfter,
+	}
+}
+
+// getDesiredChangeBudgetState returns the ConfigMap that saves the
+// changes made against the change budget
+func (r *Reconciler) getDesiredChangeBudgetState() *unstructured.Unstructured {
+	if r.changeBudgetState == nil {
+		return nil
 	}
+	return r.changeBudgetState.Desired()
 }
 
 // syncClusterPlan synchronises the CStorClusterPlan resource
@@ -292,10 +526,51 @@ func (r *Reconciler) makeReconcileResponse() ReconcileResponse {
 //	This should be invoked only after CStorClusterConfig is set
 // with defaults if required.
 func (r *Reconciler) syncClusterPlan() error {
+	err := faultinject.Inject(faultinject.PhasePlanComputation)
+	if err != nil {
+		return err
+	}
 	var observedNodes []types.CStorClusterPlanNode
 	if r.ClusterPlan != nil {
 		observedNodes = r.ClusterPlan.Spec.Nodes
 	}
+	missingSince, err := r.getObservedNodesMissingSince()
+	if err != nil {
+		return err
+	}
+	r.NodePlanner.StabilityWindow = r.nodeStabilityWindow
+	r.NodePlanner.ObservedMissingSince = missingSince
+	r.NodePlanner.PinnedNodeNames = getPinnedNodeNames(r.ClusterPlan)
+	minPoolCount, maxPoolCount := r.minPoolCount, r.maxPoolCount
+	poolCount, isAutoscaled, err := r.getAutoscaledPoolCount()
+	if err != nil {
+		return err
+	}
+	if isAutoscaled {
+		// planner converges to the exact pool count that was
+		// set by the pool autoscaler
+		minPoolCount, maxPoolCount = poolCount, poolCount
+		r.isAutoscaled = poolCount != int64(len(observedNodes))
+	}
+	replicas, isFollowed, err := r.getMachinePoolReplicaCount()
+	if err != nil {
+		return err
+	}
+	if isFollowed {
+		// planner converges to the replica count of the machine
+		// pool
+		minPoolCount, maxPoolCount = replicas, replicas
+	}
+	isHybrid := r.NodePlanner.DiskSources != nil
+	if isHybrid {
+		// pool counts include the pools of the nodes that use
+		// local disks
+		minPoolCount, maxPoolCount, err =
+			r.excludeLocalDiskPoolCount(minPoolCount, maxPoolCount)
+		if err != nil {
+			return err
+		}
+	}
 	// Plan should be invoked only after CStorClusterConfig is
 	// set with defaults.
 	//
@@ -304,42 +579,263 @@ func (r *Reconciler) syncClusterPlan() error {
 	// that are fit to form CStorPoolCluster
 	nodes, err := r.NodePlanner.Plan(NodePlannerConfig{
 		ObservedNodes: observedNodes,
-		MinPoolCount:  *resource.NewQuantity(r.minPoolCount, resource.DecimalExponent),
-		MaxPoolCount:  *resource.NewQuantity(r.maxPoolCount, resource.DecimalExponent),
+		MinPoolCount:  *resource.NewQuantity(minPoolCount, resource.DecimalExponent),
+		MaxPoolCount:  *resource.NewQuantity(maxPoolCount, resource.DecimalExponent),
 	})
 	if err != nil {
 		return err
 	}
-	if len(nodes) == 0 {
-		return errors.Errorf("No elgible nodes were found")
+	if len(nodes) == 0 && !isHybrid {
+		return errs.NotEnoughResourcesErrorf("No elgible nodes were found")
 	}
 	r.desiredNodes = nodes
+	err = r.limitClusterPlanByChangeBudget(observedNodes)
+	if err != nil {
+		return err
+	}
+	return r.explainClusterPlan(observedNodes, minPoolCount, maxPoolCount)
+}
+
+// excludeLocalDiskPoolCount returns the given min & max pool counts
+// less the count of nodes that use local disks of a hybrid disk
+// config
+func (r *Reconciler) excludeLocalDiskPoolCount(
+	minPoolCount, maxPoolCount int64,
+) (int64, int64, error) {
+	localDiskNodeCount, err := r.NodePlanner.GetLocalDiskNodeCountOrCached()
+	if err != nil {
+		return 0, 0, err
+	}
+	minPoolCount -= localDiskNodeCount
+	if minPoolCount < 0 {
+		minPoolCount = 0
+	}
+	maxPoolCount -= localDiskNodeCount
+	if maxPoolCount < 0 {
+		maxPoolCount = 0
+	}
+	return minPoolCount, maxPoolCount, nil
+}
+
+// limitClusterPlanByChangeBudget defers the addition & removal of
+// desired nodes that are beyond the change budget
+//
+// NOTE:
+//	This should be invoked only after desired nodes are planned
+func (r *Reconciler) limitClusterPlanByChangeBudget(
+	observedNodes []types.CStorClusterPlanNode,
+) error {
+	if r.ClusterConfig == nil || r.ClusterConfig.Spec.PoolConfig.ChangeBudget == nil {
+		return nil
+	}
+	planner := &ChangeBudgetPlanner{
+		Budget:        r.ClusterConfig.Spec.PoolConfig.ChangeBudget,
+		State:         r.changeBudgetState,
+		Now:           r.NodePlanner.Now,
+		ObservedNodes: observedNodes,
+		DesiredNodes:  r.desiredNodes,
+		AllNodes:      r.NodePlanner.GetAllNodes(),
+	}
+	result, err := planner.Plan()
+	if err != nil {
+		return err
+	}
+	if len(result.DeferredAdds) != 0 || len(result.DeferredRemoves) != 0 {
+		glog.V(2).Infof(
+			"Changes deferred by change budget: CStorClusterConfig %q / %q: Zone %q: Adds %d: Removes %d: Resync after %s",
+			r.ClusterConfig.GetNamespace(), r.ClusterConfig.GetName(), r.Zone,
+			len(result.DeferredAdds), len(result.DeferredRemoves), result.ResyncAfter,
+		)
+	}
+	r.desiredNodes = result.Nodes
+	r.deferredAdds = result.DeferredAdds
+	r.deferredRemoves = result.DeferredRemoves
+	r.resyncAfter = result.ResyncAfter
+	return nil
+}
+
+// explainClusterPlan explains the planning of desired nodes
+//
+// NOTE:
+//	This should be invoked only after desired nodes are planned
+func (r *Reconciler) explainClusterPlan(
+	observedNodes []types.CStorClusterPlanNode, minPoolCount, maxPoolCount int64,
+) error {
+	allowedNodes, err := r.NodePlanner.GetAllowedNodesOrCached()
+	if err != nil {
+		return err
+	}
+	stableNodes, err := r.NodePlanner.GetStableAllowedNodes()
+	if err != nil {
+		return err
+	}
+	planner := &ExplainPlanner{
+		ObservedNodes:     observedNodes,
+		DesiredNodes:      r.desiredNodes,
+		EligibleNodeCount: int64(len(stableNodes)),
+		MinPoolCount:      minPoolCount,
+		MaxPoolCount:      maxPoolCount,
+		RevisionPlanner: &RevisionPlanner{
+			ObservedNodes: observedNodes,
+			DesiredNodes:  r.desiredNodes,
+			AllNodes:      r.NodePlanner.GetAllNodes(),
+			AllowedNodes:  allowedNodes,
+			IsAutoscaled:  r.isAutoscaled,
+		},
+		DeferredAdds:    r.deferredAdds,
+		DeferredRemoves: r.deferredRemoves,
+	}
+	explain := planner.Plan()
+	r.planExplain = &explain
 	return nil
 }
 
+// syncRejectedNodes records the nodes that are not eligible for
+// pools along with the reason of their rejection
+//
+// NOTE:
+//	This should be invoked only after desired nodes are planned
+func (r *Reconciler) syncRejectedNodes() error {
+	if r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC {
+		// nodes are planned by the planners of zones while this
+		// planner evaluates the nodes of all the zones
+		observedPlans, err := r.getObservedZonedClusterPlans()
+		if err != nil {
+			return err
+		}
+		r.NodePlanner.PlannedNodeNames = map[string]bool{}
+		for _, plan := range observedPlans {
+			for name := range getPlannedNodeNames(plan) {
+				r.NodePlanner.PlannedNodeNames[name] = true
+			}
+		}
+	}
+	r.NodePlanner.StabilityWindow = r.nodeStabilityWindow
+	allowedNodes, err := r.NodePlanner.GetAllowedNodesOrCached()
+	if err != nil {
+		return err
+	}
+	_, err = r.NodePlanner.GetStableAllowedNodes()
+	if err != nil {
+		return err
+	}
+	if r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC {
+		for _, node := range allowedNodes {
+			if nodecommon.GetZone(node) == "" {
+				// nodes without zone labels are not planned
+				r.NodePlanner.reject(node, types.RejectedNodeReasonNoZone)
+			}
+		}
+	}
+	r.rejectedNodes = r.NodePlanner.GetRejectedNodes()
+	if len(r.rejectedNodes) != 0 {
+		var table strings.Builder
+		fmt.Fprintf(&table, "%-40s %s", "NODE", "REASON")
+		for _, node := range r.rejectedNodes {
+			fmt.Fprintf(&table, "\n%-40s %s", node.Name, node.Reason)
+		}
+		glog.V(4).Infof(
+			"Rejected nodes: CStorClusterConfig %q / %q: Count %d:\n%s",
+			r.ClusterConfig.GetNamespace(), r.ClusterConfig.GetName(),
+			len(r.rejectedNodes), table.String(),
+		)
+	}
+	return nil
+}
+
+// syncClusterPlanRevisions records the changes made to
+// CStorClusterPlan nodes as CStorClusterPlanRevision(s)
+//
+// NOTE:
+//	This should be invoked only after desired nodes are planned
+func (r *Reconciler) syncClusterPlanRevisions() error {
+	var observedNodes []types.CStorClusterPlanNode
+	if r.ClusterPlan != nil {
+		observedNodes = r.ClusterPlan.Spec.Nodes
+	}
+	allowedNodes, err := r.NodePlanner.GetAllowedNodesOrCached()
+	if err != nil {
+		return err
+	}
+	var observedRevisions []*unstructured.Unstructured
+	for _, res := range r.Resources {
+		if res == nil || res.GetKind() != string(types.KindCStorClusterPlanRevision) {
+			continue
+		}
+		uid, _ := unstruct.GetValueForKey(
+			res.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+		)
+		// revisions of other zones belong to other plans
+		zone, _, _ := unstructured.NestedString(res.Object, "spec", "zone")
+		if string(r.ClusterConfig.GetUID()) == uid && zone == r.Zone {
+			observedRevisions = append(observedRevisions, res)
+		}
+	}
+	planner := &RevisionPlanner{
+		ClusterConfig:     r.ClusterConfig,
+		Zone:              r.Zone,
+		ClusterPlanName:   r.getClusterPlanName(),
+		ObservedNodes:     observedNodes,
+		DesiredNodes:      r.desiredNodes,
+		AllNodes:          r.NodePlanner.GetAllNodes(),
+		AllowedNodes:      allowedNodes,
+		ObservedRevisions: observedRevisions,
+		HistoryLimit:      r.revisionHistoryLimit,
+		IsAutoscaled:      r.isAutoscaled,
+	}
+	r.desiredRevisions, err = planner.Plan()
+	return err
+}
+
 func (r *Reconciler) getDesiredClusterPlan(
 	desiredNodes []types.CStorClusterPlanNode,
 ) *unstructured.Unstructured {
+	// nodes are sorted by name to build the same plan irrespective
+	// of the order the nodes were planned
+	sortedNodes := append([]types.CStorClusterPlanNode{}, desiredNodes...)
+	sort.SliceStable(sortedNodes, func(i, j int) bool {
+		return sortedNodes[i].Name < sortedNodes[j].Name
+	})
 	plan := &unstructured.Unstructured{}
 	plan.SetUnstructuredContent(
 		map[string]interface{}{
 			"spec": map[string]interface{}{
-				"nodes": types.MakeListMapOfPlanNodes(desiredNodes),
+				"nodes": types.MakeListMapOfPlanNodes(sortedNodes),
 			},
 		},
 	)
+	if r.Zone != "" {
+		plan.Object["spec"].(map[string]interface{})["zone"] = r.Zone
+	}
 	plan.SetGroupVersionKind(schema.GroupVersionKind{
 		Group:   string(types.GroupDAOMayaDataIO),
 		Version: string(types.VersionV1Alpha1),
 		Kind:    string(types.KindCStorClusterPlan),
 	})
-	// name & namespace are same as CStorClusterConfig
-	plan.SetName(r.ClusterConfig.GetName())
+	// name is suffixed with zone if pools are planned per zone
+	// while namespace is same as CStorClusterConfig
+	plan.SetName(r.getClusterPlanName())
 	plan.SetNamespace(r.ClusterConfig.GetNamespace())
 	// create annotations that refer to CStorClusterConfig UID
-	plan.SetAnnotations(map[string]string{
+	annotations := map[string]string{
 		types.AnnKeyCStorClusterConfigUID: string(r.ClusterConfig.GetUID()),
-	})
+	}
+	// nodes that are retained till these stay missing for the
+	// stability window
+	if missingSince := r.NodePlanner.GetMissingSince(); len(missingSince) != 0 {
+		// marshalling a map of strings never fails
+		raw, _ := json.Marshal(missingSince)
+		annotations[types.AnnKeyCStorClusterPlanNodesMissingSince] = string(raw)
+	}
+	// planning decisions are explained for automated verification
+	if r.planExplain != nil {
+		// marshalling a struct of strings & numbers never fails
+		raw, _ := json.Marshal(r.planExplain)
+		annotations[types.AnnKeyCStorClusterPlanExplain] = string(raw)
+	}
+	plan.SetAnnotations(annotations)
+	// user provided labels & annotations if any
+	metadata.Propagate(plan, r.ClusterConfig.Spec.ChildMetadata)
 
 	return plan
 }
@@ -352,6 +848,7 @@ func (r *Reconciler) syncClusterConfig() error {
 	setDefaultFns := []func() error{
 		// pre checks
 		r.validateDiskConfig,
+		r.validatePerZoneCSPC,
 		r.validateExternalDiskConfig,
 		// set to defaults if not set
 		r.setMinPoolCountIfNotSet,
@@ -359,8 +856,10 @@ func (r *Reconciler) syncClusterConfig() error {
 		r.setRAIDTypeIfNotSet,
 		r.setMinDiskCountIfNotSet,
 		r.setMinDiskCapacityIfNotSet,
+		r.setNodeStabilityWindowIfNotSet,
 		// post checks
 		r.validateRAIDType,
+		r.validateRAIDGroupConfig,
 		r.validateMinDiskCount,
 	}
 	for _, setDefaultFn := range setDefaultFns {
@@ -389,6 +888,15 @@ func (r *Reconciler) getDesiredClusterConfig() *unstructured.Unstructured {
 			},
 		},
 	)
+	// defaults of the policy are persisted to let other controllers
+	// that read CStorClusterConfig observe them
+	for _, d := range r.policyDefaults {
+		// values of the policy are valid JSON values & hence setting
+		// these does not fail
+		_ = unstructured.SetNestedField(
+			config.Object, d.Value, append([]string{"spec"}, d.Path...)...,
+		)
+	}
 	config.SetGroupVersionKind(schema.GroupVersionKind{
 		Group:   string(types.GroupDAOMayaDataIO),
 		Version: string(types.VersionV1Alpha1),
@@ -409,7 +917,7 @@ func (r *Reconciler) setMinPoolCountIfNotSet() error {
 	var minPoolCount int64
 	minPoolCount = r.ClusterConfig.Spec.MinPoolCount.Value()
 	if minPoolCount < 0 {
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"Invalid MinPoolCount %d: Want positive value", minPoolCount,
 		)
 	}
@@ -430,6 +938,13 @@ func (r *Reconciler) setMinPoolCountIfNotSet() error {
 	if err != nil {
 		return err
 	}
+	// nodes that use local disks of a hybrid disk config get
+	// pools as well
+	localDiskNodeCount, err := r.NodePlanner.GetLocalDiskNodeCountOrCached()
+	if err != nil {
+		return err
+	}
+	eligibleNodeCount += localDiskNodeCount
 	// start by setting min pool count to default value
 	minPoolCount = DefaultMinPoolCount
 	if availableNodeCount < minPoolCount {
@@ -447,7 +962,7 @@ func (r *Reconciler) setMinPoolCountIfNotSet() error {
 	//	0 may be a valid value that we might want to consider
 	// e.g. scale down to 0 pools
 	if minPoolCount <= 0 {
-		return errors.Errorf(
+		return errs.NotEnoughResourcesErrorf(
 			"Preferred nodes not found: MinPoolCount evaluates to %d:",
 			minPoolCount,
 		)
@@ -472,7 +987,7 @@ func (r *Reconciler) setMaxPoolCountIfNotSet() error {
 	}
 	// check further if min is greater than max which is an error
 	if minPoolCount > maxPoolCount {
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"MaxPoolCount %d can't be less than MinPoolCount %d",
 			maxPoolCount,
 			minPoolCount,
@@ -495,7 +1010,7 @@ func (r *Reconciler) setMinDiskCountIfNotSet() error {
 	var minDiskCount int64
 	minDiskCount = r.ClusterConfig.Spec.DiskConfig.MinCount.Value()
 	if minDiskCount < 0 {
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"Invalid MinDiskCount %d: Want positive value", minDiskCount,
 		)
 	}
@@ -511,8 +1026,9 @@ func (r *Reconciler) setMinDiskCountIfNotSet() error {
 	//  It may be good to use pointer to represent MinCount.
 	// This will help in differentiating a value that was not
 	// set vs. a value that was set to 0.
-	r.minDiskCount =
-		types.RAIDTypeToDefaultMinDiskCount[r.poolRAIDType]
+	r.minDiskCount = types.GetMinDiskCount(
+		r.poolRAIDType, r.getRAIDGroupConfig(),
+	)
 	return nil
 }
 
@@ -520,7 +1036,7 @@ func (r *Reconciler) setMinDiskCapacityIfNotSet() error {
 	var minDiskCapacity int64
 	minDiskCapacity = r.ClusterConfig.Spec.DiskConfig.MinCapacity.Value()
 	if minDiskCapacity < 0 {
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"Invalid MinDiskCapacity %s: Want positive value",
 			r.ClusterConfig.Spec.DiskConfig.MinCapacity.String(),
 		)
@@ -534,36 +1050,105 @@ func (r *Reconciler) setMinDiskCapacityIfNotSet() error {
 	return nil
 }
 
+func (r *Reconciler) setNodeStabilityWindowIfNotSet() error {
+	window := r.ClusterConfig.Spec.PoolConfig.NodeStabilityWindow
+	if window == nil {
+		r.nodeStabilityWindow = types.DefaultNodeStabilityWindow.Duration
+		return nil
+	}
+	if window.Duration < 0 {
+		return errs.ValidationErrorf(
+			"Invalid NodeStabilityWindow %q: Want positive value", window.Duration,
+		)
+	}
+	r.nodeStabilityWindow = window.Duration
+	return nil
+}
+
+// getObservedNodesMissingSince returns the planned nodes of the
+// observed CStorClusterPlan that are no longer allowed mapped to
+// the time since when these are missing
+func (r *Reconciler) getObservedNodesMissingSince() (map[string]string, error) {
+	if r.ClusterPlan == nil {
+		return nil, nil
+	}
+	val, _ := unstruct.GetValueForKey(
+		r.ClusterPlan.GetAnnotations(),
+		types.AnnKeyCStorClusterPlanNodesMissingSince,
+	)
+	if val == "" {
+		return nil, nil
+	}
+	var missingSince map[string]string
+	err := json.Unmarshal([]byte(val), &missingSince)
+	if err != nil {
+		return nil, errs.AsValidationError(errors.Wrapf(
+			err,
+			"Invalid annotation %q",
+			types.AnnKeyCStorClusterPlanNodesMissingSince,
+		))
+	}
+	return missingSince, nil
+}
+
 func (r *Reconciler) validateRAIDType() error {
 	// verify if the RAID type that was set against the resource is valid
 	switch r.poolRAIDType {
 	case types.PoolRAIDTypeStripe, types.PoolRAIDTypeMirror,
-		types.PoolRAIDTypeRAIDZ, types.PoolRAIDTypeRAIDZ2:
+		types.PoolRAIDTypeStripedMirror, types.PoolRAIDTypeRAIDZ,
+		types.PoolRAIDTypeRAIDZ2:
 		// do nothing
 	default:
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"Invalid RAID type %s", r.poolRAIDType,
 		)
 	}
 	return nil
 }
 
+// getRAIDGroupConfig returns the raid group config if any
+func (r *Reconciler) getRAIDGroupConfig() *types.PoolRAIDGroupConfig {
+	if r.ClusterConfig == nil {
+		return nil
+	}
+	return r.ClusterConfig.Spec.PoolConfig.RAIDGroupConfig
+}
+
+// validateRAIDGroupConfig verifies if the group device count if
+// set can form the raid groups of the raid type
+func (r *Reconciler) validateRAIDGroupConfig() error {
+	err := types.ValidateRAIDGroupConfig(
+		r.poolRAIDType, r.getRAIDGroupConfig(),
+	)
+	if err != nil {
+		return errs.AsValidationError(err)
+	}
+	return nil
+}
+
 func (r *Reconciler) validateMinDiskCount() error {
 	diskCount := r.minDiskCount
 	if diskCount == 0 {
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"Invalid min disk count '0'",
 		)
 	}
-	defaultCount := types.RAIDTypeToDefaultMinDiskCount[r.poolRAIDType]
+	groupConfig := r.getRAIDGroupConfig()
+	defaultCount := types.GetMinDiskCount(r.poolRAIDType, groupConfig)
 	if defaultCount == 0 {
-		return errors.Errorf(
+		return errs.ValidationErrorf(
 			"Can't eval default disk count: RAID type %q is not set", r.poolRAIDType,
 		)
 	}
-	if diskCount%defaultCount != 0 {
-		return errors.Errorf(
-			"Invalid disk count %d: Want multiples of %d", diskCount, defaultCount,
+	groupCount := types.GetRAIDGroupDiskCount(r.poolRAIDType, groupConfig)
+	if diskCount%groupCount != 0 {
+		return errs.ValidationErrorf(
+			"Invalid disk count %d: Want multiples of %d", diskCount, groupCount,
+		)
+	}
+	if diskCount < defaultCount {
+		return errs.ValidationErrorf(
+			"Invalid disk count %d: Want at least %d", diskCount, defaultCount,
 		)
 	}
 	return nil
@@ -583,25 +1168,247 @@ func (r *Reconciler) isExternalDiskConfig() bool {
 	return r.ClusterConfig.Spec.DiskConfig.LocalDiskConfig == nil
 }
 
+// validateDiskConfig verifies the node overrides of the disk config.
+// Both local as well as external disk configs can be set i.e. a
+// hybrid disk config.
 func (r *Reconciler) validateDiskConfig() error {
-	if r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig != nil &&
-		r.ClusterConfig.Spec.DiskConfig.LocalDiskConfig != nil {
-		return errors.Errorf(
-			"Invalid disk config: Either external or local config needed, not both",
+	err := disksource.Validate(r.ClusterConfig.Spec.DiskConfig)
+	if err != nil {
+		return err
+	}
+	if disksource.IsHybrid(r.ClusterConfig.Spec.DiskConfig) &&
+		r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC {
+		return errs.ValidationErrorf(
+			"Invalid disk config: Hybrid config doesn't support perZoneCSPC",
+		)
+	}
+	return nil
+}
+
+// validatePerZoneCSPC verifies if pools can be planned per zone
+func (r *Reconciler) validatePerZoneCSPC() error {
+	if r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC &&
+		!features.Enabled(features.PerZoneCSPC) {
+		return features.NewDisabledError(
+			features.PerZoneCSPC, "Invalid pool config: perZoneCSPC",
 		)
 	}
 	return nil
 }
 
 func (r *Reconciler) validateExternalDiskConfig() error {
-	if r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig == nil {
+	extConfig := r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig
+	if extConfig == nil {
 		return errors.Errorf("Nil external disk config")
 	}
-	if r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.CSIAttacherName == "" ||
-		r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.StorageClassName == "" {
-		return errors.Errorf(
-			"Invalid external disk config: Both csi attacher & storageclass are required",
+	if len(extConfig.StorageClasses) == 0 {
+		if extConfig.CSIAttacherName == "" || extConfig.StorageClassName == "" {
+			return errs.ValidationErrorf(
+				"Invalid external disk config: Both csi attacher & storageclass are required",
+			)
+		}
+		return nil
+	}
+	isNameUsed := map[string]bool{}
+	for _, class := range extConfig.StorageClasses {
+		if class.CSIAttacherName == "" || class.StorageClassName == "" {
+			return errs.ValidationErrorf(
+				"Invalid external disk config: Both csi attacher & storageclass are required for every storage class",
+			)
+		}
+		if class.Weight < 0 {
+			return errs.ValidationErrorf(
+				"Invalid external disk config: StorageClass %q: Negative weight %d",
+				class.StorageClassName, class.Weight,
+			)
+		}
+		if isNameUsed[class.StorageClassName] {
+			return errs.ValidationErrorf(
+				"Invalid external disk config: Duplicate StorageClass %q",
+				class.StorageClassName,
+			)
+		}
+		isNameUsed[class.StorageClassName] = true
+	}
+	return nil
+}
+
+// validateNaming verifies if the naming options of CStorClusterConfig
+// form valid names
+func (r *Reconciler) validateNaming() error {
+	return naming.Validate(r.ClusterConfig.Spec.Naming)
+}
+
+// getClusterPlanName returns the name of the observed CStorClusterPlan
+// if available. Otherwise the name is derived from CStorClusterConfig.
+//
+// NOTE:
+//	Observed CStorClusterPlan is never renamed since this would
+// delete & re-create its CStorPoolCluster
+func (r *Reconciler) getClusterPlanName() string {
+	if r.ClusterPlan != nil && r.ClusterPlan.GetName() != "" {
+		return r.ClusterPlan.GetName()
+	}
+	return getClusterPlanName(r.ClusterConfig, r.Zone)
+}
+
+// validateStorageClass verifies if the StorageClass(es) referred to
+// by external disk config exist & are provisioned by their CSI
+// attachers. Parameters of known CSI attachers are verified as well.
+//
+// NOTE:
+//	This should be invoked only after external disk config is
+// validated
+func (r *Reconciler) validateStorageClass() error {
+	extConfig := r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig
+	for _, class := range extConfig.GetStorageClasses() {
+		err := r.validateExternalStorageClass(class, extConfig.Parameters)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateReclaimPolicy verifies if the reclaim policy of the
+// external disk config is supported
+func (r *Reconciler) validateReclaimPolicy() error {
+	policy := r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.GetReclaimPolicy()
+	if !types.SupportedExternalDiskReclaimPolicies[policy] {
+		return errs.ValidationErrorf(
+			"Invalid external disk config: Unsupported reclaim policy %q", policy,
 		)
 	}
 	return nil
 }
+
+// validateZFSProperties verifies if the ZFS properties of the pools
+// are whitelisted & have allowed values
+func (r *Reconciler) validateZFSProperties() error {
+	return zfsproperty.Validate(r.ClusterConfig.Spec.PoolConfig.GetZFSProperties())
+}
+
+// validateNodeSelectorKey verifies if the node selector key of the
+// pools is a valid label key
+func (r *Reconciler) validateNodeSelectorKey() error {
+	key := r.ClusterConfig.Spec.PoolConfig.GetNodeSelectorKey()
+	if msgs := validation.IsQualifiedName(key); len(msgs) != 0 {
+		return errs.ValidationErrorf(
+			"Invalid pool config: nodeSelectorKey %q: %s",
+			key, strings.Join(msgs, ", "),
+		)
+	}
+	return nil
+}
+
+// validateIgnoreFields verifies if the field paths of
+// CStorPoolCluster that are ignored from reconciliation are valid
+func (r *Reconciler) validateIgnoreFields() error {
+	if r.ClusterConfig.Spec.Reconcile == nil {
+		return nil
+	}
+	return ignorefields.Validate(r.ClusterConfig.Spec.Reconcile.IgnoreFields)
+}
+
+// validateOverlays verifies if the overlays of CStorClusterConfig
+// can be applied to its children
+func (r *Reconciler) validateOverlays() error {
+	return overlay.Validate(r.ClusterConfig.Spec.Overlays)
+}
+
+// validateChangeBudget verifies if the change budget of the pools
+// has positive limits
+func (r *Reconciler) validateChangeBudget() error {
+	budget := r.ClusterConfig.Spec.PoolConfig.ChangeBudget
+	if budget == nil {
+		return nil
+	}
+	if budget.MaxAddPerHour != nil && *budget.MaxAddPerHour < 0 {
+		return errs.ValidationErrorf(
+			"Invalid pool config: changeBudget: Negative maxAddPerHour %d",
+			*budget.MaxAddPerHour,
+		)
+	}
+	if budget.MaxRemovePerHour != nil && *budget.MaxRemovePerHour < 0 {
+		return errs.ValidationErrorf(
+			"Invalid pool config: changeBudget: Negative maxRemovePerHour %d",
+			*budget.MaxRemovePerHour,
+		)
+	}
+	return nil
+}
+
+// validateMinReadyNodesBeforeCreate verifies if the min ready nodes
+// before creating the CStorPoolCluster can be met by the max pool
+// count. CStorPoolCluster would never be created otherwise.
+func (r *Reconciler) validateMinReadyNodesBeforeCreate() error {
+	count := r.ClusterConfig.Spec.PoolConfig.MinReadyNodesBeforeCreate
+	if count < 0 {
+		return errs.ValidationErrorf(
+			"Invalid pool config: Negative minReadyNodesBeforeCreate %d", count,
+		)
+	}
+	if r.maxPoolCount > 0 && count > r.maxPoolCount {
+		return errs.ValidationErrorf(
+			"Invalid pool config: minReadyNodesBeforeCreate %d exceeds max pool count %d",
+			count, r.maxPoolCount,
+		)
+	}
+	return nil
+}
+
+// validateExternalStorageClass verifies if the given StorageClass
+// exists & is provisioned by its CSI attacher. Given parameters are
+// verified if the CSI attacher is known.
+func (r *Reconciler) validateExternalStorageClass(
+	class types.ExternalStorageClass, parameters map[string]string,
+) error {
+	var storageClass *unstructured.Unstructured
+	for _, res := range r.Resources {
+		if res != nil &&
+			res.GetKind() == string(types.KindStorageClass) &&
+			res.GetName() == class.StorageClassName {
+			storageClass = res
+			break
+		}
+	}
+	if storageClass == nil {
+		return errs.NotEnoughResourcesErrorf(
+			"Invalid external disk config: StorageClass %q not found",
+			class.StorageClassName,
+		)
+	}
+	provisioner, _, err :=
+		unstructured.NestedString(storageClass.Object, "provisioner")
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"Invalid external disk config: StorageClass %q",
+			class.StorageClassName,
+		)
+	}
+	if provisioner != class.CSIAttacherName {
+		return errs.ValidationErrorf(
+			"Invalid external disk config: StorageClass %q is provisioned by %q: Want %q",
+			class.StorageClassName,
+			provisioner,
+			class.CSIAttacherName,
+		)
+	}
+	supported, isKnown :=
+		types.CSIAttacherToSupportedParameters[class.CSIAttacherName]
+	if !isKnown {
+		// parameters of unknown CSI attachers are passed as is
+		return nil
+	}
+	for param := range parameters {
+		if !supported[param] {
+			return errs.ValidationErrorf(
+				"Invalid external disk config: Parameter %q is not supported by %q",
+				param,
+				class.CSIAttacherName,
+			)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,9 @@ import (
 	"reflect"
 	"testing"
 
+	"mayadata.io/cstorpoolauto/common/disksource"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/faultinject"
 	"mayadata.io/cstorpoolauto/types"
 
 	"github.com/google/go-cmp/cmp"
@@ -644,6 +647,26 @@ func TestReconcilerValidateMinDiskCount(t *testing.T) {
 			RAIDType:     types.PoolRAIDTypeRAIDZ2,
 			isErr:        false,
 		},
+		"Disk Count = 2 && RAID type = striped-mirror": {
+			MinDiskCount: 2,
+			RAIDType:     types.PoolRAIDTypeStripedMirror,
+			isErr:        true,
+		},
+		"Disk Count = 4 && RAID type = striped-mirror": {
+			MinDiskCount: 4,
+			RAIDType:     types.PoolRAIDTypeStripedMirror,
+			isErr:        false,
+		},
+		"Disk Count = 5 && RAID type = striped-mirror": {
+			MinDiskCount: 5,
+			RAIDType:     types.PoolRAIDTypeStripedMirror,
+			isErr:        true,
+		},
+		"Disk Count = 6 && RAID type = striped-mirror": {
+			MinDiskCount: 6,
+			RAIDType:     types.PoolRAIDTypeStripedMirror,
+			isErr:        false,
+		},
 	}
 	for name, mock := range tests {
 		name := name
@@ -712,6 +735,63 @@ func TestReconcilerValidateExternalDiskConfig(t *testing.T) {
 			},
 			isErr: true,
 		},
+		"StorageClasses without CSIAttacherName & StorageClassName": {
+			CStorClusterConfig: &types.CStorClusterConfig{
+				Spec: types.CStorClusterConfigSpec{
+					DiskConfig: types.DiskConfig{
+						ExternalDiskConfig: &types.ExternalDiskConfig{
+							StorageClasses: []types.ExternalStorageClass{
+								{CSIAttacherName: "ebs.csi.aws.com", StorageClassName: "premium", Weight: 1},
+								{CSIAttacherName: "ebs.csi.aws.com", StorageClassName: "standard"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"StorageClasses with empty StorageClassName": {
+			CStorClusterConfig: &types.CStorClusterConfig{
+				Spec: types.CStorClusterConfigSpec{
+					DiskConfig: types.DiskConfig{
+						ExternalDiskConfig: &types.ExternalDiskConfig{
+							StorageClasses: []types.ExternalStorageClass{
+								{CSIAttacherName: "ebs.csi.aws.com"},
+							},
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+		"StorageClasses with negative weight": {
+			CStorClusterConfig: &types.CStorClusterConfig{
+				Spec: types.CStorClusterConfigSpec{
+					DiskConfig: types.DiskConfig{
+						ExternalDiskConfig: &types.ExternalDiskConfig{
+							StorageClasses: []types.ExternalStorageClass{
+								{CSIAttacherName: "ebs.csi.aws.com", StorageClassName: "premium", Weight: -1},
+							},
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+		"StorageClasses with duplicate StorageClassName": {
+			CStorClusterConfig: &types.CStorClusterConfig{
+				Spec: types.CStorClusterConfigSpec{
+					DiskConfig: types.DiskConfig{
+						ExternalDiskConfig: &types.ExternalDiskConfig{
+							StorageClasses: []types.ExternalStorageClass{
+								{CSIAttacherName: "ebs.csi.aws.com", StorageClassName: "premium"},
+								{CSIAttacherName: "ebs.csi.aws.com", StorageClassName: "premium"},
+							},
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
 	}
 	for name, mock := range tests {
 		name := name
@@ -731,6 +811,383 @@ func TestReconcilerValidateExternalDiskConfig(t *testing.T) {
 	}
 }
 
+func TestReconcilerValidateStorageClass(t *testing.T) {
+	newConfig := func(attacher string, params map[string]string) *types.CStorClusterConfig {
+		return &types.CStorClusterConfig{
+			Spec: types.CStorClusterConfigSpec{
+				DiskConfig: types.DiskConfig{
+					ExternalDiskConfig: &types.ExternalDiskConfig{
+						CSIAttacherName:  attacher,
+						StorageClassName: "csi-sc",
+						Parameters:       params,
+					},
+				},
+			},
+		}
+	}
+	newMultiClassConfig := func() *types.CStorClusterConfig {
+		return &types.CStorClusterConfig{
+			Spec: types.CStorClusterConfigSpec{
+				DiskConfig: types.DiskConfig{
+					ExternalDiskConfig: &types.ExternalDiskConfig{
+						StorageClasses: []types.ExternalStorageClass{
+							{CSIAttacherName: types.CSIAttacherNameAWSEBS, StorageClassName: "premium"},
+							{CSIAttacherName: types.CSIAttacherNameAWSEBS, StorageClassName: "standard"},
+						},
+					},
+				},
+			},
+		}
+	}
+	newStorageClass := func(name, provisioner string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindStorageClass),
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"provisioner": provisioner,
+			},
+		}
+	}
+	var tests = map[string]struct {
+		CStorClusterConfig *types.CStorClusterConfig
+		Resources          []*unstructured.Unstructured
+		isErr              bool
+	}{
+		"no storage class": {
+			CStorClusterConfig: newConfig(types.CSIAttacherNameGCEPD, nil),
+			isErr:              true,
+		},
+		"storage class with different name": {
+			CStorClusterConfig: newConfig(types.CSIAttacherNameGCEPD, nil),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("standard", types.CSIAttacherNameGCEPD),
+			},
+			isErr: true,
+		},
+		"storage class with different provisioner": {
+			CStorClusterConfig: newConfig(types.CSIAttacherNameGCEPD, nil),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("csi-sc", types.CSIAttacherNameAWSEBS),
+			},
+			isErr: true,
+		},
+		"storage class with same provisioner": {
+			CStorClusterConfig: newConfig(types.CSIAttacherNameGCEPD, nil),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("csi-sc", types.CSIAttacherNameGCEPD),
+			},
+		},
+		"supported parameters of known attacher": {
+			CStorClusterConfig: newConfig(
+				types.CSIAttacherNameAWSEBS,
+				map[string]string{"iops": "3000", "throughput": "125"},
+			),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("csi-sc", types.CSIAttacherNameAWSEBS),
+			},
+		},
+		"unsupported parameter of known attacher": {
+			CStorClusterConfig: newConfig(
+				types.CSIAttacherNameGCEPD,
+				map[string]string{"iops": "3000"},
+			),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("csi-sc", types.CSIAttacherNameGCEPD),
+			},
+			isErr: true,
+		},
+		"any parameter of unknown attacher": {
+			CStorClusterConfig: newConfig(
+				"abc-driver",
+				map[string]string{"diskType": "ssd"},
+			),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("csi-sc", "abc-driver"),
+			},
+		},
+		"all of the storage classes exist": {
+			CStorClusterConfig: newMultiClassConfig(),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("premium", types.CSIAttacherNameAWSEBS),
+				newStorageClass("standard", types.CSIAttacherNameAWSEBS),
+			},
+		},
+		"one of the storage classes is missing": {
+			CStorClusterConfig: newMultiClassConfig(),
+			Resources: []*unstructured.Unstructured{
+				newStorageClass("premium", types.CSIAttacherNameAWSEBS),
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: mock.CStorClusterConfig,
+				Resources:     mock.Resources,
+			}
+			got := r.validateStorageClass()
+			if mock.isErr && got == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && got != nil {
+				t.Fatalf("Expected no error got [%+v]", got)
+			}
+		})
+	}
+}
+
+func TestReconcilerValidateReclaimPolicy(t *testing.T) {
+	var tests = map[string]struct {
+		policy types.ExternalDiskReclaimPolicy
+		isErr  bool
+	}{
+		"default reclaim policy": {},
+		"retain": {
+			policy: types.ExternalDiskReclaimPolicyRetain,
+		},
+		"delete": {
+			policy: types.ExternalDiskReclaimPolicyDelete,
+		},
+		"unsupported reclaim policy": {
+			policy: "Recycle",
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &types.CStorClusterConfig{
+					Spec: types.CStorClusterConfigSpec{
+						DiskConfig: types.DiskConfig{
+							ExternalDiskConfig: &types.ExternalDiskConfig{
+								ReclaimPolicy: mock.policy,
+							},
+						},
+					},
+				},
+			}
+			got := r.validateReclaimPolicy()
+			if mock.isErr && got == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && got != nil {
+				t.Fatalf("Expected no error got [%+v]", got)
+			}
+			if mock.isErr && errs.TypeOf(got) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", got)
+			}
+		})
+	}
+}
+
+func TestReconcilerValidateZFSProperties(t *testing.T) {
+	var tests = map[string]struct {
+		properties map[string]types.ZFSPropertyValue
+		isErr      bool
+	}{
+		"no zfs properties": {},
+		"whitelisted zfs properties": {
+			properties: map[string]types.ZFSPropertyValue{
+				"compression": "lz4",
+				"recordsize":  "128K",
+				"atime":       "off",
+			},
+		},
+		"unsupported zfs property": {
+			properties: map[string]types.ZFSPropertyValue{
+				"mountpoint": "/data",
+			},
+			isErr: true,
+		},
+		"invalid value of zfs property": {
+			properties: map[string]types.ZFSPropertyValue{
+				"recordsize": "100K",
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &types.CStorClusterConfig{
+					Spec: types.CStorClusterConfigSpec{
+						PoolConfig: types.PoolConfig{
+							ZFSProperties: mock.properties,
+						},
+					},
+				},
+			}
+			got := r.validateZFSProperties()
+			if mock.isErr && got == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && got != nil {
+				t.Fatalf("Expected no error got [%+v]", got)
+			}
+			if mock.isErr && errs.TypeOf(got) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", got)
+			}
+		})
+	}
+}
+
+func TestReconcilerValidateNodeSelectorKey(t *testing.T) {
+	var tests = map[string]struct {
+		key   string
+		isErr bool
+	}{
+		"default node selector key": {},
+		"custom node selector key": {
+			key: "example.com/hostname",
+		},
+		"node pool as node selector key": {
+			key: "cloud.google.com/gke-nodepool",
+		},
+		"invalid node selector key": {
+			key:   "example.com/",
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &types.CStorClusterConfig{
+					Spec: types.CStorClusterConfigSpec{
+						PoolConfig: types.PoolConfig{
+							NodeSelectorKey: mock.key,
+						},
+					},
+				},
+			}
+			got := r.validateNodeSelectorKey()
+			if mock.isErr && got == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && got != nil {
+				t.Fatalf("Expected no error got [%+v]", got)
+			}
+			if mock.isErr && errs.TypeOf(got) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", got)
+			}
+		})
+	}
+}
+
+func TestReconcilerValidateChangeBudget(t *testing.T) {
+	one, negative := int64(1), int64(-1)
+	var tests = map[string]struct {
+		budget *types.ChangeBudget
+		isErr  bool
+	}{
+		"no change budget": {},
+		"empty change budget": {
+			budget: &types.ChangeBudget{},
+		},
+		"valid change budget": {
+			budget: &types.ChangeBudget{
+				MaxAddPerHour:    &one,
+				MaxRemovePerHour: &one,
+			},
+		},
+		"negative max add per hour": {
+			budget: &types.ChangeBudget{MaxAddPerHour: &negative},
+			isErr:  true,
+		},
+		"negative max remove per hour": {
+			budget: &types.ChangeBudget{MaxRemovePerHour: &negative},
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &types.CStorClusterConfig{
+					Spec: types.CStorClusterConfigSpec{
+						PoolConfig: types.PoolConfig{
+							ChangeBudget: mock.budget,
+						},
+					},
+				},
+			}
+			got := r.validateChangeBudget()
+			if mock.isErr && got == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && got != nil {
+				t.Fatalf("Expected no error got [%+v]", got)
+			}
+			if mock.isErr && errs.TypeOf(got) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", got)
+			}
+		})
+	}
+}
+
+func TestReconcilerValidateMinReadyNodesBeforeCreate(t *testing.T) {
+	var tests = map[string]struct {
+		count        int64
+		maxPoolCount int64
+		isErr        bool
+	}{
+		"no min ready nodes": {
+			maxPoolCount: 3,
+		},
+		"min ready nodes within max pool count": {
+			count:        3,
+			maxPoolCount: 3,
+		},
+		"min ready nodes beyond max pool count": {
+			count:        4,
+			maxPoolCount: 3,
+			isErr:        true,
+		},
+		"negative min ready nodes": {
+			count:        -1,
+			maxPoolCount: 3,
+			isErr:        true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &types.CStorClusterConfig{
+					Spec: types.CStorClusterConfigSpec{
+						PoolConfig: types.PoolConfig{
+							MinReadyNodesBeforeCreate: mock.count,
+						},
+					},
+				},
+				maxPoolCount: mock.maxPoolCount,
+			}
+			got := r.validateMinReadyNodesBeforeCreate()
+			if mock.isErr && got == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && got != nil {
+				t.Fatalf("Expected no error got [%+v]", got)
+			}
+			if mock.isErr && errs.TypeOf(got) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", got)
+			}
+		})
+	}
+}
+
 func TestReconcilerSyncClusterConfig(t *testing.T) {
 	var tests = map[string]struct {
 		CStorClusterConfig    *types.CStorClusterConfig
@@ -1220,10 +1677,113 @@ func TestNewReconciler(t *testing.T) {
 	}
 }
 
+func TestNewReconcilerWithAutoPolicy(t *testing.T) {
+	config := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "test",
+				"namespace": "default",
+				"labels": map[string]interface{}{
+					"team": "storage",
+				},
+			},
+			"spec": map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"external": map[string]interface{}{
+						"csiAttacherName":  "pd.csi.storage.gke.io",
+						"storageClassName": "csi-gce-pd",
+					},
+				},
+				"poolConfig": map[string]interface{}{
+					"zfsProperties": map[string]interface{}{
+						"compression": "off",
+					},
+				},
+			},
+		},
+	}
+	newPolicy := func(name string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+				"kind":       string(types.KindCStorPoolAutoPolicy),
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": spec,
+			},
+		}
+	}
+	resources := []*unstructured.Unstructured{
+		newPolicy("org-wide", map[string]interface{}{
+			"raidType": "stripe",
+		}),
+		newPolicy("team", map[string]interface{}{
+			"configSelector": map[string]interface{}{
+				"team": "storage",
+			},
+			"raidType":      "mirror",
+			"reclaimPolicy": "Delete",
+			"zfsProperties": map[string]interface{}{
+				"compression": "lz4",
+				"recordsize":  "64k",
+			},
+		}),
+	}
+	r, err := NewReconciler(config, nil, resources)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	spec := r.ClusterConfig.Spec
+	if spec.PoolConfig.RAIDType != types.PoolRAIDTypeMirror {
+		t.Fatalf("Expected raid type mirror got %q", spec.PoolConfig.RAIDType)
+	}
+	if spec.DiskConfig.ExternalDiskConfig.GetReclaimPolicy() !=
+		types.ExternalDiskReclaimPolicyDelete {
+		t.Fatalf(
+			"Expected reclaim policy Delete got %q",
+			spec.DiskConfig.ExternalDiskConfig.GetReclaimPolicy(),
+		)
+	}
+	err = r.setRAIDTypeIfNotSet()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	got, _, _ := unstructured.NestedMap(r.getDesiredClusterConfig().Object, "spec")
+	expect := map[string]interface{}{
+		"minPoolCount": int64(0),
+		"maxPoolCount": int64(0),
+		"diskConfig": map[string]interface{}{
+			"minCapacity": int64(0),
+			"minCount":    int64(0),
+			"external": map[string]interface{}{
+				"reclaimPolicy": "Delete",
+			},
+		},
+		"poolConfig": map[string]interface{}{
+			"raidType": "mirror",
+			"zfsProperties": map[string]interface{}{
+				"recordsize": "64k",
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("Expected no diff in desired config got\n%s", diff)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(
+		config.Object, "spec", "poolConfig", "raidType",
+	); found {
+		t.Fatalf("Expected observed config to be unchanged")
+	}
+}
+
 func TestReconcilerTestSyncClusterPlan(t *testing.T) {
 	var tests = map[string]struct {
 		ClusterPlan *types.CStorClusterPlan
 		nodePlanFn  func(NodePlannerConfig) ([]types.CStorClusterPlanNode, error)
+		isHybrid    bool
 		isErr       bool
 	}{
 		"ClusterPlan = nil && Planned nodes = nil": {
@@ -1238,6 +1798,13 @@ func TestReconcilerTestSyncClusterPlan(t *testing.T) {
 			},
 			isErr: true,
 		},
+		"Hybrid disk config && Planned nodes = empty": {
+			nodePlanFn: func(conf NodePlannerConfig) ([]types.CStorClusterPlanNode, error) {
+				return []types.CStorClusterPlanNode{}, nil
+			},
+			isHybrid: true,
+			isErr:    false,
+		},
 		"ClusterPlan = nil && Planned nodes count = 1": {
 			nodePlanFn: func(conf NodePlannerConfig) ([]types.CStorClusterPlanNode, error) {
 				return []types.CStorClusterPlanNode{
@@ -1288,6 +1855,9 @@ func TestReconcilerTestSyncClusterPlan(t *testing.T) {
 					planFn: mock.nodePlanFn,
 				},
 			}
+			if mock.isHybrid {
+				r.NodePlanner.DiskSources = &disksource.Resolver{}
+			}
 			got := r.syncClusterPlan()
 			if mock.isErr && got == nil {
 				t.Fatalf("Expected error got none")
@@ -1298,6 +1868,31 @@ func TestReconcilerTestSyncClusterPlan(t *testing.T) {
 		})
 	}
 }
+func TestReconcilerSyncClusterPlanWithInjectedFault(t *testing.T) {
+	err := faultinject.SetRules("plan-computation=fail:100")
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	defer faultinject.SetRules("")
+
+	var isPlanned bool
+	r := &Reconciler{
+		NodePlanner: &NodePlanner{
+			planFn: func(conf NodePlannerConfig) ([]types.CStorClusterPlanNode, error) {
+				isPlanned = true
+				return []types.CStorClusterPlanNode{{Name: "node1"}}, nil
+			},
+		},
+	}
+	err = r.syncClusterPlan()
+	if !errs.IsRetryable(err) {
+		t.Fatalf("Expected retryable error got [%+v]", err)
+	}
+	if isPlanned || len(r.desiredNodes) != 0 {
+		t.Fatalf("Expected no planned nodes got %v", r.desiredNodes)
+	}
+}
+
 func TestReconcilerGetDesiredClusterConfig(t *testing.T) {
 	var tests = map[string]struct {
 		clusterConfig *types.CStorClusterConfig
@@ -1458,13 +2053,43 @@ func TestValidateDiskConfig(t *testing.T) {
 			},
 			isErr: false,
 		},
-		"invalid cstor cluster config - both local & external disk config": {
+		"valid cstor cluster config - hybrid disk config": {
+			CStorClusterConfig: &types.CStorClusterConfig{
+				Spec: types.CStorClusterConfigSpec{
+					DiskConfig: types.DiskConfig{
+						ExternalDiskConfig: &types.ExternalDiskConfig{},
+						LocalDiskConfig:    &types.LocalDiskConfig{},
+						NodeOverrides: []types.DiskNodeOverride{
+							{Source: types.DiskSourceLocal},
+						},
+					},
+				},
+			},
+			isErr: false,
+		},
+		"invalid cstor cluster config - hybrid disk config with per zone cspc": {
 			CStorClusterConfig: &types.CStorClusterConfig{
 				Spec: types.CStorClusterConfigSpec{
 					DiskConfig: types.DiskConfig{
 						ExternalDiskConfig: &types.ExternalDiskConfig{},
 						LocalDiskConfig:    &types.LocalDiskConfig{},
 					},
+					PoolConfig: types.PoolConfig{
+						PerZoneCSPC: true,
+					},
+				},
+			},
+			isErr: true,
+		},
+		"invalid cstor cluster config - node overrides without hybrid disk config": {
+			CStorClusterConfig: &types.CStorClusterConfig{
+				Spec: types.CStorClusterConfigSpec{
+					DiskConfig: types.DiskConfig{
+						ExternalDiskConfig: &types.ExternalDiskConfig{},
+						NodeOverrides: []types.DiskNodeOverride{
+							{Source: types.DiskSourceLocal},
+						},
+					},
 				},
 			},
 			isErr: true,
@@ -1814,3 +2439,40 @@ func TestMetacApplyCStorClusterPlan(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcilerGetDesiredClusterPlanIsStable(t *testing.T) {
+	r := &Reconciler{
+		ClusterConfig: &types.CStorClusterConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "test",
+				UID:       "test-101",
+			},
+		},
+	}
+	desiredNodes := []types.CStorClusterPlanNode{
+		{Name: "node-3", UID: "node-3-uid"},
+		{Name: "node-1", UID: "node-1-uid"},
+		{Name: "node-2", UID: "node-2-uid"},
+	}
+	got := r.getDesiredClusterPlan(desiredNodes)
+	expectNodes := []interface{}{
+		map[string]interface{}{"name": "node-1", "uid": "node-1-uid"},
+		map[string]interface{}{"name": "node-2", "uid": "node-2-uid"},
+		map[string]interface{}{"name": "node-3", "uid": "node-3-uid"},
+	}
+	gotNodes, _, _ := unstructured.NestedSlice(got.Object, "spec", "nodes")
+	if !reflect.DeepEqual(expectNodes, gotNodes) {
+		t.Fatalf("Expected nodes %v got %v", expectNodes, gotNodes)
+	}
+	// reversed order of desired nodes results in the same plan
+	reversed := []types.CStorClusterPlanNode{
+		desiredNodes[2], desiredNodes[1], desiredNodes[0],
+	}
+	if !reflect.DeepEqual(got, r.getDesiredClusterPlan(reversed)) {
+		t.Fatalf("Expected same plan irrespective of the order of nodes")
+	}
+	if desiredNodes[0].Name != "node-3" {
+		t.Fatalf("Expected desired nodes not to be modified")
+	}
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"mayadata.io/cstorpoolauto/common/metadata"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// DefaultRevisionHistoryLimit is the default number of
+// CStorClusterPlanRevision(s) retained per CStorClusterPlan
+const DefaultRevisionHistoryLimit int = 10
+
+// RevisionHistoryLimit is the number of CStorClusterPlanRevision(s)
+// retained per CStorClusterPlan. Older revisions get deleted.
+//
+// NOTE:
+//	A value <= 0 disables recording of revisions
+var RevisionHistoryLimit = DefaultRevisionHistoryLimit
+
+// RevisionPlanner determines the desired CStorClusterPlanRevision(s)
+// based on the observed & desired nodes of CStorClusterPlan
+type RevisionPlanner struct {
+	ClusterConfig *types.CStorClusterConfig
+
+	// Zone is the topology zone of CStorClusterPlan if pools
+	// are planned per zone
+	Zone string
+
+	// ClusterPlanName is the name of CStorClusterPlan. Name is
+	// derived from ClusterConfig & Zone if this is not set.
+	ClusterPlanName string
+
+	// nodes that were planned during previous reconciliations
+	ObservedNodes []types.CStorClusterPlanNode
+
+	// nodes that are planned in the current reconciliation
+	DesiredNodes []types.CStorClusterPlanNode
+
+	// AllNodes & AllowedNodes are used to find the reason
+	// behind removal of a node
+	AllNodes     []*unstructured.Unstructured
+	AllowedNodes []*unstructured.Unstructured
+
+	ObservedRevisions []*unstructured.Unstructured
+	HistoryLimit      int
+
+	// IsAutoscaled is true if desired nodes were planned to
+	// change the pool count as set by the pool autoscaler
+	IsAutoscaled bool
+}
+
+// Plan returns the CStorClusterPlanRevision(s) that should be
+// retained along with a new revision if CStorClusterPlan nodes
+// have changed.
+//
+// NOTE:
+//	Revisions that are not returned get deleted by metac
+func (p *RevisionPlanner) Plan() ([]*unstructured.Unstructured, error) {
+	if p.HistoryLimit <= 0 {
+		return nil, nil
+	}
+	revisions, err := p.getObservedRevisionsOrderedByRevision()
+	if err != nil {
+		return nil, err
+	}
+	changes := p.getChanges()
+	if len(changes) != 0 && !p.isRecorded(revisions) {
+		var latest int64
+		if len(revisions) != 0 {
+			latest = revisions[len(revisions)-1].Spec.Revision
+		}
+		revisions = append(revisions, types.CStorClusterPlanRevision{
+			Spec: types.CStorClusterPlanRevisionSpec{
+				Revision:         latest + 1,
+				ConfigGeneration: p.ClusterConfig.GetGeneration(),
+				Changes:          changes,
+				Nodes:            p.DesiredNodes,
+				Zone:             p.Zone,
+			},
+		})
+	}
+	if len(revisions) > p.HistoryLimit {
+		// retain the most recent ones
+		revisions = revisions[len(revisions)-p.HistoryLimit:]
+	}
+	var desired []*unstructured.Unstructured
+	for _, revision := range revisions {
+		desired = append(desired, p.getDesiredRevision(revision.Spec))
+	}
+	return desired, nil
+}
+
+// getObservedRevisionsOrderedByRevision returns the observed
+// revisions sorted in ascending order of their revision
+func (p *RevisionPlanner) getObservedRevisionsOrderedByRevision() (
+	[]types.CStorClusterPlanRevision, error,
+) {
+	var revisions []types.CStorClusterPlanRevision
+	for _, obj := range p.ObservedRevisions {
+		var revision types.CStorClusterPlanRevision
+		err := unstruct.UnstructToTyped(obj, &revision)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.SliceStable(revisions, func(i, j int) bool {
+		return revisions[i].Spec.Revision < revisions[j].Spec.Revision
+	})
+	return revisions, nil
+}
+
+// isRecorded returns true if the latest revision has already
+// recorded the desired nodes
+//
+// NOTE:
+//	This avoids recording the same change more than once when
+// CStorClusterPlan update is yet to be observed
+func (p *RevisionPlanner) isRecorded(revisions []types.CStorClusterPlanRevision) bool {
+	if len(revisions) == 0 {
+		return false
+	}
+	latest := types.CStorClusterPlanNodeList(revisions[len(revisions)-1].Spec.Nodes)
+	return latest.ContainsAll(p.DesiredNodes)
+}
+
+// getChanges returns the nodes that were removed & added
+// along with the reason
+func (p *RevisionPlanner) getChanges() []types.CStorClusterPlanNodeChange {
+	var changes []types.CStorClusterPlanNodeChange
+	desiredList := types.CStorClusterPlanNodeList(p.DesiredNodes)
+	observedList := types.CStorClusterPlanNodeList(p.ObservedNodes)
+	for _, observed := range p.ObservedNodes {
+		if desiredList.Contains(observed.Name, observed.UID) {
+			continue
+		}
+		reason := p.getRemoveReason(observed)
+		if isReplaced(observed.Name, p.DesiredNodes) {
+			reason = types.PlanRevisionReasonNodeReplaced
+		}
+		changes = append(changes, types.CStorClusterPlanNodeChange{
+			Node:   observed,
+			Action: types.CStorClusterPlanNodeActionRemove,
+			Reason: reason,
+		})
+	}
+	for _, desired := range p.DesiredNodes {
+		if observedList.Contains(desired.Name, desired.UID) {
+			continue
+		}
+		reason := types.PlanRevisionReasonBelowMinPoolCount
+		if p.IsAutoscaled {
+			reason = types.PlanRevisionReasonAutoscaleUp
+		}
+		if len(p.ObservedNodes) == 0 {
+			reason = types.PlanRevisionReasonInitialPlan
+		}
+		if isReplaced(desired.Name, p.ObservedNodes) {
+			reason = types.PlanRevisionReasonNodeReplaced
+		}
+		changes = append(changes, types.CStorClusterPlanNodeChange{
+			Node:   desired,
+			Action: types.CStorClusterPlanNodeActionAdd,
+			Reason: reason,
+		})
+	}
+	return changes
+}
+
+// isReplaced returns true if the given nodes have a node with the
+// given name. This is used to find the nodes that were re-created
+// with a different uid.
+func isReplaced(name string, nodes []types.CStorClusterPlanNode) bool {
+	for _, node := range nodes {
+		if node.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getRemoveReason returns the reason behind removal of the
+// given node from CStorClusterPlan
+func (p *RevisionPlanner) getRemoveReason(planNode types.CStorClusterPlanNode) string {
+	node := NodeList(p.AllNodes).FindByNameAndUID(planNode.Name, planNode.UID)
+	if node == nil {
+		return types.PlanRevisionReasonNodeNotFound
+	}
+	if nodecommon.IsPoolDecommissionRequested(node) {
+		return types.PlanRevisionReasonPoolDecommission
+	}
+	if !NodeList(p.AllowedNodes).Contains(planNode.Name, planNode.UID) {
+		return types.PlanRevisionReasonNodeNotAllowed
+	}
+	if p.IsAutoscaled {
+		return types.PlanRevisionReasonAutoscaleDown
+	}
+	return types.PlanRevisionReasonAboveMaxPoolCount
+}
+
+// getDesiredRevision returns the desired state of
+// CStorClusterPlanRevision based on the given spec
+//
+// NOTE:
+//	The returned instance is idempotent and hence can be used during
+// create & update operations
+func (p *RevisionPlanner) getDesiredRevision(
+	spec types.CStorClusterPlanRevisionSpec,
+) *unstructured.Unstructured {
+	revision := &unstructured.Unstructured{}
+	revision.SetUnstructuredContent(
+		map[string]interface{}{
+			"spec": map[string]interface{}{
+				"revision":         spec.Revision,
+				"configGeneration": spec.ConfigGeneration,
+				"changes":          types.MakeListMapOfPlanNodeChanges(spec.Changes),
+				"nodes":            types.MakeListMapOfPlanNodes(spec.Nodes),
+			},
+		},
+	)
+	if spec.Zone != "" {
+		revision.Object["spec"].(map[string]interface{})["zone"] = spec.Zone
+	}
+	revision.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   string(types.GroupDAOMayaDataIO),
+		Version: string(types.VersionV1Alpha1),
+		Kind:    string(types.KindCStorClusterPlanRevision),
+	})
+	// name is derived from CStorClusterPlan name
+	planName := p.ClusterPlanName
+	if planName == "" {
+		planName = getClusterPlanName(p.ClusterConfig, p.Zone)
+	}
+	revision.SetName(planName + "-" + strconv.FormatInt(spec.Revision, 10))
+	revision.SetNamespace(p.ClusterConfig.GetNamespace())
+	// create annotations that refer to CStorClusterConfig UID
+	revision.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: string(p.ClusterConfig.GetUID()),
+	})
+	// user provided labels & annotations if any
+	metadata.Propagate(revision, p.ClusterConfig.Spec.ChildMetadata)
+
+	return revision
+}
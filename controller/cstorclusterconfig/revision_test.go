@@ -0,0 +1,266 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	autotypes "mayadata.io/cstorpoolauto/types"
+)
+
+func newRevisionTestNode(name, uid string, anns map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(autotypes.KindNode),
+			"metadata": map[string]interface{}{
+				"name":        name,
+				"uid":         uid,
+				"annotations": anns,
+			},
+		},
+	}
+}
+
+func newRevisionTestRevision(revision int64, nodes ...autotypes.CStorClusterPlanNode) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(autotypes.KindCStorClusterPlanRevision),
+			"spec": map[string]interface{}{
+				"revision": revision,
+				"nodes":    autotypes.MakeListMapOfPlanNodes(nodes),
+			},
+		},
+	}
+}
+
+func TestRevisionPlannerPlan(t *testing.T) {
+	n1 := autotypes.CStorClusterPlanNode{Name: "node-1", UID: "n1"}
+	n2 := autotypes.CStorClusterPlanNode{Name: "node-2", UID: "n2"}
+	n3 := autotypes.CStorClusterPlanNode{Name: "node-3", UID: "n3"}
+	var tests = map[string]struct {
+		planner         *RevisionPlanner
+		expectRevisions []int64
+		expectChanges   []interface{}
+	}{
+		"history limit is 0": {
+			planner: &RevisionPlanner{
+				DesiredNodes: []autotypes.CStorClusterPlanNode{n1},
+				HistoryLimit: 0,
+			},
+		},
+		"initial plan": {
+			planner: &RevisionPlanner{
+				DesiredNodes: []autotypes.CStorClusterPlanNode{n1, n2},
+				HistoryLimit: 10,
+			},
+			expectRevisions: []int64{1},
+			expectChanges: []interface{}{
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-1", "uid": "n1"},
+					"action": string(autotypes.CStorClusterPlanNodeActionAdd),
+					"reason": autotypes.PlanRevisionReasonInitialPlan,
+				},
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-2", "uid": "n2"},
+					"action": string(autotypes.CStorClusterPlanNodeActionAdd),
+					"reason": autotypes.PlanRevisionReasonInitialPlan,
+				},
+			},
+		},
+		"no change in nodes": {
+			planner: &RevisionPlanner{
+				ObservedNodes: []autotypes.CStorClusterPlanNode{n1},
+				DesiredNodes:  []autotypes.CStorClusterPlanNode{n1},
+				ObservedRevisions: []*unstructured.Unstructured{
+					newRevisionTestRevision(1, n1),
+				},
+				HistoryLimit: 10,
+			},
+			expectRevisions: []int64{1},
+		},
+		"change is already recorded": {
+			planner: &RevisionPlanner{
+				ObservedNodes: []autotypes.CStorClusterPlanNode{n1},
+				DesiredNodes:  []autotypes.CStorClusterPlanNode{n1, n2},
+				ObservedRevisions: []*unstructured.Unstructured{
+					newRevisionTestRevision(2, n1, n2),
+					newRevisionTestRevision(1, n1),
+				},
+				HistoryLimit: 10,
+			},
+			expectRevisions: []int64{1, 2},
+		},
+		"node is re-created with a new uid": {
+			planner: &RevisionPlanner{
+				ObservedNodes: []autotypes.CStorClusterPlanNode{n1, n2},
+				DesiredNodes: []autotypes.CStorClusterPlanNode{
+					n1, {Name: "node-2", UID: "n2-new"},
+				},
+				AllNodes: []*unstructured.Unstructured{
+					newRevisionTestNode("node-1", "n1", nil),
+					newRevisionTestNode("node-2", "n2-new", nil),
+				},
+				ObservedRevisions: []*unstructured.Unstructured{
+					newRevisionTestRevision(1, n1, n2),
+				},
+				HistoryLimit: 10,
+			},
+			expectRevisions: []int64{1, 2},
+			expectChanges: []interface{}{
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-2", "uid": "n2"},
+					"action": string(autotypes.CStorClusterPlanNodeActionRemove),
+					"reason": autotypes.PlanRevisionReasonNodeReplaced,
+				},
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-2", "uid": "n2-new"},
+					"action": string(autotypes.CStorClusterPlanNodeActionAdd),
+					"reason": autotypes.PlanRevisionReasonNodeReplaced,
+				},
+			},
+		},
+		"node is replaced due to decommission": {
+			planner: &RevisionPlanner{
+				ObservedNodes: []autotypes.CStorClusterPlanNode{n1, n2},
+				DesiredNodes:  []autotypes.CStorClusterPlanNode{n2, n3},
+				AllNodes: []*unstructured.Unstructured{
+					newRevisionTestNode("node-1", "n1", map[string]interface{}{
+						autotypes.AnnKeyNodeDecommissionPool: "true",
+					}),
+					newRevisionTestNode("node-2", "n2", nil),
+					newRevisionTestNode("node-3", "n3", nil),
+				},
+				ObservedRevisions: []*unstructured.Unstructured{
+					newRevisionTestRevision(1, n1, n2),
+				},
+				HistoryLimit: 10,
+			},
+			expectRevisions: []int64{1, 2},
+			expectChanges: []interface{}{
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-1", "uid": "n1"},
+					"action": string(autotypes.CStorClusterPlanNodeActionRemove),
+					"reason": autotypes.PlanRevisionReasonPoolDecommission,
+				},
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-3", "uid": "n3"},
+					"action": string(autotypes.CStorClusterPlanNodeActionAdd),
+					"reason": autotypes.PlanRevisionReasonBelowMinPoolCount,
+				},
+			},
+		},
+		"nodes are removed && older revisions are deleted": {
+			planner: &RevisionPlanner{
+				ObservedNodes: []autotypes.CStorClusterPlanNode{n1, n2, n3},
+				DesiredNodes:  []autotypes.CStorClusterPlanNode{n3},
+				AllNodes: []*unstructured.Unstructured{
+					newRevisionTestNode("node-2", "n2", nil),
+					newRevisionTestNode("node-3", "n3", nil),
+				},
+				AllowedNodes: []*unstructured.Unstructured{
+					newRevisionTestNode("node-3", "n3", nil),
+				},
+				ObservedRevisions: []*unstructured.Unstructured{
+					newRevisionTestRevision(1, n1),
+					newRevisionTestRevision(2, n1, n2),
+					newRevisionTestRevision(3, n1, n2, n3),
+				},
+				HistoryLimit: 2,
+			},
+			expectRevisions: []int64{3, 4},
+			expectChanges: []interface{}{
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-1", "uid": "n1"},
+					"action": string(autotypes.CStorClusterPlanNodeActionRemove),
+					"reason": autotypes.PlanRevisionReasonNodeNotFound,
+				},
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-2", "uid": "n2"},
+					"action": string(autotypes.CStorClusterPlanNodeActionRemove),
+					"reason": autotypes.PlanRevisionReasonNodeNotAllowed,
+				},
+			},
+		},
+		"node is removed due to max pool count": {
+			planner: &RevisionPlanner{
+				ObservedNodes: []autotypes.CStorClusterPlanNode{n1, n2},
+				DesiredNodes:  []autotypes.CStorClusterPlanNode{n1},
+				AllNodes: []*unstructured.Unstructured{
+					newRevisionTestNode("node-1", "n1", nil),
+					newRevisionTestNode("node-2", "n2", nil),
+				},
+				AllowedNodes: []*unstructured.Unstructured{
+					newRevisionTestNode("node-1", "n1", nil),
+					newRevisionTestNode("node-2", "n2", nil),
+				},
+				HistoryLimit: 10,
+			},
+			expectRevisions: []int64{1},
+			expectChanges: []interface{}{
+				map[string]interface{}{
+					"node":   map[string]interface{}{"name": "node-2", "uid": "n2"},
+					"action": string(autotypes.CStorClusterPlanNodeActionRemove),
+					"reason": autotypes.PlanRevisionReasonAboveMaxPoolCount,
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			mock.planner.ClusterConfig = &autotypes.CStorClusterConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "ccc",
+					Namespace:  "openebs",
+					UID:        "ccc-1",
+					Generation: 3,
+				},
+			}
+			got, err := mock.planner.Plan()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			var gotRevisions []int64
+			for _, revision := range got {
+				num, _, _ := unstructured.NestedInt64(revision.Object, "spec", "revision")
+				gotRevisions = append(gotRevisions, num)
+			}
+			if diff := cmp.Diff(mock.expectRevisions, gotRevisions); diff != "" {
+				t.Fatalf("Expected no diff in revisions got\n%s", diff)
+			}
+			if len(mock.expectChanges) == 0 {
+				return
+			}
+			latest := got[len(got)-1]
+			gotChanges, _, _ := unstructured.NestedSlice(latest.Object, "spec", "changes")
+			if diff := cmp.Diff(mock.expectChanges, gotChanges); diff != "" {
+				t.Fatalf("Expected no diff in changes got\n%s", diff)
+			}
+			gotGeneration, _, _ := unstructured.NestedInt64(
+				latest.Object, "spec", "configGeneration",
+			)
+			if gotGeneration != 3 {
+				t.Fatalf("Expected config generation 3 got %d", gotGeneration)
+			}
+		})
+	}
+}
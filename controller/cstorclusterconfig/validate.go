@@ -0,0 +1,209 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+// ValidationFailure is a failed check of a CStorClusterConfig
+type ValidationFailure struct {
+	// Check is the name of the failed check e.g. raid type
+	Check string
+
+	Err error
+}
+
+// Validator runs the validations of a CStorClusterConfig that do
+// not need a cluster e.g. to verify manifests before these are
+// applied
+//
+// NOTE:
+//	Pool counts are verified against the eligible nodes only if
+// Nodes are set. Checks that need the resources of a cluster e.g.
+// StorageClasses are not run.
+type Validator struct {
+	ClusterConfig *unstructured.Unstructured
+
+	// Nodes are the nodes of the target cluster if known
+	Nodes []*unstructured.Unstructured
+
+	reconciler *Reconciler
+	helper     *ccc.Helper
+	failures   []ValidationFailure
+}
+
+// check is a named validation
+type check struct {
+	name string
+	fn   func() error
+}
+
+// run runs the given checks in order till the first failure
+//
+// NOTE:
+//	Checks of a group depend on the values set by their preceding
+// checks. Hence, checks that follow a failed check are not run.
+func (v *Validator) run(checks ...check) bool {
+	for _, c := range checks {
+		err := c.fn()
+		if err != nil {
+			v.failures = append(v.failures, ValidationFailure{Check: c.name, Err: err})
+			return false
+		}
+	}
+	return true
+}
+
+// Validate returns the failed checks of the CStorClusterConfig. No
+// failures are returned if the CStorClusterConfig is valid.
+func (v *Validator) Validate() []ValidationFailure {
+	v.failures = nil
+	r, err := NewReconciler(v.ClusterConfig, nil, v.Nodes)
+	if err != nil {
+		return []ValidationFailure{{Check: "schema", Err: errs.AsValidationError(err)}}
+	}
+	v.reconciler = r
+	v.helper = ccc.NewHelper(v.ClusterConfig)
+
+	v.run(check{"disk config", r.validateDiskConfig})
+	v.run(check{"per zone cspc", r.validatePerZoneCSPC})
+	if r.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig != nil {
+		v.run(
+			check{"external disk config", r.validateExternalDiskConfig},
+			check{"reclaim policy", r.validateReclaimPolicy},
+		)
+	}
+	if r.ClusterConfig.Spec.DiskConfig.LocalDiskConfig != nil {
+		v.validateLocalDiskConfig()
+	}
+	v.run(check{"naming", r.validateNaming})
+	v.run(check{"zfs properties", r.validateZFSProperties})
+	v.run(check{"node selector key", r.validateNodeSelectorKey})
+	v.run(check{"change budget", r.validateChangeBudget})
+	v.run(check{"machine pool", r.validateMachinePool})
+	v.run(check{"ignore fields", r.validateIgnoreFields})
+	v.run(check{"overlays", r.validateOverlays})
+	v.run(check{"drift policy", v.validateDriftPolicy})
+	v.run(check{"output mode", v.validateOutputMode})
+	v.run(check{"node stability window", r.setNodeStabilityWindowIfNotSet})
+	v.run(check{"disk capacity", r.setMinDiskCapacityIfNotSet})
+	v.run(
+		check{"raid type", r.setRAIDTypeIfNotSet},
+		check{"raid type", r.validateRAIDType},
+		check{"raid group config", r.validateRAIDGroupConfig},
+		check{"disk count", r.setMinDiskCountIfNotSet},
+		check{"disk count", r.validateMinDiskCount},
+	)
+	v.validatePoolCounts()
+	// max pool count is set by the pool count checks
+	v.run(check{"min ready nodes", r.validateMinReadyNodesBeforeCreate})
+	return v.failures
+}
+
+// validateLocalDiskConfig verifies the local disk config the way the
+// local device controllers read it
+func (v *Validator) validateLocalDiskConfig() {
+	v.run(check{"block device selector", func() error {
+		_, err := v.helper.GetLocalBlockDeviceSelector()
+		return err
+	}})
+	v.run(check{"explicit device map", func() error {
+		_, err := v.helper.GetLocalExplicitDeviceMap()
+		return err
+	}})
+	v.run(check{"failure domain", func() error {
+		_, err := v.helper.GetLocalFailureDomainKey()
+		return err
+	}})
+	v.run(check{"device preference", func() error {
+		_, err := v.helper.GetDevicePreference()
+		return err
+	}})
+	v.run(check{"wear leveling", func() error {
+		_, err := v.helper.GetWearScorer()
+		return err
+	}})
+	v.run(check{"reserve per node", func() error {
+		_, err := v.helper.GetReservePerNode()
+		return err
+	}})
+	v.run(check{"device capacity", func() error {
+		_, _, err := v.helper.GetLocalDeviceCapacityBounds()
+		return err
+	}})
+	v.run(check{"partial placement policy", func() error {
+		_, err := v.helper.GetPartialPlacementPolicy()
+		return err
+	}})
+}
+
+// validateDriftPolicy verifies if the drift policy is supported
+func (v *Validator) validateDriftPolicy() error {
+	_, err := v.helper.GetDriftPolicy()
+	return err
+}
+
+// validateOutputMode verifies if the output mode is supported
+func (v *Validator) validateOutputMode() error {
+	_, err := v.helper.GetOutputMode()
+	return err
+}
+
+// validatePoolCounts verifies the pool counts & verifies these
+// against the eligible nodes if nodes are known
+//
+// NOTE:
+//	Min pool count defaults to the count of eligible nodes. Hence,
+// this default is verified only if nodes are known.
+func (v *Validator) validatePoolCounts() {
+	r := v.reconciler
+	isNodesKnown := v.Nodes != nil
+	if !isNodesKnown && r.ClusterConfig.Spec.MinPoolCount.Value() == 0 {
+		v.run(check{"pool count", r.setMaxPoolCountIfNotSet})
+		return
+	}
+	v.run(
+		check{"pool count", r.setMinPoolCountIfNotSet},
+		check{"pool count", r.setMaxPoolCountIfNotSet},
+		check{"pool count", func() error {
+			if !isNodesKnown {
+				return nil
+			}
+			eligible, err := r.NodePlanner.GetAllowedNodeCountOrCached()
+			if err != nil {
+				return err
+			}
+			// nodes that use local disks of a hybrid disk config
+			// get pools as well
+			localDiskNodeCount, err := r.NodePlanner.GetLocalDiskNodeCountOrCached()
+			if err != nil {
+				return err
+			}
+			eligible += localDiskNodeCount
+			if r.minPoolCount > eligible {
+				return errs.NotEnoughResourcesErrorf(
+					"MinPoolCount %d exceeds %d eligible nodes", r.minPoolCount, eligible,
+				)
+			}
+			return nil
+		}},
+	)
+}
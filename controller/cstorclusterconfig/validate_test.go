@@ -0,0 +1,283 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestValidatorValidate(t *testing.T) {
+	newConfig := func(spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "dao.mayadata.io/v1alpha1",
+				"kind":       string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      "my-config",
+					"namespace": "openebs",
+				},
+				"spec": spec,
+			},
+		}
+	}
+	newNodes := func(count int) []*unstructured.Unstructured {
+		nodes := []*unstructured.Unstructured{}
+		for i := 0; i < count; i++ {
+			node := &unstructured.Unstructured{}
+			node.SetKind(string(types.KindNode))
+			node.SetName("node-" + string(rune('a'+i)))
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	external := map[string]interface{}{
+		"external": map[string]interface{}{
+			"csiAttacherName":  "pd.csi.storage.gke.io",
+			"storageClassName": "csi-gce-pd",
+		},
+	}
+	var tests = map[string]struct {
+		config       *unstructured.Unstructured
+		nodes        []*unstructured.Unstructured
+		expectChecks []string
+	}{
+		"valid external disk config": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": external,
+			}),
+		},
+		"valid local disk config with nodes": {
+			config: newConfig(map[string]interface{}{
+				"minPoolCount": int64(3),
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{},
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "mirror",
+				},
+			}),
+			nodes: newNodes(3),
+		},
+		"hybrid disk config with nodes": {
+			config: newConfig(map[string]interface{}{
+				"minPoolCount": int64(3),
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{},
+					"external": map[string]interface{}{
+						"csiAttacherName":  "pd.csi.storage.gke.io",
+						"storageClassName": "csi-gce-pd",
+					},
+					"nodeOverrides": []interface{}{
+						map[string]interface{}{
+							"nodeSelector": map[string]interface{}{},
+							"source":       "Local",
+						},
+					},
+				},
+			}),
+			nodes: newNodes(3),
+		},
+		"hybrid disk config with per zone cspc": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{},
+					"external": map[string]interface{}{
+						"csiAttacherName":  "pd.csi.storage.gke.io",
+						"storageClassName": "csi-gce-pd",
+					},
+				},
+				"poolConfig": map[string]interface{}{
+					"perZoneCSPC": true,
+				},
+			}),
+			expectChecks: []string{"disk config"},
+		},
+		"node overrides without hybrid disk config": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{},
+					"nodeOverrides": []interface{}{
+						map[string]interface{}{
+							"nodeSelector": map[string]interface{}{},
+							"source":       "Local",
+						},
+					},
+				},
+			}),
+			expectChecks: []string{"disk config"},
+		},
+		"ignore field of raid groups": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": external,
+				"reconcile": map[string]interface{}{
+					"ignoreFields": []interface{}{
+						"spec.pools[*].poolConfig.resources",
+						"spec.pools[*].raidGroups",
+					},
+				},
+			}),
+			expectChecks: []string{"ignore fields"},
+		},
+		"external disk config without storage class": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"external": map[string]interface{}{
+						"csiAttacherName": "pd.csi.storage.gke.io",
+					},
+				},
+			}),
+			expectChecks: []string{"external disk config"},
+		},
+		"invalid raid type & disk count": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": external,
+				"poolConfig": map[string]interface{}{
+					"raidType": "raid5",
+				},
+			}),
+			// disk count depends on raid type & is not verified
+			expectChecks: []string{"raid type"},
+		},
+		"disk count not a multiple of raid group": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"minCount": int64(3),
+					"external": external["external"],
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "mirror",
+				},
+			}),
+			expectChecks: []string{"disk count"},
+		},
+		"max pool count less than min pool count": {
+			config: newConfig(map[string]interface{}{
+				"minPoolCount": int64(5),
+				"maxPoolCount": int64(3),
+				"diskConfig":   external,
+			}),
+			expectChecks: []string{"pool count"},
+		},
+		"min pool count exceeds eligible nodes": {
+			config: newConfig(map[string]interface{}{
+				"minPoolCount": int64(5),
+				"diskConfig":   external,
+			}),
+			nodes:        newNodes(3),
+			expectChecks: []string{"pool count"},
+		},
+		"no eligible nodes": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": external,
+			}),
+			nodes:        newNodes(0),
+			expectChecks: []string{"pool count"},
+		},
+		"negative change budget": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": external,
+				"poolConfig": map[string]interface{}{
+					"changeBudget": map[string]interface{}{
+						"maxAddPerHour": int64(-1),
+					},
+				},
+			}),
+			expectChecks: []string{"change budget"},
+		},
+		"unsupported output mode": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": external,
+				"outputMode": "Print",
+			}),
+			expectChecks: []string{"output mode"},
+		},
+		"wear leveling without field paths": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local":        map[string]interface{}{},
+					"wearLeveling": map[string]interface{}{},
+				},
+			}),
+			expectChecks: []string{"wear leveling"},
+		},
+		"unsupported partial placement policy": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{},
+				},
+				"poolConfig": map[string]interface{}{
+					"partialPlacementPolicy": "Quorum",
+				},
+			}),
+			expectChecks: []string{"partial placement policy"},
+		},
+		"invalid overlay": {
+			config: newConfig(map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{},
+				},
+				"overlays": []interface{}{
+					map[string]interface{}{
+						"targetKind": "StorageClass",
+						"patch":      "metadata: {}",
+					},
+				},
+			}),
+			expectChecks: []string{"overlays"},
+		},
+		"multiple failures": {
+			config: newConfig(map[string]interface{}{
+				"driftPolicy": "Revert",
+				"diskConfig": map[string]interface{}{
+					"devicePreference": "Random",
+					"local":            map[string]interface{}{},
+				},
+				"poolConfig": map[string]interface{}{
+					"zfsProperties": map[string]interface{}{
+						"mountpoint": "/data",
+					},
+				},
+			}),
+			expectChecks: []string{"device preference", "zfs properties", "drift policy"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			v := &Validator{
+				ClusterConfig: mock.config,
+				Nodes:         mock.nodes,
+			}
+			var gotChecks []string
+			for _, failure := range v.Validate() {
+				if failure.Err == nil {
+					t.Fatalf("Expected error for check %q got none", failure.Check)
+				}
+				gotChecks = append(gotChecks, failure.Check)
+			}
+			if diff := cmp.Diff(mock.expectChecks, gotChecks); diff != "" {
+				t.Fatalf("Expected no diff in failed checks got\n%s", diff)
+			}
+		})
+	}
+}
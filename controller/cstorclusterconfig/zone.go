@@ -0,0 +1,279 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/naming"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/tracing"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// getClusterPlanName returns the name of CStorClusterPlan that
+// is planned for the given config & zone
+//
+// NOTE:
+//	Name of CStorClusterPlan is derived from the name of
+// CStorClusterConfig, its naming options & the zone if pools are
+// planned per zone. CStorPoolCluster gets the name of its
+// CStorClusterPlan.
+func getClusterPlanName(config *types.CStorClusterConfig, zone string) string {
+	return naming.Name(
+		config.GetNamespace(), config.GetName(), config.Spec.Naming, zone,
+	)
+}
+
+// reconcilePerZone runs through the reconciliation logic to plan
+// one CStorClusterPlan per topology zone
+func (r *Reconciler) reconcilePerZone() (ReconcileResponse, error) {
+	syncFns := []func() error{
+		r.syncClusterConfig,
+		r.validateNaming,
+		r.validateStorageClass,
+		r.validateReclaimPolicy,
+		r.validateZFSProperties,
+		r.validateNodeSelectorKey,
+		r.validateChangeBudget,
+		r.validateMinReadyNodesBeforeCreate,
+		r.validateMachinePool,
+		r.validateIgnoreFields,
+		r.validateClusterPlans,
+		r.syncZonedClusterPlans,
+		r.syncRejectedNodes,
+	}
+	for _, syncFn := range syncFns {
+		// remaining steps are not run past the deadline of this sync
+		err := errs.CheckContext(r.Context)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+		end := tracing.StartPhase(r.Context, syncFn)
+		err = syncFn()
+		end()
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+	}
+	return ReconcileResponse{
+		CStorClusterConfig: r.getDesiredClusterConfig(),
+		CStorClusterPlans:  r.desiredPlans,
+
+		CStorClusterPlanRevisions: r.desiredRevisions,
+
+		ChangeBudgetState: r.getDesiredChangeBudgetState(),
+		RejectedNodes:     r.rejectedNodes,
+		ResyncAfter:       r.resyncAfter,
+	}, nil
+}
+
+// validateClusterPlans verifies if the observed CStorClusterPlan(s)
+// were planned the same way i.e. per zone or not as is desired now
+//
+// NOTE:
+//	Toggling perZoneCSPC would otherwise delete the observed plans
+// & their CStorPoolCluster(s). Observed plans need to be deleted
+// explicitly before toggling.
+func (r *Reconciler) validateClusterPlans() error {
+	isPerZone := r.ClusterConfig.Spec.PoolConfig.PerZoneCSPC
+	for _, res := range r.Resources {
+		if res == nil || res.GetKind() != string(types.KindCStorClusterPlan) {
+			continue
+		}
+		uid, _ := unstruct.GetValueForKey(
+			res.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+		)
+		if string(r.ClusterConfig.GetUID()) != uid {
+			continue
+		}
+		zone, _, _ := unstructured.NestedString(res.Object, "spec", "zone")
+		if isPerZone == (zone != "") {
+			continue
+		}
+		return errs.ValidationErrorf(
+			"Can't set perZoneCSPC to %t: CStorClusterPlan %q has zone %q",
+			isPerZone, res.GetName(), zone,
+		)
+	}
+	return nil
+}
+
+// getObservedZonedClusterPlans returns the observed CStorClusterPlan(s)
+// of this config mapped by their zones
+func (r *Reconciler) getObservedZonedClusterPlans() (
+	map[string]*types.CStorClusterPlan, error,
+) {
+	plans := map[string]*types.CStorClusterPlan{}
+	for _, res := range r.Resources {
+		if res == nil || res.GetKind() != string(types.KindCStorClusterPlan) {
+			continue
+		}
+		uid, _ := unstruct.GetValueForKey(
+			res.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+		)
+		if string(r.ClusterConfig.GetUID()) != uid {
+			continue
+		}
+		var plan types.CStorClusterPlan
+		err := unstruct.UnstructToTyped(res, &plan)
+		if err != nil {
+			return nil, err
+		}
+		if plan.Spec.Zone == "" {
+			continue
+		}
+		plans[plan.Spec.Zone] = &plan
+	}
+	return plans, nil
+}
+
+// getZones returns the zones of allowed nodes as well as of the
+// observed plans in a sorted order
+//
+// NOTE:
+//	Allowed nodes without zone labels are not planned
+func (r *Reconciler) getZones(
+	observedPlans map[string]*types.CStorClusterPlan,
+) ([]string, error) {
+	allowedNodes, err := r.NodePlanner.GetAllowedNodesOrCached()
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]bool{}
+	for _, node := range allowedNodes {
+		zone := nodecommon.GetZone(node)
+		if zone != "" {
+			found[zone] = true
+		}
+	}
+	for zone := range observedPlans {
+		found[zone] = true
+	}
+	var zones []string
+	for zone := range found {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones, nil
+}
+
+// newZoneReconciler returns a new instance of Reconciler that plans
+// the CStorClusterPlan of the given zone
+//
+// NOTE:
+//	This should be invoked only after CStorClusterConfig is set
+// with defaults
+func (r *Reconciler) newZoneReconciler(
+	zone string, observedPlan *types.CStorClusterPlan,
+) *Reconciler {
+	return &Reconciler{
+		ClusterConfig: r.ClusterConfig,
+		ClusterPlan:   observedPlan,
+		Resources:     r.Resources,
+		NodePlanner: &NodePlanner{
+			NodeSelector: r.NodePlanner.NodeSelector,
+			MachinePool:  r.NodePlanner.MachinePool,
+			Resources:    r.Resources,
+			Zone:         zone,
+			Now:          r.NodePlanner.Now,
+			Context:      r.NodePlanner.Context,
+
+			AllowControlPlaneNodes: r.NodePlanner.AllowControlPlaneNodes,
+			PlannedNodeNames:       getPlannedNodeNames(observedPlan),
+		},
+		Zone:                 zone,
+		minPoolCount:         r.minPoolCount,
+		maxPoolCount:         r.maxPoolCount,
+		poolRAIDType:         r.poolRAIDType,
+		minDiskCount:         r.minDiskCount,
+		minDiskCapacity:      r.minDiskCapacity,
+		nodeStabilityWindow:  r.nodeStabilityWindow,
+		revisionHistoryLimit: r.revisionHistoryLimit,
+		changeBudgetState:    r.changeBudgetState,
+	}
+}
+
+// syncZonedClusterPlans plans one CStorClusterPlan along with its
+// revisions per zone
+func (r *Reconciler) syncZonedClusterPlans() error {
+	observedPlans, err := r.getObservedZonedClusterPlans()
+	if err != nil {
+		return err
+	}
+	zones, err := r.getZones(observedPlans)
+	if err != nil {
+		return err
+	}
+	if len(zones) == 0 {
+		return errs.NotEnoughResourcesErrorf(
+			"Can't plan per zone: No allowed nodes with label %q", types.LblKeyZone,
+		)
+	}
+	for _, zone := range zones {
+		zr := r.newZoneReconciler(zone, observedPlans[zone])
+		err := zr.syncZonedClusterPlan()
+		if err != nil {
+			return errors.Wrapf(err, "Can't plan zone %q", zone)
+		}
+		r.desiredPlans = append(r.desiredPlans, zr.getDesiredClusterPlan(zr.desiredNodes))
+		r.desiredRevisions = append(r.desiredRevisions, zr.desiredRevisions...)
+		if zr.resyncAfter > 0 && (r.resyncAfter == 0 || zr.resyncAfter < r.resyncAfter) {
+			// resync as soon as the budget of any zone is available
+			r.resyncAfter = zr.resyncAfter
+		}
+	}
+	return nil
+}
+
+// syncZonedClusterPlan plans the CStorClusterPlan of this zone
+//
+// NOTE:
+//	Pool counts are capped to the number of allowed nodes in this
+// zone. Observed plan is retained as is if this zone does not have
+// any allowed nodes. This avoids deleting its CStorPoolCluster.
+func (r *Reconciler) syncZonedClusterPlan() error {
+	allowedNodeCount, err := r.NodePlanner.GetAllowedNodeCountOrCached()
+	if err != nil {
+		return err
+	}
+	if allowedNodeCount == 0 {
+		if r.ClusterPlan != nil {
+			r.desiredNodes = r.ClusterPlan.Spec.Nodes
+		}
+		return r.syncClusterPlanRevisions()
+	}
+	if allowedNodeCount < r.minPoolCount {
+		r.minPoolCount = allowedNodeCount
+	}
+	syncFns := []func() error{
+		r.syncClusterPlan,
+		r.syncClusterPlanRevisions,
+	}
+	for _, syncFn := range syncFns {
+		err = syncFn()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterconfig
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	autotypes "mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+func newZoneTestNode(name, zone string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(autotypes.KindNode),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"uid":  name + "-uid",
+			},
+		},
+	}
+	if zone != "" {
+		obj.SetLabels(map[string]string{autotypes.LblKeyZone: zone})
+	}
+	return obj
+}
+
+func newZoneTestPlan(configUID, name, zone string, nodes ...string) *unstructured.Unstructured {
+	var planNodes []autotypes.CStorClusterPlanNode
+	for _, node := range nodes {
+		planNodes = append(planNodes, autotypes.CStorClusterPlanNode{
+			Name: node,
+			UID:  k8stypes.UID(node + "-uid"),
+		})
+	}
+	spec := map[string]interface{}{
+		"nodes": autotypes.MakeListMapOfPlanNodes(planNodes),
+	}
+	if zone != "" {
+		spec["zone"] = zone
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(autotypes.KindCStorClusterPlan),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"annotations": map[string]interface{}{
+					autotypes.AnnKeyCStorClusterConfigUID: configUID,
+				},
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestGetClusterPlanName(t *testing.T) {
+	config := &autotypes.CStorClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config"},
+	}
+	var tests = map[string]struct {
+		zone   string
+		naming *autotypes.Naming
+		expect string
+	}{
+		"no zone": {
+			expect: "my-config",
+		},
+		"zone": {
+			zone:   "zone-a",
+			expect: "my-config-zone-a",
+		},
+		"zone && naming": {
+			zone:   "zone-a",
+			naming: &autotypes.Naming{Prefix: "team", Suffix: "pool"},
+			expect: "team-my-config-zone-a-pool",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			config := config.DeepCopy()
+			config.Spec.Naming = mock.naming
+			got := getClusterPlanName(config, mock.zone)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestReconcilerValidateClusterPlans(t *testing.T) {
+	var tests = map[string]struct {
+		isPerZone bool
+		resources []*unstructured.Unstructured
+		isErr     bool
+	}{
+		"no plans": {},
+		"not per zone && plan without zone": {
+			resources: []*unstructured.Unstructured{
+				newZoneTestPlan("config-101", "my-config", ""),
+			},
+		},
+		"per zone && plan with zone": {
+			isPerZone: true,
+			resources: []*unstructured.Unstructured{
+				newZoneTestPlan("config-101", "my-config-zone-a", "zone-a"),
+			},
+		},
+		"per zone && plan without zone": {
+			isPerZone: true,
+			resources: []*unstructured.Unstructured{
+				newZoneTestPlan("config-101", "my-config", ""),
+			},
+			isErr: true,
+		},
+		"not per zone && plan with zone": {
+			resources: []*unstructured.Unstructured{
+				newZoneTestPlan("config-101", "my-config-zone-a", "zone-a"),
+			},
+			isErr: true,
+		},
+		"per zone && plan without zone of other config": {
+			isPerZone: true,
+			resources: []*unstructured.Unstructured{
+				newZoneTestPlan("config-201", "other-config", ""),
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &autotypes.CStorClusterConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-config",
+						UID:  "config-101",
+					},
+					Spec: autotypes.CStorClusterConfigSpec{
+						PoolConfig: autotypes.PoolConfig{
+							PerZoneCSPC: mock.isPerZone,
+						},
+					},
+				},
+				Resources: mock.resources,
+			}
+			err := r.validateClusterPlans()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestReconcilerSyncZonedClusterPlans(t *testing.T) {
+	var tests = map[string]struct {
+		resources       []*unstructured.Unstructured
+		minPoolCount    int64
+		maxPoolCount    int64
+		historyLimit    int
+		expectPlans     map[string][]string
+		expectRevisions []string
+		isErr           bool
+	}{
+		"no nodes with zone": {
+			resources: []*unstructured.Unstructured{
+				newZoneTestNode("node-1", ""),
+			},
+			minPoolCount: 1,
+			maxPoolCount: 3,
+			isErr:        true,
+		},
+		"initial plan across 2 zones": {
+			resources: []*unstructured.Unstructured{
+				newZoneTestNode("node-1", "zone-a"),
+				newZoneTestNode("node-2", "zone-a"),
+				newZoneTestNode("node-3", "zone-a"),
+				newZoneTestNode("node-4", "zone-b"),
+				newZoneTestNode("node-5", ""),
+			},
+			minPoolCount: 2,
+			maxPoolCount: 4,
+			historyLimit: 10,
+			expectPlans: map[string][]string{
+				"my-config-zone-a": []string{"node-1", "node-2"},
+				"my-config-zone-b": []string{"node-4"},
+			},
+			expectRevisions: []string{"my-config-zone-a-1", "my-config-zone-b-1"},
+		},
+		"observed plans are retained": {
+			resources: []*unstructured.Unstructured{
+				newZoneTestNode("node-1", "zone-a"),
+				newZoneTestNode("node-2", "zone-a"),
+				newZoneTestPlan("config-101", "my-config-zone-a", "zone-a", "node-2"),
+				newZoneTestPlan("config-101", "my-config-zone-b", "zone-b", "node-4"),
+			},
+			minPoolCount: 1,
+			maxPoolCount: 3,
+			expectPlans: map[string][]string{
+				"my-config-zone-a": []string{"node-2"},
+				"my-config-zone-b": []string{"node-4"},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ClusterConfig: &autotypes.CStorClusterConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "my-config",
+						UID:  "config-101",
+					},
+				},
+				Resources: mock.resources,
+				NodePlanner: &NodePlanner{
+					Resources: mock.resources,
+				},
+				minPoolCount:         mock.minPoolCount,
+				maxPoolCount:         mock.maxPoolCount,
+				revisionHistoryLimit: mock.historyLimit,
+			}
+			err := r.syncZonedClusterPlans()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotPlans = map[string][]string{}
+			for _, plan := range r.desiredPlans {
+				var typed autotypes.CStorClusterPlan
+				err := unstruct.UnstructToTyped(plan, &typed)
+				if err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+				if getClusterPlanName(r.ClusterConfig, typed.Spec.Zone) != plan.GetName() {
+					t.Fatalf("Expected plan %q to have zone got %q", plan.GetName(), typed.Spec.Zone)
+				}
+				for _, node := range typed.Spec.Nodes {
+					gotPlans[plan.GetName()] = append(gotPlans[plan.GetName()], node.Name)
+				}
+			}
+			if diff := cmp.Diff(mock.expectPlans, gotPlans); diff != "" {
+				t.Fatalf("Expected no diff in plans got \n%s", diff)
+			}
+			var gotRevisions []string
+			for _, revision := range r.desiredRevisions {
+				gotRevisions = append(gotRevisions, revision.GetName())
+			}
+			if diff := cmp.Diff(mock.expectRevisions, gotRevisions); diff != "" {
+				t.Fatalf("Expected no diff in revisions got \n%s", diff)
+			}
+		})
+	}
+}
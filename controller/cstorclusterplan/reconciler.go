@@ -17,17 +17,32 @@ limitations under the License.
 package cstorclusterplan
 
 import (
+	"sort"
+
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
 	"openebs.io/metac/controller/generic"
 
+	"mayadata.io/cstorpoolauto/common/adoption"
+	"mayadata.io/cstorpoolauto/common/generation"
 	"mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/metadata"
+	"mayadata.io/cstorpoolauto/common/migration"
+	"mayadata.io/cstorpoolauto/common/overlay"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 )
 
+// controllerName is reported against the skipped reconciliations
+// of CStorClusterPlan
+const controllerName = "CStorClusterPlan"
+
 type reconcileErrHandler struct {
 	clusterPlan  *unstructured.Unstructured
 	hookResponse *generic.SyncHookResponse
@@ -44,11 +59,7 @@ func (h *reconcileErrHandler) handle(err error) {
 		h.clusterPlan.GetNamespace(), h.clusterPlan.GetName(), err,
 	)
 
-	conds, mergeErr :=
-		unstruct.MergeStatusConditions(
-			h.clusterPlan,
-			types.MakeCStorClusterPlanReconcileErrCond(err),
-		)
+	conds, mergeErr := conditions.MergeCondition(h.clusterPlan, types.MakeCStorClusterPlanReconcileErrCond(err))
 	if mergeErr != nil {
 		glog.Errorf(
 			"Failed to reconcile CStorClusterPlan %s %s: Can't set status conditions: %+v",
@@ -66,9 +77,15 @@ func (h *reconcileErrHandler) handle(err error) {
 		h.hookResponse.Status = map[string]interface{}{}
 		h.hookResponse.Status["phase"] = types.CStorClusterPlanStatusPhaseError
 		h.hookResponse.Status["conditions"] = conds
+		h.hookResponse.Status =
+			generation.SetObservedGeneration(h.clusterPlan, h.hookResponse.Status)
 	}
 	// this will stop further reconciliation by metac since there was an error
 	h.hookResponse.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(err) {
+		h.hookResponse.ResyncAfterSeconds = errs.ResyncAfterSeconds(err)
+	}
 }
 
 // Sync implements the idempotent logic to reconcile CStorClusterPlan
@@ -107,6 +124,11 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 		hookResponse: response,
 	}
 
+	// resources annotated by an older release are adopted before
+	// these are matched against the watch
+	adoption.Adopt(request.Watch, adoption.LegacyKeys)
+	adoption.AdoptAll(request.Attachments.List(), adoption.LegacyKeys)
+
 	var observedStorageSets []*unstructured.Unstructured
 	var cstorClusterConfig *unstructured.Unstructured
 	var desiredCStorClusterConfigUID string
@@ -139,7 +161,16 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 		response.Attachments = append(response.Attachments, attachment)
 	}
 	if cstorClusterConfig == nil {
-		errHandler.handle(errors.Errorf("Missing CStorClusterConfig attachment"))
+		// config may not be observed yet
+		errHandler.handle(errs.TransientErrorf("Missing CStorClusterConfig attachment"))
+		return nil
+	}
+	isPaused, err := pause.Skip(controllerName, cstorClusterConfig, request.Watch, response)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if isPaused {
 		return nil
 	}
 
@@ -153,7 +184,14 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 		errHandler.handle(err)
 		return nil
 	}
-	response.Attachments = append(response.Attachments, op.DesiredStorageSets...)
+	// storage sets generated by an older release are migrated to
+	// the current schema
+	desiredStorageSets, err := migration.MigrateAll(observedStorageSets, op.DesiredStorageSets)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	response.Attachments = append(response.Attachments, desiredStorageSets...)
 
 	// TODO (@amitkumardas):
 	// Can't set status as this creates a never ending hot loop
@@ -233,6 +271,11 @@ func (r *Reconciler) Reconcile() (*ReconcileResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	// user provided patches if any
+	err = overlay.ApplyAll(desiredStorageSets, r.ClusterConfig.Spec.Overlays)
+	if err != nil {
+		return nil, err
+	}
 	return &ReconcileResponse{
 		DesiredStorageSets: desiredStorageSets,
 		Status: types.MakeCStorClusterPlanToOnlineWithNoReconcileErr(
@@ -260,8 +303,14 @@ type StorageSetListPlanner struct {
 	IsNodeRemove map[string]bool // map of nodes that are no more needed
 	IsNodeNoop   map[string]bool // map of nodes with no change i.e. nodes already in-use
 
-	PlannedNodeNames map[string]string // map of desired node names
-	NodeUpdates      map[string]string // map of not needed to newly desired nodes
+	PlannedNodeNames  map[string]string // map of desired node names
+	ObservedNodeNames map[string]string // map of observed node names
+	NodeUpdates       map[string]string // map of not needed to newly desired nodes
+	IsNodeReplaced    map[string]bool   // map of nodes that were re-created with a new UID
+
+	// block device exclude terms that are passed on to every
+	// StorageSet as is
+	DesiredBlockDeviceExclude map[string]interface{}
 }
 
 // NewStorageSetsPlanner returns a new instance of
@@ -288,7 +337,23 @@ func NewStorageSetsPlanner(
 		IsNodeRemove:           map[string]bool{},
 		IsNodeNoop:             map[string]bool{},
 		PlannedNodeNames:       map[string]string{},
+		ObservedNodeNames:      map[string]string{},
 		NodeUpdates:            map[string]string{},
+		IsNodeReplaced:         map[string]bool{},
+	}
+	localDiskConfig := clusterConfig.Spec.DiskConfig.LocalDiskConfig
+	if localDiskConfig != nil && localDiskConfig.BlockDeviceExclude != nil {
+		exclude, err := runtime.DefaultUnstructuredConverter.ToUnstructured(
+			localDiskConfig.BlockDeviceExclude,
+		)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err,
+				"Failed to convert blockDeviceExclude: CStorClusterConfig %s %s",
+				clusterConfig.GetNamespace(), clusterConfig.GetName(),
+			)
+		}
+		planner.DesiredBlockDeviceExclude = exclude
 	}
 	// logic to categorise storage sets indexed by their node UID
 	for _, storageSet := range observedStorageSets {
@@ -303,13 +368,24 @@ func NewStorageSetsPlanner(
 			)
 		}
 		if !found || nodeUID == "" {
-			return nil, errors.Errorf(
+			return nil, errs.ValidationErrorf(
 				"Invalid StorageSet %s %s: Missing spec.node.uid",
 				storageSet.GetNamespace(), storageSet.GetName(),
 			)
 		}
+		nodeName, _, err := unstructured.NestedString(
+			storageSet.UnstructuredContent(), "spec", "node", "name",
+		)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err,
+				"Failed to get spec.node.name: StorageSet %s %s",
+				storageSet.GetNamespace(), storageSet.GetName(),
+			)
+		}
 		planner.ObservedStorageSets[nodeUID] = true
 		planner.ObservedStorageSetObjs[nodeUID] = storageSet
+		planner.ObservedNodeNames[nodeUID] = nodeName
 	}
 	// logic to create new categories based on changes w.r.t node UID
 	for _, plannedNode := range clusterPlan.Spec.Nodes {
@@ -337,6 +413,7 @@ func NewStorageSetsPlanner(
 		}
 		planner.IsNodeRemove[observedNodeUID] = true
 	}
+	planner.planNodeReplacements()
 	// build update inventory i.e. move observed storageset's
 	// from old to a newly desired node based on create & remove
 	// inventories
@@ -366,6 +443,57 @@ func NewStorageSetsPlanner(
 	return planner, nil
 }
 
+// planNodeReplacements moves the observed storagesets of the nodes
+// that were re-created to the new instances of these nodes
+//
+// NOTE:
+//	A node is considered re-created if a node with the same name
+// but a different UID is newly desired. These are paired before
+// other nodes to attach the disks of this node to its new instance
+// rather than an arbitrary newly desired node. UIDs are sorted to
+// make this pairing deterministic.
+//
+// NOTE:
+//	Observed storageset is updated in place. Hence, its name,
+// annotations & Storage(s) are retained by the new node instance.
+func (p *StorageSetListPlanner) planNodeReplacements() {
+	createNodeUIDs := sortedKeys(p.IsNodeCreate)
+	for _, removeNodeUID := range sortedKeys(p.IsNodeRemove) {
+		name := p.ObservedNodeNames[removeNodeUID]
+		if name == "" {
+			continue
+		}
+		for _, createNodeUID := range createNodeUIDs {
+			if !p.IsNodeCreate[createNodeUID] ||
+				p.PlannedNodeNames[createNodeUID] != name {
+				continue
+			}
+			glog.V(2).Infof(
+				"Node %s was re-created: Will move CStorClusterStorageSet from node uid %s to %s",
+				name, removeNodeUID, createNodeUID,
+			)
+			p.NodeUpdates[removeNodeUID] = createNodeUID
+			p.IsNodeReplaced[removeNodeUID] = true
+			p.IsNodeRemove[removeNodeUID] = false
+			p.IsNodeCreate[createNodeUID] = false
+			break
+		}
+	}
+}
+
+// sortedKeys returns the keys of the given map that are set to true
+// in sorted order
+func sortedKeys(given map[string]bool) []string {
+	var keys []string
+	for key, val := range given {
+		if val {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Plan provides the list of desired StorageSets
 func (p *StorageSetListPlanner) Plan() ([]*unstructured.Unstructured, error) {
 	var finalStorageSets []*unstructured.Unstructured
@@ -491,6 +619,11 @@ func (p *StorageSetListPlanner) updateNode() ([]*unstructured.Unstructured, erro
 func (p *StorageSetListPlanner) getDesiredStorageSet(
 	storageSetName string, nodeUID string,
 ) *unstructured.Unstructured {
+	// Storage(s) of this storage set are created in this namespace
+	targetNamespace := p.ClusterConfig.Spec.TargetNamespace
+	if targetNamespace == "" {
+		targetNamespace = types.DefaultTargetNamespace
+	}
 	storageSet := &unstructured.Unstructured{}
 	storageSet.SetUnstructuredContent(map[string]interface{}{
 		"metadata": map[string]interface{}{
@@ -498,6 +631,7 @@ func (p *StorageSetListPlanner) getDesiredStorageSet(
 			"namespace": p.ClusterPlan.GetNamespace(),
 		},
 		"spec": map[string]interface{}{
+			"targetNamespace": targetNamespace,
 			"node": map[string]interface{}{
 				"name": p.PlannedNodeNames[nodeUID],
 				"uid":  nodeUID,
@@ -512,13 +646,80 @@ func (p *StorageSetListPlanner) getDesiredStorageSet(
 			},
 		},
 	})
+	if p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.StorageNamespace != "" {
+		unstructured.SetNestedField(
+			storageSet.Object,
+			p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.StorageNamespace,
+			"spec", "externalDiskConfig", "storageNamespace",
+		)
+	}
+	if len(p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.StorageLabels) != 0 {
+		// labels are passed on to let StorageSet controller set
+		// these against the Storage(s)
+		unstructured.SetNestedStringMap(
+			storageSet.Object,
+			p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.StorageLabels,
+			"spec", "externalDiskConfig", "storageLabels",
+		)
+	}
+	if len(p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.StorageClasses) != 0 {
+		// disks are provisioned from all of these storage classes
+		unstructured.SetNestedSlice(
+			storageSet.Object,
+			types.MakeListMapOfExternalStorageClasses(
+				p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.StorageClasses,
+			),
+			"spec", "externalDiskConfig", "storageClasses",
+		)
+	}
+	if len(p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.Parameters) != 0 {
+		// parameters are passed on to let StorageSet controller
+		// tune the Storage(s)
+		unstructured.SetNestedStringMap(
+			storageSet.Object,
+			p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.Parameters,
+			"spec", "externalDiskConfig", "parameters",
+		)
+	}
+	if p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.ReclaimPolicy != "" {
+		// reclaim policy is passed on to let StorageSet controller
+		// handle the PVCs once this storage set is deleted
+		unstructured.SetNestedField(
+			storageSet.Object,
+			string(p.ClusterConfig.Spec.DiskConfig.ExternalDiskConfig.ReclaimPolicy),
+			"spec", "externalDiskConfig", "reclaimPolicy",
+		)
+	}
+	if p.ClusterConfig.Spec.ChildMetadata != nil {
+		// child metadata is passed on to let StorageSet controller
+		// propagate the same to Storage(s)
+		unstructured.SetNestedField(
+			storageSet.Object,
+			types.MakeMapOfChildMetadata(p.ClusterConfig.Spec.ChildMetadata),
+			"spec", "childMetadata",
+		)
+	}
+	if p.DesiredBlockDeviceExclude != nil {
+		// exclude terms are passed on to let BlockDevice controller
+		// skip these devices while associating them with Storage(s)
+		unstructured.SetNestedField(
+			storageSet.Object,
+			runtime.DeepCopyJSON(p.DesiredBlockDeviceExclude),
+			"spec", "blockDeviceExclude",
+		)
+	}
 	// create annotations that refers to the instance which
 	// triggered creation of this storage set i.e. CStorClusterPlan
 	storageSet.SetAnnotations(
 		map[string]string{
 			types.AnnKeyCStorClusterPlanUID: string(p.ClusterPlan.GetUID()),
+			// config UID lets the controllers of storage set find
+			// the config & its pause annotation
+			types.AnnKeyCStorClusterConfigUID: string(p.ClusterConfig.GetUID()),
 		},
 	)
+	// user provided labels & annotations if any
+	metadata.Propagate(storageSet, p.ClusterConfig.Spec.ChildMetadata)
 	// below is the right way to set APIVersion & Kind
 	storageSet.SetAPIVersion(string(types.APIVersionDAOMayaDataV1Alpha1))
 	storageSet.SetKind(string(types.KindCStorClusterStorageSet))
@@ -0,0 +1,107 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterplan
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestNewStorageSetsPlannerWithRecreatedNodes(t *testing.T) {
+	newStorageSet := func(name, nodeName, nodeUID string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{
+					"node": map[string]interface{}{
+						"name": nodeName,
+						"uid":  nodeUID,
+					},
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		plannedNodes        []types.CStorClusterPlanNode
+		observedStorageSets []*unstructured.Unstructured
+		expectUpdates       map[string]string
+		expectReplaced      map[string]bool
+	}{
+		"re-created node is paired with its storage set": {
+			plannedNodes: []types.CStorClusterPlanNode{
+				{Name: "node-1", UID: "uid-1"},
+				{Name: "node-3", UID: "uid-3"},
+				{Name: "node-2", UID: "uid-2-new"},
+			},
+			observedStorageSets: []*unstructured.Unstructured{
+				newStorageSet("plan-uid-1", "node-1", "uid-1"),
+				newStorageSet("plan-uid-2", "node-2", "uid-2"),
+				newStorageSet("plan-uid-4", "node-4", "uid-4"),
+			},
+			expectUpdates: map[string]string{
+				"uid-2": "uid-2-new",
+				"uid-4": "uid-3",
+			},
+			expectReplaced: map[string]bool{
+				"uid-2": true,
+			},
+		},
+		"no re-created nodes": {
+			plannedNodes: []types.CStorClusterPlanNode{
+				{Name: "node-1", UID: "uid-1"},
+				{Name: "node-3", UID: "uid-3"},
+			},
+			observedStorageSets: []*unstructured.Unstructured{
+				newStorageSet("plan-uid-1", "node-1", "uid-1"),
+				newStorageSet("plan-uid-2", "node-2", "uid-2"),
+			},
+			expectUpdates: map[string]string{
+				"uid-2": "uid-3",
+			},
+			expectReplaced: map[string]bool{},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			plan := &types.CStorClusterPlan{
+				Spec: types.CStorClusterPlanSpec{
+					Nodes: mock.plannedNodes,
+				},
+			}
+			planner, err := NewStorageSetsPlanner(
+				plan, &types.CStorClusterConfig{}, mock.observedStorageSets,
+			)
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if diff := cmp.Diff(mock.expectUpdates, planner.NodeUpdates); diff != "" {
+				t.Fatalf("Node updates mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectReplaced, planner.IsNodeReplaced); diff != "" {
+				t.Fatalf("Replaced nodes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
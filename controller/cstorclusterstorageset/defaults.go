@@ -0,0 +1,72 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// StorageNamespace is the namespace where Storages are created if
+// the config does not specify one. Target namespace of the storage
+// set is used if this is empty. This is set via --storage-namespace
+// flag.
+var StorageNamespace string
+
+// StorageLabels are set against every Storage. Labels specified in
+// the config take precedence over these. This is set via
+// --storage-labels flag.
+var StorageLabels map[string]string
+
+// SetStorageNamespace sets the default namespace of Storages
+func SetStorageNamespace(namespace string) {
+	StorageNamespace = strings.TrimSpace(namespace)
+}
+
+// SetStorageLabels sets the default labels of Storages from the
+// given comma separated key=value pairs
+func SetStorageLabels(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		StorageLabels = nil
+		return nil
+	}
+	lbls, err := labels.ConvertSelectorToLabelsMap(value)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid storage labels %q", value)
+	}
+	StorageLabels = lbls
+	return nil
+}
+
+// mergeStorageLabels returns the default labels merged with the
+// given labels. Given labels win in case of same keys.
+func mergeStorageLabels(given map[string]string) map[string]string {
+	if len(StorageLabels) == 0 && len(given) == 0 {
+		return nil
+	}
+	merged := map[string]string{}
+	for key, value := range StorageLabels {
+		merged[key] = value
+	}
+	for key, value := range given {
+		merged[key] = value
+	}
+	return merged
+}
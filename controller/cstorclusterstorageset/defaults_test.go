@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestSetStorageLabels(t *testing.T) {
+	var tests = map[string]struct {
+		value        string
+		expectLabels map[string]string
+		isErr        bool
+	}{
+		"empty": {},
+		"single label": {
+			value:        "team=storage",
+			expectLabels: map[string]string{"team": "storage"},
+		},
+		"multiple labels with spaces": {
+			value: " team=storage, env=prod ",
+			expectLabels: map[string]string{
+				"team": "storage",
+				"env":  "prod",
+			},
+		},
+		"invalid label": {
+			value: "team",
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			defer func() { StorageLabels = nil }()
+			err := SetStorageLabels(mock.value)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if !reflect.DeepEqual(StorageLabels, mock.expectLabels) {
+				t.Fatalf("Expected labels %v got %v", mock.expectLabels, StorageLabels)
+			}
+		})
+	}
+}
+
+func TestNewStoragePlannerNamespaceAndLabels(t *testing.T) {
+	var tests = map[string]struct {
+		flagNamespace   string
+		flagLabels      map[string]string
+		config          types.ExternalDiskConfig
+		targetNamespace string
+		expectNamespace string
+		expectLabels    map[string]string
+	}{
+		"storage set namespace": {
+			expectNamespace: "openebs",
+		},
+		"target namespace": {
+			targetNamespace: "target",
+			expectNamespace: "target",
+		},
+		"flag namespace wins over target namespace": {
+			flagNamespace:   "flag",
+			targetNamespace: "target",
+			expectNamespace: "flag",
+		},
+		"config namespace wins over flag namespace": {
+			flagNamespace:   "flag",
+			targetNamespace: "target",
+			config: types.ExternalDiskConfig{
+				StorageNamespace: "config",
+			},
+			expectNamespace: "config",
+		},
+		"flag labels": {
+			flagLabels:      map[string]string{"team": "storage"},
+			expectNamespace: "openebs",
+			expectLabels:    map[string]string{"team": "storage"},
+		},
+		"config labels win over flag labels": {
+			flagLabels: map[string]string{"team": "storage", "env": "dev"},
+			config: types.ExternalDiskConfig{
+				StorageLabels: map[string]string{"env": "prod"},
+			},
+			expectNamespace: "openebs",
+			expectLabels:    map[string]string{"team": "storage", "env": "prod"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			StorageNamespace = mock.flagNamespace
+			StorageLabels = mock.flagLabels
+			defer func() {
+				StorageNamespace = ""
+				StorageLabels = nil
+			}()
+			planner := NewStoragePlanner(&types.CStorClusterStorageSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "sset",
+					Namespace: "openebs",
+				},
+				Spec: types.CStorClusterStorageSetSpec{
+					TargetNamespace:    mock.targetNamespace,
+					ExternalDiskConfig: mock.config,
+				},
+			})
+			got := planner.getDesiredStorage("sset-0", planner.getStorageClasses(1)[0])
+			if got.GetNamespace() != mock.expectNamespace {
+				t.Fatalf(
+					"Expected namespace %q got %q", mock.expectNamespace, got.GetNamespace(),
+				)
+			}
+			if !reflect.DeepEqual(got.GetLabels(), mock.expectLabels) {
+				t.Fatalf("Expected labels %v got %v", mock.expectLabels, got.GetLabels())
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// FinalizeResyncAfterSeconds is the interval after which the
+// finalize gets reconciled again while the Storages or PVCs of a
+// StorageSet are being deleted
+var FinalizeResyncAfterSeconds float64 = 5
+
+// PVCReclaimer handles the PVCs of a StorageSet that is being
+// deleted i.e. whose node was removed from the plan
+//
+// NOTE:
+//	PVCs are deleted only after the Storages of the StorageSet are
+// deleted. This avoids the storage provisioner from provisioning
+// the deleted PVCs again. PVCs are annotated with the StorageSet UID
+// when they get adopted by their Storages. This lets the PVCs be
+// found once their Storages are gone.
+//
+// NOTE:
+//	Storages are left as is if the PVCs are retained
+type PVCReclaimer struct {
+	CStorClusterConfigUID string
+	ReclaimPolicy         types.ExternalDiskReclaimPolicy
+
+	// Storages that belong to the StorageSet
+	ObservedStorages []*unstructured.Unstructured
+
+	// PVCs that belong to the StorageSet
+	ObservedPVCs []*unstructured.Unstructured
+}
+
+// PVCReclaimResponse is the outcome of reclaiming the PVCs of a
+// StorageSet
+type PVCReclaimResponse struct {
+	// DesiredStorages are the Storages of the StorageSet that are
+	// retained
+	DesiredStorages []*unstructured.Unstructured
+
+	// DesiredPVCs are the PVCs of the StorageSet without the ones
+	// that get deleted. Retained PVCs are labeled.
+	DesiredPVCs []*unstructured.Unstructured
+
+	// IsReclaimed is true if the PVCs of the StorageSet are either
+	// labeled as retained or are being deleted
+	IsReclaimed bool
+}
+
+// Reclaim returns the desired Storages & PVCs as per the reclaim
+// policy. Unsupported reclaim policy is handled as Retain.
+func (r *PVCReclaimer) Reclaim() PVCReclaimResponse {
+	if r.ReclaimPolicy == types.ExternalDiskReclaimPolicyDelete {
+		return r.delete()
+	}
+	return r.retain()
+}
+
+// retain returns the PVCs of the StorageSet labeled as retained
+func (r *PVCReclaimer) retain() PVCReclaimResponse {
+	resp := PVCReclaimResponse{
+		DesiredStorages: r.ObservedStorages,
+		IsReclaimed:     true,
+	}
+	for _, pvc := range r.ObservedPVCs {
+		labels := pvc.GetLabels()
+		value, found := labels[types.LblKeyRetainedCStorClusterConfigUID]
+		if found && value == r.CStorClusterConfigUID {
+			resp.DesiredPVCs = append(resp.DesiredPVCs, pvc)
+			continue
+		}
+		// finalize completes once the labels are observed
+		resp.IsReclaimed = false
+		copied := pvc.DeepCopy()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[types.LblKeyRetainedCStorClusterConfigUID] = r.CStorClusterConfigUID
+		copied.SetLabels(labels)
+		resp.DesiredPVCs = append(resp.DesiredPVCs, copied)
+	}
+	return resp
+}
+
+// delete returns the PVCs of the StorageSet as is while its
+// Storages exist & drops these PVCs once the Storages are deleted
+func (r *PVCReclaimer) delete() PVCReclaimResponse {
+	if len(r.ObservedStorages) != 0 {
+		// Storages not added to response get deleted by metac
+		return PVCReclaimResponse{
+			DesiredPVCs: r.ObservedPVCs,
+		}
+	}
+	// PVCs not added to response get deleted by metac
+	//
+	// NOTE:
+	//	Finalize completes once all the PVCs are being deleted
+	resp := PVCReclaimResponse{
+		IsReclaimed: true,
+	}
+	for _, pvc := range r.ObservedPVCs {
+		if pvc.GetDeletionTimestamp() == nil {
+			resp.IsReclaimed = false
+		}
+	}
+	return resp
+}
+
+// Finalize implements the idempotent logic to reclaim the PVCs of
+// a CStorClusterStorageSet as per the reclaim policy of its external
+// disk config. This gets triggered only when CStorClusterStorageSet
+// is being deleted e.g. when its node is removed from the plan.
+//
+// NOTE:
+// 	Finalize hook automatically sets a finalizer against the watch.
+// This finalizer is removed when hookresponse's Finalized field
+// is set to true.
+//
+// NOTE:
+//	Attachments that are not added to the response get deleted by
+// metac. Hence, all the attachments other than the ones to be
+// deleted are added to the response.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are logged.
+func Finalize(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	if request == nil {
+		return errors.Errorf("Failed to finalize CStorClusterStorageSet: Nil request found")
+	}
+	if response == nil {
+		return errors.Errorf("Failed to finalize CStorClusterStorageSet: Nil response found")
+	}
+
+	glog.V(3).Infof(
+		"Will finalize CStorClusterStorageSet %s %s:",
+		request.Watch.GetNamespace(), request.Watch.GetName(),
+	)
+
+	errHandler := &reconcileErrHandler{
+		storageSet:   request.Watch,
+		hookResponse: response,
+	}
+
+	var observedStorages []*unstructured.Unstructured
+	var observedPVCs []*unstructured.Unstructured
+	var cstorClusterConfig *unstructured.Unstructured
+	desiredCStorClusterConfigUID, _ := unstruct.GetValueForKey(
+		request.Watch.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+	)
+	var attachments []*unstructured.Unstructured
+	if request.Attachments != nil {
+		attachments = request.Attachments.List()
+	}
+	for _, attachment := range attachments {
+		if attachment.GetKind() == string(types.KindStorage) {
+			uid, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterStorageSetUID,
+			)
+			if string(request.Watch.GetUID()) == uid {
+				// Storages of this watch are added after reclaim
+				observedStorages = append(observedStorages, attachment)
+				continue
+			}
+		}
+		if attachment.GetKind() == string(types.KindPersistentVolumeClaim) {
+			uid, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterStorageSetUID,
+			)
+			if string(request.Watch.GetUID()) == uid {
+				// PVCs of this watch are added after reclaim
+				observedPVCs = append(observedPVCs, attachment)
+				continue
+			}
+		}
+		if attachment.GetKind() == string(types.KindCStorClusterConfig) &&
+			string(attachment.GetUID()) == desiredCStorClusterConfigUID {
+			// config is only observed to check if automation is paused
+			cstorClusterConfig = attachment
+		}
+		response.Attachments = append(response.Attachments, attachment)
+	}
+
+	isPaused, err := pause.Skip(controllerName, cstorClusterConfig, request.Watch, response)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if isPaused {
+		return nil
+	}
+
+	reconciler, err := NewReconciler(request.Watch)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	extConfig := reconciler.CStorClusterStorageSet.Spec.ExternalDiskConfig
+	reclaimPolicy := extConfig.GetReclaimPolicy()
+	if !types.SupportedExternalDiskReclaimPolicies[reclaimPolicy] {
+		glog.Warningf(
+			"Will retain PVCs of CStorClusterStorageSet %s %s: Unsupported reclaim policy %q",
+			request.Watch.GetNamespace(), request.Watch.GetName(), reclaimPolicy,
+		)
+		reclaimPolicy = types.ExternalDiskReclaimPolicyRetain
+	}
+	reclaimer := &PVCReclaimer{
+		CStorClusterConfigUID: desiredCStorClusterConfigUID,
+		ReclaimPolicy:         reclaimPolicy,
+		ObservedStorages:      observedStorages,
+		ObservedPVCs:          observedPVCs,
+	}
+	op := reclaimer.Reclaim()
+	response.Attachments = append(response.Attachments, op.DesiredStorages...)
+	response.Attachments = append(response.Attachments, op.DesiredPVCs...)
+	response.Finalized = op.IsReclaimed
+	if !op.IsReclaimed {
+		response.ResyncAfterSeconds = FinalizeResyncAfterSeconds
+	}
+
+	glog.V(2).Infof(
+		"CStorClusterStorageSet %s %s finalized: Reclaim policy %q: Finalized %t: %s",
+		request.Watch.GetNamespace(), request.Watch.GetName(),
+		reclaimPolicy, response.Finalized,
+		metac.GetDetailsFromResponse(response),
+	)
+	return nil
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestPVCReclaimerReclaim(t *testing.T) {
+	newStorage := func(name, uid string) *unstructured.Unstructured {
+		storage := &unstructured.Unstructured{}
+		storage.SetKind(string(types.KindStorage))
+		storage.SetName(name)
+		storage.SetNamespace("openebs")
+		storage.SetUID(k8stypes.UID(uid))
+		return storage
+	}
+	newPVC := func(
+		name string, annotations, labels map[string]string,
+	) *unstructured.Unstructured {
+		pvc := &unstructured.Unstructured{}
+		pvc.SetKind(string(types.KindPersistentVolumeClaim))
+		pvc.SetName(name)
+		pvc.SetNamespace("openebs")
+		pvc.SetAnnotations(annotations)
+		pvc.SetLabels(labels)
+		return pvc
+	}
+	newDeletingPVC := func(name string, annotations map[string]string) *unstructured.Unstructured {
+		pvc := newPVC(name, annotations, nil)
+		now := metav1.Now()
+		pvc.SetDeletionTimestamp(&now)
+		return pvc
+	}
+	retained := map[string]string{types.LblKeyRetainedCStorClusterConfigUID: "ccc-1"}
+	var tests = map[string]struct {
+		policy            types.ExternalDiskReclaimPolicy
+		observedStorages  []*unstructured.Unstructured
+		observedPVCs      []*unstructured.Unstructured
+		expectStorages    []string
+		expectPVCs        []string
+		expectLabels      map[string]map[string]string
+		expectAnnotations map[string]map[string]string
+		expectReclaimed   bool
+	}{
+		"no storages & no pvcs": {
+			policy:          types.ExternalDiskReclaimPolicyDelete,
+			expectReclaimed: true,
+		},
+		"retain - pvc of storage is labeled": {
+			policy:           types.ExternalDiskReclaimPolicyRetain,
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-1")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{types.AnnKeyStorageUID: "s-1"}, nil),
+			},
+			expectStorages: []string{"storage-1"},
+			expectPVCs:     []string{"storage-1"},
+			expectLabels: map[string]map[string]string{
+				"storage-1": retained,
+			},
+		},
+		"retain - labeled pvc is reclaimed": {
+			policy:           types.ExternalDiskReclaimPolicyRetain,
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-1")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{types.AnnKeyStorageUID: "s-1"}, retained),
+			},
+			expectStorages: []string{"storage-1"},
+			expectPVCs:     []string{"storage-1"},
+			expectLabels: map[string]map[string]string{
+				"storage-1": retained,
+			},
+			expectReclaimed: true,
+		},
+		"retain - pvc without storage is labeled": {
+			policy: types.ExternalDiskReclaimPolicyRetain,
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{
+					types.AnnKeyCStorClusterStorageSetUID: "css-1",
+				}, nil),
+			},
+			expectPVCs: []string{"storage-1"},
+			expectLabels: map[string]map[string]string{
+				"storage-1": retained,
+			},
+		},
+		"delete - storages are deleted before pvcs": {
+			policy:           types.ExternalDiskReclaimPolicyDelete,
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-1")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{
+					types.AnnKeyStorageUID:                "s-1",
+					types.AnnKeyCStorClusterStorageSetUID: "css-1",
+				}, nil),
+			},
+			expectPVCs: []string{"storage-1"},
+			expectAnnotations: map[string]map[string]string{
+				"storage-1": {
+					types.AnnKeyStorageUID:                "s-1",
+					types.AnnKeyCStorClusterStorageSetUID: "css-1",
+				},
+			},
+		},
+		"delete - pvcs are deleted after storages": {
+			policy: types.ExternalDiskReclaimPolicyDelete,
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{
+					types.AnnKeyCStorClusterStorageSetUID: "css-1",
+				}, nil),
+			},
+		},
+		"delete - pvcs being deleted are reclaimed": {
+			policy: types.ExternalDiskReclaimPolicyDelete,
+			observedPVCs: []*unstructured.Unstructured{
+				newDeletingPVC("storage-1", map[string]string{
+					types.AnnKeyCStorClusterStorageSetUID: "css-1",
+				}),
+			},
+			expectReclaimed: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &PVCReclaimer{
+				CStorClusterConfigUID: "ccc-1",
+				ReclaimPolicy:         mock.policy,
+				ObservedStorages:      mock.observedStorages,
+				ObservedPVCs:          mock.observedPVCs,
+			}
+			got := r.Reclaim()
+			if got.IsReclaimed != mock.expectReclaimed {
+				t.Fatalf(
+					"Expected reclaimed %t got %t", mock.expectReclaimed, got.IsReclaimed,
+				)
+			}
+			var gotStorages, gotPVCs []string
+			for _, storage := range got.DesiredStorages {
+				gotStorages = append(gotStorages, storage.GetName())
+			}
+			gotLabels := map[string]map[string]string{}
+			gotAnnotations := map[string]map[string]string{}
+			for _, pvc := range got.DesiredPVCs {
+				gotPVCs = append(gotPVCs, pvc.GetName())
+				gotLabels[pvc.GetName()] = pvc.GetLabels()
+				gotAnnotations[pvc.GetName()] = pvc.GetAnnotations()
+			}
+			if diff := cmp.Diff(mock.expectStorages, gotStorages); diff != "" {
+				t.Fatalf("Expected no diff in storages got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectPVCs, gotPVCs); diff != "" {
+				t.Fatalf("Expected no diff in pvcs got\n%s", diff)
+			}
+			if mock.expectLabels != nil {
+				if diff := cmp.Diff(mock.expectLabels, gotLabels); diff != "" {
+					t.Fatalf("Expected no diff in labels got\n%s", diff)
+				}
+			}
+			if mock.expectAnnotations != nil {
+				if diff := cmp.Diff(mock.expectAnnotations, gotAnnotations); diff != "" {
+					t.Fatalf("Expected no diff in annotations got\n%s", diff)
+				}
+			}
+		})
+	}
+}
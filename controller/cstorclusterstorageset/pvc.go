@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// PVCAdopter verifies the ownership of the PVCs that are named
+// after the desired Storages
+//
+// NOTE:
+//	A PVC is owned by a Storage if the PVC is annotated with this
+// Storage's UID. Owned PVCs are annotated with the config UID of
+// their StorageSet. This config UID lets a PVC get adopted after its
+// Storage was lost e.g. when the operator was re-installed.
+//
+// NOTE:
+//	Owned PVCs are annotated with the UID of their StorageSet as
+// well. Finalize of the StorageSet selects its PVCs by this UID.
+type PVCAdopter struct {
+	// StorageSetUID is the UID of the StorageSet that desires the
+	// Storages. PVCs are not annotated with this UID if this is empty.
+	StorageSetUID string
+
+	// CStorClusterConfigUID is the UID of the config that the
+	// StorageSet belongs to. PVCs are neither stamped nor adopted
+	// if this is empty.
+	CStorClusterConfigUID string
+
+	// Storages desired by the StorageSet
+	DesiredStorages []*unstructured.Unstructured
+
+	// Storages that belong to the StorageSet
+	ObservedStorages []*unstructured.Unstructured
+
+	// Storages that do not belong to the StorageSet
+	ObservedOtherStorages []*unstructured.Unstructured
+
+	// PVCs that are observed in the cluster
+	ObservedPVCs []*unstructured.Unstructured
+}
+
+// Adopt returns the observed PVCs with the PVCs of the desired
+// Storages annotated with their owners. An error is returned if a
+// desired Storage collides with a Storage or PVC that was not
+// created for it.
+//
+// NOTE:
+//	A PVC is adopted if the Storage it is annotated with no longer
+// exists & the PVC belongs to the same config. Adopted PVC gets
+// annotated with the UID of the desired Storage once this Storage is
+// observed.
+func (a *PVCAdopter) Adopt() ([]*unstructured.Unstructured, error) {
+	observedStorages := map[string]*unstructured.Unstructured{}
+	liveStorageUIDs := map[string]bool{}
+	for _, storage := range a.ObservedStorages {
+		observedStorages[storage.GetNamespace()+"/"+storage.GetName()] = storage
+		liveStorageUIDs[string(storage.GetUID())] = true
+	}
+	otherStorages := map[string]bool{}
+	for _, storage := range a.ObservedOtherStorages {
+		otherStorages[storage.GetNamespace()+"/"+storage.GetName()] = true
+		liveStorageUIDs[string(storage.GetUID())] = true
+	}
+	desiredNames := map[string]bool{}
+	for _, storage := range a.DesiredStorages {
+		nsName := storage.GetNamespace() + "/" + storage.GetName()
+		if otherStorages[nsName] {
+			return nil, errors.Errorf(
+				"Storage %s collides with a Storage of a different StorageSet", nsName,
+			)
+		}
+		desiredNames[nsName] = true
+	}
+	var pvcs []*unstructured.Unstructured
+	for _, pvc := range a.ObservedPVCs {
+		nsName := pvc.GetNamespace() + "/" + pvc.GetName()
+		if !desiredNames[nsName] {
+			// not a PVC of this StorageSet
+			pvcs = append(pvcs, pvc)
+			continue
+		}
+		owned, err := a.adopt(pvc, observedStorages[nsName], liveStorageUIDs)
+		if err != nil {
+			return nil, err
+		}
+		pvcs = append(pvcs, owned)
+	}
+	return pvcs, nil
+}
+
+// adopt returns the given PVC annotated with its owners
+func (a *PVCAdopter) adopt(
+	pvc *unstructured.Unstructured,
+	storage *unstructured.Unstructured,
+	liveStorageUIDs map[string]bool,
+) (*unstructured.Unstructured, error) {
+	annotations := pvc.GetAnnotations()
+	storageUID, _ := unstruct.GetValueForKey(annotations, types.AnnKeyStorageUID)
+	configUID, _ := unstruct.GetValueForKey(annotations, types.AnnKeyCStorClusterConfigUID)
+	if storage != nil && storageUID == string(storage.GetUID()) {
+		// PVC was provisioned for the desired Storage
+		return a.annotate(pvc, storageUID), nil
+	}
+	isOrphan := storageUID != "" && !liveStorageUIDs[storageUID]
+	if !isOrphan ||
+		a.CStorClusterConfigUID == "" ||
+		configUID != a.CStorClusterConfigUID {
+		return nil, errors.Errorf(
+			"Can't adopt PVC %s/%s: Not created for Storage %s: Storage UID %q: Config UID %q",
+			pvc.GetNamespace(), pvc.GetName(), pvc.GetName(), storageUID, configUID,
+		)
+	}
+	if storage == nil {
+		// PVC is adopted once the desired Storage gets created
+		return pvc, nil
+	}
+	glog.V(2).Infof(
+		"Will adopt orphaned PVC %s/%s: Storage UID %q -> %q",
+		pvc.GetNamespace(), pvc.GetName(), storageUID, storage.GetUID(),
+	)
+	return a.annotate(pvc, string(storage.GetUID())), nil
+}
+
+// annotate returns a copy of the given PVC annotated with the given
+// Storage UID, the StorageSet UID & the config UID. The given PVC
+// is returned as is if it has these annotations already.
+func (a *PVCAdopter) annotate(
+	pvc *unstructured.Unstructured, storageUID string,
+) *unstructured.Unstructured {
+	annotations := pvc.GetAnnotations()
+	desired := map[string]string{
+		types.AnnKeyStorageUID: storageUID,
+	}
+	if a.StorageSetUID != "" {
+		desired[types.AnnKeyCStorClusterStorageSetUID] = a.StorageSetUID
+	}
+	if a.CStorClusterConfigUID != "" {
+		desired[types.AnnKeyCStorClusterConfigUID] = a.CStorClusterConfigUID
+	}
+	var isChanged bool
+	for key, value := range desired {
+		if annotations[key] != value {
+			isChanged = true
+		}
+	}
+	if !isChanged {
+		return pvc
+	}
+	copied := pvc.DeepCopy()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for key, value := range desired {
+		annotations[key] = value
+	}
+	copied.SetAnnotations(annotations)
+	return copied
+}
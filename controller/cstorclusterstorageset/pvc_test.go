@@ -0,0 +1,216 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestPVCAdopterAdopt(t *testing.T) {
+	newStorage := func(name, uid string) *unstructured.Unstructured {
+		storage := &unstructured.Unstructured{}
+		storage.SetKind(string(types.KindStorage))
+		storage.SetName(name)
+		storage.SetNamespace("openebs")
+		storage.SetUID(k8stypes.UID(uid))
+		return storage
+	}
+	newPVC := func(name string, annotations map[string]string) *unstructured.Unstructured {
+		pvc := &unstructured.Unstructured{}
+		pvc.SetKind(string(types.KindPersistentVolumeClaim))
+		pvc.SetName(name)
+		pvc.SetNamespace("openebs")
+		pvc.SetAnnotations(annotations)
+		return pvc
+	}
+	var tests = map[string]struct {
+		storageSetUID     string
+		configUID         string
+		desiredStorages   []*unstructured.Unstructured
+		observedStorages  []*unstructured.Unstructured
+		otherStorages     []*unstructured.Unstructured
+		observedPVCs      []*unstructured.Unstructured
+		expectAnnotations map[string]map[string]string
+		isErr             bool
+	}{
+		"no pvcs": {
+			configUID:       "ccc-1",
+			desiredStorages: []*unstructured.Unstructured{newStorage("storage-1", "")},
+		},
+		"pvc of other storage set is retained as is": {
+			configUID:       "ccc-1",
+			desiredStorages: []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-2", map[string]string{types.AnnKeyStorageUID: "s-2"}),
+			},
+			expectAnnotations: map[string]map[string]string{
+				"storage-2": {types.AnnKeyStorageUID: "s-2"},
+			},
+		},
+		"owned pvc is annotated with config uid": {
+			configUID:        "ccc-1",
+			desiredStorages:  []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-1")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{types.AnnKeyStorageUID: "s-1"}),
+			},
+			expectAnnotations: map[string]map[string]string{
+				"storage-1": {
+					types.AnnKeyStorageUID:            "s-1",
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				},
+			},
+		},
+		"owned pvc is annotated with storage set uid": {
+			storageSetUID:    "css-1",
+			configUID:        "ccc-1",
+			desiredStorages:  []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-1")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{types.AnnKeyStorageUID: "s-1"}),
+			},
+			expectAnnotations: map[string]map[string]string{
+				"storage-1": {
+					types.AnnKeyStorageUID:                "s-1",
+					types.AnnKeyCStorClusterStorageSetUID: "css-1",
+					types.AnnKeyCStorClusterConfigUID:     "ccc-1",
+				},
+			},
+		},
+		"orphaned pvc of same config is adopted": {
+			configUID:        "ccc-1",
+			desiredStorages:  []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-new")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{
+					types.AnnKeyStorageUID:            "s-old",
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				}),
+			},
+			expectAnnotations: map[string]map[string]string{
+				"storage-1": {
+					types.AnnKeyStorageUID:            "s-new",
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				},
+			},
+		},
+		"orphaned pvc waits for its storage": {
+			configUID:       "ccc-1",
+			desiredStorages: []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{
+					types.AnnKeyStorageUID:            "s-old",
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				}),
+			},
+			expectAnnotations: map[string]map[string]string{
+				"storage-1": {
+					types.AnnKeyStorageUID:            "s-old",
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				},
+			},
+		},
+		"orphaned pvc of other config is not adopted": {
+			configUID:        "ccc-1",
+			desiredStorages:  []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-new")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{
+					types.AnnKeyStorageUID:            "s-old",
+					types.AnnKeyCStorClusterConfigUID: "ccc-2",
+				}),
+			},
+			isErr: true,
+		},
+		"pvc of live storage is not adopted": {
+			configUID:        "ccc-1",
+			desiredStorages:  []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-new")},
+			otherStorages:    []*unstructured.Unstructured{newStorage("storage-9", "s-9")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{
+					types.AnnKeyStorageUID:            "s-9",
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				}),
+			},
+			isErr: true,
+		},
+		"pvc not created by storage provisioner is not adopted": {
+			configUID:        "ccc-1",
+			desiredStorages:  []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-1")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", nil),
+			},
+			isErr: true,
+		},
+		"orphaned pvc without config uid is not adopted": {
+			desiredStorages:  []*unstructured.Unstructured{newStorage("storage-1", "")},
+			observedStorages: []*unstructured.Unstructured{newStorage("storage-1", "s-new")},
+			observedPVCs: []*unstructured.Unstructured{
+				newPVC("storage-1", map[string]string{types.AnnKeyStorageUID: "s-old"}),
+			},
+			isErr: true,
+		},
+		"storage collides with storage of other storage set": {
+			configUID:       "ccc-1",
+			desiredStorages: []*unstructured.Unstructured{newStorage("storage-1", "")},
+			otherStorages:   []*unstructured.Unstructured{newStorage("storage-1", "s-9")},
+			isErr:           true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			a := &PVCAdopter{
+				StorageSetUID:         mock.storageSetUID,
+				CStorClusterConfigUID: mock.configUID,
+				DesiredStorages:       mock.desiredStorages,
+				ObservedStorages:      mock.observedStorages,
+				ObservedOtherStorages: mock.otherStorages,
+				ObservedPVCs:          mock.observedPVCs,
+			}
+			got, err := a.Adopt()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotAnnotations map[string]map[string]string
+			for _, pvc := range got {
+				if gotAnnotations == nil {
+					gotAnnotations = map[string]map[string]string{}
+				}
+				gotAnnotations[pvc.GetName()] = pvc.GetAnnotations()
+			}
+			if diff := cmp.Diff(mock.expectAnnotations, gotAnnotations); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,21 +17,38 @@ limitations under the License.
 package cstorclusterstorageset
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"strconv"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/json"
 	"openebs.io/metac/controller/generic"
 
+	"mayadata.io/cstorpoolauto/common/adoption"
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	"mayadata.io/cstorpoolauto/common/generation"
 	"mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/metadata"
+	"mayadata.io/cstorpoolauto/common/overlay"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/skip"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 )
 
+// controllerName is reported against the skipped reconciliations
+// of CStorClusterStorageSet
+const controllerName = "CStorClusterStorageSet"
+
 type reconcileErrHandler struct {
 	storageSet   *unstructured.Unstructured
 	hookResponse *generic.SyncHookResponse
@@ -48,11 +65,7 @@ func (h *reconcileErrHandler) handle(err error) {
 		h.storageSet.GetNamespace(), h.storageSet.GetName(), err,
 	)
 
-	conds, mergeErr :=
-		unstruct.MergeStatusConditions(
-			h.storageSet,
-			types.MakeCStorClusterStorageSetReconcileErrCond(err),
-		)
+	conds, mergeErr := conditions.MergeCondition(h.storageSet, types.MakeCStorClusterStorageSetReconcileErrCond(err))
 	if mergeErr != nil {
 		glog.Errorf(
 			"Failed to reconcile CStorClusterStorageSet %s %s: Can't set status conditions: %+v",
@@ -70,9 +83,15 @@ func (h *reconcileErrHandler) handle(err error) {
 		h.hookResponse.Status = map[string]interface{}{}
 		h.hookResponse.Status["phase"] = types.CStorClusterStorageSetStatusPhaseError
 		h.hookResponse.Status["conditions"] = conds
+		h.hookResponse.Status =
+			generation.SetObservedGeneration(h.storageSet, h.hookResponse.Status)
 	}
 	// this will stop further reconciliation at metac since there was an error
 	h.hookResponse.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(err) {
+		h.hookResponse.ResyncAfterSeconds = errs.ResyncAfterSeconds(err)
+	}
 }
 
 // Sync implements the idempotent logic to reconcile
@@ -112,7 +131,19 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 		hookResponse: response,
 	}
 
+	// resources annotated by an older release are adopted before
+	// these are matched against the watch
+	adoption.Adopt(request.Watch, adoption.LegacyKeys)
+	adoption.AdoptAll(request.Attachments.List(), adoption.LegacyKeys)
+
 	var observedStorages []*unstructured.Unstructured
+	var observedOtherStorages []*unstructured.Unstructured
+	var observedPVCs []*unstructured.Unstructured
+	var observedBlockDevices []*unstructured.Unstructured
+	var cstorClusterConfig *unstructured.Unstructured
+	desiredCStorClusterConfigUID, _ := unstruct.GetValueForKey(
+		request.Watch.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+	)
 	for _, attachment := range request.Attachments.List() {
 		if attachment.GetKind() == string(types.KindStorage) {
 			// verify further if this belongs to the current watch
@@ -126,29 +157,101 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 				// but later after reconciliation
 				continue
 			}
+			// Storages of other storage sets are observed to detect
+			// name collisions & orphaned PVCs
+			observedOtherStorages = append(observedOtherStorages, attachment)
+		}
+		if attachment.GetKind() == string(types.KindPersistentVolumeClaim) {
+			// PVCs are observed to report the binding progress & to
+			// verify their ownership. These are added later after
+			// reconciliation.
+			observedPVCs = append(observedPVCs, attachment)
+			continue
+		}
+		if attachment.GetKind() == string(types.KindBlockDevice) {
+			// BlockDevices are only observed to report the attach progress
+			observedBlockDevices = append(observedBlockDevices, attachment)
+		}
+		if attachment.GetKind() == string(types.KindCStorClusterConfig) &&
+			string(attachment.GetUID()) == desiredCStorClusterConfigUID {
+			// config is only observed to check if automation is paused
+			cstorClusterConfig = attachment
 		}
 		// add other attachments to response i.e. those that are not of kind Storage
 		response.Attachments = append(response.Attachments, attachment)
 	}
 
+	// NOTE:
+	//	Config is not found for the storage sets that are yet to be
+	// annotated with its UID. Such storage sets are not paused.
+	isPaused, err := pause.Skip(controllerName, cstorClusterConfig, request.Watch, response)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if isPaused {
+		return nil
+	}
+
+	// NOTE:
+	//	Storage set is synced only if its spec or any of its
+	// attachments changed since the last successful sync
+	resolvedState, err := generation.ResolvedStateHash(
+		request.Watch,
+		request.Attachments.List(),
+		types.AnnKeyCStorClusterStorageSetResolvedState,
+	)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if generation.IsProcessed(
+		request.Watch, resolvedState, types.AnnKeyCStorClusterStorageSetResolvedState,
+	) {
+		err = skip.Skip(
+			controllerName, request.Watch, response,
+			skip.ReasonNothingChanged,
+			"Watch & attachments did not change since the last sync",
+		)
+		if err != nil {
+			errHandler.handle(err)
+		}
+		return nil
+	}
+
 	reconciler, err := NewReconciler(request.Watch)
 	if err != nil {
 		errHandler.handle(err)
 		return nil
 	}
+	reconciler.ObservedStorages = observedStorages
+	reconciler.ObservedOtherStorages = observedOtherStorages
+	reconciler.ObservedPVCs = observedPVCs
+	reconciler.ObservedBlockDevices = observedBlockDevices
+	if cstorClusterConfig != nil {
+		reconciler.Overlays, err =
+			ccc.NewHelper(cstorClusterConfig).GetOverlays()
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
+	}
 	op, err := reconciler.Reconcile()
 	if err != nil {
 		errHandler.handle(err)
 		return nil
 	}
 	response.Attachments = append(response.Attachments, op.DesiredStorages...)
+	response.Attachments = append(response.Attachments, op.DesiredPVCs...)
 
-	// TODO (@amitkumardas):
-	//
-	// Can't set status as this creates a never ending hot loop
-	// In other words, this updates the watch & reconciliations
-	// of this watch due to other controllers get impacted
-	//response.Status = op.Status
+	// NOTE:
+	//	Status remains same across syncs if nothing changed in
+	// the cluster. This avoids a never ending hot loop that would
+	// otherwise happen since the watch gets updated with the status.
+	response.Status = generation.SetObservedGeneration(request.Watch, op.Status)
+	response.Annotations = map[string]*string{
+		types.AnnKeyCStorClusterStorageSetResolvedState: &resolvedState,
+	}
 
 	glog.V(2).Infof(
 		"CStorClusterStorageSet %s %s reconciled successfully: %s",
@@ -163,13 +266,31 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 type Reconciler struct {
 	CStorClusterStorageSet *types.CStorClusterStorageSet
 	ObservedStorages       []*unstructured.Unstructured
+
+	// ObservedOtherStorages are the Storages that do not belong to
+	// this StorageSet
+	ObservedOtherStorages []*unstructured.Unstructured
+
+	// PVCs & BlockDevices are used to report the progress of
+	// Storages in CStorClusterStorageSet status
+	ObservedPVCs         []*unstructured.Unstructured
+	ObservedBlockDevices []*unstructured.Unstructured
+
+	// Overlays of the config are applied to the PVCs of the
+	// desired Storages
+	Overlays []types.Overlay
 }
 
 // ReconcileResponse forms the response due to reconciliation of
 // CStorClusterStorageSet
 type ReconcileResponse struct {
 	DesiredStorages []*unstructured.Unstructured
-	Status          map[string]interface{}
+
+	// DesiredPVCs are the observed PVCs with the ones that belong
+	// to the desired Storages annotated with their owner
+	DesiredPVCs []*unstructured.Unstructured
+
+	Status map[string]interface{}
 }
 
 // NewReconciler returns a new instance of reconciler
@@ -202,15 +323,56 @@ func NewReconciler(storageSet *unstructured.Unstructured) (*Reconciler, error) {
 // handled by metac (which is the underlying library)
 func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
 	planner := NewStoragePlanner(r.CStorClusterStorageSet)
+	planner.ObservedStorageNamespaces = map[string]string{}
+	for _, storage := range r.ObservedStorages {
+		planner.ObservedStorageNamespaces[storage.GetName()] = storage.GetNamespace()
+	}
 	desiredStorages, err := planner.Plan()
 	if err != nil {
 		return ReconcileResponse{}, err
 	}
+	adopter := &PVCAdopter{
+		StorageSetUID:         string(planner.StorageSetUID),
+		CStorClusterConfigUID: planner.CStorClusterConfigUID,
+		DesiredStorages:       desiredStorages,
+		ObservedStorages:      r.ObservedStorages,
+		ObservedOtherStorages: r.ObservedOtherStorages,
+		ObservedPVCs:          r.ObservedPVCs,
+	}
+	desiredPVCs, err := adopter.Adopt()
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	err = overlay.ApplyAll(desiredPVCs, r.Overlays)
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	var desiredStorageNames []string
+	desiredStorageClassNames := map[string]string{}
+	for _, storage := range desiredStorages {
+		desiredStorageNames = append(desiredStorageNames, storage.GetName())
+		desiredStorageClassNames[storage.GetName()] =
+			storage.GetAnnotations()[types.AnnKeyStorageProvisionerStorageClassName]
+	}
+	statusBuilder := &StatusBuilder{
+		StorageSet:               r.CStorClusterStorageSet,
+		DesiredStorageNames:      desiredStorageNames,
+		DesiredStorageClassNames: desiredStorageClassNames,
+		ObservedStorages:         r.ObservedStorages,
+		ObservedPVCs:             desiredPVCs,
+		ObservedBlockDevices:     r.ObservedBlockDevices,
+	}
+	status := statusBuilder.Build()
+	statusMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return ReconcileResponse{}, errors.Wrapf(
+			err, "Can't convert CStorClusterStorageSet status to unstructured",
+		)
+	}
 	return ReconcileResponse{
 		DesiredStorages: desiredStorages,
-		Status: types.MakeCStorClusterStorageSetToOnlineWithNoReconcileErr(
-			r.CStorClusterStorageSet,
-		),
+		DesiredPVCs:     desiredPVCs,
+		Status:          statusMap,
 	}, nil
 }
 
@@ -226,10 +388,48 @@ type StoragePlanner struct {
 	DesiredNamespace        string
 	DesiredCSIAttacherName  string
 	DesiredStorageClassName string
+	DesiredChildMetadata    *types.ChildMetadata
+
+	// CStorClusterConfigUID is the UID of the config that this
+	// StorageSet belongs to. This is empty for the StorageSets
+	// that are yet to be annotated with this UID.
+	CStorClusterConfigUID string
+
+	// DesiredParameters tune the provisioned disks
+	DesiredParameters map[string]string
+
+	// DesiredLabels are set against every Storage
+	DesiredLabels map[string]string
+
+	// DesiredStorageClasses provision the disks in proportion to
+	// their weights. DesiredCSIAttacherName & DesiredStorageClassName
+	// are used if these are not set.
+	DesiredStorageClasses []types.ExternalStorageClass
+
+	// ObservedStorageNamespaces maps the names of observed Storages
+	// to their namespaces
+	//
+	// NOTE:
+	//	Observed Storages are retained in their namespaces since
+	// moving them to a different namespace would re-create them
+	ObservedStorageNamespaces map[string]string
 }
 
 // NewStoragePlanner returns a new instance of StoragePlanner
 func NewStoragePlanner(storageSet *types.CStorClusterStorageSet) *StoragePlanner {
+	// Storages are created in the namespace set in the config,
+	// else in the namespace set via flag, else in the target
+	// namespace, else in the namespace of storage set
+	namespace := storageSet.Spec.ExternalDiskConfig.StorageNamespace
+	if namespace == "" {
+		namespace = StorageNamespace
+	}
+	if namespace == "" {
+		namespace = storageSet.Spec.TargetNamespace
+	}
+	if namespace == "" {
+		namespace = storageSet.GetNamespace()
+	}
 	// initialize the planner
 	return &StoragePlanner{
 		StorageSetName:          storageSet.GetName(),
@@ -237,9 +437,14 @@ func NewStoragePlanner(storageSet *types.CStorClusterStorageSet) *StoragePlanner
 		DesiredCount:            storageSet.Spec.Disk.Count,
 		DesiredCapacity:         storageSet.Spec.Disk.Capacity,
 		DesiredNodeName:         storageSet.Spec.Node.Name,
-		DesiredNamespace:        storageSet.GetNamespace(),
+		DesiredNamespace:        namespace,
 		DesiredCSIAttacherName:  storageSet.Spec.ExternalDiskConfig.CSIAttacherName,
 		DesiredStorageClassName: storageSet.Spec.ExternalDiskConfig.StorageClassName,
+		DesiredChildMetadata:    storageSet.Spec.ChildMetadata,
+		DesiredParameters:       storageSet.Spec.ExternalDiskConfig.Parameters,
+		DesiredLabels:           mergeStorageLabels(storageSet.Spec.ExternalDiskConfig.StorageLabels),
+		DesiredStorageClasses:   storageSet.Spec.ExternalDiskConfig.StorageClasses,
+		CStorClusterConfigUID:   storageSet.GetAnnotations()[types.AnnKeyCStorClusterConfigUID],
 	}
 }
 
@@ -256,27 +461,106 @@ func (p *StoragePlanner) Plan() ([]*unstructured.Unstructured, error) {
 // that in turn either get created or updated in the cluster
 func (p *StoragePlanner) plan(count int64) []*unstructured.Unstructured {
 	var desiredStorages []*unstructured.Unstructured
+	classes := p.getStorageClasses(count)
 	var i int64
 	for i = 0; i < count; i++ {
 		glog.V(3).Infof(
 			"Will sync Storage %d for CStorClusterStorageSet UID %q", i, p.StorageSetUID,
 		)
-		storageName := p.StorageSetName + "-" + strconv.FormatInt(i, 10)
-		desiredStorages = append(desiredStorages, p.getDesiredStorage(storageName))
+		desiredStorages = append(
+			desiredStorages, p.getDesiredStorage(p.getStorageName(i), classes[i]),
+		)
 	}
 	return desiredStorages
 }
 
+// getStorageClasses returns the StorageClass of each of the given
+// count of Storages
+//
+// NOTE:
+//	Storages are assigned to StorageClasses by smooth weighted round
+// robin. Hence, StorageClass of a Storage does not change when the
+// disk count grows & Storages of every StorageClass are interleaved
+// in proportion to the weights.
+func (p *StoragePlanner) getStorageClasses(count int64) []types.ExternalStorageClass {
+	candidates := p.DesiredStorageClasses
+	if len(candidates) == 0 {
+		candidates = []types.ExternalStorageClass{
+			{
+				CSIAttacherName:  p.DesiredCSIAttacherName,
+				StorageClassName: p.DesiredStorageClassName,
+			},
+		}
+	}
+	var totalWeight int64
+	for _, candidate := range candidates {
+		totalWeight += candidate.GetWeight()
+	}
+	current := make([]int64, len(candidates))
+	var classes []types.ExternalStorageClass
+	var i int64
+	for i = 0; i < count; i++ {
+		pick := 0
+		for j, candidate := range candidates {
+			current[j] += candidate.GetWeight()
+			// ties are broken by the order of StorageClasses
+			if current[j] > current[pick] {
+				pick = j
+			}
+		}
+		current[pick] -= totalWeight
+		classes = append(classes, candidates[pick])
+	}
+	return classes
+}
+
+// getStorageName returns the name of the Storage at the given index
+//
+// NOTE:
+//	Storages that were observed with their legacy names i.e.
+// <storageset-name>-<index> retain these names. Renaming them
+// would re-create their disks.
+func (p *StoragePlanner) getStorageName(index int64) string {
+	legacyName := p.StorageSetName + "-" + strconv.FormatInt(index, 10)
+	if _, found := p.ObservedStorageNamespaces[legacyName]; found {
+		return legacyName
+	}
+	ownerUID := p.CStorClusterConfigUID
+	if ownerUID == "" {
+		ownerUID = string(p.StorageSetUID)
+	}
+	return GetStorageName(ownerUID, p.DesiredNodeName, index)
+}
+
+// GetStorageName returns the deterministic name of the Storage at
+// the given index of the given node. PVC provisioned for a Storage
+// is named after this Storage.
+//
+// NOTE:
+//	Name is a hash of the owner UID, node & index. Hence, it is
+// same across resyncs & does not collide across configs even if
+// config or storage set names get reused.
+func GetStorageName(ownerUID, nodeName string, index int64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%d", ownerUID, nodeName, index)))
+	return "storage-" + hex.EncodeToString(sum[:])[:20]
+}
+
 // getDesiredStorage returns the desired state of the
 // Storage resource. This returned structure is idempotent
 // and hence can be used during create &/ update based
 // reconciliations.
-func (p *StoragePlanner) getDesiredStorage(storageName string) *unstructured.Unstructured {
+func (p *StoragePlanner) getDesiredStorage(
+	storageName string, class types.ExternalStorageClass,
+) *unstructured.Unstructured {
+	namespace := p.DesiredNamespace
+	if observed := p.ObservedStorageNamespaces[storageName]; observed != "" {
+		namespace = observed
+	}
 	storage := &unstructured.Unstructured{}
 	storage.SetUnstructuredContent(map[string]interface{}{
 		"metadata": map[string]interface{}{
 			"name":      storageName,
-			"namespace": p.DesiredNamespace,
+			"namespace": namespace,
 		},
 		"spec": map[string]interface{}{
 			"capacity": p.DesiredCapacity,
@@ -284,17 +568,37 @@ func (p *StoragePlanner) getDesiredStorage(storageName string) *unstructured.Uns
 		},
 	})
 	// set the desired annotations
-	storage.SetAnnotations(map[string]string{
+	annotations := map[string]string{
 		// set CStorClusterStorageSet in annotations to indicate
 		// the resource that triggered creation of this Storage
 		types.AnnKeyCStorClusterStorageSetUID: string(p.StorageSetUID),
 
 		// CSIAttacherName will be used later during storage provisioning
-		types.AnnKeyStorageProvisionerCSIAttacherName: p.DesiredCSIAttacherName,
+		types.AnnKeyStorageProvisionerCSIAttacherName: class.CSIAttacherName,
 
 		// StorageClassName will be used later during storage provisioning
-		types.AnnKeyStorageProvisionerStorageClassName: p.DesiredStorageClassName,
-	})
+		types.AnnKeyStorageProvisionerStorageClassName: class.StorageClassName,
+	}
+	if p.CStorClusterConfigUID != "" {
+		// config UID is stable across operator re-installs & is
+		// used to adopt the PVCs orphaned by the previous instance
+		annotations[types.AnnKeyCStorClusterConfigUID] = p.CStorClusterConfigUID
+	}
+	// parameters are set as annotations that are prefixed with
+	// CSIAttacherName e.g. ebs.csi.aws.com/iops
+	//
+	// NOTE:
+	//	Storage provisioner is expected to set these annotations
+	// against the PVC for CSI drivers that support per volume tuning
+	for param, value := range p.DesiredParameters {
+		annotations[class.CSIAttacherName+"/"+param] = value
+	}
+	storage.SetAnnotations(annotations)
+	if len(p.DesiredLabels) != 0 {
+		storage.SetLabels(p.DesiredLabels)
+	}
+	// user provided labels & annotations if any
+	metadata.Propagate(storage, p.DesiredChildMetadata)
 	// below is the right way to set the desired APIVersion & Kind
 	storage.SetAPIVersion(string(types.APIVersionDAOMayaDataV1Alpha1))
 	storage.SetKind(string(types.KindStorage))
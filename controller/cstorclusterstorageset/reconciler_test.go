@@ -0,0 +1,262 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestStoragePlannerGetDesiredStorage(t *testing.T) {
+	var tests = map[string]struct {
+		planner           *StoragePlanner
+		expectAnnotations map[string]string
+	}{
+		"no parameters": {
+			planner: &StoragePlanner{
+				StorageSetUID:           "sset-1",
+				DesiredCSIAttacherName:  types.CSIAttacherNameAWSEBS,
+				DesiredStorageClassName: "csi-ebs",
+			},
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterStorageSetUID:          "sset-1",
+				types.AnnKeyStorageProvisionerCSIAttacherName:  types.CSIAttacherNameAWSEBS,
+				types.AnnKeyStorageProvisionerStorageClassName: "csi-ebs",
+			},
+		},
+		"with parameters": {
+			planner: &StoragePlanner{
+				StorageSetUID:           "sset-1",
+				DesiredCSIAttacherName:  types.CSIAttacherNameAWSEBS,
+				DesiredStorageClassName: "csi-ebs",
+				DesiredParameters: map[string]string{
+					"iops":       "3000",
+					"throughput": "125",
+				},
+			},
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterStorageSetUID:          "sset-1",
+				types.AnnKeyStorageProvisionerCSIAttacherName:  types.CSIAttacherNameAWSEBS,
+				types.AnnKeyStorageProvisionerStorageClassName: "csi-ebs",
+				"ebs.csi.aws.com/iops":                         "3000",
+				"ebs.csi.aws.com/throughput":                   "125",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.planner.getDesiredStorage("sset-0", mock.planner.getStorageClasses(1)[0])
+			if diff := cmp.Diff(mock.expectAnnotations, got.GetAnnotations()); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStoragePlannerGetStorageClasses(t *testing.T) {
+	premium := types.ExternalStorageClass{
+		CSIAttacherName: types.CSIAttacherNameAWSEBS, StorageClassName: "premium", Weight: 1,
+	}
+	standard := types.ExternalStorageClass{
+		CSIAttacherName: types.CSIAttacherNameAWSEBS, StorageClassName: "standard", Weight: 2,
+	}
+	unweighted := types.ExternalStorageClass{
+		CSIAttacherName: types.CSIAttacherNameAWSEBS, StorageClassName: "unweighted",
+	}
+	var tests = map[string]struct {
+		planner     *StoragePlanner
+		count       int64
+		expectNames []string
+	}{
+		"single storage class": {
+			planner: &StoragePlanner{
+				DesiredCSIAttacherName:  types.CSIAttacherNameAWSEBS,
+				DesiredStorageClassName: "csi-ebs",
+			},
+			count:       2,
+			expectNames: []string{"csi-ebs", "csi-ebs"},
+		},
+		"weighted storage classes": {
+			planner: &StoragePlanner{
+				DesiredStorageClasses: []types.ExternalStorageClass{premium, standard},
+			},
+			count: 6,
+			expectNames: []string{
+				"standard", "premium", "standard", "standard", "premium", "standard",
+			},
+		},
+		"default weights": {
+			planner: &StoragePlanner{
+				DesiredStorageClasses: []types.ExternalStorageClass{premium, unweighted},
+			},
+			count:       4,
+			expectNames: []string{"premium", "unweighted", "premium", "unweighted"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var gotNames []string
+			for _, class := range mock.planner.getStorageClasses(mock.count) {
+				gotNames = append(gotNames, class.StorageClassName)
+			}
+			if diff := cmp.Diff(mock.expectNames, gotNames); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+			// storage class of a storage does not change as count grows
+			grown := mock.planner.getStorageClasses(mock.count + 3)
+			for i, name := range gotNames {
+				if grown[i].StorageClassName != name {
+					t.Fatalf(
+						"Expected storage %d to retain %q got %q", i, name, grown[i].StorageClassName,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestStoragePlannerGetDesiredStorageNamespace(t *testing.T) {
+	var tests = map[string]struct {
+		planner         *StoragePlanner
+		expectNamespace string
+	}{
+		"no observed storage": {
+			planner: &StoragePlanner{
+				DesiredNamespace: "openebs",
+			},
+			expectNamespace: "openebs",
+		},
+		"observed storage in a different namespace": {
+			planner: &StoragePlanner{
+				DesiredNamespace: "openebs",
+				ObservedStorageNamespaces: map[string]string{
+					"sset-0": "legacy",
+				},
+			},
+			expectNamespace: "legacy",
+		},
+		"other observed storage in a different namespace": {
+			planner: &StoragePlanner{
+				DesiredNamespace: "openebs",
+				ObservedStorageNamespaces: map[string]string{
+					"sset-1": "legacy",
+				},
+			},
+			expectNamespace: "openebs",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.planner.getDesiredStorage("sset-0", mock.planner.getStorageClasses(1)[0])
+			if got.GetNamespace() != mock.expectNamespace {
+				t.Fatalf(
+					"Expected namespace %q got %q", mock.expectNamespace, got.GetNamespace(),
+				)
+			}
+		})
+	}
+}
+
+func TestStoragePlannerGetStorageName(t *testing.T) {
+	var tests = map[string]struct {
+		planner    *StoragePlanner
+		index      int64
+		expectName string
+	}{
+		"config uid": {
+			planner: &StoragePlanner{
+				StorageSetName:        "sset",
+				StorageSetUID:         "sset-1",
+				CStorClusterConfigUID: "ccc-1",
+				DesiredNodeName:       "node-1",
+			},
+			expectName: GetStorageName("ccc-1", "node-1", 0),
+		},
+		"no config uid": {
+			planner: &StoragePlanner{
+				StorageSetName:  "sset",
+				StorageSetUID:   "sset-1",
+				DesiredNodeName: "node-1",
+			},
+			index:      1,
+			expectName: GetStorageName("sset-1", "node-1", 1),
+		},
+		"observed storage with legacy name": {
+			planner: &StoragePlanner{
+				StorageSetName:        "sset",
+				StorageSetUID:         "sset-1",
+				CStorClusterConfigUID: "ccc-1",
+				DesiredNodeName:       "node-1",
+				ObservedStorageNamespaces: map[string]string{
+					"sset-1": "openebs",
+				},
+			},
+			index:      1,
+			expectName: "sset-1",
+		},
+		"observed storage with legacy name of other index": {
+			planner: &StoragePlanner{
+				StorageSetName:        "sset",
+				StorageSetUID:         "sset-1",
+				CStorClusterConfigUID: "ccc-1",
+				DesiredNodeName:       "node-1",
+				ObservedStorageNamespaces: map[string]string{
+					"sset-1": "openebs",
+				},
+			},
+			expectName: GetStorageName("ccc-1", "node-1", 0),
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.planner.getStorageName(mock.index)
+			if got != mock.expectName {
+				t.Fatalf("Expected name %q got %q", mock.expectName, got)
+			}
+		})
+	}
+}
+
+func TestGetStorageName(t *testing.T) {
+	name := GetStorageName("ccc-1", "node-1", 0)
+	if name != GetStorageName("ccc-1", "node-1", 0) {
+		t.Fatalf("Expected same name across calls")
+	}
+	if len(name) != len("storage-")+20 {
+		t.Fatalf("Expected name of length %d got %q", len("storage-")+20, name)
+	}
+	for _, other := range []string{
+		GetStorageName("ccc-2", "node-1", 0),
+		GetStorageName("ccc-1", "node-2", 0),
+		GetStorageName("ccc-1", "node-1", 1),
+	} {
+		if name == other {
+			t.Fatalf("Expected different names got %q", name)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// StatusBuilder builds the status of CStorClusterStorageSet based
+// on the progress of its Storage(s), PVC(s) & BlockDevice(s)
+//
+// NOTE:
+//	Built status is expected to be same across syncs if nothing
+// changed in the cluster. A status that changes during every sync
+// results in a never ending hot loop since the watch gets updated
+// with this status.
+type StatusBuilder struct {
+	StorageSet *types.CStorClusterStorageSet
+
+	// names of the Storage(s) desired by this StorageSet
+	DesiredStorageNames []string
+
+	// DesiredStorageClassNames maps the names of desired Storage(s)
+	// to the StorageClass their PVCs are provisioned from
+	DesiredStorageClassNames map[string]string
+
+	// Storage(s) that belong to this StorageSet
+	ObservedStorages []*unstructured.Unstructured
+
+	// PVC(s) that might belong to the observed Storage(s)
+	ObservedPVCs []*unstructured.Unstructured
+
+	// BlockDevice(s) that might be associated with this StorageSet
+	ObservedBlockDevices []*unstructured.Unstructured
+}
+
+// Build returns the status of CStorClusterStorageSet
+func (b *StatusBuilder) Build() types.CStorClusterStorageSetStatus {
+	status := types.CStorClusterStorageSetStatus{
+		Phase:            types.CStorClusterStorageSetStatusPhaseOnline,
+		Conditions:       b.buildConditions(),
+		DesiredDiskCount: b.StorageSet.Spec.Disk.Count.Value(),
+		Node:             b.buildNodeStatus(),
+	}
+	for _, storage := range b.buildStorageStatuses() {
+		if storage.IsBound {
+			status.BoundCount++
+		} else {
+			status.PendingCount++
+		}
+		status.Storages = append(status.Storages, storage)
+	}
+	return status
+}
+
+// buildConditions returns the existing conditions with reconcile
+// error condition set to absent
+//
+// NOTE:
+//	Last observed time of absent reconcile error condition is
+// retained if it was already absent. This keeps the status same
+// across syncs.
+func (b *StatusBuilder) buildConditions() []types.CStorClusterStorageSetStatusCondition {
+	var conds []types.CStorClusterStorageSetStatusCondition
+	var isAbsent bool
+	for _, old := range b.StorageSet.Status.Conditions {
+		if old.Type == types.CStorClusterStorageSetReconcileErrorCondition {
+			if old.Status != types.ConditionIsAbsent || isAbsent {
+				// ignore previous error & duplicates if any
+				continue
+			}
+			isAbsent = true
+		}
+		conds = append(conds, old)
+	}
+	if !isAbsent {
+		noErrStorageSet := &types.CStorClusterStorageSet{}
+		types.MergeNoReconcileErrorOnCStorClusterStorageSet(noErrStorageSet)
+		conds = append(conds, noErrStorageSet.Status.Conditions...)
+	}
+	return conds
+}
+
+// buildStorageStatuses returns the status of each desired Storage
+// in the order of desired Storage names
+func (b *StatusBuilder) buildStorageStatuses() []types.CStorClusterStorageSetStorageStatus {
+	storageNameToUID := map[string]string{}
+	for _, storage := range b.ObservedStorages {
+		storageNameToUID[storage.GetName()] = string(storage.GetUID())
+	}
+	storageUIDToPVC := map[string]*unstructured.Unstructured{}
+	for _, pvc := range b.ObservedPVCs {
+		uid, _ := unstruct.GetValueForKey(pvc.GetAnnotations(), types.AnnKeyStorageUID)
+		if uid != "" {
+			storageUIDToPVC[uid] = pvc
+		}
+	}
+	var statuses []types.CStorClusterStorageSetStorageStatus
+	for _, name := range b.DesiredStorageNames {
+		status := types.CStorClusterStorageSetStorageStatus{
+			Name:             name,
+			StorageClassName: b.DesiredStorageClassNames[name],
+		}
+		uid := storageNameToUID[name]
+		if pvc := storageUIDToPVC[uid]; uid != "" && pvc != nil {
+			status.PVCName = pvc.GetName()
+			// StorageClass of an existing PVC is the one it was
+			// actually provisioned from
+			className, _, _ :=
+				unstructured.NestedString(pvc.UnstructuredContent(), "spec", "storageClassName")
+			if className != "" {
+				status.StorageClassName = className
+			}
+			status.PVName, _, _ =
+				unstructured.NestedString(pvc.UnstructuredContent(), "spec", "volumeName")
+			phase, _, _ :=
+				unstructured.NestedString(pvc.UnstructuredContent(), "status", "phase")
+			// PVC needs to be bound to a PV for its disk to get attached
+			status.IsBound = phase == "Bound" && status.PVName != ""
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// buildNodeStatus returns the disks attached to the node of this
+// StorageSet
+func (b *StatusBuilder) buildNodeStatus() types.CStorClusterStorageSetNodeStatus {
+	node := types.CStorClusterStorageSetNodeStatus{
+		Name: b.StorageSet.Spec.Node.Name,
+	}
+	for _, device := range b.ObservedBlockDevices {
+		// TODO (@amitkumardas):
+		//	We are using labels since there might be a bug
+		// in metac to merge annotations. Use of labels is a
+		// workaround that needs to be changed to annotations
+		// once metac fixes this bug.
+		uid, _ := unstruct.GetValueForKey(
+			device.GetLabels(), types.AnnKeyCStorClusterStorageSetUID,
+		)
+		if uid != string(b.StorageSet.GetUID()) {
+			continue
+		}
+		node.AttachedBlockDeviceNames =
+			append(node.AttachedBlockDeviceNames, device.GetName())
+	}
+	// sorted to keep the status same across syncs
+	sort.Strings(node.AttachedBlockDeviceNames)
+	node.AttachedDiskCount = int64(len(node.AttachedBlockDeviceNames))
+	node.IsReady =
+		b.StorageSet.Spec.Disk.Count.CmpInt64(node.AttachedDiskCount) <= 0
+	return node
+}
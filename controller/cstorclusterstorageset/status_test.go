@@ -0,0 +1,288 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorclusterstorageset
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestStorageSet(count string, conds ...types.CStorClusterStorageSetStatusCondition) *types.CStorClusterStorageSet {
+	return &types.CStorClusterStorageSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "sset",
+			UID:  "sset-1",
+		},
+		Spec: types.CStorClusterStorageSetSpec{
+			Node: types.CStorClusterPlanNode{
+				Name: "node-1",
+			},
+			Disk: types.CStorClusterStorageSetDisk{
+				Count: resource.MustParse(count),
+			},
+		},
+		Status: types.CStorClusterStorageSetStatus{
+			Conditions: conds,
+		},
+	}
+}
+
+func newTestStorage(name, uid string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindStorage),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"uid":  uid,
+			},
+		},
+	}
+}
+
+func newTestPVC(name, storageUID, pvName, phase string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindPersistentVolumeClaim),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"annotations": map[string]interface{}{
+					types.AnnKeyStorageUID: storageUID,
+				},
+			},
+			"spec": map[string]interface{}{
+				"volumeName": pvName,
+			},
+			"status": map[string]interface{}{
+				"phase": phase,
+			},
+		},
+	}
+}
+
+func newTestBlockDevice(name, storageSetUID string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"labels": map[string]interface{}{
+					types.AnnKeyCStorClusterStorageSetUID: storageSetUID,
+				},
+			},
+		},
+	}
+}
+
+func TestStatusBuilderBuild(t *testing.T) {
+	noErrCond := types.CStorClusterStorageSetStatusCondition{
+		Type:             types.CStorClusterStorageSetReconcileErrorCondition,
+		Status:           types.ConditionIsAbsent,
+		LastObservedTime: "2020-01-01T00:00:00Z",
+	}
+	var tests = map[string]struct {
+		builder *StatusBuilder
+		expect  types.CStorClusterStorageSetStatus
+	}{
+		"nothing is observed": {
+			builder: &StatusBuilder{
+				StorageSet:          newTestStorageSet("2", noErrCond),
+				DesiredStorageNames: []string{"sset-0", "sset-1"},
+			},
+			expect: types.CStorClusterStorageSetStatus{
+				Phase:            types.CStorClusterStorageSetStatusPhaseOnline,
+				Conditions:       []types.CStorClusterStorageSetStatusCondition{noErrCond},
+				DesiredDiskCount: 2,
+				PendingCount:     2,
+				Storages: []types.CStorClusterStorageSetStorageStatus{
+					{Name: "sset-0"},
+					{Name: "sset-1"},
+				},
+				Node: types.CStorClusterStorageSetNodeStatus{
+					Name: "node-1",
+				},
+			},
+		},
+		"one pvc is bound & its disk is attached": {
+			builder: &StatusBuilder{
+				StorageSet:          newTestStorageSet("2", noErrCond),
+				DesiredStorageNames: []string{"sset-0", "sset-1"},
+				ObservedStorages: []*unstructured.Unstructured{
+					newTestStorage("sset-0", "s-0"),
+					newTestStorage("sset-1", "s-1"),
+				},
+				ObservedPVCs: []*unstructured.Unstructured{
+					newTestPVC("pvc-0", "s-0", "pv-0", "Bound"),
+					newTestPVC("pvc-1", "s-1", "", "Pending"),
+					newTestPVC("pvc-x", "s-x", "pv-x", "Bound"),
+				},
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-0", "sset-1"),
+					newTestBlockDevice("bd-x", "sset-x"),
+				},
+			},
+			expect: types.CStorClusterStorageSetStatus{
+				Phase:            types.CStorClusterStorageSetStatusPhaseOnline,
+				Conditions:       []types.CStorClusterStorageSetStatusCondition{noErrCond},
+				DesiredDiskCount: 2,
+				BoundCount:       1,
+				PendingCount:     1,
+				Storages: []types.CStorClusterStorageSetStorageStatus{
+					{Name: "sset-0", PVCName: "pvc-0", PVName: "pv-0", IsBound: true},
+					{Name: "sset-1", PVCName: "pvc-1"},
+				},
+				Node: types.CStorClusterStorageSetNodeStatus{
+					Name:                     "node-1",
+					AttachedDiskCount:        1,
+					AttachedBlockDeviceNames: []string{"bd-0"},
+				},
+			},
+		},
+		"storage classes of pvcs & pending storages": {
+			builder: &StatusBuilder{
+				StorageSet:          newTestStorageSet("2", noErrCond),
+				DesiredStorageNames: []string{"sset-0", "sset-1"},
+				DesiredStorageClassNames: map[string]string{
+					"sset-0": "premium",
+					"sset-1": "standard",
+				},
+				ObservedStorages: []*unstructured.Unstructured{
+					newTestStorage("sset-0", "s-0"),
+				},
+				ObservedPVCs: []*unstructured.Unstructured{
+					func() *unstructured.Unstructured {
+						pvc := newTestPVC("pvc-0", "s-0", "pv-0", "Bound")
+						unstructured.SetNestedField(pvc.Object, "gold", "spec", "storageClassName")
+						return pvc
+					}(),
+				},
+			},
+			expect: types.CStorClusterStorageSetStatus{
+				Phase:            types.CStorClusterStorageSetStatusPhaseOnline,
+				Conditions:       []types.CStorClusterStorageSetStatusCondition{noErrCond},
+				DesiredDiskCount: 2,
+				BoundCount:       1,
+				PendingCount:     1,
+				Storages: []types.CStorClusterStorageSetStorageStatus{
+					{Name: "sset-0", PVCName: "pvc-0", PVName: "pv-0", IsBound: true, StorageClassName: "gold"},
+					{Name: "sset-1", StorageClassName: "standard"},
+				},
+				Node: types.CStorClusterStorageSetNodeStatus{
+					Name: "node-1",
+				},
+			},
+		},
+		"all disks are attached": {
+			builder: &StatusBuilder{
+				StorageSet:          newTestStorageSet("1", noErrCond),
+				DesiredStorageNames: []string{"sset-0"},
+				ObservedStorages: []*unstructured.Unstructured{
+					newTestStorage("sset-0", "s-0"),
+				},
+				ObservedPVCs: []*unstructured.Unstructured{
+					newTestPVC("pvc-0", "s-0", "pv-0", "Bound"),
+				},
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					newTestBlockDevice("bd-0", "sset-1"),
+				},
+			},
+			expect: types.CStorClusterStorageSetStatus{
+				Phase:            types.CStorClusterStorageSetStatusPhaseOnline,
+				Conditions:       []types.CStorClusterStorageSetStatusCondition{noErrCond},
+				DesiredDiskCount: 1,
+				BoundCount:       1,
+				Storages: []types.CStorClusterStorageSetStorageStatus{
+					{Name: "sset-0", PVCName: "pvc-0", PVName: "pv-0", IsBound: true},
+				},
+				Node: types.CStorClusterStorageSetNodeStatus{
+					Name:                     "node-1",
+					AttachedDiskCount:        1,
+					AttachedBlockDeviceNames: []string{"bd-0"},
+					IsReady:                  true,
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.builder.Build()
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStatusBuilderBuildConditions(t *testing.T) {
+	var tests = map[string]struct {
+		conds              []types.CStorClusterStorageSetStatusCondition
+		expectCount        int
+		expectRetainedTime bool
+	}{
+		"no conditions": {
+			expectCount: 1,
+		},
+		"previous error is replaced": {
+			conds: []types.CStorClusterStorageSetStatusCondition{
+				{
+					Type:             types.CStorClusterStorageSetReconcileErrorCondition,
+					Status:           types.ConditionIsPresent,
+					LastObservedTime: "2020-01-01T00:00:00Z",
+				},
+			},
+			expectCount: 1,
+		},
+		"no error condition is retained as is": {
+			conds: []types.CStorClusterStorageSetStatusCondition{
+				{
+					Type:             types.CStorClusterStorageSetReconcileErrorCondition,
+					Status:           types.ConditionIsAbsent,
+					LastObservedTime: "2020-01-01T00:00:00Z",
+				},
+			},
+			expectCount:        1,
+			expectRetainedTime: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			b := &StatusBuilder{
+				StorageSet: newTestStorageSet("1", mock.conds...),
+			}
+			got := b.buildConditions()
+			if len(got) != mock.expectCount {
+				t.Fatalf("Expected condition count %d got %d", mock.expectCount, len(got))
+			}
+			if got[0].Status != types.ConditionIsAbsent {
+				t.Fatalf("Expected status %q got %q", types.ConditionIsAbsent, got[0].Status)
+			}
+			isRetained := got[0].LastObservedTime == "2020-01-01T00:00:00Z"
+			if mock.expectRetainedTime != isRetained {
+				t.Fatalf("Expected retained time %t got %t", mock.expectRetainedTime, isRetained)
+			}
+		})
+	}
+}
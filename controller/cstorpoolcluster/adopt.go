@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorpoolcluster
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// isAdoptedInto returns true if the given CStorPoolCluster is
+// annotated to be adopted into the given CStorClusterConfig
+func isAdoptedInto(cspc, config *unstructured.Unstructured) bool {
+	adoptInto := cspc.GetAnnotations()[types.AnnKeyCStorPoolClusterAdoptInto]
+	if adoptInto == "" {
+		return false
+	}
+	if adoptInto == config.GetNamespace()+"/"+config.GetName() {
+		return true
+	}
+	return adoptInto == config.GetName() &&
+		cspc.GetNamespace() == config.GetNamespace()
+}
+
+// SelectAdoptableCStorPoolCluster returns the CStorPoolCluster that
+// should be adopted by the given CStorClusterPlan along with the
+// remaining CStorPoolClusters
+//
+// NOTE:
+//	A CStorPoolCluster is adoptable if it is annotated to be adopted
+// into the config of the plan. If the plan belongs to a zone, the
+// CStorPoolCluster must be labeled with the same zone. More than one
+// adoptable CStorPoolCluster results in an error since pools of only
+// one CStorPoolCluster can be managed by a plan.
+func SelectAdoptableCStorPoolCluster(
+	clusterPlan *unstructured.Unstructured,
+	clusterConfig *unstructured.Unstructured,
+	candidates []*unstructured.Unstructured,
+) (*unstructured.Unstructured, []*unstructured.Unstructured, error) {
+	zone, _, _ := unstructured.NestedString(clusterPlan.Object, "spec", "zone")
+	var adoptables []*unstructured.Unstructured
+	var others []*unstructured.Unstructured
+	for _, candidate := range candidates {
+		if !isAdoptedInto(candidate, clusterConfig) ||
+			(zone != "" && candidate.GetLabels()[types.LblKeyZone] != zone) {
+			others = append(others, candidate)
+			continue
+		}
+		adoptables = append(adoptables, candidate)
+	}
+	if len(adoptables) > 1 {
+		var names []string
+		for _, adoptable := range adoptables {
+			names = append(names, adoptable.GetNamespace()+"/"+adoptable.GetName())
+		}
+		sort.Strings(names)
+		return nil, candidates, errors.Errorf(
+			"Can't adopt CStorPoolCluster: Want 1 CStorPoolCluster to adopt into %q: Got %d %v",
+			clusterConfig.GetName(), len(adoptables), names,
+		)
+	}
+	if len(adoptables) == 0 {
+		return nil, others, nil
+	}
+	return adoptables[0], others, nil
+}
+
+// SetAdoptedPoolsStatus sets the given adopted pools against the
+// given status of a CStorClusterPlan
+func SetAdoptedPoolsStatus(
+	status map[string]interface{}, adoptedPools []types.CStorClusterPlanAdoptedPool,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	if len(adoptedPools) == 0 {
+		delete(status, "adoptedPools")
+		return status, nil
+	}
+	var pools []interface{}
+	for _, pool := range adoptedPools {
+		pool := pool
+		poolMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&pool)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't set adopted pool %q", pool.HostName,
+			)
+		}
+		pools = append(pools, poolMap)
+	}
+	status["adoptedPools"] = pools
+	return status, nil
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorpoolcluster
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newAdoptableCSPC(namespace, name, adoptInto, zone string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetAnnotations(map[string]string{
+		types.AnnKeyCStorPoolClusterAdoptInto: adoptInto,
+	})
+	if zone != "" {
+		obj.SetLabels(map[string]string{types.LblKeyZone: zone})
+	}
+	return obj
+}
+
+func TestSelectAdoptableCStorPoolCluster(t *testing.T) {
+	config := &unstructured.Unstructured{}
+	config.SetNamespace("openebs")
+	config.SetName("my-config")
+	var tests = map[string]struct {
+		zone         string
+		candidates   []*unstructured.Unstructured
+		expectName   string
+		expectOthers int
+		isErr        bool
+	}{
+		"no candidates": {},
+		"adopt by name": {
+			candidates: []*unstructured.Unstructured{
+				newAdoptableCSPC("openebs", "cspc-1", "my-config", ""),
+			},
+			expectName: "cspc-1",
+		},
+		"adopt by namespaced name": {
+			candidates: []*unstructured.Unstructured{
+				newAdoptableCSPC("other", "cspc-1", "openebs/my-config", ""),
+			},
+			expectName: "cspc-1",
+		},
+		"name in a different namespace is not adopted": {
+			candidates: []*unstructured.Unstructured{
+				newAdoptableCSPC("other", "cspc-1", "my-config", ""),
+			},
+			expectOthers: 1,
+		},
+		"adopt into a different config": {
+			candidates: []*unstructured.Unstructured{
+				newAdoptableCSPC("openebs", "cspc-1", "my-config", ""),
+				newAdoptableCSPC("openebs", "cspc-2", "your-config", ""),
+			},
+			expectName:   "cspc-1",
+			expectOthers: 1,
+		},
+		"adopt by zone": {
+			zone: "zone-a",
+			candidates: []*unstructured.Unstructured{
+				newAdoptableCSPC("openebs", "cspc-1", "my-config", "zone-b"),
+				newAdoptableCSPC("openebs", "cspc-2", "my-config", "zone-a"),
+			},
+			expectName:   "cspc-2",
+			expectOthers: 1,
+		},
+		"more than one to adopt": {
+			candidates: []*unstructured.Unstructured{
+				newAdoptableCSPC("openebs", "cspc-1", "my-config", ""),
+				newAdoptableCSPC("openebs", "cspc-2", "my-config", ""),
+			},
+			expectOthers: 2,
+			isErr:        true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			plan := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"zone": mock.zone,
+					},
+				},
+			}
+			got, others, err := SelectAdoptableCStorPoolCluster(plan, config, mock.candidates)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if len(others) != mock.expectOthers {
+				t.Fatalf("Expected others %d got %d", mock.expectOthers, len(others))
+			}
+			var gotName string
+			if got != nil {
+				gotName = got.GetName()
+			}
+			if gotName != mock.expectName {
+				t.Fatalf("Expected adopted %q got %q", mock.expectName, gotName)
+			}
+		})
+	}
+}
+
+func TestPlannerInitNodeToAdoptedDevices(t *testing.T) {
+	var tests = map[string]struct {
+		cspcPlanUID               string
+		adoptedPools              []types.CStorClusterPlanAdoptedPool
+		nodeNameToCSPCDevices     map[string][]string
+		expectNodeToAdoptedDevice map[string][]string
+	}{
+		"cspc is being adopted": {
+			nodeNameToCSPCDevices: map[string][]string{
+				"node-1": []string{"bd-1", "bd-2"},
+				"node-2": []string{"bd-3", "bd-4"},
+			},
+			expectNodeToAdoptedDevice: map[string][]string{
+				"node-1": []string{"bd-1", "bd-2"},
+				"node-2": []string{"bd-3", "bd-4"},
+			},
+		},
+		"cspc was adopted": {
+			cspcPlanUID: "plan-101",
+			adoptedPools: []types.CStorClusterPlanAdoptedPool{
+				{HostName: "node-1", BlockDeviceNames: []string{"bd-1", "bd-2"}},
+			},
+			nodeNameToCSPCDevices: map[string][]string{
+				"node-1": []string{"bd-1", "bd-2", "bd-5"},
+				"node-2": []string{"bd-3", "bd-4"},
+			},
+			expectNodeToAdoptedDevice: map[string][]string{
+				"node-1": []string{"bd-1", "bd-2"},
+			},
+		},
+		"adopted pool was removed": {
+			cspcPlanUID: "plan-101",
+			adoptedPools: []types.CStorClusterPlanAdoptedPool{
+				{HostName: "node-1", BlockDeviceNames: []string{"bd-1", "bd-2"}},
+			},
+			nodeNameToCSPCDevices: map[string][]string{
+				"node-2": []string{"bd-3", "bd-4"},
+			},
+			expectNodeToAdoptedDevice: map[string][]string{},
+		},
+		"cspc was never adopted": {
+			cspcPlanUID: "plan-101",
+			nodeNameToCSPCDevices: map[string][]string{
+				"node-2": []string{"bd-3", "bd-4"},
+			},
+			expectNodeToAdoptedDevice: map[string][]string{},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			observed := &unstructured.Unstructured{}
+			observed.SetAnnotations(map[string]string{
+				types.AnnKeyCStorClusterPlanUID: mock.cspcPlanUID,
+			})
+			p := &Planner{
+				ObservedCStorClusterPlan: &types.CStorClusterPlan{
+					ObjectMeta: metav1.ObjectMeta{
+						UID: "plan-101",
+					},
+					Status: types.CStorClusterPlanStatus{
+						AdoptedPools: mock.adoptedPools,
+					},
+				},
+				ObservedCStorPoolCluster:      observed,
+				nodeNameToObservedCSPCDevices: mock.nodeNameToCSPCDevices,
+			}
+			err := p.initNodeToAdoptedDevices()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if !reflect.DeepEqual(p.nodeNameToAdoptedDevices, mock.expectNodeToAdoptedDevice) {
+				t.Fatalf(
+					"Expected adopted devices %v got %v",
+					mock.expectNodeToAdoptedDevice, p.nodeNameToAdoptedDevices,
+				)
+			}
+		})
+	}
+}
+
+func TestPlannerInitNodeToDesiredCSPCDevicesWithAdoptedDevices(t *testing.T) {
+	p := &Planner{
+		storageSetToObservedBlockDevices: map[string][]string{
+			"sset-101": []string{"bd-5", "bd-6"},
+		},
+		storageSetUIDToObservedNodeName: map[string]string{
+			"sset-101": "node-1",
+		},
+		nodeNameToObservedCSPCDevices: map[string][]string{
+			"node-1": []string{"bd-1", "bd-2"},
+			"node-2": []string{"bd-3", "bd-4"},
+		},
+		nodeNameToAdoptedDevices: map[string][]string{
+			"node-1": []string{"bd-1", "bd-2"},
+			"node-2": []string{"bd-3", "bd-4"},
+		},
+	}
+	err := p.initNodeToDesiredCSPCDevices()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	expect := map[string][]string{
+		"node-1": []string{"bd-1", "bd-2", "bd-5", "bd-6"},
+		"node-2": []string{"bd-3", "bd-4"},
+	}
+	if !reflect.DeepEqual(p.nodeNameToDesiredCSPCDevices, expect) {
+		t.Fatalf("Expected desired devices %v got %v", expect, p.nodeNameToDesiredCSPCDevices)
+	}
+	if len(p.getAdoptedPools()) != 2 {
+		t.Fatalf("Expected 2 adopted pools got %d", len(p.getAdoptedPools()))
+	}
+}
+
+func TestSetAdoptedPoolsStatus(t *testing.T) {
+	status, err := SetAdoptedPoolsStatus(nil, []types.CStorClusterPlanAdoptedPool{
+		{HostName: "node-1", BlockDeviceNames: []string{"bd-1"}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	pools, found, _ := unstructured.NestedSlice(status, "adoptedPools")
+	if !found || len(pools) != 1 {
+		t.Fatalf("Expected 1 adopted pool got %v", status)
+	}
+	status, err = SetAdoptedPoolsStatus(status, nil)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if _, found := status["adoptedPools"]; found {
+		t.Fatalf("Expected no adopted pools got %v", status)
+	}
+}
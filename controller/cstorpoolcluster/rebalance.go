@@ -0,0 +1,242 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorpoolcluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	stringcommon "mayadata.io/cstorpoolauto/common/string"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// RebalanceResult is the outcome of checking the raid group count
+// skew across the desired pool instances
+type RebalanceResult struct {
+	// IsSkewed is true if the raid group counts of any two pool
+	// instances differ by more than the max raid group skew
+	IsSkewed bool
+
+	// Reason explains the skew along with the suggested block
+	// device moves that remove this skew
+	Reason string
+}
+
+// rebalanceMove is a suggested move of the block devices of a raid
+// group from one node to another
+type rebalanceMove struct {
+	BlockDeviceNames []string
+	FromNodeName     string
+	ToNodeName       string
+}
+
+func (m rebalanceMove) String() string {
+	return fmt.Sprintf(
+		"Move %v from %q to %q", m.BlockDeviceNames, m.FromNodeName, m.ToNodeName,
+	)
+}
+
+// Rebalancer detects the raid group count skew across the desired
+// pool instances
+//
+// NOTE:
+//	Pools become unbalanced when block devices get added to a few
+// nodes only e.g. node A with 6 devices & node B with 2 devices of
+// a mirror pool. Skew is reported with the block device moves that
+// balance the raid group counts. Refuse policy additionally holds
+// back the raid groups beyond the max skew. Raid groups that are
+// already part of the observed pools are never held back.
+type Rebalancer struct {
+	// nil config disables the skew check
+	Config   *types.Rebalance
+	RAIDType types.PoolRAIDType
+
+	// sizes the raid groups; raid groups are sized as per the raid
+	// type if this is not set
+	RAIDGroupConfig *types.PoolRAIDGroupConfig
+
+	// node name to desired block devices of its pool
+	NodeNameToDesiredDevices map[string][]string
+
+	// node name to block devices of its pool in the observed
+	// CStorPoolCluster
+	NodeNameToObservedDevices map[string][]string
+}
+
+// Balance returns the node name to desired block devices after
+// holding back the raid groups that can't be added along with the
+// result of the skew check
+func (r *Rebalancer) Balance() (map[string][]string, RebalanceResult, error) {
+	if r.Config == nil || len(r.NodeNameToDesiredDevices) < 2 {
+		return r.NodeNameToDesiredDevices, RebalanceResult{}, nil
+	}
+	groupDiskCount := types.GetRAIDGroupDiskCount(r.RAIDType, r.RAIDGroupConfig)
+	if groupDiskCount <= 0 {
+		return nil, RebalanceResult{}, errors.Errorf(
+			"Can't check raid group skew: Unsupported raid type %q", r.RAIDType,
+		)
+	}
+	// nodes are sorted to suggest the same moves across syncs
+	var nodeNames []string
+	groupCounts := map[string]int64{}
+	for nodeName, deviceNames := range r.NodeNameToDesiredDevices {
+		nodeNames = append(nodeNames, nodeName)
+		groupCounts[nodeName] = int64(len(deviceNames)) / groupDiskCount
+	}
+	sort.Strings(nodeNames)
+	minNodeName, maxNodeName := findMinMaxNodeNames(nodeNames, groupCounts)
+	minGroupCount := groupCounts[minNodeName]
+	skew := groupCounts[maxNodeName] - minGroupCount
+	if skew <= r.Config.MaxRAIDGroupSkew {
+		return r.NodeNameToDesiredDevices, RebalanceResult{}, nil
+	}
+	var moves []string
+	for _, move := range r.suggestMoves(nodeNames, groupCounts, groupDiskCount) {
+		moves = append(moves, move.String())
+	}
+	result := RebalanceResult{
+		IsSkewed: true,
+		Reason: fmt.Sprintf(
+			"RAID group skew %d exceeds %d: %s",
+			skew, r.Config.MaxRAIDGroupSkew, strings.Join(moves, "; "),
+		),
+	}
+	if r.Config.Policy != types.RebalancePolicyRefuse {
+		return r.NodeNameToDesiredDevices, result, nil
+	}
+	allowedDiskCount := (minGroupCount + r.Config.MaxRAIDGroupSkew) * groupDiskCount
+	balanced := map[string][]string{}
+	for _, nodeName := range nodeNames {
+		balanced[nodeName] = r.holdBack(nodeName, allowedDiskCount)
+	}
+	return balanced, result, nil
+}
+
+// holdBack returns the desired block devices of the given node that
+// are either observed in its pool or fit within the given count
+func (r *Rebalancer) holdBack(nodeName string, allowedDiskCount int64) []string {
+	desired := r.NodeNameToDesiredDevices[nodeName]
+	if int64(len(desired)) <= allowedDiskCount {
+		return desired
+	}
+	observed := stringcommon.List(r.NodeNameToObservedDevices[nodeName])
+	var kept, heldBack []string
+	for _, name := range desired {
+		if observed.ContainsExact(name) || int64(len(kept)) < allowedDiskCount {
+			kept = append(kept, name)
+			continue
+		}
+		heldBack = append(heldBack, name)
+	}
+	if len(heldBack) != 0 {
+		glog.Warningf(
+			"Will not add BlockDevice(s) %v to pool of node %q: RAID group skew exceeds %d",
+			heldBack, nodeName, r.Config.MaxRAIDGroupSkew,
+		)
+	}
+	return kept
+}
+
+// suggestMoves returns the moves of raid groups from the node with
+// the most raid groups to the node with the least raid groups till
+// the skew is within the max raid group skew
+func (r *Rebalancer) suggestMoves(
+	nodeNames []string, observedGroupCounts map[string]int64, groupDiskCount int64,
+) []rebalanceMove {
+	groupCounts := map[string]int64{}
+	devices := map[string][]string{}
+	for _, nodeName := range nodeNames {
+		groupCounts[nodeName] = observedGroupCounts[nodeName]
+		devices[nodeName] = append([]string{}, r.NodeNameToDesiredDevices[nodeName]...)
+	}
+	var moves []rebalanceMove
+	for {
+		minNodeName, maxNodeName := findMinMaxNodeNames(nodeNames, groupCounts)
+		if groupCounts[maxNodeName]-groupCounts[minNodeName] <= r.Config.MaxRAIDGroupSkew {
+			return moves
+		}
+		// last raid group is the most recently added one
+		end := groupCounts[maxNodeName] * groupDiskCount
+		group := append([]string{}, devices[maxNodeName][end-groupDiskCount:end]...)
+		devices[maxNodeName] = append(
+			append([]string{}, devices[maxNodeName][:end-groupDiskCount]...),
+			devices[maxNodeName][end:]...,
+		)
+		devices[minNodeName] = append(devices[minNodeName], group...)
+		groupCounts[maxNodeName]--
+		groupCounts[minNodeName]++
+		moves = append(moves, rebalanceMove{
+			BlockDeviceNames: group,
+			FromNodeName:     maxNodeName,
+			ToNodeName:       minNodeName,
+		})
+	}
+}
+
+// findMinMaxNodeNames returns the nodes with the least & the most
+// raid groups. Ties are resolved by the order of the given nodes.
+func findMinMaxNodeNames(
+	nodeNames []string, groupCounts map[string]int64,
+) (string, string) {
+	var minNodeName, maxNodeName string
+	for _, nodeName := range nodeNames {
+		if minNodeName == "" || groupCounts[nodeName] < groupCounts[minNodeName] {
+			minNodeName = nodeName
+		}
+		if maxNodeName == "" || groupCounts[nodeName] > groupCounts[maxNodeName] {
+			maxNodeName = nodeName
+		}
+	}
+	return minNodeName, maxNodeName
+}
+
+// SetRebalanceCondition sets the RebalanceRecommended condition
+// against the given status of a CStorClusterPlan
+//
+// NOTE:
+//	Condition is reported only after a skew is detected. It is
+// marked absent once the skew is removed. Existing condition is
+// retained as is if neither its status nor its reason changed.
+func SetRebalanceCondition(
+	status map[string]interface{}, result RebalanceResult,
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	_, found, err := conditions.GetCondition(
+		status, types.CStorClusterPlanRebalanceRecommendedCondition,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set rebalance condition")
+	}
+	if !found && !result.IsSkewed {
+		return status, nil
+	}
+	status, err = conditions.SetCondition(
+		status,
+		types.MakeCStorClusterPlanRebalanceRecommendedCond(result.IsSkewed, result.Reason),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set rebalance condition")
+	}
+	return status, nil
+}
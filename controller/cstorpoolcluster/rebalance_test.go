@@ -0,0 +1,224 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cstorpoolcluster
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestRebalancerBalance(t *testing.T) {
+	var tests = map[string]struct {
+		config          *types.Rebalance
+		raidType        types.PoolRAIDType
+		desiredDevices  map[string][]string
+		observedDevices map[string][]string
+		expectDevices   map[string][]string
+		expectResult    RebalanceResult
+		isErr           bool
+	}{
+		"rebalance is not configured": {
+			raidType: types.PoolRAIDTypeMirror,
+			desiredDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+		},
+		"skew within max skew": {
+			config: &types.Rebalance{
+				MaxRAIDGroupSkew: 1,
+				Policy:           types.RebalancePolicyRecommend,
+			},
+			raidType: types.PoolRAIDTypeMirror,
+			desiredDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+		},
+		"skew is recommended to be rebalanced": {
+			config: &types.Rebalance{
+				MaxRAIDGroupSkew: 1,
+				Policy:           types.RebalancePolicyRecommend,
+			},
+			raidType: types.PoolRAIDTypeMirror,
+			desiredDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectResult: RebalanceResult{
+				IsSkewed: true,
+				Reason: `RAID group skew 2 exceeds 1: ` +
+					`Move [bd-5 bd-6] from "node-a" to "node-b"`,
+			},
+		},
+		"skew across many nodes is recommended to be rebalanced": {
+			config: &types.Rebalance{
+				MaxRAIDGroupSkew: 1,
+			},
+			raidType: types.PoolRAIDTypeStripe,
+			desiredDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5"},
+				"node-b": []string{"bd-6"},
+				"node-c": []string{"bd-7"},
+			},
+			expectDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5"},
+				"node-b": []string{"bd-6"},
+				"node-c": []string{"bd-7"},
+			},
+			expectResult: RebalanceResult{
+				IsSkewed: true,
+				Reason: `RAID group skew 4 exceeds 1: ` +
+					`Move [bd-5] from "node-a" to "node-b"; ` +
+					`Move [bd-4] from "node-a" to "node-c"`,
+			},
+		},
+		"skew is refused": {
+			config: &types.Rebalance{
+				MaxRAIDGroupSkew: 1,
+				Policy:           types.RebalancePolicyRefuse,
+			},
+			raidType: types.PoolRAIDTypeMirror,
+			desiredDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			observedDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectResult: RebalanceResult{
+				IsSkewed: true,
+				Reason: `RAID group skew 2 exceeds 1: ` +
+					`Move [bd-5 bd-6] from "node-a" to "node-b"`,
+			},
+		},
+		"refuse retains the observed raid groups": {
+			config: &types.Rebalance{
+				MaxRAIDGroupSkew: 1,
+				Policy:           types.RebalancePolicyRefuse,
+			},
+			raidType: types.PoolRAIDTypeMirror,
+			desiredDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			observedDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectDevices: map[string][]string{
+				"node-a": []string{"bd-1", "bd-2", "bd-3", "bd-4", "bd-5", "bd-6"},
+				"node-b": []string{"bd-7", "bd-8"},
+			},
+			expectResult: RebalanceResult{
+				IsSkewed: true,
+				Reason: `RAID group skew 2 exceeds 1: ` +
+					`Move [bd-5 bd-6] from "node-a" to "node-b"`,
+			},
+		},
+		"unsupported raid type": {
+			config: &types.Rebalance{
+				MaxRAIDGroupSkew: 1,
+			},
+			raidType: "junk",
+			desiredDevices: map[string][]string{
+				"node-a": []string{"bd-1"},
+				"node-b": []string{"bd-2"},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Rebalancer{
+				Config:                    mock.config,
+				RAIDType:                  mock.raidType,
+				NodeNameToDesiredDevices:  mock.desiredDevices,
+				NodeNameToObservedDevices: mock.observedDevices,
+			}
+			gotDevices, gotResult, err := r.Balance()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expectDevices, gotDevices); diff != "" {
+				t.Fatalf("Expected no devices diff got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectResult, gotResult); diff != "" {
+				t.Fatalf("Expected no result diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSetRebalanceCondition(t *testing.T) {
+	status, err := SetRebalanceCondition(nil, RebalanceResult{})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if _, found := status["conditions"]; found {
+		t.Fatalf("Expected no conditions got %v", status)
+	}
+	status, err = SetRebalanceCondition(status, RebalanceResult{
+		IsSkewed: true, Reason: "skewed",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	conds, _, _ := unstructured.NestedSlice(status, "conditions")
+	if len(conds) != 1 ||
+		conds[0].(map[string]interface{})["status"] != string(types.ConditionIsPresent) {
+		t.Fatalf("Expected 1 present condition got %v", conds)
+	}
+	status, err = SetRebalanceCondition(status, RebalanceResult{})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	conds, _, _ = unstructured.NestedSlice(status, "conditions")
+	if len(conds) != 1 ||
+		conds[0].(map[string]interface{})["status"] != string(types.ConditionIsAbsent) {
+		t.Fatalf("Expected 1 absent condition got %v", conds)
+	}
+}
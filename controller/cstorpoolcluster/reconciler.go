@@ -17,6 +17,10 @@ limitations under the License.
 package cstorpoolcluster
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -24,12 +28,37 @@ import (
 	"k8s.io/apimachinery/pkg/util/json"
 	"openebs.io/metac/controller/generic"
 
+	"mayadata.io/cstorpoolauto/common/adoption"
+	bdc "mayadata.io/cstorpoolauto/common/blockdeviceclaim"
+	"mayadata.io/cstorpoolauto/common/cspcexport"
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	cspccommon "mayadata.io/cstorpoolauto/common/cstorpoolcluster/v1alpha1"
+	"mayadata.io/cstorpoolauto/common/drift"
+	"mayadata.io/cstorpoolauto/common/generation"
+	"mayadata.io/cstorpoolauto/common/ignorefields"
 	"mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/metadata"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	"mayadata.io/cstorpoolauto/common/overlay"
+	"mayadata.io/cstorpoolauto/common/owner"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/raidchange"
+	"mayadata.io/cstorpoolauto/common/remediation"
+	"mayadata.io/cstorpoolauto/common/skip"
 	stringcommon "mayadata.io/cstorpoolauto/common/string"
+	"mayadata.io/cstorpoolauto/common/syncdiff"
+	"mayadata.io/cstorpoolauto/pkg/cspc"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/faultinject"
+	"mayadata.io/cstorpoolauto/pkg/index"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 )
 
+// controllerName is reported against the skipped reconciliations
+// of CStorPoolCluster
+const controllerName = "CStorPoolCluster"
+
 // reconcileErrHandler logs the error & updates these errors
 // against CStorClusterPlan status conditions
 type reconcileErrHandler struct {
@@ -44,6 +73,10 @@ func (h *reconcileErrHandler) handle(err error) {
 	)
 	// will skip reconciliation process at metac since there was an error
 	h.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(err) {
+		h.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(err)
+	}
 }
 
 // Sync implements the idempotent logic to apply a CStorPoolCluster
@@ -89,14 +122,61 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 	var observedCStorPoolCluster *unstructured.Unstructured
 	var observedClusterConfig *unstructured.Unstructured
 	var observedBlockDevices []*unstructured.Unstructured
+	var observedBlockDeviceClaims []*unstructured.Unstructured
 	var observedStorageSets []*unstructured.Unstructured
+	var observedCStorPoolInstances []*unstructured.Unstructured
+	var observedNodes []*unstructured.Unstructured
+	// manually created CStorPoolCluster(s) that may get adopted
+	var adoptableCStorPoolClusters []*unstructured.Unstructured
+	// CStorPoolCluster(s) of the config owned by other controllers
+	var otherCStorPoolClusters []*unstructured.Unstructured
+	// resources annotated by an older release are adopted before
+	// these are matched against the watch
+	adoption.Adopt(request.Watch, adoption.LegacyKeys)
+	adoption.AdoptAll(request.Attachments.List(), adoption.LegacyKeys)
+
+	configUID, _ := unstruct.GetValueForKey(
+		request.Watch.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+	)
 	for _, attachment := range request.Attachments.List() {
+		if attachment.GetKind() == string(types.KindEvent) {
+			// events are observed only to be applied again as
+			// desired events after remediation
+			continue
+		}
+		if cspcexport.IsExport(attachment) &&
+			attachment.GetAnnotations()[types.AnnKeyCStorClusterConfigUID] == configUID {
+			// exported CStorPoolCluster is added to response later
+			// if the config is still exported
+			continue
+		}
+		if attachment.GetKind() == string(types.KindCStorPoolInstance) {
+			// pool instances are filtered by remediation
+			observedCStorPoolInstances =
+				append(observedCStorPoolInstances, attachment)
+		}
+		if attachment.GetKind() == string(types.KindNode) {
+			// nodes resolve the hostnames of the pools
+			observedNodes = append(observedNodes, attachment)
+		}
 		if attachment.GetKind() == string(types.KindCStorPoolCluster) {
 			// verify further if this belongs to the current watch
 			// i.e. CStorClusterPlan
 			uid, _ := unstruct.GetValueForKey(
 				attachment.GetAnnotations(), types.AnnKeyCStorClusterPlanUID,
 			)
+			cspcConfigUID, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+			)
+			isOfWatch := string(request.Watch.GetUID()) == uid ||
+				(configUID != "" && cspcConfigUID == configUID)
+			current := owner.Get(attachment)
+			if isOfWatch && current != "" && current != owner.CStorPoolCluster {
+				// this is added to response later if not taken over
+				otherCStorPoolClusters =
+					append(otherCStorPoolClusters, attachment)
+				continue
+			}
 			if string(request.Watch.GetUID()) == uid {
 				// this is the desired CStorPoolCluster
 				observedCStorPoolCluster = attachment
@@ -104,6 +184,12 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 				// as **desired state** after its reconciliation
 				continue
 			}
+			if uid == "" && attachment.GetAnnotations()[types.AnnKeyCStorPoolClusterAdoptInto] != "" {
+				// this is added to response later if not adopted
+				adoptableCStorPoolClusters =
+					append(adoptableCStorPoolClusters, attachment)
+				continue
+			}
 		}
 		if attachment.GetKind() == string(types.KindBlockDevice) {
 			// verify further if this belongs to the current watch
@@ -136,6 +222,18 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 			// finally this is one of the desired BlockDevice(s)
 			observedBlockDevices = append(observedBlockDevices, attachment)
 		}
+		if attachment.GetKind() == string(types.KindBlockDeviceClaim) {
+			// verify further if this belongs to the CStorClusterConfig
+			// of the current watch i.e. CStorClusterPlan
+			uid, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+			)
+			if uid != "" && configUID == uid {
+				// this is one of the desired BlockDeviceClaim(s)
+				observedBlockDeviceClaims =
+					append(observedBlockDeviceClaims, attachment)
+			}
+		}
 		if attachment.GetKind() == string(types.KindCStorClusterStorageSet) {
 			// verify further if this belongs to the current watch
 			// i.e. CStorClusterPlan
@@ -150,10 +248,7 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 		if attachment.GetKind() == string(types.KindCStorClusterConfig) {
 			// verify further if this belongs to the current watch
 			// i.e. CStorClusterPlan
-			uid, _ := unstruct.GetValueForKey(
-				request.Watch.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
-			)
-			if string(attachment.GetUID()) == uid {
+			if string(attachment.GetUID()) == configUID {
 				// this is the desired CStorClusterConfig
 				observedClusterConfig = attachment
 			}
@@ -166,13 +261,98 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 	if observedClusterConfig == nil {
 		return errors.Errorf("CStorClusterConfig instance was not found")
 	}
+	adopted, others, err := SelectAdoptableCStorPoolCluster(
+		request.Watch, observedClusterConfig, adoptableCStorPoolClusters,
+	)
+	response.Attachments = append(response.Attachments, others...)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if observedCStorPoolCluster == nil && adopted != nil {
+		glog.V(2).Infof(
+			"Will adopt CStorPoolCluster %q / %q: CStorClusterPlan %q / %q",
+			adopted.GetNamespace(), adopted.GetName(),
+			request.Watch.GetNamespace(), request.Watch.GetName(),
+		)
+		observedCStorPoolCluster = adopted
+	} else if adopted != nil {
+		// plan manages a CStorPoolCluster already
+		response.Attachments = append(response.Attachments, adopted)
+	}
+	isPaused, err := pause.Skip(
+		controllerName, observedClusterConfig, request.Watch, response,
+	)
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if isPaused {
+		return nil
+	}
+	_, isHybrid, err :=
+		ccc.NewHelper(observedClusterConfig).GetHybridDiskNodeOverrides()
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
+	if isHybrid {
+		// pools of local as well as external disks belong to the
+		// CStorPoolCluster applied by LocalDevice controller
+		err = skip.Skip(
+			controllerName, request.Watch, response,
+			skip.ReasonNotOwner,
+			"Hybrid disk config: CStorPoolCluster is applied by LocalDevice",
+		)
+		if err != nil {
+			errHandler.handle(err)
+		}
+		return nil
+	}
+	ownership := owner.Arbitrate(
+		owner.CStorPoolCluster, observedClusterConfig, otherCStorPoolClusters,
+	)
+	if !ownership.IsOwner {
+		// CStorPoolCluster is applied by its owner
+		err = skip.Skip(
+			controllerName, request.Watch, response,
+			skip.ReasonNotOwner, ownership.Reason,
+		)
+		if err != nil {
+			errHandler.handle(err)
+		}
+		return nil
+	}
+	for _, taken := range ownership.TakenOver {
+		takenPlanUID, _ := unstruct.GetValueForKey(
+			taken.GetAnnotations(), types.AnnKeyCStorClusterPlanUID,
+		)
+		if observedCStorPoolCluster == nil &&
+			(takenPlanUID == string(request.Watch.GetUID()) ||
+				taken.GetName() == request.Watch.GetName()) {
+			// taken over CStorPoolCluster was either planned by this
+			// plan earlier or is named after this plan
+			observedCStorPoolCluster = taken
+			continue
+		}
+		response.Attachments = append(response.Attachments, taken)
+	}
+
+	outputMode, err := ccc.NewHelper(observedClusterConfig).GetOutputMode()
+	if err != nil {
+		errHandler.handle(err)
+		return nil
+	}
 
 	reconciler, err := NewReconciler(ReconcilerConfig{
-		ObservedCStorClusterPlan: request.Watch,
-		ObservedCStorPoolCluster: observedCStorPoolCluster,
-		ObservedClusterConfig:    observedClusterConfig,
-		ObservedStorageSets:      observedStorageSets,
-		ObservedBlockDevices:     observedBlockDevices,
+		ObservedCStorClusterPlan:   request.Watch,
+		ObservedCStorPoolCluster:   observedCStorPoolCluster,
+		ObservedClusterConfig:      observedClusterConfig,
+		ObservedStorageSets:        observedStorageSets,
+		ObservedBlockDevices:       observedBlockDevices,
+		ObservedBlockDeviceClaims:  observedBlockDeviceClaims,
+		ObservedCStorPoolInstances: observedCStorPoolInstances,
+		ObservedNodes:              observedNodes,
 	})
 	if err != nil {
 		errHandler.handle(err)
@@ -185,11 +365,107 @@ func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse)
 	}
 	// Cluster may or may not be **ready** to create a CStorPoolCluster
 	if op.DesiredCStorPoolCluster != nil {
-		response.Attachments = append(response.Attachments, op.DesiredCStorPoolCluster)
+		// changes applied against the observed CStorPoolCluster
+		var syncDiff syncdiff.Result
+		owner.Set(op.DesiredCStorPoolCluster, owner.CStorPoolCluster)
+		if outputMode == types.OutputModeExport {
+			// CStorPoolCluster is applied by the team's own tooling
+			exporter := cspcexport.Exporter{
+				ClusterConfig: observedClusterConfig,
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					observedCStorPoolCluster,
+				},
+			}
+			exported, err := exporter.Export(
+				[]*unstructured.Unstructured{op.DesiredCStorPoolCluster},
+			)
+			if err != nil {
+				errHandler.handle(err)
+				return nil
+			}
+			response.Attachments = append(response.Attachments, exported...)
+		} else {
+			syncDiff, err = syncdiff.Compute(
+				string(request.Watch.GetUID()),
+				observedCStorPoolCluster,
+				op.DesiredCStorPoolCluster,
+			)
+			if err != nil {
+				errHandler.handle(err)
+				return nil
+			}
+			if syncDiff.HasDiff {
+				glog.V(2).Infof(
+					"Will update CStorPoolCluster %q / %q: CStorClusterPlan %q / %q:\n%s",
+					op.DesiredCStorPoolCluster.GetNamespace(),
+					op.DesiredCStorPoolCluster.GetName(),
+					request.Watch.GetNamespace(), request.Watch.GetName(),
+					syncDiff.Render(),
+				)
+			}
+			response.Attachments = append(response.Attachments, op.DesiredCStorPoolCluster)
+		}
+		// events of unhealthy pool instances if any
+		response.Attachments = append(response.Attachments, op.Remediation.Events...)
+		status, _, _ := unstructured.NestedMap(request.Watch.Object, "status")
+		// drift condition is reported only after a drift is detected
+		if op.IsDrifted || drift.HasCondition(request.Watch) {
+			status, err = drift.SetCondition(
+				status,
+				drift.Result{IsDrifted: op.IsDrifted, Reason: op.DriftReason},
+			)
+			if err != nil {
+				errHandler.handle(err)
+				return nil
+			}
+		}
+		// sync diff condition is reported only after a diff is found
+		if syncDiff.HasDiff || syncdiff.HasCondition(request.Watch) {
+			status, err = syncdiff.SetCondition(status, syncDiff)
+			if err != nil {
+				errHandler.handle(err)
+				return nil
+			}
+		}
+		status, err = remediation.SetStatus(status, op.Remediation)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
+		status, err = SetRebalanceCondition(status, op.Rebalance)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
+		status, err = raidchange.SetCondition(status, op.RAIDTypeChange)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
+		response.Status, err = SetAdoptedPoolsStatus(status, op.AdoptedPools)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
+		response.Status = generation.SetObservedGeneration(request.Watch, response.Status)
+		err = skip.Clear(controllerName, request.Watch, response)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
 	} else {
 		// will stop further reconciliation at metac since cluster is
 		// not ready to create CStorPoolCluster
-		response.SkipReconcile = true
+		var reason, msg = skip.ReasonClusterNotReady,
+			"Cluster is not ready to form CStorPoolCluster"
+		if op.WaitingReason != "" {
+			reason, msg = skip.ReasonWaitingForReadyNodes, op.WaitingReason
+		}
+		err = skip.Skip(controllerName, request.Watch, response, reason, msg)
+		if err != nil {
+			errHandler.handle(err)
+			return nil
+		}
 		// trigger a new reconciliation after configured seconds
 		// hoping that cluster will be ready to form CStorPoolCluster
 		response.ResyncAfterSeconds = 3
@@ -211,6 +487,15 @@ type Reconciler struct {
 	ObservedClusterConfig    *unstructured.Unstructured
 	ObservedStorageSets      []*unstructured.Unstructured
 	ObservedBlockDevices     []*unstructured.Unstructured
+
+	// claims that belong to the observed CStorClusterConfig
+	ObservedBlockDeviceClaims []*unstructured.Unstructured
+
+	// pool instances that are checked for remediation
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+
+	// nodes that resolve the hostnames of the pools
+	ObservedNodes []*unstructured.Unstructured
 }
 
 // ReconcilerConfig is a helper structure used to create a
@@ -221,6 +506,15 @@ type ReconcilerConfig struct {
 	ObservedClusterConfig    *unstructured.Unstructured
 	ObservedStorageSets      []*unstructured.Unstructured
 	ObservedBlockDevices     []*unstructured.Unstructured
+
+	// claims that belong to the observed CStorClusterConfig
+	ObservedBlockDeviceClaims []*unstructured.Unstructured
+
+	// pool instances that are checked for remediation
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+
+	// nodes that resolve the hostnames of the pools
+	ObservedNodes []*unstructured.Unstructured
 }
 
 // ReconcileResponse forms the response due to reconciliation of
@@ -228,6 +522,31 @@ type ReconcilerConfig struct {
 type ReconcileResponse struct {
 	DesiredCStorPoolCluster *unstructured.Unstructured
 	Status                  map[string]interface{}
+
+	// IsDrifted is true if manual edits to the pools of
+	// CStorPoolCluster are retained
+	IsDrifted   bool
+	DriftReason string
+
+	// Remediation reports the unhealthy pool instances & the
+	// events that should be applied
+	Remediation remediation.Result
+
+	// AdoptedPools are the pools of the adopted CStorPoolCluster
+	// that are retained
+	AdoptedPools []types.CStorClusterPlanAdoptedPool
+
+	// Rebalance reports the raid group count skew across the
+	// pool instances
+	Rebalance RebalanceResult
+
+	// RAIDTypeChange reports the progress of re-creating the pools
+	// whose raid type differs from the desired raid type
+	RAIDTypeChange raidchange.Result
+
+	// WaitingReason is set if CStorPoolCluster is not created since
+	// fewer than the min ready nodes are ready
+	WaitingReason string
 }
 
 // NewReconciler returns a new instance of reconciler
@@ -244,11 +563,14 @@ func NewReconciler(conf ReconcilerConfig) (*Reconciler, error) {
 	}
 	// use above constructed object to build Reconciler instance
 	return &Reconciler{
-		ObservedCStorClusterPlan: &cstorClusterPlanTyped,
-		ObservedCStorPoolCluster: conf.ObservedCStorPoolCluster,
-		ObservedClusterConfig:    conf.ObservedClusterConfig,
-		ObservedStorageSets:      conf.ObservedStorageSets,
-		ObservedBlockDevices:     conf.ObservedBlockDevices,
+		ObservedCStorClusterPlan:   &cstorClusterPlanTyped,
+		ObservedCStorPoolCluster:   conf.ObservedCStorPoolCluster,
+		ObservedClusterConfig:      conf.ObservedClusterConfig,
+		ObservedStorageSets:        conf.ObservedStorageSets,
+		ObservedBlockDevices:       conf.ObservedBlockDevices,
+		ObservedBlockDeviceClaims:  conf.ObservedBlockDeviceClaims,
+		ObservedCStorPoolInstances: conf.ObservedCStorPoolInstances,
+		ObservedNodes:              conf.ObservedNodes,
 	}, nil
 }
 
@@ -256,22 +578,118 @@ func NewReconciler(conf ReconcilerConfig) (*Reconciler, error) {
 // state
 func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
 	planner := Planner{
-		ObservedCStorClusterPlan: r.ObservedCStorClusterPlan,
-		ObservedCStorPoolCluster: r.ObservedCStorPoolCluster,
-		ObservedClusterConfig:    r.ObservedClusterConfig,
-		ObservedStorageSets:      r.ObservedStorageSets,
-		ObservedBlockDevices:     r.ObservedBlockDevices,
+		ObservedCStorClusterPlan:   r.ObservedCStorClusterPlan,
+		ObservedCStorPoolCluster:   r.ObservedCStorPoolCluster,
+		ObservedClusterConfig:      r.ObservedClusterConfig,
+		ObservedStorageSets:        r.ObservedStorageSets,
+		ObservedBlockDevices:       r.ObservedBlockDevices,
+		ObservedBlockDeviceClaims:  r.ObservedBlockDeviceClaims,
+		ObservedCStorPoolInstances: r.ObservedCStorPoolInstances,
+		ObservedNodes:              r.ObservedNodes,
 	}
 	desiredCStorPoolCluster, err := planner.Plan()
 	if err != nil {
 		return ReconcileResponse{}, err
 	}
+	var driftResult = drift.Result{CStorPoolCluster: desiredCStorPoolCluster}
+	var raidChangeResult = raidchange.Result{CStorPoolCluster: desiredCStorPoolCluster}
+	if desiredCStorPoolCluster != nil {
+		driftResult, err = r.resolveDrift(desiredCStorPoolCluster)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+		raidChangeResult, err = r.orchestrateRAIDTypeChange(
+			driftResult.CStorPoolCluster, types.PoolRAIDType(planner.desiredRAIDType),
+		)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+		err = r.retainIgnoredFields(raidChangeResult.CStorPoolCluster)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+		err = r.applyOverlays(raidChangeResult.CStorPoolCluster)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+	}
 	return ReconcileResponse{
-		DesiredCStorPoolCluster: desiredCStorPoolCluster,
+		DesiredCStorPoolCluster: raidChangeResult.CStorPoolCluster,
 		Status:                  r.getClusterPlanStatusAsNoError(),
+		IsDrifted:               driftResult.IsDrifted,
+		DriftReason:             driftResult.Reason,
+		Remediation:             planner.remediationResult,
+		AdoptedPools:            planner.getAdoptedPools(),
+		Rebalance:               planner.rebalanceResult,
+		RAIDTypeChange:          raidChangeResult,
+		WaitingReason:           planner.waitingReason,
 	}, nil
 }
 
+// orchestrateRAIDTypeChange re-creates the pools of observed
+// CStorPoolCluster whose raid type differs from the desired raid
+// type once this change is approved
+func (r *Reconciler) orchestrateRAIDTypeChange(
+	desiredCStorPoolCluster *unstructured.Unstructured, desiredRAIDType types.PoolRAIDType,
+) (raidchange.Result, error) {
+	approved, _ := unstruct.GetValueForKey(
+		r.ObservedClusterConfig.GetAnnotations(),
+		types.AnnKeyCStorClusterConfigApproveRAIDTypeChange,
+	)
+	orchestrator := &raidchange.Orchestrator{
+		DesiredRAIDType:            desiredRAIDType,
+		ApprovedRAIDType:           types.PoolRAIDType(approved),
+		ObservedCStorPoolCluster:   r.ObservedCStorPoolCluster,
+		DesiredCStorPoolCluster:    desiredCStorPoolCluster,
+		ObservedCStorPoolInstances: r.ObservedCStorPoolInstances,
+	}
+	return orchestrator.Orchestrate()
+}
+
+// retainIgnoredFields sets the observed values of the fields that
+// are ignored from reconciliation against the desired
+// CStorPoolCluster
+func (r *Reconciler) retainIgnoredFields(
+	desiredCStorPoolCluster *unstructured.Unstructured,
+) error {
+	paths, err := ccc.NewHelper(r.ObservedClusterConfig).GetIgnoreFields()
+	if err != nil {
+		return err
+	}
+	return ignorefields.Retain(paths, r.ObservedCStorPoolCluster, desiredCStorPoolCluster)
+}
+
+// applyOverlays patches the desired CStorPoolCluster with the
+// overlays set in CStorClusterConfig
+func (r *Reconciler) applyOverlays(
+	desiredCStorPoolCluster *unstructured.Unstructured,
+) error {
+	overlays, err := ccc.NewHelper(r.ObservedClusterConfig).GetOverlays()
+	if err != nil {
+		return err
+	}
+	return overlay.Apply(desiredCStorPoolCluster, overlays)
+}
+
+// resolveDrift resolves the manual edits made to the pools of
+// observed CStorPoolCluster based on the drift policy set in
+// CStorClusterConfig
+func (r *Reconciler) resolveDrift(
+	desiredCStorPoolCluster *unstructured.Unstructured,
+) (drift.Result, error) {
+	policy, err := ccc.NewHelper(r.ObservedClusterConfig).GetDriftPolicy()
+	if err != nil {
+		return drift.Result{}, err
+	}
+	resolver := &drift.Resolver{
+		Policy:                   policy,
+		WatchUID:                 string(r.ObservedCStorClusterPlan.GetUID()),
+		ObservedCStorPoolCluster: r.ObservedCStorPoolCluster,
+		DesiredCStorPoolCluster:  desiredCStorPoolCluster,
+	}
+	return resolver.Resolve()
+}
+
 func (r *Reconciler) getClusterPlanStatusAsNoError() map[string]interface{} {
 	types.MergeNoCSPCApplyErrorOnCStorClusterPlan(r.ObservedCStorClusterPlan)
 	return map[string]interface{}{
@@ -290,6 +708,18 @@ type Planner struct {
 	ObservedStorageSets      []*unstructured.Unstructured
 	ObservedBlockDevices     []*unstructured.Unstructured
 
+	// claims that belong to the observed CStorClusterConfig
+	ObservedBlockDeviceClaims []*unstructured.Unstructured
+
+	// pool instances that are checked for remediation
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+
+	// nodes that resolve the hostnames of the pools
+	ObservedNodes []*unstructured.Unstructured
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+
 	// Node name to StorageSet UID
 	nodeNameToObservedStorageSetUID map[string]string
 
@@ -316,15 +746,67 @@ type Planner struct {
 	// to those found in CStorPoolCluster spec
 	nodeNameToDesiredCSPCDevices map[string][]string
 
+	// Node name to BlockDevices of the pools of an adopted
+	// CStorPoolCluster
+	nodeNameToAdoptedDevices map[string][]string
+
+	// resolves the hostnames of the planned nodes
+	hostNameResolver *nodecommon.HostNameResolver
+
+	// resolves the node selector values of the planned nodes i.e.
+	// the values of the label that the pools select their nodes by
+	nodeSelectorResolver *nodecommon.HostNameResolver
+
+	// label key used by the pools to select their nodes
+	desiredNodeSelectorKey string
+
 	desiredRAIDType string
+
+	// sizes the raid groups of every pool; raid groups are sized as
+	// per the raid type if this is not set
+	desiredRAIDGroupConfig *types.PoolRAIDGroupConfig
+
+	// ZFS properties to be set against every pool
+	desiredZFSProperties map[string]string
+
+	// labels & annotations to be propagated to CStorPoolCluster
+	desiredChildMetadata *types.ChildMetadata
+
+	// namespace of the desired CStorPoolCluster
+	desiredNamespace string
+
+	// remediation of the unhealthy pool instances
+	remediationResult remediation.Result
+
+	// block devices that are no longer used to plan pools since
+	// these were replaced by remediation
+	replacedBlockDeviceNames map[string]bool
+
+	// raid group count skew across the desired pool instances
+	rebalanceResult RebalanceResult
+
+	// number of nodes that need to have all their disks before
+	// CStorPoolCluster is created
+	desiredMinReadyNodes int64
+
+	// reason to wait for more nodes to be ready before creating
+	// CStorPoolCluster
+	waitingReason string
 }
 
 func (p *Planner) init() error {
 	var initFuncs = []func() error{
+		p.initHostNameResolver,
 		p.initStorageSetMappings,
 		p.initDesiredRAIDType,
+		p.initDesiredZFSProperties,
+		p.initDesiredChildMetadata,
+		p.initDesiredNamespace,
+		p.initDesiredMinReadyNodes,
 		p.initStorageSetToObservedBlockDevices,
 		p.initNodeToObservedCSPCDevices,
+		p.initNodeToAdoptedDevices,
+		p.initRemediation,
 		p.initNodeToDesiredCSPCDevices,
 	}
 	for _, fn := range initFuncs {
@@ -339,6 +821,7 @@ func (p *Planner) init() error {
 func (p *Planner) isReady() bool {
 	var isReadyFuncs = []func() bool{
 		p.isReadyByNodeCount,
+		p.isReadyByMinReadyNodes,
 		p.isReadyByNodeDiskCount,
 	}
 	for _, isready := range isReadyFuncs {
@@ -381,6 +864,41 @@ func (p *Planner) isReadyByNodeCount() bool {
 	return true
 }
 
+// isReadyByMinReadyNodes will return false if CStorPoolCluster is
+// not yet created & fewer than the min ready nodes have all their
+// desired disks
+//
+// NOTE:
+//	Pools are created together once enough nodes are ready. This
+// avoids skewed data placement when mirror or raidz pools get added
+// one at a time.
+func (p *Planner) isReadyByMinReadyNodes() bool {
+	if p.ObservedCStorPoolCluster != nil || p.desiredMinReadyNodes == 0 {
+		return true
+	}
+	var readyNodeCount int64
+	for storageSetUID, desiredDiskCount := range p.storageSetUIDToDesiredDiskCount {
+		observedDeviceCount := int64(len(p.storageSetToObservedBlockDevices[storageSetUID]))
+		if desiredDiskCount.CmpInt64(observedDeviceCount) <= 0 {
+			readyNodeCount++
+		}
+	}
+	if readyNodeCount >= p.desiredMinReadyNodes {
+		return true
+	}
+	p.waitingReason = fmt.Sprintf(
+		"%d of %d min ready nodes have all their disks",
+		readyNodeCount, p.desiredMinReadyNodes,
+	)
+	glog.V(3).Infof(
+		"Skip CStorPoolCluster %q / %q: %s",
+		p.ObservedCStorClusterPlan.GetNamespace(),
+		p.ObservedCStorClusterPlan.GetName(),
+		p.waitingReason,
+	)
+	return false
+}
+
 // isReadyByNodeDiskCount will return false if node
 // does not have desired disks
 //
@@ -419,6 +937,65 @@ func (p *Planner) initDesiredRAIDType() error {
 		return err
 	}
 	p.desiredRAIDType = raidType
+	p.desiredRAIDGroupConfig, err =
+		ccc.NewHelper(p.ObservedClusterConfig).GetRAIDGroupConfig()
+	return err
+}
+
+// initDesiredZFSProperties extracts the ZFS properties from
+// CStorClusterConfig that need to be set against the pools
+func (p *Planner) initDesiredZFSProperties() (err error) {
+	p.desiredZFSProperties, err =
+		ccc.NewHelper(p.ObservedClusterConfig).GetZFSProperties()
+	return
+}
+
+// initDesiredChildMetadata extracts the labels & annotations from
+// CStorClusterConfig that need to be propagated to CStorPoolCluster
+func (p *Planner) initDesiredChildMetadata() (err error) {
+	p.desiredChildMetadata, err =
+		ccc.NewHelper(p.ObservedClusterConfig).GetChildMetadata()
+	return
+}
+
+// initDesiredNamespace extracts the namespace from CStorClusterConfig
+// where CStorPoolCluster should be created
+//
+// NOTE:
+//	Observed CStorPoolCluster is retained in its namespace
+func (p *Planner) initDesiredNamespace() (err error) {
+	p.desiredNamespace, err =
+		ccc.NewHelper(p.ObservedClusterConfig).
+			GetTargetNamespaceOrObserved(p.ObservedCStorPoolCluster)
+	return
+}
+
+// initDesiredMinReadyNodes extracts the number of nodes from
+// CStorClusterConfig that need to be ready before CStorPoolCluster
+// is created
+func (p *Planner) initDesiredMinReadyNodes() (err error) {
+	p.desiredMinReadyNodes, err =
+		ccc.NewHelper(p.ObservedClusterConfig).GetMinReadyNodesBeforeCreate()
+	return
+}
+
+// initHostNameResolver builds the mapping of node name to hostname
+// from the observed nodes
+//
+// NOTE:
+//	Pools of CStorPoolCluster select their nodes by hostname whereas
+// storage sets refer to their nodes by name. Pools may instead
+// select their nodes by a custom label set in CStorClusterConfig.
+func (p *Planner) initHostNameResolver() (err error) {
+	p.hostNameResolver = nodecommon.NewHostNameResolver(p.ObservedNodes)
+	p.desiredNodeSelectorKey, err =
+		ccc.NewHelper(p.ObservedClusterConfig).GetNodeSelectorKey()
+	if err != nil {
+		return err
+	}
+	p.nodeSelectorResolver = nodecommon.NewHostNameResolverWithKey(
+		p.ObservedNodes, p.desiredNodeSelectorKey,
+	)
 	return nil
 }
 
@@ -452,22 +1029,47 @@ func (p *Planner) initStorageSetMappings() error {
 
 // initStorageSetToObservedBlockDevices maps CStorClusterStorageSet UID
 // to observed BlockDevice(s)
+//
+// NOTE:
+//	BlockDevice(s) that are not yet claimed are not mapped. These
+// get mapped once their BlockDeviceClaim(s) are bound.
 func (p *Planner) initStorageSetToObservedBlockDevices() error {
 	p.storageSetToObservedBlockDevices = map[string][]string{}
+	// TODO (@amitkumardas):
+	//	We are using labels since there might be a bug
+	// in metac to merge annotations. Use of labels is a
+	// workaround that needs to be changed to annotations
+	// once metac fixes this bug.
+	storageSetToDevices, err :=
+		index.NewBlockDeviceIndex(p.ObservedBlockDevices).DeviceNamesByStorageSetUID()
+	if err != nil {
+		return err
+	}
+	var deviceNames []string
 	for _, device := range p.ObservedBlockDevices {
-		// TODO (@amitkumardas):
-		//	We are using labels since there might be a bug
-		// in metac to merge annotations. Use of labels is a
-		// workaround that needs to be changed to annotations
-		// once metac fixes this bug.
-		sSetUID, err :=
-			unstruct.GetLabelForKeyOrError(device, types.AnnKeyCStorClusterStorageSetUID)
-		if err != nil {
-			return err
+		deviceNames = append(deviceNames, device.GetName())
+	}
+	pending, err := bdc.FilterPendingDeviceNames(
+		deviceNames, p.ObservedBlockDeviceClaims, p.ObservedCStorPoolCluster,
+	)
+	if err != nil {
+		return err
+	}
+	isPending := map[string]bool{}
+	for _, name := range pending {
+		isPending[name] = true
+	}
+	for sSetUID, names := range storageSetToDevices {
+		for _, name := range names {
+			if isPending[name] {
+				glog.V(3).Infof(
+					"Skip BlockDevice %q: BlockDeviceClaim is not bound", name,
+				)
+				continue
+			}
+			p.storageSetToObservedBlockDevices[sSetUID] =
+				append(p.storageSetToObservedBlockDevices[sSetUID], name)
 		}
-		devices := p.storageSetToObservedBlockDevices[sSetUID]
-		devices = append(devices, device.GetName())
-		p.storageSetToObservedBlockDevices[sSetUID] = devices
 	}
 	return nil
 }
@@ -488,10 +1090,13 @@ func (p *Planner) initNodeToObservedCSPCDevices() error {
 		return nil
 	}
 	// defines a series of local functions
-	getNodeName := func(obj *unstructured.Unstructured) (err error) {
-		currentNodeName, err =
-			unstruct.GetStringOrError(obj, "spec", "nodeSelector", "kubernetes.io/hostname")
-		return
+	getNodeName := func(obj *unstructured.Unstructured) error {
+		value, err := cspccommon.GetNodeSelectorValue(obj)
+		if err != nil {
+			return err
+		}
+		currentNodeName = p.nodeSelectorResolver.GetNodeName(value)
+		return nil
 	}
 	getBlockDeviceName := func(obj *unstructured.Unstructured) error {
 		deviceName, err := unstruct.GetStringOrError(obj, "spec", "blockDeviceName")
@@ -532,6 +1137,100 @@ func (p *Planner) initNodeToObservedCSPCDevices() error {
 	)
 }
 
+// initNodeToAdoptedDevices maps node name to the block devices of
+// the pools of an adopted CStorPoolCluster
+//
+// NOTE:
+//	Pools are reverse engineered from the observed CStorPoolCluster
+// when it gets adopted. These are recorded in the plan status & are
+// retained as long as the observed CStorPoolCluster has them.
+func (p *Planner) initNodeToAdoptedDevices() error {
+	p.nodeNameToAdoptedDevices = map[string][]string{}
+	if p.ObservedCStorPoolCluster == nil {
+		return nil
+	}
+	uid, _ := unstruct.GetValueForKey(
+		p.ObservedCStorPoolCluster.GetAnnotations(), types.AnnKeyCStorClusterPlanUID,
+	)
+	if uid != string(p.ObservedCStorClusterPlan.GetUID()) {
+		// CStorPoolCluster is being adopted
+		for nodeName, deviceNames := range p.nodeNameToObservedCSPCDevices {
+			p.nodeNameToAdoptedDevices[nodeName] = deviceNames
+		}
+		return nil
+	}
+	for _, pool := range p.ObservedCStorClusterPlan.Status.AdoptedPools {
+		nodeName := p.nodeSelectorResolver.GetNodeName(pool.HostName)
+		if _, found := p.nodeNameToObservedCSPCDevices[nodeName]; !found {
+			// pool was removed from CStorPoolCluster
+			continue
+		}
+		p.nodeNameToAdoptedDevices[nodeName] = pool.BlockDeviceNames
+	}
+	return nil
+}
+
+// getAdoptedPools returns the adopted pools sorted by their node names
+func (p *Planner) getAdoptedPools() []types.CStorClusterPlanAdoptedPool {
+	var nodeNames []string
+	for nodeName := range p.nodeNameToAdoptedDevices {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+	var pools []types.CStorClusterPlanAdoptedPool
+	for _, nodeName := range nodeNames {
+		pools = append(pools, types.CStorClusterPlanAdoptedPool{
+			HostName:         p.nodeSelectorResolver.GetHostName(nodeName),
+			BlockDeviceNames: p.nodeNameToAdoptedDevices[nodeName],
+		})
+	}
+	return pools
+}
+
+// initRemediation tracks the unhealthy pool instances of the
+// observed CStorPoolCluster & remediates them if they stay
+// unhealthy beyond the remediation timeout
+func (p *Planner) initRemediation() error {
+	config, err := ccc.NewHelper(p.ObservedClusterConfig).GetRemediation()
+	if err != nil {
+		return err
+	}
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	// pool instances refer to their nodes by hostname
+	hostNameToDevices := map[string][]string{}
+	for sSetUID, deviceNames := range p.storageSetToObservedBlockDevices {
+		nodeName := p.storageSetUIDToObservedNodeName[sSetUID]
+		hostNameToDevices[p.hostNameResolver.GetHostName(nodeName)] = deviceNames
+	}
+	hostNameToPoolDevices := map[string][]string{}
+	for nodeName, deviceNames := range p.nodeNameToObservedCSPCDevices {
+		hostNameToPoolDevices[p.hostNameResolver.GetHostName(nodeName)] = deviceNames
+	}
+	remediator := &remediation.Remediator{
+		Remediation:                      config,
+		Now:                              now(),
+		ObservedCStorPoolCluster:         p.ObservedCStorPoolCluster,
+		ObservedCStorPoolInstances:       p.ObservedCStorPoolInstances,
+		ObservedUnhealthyPools:           p.ObservedCStorClusterPlan.Status.UnhealthyPools,
+		ObservedReplacedBlockDeviceNames: p.ObservedCStorClusterPlan.Status.ReplacedBlockDeviceNames,
+		HostNameToPoolDeviceNames:        hostNameToPoolDevices,
+		HostNameToDeviceNames:            hostNameToDevices,
+		CordonedHostNames:                nodecommon.GetCordonedHostNames(p.ObservedNodes),
+	}
+	p.remediationResult, err = remediator.Remediate()
+	if err != nil {
+		return err
+	}
+	p.replacedBlockDeviceNames = map[string]bool{}
+	for _, name := range p.remediationResult.ReplacedBlockDeviceNames {
+		p.replacedBlockDeviceNames[name] = true
+	}
+	return nil
+}
+
 // initNodeToDesiredCSPCDevices manages reconciling the observed
 // BlockDevice(s) to desired CSPC device(s)
 func (p *Planner) initNodeToDesiredCSPCDevices() error {
@@ -548,129 +1247,135 @@ func (p *Planner) initNodeToDesiredCSPCDevices() error {
 				"Failed to map node to desired cspc devices: Empty node name",
 			)
 		}
-		observedCSPCDevices := p.nodeNameToObservedCSPCDevices[nodeName]
-		p.nodeNameToDesiredCSPCDevices[nodeName] =
-			stringcommon.NewEquality(observedCSPCDevices, observedBlockDevices).Merge()
-	}
-	return nil
-}
-
-// buildDesiredRAIDGroupsByNodeName builds that fragment of the
-// CStorPoolCluster spec that deals with raid groups.
-// The resulting fragment is based on the given node name.
-func (p *Planner) buildDesiredRAIDGroupsByNodeName(nodeName string) []interface{} {
-	buildBlockDevices := func(deviceNames []string) []interface{} {
-		var blockDevices []interface{}
-		for _, deviceName := range deviceNames {
-			blockDevices = append(
-				blockDevices, map[string]interface{}{
-					"blockDeviceName": deviceName,
-				},
-			)
+		// replaced devices get substituted by spare devices at
+		// their positions in the observed pool
+		var availableDevices []string
+		for _, name := range observedBlockDevices {
+			if !p.replacedBlockDeviceNames[name] {
+				availableDevices = append(availableDevices, name)
+			}
 		}
-		return blockDevices
+		p.nodeNameToDesiredCSPCDevices[nodeName] =
+			p.mergeWithAdoptedDevices(nodeName, availableDevices)
 	}
-	buildSingleRAIDGroup := func(deviceNames []string) interface{} {
-		return map[string]interface{}{
-			"type":         p.desiredRAIDType,
-			"isWriteCache": false,
-			"isSpare":      false,
-			"isReadCache":  false,
-			"blockDevices": buildBlockDevices(deviceNames),
+	// pools of adopted nodes that are not planned are retained
+	for nodeName := range p.nodeNameToAdoptedDevices {
+		if _, found := p.nodeNameToDesiredCSPCDevices[nodeName]; found {
+			continue
 		}
+		p.nodeNameToDesiredCSPCDevices[nodeName] =
+			p.mergeWithAdoptedDevices(nodeName, nil)
 	}
-	buildAllRAIDGroupsPerNode := func(deviceNames []string) []interface{} {
-		var raidGroupList []interface{}
-		var raidGroup []string
+	return nil
+}
 
-		diskCountPerGroup :=
-			int(types.RAIDTypeToDefaultMinDiskCount[types.PoolRAIDType(p.desiredRAIDType)])
-		for idx, deviceName := range deviceNames {
-			raidGroup = append(raidGroup, deviceName)
-			// Following logic takes care of distributing disks based
-			// on RAID type.
-			//
-			// NOTE:
-			//	Disks get distributed as follows:
-			// 	- Mirror has 2 disks per raid group
-			//	- RAIDZ has 3 disks per raid group
-			//  - RAIDZ2 has 6 disks per raid group
-			//  - Stripe has 1 disk per raid group
-			if (idx+1)%diskCountPerGroup == 0 {
-				raidGroupList = append(raidGroupList, buildSingleRAIDGroup(raidGroup))
-				// reset the raidGroup to make way to build next raidGroup for this pool
-				raidGroup = nil
-			}
+// mergeWithAdoptedDevices merges the given available devices & the
+// adopted devices of the given node with the observed CSPC devices
+func (p *Planner) mergeWithAdoptedDevices(nodeName string, availableDevices []string) []string {
+	for _, name := range p.nodeNameToAdoptedDevices[nodeName] {
+		if !p.replacedBlockDeviceNames[name] &&
+			!stringcommon.List(availableDevices).ContainsExact(name) {
+			availableDevices = append(availableDevices, name)
 		}
-		return raidGroupList
 	}
-	return buildAllRAIDGroupsPerNode(p.nodeNameToDesiredCSPCDevices[nodeName])
+	observedCSPCDevices := p.nodeNameToObservedCSPCDevices[nodeName]
+	return stringcommon.NewEquality(observedCSPCDevices, availableDevices).Merge()
 }
 
-// buildDesiredPoolByNodeName builds that fragment of CStorPoolCluster
-// that deals with specifying a single pool instance. The resulting
-// fragment is based on the given node name.
-func (p *Planner) buildDesiredPoolByNodeName(nodeName string) interface{} {
-	return map[string]interface{}{
-		"nodeSelector": map[string]interface{}{
-			"kubernetes.io/hostname": nodeName,
-		},
-		"raidGroups": p.buildDesiredRAIDGroupsByNodeName(nodeName),
-		"poolConfig": map[string]interface{}{
-			"defaultRaidGroupType": p.desiredRAIDType,
-			"overProvisioning":     false,
-			"compression":          "off",
-		},
-	}
-}
-
-// buildDesiredPools builds that fragment of CStorPoolCluster
-// that deals with specifying all the desired pool instances.
-func (p *Planner) buildDesiredPools() []interface{} {
-	var pools []interface{}
-	// TODO (@amitkumardas):
-	//
-	//	Since we are using a map i.e. nodeNameToObservedStorageSetUID
-	// the nodeName will reorder & hence result in a diff
-	// between observed CSPC vs. desired CSPC even though
-	// there is no semantic difference.
-	//
-	// NOTE:
-	//	spec.pools in CSPC is an array type
-	//
-	// FUTURE:
-	//	To make the desired pools follow the same order as that
-	// of observed pools, we could store the nodes in the same
-	// order as they are found in observed CSPC.
-	for node := range p.nodeNameToObservedStorageSetUID {
-		pool := p.buildDesiredPoolByNodeName(node)
-		pools = append(pools, pool)
+// rebalance checks the raid group count skew across the desired
+// pool instances & holds back the raid groups that cause this skew
+// if Refuse policy is set
+func (p *Planner) rebalance() error {
+	config, err := ccc.NewHelper(p.ObservedClusterConfig).GetRebalance()
+	if err != nil {
+		return err
 	}
-	return pools
+	rebalancer := &Rebalancer{
+		Config:                    config,
+		RAIDType:                  types.PoolRAIDType(p.desiredRAIDType),
+		RAIDGroupConfig:           p.desiredRAIDGroupConfig,
+		NodeNameToDesiredDevices:  p.nodeNameToDesiredCSPCDevices,
+		NodeNameToObservedDevices: p.nodeNameToObservedCSPCDevices,
+	}
+	p.nodeNameToDesiredCSPCDevices, p.rebalanceResult, err = rebalancer.Balance()
+	return err
 }
 
-// TODO (@amitkumardas):
-//  Make use of common/cstorpoolcluster/builder.go methods
-func (p *Planner) getDesiredCStorPoolCluster() *unstructured.Unstructured {
-	cspc := &unstructured.Unstructured{}
-	cspc.SetUnstructuredContent(map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"name":      p.ObservedCStorClusterPlan.GetName(),
-			"namespace": p.ObservedCStorClusterPlan.GetNamespace(),
-		},
-		"spec": map[string]interface{}{
-			"pools": p.buildDesiredPools(),
-		},
-	})
+// getDesiredCStorPoolCluster builds the desired CStorPoolCluster
+// with a pool per node of the observed storage sets
+func (p *Planner) getDesiredCStorPoolCluster() (*unstructured.Unstructured, error) {
+	err := faultinject.Inject(faultinject.PhaseCSPCBuild)
+	if err != nil {
+		return nil, err
+	}
 	// create annotations with CStorClusterPlan UID & CStorClusterConfig UID
-	cspc.SetAnnotations(map[string]string{
+	annotations := map[string]string{
 		types.AnnKeyCStorClusterPlanUID:   string(p.ObservedCStorClusterPlan.GetUID()),
 		types.AnnKeyCStorClusterConfigUID: string(p.ObservedClusterConfig.GetUID()),
-	})
-	// below is the right way to set APIVersion & Kind
-	cspc.SetAPIVersion(string(types.APIVersionOpenEBSV1Alpha1))
-	cspc.SetKind(string(types.KindCStorPoolCluster))
-	return cspc
+		// config may be in a different namespace
+		types.AnnKeyCStorClusterConfigNamespacedName: p.ObservedClusterConfig.GetNamespace() +
+			"/" + p.ObservedClusterConfig.GetName(),
+	}
+	// zone is set as a label to let the pools of a zone be selected
+	var labels map[string]string
+	if p.ObservedCStorClusterPlan.Spec.Zone != "" {
+		labels = map[string]string{
+			types.LblKeyZone: p.ObservedCStorClusterPlan.Spec.Zone,
+		}
+	}
+	// adopted CStorPoolCluster retains its name
+	name := p.ObservedCStorClusterPlan.GetName()
+	if p.ObservedCStorPoolCluster != nil {
+		name = p.ObservedCStorPoolCluster.GetName()
+	}
+	builder := cspc.NewBuilder().
+		WithSchema(cspc.SchemaV1Alpha1).
+		WithName(name).
+		WithNamespace(p.desiredNamespace).
+		WithAnnotations(annotations).
+		WithLabels(labels).
+		WithRAIDType(types.PoolRAIDType(p.desiredRAIDType)).
+		WithRAIDGroupDeviceCount(types.GetRAIDGroupDiskCount(
+			types.PoolRAIDType(p.desiredRAIDType), p.desiredRAIDGroupConfig,
+		)).
+		WithZFSProperties(p.desiredZFSProperties).
+		WithNodeSelectorKey(p.desiredNodeSelectorKey)
+	// pools are sorted by node name since spec.pools in CSPC is an
+	// array type. Iterating over nodeNameToObservedStorageSetUID
+	// would otherwise reorder the pools & result in a diff between
+	// observed & desired CSPC without any semantic difference.
+	var nodeNames []string
+	for nodeName := range p.nodeNameToObservedStorageSetUID {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	for nodeName := range p.nodeNameToAdoptedDevices {
+		if _, found := p.nodeNameToObservedStorageSetUID[nodeName]; !found {
+			nodeNames = append(nodeNames, nodeName)
+		}
+	}
+	sort.Strings(nodeNames)
+	selectedBy := map[string]string{}
+	for _, nodeName := range nodeNames {
+		value := p.nodeSelectorResolver.GetHostName(nodeName)
+		if other, found := selectedBy[value]; found {
+			// a pool can not be shared by storage sets
+			return nil, errs.ValidationErrorf(
+				"Can't build CStorPoolCluster: Nodes %q & %q have same value %q for node selector key %q",
+				other, nodeName, value, p.desiredNodeSelectorKey,
+			)
+		}
+		selectedBy[value] = nodeName
+		builder.
+			WithPool(value).
+			WithDevices(p.nodeNameToDesiredCSPCDevices[nodeName]...)
+	}
+	desired, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	// user provided labels & annotations if any
+	metadata.Propagate(desired, p.desiredChildMetadata)
+	return desired, nil
 }
 
 // Plan builds the desired CStorPoolCluster (i.e. CSPC) instance
@@ -684,5 +1389,9 @@ func (p *Planner) Plan() (*unstructured.Unstructured, error) {
 		// ready to reconcile CStorPoolCluster
 		return nil, nil
 	}
-	return p.getDesiredCStorPoolCluster(), nil
+	err = p.rebalance()
+	if err != nil {
+		return nil, err
+	}
+	return p.getDesiredCStorPoolCluster()
 }
@@ -264,7 +264,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 			observedClusterConfig: &unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"metadata": map[string]interface{}{
-						"uid": "config-101",
+						"name":      "my-config",
+						"namespace": "openebs",
+						"uid":       "config-101",
 					},
 				},
 			},
@@ -285,8 +287,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 						"name":      "mirror-2x4",
 						"namespace": "test-mirror",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterPlanUID):   "plan-101",
-							string(types.AnnKeyCStorClusterConfigUID): "config-101",
+							string(types.AnnKeyCStorClusterPlanUID):              "plan-101",
+							string(types.AnnKeyCStorClusterConfigUID):            "config-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "openebs/my-config",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -387,7 +390,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 			observedClusterConfig: &unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"metadata": map[string]interface{}{
-						"uid": "config-101",
+						"name":      "my-config",
+						"namespace": "openebs",
+						"uid":       "config-101",
 					},
 				},
 			},
@@ -408,8 +413,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 						"name":      "mirror-2x2",
 						"namespace": "test-mirror",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterPlanUID):   "plan-101",
-							string(types.AnnKeyCStorClusterConfigUID): "config-101",
+							string(types.AnnKeyCStorClusterPlanUID):              "plan-101",
+							string(types.AnnKeyCStorClusterConfigUID):            "config-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "openebs/my-config",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -471,6 +477,82 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 				},
 			},
 		},
+		"Mirror : 1x2 : 1 Pool x 2 Disks : Zoned plan": {
+			observedCStorClusterPlan: &types.CStorClusterPlan{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "mirror-zone-a",
+					Namespace: "test-mirror",
+					UID:       "plan-101",
+				},
+				Spec: types.CStorClusterPlanSpec{
+					Zone: "zone-a",
+				},
+			},
+			observedClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "my-config",
+						"namespace": "openebs",
+						"uid":       "config-101",
+					},
+				},
+			},
+			desiredRAIDType: string(types.PoolRAIDTypeMirror),
+			nodeNameToObservedStorageSetUID: map[string]string{
+				"node-101": "sset-101",
+			},
+			nodeNameToDesiredCSPCDevices: map[string][]string{
+				"node-101": []string{"bd-1", "bd-2"},
+			},
+			expectCSPC: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":       string(types.KindCStorPoolCluster),
+					"apiVersion": string(types.APIVersionOpenEBSV1Alpha1),
+					"metadata": map[string]interface{}{
+						"name":      "mirror-zone-a",
+						"namespace": "test-mirror",
+						"annotations": map[string]interface{}{
+							string(types.AnnKeyCStorClusterPlanUID):              "plan-101",
+							string(types.AnnKeyCStorClusterConfigUID):            "config-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "openebs/my-config",
+						},
+						"labels": map[string]interface{}{
+							types.LblKeyZone: "zone-a",
+						},
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"poolConfig": map[string]interface{}{
+									"defaultRaidGroupType": "mirror",
+									"overProvisioning":     false,
+									"compression":          "off",
+								},
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-101",
+								},
+								"raidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd-1",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd-2",
+											},
+										},
+										"type":         "mirror",
+										"isWriteCache": false,
+										"isSpare":      false,
+										"isReadCache":  false,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		"Stripe: 2x4 : 2 Pools x 4 Disks on each Pool": {
 			observedCStorClusterPlan: &types.CStorClusterPlan{
 				ObjectMeta: metav1.ObjectMeta{
@@ -482,7 +564,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 			observedClusterConfig: &unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"metadata": map[string]interface{}{
-						"uid": "config-101",
+						"name":      "my-config",
+						"namespace": "openebs",
+						"uid":       "config-101",
 					},
 				},
 			},
@@ -503,8 +587,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 						"name":      "stripe-2x4",
 						"namespace": "test-stripe",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterPlanUID):   "plan-101",
-							string(types.AnnKeyCStorClusterConfigUID): "config-101",
+							string(types.AnnKeyCStorClusterPlanUID):              "plan-101",
+							string(types.AnnKeyCStorClusterConfigUID):            "config-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "openebs/my-config",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -637,7 +722,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 			observedClusterConfig: &unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"metadata": map[string]interface{}{
-						"uid": "config-101",
+						"name":      "my-config",
+						"namespace": "openebs",
+						"uid":       "config-101",
 					},
 				},
 			},
@@ -658,8 +745,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 						"name":      "raidz-2x6",
 						"namespace": "test-raidz",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterPlanUID):   "plan-101",
-							string(types.AnnKeyCStorClusterConfigUID): "config-101",
+							string(types.AnnKeyCStorClusterPlanUID):              "plan-101",
+							string(types.AnnKeyCStorClusterConfigUID):            "config-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "openebs/my-config",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -772,7 +860,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 			observedClusterConfig: &unstructured.Unstructured{
 				Object: map[string]interface{}{
 					"metadata": map[string]interface{}{
-						"uid": "config-101",
+						"name":      "my-config",
+						"namespace": "openebs",
+						"uid":       "config-101",
 					},
 				},
 			},
@@ -793,8 +883,9 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 						"name":      "raidz2-2x6",
 						"namespace": "test-raidz2",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterPlanUID):   "plan-101",
-							string(types.AnnKeyCStorClusterConfigUID): "config-101",
+							string(types.AnnKeyCStorClusterPlanUID):              "plan-101",
+							string(types.AnnKeyCStorClusterConfigUID):            "config-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "openebs/my-config",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -889,10 +980,14 @@ func TestPlannerGetDesiredCStorPoolCluster(t *testing.T) {
 				ObservedCStorClusterPlan:        mock.observedCStorClusterPlan,
 				ObservedClusterConfig:           mock.observedClusterConfig,
 				desiredRAIDType:                 mock.desiredRAIDType,
+				desiredNamespace:                mock.observedCStorClusterPlan.GetNamespace(),
 				nodeNameToObservedStorageSetUID: mock.nodeNameToObservedStorageSetUID,
 				nodeNameToDesiredCSPCDevices:    mock.nodeNameToDesiredCSPCDevices,
 			}
-			got := p.getDesiredCStorPoolCluster()
+			got, err := p.getDesiredCStorPoolCluster()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
 			expect := mock.expectCSPC
 			// api version check
 			if got.GetAPIVersion() != expect.GetAPIVersion() {
@@ -1224,9 +1319,28 @@ func TestPlannerInitNodeToDesiredCSPCDevices(t *testing.T) {
 	}
 }
 
+func newTestBoundClaims(deviceNames ...string) []*unstructured.Unstructured {
+	var claims []*unstructured.Unstructured
+	for _, name := range deviceNames {
+		claims = append(claims, &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDeviceClaim),
+				"spec": map[string]interface{}{
+					"blockDeviceName": name,
+				},
+				"status": map[string]interface{}{
+					"phase": string(types.BlockDeviceClaimBound),
+				},
+			},
+		})
+	}
+	return claims
+}
+
 func TestPlannerInitStorageSetToObservedBlockDevices(t *testing.T) {
 	var tests = map[string]struct {
 		blockDevices      []*unstructured.Unstructured
+		blockDeviceClaims []*unstructured.Unstructured
 		expectDeviceNames map[string][]string
 		isErr             bool
 	}{
@@ -1262,11 +1376,30 @@ func TestPlannerInitStorageSetToObservedBlockDevices(t *testing.T) {
 					},
 				},
 			},
+			blockDeviceClaims: newTestBoundClaims("single-valid"),
 			expectDeviceNames: map[string][]string{
 				"101": []string{"single-valid"},
 			},
 			isErr: false,
 		},
+		"single unclaimed blockdevice": {
+			blockDevices: []*unstructured.Unstructured{
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"name": "single-unclaimed",
+							"labels": map[string]interface{}{
+								string(types.AnnKeyCStorClusterStorageSetUID): "101",
+							},
+						},
+					},
+				},
+			},
+			expectDeviceNames: map[string][]string{
+				"101": nil,
+			},
+			isErr: false,
+		},
 		"multi valid blockdevices": {
 			blockDevices: []*unstructured.Unstructured{
 				&unstructured.Unstructured{
@@ -1300,6 +1433,9 @@ func TestPlannerInitStorageSetToObservedBlockDevices(t *testing.T) {
 					},
 				},
 			},
+			blockDeviceClaims: newTestBoundClaims(
+				"multi-valid-1", "multi-valid-2", "single-valid",
+			),
 			expectDeviceNames: map[string][]string{
 				"101": []string{"multi-valid-1", "multi-valid-2"},
 				"201": []string{"single-valid"},
@@ -1312,7 +1448,8 @@ func TestPlannerInitStorageSetToObservedBlockDevices(t *testing.T) {
 		mock := mock
 		t.Run(name, func(t *testing.T) {
 			p := &Planner{
-				ObservedBlockDevices: mock.blockDevices,
+				ObservedBlockDevices:      mock.blockDevices,
+				ObservedBlockDeviceClaims: mock.blockDeviceClaims,
 			}
 			err := p.initStorageSetToObservedBlockDevices()
 			if mock.isErr && err == nil {
@@ -1648,3 +1785,471 @@ func TestPlannerIsReadyByNodeDiskCount(t *testing.T) {
 		})
 	}
 }
+
+func TestPlannerIsReadyByMinReadyNodes(t *testing.T) {
+	mockloginfo := &types.CStorClusterPlan{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "test",
+		},
+	}
+	var tests = map[string]struct {
+		planner       *Planner
+		isReady       bool
+		expectWaiting string
+	}{
+		"min ready nodes is not set": {
+			planner: &Planner{
+				storageSetUIDToDesiredDiskCount: map[string]resource.Quantity{
+					"101": resource.MustParse("2"),
+				},
+			},
+			isReady: true,
+		},
+		"ready nodes == min ready nodes": {
+			planner: &Planner{
+				desiredMinReadyNodes: 2,
+				storageSetUIDToDesiredDiskCount: map[string]resource.Quantity{
+					"101": resource.MustParse("1"),
+					"102": resource.MustParse("1"),
+					"103": resource.MustParse("1"),
+				},
+				storageSetToObservedBlockDevices: map[string][]string{
+					"101": []string{"bd1"},
+					"102": []string{"bd2"},
+				},
+			},
+			isReady: true,
+		},
+		"ready nodes < min ready nodes": {
+			planner: &Planner{
+				ObservedCStorClusterPlan: mockloginfo,
+				desiredMinReadyNodes:     3,
+				storageSetUIDToDesiredDiskCount: map[string]resource.Quantity{
+					"101": resource.MustParse("2"),
+					"102": resource.MustParse("2"),
+					"103": resource.MustParse("2"),
+				},
+				storageSetToObservedBlockDevices: map[string][]string{
+					"101": []string{"bd1", "bd2"},
+					"102": []string{"bd3"},
+				},
+			},
+			isReady:       false,
+			expectWaiting: "1 of 3 min ready nodes have all their disks",
+		},
+		"ready nodes < min ready nodes with observed cspc": {
+			planner: &Planner{
+				ObservedCStorPoolCluster: &unstructured.Unstructured{},
+				desiredMinReadyNodes:     3,
+				storageSetUIDToDesiredDiskCount: map[string]resource.Quantity{
+					"101": resource.MustParse("1"),
+				},
+				storageSetToObservedBlockDevices: map[string][]string{
+					"101": []string{"bd1"},
+				},
+			},
+			isReady: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.planner.isReadyByMinReadyNodes()
+			if got != mock.isReady {
+				t.Fatalf("Want %t got %t", mock.isReady, got)
+			}
+			if mock.planner.waitingReason != mock.expectWaiting {
+				t.Fatalf(
+					"Want waiting reason %q got %q",
+					mock.expectWaiting, mock.planner.waitingReason,
+				)
+			}
+		})
+	}
+}
+
+func TestReconcilerResolveDrift(t *testing.T) {
+	newConfig := func(policy string) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if policy != "" {
+			spec["driftPolicy"] = policy
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": spec,
+			},
+		}
+	}
+	newCSPC := func(poolName string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorPoolCluster),
+				"spec": map[string]interface{}{
+					"pools": []interface{}{
+						map[string]interface{}{
+							"name": poolName,
+						},
+					},
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		clusterConfig *unstructured.Unstructured
+		observedCSPC  *unstructured.Unstructured
+		expectPool    string
+		isErr         bool
+	}{
+		"default policy": {
+			clusterConfig: newConfig(""),
+			observedCSPC:  newCSPC("observed"),
+			expectPool:    "desired",
+		},
+		"ignore policy - nil observed cspc": {
+			clusterConfig: newConfig("Ignore"),
+			expectPool:    "desired",
+		},
+		"ignore policy - observed pools are retained": {
+			clusterConfig: newConfig("Ignore"),
+			observedCSPC:  newCSPC("observed"),
+			expectPool:    "observed",
+		},
+		"invalid policy": {
+			clusterConfig: newConfig("junk"),
+			observedCSPC:  newCSPC("observed"),
+			isErr:         true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ObservedCStorClusterPlan: &types.CStorClusterPlan{
+					ObjectMeta: metav1.ObjectMeta{
+						UID: "plan-1",
+					},
+				},
+				ObservedClusterConfig:    mock.clusterConfig,
+				ObservedCStorPoolCluster: mock.observedCSPC,
+			}
+			got, err := r.resolveDrift(newCSPC("desired"))
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			pools, _, _ := unstructured.NestedSlice(
+				got.CStorPoolCluster.Object, "spec", "pools",
+			)
+			gotPool := pools[0].(map[string]interface{})["name"]
+			if gotPool != mock.expectPool {
+				t.Fatalf("Expected pool %q got %v", mock.expectPool, gotPool)
+			}
+		})
+	}
+}
+
+func TestPlannerGetDesiredCStorPoolClusterIsStable(t *testing.T) {
+	nodeNameToObservedStorageSetUID := map[string]string{}
+	nodeNameToDesiredCSPCDevices := map[string][]string{}
+	for _, nodeName := range []string{"node-3", "node-1", "node-5", "node-2", "node-4"} {
+		nodeNameToObservedStorageSetUID[nodeName] = "sset-" + nodeName
+		nodeNameToDesiredCSPCDevices[nodeName] = []string{
+			"bd-" + nodeName + "-1", "bd-" + nodeName + "-2",
+		}
+	}
+	newPlanner := func() *Planner {
+		return &Planner{
+			ObservedCStorClusterPlan: &types.CStorClusterPlan{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "my-plan",
+					Namespace: "openebs",
+					UID:       "plan-101",
+				},
+			},
+			ObservedClusterConfig: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"name":      "my-config",
+						"namespace": "openebs",
+						"uid":       "config-101",
+					},
+				},
+			},
+			desiredRAIDType:                 string(types.PoolRAIDTypeMirror),
+			desiredNamespace:                "openebs",
+			nodeNameToObservedStorageSetUID: nodeNameToObservedStorageSetUID,
+			nodeNameToDesiredCSPCDevices:    nodeNameToDesiredCSPCDevices,
+		}
+	}
+	first, err := newPlanner().getDesiredCStorPoolCluster()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	var gotNodeNames []string
+	for _, pool := range unstruct.MustGetNestedSlice(first, "spec", "pools") {
+		nodeName, _, _ := unstructured.NestedString(
+			pool.(map[string]interface{}), "nodeSelector", "kubernetes.io/hostname",
+		)
+		gotNodeNames = append(gotNodeNames, nodeName)
+	}
+	expectNodeNames := []string{"node-1", "node-2", "node-3", "node-4", "node-5"}
+	if !reflect.DeepEqual(gotNodeNames, expectNodeNames) {
+		t.Fatalf("Expected pools of %v got %v", expectNodeNames, gotNodeNames)
+	}
+	for i := 0; i < 10; i++ {
+		got, err := newPlanner().getDesiredCStorPoolCluster()
+		if err != nil {
+			t.Fatalf("Expected no error got [%+v]", err)
+		}
+		if !reflect.DeepEqual(first, got) {
+			t.Fatalf("Expected same cspc across runs: Run %d", i)
+		}
+	}
+}
+
+func TestPlannerPlanWithMismatchedHostNames(t *testing.T) {
+	node := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindNode),
+			"metadata": map[string]interface{}{
+				"name": "ip-10-0-0-1.ec2.internal",
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname": "ip-10-0-0-1",
+				},
+			},
+		},
+	}
+	observedCSPC := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"pools": []interface{}{
+					map[string]interface{}{
+						"nodeSelector": map[string]interface{}{
+							"kubernetes.io/hostname": "ip-10-0-0-1",
+						},
+						"raidGroups": []interface{}{
+							map[string]interface{}{
+								"blockDevices": []interface{}{
+									map[string]interface{}{
+										"blockDeviceName": "bd-2",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	p := &Planner{
+		ObservedCStorClusterPlan: &types.CStorClusterPlan{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "my-plan",
+				Namespace: "openebs",
+				UID:       "plan-101",
+			},
+		},
+		ObservedCStorPoolCluster: observedCSPC,
+		ObservedClusterConfig: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      "my-config",
+					"namespace": "openebs",
+					"uid":       "config-101",
+				},
+			},
+		},
+		ObservedNodes:    []*unstructured.Unstructured{node},
+		desiredRAIDType:  string(types.PoolRAIDTypeStripe),
+		desiredNamespace: "openebs",
+		storageSetToObservedBlockDevices: map[string][]string{
+			"sset-101": []string{"bd-1", "bd-2"},
+		},
+		storageSetUIDToObservedNodeName: map[string]string{
+			"sset-101": "ip-10-0-0-1.ec2.internal",
+		},
+		nodeNameToObservedStorageSetUID: map[string]string{
+			"ip-10-0-0-1.ec2.internal": "sset-101",
+		},
+	}
+	for _, fn := range []func() error{
+		p.initHostNameResolver,
+		p.initNodeToObservedCSPCDevices,
+		p.initNodeToDesiredCSPCDevices,
+	} {
+		if err := fn(); err != nil {
+			t.Fatalf("Expected no error got [%+v]", err)
+		}
+	}
+	expectDevices := []string{"bd-2", "bd-1"}
+	gotDevices := p.nodeNameToDesiredCSPCDevices["ip-10-0-0-1.ec2.internal"]
+	if !reflect.DeepEqual(gotDevices, expectDevices) {
+		t.Fatalf("Expected devices %v got %v", expectDevices, gotDevices)
+	}
+	got, err := p.getDesiredCStorPoolCluster()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	pools := unstruct.MustGetNestedSlice(got, "spec", "pools")
+	if len(pools) != 1 {
+		t.Fatalf("Expected 1 pool got %d", len(pools))
+	}
+	hostName, _, _ := unstructured.NestedString(
+		pools[0].(map[string]interface{}), "nodeSelector", "kubernetes.io/hostname",
+	)
+	if hostName != "ip-10-0-0-1" {
+		t.Fatalf("Expected pool hostname %q got %q", "ip-10-0-0-1", hostName)
+	}
+}
+
+func TestPlannerPlanWithCustomNodeSelectorKey(t *testing.T) {
+	const key = "topology.example.com/node"
+	newNode := func(name, value string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname": name,
+						key:                      value,
+					},
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		nodes        []*unstructured.Unstructured
+		expectValues []string
+		isErr        bool
+	}{
+		"pools select nodes by custom key": {
+			nodes: []*unstructured.Unstructured{
+				newNode("node-1", "id-1"),
+				newNode("node-2", "id-2"),
+			},
+			expectValues: []string{"id-1", "id-2"},
+		},
+		"nodes with same custom key value": {
+			nodes: []*unstructured.Unstructured{
+				newNode("node-1", "id-1"),
+				newNode("node-2", "id-1"),
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			observedCSPC := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									key: "id-1",
+								},
+								"raidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd-2",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			p := &Planner{
+				ObservedCStorClusterPlan: &types.CStorClusterPlan{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-plan",
+						Namespace: "openebs",
+						UID:       "plan-101",
+					},
+				},
+				ObservedCStorPoolCluster: observedCSPC,
+				ObservedClusterConfig: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": string(types.KindCStorClusterConfig),
+						"metadata": map[string]interface{}{
+							"name":      "my-config",
+							"namespace": "openebs",
+							"uid":       "config-101",
+						},
+						"spec": map[string]interface{}{
+							"poolConfig": map[string]interface{}{
+								"nodeSelectorKey": key,
+							},
+						},
+					},
+				},
+				ObservedNodes:    mock.nodes,
+				desiredRAIDType:  string(types.PoolRAIDTypeStripe),
+				desiredNamespace: "openebs",
+				storageSetToObservedBlockDevices: map[string][]string{
+					"sset-101": []string{"bd-1", "bd-2"},
+					"sset-102": []string{"bd-3"},
+				},
+				storageSetUIDToObservedNodeName: map[string]string{
+					"sset-101": "node-1",
+					"sset-102": "node-2",
+				},
+				nodeNameToObservedStorageSetUID: map[string]string{
+					"node-1": "sset-101",
+					"node-2": "sset-102",
+				},
+			}
+			for _, fn := range []func() error{
+				p.initHostNameResolver,
+				p.initNodeToObservedCSPCDevices,
+				p.initNodeToDesiredCSPCDevices,
+			} {
+				if err := fn(); err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+			}
+			got, err := p.getDesiredCStorPoolCluster()
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			pools := unstruct.MustGetNestedSlice(got, "spec", "pools")
+			var gotValues []string
+			for _, pool := range pools {
+				value, _, _ := unstructured.NestedString(
+					pool.(map[string]interface{}), "nodeSelector", key,
+				)
+				gotValues = append(gotValues, value)
+			}
+			if !reflect.DeepEqual(gotValues, mock.expectValues) {
+				t.Fatalf("Expected pool values %v got %v", mock.expectValues, gotValues)
+			}
+			// observed device order of the pool is retained
+			expectDevices := []string{"bd-2", "bd-1"}
+			gotDevices := p.nodeNameToDesiredCSPCDevices["node-1"]
+			if !reflect.DeepEqual(gotDevices, expectDevices) {
+				t.Fatalf("Expected devices %v got %v", expectDevices, gotDevices)
+			}
+		})
+	}
+}
@@ -17,11 +17,18 @@ limitations under the License.
 package localdevice
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	"mayadata.io/cstorpoolauto/common/owner"
+	"mayadata.io/cstorpoolauto/common/skip"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/lock"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 	"openebs.io/metac/controller/common"
@@ -188,8 +195,8 @@ func TestSyncerSkipIfNotLocalDisk(t *testing.T) {
 				},
 				response: &generic.SyncHookResponse{},
 			},
-			isSkip: true,
-			isErr:  false,
+			isSkip: false,
+			isErr:  true,
 		},
 		"valid watch kind && nil diskconfig.local.blockDeviceSelector.selectorTerms": {
 			syncer: &syncer{
@@ -211,8 +218,8 @@ func TestSyncerSkipIfNotLocalDisk(t *testing.T) {
 				},
 				response: &generic.SyncHookResponse{},
 			},
-			isSkip: true,
-			isErr:  false,
+			isSkip: false,
+			isErr:  true,
 		},
 		"valid watch kind && 0 diskconfig.local.blockDeviceSelector.selectorTerms": {
 			syncer: &syncer{
@@ -234,8 +241,8 @@ func TestSyncerSkipIfNotLocalDisk(t *testing.T) {
 				},
 				response: &generic.SyncHookResponse{},
 			},
-			isSkip: true,
-			isErr:  false,
+			isSkip: false,
+			isErr:  true,
 		},
 		"valid watch kind && 1 empty diskconfig.local.blockDeviceSelector.selectorTerms": {
 			syncer: &syncer{
@@ -262,6 +269,27 @@ func TestSyncerSkipIfNotLocalDisk(t *testing.T) {
 			isSkip: false,
 			isErr:  false,
 		},
+		"valid watch kind && diskconfig.local.selectAll": {
+			syncer: &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"kind": string(types.KindCStorClusterConfig),
+							"spec": map[string]interface{}{
+								"diskConfig": map[string]interface{}{
+									"local": map[string]interface{}{
+										"selectAll": true,
+									},
+								},
+							},
+						},
+					},
+				},
+				response: &generic.SyncHookResponse{},
+			},
+			isSkip: false,
+			isErr:  false,
+		},
 	}
 	for name, mock := range tests {
 		name := name
@@ -386,6 +414,179 @@ func TestSyncerSkipIfEmptyAttachments(t *testing.T) {
 	}
 }
 
+func TestSyncerSkipIfPaused(t *testing.T) {
+	var tests = map[string]struct {
+		annotations map[string]string
+		isSkip      bool
+		isErr       bool
+	}{
+		"no annotation": {
+			isSkip: false,
+		},
+		"paused": {
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigPaused: "true",
+			},
+			isSkip: true,
+		},
+		"not paused": {
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigPaused: "false",
+			},
+			isSkip: false,
+		},
+		"invalid annotation": {
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigPaused: "junk",
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			watch := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": string(types.KindCStorClusterConfig),
+				},
+			}
+			watch.SetAnnotations(mock.annotations)
+			s := &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: watch,
+				},
+				response: &generic.SyncHookResponse{},
+			}
+			// function under test
+			s.skipIfPaused()
+			if mock.isErr && s.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && s.err != nil {
+				t.Fatalf("Expected no error got [%+v]", s.err)
+			}
+			if mock.isSkip != s.response.SkipReconcile {
+				t.Fatalf(
+					"Expected skip %t got %t",
+					mock.isSkip, s.response.SkipReconcile,
+				)
+			}
+			if mock.isSkip && s.response.Status == nil {
+				t.Fatalf("Expected paused status got nil")
+			}
+		})
+	}
+}
+
+func TestSyncerHandleError(t *testing.T) {
+	var tests = map[string]struct {
+		err          error
+		expectResync float64
+	}{
+		"unclassified error": {
+			err:          errors.Errorf("oops"),
+			expectResync: 0,
+		},
+		"validation error": {
+			err:          errs.ValidationErrorf("Invalid CStorClusterConfig"),
+			expectResync: 0,
+		},
+		"not enough resources error": {
+			err:          errs.NotEnoughResourcesErrorf("0 of 3 block devices selected"),
+			expectResync: errs.TypeToResyncAfterSeconds[errs.TypeNotEnoughResources],
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			s := &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"kind": string(types.KindCStorClusterConfig),
+						},
+					},
+				},
+				response: &generic.SyncHookResponse{},
+				err:      mock.err,
+			}
+			// function under test
+			s.handleError()
+			if !s.response.SkipReconcile {
+				t.Fatalf("Expected skip got none")
+			}
+			if s.response.ResyncAfterSeconds != mock.expectResync {
+				t.Fatalf(
+					"Expected resync after %v got %v",
+					mock.expectResync, s.response.ResyncAfterSeconds,
+				)
+			}
+		})
+	}
+}
+
+func TestSyncerReportBlockDeviceSelection(t *testing.T) {
+	var tests = map[string]struct {
+		status       map[string]interface{}
+		report       []string
+		expectStatus map[string]interface{}
+	}{
+		"no report && no previous report": {
+			status: map[string]interface{}{
+				"phase": "Online",
+			},
+		},
+		"no report && previous report": {
+			status: map[string]interface{}{
+				"phase":                      "Online",
+				"blockDeviceSelectionReport": []interface{}{"term 1 rejected 1 of 1 devices"},
+			},
+			expectStatus: map[string]interface{}{
+				"phase": "Online",
+			},
+		},
+		"report": {
+			status: map[string]interface{}{
+				"phase": "Online",
+			},
+			report: []string{"term 1 rejected 1 of 1 devices: 1 by field spec.path"},
+			expectStatus: map[string]interface{}{
+				"phase": "Online",
+				"blockDeviceSelectionReport": []interface{}{
+					"term 1 rejected 1 of 1 devices: 1 by field spec.path",
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			s := &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"kind":   string(types.KindCStorClusterConfig),
+							"status": mock.status,
+						},
+					},
+				},
+				response: &generic.SyncHookResponse{},
+				reconcileResponse: ReconcileResponse{
+					BlockDeviceSelectionReport: mock.report,
+				},
+			}
+			// function under test
+			s.reportBlockDeviceSelection()
+			if diff := cmp.Diff(mock.expectStatus, s.response.Status); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+		})
+	}
+}
+
 func TestSyncerRegisterAttachments(t *testing.T) {
 	var tests = map[string]struct {
 		syncer                 *syncer
@@ -544,6 +745,89 @@ func TestSyncerRegisterAttachments(t *testing.T) {
 			expectBlockDeviceCount: 2,
 			expectAttachmentsCount: 3,
 		},
+		"1 blockdevice & watch attachments without persist defaults": {
+			syncer: &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"metadata": map[string]interface{}{
+								"uid": "123",
+							},
+						},
+					},
+					Attachments: common.AnyUnstructRegistry(
+						map[string]map[string]*unstructured.Unstructured{
+							"gvk1": {
+								"nsname1": &unstructured.Unstructured{
+									Object: map[string]interface{}{
+										"kind": string(types.KindBlockDevice),
+									},
+								},
+							},
+							"gvk2": {
+								"nsname1": &unstructured.Unstructured{
+									Object: map[string]interface{}{
+										"kind": string(types.KindCStorClusterConfig),
+										"metadata": map[string]interface{}{
+											"uid": "123",
+										},
+									},
+								},
+							},
+						},
+					),
+				},
+				response: &generic.SyncHookResponse{},
+			},
+			expectBlockDeviceCount: 1,
+			expectAttachmentsCount: 2,
+		},
+		"1 blockdevice & watch attachments with persist defaults": {
+			syncer: &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"metadata": map[string]interface{}{
+								"uid": "123",
+							},
+						},
+					},
+					Attachments: common.AnyUnstructRegistry(
+						map[string]map[string]*unstructured.Unstructured{
+							"gvk1": {
+								"nsname1": &unstructured.Unstructured{
+									Object: map[string]interface{}{
+										"kind": string(types.KindBlockDevice),
+									},
+								},
+							},
+							"gvk2": {
+								"nsname1": &unstructured.Unstructured{
+									Object: map[string]interface{}{
+										"kind": string(types.KindCStorClusterConfig),
+										"metadata": map[string]interface{}{
+											"uid": "123",
+										},
+									},
+								},
+								"nsname2": &unstructured.Unstructured{
+									Object: map[string]interface{}{
+										"kind": string(types.KindCStorClusterConfig),
+										"metadata": map[string]interface{}{
+											"uid": "1234",
+										},
+									},
+								},
+							},
+						},
+					),
+				},
+				response:          &generic.SyncHookResponse{},
+				isPersistDefaults: true,
+			},
+			expectBlockDeviceCount: 1,
+			expectAttachmentsCount: 2,
+		},
 	}
 	for name, mock := range tests {
 		name := name
@@ -578,55 +862,187 @@ func TestSyncerRegisterAttachments(t *testing.T) {
 }
 
 // TODO
-func TestSyncerReconcile(t *testing.T) {
+func newTestBoundClaims(deviceNames ...string) []*unstructured.Unstructured {
+	var claims []*unstructured.Unstructured
+	for _, name := range deviceNames {
+		claims = append(claims, &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDeviceClaim),
+				"spec": map[string]interface{}{
+					"blockDeviceName": name,
+				},
+				"status": map[string]interface{}{
+					"phase": string(types.BlockDeviceClaimBound),
+				},
+			},
+		})
+	}
+	return claims
+}
+
+func TestSyncerArbitrateOwnership(t *testing.T) {
+	newWatch := func(approved string) *unstructured.Unstructured {
+		watch := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": string(types.APIVersionDAOMayaDataV1Alpha1),
+				"kind":       string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      "my-config",
+					"namespace": "openebs",
+				},
+			},
+		}
+		if approved != "" {
+			watch.SetAnnotations(map[string]string{
+				types.AnnKeyCStorClusterConfigApproveOwnerSwitch: approved,
+			})
+		}
+		return watch
+	}
+	newCSPC := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorPoolCluster),
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": "openebs",
+					"annotations": map[string]interface{}{
+						types.AnnKeyCStorPoolClusterOwner: owner.CStorPoolCluster,
+					},
+				},
+			},
+		}
+	}
 	var tests = map[string]struct {
-		syncer                *syncer
-		expectAttachmentCount int
-		isSkipReconcile       bool
-		isErr                 bool
+		syncer                 *syncer
+		isSkip                 bool
+		expectCStorPoolCluster string
+		expectAttachmentsCount int
 	}{
-		"empty syncer": {
+		"no cspc of other owner": {
 			syncer: &syncer{
-				request: &generic.SyncHookRequest{},
+				request:  &generic.SyncHookRequest{Watch: newWatch("")},
+				response: &generic.SyncHookResponse{},
 			},
-			isErr: true,
 		},
-		"non empty watch": {
+		"cspc of other owner": {
 			syncer: &syncer{
-				request: &generic.SyncHookRequest{
-					Watch: &unstructured.Unstructured{
-						Object: map[string]interface{}{},
-					},
+				request:  &generic.SyncHookRequest{Watch: newWatch("")},
+				response: &generic.SyncHookResponse{},
+				otherCStorPoolClusters: []*unstructured.Unstructured{
+					newCSPC("my-config"),
 				},
 			},
-			isErr: true,
+			isSkip: true,
 		},
-		"non empty watch && non empty block devices": {
+		"cspcs of other owner && switch approved": {
 			syncer: &syncer{
 				request: &generic.SyncHookRequest{
-					Watch: &unstructured.Unstructured{
-						Object: map[string]interface{}{},
-					},
+					Watch: newWatch(owner.LocalDevice),
 				},
-				blockDevices: []*unstructured.Unstructured{
-					{},
+				response: &generic.SyncHookResponse{},
+				otherCStorPoolClusters: []*unstructured.Unstructured{
+					newCSPC("my-config"),
+					newCSPC("my-config-zone-a"),
 				},
 			},
-			isErr: true,
+			expectCStorPoolCluster: "my-config",
+			expectAttachmentsCount: 1,
 		},
-		"striped CStorClusterConfig && 1 block device in 1 node && 0 CStorPoolCluster": {
-			syncer: &syncer{
-				response: &generic.SyncHookResponse{},
-				request: &generic.SyncHookRequest{
-					Watch: &unstructured.Unstructured{
-						Object: map[string]interface{}{
-							"kind": string(types.KindCStorClusterConfig),
-							"metadata": map[string]interface{}{
-								"name":      "test",
-								"namespace": "test",
-							},
-							"spec": map[string]interface{}{
-								"diskConfig": map[string]interface{}{
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			mock.syncer.arbitrateOwnership()
+			if mock.syncer.err != nil {
+				t.Fatalf("Expected no error got [%+v]", mock.syncer.err)
+			}
+			if mock.syncer.response.SkipReconcile != mock.isSkip {
+				t.Fatalf(
+					"Expected skip %t got %t",
+					mock.isSkip, mock.syncer.response.SkipReconcile,
+				)
+			}
+			if mock.isSkip && !skip.HasCondition(
+				&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"status": mock.syncer.response.Status,
+					},
+				},
+				controllerName,
+			) {
+				t.Fatalf("Expected skip condition got none")
+			}
+			var gotName string
+			if mock.syncer.cstorPoolCluster != nil {
+				gotName = mock.syncer.cstorPoolCluster.GetName()
+			}
+			if gotName != mock.expectCStorPoolCluster {
+				t.Fatalf(
+					"Expected cspc %q got %q", mock.expectCStorPoolCluster, gotName,
+				)
+			}
+			if len(mock.syncer.response.Attachments) != mock.expectAttachmentsCount {
+				t.Fatalf(
+					"Expected attachments count %d got %d",
+					mock.expectAttachmentsCount,
+					len(mock.syncer.response.Attachments),
+				)
+			}
+		})
+	}
+}
+
+func TestSyncerReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		syncer                *syncer
+		expectAttachmentCount int
+		isSkipReconcile       bool
+		isErr                 bool
+	}{
+		"empty syncer": {
+			syncer: &syncer{
+				request: &generic.SyncHookRequest{},
+			},
+			isErr: true,
+		},
+		"non empty watch": {
+			syncer: &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{},
+					},
+				},
+			},
+			isErr: true,
+		},
+		"non empty watch && non empty block devices": {
+			syncer: &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{},
+					},
+				},
+				blockDevices: []*unstructured.Unstructured{
+					{},
+				},
+			},
+			isErr: true,
+		},
+		"striped CStorClusterConfig && 1 block device in 1 node && 0 CStorPoolCluster": {
+			syncer: &syncer{
+				response: &generic.SyncHookResponse{},
+				request: &generic.SyncHookRequest{
+					Watch: &unstructured.Unstructured{
+						Object: map[string]interface{}{
+							"kind": string(types.KindCStorClusterConfig),
+							"metadata": map[string]interface{}{
+								"name":      "test",
+								"namespace": "test",
+							},
+							"spec": map[string]interface{}{
+								"diskConfig": map[string]interface{}{
 									"local": map[string]interface{}{
 										"blockDeviceSelector": map[string]interface{}{
 											"selectorTerms": []interface{}{
@@ -646,6 +1062,7 @@ func TestSyncerReconcile(t *testing.T) {
 						},
 					},
 				},
+				blockDeviceClaims: newTestBoundClaims("bd1"),
 				blockDevices: []*unstructured.Unstructured{
 					{
 						Object: map[string]interface{}{
@@ -738,6 +1155,7 @@ func TestSyncerReconcile(t *testing.T) {
 						},
 					},
 				},
+				blockDeviceClaims: newTestBoundClaims("bd1", "bd2"),
 				blockDevices: []*unstructured.Unstructured{
 					{
 						Object: map[string]interface{}{
@@ -806,6 +1224,7 @@ func TestSyncerReconcile(t *testing.T) {
 						},
 					},
 				},
+				blockDeviceClaims: newTestBoundClaims("bd11", "bd12", "bd21", "bd22"),
 				blockDevices: []*unstructured.Unstructured{
 					{
 						Object: map[string]interface{}{
@@ -898,6 +1317,9 @@ func TestSyncerReconcile(t *testing.T) {
 						},
 					},
 				},
+				blockDeviceClaims: newTestBoundClaims(
+					"bd11", "bd12", "bd13", "bd21", "bd22", "bd23",
+				),
 				blockDevices: []*unstructured.Unstructured{
 					{
 						Object: map[string]interface{}{
@@ -1008,6 +1430,194 @@ func TestSyncerReconcile(t *testing.T) {
 	}
 }
 
+func TestSyncerReconcileWithExportOutputMode(t *testing.T) {
+	newWatch := func(outputMode string) *unstructured.Unstructured {
+		watch := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+				"kind":       string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      "test",
+					"namespace": "test",
+					"uid":       "config-1",
+				},
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"local": map[string]interface{}{
+							"blockDeviceSelector": map[string]interface{}{
+								"selectorTerms": []interface{}{
+									map[string]interface{}{
+										"matchLabels": map[string]interface{}{
+											"kubernetes.io/hostname": "node-001",
+										},
+									},
+								},
+							},
+						},
+					},
+					"poolConfig": map[string]interface{}{
+						"raidType": string(types.PoolRAIDTypeStripe),
+					},
+				},
+			},
+		}
+		if outputMode != "" {
+			unstructured.SetNestedField(watch.Object, outputMode, "spec", "outputMode")
+		}
+		return watch
+	}
+	var tests = map[string]struct {
+		outputMode  types.OutputMode
+		expectKinds []string
+	}{
+		"apply output mode": {
+			outputMode:  types.OutputModeApply,
+			expectKinds: []string{string(types.KindCStorPoolCluster)},
+		},
+		"export output mode": {
+			outputMode:  types.OutputModeExport,
+			expectKinds: []string{"ConfigMap"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			s := &syncer{
+				request: &generic.SyncHookRequest{
+					Watch: newWatch(string(mock.outputMode)),
+				},
+				response:          &generic.SyncHookResponse{},
+				outputMode:        mock.outputMode,
+				blockDeviceClaims: newTestBoundClaims("bd1"),
+				blockDevices: []*unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindBlockDevice),
+							"metadata": map[string]interface{}{
+								"name":      "bd1",
+								"namespace": "storage",
+								"labels": map[string]interface{}{
+									"kubernetes.io/hostname": "node-001",
+								},
+							},
+						},
+					},
+				},
+			}
+			s.reconcile()
+			if s.err != nil {
+				t.Fatalf("Expected no error got [%+v]", s.err)
+			}
+			var gotKinds []string
+			for _, attachment := range s.response.Attachments {
+				gotKinds = append(gotKinds, attachment.GetKind())
+			}
+			if !reflect.DeepEqual(gotKinds, mock.expectKinds) {
+				t.Fatalf("Expected kinds %v got %v", mock.expectKinds, gotKinds)
+			}
+		})
+	}
+}
+
+func TestSyncerLockDevicesOfTwoConfigs(t *testing.T) {
+	newWatch := func(uid string, matchLabels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      uid,
+					"namespace": "test",
+					"uid":       uid,
+				},
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"local": map[string]interface{}{
+							"blockDeviceSelector": map[string]interface{}{
+								"selectorTerms": []interface{}{
+									map[string]interface{}{
+										"matchLabels": matchLabels,
+									},
+								},
+							},
+						},
+					},
+					"poolConfig": map[string]interface{}{
+						"raidType": string(types.PoolRAIDTypeStripe),
+					},
+				},
+			},
+		}
+	}
+	newDevice := func(name string, labels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": "storage",
+					"labels":    labels,
+				},
+			},
+		}
+	}
+	// devices of the same node are observed by both the configs
+	devices := []*unstructured.Unstructured{
+		newDevice("bd1", map[string]interface{}{
+			"kubernetes.io/hostname": "node-001",
+			"tier":                   "fast",
+		}),
+		newDevice("bd2", map[string]interface{}{
+			"kubernetes.io/hostname": "node-001",
+		}),
+		newDevice("bd3", map[string]interface{}{
+			"kubernetes.io/hostname": "node-001",
+		}),
+	}
+	// first config selects its device & second config selects all
+	// the devices of the node
+	first := &syncer{
+		request: &generic.SyncHookRequest{
+			Watch: newWatch("ccc-1", map[string]interface{}{"tier": "fast"}),
+		},
+		response:     &generic.SyncHookResponse{},
+		blockDevices: devices,
+	}
+	second := &syncer{
+		request: &generic.SyncHookRequest{
+			Watch: newWatch("ccc-2", map[string]interface{}{
+				"kubernetes.io/hostname": "node-001",
+			}),
+		},
+		response:     &generic.SyncHookResponse{},
+		blockDevices: devices,
+	}
+	// devices reserved by the syncs of other tests are released
+	lock.ReleaseDevices("bd1", "bd2", "bd3")
+	defer lock.ReserveDevices("ccc-1")
+	defer lock.ReserveDevices("ccc-2")
+	for _, s := range []*syncer{first, second} {
+		// claims of the selected devices are not observed yet & hence
+		// reconciliation is skipped after selecting the devices
+		s.lockDevices()
+		s.reconcile()
+		s.unlock()
+		if s.err != nil {
+			t.Fatalf("Expected no error got [%+v]", s.err)
+		}
+	}
+	if diff := cmp.Diff(
+		[]string{"bd1"}, first.reconcileResponse.SelectedBlockDeviceNames,
+	); diff != "" {
+		t.Fatalf("Expected no diff in devices of first config got\n%s", diff)
+	}
+	if diff := cmp.Diff(
+		[]string{"bd2", "bd3"}, second.reconcileResponse.SelectedBlockDeviceNames,
+	); diff != "" {
+		t.Fatalf("Expected no diff in devices of second config got\n%s", diff)
+	}
+}
+
 func TestReconcilerIsObservedBlockDeviceCountMatchRAIDType(t *testing.T) {
 	var tests = map[string]struct {
 		reconciler *Reconciler
@@ -1177,6 +1787,118 @@ func TestReconcilerIsObservedBlockDeviceCountMatchRAIDType(t *testing.T) {
 	}
 }
 
+func TestReconcilerIsSelectedBlockDeviceCountMatchRAIDTypeBestEffort(t *testing.T) {
+	newConfig := func(policy string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"poolConfig": map[string]interface{}{
+						"raidType":               string(types.PoolRAIDTypeMirror),
+						"partialPlacementPolicy": policy,
+					},
+				},
+			},
+		}
+	}
+	newDevice := func(name string) *unstructured.Unstructured {
+		device := &unstructured.Unstructured{}
+		device.SetName(name)
+		return device
+	}
+	var tests = map[string]struct {
+		policy                  string
+		observedHostNamesInCSPC []string
+		expectHostNames         []string
+		expectSkippedHostNames  []string
+		expectDeviceCount       int
+		isErr                   bool
+	}{
+		"strict policy": {
+			policy: "Strict",
+			isErr:  true,
+		},
+		"best effort policy skips nodes with invalid device count": {
+			policy:                 "BestEffort",
+			expectHostNames:        []string{"node-001"},
+			expectSkippedHostNames: []string{"node-002", "node-003"},
+			expectDeviceCount:      2,
+		},
+		"best effort policy never skips nodes of observed pools": {
+			policy:                  "BestEffort",
+			observedHostNamesInCSPC: []string{"node-001", "node-003"},
+			isErr:                   true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ObservedCStorClusterConfig: newConfig(mock.policy),
+				selectedBlockDevices: []*unstructured.Unstructured{
+					newDevice("bd1"),
+					newDevice("bd2"),
+					newDevice("bd21"),
+					newDevice("bd31"),
+					newDevice("bd32"),
+					newDevice("bd33"),
+				},
+				hostNameToSelectedBlockDeviceNames: map[string][]string{
+					"node-001": {"bd1", "bd2"},
+					"node-002": {"bd21"},
+					"node-003": {"bd31", "bd32", "bd33"},
+				},
+				nodeHostNameToSelectedBlockDeviceNames: map[string][]string{
+					"node-001": {"bd1", "bd2"},
+					"node-002": {"bd21"},
+					"node-003": {"bd31", "bd32", "bd33"},
+				},
+				observedHostNamesInCSPC: mock.observedHostNamesInCSPC,
+			}
+			r.init()
+			r.isSelectedBlockDeviceCountMatchRAIDType()
+			if mock.isErr {
+				if errs.TypeOf(r.err) != errs.TypeNotEnoughResources {
+					t.Fatalf("Expected not enough resources error got [%+v]", r.err)
+				}
+				return
+			}
+			if r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			var gotHostNames, gotNodeHostNames, gotSkippedHostNames []string
+			for hostName := range r.hostNameToSelectedBlockDeviceNames {
+				gotHostNames = append(gotHostNames, hostName)
+			}
+			for hostName := range r.nodeHostNameToSelectedBlockDeviceNames {
+				gotNodeHostNames = append(gotNodeHostNames, hostName)
+			}
+			for _, skipped := range r.skippedNodes {
+				if skipped.Reason == "" {
+					t.Fatalf("Expected reason for skipped node %q got none", skipped.HostName)
+				}
+				gotSkippedHostNames = append(gotSkippedHostNames, skipped.HostName)
+			}
+			if diff := cmp.Diff(mock.expectHostNames, gotHostNames); diff != "" {
+				t.Fatalf("Expected no diff in pool host names got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectHostNames, gotNodeHostNames); diff != "" {
+				t.Fatalf("Expected no diff in node host names got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectSkippedHostNames, gotSkippedHostNames); diff != "" {
+				t.Fatalf("Expected no diff in skipped host names got\n%s", diff)
+			}
+			if len(r.selectedBlockDevices) != mock.expectDeviceCount {
+				t.Fatalf(
+					"Expected %d selected devices got %d",
+					mock.expectDeviceCount, len(r.selectedBlockDevices),
+				)
+			}
+		})
+	}
+}
+
 func TestReconcilerMapHostNameToSelectedBlockDevices(t *testing.T) {
 	var tests = map[string]struct {
 		reconciler                   *Reconciler
@@ -1377,6 +2099,74 @@ func TestReconcilerMapHostNameToSelectedBlockDevices(t *testing.T) {
 	}
 }
 
+func TestReconcilerGroupByNodeSelectorValue(t *testing.T) {
+	newNode := func(name, hostName, zone string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname":      hostName,
+						"topology.kubernetes.io/zone": zone,
+					},
+				},
+			},
+		}
+	}
+	nodes := []*unstructured.Unstructured{
+		newNode("node-1", "host-1", "zone-a"),
+		newNode("node-2", "host-2", "zone-a"),
+		newNode("node-3", "host-3", "zone-b"),
+	}
+	hostNameToDeviceNames := map[string][]string{
+		"host-2": {"bd-3"},
+		"host-1": {"bd-1", "bd-2"},
+		"host-3": {"bd-4"},
+	}
+	var tests = map[string]struct {
+		nodeSelectorKey string
+		expect          map[string][]string
+	}{
+		"no key": {
+			expect: hostNameToDeviceNames,
+		},
+		"default key": {
+			nodeSelectorKey: types.DefaultNodeSelectorKey,
+			expect:          hostNameToDeviceNames,
+		},
+		"zone key": {
+			nodeSelectorKey: "topology.kubernetes.io/zone",
+			expect: map[string][]string{
+				"zone-a": {"bd-1", "bd-2", "bd-3"},
+				"zone-b": {"bd-4"},
+			},
+		},
+		"key missing from nodes": {
+			nodeSelectorKey: "example.com/rack",
+			expect: map[string][]string{
+				"node-1": {"bd-1", "bd-2"},
+				"node-2": {"bd-3"},
+				"node-3": {"bd-4"},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ObservedNodes:   nodes,
+				nodeSelectorKey: mock.nodeSelectorKey,
+			}
+			got := r.groupByNodeSelectorValue(hostNameToDeviceNames)
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestReconcilerWalkObservedCStorPoolCluster(t *testing.T) {
 	var tests = map[string]struct {
 		reconciler              *Reconciler
@@ -1599,7 +2389,9 @@ func TestReconcilerBuildDesiredCStorPoolCluster(t *testing.T) {
 					"node-001": {"bd10", "bd11"},
 					"node-002": {"bd20", "bd21"},
 				},
-				raidType: types.PoolRAIDTypeMirror,
+				raidType:             types.PoolRAIDTypeMirror,
+				targetNamespace:      "openebs",
+				cstorPoolClusterName: "test",
 			},
 			expectCSPC: &unstructured.Unstructured{
 				Object: map[string]interface{}{
@@ -1607,10 +2399,11 @@ func TestReconcilerBuildDesiredCStorPoolCluster(t *testing.T) {
 					"apiVersion": types.APIVersionCStorOpenEBSV1,
 					"metadata": map[string]interface{}{
 						"name":      "test",
-						"namespace": "test",
+						"namespace": "openebs",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterConfigLocalDisk): "true",
-							string(types.AnnKeyCStorClusterConfigUID):       "ccc-101",
+							string(types.AnnKeyCStorClusterConfigLocalDisk):      "true",
+							string(types.AnnKeyCStorClusterConfigUID):            "ccc-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "test/test",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -1684,7 +2477,9 @@ func TestReconcilerBuildDesiredCStorPoolCluster(t *testing.T) {
 					"node-001": {"bd10", "bd11"},
 					"node-002": {"bd20", "bd21"},
 				},
-				raidType: types.PoolRAIDTypeStripe,
+				raidType:             types.PoolRAIDTypeStripe,
+				targetNamespace:      "openebs",
+				cstorPoolClusterName: "test",
 			},
 			expectCSPC: &unstructured.Unstructured{
 				Object: map[string]interface{}{
@@ -1692,10 +2487,11 @@ func TestReconcilerBuildDesiredCStorPoolCluster(t *testing.T) {
 					"apiVersion": types.APIVersionCStorOpenEBSV1,
 					"metadata": map[string]interface{}{
 						"name":      "test",
-						"namespace": "test",
+						"namespace": "openebs",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterConfigLocalDisk): "true",
-							string(types.AnnKeyCStorClusterConfigUID):       "ccc-101",
+							string(types.AnnKeyCStorClusterConfigLocalDisk):      "true",
+							string(types.AnnKeyCStorClusterConfigUID):            "ccc-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "test/test",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -1815,6 +2611,7 @@ func TestReconcilerReconcile(t *testing.T) {
 		},
 		"expect mirror cspc when observed cspc is nil": {
 			reconciler: &Reconciler{
+				ObservedBlockDeviceClaims: newTestBoundClaims("bd1", "bd2"),
 				ObservedBlockDevices: []*unstructured.Unstructured{
 					{
 						Object: map[string]interface{}{
@@ -1874,10 +2671,11 @@ func TestReconcilerReconcile(t *testing.T) {
 					"apiVersion": types.APIVersionCStorOpenEBSV1,
 					"metadata": map[string]interface{}{
 						"name":      "test",
-						"namespace": "test",
+						"namespace": "openebs",
 						"annotations": map[string]interface{}{
-							string(types.AnnKeyCStorClusterConfigLocalDisk): "true",
-							string(types.AnnKeyCStorClusterConfigUID):       "ccc-101",
+							string(types.AnnKeyCStorClusterConfigLocalDisk):      "true",
+							string(types.AnnKeyCStorClusterConfigUID):            "ccc-101",
+							string(types.AnnKeyCStorClusterConfigNamespacedName): "test/test",
 						},
 					},
 					"spec": map[string]interface{}{
@@ -1935,10 +2733,36 @@ func TestReconcilerReconcile(t *testing.T) {
 	}
 }
 
+func TestReconcilerReconcilePastDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), -1)
+	defer cancel()
+	r := &Reconciler{
+		ObservedCStorClusterConfig: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+			},
+		},
+		ObservedBlockDevices: []*unstructured.Unstructured{
+			{
+				Object: map[string]interface{}{},
+			},
+		},
+		Context: ctx,
+	}
+	_, err := r.Reconcile()
+	if errs.TypeOf(err) != errs.TypeTimeout {
+		t.Fatalf("Expected timeout error got [%+v]", err)
+	}
+	if r.raidType != "" {
+		t.Fatalf("Expected no steps to run got raid type %q", r.raidType)
+	}
+}
+
 func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 	var tests = map[string]struct {
 		reconciler         *Reconciler
 		expectBlockDevices []*unstructured.Unstructured
+		expectReport       []string
 		isErr              bool
 	}{
 		"nil observed block devices": {
@@ -2178,6 +3002,9 @@ func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 					},
 				},
 			},
+			expectReport: []string{
+				"term 1 rejected 2 of 2 devices: 2 by field spec.path",
+			},
 			isErr: true,
 		},
 		"passing label based blockdevice selector term": {
@@ -2258,7 +3085,7 @@ func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 			},
 			isErr: false,
 		},
-		"failing label based blockdevice selector term": {
+		"all selected blockdevices are excluded": {
 			reconciler: &Reconciler{
 				ObservedBlockDevices: []*unstructured.Unstructured{
 					{
@@ -2268,7 +3095,7 @@ func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 								"name":      "bd1",
 								"namespace": "openebs",
 								"labels": map[string]interface{}{
-									"app": "ndm-1",
+									"reserved": "true",
 								},
 							},
 							"spec": map[string]interface{}{
@@ -2276,21 +3103,6 @@ func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 							},
 						},
 					},
-					{
-						Object: map[string]interface{}{
-							"kind": string(types.KindBlockDevice),
-							"metadata": map[string]interface{}{
-								"name":      "bd2",
-								"namespace": "openebs",
-								"labels": map[string]interface{}{
-									"app": "ndm-2",
-								},
-							},
-							"spec": map[string]interface{}{
-								"path": "/dev/sdb",
-							},
-						},
-					},
 				},
 				ObservedCStorClusterConfig: &unstructured.Unstructured{
 					Object: map[string]interface{}{
@@ -2306,17 +3118,222 @@ func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 										"selectorTerms": []interface{}{
 											map[string]interface{}{
 												"matchFields": map[string]interface{}{
-													"metadata.labels.app": "ndm-33",
+													"spec.path": "/dev/sdc",
 												},
 											},
 										},
 									},
-								},
-							},
-						},
+									"blockDeviceExclude": map[string]interface{}{
+										"selectorTerms": []interface{}{
+											map[string]interface{}{
+												"matchLabels": map[string]interface{}{
+													"reserved": "true",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectReport: []string{
+				"term 1 rejected 0 of 1 devices",
+				"exclude terms rejected 1 of 1 matching devices",
+			},
+			isErr: true,
+		},
+		"all selected blockdevices are reserved": {
+			reconciler: &Reconciler{
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindBlockDevice),
+							"metadata": map[string]interface{}{
+								"name":      "bd1",
+								"namespace": "openebs",
+								"labels": map[string]interface{}{
+									"ndm.io/reserved": "true",
+								},
+							},
+							"spec": map[string]interface{}{
+								"path": "/dev/sdc",
+							},
+						},
+					},
+				},
+				ObservedCStorClusterConfig: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": string(types.KindCStorClusterConfig),
+						"metadata": map[string]interface{}{
+							"name":      "test",
+							"namespace": "test",
+						},
+						"spec": map[string]interface{}{
+							"diskConfig": map[string]interface{}{
+								"local": map[string]interface{}{
+									"blockDeviceSelector": map[string]interface{}{
+										"selectorTerms": []interface{}{
+											map[string]interface{}{
+												"matchFields": map[string]interface{}{
+													"spec.path": "/dev/sdc",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectReport: []string{
+				"term 1 rejected 0 of 1 devices",
+				"reservations rejected 1 of 1 matching devices: 1 by reserved key ndm.io/reserved",
+			},
+			isErr: true,
+		},
+		"selected blockdevices minus excluded blockdevices": {
+			reconciler: &Reconciler{
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindBlockDevice),
+							"metadata": map[string]interface{}{
+								"name":      "bd1",
+								"namespace": "openebs",
+							},
+							"spec": map[string]interface{}{
+								"path": "/dev/sdc",
+							},
+						},
+					},
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindBlockDevice),
+							"metadata": map[string]interface{}{
+								"name":      "bd2",
+								"namespace": "openebs",
+							},
+							"spec": map[string]interface{}{
+								"path": "/dev/sda",
+							},
+						},
+					},
+				},
+				ObservedCStorClusterConfig: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": string(types.KindCStorClusterConfig),
+						"metadata": map[string]interface{}{
+							"name":      "test",
+							"namespace": "test",
+						},
+						"spec": map[string]interface{}{
+							"diskConfig": map[string]interface{}{
+								"local": map[string]interface{}{
+									"blockDeviceSelector": map[string]interface{}{
+										"selectorTerms": []interface{}{
+											map[string]interface{}{
+												"matchFields": map[string]interface{}{
+													"metadata.namespace": "openebs",
+												},
+											},
+										},
+									},
+									"blockDeviceExclude": map[string]interface{}{
+										"selectorTerms": []interface{}{
+											map[string]interface{}{
+												"matchFields": map[string]interface{}{
+													"spec.path": "/dev/sda",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectBlockDevices: []*unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"kind": string(types.KindBlockDevice),
+						"metadata": map[string]interface{}{
+							"name":      "bd1",
+							"namespace": "openebs",
+						},
+						"spec": map[string]interface{}{
+							"path": "/dev/sdc",
+						},
+					},
+				},
+			},
+			isErr: false,
+		},
+		"failing label based blockdevice selector term": {
+			reconciler: &Reconciler{
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindBlockDevice),
+							"metadata": map[string]interface{}{
+								"name":      "bd1",
+								"namespace": "openebs",
+								"labels": map[string]interface{}{
+									"app": "ndm-1",
+								},
+							},
+							"spec": map[string]interface{}{
+								"path": "/dev/sdc",
+							},
+						},
+					},
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindBlockDevice),
+							"metadata": map[string]interface{}{
+								"name":      "bd2",
+								"namespace": "openebs",
+								"labels": map[string]interface{}{
+									"app": "ndm-2",
+								},
+							},
+							"spec": map[string]interface{}{
+								"path": "/dev/sdb",
+							},
+						},
+					},
+				},
+				ObservedCStorClusterConfig: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": string(types.KindCStorClusterConfig),
+						"metadata": map[string]interface{}{
+							"name":      "test",
+							"namespace": "test",
+						},
+						"spec": map[string]interface{}{
+							"diskConfig": map[string]interface{}{
+								"local": map[string]interface{}{
+									"blockDeviceSelector": map[string]interface{}{
+										"selectorTerms": []interface{}{
+											map[string]interface{}{
+												"matchFields": map[string]interface{}{
+													"metadata.labels.app": "ndm-33",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
+			expectReport: []string{
+				"term 1 rejected 2 of 2 devices: 2 by field metadata.labels.app",
+			},
 			isErr: true,
 		},
 	}
@@ -2333,6 +3350,9 @@ func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 			if !mock.isErr && r.err != nil {
 				t.Fatalf("Expected no error got [%+v]", r.err)
 			}
+			if diff := cmp.Diff(mock.expectReport, r.selectionReport); diff != "" {
+				t.Fatalf("Expected no diff in report got \n%s", diff)
+			}
 			if mock.isErr {
 				return
 			}
@@ -2350,3 +3370,1118 @@ func TestReconcilerSelectFromObservedBlockDevices(t *testing.T) {
 		})
 	}
 }
+
+func TestReconcilerSelectFromObservedBlockDevicesWithExplicitDeviceMap(t *testing.T) {
+	newConfig := func(selectorTerms []interface{}, mappings ...interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      "test",
+					"namespace": "test",
+				},
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"local": map[string]interface{}{
+							"blockDeviceSelector": map[string]interface{}{
+								"selectorTerms": selectorTerms,
+							},
+							"explicitDeviceMap": mappings,
+						},
+					},
+				},
+			},
+		}
+	}
+	poolTerms := []interface{}{
+		map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				"pool": "yes",
+			},
+		},
+	}
+	devices := []*unstructured.Unstructured{
+		newTestRackDevice("bd1", "node-1"),
+		newTestRackDevice("bd2", "node-1"),
+		newTestRackDevice("bd3", "node-2"),
+		newTestRackDevice("bd4", "node-2"),
+	}
+	var tests = map[string]struct {
+		config        *unstructured.Unstructured
+		failureDomain string
+		expect        []string
+		isErr         bool
+	}{
+		"explicit device map without selector": {
+			config: newConfig(nil, map[string]interface{}{
+				"nodeName":         "node-2",
+				"blockDeviceNames": []interface{}{"bd4"},
+			}),
+			expect: []string{"bd4"},
+		},
+		"selector applies to nodes that are not mapped": {
+			config: newConfig(poolTerms, map[string]interface{}{
+				"nodeName":         "node-1",
+				"blockDeviceNames": []interface{}{"bd2"},
+			}),
+			expect: []string{"bd3", "bd4", "bd2"},
+		},
+		"device on wrong node": {
+			config: newConfig(poolTerms, map[string]interface{}{
+				"nodeName":         "node-1",
+				"blockDeviceNames": []interface{}{"bd3"},
+			}),
+			isErr: true,
+		},
+		"missing device": {
+			config: newConfig(poolTerms, map[string]interface{}{
+				"nodeName":         "node-1",
+				"blockDeviceNames": []interface{}{"bd9"},
+			}),
+			isErr: true,
+		},
+		"missing device of a failure domain is ignored": {
+			config: newConfig(poolTerms, map[string]interface{}{
+				"nodeName":         "node-1",
+				"blockDeviceNames": []interface{}{"bd1", "bd9"},
+			}),
+			failureDomain: "rack-a",
+			expect:        []string{"bd3", "bd4", "bd1"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ObservedCStorClusterConfig: mock.config,
+				ObservedBlockDevices:       devices,
+				FailureDomain:              mock.failureDomain,
+			}
+			r.init()
+			r.selectFromObservedBlockDevices()
+			if mock.isErr && r.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isErr {
+				return
+			}
+			var got []string
+			for _, device := range r.selectedBlockDevices {
+				got = append(got, device.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcilerSkipIfBlockDeviceClaimsNotBound(t *testing.T) {
+	var tests = map[string]struct {
+		reconciler   *Reconciler
+		isSkip       bool
+		expectReason string
+	}{
+		"all claims are bound": {
+			reconciler: &Reconciler{
+				selectedBlockDevices: []*unstructured.Unstructured{
+					{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "bd1"}}},
+					{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "bd2"}}},
+				},
+				ObservedBlockDeviceClaims: newTestBoundClaims("bd1", "bd2"),
+			},
+		},
+		"one claim is not bound": {
+			reconciler: &Reconciler{
+				selectedBlockDevices: []*unstructured.Unstructured{
+					{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "bd1"}}},
+					{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "bd2"}}},
+				},
+				ObservedBlockDeviceClaims: newTestBoundClaims("bd1"),
+			},
+			isSkip:       true,
+			expectReason: "1 of 2 block devices are not claimed: [bd2]",
+		},
+		"no claims": {
+			reconciler: &Reconciler{
+				selectedBlockDevices: []*unstructured.Unstructured{
+					{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "bd1"}}},
+				},
+			},
+			isSkip:       true,
+			expectReason: "1 of 1 block devices are not claimed: [bd1]",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.skipIfBlockDeviceClaimsNotBound()
+			if r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isSkip != r.skipReconcile {
+				t.Fatalf("Expected skip %t got %t", mock.isSkip, r.skipReconcile)
+			}
+			if mock.expectReason != r.skipReconcileReason {
+				t.Fatalf(
+					"Expected reason %q got %q", mock.expectReason, r.skipReconcileReason,
+				)
+			}
+			if mock.isSkip && r.skipReconcileCode != skip.ReasonBlockDeviceClaimsPending {
+				t.Fatalf(
+					"Expected code %q got %q",
+					skip.ReasonBlockDeviceClaimsPending, r.skipReconcileCode,
+				)
+			}
+		})
+	}
+}
+
+func TestReconcilerSkipIfNotEnoughReadyNodes(t *testing.T) {
+	var newConfig = func(minReady int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": "CStorClusterConfig",
+				"spec": map[string]interface{}{
+					"poolConfig": map[string]interface{}{
+						"minReadyNodesBeforeCreate": minReady,
+					},
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		reconciler   *Reconciler
+		isSkip       bool
+		expectReason string
+	}{
+		"min ready nodes is not set": {
+			reconciler: &Reconciler{
+				cccHelper: ccc.NewHelper(&unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind": "CStorClusterConfig",
+					},
+				}),
+			},
+		},
+		"enough ready nodes": {
+			reconciler: &Reconciler{
+				cccHelper: ccc.NewHelper(newConfig(2)),
+				hostNameToSelectedBlockDeviceNames: map[string][]string{
+					"node1": {"bd1"},
+					"node2": {"bd2"},
+				},
+			},
+		},
+		"not enough ready nodes": {
+			reconciler: &Reconciler{
+				cccHelper: ccc.NewHelper(newConfig(3)),
+				hostNameToSelectedBlockDeviceNames: map[string][]string{
+					"node1": {"bd1"},
+				},
+				raidType: types.PoolRAIDTypeStripe,
+			},
+			isSkip:       true,
+			expectReason: `1 of 3 min ready nodes have block devices that match RAID "stripe"`,
+		},
+		"not enough ready nodes with existing cstorpoolcluster": {
+			reconciler: &Reconciler{
+				cccHelper:                ccc.NewHelper(newConfig(3)),
+				ObservedCStorPoolCluster: &unstructured.Unstructured{},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.skipIfNotEnoughReadyNodes()
+			if r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isSkip != r.skipReconcile {
+				t.Fatalf("Expected skip %t got %t", mock.isSkip, r.skipReconcile)
+			}
+			if mock.expectReason != r.skipReconcileReason {
+				t.Fatalf(
+					"Expected reason %q got %q", mock.expectReason, r.skipReconcileReason,
+				)
+			}
+			if mock.isSkip && r.skipReconcileCode != skip.ReasonWaitingForReadyNodes {
+				t.Fatalf(
+					"Expected code %q got %q",
+					skip.ReasonWaitingForReadyNodes, r.skipReconcileCode,
+				)
+			}
+		})
+	}
+}
+
+func TestReconcilerArrangeRAIDGroupsByWear(t *testing.T) {
+	newDevice := func(name, wear string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"annotations": map[string]interface{}{
+						"example.io/ssd-wear": wear,
+					},
+				},
+			},
+		}
+	}
+	newConfig := func(wearLeveling interface{}) *unstructured.Unstructured {
+		diskConfig := map[string]interface{}{}
+		if wearLeveling != nil {
+			diskConfig["wearLeveling"] = wearLeveling
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": diskConfig,
+				},
+			},
+		}
+	}
+	wearLeveling := map[string]interface{}{
+		"fieldPaths": []interface{}{"metadata.annotations[example.io/ssd-wear]"},
+	}
+	devices := []*unstructured.Unstructured{
+		newDevice("bd1", "90"),
+		newDevice("bd2", "85"),
+		newDevice("bd3", "5"),
+		newDevice("bd4", "10"),
+		newDevice("bd5", "95"),
+		newDevice("bd6", "1"),
+	}
+	var tests = map[string]struct {
+		reconciler *Reconciler
+		expect     map[string][]string
+		isErr      bool
+	}{
+		"no wear leveling": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(nil),
+				selectedBlockDevices:       devices,
+				raidType:                   types.PoolRAIDTypeMirror,
+				hostNameToSelectedBlockDeviceNames: map[string][]string{
+					"node1": {"bd1", "bd2", "bd3", "bd4"},
+				},
+			},
+			expect: map[string][]string{
+				"node1": {"bd1", "bd2", "bd3", "bd4"},
+			},
+		},
+		"worn devices are mirrored with fresh devices": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(wearLeveling),
+				selectedBlockDevices:       devices,
+				raidType:                   types.PoolRAIDTypeMirror,
+				hostNameToSelectedBlockDeviceNames: map[string][]string{
+					"node1": {"bd1", "bd2", "bd3", "bd4"},
+				},
+			},
+			expect: map[string][]string{
+				"node1": {"bd3", "bd1", "bd4", "bd2"},
+			},
+		},
+		"observed devices retain their raid groups": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(wearLeveling),
+				selectedBlockDevices:       devices,
+				raidType:                   types.PoolRAIDTypeMirror,
+				hostNameToSelectedBlockDeviceNames: map[string][]string{
+					"node1": {"bd1", "bd2", "bd3", "bd4", "bd5", "bd6"},
+				},
+				hostNameToObservedCSPCDeviceNames: map[string][]string{
+					"node1": {"bd1", "bd2"},
+				},
+			},
+			expect: map[string][]string{
+				"node1": {"bd1", "bd2", "bd6", "bd5", "bd3", "bd4"},
+			},
+		},
+		"invalid wear leveling": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(map[string]interface{}{}),
+				selectedBlockDevices:       devices,
+				raidType:                   types.PoolRAIDTypeMirror,
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.init()
+			r.arrangeRAIDGroupsByWear()
+			if mock.isErr {
+				if errs.TypeOf(r.err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", r.err)
+				}
+				return
+			}
+			if r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if diff := cmp.Diff(mock.expect, r.hostNameToSelectedBlockDeviceNames); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReconcilerReserveCapacityPerNode(t *testing.T) {
+	newDevice := func(name, hostName string, bytes int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname": hostName,
+					},
+				},
+				"spec": map[string]interface{}{
+					"capacity": map[string]interface{}{
+						"storage": bytes,
+					},
+				},
+			},
+		}
+	}
+	newConfig := func(reservePerNode interface{}) *unstructured.Unstructured {
+		diskConfig := map[string]interface{}{}
+		if reservePerNode != nil {
+			diskConfig["reservePerNode"] = reservePerNode
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": diskConfig,
+				},
+			},
+		}
+	}
+	devices := []*unstructured.Unstructured{
+		newDevice("bd1", "node1", 100),
+		newDevice("bd2", "node1", 300),
+		newDevice("bd3", "node1", 100),
+		newDevice("bd4", "node2", 100),
+	}
+	var tests = map[string]struct {
+		reconciler  *Reconciler
+		expectNames []string
+		isErr       bool
+	}{
+		"no reserve": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(nil),
+				ObservedBlockDevices:       devices,
+				selectedBlockDevices:       devices,
+				raidType:                   types.PoolRAIDTypeStripe,
+			},
+			expectNames: []string{"bd1", "bd2", "bd3", "bd4"},
+		},
+		"reserve drops largest device": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("200"),
+				ObservedBlockDevices:       devices,
+				selectedBlockDevices:       devices[:3],
+				raidType:                   types.PoolRAIDTypeStripe,
+			},
+			expectNames: []string{"bd1", "bd3"},
+		},
+		"reserve drops mirror group": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("350"),
+				ObservedBlockDevices:       devices,
+				selectedBlockDevices:       []*unstructured.Unstructured{devices[0], devices[2]},
+				raidType:                   types.PoolRAIDTypeMirror,
+			},
+			isErr: true,
+		},
+		"reserve all capacity": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("100%"),
+				ObservedBlockDevices:       devices,
+				selectedBlockDevices:       devices,
+				raidType:                   types.PoolRAIDTypeStripe,
+			},
+			isErr: true,
+		},
+		"invalid reserve": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("junk"),
+				ObservedBlockDevices:       devices,
+				selectedBlockDevices:       devices,
+				raidType:                   types.PoolRAIDTypeStripe,
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.init()
+			r.reserveCapacityPerNode()
+			if mock.isErr && r.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotNames []string
+			for _, device := range r.selectedBlockDevices {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if !reflect.DeepEqual(gotNames, mock.expectNames) {
+				t.Fatalf("Expected no diff got\n%s",
+					cmp.Diff(gotNames, mock.expectNames),
+				)
+			}
+		})
+	}
+}
+
+func TestReconcilerSelectHybridBlockDevices(t *testing.T) {
+	newNode := func(name string, labels map[string]interface{}) *unstructured.Unstructured {
+		allLabels := map[string]interface{}{
+			"kubernetes.io/hostname": name,
+		}
+		for key, value := range labels {
+			allLabels[key] = value
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindNode),
+				"metadata": map[string]interface{}{
+					"name":   name,
+					"labels": allLabels,
+				},
+			},
+		}
+	}
+	newDevice := func(
+		name, hostName, planUID, claimState string,
+	) *unstructured.Unstructured {
+		labels := map[string]interface{}{
+			"kubernetes.io/hostname": hostName,
+		}
+		if planUID != "" {
+			labels[types.AnnKeyCStorClusterPlanUID] = planUID
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name":   name,
+					"labels": labels,
+				},
+				"status": map[string]interface{}{
+					"state":      string(types.BlockDeviceActive),
+					"claimState": claimState,
+				},
+			},
+		}
+	}
+	newConfig := func(isHybrid bool) *unstructured.Unstructured {
+		diskConfig := map[string]interface{}{
+			"local": map[string]interface{}{
+				"selectAll": true,
+			},
+		}
+		if isHybrid {
+			diskConfig["external"] = map[string]interface{}{
+				"csiAttacherName":  "ebs.csi.aws.com",
+				"storageClassName": "gp2",
+			}
+			diskConfig["nodeOverrides"] = []interface{}{
+				map[string]interface{}{
+					"nodeSelector": map[string]interface{}{
+						"selectorTerms": []interface{}{
+							map[string]interface{}{
+								"matchLabels": map[string]interface{}{
+									"disk-type": "nvme",
+								},
+							},
+						},
+					},
+					"source": "Local",
+				},
+			}
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": diskConfig,
+				},
+			},
+		}
+	}
+	plan := &unstructured.Unstructured{}
+	plan.SetUID("plan-1")
+	nodes := []*unstructured.Unstructured{
+		newNode("node1", map[string]interface{}{"disk-type": "nvme"}),
+		newNode("node2", nil),
+	}
+	localDevice := newDevice("bd1", "node1", "", "Unclaimed")
+	otherLocalDevice := newDevice("bd2", "node2", "", "Unclaimed")
+	plannedDevice := newDevice("bd3", "node2", "plan-1", "Claimed")
+	misplacedDevice := newDevice("bd4", "node1", "plan-1", "Claimed")
+	var tests = map[string]struct {
+		reconciler  *Reconciler
+		expectNames []string
+		isErr       bool
+	}{
+		"local disk config": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(false),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					localDevice, otherLocalDevice, plannedDevice,
+				},
+				ObservedNodes: nodes,
+			},
+			expectNames: []string{"bd1", "bd2"},
+		},
+		"hybrid disk config": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(true),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					localDevice, otherLocalDevice, plannedDevice, misplacedDevice,
+				},
+				ObservedNodes:            nodes,
+				ObservedCStorClusterPlan: plan,
+			},
+			expectNames: []string{"bd1", "bd3"},
+		},
+		"hybrid disk config without plan": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(true),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					localDevice, otherLocalDevice, plannedDevice,
+				},
+				ObservedNodes: nodes,
+			},
+			expectNames: []string{"bd1"},
+		},
+		"hybrid disk config without local devices": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(true),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					otherLocalDevice, plannedDevice,
+				},
+				ObservedNodes:            nodes,
+				ObservedCStorClusterPlan: plan,
+			},
+			expectNames: []string{"bd3"},
+		},
+		"hybrid disk config without any usable devices": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(true),
+				ObservedBlockDevices: []*unstructured.Unstructured{
+					otherLocalDevice,
+				},
+				ObservedNodes:            nodes,
+				ObservedCStorClusterPlan: plan,
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.init()
+			for _, fn := range []func(){
+				r.splitBlockDevicesByDiskSource,
+				r.selectLocalBlockDevices,
+				r.addPlannedBlockDevices,
+			} {
+				fn()
+				if r.err != nil {
+					break
+				}
+			}
+			if mock.isErr && r.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotNames []string
+			for _, device := range r.selectedBlockDevices {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if !reflect.DeepEqual(gotNames, mock.expectNames) {
+				t.Fatalf("Expected no diff got\n%s",
+					cmp.Diff(gotNames, mock.expectNames),
+				)
+			}
+		})
+	}
+}
+
+func TestReconcilerSelectBlockDevicesWithinCapacityBounds(t *testing.T) {
+	newDevice := func(name string, bytes int64) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{
+					"capacity": map[string]interface{}{
+						"storage": bytes,
+					},
+				},
+			},
+		}
+	}
+	newConfig := func(min, max string) *unstructured.Unstructured {
+		local := map[string]interface{}{}
+		if min != "" {
+			local["minDeviceCapacity"] = min
+		}
+		if max != "" {
+			local["maxDeviceCapacity"] = max
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"local": local,
+					},
+				},
+			},
+		}
+	}
+	devices := []*unstructured.Unstructured{
+		newDevice("bd1", 100),
+		newDevice("bd2", 1000),
+		newDevice("bd3", 10000),
+	}
+	var tests = map[string]struct {
+		reconciler  *Reconciler
+		expectNames []string
+		isErr       bool
+	}{
+		"no bounds": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("", ""),
+				selectedBlockDevices:       devices,
+			},
+			expectNames: []string{"bd1", "bd2", "bd3"},
+		},
+		"min & max bounds": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("500", "5000"),
+				selectedBlockDevices:       devices,
+			},
+			expectNames: []string{"bd2"},
+		},
+		"bounds retain devices of observed cstor pool cluster": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("500", ""),
+				ObservedCStorPoolCluster: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind":       string(types.KindCStorPoolCluster),
+						"apiVersion": string(types.APIVersionCStorOpenEBSV1),
+						"spec": map[string]interface{}{
+							"pools": []interface{}{
+								map[string]interface{}{
+									"nodeSelector": map[string]interface{}{
+										"kubernetes.io/hostname": "node1",
+									},
+									"dataRaidGroups": []interface{}{
+										map[string]interface{}{
+											"blockDevices": []interface{}{
+												map[string]interface{}{
+													"blockDeviceName": "bd1",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				selectedBlockDevices: devices,
+			},
+			expectNames: []string{"bd1", "bd2", "bd3"},
+		},
+		"no device within bounds": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("1Mi", ""),
+				selectedBlockDevices:       devices,
+			},
+			isErr: true,
+		},
+		"min more than max": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("5000", "500"),
+				selectedBlockDevices:       devices,
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.init()
+			r.selectBlockDevicesWithinCapacityBounds()
+			if mock.isErr && r.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotNames []string
+			for _, device := range r.selectedBlockDevices {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if !reflect.DeepEqual(gotNames, mock.expectNames) {
+				t.Fatalf("Expected no diff got\n%s",
+					cmp.Diff(gotNames, mock.expectNames),
+				)
+			}
+		})
+	}
+}
+
+func TestReconcilerRejectUnhealthyBlockDevices(t *testing.T) {
+	newDevice := func(name, state, smartStatus string) *unstructured.Unstructured {
+		device := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name": name,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname": "node1",
+					},
+				},
+				"status": map[string]interface{}{
+					"state": state,
+				},
+			},
+		}
+		if smartStatus != "" {
+			device.SetAnnotations(map[string]string{
+				types.AnnKeyBlockDeviceSMARTStatus: smartStatus,
+			})
+		}
+		return device
+	}
+	newConfig := func(requireSMARTPass bool) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"spec": map[string]interface{}{
+					"diskConfig": map[string]interface{}{
+						"healthCheck": map[string]interface{}{
+							"requireSMARTPass": requireSMARTPass,
+						},
+					},
+				},
+			},
+		}
+	}
+	devices := []*unstructured.Unstructured{
+		newDevice("bd1", "Active", types.BlockDeviceSMARTStatusPassed),
+		newDevice("bd2", "Active", types.BlockDeviceSMARTStatusFailed),
+		newDevice("bd3", "Active", ""),
+		newDevice("bd4", "Inactive", types.BlockDeviceSMARTStatusPassed),
+	}
+	var tests = map[string]struct {
+		reconciler    *Reconciler
+		expectNames   []string
+		expectRejects []string
+		isErr         bool
+	}{
+		"smart pass is not required": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(false),
+				selectedBlockDevices:       devices,
+			},
+			expectNames: []string{"bd1", "bd2", "bd3", "bd4"},
+		},
+		"smart pass is required": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(true),
+				selectedBlockDevices:       devices,
+			},
+			expectNames:   []string{"bd1"},
+			expectRejects: []string{"bd2", "bd3", "bd4"},
+		},
+		"smart pass is required & device is used by cspc": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(true),
+				ObservedCStorPoolCluster: &unstructured.Unstructured{
+					Object: map[string]interface{}{
+						"kind":       string(types.KindCStorPoolCluster),
+						"apiVersion": string(types.APIVersionCStorOpenEBSV1),
+						"spec": map[string]interface{}{
+							"pools": []interface{}{
+								map[string]interface{}{
+									"nodeSelector": map[string]interface{}{
+										"kubernetes.io/hostname": "node1",
+									},
+									"dataRaidGroups": []interface{}{
+										map[string]interface{}{
+											"blockDevices": []interface{}{
+												map[string]interface{}{
+													"blockDeviceName": "bd2",
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				selectedBlockDevices: devices,
+			},
+			expectNames:   []string{"bd1", "bd2"},
+			expectRejects: []string{"bd3", "bd4"},
+		},
+		"smart pass is required & no device passed": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(true),
+				selectedBlockDevices:       devices[1:],
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.init()
+			r.rejectUnhealthyBlockDevices()
+			if mock.isErr && r.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotNames []string
+			for _, device := range r.selectedBlockDevices {
+				gotNames = append(gotNames, device.GetName())
+			}
+			if !reflect.DeepEqual(gotNames, mock.expectNames) {
+				t.Fatalf("Expected no diff got\n%s",
+					cmp.Diff(gotNames, mock.expectNames),
+				)
+			}
+			var gotRejects []string
+			for _, device := range r.rejectedBlockDevices {
+				gotRejects = append(gotRejects, device.Name)
+			}
+			if !reflect.DeepEqual(gotRejects, mock.expectRejects) {
+				t.Fatalf("Expected no diff got\n%s",
+					cmp.Diff(gotRejects, mock.expectRejects),
+				)
+			}
+		})
+	}
+}
+
+func TestReconcilerResolveDrift(t *testing.T) {
+	newConfig := func(policy string) *unstructured.Unstructured {
+		spec := map[string]interface{}{}
+		if policy != "" {
+			spec["driftPolicy"] = policy
+		}
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"uid": "ccc-1",
+				},
+				"spec": spec,
+			},
+		}
+	}
+	newCSPC := func(poolName string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"kind": string(types.KindCStorPoolCluster),
+				"spec": map[string]interface{}{
+					"pools": []interface{}{
+						map[string]interface{}{
+							"name": poolName,
+						},
+					},
+				},
+			},
+		}
+	}
+	var tests = map[string]struct {
+		reconciler *Reconciler
+		expectPool string
+		isErr      bool
+	}{
+		"default policy - nil observed cspc": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(""),
+				desiredCStorPoolCluster:    newCSPC("desired"),
+			},
+			expectPool: "desired",
+		},
+		"default policy - never applied cspc": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig(""),
+				ObservedCStorPoolCluster:   newCSPC("observed"),
+				desiredCStorPoolCluster:    newCSPC("desired"),
+			},
+			expectPool: "desired",
+		},
+		"ignore policy - nil observed cspc": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("Ignore"),
+				desiredCStorPoolCluster:    newCSPC("desired"),
+			},
+			expectPool: "desired",
+		},
+		"ignore policy - observed pools are retained": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("Ignore"),
+				ObservedCStorPoolCluster:   newCSPC("observed"),
+				desiredCStorPoolCluster:    newCSPC("desired"),
+			},
+			expectPool: "observed",
+		},
+		"invalid policy": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: newConfig("junk"),
+				ObservedCStorPoolCluster:   newCSPC("observed"),
+				desiredCStorPoolCluster:    newCSPC("desired"),
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := mock.reconciler
+			r.init()
+			r.resolveDrift()
+			if mock.isErr && r.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isErr {
+				return
+			}
+			pools, _, _ := unstructured.NestedSlice(
+				r.desiredCStorPoolCluster.Object, "spec", "pools",
+			)
+			gotPool := pools[0].(map[string]interface{})["name"]
+			if gotPool != mock.expectPool {
+				t.Fatalf("Expected pool %q got %v", mock.expectPool, gotPool)
+			}
+		})
+	}
+}
+
+func TestReconcilerBuildDesiredCStorClusterConfig(t *testing.T) {
+	observed := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "dao.mayadata.io/v1alpha1",
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-cstor-cluster",
+				"namespace": "openebs",
+				"uid":       "123",
+			},
+			"spec": map[string]interface{}{
+				"minPoolCount": 3,
+			},
+		},
+	}
+	var tests = map[string]struct {
+		reconciler *Reconciler
+		expect     *unstructured.Unstructured
+	}{
+		"don't persist defaults": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: observed,
+				raidType:                   types.PoolRAIDTypeMirror,
+				driftPolicy:                types.DriftPolicyEnforce,
+			},
+		},
+		"persist defaults": {
+			reconciler: &Reconciler{
+				ObservedCStorClusterConfig: observed,
+				IsPersistDefaults:          true,
+				raidType:                   types.PoolRAIDTypeMirror,
+				driftPolicy:                types.DriftPolicyEnforce,
+				targetNamespace:            "openebs",
+			},
+			expect: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "dao.mayadata.io/v1alpha1",
+					"kind":       string(types.KindCStorClusterConfig),
+					"metadata": map[string]interface{}{
+						"name":      "my-cstor-cluster",
+						"namespace": "openebs",
+					},
+					"spec": map[string]interface{}{
+						"poolConfig": map[string]interface{}{
+							"raidType": "mirror",
+						},
+						"driftPolicy":     "Enforce",
+						"targetNamespace": "openebs",
+					},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			mock.reconciler.buildDesiredCStorClusterConfig()
+			if diff := cmp.Diff(mock.expect, mock.reconciler.desiredCStorClusterConfig); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
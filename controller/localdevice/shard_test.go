@@ -0,0 +1,440 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localdevice
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestRackNode(hostName, rack string) *unstructured.Unstructured {
+	labels := map[string]interface{}{
+		"kubernetes.io/hostname": hostName,
+	}
+	if rack != "" {
+		labels["rack"] = rack
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": "Node",
+			"metadata": map[string]interface{}{
+				"name":   hostName,
+				"labels": labels,
+			},
+		},
+	}
+}
+
+func newTestRackDevice(name, hostName string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname": hostName,
+					"pool":                   "yes",
+				},
+			},
+		},
+	}
+}
+
+func newTestRackCStorPoolCluster(name, rack string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind":       string(types.KindCStorPoolCluster),
+			"apiVersion": types.APIVersionCStorOpenEBSV1,
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+			},
+		},
+	}
+	if rack != "" {
+		obj.SetAnnotations(map[string]string{
+			types.AnnKeyCStorPoolClusterFailureDomain: rack,
+		})
+	}
+	return obj
+}
+
+func TestShardedReconcilerGroupBlockDevicesByFailureDomain(t *testing.T) {
+	var tests = map[string]struct {
+		nodes           []*unstructured.Unstructured
+		devices         []*unstructured.Unstructured
+		expectDomains   map[string][]string
+		expectReportLen int
+	}{
+		"devices of labeled nodes": {
+			nodes: []*unstructured.Unstructured{
+				newTestRackNode("node-1", "rack-a"),
+				newTestRackNode("node-2", "rack-b"),
+			},
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-1"),
+				newTestRackDevice("bd2", "node-2"),
+				newTestRackDevice("bd3", "node-1"),
+			},
+			expectDomains: map[string][]string{
+				"rack-a": {"bd1", "bd3"},
+				"rack-b": {"bd2"},
+			},
+		},
+		"devices of unlabeled nodes are left out": {
+			nodes: []*unstructured.Unstructured{
+				newTestRackNode("node-1", "rack-a"),
+				newTestRackNode("node-2", ""),
+			},
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-1"),
+				newTestRackDevice("bd2", "node-2"),
+				newTestRackDevice("bd3", "node-3"),
+			},
+			expectDomains: map[string][]string{
+				"rack-a": {"bd1"},
+			},
+			expectReportLen: 1,
+		},
+		"device without hostname label is mapped via its node name": {
+			nodes: []*unstructured.Unstructured{
+				newTestRackNode("node-1", "rack-a"),
+			},
+			devices: []*unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"kind": string(types.KindBlockDevice),
+						"metadata": map[string]interface{}{
+							"name": "bd1",
+						},
+						"spec": map[string]interface{}{
+							"nodeAttributes": map[string]interface{}{
+								"nodeName": "node-1",
+							},
+						},
+					},
+				},
+			},
+			expectDomains: map[string][]string{
+				"rack-a": {"bd1"},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &ShardedReconciler{
+				ObservedNodes:        mock.nodes,
+				ObservedBlockDevices: mock.devices,
+				FailureDomainKey:     "rack",
+				domainToBlockDevices: map[string][]*unstructured.Unstructured{},
+			}
+			r.groupBlockDevicesByFailureDomain()
+			got := map[string][]string{}
+			for domain, devices := range r.domainToBlockDevices {
+				for _, device := range devices {
+					got[domain] = append(got[domain], device.GetName())
+				}
+			}
+			if !reflect.DeepEqual(got, mock.expectDomains) {
+				t.Fatalf("Expected domains %v got %v", mock.expectDomains, got)
+			}
+			if len(r.selectionReport) != mock.expectReportLen {
+				t.Fatalf(
+					"Expected report len %d got %d: %v",
+					mock.expectReportLen, len(r.selectionReport), r.selectionReport,
+				)
+			}
+		})
+	}
+}
+
+func TestShardedReconcilerGroupCStorPoolClustersByFailureDomain(t *testing.T) {
+	var tests = map[string]struct {
+		cspcs         []*unstructured.Unstructured
+		expectDomains map[string]string
+		isErr         bool
+	}{
+		"no cspcs": {
+			expectDomains: map[string]string{},
+		},
+		"cspc per domain": {
+			cspcs: []*unstructured.Unstructured{
+				newTestRackCStorPoolCluster("test-rack-a", "rack-a"),
+				newTestRackCStorPoolCluster("test-rack-b", "rack-b"),
+			},
+			expectDomains: map[string]string{
+				"rack-a": "test-rack-a",
+				"rack-b": "test-rack-b",
+			},
+		},
+		"unsharded cspc": {
+			cspcs: []*unstructured.Unstructured{
+				newTestRackCStorPoolCluster("test", ""),
+			},
+			isErr: true,
+		},
+		"two cspcs of same domain": {
+			cspcs: []*unstructured.Unstructured{
+				newTestRackCStorPoolCluster("test-rack-a", "rack-a"),
+				newTestRackCStorPoolCluster("test-rack-a-2", "rack-a"),
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &ShardedReconciler{
+				ObservedCStorPoolClusters: mock.cspcs,
+				FailureDomainKey:          "rack",
+				domainToCStorPoolCluster:  map[string]*unstructured.Unstructured{},
+			}
+			r.groupCStorPoolClustersByFailureDomain()
+			if mock.isErr && r.err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && r.err != nil {
+				t.Fatalf("Expected no error got [%+v]", r.err)
+			}
+			if mock.isErr {
+				if errs.TypeOf(r.err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", r.err)
+				}
+				return
+			}
+			got := map[string]string{}
+			for domain, cspc := range r.domainToCStorPoolCluster {
+				got[domain] = cspc.GetName()
+			}
+			if !reflect.DeepEqual(got, mock.expectDomains) {
+				t.Fatalf("Expected domains %v got %v", mock.expectDomains, got)
+			}
+		})
+	}
+}
+
+func TestShardedReconcilerReconcile(t *testing.T) {
+	config := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "test",
+				"namespace": "test",
+				"uid":       "ccc-101",
+			},
+			"spec": map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"raidType": "mirror",
+				},
+				"diskConfig": map[string]interface{}{
+					"local": map[string]interface{}{
+						"failureDomainKey": "rack",
+						"blockDeviceSelector": map[string]interface{}{
+							"selectorTerms": []interface{}{
+								map[string]interface{}{
+									"matchLabels": map[string]interface{}{
+										"pool": "yes",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	nodes := []*unstructured.Unstructured{
+		newTestRackNode("node-1", "rack-a"),
+		newTestRackNode("node-2", "rack-b"),
+		newTestRackNode("node-3", "rack-c"),
+	}
+	var tests = map[string]struct {
+		devices           []*unstructured.Unstructured
+		cspcs             []*unstructured.Unstructured
+		expectCSPCs       map[string]string
+		expectPoolDomains map[string]string
+		isErr             bool
+		expectErrType     errs.Type
+	}{
+		"cspc per domain": {
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-1"),
+				newTestRackDevice("bd2", "node-1"),
+				newTestRackDevice("bd3", "node-2"),
+				newTestRackDevice("bd4", "node-2"),
+			},
+			expectCSPCs: map[string]string{
+				"test-rack-a": "rack-a",
+				"test-rack-b": "rack-b",
+			},
+			expectPoolDomains: map[string]string{
+				"node-1": "rack-a",
+				"node-2": "rack-b",
+			},
+		},
+		"domain without enough devices is left out": {
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-1"),
+				newTestRackDevice("bd2", "node-1"),
+				newTestRackDevice("bd3", "node-3"),
+			},
+			expectCSPCs: map[string]string{
+				"test-rack-a": "rack-a",
+			},
+			expectPoolDomains: map[string]string{
+				"node-1": "rack-a",
+			},
+		},
+		"observed cspc is never renamed": {
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-1"),
+				newTestRackDevice("bd2", "node-1"),
+			},
+			cspcs: []*unstructured.Unstructured{
+				func() *unstructured.Unstructured {
+					obj := newTestRackCStorPoolCluster("old-rack-a", "rack-a")
+					obj.Object["spec"] = map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-1",
+								},
+								"dataRaidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd1",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd2",
+											},
+										},
+									},
+								},
+							},
+						},
+					}
+					return obj
+				}(),
+			},
+			expectCSPCs: map[string]string{
+				"old-rack-a": "rack-a",
+			},
+			expectPoolDomains: map[string]string{
+				"node-1": "rack-a",
+			},
+		},
+		"no domain has enough devices": {
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-1"),
+				newTestRackDevice("bd3", "node-3"),
+			},
+			isErr:         true,
+			expectErrType: errs.TypeNotEnoughResources,
+		},
+		"no devices on labeled nodes": {
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-9"),
+			},
+			isErr:         true,
+			expectErrType: errs.TypeNotEnoughResources,
+		},
+		"unsharded cspc": {
+			devices: []*unstructured.Unstructured{
+				newTestRackDevice("bd1", "node-1"),
+				newTestRackDevice("bd2", "node-1"),
+			},
+			cspcs: []*unstructured.Unstructured{
+				newTestRackCStorPoolCluster("test", ""),
+			},
+			isErr:         true,
+			expectErrType: errs.TypeValidation,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var deviceNames []string
+			for _, device := range mock.devices {
+				deviceNames = append(deviceNames, device.GetName())
+			}
+			r := &ShardedReconciler{
+				ObservedCStorClusterConfig: config,
+				ObservedBlockDevices:       mock.devices,
+				ObservedBlockDeviceClaims:  newTestBoundClaims(deviceNames...),
+				ObservedCStorPoolClusters:  mock.cspcs,
+				ObservedNodes:              nodes,
+				FailureDomainKey:           "rack",
+			}
+			resp, err := r.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				if errs.TypeOf(err) != mock.expectErrType {
+					t.Fatalf(
+						"Expected error type %q got [%+v]", mock.expectErrType, err,
+					)
+				}
+				return
+			}
+			if resp.CStorPoolCluster != nil {
+				t.Fatalf("Expected nil cspc got %s", resp.CStorPoolCluster.GetName())
+			}
+			gotCSPCs := map[string]string{}
+			for _, cspc := range resp.CStorPoolClusters {
+				gotCSPCs[cspc.GetName()] =
+					cspc.GetAnnotations()[types.AnnKeyCStorPoolClusterFailureDomain]
+			}
+			if !reflect.DeepEqual(gotCSPCs, mock.expectCSPCs) {
+				t.Fatalf("Expected cspcs %v got %v", mock.expectCSPCs, gotCSPCs)
+			}
+			if resp.PoolTopology.CStorPoolClusterName != "" {
+				t.Fatalf(
+					"Expected empty topology cspc name got %q",
+					resp.PoolTopology.CStorPoolClusterName,
+				)
+			}
+			gotPoolDomains := map[string]string{}
+			for _, pool := range resp.PoolTopology.Pools {
+				if mock.expectCSPCs[pool.CStorPoolClusterName] != pool.FailureDomain {
+					t.Fatalf(
+						"Expected pool of %q to belong to cspc of %q got %q",
+						pool.HostName, pool.FailureDomain, pool.CStorPoolClusterName,
+					)
+				}
+				gotPoolDomains[pool.HostName] = pool.FailureDomain
+			}
+			if !reflect.DeepEqual(gotPoolDomains, mock.expectPoolDomains) {
+				t.Fatalf(
+					"Expected pool domains %v got %v", mock.expectPoolDomains, gotPoolDomains,
+				)
+			}
+		})
+	}
+}
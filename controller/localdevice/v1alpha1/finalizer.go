@@ -24,6 +24,9 @@ import (
 
 	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
 	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/skip"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/metrics"
 )
 
 type finalizer struct {
@@ -44,20 +47,26 @@ func (f *finalizer) validateArgs() {
 func (f *finalizer) skipIfNotLocalDisk() {
 	f.isDiskLocal, f.err =
 		ccc.NewHelper(f.request.Watch).IsLocalBlockDiskConfig()
+	if errs.TypeOf(f.err) == errs.TypeValidation {
+		// NOTE:
+		//	An invalid local disk config should not block the
+		// deletion of this watch. This is finalized the same way
+		// as a config without local disks.
+		f.isDiskLocal, f.err = false, nil
+	}
 	if f.err != nil {
 		return
 	}
 	if !f.isDiskLocal {
-		var msg = fmt.Sprintf(
-			"Will skip LocalDevice finalize: DiskConfig is not local: Watch %q - %q / %q",
-			f.request.Watch.GetKind(),
-			f.request.Watch.GetNamespace(),
-			f.request.Watch.GetName(),
-		)
-		glog.V(3).Infof(msg)
 		// we want to skip reconciling this since this CstorClusterConfig
 		// is not meant for local devices
-		f.response.SkipReconcile = true
+		f.err = skip.Skip(
+			controllerName,
+			f.request.Watch,
+			f.response,
+			skip.ReasonNotLocalDisk,
+			"DiskConfig is not local",
+		)
 	}
 }
 
@@ -84,6 +93,9 @@ func (f *finalizer) markFinalizedIfNoAttachments() {
 			f.request.Watch.GetName(),
 		)
 		glog.V(3).Infof(msg)
+		metrics.DeleteNodeBlockDeviceUtilization(
+			f.request.Watch.GetNamespace(), f.request.Watch.GetName(),
+		)
 		// setting finalized to true will indicate metac to remove
 		// its annotations from the watch i.e. CStorClusterConfig
 		f.response.Finalized = true
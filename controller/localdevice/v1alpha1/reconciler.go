@@ -17,31 +17,91 @@ limitations under the License.
 package localdevice
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"openebs.io/metac/controller/generic"
 
 	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	bdc "mayadata.io/cstorpoolauto/common/blockdeviceclaim"
+	"mayadata.io/cstorpoolauto/common/capacity"
+	"mayadata.io/cstorpoolauto/common/cspcexport"
 	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
 	cspc "mayadata.io/cstorpoolauto/common/cstorpoolcluster/v1alpha1"
+	"mayadata.io/cstorpoolauto/common/disksource"
+	"mayadata.io/cstorpoolauto/common/drift"
+	"mayadata.io/cstorpoolauto/common/generation"
+	"mayadata.io/cstorpoolauto/common/ignorefields"
 	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/metadata"
+	"mayadata.io/cstorpoolauto/common/naming"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	"mayadata.io/cstorpoolauto/common/overlay"
+	"mayadata.io/cstorpoolauto/common/owner"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/raidchange"
+	"mayadata.io/cstorpoolauto/common/skip"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/faultinject"
+	"mayadata.io/cstorpoolauto/pkg/index"
+	"mayadata.io/cstorpoolauto/pkg/lock"
+	"mayadata.io/cstorpoolauto/pkg/metrics"
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+	"mayadata.io/cstorpoolauto/pkg/tracing"
 	"mayadata.io/cstorpoolauto/types"
 	"mayadata.io/cstorpoolauto/unstruct"
 )
 
+// controllerName is reported against the reconciliations that
+// are skipped by this controller
+const controllerName = "LocalDevice"
+
+// selectionReportLogs limits the logs of block device selection
+// reports to once per CStorClusterConfig in five minutes
+var selectionReportLogs = throttle.New(5 * time.Minute)
+
 type syncer struct {
 	request  *generic.SyncHookRequest
 	response *generic.SyncHookResponse
 
-	blockDevices     []*unstructured.Unstructured
-	cstorPoolCluster *unstructured.Unstructured
+	blockDevices       []*unstructured.Unstructured
+	blockDeviceClaims  []*unstructured.Unstructured
+	cstorPoolCluster   *unstructured.Unstructured
+	cstorPoolInstances []*unstructured.Unstructured
+	nodes              []*unstructured.Unstructured
+
+	// plan of the watch that selects the external disks of a hybrid
+	// disk config
+	cstorClusterPlan *unstructured.Unstructured
+
+	// CStorPoolCluster(s) of the watch if its pools are sharded by
+	// failure domain
+	cstorPoolClusters []*unstructured.Unstructured
+	failureDomainKey  string
+
+	// CStorPoolCluster(s) of the watch owned by other controllers
+	otherCStorPoolClusters []*unstructured.Unstructured
+
+	// names of block devices reserved by the syncs of other configs
+	reservedBlockDeviceNames map[string]bool
+
+	// desired CStorPoolCluster(s) are exported instead of being
+	// applied if the output mode is Export
+	outputMode types.OutputMode
 
 	reconcileResponse ReconcileResponse
 	isDiskLocal       bool
+	isPersistDefaults bool
+	resolvedState     string
+	unlock            func()
 	fatal             error
 	err               error
 	warns             []string
@@ -59,31 +119,59 @@ func (s *syncer) skipIfNotLocalDisk() {
 		return
 	}
 	if !s.isDiskLocal {
-		var msg = fmt.Sprintf(
-			"Will skip LocalDevice sync: DiskConfig is not local: Watch %q - %q / %q",
-			s.request.Watch.GetKind(),
-			s.request.Watch.GetNamespace(),
-			s.request.Watch.GetName(),
+		s.err = skip.Skip(
+			controllerName,
+			s.request.Watch,
+			s.response,
+			skip.ReasonNotLocalDisk,
+			"DiskConfig is not local",
 		)
-		glog.V(3).Infof(msg)
-		s.response.SkipReconcile = true
 	}
 }
 
+func (s *syncer) skipIfPaused() {
+	_, s.err = pause.Skip(
+		controllerName, s.request.Watch, s.request.Watch, s.response,
+	)
+}
+
 func (s *syncer) skipIfEmptyAttachments() {
 	// Nothing needs to be done if there are no attachments in request
 	//
 	// NOTE:
 	// 	It is expected to have at-least BlockDevices as attachments
 	if s.request.Attachments == nil || s.request.Attachments.IsEmpty() {
-		var msg = fmt.Sprintf(
-			"Will skip LocalDevice sync: Nil attachments: Watch %q - %q / %q",
-			s.request.Watch.GetKind(),
-			s.request.Watch.GetNamespace(),
-			s.request.Watch.GetName(),
+		s.err = skip.Skip(
+			controllerName,
+			s.request.Watch,
+			s.response,
+			skip.ReasonNilAttachments,
+			"No attachments",
+		)
+	}
+}
+
+// skipIfProcessed skips the sync if neither the watch nor any of
+// its attachments changed since the last successful sync
+func (s *syncer) skipIfProcessed() {
+	s.resolvedState, s.err = generation.ResolvedStateHash(
+		s.request.Watch,
+		s.request.Attachments.List(),
+		types.AnnKeyLocalDeviceResolvedState,
+	)
+	if s.err != nil {
+		return
+	}
+	if generation.IsProcessed(
+		s.request.Watch, s.resolvedState, types.AnnKeyLocalDeviceResolvedState,
+	) {
+		s.err = skip.Skip(
+			controllerName,
+			s.request.Watch,
+			s.response,
+			skip.ReasonNothingChanged,
+			"Watch & attachments did not change since the last sync",
 		)
-		glog.V(3).Infof(msg)
-		s.response.SkipReconcile = true
 	}
 }
 
@@ -96,6 +184,24 @@ func (s *syncer) logSyncStart() {
 	)
 }
 
+func (s *syncer) setPersistDefaults() {
+	s.isPersistDefaults, s.err =
+		ccc.NewHelper(s.request.Watch).IsPersistDefaults()
+}
+
+// setFailureDomainKey sets the node label key that shards the pools
+// of the watch by failure domain if any
+func (s *syncer) setFailureDomainKey() {
+	s.failureDomainKey, s.err =
+		ccc.NewHelper(s.request.Watch).GetLocalFailureDomainKey()
+}
+
+// setOutputMode sets the mode in which the desired
+// CStorPoolCluster(s) of the watch are delivered
+func (s *syncer) setOutputMode() {
+	s.outputMode, s.err = ccc.NewHelper(s.request.Watch).GetOutputMode()
+}
+
 func (s *syncer) registerAttachments() {
 	// TODO (@amitkumardas):
 	// Make use of unstruct list selector
@@ -106,11 +212,54 @@ func (s *syncer) registerAttachments() {
 		if attachment.GetKind() == string(types.KindBlockDevice) {
 			s.blockDevices = append(s.blockDevices, attachment)
 		}
+		// claims decide if block devices can be used by the pool
+		if attachment.GetKind() == string(types.KindBlockDeviceClaim) {
+			uid, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+			)
+			if string(s.request.Watch.GetUID()) == uid {
+				s.blockDeviceClaims = append(s.blockDeviceClaims, attachment)
+			}
+		}
+		// pool instances are used to report capacity
+		if attachment.GetKind() == string(types.KindCStorPoolInstance) {
+			s.cstorPoolInstances = append(s.cstorPoolInstances, attachment)
+		}
+		// nodes restrict the devices if all devices are selected
+		if attachment.GetKind() == string(types.KindNode) {
+			s.nodes = append(s.nodes, attachment)
+		}
+		if cspcexport.IsExportOf(attachment, s.request.Watch) {
+			// exported cspcs are added to response after completing
+			// reconciliation if the watch is still exported
+			continue
+		}
+		// plan is used to select the external disks if any
+		if attachment.GetKind() == string(types.KindCStorClusterPlan) {
+			uid, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+			)
+			if string(s.request.Watch.GetUID()) == uid {
+				s.cstorClusterPlan = attachment
+			}
+		}
 		if attachment.GetKind() == string(types.KindCStorPoolCluster) {
 			uid, _ := unstruct.GetValueForKey(
 				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
 			)
 			if string(s.request.Watch.GetUID()) == uid {
+				if current := owner.Get(attachment); current != "" &&
+					current != owner.LocalDevice {
+					// ownership of this cspc is arbitrated later
+					s.otherCStorPoolClusters =
+						append(s.otherCStorPoolClusters, attachment)
+					continue
+				}
+				if s.failureDomainKey != "" {
+					// every failure domain has its own cspc
+					s.cstorPoolClusters = append(s.cstorPoolClusters, attachment)
+					continue
+				}
 				s.cstorPoolCluster = attachment
 				// don't add cspc to response now
 				//
@@ -119,36 +268,328 @@ func (s *syncer) registerAttachments() {
 				continue
 			}
 		}
+		if s.isPersistDefaults &&
+			attachment.GetKind() == string(types.KindCStorClusterConfig) &&
+			attachment.GetUID() == s.request.Watch.GetUID() {
+			// don't add the watch to response now
+			//
+			// NOTE:
+			// 	watch is added to response with resolved defaults
+			// after completing reconciliation
+			continue
+		}
 		s.response.Attachments = append(s.response.Attachments, attachment)
 	}
 }
 
+// arbitrateOwnership skips the sync if any CStorPoolCluster of the
+// watch is owned by another controller. CStorPoolCluster(s) whose
+// switch of ownership is approved are taken over.
+func (s *syncer) arbitrateOwnership() {
+	result := owner.Arbitrate(
+		owner.LocalDevice, s.request.Watch, s.otherCStorPoolClusters,
+	)
+	if !result.IsOwner {
+		s.err = skip.Skip(
+			controllerName,
+			s.request.Watch,
+			s.response,
+			skip.ReasonNotOwner,
+			result.Reason,
+		)
+		return
+	}
+	for _, taken := range result.TakenOver {
+		if s.failureDomainKey != "" {
+			// taken over cspcs are reconciled per failure domain
+			s.cstorPoolClusters = append(s.cstorPoolClusters, taken)
+			continue
+		}
+		if s.cstorPoolCluster == nil {
+			// taken over cspc is reconciled as if it was created
+			// by this controller
+			s.cstorPoolCluster = taken
+			continue
+		}
+		// other cspcs are retained as is
+		s.response.Attachments = append(s.response.Attachments, taken)
+	}
+}
+
+// lockDevices serializes this sync with the syncs of other configs
+// that observe block devices of the same nodes
+//
+// NOTE:
+//	This avoids selecting the same block devices for different
+// configs at the same instant
+//
+// NOTE:
+//	Block devices selected by other configs stay reserved after
+// their syncs release the lock & till these devices are observed
+// in claims or pools. Reserved devices are not selected.
+func (s *syncer) lockDevices() {
+	uid := string(s.request.Watch.GetUID())
+	keys := []string{lock.ConfigKey(uid)}
+	for _, device := range s.blockDevices {
+		hostName, err := bd.GetHostName(*device)
+		if err != nil || hostName == "" {
+			// devices without host name are not selected
+			continue
+		}
+		keys = append(keys, lock.NodeKey(hostName))
+	}
+	s.unlock = lock.Reconcile(keys...)
+	if s.request.Attachments != nil {
+		var held []string
+		held, s.err = bdc.GetHeldDeviceNames(s.request.Attachments.List())
+		if s.err != nil {
+			return
+		}
+		lock.ReleaseDevices(held...)
+	}
+	s.reservedBlockDeviceNames = lock.DevicesReservedByOthers(uid)
+}
+
+// reserveDevices reserves the block devices selected by this sync
+// till these are observed in claims or pools
+func (s *syncer) reserveDevices() {
+	lock.ReserveDevices(
+		string(s.request.Watch.GetUID()),
+		s.reconcileResponse.SelectedBlockDeviceNames...,
+	)
+}
+
+// isShardedCStorPoolCluster returns true if the observed
+// CStorPoolCluster belongs to a failure domain
+func (s *syncer) isShardedCStorPoolCluster() bool {
+	if s.cstorPoolCluster == nil {
+		return false
+	}
+	annotations := s.cstorPoolCluster.GetAnnotations()
+	return annotations[types.AnnKeyCStorPoolClusterFailureDomain] != ""
+}
+
 func (s *syncer) reconcile() {
-	// reconciler performs reconciliation of CStorClusterConfig
-	reconciler := &Reconciler{
-		ObservedCStorClusterConfig: s.request.Watch,
-		ObservedBlockDevices:       s.blockDevices,
-		ObservedCStorPoolCluster:   s.cstorPoolCluster,
+	if s.failureDomainKey != "" {
+		// one CStorPoolCluster is reconciled per failure domain
+		reconciler := &ShardedReconciler{
+			ObservedCStorClusterConfig: s.request.Watch,
+			ObservedBlockDevices:       s.blockDevices,
+			ObservedBlockDeviceClaims:  s.blockDeviceClaims,
+			ObservedCStorPoolClusters:  s.cstorPoolClusters,
+			ObservedCStorPoolInstances: s.cstorPoolInstances,
+			ObservedNodes:              s.nodes,
+			IsPersistDefaults:          s.isPersistDefaults,
+			FailureDomainKey:           s.failureDomainKey,
+			ReservedBlockDeviceNames:   s.reservedBlockDeviceNames,
+			Context:                    tracing.ContextOf(s.request),
+		}
+		s.reconcileResponse, s.err = reconciler.Reconcile()
+	} else if s.isShardedCStorPoolCluster() {
+		// sharded pools can't be merged back into one CStorPoolCluster
+		s.err = errs.ValidationErrorf(
+			"Can't reconcile: CStorPoolCluster %q is sharded by failure domain: Missing failureDomainKey",
+			s.cstorPoolCluster.GetName(),
+		)
+	} else {
+		// reconciler performs reconciliation of CStorClusterConfig
+		reconciler := &Reconciler{
+			ObservedCStorClusterConfig: s.request.Watch,
+			ObservedBlockDevices:       s.blockDevices,
+			ObservedBlockDeviceClaims:  s.blockDeviceClaims,
+			ObservedCStorPoolCluster:   s.cstorPoolCluster,
+			ObservedCStorPoolInstances: s.cstorPoolInstances,
+			ObservedNodes:              s.nodes,
+			ObservedCStorClusterPlan:   s.cstorClusterPlan,
+			IsPersistDefaults:          s.isPersistDefaults,
+			ReservedBlockDeviceNames:   s.reservedBlockDeviceNames,
+			Context:                    tracing.ContextOf(s.request),
+		}
+		s.reconcileResponse, s.err = reconciler.Reconcile()
 	}
-	s.reconcileResponse, s.err = reconciler.Reconcile()
 	if s.err != nil {
+		s.reportBlockDeviceSelection()
 		return
 	}
+	// selected devices are reserved even if the reconciliation
+	// is skipped since their claims are yet to be bound
+	s.reserveDevices()
 	if s.reconcileResponse.SkipReconcile {
 		// skip reconciliation at metac
-		s.response.SkipReconcile = true
-		glog.V(3).Infof(
-			"Will skip LocalDevice sync: Reason %s: Watch %q - %q / %q",
+		s.err = skip.Skip(
+			controllerName,
+			s.request.Watch,
+			s.response,
+			s.reconcileResponse.SkipCode,
 			s.reconcileResponse.SkipReason,
+		)
+		if s.reconcileResponse.SkipCode == skip.ReasonWaitingForReadyNodes {
+			// nodes are retried till enough of them are ready
+			s.response.ResyncAfterSeconds =
+				errs.TypeToResyncAfterSeconds[errs.TypeNotEnoughResources]
+		}
+		return
+	}
+	// add desired CStorPoolCluster(s) to response
+	desiredCStorPoolClusters := s.reconcileResponse.CStorPoolClusters
+	if s.reconcileResponse.CStorPoolCluster != nil {
+		desiredCStorPoolClusters = append(
+			desiredCStorPoolClusters, s.reconcileResponse.CStorPoolCluster,
+		)
+	}
+	for _, desired := range desiredCStorPoolClusters {
+		owner.Set(desired, owner.LocalDevice)
+	}
+	if s.outputMode == types.OutputModeExport {
+		// cspcs are applied by the team's own tooling
+		exporter := cspcexport.Exporter{
+			ClusterConfig: s.request.Watch,
+			ObservedCStorPoolClusters: append(
+				[]*unstructured.Unstructured{s.cstorPoolCluster},
+				s.cstorPoolClusters...,
+			),
+		}
+		var exported []*unstructured.Unstructured
+		exported, s.err = exporter.Export(desiredCStorPoolClusters)
+		if s.err != nil {
+			return
+		}
+		s.response.Attachments = append(s.response.Attachments, exported...)
+	} else {
+		s.response.Attachments =
+			append(s.response.Attachments, desiredCStorPoolClusters...)
+	}
+	if s.reconcileResponse.CStorClusterConfig != nil {
+		// write the resolved defaults back to the watch
+		s.response.Attachments = append(
+			s.response.Attachments, s.reconcileResponse.CStorClusterConfig,
+		)
+	}
+	// report the aggregated capacity against CStorClusterConfig
+	s.response.Status, s.err = capacity.MakeStatusWithCapacity(
+		s.request.Watch, s.reconcileResponse.Capacity,
+	)
+	if s.err != nil {
+		return
+	}
+	s.recordNodeBlockDeviceUtilization()
+	// report the block devices that failed health checks
+	s.response.Status, s.err = ccc.SetRejectedBlockDevices(
+		s.response.Status, s.reconcileResponse.RejectedBlockDevices,
+	)
+	if s.err != nil {
+		return
+	}
+	// report the layout of pools for external schedulers
+	s.response.Status, s.err = ccc.SetPoolTopology(
+		s.response.Status, s.reconcileResponse.PoolTopology,
+	)
+	if s.err != nil {
+		return
+	}
+	// report the nodes whose pools were not built
+	s.response.Status, s.err = ccc.SetSkippedNodes(
+		s.response.Status, s.reconcileResponse.SkippedNodes,
+	)
+	if s.err != nil {
+		return
+	}
+	if len(s.reconcileResponse.SkippedNodes) != 0 {
+		// skipped nodes are retried till they have enough block
+		// devices
+		s.response.ResyncAfterSeconds =
+			errs.TypeToResyncAfterSeconds[errs.TypeNotEnoughResources]
+	}
+	// block devices were selected & hence there is nothing to explain
+	s.response.Status = ccc.SetBlockDeviceSelectionReport(s.response.Status, nil)
+	// raid type change condition is reported only after a change is
+	// requested
+	s.response.Status, s.err = raidchange.SetCondition(
+		s.response.Status, s.reconcileResponse.RAIDTypeChange,
+	)
+	if s.err != nil {
+		return
+	}
+	if !s.reconcileResponse.IsDrifted && !drift.HasCondition(s.request.Watch) {
+		// drift condition is reported only after a drift is detected
+		return
+	}
+	s.response.Status, s.err = drift.SetCondition(
+		s.response.Status,
+		drift.Result{
+			IsDrifted: s.reconcileResponse.IsDrifted,
+			Reason:    s.reconcileResponse.DriftReason,
+		},
+	)
+}
+
+// reportBlockDeviceSelection sets the block device selection report
+// against the watch's status & logs it if there was no log for this
+// watch in a while
+//
+// NOTE:
+//	Metac applies the status even if reconciliation is skipped due
+// to an error. A stale report is removed if the current error is
+// not due to the selection of block devices.
+func (s *syncer) reportBlockDeviceSelection() {
+	if s.request.Watch == nil {
+		return
+	}
+	report := s.reconcileResponse.BlockDeviceSelectionReport
+	status, _, err := unstructured.NestedMap(s.request.Watch.Object, "status")
+	if err != nil {
+		glog.Errorf(
+			"Can't set block device selection report: Watch %q - %q / %q: %+v",
 			s.request.Watch.GetKind(),
 			s.request.Watch.GetNamespace(),
 			s.request.Watch.GetName(),
+			err,
 		)
 		return
 	}
-	// add desired CStorPoolCluster to response
-	s.response.Attachments = append(
-		s.response.Attachments, s.reconcileResponse.CStorPoolCluster,
+	if _, found := status["blockDeviceSelectionReport"]; !found && len(report) == 0 {
+		// nothing to report or remove
+		return
+	}
+	s.response.Status = ccc.SetBlockDeviceSelectionReport(status, report)
+	if len(report) == 0 || !selectionReportLogs.Allow(string(s.request.Watch.GetUID())) {
+		return
+	}
+	glog.Warningf(
+		"Block device selection report: Watch %q - %q / %q: %s",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		strings.Join(report, "; "),
+	)
+}
+
+// setObservedGeneration reports the generation of the watch that
+// got synced & the state it got synced with
+func (s *syncer) setObservedGeneration() {
+	s.response.Status = generation.SetObservedGeneration(
+		s.request.Watch, s.response.Status,
+	)
+	s.response.Annotations = map[string]*string{
+		types.AnnKeyLocalDeviceResolvedState: &s.resolvedState,
+	}
+}
+
+// clearSkipCondition removes the condition that was set when this
+// controller skipped an earlier sync
+func (s *syncer) clearSkipCondition() {
+	s.err = skip.Clear(controllerName, s.request.Watch, s.response)
+}
+
+// recordNodeBlockDeviceUtilization records the block device
+// utilization of nodes as metrics for capacity planners
+func (s *syncer) recordNodeBlockDeviceUtilization() {
+	var nodes []types.CStorClusterConfigNodeCapacity
+	if s.reconcileResponse.Capacity != nil {
+		nodes = s.reconcileResponse.Capacity.Nodes
+	}
+	metrics.SetNodeBlockDeviceUtilization(
+		s.request.Watch.GetNamespace(), s.request.Watch.GetName(), nodes,
 	)
 }
 
@@ -176,22 +617,45 @@ func (s *syncer) handleError() {
 		s.request.Watch.GetName(),
 		s.err,
 	)
+	tracing.RecordError(tracing.ContextOf(s.request), s.err)
 	// stop further reconciliation at metac since there was an error
 	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
 }
 
 func (s *syncer) sync() error {
+	defer func() {
+		if s.unlock != nil {
+			s.unlock()
+		}
+	}()
 	fns := []func(){
 		s.validateArgs,
 		s.skipIfNotLocalDisk,
+		s.skipIfPaused,
 		s.skipIfEmptyAttachments,
+		s.skipIfProcessed,
 		s.logSyncStart,
+		s.setPersistDefaults,
+		s.setFailureDomainKey,
+		s.setOutputMode,
 		s.registerAttachments,
+		s.arbitrateOwnership,
+		s.lockDevices,
 		s.reconcile,
+		s.setObservedGeneration,
+		s.clearSkipCondition,
 		s.logSyncFinish,
 	}
+	// each step is traced as a phase of this sync
+	ctx := tracing.ContextOf(s.request)
 	for _, fn := range fns {
+		end := tracing.StartPhase(ctx, fn)
 		fn()
+		end()
 		// post operation checks
 		if s.fatal != nil {
 			return s.fatal
@@ -236,20 +700,77 @@ type Reconciler struct {
 	ObservedCStorClusterConfig *unstructured.Unstructured
 	ObservedBlockDevices       []*unstructured.Unstructured
 	ObservedCStorPoolCluster   *unstructured.Unstructured
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+	ObservedNodes              []*unstructured.Unstructured
+
+	// claims that belong to the observed CStorClusterConfig
+	ObservedBlockDeviceClaims []*unstructured.Unstructured
+
+	// ObservedCStorClusterPlan is the plan of the observed
+	// CStorClusterConfig if its disk config is hybrid. Block devices
+	// provisioned for this plan build the pools of the nodes that
+	// use external disks.
+	ObservedCStorClusterPlan *unstructured.Unstructured
+
+	// IsPersistDefaults when true results in a desired
+	// CStorClusterConfig with the resolved defaults set in its spec
+	IsPersistDefaults bool
+
+	// FailureDomain is set if the pools are sharded by failure
+	// domain. Observed block devices & CStorPoolCluster belong to
+	// this failure domain in this case.
+	FailureDomain string
+
+	// ReservedBlockDeviceNames are the block devices selected by
+	// other configs that are yet to be claimed. These are not
+	// selected.
+	ReservedBlockDeviceNames map[string]bool
+
+	// Context is the context of the traced sync if any. Each step
+	// of reconciliation is traced as a phase of this sync.
+	Context context.Context
 
 	cccHelper *ccc.Helper
 
+	// devices provisioned for the observed CStorClusterPlan if the
+	// disk config is hybrid
+	isHybrid            bool
+	plannedBlockDevices []*unstructured.Unstructured
+
 	selectedBlockDevices               []*unstructured.Unstructured
+	rejectedBlockDevices               []types.CStorClusterConfigRejectedBlockDevice
+	skippedNodes                       []types.CStorClusterConfigSkippedNode
 	hostNameToSelectedBlockDeviceNames map[string][]string
 	hostNameToObservedCSPCDeviceNames  map[string][]string
 	observedHostNamesInCSPC            []string
 
-	deviceSelector             metac.ResourceSelector
+	// selected block device names grouped by the hostname of their
+	// nodes. This differs from hostNameToSelectedBlockDeviceNames
+	// when pools select their nodes by a custom label.
+	nodeHostNameToSelectedBlockDeviceNames map[string][]string
+
+	deviceSelector             types.BlockDeviceSelector
+	deviceExclude              types.BlockDeviceSelector
+	selectionReport            []string
 	desiredCStorPoolCluster    *unstructured.Unstructured
+	desiredCStorClusterConfig  *unstructured.Unstructured
+	driftPolicy                types.DriftPolicy
+	driftResult                drift.Result
+	raidChangeResult           raidchange.Result
+	capacity                   *types.CStorClusterConfigCapacity
+	poolTopology               *types.CStorClusterConfigPoolTopology
 	isDeviceCountMatchRAIDType bool
 	skipReconcile              bool
+	skipReconcileCode          skip.Reason
 	skipReconcileReason        string
 	raidType                   types.PoolRAIDType
+	raidGroupConfig            *types.PoolRAIDGroupConfig
+	zfsProperties              map[string]string
+	nodeSelectorKey            string
+	childMetadata              *types.ChildMetadata
+	overlays                   []types.Overlay
+	targetNamespace            string
+	cstorPoolClusterName       string
 	err                        error
 }
 
@@ -257,8 +778,48 @@ type Reconciler struct {
 // of a successful reconciliation
 type ReconcileResponse struct {
 	CStorPoolCluster *unstructured.Unstructured
-	SkipReconcile    bool
-	SkipReason       string
+
+	// CStorPoolClusters are set instead of CStorPoolCluster if the
+	// pools are sharded by failure domain
+	CStorPoolClusters []*unstructured.Unstructured
+
+	// CStorClusterConfig is set only if resolved defaults need
+	// to be persisted
+	CStorClusterConfig *unstructured.Unstructured
+
+	Capacity      *types.CStorClusterConfigCapacity
+	SkipReconcile bool
+	SkipCode      skip.Reason
+	SkipReason    string
+
+	// IsDrifted is true if manual edits to the pools of
+	// CStorPoolCluster are retained
+	IsDrifted   bool
+	DriftReason string
+
+	// RAIDTypeChange reports the progress of re-creating the pools
+	// whose raid type differs from the desired raid type
+	RAIDTypeChange raidchange.Result
+
+	// RejectedBlockDevices are the selected block devices that
+	// failed health checks
+	RejectedBlockDevices []types.CStorClusterConfigRejectedBlockDevice
+
+	// SkippedNodes are the nodes whose pools were not built since
+	// their block devices did not match the raid type
+	SkippedNodes []types.CStorClusterConfigSkippedNode
+
+	// PoolTopology is the layout of pools of the desired
+	// CStorPoolCluster
+	PoolTopology *types.CStorClusterConfigPoolTopology
+
+	// BlockDeviceSelectionReport explains why no block devices were
+	// selected. This is set along with the error of reconciliation.
+	BlockDeviceSelectionReport []string
+
+	// SelectedBlockDeviceNames are the names of the selected block
+	// devices. This is set even if the reconciliation is skipped.
+	SelectedBlockDeviceNames []string
 }
 
 // NilReconcileResponse is used to represent a nil
@@ -272,40 +833,545 @@ func (r *Reconciler) init() {
 func (r *Reconciler) setRAIDType() {
 	// RAID type is used from CStorClusterConfig specs
 	r.raidType, r.err = r.cccHelper.GetRAIDTypeOrCached()
+	if r.err != nil {
+		return
+	}
+	// raid groups are sized as per the raid type unless this is set
+	r.raidGroupConfig, r.err = r.cccHelper.GetRAIDGroupConfig()
+}
+
+func (r *Reconciler) setZFSProperties() {
+	// ZFS properties are set against every pool
+	r.zfsProperties, r.err = r.cccHelper.GetZFSProperties()
+}
+
+func (r *Reconciler) setNodeSelectorKey() {
+	// pools select their nodes by this label key
+	r.nodeSelectorKey, r.err = r.cccHelper.GetNodeSelectorKey()
+}
+
+func (r *Reconciler) setChildMetadata() {
+	// labels & annotations to be propagated to CStorPoolCluster
+	r.childMetadata, r.err = r.cccHelper.GetChildMetadata()
+}
+
+func (r *Reconciler) setOverlays() {
+	// user provided patches if any are applied to CStorPoolCluster
+	r.overlays, r.err = r.cccHelper.GetOverlays()
+}
+
+func (r *Reconciler) setTargetNamespace() {
+	// observed CStorPoolCluster is retained in its namespace
+	r.targetNamespace, r.err =
+		r.cccHelper.GetTargetNamespaceOrObserved(r.ObservedCStorPoolCluster)
+}
+
+func (r *Reconciler) setCStorPoolClusterName() {
+	var parts []string
+	if r.FailureDomain != "" {
+		// failure domain tells the CStorPoolClusters of the config
+		// apart
+		parts = append(parts, naming.Part(r.FailureDomain))
+	}
+	// observed CStorPoolCluster is never renamed
+	r.cstorPoolClusterName, r.err =
+		r.cccHelper.GetChildNameOrObserved(r.ObservedCStorPoolCluster, parts...)
+}
+
+// splitBlockDevicesByDiskSource restricts the observed block devices
+// to the devices of the nodes that use local disks if the disk config
+// is hybrid. Devices provisioned for the observed CStorClusterPlan
+// are set aside to build the pools of the nodes that use external
+// disks.
+func (r *Reconciler) splitBlockDevicesByDiskSource() {
+	var overrides []types.DiskNodeOverride
+	overrides, r.isHybrid, r.err = r.cccHelper.GetHybridDiskNodeOverrides()
+	if r.err != nil || !r.isHybrid {
+		return
+	}
+	var planUID string
+	if r.ObservedCStorClusterPlan != nil {
+		planUID = string(r.ObservedCStorClusterPlan.GetUID())
+	}
+	resolver := disksource.Resolver{
+		Overrides: overrides,
+		Nodes:     r.ObservedNodes,
+	}
+	r.ObservedBlockDevices, r.plannedBlockDevices, r.err =
+		resolver.SplitBlockDevices(r.ObservedBlockDevices, planUID)
+}
+
+// selectLocalBlockDevices selects the local block devices that
+// build the pools
+//
+// NOTE:
+//	Nothing is selected if the disk config is hybrid & none of
+// its nodes that use local disks have any block device
+func (r *Reconciler) selectLocalBlockDevices() {
+	if r.isHybrid && len(r.ObservedBlockDevices) == 0 {
+		return
+	}
+	r.err = faultinject.Inject(faultinject.PhaseDeviceSelection)
+	if r.err != nil {
+		return
+	}
+	fns := []func(){
+		r.selectFromObservedBlockDevices,
+		r.dropBlockDevicesReservedByOthers,
+		r.selectBlockDevicesWithinCapacityBounds,
+		r.rejectUnhealthyBlockDevices,
+		r.reserveCapacityPerNode,
+	}
+	for _, fn := range fns {
+		end := tracing.StartPhase(r.Context, fn)
+		fn()
+		end()
+		if r.err != nil {
+			return
+		}
+	}
+}
+
+// addPlannedBlockDevices adds the block devices provisioned for the
+// nodes that use external disks to the selected block devices if the
+// disk config is hybrid
+func (r *Reconciler) addPlannedBlockDevices() {
+	if !r.isHybrid {
+		return
+	}
+	r.selectedBlockDevices =
+		append(r.selectedBlockDevices, r.plannedBlockDevices...)
+	if len(r.selectedBlockDevices) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"0 of %d block devices selected: Hybrid disk config",
+			len(r.ObservedBlockDevices)+len(r.plannedBlockDevices),
+		)
+	}
 }
 
 // selectFromObservedBlockDevices filters the
 // observed blockdevices based on local disk selector terms
+// & thereafter drops the ones matching local disk exclude terms.
+// Selected devices of the nodes listed in the explicit device map
+// are replaced with their pinned devices.
 func (r *Reconciler) selectFromObservedBlockDevices() {
 	r.deviceSelector, r.err = r.cccHelper.GetLocalBlockDeviceSelector()
 	if r.err != nil {
 		return
 	}
-	if len(r.deviceSelector.SelectorTerms) == 0 {
-		r.err = errors.Errorf(
+	var explicitDeviceMap []types.ExplicitDeviceMapping
+	explicitDeviceMap, r.err = r.cccHelper.GetLocalExplicitDeviceMap()
+	if r.err != nil {
+		return
+	}
+	if len(r.deviceSelector.SelectorTerms) == 0 && len(explicitDeviceMap) == 0 {
+		r.err = errs.ValidationErrorf(
 			"Invalid CStorClusterConfig: No block device selector found",
 		)
 		return
 	}
-	l := unstruct.ListSelector(r.deviceSelector, r.ObservedBlockDevices...)
-	r.selectedBlockDevices, _ = l.List()
+	r.deviceExclude, r.err = r.cccHelper.GetLocalBlockDeviceExclude()
+	if r.err != nil {
+		return
+	}
+	var isSelectAll bool
+	isSelectAll, r.err = r.cccHelper.IsLocalBlockDeviceSelectAll()
+	if r.err != nil {
+		return
+	}
+	var check = bd.SelectorCheck{
+		Exclude:      r.deviceExclude,
+		ReservedKeys: bd.ReservedKeys,
+	}
+	if isSelectAll {
+		check.Devices, r.err = r.selectAllBlockDevices()
+	} else if len(r.deviceSelector.SelectorTerms) != 0 {
+		check.Selector = r.deviceSelector
+		check.Devices = r.ObservedBlockDevices
+	}
+	if r.err != nil {
+		return
+	}
+	check.InUseDeviceNames, r.err = r.getInUseBlockDeviceNames()
+	if r.err != nil {
+		return
+	}
+	r.selectedBlockDevices, r.err = check.Apply()
+	if r.err != nil {
+		return
+	}
+	r.selectedBlockDevices, r.err = r.applyExplicitDeviceMap(explicitDeviceMap)
+	if r.err != nil {
+		return
+	}
 	if len(r.selectedBlockDevices) == 0 {
-		r.err = errors.Errorf(
+		// explain the terms that rejected the devices since the
+		// error alone does not help to fix the selector
+		r.selectionReport, r.err = bd.SelectionDiagnosis{
+			Selector:     r.deviceSelector,
+			Exclude:      r.deviceExclude,
+			ReservedKeys: bd.ReservedKeys,
+			Devices:      r.ObservedBlockDevices,
+		}.Report()
+		if r.err != nil {
+			return
+		}
+		r.err = errs.NotEnoughResourcesErrorf(
 			"0 of %d block devices selected", len(r.ObservedBlockDevices),
 		)
+		return
+	}
+	for _, device := range r.selectedBlockDevices {
+		// stable devlink identifies the device across node restarts
+		stableDevLink, _ := bd.GetStableDevLink(*device)
+		glog.V(4).Infof(
+			"Selected BlockDevice %q: Stable devlink %q",
+			device.GetName(), stableDevLink,
+		)
+	}
+}
+
+// dropBlockDevicesReservedByOthers drops the selected block devices
+// that are reserved by other configs
+//
+// NOTE:
+//	Block devices claimed by this config or used by the observed
+// CStorPoolCluster are never dropped
+func (r *Reconciler) dropBlockDevicesReservedByOthers() {
+	if len(r.ReservedBlockDeviceNames) == 0 {
+		return
+	}
+	var owned map[string]bool
+	owned, r.err = bdc.GetOwnedDeviceNames(
+		r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+	if r.err != nil {
+		return
+	}
+	var available []*unstructured.Unstructured
+	var reserved []string
+	for _, device := range r.selectedBlockDevices {
+		name := device.GetName()
+		if r.ReservedBlockDeviceNames[name] && !owned[name] {
+			reserved = append(reserved, name)
+			continue
+		}
+		available = append(available, device)
+	}
+	r.selectedBlockDevices = available
+	if len(reserved) == 0 {
+		return
+	}
+	glog.V(3).Infof(
+		"Dropped %d block devices reserved by other configs: CStorClusterConfig %s %s: [%s]",
+		len(reserved),
+		r.ObservedCStorClusterConfig.GetNamespace(),
+		r.ObservedCStorClusterConfig.GetName(),
+		strings.Join(reserved, ", "),
+	)
+	if len(available) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"0 of %d selected block devices are available: Reserved by other configs: [%s]",
+			len(reserved), strings.Join(reserved, ", "),
+		)
+	}
+}
+
+// getSelectedBlockDeviceNames returns the names of the selected
+// block devices
+func (r *Reconciler) getSelectedBlockDeviceNames() []string {
+	var names []string
+	for _, device := range r.selectedBlockDevices {
+		names = append(names, device.GetName())
+	}
+	return names
+}
+
+// getInUseBlockDeviceNames returns the names of the block devices
+// that are used by the observed CStorPoolCluster
+//
+// NOTE:
+//	These block devices are never dropped as reserved.
+func (r *Reconciler) getInUseBlockDeviceNames() (map[string]bool, error) {
+	inUseDeviceNames, err :=
+		bdc.GetCStorPoolClusterDeviceNames(r.ObservedCStorPoolCluster)
+	if err != nil {
+		return nil, err
+	}
+	var isInUse = map[string]bool{}
+	for _, name := range inUseDeviceNames {
+		isInUse[name] = true
+	}
+	return isInUse, nil
+}
+
+// applyExplicitDeviceMap replaces the selected block devices of the
+// nodes listed in the given explicit device map with their pinned
+// block devices
+//
+// NOTE:
+//	Pinned devices that are not observed are ignored if the pools
+// are sharded by failure domain since only the devices of this
+// failure domain are observed. ShardedReconciler validates these
+// against all the observed devices.
+func (r *Reconciler) applyExplicitDeviceMap(
+	mappings []types.ExplicitDeviceMapping,
+) ([]*unstructured.Unstructured, error) {
+	if len(mappings) == 0 {
+		return r.selectedBlockDevices, nil
+	}
+	owned, err := bdc.GetOwnedDeviceNames(
+		r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return bd.ExplicitMapCheck{
+		Mappings:         mappings,
+		Devices:          r.ObservedBlockDevices,
+		OwnedDeviceNames: owned,
+		IgnoreMissing:    r.FailureDomain != "",
+	}.Apply(r.selectedBlockDevices)
+}
+
+// selectAllBlockDevices selects the active & unclaimed block devices
+// of the allowed nodes along with the devices owned by this config
+//
+// NOTE:
+//	Devices are restricted to the allowed nodes only if nodes
+// are observed
+func (r *Reconciler) selectAllBlockDevices() ([]*unstructured.Unstructured, error) {
+	owned, err := bdc.GetOwnedDeviceNames(
+		r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+	if err != nil {
+		return nil, err
+	}
+	check := bd.SelectAllCheck{
+		Selector:         r.deviceSelector,
+		Devices:          r.ObservedBlockDevices,
+		OwnedDeviceNames: owned,
+	}
+	if len(r.ObservedNodes) != 0 {
+		allowedNodeSelector, err := r.cccHelper.GetAllowedNodeSelector()
+		if err != nil {
+			return nil, err
+		}
+		check.AllowedHostNames, err =
+			nodecommon.GetAllowedHostNames(allowedNodeSelector, r.ObservedNodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return check.Apply()
+}
+
+// selectBlockDevicesWithinCapacityBounds drops the selected block
+// devices whose capacity is outside the configured min & max device
+// capacities
+//
+// NOTE:
+//	Block devices that are already used by the observed
+// CStorPoolCluster are never dropped.
+func (r *Reconciler) selectBlockDevicesWithinCapacityBounds() {
+	var minCapacity, maxCapacity *resource.Quantity
+	minCapacity, maxCapacity, r.err = r.cccHelper.GetLocalDeviceCapacityBounds()
+	if r.err != nil || (minCapacity == nil && maxCapacity == nil) {
+		return
+	}
+	var inUseDeviceNames []string
+	inUseDeviceNames, r.err =
+		bdc.GetCStorPoolClusterDeviceNames(r.ObservedCStorPoolCluster)
+	if r.err != nil {
+		return
+	}
+	check := bd.CapacityCheck{
+		Min:              minCapacity,
+		Max:              maxCapacity,
+		Devices:          r.selectedBlockDevices,
+		InUseDeviceNames: map[string]bool{},
+	}
+	for _, name := range inUseDeviceNames {
+		check.InUseDeviceNames[name] = true
+	}
+	selectedCount := len(r.selectedBlockDevices)
+	r.selectedBlockDevices, r.err = check.Apply()
+	if r.err != nil {
+		return
+	}
+	if len(r.selectedBlockDevices) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"0 of %d selected block devices within capacity bounds: Min %q: Max %q",
+			selectedCount, minCapacity, maxCapacity,
+		)
+	}
+}
+
+// rejectUnhealthyBlockDevices drops the selected block devices
+// that failed SMART checks if the CStorClusterConfig requires so
+//
+// NOTE:
+//	Block devices that are already used by the observed
+// CStorPoolCluster are never dropped.
+func (r *Reconciler) rejectUnhealthyBlockDevices() {
+	var isRequireSMARTPass bool
+	isRequireSMARTPass, r.err = r.cccHelper.IsRequireSMARTPass()
+	if r.err != nil || !isRequireSMARTPass {
+		return
+	}
+	var inUseDeviceNames []string
+	inUseDeviceNames, r.err =
+		bdc.GetCStorPoolClusterDeviceNames(r.ObservedCStorPoolCluster)
+	if r.err != nil {
+		return
+	}
+	check := bd.SMARTCheck{
+		Devices:          r.selectedBlockDevices,
+		InUseDeviceNames: map[string]bool{},
+	}
+	for _, name := range inUseDeviceNames {
+		check.InUseDeviceNames[name] = true
+	}
+	selectedCount := len(r.selectedBlockDevices)
+	r.selectedBlockDevices, r.rejectedBlockDevices, r.err = check.Apply()
+	if r.err != nil {
+		return
+	}
+	if len(r.selectedBlockDevices) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"0 of %d selected block devices passed SMART checks", selectedCount,
+		)
+	}
+}
+
+// reserveCapacityPerNode drops some of the selected block devices
+// to leave the reserved raw capacity unclaimed on every node
+//
+// NOTE:
+//	Block devices that are already used by the observed
+// CStorPoolCluster are never dropped.
+func (r *Reconciler) reserveCapacityPerNode() {
+	var reservePerNode *intstr.IntOrString
+	reservePerNode, r.err = r.cccHelper.GetReservePerNode()
+	if r.err != nil || reservePerNode == nil {
+		return
+	}
+	var preference types.DevicePreference
+	preference, r.err = r.cccHelper.GetDevicePreference()
+	if r.err != nil {
+		return
+	}
+	var wear *bd.WearScorer
+	wear, r.err = r.cccHelper.GetWearScorer()
+	if r.err != nil {
+		return
+	}
+	var inUseDeviceNames []string
+	inUseDeviceNames, r.err =
+		bdc.GetCStorPoolClusterDeviceNames(r.ObservedCStorPoolCluster)
+	if r.err != nil {
+		return
+	}
+	reservation := capacity.Reservation{
+		ReservePerNode:       reservePerNode,
+		ObservedBlockDevices: r.ObservedBlockDevices,
+		SelectedBlockDevices: r.selectedBlockDevices,
+		InUseDeviceNames:     inUseDeviceNames,
+		GroupDeviceCount:     types.GetRAIDGroupDiskCount(r.raidType, r.raidGroupConfig),
+		Preference:           preference,
+		Wear:                 wear,
+	}
+	selectedCount := len(r.selectedBlockDevices)
+	r.selectedBlockDevices, r.err = reservation.Apply()
+	if r.err != nil {
+		return
+	}
+	if len(r.selectedBlockDevices) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"0 of %d block devices selected: Reserve %q per node",
+			selectedCount, reservePerNode.String(),
+		)
 	}
 }
 
 // mapHostNameToSelectedBlockDevices traverses through all the block devices
 // and sets a mapping of hostname to corresponding block device names
+//
+// NOTE:
+//	Block devices without hostname label are mapped to the hostname of
+// their node
 func (r *Reconciler) mapHostNameToSelectedBlockDevices() {
-	l := bd.NewListHelper(r.selectedBlockDevices)
-	r.hostNameToSelectedBlockDeviceNames, r.err = l.GroupDeviceNamesByHostName()
+	var devices *index.BlockDeviceIndex
+	devices, r.err = index.NewBlockDeviceIndexWithHostNamesOrError(
+		r.selectedBlockDevices,
+		nodecommon.NewHostNameResolver(r.ObservedNodes).NodeNameToHostName(),
+	)
+	if r.err != nil {
+		return
+	}
+	r.nodeHostNameToSelectedBlockDeviceNames, r.err = devices.DeviceNamesByNode()
+	if r.err != nil {
+		return
+	}
+	// pools are keyed by the value of node selector key
+	r.hostNameToSelectedBlockDeviceNames = r.groupByNodeSelectorValue(
+		r.nodeHostNameToSelectedBlockDeviceNames,
+	)
+}
+
+// groupByNodeSelectorValue re-groups the given hostname to device
+// names by the value of the node selector key of the respective
+// nodes
+//
+// NOTE:
+//	Devices of the nodes that share the same node selector value
+// e.g. a zone end up in the same pool
+func (r *Reconciler) groupByNodeSelectorValue(
+	hostNameToDeviceNames map[string][]string,
+) map[string][]string {
+	if r.nodeSelectorKey == "" || r.nodeSelectorKey == types.DefaultNodeSelectorKey {
+		return hostNameToDeviceNames
+	}
+	hostNames := make([]string, 0, len(hostNameToDeviceNames))
+	for hostName := range hostNameToDeviceNames {
+		hostNames = append(hostNames, hostName)
+	}
+	// sorted to build the same specs irrespective of map ordering
+	sort.Strings(hostNames)
+	hostNameResolver := nodecommon.NewHostNameResolver(r.ObservedNodes)
+	selectorResolver :=
+		nodecommon.NewHostNameResolverWithKey(r.ObservedNodes, r.nodeSelectorKey)
+	grouped := map[string][]string{}
+	for _, hostName := range hostNames {
+		value := selectorResolver.GetHostName(hostNameResolver.GetNodeName(hostName))
+		grouped[value] = append(grouped[value], hostNameToDeviceNames[hostName]...)
+	}
+	return grouped
 }
 
+// isSelectedBlockDeviceCountMatchRAIDType verifies if the selected
+// block device count of every pool matches the raid type
+//
+// NOTE:
+//	Pools with an invalid device count are skipped instead if the
+// partial placement policy is BestEffort. Pools that are found in
+// the observed CStorPoolCluster are never skipped since these would
+// get removed otherwise.
 func (r *Reconciler) isSelectedBlockDeviceCountMatchRAIDType() {
+	isObserved := map[string]bool{}
+	for _, hostName := range r.observedHostNamesInCSPC {
+		isObserved[hostName] = true
+	}
+	hostNames := make([]string, 0, len(r.hostNameToSelectedBlockDeviceNames))
+	for hostName := range r.hostNameToSelectedBlockDeviceNames {
+		hostNames = append(hostNames, hostName)
+	}
+	// sorted to report the same nodes irrespective of map ordering
+	sort.Strings(hostNames)
+	isSkippedDevice := map[string]bool{}
 	// match device count on a per node basis
-	for observedNode, selectedBlockDevices := range r.hostNameToSelectedBlockDeviceNames {
+	for _, observedNode := range hostNames {
+		selectedBlockDevices := r.hostNameToSelectedBlockDeviceNames[observedNode]
 		// does device count match the RAID type
 		r.isDeviceCountMatchRAIDType, r.err =
 			r.cccHelper.IsDiskCountMatchRAIDType(int64(len(selectedBlockDevices)))
@@ -313,9 +1379,17 @@ func (r *Reconciler) isSelectedBlockDeviceCountMatchRAIDType() {
 			// this was a runtime error
 			return
 		}
-		if !r.isDeviceCountMatchRAIDType {
+		if r.isDeviceCountMatchRAIDType {
+			continue
+		}
+		var policy types.PartialPlacementPolicy
+		policy, r.err = r.cccHelper.GetPartialPlacementPolicy()
+		if r.err != nil {
+			return
+		}
+		if policy != types.PartialPlacementPolicyBestEffort || isObserved[observedNode] {
 			r.err =
-				errors.Errorf(
+				errs.NotEnoughResourcesErrorf(
 					"Can't reconcile: Invalid block device count %d: RAID %q: Node %q",
 					len(selectedBlockDevices), r.raidType, observedNode,
 				)
@@ -323,6 +1397,84 @@ func (r *Reconciler) isSelectedBlockDeviceCountMatchRAIDType() {
 			// operation invalid
 			return
 		}
+		r.skippedNodes = append(r.skippedNodes, types.CStorClusterConfigSkippedNode{
+			HostName: observedNode,
+			Reason: fmt.Sprintf(
+				"Invalid block device count %d: RAID %q",
+				len(selectedBlockDevices), r.raidType,
+			),
+		})
+		for _, name := range selectedBlockDevices {
+			isSkippedDevice[name] = true
+		}
+		delete(r.hostNameToSelectedBlockDeviceNames, observedNode)
+	}
+	if len(r.skippedNodes) == 0 {
+		return
+	}
+	if len(r.hostNameToSelectedBlockDeviceNames) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"Can't reconcile: Invalid block device count on all %d node(s): RAID %q",
+			len(r.skippedNodes), r.raidType,
+		)
+		return
+	}
+	r.dropSkippedBlockDevices(isSkippedDevice)
+}
+
+// skipIfNotEnoughReadyNodes skips the reconciliation if the
+// CStorPoolCluster is not yet created & fewer nodes than the min
+// ready nodes have block devices that match the raid type
+//
+// NOTE:
+//	Pools are created together once enough nodes are ready. This
+// avoids skewed data placement when mirror or raidz pools get added
+// one at a time. Pools added to an existing CStorPoolCluster are
+// not held back.
+func (r *Reconciler) skipIfNotEnoughReadyNodes() {
+	if r.ObservedCStorPoolCluster != nil {
+		return
+	}
+	var minReady int64
+	minReady, r.err = r.cccHelper.GetMinReadyNodesBeforeCreate()
+	if r.err != nil {
+		return
+	}
+	ready := int64(len(r.hostNameToSelectedBlockDeviceNames))
+	if ready >= minReady {
+		return
+	}
+	r.skipReconcile = true
+	r.skipReconcileCode = skip.ReasonWaitingForReadyNodes
+	r.skipReconcileReason = fmt.Sprintf(
+		"%d of %d min ready nodes have block devices that match RAID %q",
+		ready, minReady, r.raidType,
+	)
+}
+
+// dropSkippedBlockDevices removes the block devices of skipped nodes
+// from the selected block devices so that these are neither waited
+// for nor reported as capacity
+func (r *Reconciler) dropSkippedBlockDevices(isSkipped map[string]bool) {
+	var selected []*unstructured.Unstructured
+	for _, device := range r.selectedBlockDevices {
+		if !isSkipped[device.GetName()] {
+			selected = append(selected, device)
+		}
+	}
+	r.selectedBlockDevices = selected
+	for hostName, deviceNames := range r.nodeHostNameToSelectedBlockDeviceNames {
+		var names []string
+		for _, name := range deviceNames {
+			if !isSkipped[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			delete(r.nodeHostNameToSelectedBlockDeviceNames, hostName)
+			continue
+		}
+		r.nodeHostNameToSelectedBlockDeviceNames[hostName] = names
 	}
 }
 
@@ -345,25 +1497,226 @@ func (r *Reconciler) walkObservedCStorPoolCluster() {
 	r.hostNameToObservedCSPCDeviceNames, r.err = h.GroupBlockDeviceNamesByHostName()
 }
 
+// arrangeRAIDGroupsByWear orders the selected block devices of
+// every pool such that its new raid groups avoid pairing highly worn
+// devices if the CStorClusterConfig has wear leveling
+//
+// NOTE:
+//	Devices that are already used by the observed CStorPoolCluster
+// retain their raid groups.
+func (r *Reconciler) arrangeRAIDGroupsByWear() {
+	var wear *bd.WearScorer
+	wear, r.err = r.cccHelper.GetWearScorer()
+	if r.err != nil || wear == nil {
+		return
+	}
+	devices := map[string]*unstructured.Unstructured{}
+	for _, device := range r.selectedBlockDevices {
+		devices[device.GetName()] = device
+	}
+	groupDeviceCount := types.GetRAIDGroupDiskCount(r.raidType, r.raidGroupConfig)
+	for hostName, deviceNames := range r.hostNameToSelectedBlockDeviceNames {
+		isObserved := map[string]bool{}
+		for _, name := range r.hostNameToObservedCSPCDeviceNames[hostName] {
+			isObserved[name] = true
+		}
+		var arranged []string
+		var newDevices []*unstructured.Unstructured
+		for _, name := range deviceNames {
+			if isObserved[name] || devices[name] == nil {
+				arranged = append(arranged, name)
+				continue
+			}
+			newDevices = append(newDevices, devices[name])
+		}
+		for _, device := range wear.ArrangeRAIDGroups(newDevices, groupDeviceCount) {
+			arranged = append(arranged, device.GetName())
+		}
+		r.hostNameToSelectedBlockDeviceNames[hostName] = arranged
+	}
+}
+
+// skipIfBlockDeviceClaimsNotBound skips the reconciliation if any
+// of the selected block devices is not yet claimed
+//
+// NOTE:
+//	Block devices are claimed by blockdeviceclaim controller. A
+// block device that is already used by the observed CStorPoolCluster
+// is not waited for.
+func (r *Reconciler) skipIfBlockDeviceClaimsNotBound() {
+	var deviceNames []string
+	for _, device := range r.selectedBlockDevices {
+		deviceNames = append(deviceNames, device.GetName())
+	}
+	var pending []string
+	pending, r.err = bdc.FilterPendingDeviceNames(
+		deviceNames, r.ObservedBlockDeviceClaims, r.ObservedCStorPoolCluster,
+	)
+	if r.err != nil {
+		return
+	}
+	if len(pending) != 0 {
+		r.skipReconcile = true
+		r.skipReconcileCode = skip.ReasonBlockDeviceClaimsPending
+		r.skipReconcileReason = fmt.Sprintf(
+			"%d of %d block devices are not claimed: [%s]",
+			len(pending), len(deviceNames), strings.Join(pending, ", "),
+		)
+	}
+}
+
 // buildDesiredCStorPoolCluster returns the desired CStorPoolCluster state
 //
 // NOTE:
 //	This logic is idempotent. In other words, it returns same structure
 // for every reconcile action i.e. add, update, even no change in state.
 func (r *Reconciler) buildDesiredCStorPoolCluster() {
+	r.err = faultinject.Inject(faultinject.PhaseCSPCBuild)
+	if r.err != nil {
+		return
+	}
 	b := &cspc.Builder{
-		Name:                          r.ObservedCStorClusterConfig.GetName(),
-		Namespace:                     r.ObservedCStorClusterConfig.GetNamespace(),
+		Name:                          r.cstorPoolClusterName,
+		Namespace:                     r.targetNamespace,
 		OrderedHostNames:              r.observedHostNamesInCSPC,
 		HostNameToObservedDeviceNames: r.hostNameToObservedCSPCDeviceNames,
 		HostNameToDesiredDeviceNames:  r.hostNameToSelectedBlockDeviceNames,
 		DesiredAnnotations: map[string]string{
 			types.AnnKeyCStorClusterConfigUID:       string(r.ObservedCStorClusterConfig.GetUID()),
 			types.AnnKeyCStorClusterConfigLocalDisk: "true",
+			// config may be in a different namespace
+			types.AnnKeyCStorClusterConfigNamespacedName: r.ObservedCStorClusterConfig.GetNamespace() +
+				"/" + r.ObservedCStorClusterConfig.GetName(),
 		},
-		DesiredRAIDType: r.raidType,
+		DesiredRAIDType:        r.raidType,
+		DesiredRAIDGroupConfig: r.raidGroupConfig,
+		DesiredZFSProperties:   r.zfsProperties,
+		DesiredNodeSelectorKey: r.nodeSelectorKey,
+	}
+	if r.FailureDomain != "" {
+		b.DesiredAnnotations[types.AnnKeyCStorPoolClusterFailureDomain] = r.FailureDomain
 	}
 	r.desiredCStorPoolCluster, r.err = b.BuildDesiredState()
+	if r.err != nil {
+		return
+	}
+	// user provided labels & annotations if any
+	metadata.Propagate(r.desiredCStorPoolCluster, r.childMetadata)
+}
+
+// applyOverlays patches the desired CStorPoolCluster with the
+// overlays of the config once all its fields are resolved
+func (r *Reconciler) applyOverlays() {
+	r.err = overlay.Apply(r.desiredCStorPoolCluster, r.overlays)
+}
+
+// resolveDrift resolves the manual edits made to the pools of
+// observed CStorPoolCluster based on the drift policy
+func (r *Reconciler) resolveDrift() {
+	r.driftPolicy, r.err = r.cccHelper.GetDriftPolicy()
+	if r.err != nil {
+		return
+	}
+	resolver := &drift.Resolver{
+		Policy:                   r.driftPolicy,
+		WatchUID:                 string(r.ObservedCStorClusterConfig.GetUID()),
+		ObservedCStorPoolCluster: r.ObservedCStorPoolCluster,
+		DesiredCStorPoolCluster:  r.desiredCStorPoolCluster,
+	}
+	r.driftResult, r.err = resolver.Resolve()
+	if r.err != nil {
+		return
+	}
+	r.desiredCStorPoolCluster = r.driftResult.CStorPoolCluster
+}
+
+// orchestrateRAIDTypeChange re-creates the pools of observed
+// CStorPoolCluster whose raid type differs from the desired raid
+// type once this change is approved
+func (r *Reconciler) orchestrateRAIDTypeChange() {
+	approved, _ := unstruct.GetValueForKey(
+		r.ObservedCStorClusterConfig.GetAnnotations(),
+		types.AnnKeyCStorClusterConfigApproveRAIDTypeChange,
+	)
+	orchestrator := &raidchange.Orchestrator{
+		DesiredRAIDType:            r.raidType,
+		ApprovedRAIDType:           types.PoolRAIDType(approved),
+		ObservedCStorPoolCluster:   r.ObservedCStorPoolCluster,
+		DesiredCStorPoolCluster:    r.desiredCStorPoolCluster,
+		ObservedCStorPoolInstances: r.ObservedCStorPoolInstances,
+	}
+	r.raidChangeResult, r.err = orchestrator.Orchestrate()
+	if r.err != nil {
+		return
+	}
+	r.desiredCStorPoolCluster = r.raidChangeResult.CStorPoolCluster
+}
+
+// retainIgnoredFields sets the observed values of the fields that
+// are ignored from reconciliation against the desired
+// CStorPoolCluster
+func (r *Reconciler) retainIgnoredFields() {
+	var paths []string
+	paths, r.err = r.cccHelper.GetIgnoreFields()
+	if r.err != nil {
+		return
+	}
+	r.err = ignorefields.Retain(
+		paths, r.ObservedCStorPoolCluster, r.desiredCStorPoolCluster,
+	)
+}
+
+// buildDesiredCStorClusterConfig builds the CStorClusterConfig with
+// the resolved defaults set in its spec
+//
+// NOTE:
+//	Only the defaulted fields are set. These fields are merged with
+// the observed spec when applied & hence leave the other fields as is.
+func (r *Reconciler) buildDesiredCStorClusterConfig() {
+	if !r.IsPersistDefaults {
+		return
+	}
+	r.desiredCStorClusterConfig = &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": r.ObservedCStorClusterConfig.GetAPIVersion(),
+			"kind":       r.ObservedCStorClusterConfig.GetKind(),
+			"metadata": map[string]interface{}{
+				"name":      r.ObservedCStorClusterConfig.GetName(),
+				"namespace": r.ObservedCStorClusterConfig.GetNamespace(),
+			},
+			"spec": map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"raidType": string(r.raidType),
+				},
+				"driftPolicy":     string(r.driftPolicy),
+				"targetNamespace": r.targetNamespace,
+			},
+		},
+	}
+}
+
+// aggregateCapacity aggregates the capacity of the pool instances
+// & block devices managed by CStorClusterConfig
+func (r *Reconciler) aggregateCapacity() {
+	a := &capacity.Aggregator{
+		CStorPoolClusterName:      r.desiredCStorPoolCluster.GetName(),
+		CStorPoolClusterNamespace: r.desiredCStorPoolCluster.GetNamespace(),
+		CStorPoolInstances:        r.ObservedCStorPoolInstances,
+		BlockDevices:              append(r.ObservedBlockDevices, r.plannedBlockDevices...),
+		HostNameToPoolDeviceNames: r.nodeHostNameToSelectedBlockDeviceNames,
+	}
+	r.capacity = a.Aggregate()
+}
+
+// buildPoolTopology builds the layout of pools of the desired
+// CStorPoolCluster
+//
+// NOTE:
+//	Desired CStorPoolCluster is used since it is the one that gets
+// applied. This includes the pools retained due to drift.
+func (r *Reconciler) buildPoolTopology() {
+	r.poolTopology, r.err =
+		cspc.NewHelper(r.desiredCStorPoolCluster).GetPoolTopology()
 }
 
 // Reconcile runs through the reconciliation logic
@@ -379,31 +1732,67 @@ func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
 	}
 	if len(r.ObservedBlockDevices) == 0 {
 		return NilReconcileResponse,
-			errors.Errorf("Can't reconcile: Missing block devices")
+			errs.NotEnoughResourcesErrorf("Can't reconcile: Missing block devices")
 	}
 	r.init()
 	fns := []func(){
 		r.setRAIDType,
-		r.selectFromObservedBlockDevices,
+		r.setZFSProperties,
+		r.setNodeSelectorKey,
+		r.setChildMetadata,
+		r.setOverlays,
+		r.setTargetNamespace,
+		r.setCStorPoolClusterName,
+		r.splitBlockDevicesByDiskSource,
+		r.selectLocalBlockDevices,
+		r.addPlannedBlockDevices,
 		r.mapHostNameToSelectedBlockDevices,
-		r.isSelectedBlockDeviceCountMatchRAIDType,
 		r.walkObservedCStorPoolCluster,
+		r.isSelectedBlockDeviceCountMatchRAIDType,
+		r.skipIfNotEnoughReadyNodes,
+		r.arrangeRAIDGroupsByWear,
+		r.skipIfBlockDeviceClaimsNotBound,
 		r.buildDesiredCStorPoolCluster,
+		r.resolveDrift,
+		r.orchestrateRAIDTypeChange,
+		r.retainIgnoredFields,
+		r.applyOverlays,
+		r.buildDesiredCStorClusterConfig,
+		r.aggregateCapacity,
+		r.buildPoolTopology,
 	}
 	for _, fn := range fns {
-		fn()
+		// remaining steps are not run past the deadline of this sync
+		if r.err = errs.CheckContext(r.Context); r.err == nil {
+			end := tracing.StartPhase(r.Context, fn)
+			fn()
+			end()
+		}
 		// post operation checks
 		if r.err != nil {
-			return NilReconcileResponse, r.err
+			return ReconcileResponse{
+				BlockDeviceSelectionReport: r.selectionReport,
+			}, r.err
 		}
 		if r.skipReconcile {
 			return ReconcileResponse{
-				SkipReconcile: true,
-				SkipReason:    r.skipReconcileReason,
+				SkipReconcile:            true,
+				SkipCode:                 r.skipReconcileCode,
+				SkipReason:               r.skipReconcileReason,
+				SelectedBlockDeviceNames: r.getSelectedBlockDeviceNames(),
 			}, nil
 		}
 	}
 	return ReconcileResponse{
-		CStorPoolCluster: r.desiredCStorPoolCluster,
+		CStorPoolCluster:         r.desiredCStorPoolCluster,
+		CStorClusterConfig:       r.desiredCStorClusterConfig,
+		Capacity:                 r.capacity,
+		IsDrifted:                r.driftResult.IsDrifted,
+		DriftReason:              r.driftResult.Reason,
+		RAIDTypeChange:           r.raidChangeResult,
+		RejectedBlockDevices:     r.rejectedBlockDevices,
+		SkippedNodes:             r.skippedNodes,
+		PoolTopology:             r.poolTopology,
+		SelectedBlockDeviceNames: r.getSelectedBlockDeviceNames(),
 	}, nil
 }
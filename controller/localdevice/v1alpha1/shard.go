@@ -0,0 +1,399 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package localdevice
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	bdc "mayadata.io/cstorpoolauto/common/blockdeviceclaim"
+	"mayadata.io/cstorpoolauto/common/capacity"
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	"mayadata.io/cstorpoolauto/common/skip"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/index"
+	"mayadata.io/cstorpoolauto/pkg/tracing"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// shard is the outcome of reconciling the pools of a single
+// failure domain
+type shard struct {
+	domain   string
+	response ReconcileResponse
+}
+
+// ShardedReconciler reconciles one CStorPoolCluster per failure
+// domain of the observed nodes
+//
+// NOTE:
+//	Failure domain of a block device is the value of the
+// FailureDomainKey label of its node. Block devices of nodes without
+// this label are not selected. Each failure domain is reconciled by
+// a Reconciler that observes the block devices & the CStorPoolCluster
+// of this failure domain only.
+type ShardedReconciler struct {
+	ObservedCStorClusterConfig *unstructured.Unstructured
+	ObservedBlockDevices       []*unstructured.Unstructured
+	ObservedBlockDeviceClaims  []*unstructured.Unstructured
+	ObservedCStorPoolClusters  []*unstructured.Unstructured
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+	ObservedNodes              []*unstructured.Unstructured
+
+	// IsPersistDefaults when true results in a desired
+	// CStorClusterConfig with the resolved defaults set in its spec
+	IsPersistDefaults bool
+
+	// FailureDomainKey is the node label key whose values are the
+	// failure domains
+	FailureDomainKey string
+
+	// ReservedBlockDeviceNames are the block devices selected by
+	// other configs that are yet to be claimed. These are not
+	// selected.
+	ReservedBlockDeviceNames map[string]bool
+
+	// Context is the context of the traced sync if any
+	Context context.Context
+
+	domains                  []string
+	domainToBlockDevices     map[string][]*unstructured.Unstructured
+	domainToCStorPoolCluster map[string]*unstructured.Unstructured
+
+	shards          []shard
+	selectionReport []string
+
+	// names of the block devices selected across failure domains
+	selectedBlockDeviceNames []string
+
+	skipResponse *ReconcileResponse
+	err          error
+}
+
+// validateExplicitDeviceMap validates the pinned block devices if
+// any against all the observed block devices
+//
+// NOTE:
+//	Reconciler of a failure domain observes only the devices of its
+// failure domain & hence can't tell if a pinned device is missing
+func (r *ShardedReconciler) validateExplicitDeviceMap() {
+	var mappings []types.ExplicitDeviceMapping
+	mappings, r.err =
+		ccc.NewHelper(r.ObservedCStorClusterConfig).GetLocalExplicitDeviceMap()
+	if r.err != nil || len(mappings) == 0 {
+		return
+	}
+	var owned map[string]bool
+	owned, r.err = bdc.GetOwnedDeviceNames(r.ObservedBlockDeviceClaims, nil)
+	if r.err != nil {
+		return
+	}
+	for _, observed := range r.ObservedCStorPoolClusters {
+		var inUse []string
+		inUse, r.err = bdc.GetCStorPoolClusterDeviceNames(observed)
+		if r.err != nil {
+			return
+		}
+		for _, name := range inUse {
+			owned[name] = true
+		}
+	}
+	r.err = bd.ExplicitMapCheck{
+		Mappings:         mappings,
+		Devices:          r.ObservedBlockDevices,
+		OwnedDeviceNames: owned,
+	}.Validate()
+}
+
+// groupBlockDevicesByFailureDomain maps the observed block devices
+// to the failure domains of their nodes
+func (r *ShardedReconciler) groupBlockDevicesByFailureDomain() {
+	hostNameToDomain := map[string]string{}
+	for _, node := range r.ObservedNodes {
+		if node == nil {
+			continue
+		}
+		domain := node.GetLabels()[r.FailureDomainKey]
+		if domain == "" {
+			continue
+		}
+		hostNameToDomain[nodecommon.GetHostName(node)] = domain
+	}
+	nodeNameToHostName :=
+		nodecommon.NewHostNameResolver(r.ObservedNodes).NodeNameToHostName()
+	var missingDomain []string
+	for _, device := range r.ObservedBlockDevices {
+		if device == nil {
+			continue
+		}
+		hostName := device.GetLabels()[index.LblKeyHostName]
+		if hostName == "" {
+			nodeName, _, _ := unstructured.NestedString(
+				device.Object, "spec", "nodeAttributes", "nodeName",
+			)
+			hostName = nodeNameToHostName[nodeName]
+		}
+		domain := hostNameToDomain[hostName]
+		if domain == "" {
+			missingDomain = append(missingDomain, device.GetName())
+			continue
+		}
+		r.domainToBlockDevices[domain] =
+			append(r.domainToBlockDevices[domain], device)
+	}
+	if len(missingDomain) != 0 {
+		r.selectionReport = append(r.selectionReport, fmt.Sprintf(
+			"%d block device(s) on nodes without label %q: %s",
+			len(missingDomain),
+			r.FailureDomainKey,
+			strings.Join(missingDomain, ", "),
+		))
+	}
+}
+
+// groupCStorPoolClustersByFailureDomain maps the observed
+// CStorPoolClusters to their failure domains
+func (r *ShardedReconciler) groupCStorPoolClustersByFailureDomain() {
+	for _, observed := range r.ObservedCStorPoolClusters {
+		if observed == nil {
+			continue
+		}
+		domain :=
+			observed.GetAnnotations()[types.AnnKeyCStorPoolClusterFailureDomain]
+		if domain == "" {
+			// pools can't be sharded once they are created
+			r.err = errs.ValidationErrorf(
+				"Can't shard pools by %q: CStorPoolCluster %q isn't sharded",
+				r.FailureDomainKey,
+				observed.GetName(),
+			)
+			return
+		}
+		if existing, found := r.domainToCStorPoolCluster[domain]; found {
+			r.err = errs.ValidationErrorf(
+				"Can't shard pools by %q: CStorPoolClusters %q & %q belong to failure domain %q",
+				r.FailureDomainKey,
+				existing.GetName(),
+				observed.GetName(),
+				domain,
+			)
+			return
+		}
+		r.domainToCStorPoolCluster[domain] = observed
+	}
+}
+
+// setFailureDomains sets the sorted failure domains that have either
+// block devices or a CStorPoolCluster
+func (r *ShardedReconciler) setFailureDomains() {
+	domains := map[string]bool{}
+	for domain := range r.domainToBlockDevices {
+		domains[domain] = true
+	}
+	for domain := range r.domainToCStorPoolCluster {
+		domains[domain] = true
+	}
+	for domain := range domains {
+		r.domains = append(r.domains, domain)
+	}
+	sort.Strings(r.domains)
+	if len(r.domains) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"Can't reconcile: No block devices on nodes with label %q",
+			r.FailureDomainKey,
+		)
+	}
+}
+
+// reconcileFailureDomains reconciles the pools of each failure
+// domain
+//
+// NOTE:
+//	A failure domain without enough block devices & without a
+// CStorPoolCluster is left out. In other words, a failure domain
+// gets its CStorPoolCluster once it has enough block devices.
+// Similarly, a failure domain that is waiting for its min ready
+// nodes is left out.
+func (r *ShardedReconciler) reconcileFailureDomains() {
+	var leftOut, waiting []string
+	for _, domain := range r.domains {
+		// failure domains are not reconciled past the deadline of
+		// this sync
+		r.err = errs.CheckContext(r.Context)
+		if r.err != nil {
+			return
+		}
+		// phases of each failure domain are traced separately
+		ctx, end := tracing.StartSpan(
+			r.Context, "failureDomain", map[string]string{"failureDomain": domain},
+		)
+		reconciler := &Reconciler{
+			ObservedCStorClusterConfig: r.ObservedCStorClusterConfig,
+			ObservedBlockDevices:       r.domainToBlockDevices[domain],
+			ObservedBlockDeviceClaims:  r.ObservedBlockDeviceClaims,
+			ObservedCStorPoolCluster:   r.domainToCStorPoolCluster[domain],
+			ObservedCStorPoolInstances: r.ObservedCStorPoolInstances,
+			ObservedNodes:              r.ObservedNodes,
+			IsPersistDefaults:          r.IsPersistDefaults,
+			FailureDomain:              domain,
+			ReservedBlockDeviceNames:   r.ReservedBlockDeviceNames,
+			Context:                    ctx,
+		}
+		resp, err := reconciler.Reconcile()
+		end()
+		r.selectedBlockDeviceNames =
+			append(r.selectedBlockDeviceNames, resp.SelectedBlockDeviceNames...)
+		for _, line := range resp.BlockDeviceSelectionReport {
+			r.selectionReport = append(
+				r.selectionReport, fmt.Sprintf("Failure domain %q: %s", domain, line),
+			)
+		}
+		if err != nil {
+			if errs.TypeOf(err) == errs.TypeNotEnoughResources &&
+				r.domainToCStorPoolCluster[domain] == nil {
+				leftOut = append(
+					leftOut, fmt.Sprintf("Failure domain %q: %s", domain, err.Error()),
+				)
+				continue
+			}
+			r.err = errors.Wrapf(err, "Failure domain %q", domain)
+			return
+		}
+		if resp.SkipCode == skip.ReasonWaitingForReadyNodes {
+			// other failure domains are not held back
+			waiting = append(
+				waiting, fmt.Sprintf("Failure domain %q: %s", domain, resp.SkipReason),
+			)
+			continue
+		}
+		if resp.SkipReconcile {
+			resp.SkipReason =
+				fmt.Sprintf("Failure domain %q: %s", domain, resp.SkipReason)
+			r.skipResponse = &resp
+			return
+		}
+		r.shards = append(r.shards, shard{domain: domain, response: resp})
+	}
+	if len(r.shards) == 0 && len(waiting) != 0 {
+		r.skipResponse = &ReconcileResponse{
+			SkipReconcile: true,
+			SkipCode:      skip.ReasonWaitingForReadyNodes,
+			SkipReason:    strings.Join(waiting, "; "),
+		}
+		return
+	}
+	if len(r.shards) == 0 {
+		r.err = errs.NotEnoughResourcesErrorf(
+			"Can't reconcile: No failure domain has enough block devices: %s",
+			strings.Join(leftOut, "; "),
+		)
+	}
+}
+
+// merge returns the response that combines the responses of all the
+// reconciled failure domains
+func (r *ShardedReconciler) merge() ReconcileResponse {
+	var merged ReconcileResponse
+	var capacities []*types.CStorClusterConfigCapacity
+	var driftReasons, raidChangeReasons []string
+	merged.PoolTopology = &types.CStorClusterConfigPoolTopology{}
+	for _, s := range r.shards {
+		resp := s.response
+		merged.CStorPoolClusters =
+			append(merged.CStorPoolClusters, resp.CStorPoolCluster)
+		if merged.CStorClusterConfig == nil {
+			// resolved defaults are the same for all failure domains
+			merged.CStorClusterConfig = resp.CStorClusterConfig
+		}
+		capacities = append(capacities, resp.Capacity)
+		if resp.IsDrifted {
+			merged.IsDrifted = true
+			driftReasons = append(driftReasons,
+				fmt.Sprintf("Failure domain %q: %s", s.domain, resp.DriftReason))
+		}
+		if resp.RAIDTypeChange.IsRequested {
+			merged.RAIDTypeChange.IsRequested = true
+			raidChangeReasons = append(raidChangeReasons,
+				fmt.Sprintf("Failure domain %q: %s", s.domain, resp.RAIDTypeChange.Reason))
+		}
+		merged.RejectedBlockDevices =
+			append(merged.RejectedBlockDevices, resp.RejectedBlockDevices...)
+		merged.SkippedNodes = append(merged.SkippedNodes, resp.SkippedNodes...)
+		if resp.PoolTopology == nil {
+			continue
+		}
+		for _, pool := range resp.PoolTopology.Pools {
+			pool.CStorPoolClusterName = resp.PoolTopology.CStorPoolClusterName
+			pool.FailureDomain = s.domain
+			merged.PoolTopology.Pools = append(merged.PoolTopology.Pools, pool)
+		}
+	}
+	merged.Capacity = capacity.Merge(capacities...)
+	merged.DriftReason = strings.Join(driftReasons, "; ")
+	merged.RAIDTypeChange.Reason = strings.Join(raidChangeReasons, "; ")
+	return merged
+}
+
+// Reconcile runs through the reconciliation logic of every failure
+// domain
+func (r *ShardedReconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedCStorClusterConfig == nil {
+		return NilReconcileResponse,
+			errors.Errorf("Can't reconcile: Nil CStorClusterConfig")
+	}
+	if r.FailureDomainKey == "" {
+		return NilReconcileResponse,
+			errors.Errorf("Can't reconcile: Missing failure domain key")
+	}
+	r.domainToBlockDevices = map[string][]*unstructured.Unstructured{}
+	r.domainToCStorPoolCluster = map[string]*unstructured.Unstructured{}
+	fns := []func(){
+		r.validateExplicitDeviceMap,
+		r.groupBlockDevicesByFailureDomain,
+		r.groupCStorPoolClustersByFailureDomain,
+		r.setFailureDomains,
+		r.reconcileFailureDomains,
+	}
+	for _, fn := range fns {
+		// remaining steps are not run past the deadline of this sync
+		if r.err = errs.CheckContext(r.Context); r.err == nil {
+			end := tracing.StartPhase(r.Context, fn)
+			fn()
+			end()
+		}
+		// post operation checks
+		if r.err != nil {
+			return ReconcileResponse{
+				BlockDeviceSelectionReport: r.selectionReport,
+			}, r.err
+		}
+		if r.skipResponse != nil {
+			r.skipResponse.SelectedBlockDeviceNames = r.selectedBlockDeviceNames
+			return *r.skipResponse, nil
+		}
+	}
+	merged := r.merge()
+	merged.SelectedBlockDeviceNames = r.selectedBlockDeviceNames
+	return merged, nil
+}
@@ -0,0 +1,477 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placementhint
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/skip"
+	capacitymath "mayadata.io/cstorpoolauto/pkg/capacity"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// controllerName is reported against the skipped reconciliations
+// of this controller
+const controllerName = "PlacementHint"
+
+// ResyncAfterSeconds is the interval after which the free capacity
+// of pools gets evaluated again
+var ResyncAfterSeconds float64 = 60
+
+const (
+	// DataKeyCStorPoolCluster is the key of the hints ConfigMap that
+	// refers to the CStorPoolCluster of the hinted pool instances
+	DataKeyCStorPoolCluster string = "cstorPoolCluster"
+
+	// DataKeyImbalanced is the key of the hints ConfigMap that is
+	// true if the free capacity skew is beyond the max allowed skew
+	DataKeyImbalanced string = "imbalanced"
+
+	// DataKeyFreeCapacitySkew is the key of the hints ConfigMap that
+	// refers to the difference between the max & min free capacity
+	// percentages of the pool instances
+	DataKeyFreeCapacitySkew string = "freeCapacitySkew"
+
+	// DataKeyPreferredPools is the key of the hints ConfigMap that
+	// has the comma separated names of the pool instances that
+	// should be preferred for new volume replicas. Most free pool
+	// instance is listed first. This is empty if the pool instances
+	// are not imbalanced.
+	DataKeyPreferredPools string = "preferredPools"
+)
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	cstorPoolClusters  []*unstructured.Unstructured
+	cstorPoolInstances []*unstructured.Unstructured
+	hints              []*unstructured.Unstructured
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) skipIfPaused() {
+	_, s.err = pause.Skip(
+		controllerName, s.request.Watch, s.request.Watch, s.response,
+	)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started PlacementHint sync: Watch %q - %q / %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+	)
+}
+
+func (s *syncer) registerAttachments() {
+	if s.request.Attachments == nil {
+		return
+	}
+	for _, attachment := range s.request.Attachments.List() {
+		uid, _ := unstruct.GetValueForKey(
+			attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+		)
+		isOwned := string(s.request.Watch.GetUID()) == uid
+		switch attachment.GetKind() {
+		case string(types.KindCStorPoolCluster):
+			// cspcs are only observed & are never modified
+			if isOwned {
+				s.cstorPoolClusters = append(s.cstorPoolClusters, attachment)
+			}
+		case string(types.KindCStorPoolInstance):
+			// pool instances are only observed to get the free capacity
+			s.cstorPoolInstances = append(s.cstorPoolInstances, attachment)
+		case "ConfigMap":
+			_, isHint := attachment.GetAnnotations()[types.AnnKeyCStorPoolClusterPlacementHints]
+			if isOwned && isHint {
+				// hints are added to response after reconciliation
+				s.hints = append(s.hints, attachment)
+				continue
+			}
+		}
+		s.response.Attachments = append(s.response.Attachments, attachment)
+	}
+}
+
+func (s *syncer) skipIfNotEnabled() {
+	hints, found, _ := unstructured.NestedMap(
+		s.request.Watch.Object, "spec", "placementHints",
+	)
+	if (found && hints != nil) || len(s.hints) != 0 {
+		// hints are deleted if these are not desired
+		return
+	}
+	s.err = skip.Skip(
+		controllerName,
+		s.request.Watch,
+		s.response,
+		skip.ReasonNotEnabled,
+		"Placement hints are not enabled",
+	)
+}
+
+// clearSkipCondition removes the condition that was set when this
+// controller skipped an earlier sync
+func (s *syncer) clearSkipCondition() {
+	s.err = skip.Clear(controllerName, s.request.Watch, s.response)
+}
+
+func (s *syncer) reconcile() {
+	reconciler := &Reconciler{
+		ObservedClusterConfig:      s.request.Watch,
+		ObservedCStorPoolClusters:  s.cstorPoolClusters,
+		ObservedCStorPoolInstances: s.cstorPoolInstances,
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+	if s.err != nil {
+		return
+	}
+	// hints that are not desired get deleted
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredHints...,
+	)
+	// free capacity is evaluated periodically since changes to the
+	// capacity of pool instances do not trigger this sync
+	if len(s.reconcileResponse.DesiredHints) != 0 {
+		s.response.ResyncAfterSeconds = ResyncAfterSeconds
+	}
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished PlacementHint sync: Imbalanced CStorPoolClusters %v: Watch %q - %q / %q: %s",
+		s.reconcileResponse.ImbalancedCStorPoolClusters,
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync PlacementHint: Watch %q - %q / %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.skipIfPaused,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.skipIfNotEnabled,
+		s.reconcile,
+		s.clearSkipCondition,
+		s.logSyncFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to hint the pool instances of
+// a CStorClusterConfig that should be preferred for the replicas of
+// new volumes.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses CStorClusterConfig as the watched resource.
+// SyncHookResponse has the resources that forms the desired state
+// w.r.t the watched resource.
+//
+// NOTE:
+//	ConfigMaps created by this controller get deleted when
+// spec.placementHints is unset.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// PoolFreeCapacity is the free capacity of a pool instance
+type PoolFreeCapacity struct {
+	Name string
+
+	// Percent is the free capacity w.r.t the total capacity
+	Percent int64
+}
+
+// Reconciler evaluates the placement hints of the CStorPoolCluster(s)
+// of the observed CStorClusterConfig
+//
+// NOTE:
+//	Hints of a CStorPoolCluster are written into a ConfigMap in the
+// namespace of this CStorPoolCluster. Pool instances whose free
+// capacity is at least the average free capacity are preferred if
+// the free capacity skew goes beyond the max allowed skew.
+type Reconciler struct {
+	ObservedClusterConfig      *unstructured.Unstructured
+	ObservedCStorPoolClusters  []*unstructured.Unstructured
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+
+	config         types.CStorClusterConfig
+	maxFreeSkew    int64
+	imbalanced     []string
+	isHintsEnabled bool
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	// DesiredHints are the ConfigMaps with the hints of every
+	// observed CStorPoolCluster
+	DesiredHints []*unstructured.Unstructured
+
+	// ImbalancedCStorPoolClusters are the names of CStorPoolCluster(s)
+	// whose pool instances are imbalanced in free capacity
+	ImbalancedCStorPoolClusters []string
+}
+
+func (r *Reconciler) init() error {
+	err := unstruct.UnstructToTyped(r.ObservedClusterConfig, &r.config)
+	if err != nil {
+		return err
+	}
+	if r.config.Spec.PlacementHints == nil {
+		return nil
+	}
+	r.isHintsEnabled = true
+	r.maxFreeSkew = r.config.Spec.PlacementHints.MaxFreeCapacitySkew
+	if r.maxFreeSkew == 0 {
+		r.maxFreeSkew = types.DefaultMaxFreeCapacitySkew
+	}
+	return nil
+}
+
+func (r *Reconciler) validate() error {
+	if r.maxFreeSkew < 0 || r.maxFreeSkew > 100 {
+		return errs.ValidationErrorf(
+			"Invalid maxFreeCapacitySkew %d: Want value between 1 & 100",
+			r.maxFreeSkew,
+		)
+	}
+	return nil
+}
+
+// getPoolFreeCapacities returns the free capacity of the pool
+// instances of the given CStorPoolCluster sorted by most free first
+//
+// NOTE:
+//	Pool instances whose capacity is not observable yet are not
+// hinted
+func (r *Reconciler) getPoolFreeCapacities(
+	cluster *unstructured.Unstructured,
+) ([]PoolFreeCapacity, error) {
+	a := &capacity.Aggregator{
+		CStorPoolClusterName:      cluster.GetName(),
+		CStorPoolClusterNamespace: cluster.GetNamespace(),
+		CStorPoolInstances:        r.ObservedCStorPoolInstances,
+	}
+	var pools []PoolFreeCapacity
+	for _, pool := range a.Aggregate().Pools {
+		total := pool.Total.Value()
+		if total <= 0 {
+			continue
+		}
+		free := pool.Free.Value()
+		if pool.Free.IsZero() && !pool.Used.IsZero() {
+			free = total - pool.Used.Value()
+		}
+		freePercent, err := capacitymath.Multiply(free, 100)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't compute free capacity of pool %q", pool.Name,
+			)
+		}
+		pools = append(pools, PoolFreeCapacity{
+			Name:    pool.Name,
+			Percent: freePercent / total,
+		})
+	}
+	// sort to keep the hints idempotent across reconciliations
+	sort.SliceStable(pools, func(i, j int) bool {
+		if pools[i].Percent != pools[j].Percent {
+			return pools[i].Percent > pools[j].Percent
+		}
+		return pools[i].Name < pools[j].Name
+	})
+	return pools, nil
+}
+
+// getDesiredHint returns the ConfigMap with the hints of the given
+// CStorPoolCluster
+func (r *Reconciler) getDesiredHint(
+	cluster *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	pools, err := r.getPoolFreeCapacities(cluster)
+	if err != nil {
+		return nil, err
+	}
+	var skew, sum int64
+	if len(pools) != 0 {
+		skew = pools[0].Percent - pools[len(pools)-1].Percent
+	}
+	for _, pool := range pools {
+		sum += pool.Percent
+	}
+	isImbalanced := skew > r.maxFreeSkew
+	var preferred []string
+	if isImbalanced {
+		r.imbalanced = append(r.imbalanced, cluster.GetName())
+		for _, pool := range pools {
+			// pools are compared against the average without
+			// losing the remainder of the division
+			if pool.Percent*int64(len(pools)) >= sum {
+				preferred = append(preferred, pool.Name)
+			}
+		}
+	}
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data": map[string]interface{}{
+				DataKeyCStorPoolCluster: cluster.GetName(),
+				DataKeyImbalanced:       strconv.FormatBool(isImbalanced),
+				DataKeyFreeCapacitySkew: strconv.FormatInt(skew, 10),
+				DataKeyPreferredPools:   strings.Join(preferred, ","),
+			},
+		},
+	}
+	desired.SetName(cluster.GetName() + "-placement-hints")
+	desired.SetNamespace(cluster.GetNamespace())
+	desired.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID:          string(r.ObservedClusterConfig.GetUID()),
+		types.AnnKeyCStorPoolClusterPlacementHints: cluster.GetName(),
+	})
+	return desired, nil
+}
+
+// getDesiredHints returns the ConfigMaps with the hints of every
+// observed CStorPoolCluster
+func (r *Reconciler) getDesiredHints() ([]*unstructured.Unstructured, error) {
+	if !r.isHintsEnabled {
+		// observed hints get deleted
+		return nil, nil
+	}
+	// sort to keep the response idempotent across reconciliations
+	clusters := append([]*unstructured.Unstructured{}, r.ObservedCStorPoolClusters...)
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].GetName() < clusters[j].GetName()
+	})
+	var hints []*unstructured.Unstructured
+	for _, cluster := range clusters {
+		hint, err := r.getDesiredHint(cluster)
+		if err != nil {
+			return nil, err
+		}
+		hints = append(hints, hint)
+	}
+	return hints, nil
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	This logic is idempotent. Hints are not emitted for the
+// CStorPoolCluster(s) that are not observed yet.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedClusterConfig == nil {
+		return ReconcileResponse{},
+			errors.Errorf("Can't reconcile: Nil CStorClusterConfig")
+	}
+	fns := []func() error{
+		r.init,
+		r.validate,
+	}
+	for _, fn := range fns {
+		err := fn()
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+	}
+	hints, err := r.getDesiredHints()
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	return ReconcileResponse{
+		DesiredHints:                hints,
+		ImbalancedCStorPoolClusters: r.imbalanced,
+	}, nil
+}
@@ -0,0 +1,234 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package placementhint
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestConfig(placementHints map[string]interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"minPoolCount": int64(2),
+		"maxPoolCount": int64(4),
+	}
+	if placementHints != nil {
+		spec["placementHints"] = placementHints
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "dao.mayadata.io/v1alpha1",
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"uid":       "config-101",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func newTestCSPC(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+			},
+		},
+	}
+}
+
+func newTestCSPI(cspcName, name, total, free string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolInstance),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+				"labels": map[string]interface{}{
+					capacity.LabelKeyCStorPoolCluster: cspcName,
+				},
+			},
+		},
+	}
+	if total != "" {
+		unstructured.SetNestedStringMap(obj.Object, map[string]string{
+			"total": total,
+			"free":  free,
+		}, "status", "capacity")
+	}
+	return obj
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		config             *unstructured.Unstructured
+		cstorPoolClusters  []*unstructured.Unstructured
+		cstorPoolInstances []*unstructured.Unstructured
+		expectHints        []map[string]interface{}
+		expectImbalanced   []string
+		isErr              bool
+	}{
+		"nil config": {
+			isErr: true,
+		},
+		"hints are not enabled": {
+			config:            newTestConfig(nil),
+			cstorPoolClusters: []*unstructured.Unstructured{newTestCSPC("my-cspc")},
+			cstorPoolInstances: []*unstructured.Unstructured{
+				newTestCSPI("my-cspc", "cspi-a", "100Gi", "90Gi"),
+				newTestCSPI("my-cspc", "cspi-b", "100Gi", "10Gi"),
+			},
+		},
+		"invalid max free capacity skew": {
+			config: newTestConfig(map[string]interface{}{
+				"maxFreeCapacitySkew": int64(101),
+			}),
+			isErr: true,
+		},
+		"no cstor pool cluster": {
+			config: newTestConfig(map[string]interface{}{}),
+		},
+		"balanced pools": {
+			config:            newTestConfig(map[string]interface{}{}),
+			cstorPoolClusters: []*unstructured.Unstructured{newTestCSPC("my-cspc")},
+			cstorPoolInstances: []*unstructured.Unstructured{
+				newTestCSPI("my-cspc", "cspi-a", "100Gi", "60Gi"),
+				newTestCSPI("my-cspc", "cspi-b", "100Gi", "50Gi"),
+				newTestCSPI("other-cspc", "cspi-c", "100Gi", "0"),
+			},
+			expectHints: []map[string]interface{}{
+				{
+					DataKeyCStorPoolCluster: "my-cspc",
+					DataKeyImbalanced:       "false",
+					DataKeyFreeCapacitySkew: "10",
+					DataKeyPreferredPools:   "",
+				},
+			},
+		},
+		"imbalanced pools": {
+			config:            newTestConfig(map[string]interface{}{}),
+			cstorPoolClusters: []*unstructured.Unstructured{newTestCSPC("my-cspc")},
+			cstorPoolInstances: []*unstructured.Unstructured{
+				newTestCSPI("my-cspc", "cspi-a", "100Gi", "10Gi"),
+				newTestCSPI("my-cspc", "cspi-b", "100Gi", "70Gi"),
+				newTestCSPI("my-cspc", "cspi-c", "200Gi", "180Gi"),
+				newTestCSPI("my-cspc", "cspi-d", "", ""),
+			},
+			expectHints: []map[string]interface{}{
+				{
+					DataKeyCStorPoolCluster: "my-cspc",
+					DataKeyImbalanced:       "true",
+					DataKeyFreeCapacitySkew: "80",
+					DataKeyPreferredPools:   "cspi-c,cspi-b",
+				},
+			},
+			expectImbalanced: []string{"my-cspc"},
+		},
+		"skew within the configured max skew": {
+			config: newTestConfig(map[string]interface{}{
+				"maxFreeCapacitySkew": int64(90),
+			}),
+			cstorPoolClusters: []*unstructured.Unstructured{newTestCSPC("my-cspc")},
+			cstorPoolInstances: []*unstructured.Unstructured{
+				newTestCSPI("my-cspc", "cspi-a", "100Gi", "10Gi"),
+				newTestCSPI("my-cspc", "cspi-b", "100Gi", "90Gi"),
+			},
+			expectHints: []map[string]interface{}{
+				{
+					DataKeyCStorPoolCluster: "my-cspc",
+					DataKeyImbalanced:       "false",
+					DataKeyFreeCapacitySkew: "80",
+					DataKeyPreferredPools:   "",
+				},
+			},
+		},
+		"hints per cstor pool cluster": {
+			config: newTestConfig(map[string]interface{}{}),
+			cstorPoolClusters: []*unstructured.Unstructured{
+				newTestCSPC("zone-b"),
+				newTestCSPC("zone-a"),
+			},
+			cstorPoolInstances: []*unstructured.Unstructured{
+				newTestCSPI("zone-a", "cspi-a1", "100Gi", "10Gi"),
+				newTestCSPI("zone-a", "cspi-a2", "100Gi", "90Gi"),
+				newTestCSPI("zone-b", "cspi-b1", "100Gi", "50Gi"),
+			},
+			expectHints: []map[string]interface{}{
+				{
+					DataKeyCStorPoolCluster: "zone-a",
+					DataKeyImbalanced:       "true",
+					DataKeyFreeCapacitySkew: "80",
+					DataKeyPreferredPools:   "cspi-a2",
+				},
+				{
+					DataKeyCStorPoolCluster: "zone-b",
+					DataKeyImbalanced:       "false",
+					DataKeyFreeCapacitySkew: "0",
+					DataKeyPreferredPools:   "",
+				},
+			},
+			expectImbalanced: []string{"zone-a"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ObservedClusterConfig:      mock.config,
+				ObservedCStorPoolClusters:  mock.cstorPoolClusters,
+				ObservedCStorPoolInstances: mock.cstorPoolInstances,
+			}
+			got, err := r.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var gotHints []map[string]interface{}
+			for _, hint := range got.DesiredHints {
+				if hint.GetName() != hint.GetAnnotations()[types.AnnKeyCStorPoolClusterPlacementHints]+"-placement-hints" {
+					t.Fatalf("Expected hint to be named after its cspc got %q", hint.GetName())
+				}
+				if hint.GetAnnotations()[types.AnnKeyCStorClusterConfigUID] != "config-101" {
+					t.Fatalf("Expected hint to refer to config uid got %v", hint.GetAnnotations())
+				}
+				data, _, _ := unstructured.NestedMap(hint.Object, "data")
+				gotHints = append(gotHints, data)
+			}
+			if diff := cmp.Diff(mock.expectHints, gotHints); diff != "" {
+				t.Fatalf("Expected no diff in hints got:\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectImbalanced, got.ImbalancedCStorPoolClusters); diff != "" {
+				t.Fatalf("Expected no diff in imbalanced cspcs got:\n%s", diff)
+			}
+		})
+	}
+}
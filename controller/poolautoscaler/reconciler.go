@@ -0,0 +1,603 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolautoscaler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/skip"
+	"mayadata.io/cstorpoolauto/common/state"
+	capacitymath "mayadata.io/cstorpoolauto/pkg/capacity"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// DefaultScaleDownStabilizationSeconds is the default duration for
+// which utilization needs to stay below the scale down threshold
+// before a pool gets removed
+const DefaultScaleDownStabilizationSeconds int64 = 3600
+
+// StateComponent is the name of the component whose state holds
+// the memory of pool autoscaler
+const StateComponent string = "poolautoscaler"
+
+// StateKeyBelowThresholdSince is the key of the state that holds
+// the time since when the utilization of pools is below the scale
+// down threshold
+const StateKeyBelowThresholdSince string = "belowThresholdSince"
+
+// ResyncAfterSeconds is the interval after which the utilization
+// of pools gets evaluated again
+var ResyncAfterSeconds float64 = 60
+
+// controllerName is reported against the skipped reconciliations
+// of pool autoscaler
+const controllerName = "PoolAutoscaler"
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	cstorPoolClusters  []*unstructured.Unstructured
+	cstorPoolInstances []*unstructured.Unstructured
+	states             []*unstructured.Unstructured
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) skipIfAutoscaleNotEnabled() {
+	autoscale, found, _ := unstructured.NestedMap(
+		s.request.Watch.Object, "spec", "autoscale",
+	)
+	if found && autoscale != nil {
+		return
+	}
+	s.err = skip.Skip(
+		controllerName,
+		s.request.Watch,
+		s.response,
+		skip.ReasonNotEnabled,
+		"Autoscale is not enabled",
+	)
+}
+
+func (s *syncer) skipIfPaused() {
+	_, s.err = pause.Skip(
+		controllerName, s.request.Watch, s.request.Watch, s.response,
+	)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started PoolAutoscaler sync: Watch %q - %q / %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+	)
+}
+
+func (s *syncer) registerAttachments() {
+	if s.request.Attachments == nil {
+		return
+	}
+	for _, attachment := range s.request.Attachments.List() {
+		switch attachment.GetKind() {
+		case string(types.KindCStorClusterConfig):
+			if attachment.GetUID() == s.request.Watch.GetUID() {
+				// watch is added to response after reconciliation
+				continue
+			}
+		case string(types.KindCStorPoolCluster):
+			// cspcs are only observed & are never modified
+			uid, _ := unstruct.GetValueForKey(
+				attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+			)
+			if string(s.request.Watch.GetUID()) == uid {
+				s.cstorPoolClusters = append(s.cstorPoolClusters, attachment)
+			}
+		case string(types.KindCStorPoolInstance):
+			// pool instances are only observed to get the utilization
+			s.cstorPoolInstances = append(s.cstorPoolInstances, attachment)
+		case "ConfigMap":
+			if state.IsStateOf(attachment, s.request.Watch, StateComponent) {
+				// state is added to response after reconciliation
+				s.states = append(s.states, attachment)
+				continue
+			}
+		}
+		s.response.Attachments = append(s.response.Attachments, attachment)
+	}
+}
+
+func (s *syncer) reconcile() {
+	reconciler := &Reconciler{
+		ObservedClusterConfig:      s.request.Watch,
+		ObservedCStorPoolClusters:  s.cstorPoolClusters,
+		ObservedCStorPoolInstances: s.cstorPoolInstances,
+		ObservedStates:             s.states,
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+	if s.err != nil {
+		return
+	}
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredClusterConfig,
+	)
+	if s.reconcileResponse.DesiredState != nil {
+		s.response.Attachments = append(
+			s.response.Attachments, s.reconcileResponse.DesiredState,
+		)
+	}
+	// utilization is evaluated periodically since changes to the
+	// capacity of pool instances do not trigger this sync
+	s.response.ResyncAfterSeconds = ResyncAfterSeconds
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished PoolAutoscaler sync: Utilization %d%%: Pool count %d: Watch %q - %q / %q: %s",
+		s.reconcileResponse.Utilization,
+		s.reconcileResponse.PoolCount,
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// clearSkipCondition removes the condition that was set when this
+// controller skipped an earlier sync
+func (s *syncer) clearSkipCondition() {
+	s.err = skip.Clear(controllerName, s.request.Watch, s.response)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync PoolAutoscaler: Watch %q - %q / %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.skipIfAutoscaleNotEnabled,
+		s.skipIfPaused,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.reconcile,
+		s.clearSkipCondition,
+		s.logSyncFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to scale the pool count of
+// a CStorClusterConfig based on the utilization of its pools.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses CStorClusterConfig as the watched resource.
+// SyncHookResponse has the resources that forms the desired state
+// w.r.t the watched resource.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// Reconciler evaluates the desired pool count of the observed
+// CStorClusterConfig based on the utilization of its pools
+//
+// NOTE:
+//	Desired pool count is set against the CStorClusterConfig as
+// an annotation. CStorClusterConfig controller plans the nodes of
+// CStorClusterPlan based on this pool count.
+//
+// NOTE:
+//	Time since when the utilization is below the scale down
+// threshold is persisted in the state of this component. This
+// survives the restarts of this operator.
+type Reconciler struct {
+	ObservedClusterConfig      *unstructured.Unstructured
+	ObservedCStorPoolClusters  []*unstructured.Unstructured
+	ObservedCStorPoolInstances []*unstructured.Unstructured
+
+	// ObservedStates are the ConfigMaps that persist the state of
+	// pool autoscaler
+	ObservedStates []*unstructured.Unstructured
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+
+	config            types.CStorClusterConfig
+	autoscale         types.Autoscale
+	minPoolCount      int64
+	maxPoolCount      int64
+	poolInstanceCount int64
+	utilization       int64
+	isObservable      bool
+	state             *state.Store
+
+	// pool count & below threshold since as observed from the
+	// annotation & state & as desired after reconciliation
+	poolCount           int64
+	belowThresholdSince time.Time
+
+	// true if pool count is neither set nor observable
+	isPoolCountUnknown bool
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	DesiredClusterConfig *unstructured.Unstructured
+
+	// Utilization is the percentage of used capacity of all the
+	// pool instances
+	Utilization int64
+
+	// PoolCount is the desired pool count
+	PoolCount int64
+
+	// DesiredState is the ConfigMap that saves the state of pool
+	// autoscaler; nil if there is nothing to save
+	DesiredState *unstructured.Unstructured
+}
+
+func (r *Reconciler) init() error {
+	if r.Now == nil {
+		r.Now = time.Now
+	}
+	err := unstruct.UnstructToTyped(r.ObservedClusterConfig, &r.config)
+	if err != nil {
+		return err
+	}
+	if r.config.Spec.Autoscale == nil {
+		return errs.ValidationErrorf("Can't autoscale: Nil spec.autoscale")
+	}
+	r.autoscale = *r.config.Spec.Autoscale
+	if r.autoscale.ScaleDownStabilizationSeconds == 0 {
+		r.autoscale.ScaleDownStabilizationSeconds = DefaultScaleDownStabilizationSeconds
+	}
+	r.state, err = state.Load(r.ObservedClusterConfig, StateComponent, r.ObservedStates)
+	return err
+}
+
+func (r *Reconciler) validate() error {
+	if r.config.Spec.PoolConfig.PerZoneCSPC {
+		return errs.ValidationErrorf(
+			"Can't autoscale: Autoscale is not supported with perZoneCSPC",
+		)
+	}
+	if r.autoscale.ScaleUpThreshold <= 0 || r.autoscale.ScaleUpThreshold > 100 {
+		return errs.ValidationErrorf(
+			"Invalid scaleUpThreshold %d: Want value between 1 & 100",
+			r.autoscale.ScaleUpThreshold,
+		)
+	}
+	if r.autoscale.ScaleDownThreshold < 0 ||
+		r.autoscale.ScaleDownThreshold >= r.autoscale.ScaleUpThreshold {
+		return errs.ValidationErrorf(
+			"Invalid scaleDownThreshold %d: Want value between 0 & scaleUpThreshold %d",
+			r.autoscale.ScaleDownThreshold, r.autoscale.ScaleUpThreshold,
+		)
+	}
+	if r.autoscale.ScaleDownStabilizationSeconds < 0 {
+		return errs.ValidationErrorf(
+			"Invalid scaleDownStabilizationSeconds %d: Want positive value",
+			r.autoscale.ScaleDownStabilizationSeconds,
+		)
+	}
+	return nil
+}
+
+// setPoolCountBounds sets the min & max pool counts
+//
+// NOTE:
+//	These counts are set by CStorClusterConfig controller while
+// resolving the defaults
+func (r *Reconciler) setPoolCountBounds() error {
+	r.minPoolCount = r.config.Spec.MinPoolCount.Value()
+	r.maxPoolCount = r.config.Spec.MaxPoolCount.Value()
+	if r.minPoolCount <= 0 || r.maxPoolCount <= 0 {
+		return errs.TransientErrorf(
+			"Can't autoscale: Pool counts are yet to be set: Min %d: Max %d",
+			r.minPoolCount, r.maxPoolCount,
+		)
+	}
+	return nil
+}
+
+// aggregateUtilization aggregates the utilization of pool
+// instances of all the CStorPoolCluster(s) of this config
+func (r *Reconciler) aggregateUtilization() error {
+	var total, used int64
+	for _, cluster := range r.ObservedCStorPoolClusters {
+		a := &capacity.Aggregator{
+			CStorPoolClusterName:      cluster.GetName(),
+			CStorPoolClusterNamespace: cluster.GetNamespace(),
+			CStorPoolInstances:        r.ObservedCStorPoolInstances,
+		}
+		aggregated := a.Aggregate()
+		var err error
+		total, err = capacitymath.Add(total, aggregated.Total.Value())
+		if err != nil {
+			return errors.Wrapf(err, "Can't aggregate total capacity")
+		}
+		used, err = capacitymath.Add(used, aggregated.Used.Value())
+		if err != nil {
+			return errors.Wrapf(err, "Can't aggregate used capacity")
+		}
+		r.poolInstanceCount += int64(len(aggregated.Pools))
+	}
+	if total <= 0 {
+		// utilization is not observable yet
+		return nil
+	}
+	usedPercent, err := capacitymath.Multiply(used, 100)
+	if err != nil {
+		return errors.Wrapf(err, "Can't compute utilization")
+	}
+	r.isObservable = true
+	r.utilization = usedPercent / total
+	return nil
+}
+
+// setObservedBelowThresholdSince sets the time since when the
+// utilization is below the scale down threshold from the state
+//
+// NOTE:
+//	Older versions of pool autoscaler set this time as an annotation
+// against the config. This annotation is used if the state does not
+// have this time yet.
+func (r *Reconciler) setObservedBelowThresholdSince() error {
+	since, found, err := r.state.GetTime(StateKeyBelowThresholdSince)
+	if err != nil || found {
+		r.belowThresholdSince = since
+		return err
+	}
+	val, _ := unstruct.GetValueForKey(
+		r.ObservedClusterConfig.GetAnnotations(),
+		types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince,
+	)
+	if val == "" {
+		return nil
+	}
+	r.belowThresholdSince, err = time.Parse(time.RFC3339, val)
+	if err != nil {
+		return errs.AsValidationError(errors.Wrapf(
+			err,
+			"Invalid annotation %q",
+			types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince,
+		))
+	}
+	return nil
+}
+
+// setObservedPoolCount sets the pool count from the annotation set
+// during previous reconciliations or from the observed pool
+// instances if not set
+func (r *Reconciler) setObservedPoolCount() error {
+	annotations := r.ObservedClusterConfig.GetAnnotations()
+	val, _ := unstruct.GetValueForKey(
+		annotations, types.AnnKeyCStorClusterConfigAutoscalePoolCount,
+	)
+	if val == "" && !r.isObservable {
+		// pool count is set only after pools are observed to
+		// avoid planning a pool count different from existing
+		r.isPoolCountUnknown = true
+		return nil
+	}
+	if val == "" {
+		r.poolCount = r.poolInstanceCount
+	} else {
+		poolCount, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return errs.AsValidationError(errors.Wrapf(
+				err,
+				"Invalid annotation %q",
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount,
+			))
+		}
+		r.poolCount = poolCount
+	}
+	if r.poolCount < r.minPoolCount {
+		r.poolCount = r.minPoolCount
+	}
+	if r.poolCount > r.maxPoolCount {
+		r.poolCount = r.maxPoolCount
+	}
+	return nil
+}
+
+// isScaleDownStable returns true if utilization has stayed below
+// the scale down threshold for the stabilization duration
+func (r *Reconciler) isScaleDownStable() bool {
+	stabilization := time.Duration(r.autoscale.ScaleDownStabilizationSeconds) * time.Second
+	return !r.Now().Before(r.belowThresholdSince.Add(stabilization))
+}
+
+// scale evaluates the desired pool count based on the utilization
+//
+// NOTE:
+//	Pool count is changed by one at a time. Pool count is not
+// changed till the previous change is observed at pool instances.
+func (r *Reconciler) scale() error {
+	if r.isPoolCountUnknown ||
+		!r.isObservable ||
+		r.poolInstanceCount != r.poolCount {
+		// wait for the previous change to reflect at pool instances
+		return nil
+	}
+	if r.utilization >= r.autoscale.ScaleUpThreshold {
+		r.belowThresholdSince = time.Time{}
+		if r.poolCount < r.maxPoolCount {
+			glog.V(2).Infof(
+				"Will scale up pool count to %d: Utilization %d%% >= %d%%: CStorClusterConfig %q / %q",
+				r.poolCount+1, r.utilization, r.autoscale.ScaleUpThreshold,
+				r.ObservedClusterConfig.GetNamespace(), r.ObservedClusterConfig.GetName(),
+			)
+			r.poolCount++
+		}
+		return nil
+	}
+	if r.autoscale.ScaleDownThreshold == 0 ||
+		r.utilization >= r.autoscale.ScaleDownThreshold ||
+		r.poolCount <= r.minPoolCount {
+		r.belowThresholdSince = time.Time{}
+		return nil
+	}
+	if r.belowThresholdSince.IsZero() {
+		// scale down only if utilization stays below the threshold
+		r.belowThresholdSince = r.Now().UTC().Truncate(time.Second)
+		return nil
+	}
+	if !r.isScaleDownStable() {
+		return nil
+	}
+	glog.V(2).Infof(
+		"Will scale down pool count to %d: Utilization %d%% < %d%% since %s: CStorClusterConfig %q / %q",
+		r.poolCount-1, r.utilization, r.autoscale.ScaleDownThreshold,
+		r.belowThresholdSince.Format(time.RFC3339),
+		r.ObservedClusterConfig.GetNamespace(), r.ObservedClusterConfig.GetName(),
+	)
+	r.belowThresholdSince = time.Time{}
+	r.poolCount--
+	return nil
+}
+
+// getDesiredClusterConfig returns the desired state of the observed
+// config with the autoscale annotations
+//
+// NOTE:
+//	Below threshold since annotation set by older versions is
+// removed since this time is persisted in the state
+func (r *Reconciler) getDesiredClusterConfig() *unstructured.Unstructured {
+	if r.isPoolCountUnknown {
+		return r.ObservedClusterConfig
+	}
+	desired := r.ObservedClusterConfig.DeepCopy()
+	annotations := desired.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[types.AnnKeyCStorClusterConfigAutoscalePoolCount] =
+		strconv.FormatInt(r.poolCount, 10)
+	delete(annotations, types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince)
+	desired.SetAnnotations(annotations)
+	return desired
+}
+
+// getDesiredState returns the ConfigMap that saves the state of
+// pool autoscaler
+func (r *Reconciler) getDesiredState() *unstructured.Unstructured {
+	r.state.SetTime(StateKeyBelowThresholdSince, r.belowThresholdSince)
+	return r.state.Desired()
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	This logic is idempotent. Pool count is scaled up as soon as
+// the utilization reaches the scale up threshold. Pool count is
+// scaled down only after the utilization stays below the scale
+// down threshold for the stabilization duration.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedClusterConfig == nil {
+		return ReconcileResponse{},
+			errors.Errorf("Can't reconcile: Nil CStorClusterConfig")
+	}
+	fns := []func() error{
+		r.init,
+		r.validate,
+		r.setPoolCountBounds,
+		r.aggregateUtilization,
+		r.setObservedBelowThresholdSince,
+		r.setObservedPoolCount,
+		r.scale,
+	}
+	for _, fn := range fns {
+		err := fn()
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+	}
+	return ReconcileResponse{
+		DesiredClusterConfig: r.getDesiredClusterConfig(),
+		Utilization:          r.utilization,
+		PoolCount:            r.poolCount,
+		DesiredState:         r.getDesiredState(),
+	}, nil
+}
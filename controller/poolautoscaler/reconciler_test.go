@@ -0,0 +1,439 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolautoscaler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/capacity"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+var testNow = time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+
+func newTestConfig(
+	autoscale map[string]interface{}, annotations map[string]string,
+) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "dao.mayadata.io/v1alpha1",
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"uid":       "config-101",
+			},
+			"spec": map[string]interface{}{
+				"minPoolCount": int64(2),
+				"maxPoolCount": int64(4),
+				"autoscale":    autoscale,
+			},
+		},
+	}
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func newTestCSPC(configUID string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name":      "my-cspc",
+				"namespace": "openebs",
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterConfigUID: configUID,
+				},
+			},
+		},
+	}
+}
+
+func newTestCSPI(name, total, used string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolInstance),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+				"labels": map[string]interface{}{
+					capacity.LabelKeyCStorPoolCluster: "my-cspc",
+				},
+			},
+			"status": map[string]interface{}{
+				"capacity": map[string]interface{}{
+					"total": total,
+					"used":  used,
+				},
+			},
+		},
+	}
+}
+
+func newTestState(configUID string, data map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "my-config-poolautoscaler-state",
+				"namespace": "openebs",
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterConfigUID: configUID,
+					types.AnnKeyStateComponent:        StateComponent,
+				},
+			},
+			"data": data,
+		},
+	}
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		autoscale         map[string]interface{}
+		annotations       map[string]string
+		perZone           bool
+		cspis             []*unstructured.Unstructured
+		states            []*unstructured.Unstructured
+		expectUtilization int64
+		expectAnnotations map[string]string
+		expectState       map[string]interface{}
+		isErr             bool
+	}{
+		"invalid scale up threshold": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(101),
+			},
+			isErr: true,
+		},
+		"scale down threshold above scale up threshold": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(80),
+			},
+			isErr: true,
+		},
+		"per zone cspc": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(80),
+			},
+			perZone: true,
+			isErr:   true,
+		},
+		"invalid pool count annotation": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(80),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "two",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "1Gi"),
+			},
+			isErr: true,
+		},
+		"no pool instances": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(80),
+			},
+		},
+		"utilization below scale up threshold": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(80),
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "5Gi"),
+				newTestCSPI("cspi-2", "10Gi", "5Gi"),
+			},
+			expectUtilization: 50,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "2",
+			},
+		},
+		"utilization above scale up threshold": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(80),
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "9Gi"),
+				newTestCSPI("cspi-2", "10Gi", "8Gi"),
+			},
+			expectUtilization: 85,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+		},
+		"utilization above scale up threshold && pool count at max": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(80),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "4",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "9Gi"),
+				newTestCSPI("cspi-2", "10Gi", "9Gi"),
+				newTestCSPI("cspi-3", "10Gi", "9Gi"),
+				newTestCSPI("cspi-4", "10Gi", "9Gi"),
+			},
+			expectUtilization: 90,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "4",
+			},
+		},
+		"utilization above scale up threshold && previous scale up is pending": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold": int64(80),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "9Gi"),
+				newTestCSPI("cspi-2", "10Gi", "9Gi"),
+			},
+			expectUtilization: 90,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+		},
+		"utilization below scale down threshold for the first time": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "1Gi"),
+				newTestCSPI("cspi-2", "10Gi", "1Gi"),
+				newTestCSPI("cspi-3", "10Gi", "1Gi"),
+			},
+			expectUtilization: 10,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+			expectState: map[string]interface{}{
+				StateKeyBelowThresholdSince: "2020-04-01T10:00:00Z",
+			},
+		},
+		"utilization below scale down threshold within stabilization": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount:           "3",
+				types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince: "2020-04-01T09:30:00Z",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "1Gi"),
+				newTestCSPI("cspi-2", "10Gi", "1Gi"),
+				newTestCSPI("cspi-3", "10Gi", "1Gi"),
+			},
+			expectUtilization: 10,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+			expectState: map[string]interface{}{
+				StateKeyBelowThresholdSince: "2020-04-01T09:30:00Z",
+			},
+		},
+		"utilization below scale down threshold after stabilization": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount:           "3",
+				types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince: "2020-04-01T09:00:00Z",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "1Gi"),
+				newTestCSPI("cspi-2", "10Gi", "1Gi"),
+				newTestCSPI("cspi-3", "10Gi", "1Gi"),
+			},
+			expectUtilization: 10,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "2",
+			},
+		},
+		"utilization below scale down threshold && pool count at min": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount:           "2",
+				types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince: "2020-04-01T09:00:00Z",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "1Gi"),
+				newTestCSPI("cspi-2", "10Gi", "1Gi"),
+			},
+			expectUtilization: 10,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "2",
+			},
+		},
+		"invalid below threshold since state": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			states: []*unstructured.Unstructured{
+				newTestState("config-101", map[string]interface{}{
+					StateKeyBelowThresholdSince: "yesterday",
+				}),
+			},
+			isErr: true,
+		},
+		"utilization below scale down threshold within stabilization as per state": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+			states: []*unstructured.Unstructured{
+				newTestState("config-101", map[string]interface{}{
+					StateKeyBelowThresholdSince: "2020-04-01T09:30:00Z",
+				}),
+				// state of some other config is ignored
+				newTestState("config-102", map[string]interface{}{
+					StateKeyBelowThresholdSince: "2020-04-01T08:00:00Z",
+				}),
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "1Gi"),
+				newTestCSPI("cspi-2", "10Gi", "1Gi"),
+				newTestCSPI("cspi-3", "10Gi", "1Gi"),
+			},
+			expectUtilization: 10,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+			expectState: map[string]interface{}{
+				StateKeyBelowThresholdSince: "2020-04-01T09:30:00Z",
+			},
+		},
+		"utilization below scale down threshold after stabilization as per state": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount:           "3",
+				types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince: "2020-04-01T09:55:00Z",
+			},
+			states: []*unstructured.Unstructured{
+				newTestState("config-101", map[string]interface{}{
+					StateKeyBelowThresholdSince: "2020-04-01T09:00:00Z",
+				}),
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "1Gi"),
+				newTestCSPI("cspi-2", "10Gi", "1Gi"),
+				newTestCSPI("cspi-3", "10Gi", "1Gi"),
+			},
+			expectUtilization: 10,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "2",
+			},
+		},
+		"utilization recovers above scale down threshold": {
+			autoscale: map[string]interface{}{
+				"scaleUpThreshold":   int64(80),
+				"scaleDownThreshold": int64(20),
+			},
+			annotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount:           "3",
+				types.AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince: "2020-04-01T09:00:00Z",
+			},
+			cspis: []*unstructured.Unstructured{
+				newTestCSPI("cspi-1", "10Gi", "5Gi"),
+				newTestCSPI("cspi-2", "10Gi", "5Gi"),
+				newTestCSPI("cspi-3", "10Gi", "5Gi"),
+			},
+			expectUtilization: 50,
+			expectAnnotations: map[string]string{
+				types.AnnKeyCStorClusterConfigAutoscalePoolCount: "3",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			config := newTestConfig(mock.autoscale, mock.annotations)
+			if mock.perZone {
+				err := unstructured.SetNestedField(
+					config.Object, true, "spec", "poolConfig", "perZoneCSPC",
+				)
+				if err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+			}
+			r := &Reconciler{
+				ObservedClusterConfig: config,
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					newTestCSPC("config-101"),
+				},
+				ObservedCStorPoolInstances: mock.cspis,
+				ObservedStates:             mock.states,
+				Now: func() time.Time {
+					return testNow
+				},
+			}
+			got, err := r.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if got.Utilization != mock.expectUtilization {
+				t.Fatalf(
+					"Expected utilization %d got %d", mock.expectUtilization, got.Utilization,
+				)
+			}
+			gotAnnotations := got.DesiredClusterConfig.GetAnnotations()
+			if diff := cmp.Diff(mock.expectAnnotations, gotAnnotations); diff != "" {
+				t.Fatalf("Expected no diff got \n%s", diff)
+			}
+			var gotState map[string]interface{}
+			if got.DesiredState != nil {
+				gotState, _, _ = unstructured.NestedMap(got.DesiredState.Object, "data")
+			}
+			if len(gotState) == 0 {
+				gotState = nil
+			}
+			if diff := cmp.Diff(mock.expectState, gotState); diff != "" {
+				t.Fatalf("Expected no state diff got \n%s", diff)
+			}
+		})
+	}
+}
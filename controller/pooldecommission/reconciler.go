@@ -0,0 +1,470 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pooldecommission
+
+import (
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	cspc "mayadata.io/cstorpoolauto/common/cstorpoolcluster"
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	nodecommon "mayadata.io/cstorpoolauto/common/node"
+	"mayadata.io/cstorpoolauto/common/skip"
+	"mayadata.io/cstorpoolauto/pkg/conditions"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// ResyncAfterSeconds is the interval after which a pending
+// pool decommission gets reconciled again
+var ResyncAfterSeconds float64 = 5
+
+// controllerName is reported against the skipped reconciliations
+// of pool decommission
+const controllerName = "PoolDecommission"
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	clusterPlans      []*unstructured.Unstructured
+	clusterConfigs    []*unstructured.Unstructured
+	cstorPoolClusters []*unstructured.Unstructured
+	storageSets       []*unstructured.Unstructured
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) skipIfNotMarkedForDecommission() {
+	if nodecommon.IsPoolDecommissionRequested(s.request.Watch) {
+		return
+	}
+	s.err = skip.Skip(
+		controllerName,
+		s.request.Watch,
+		s.response,
+		skip.ReasonNotMarkedForDecommission,
+		"Node is not marked for decommission",
+	)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started PoolDecommission sync: Watch %q - %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetName(),
+	)
+}
+
+func (s *syncer) registerAttachments() {
+	if s.request.Attachments == nil {
+		return
+	}
+	for _, attachment := range s.request.Attachments.List() {
+		switch attachment.GetKind() {
+		case string(types.KindCStorClusterPlan):
+			s.clusterPlans = append(s.clusterPlans, attachment)
+			// plans are added to response after reconciliation
+			continue
+		case string(types.KindCStorClusterConfig):
+			s.clusterConfigs = append(s.clusterConfigs, attachment)
+			// configs are added to response after reconciliation
+			continue
+		case string(types.KindCStorClusterStorageSet):
+			s.storageSets = append(s.storageSets, attachment)
+			// storage sets are added to response after reconciliation
+			continue
+		case string(types.KindCStorPoolCluster):
+			// cspcs are only observed & are never modified
+			s.cstorPoolClusters = append(s.cstorPoolClusters, attachment)
+		}
+		s.response.Attachments = append(s.response.Attachments, attachment)
+	}
+}
+
+func (s *syncer) reconcile() {
+	reconciler := &Reconciler{
+		ObservedNode:              s.request.Watch,
+		ObservedClusterPlans:      s.clusterPlans,
+		ObservedClusterConfigs:    s.clusterConfigs,
+		ObservedCStorPoolClusters: s.cstorPoolClusters,
+		ObservedStorageSets:       s.storageSets,
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+	if s.err != nil {
+		return
+	}
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredClusterPlans...,
+	)
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredClusterConfigs...,
+	)
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredStorageSets...,
+	)
+	if s.reconcileResponse.IsPending {
+		// check again after some time to verify if the pool
+		// got removed from this node
+		s.response.ResyncAfterSeconds = ResyncAfterSeconds
+	}
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished PoolDecommission sync: Pending %t: Watch %q - %q: %s",
+		s.reconcileResponse.IsPending,
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync PoolDecommission: Watch %q - %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.skipIfNotMarkedForDecommission,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.reconcile,
+		s.logSyncFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to decommission the cstor
+// pool running on a node. Decommission is triggered by annotating
+// the node with dao.mayadata.io/decommission-pool=true.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses Node as the watched resource.
+// SyncHookResponse has the resources that forms the desired state
+// w.r.t the watched resource.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// Reconciler enables decommissioning the cstor pool from
+// the observed node
+type Reconciler struct {
+	ObservedNode              *unstructured.Unstructured
+	ObservedClusterPlans      []*unstructured.Unstructured
+	ObservedClusterConfigs    []*unstructured.Unstructured
+	ObservedCStorPoolClusters []*unstructured.Unstructured
+	ObservedStorageSets       []*unstructured.Unstructured
+
+	// storage sets that are no more required
+	removeStorageSetUIDs map[string]bool
+
+	// config UID to decommission pending status mapping
+	configUIDToIsPending map[string]bool
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	DesiredClusterPlans   []*unstructured.Unstructured
+	DesiredClusterConfigs []*unstructured.Unstructured
+	DesiredStorageSets    []*unstructured.Unstructured
+	IsPending             bool
+}
+
+// removeNodeFromPlan returns the desired state of the given plan
+// without the observed node. It returns true if observed node was
+// found in the given plan.
+func (r *Reconciler) removeNodeFromPlan(
+	plan *unstructured.Unstructured,
+) (*unstructured.Unstructured, bool, error) {
+	nodes, _, err := unstructured.NestedSlice(plan.Object, "spec", "nodes")
+	if err != nil {
+		return nil, false, errors.Wrapf(
+			err,
+			"Failed to get spec.nodes: CStorClusterPlan %q / %q",
+			plan.GetNamespace(), plan.GetName(),
+		)
+	}
+	var isFound bool
+	var desiredNodes []interface{}
+	for _, node := range nodes {
+		nodeMap, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false, errors.Errorf(
+				"Invalid spec.nodes: Want map[string]interface{} got %T: CStorClusterPlan %q / %q",
+				node, plan.GetNamespace(), plan.GetName(),
+			)
+		}
+		if nodeMap["name"] == r.ObservedNode.GetName() &&
+			nodeMap["uid"] == string(r.ObservedNode.GetUID()) {
+			isFound = true
+			continue
+		}
+		desiredNodes = append(desiredNodes, node)
+	}
+	if !isFound {
+		// nothing to be done
+		return plan, false, nil
+	}
+	desired := plan.DeepCopy()
+	if desiredNodes == nil {
+		desiredNodes = []interface{}{}
+	}
+	err = unstructured.SetNestedSlice(desired.Object, desiredNodes, "spec", "nodes")
+	if err != nil {
+		return nil, false, errors.Wrapf(
+			err,
+			"Failed to set spec.nodes: CStorClusterPlan %q / %q",
+			plan.GetNamespace(), plan.GetName(),
+		)
+	}
+	return desired, true, nil
+}
+
+// listStorageSetsOnNode returns the storage sets of the given plan
+// that are placed on the observed node
+func (r *Reconciler) listStorageSetsOnNode(
+	plan *unstructured.Unstructured,
+) []*unstructured.Unstructured {
+	var storageSets []*unstructured.Unstructured
+	for _, storageSet := range r.ObservedStorageSets {
+		planUID, _ := unstruct.GetValueForKey(
+			storageSet.GetAnnotations(), types.AnnKeyCStorClusterPlanUID,
+		)
+		if planUID != string(plan.GetUID()) {
+			continue
+		}
+		nodeUID, _, _ := unstructured.NestedString(
+			storageSet.Object, "spec", "node", "uid",
+		)
+		if nodeUID != string(r.ObservedNode.GetUID()) {
+			continue
+		}
+		storageSets = append(storageSets, storageSet)
+	}
+	return storageSets
+}
+
+// isPoolRemoved returns true if CStorPoolCluster of the given plan
+// does not have any pool on the observed node
+func (r *Reconciler) isPoolRemoved(plan *unstructured.Unstructured) (bool, error) {
+	hostName := nodecommon.GetHostName(r.ObservedNode)
+	for _, cluster := range r.ObservedCStorPoolClusters {
+		planUID, _ := unstruct.GetValueForKey(
+			cluster.GetAnnotations(), types.AnnKeyCStorClusterPlanUID,
+		)
+		if planUID != string(plan.GetUID()) {
+			continue
+		}
+		pools, _, err := unstruct.GetSlice(cluster, "spec", "pools")
+		if err != nil {
+			return false, err
+		}
+		if len(pools) == 0 {
+			continue
+		}
+		hostNames, err := cspc.NewHelper(cluster).GetOrderedHostNames()
+		if err != nil {
+			return false, err
+		}
+		for _, name := range hostNames {
+			if name == hostName {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+// decommissionFromPlan removes the observed node from the given
+// plan & returns the desired state of this plan
+func (r *Reconciler) decommissionFromPlan(
+	plan *unstructured.Unstructured,
+) (*unstructured.Unstructured, error) {
+	desired, isNodeRemoved, err := r.removeNodeFromPlan(plan)
+	if err != nil {
+		return nil, err
+	}
+	storageSets := r.listStorageSetsOnNode(plan)
+	if !isNodeRemoved && len(storageSets) == 0 {
+		// this plan does not refer to the observed node
+		return desired, nil
+	}
+	isPoolRemoved, err := r.isPoolRemoved(plan)
+	if err != nil {
+		return nil, err
+	}
+	if isPoolRemoved {
+		// storage sets are removed only after removal of the pool
+		for _, storageSet := range storageSets {
+			r.removeStorageSetUIDs[string(storageSet.GetUID())] = true
+		}
+	}
+	configUID, _ := unstruct.GetValueForKey(
+		plan.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+	)
+	r.configUIDToIsPending[configUID] =
+		r.configUIDToIsPending[configUID] || isNodeRemoved || !isPoolRemoved
+	return desired, nil
+}
+
+// setDecommissionCondition returns the desired state of the given
+// config with decommission condition set against its status
+func (r *Reconciler) setDecommissionCondition(
+	config *unstructured.Unstructured, isPending bool,
+) (*unstructured.Unstructured, error) {
+	newCond := types.MakeCStorClusterConfigPoolDecommissionCond(
+		r.ObservedNode.GetName(), isPending,
+	)
+	status, _, err := unstructured.NestedMap(config.Object, "status")
+	if err != nil {
+		return nil, err
+	}
+	oldCond, found, err := conditions.GetCondition(
+		status, types.CStorClusterConfigPoolDecommissionCondition,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if found &&
+		oldCond["status"] == newCond["status"] &&
+		oldCond["reason"] == newCond["reason"] {
+		// no need to update the config since this condition
+		// is already set
+		return config, nil
+	}
+	desired := config.DeepCopy()
+	conds, err := conditions.MergeCondition(desired, newCond)
+	if err == nil {
+		err = unstructured.SetNestedSlice(desired.Object, conds, "status", "conditions")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"Failed to set decommission condition: CStorClusterConfig %q / %q",
+			config.GetNamespace(), config.GetName(),
+		)
+	}
+	return desired, nil
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	This logic is idempotent. Node is removed from the plans first.
+// Storage sets on this node are removed after the CStorPoolCluster
+// stops referring to this node.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedNode == nil {
+		return ReconcileResponse{}, errors.Errorf("Can't reconcile: Nil node")
+	}
+	r.removeStorageSetUIDs = map[string]bool{}
+	r.configUIDToIsPending = map[string]bool{}
+
+	var resp ReconcileResponse
+	for _, plan := range r.ObservedClusterPlans {
+		desired, err := r.decommissionFromPlan(plan)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+		resp.DesiredClusterPlans = append(resp.DesiredClusterPlans, desired)
+	}
+	for _, storageSet := range r.ObservedStorageSets {
+		if r.removeStorageSetUIDs[string(storageSet.GetUID())] {
+			glog.V(2).Infof(
+				"Will remove CStorClusterStorageSet %q / %q: Pool decommissioned from node %q",
+				storageSet.GetNamespace(), storageSet.GetName(), r.ObservedNode.GetName(),
+			)
+			continue
+		}
+		resp.DesiredStorageSets = append(resp.DesiredStorageSets, storageSet)
+	}
+	for _, config := range r.ObservedClusterConfigs {
+		isPending, found := r.configUIDToIsPending[string(config.GetUID())]
+		if !found {
+			// this config is not affected by this decommission
+			resp.DesiredClusterConfigs = append(resp.DesiredClusterConfigs, config)
+			continue
+		}
+		desired, err := r.setDecommissionCondition(config, isPending)
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+		resp.DesiredClusterConfigs = append(resp.DesiredClusterConfigs, desired)
+	}
+	for _, isPending := range r.configUIDToIsPending {
+		resp.IsPending = resp.IsPending || isPending
+	}
+	return resp, nil
+}
@@ -0,0 +1,347 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pooldecommission
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/common"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newNode(name, uid string, anns map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindNode),
+			"metadata": map[string]interface{}{
+				"name":        name,
+				"uid":         uid,
+				"annotations": anns,
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname": name,
+				},
+			},
+		},
+	}
+}
+
+func newPlan(uid string, nodes ...interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorClusterPlan),
+			"metadata": map[string]interface{}{
+				"name": "plan-" + uid,
+				"uid":  uid,
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterConfigUID: "ccc-1",
+				},
+			},
+			"spec": map[string]interface{}{
+				"nodes": nodes,
+			},
+		},
+	}
+}
+
+func newStorageSet(uid, planUID, nodeUID string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorClusterStorageSet),
+			"metadata": map[string]interface{}{
+				"name": "ss-" + uid,
+				"uid":  uid,
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterPlanUID: planUID,
+				},
+			},
+			"spec": map[string]interface{}{
+				"node": map[string]interface{}{
+					"uid": nodeUID,
+				},
+			},
+		},
+	}
+}
+
+func newCSPC(planUID string, hostNames ...string) *unstructured.Unstructured {
+	var pools []interface{}
+	for _, hostName := range hostNames {
+		pools = append(pools, map[string]interface{}{
+			"nodeSelector": map[string]interface{}{
+				"kubernetes.io/hostname": hostName,
+			},
+		})
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name": "cspc-" + planUID,
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterPlanUID: planUID,
+				},
+			},
+			"spec": map[string]interface{}{
+				"pools": pools,
+			},
+		},
+	}
+}
+
+func newConfig() *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name": "ccc",
+				"uid":  "ccc-1",
+			},
+		},
+	}
+}
+
+func getConditionStatus(obj *unstructured.Unstructured) string {
+	conds, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, cond := range conds {
+		condMap := cond.(map[string]interface{})
+		if condMap["type"] == string(types.CStorClusterConfigPoolDecommissionCondition) {
+			return condMap["status"].(string)
+		}
+	}
+	return ""
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		reconciler           *Reconciler
+		expectPlanNodes      []interface{}
+		expectStorageSetUIDs []string
+		expectCondStatus     string
+		isPending            bool
+		isErr                bool
+	}{
+		"nil node": {
+			reconciler: &Reconciler{},
+			isErr:      true,
+		},
+		"node is part of plan": {
+			reconciler: &Reconciler{
+				ObservedNode: newNode("node-1", "n1", nil),
+				ObservedClusterPlans: []*unstructured.Unstructured{
+					newPlan(
+						"p1",
+						map[string]interface{}{"name": "node-1", "uid": "n1"},
+						map[string]interface{}{"name": "node-2", "uid": "n2"},
+					),
+				},
+				ObservedClusterConfigs: []*unstructured.Unstructured{
+					newConfig(),
+				},
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					newCSPC("p1", "node-1", "node-2"),
+				},
+				ObservedStorageSets: []*unstructured.Unstructured{
+					newStorageSet("s1", "p1", "n1"),
+					newStorageSet("s2", "p1", "n2"),
+				},
+			},
+			expectPlanNodes: []interface{}{
+				map[string]interface{}{"name": "node-2", "uid": "n2"},
+			},
+			expectStorageSetUIDs: []string{"s1", "s2"},
+			expectCondStatus:     string(types.ConditionIsPresent),
+			isPending:            true,
+		},
+		"node is removed from plan && pool is yet to be removed": {
+			reconciler: &Reconciler{
+				ObservedNode: newNode("node-1", "n1", nil),
+				ObservedClusterPlans: []*unstructured.Unstructured{
+					newPlan(
+						"p1",
+						map[string]interface{}{"name": "node-2", "uid": "n2"},
+					),
+				},
+				ObservedClusterConfigs: []*unstructured.Unstructured{
+					newConfig(),
+				},
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					newCSPC("p1", "node-1", "node-2"),
+				},
+				ObservedStorageSets: []*unstructured.Unstructured{
+					newStorageSet("s1", "p1", "n1"),
+					newStorageSet("s2", "p1", "n2"),
+				},
+			},
+			expectPlanNodes: []interface{}{
+				map[string]interface{}{"name": "node-2", "uid": "n2"},
+			},
+			expectStorageSetUIDs: []string{"s1", "s2"},
+			expectCondStatus:     string(types.ConditionIsPresent),
+			isPending:            true,
+		},
+		"node is removed from plan && pool is removed": {
+			reconciler: &Reconciler{
+				ObservedNode: newNode("node-1", "n1", nil),
+				ObservedClusterPlans: []*unstructured.Unstructured{
+					newPlan(
+						"p1",
+						map[string]interface{}{"name": "node-2", "uid": "n2"},
+					),
+				},
+				ObservedClusterConfigs: []*unstructured.Unstructured{
+					newConfig(),
+				},
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					newCSPC("p1", "node-2"),
+				},
+				ObservedStorageSets: []*unstructured.Unstructured{
+					newStorageSet("s1", "p1", "n1"),
+					newStorageSet("s2", "p1", "n2"),
+				},
+			},
+			expectPlanNodes: []interface{}{
+				map[string]interface{}{"name": "node-2", "uid": "n2"},
+			},
+			expectStorageSetUIDs: []string{"s2"},
+			expectCondStatus:     string(types.ConditionIsAbsent),
+			isPending:            false,
+		},
+		"node is not referred by plan": {
+			reconciler: &Reconciler{
+				ObservedNode: newNode("node-3", "n3", nil),
+				ObservedClusterPlans: []*unstructured.Unstructured{
+					newPlan(
+						"p1",
+						map[string]interface{}{"name": "node-2", "uid": "n2"},
+					),
+				},
+				ObservedClusterConfigs: []*unstructured.Unstructured{
+					newConfig(),
+				},
+				ObservedCStorPoolClusters: []*unstructured.Unstructured{
+					newCSPC("p1", "node-2"),
+				},
+				ObservedStorageSets: []*unstructured.Unstructured{
+					newStorageSet("s2", "p1", "n2"),
+				},
+			},
+			expectPlanNodes: []interface{}{
+				map[string]interface{}{"name": "node-2", "uid": "n2"},
+			},
+			expectStorageSetUIDs: []string{"s2"},
+			expectCondStatus:     "",
+			isPending:            false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := mock.reconciler.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if got.IsPending != mock.isPending {
+				t.Fatalf("Expected pending %t got %t", mock.isPending, got.IsPending)
+			}
+			if len(got.DesiredClusterPlans) != 1 {
+				t.Fatalf("Expected 1 plan got %d", len(got.DesiredClusterPlans))
+			}
+			gotNodes, _, _ := unstructured.NestedSlice(
+				got.DesiredClusterPlans[0].Object, "spec", "nodes",
+			)
+			if diff := cmp.Diff(mock.expectPlanNodes, gotNodes); diff != "" {
+				t.Fatalf("Expected no diff in plan nodes got\n%s", diff)
+			}
+			var gotStorageSetUIDs []string
+			for _, storageSet := range got.DesiredStorageSets {
+				gotStorageSetUIDs = append(gotStorageSetUIDs, string(storageSet.GetUID()))
+			}
+			if diff := cmp.Diff(mock.expectStorageSetUIDs, gotStorageSetUIDs); diff != "" {
+				t.Fatalf("Expected no diff in storage sets got\n%s", diff)
+			}
+			if len(got.DesiredClusterConfigs) != 1 {
+				t.Fatalf("Expected 1 config got %d", len(got.DesiredClusterConfigs))
+			}
+			gotCondStatus := getConditionStatus(got.DesiredClusterConfigs[0])
+			if gotCondStatus != mock.expectCondStatus {
+				t.Fatalf(
+					"Expected condition status %q got %q",
+					mock.expectCondStatus, gotCondStatus,
+				)
+			}
+		})
+	}
+}
+
+func TestSyncSkipIfNotMarkedForDecommission(t *testing.T) {
+	var tests = map[string]struct {
+		node      *unstructured.Unstructured
+		isSkipped bool
+	}{
+		"node is not marked for decommission": {
+			node:      newNode("node-1", "n1", nil),
+			isSkipped: true,
+		},
+		"node is marked for decommission": {
+			node: newNode("node-1", "n1", map[string]interface{}{
+				types.AnnKeyNodeDecommissionPool: "true",
+			}),
+			isSkipped: false,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			request := &generic.SyncHookRequest{
+				Watch: mock.node,
+				Attachments: common.AnyUnstructRegistry(
+					map[string]map[string]*unstructured.Unstructured{
+						"dao.mayadata.io/v1alpha1/CStorClusterPlan": map[string]*unstructured.Unstructured{
+							"/plan-p1": newPlan(
+								"p1",
+								map[string]interface{}{"name": "node-1", "uid": "n1"},
+							),
+						},
+					},
+				),
+			}
+			response := &generic.SyncHookResponse{}
+			err := Sync(request, response)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if response.SkipReconcile != mock.isSkipped {
+				t.Fatalf(
+					"Expected skip reconcile %t got %t",
+					mock.isSkipped, response.SkipReconcile,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectordryrun
+
+import (
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"openebs.io/metac/controller/generic"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	"mayadata.io/cstorpoolauto/common/generation"
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	blockDevices []*unstructured.Unstructured
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started SelectorDryRun sync: Watch %q - %q / %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+	)
+}
+
+func (s *syncer) registerAttachments() {
+	if s.request.Attachments == nil {
+		return
+	}
+	for _, attachment := range s.request.Attachments.List() {
+		if attachment.GetKind() == string(types.KindBlockDevice) {
+			s.blockDevices = append(s.blockDevices, attachment)
+		}
+		// block devices are only observed & are never modified
+		s.response.Attachments = append(s.response.Attachments, attachment)
+	}
+}
+
+func (s *syncer) reconcile() {
+	reconciler := &Reconciler{
+		ObservedDryRun:       s.request.Watch,
+		ObservedBlockDevices: s.blockDevices,
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+}
+
+func (s *syncer) setStatus() {
+	var status map[string]interface{}
+	status, s.err = runtime.DefaultUnstructuredConverter.ToUnstructured(
+		&s.reconcileResponse.Status,
+	)
+	if s.err != nil {
+		s.err = errors.Wrapf(s.err, "Can't set dry run status")
+		return
+	}
+	s.response.Status = generation.SetObservedGeneration(s.request.Watch, status)
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished SelectorDryRun sync: Phase %q: MatchCount %d: Watch %q - %q / %q: %s",
+		s.reconcileResponse.Status.Phase,
+		s.reconcileResponse.Status.MatchCount,
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync SelectorDryRun: Watch %q - %q / %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.reconcile,
+		s.setStatus,
+		s.logSyncFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to report the block devices
+// that are matched by the selector of a BlockDeviceSelectorDryRun.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses BlockDeviceSelectorDryRun as the watched
+// resource. SyncHookResponse has the observed block devices as is
+// since nothing is claimed by a dry run.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// Reconciler evaluates the selector of the observed
+// BlockDeviceSelectorDryRun against the observed block devices
+//
+// NOTE:
+//	Devices are matched the same way as the local disk config of a
+// CStorClusterConfig matches these i.e. via the selector terms, the
+// exclude terms & the reserved keys. Devices used by the pools of
+// existing configs are matched like any other device.
+type Reconciler struct {
+	ObservedDryRun       *unstructured.Unstructured
+	ObservedBlockDevices []*unstructured.Unstructured
+
+	dryRun types.BlockDeviceSelectorDryRun
+	status types.BlockDeviceSelectorDryRunStatus
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	Status types.BlockDeviceSelectorDryRunStatus
+}
+
+func (r *Reconciler) init() error {
+	return unstruct.UnstructToTyped(r.ObservedDryRun, &r.dryRun)
+}
+
+func (r *Reconciler) validate() error {
+	sel := r.dryRun.Spec.BlockDeviceSelector
+	if len(sel.SelectorTerms) == 0 {
+		return errs.ValidationErrorf(
+			"Invalid BlockDeviceSelectorDryRun: No block device selector terms found",
+		)
+	}
+	err := bd.ValidateSelector(sel)
+	if err != nil {
+		return errs.ValidationErrorf(
+			"Invalid BlockDeviceSelectorDryRun: Invalid blockDeviceSelector: %s", err,
+		)
+	}
+	if r.dryRun.Spec.BlockDeviceExclude == nil {
+		return nil
+	}
+	err = bd.ValidateSelector(*r.dryRun.Spec.BlockDeviceExclude)
+	if err != nil {
+		return errs.ValidationErrorf(
+			"Invalid BlockDeviceSelectorDryRun: Invalid blockDeviceExclude: %s", err,
+		)
+	}
+	return nil
+}
+
+// getExclude returns the exclude terms if any
+func (r *Reconciler) getExclude() types.BlockDeviceSelector {
+	if r.dryRun.Spec.BlockDeviceExclude == nil {
+		return types.BlockDeviceSelector{}
+	}
+	return *r.dryRun.Spec.BlockDeviceExclude
+}
+
+// setMatches sets the matched block devices grouped by the hostname
+// of their nodes against the status
+func (r *Reconciler) setMatches(matches []*unstructured.Unstructured) {
+	devicesByHostName := map[string][]types.BlockDeviceSelectorDryRunDevice{}
+	for _, device := range matches {
+		// devices without host name are grouped under empty host name
+		// & devices without capacity are reported with zero capacity
+		hostName, _ := bd.GetHostName(*device)
+		capacity, _ := bd.GetCapacity(*device)
+		path, _, _ := unstructured.NestedString(device.Object, "spec", "path")
+		devicesByHostName[hostName] = append(
+			devicesByHostName[hostName],
+			types.BlockDeviceSelectorDryRunDevice{
+				Name:     device.GetName(),
+				Path:     path,
+				Capacity: capacity,
+			},
+		)
+	}
+	// sort to keep the status idempotent across reconciliations
+	var hostNames []string
+	for hostName := range devicesByHostName {
+		hostNames = append(hostNames, hostName)
+	}
+	sort.Strings(hostNames)
+	for _, hostName := range hostNames {
+		devices := devicesByHostName[hostName]
+		sort.Slice(devices, func(i, j int) bool {
+			return devices[i].Name < devices[j].Name
+		})
+		r.status.Nodes = append(r.status.Nodes, types.BlockDeviceSelectorDryRunNode{
+			HostName:     hostName,
+			BlockDevices: devices,
+		})
+	}
+	r.status.MatchCount = len(matches)
+}
+
+// selectBlockDevices evaluates the selector against the observed
+// block devices & sets the result against the status
+func (r *Reconciler) selectBlockDevices() error {
+	matches, err := bd.SelectorCheck{
+		Selector:     r.dryRun.Spec.BlockDeviceSelector,
+		Exclude:      r.getExclude(),
+		ReservedKeys: bd.ReservedKeys,
+		Devices:      r.ObservedBlockDevices,
+	}.Apply()
+	if err != nil {
+		return err
+	}
+	r.status.Phase = types.BlockDeviceSelectorDryRunStatusPhaseOnline
+	if len(matches) != 0 {
+		r.setMatches(matches)
+		return nil
+	}
+	// explain why nothing matched
+	r.status.BlockDeviceSelectionReport, err = bd.SelectionDiagnosis{
+		Selector:     r.dryRun.Spec.BlockDeviceSelector,
+		Exclude:      r.getExclude(),
+		ReservedKeys: bd.ReservedKeys,
+		Devices:      r.ObservedBlockDevices,
+	}.Report()
+	return err
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	An invalid selector is reported against the status with phase
+// Error instead of being returned as an error. This lets the user
+// fix the selector by looking at the dry run.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedDryRun == nil {
+		return ReconcileResponse{},
+			errors.Errorf("Can't reconcile: Nil BlockDeviceSelectorDryRun")
+	}
+	err := r.init()
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	err = r.validate()
+	if err != nil {
+		return ReconcileResponse{
+			Status: types.BlockDeviceSelectorDryRunStatus{
+				Phase:  types.BlockDeviceSelectorDryRunStatusPhaseError,
+				Reason: err.Error(),
+			},
+		}, nil
+	}
+	err = r.selectBlockDevices()
+	if err != nil {
+		return ReconcileResponse{}, err
+	}
+	return ReconcileResponse{Status: r.status}, nil
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectordryrun
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestDryRun(spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "dao.mayadata.io/v1alpha1",
+			"kind":       string(types.KindBlockDeviceSelectorDryRun),
+			"metadata": map[string]interface{}{
+				"name":      "my-dry-run",
+				"namespace": "openebs",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func newTestDevice(
+	name, hostName, path string, labels map[string]interface{},
+) *unstructured.Unstructured {
+	if labels == nil {
+		labels = map[string]interface{}{}
+	}
+	labels["kubernetes.io/hostname"] = hostName
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+				"labels":    labels,
+			},
+			"spec": map[string]interface{}{
+				"path": path,
+				"capacity": map[string]interface{}{
+					"storage": int64(1073741824),
+				},
+			},
+		},
+	}
+}
+
+func newTestSelector(key, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"selectorTerms": []interface{}{
+			map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					key: value,
+				},
+			},
+		},
+	}
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	var devices = []*unstructured.Unstructured{
+		newTestDevice("bd3", "node-2", "/dev/sdb", map[string]interface{}{"tier": "hot"}),
+		newTestDevice("bd2", "node-1", "/dev/sdc", map[string]interface{}{"tier": "hot"}),
+		newTestDevice("bd1", "node-1", "/dev/sdb", map[string]interface{}{"tier": "hot"}),
+		newTestDevice("bd4", "node-2", "/dev/sdc", map[string]interface{}{"tier": "cold"}),
+		newTestDevice("bd5", "node-3", "/dev/sdb", map[string]interface{}{
+			"tier":            "hot",
+			"ndm.io/reserved": "true",
+		}),
+	}
+	var gi = resource.MustParse("1Gi")
+	var tests = map[string]struct {
+		dryRun       *unstructured.Unstructured
+		devices      []*unstructured.Unstructured
+		expectStatus types.BlockDeviceSelectorDryRunStatus
+		isErr        bool
+	}{
+		"nil dry run": {
+			isErr: true,
+		},
+		"no selector terms": {
+			dryRun: newTestDryRun(map[string]interface{}{}),
+			expectStatus: types.BlockDeviceSelectorDryRunStatus{
+				Phase:  types.BlockDeviceSelectorDryRunStatusPhaseError,
+				Reason: "Invalid BlockDeviceSelectorDryRun: No block device selector terms found",
+			},
+		},
+		"invalid selector terms": {
+			dryRun: newTestDryRun(map[string]interface{}{
+				"blockDeviceSelector": map[string]interface{}{
+					"selectorTerms": []interface{}{
+						map[string]interface{}{
+							"matchNumericRange": []interface{}{
+								map[string]interface{}{
+									"key": "spec.capacity.storage",
+									"min": "10Gi",
+									"max": "1Gi",
+								},
+							},
+						},
+					},
+				},
+			}),
+			devices: devices,
+			expectStatus: types.BlockDeviceSelectorDryRunStatus{
+				Phase: types.BlockDeviceSelectorDryRunStatusPhaseError,
+			},
+		},
+		"matched devices are grouped by host name": {
+			dryRun: newTestDryRun(map[string]interface{}{
+				"blockDeviceSelector": newTestSelector("tier", "hot"),
+			}),
+			devices: devices,
+			expectStatus: types.BlockDeviceSelectorDryRunStatus{
+				Phase:      types.BlockDeviceSelectorDryRunStatusPhaseOnline,
+				MatchCount: 3,
+				Nodes: []types.BlockDeviceSelectorDryRunNode{
+					{
+						HostName: "node-1",
+						BlockDevices: []types.BlockDeviceSelectorDryRunDevice{
+							{Name: "bd1", Path: "/dev/sdb", Capacity: gi},
+							{Name: "bd2", Path: "/dev/sdc", Capacity: gi},
+						},
+					},
+					{
+						HostName: "node-2",
+						BlockDevices: []types.BlockDeviceSelectorDryRunDevice{
+							{Name: "bd3", Path: "/dev/sdb", Capacity: gi},
+						},
+					},
+				},
+			},
+		},
+		"excluded devices are not matched": {
+			dryRun: newTestDryRun(map[string]interface{}{
+				"blockDeviceSelector": newTestSelector("tier", "hot"),
+				"blockDeviceExclude":  newTestSelector("kubernetes.io/hostname", "node-1"),
+			}),
+			devices: devices,
+			expectStatus: types.BlockDeviceSelectorDryRunStatus{
+				Phase:      types.BlockDeviceSelectorDryRunStatusPhaseOnline,
+				MatchCount: 1,
+				Nodes: []types.BlockDeviceSelectorDryRunNode{
+					{
+						HostName: "node-2",
+						BlockDevices: []types.BlockDeviceSelectorDryRunDevice{
+							{Name: "bd3", Path: "/dev/sdb", Capacity: gi},
+						},
+					},
+				},
+			},
+		},
+		"no devices are matched": {
+			dryRun: newTestDryRun(map[string]interface{}{
+				"blockDeviceSelector": newTestSelector("tier", "warm"),
+			}),
+			devices: devices,
+			expectStatus: types.BlockDeviceSelectorDryRunStatus{
+				Phase: types.BlockDeviceSelectorDryRunStatusPhaseOnline,
+				BlockDeviceSelectionReport: []string{
+					"term 1 rejected 5 of 5 devices: 5 by label tier",
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ObservedDryRun:       mock.dryRun,
+				ObservedBlockDevices: mock.devices,
+			}
+			got, err := r.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if mock.expectStatus.Phase == types.BlockDeviceSelectorDryRunStatusPhaseError {
+				if got.Status.Phase != mock.expectStatus.Phase || got.Status.Reason == "" {
+					t.Fatalf("Expected phase Error with reason got %+v", got.Status)
+				}
+				if mock.expectStatus.Reason != "" && got.Status.Reason != mock.expectStatus.Reason {
+					t.Fatalf(
+						"Expected reason %q got %q",
+						mock.expectStatus.Reason, got.Status.Reason,
+					)
+				}
+				return
+			}
+			if diff := cmp.Diff(mock.expectStatus, got.Status); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,455 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclass
+
+import (
+	"strconv"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/naming"
+	"mayadata.io/cstorpoolauto/common/pause"
+	"mayadata.io/cstorpoolauto/common/skip"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// controllerName is reported against the skipped reconciliations
+// of this controller
+const controllerName = "StorageClass"
+
+// CSIProvisioner is the provisioner of cStor CSI volumes
+const CSIProvisioner string = "cstor.csi.openebs.io"
+
+const (
+	// ParamKeyCASType is the StorageClass parameter that refers to
+	// the storage engine
+	ParamKeyCASType string = "cas-type"
+
+	// ParamKeyCStorPoolCluster is the StorageClass parameter that
+	// refers to the CStorPoolCluster of the volume replicas
+	ParamKeyCStorPoolCluster string = "cstorPoolCluster"
+
+	// ParamKeyReplicaCount is the StorageClass parameter that refers
+	// to the number of replicas of a volume
+	ParamKeyReplicaCount string = "replicaCount"
+)
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	cstorPoolClusters []*unstructured.Unstructured
+	storageClasses    []*unstructured.Unstructured
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) skipIfPaused() {
+	_, s.err = pause.Skip(
+		controllerName, s.request.Watch, s.request.Watch, s.response,
+	)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started StorageClass sync: Watch %q - %q / %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+	)
+}
+
+func (s *syncer) registerAttachments() {
+	if s.request.Attachments == nil {
+		return
+	}
+	for _, attachment := range s.request.Attachments.List() {
+		uid, _ := unstruct.GetValueForKey(
+			attachment.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+		)
+		isOwned := string(s.request.Watch.GetUID()) == uid
+		switch attachment.GetKind() {
+		case string(types.KindCStorPoolCluster):
+			// cspcs are only observed & are never modified
+			if isOwned {
+				s.cstorPoolClusters = append(s.cstorPoolClusters, attachment)
+			}
+		case "StorageClass":
+			if isOwned {
+				// storage class is added to response after
+				// reconciliation
+				s.storageClasses = append(s.storageClasses, attachment)
+				continue
+			}
+		}
+		s.response.Attachments = append(s.response.Attachments, attachment)
+	}
+}
+
+func (s *syncer) skipIfNotEnabled() {
+	create, _, _ := unstructured.NestedBool(
+		s.request.Watch.Object, "spec", "storageClass", "create",
+	)
+	if create || len(s.storageClasses) != 0 {
+		// storage classes are deleted if these are not desired
+		return
+	}
+	s.err = skip.Skip(
+		controllerName,
+		s.request.Watch,
+		s.response,
+		skip.ReasonNotEnabled,
+		"StorageClass is not enabled",
+	)
+}
+
+// clearSkipCondition removes the condition that was set when this
+// controller skipped an earlier sync
+func (s *syncer) clearSkipCondition() {
+	s.err = skip.Clear(controllerName, s.request.Watch, s.response)
+}
+
+func (s *syncer) reconcile() {
+	reconciler := &Reconciler{
+		ObservedClusterConfig:     s.request.Watch,
+		ObservedCStorPoolClusters: s.cstorPoolClusters,
+		ObservedStorageClasses:    s.storageClasses,
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+	if s.err != nil {
+		return
+	}
+	// storage classes that are not desired get deleted
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredStorageClasses...,
+	)
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished StorageClass sync: Replica count %d: Pool count %d: Watch %q - %q / %q: %s",
+		s.reconcileResponse.ReplicaCount,
+		s.reconcileResponse.PoolCount,
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync StorageClass: Watch %q - %q / %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.skipIfPaused,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.skipIfNotEnabled,
+		s.reconcile,
+		s.clearSkipCondition,
+		s.logSyncFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to create a cStor CSI
+// StorageClass for the CStorPoolCluster of a CStorClusterConfig.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses CStorClusterConfig as the watched resource.
+// SyncHookResponse has the resources that forms the desired state
+// w.r.t the watched resource.
+//
+// NOTE:
+//	StorageClass created by this controller gets deleted when
+// spec.storageClass.create is unset.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// Reconciler evaluates the desired cStor CSI StorageClass of the
+// observed CStorClusterConfig
+//
+// NOTE:
+//	Parameters of a StorageClass can't be updated. StorageClass is
+// hence re-created whenever its replica count changes with the pool
+// count. Volumes that were already provisioned are not affected.
+type Reconciler struct {
+	ObservedClusterConfig *unstructured.Unstructured
+
+	// CStorPoolClusters & StorageClasses that belong to the
+	// observed config
+	ObservedCStorPoolClusters []*unstructured.Unstructured
+	ObservedStorageClasses    []*unstructured.Unstructured
+
+	config       types.CStorClusterConfig
+	storageClass types.StorageClass
+	cspc         *unstructured.Unstructured
+	poolCount    int64
+	replicaCount int64
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	DesiredStorageClasses []*unstructured.Unstructured
+
+	// ReplicaCount is the replica count set against the desired
+	// StorageClass
+	ReplicaCount int64
+
+	// PoolCount is the number of pools of the CStorPoolCluster
+	PoolCount int64
+}
+
+func (r *Reconciler) init() error {
+	err := unstruct.UnstructToTyped(r.ObservedClusterConfig, &r.config)
+	if err != nil {
+		return err
+	}
+	if r.config.Spec.StorageClass != nil {
+		r.storageClass = *r.config.Spec.StorageClass
+	}
+	if r.storageClass.Name == "" {
+		r.storageClass.Name = r.getDefaultName()
+	}
+	return nil
+}
+
+// getDefaultName returns the name of the observed storage class if
+// available. Otherwise the name is derived from the naming options
+// of the observed config.
+//
+// NOTE:
+//	Observed storage class is not renamed if the naming options
+// change since this would delete & re-create it
+func (r *Reconciler) getDefaultName() string {
+	if len(r.ObservedStorageClasses) == 1 &&
+		r.ObservedStorageClasses[0].GetName() != "" {
+		return r.ObservedStorageClasses[0].GetName()
+	}
+	return naming.Name(
+		r.ObservedClusterConfig.GetNamespace(),
+		r.ObservedClusterConfig.GetName(),
+		r.config.Spec.Naming,
+	)
+}
+
+func (r *Reconciler) isEnabled() bool {
+	return r.storageClass.Create
+}
+
+func (r *Reconciler) validate() error {
+	if !r.isEnabled() {
+		return nil
+	}
+	if r.config.Spec.PoolConfig.PerZoneCSPC {
+		return errs.ValidationErrorf(
+			"Can't create storage class: StorageClass is not supported with perZoneCSPC",
+		)
+	}
+	err := naming.Validate(r.config.Spec.Naming)
+	if err != nil {
+		return err
+	}
+	if r.storageClass.ReplicaCount < 0 {
+		return errs.ValidationErrorf(
+			"Invalid replicaCount %d: Want positive value",
+			r.storageClass.ReplicaCount,
+		)
+	}
+	return nil
+}
+
+// selectCStorPoolCluster selects the CStorPoolCluster that gets
+// referred to by the StorageClass
+func (r *Reconciler) selectCStorPoolCluster() error {
+	if len(r.ObservedCStorPoolClusters) > 1 {
+		return errs.ValidationErrorf(
+			"Can't create storage class: Want 1 CStorPoolCluster got %d",
+			len(r.ObservedCStorPoolClusters),
+		)
+	}
+	if len(r.ObservedCStorPoolClusters) == 0 {
+		return nil
+	}
+	r.cspc = r.ObservedCStorPoolClusters[0]
+	pools, _, err := unstructured.NestedSlice(r.cspc.Object, "spec", "pools")
+	if err != nil {
+		return errors.Wrapf(
+			err,
+			"Can't create storage class: Invalid CStorPoolCluster %q / %q",
+			r.cspc.GetNamespace(), r.cspc.GetName(),
+		)
+	}
+	r.poolCount = int64(len(pools))
+	return nil
+}
+
+// setReplicaCount sets the replica count of volumes
+//
+// NOTE:
+//	Replica count is capped at the pool count since every replica
+// of a volume is placed on a different pool
+func (r *Reconciler) setReplicaCount() {
+	r.replicaCount = r.storageClass.ReplicaCount
+	if r.replicaCount == 0 {
+		r.replicaCount = types.DefaultStorageClassReplicaCount
+	}
+	if r.poolCount > 0 && r.replicaCount > r.poolCount {
+		glog.V(3).Infof(
+			"Will cap replica count %d at pool count %d: CStorClusterConfig %q / %q",
+			r.replicaCount, r.poolCount,
+			r.ObservedClusterConfig.GetNamespace(), r.ObservedClusterConfig.GetName(),
+		)
+		r.replicaCount = r.poolCount
+	}
+}
+
+// getDesiredStorageClass returns the StorageClass that refers to
+// the selected CStorPoolCluster
+func (r *Reconciler) getDesiredStorageClass() *unstructured.Unstructured {
+	parameters := map[string]interface{}{}
+	for key, value := range r.storageClass.Parameters {
+		parameters[key] = value
+	}
+	// parameters owned by this operator override the configured ones
+	parameters[ParamKeyCASType] = "cstor"
+	parameters[ParamKeyCStorPoolCluster] = r.cspc.GetName()
+	parameters[ParamKeyReplicaCount] = strconv.FormatInt(r.replicaCount, 10)
+
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion":           "storage.k8s.io/v1",
+			"kind":                 "StorageClass",
+			"provisioner":          CSIProvisioner,
+			"allowVolumeExpansion": true,
+			"parameters":           parameters,
+		},
+	}
+	desired.SetName(r.storageClass.Name)
+	desired.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: string(r.ObservedClusterConfig.GetUID()),
+	})
+	return desired
+}
+
+// getDesiredStorageClasses returns the StorageClasses that should
+// exist for the observed config
+func (r *Reconciler) getDesiredStorageClasses() []*unstructured.Unstructured {
+	if !r.isEnabled() {
+		// observed storage classes get deleted
+		return nil
+	}
+	if r.cspc == nil {
+		// observed storage classes are retained till the
+		// CStorPoolCluster is observed again
+		return r.ObservedStorageClasses
+	}
+	return []*unstructured.Unstructured{r.getDesiredStorageClass()}
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	This logic is idempotent. StorageClass is created only after
+// the CStorPoolCluster of this config is observed.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedClusterConfig == nil {
+		return ReconcileResponse{},
+			errors.Errorf("Can't reconcile: Nil CStorClusterConfig")
+	}
+	fns := []func() error{
+		r.init,
+		r.validate,
+		r.selectCStorPoolCluster,
+	}
+	for _, fn := range fns {
+		err := fn()
+		if err != nil {
+			return ReconcileResponse{}, err
+		}
+	}
+	r.setReplicaCount()
+	return ReconcileResponse{
+		DesiredStorageClasses: r.getDesiredStorageClasses(),
+		ReplicaCount:          r.replicaCount,
+		PoolCount:             r.poolCount,
+	}, nil
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storageclass
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestConfig(storageClass map[string]interface{}) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"minPoolCount": int64(2),
+		"maxPoolCount": int64(4),
+	}
+	if storageClass != nil {
+		spec["storageClass"] = storageClass
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "dao.mayadata.io/v1alpha1",
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"uid":       "config-101",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func newTestCSPC(poolCount int) *unstructured.Unstructured {
+	var pools []interface{}
+	for i := 0; i < poolCount; i++ {
+		pools = append(pools, map[string]interface{}{})
+	}
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindCStorPoolCluster),
+			"metadata": map[string]interface{}{
+				"name":      "my-cspc",
+				"namespace": "openebs",
+			},
+			"spec": map[string]interface{}{
+				"pools": pools,
+			},
+		},
+	}
+}
+
+func newTestStorageClass(name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "storage.k8s.io/v1",
+			"kind":       "StorageClass",
+		},
+	}
+	obj.SetName(name)
+	return obj
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		storageClass       map[string]interface{}
+		perZone            bool
+		cspcs              []*unstructured.Unstructured
+		storageClasses     []*unstructured.Unstructured
+		expectNames        []string
+		expectParameters   map[string]interface{}
+		expectReplicaCount int64
+		isErr              bool
+	}{
+		"per zone cspc": {
+			storageClass: map[string]interface{}{
+				"create": true,
+			},
+			perZone: true,
+			isErr:   true,
+		},
+		"invalid replica count": {
+			storageClass: map[string]interface{}{
+				"create":       true,
+				"replicaCount": int64(-1),
+			},
+			isErr: true,
+		},
+		"more than one cspc": {
+			storageClass: map[string]interface{}{
+				"create": true,
+			},
+			cspcs: []*unstructured.Unstructured{newTestCSPC(3), newTestCSPC(3)},
+			isErr: true,
+		},
+		"not enabled": {
+			storageClasses: []*unstructured.Unstructured{
+				newTestStorageClass("my-config"),
+			},
+			cspcs:              []*unstructured.Unstructured{newTestCSPC(3)},
+			expectReplicaCount: 3,
+		},
+		"cspc is not observed": {
+			storageClass: map[string]interface{}{
+				"create": true,
+			},
+			storageClasses: []*unstructured.Unstructured{
+				newTestStorageClass("my-config"),
+			},
+			expectNames:        []string{"my-config"},
+			expectReplicaCount: 3,
+		},
+		"default replica count": {
+			storageClass: map[string]interface{}{
+				"create": true,
+			},
+			cspcs:       []*unstructured.Unstructured{newTestCSPC(4)},
+			expectNames: []string{"my-config"},
+			expectParameters: map[string]interface{}{
+				ParamKeyCASType:          "cstor",
+				ParamKeyCStorPoolCluster: "my-cspc",
+				ParamKeyReplicaCount:     "3",
+			},
+			expectReplicaCount: 3,
+		},
+		"replica count is capped at pool count": {
+			storageClass: map[string]interface{}{
+				"create":       true,
+				"name":         "cstor-mirror",
+				"replicaCount": int64(3),
+				"parameters": map[string]interface{}{
+					"fsType":                 "xfs",
+					ParamKeyCStorPoolCluster: "other-cspc",
+				},
+			},
+			cspcs:       []*unstructured.Unstructured{newTestCSPC(2)},
+			expectNames: []string{"cstor-mirror"},
+			expectParameters: map[string]interface{}{
+				"fsType":                 "xfs",
+				ParamKeyCASType:          "cstor",
+				ParamKeyCStorPoolCluster: "my-cspc",
+				ParamKeyReplicaCount:     "2",
+			},
+			expectReplicaCount: 2,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			config := newTestConfig(mock.storageClass)
+			if mock.perZone {
+				err := unstructured.SetNestedField(
+					config.Object, true, "spec", "poolConfig", "perZoneCSPC",
+				)
+				if err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+			}
+			r := &Reconciler{
+				ObservedClusterConfig:     config,
+				ObservedCStorPoolClusters: mock.cspcs,
+				ObservedStorageClasses:    mock.storageClasses,
+			}
+			got, err := r.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if got.ReplicaCount != mock.expectReplicaCount {
+				t.Fatalf(
+					"Expected replica count %d got %d",
+					mock.expectReplicaCount, got.ReplicaCount,
+				)
+			}
+			var gotNames []string
+			for _, sc := range got.DesiredStorageClasses {
+				gotNames = append(gotNames, sc.GetName())
+			}
+			if diff := cmp.Diff(mock.expectNames, gotNames); diff != "" {
+				t.Fatalf("Expected no diff in names got\n%s", diff)
+			}
+			if mock.expectParameters == nil {
+				return
+			}
+			sc := got.DesiredStorageClasses[0]
+			gotParameters, _, _ := unstructured.NestedMap(sc.Object, "parameters")
+			if diff := cmp.Diff(mock.expectParameters, gotParameters); diff != "" {
+				t.Fatalf("Expected no diff in parameters got\n%s", diff)
+			}
+			if sc.GetAnnotations()[types.AnnKeyCStorClusterConfigUID] != "config-101" {
+				t.Fatalf(
+					"Expected config uid annotation got %v", sc.GetAnnotations(),
+				)
+			}
+			provisioner, _, _ := unstructured.NestedString(sc.Object, "provisioner")
+			if provisioner != CSIProvisioner {
+				t.Fatalf("Expected provisioner %q got %q", CSIProvisioner, provisioner)
+			}
+		})
+	}
+}
@@ -0,0 +1,403 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package supportbundle
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	metaccommon "mayadata.io/cstorpoolauto/common/metac"
+	"mayadata.io/cstorpoolauto/common/skip"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/pkg/supportbundle"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/unstruct"
+)
+
+// BundleFileName is the key of the ConfigMap's binaryData that
+// holds the gzipped tarball of a support bundle
+const BundleFileName string = "support-bundle.tar.gz"
+
+// controllerName is reported against the skipped reconciliations
+// of support bundle
+const controllerName = "SupportBundle"
+
+// Name returns the name of the ConfigMap that holds the support
+// bundle of the given config
+func Name(configName string) string {
+	return configName + "-support-bundle"
+}
+
+// IsBundleOf returns true if the given object holds the support
+// bundle of the given config
+func IsBundleOf(obj, config *unstructured.Unstructured) bool {
+	if obj == nil || config == nil || obj.GetKind() != "ConfigMap" {
+		return false
+	}
+	annotations := obj.GetAnnotations()
+	if annotations[types.AnnKeyCStorClusterConfigUID] != string(config.GetUID()) {
+		return false
+	}
+	_, found := annotations[types.AnnKeySupportBundleToken]
+	return found
+}
+
+type syncer struct {
+	request  *generic.SyncHookRequest
+	response *generic.SyncHookResponse
+
+	nodes             []*unstructured.Unstructured
+	blockDevices      []*unstructured.Unstructured
+	clusterPlans      []*unstructured.Unstructured
+	storageSets       []*unstructured.Unstructured
+	cstorPoolClusters []*unstructured.Unstructured
+	bundles           []*unstructured.Unstructured
+
+	reconcileResponse ReconcileResponse
+	fatal             error
+	err               error
+}
+
+func (s *syncer) validateArgs() {
+	// validation failure of request &/ response is a fatal error
+	s.fatal = metaccommon.ValidateGenericControllerArgs(s.request, s.response)
+}
+
+func (s *syncer) skipIfNotRequested() {
+	token, _ := unstruct.GetValueForKey(
+		s.request.Watch.GetAnnotations(),
+		types.AnnKeyCStorClusterConfigCollectSupportBundle,
+	)
+	if token != "" {
+		return
+	}
+	s.err = skip.Skip(
+		controllerName,
+		s.request.Watch,
+		s.response,
+		skip.ReasonNotEnabled,
+		"Support bundle is not requested",
+	)
+}
+
+func (s *syncer) logSyncStart() {
+	glog.V(3).Infof(
+		"Started SupportBundle sync: Watch %q - %q / %q",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+	)
+}
+
+// isOwnedByWatch returns true if the given object is annotated
+// with the UID of the watch
+func (s *syncer) isOwnedByWatch(obj *unstructured.Unstructured) bool {
+	uid, _ := unstruct.GetValueForKey(
+		obj.GetAnnotations(), types.AnnKeyCStorClusterConfigUID,
+	)
+	return uid == string(s.request.Watch.GetUID())
+}
+
+func (s *syncer) registerAttachments() {
+	if s.request.Attachments == nil {
+		return
+	}
+	// attachments are only observed & are never modified
+	for _, attachment := range s.request.Attachments.List() {
+		switch attachment.GetKind() {
+		case string(types.KindNode):
+			s.nodes = append(s.nodes, attachment)
+		case string(types.KindBlockDevice):
+			s.blockDevices = append(s.blockDevices, attachment)
+		case string(types.KindCStorClusterPlan):
+			if s.isOwnedByWatch(attachment) {
+				s.clusterPlans = append(s.clusterPlans, attachment)
+			}
+		case string(types.KindCStorClusterStorageSet):
+			if s.isOwnedByWatch(attachment) {
+				s.storageSets = append(s.storageSets, attachment)
+			}
+		case string(types.KindCStorPoolCluster):
+			if s.isOwnedByWatch(attachment) {
+				s.cstorPoolClusters = append(s.cstorPoolClusters, attachment)
+			}
+		case "ConfigMap":
+			if IsBundleOf(attachment, s.request.Watch) {
+				// bundle is added to response after reconciliation
+				s.bundles = append(s.bundles, attachment)
+				continue
+			}
+		}
+		s.response.Attachments = append(s.response.Attachments, attachment)
+	}
+}
+
+func (s *syncer) reconcile() {
+	reconciler := &Reconciler{
+		ObservedClusterConfig:     s.request.Watch,
+		ObservedNodes:             s.nodes,
+		ObservedBlockDevices:      s.blockDevices,
+		ObservedClusterPlans:      s.clusterPlans,
+		ObservedStorageSets:       s.storageSets,
+		ObservedCStorPoolClusters: s.cstorPoolClusters,
+		ObservedBundles:           s.bundles,
+		Decisions:                 supportbundle.GetDecisions(),
+	}
+	s.reconcileResponse, s.err = reconciler.Reconcile()
+	if s.err != nil {
+		return
+	}
+	s.response.Attachments = append(
+		s.response.Attachments, s.reconcileResponse.DesiredBundle,
+	)
+}
+
+func (s *syncer) logSyncFinish() {
+	glog.V(2).Infof(
+		"Finished SupportBundle sync: Collected %t: Watch %q - %q / %q: %s",
+		s.reconcileResponse.IsCollected,
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		metaccommon.GetDetailsFromResponse(s.response),
+	)
+}
+
+// clearSkipCondition removes the condition that was set when this
+// controller skipped an earlier sync
+func (s *syncer) clearSkipCondition() {
+	s.err = skip.Clear(controllerName, s.request.Watch, s.response)
+}
+
+// handleError logs the error if any
+func (s *syncer) handleError() {
+	if s.err == nil {
+		// nothing to do if there was no error
+		return
+	}
+	// log this error with context
+	glog.Errorf(
+		"Failed to sync SupportBundle: Watch %q - %q / %q: %+v",
+		s.request.Watch.GetKind(),
+		s.request.Watch.GetNamespace(),
+		s.request.Watch.GetName(),
+		s.err,
+	)
+	// stop further reconciliation at metac since there was an error
+	s.response.SkipReconcile = true
+	// retry the errors that are expected to go away on their own
+	if errs.IsRetryable(s.err) {
+		s.response.ResyncAfterSeconds = errs.ResyncAfterSeconds(s.err)
+	}
+}
+
+func (s *syncer) sync() error {
+	fns := []func(){
+		s.validateArgs,
+		s.skipIfNotRequested,
+		s.logSyncStart,
+		s.registerAttachments,
+		s.reconcile,
+		s.clearSkipCondition,
+		s.logSyncFinish,
+	}
+	for _, fn := range fns {
+		fn()
+		// post operation checks
+		if s.fatal != nil {
+			return s.fatal
+		}
+		if s.err != nil {
+			// this logs the error thus avoiding panic in the
+			// controller
+			s.handleError()
+		}
+		if s.response.SkipReconcile {
+			return nil
+		}
+	}
+	return nil
+}
+
+// Sync implements the idempotent logic to collect a support bundle
+// of a CStorClusterConfig. Collection is triggered by annotating the
+// config with dao.mayadata.io/collect-support-bundle=<token>.
+//
+// NOTE:
+// 	SyncHookRequest is the payload received as part of reconcile
+// request. Similarly, SyncHookResponse is the payload sent as a
+// response as part of reconcile request.
+//
+// NOTE:
+//	SyncHookRequest uses CStorClusterConfig as the watched resource.
+// SyncHookResponse has the resources that forms the desired state
+// w.r.t the watched resource.
+//
+// NOTE:
+//	Returning error will panic this process. We would rather want this
+// controller to run continuously. Hence, the errors are handled.
+func Sync(request *generic.SyncHookRequest, response *generic.SyncHookResponse) error {
+	s := &syncer{
+		request:  request,
+		response: response,
+	}
+	return s.sync()
+}
+
+// Reconciler collects the support bundle of the observed
+// CStorClusterConfig into a ConfigMap
+//
+// NOTE:
+//	A bundle is collected once per token. The ConfigMap of an
+// earlier token is replaced when the token changes.
+type Reconciler struct {
+	ObservedClusterConfig     *unstructured.Unstructured
+	ObservedNodes             []*unstructured.Unstructured
+	ObservedBlockDevices      []*unstructured.Unstructured
+	ObservedClusterPlans      []*unstructured.Unstructured
+	ObservedStorageSets       []*unstructured.Unstructured
+	ObservedCStorPoolClusters []*unstructured.Unstructured
+
+	// ObservedBundles are the ConfigMaps that hold the support
+	// bundle of this config
+	ObservedBundles []*unstructured.Unstructured
+
+	// Decisions are the latest sync decisions of the controllers
+	Decisions []supportbundle.Decision
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// ReconcileResponse is a helper struct used to form the response
+// of a successful reconciliation
+type ReconcileResponse struct {
+	// DesiredBundle is the ConfigMap that holds the support bundle
+	DesiredBundle *unstructured.Unstructured
+
+	// IsCollected is true if a new bundle was collected
+	IsCollected bool
+}
+
+// getDesiredBundle returns the ConfigMap that holds the given
+// archive collected for the given token
+//
+// NOTE:
+//	ConfigMap is owned by the config & hence gets garbage collected
+// when this config is deleted.
+func (r *Reconciler) getDesiredBundle(
+	token string, archive []byte, collectedAt time.Time,
+) *unstructured.Unstructured {
+	config := r.ObservedClusterConfig
+	desired := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"data": map[string]interface{}{
+				"collectedAt": collectedAt.UTC().Format(time.RFC3339),
+			},
+			"binaryData": map[string]interface{}{
+				BundleFileName: base64.StdEncoding.EncodeToString(archive),
+			},
+		},
+	}
+	desired.SetName(Name(config.GetName()))
+	desired.SetNamespace(config.GetNamespace())
+	desired.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: string(config.GetUID()),
+		types.AnnKeySupportBundleToken:    token,
+	})
+	desired.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: config.GetAPIVersion(),
+			Kind:       config.GetKind(),
+			Name:       config.GetName(),
+			UID:        config.GetUID(),
+		},
+	})
+	return desired
+}
+
+// Reconcile runs through the reconciliation logic
+//
+// NOTE:
+//	This logic is idempotent. Observed bundle is returned as is if
+// it was collected for the requested token.
+func (r *Reconciler) Reconcile() (ReconcileResponse, error) {
+	if r.ObservedClusterConfig == nil {
+		return ReconcileResponse{},
+			errors.Errorf("Can't reconcile: Nil CStorClusterConfig")
+	}
+	if r.Now == nil {
+		r.Now = time.Now
+	}
+	token, _ := unstruct.GetValueForKey(
+		r.ObservedClusterConfig.GetAnnotations(),
+		types.AnnKeyCStorClusterConfigCollectSupportBundle,
+	)
+	if token == "" {
+		return ReconcileResponse{}, errs.ValidationErrorf(
+			"Can't collect support bundle: Missing annotation %q: CStorClusterConfig %q / %q",
+			types.AnnKeyCStorClusterConfigCollectSupportBundle,
+			r.ObservedClusterConfig.GetNamespace(),
+			r.ObservedClusterConfig.GetName(),
+		)
+	}
+	for _, observed := range r.ObservedBundles {
+		if observed.GetAnnotations()[types.AnnKeySupportBundleToken] == token {
+			// bundle was already collected for this token
+			return ReconcileResponse{DesiredBundle: observed}, nil
+		}
+	}
+	collectedAt := r.Now()
+	bundle := supportbundle.Bundle{
+		ClusterConfig:     r.ObservedClusterConfig,
+		Nodes:             r.ObservedNodes,
+		BlockDevices:      r.ObservedBlockDevices,
+		ClusterPlans:      r.ObservedClusterPlans,
+		StorageSets:       r.ObservedStorageSets,
+		CStorPoolClusters: r.ObservedCStorPoolClusters,
+		Decisions:         r.Decisions,
+		CollectedAt:       collectedAt,
+	}
+	archive, err := bundle.Archive()
+	if err != nil {
+		return ReconcileResponse{}, errors.Wrapf(
+			err,
+			"CStorClusterConfig %q / %q",
+			r.ObservedClusterConfig.GetNamespace(),
+			r.ObservedClusterConfig.GetName(),
+		)
+	}
+	glog.V(2).Infof(
+		"Collected support bundle of %d bytes: Token %q: CStorClusterConfig %q / %q",
+		len(archive),
+		token,
+		r.ObservedClusterConfig.GetNamespace(),
+		r.ObservedClusterConfig.GetName(),
+	)
+	return ReconcileResponse{
+		DesiredBundle: r.getDesiredBundle(token, archive, collectedAt),
+		IsCollected:   true,
+	}, nil
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supportbundle
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+var testNow = time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+
+func newTestConfig(token string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "dao.mayadata.io/v1alpha1",
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "my-config",
+				"namespace": "openebs",
+				"uid":       "config-101",
+			},
+		},
+	}
+	if token != "" {
+		obj.SetAnnotations(map[string]string{
+			types.AnnKeyCStorClusterConfigCollectSupportBundle: token,
+		})
+	}
+	return obj
+}
+
+func newTestBundle(configUID, token string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name":      "my-config-support-bundle",
+				"namespace": "openebs",
+				"annotations": map[string]interface{}{
+					types.AnnKeyCStorClusterConfigUID: configUID,
+					types.AnnKeySupportBundleToken:    token,
+				},
+			},
+			"binaryData": map[string]interface{}{
+				BundleFileName: "old",
+			},
+		},
+	}
+}
+
+func TestIsBundleOf(t *testing.T) {
+	var tests = map[string]struct {
+		obj    *unstructured.Unstructured
+		expect bool
+	}{
+		"nil object": {},
+		"bundle of this config": {
+			obj:    newTestBundle("config-101", "t1"),
+			expect: true,
+		},
+		"bundle of another config": {
+			obj: newTestBundle("config-102", "t1"),
+		},
+		"config map without token": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": "ConfigMap",
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{
+							types.AnnKeyCStorClusterConfigUID: "config-101",
+						},
+					},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name, mock := name, mock
+		t.Run(name, func(t *testing.T) {
+			got := IsBundleOf(mock.obj, newTestConfig("t1"))
+			if got != mock.expect {
+				t.Fatalf("Expected %t got %t", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestReconcilerReconcile(t *testing.T) {
+	var tests = map[string]struct {
+		token         string
+		bundles       []*unstructured.Unstructured
+		expectCollect bool
+		isErr         bool
+	}{
+		"not requested": {
+			isErr: true,
+		},
+		"new request": {
+			token:         "t1",
+			expectCollect: true,
+		},
+		"bundle already collected for this token": {
+			token: "t1",
+			bundles: []*unstructured.Unstructured{
+				newTestBundle("config-101", "t1"),
+			},
+		},
+		"bundle collected for an older token": {
+			token: "t2",
+			bundles: []*unstructured.Unstructured{
+				newTestBundle("config-101", "t1"),
+			},
+			expectCollect: true,
+		},
+	}
+	for name, mock := range tests {
+		name, mock := name, mock
+		t.Run(name, func(t *testing.T) {
+			r := &Reconciler{
+				ObservedClusterConfig: newTestConfig(mock.token),
+				ObservedNodes: []*unstructured.Unstructured{
+					{
+						Object: map[string]interface{}{
+							"kind": string(types.KindNode),
+							"metadata": map[string]interface{}{
+								"name": "node-1",
+							},
+						},
+					},
+				},
+				ObservedBundles: mock.bundles,
+				Now:             func() time.Time { return testNow },
+			}
+			got, err := r.Reconcile()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if got.IsCollected != mock.expectCollect {
+				t.Fatalf(
+					"Expected collected %t got %t", mock.expectCollect, got.IsCollected,
+				)
+			}
+			if got.DesiredBundle == nil {
+				t.Fatalf("Expected desired bundle got nil")
+			}
+			gotToken := got.DesiredBundle.GetAnnotations()[types.AnnKeySupportBundleToken]
+			if gotToken != mock.token {
+				t.Fatalf("Expected token %q got %q", mock.token, gotToken)
+			}
+			if got.DesiredBundle.GetName() != "my-config-support-bundle" {
+				t.Fatalf(
+					"Expected name my-config-support-bundle got %q",
+					got.DesiredBundle.GetName(),
+				)
+			}
+			archive, _, _ := unstructured.NestedString(
+				got.DesiredBundle.Object, "binaryData", BundleFileName,
+			)
+			if mock.expectCollect && (archive == "" || archive == "old") {
+				t.Fatalf("Expected new archive got %q", archive)
+			}
+			if !mock.expectCollect && archive != "old" {
+				t.Fatalf("Expected observed archive got %q", archive)
+			}
+			if mock.expectCollect && len(got.DesiredBundle.GetOwnerReferences()) != 1 {
+				t.Fatalf("Expected owner reference to config")
+			}
+		})
+	}
+}
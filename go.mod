@@ -3,12 +3,20 @@ module mayadata.io/cstorpoolauto
 go 1.13
 
 require (
+	contrib.go.opencensus.io/exporter/prometheus v0.1.0
+	github.com/evanphx/json-patch v4.2.0+incompatible
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
 	github.com/google/go-cmp v0.4.0
+	github.com/google/gofuzz v1.0.0
 	github.com/pkg/errors v0.9.1
+	go.opencensus.io v0.21.0
+	k8s.io/api v0.17.3
+	k8s.io/apiextensions-apiserver v0.17.0
 	k8s.io/apimachinery v0.17.3
-	k8s.io/client-go v0.17.3 // indirect
+	k8s.io/client-go v0.17.3
 	openebs.io/metac v0.2.1
+	sigs.k8s.io/yaml v1.1.0
 )
 
 replace (
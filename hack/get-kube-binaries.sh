@@ -0,0 +1,41 @@
+#!/bin/bash
+
+set -e
+set -u
+
+# This script downloads kubectl, kube-apiserver & etcd binaries
+# that are used by integration tests and places them in hack/bin/.
+#
+# The integration test framework expects these binaries to be
+# found in the PATH.
+
+# This is the kube-apiserver version to test against.
+KUBE_VERSION="${KUBE_VERSION:-v1.16.4}"
+KUBERNETES_RELEASE_URL="${KUBERNETES_RELEASE_URL:-https://dl.k8s.io}"
+
+# This should be the etcd version downloaded by
+# kubernetes/hack/lib/etcd.sh as of the above Kubernetes version.
+ETCD_VERSION="${ETCD_VERSION:-v3.4.3}"
+
+mkdir -p hack/bin
+cd hack/bin
+
+if [[ ! -f ./kubectl ]]; then
+    wget -nv "${KUBERNETES_RELEASE_URL}/${KUBE_VERSION}/bin/linux/amd64/kubectl"
+    chmod +x kubectl
+fi
+
+if [[ ! -f ./kube-apiserver ]]; then
+    wget -nv "${KUBERNETES_RELEASE_URL}/${KUBE_VERSION}/bin/linux/amd64/kube-apiserver"
+    chmod +x kube-apiserver
+fi
+
+if [[ ! -f ./etcd ]]; then
+    basename="etcd-${ETCD_VERSION}-linux-amd64"
+    filename="${basename}.tar.gz"
+    url="https://github.com/coreos/etcd/releases/download/${ETCD_VERSION}/${filename}"
+    wget -nv "${url}"
+    tar -zxf "${filename}"
+    mv "${basename}/etcd" etcd
+    rm -rf "${basename}" "${filename}"
+fi
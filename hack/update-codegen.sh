@@ -0,0 +1,93 @@
+#!/bin/bash
+
+set -e
+set -u
+set -o pipefail
+
+# This script generates the typed clientset, listers & informers of
+# dao.mayadata.io custom resources and places them in pkg/client/.
+#
+# Generators of k8s.io/code-generator expect the custom resources to
+# be present at a <group>/<version> path. Hence, types package is
+# copied to a temporary apis/dao/v1alpha1 package before generation.
+# Generated code is then made to import the types package.
+
+# This should match the version of k8s.io/client-go used in go.mod
+CODE_GENERATOR_VERSION="${CODE_GENERATOR_VERSION:-v0.17.3}"
+
+MODULE="mayadata.io/cstorpoolauto"
+ROOT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)"
+BIN_DIR="${ROOT_DIR}/hack/bin"
+HEADER_FILE="${ROOT_DIR}/hack/boilerplate.go.txt"
+
+APIS_PKG="${MODULE}/apis/dao/v1alpha1"
+APIS_DIR="${ROOT_DIR}/apis"
+CLIENT_PKG="${MODULE}/pkg/client"
+
+OUTPUT_DIR="$(mktemp -d)"
+trap 'rm -rf "${APIS_DIR}" "${OUTPUT_DIR}"' EXIT
+
+# code-generator has replace directives in its go.mod. Hence, its
+# binaries are built from a temporary module.
+if [[ ! -f "${BIN_DIR}/client-gen" ]]; then
+    TOOLS_DIR="$(mktemp -d)"
+    cat > "${TOOLS_DIR}/go.mod" <<EOF
+module tools
+
+go 1.13
+
+require k8s.io/code-generator ${CODE_GENERATOR_VERSION}
+
+replace (
+	golang.org/x/sys => golang.org/x/sys v0.0.0-20190813064441-fde4db37ae7a
+	golang.org/x/tools => golang.org/x/tools v0.0.0-20190821162956-65e3620a7ae7
+)
+EOF
+    mkdir -p "${BIN_DIR}"
+    for gen in client-gen lister-gen informer-gen; do
+        (cd "${TOOLS_DIR}" && GO111MODULE=on GOFLAGS=-mod=mod \
+            go build -o "${BIN_DIR}/${gen}" "k8s.io/code-generator/cmd/${gen}")
+    done
+    rm -rf "${TOOLS_DIR}"
+fi
+
+mkdir -p "${APIS_DIR}/dao/v1alpha1"
+find "${ROOT_DIR}/types" -maxdepth 1 -name "*.go" ! -name "*_test.go" \
+    -exec cp {} "${APIS_DIR}/dao/v1alpha1/" \;
+
+cd "${ROOT_DIR}"
+export GO111MODULE=on
+export GOFLAGS=-mod=mod
+
+"${BIN_DIR}/client-gen" \
+    --clientset-name versioned \
+    --input-base "" \
+    --input "${APIS_PKG}" \
+    --output-package "${CLIENT_PKG}/clientset" \
+    --output-base "${OUTPUT_DIR}" \
+    --go-header-file "${HEADER_FILE}"
+
+"${BIN_DIR}/lister-gen" \
+    --input-dirs "${APIS_PKG}" \
+    --output-package "${CLIENT_PKG}/listers" \
+    --output-base "${OUTPUT_DIR}" \
+    --go-header-file "${HEADER_FILE}"
+
+"${BIN_DIR}/informer-gen" \
+    --input-dirs "${APIS_PKG}" \
+    --versioned-clientset-package "${CLIENT_PKG}/clientset/versioned" \
+    --listers-package "${CLIENT_PKG}/listers" \
+    --output-package "${CLIENT_PKG}/informers" \
+    --output-base "${OUTPUT_DIR}" \
+    --go-header-file "${HEADER_FILE}"
+
+# generated code should import the types package
+find "${OUTPUT_DIR}" -name "*.go" -exec \
+    sed -i "s|\"${APIS_PKG}\"|\"${MODULE}/types\"|g" {} \;
+gofmt -w "${OUTPUT_DIR}"
+
+for dir in clientset listers informers; do
+    rm -rf "${ROOT_DIR}/pkg/client/${dir}"
+    mkdir -p "${ROOT_DIR}/pkg/client"
+    cp -r "${OUTPUT_DIR}/${CLIENT_PKG}/${dir}" "${ROOT_DIR}/pkg/client/${dir}"
+done
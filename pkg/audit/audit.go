@@ -0,0 +1,415 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit finds the children of CStorClusterConfig(s) that
+// are left behind once their parents are deleted.
+//
+// NOTE:
+//	Children are garbage collected by the finalize hooks of the
+// controllers. A child is left behind if its finalize hook was
+// missed e.g. the operator was not running when its parent got
+// deleted. Audit closes this gap by running in the background
+// independent of the controllers.
+package audit
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// Policy determines what is done with the orphaned children
+type Policy string
+
+const (
+	// PolicyReport logs the orphaned children & reports them as
+	// events
+	PolicyReport Policy = "Report"
+
+	// PolicyDelete deletes the orphaned children in addition to
+	// reporting them
+	PolicyDelete Policy = "Delete"
+)
+
+const (
+	// EventReasonOrphaned is the reason of the event emitted
+	// against an orphaned child
+	EventReasonOrphaned string = "Orphaned"
+
+	// EventReasonOrphanDeleted is the reason of the event emitted
+	// against an orphaned child that got deleted
+	EventReasonOrphanDeleted string = "OrphanDeleted"
+
+	// eventSourceComponent is set as the source of the events
+	eventSourceComponent string = "cstorpoolauto-audit"
+)
+
+// ParsePolicy returns the policy of the given value
+func ParsePolicy(value string) (Policy, error) {
+	switch Policy(value) {
+	case PolicyReport, PolicyDelete:
+		return Policy(value), nil
+	default:
+		return "", errors.Errorf(
+			"Invalid orphan audit policy %q: Want %q or %q",
+			value, PolicyReport, PolicyDelete,
+		)
+	}
+}
+
+// Group version resources of the parents
+var (
+	gvrCStorClusterConfig = schema.GroupVersionResource{
+		Group:    types.GroupDAOMayaDataIO,
+		Version:  types.VersionV1Alpha1,
+		Resource: "cstorclusterconfigs",
+	}
+	gvrCStorClusterPlan = schema.GroupVersionResource{
+		Group:    types.GroupDAOMayaDataIO,
+		Version:  types.VersionV1Alpha1,
+		Resource: "cstorclusterplans",
+	}
+	gvrEvent = schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "events",
+	}
+)
+
+// ChildResources are the resources that are audited. Resources that
+// are not served by the API server are skipped.
+var ChildResources = []schema.GroupVersionResource{
+	{
+		Group:    types.GroupDAOMayaDataIO,
+		Version:  types.VersionV1Alpha1,
+		Resource: "cstorclusterstoragesets",
+	},
+	{
+		Group:    types.GroupOpenEBSIO,
+		Version:  types.VersionV1Alpha1,
+		Resource: "cstorpoolclusters",
+	},
+	{
+		Group:    types.GroupCStorOpenEBSIO,
+		Version:  types.VersionV1,
+		Resource: "cstorpoolclusters",
+	},
+	{
+		Version:  "v1",
+		Resource: "persistentvolumeclaims",
+	},
+}
+
+// Orphan is a child whose parent was not found
+type Orphan struct {
+	Resource  schema.GroupVersionResource `json:"-"`
+	Kind      string                      `json:"kind"`
+	Namespace string                      `json:"namespace"`
+	Name      string                      `json:"name"`
+	UID       string                      `json:"uid"`
+	Reason    string                      `json:"reason"`
+}
+
+// orphanRegistry holds the orphans found by the latest audit
+type orphanRegistry struct {
+	sync.Mutex
+	orphans []Orphan
+}
+
+var orphanRegistryInstance = &orphanRegistry{}
+
+// GetOrphans returns a copy of the orphans found by the latest audit
+func GetOrphans() []Orphan {
+	r := orphanRegistryInstance
+	r.Lock()
+	defer r.Unlock()
+	copied := make([]Orphan, len(r.orphans))
+	copy(copied, r.orphans)
+	return copied
+}
+
+func setOrphans(orphans []Orphan) {
+	r := orphanRegistryInstance
+	r.Lock()
+	defer r.Unlock()
+	r.orphans = orphans
+}
+
+// FindOrphans returns the given children that refer to a
+// CStorClusterConfig or CStorClusterPlan that is not found amongst
+// the given ones. Children without these references are not owned
+// by this operator & are ignored.
+//
+// NOTE:
+//	PVCs retained as per the reclaim policy of their config are
+// meant to outlive their parents & are never orphans.
+func FindOrphans(
+	resource schema.GroupVersionResource,
+	configs []*unstructured.Unstructured,
+	plans []*unstructured.Unstructured,
+	children []*unstructured.Unstructured,
+) []Orphan {
+	configUIDs := uidSet(configs)
+	planUIDs := uidSet(plans)
+	var orphans []Orphan
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if _, found := child.GetLabels()[types.LblKeyRetainedCStorClusterConfigUID]; found {
+			continue
+		}
+		annotations := child.GetAnnotations()
+		var reasons []string
+		configUID := annotations[types.AnnKeyCStorClusterConfigUID]
+		if configUID != "" && !configUIDs[configUID] {
+			reasons = append(reasons, "CStorClusterConfig "+configUID+" not found")
+		}
+		planUID := annotations[types.AnnKeyCStorClusterPlanUID]
+		if planUID != "" && !planUIDs[planUID] {
+			reasons = append(reasons, "CStorClusterPlan "+planUID+" not found")
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		orphans = append(orphans, Orphan{
+			Resource:  resource,
+			Kind:      child.GetKind(),
+			Namespace: child.GetNamespace(),
+			Name:      child.GetName(),
+			UID:       string(child.GetUID()),
+			Reason:    strings.Join(reasons, ": "),
+		})
+	}
+	return orphans
+}
+
+func uidSet(objs []*unstructured.Unstructured) map[string]bool {
+	uids := map[string]bool{}
+	for _, obj := range objs {
+		if obj == nil {
+			continue
+		}
+		uids[string(obj.GetUID())] = true
+	}
+	return uids
+}
+
+// Auditor finds the orphaned children across the cluster & reports
+// or deletes them based on its policy
+//
+// NOTE:
+//	An orphan is deleted only if it was found by the previous audit
+// as well. This avoids deleting a child whose parent is still being
+// created or whose finalize hook is in progress.
+type Auditor struct {
+	Client dynamic.Interface
+	Policy Policy
+
+	// orphans found by the previous audit keyed by their UID
+	suspects map[string]bool
+}
+
+// Run audits at the given interval till the given channel is closed
+func (a *Auditor) Run(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if level := throttle.API.Stats().Level; level > 0 {
+				// audit lists the resources of the entire cluster
+				glog.V(2).Infof("Skipping orphan audit: API throttle level %d", level)
+				continue
+			}
+			_, err := a.AuditOnce()
+			if err != nil {
+				glog.Errorf("Orphan audit failed: %+v", err)
+			}
+		}
+	}
+}
+
+// AuditOnce finds the orphaned children & handles them as per the
+// policy. It returns the orphans found by this audit.
+func (a *Auditor) AuditOnce() ([]Orphan, error) {
+	// children are listed before their parents. A child created
+	// after its parent is listed would otherwise be found orphaned.
+	resourceToChildren := map[schema.GroupVersionResource][]*unstructured.Unstructured{}
+	for _, resource := range ChildResources {
+		children, err := a.list(resource)
+		if err != nil {
+			return nil, err
+		}
+		resourceToChildren[resource] = children
+	}
+	configs, err := a.list(gvrCStorClusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := a.list(gvrCStorClusterPlan)
+	if err != nil {
+		return nil, err
+	}
+	var orphans []Orphan
+	for _, resource := range ChildResources {
+		orphans = append(
+			orphans,
+			FindOrphans(resource, configs, plans, resourceToChildren[resource])...,
+		)
+	}
+	// orphans are sorted to report them in the same order
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].Kind != orphans[j].Kind {
+			return orphans[i].Kind < orphans[j].Kind
+		}
+		if orphans[i].Namespace != orphans[j].Namespace {
+			return orphans[i].Namespace < orphans[j].Namespace
+		}
+		return orphans[i].Name < orphans[j].Name
+	})
+	setOrphans(orphans)
+
+	suspects := map[string]bool{}
+	for _, orphan := range orphans {
+		suspects[orphan.UID] = true
+		if a.Policy != PolicyDelete || !a.suspects[orphan.UID] {
+			glog.Warningf(
+				"Found orphaned %s %s/%s: %s",
+				orphan.Kind, orphan.Namespace, orphan.Name, orphan.Reason,
+			)
+			a.report(orphan, EventReasonOrphaned)
+			continue
+		}
+		err := a.delete(orphan)
+		if err != nil {
+			return orphans, err
+		}
+		glog.Infof(
+			"Deleted orphaned %s %s/%s: %s",
+			orphan.Kind, orphan.Namespace, orphan.Name, orphan.Reason,
+		)
+		a.report(orphan, EventReasonOrphanDeleted)
+	}
+	a.suspects = suspects
+	return orphans, nil
+}
+
+// list returns all the instances of the given resource. No
+// instances are returned if this resource is not served.
+func (a *Auditor) list(
+	resource schema.GroupVersionResource,
+) ([]*unstructured.Unstructured, error) {
+	list, err := a.Client.Resource(resource).List(metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't list %s", resource.String())
+	}
+	var objs []*unstructured.Unstructured
+	for idx := range list.Items {
+		objs = append(objs, &list.Items[idx])
+	}
+	return objs, nil
+}
+
+// delete deletes the given orphan provided it was not re-created
+func (a *Auditor) delete(orphan Orphan) error {
+	uid := k8stypes.UID(orphan.UID)
+	err := a.Client.Resource(orphan.Resource).
+		Namespace(orphan.Namespace).
+		Delete(orphan.Name, &metav1.DeleteOptions{
+			Preconditions: &metav1.Preconditions{UID: &uid},
+		})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(
+			err,
+			"Can't delete orphaned %s %s/%s",
+			orphan.Kind, orphan.Namespace, orphan.Name,
+		)
+	}
+	return nil
+}
+
+// report emits an event against the given orphan. An event that
+// exists already is not updated.
+//
+// NOTE:
+//	Events are best effort & their errors are only logged
+func (a *Auditor) report(orphan Orphan, reason string) {
+	event := NewEvent(orphan, reason, time.Now().UTC().Format(time.RFC3339))
+	_, err := a.Client.Resource(gvrEvent).
+		Namespace(orphan.Namespace).
+		Create(event, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		glog.Errorf(
+			"Can't report orphaned %s %s/%s: %v",
+			orphan.Kind, orphan.Namespace, orphan.Name, err,
+		)
+	}
+}
+
+// NewEvent returns the event of the given orphan
+func NewEvent(orphan Orphan, reason, timestamp string) *unstructured.Unstructured {
+	apiVersion := orphan.Resource.Version
+	if orphan.Resource.Group != "" {
+		apiVersion = orphan.Resource.Group + "/" + apiVersion
+	}
+	event := &unstructured.Unstructured{}
+	event.SetUnstructuredContent(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      orphan.Name + "." + strings.ToLower(reason),
+			"namespace": orphan.Namespace,
+		},
+		"involvedObject": map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       orphan.Kind,
+			"name":       orphan.Name,
+			"namespace":  orphan.Namespace,
+			"uid":        orphan.UID,
+		},
+		"type":    "Warning",
+		"reason":  reason,
+		"message": orphan.Reason,
+		"source": map[string]interface{}{
+			"component": eventSourceComponent,
+		},
+		"firstTimestamp": timestamp,
+		"lastTimestamp":  timestamp,
+		"count":          int64(1),
+	})
+	event.SetAPIVersion("v1")
+	event.SetKind(string(types.KindEvent))
+	return event
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newObj(
+	apiVersion, kind, name, uid string, annotations map[string]string,
+) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("openebs")
+	obj.SetName(name)
+	obj.SetUID(k8stypes.UID(uid))
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestParsePolicy(t *testing.T) {
+	var tests = map[string]struct {
+		value  string
+		expect Policy
+		isErr  bool
+	}{
+		"report": {
+			value:  "Report",
+			expect: PolicyReport,
+		},
+		"delete": {
+			value:  "Delete",
+			expect: PolicyDelete,
+		},
+		"invalid": {
+			value: "Retain",
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := ParsePolicy(mock.value)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expect {
+				t.Fatalf("Expected policy %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestFindOrphans(t *testing.T) {
+	configs := []*unstructured.Unstructured{
+		newObj(types.APIVersionDAOMayaDataV1Alpha1, "CStorClusterConfig", "ccc", "config-1", nil),
+	}
+	plans := []*unstructured.Unstructured{
+		newObj(types.APIVersionDAOMayaDataV1Alpha1, "CStorClusterPlan", "ccc", "plan-1", nil),
+	}
+	var tests = map[string]struct {
+		children      []*unstructured.Unstructured
+		expectNames   []string
+		expectReasons []string
+	}{
+		"children without owner annotations": {
+			children: []*unstructured.Unstructured{
+				newObj("v1", "PersistentVolumeClaim", "pvc-1", "uid-1", nil),
+			},
+		},
+		"children with live parents": {
+			children: []*unstructured.Unstructured{
+				newObj("v1", "PersistentVolumeClaim", "pvc-1", "uid-1", map[string]string{
+					types.AnnKeyCStorClusterConfigUID: "config-1",
+				}),
+				newObj("v1", "PersistentVolumeClaim", "pvc-2", "uid-2", map[string]string{
+					types.AnnKeyCStorClusterConfigUID: "config-1",
+					types.AnnKeyCStorClusterPlanUID:   "plan-1",
+				}),
+			},
+		},
+		"retained children with missing parents": {
+			children: []*unstructured.Unstructured{
+				func() *unstructured.Unstructured {
+					pvc := newObj("v1", "PersistentVolumeClaim", "pvc-1", "uid-1", map[string]string{
+						types.AnnKeyCStorClusterConfigUID: "config-2",
+					})
+					pvc.SetLabels(map[string]string{
+						types.LblKeyRetainedCStorClusterConfigUID: "config-2",
+					})
+					return pvc
+				}(),
+			},
+		},
+		"children with missing parents": {
+			children: []*unstructured.Unstructured{
+				newObj("v1", "PersistentVolumeClaim", "pvc-1", "uid-1", map[string]string{
+					types.AnnKeyCStorClusterConfigUID: "config-2",
+				}),
+				newObj("v1", "PersistentVolumeClaim", "pvc-2", "uid-2", map[string]string{
+					types.AnnKeyCStorClusterConfigUID: "config-1",
+					types.AnnKeyCStorClusterPlanUID:   "plan-2",
+				}),
+				newObj("v1", "PersistentVolumeClaim", "pvc-3", "uid-3", map[string]string{
+					types.AnnKeyCStorClusterConfigUID: "config-1",
+				}),
+			},
+			expectNames: []string{"pvc-1", "pvc-2"},
+			expectReasons: []string{
+				"CStorClusterConfig config-2 not found",
+				"CStorClusterPlan plan-2 not found",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var gotNames, gotReasons []string
+			for _, orphan := range FindOrphans(ChildResources[0], configs, plans, mock.children) {
+				gotNames = append(gotNames, orphan.Name)
+				gotReasons = append(gotReasons, orphan.Reason)
+			}
+			if diff := cmp.Diff(mock.expectNames, gotNames); diff != "" {
+				t.Fatalf("Expected no diff in orphans got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectReasons, gotReasons); diff != "" {
+				t.Fatalf("Expected no diff in reasons got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAuditorAuditOnce(t *testing.T) {
+	newClient := func() *fake.FakeDynamicClient {
+		return fake.NewSimpleDynamicClient(
+			runtime.NewScheme(),
+			newObj(types.APIVersionDAOMayaDataV1Alpha1, "CStorClusterConfig", "ccc", "config-1", nil),
+			newObj(types.APIVersionDAOMayaDataV1Alpha1, "CStorClusterStorageSet", "sset-1", "uid-1", map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "config-1",
+				types.AnnKeyCStorClusterPlanUID:   "plan-1",
+			}),
+			newObj(types.APIVersionCStorOpenEBSV1, "CStorPoolCluster", "cspc-1", "uid-2", map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "config-1",
+			}),
+			newObj("v1", "PersistentVolumeClaim", "pvc-1", "uid-3", map[string]string{
+				types.AnnKeyCStorClusterConfigUID: "config-2",
+			}),
+		)
+	}
+	var tests = map[string]struct {
+		policy        Policy
+		auditCount    int
+		expectOrphans []string
+		expectDeleted []string
+	}{
+		"report": {
+			policy:        PolicyReport,
+			auditCount:    2,
+			expectOrphans: []string{"sset-1", "pvc-1"},
+		},
+		"delete is skipped for new orphans": {
+			policy:        PolicyDelete,
+			auditCount:    1,
+			expectOrphans: []string{"sset-1", "pvc-1"},
+		},
+		"delete orphans found by previous audit": {
+			policy:        PolicyDelete,
+			auditCount:    2,
+			expectOrphans: []string{"sset-1", "pvc-1"},
+			expectDeleted: []string{"sset-1", "pvc-1"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			client := newClient()
+			a := &Auditor{Client: client, Policy: mock.policy}
+			var orphans []Orphan
+			var err error
+			for i := 0; i < mock.auditCount; i++ {
+				orphans, err = a.AuditOnce()
+				if err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+			}
+			var gotOrphans []string
+			for _, orphan := range orphans {
+				gotOrphans = append(gotOrphans, orphan.Name)
+			}
+			if diff := cmp.Diff(mock.expectOrphans, gotOrphans); diff != "" {
+				t.Fatalf("Expected no diff in orphans got\n%s", diff)
+			}
+			var gotDeleted []string
+			for _, action := range client.Actions() {
+				if deleted, ok := action.(clienttesting.DeleteAction); ok {
+					gotDeleted = append(gotDeleted, deleted.GetName())
+				}
+			}
+			if diff := cmp.Diff(mock.expectDeleted, gotDeleted); diff != "" {
+				t.Fatalf("Expected no diff in deleted orphans got\n%s", diff)
+			}
+			events, err := client.Resource(gvrEvent).Namespace("openebs").List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if len(events.Items) == 0 {
+				t.Fatalf("Expected events got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package capacity provides overflow safe arithmetic on capacities
+// expressed either as bytes or as quantities.
+//
+// NOTE:
+//	Capacities are never negative. Hence, negative operands are
+// rejected instead of being wrapped around.
+package capacity
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Add returns the sum of the given bytes. Error is returned if any
+// of the given bytes is negative or if the sum overflows int64.
+func Add(values ...int64) (int64, error) {
+	var sum int64
+	for _, value := range values {
+		if value < 0 {
+			return 0, errors.Errorf("Can't add %d: Negative value", value)
+		}
+		if sum > math.MaxInt64-value {
+			return 0, errors.Errorf("Can't add %d to %d: Overflow", value, sum)
+		}
+		sum += value
+	}
+	return sum, nil
+}
+
+// Multiply returns the product of the given values. Error is
+// returned if any of the given values is negative or if the
+// product overflows int64.
+func Multiply(values ...int64) (int64, error) {
+	var product int64 = 1
+	for _, value := range values {
+		if value < 0 {
+			return 0, errors.Errorf("Can't multiply %d: Negative value", value)
+		}
+		if value != 0 && product > math.MaxInt64/value {
+			return 0, errors.Errorf("Can't multiply %d by %d: Overflow", product, value)
+		}
+		product *= value
+	}
+	return product, nil
+}
+
+// DivideCeil returns the given dividend divided by the given
+// divisor rounded up to the nearest integer e.g. the number of
+// devices of a given capacity that can hold the given bytes.
+func DivideCeil(dividend, divisor int64) (int64, error) {
+	if dividend < 0 {
+		return 0, errors.Errorf("Can't divide %d: Negative value", dividend)
+	}
+	if divisor <= 0 {
+		return 0, errors.Errorf("Can't divide %d by %d: Want positive divisor", dividend, divisor)
+	}
+	quotient := dividend / divisor
+	if dividend%divisor != 0 {
+		quotient++
+	}
+	return quotient, nil
+}
+
+// MultiplyQuantity returns the given quantity multiplied by the
+// given count. Returned quantity has the format of the given
+// quantity. Error is returned if the given quantity can't be
+// expressed in bytes or if the product overflows int64.
+func MultiplyQuantity(quantity resource.Quantity, count int64) (resource.Quantity, error) {
+	bytes, ok := quantity.AsInt64()
+	if !ok {
+		return resource.Quantity{}, errors.Errorf(
+			"Can't multiply %q: Not an int64", quantity.String(),
+		)
+	}
+	product, err := Multiply(bytes, count)
+	if err != nil {
+		return resource.Quantity{}, errors.Wrapf(
+			err, "Can't multiply %q by %d", quantity.String(), count,
+		)
+	}
+	return *resource.NewQuantity(product, quantity.Format), nil
+}
+
+// MinQuantity returns the smaller of the given quantities
+func MinQuantity(first resource.Quantity, others ...resource.Quantity) resource.Quantity {
+	min := first
+	for _, other := range others {
+		if other.Cmp(min) < 0 {
+			min = other
+		}
+	}
+	return min
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capacity
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"testing/quick"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	gi int64 = 1 << 30
+	ti int64 = 1 << 40
+)
+
+// bigFits returns the given big int as int64 if it fits
+func bigFits(b *big.Int) (int64, bool) {
+	if !b.IsInt64() {
+		return 0, false
+	}
+	return b.Int64(), true
+}
+
+func TestAddProperty(t *testing.T) {
+	property := func(a, b int64) bool {
+		got, err := Add(a, b)
+		if a < 0 || b < 0 {
+			return err != nil
+		}
+		want, fits := bigFits(new(big.Int).Add(big.NewInt(a), big.NewInt(b)))
+		if !fits {
+			return err != nil
+		}
+		return err == nil && got == want
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatalf("Expected add to match big int sum: %+v", err)
+	}
+}
+
+func TestMultiplyProperty(t *testing.T) {
+	property := func(a, b int64) bool {
+		got, err := Multiply(a, b)
+		if a < 0 || b < 0 {
+			return err != nil
+		}
+		want, fits := bigFits(new(big.Int).Mul(big.NewInt(a), big.NewInt(b)))
+		if !fits {
+			return err != nil
+		}
+		return err == nil && got == want
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatalf("Expected multiply to match big int product: %+v", err)
+	}
+	// small operands exercise the products that fit in int64
+	small := func(a uint32, b uint16) bool {
+		got, err := Multiply(int64(a), int64(b))
+		return err == nil && got == int64(a)*int64(b)
+	}
+	if err := quick.Check(small, nil); err != nil {
+		t.Fatalf("Expected multiply of small values to succeed: %+v", err)
+	}
+}
+
+func TestDivideCeilProperty(t *testing.T) {
+	property := func(dividend int64, divisor uint32) bool {
+		if dividend < 0 {
+			dividend = -(dividend + 1)
+		}
+		d := int64(divisor) + 1
+		got, err := DivideCeil(dividend, d)
+		if err != nil {
+			return false
+		}
+		// got is the smallest count whose product covers dividend
+		covers := new(big.Int).Mul(big.NewInt(got), big.NewInt(d))
+		lesser := new(big.Int).Mul(big.NewInt(got-1), big.NewInt(d))
+		return covers.Cmp(big.NewInt(dividend)) >= 0 &&
+			lesser.Cmp(big.NewInt(dividend)) < 0
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatalf("Expected divide ceil to be the smallest cover: %+v", err)
+	}
+}
+
+func TestDivideCeil(t *testing.T) {
+	var tests = map[string]struct {
+		dividend int64
+		divisor  int64
+		expect   int64
+		isErr    bool
+	}{
+		"exact gi": {
+			dividend: 100 * gi, divisor: 10 * gi, expect: 10,
+		},
+		"one byte over gi boundary": {
+			dividend: 100*gi + 1, divisor: 10 * gi, expect: 11,
+		},
+		"one byte below gi boundary": {
+			dividend: 100*gi - 1, divisor: 10 * gi, expect: 10,
+		},
+		"ti over gi": {
+			dividend: ti, divisor: 100 * gi, expect: 11,
+		},
+		"max int64": {
+			dividend: math.MaxInt64, divisor: ti, expect: 1 << 23,
+		},
+		"zero dividend": {
+			dividend: 0, divisor: gi, expect: 0,
+		},
+		"zero divisor": {
+			dividend: gi, divisor: 0, isErr: true,
+		},
+		"negative dividend": {
+			dividend: -gi, divisor: gi, isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := DivideCeil(mock.dividend, mock.divisor)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got != mock.expect {
+				t.Fatalf("Expected %d got %d", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestMultiplyQuantity(t *testing.T) {
+	var tests = map[string]struct {
+		quantity string
+		count    int64
+		expect   string
+		isErr    bool
+	}{
+		"gi": {
+			quantity: "100Gi", count: 3, expect: "300Gi",
+		},
+		"gi to ti": {
+			quantity: "512Gi", count: 2, expect: "1Ti",
+		},
+		"ti": {
+			quantity: "4Ti", count: 1024, expect: "4Pi",
+		},
+		"decimal": {
+			quantity: "100G", count: 10, expect: "1T",
+		},
+		"zero count": {
+			quantity: "100Gi", count: 0, expect: "0",
+		},
+		"largest ti count that fits": {
+			quantity: "1Ti", count: math.MaxInt64 / ti, expect: "8388607Ti",
+		},
+		"overflow beyond ti count": {
+			quantity: "1Ti", count: math.MaxInt64/ti + 1, isErr: true,
+		},
+		"negative count": {
+			quantity: "1Gi", count: -1, isErr: true,
+		},
+		"not an int64": {
+			quantity: "100Ei", count: 1, isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := MultiplyQuantity(resource.MustParse(mock.quantity), mock.count)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if got.Cmp(resource.MustParse(mock.expect)) != 0 {
+				t.Fatalf("Expected %s got %s", mock.expect, got.String())
+			}
+		})
+	}
+}
+
+func TestMultiplyQuantityProperty(t *testing.T) {
+	property := func(count uint32) bool {
+		got, err := MultiplyQuantity(resource.MustParse("1Gi"), int64(count))
+		return err == nil && got.Value() == int64(count)*gi
+	}
+	if err := quick.Check(property, nil); err != nil {
+		t.Fatalf("Expected gi multiples to be exact: %+v", err)
+	}
+}
+
+func TestMinQuantity(t *testing.T) {
+	var tests = map[string]struct {
+		quantities []string
+		expect     string
+	}{
+		"single": {
+			quantities: []string{"1Gi"}, expect: "1Gi",
+		},
+		"binary vs decimal": {
+			quantities: []string{"1Gi", "1G"}, expect: "1G",
+		},
+		"ti vs gi": {
+			quantities: []string{"1Ti", "1023Gi", "1024Gi"}, expect: "1023Gi",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var quantities []resource.Quantity
+			for _, q := range mock.quantities {
+				quantities = append(quantities, resource.MustParse(q))
+			}
+			got := MinQuantity(quantities[0], quantities[1:]...)
+			if got.Cmp(resource.MustParse(mock.expect)) != 0 {
+				t.Fatalf("Expected %s got %s", mock.expect, got.String())
+			}
+		})
+	}
+}
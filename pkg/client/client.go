@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client helps programmatic consumers to work with the
+// custom resources of dao.mayadata.io group.
+//
+// NOTE:
+//	Clientset, listers & informers are generated at clientset,
+// listers & informers packages respectively. Run 'make generate'
+// after changing the types package.
+package client
+
+import (
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	"mayadata.io/cstorpoolauto/pkg/client/clientset/versioned"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// NewClientset returns the clientset of dao.mayadata.io custom
+// resources from the given kubeconfig path
+//
+// NOTE:
+//	In-cluster config is used if kubeconfig path is empty
+func NewClientset(kubeconfig string) (versioned.Interface, error) {
+	var config *rest.Config
+	var err error
+	if kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		config, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't build kube config")
+	}
+	clientset, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't build clientset")
+	}
+	return clientset, nil
+}
+
+// NewCStorClusterConfig returns a CStorClusterConfig with the
+// given spec
+//
+// NOTE:
+//	Default RAID type is set if none was specified. Other fields
+// are defaulted by the controllers.
+func NewCStorClusterConfig(
+	namespace, name string, spec types.CStorClusterConfigSpec,
+) *types.CStorClusterConfig {
+	if spec.PoolConfig.RAIDType == "" {
+		spec.PoolConfig.RAIDType = types.PoolRAIDTypeDefault
+	}
+	return &types.CStorClusterConfig{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: types.APIVersionDAOMayaDataV1Alpha1,
+			Kind:       string(types.KindCStorClusterConfig),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: spec,
+	}
+}
+
+// NewLocalDiskCStorClusterConfig returns a CStorClusterConfig that
+// builds cstor pools from the local block devices selected by the
+// given selector
+//
+// NOTE:
+//	Resulting CStorClusterConfig is reconciled into a CStorPoolCluster
+// of cstor.openebs.io/v1 version
+func NewLocalDiskCStorClusterConfig(
+	namespace, name string,
+	raidType types.PoolRAIDType,
+	blockDeviceSelector metac.ResourceSelector,
+) *types.CStorClusterConfig {
+	config := NewCStorClusterConfig(
+		namespace,
+		name,
+		types.CStorClusterConfigSpec{
+			DiskConfig: types.DiskConfig{
+				LocalDiskConfig: &types.LocalDiskConfig{
+					BlockDeviceSelector: types.NewBlockDeviceSelector(blockDeviceSelector),
+				},
+			},
+			PoolConfig: types.PoolConfig{
+				RAIDType: raidType,
+			},
+		},
+	)
+	config.SetLabels(map[string]string{
+		types.LblKeyCStorPoolClusterVersion: types.VersionV1,
+	})
+	return config
+}
+
+// GetCStorClusterPlan returns the CStorClusterPlan that was formed
+// for the given CStorClusterConfig
+//
+// NOTE:
+//	CStorClusterPlan has the same name & namespace as its
+// CStorClusterConfig
+func GetCStorClusterPlan(
+	clientset versioned.Interface, config *types.CStorClusterConfig,
+) (*types.CStorClusterPlan, error) {
+	if config == nil {
+		return nil, errors.Errorf("Can't get CStorClusterPlan: Nil CStorClusterConfig")
+	}
+	plan, err := clientset.DaoV1alpha1().
+		CStorClusterPlans(config.GetNamespace()).
+		Get(config.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"Can't get CStorClusterPlan %q / %q",
+			config.GetNamespace(),
+			config.GetName(),
+		)
+	}
+	return plan, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+
+	ccc "mayadata.io/cstorpoolauto/common/cstorclusterconfig"
+	"mayadata.io/cstorpoolauto/pkg/client/clientset/versioned/fake"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestNewLocalDiskCStorClusterConfig(t *testing.T) {
+	var tests = map[string]struct {
+		raidType       types.PoolRAIDType
+		expectRAIDType types.PoolRAIDType
+	}{
+		"no raid type": {
+			expectRAIDType: types.PoolRAIDTypeMirror,
+		},
+		"stripe raid type": {
+			raidType:       types.PoolRAIDTypeStripe,
+			expectRAIDType: types.PoolRAIDTypeStripe,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			config := NewLocalDiskCStorClusterConfig(
+				"openebs",
+				"my-cstor-cluster",
+				mock.raidType,
+				metac.ResourceSelector{
+					SelectorTerms: []*metac.SelectorTerm{
+						&metac.SelectorTerm{
+							MatchLabels: map[string]string{
+								"mirror-pool": "mysql",
+							},
+						},
+					},
+				},
+			)
+			if config.GetLabels()[types.LblKeyCStorPoolClusterVersion] != types.VersionV1 {
+				t.Fatalf("Expected cspc version label got [%+v]", config.GetLabels())
+			}
+			// controllers should understand the typed config
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(config)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			h := ccc.NewHelper(&unstructured.Unstructured{Object: obj})
+			isLocal, err := h.IsLocalBlockDiskConfig()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if !isLocal {
+				t.Fatalf("Expected local disk config got none")
+			}
+			raidType, err := h.GetRAIDType()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if raidType != mock.expectRAIDType {
+				t.Fatalf("Expected raid type %q got %q", mock.expectRAIDType, raidType)
+			}
+		})
+	}
+}
+
+func TestGetCStorClusterPlan(t *testing.T) {
+	config := NewCStorClusterConfig(
+		"openebs", "my-cstor-cluster", types.CStorClusterConfigSpec{},
+	)
+	var tests = map[string]struct {
+		config *types.CStorClusterConfig
+		plans  []*types.CStorClusterPlan
+		isErr  bool
+	}{
+		"nil config": {
+			isErr: true,
+		},
+		"no plan": {
+			config: config,
+			isErr:  true,
+		},
+		"plan with same name & namespace": {
+			config: config,
+			plans: []*types.CStorClusterPlan{
+				&types.CStorClusterPlan{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-cstor-cluster",
+						Namespace: "openebs",
+					},
+				},
+			},
+		},
+		"plan with different namespace": {
+			config: config,
+			plans: []*types.CStorClusterPlan{
+				&types.CStorClusterPlan{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "my-cstor-cluster",
+						Namespace: "default",
+					},
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset()
+			for _, plan := range mock.plans {
+				_, err := clientset.DaoV1alpha1().
+					CStorClusterPlans(plan.GetNamespace()).
+					Create(plan)
+				if err != nil {
+					t.Fatalf("Can't create plan: %+v", err)
+				}
+			}
+			got, err := GetCStorClusterPlan(clientset, mock.config)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if !mock.isErr && got.GetName() != mock.config.GetName() {
+				t.Fatalf("Expected plan %q got %q", mock.config.GetName(), got.GetName())
+			}
+		})
+	}
+}
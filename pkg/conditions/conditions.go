@@ -0,0 +1,257 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conditions gets & sets the conditions of the unstructured
+// status of the resources reconciled by the controllers.
+//
+// NOTE:
+//	A condition is identified by its type & by the controller that
+// set it if any. Conditions that are set per controller e.g.
+// ReconcileSkipped have a controller field whereas others don't.
+package conditions
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+const (
+	// KeyType is the field of a condition that has its type
+	KeyType = "type"
+
+	// KeyController is the field of a condition that has the name
+	// of the controller that set it
+	KeyController = "controller"
+
+	// KeyStatus is the field of a condition that has its status
+	KeyStatus = "status"
+
+	// KeyReason is the field of a condition that has its reason
+	KeyReason = "reason"
+
+	// KeyMessage is the field of a condition that has its message
+	KeyMessage = "message"
+
+	// KeyLastTransitionTime is the field of a condition that has the
+	// time when its status last changed
+	KeyLastTransitionTime = "lastTransitionTime"
+)
+
+// now returns the current time in the layout of condition times;
+// this is overridden in tests
+var now = func() string {
+	return metav1.Now().Format(types.ConditionTimeLayout)
+}
+
+// getField returns the given field of the given condition as a
+// string
+//
+// NOTE:
+//	Some conditions are built with typed values e.g. ConditionType
+// & hence are formatted instead of being asserted as string.
+func getField(cond map[string]interface{}, key string) string {
+	val, ok := cond[key]
+	if !ok || val == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s", val)
+}
+
+// isMatch returns true if the given condition has the given type &
+// was set by the given controller
+func isMatch(cond interface{}, condType types.ConditionType, controller string) bool {
+	condMap, ok := cond.(map[string]interface{})
+	return ok &&
+		getField(condMap, KeyType) == string(condType) &&
+		getField(condMap, KeyController) == controller
+}
+
+// getConditions returns the conditions of the given status
+func getConditions(status map[string]interface{}) ([]interface{}, error) {
+	conds, _, err := unstructured.NestedSlice(status, "conditions")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid status conditions")
+	}
+	return conds, nil
+}
+
+// GetCondition returns the condition of the given type from the
+// given status. It returns false if no such condition is found.
+//
+// NOTE:
+//	Conditions set per controller are not returned. Use
+// GetControllerCondition for these.
+func GetCondition(
+	status map[string]interface{}, condType types.ConditionType,
+) (map[string]interface{}, bool, error) {
+	return GetControllerCondition(status, condType, "")
+}
+
+// GetControllerCondition returns the condition of the given type
+// that was set by the given controller from the given status. It
+// returns false if no such condition is found.
+func GetControllerCondition(
+	status map[string]interface{}, condType types.ConditionType, controller string,
+) (map[string]interface{}, bool, error) {
+	conds, err := getConditions(status)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, cond := range conds {
+		if isMatch(cond, condType, controller) {
+			return cond.(map[string]interface{}), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// IsConditionTrue returns true if the condition of the given type
+// is set against the given status with status True
+func IsConditionTrue(status map[string]interface{}, condType types.ConditionType) bool {
+	cond, found, err := GetCondition(status, condType)
+	if err != nil || !found {
+		return false
+	}
+	return getField(cond, KeyStatus) == string(types.ConditionIsPresent)
+}
+
+// SetCondition sets the given condition against the given status &
+// returns the updated status
+//
+// NOTE:
+//	Existing condition is retained as is if its status, reason &
+// message did not change. This keeps the status same across syncs.
+// Otherwise the existing condition is replaced with the given one
+// & its lastTransitionTime is carried over unless its status
+// changed.
+func SetCondition(
+	status map[string]interface{}, cond map[string]interface{},
+) (map[string]interface{}, error) {
+	if status == nil {
+		status = map[string]interface{}{}
+	}
+	condType := types.ConditionType(getField(cond, KeyType))
+	if condType == "" {
+		return nil, errors.Errorf("Can't set condition: Missing type")
+	}
+	conds, err := getConditions(status)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't set condition %q", condType)
+	}
+	controller := getField(cond, KeyController)
+	var isSet bool
+	for idx, old := range conds {
+		if !isMatch(old, condType, controller) {
+			continue
+		}
+		isSet = true
+		oldMap := old.(map[string]interface{})
+		if getField(oldMap, KeyStatus) == getField(cond, KeyStatus) &&
+			getField(oldMap, KeyReason) == getField(cond, KeyReason) &&
+			getField(oldMap, KeyMessage) == getField(cond, KeyMessage) {
+			continue
+		}
+		newCond := copyCondition(cond)
+		if getField(oldMap, KeyStatus) == getField(cond, KeyStatus) &&
+			getField(oldMap, KeyLastTransitionTime) != "" {
+			newCond[KeyLastTransitionTime] = oldMap[KeyLastTransitionTime]
+		}
+		conds[idx] = newCond
+	}
+	if !isSet {
+		conds = append(conds, copyCondition(cond))
+	}
+	status["conditions"] = conds
+	return status, nil
+}
+
+// copyCondition returns a copy of the given condition with its
+// lastTransitionTime set to now
+//
+// NOTE:
+//	Typed values of the well known fields are set as strings. This
+// keeps the conditions deep copyable as JSON values.
+func copyCondition(cond map[string]interface{}) map[string]interface{} {
+	newCond := make(map[string]interface{}, len(cond)+1)
+	for key, val := range cond {
+		newCond[key] = val
+	}
+	for _, key := range []string{KeyType, KeyController, KeyStatus, KeyReason, KeyMessage} {
+		if _, ok := newCond[key]; ok {
+			newCond[key] = getField(cond, key)
+		}
+	}
+	newCond[KeyLastTransitionTime] = now()
+	return newCond
+}
+
+// RemoveControllerCondition removes the condition of the given type
+// that was set by the given controller from the given status &
+// returns the updated status
+func RemoveControllerCondition(
+	status map[string]interface{}, condType types.ConditionType, controller string,
+) (map[string]interface{}, error) {
+	if status == nil {
+		return nil, nil
+	}
+	conds, found, err := unstructured.NestedSlice(status, "conditions")
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't remove condition %q", condType)
+	}
+	if !found {
+		return status, nil
+	}
+	retained := []interface{}{}
+	for _, cond := range conds {
+		if isMatch(cond, condType, controller) {
+			continue
+		}
+		retained = append(retained, cond)
+	}
+	status["conditions"] = retained
+	return status, nil
+}
+
+// MergeCondition returns the conditions of the status of the given
+// object with the given condition set against these
+//
+// NOTE:
+//	Given object is not modified.
+func MergeCondition(
+	obj *unstructured.Unstructured, cond map[string]interface{},
+) ([]interface{}, error) {
+	// status is copied to avoid modifying the object
+	status, _, err := unstructured.NestedMap(obj.Object, "status")
+	if err != nil {
+		return nil, errors.Wrapf(
+			err,
+			"Invalid status: %q - %q / %q",
+			obj.GetKind(),
+			obj.GetNamespace(),
+			obj.GetName(),
+		)
+	}
+	status, err = SetCondition(status, cond)
+	if err != nil {
+		return nil, err
+	}
+	return status["conditions"].([]interface{}), nil
+}
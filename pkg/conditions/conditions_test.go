@@ -0,0 +1,348 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditions
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestGetCondition(t *testing.T) {
+	var tests = map[string]struct {
+		status       map[string]interface{}
+		condType     types.ConditionType
+		controller   string
+		expectCond   map[string]interface{}
+		isFound      bool
+		isErr        bool
+		expectIsTrue bool
+	}{
+		"nil status": {
+			condType: types.CStorPoolClusterDriftDetectedCondition,
+		},
+		"invalid conditions": {
+			status: map[string]interface{}{
+				"conditions": "junk",
+			},
+			condType: types.CStorPoolClusterDriftDetectedCondition,
+			isErr:    true,
+		},
+		"condition of given type is true": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Paused", "status": "False"},
+					map[string]interface{}{"type": "DriftDetected", "status": "True"},
+				},
+			},
+			condType:     types.CStorPoolClusterDriftDetectedCondition,
+			expectCond:   map[string]interface{}{"type": "DriftDetected", "status": "True"},
+			isFound:      true,
+			expectIsTrue: true,
+		},
+		"condition of given type is false": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "DriftDetected", "status": "False"},
+				},
+			},
+			condType:   types.CStorPoolClusterDriftDetectedCondition,
+			expectCond: map[string]interface{}{"type": "DriftDetected", "status": "False"},
+			isFound:    true,
+		},
+		"condition of given controller": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "ReconcileSkipped", "status": "True", "controller": "c1",
+					},
+					map[string]interface{}{
+						"type": "ReconcileSkipped", "status": "True", "controller": "c2",
+					},
+				},
+			},
+			condType:   types.ReconcileSkippedCondition,
+			controller: "c2",
+			expectCond: map[string]interface{}{
+				"type": "ReconcileSkipped", "status": "True", "controller": "c2",
+			},
+			isFound: true,
+		},
+		"condition of other controller": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "ReconcileSkipped", "status": "True", "controller": "c1",
+					},
+				},
+			},
+			condType:   types.ReconcileSkippedCondition,
+			controller: "c2",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, found, err := GetControllerCondition(
+				mock.status, mock.condType, mock.controller,
+			)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if found != mock.isFound {
+				t.Fatalf("Expected found %t got %t", mock.isFound, found)
+			}
+			if diff := cmp.Diff(mock.expectCond, got); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+			if mock.controller != "" {
+				return
+			}
+			isTrue := IsConditionTrue(mock.status, mock.condType)
+			if isTrue != mock.expectIsTrue {
+				t.Fatalf("Expected is true %t got %t", mock.expectIsTrue, isTrue)
+			}
+		})
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	now = func() string {
+		return "now"
+	}
+	var tests = map[string]struct {
+		status       map[string]interface{}
+		cond         map[string]interface{}
+		expectStatus map[string]interface{}
+		isErr        bool
+	}{
+		"nil status": {
+			cond: map[string]interface{}{
+				"type": types.CStorPoolClusterDriftDetectedCondition, "status": "True",
+			},
+			expectStatus: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "DriftDetected", "status": "True", "lastTransitionTime": "now",
+					},
+				},
+			},
+		},
+		"condition without type": {
+			cond:  map[string]interface{}{"status": "True"},
+			isErr: true,
+		},
+		"invalid conditions": {
+			status: map[string]interface{}{"conditions": "junk"},
+			cond:   map[string]interface{}{"type": "DriftDetected", "status": "True"},
+			isErr:  true,
+		},
+		"same condition is retained as is": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "DriftDetected", "status": "True", "reason": "r1",
+						"lastObservedTime": "before", "lastTransitionTime": "before",
+					},
+				},
+			},
+			cond: map[string]interface{}{
+				"type": "DriftDetected", "status": "True", "reason": "r1",
+				"lastObservedTime": "after",
+			},
+			expectStatus: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "DriftDetected", "status": "True", "reason": "r1",
+						"lastObservedTime": "before", "lastTransitionTime": "before",
+					},
+				},
+			},
+		},
+		"changed reason retains the transition time": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "DriftDetected", "status": "True", "reason": "r1",
+						"lastTransitionTime": "before",
+					},
+				},
+			},
+			cond: map[string]interface{}{
+				"type": "DriftDetected", "status": "True", "reason": "r2",
+			},
+			expectStatus: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "DriftDetected", "status": "True", "reason": "r2",
+						"lastTransitionTime": "before",
+					},
+				},
+			},
+		},
+		"changed status sets the transition time": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "DriftDetected", "status": "True", "reason": "r1",
+						"lastTransitionTime": "before",
+					},
+				},
+			},
+			cond: map[string]interface{}{
+				"type":   types.CStorPoolClusterDriftDetectedCondition,
+				"status": types.ConditionIsAbsent,
+			},
+			expectStatus: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "DriftDetected", "status": "False",
+						"lastTransitionTime": "now",
+					},
+				},
+			},
+		},
+		"condition of other controller is retained": {
+			status: map[string]interface{}{
+				"phase": "Online",
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "ReconcileSkipped", "status": "True", "controller": "c1",
+					},
+				},
+			},
+			cond: map[string]interface{}{
+				"type": "ReconcileSkipped", "status": "True", "controller": "c2",
+			},
+			expectStatus: map[string]interface{}{
+				"phase": "Online",
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type": "ReconcileSkipped", "status": "True", "controller": "c1",
+					},
+					map[string]interface{}{
+						"type": "ReconcileSkipped", "status": "True", "controller": "c2",
+						"lastTransitionTime": "now",
+					},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := SetCondition(mock.status, mock.cond)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expectStatus, got); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRemoveControllerCondition(t *testing.T) {
+	var tests = map[string]struct {
+		status       map[string]interface{}
+		expectStatus map[string]interface{}
+	}{
+		"nil status": {},
+		"no conditions": {
+			status:       map[string]interface{}{"phase": "Online"},
+			expectStatus: map[string]interface{}{"phase": "Online"},
+		},
+		"condition of given controller is removed": {
+			status: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "ReconcileSkipped", "controller": "c1"},
+					map[string]interface{}{"type": "ReconcileSkipped", "controller": "c2"},
+					map[string]interface{}{"type": "DriftDetected"},
+				},
+			},
+			expectStatus: map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "ReconcileSkipped", "controller": "c2"},
+					map[string]interface{}{"type": "DriftDetected"},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := RemoveControllerCondition(
+				mock.status, types.ReconcileSkippedCondition, "c1",
+			)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expectStatus, got); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMergeCondition(t *testing.T) {
+	now = func() string {
+		return "now"
+	}
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"status": map[string]interface{}{
+				"phase": "Online",
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "DriftDetected", "status": "False"},
+				},
+			},
+		},
+	}
+	got, err := MergeCondition(
+		obj, map[string]interface{}{"type": "DriftDetected", "status": "True"},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	expect := []interface{}{
+		map[string]interface{}{
+			"type": "DriftDetected", "status": "True", "lastTransitionTime": "now",
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("Expected no diff got:\n%s", diff)
+	}
+	conds, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if conds[0].(map[string]interface{})["status"] != "False" {
+		t.Fatalf("Expected object to be unmodified got %v", conds)
+	}
+}
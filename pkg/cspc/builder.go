@@ -0,0 +1,372 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cspc builds unstructured instances of CStorPoolCluster
+// for each of its supported schema versions.
+package cspc
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/pkg/zfsproperty"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// SchemaVersion is the api version whose schema is followed by
+// the built CStorPoolCluster
+type SchemaVersion string
+
+const (
+	// SchemaV1 refers to the schema of cstor.openebs.io/v1
+	SchemaV1 SchemaVersion = SchemaVersion(types.APIVersionCStorOpenEBSV1)
+
+	// SchemaV1Alpha1 refers to the schema of openebs.io/v1alpha1
+	SchemaV1Alpha1 SchemaVersion = SchemaVersion(types.APIVersionOpenEBSV1Alpha1)
+)
+
+// schema has the keys & values that differ across schema versions
+type schema struct {
+	// raidGroupsKey is the key of raid groups of a pool
+	raidGroupsKey string
+
+	// raidGroupTypeKey is the key of raid group type in the pool
+	// config
+	raidGroupTypeKey string
+
+	// provisioningKey is the key of thick or over provisioning in
+	// the pool config
+	provisioningKey string
+
+	// isTypedRAIDGroup is true if each raid group specifies its
+	// type & roles
+	isTypedRAIDGroup bool
+
+	// isSingleStripeRAIDGroup is true if all the devices of a
+	// stripe pool form a single raid group
+	isSingleStripeRAIDGroup bool
+}
+
+// versionToSchema maps the supported schema versions to their
+// schema
+var versionToSchema = map[SchemaVersion]schema{
+	SchemaV1: {
+		raidGroupsKey:           "dataRaidGroups",
+		raidGroupTypeKey:        "dataRaidGroupType",
+		provisioningKey:         "thickProvision",
+		isSingleStripeRAIDGroup: true,
+	},
+	SchemaV1Alpha1: {
+		raidGroupsKey:    "raidGroups",
+		raidGroupTypeKey: "defaultRaidGroupType",
+		provisioningKey:  "overProvisioning",
+		isTypedRAIDGroup: true,
+	},
+}
+
+// pool is a single pool of the desired CStorPoolCluster
+type pool struct {
+	hostName string
+
+	// raidGroups are the raid groups that are set explicitly
+	raidGroups [][]string
+
+	// deviceNames are grouped into raid groups based on the
+	// raid type
+	deviceNames []string
+}
+
+// Builder builds an unstructured instance of CStorPoolCluster
+//
+// NOTE:
+//	Builder is used in a fluent manner e.g.
+// NewBuilder().WithPool(node).WithRaidGroup(devices...).WithRAIDType(...)
+// The first error if any is returned by Build.
+type Builder struct {
+	version     SchemaVersion
+	name        string
+	namespace   string
+	labels      map[string]string
+	annotations map[string]string
+	raidType    types.PoolRAIDType
+	pools       []*pool
+	err         error
+
+	// zfsProperties are set against the pool config of every pool
+	zfsProperties map[string]string
+
+	// nodeSelectorKey is the node label used by every pool to
+	// select its node
+	nodeSelectorKey string
+
+	// raidGroupDeviceCount is the number of devices per raid group.
+	// This defaults to the disk count of raid groups of the raid
+	// type if not set.
+	raidGroupDeviceCount int64
+}
+
+// NewBuilder returns a new instance of Builder that follows the
+// v1 schema by default
+func NewBuilder() *Builder {
+	return &Builder{
+		version: SchemaV1,
+	}
+}
+
+// WithSchema sets the schema version of the CStorPoolCluster
+func (b *Builder) WithSchema(version SchemaVersion) *Builder {
+	b.version = version
+	return b
+}
+
+// WithName sets the name of the CStorPoolCluster
+func (b *Builder) WithName(name string) *Builder {
+	b.name = name
+	return b
+}
+
+// WithNamespace sets the namespace of the CStorPoolCluster
+func (b *Builder) WithNamespace(namespace string) *Builder {
+	b.namespace = namespace
+	return b
+}
+
+// WithLabels sets the labels of the CStorPoolCluster
+func (b *Builder) WithLabels(labels map[string]string) *Builder {
+	b.labels = labels
+	return b
+}
+
+// WithAnnotations sets the annotations of the CStorPoolCluster
+func (b *Builder) WithAnnotations(annotations map[string]string) *Builder {
+	b.annotations = annotations
+	return b
+}
+
+// WithRAIDType sets the raid type of all the pools
+func (b *Builder) WithRAIDType(raidType types.PoolRAIDType) *Builder {
+	b.raidType = raidType
+	return b
+}
+
+// WithRAIDGroupDeviceCount sets the number of devices per raid
+// group of all the pools. Raid groups are sized as per the raid type
+// if this is not set.
+func (b *Builder) WithRAIDGroupDeviceCount(count int64) *Builder {
+	b.raidGroupDeviceCount = count
+	return b
+}
+
+// WithZFSProperties sets the ZFS properties of all the pools.
+// Properties that are not supported by CStorPoolCluster are left out.
+func (b *Builder) WithZFSProperties(properties map[string]string) *Builder {
+	b.zfsProperties = properties
+	return b
+}
+
+// WithNodeSelectorKey sets the node label used by all the pools to
+// select their nodes. Host names of the pools are the values of this
+// label. Pools select their nodes by kubernetes.io/hostname if this
+// is not set.
+func (b *Builder) WithNodeSelectorKey(key string) *Builder {
+	b.nodeSelectorKey = key
+	return b
+}
+
+// WithPool adds a pool on the node with the given host name.
+// Subsequent raid groups & devices are added to this pool.
+func (b *Builder) WithPool(hostName string) *Builder {
+	if hostName == "" && b.err == nil {
+		b.err = errors.Errorf("Can't add pool: Missing host name")
+	}
+	b.pools = append(b.pools, &pool{hostName: hostName})
+	return b
+}
+
+// WithRaidGroup adds a raid group formed by the given devices to
+// the last added pool
+func (b *Builder) WithRaidGroup(deviceNames ...string) *Builder {
+	if len(b.pools) == 0 {
+		if b.err == nil {
+			b.err = errors.Errorf("Can't add raid group: Missing pool")
+		}
+		return b
+	}
+	last := b.pools[len(b.pools)-1]
+	last.raidGroups = append(last.raidGroups, deviceNames)
+	return b
+}
+
+// WithDevices adds the given devices to the last added pool. These
+// devices are grouped into raid groups based on the raid type.
+func (b *Builder) WithDevices(deviceNames ...string) *Builder {
+	if len(b.pools) == 0 {
+		if b.err == nil {
+			b.err = errors.Errorf("Can't add devices: Missing pool")
+		}
+		return b
+	}
+	last := b.pools[len(b.pools)-1]
+	last.deviceNames = append(last.deviceNames, deviceNames...)
+	return b
+}
+
+// groupDeviceNames groups the given devices into raid groups based
+// on the raid type
+//
+// NOTE:
+//	Disks get distributed as follows:
+// 	- Mirror has 2 disks per raid group
+// 	- Striped mirror has 2 disks per raid group
+//	- RAIDZ has 3 disks per raid group
+//  - RAIDZ2 has 6 disks per raid group
+//  - Stripe has 1 disk per raid group unless the schema forms a
+// single raid group of all the disks
+//
+// Raid group device count if set overrides the above.
+//
+// Disks that can not form a complete raid group are left out.
+func (b *Builder) groupDeviceNames(s schema, deviceNames []string) [][]string {
+	if len(deviceNames) == 0 {
+		return nil
+	}
+	if b.raidType == types.PoolRAIDTypeStripe && s.isSingleStripeRAIDGroup {
+		return [][]string{deviceNames}
+	}
+	var groups [][]string
+	var group []string
+	diskCountPerGroup := int(b.getRAIDGroupDeviceCount())
+	for idx, deviceName := range deviceNames {
+		group = append(group, deviceName)
+		if (idx+1)%diskCountPerGroup == 0 {
+			groups = append(groups, group)
+			// reset to build the next raid group of this pool
+			group = nil
+		}
+	}
+	return groups
+}
+
+// getRAIDGroupDeviceCount returns the number of devices per raid
+// group
+func (b *Builder) getRAIDGroupDeviceCount() int64 {
+	if b.raidGroupDeviceCount > 0 {
+		return b.raidGroupDeviceCount
+	}
+	return types.RAIDTypeToRAIDGroupDiskCount[b.raidType]
+}
+
+// buildRAIDGroup builds a raid group formed by the given devices
+func (b *Builder) buildRAIDGroup(s schema, deviceNames []string) interface{} {
+	var blockDevices []interface{}
+	for _, deviceName := range deviceNames {
+		blockDevices = append(blockDevices, map[string]interface{}{
+			"blockDeviceName": deviceName,
+		})
+	}
+	if !s.isTypedRAIDGroup {
+		return map[string]interface{}{
+			"blockDevices": blockDevices,
+		}
+	}
+	return map[string]interface{}{
+		"type":         string(types.RAIDTypeToRAIDGroupType[b.raidType]),
+		"isWriteCache": false,
+		"isSpare":      false,
+		"isReadCache":  false,
+		"blockDevices": blockDevices,
+	}
+}
+
+// buildPool builds a single pool of the CStorPoolCluster
+func (b *Builder) buildPool(s schema, p *pool) interface{} {
+	var raidGroups []interface{}
+	groups := append([][]string{}, p.raidGroups...)
+	groups = append(groups, b.groupDeviceNames(s, p.deviceNames)...)
+	for _, group := range groups {
+		raidGroups = append(raidGroups, b.buildRAIDGroup(s, group))
+	}
+	poolConfig := map[string]interface{}{
+		s.raidGroupTypeKey: string(types.RAIDTypeToRAIDGroupType[b.raidType]),
+		s.provisioningKey:  false,
+		"compression":      "off",
+	}
+	// properties of the config override the defaults
+	for key, value := range zfsproperty.ToPoolConfig(b.zfsProperties) {
+		poolConfig[key] = value
+	}
+	nodeSelectorKey := b.nodeSelectorKey
+	if nodeSelectorKey == "" {
+		nodeSelectorKey = types.DefaultNodeSelectorKey
+	}
+	return map[string]interface{}{
+		"nodeSelector": map[string]interface{}{
+			nodeSelectorKey: p.hostName,
+		},
+		s.raidGroupsKey: raidGroups,
+		"poolConfig":    poolConfig,
+	}
+}
+
+// Build returns the CStorPoolCluster or the first error that was
+// found while building it
+func (b *Builder) Build() (*unstructured.Unstructured, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	s, found := versionToSchema[b.version]
+	if !found {
+		return nil, errors.Errorf(
+			"Can't build CStorPoolCluster: Unsupported schema %q", b.version,
+		)
+	}
+	if types.RAIDTypeToRAIDGroupDiskCount[b.raidType] == 0 {
+		return nil, errors.Errorf(
+			"Can't build CStorPoolCluster: Unsupported raid type %q", b.raidType,
+		)
+	}
+	err := types.ValidateRAIDGroupConfig(
+		b.raidType,
+		&types.PoolRAIDGroupConfig{GroupDeviceCount: b.raidGroupDeviceCount},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't build CStorPoolCluster")
+	}
+	var pools []interface{}
+	for _, p := range b.pools {
+		pools = append(pools, b.buildPool(s, p))
+	}
+	cspc := &unstructured.Unstructured{}
+	cspc.SetUnstructuredContent(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      b.name,
+			"namespace": b.namespace,
+		},
+		"spec": map[string]interface{}{
+			"pools": pools,
+		},
+	})
+	if len(b.annotations) != 0 {
+		cspc.SetAnnotations(b.annotations)
+	}
+	if len(b.labels) != 0 {
+		cspc.SetLabels(b.labels)
+	}
+	// below is the right way to set APIVersion & Kind
+	cspc.SetAPIVersion(string(b.version))
+	cspc.SetKind(string(types.KindCStorPoolCluster))
+	return cspc, nil
+}
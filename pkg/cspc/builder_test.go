@@ -0,0 +1,588 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cspc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestBuilderBuild(t *testing.T) {
+	var tests = map[string]struct {
+		builder *Builder
+		expect  *unstructured.Unstructured
+		isErr   bool
+	}{
+		"raid group without pool": {
+			builder: NewBuilder().
+				WithRAIDType(types.PoolRAIDTypeMirror).
+				WithRaidGroup("bd1", "bd2"),
+			isErr: true,
+		},
+		"pool without host name": {
+			builder: NewBuilder().
+				WithRAIDType(types.PoolRAIDTypeMirror).
+				WithPool(""),
+			isErr: true,
+		},
+		"missing raid type": {
+			builder: NewBuilder().WithPool("node-1"),
+			isErr:   true,
+		},
+		"unsupported schema": {
+			builder: NewBuilder().
+				WithSchema("openebs.io/v2").
+				WithRAIDType(types.PoolRAIDTypeMirror),
+			isErr: true,
+		},
+		"v1 schema with explicit raid groups": {
+			builder: NewBuilder().
+				WithName("my-cspc").
+				WithNamespace("openebs").
+				WithLabels(map[string]string{"app": "cstor"}).
+				WithRAIDType(types.PoolRAIDTypeStripedMirror).
+				WithPool("node-1").
+				WithRaidGroup("bd1", "bd2").
+				WithRaidGroup("bd3", "bd4"),
+			expect: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "cstor.openebs.io/v1",
+					"kind":       "CStorPoolCluster",
+					"metadata": map[string]interface{}{
+						"name":      "my-cspc",
+						"namespace": "openebs",
+						"labels": map[string]interface{}{
+							"app": "cstor",
+						},
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-1",
+								},
+								"dataRaidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd1",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd2",
+											},
+										},
+									},
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd3",
+											},
+											map[string]interface{}{
+												"blockDeviceName": "bd4",
+											},
+										},
+									},
+								},
+								"poolConfig": map[string]interface{}{
+									"dataRaidGroupType": "mirror",
+									"thickProvision":    false,
+									"compression":       "off",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"v1alpha1 schema with stripe devices": {
+			builder: NewBuilder().
+				WithSchema(SchemaV1Alpha1).
+				WithName("my-cspc").
+				WithNamespace("openebs").
+				WithAnnotations(map[string]string{"owner": "me"}).
+				WithPool("node-1").
+				WithDevices("bd1", "bd2").
+				WithRAIDType(types.PoolRAIDTypeStripe),
+			expect: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "openebs.io/v1alpha1",
+					"kind":       "CStorPoolCluster",
+					"metadata": map[string]interface{}{
+						"name":      "my-cspc",
+						"namespace": "openebs",
+						"annotations": map[string]interface{}{
+							"owner": "me",
+						},
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-1",
+								},
+								"raidGroups": []interface{}{
+									map[string]interface{}{
+										"type":         "stripe",
+										"isWriteCache": false,
+										"isSpare":      false,
+										"isReadCache":  false,
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd1",
+											},
+										},
+									},
+									map[string]interface{}{
+										"type":         "stripe",
+										"isWriteCache": false,
+										"isSpare":      false,
+										"isReadCache":  false,
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd2",
+											},
+										},
+									},
+								},
+								"poolConfig": map[string]interface{}{
+									"defaultRaidGroupType": "stripe",
+									"overProvisioning":     false,
+									"compression":          "off",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"v1 schema with zfs properties": {
+			builder: NewBuilder().
+				WithName("my-cspc").
+				WithNamespace("openebs").
+				WithRAIDType(types.PoolRAIDTypeStripe).
+				WithZFSProperties(map[string]string{
+					"compression": "lz4",
+					"recordsize":  "64K",
+				}).
+				WithPool("node-1").
+				WithDevices("bd1"),
+			expect: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "cstor.openebs.io/v1",
+					"kind":       "CStorPoolCluster",
+					"metadata": map[string]interface{}{
+						"name":      "my-cspc",
+						"namespace": "openebs",
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"kubernetes.io/hostname": "node-1",
+								},
+								"dataRaidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd1",
+											},
+										},
+									},
+								},
+								"poolConfig": map[string]interface{}{
+									"dataRaidGroupType": "stripe",
+									"thickProvision":    false,
+									"compression":       "lz4",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"v1 schema with custom node selector key": {
+			builder: NewBuilder().
+				WithName("my-cspc").
+				WithNamespace("openebs").
+				WithRAIDType(types.PoolRAIDTypeStripe).
+				WithNodeSelectorKey("example.com/hostname").
+				WithPool("host-1").
+				WithDevices("bd1"),
+			expect: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "cstor.openebs.io/v1",
+					"kind":       "CStorPoolCluster",
+					"metadata": map[string]interface{}{
+						"name":      "my-cspc",
+						"namespace": "openebs",
+					},
+					"spec": map[string]interface{}{
+						"pools": []interface{}{
+							map[string]interface{}{
+								"nodeSelector": map[string]interface{}{
+									"example.com/hostname": "host-1",
+								},
+								"dataRaidGroups": []interface{}{
+									map[string]interface{}{
+										"blockDevices": []interface{}{
+											map[string]interface{}{
+												"blockDeviceName": "bd1",
+											},
+										},
+									},
+								},
+								"poolConfig": map[string]interface{}{
+									"dataRaidGroupType": "stripe",
+									"thickProvision":    false,
+									"compression":       "off",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := mock.builder.Build()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if !reflect.DeepEqual(got, mock.expect) {
+				t.Fatalf("Expected no diff got\n%s", cmp.Diff(got, mock.expect))
+			}
+		})
+	}
+}
+
+func TestBuilderWithDevices(t *testing.T) {
+	var tests = map[string]struct {
+		raidType    types.PoolRAIDType
+		deviceNames []string
+		expect      interface{}
+	}{
+		"stripe with  n disks where n=1 disks": {
+			raidType:    types.PoolRAIDTypeStripe,
+			deviceNames: []string{"bd1"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+					},
+				},
+			},
+		},
+		"stripe with  n disks where n>1 disks": {
+			raidType:    types.PoolRAIDTypeStripe,
+			deviceNames: []string{"bd1", "bd2", "bd3"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd2",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd3",
+						},
+					},
+				},
+			},
+		},
+		"mirror with  2n disks where n=1 disks": {
+			raidType:    types.PoolRAIDTypeMirror,
+			deviceNames: []string{"bd1", "bd2"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd2",
+						},
+					},
+				},
+			},
+		},
+		"mirror with  2n disks where n>1 disks": {
+			raidType:    types.PoolRAIDTypeMirror,
+			deviceNames: []string{"bd1", "bd2", "bd3", "bd4"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd2",
+						},
+					},
+				},
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd3",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd4",
+						},
+					},
+				},
+			},
+		},
+		"raidz with 2n+1 disks where n=1 disks": {
+			raidType:    types.PoolRAIDTypeRAIDZ,
+			deviceNames: []string{"bd1", "bd2", "bd3"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd2",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd3",
+						},
+					},
+				},
+			},
+		},
+		"raidz with 2n+1 disks where n>1 disks": {
+			raidType:    types.PoolRAIDTypeRAIDZ,
+			deviceNames: []string{"bd1", "bd2", "bd3", "bd4", "bd5", "bd6"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd2",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd3",
+						},
+					},
+				},
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd4",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd5",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd6",
+						},
+					},
+				},
+			},
+		},
+		"raidz2 with 2n+2 disks where n=2 disks": {
+			raidType:    types.PoolRAIDTypeRAIDZ2,
+			deviceNames: []string{"bd1", "bd2", "bd3", "bd4", "bd5", "bd6"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd2",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd3",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd4",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd5",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd6",
+						},
+					},
+				},
+			},
+		},
+		"raidz2 with 2n+2 disks where n>2 disks": {
+			raidType:    types.PoolRAIDTypeRAIDZ2,
+			deviceNames: []string{"bd1", "bd2", "bd3", "bd4", "bd5", "bd6", "bd7", "bd8", "bd9", "bd10", "bd11", "bd12"},
+			expect: []interface{}{
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd1",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd2",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd3",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd4",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd5",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd6",
+						},
+					},
+				},
+				map[string]interface{}{
+					"blockDevices": []interface{}{
+						map[string]interface{}{
+							"blockDeviceName": "bd7",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd8",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd9",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd10",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd11",
+						},
+						map[string]interface{}{
+							"blockDeviceName": "bd12",
+						},
+					},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			obj, err := NewBuilder().
+				WithRAIDType(mock.raidType).
+				WithPool("node-001").
+				WithDevices(mock.deviceNames...).
+				Build()
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			pools, _, _ := unstructured.NestedSlice(obj.Object, "spec", "pools")
+			got := pools[0].(map[string]interface{})["dataRaidGroups"]
+			if !reflect.DeepEqual(got, mock.expect) {
+				t.Fatalf("Expected no diff got\n%s", cmp.Diff(got, mock.expect))
+			}
+		})
+	}
+}
+
+func TestBuilderWithRAIDGroupDeviceCount(t *testing.T) {
+	var tests = map[string]struct {
+		raidType    types.PoolRAIDType
+		count       int64
+		deviceNames []string
+		expect      [][]string
+		isErr       bool
+	}{
+		"raidz with 4 disks per group": {
+			raidType:    types.PoolRAIDTypeRAIDZ,
+			count:       4,
+			deviceNames: []string{"bd1", "bd2", "bd3", "bd4", "bd5", "bd6", "bd7", "bd8"},
+			expect:      [][]string{{"bd1", "bd2", "bd3", "bd4"}, {"bd5", "bd6", "bd7", "bd8"}},
+		},
+		"mirror with 4 disks per group": {
+			raidType:    types.PoolRAIDTypeMirror,
+			count:       4,
+			deviceNames: []string{"bd1", "bd2", "bd3", "bd4"},
+			expect:      [][]string{{"bd1", "bd2", "bd3", "bd4"}},
+		},
+		"mirror with 3 disks per group": {
+			raidType:    types.PoolRAIDTypeMirror,
+			count:       3,
+			deviceNames: []string{"bd1", "bd2", "bd3"},
+			isErr:       true,
+		},
+		"raidz2 with 5 disks per group": {
+			raidType:    types.PoolRAIDTypeRAIDZ2,
+			count:       5,
+			deviceNames: []string{"bd1", "bd2", "bd3", "bd4", "bd5"},
+			isErr:       true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			obj, err := NewBuilder().
+				WithRAIDType(mock.raidType).
+				WithRAIDGroupDeviceCount(mock.count).
+				WithPool("node-001").
+				WithDevices(mock.deviceNames...).
+				Build()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			var expect []interface{}
+			for _, group := range mock.expect {
+				var devices []interface{}
+				for _, name := range group {
+					devices = append(devices, map[string]interface{}{
+						"blockDeviceName": name,
+					})
+				}
+				expect = append(expect, map[string]interface{}{
+					"blockDevices": devices,
+				})
+			}
+			pools, _, _ := unstructured.NestedSlice(obj.Object, "spec", "pools")
+			got := pools[0].(map[string]interface{})["dataRaidGroups"]
+			if !reflect.DeepEqual(got, expect) {
+				t.Fatalf("Expected no diff got\n%s", cmp.Diff(got, expect))
+			}
+		})
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errors classifies the errors of reconciliations.
+//
+// NOTE:
+//	Controllers make use of this classification to decide when
+// to resync & to report the reason of a failed reconciliation.
+// A ValidationError needs a change in the spec to be fixed. Hence,
+// it is not retried. Other errors are expected to go away on their
+// own & are retried after an interval that suits their type.
+package errors
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Type classifies an error
+type Type string
+
+const (
+	// TypeValidation refers to an error due to an invalid spec
+	TypeValidation Type = "ValidationError"
+
+	// TypeNotEnoughResources refers to an error due to resources
+	// e.g. nodes, block devices that are not available yet
+	TypeNotEnoughResources Type = "NotEnoughResourcesError"
+
+	// TypeTransient refers to an error that is expected to go
+	// away on its own e.g. stale cache, API server timeouts
+	TypeTransient Type = "TransientError"
+
+	// TypeConflict refers to an error due to concurrent changes
+	// made to the same resource
+	TypeConflict Type = "ConflictError"
+
+	// TypeTimeout refers to an error due to a sync that did not
+	// finish within its deadline
+	TypeTimeout Type = "TimeoutError"
+
+	// TypeUnknown refers to an error that is not classified
+	TypeUnknown Type = "ReconcileError"
+)
+
+// TypeToResyncAfterSeconds maps the type of error to the interval
+// after which the failed reconciliation is retried
+//
+// NOTE:
+//	Types that are not found here are not retried. These are
+// reconciled again when the watch changes or when metac resyncs.
+var TypeToResyncAfterSeconds = map[Type]float64{
+	TypeNotEnoughResources: 30,
+	TypeTransient:          5,
+	TypeConflict:           1,
+	TypeTimeout:            2,
+}
+
+// ValidationError is returned when the spec is invalid
+type ValidationError struct {
+	cause error
+}
+
+// NotEnoughResourcesError is returned when the resources needed
+// to reconcile are not available yet
+type NotEnoughResourcesError struct {
+	cause error
+}
+
+// TransientError is returned when the error is expected to go
+// away on its own
+type TransientError struct {
+	cause error
+}
+
+// ConflictError is returned when the resource was changed
+// concurrently
+type ConflictError struct {
+	cause error
+}
+
+// TimeoutError is returned when the sync did not finish within its
+// deadline
+type TimeoutError struct {
+	cause error
+}
+
+// Error implements error interface
+func (e *ValidationError) Error() string { return e.cause.Error() }
+
+// Cause returns the underlying error
+func (e *ValidationError) Cause() error { return e.cause }
+
+// Error implements error interface
+func (e *NotEnoughResourcesError) Error() string { return e.cause.Error() }
+
+// Cause returns the underlying error
+func (e *NotEnoughResourcesError) Cause() error { return e.cause }
+
+// Error implements error interface
+func (e *TransientError) Error() string { return e.cause.Error() }
+
+// Cause returns the underlying error
+func (e *TransientError) Cause() error { return e.cause }
+
+// Error implements error interface
+func (e *ConflictError) Error() string { return e.cause.Error() }
+
+// Cause returns the underlying error
+func (e *ConflictError) Cause() error { return e.cause }
+
+// Error implements error interface
+func (e *TimeoutError) Error() string { return e.cause.Error() }
+
+// Cause returns the underlying error
+func (e *TimeoutError) Cause() error { return e.cause }
+
+// ValidationErrorf returns a new ValidationError with the given
+// message
+func ValidationErrorf(format string, args ...interface{}) error {
+	return &ValidationError{cause: errors.Errorf(format, args...)}
+}
+
+// NotEnoughResourcesErrorf returns a new NotEnoughResourcesError
+// with the given message
+func NotEnoughResourcesErrorf(format string, args ...interface{}) error {
+	return &NotEnoughResourcesError{cause: errors.Errorf(format, args...)}
+}
+
+// TransientErrorf returns a new TransientError with the given
+// message
+func TransientErrorf(format string, args ...interface{}) error {
+	return &TransientError{cause: errors.Errorf(format, args...)}
+}
+
+// ConflictErrorf returns a new ConflictError with the given
+// message
+func ConflictErrorf(format string, args ...interface{}) error {
+	return &ConflictError{cause: errors.Errorf(format, args...)}
+}
+
+// TimeoutErrorf returns a new TimeoutError with the given message
+func TimeoutErrorf(format string, args ...interface{}) error {
+	return &TimeoutError{cause: errors.Errorf(format, args...)}
+}
+
+// AsValidationError classifies the given error as a ValidationError
+func AsValidationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ValidationError{cause: err}
+}
+
+// AsNotEnoughResourcesError classifies the given error as a
+// NotEnoughResourcesError
+func AsNotEnoughResourcesError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &NotEnoughResourcesError{cause: err}
+}
+
+// AsTransientError classifies the given error as a TransientError
+func AsTransientError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TransientError{cause: err}
+}
+
+// AsConflictError classifies the given error as a ConflictError
+func AsConflictError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ConflictError{cause: err}
+}
+
+// AsTimeoutError classifies the given error as a TimeoutError
+func AsTimeoutError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TimeoutError{cause: err}
+}
+
+// CheckContext returns an error if the given context of a sync is
+// done. A sync that ran past its deadline results in a TimeoutError
+// while a cancelled sync results in a TransientError. Nil is returned
+// if the context is nil.
+//
+// NOTE:
+//	Reconcilers check their context between their steps & while
+// iterating over large lists. Hence a sync that overruns its deadline
+// stops early & is retried soon.
+func CheckContext(ctx context.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return TimeoutErrorf("Sync deadline exceeded")
+	default:
+		return TransientErrorf("Sync cancelled: %v", ctx.Err())
+	}
+}
+
+// TypeOf returns the type of the given error
+//
+// NOTE:
+//	Outermost classification wins if the error was classified more
+// than once. Errors returned by kubernetes API server are classified
+// based on their status.
+func TypeOf(err error) Type {
+	for err != nil {
+		switch err.(type) {
+		case *ValidationError:
+			return TypeValidation
+		case *NotEnoughResourcesError:
+			return TypeNotEnoughResources
+		case *TransientError:
+			return TypeTransient
+		case *ConflictError:
+			return TypeConflict
+		case *TimeoutError:
+			return TypeTimeout
+		}
+		if apierrors.IsConflict(err) {
+			return TypeConflict
+		}
+		if apierrors.IsServerTimeout(err) ||
+			apierrors.IsTimeout(err) ||
+			apierrors.IsTooManyRequests(err) ||
+			apierrors.IsServiceUnavailable(err) ||
+			apierrors.IsInternalError(err) {
+			return TypeTransient
+		}
+		cause, ok := err.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		err = cause.Cause()
+	}
+	return TypeUnknown
+}
+
+// Reason returns the reason of the given error that is suitable to
+// be used in API status.conditions
+func Reason(err error) string {
+	return string(TypeOf(err))
+}
+
+// IsRetryable returns true if the reconciliation that failed with
+// the given error should be retried
+func IsRetryable(err error) bool {
+	return ResyncAfterSeconds(err) > 0
+}
+
+// ResyncAfterSeconds returns the interval after which the
+// reconciliation that failed with the given error should be retried.
+// It returns 0 if the reconciliation should not be retried.
+func ResyncAfterSeconds(err error) float64 {
+	return TypeToResyncAfterSeconds[TypeOf(err)]
+}
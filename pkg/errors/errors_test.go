@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestTypeOf(t *testing.T) {
+	var gr = schema.GroupResource{Group: "dao.mayadata.io", Resource: "storages"}
+	var tests = map[string]struct {
+		err         error
+		expectType  Type
+		isRetryable bool
+	}{
+		"nil error": {
+			err:        nil,
+			expectType: TypeUnknown,
+		},
+		"plain error": {
+			err:        errors.Errorf("oops"),
+			expectType: TypeUnknown,
+		},
+		"validation error": {
+			err:        ValidationErrorf("Invalid MinPoolCount %d", -1),
+			expectType: TypeValidation,
+		},
+		"not enough resources error": {
+			err:         NotEnoughResourcesErrorf("0 of %d block devices selected", 3),
+			expectType:  TypeNotEnoughResources,
+			isRetryable: true,
+		},
+		"transient error": {
+			err:         TransientErrorf("Missing attachment"),
+			expectType:  TypeTransient,
+			isRetryable: true,
+		},
+		"conflict error": {
+			err:         ConflictErrorf("Found %d devices", 2),
+			expectType:  TypeConflict,
+			isRetryable: true,
+		},
+		"timeout error": {
+			err:         errors.Wrapf(TimeoutErrorf("Sync deadline exceeded"), "Can't reconcile"),
+			expectType:  TypeTimeout,
+			isRetryable: true,
+		},
+		"wrapped validation error": {
+			err:        errors.Wrapf(ValidationErrorf("Invalid RAID type"), "Can't reconcile"),
+			expectType: TypeValidation,
+		},
+		"outermost classification wins": {
+			err:         AsTransientError(errors.Wrapf(ValidationErrorf("Invalid"), "Retry")),
+			expectType:  TypeTransient,
+			isRetryable: true,
+		},
+		"api conflict error": {
+			err:         apierrors.NewConflict(gr, "my-storage", errors.Errorf("stale")),
+			expectType:  TypeConflict,
+			isRetryable: true,
+		},
+		"wrapped api timeout error": {
+			err: errors.Wrapf(
+				apierrors.NewServerTimeout(gr, "update", 1), "Can't update",
+			),
+			expectType:  TypeTransient,
+			isRetryable: true,
+		},
+		"api not found error": {
+			err:        apierrors.NewNotFound(gr, "my-storage"),
+			expectType: TypeUnknown,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := TypeOf(mock.err)
+			if got != mock.expectType {
+				t.Fatalf("Expected type %q got %q", mock.expectType, got)
+			}
+			if Reason(mock.err) != string(mock.expectType) {
+				t.Fatalf("Expected reason %q got %q", mock.expectType, Reason(mock.err))
+			}
+			if IsRetryable(mock.err) != mock.isRetryable {
+				t.Fatalf(
+					"Expected retryable %t got %t",
+					mock.isRetryable, IsRetryable(mock.err),
+				)
+			}
+		})
+	}
+}
+
+func TestAsError(t *testing.T) {
+	var tests = map[string]struct {
+		as         func(error) error
+		expectType Type
+	}{
+		"as validation error": {
+			as:         AsValidationError,
+			expectType: TypeValidation,
+		},
+		"as not enough resources error": {
+			as:         AsNotEnoughResourcesError,
+			expectType: TypeNotEnoughResources,
+		},
+		"as transient error": {
+			as:         AsTransientError,
+			expectType: TypeTransient,
+		},
+		"as conflict error": {
+			as:         AsConflictError,
+			expectType: TypeConflict,
+		},
+		"as timeout error": {
+			as:         AsTimeoutError,
+			expectType: TypeTimeout,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			if mock.as(nil) != nil {
+				t.Fatalf("Expected nil error got [%+v]", mock.as(nil))
+			}
+			cause := errors.Errorf("oops")
+			err := mock.as(cause)
+			if err.Error() != cause.Error() {
+				t.Fatalf("Expected message %q got %q", cause.Error(), err.Error())
+			}
+			if errors.Cause(err) != cause {
+				t.Fatalf("Expected cause [%+v] got [%+v]", cause, errors.Cause(err))
+			}
+			if TypeOf(err) != mock.expectType {
+				t.Fatalf("Expected type %q got %q", mock.expectType, TypeOf(err))
+			}
+		})
+	}
+}
+
+func TestCheckContext(t *testing.T) {
+	expired, cancelExpired := context.WithTimeout(context.Background(), -time.Second)
+	defer cancelExpired()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	var tests = map[string]struct {
+		ctx        context.Context
+		isErr      bool
+		expectType Type
+	}{
+		"nil context": {},
+		"context without deadline": {
+			ctx: context.Background(),
+		},
+		"deadline exceeded": {
+			ctx:        expired,
+			isErr:      true,
+			expectType: TypeTimeout,
+		},
+		"cancelled": {
+			ctx:        cancelled,
+			isErr:      true,
+			expectType: TypeTransient,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := CheckContext(mock.ctx)
+			if !mock.isErr {
+				if err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+				return
+			}
+			if TypeOf(err) != mock.expectType {
+				t.Fatalf("Expected type %q got %q", mock.expectType, TypeOf(err))
+			}
+		})
+	}
+}
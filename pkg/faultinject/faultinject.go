@@ -0,0 +1,278 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faultinject fails or delays the phases of reconcilers by
+// percentage. This is meant for chaos tests that verify the
+// behaviour of controllers & the accuracy of their status conditions
+// when a reconciliation fails partially.
+//
+// NOTE:
+//	Faults are injected only if rules are set e.g. via
+// --fault-injection flag. Injecting into a phase without any rule
+// is a no-op.
+package faultinject
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+// Phase represents a phase of reconciliation that faults can be
+// injected into
+type Phase string
+
+const (
+	// PhaseDeviceSelection selects the block devices of pools
+	PhaseDeviceSelection Phase = "device-selection"
+
+	// PhasePlanComputation computes the nodes of CStorClusterPlan
+	PhasePlanComputation Phase = "plan-computation"
+
+	// PhaseCSPCBuild builds the desired CStorPoolCluster
+	PhaseCSPCBuild Phase = "cspc-build"
+)
+
+// SupportedPhases is the set of phases that faults can be injected
+// into
+var SupportedPhases = map[Phase]bool{
+	PhaseDeviceSelection: true,
+	PhasePlanComputation: true,
+	PhaseCSPCBuild:       true,
+}
+
+// Action represents the fault that is injected
+type Action string
+
+const (
+	// ActionFail fails the phase with a transient error
+	ActionFail Action = "fail"
+
+	// ActionDelay delays the phase by the delay of its rule
+	ActionDelay Action = "delay"
+)
+
+// Rule injects its action into its phase for the given percentage
+// of invocations
+type Rule struct {
+	Phase   Phase
+	Action  Action
+	Percent int
+
+	// Delay is used only by ActionDelay
+	Delay time.Duration
+}
+
+// ParseRules returns the rules of the given comma separated spec.
+// Each rule has the format <phase>=fail:<percent> or
+// <phase>=delay:<percent>:<duration> e.g.
+// device-selection=fail:10,cspc-build=delay:50:2s
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		rule, err := parseRule(item)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseRule returns the rule of the given item of a spec
+func parseRule(item string) (Rule, error) {
+	kv := strings.SplitN(item, "=", 2)
+	if len(kv) != 2 {
+		return Rule{}, errs.ValidationErrorf(
+			"Invalid fault injection rule %q: Want <phase>=<action>:<percent>[:<delay>]",
+			item,
+		)
+	}
+	rule := Rule{Phase: Phase(strings.TrimSpace(kv[0]))}
+	if !SupportedPhases[rule.Phase] {
+		return Rule{}, errs.ValidationErrorf(
+			"Invalid fault injection rule %q: Unsupported phase %q: Supports %q, %q or %q",
+			item, rule.Phase, PhaseDeviceSelection, PhasePlanComputation, PhaseCSPCBuild,
+		)
+	}
+	parts := strings.Split(kv[1], ":")
+	rule.Action = Action(parts[0])
+	var wantParts int
+	switch rule.Action {
+	case ActionFail:
+		wantParts = 2
+	case ActionDelay:
+		wantParts = 3
+	default:
+		return Rule{}, errs.ValidationErrorf(
+			"Invalid fault injection rule %q: Unsupported action %q: Supports %q or %q",
+			item, rule.Action, ActionFail, ActionDelay,
+		)
+	}
+	if len(parts) != wantParts {
+		return Rule{}, errs.ValidationErrorf(
+			"Invalid fault injection rule %q: Want %d parts for action %q got %d",
+			item, wantParts, rule.Action, len(parts),
+		)
+	}
+	percent, err := strconv.Atoi(parts[1])
+	if err != nil || percent < 0 || percent > 100 {
+		return Rule{}, errs.ValidationErrorf(
+			"Invalid fault injection rule %q: Invalid percent %q: Want 0 to 100",
+			item, parts[1],
+		)
+	}
+	rule.Percent = percent
+	if rule.Action == ActionDelay {
+		rule.Delay, err = time.ParseDuration(parts[2])
+		if err != nil || rule.Delay <= 0 {
+			return Rule{}, errs.ValidationErrorf(
+				"Invalid fault injection rule %q: Invalid delay %q",
+				item, parts[2],
+			)
+		}
+	}
+	return rule, nil
+}
+
+// Injector injects faults into the phases as per its rules
+type Injector struct {
+	mutex sync.Mutex
+	rules map[Phase][]Rule
+
+	// counts of injected faults by phase & action
+	injected map[Phase]map[Action]int
+
+	// Roll returns a number from 0 to 99; defaults to a random
+	// number
+	Roll func() int
+
+	// Sleep blocks for the given duration; defaults to time.Sleep
+	Sleep func(time.Duration)
+}
+
+// NewInjector returns a new instance of Injector with the given
+// rules
+func NewInjector(rules []Rule) *Injector {
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	i := &Injector{
+		rules:    map[Phase][]Rule{},
+		injected: map[Phase]map[Action]int{},
+		Roll:     func() int { return random.Intn(100) },
+		Sleep:    time.Sleep,
+	}
+	for _, rule := range rules {
+		i.rules[rule.Phase] = append(i.rules[rule.Phase], rule)
+	}
+	return i
+}
+
+// Inject applies the rules of the given phase. It returns a
+// transient error if a fail rule was hit. The caller is delayed
+// before this error is returned if a delay rule was hit as well.
+func (i *Injector) Inject(phase Phase) error {
+	if i == nil {
+		return nil
+	}
+	delay, isFail := i.roll(phase)
+	if delay > 0 {
+		glog.V(2).Infof("Injected fault: Delayed phase %s by %v", phase, delay)
+		i.Sleep(delay)
+	}
+	if isFail {
+		glog.V(2).Infof("Injected fault: Failed phase %s", phase)
+		return errs.TransientErrorf("Injected fault: Failed phase %s", phase)
+	}
+	return nil
+}
+
+// roll evaluates the rules of the given phase & returns the total
+// delay along with the decision to fail
+func (i *Injector) roll(phase Phase) (delay time.Duration, isFail bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for _, rule := range i.rules[phase] {
+		// Roll is not thread safe & is hence called with the lock
+		if i.Roll() >= rule.Percent {
+			continue
+		}
+		if i.injected[phase] == nil {
+			i.injected[phase] = map[Action]int{}
+		}
+		i.injected[phase][rule.Action]++
+		switch rule.Action {
+		case ActionDelay:
+			delay += rule.Delay
+		case ActionFail:
+			isFail = true
+		}
+	}
+	return delay, isFail
+}
+
+// InjectedCount returns the number of faults of the given action
+// that were injected into the given phase
+func (i *Injector) InjectedCount(phase Phase, action Action) int {
+	if i == nil {
+		return 0
+	}
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	return i.injected[phase][action]
+}
+
+var (
+	// injector is used by all the reconcilers of this process
+	injector     *Injector
+	injectorLock sync.RWMutex
+)
+
+// SetRules sets the rules of the given spec against the injector
+// used by all the reconcilers. An empty spec disables the fault
+// injection.
+func SetRules(spec string) error {
+	rules, err := ParseRules(spec)
+	if err != nil {
+		return err
+	}
+	injectorLock.Lock()
+	defer injectorLock.Unlock()
+	if len(rules) == 0 {
+		injector = nil
+		return nil
+	}
+	injector = NewInjector(rules)
+	return nil
+}
+
+// Inject applies the rules of the given phase that were set via
+// SetRules. This is a no-op if no rules were set.
+func Inject(phase Phase) error {
+	injectorLock.RLock()
+	current := injector
+	injectorLock.RUnlock()
+	return current.Inject(phase)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package faultinject
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+func TestParseRules(t *testing.T) {
+	var tests = map[string]struct {
+		spec   string
+		expect []Rule
+		isErr  bool
+	}{
+		"empty spec": {},
+		"fail & delay rules": {
+			spec: "device-selection=fail:10, cspc-build=delay:50:2s",
+			expect: []Rule{
+				{Phase: PhaseDeviceSelection, Action: ActionFail, Percent: 10},
+				{Phase: PhaseCSPCBuild, Action: ActionDelay, Percent: 50, Delay: 2 * time.Second},
+			},
+		},
+		"missing action": {
+			spec:  "device-selection",
+			isErr: true,
+		},
+		"unsupported phase": {
+			spec:  "storage-provision=fail:10",
+			isErr: true,
+		},
+		"unsupported action": {
+			spec:  "plan-computation=panic:10",
+			isErr: true,
+		},
+		"percent above 100": {
+			spec:  "plan-computation=fail:101",
+			isErr: true,
+		},
+		"delay without duration": {
+			spec:  "plan-computation=delay:10",
+			isErr: true,
+		},
+		"fail with duration": {
+			spec:  "plan-computation=fail:10:1s",
+			isErr: true,
+		},
+		"invalid duration": {
+			spec:  "plan-computation=delay:10:soon",
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseRules(mock.spec)
+			if mock.isErr {
+				if errs.TypeOf(err) != errs.TypeValidation {
+					t.Fatalf("Expected validation error got [%+v]", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInjectorInject(t *testing.T) {
+	var tests = map[string]struct {
+		rules       []Rule
+		phase       Phase
+		roll        int
+		expectDelay time.Duration
+		isErr       bool
+	}{
+		"no rules": {
+			phase: PhaseDeviceSelection,
+		},
+		"rule of other phase": {
+			rules: []Rule{
+				{Phase: PhasePlanComputation, Action: ActionFail, Percent: 100},
+			},
+			phase: PhaseDeviceSelection,
+		},
+		"fail rule is hit": {
+			rules: []Rule{
+				{Phase: PhaseDeviceSelection, Action: ActionFail, Percent: 10},
+			},
+			phase: PhaseDeviceSelection,
+			roll:  9,
+			isErr: true,
+		},
+		"fail rule is missed": {
+			rules: []Rule{
+				{Phase: PhaseDeviceSelection, Action: ActionFail, Percent: 10},
+			},
+			phase: PhaseDeviceSelection,
+			roll:  10,
+		},
+		"zero percent is never hit": {
+			rules: []Rule{
+				{Phase: PhaseDeviceSelection, Action: ActionFail, Percent: 0},
+			},
+			phase: PhaseDeviceSelection,
+		},
+		"delay & fail rules are hit": {
+			rules: []Rule{
+				{Phase: PhaseCSPCBuild, Action: ActionDelay, Percent: 50, Delay: time.Second},
+				{Phase: PhaseCSPCBuild, Action: ActionFail, Percent: 50},
+			},
+			phase:       PhaseCSPCBuild,
+			roll:        1,
+			expectDelay: time.Second,
+			isErr:       true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var gotDelay time.Duration
+			i := NewInjector(mock.rules)
+			i.Roll = func() int { return mock.roll }
+			i.Sleep = func(d time.Duration) { gotDelay += d }
+			err := i.Inject(mock.phase)
+			if mock.isErr {
+				if !errs.IsRetryable(err) {
+					t.Fatalf("Expected retryable error got [%+v]", err)
+				}
+				if i.InjectedCount(mock.phase, ActionFail) != 1 {
+					t.Fatalf(
+						"Expected 1 injected failure got %d",
+						i.InjectedCount(mock.phase, ActionFail),
+					)
+				}
+			} else if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if gotDelay != mock.expectDelay {
+				t.Fatalf("Expected delay %v got %v", mock.expectDelay, gotDelay)
+			}
+		})
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	defer SetRules("")
+
+	err := SetRules("plan-computation=fail:100")
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if err := Inject(PhasePlanComputation); err == nil {
+		t.Fatalf("Expected injected error got none")
+	}
+	if err := Inject(PhaseCSPCBuild); err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	err = SetRules("plan-computation=fail")
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+	err = SetRules("")
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if err := Inject(PhasePlanComputation); err != nil {
+		t.Fatalf("Expected no error after disabling got [%+v]", err)
+	}
+}
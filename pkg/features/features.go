@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package features has the named gates that enable or disable the
+// features of this operator per cluster. This lets a feature ship
+// disabled & be enabled once it is trusted.
+//
+// NOTE:
+//	Gates are set via --feature-gates flag or featureGates of the
+// operator config. Reconcilers check the gate of a feature before
+// its behaviour takes effect.
+package features
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+// Feature is the name of a feature gate
+type Feature string
+
+const (
+	// TopologySpread shards the pools of local disks into one
+	// CStorPoolCluster per failure domain via failureDomainKey
+	TopologySpread Feature = "TopologySpread"
+
+	// AutoDeviceReplacement replaces the block devices of pool
+	// instances that stay unhealthy if the remediation policy is
+	// Rebuild
+	AutoDeviceReplacement Feature = "AutoDeviceReplacement"
+
+	// HybridDisks builds the pools of some nodes from local disks
+	// & the pools of other nodes from external disks
+	HybridDisks Feature = "HybridDisks"
+
+	// PerZoneCSPC plans one CStorPoolCluster per topology zone via
+	// perZoneCSPC
+	PerZoneCSPC Feature = "PerZoneCSPC"
+)
+
+// Stage is the maturity of a feature
+type Stage string
+
+const (
+	// StageAlpha is a feature that is disabled by default
+	StageAlpha Stage = "Alpha"
+
+	// StageBeta is a feature that is enabled by default
+	StageBeta Stage = "Beta"
+
+	// StageGA is a feature that is always enabled & whose gate
+	// can't be disabled
+	StageGA Stage = "GA"
+)
+
+// Spec is the default & the stage of a feature
+type Spec struct {
+	Default bool
+	Stage   Stage
+}
+
+// KnownFeatures are the features that can be gated
+//
+// NOTE:
+//	Features that were released before their gates are in beta
+// stage. These are enabled by default & hence behave as before.
+var KnownFeatures = map[Feature]Spec{
+	TopologySpread:        {Default: true, Stage: StageBeta},
+	AutoDeviceReplacement: {Default: true, Stage: StageBeta},
+	HybridDisks:           {Default: true, Stage: StageBeta},
+	PerZoneCSPC:           {Default: true, Stage: StageBeta},
+}
+
+// knownFeatureNames returns the sorted names of the known features
+func knownFeatureNames() []string {
+	var names []string
+	for feature := range KnownFeatures {
+		names = append(names, string(feature))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseGates returns the gates of the given comma separated spec.
+// Each gate has the format <feature>=<true|false> e.g.
+// HybridDisks=false,PerZoneCSPC=true
+func ParseGates(spec string) (map[Feature]bool, error) {
+	gates := map[Feature]bool{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			return nil, errs.ValidationErrorf(
+				"Invalid feature gate %q: Want <feature>=<true|false>", item,
+			)
+		}
+		feature := Feature(strings.TrimSpace(kv[0]))
+		spec, found := KnownFeatures[feature]
+		if !found {
+			return nil, errs.ValidationErrorf(
+				"Invalid feature gate %q: Unknown feature %q: Supports %s",
+				item, feature, strings.Join(knownFeatureNames(), ", "),
+			)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, errs.ValidationErrorf(
+				"Invalid feature gate %q: Want true or false", item,
+			)
+		}
+		if spec.Stage == StageGA && !enabled {
+			return nil, errs.ValidationErrorf(
+				"Invalid feature gate %q: Feature %q is GA & can't be disabled",
+				item, feature,
+			)
+		}
+		gates[feature] = enabled
+	}
+	return gates, nil
+}
+
+var (
+	// gates are the gates set explicitly & are used by all the
+	// reconcilers of this process
+	gates     = map[Feature]bool{}
+	gatesLock sync.RWMutex
+)
+
+// SetGates sets the gates of the given spec. Features without a
+// gate in this spec are reset to their defaults.
+func SetGates(spec string) error {
+	parsed, err := ParseGates(spec)
+	if err != nil {
+		return err
+	}
+	gatesLock.Lock()
+	defer gatesLock.Unlock()
+	gates = parsed
+	return nil
+}
+
+// Enabled returns true if the given feature is enabled. Unknown
+// features are disabled.
+func Enabled(feature Feature) bool {
+	gatesLock.RLock()
+	defer gatesLock.RUnlock()
+	if enabled, found := gates[feature]; found {
+		return enabled
+	}
+	return KnownFeatures[feature].Default
+}
+
+// String returns the state of every known feature as comma
+// separated <feature>=<true|false> sorted by feature
+func String() string {
+	var items []string
+	for _, name := range knownFeatureNames() {
+		items = append(items, name+"="+strconv.FormatBool(Enabled(Feature(name))))
+	}
+	return strings.Join(items, ",")
+}
+
+// NewDisabledError returns a validation error for the given setting
+// that needs the given feature which is disabled
+func NewDisabledError(feature Feature, setting string) error {
+	return errs.ValidationErrorf(
+		"%s: Needs feature gate %s which is disabled", setting, feature,
+	)
+}
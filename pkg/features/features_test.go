@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+func TestParseGates(t *testing.T) {
+	var tests = map[string]struct {
+		spec   string
+		expect map[Feature]bool
+		isErr  bool
+	}{
+		"empty spec": {
+			expect: map[Feature]bool{},
+		},
+		"multiple gates": {
+			spec: "HybridDisks=false, PerZoneCSPC=true,",
+			expect: map[Feature]bool{
+				HybridDisks: false,
+				PerZoneCSPC: true,
+			},
+		},
+		"gate without value": {
+			spec:  "HybridDisks",
+			isErr: true,
+		},
+		"unknown feature": {
+			spec:  "Hybrid=false",
+			isErr: true,
+		},
+		"non bool value": {
+			spec:  "HybridDisks=off",
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseGates(mock.spec)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if mock.isErr && errs.TypeOf(err) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", err)
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseGatesDisableGA(t *testing.T) {
+	KnownFeatures["TestGA"] = Spec{Default: true, Stage: StageGA}
+	defer delete(KnownFeatures, "TestGA")
+
+	_, err := ParseGates("TestGA=false")
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+	_, err = ParseGates("TestGA=true")
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+}
+
+func TestSetGates(t *testing.T) {
+	defer SetGates("")
+
+	err := SetGates("HybridDisks=false")
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if Enabled(HybridDisks) {
+		t.Fatalf("Expected HybridDisks to be disabled")
+	}
+	if !Enabled(PerZoneCSPC) {
+		t.Fatalf("Expected PerZoneCSPC to be enabled by default")
+	}
+	if Enabled("Unknown") {
+		t.Fatalf("Expected unknown feature to be disabled")
+	}
+	expect := "AutoDeviceReplacement=true,HybridDisks=false,PerZoneCSPC=true,TopologySpread=true"
+	if String() != expect {
+		t.Fatalf("Expected %q got %q", expect, String())
+	}
+
+	// invalid spec retains the current gates
+	err = SetGates("HybridDisks=maybe")
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+	if Enabled(HybridDisks) {
+		t.Fatalf("Expected HybridDisks to stay disabled")
+	}
+
+	// features not in the spec are reset to their defaults
+	err = SetGates("")
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if !Enabled(HybridDisks) {
+		t.Fatalf("Expected HybridDisks to be reset to enabled")
+	}
+}
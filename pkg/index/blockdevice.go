@@ -0,0 +1,260 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// LblKeyHostName is the label set against a BlockDevice that
+// refers to the host of this device
+const LblKeyHostName = "kubernetes.io/hostname"
+
+// BlockDeviceIndex groups block devices by their node, storage set
+// & capacity. It is expected to be built once per sync & be shared
+// by all the logic of this sync that needs these groupings.
+//
+// NOTE:
+//	Index is read only once built. Order of devices within each
+// group is same as the order in which these devices were provided.
+type BlockDeviceIndex struct {
+	// all the indexed devices
+	devices []*unstructured.Unstructured
+
+	// devices keyed by kubernetes.io/hostname label
+	byNode map[string][]*unstructured.Unstructured
+
+	// devices keyed by CStorClusterStorageSet UID label
+	byStorageSetUID map[string][]*unstructured.Unstructured
+
+	// devices keyed by capacity in bytes
+	byCapacity map[int64][]*unstructured.Unstructured
+
+	// names of devices that could not be indexed since
+	// corresponding value was missing
+	missingNode          []string
+	missingStorageSetUID []string
+
+	// node name to host name used to index the devices that
+	// are not labeled with their host name
+	nodeNameToHostName map[string]string
+}
+
+// NewBlockDeviceIndex returns a new index of the given devices.
+// Nil devices are ignored.
+func NewBlockDeviceIndex(devices []*unstructured.Unstructured) *BlockDeviceIndex {
+	return NewBlockDeviceIndexWithHostNames(devices, nil)
+}
+
+// NewBlockDeviceIndexWithHostNames returns a new index of the given
+// devices. Devices that are not labeled with their host name are
+// indexed by the host name of their spec.nodeAttributes.nodeName
+// found in the given mapping of node name to host name.
+func NewBlockDeviceIndexWithHostNames(
+	devices []*unstructured.Unstructured, nodeNameToHostName map[string]string,
+) *BlockDeviceIndex {
+	idx := &BlockDeviceIndex{
+		byNode:             map[string][]*unstructured.Unstructured{},
+		byStorageSetUID:    map[string][]*unstructured.Unstructured{},
+		byCapacity:         map[int64][]*unstructured.Unstructured{},
+		nodeNameToHostName: nodeNameToHostName,
+	}
+	for _, device := range devices {
+		if device == nil {
+			continue
+		}
+		idx.add(device)
+	}
+	return idx
+}
+
+// NewBlockDeviceIndexOrError returns a new index of the given
+// devices. It returns error if any of the devices is nil or is not
+// of kind BlockDevice.
+func NewBlockDeviceIndexOrError(
+	devices []*unstructured.Unstructured,
+) (*BlockDeviceIndex, error) {
+	return NewBlockDeviceIndexWithHostNamesOrError(devices, nil)
+}
+
+// NewBlockDeviceIndexWithHostNamesOrError returns a new index of the
+// given devices that is built with the given mapping of node name to
+// host name. It returns error if any of the devices is nil or is not
+// of kind BlockDevice.
+func NewBlockDeviceIndexWithHostNamesOrError(
+	devices []*unstructured.Unstructured, nodeNameToHostName map[string]string,
+) (*BlockDeviceIndex, error) {
+	for pos, device := range devices {
+		if device == nil || device.Object == nil {
+			return nil, errors.Errorf(
+				"Can't index block devices: Nil device at %d", pos,
+			)
+		}
+		if device.GetKind() != string(types.KindBlockDevice) {
+			return nil, errors.Errorf(
+				"Can't index block devices: Invalid kind: Want %q got %q at %d",
+				types.KindBlockDevice, device.GetKind(), pos,
+			)
+		}
+	}
+	return NewBlockDeviceIndexWithHostNames(devices, nodeNameToHostName), nil
+}
+
+// NewBlockDeviceIndexFromAttachments returns a new index of the
+// BlockDevice(s) found in the given attachments. Attachments of
+// other kinds are ignored.
+func NewBlockDeviceIndexFromAttachments(
+	attachments []*unstructured.Unstructured,
+) *BlockDeviceIndex {
+	var devices []*unstructured.Unstructured
+	for _, attachment := range attachments {
+		if attachment == nil ||
+			attachment.GetKind() != string(types.KindBlockDevice) {
+			continue
+		}
+		devices = append(devices, attachment)
+	}
+	return NewBlockDeviceIndex(devices)
+}
+
+func (idx *BlockDeviceIndex) add(device *unstructured.Unstructured) {
+	idx.devices = append(idx.devices, device)
+
+	labels := device.GetLabels()
+	host := labels[LblKeyHostName]
+	if host == "" {
+		nodeName, _, _ :=
+			unstructured.NestedString(device.Object, "spec", "nodeAttributes", "nodeName")
+		host = idx.nodeNameToHostName[nodeName]
+	}
+	if host != "" {
+		idx.byNode[host] = append(idx.byNode[host], device)
+	} else {
+		idx.missingNode = append(idx.missingNode, device.GetName())
+	}
+	// TODO (@amitkumardas):
+	//	Storage set UID is set as a label since there might be
+	// a bug in metac to merge annotations.
+	if uid := labels[types.AnnKeyCStorClusterStorageSetUID]; uid != "" {
+		idx.byStorageSetUID[uid] = append(idx.byStorageSetUID[uid], device)
+	} else {
+		idx.missingStorageSetUID =
+			append(idx.missingStorageSetUID, device.GetName())
+	}
+	capacity, found, err :=
+		unstructured.NestedInt64(device.Object, "spec", "capacity", "storage")
+	if err == nil && found {
+		idx.byCapacity[capacity] = append(idx.byCapacity[capacity], device)
+	}
+}
+
+// Len returns the number of indexed devices
+func (idx *BlockDeviceIndex) Len() int {
+	return len(idx.devices)
+}
+
+// List returns all the indexed devices
+func (idx *BlockDeviceIndex) List() []*unstructured.Unstructured {
+	return idx.devices
+}
+
+// ByNode returns the devices of the given host name
+func (idx *BlockDeviceIndex) ByNode(hostName string) []*unstructured.Unstructured {
+	return idx.byNode[hostName]
+}
+
+// ByStorageSetUID returns the devices of the given
+// CStorClusterStorageSet UID
+func (idx *BlockDeviceIndex) ByStorageSetUID(uid string) []*unstructured.Unstructured {
+	return idx.byStorageSetUID[uid]
+}
+
+// ByCapacity returns the devices whose capacity equals the
+// given quantity
+func (idx *BlockDeviceIndex) ByCapacity(capacity resource.Quantity) []*unstructured.Unstructured {
+	return idx.byCapacity[capacity.Value()]
+}
+
+// NodeNames returns the sorted host names of indexed devices
+func (idx *BlockDeviceIndex) NodeNames() []string {
+	var names []string
+	for name := range idx.byNode {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Capacities returns the sorted capacities of indexed devices
+// in bytes
+func (idx *BlockDeviceIndex) Capacities() []int64 {
+	var capacities []int64
+	for capacity := range idx.byCapacity {
+		capacities = append(capacities, capacity)
+	}
+	sort.Slice(capacities, func(i, j int) bool {
+		return capacities[i] < capacities[j]
+	})
+	return capacities
+}
+
+// DeviceNamesByNode returns the device names grouped by their
+// host name. It returns error if host name is not set against
+// any of the devices.
+func (idx *BlockDeviceIndex) DeviceNamesByNode() (map[string][]string, error) {
+	if len(idx.missingNode) != 0 {
+		return nil, errors.Errorf(
+			"Label %q not found: BlockDevice(s) %v",
+			LblKeyHostName, idx.missingNode,
+		)
+	}
+	return toNames(idx.byNode), nil
+}
+
+// DeviceNamesByStorageSetUID returns the device names grouped by
+// their CStorClusterStorageSet UID. It returns error if this UID
+// is not set against any of the devices.
+func (idx *BlockDeviceIndex) DeviceNamesByStorageSetUID() (map[string][]string, error) {
+	if len(idx.missingStorageSetUID) != 0 {
+		return nil, errors.Errorf(
+			"Label %q not found: BlockDevice(s) %v",
+			types.AnnKeyCStorClusterStorageSetUID, idx.missingStorageSetUID,
+		)
+	}
+	return toNames(idx.byStorageSetUID), nil
+}
+
+func toNames(grouped map[string][]*unstructured.Unstructured) map[string][]string {
+	names := make(map[string][]string, len(grouped))
+	for key, devices := range grouped {
+		list := make([]string, 0, len(devices))
+		for _, device := range devices {
+			list = append(list, device.GetName())
+		}
+		// names are sorted to build the same specs irrespective
+		// of the order the devices were observed
+		sort.Strings(list)
+		names[key] = list
+	}
+	return names
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package index
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	bd "mayadata.io/cstorpoolauto/common/blockdevice"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newTestDevice(name, host, storageSetUID string, capacity int64) *unstructured.Unstructured {
+	labels := map[string]interface{}{}
+	if host != "" {
+		labels[LblKeyHostName] = host
+	}
+	if storageSetUID != "" {
+		labels[types.AnnKeyCStorClusterStorageSetUID] = storageSetUID
+	}
+	device := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name":   name,
+				"labels": labels,
+			},
+		},
+	}
+	if capacity > 0 {
+		device.Object["spec"] = map[string]interface{}{
+			"capacity": map[string]interface{}{
+				"storage": capacity,
+			},
+		}
+	}
+	return device
+}
+
+func getNames(devices []*unstructured.Unstructured) []string {
+	var names []string
+	for _, device := range devices {
+		names = append(names, device.GetName())
+	}
+	return names
+}
+
+func TestBlockDeviceIndex(t *testing.T) {
+	idx := NewBlockDeviceIndex([]*unstructured.Unstructured{
+		newTestDevice("bd-1", "node-1", "sset-1", 1024),
+		nil,
+		newTestDevice("bd-2", "node-2", "sset-2", 2048),
+		newTestDevice("bd-3", "node-1", "sset-1", 2048),
+		newTestDevice("bd-4", "node-2", "", 0),
+	})
+	if idx.Len() != 4 {
+		t.Fatalf("Expected device count 4 got %d", idx.Len())
+	}
+	if diff := cmp.Diff([]string{"bd-1", "bd-3"}, getNames(idx.ByNode("node-1"))); diff != "" {
+		t.Fatalf("Expected no diff in node-1 devices got\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"node-1", "node-2"}, idx.NodeNames()); diff != "" {
+		t.Fatalf("Expected no diff in node names got\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"bd-2"}, getNames(idx.ByStorageSetUID("sset-2"))); diff != "" {
+		t.Fatalf("Expected no diff in sset-2 devices got\n%s", diff)
+	}
+	got := getNames(idx.ByCapacity(resource.MustParse("2Ki")))
+	if diff := cmp.Diff([]string{"bd-2", "bd-3"}, got); diff != "" {
+		t.Fatalf("Expected no diff in 2Ki devices got\n%s", diff)
+	}
+	if diff := cmp.Diff([]int64{1024, 2048}, idx.Capacities()); diff != "" {
+		t.Fatalf("Expected no diff in capacities got\n%s", diff)
+	}
+	byNode, err := idx.DeviceNamesByNode()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	expectByNode := map[string][]string{
+		"node-1": []string{"bd-1", "bd-3"},
+		"node-2": []string{"bd-2", "bd-4"},
+	}
+	if diff := cmp.Diff(expectByNode, byNode); diff != "" {
+		t.Fatalf("Expected no diff in devices by node got\n%s", diff)
+	}
+	// bd-4 does not have storage set uid
+	_, err = idx.DeviceNamesByStorageSetUID()
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+}
+
+func TestNewBlockDeviceIndexOrError(t *testing.T) {
+	var tests = map[string]struct {
+		devices []*unstructured.Unstructured
+		isErr   bool
+	}{
+		"nil devices": {},
+		"nil device": {
+			devices: []*unstructured.Unstructured{nil},
+			isErr:   true,
+		},
+		"invalid kind": {
+			devices: []*unstructured.Unstructured{
+				{
+					Object: map[string]interface{}{
+						"kind": "Junk",
+					},
+				},
+			},
+			isErr: true,
+		},
+		"valid devices": {
+			devices: []*unstructured.Unstructured{
+				newTestDevice("bd-1", "node-1", "", 0),
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			_, err := NewBlockDeviceIndexOrError(mock.devices)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestNewBlockDeviceIndexFromAttachments(t *testing.T) {
+	idx := NewBlockDeviceIndexFromAttachments([]*unstructured.Unstructured{
+		newTestDevice("bd-1", "node-1", "", 0),
+		nil,
+		{
+			Object: map[string]interface{}{
+				"kind": string(types.KindStorage),
+				"metadata": map[string]interface{}{
+					"name": "storage-1",
+				},
+			},
+		},
+	})
+	if diff := cmp.Diff([]string{"bd-1"}, getNames(idx.List())); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+}
+
+// consumerCount is the number of consumers that group the
+// same list of devices during a single sync
+const consumerCount = 3
+
+func newTestDevices(count int) []*unstructured.Unstructured {
+	var devices []*unstructured.Unstructured
+	for i := 0; i < count; i++ {
+		devices = append(devices, newTestDevice(
+			fmt.Sprintf("bd-%d", i),
+			fmt.Sprintf("node-%d", i%100),
+			fmt.Sprintf("sset-%d", i%100),
+			int64(1024*(i%10+1)),
+		))
+	}
+	return devices
+}
+
+// BenchmarkListHelperGroupByHostName groups the devices once
+// per consumer
+func BenchmarkListHelperGroupByHostName(b *testing.B) {
+	devices := newTestDevices(5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for c := 0; c < consumerCount; c++ {
+			_, err := bd.NewListHelper(devices).GroupDeviceNamesByHostName()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkBlockDeviceIndexByNode builds the index once & lets
+// each consumer look up the devices of a node
+func BenchmarkBlockDeviceIndexByNode(b *testing.B) {
+	devices := newTestDevices(5000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx := NewBlockDeviceIndex(devices)
+		for c := 0; c < consumerCount; c++ {
+			for _, node := range idx.NodeNames() {
+				_ = idx.ByNode(node)
+			}
+		}
+	}
+}
+
+func TestDeviceNamesByNodeAreSorted(t *testing.T) {
+	idx := NewBlockDeviceIndex([]*unstructured.Unstructured{
+		newTestDevice("bd-3", "node-1", "sset-1", 1024),
+		newTestDevice("bd-1", "node-1", "sset-1", 1024),
+		newTestDevice("bd-2", "node-1", "sset-1", 1024),
+	})
+	byNode, err := idx.DeviceNamesByNode()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if diff := cmp.Diff([]string{"bd-1", "bd-2", "bd-3"}, byNode["node-1"]); diff != "" {
+		t.Fatalf("Expected no diff in devices by node got\n%s", diff)
+	}
+	bySSet, err := idx.DeviceNamesByStorageSetUID()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if diff := cmp.Diff([]string{"bd-1", "bd-2", "bd-3"}, bySSet["sset-1"]); diff != "" {
+		t.Fatalf("Expected no diff in devices by storage set got\n%s", diff)
+	}
+}
+
+func TestDeviceNamesByNodeWithHostNames(t *testing.T) {
+	withoutHost := newTestDevice("bd-2", "", "sset-1", 1024)
+	withoutHost.Object["spec"] = map[string]interface{}{
+		"nodeAttributes": map[string]interface{}{
+			"nodeName": "ip-10-0-0-1.ec2.internal",
+		},
+	}
+	devices := []*unstructured.Unstructured{
+		newTestDevice("bd-1", "ip-10-0-0-1", "sset-1", 1024),
+		withoutHost,
+	}
+	_, err := NewBlockDeviceIndex(devices).DeviceNamesByNode()
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+	byNode, err := NewBlockDeviceIndexWithHostNames(
+		devices, map[string]string{"ip-10-0-0-1.ec2.internal": "ip-10-0-0-1"},
+	).DeviceNamesByNode()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if diff := cmp.Diff([]string{"bd-1", "bd-2"}, byNode["ip-10-0-0-1"]); diff != "" {
+		t.Fatalf("Expected no diff in devices by node got\n%s", diff)
+	}
+}
@@ -0,0 +1,368 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package inventory lists every CStorClusterConfig of the cluster
+// in the singleton CStorPoolAutoInventory.
+//
+// NOTE:
+//	Platform operators watch this one cluster scoped resource
+// instead of enumerating the configs of every namespace. Inventory
+// runs in the background independent of the controllers since no
+// single controller observes all the configs.
+package inventory
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// Group version resources that are inventoried
+var (
+	gvrCStorClusterConfig = schema.GroupVersionResource{
+		Group:    types.GroupDAOMayaDataIO,
+		Version:  types.VersionV1Alpha1,
+		Resource: "cstorclusterconfigs",
+	}
+	gvrCStorClusterPlan = schema.GroupVersionResource{
+		Group:    types.GroupDAOMayaDataIO,
+		Version:  types.VersionV1Alpha1,
+		Resource: "cstorclusterplans",
+	}
+	gvrCStorPoolAutoInventory = schema.GroupVersionResource{
+		Group:    types.GroupDAOMayaDataIO,
+		Version:  types.VersionV1Alpha1,
+		Resource: "cstorpoolautoinventories",
+	}
+	// CStorPoolCluster(s) of both the schemas are inventoried.
+	// Schemas that are not served are skipped.
+	gvrCStorPoolClusters = []schema.GroupVersionResource{
+		{
+			Group:    types.GroupOpenEBSIO,
+			Version:  types.VersionV1Alpha1,
+			Resource: "cstorpoolclusters",
+		},
+		{
+			Group:    types.GroupCStorOpenEBSIO,
+			Version:  types.VersionV1,
+			Resource: "cstorpoolclusters",
+		},
+	}
+)
+
+// getConfigUID returns the UID of the CStorClusterConfig that the
+// given child refers to
+func getConfigUID(obj *unstructured.Unstructured) string {
+	return obj.GetAnnotations()[types.AnnKeyCStorClusterConfigUID]
+}
+
+// uniqueSorted returns the given names sorted without duplicates
+func uniqueSorted(names []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, name := range names {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// getHealth returns the health of a config based on its status &
+// the given plans along with the reason if it is not healthy
+func getHealth(
+	status types.CStorClusterConfigStatus, plans []*types.CStorClusterPlan,
+) (types.CStorPoolAutoInventoryHealth, string) {
+	switch status.Phase {
+	case "":
+		return types.CStorPoolAutoInventoryHealthUnknown, "CStorClusterConfig is not reconciled yet"
+	case types.CStorClusterConfigStatusPhaseError:
+		for _, cond := range status.Conditions {
+			if cond.Type == types.CStorClusterConfigReconcileErrorCondition &&
+				cond.Status == types.ConditionIsPresent {
+				return types.CStorPoolAutoInventoryHealthError, cond.Message
+			}
+		}
+		return types.CStorPoolAutoInventoryHealthError, "CStorClusterConfig is in Error phase"
+	}
+	for _, plan := range plans {
+		if plan.Status.Phase == types.CStorClusterPlanStatusPhaseError {
+			return types.CStorPoolAutoInventoryHealthError,
+				fmt.Sprintf("CStorClusterPlan %s is in Error phase", plan.GetName())
+		}
+	}
+	for _, plan := range plans {
+		if count := len(plan.Status.UnhealthyPools); count != 0 {
+			return types.CStorPoolAutoInventoryHealthDegraded,
+				fmt.Sprintf("CStorClusterPlan %s has %d unhealthy pools", plan.GetName(), count)
+		}
+		for _, cond := range plan.Status.Conditions {
+			if cond.Type == types.CStorClusterPlanDegradedCondition &&
+				cond.Status == types.ConditionIsPresent {
+				return types.CStorPoolAutoInventoryHealthDegraded, cond.Message
+			}
+		}
+	}
+	return types.CStorPoolAutoInventoryHealthHealthy, ""
+}
+
+// Build returns the inventory of the given configs. Plans &
+// CStorPoolCluster(s) are mapped to their configs via the config
+// UID annotation.
+//
+// NOTE:
+//	Capacity of a config is read from its status & is hence as
+// recent as its last reconciliation
+func Build(
+	configs []*unstructured.Unstructured,
+	plans []*unstructured.Unstructured,
+	cstorPoolClusters []*unstructured.Unstructured,
+) (types.CStorPoolAutoInventoryStatus, error) {
+	uidToPlans := map[string][]*types.CStorClusterPlan{}
+	for _, obj := range plans {
+		if obj == nil || getConfigUID(obj) == "" {
+			continue
+		}
+		plan := &types.CStorClusterPlan{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, plan)
+		if err != nil {
+			return types.CStorPoolAutoInventoryStatus{}, errors.Wrapf(
+				err, "Can't convert CStorClusterPlan %s/%s", obj.GetNamespace(), obj.GetName(),
+			)
+		}
+		uidToPlans[getConfigUID(obj)] = append(uidToPlans[getConfigUID(obj)], plan)
+	}
+	uidToCSPCNames := map[string][]string{}
+	for _, obj := range cstorPoolClusters {
+		if obj == nil || getConfigUID(obj) == "" {
+			continue
+		}
+		uidToCSPCNames[getConfigUID(obj)] = append(uidToCSPCNames[getConfigUID(obj)], obj.GetName())
+	}
+	inventory := types.CStorPoolAutoInventoryStatus{}
+	for _, obj := range configs {
+		if obj == nil {
+			continue
+		}
+		var status types.CStorClusterConfigStatus
+		statusMap, _, _ := unstructured.NestedMap(obj.Object, "status")
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(statusMap, &status)
+		if err != nil {
+			return types.CStorPoolAutoInventoryStatus{}, errors.Wrapf(
+				err, "Can't convert status of CStorClusterConfig %s/%s",
+				obj.GetNamespace(), obj.GetName(),
+			)
+		}
+		uid := string(obj.GetUID())
+		config := types.CStorPoolAutoInventoryConfig{
+			Namespace:         obj.GetNamespace(),
+			Name:              obj.GetName(),
+			UID:               obj.GetUID(),
+			CStorPoolClusters: uniqueSorted(uidToCSPCNames[uid]),
+		}
+		var nodes []string
+		for _, plan := range uidToPlans[uid] {
+			for _, node := range plan.Spec.Nodes {
+				nodes = append(nodes, node.Name)
+			}
+		}
+		config.Nodes = uniqueSorted(nodes)
+		if status.Capacity != nil {
+			config.Total = status.Capacity.Total.DeepCopy()
+			config.Used = status.Capacity.Used.DeepCopy()
+			inventory.Total.Add(config.Total)
+			inventory.Used.Add(config.Used)
+		}
+		config.Health, config.Reason = getHealth(status, uidToPlans[uid])
+		inventory.Configs = append(inventory.Configs, config)
+	}
+	// sort to keep the inventory idempotent across aggregations
+	sort.Slice(inventory.Configs, func(i, j int) bool {
+		if inventory.Configs[i].Namespace != inventory.Configs[j].Namespace {
+			return inventory.Configs[i].Namespace < inventory.Configs[j].Namespace
+		}
+		return inventory.Configs[i].Name < inventory.Configs[j].Name
+	})
+	inventory.ConfigCount = len(inventory.Configs)
+	return inventory, nil
+}
+
+// Aggregator maintains the singleton CStorPoolAutoInventory
+type Aggregator struct {
+	Client dynamic.Interface
+}
+
+// Run aggregates at the given interval till the given channel is
+// closed
+func (a *Aggregator) Run(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if level := throttle.API.Stats().Level; level > 0 {
+				// aggregation lists the resources of the entire cluster
+				glog.V(2).Infof("Skipping inventory: API throttle level %d", level)
+				continue
+			}
+			_, err := a.AggregateOnce()
+			if err != nil {
+				glog.Errorf("Inventory failed: %+v", err)
+			}
+		}
+	}
+}
+
+// AggregateOnce builds the inventory & writes it to the singleton
+// CStorPoolAutoInventory. It returns the inventory that was built.
+//
+// NOTE:
+//	Inventory is written only if it changed since the last write.
+// Hence lastUpdateTime is the time of the last change.
+func (a *Aggregator) AggregateOnce() (types.CStorPoolAutoInventoryStatus, error) {
+	var cstorPoolClusters []*unstructured.Unstructured
+	for _, resource := range gvrCStorPoolClusters {
+		objs, err := a.list(resource)
+		if err != nil {
+			return types.CStorPoolAutoInventoryStatus{}, err
+		}
+		cstorPoolClusters = append(cstorPoolClusters, objs...)
+	}
+	plans, err := a.list(gvrCStorClusterPlan)
+	if err != nil {
+		return types.CStorPoolAutoInventoryStatus{}, err
+	}
+	configs, err := a.list(gvrCStorClusterConfig)
+	if err != nil {
+		return types.CStorPoolAutoInventoryStatus{}, err
+	}
+	inventory, err := Build(configs, plans, cstorPoolClusters)
+	if err != nil {
+		return types.CStorPoolAutoInventoryStatus{}, err
+	}
+	return inventory, a.write(inventory)
+}
+
+// list returns all the instances of the given resource. No
+// instances are returned if this resource is not served.
+func (a *Aggregator) list(
+	resource schema.GroupVersionResource,
+) ([]*unstructured.Unstructured, error) {
+	list, err := a.Client.Resource(resource).List(metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't list %s", resource.String())
+	}
+	var objs []*unstructured.Unstructured
+	for idx := range list.Items {
+		objs = append(objs, &list.Items[idx])
+	}
+	return objs, nil
+}
+
+// toStatusMap returns the given inventory as an unstructured status
+func toStatusMap(inventory types.CStorPoolAutoInventoryStatus) (map[string]interface{}, error) {
+	status, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&inventory)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't convert inventory")
+	}
+	return status, nil
+}
+
+// isChanged returns true if the given inventory differs from the
+// status of the observed CStorPoolAutoInventory. Update time is
+// not compared.
+func isChanged(
+	observed *unstructured.Unstructured, inventory types.CStorPoolAutoInventoryStatus,
+) (bool, error) {
+	inventory.LastUpdateTime = metav1.Time{}
+	desired, err := toStatusMap(inventory)
+	if err != nil {
+		return false, err
+	}
+	delete(desired, "lastUpdateTime")
+	status, _, _ := unstructured.NestedMap(observed.Object, "status")
+	delete(status, "lastUpdateTime")
+	return !reflect.DeepEqual(status, desired), nil
+}
+
+// write creates or updates the singleton CStorPoolAutoInventory
+// with the given inventory
+func (a *Aggregator) write(inventory types.CStorPoolAutoInventoryStatus) error {
+	client := a.Client.Resource(gvrCStorPoolAutoInventory)
+	observed, err := client.Get(types.CStorPoolAutoInventoryName, metav1.GetOptions{})
+	isFound := err == nil
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "Can't get inventory %s", types.CStorPoolAutoInventoryName)
+	}
+	if isFound {
+		changed, err := isChanged(observed, inventory)
+		if err != nil || !changed {
+			return err
+		}
+	}
+	inventory.LastUpdateTime = metav1.Now()
+	status, err := toStatusMap(inventory)
+	if err != nil {
+		return err
+	}
+	if !isFound {
+		obj := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"status": status,
+			},
+		}
+		obj.SetAPIVersion(types.APIVersionDAOMayaDataV1Alpha1)
+		obj.SetKind(string(types.KindCStorPoolAutoInventory))
+		obj.SetName(types.CStorPoolAutoInventoryName)
+		_, err = client.Create(obj, metav1.CreateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "Can't create inventory %s", types.CStorPoolAutoInventoryName)
+		}
+		glog.V(3).Infof("Created inventory: Config count %d", inventory.ConfigCount)
+		return nil
+	}
+	observed.Object["status"] = status
+	_, err = client.Update(observed, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "Can't update inventory %s", types.CStorPoolAutoInventoryName)
+	}
+	glog.V(3).Infof("Updated inventory: Config count %d", inventory.ConfigCount)
+	return nil
+}
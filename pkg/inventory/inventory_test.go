@@ -0,0 +1,302 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newObj(
+	apiVersion, kind, namespace, name, uid string,
+	annotations map[string]string,
+	fields map[string]interface{},
+) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range fields {
+		obj.Object[key] = value
+	}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(k8stypes.UID(uid))
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func newConfig(namespace, name, uid string, status map[string]interface{}) *unstructured.Unstructured {
+	var fields map[string]interface{}
+	if status != nil {
+		fields = map[string]interface{}{"status": status}
+	}
+	return newObj(
+		types.APIVersionDAOMayaDataV1Alpha1, string(types.KindCStorClusterConfig),
+		namespace, name, uid, nil, fields,
+	)
+}
+
+func newPlan(name, configUID string, nodes []string, status map[string]interface{}) *unstructured.Unstructured {
+	var planNodes []interface{}
+	for _, node := range nodes {
+		planNodes = append(planNodes, map[string]interface{}{"name": node, "uid": node})
+	}
+	fields := map[string]interface{}{
+		"spec": map[string]interface{}{"nodes": planNodes},
+	}
+	if status != nil {
+		fields["status"] = status
+	}
+	return newObj(
+		types.APIVersionDAOMayaDataV1Alpha1, string(types.KindCStorClusterPlan),
+		"openebs", name, name, map[string]string{
+			types.AnnKeyCStorClusterConfigUID: configUID,
+		}, fields,
+	)
+}
+
+func newCSPC(name, configUID string) *unstructured.Unstructured {
+	return newObj(
+		types.APIVersionCStorOpenEBSV1, string(types.KindCStorPoolCluster),
+		"openebs", name, name, map[string]string{
+			types.AnnKeyCStorClusterConfigUID: configUID,
+		}, nil,
+	)
+}
+
+func onlineStatus(total, used string) map[string]interface{} {
+	return map[string]interface{}{
+		"phase": "Online",
+		"capacity": map[string]interface{}{
+			"total": total,
+			"used":  used,
+			"free":  "0",
+		},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	var tests = map[string]struct {
+		configs      []*unstructured.Unstructured
+		plans        []*unstructured.Unstructured
+		cspcs        []*unstructured.Unstructured
+		expectNames  []string
+		expectNodes  [][]string
+		expectCSPCs  [][]string
+		expectHealth []types.CStorPoolAutoInventoryHealth
+		expectTotal  string
+		expectUsed   string
+	}{
+		"no configs": {
+			expectTotal: "0",
+			expectUsed:  "0",
+		},
+		"configs are sorted by namespace & name": {
+			configs: []*unstructured.Unstructured{
+				newConfig("team-b", "ccc", "config-3", onlineStatus("10Gi", "1Gi")),
+				newConfig("team-a", "ccc-2", "config-2", onlineStatus("10Gi", "2Gi")),
+				newConfig("team-a", "ccc-1", "config-1", onlineStatus("20Gi", "3Gi")),
+			},
+			expectNames: []string{"team-a/ccc-1", "team-a/ccc-2", "team-b/ccc"},
+			expectNodes: [][]string{nil, nil, nil},
+			expectCSPCs: [][]string{nil, nil, nil},
+			expectHealth: []types.CStorPoolAutoInventoryHealth{
+				types.CStorPoolAutoInventoryHealthHealthy,
+				types.CStorPoolAutoInventoryHealthHealthy,
+				types.CStorPoolAutoInventoryHealthHealthy,
+			},
+			expectTotal: "40Gi",
+			expectUsed:  "6Gi",
+		},
+		"nodes & cspcs of plans per zone are merged": {
+			configs: []*unstructured.Unstructured{
+				newConfig("openebs", "ccc", "config-1", onlineStatus("10Gi", "1Gi")),
+			},
+			plans: []*unstructured.Unstructured{
+				newPlan("ccc-zone-b", "config-1", []string{"node-3", "node-2"}, nil),
+				newPlan("ccc-zone-a", "config-1", []string{"node-1"}, nil),
+				newPlan("other", "config-2", []string{"node-4"}, nil),
+			},
+			cspcs: []*unstructured.Unstructured{
+				newCSPC("ccc-zone-b", "config-1"),
+				newCSPC("ccc-zone-a", "config-1"),
+				newCSPC("other", "config-2"),
+			},
+			expectNames:  []string{"openebs/ccc"},
+			expectNodes:  [][]string{{"node-1", "node-2", "node-3"}},
+			expectCSPCs:  [][]string{{"ccc-zone-a", "ccc-zone-b"}},
+			expectHealth: []types.CStorPoolAutoInventoryHealth{types.CStorPoolAutoInventoryHealthHealthy},
+			expectTotal:  "10Gi",
+			expectUsed:   "1Gi",
+		},
+		"health of configs": {
+			configs: []*unstructured.Unstructured{
+				newConfig("openebs", "ccc-1", "config-1", nil),
+				newConfig("openebs", "ccc-2", "config-2", map[string]interface{}{
+					"phase": "Error",
+				}),
+				newConfig("openebs", "ccc-3", "config-3", onlineStatus("10Gi", "1Gi")),
+				newConfig("openebs", "ccc-4", "config-4", onlineStatus("10Gi", "1Gi")),
+				newConfig("openebs", "ccc-5", "config-5", onlineStatus("10Gi", "1Gi")),
+			},
+			plans: []*unstructured.Unstructured{
+				newPlan("ccc-3", "config-3", []string{"node-1"}, map[string]interface{}{
+					"phase": "Error",
+				}),
+				newPlan("ccc-4", "config-4", []string{"node-1"}, map[string]interface{}{
+					"phase": "Online",
+					"unhealthyPools": []interface{}{
+						map[string]interface{}{"name": "pool-1", "phase": "Offline", "since": ""},
+					},
+				}),
+				newPlan("ccc-5", "config-5", []string{"node-1"}, map[string]interface{}{
+					"phase": "Online",
+				}),
+			},
+			expectNames: []string{
+				"openebs/ccc-1", "openebs/ccc-2", "openebs/ccc-3", "openebs/ccc-4", "openebs/ccc-5",
+			},
+			expectNodes: [][]string{nil, nil, {"node-1"}, {"node-1"}, {"node-1"}},
+			expectCSPCs: [][]string{nil, nil, nil, nil, nil},
+			expectHealth: []types.CStorPoolAutoInventoryHealth{
+				types.CStorPoolAutoInventoryHealthUnknown,
+				types.CStorPoolAutoInventoryHealthError,
+				types.CStorPoolAutoInventoryHealthError,
+				types.CStorPoolAutoInventoryHealthDegraded,
+				types.CStorPoolAutoInventoryHealthHealthy,
+			},
+			expectTotal: "30Gi",
+			expectUsed:  "3Gi",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Build(mock.configs, mock.plans, mock.cspcs)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if got.ConfigCount != len(mock.expectNames) {
+				t.Fatalf("Expected config count %d got %d", len(mock.expectNames), got.ConfigCount)
+			}
+			var gotNames []string
+			var gotNodes, gotCSPCs [][]string
+			var gotHealth []types.CStorPoolAutoInventoryHealth
+			for _, config := range got.Configs {
+				gotNames = append(gotNames, config.Namespace+"/"+config.Name)
+				gotNodes = append(gotNodes, config.Nodes)
+				gotCSPCs = append(gotCSPCs, config.CStorPoolClusters)
+				gotHealth = append(gotHealth, config.Health)
+				if config.Health != types.CStorPoolAutoInventoryHealthHealthy && config.Reason == "" {
+					t.Fatalf("Expected reason for %s health of %s got none", config.Health, config.Name)
+				}
+			}
+			if diff := cmp.Diff(mock.expectNames, gotNames); diff != "" {
+				t.Fatalf("Expected no diff in configs got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectNodes, gotNodes); diff != "" {
+				t.Fatalf("Expected no diff in nodes got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectCSPCs, gotCSPCs); diff != "" {
+				t.Fatalf("Expected no diff in cstorpoolclusters got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectHealth, gotHealth); diff != "" {
+				t.Fatalf("Expected no diff in health got\n%s", diff)
+			}
+			if got.Total.String() != mock.expectTotal {
+				t.Fatalf("Expected total %s got %s", mock.expectTotal, got.Total.String())
+			}
+			if got.Used.String() != mock.expectUsed {
+				t.Fatalf("Expected used %s got %s", mock.expectUsed, got.Used.String())
+			}
+		})
+	}
+}
+
+func TestAggregatorAggregateOnce(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(
+		runtime.NewScheme(),
+		newConfig("openebs", "ccc", "config-1", onlineStatus("10Gi", "1Gi")),
+		newPlan("ccc", "config-1", []string{"node-1"}, nil),
+		newCSPC("ccc", "config-1"),
+	)
+	a := &Aggregator{Client: client}
+	// inventory is written only if it changed since the last write
+	var writes []string
+	for i := 0; i < 2; i++ {
+		_, err := a.AggregateOnce()
+		if err != nil {
+			t.Fatalf("Expected no error got [%+v]", err)
+		}
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "create" || action.GetVerb() == "update" {
+			writes = append(writes, action.GetVerb())
+		}
+	}
+	if diff := cmp.Diff([]string{"create"}, writes); diff != "" {
+		t.Fatalf("Expected no diff in writes got\n%s", diff)
+	}
+	got, err := client.Resource(gvrCStorPoolAutoInventory).
+		Get(types.CStorPoolAutoInventoryName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	configs, _, _ := unstructured.NestedSlice(got.Object, "status", "configs")
+	if len(configs) != 1 {
+		t.Fatalf("Expected 1 config got %d", len(configs))
+	}
+	nodes, _, _ := unstructured.NestedStringSlice(configs[0].(map[string]interface{}), "nodes")
+	if diff := cmp.Diff([]string{"node-1"}, nodes); diff != "" {
+		t.Fatalf("Expected no diff in nodes got\n%s", diff)
+	}
+
+	// a new config changes the inventory
+	_, err = client.Resource(gvrCStorClusterConfig).Namespace("openebs").Create(
+		newConfig("openebs", "ccc-2", "config-2", nil), metav1.CreateOptions{},
+	)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	client.ClearActions()
+	inventory, err := a.AggregateOnce()
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if inventory.ConfigCount != 2 {
+		t.Fatalf("Expected config count 2 got %d", inventory.ConfigCount)
+	}
+	var updated bool
+	for _, action := range client.Actions() {
+		if _, ok := action.(clienttesting.UpdateAction); ok {
+			updated = true
+		}
+	}
+	if !updated {
+		t.Fatalf("Expected inventory to be updated")
+	}
+}
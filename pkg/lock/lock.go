@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package lock serializes the reconciliations that select the
+// same resources within this process.
+//
+// NOTE:
+//	Controllers that select block devices run concurrently for
+// different watches. Locks keyed by CStorClusterConfig UID & node
+// name prevent two watches from selecting the same devices of a
+// node at the same instant.
+package lock
+
+import (
+	"sort"
+	"sync"
+)
+
+// ConfigKey returns the lock key of the CStorClusterConfig with
+// the given UID
+func ConfigKey(uid string) string {
+	return "cstorclusterconfig/" + uid
+}
+
+// NodeKey returns the lock key of the node with the given name
+func NodeKey(name string) string {
+	return "node/" + name
+}
+
+// keyedEntry is the mutex of a single key along with the count of
+// its current users
+type keyedEntry struct {
+	mutex sync.Mutex
+	users int
+}
+
+// KeyedMutex provides a mutex per key
+type KeyedMutex struct {
+	mutex   sync.Mutex
+	entries map[string]*keyedEntry
+}
+
+// NewKeyedMutex returns a new instance of KeyedMutex
+func NewKeyedMutex() *KeyedMutex {
+	return &KeyedMutex{
+		entries: map[string]*keyedEntry{},
+	}
+}
+
+// Lock locks all the given keys & returns the function that
+// unlocks them
+//
+// NOTE:
+//	Keys are locked in sorted order after removing duplicates.
+// This avoids deadlocks between callers that lock overlapping
+// keys.
+func (m *KeyedMutex) Lock(keys ...string) (unlock func()) {
+	uniqueKeys := map[string]bool{}
+	var sortedKeys []string
+	for _, key := range keys {
+		if uniqueKeys[key] {
+			continue
+		}
+		uniqueKeys[key] = true
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	for _, key := range sortedKeys {
+		m.acquire(key).mutex.Lock()
+	}
+	return func() {
+		// unlock in reverse order
+		for idx := len(sortedKeys) - 1; idx >= 0; idx-- {
+			m.release(sortedKeys[idx])
+		}
+	}
+}
+
+// acquire returns the entry of the given key after registering
+// the caller as its user
+func (m *KeyedMutex) acquire(key string) *keyedEntry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry, found := m.entries[key]
+	if !found {
+		entry = &keyedEntry{}
+		m.entries[key] = entry
+	}
+	entry.users++
+	return entry
+}
+
+// release unlocks the given key & removes its entry once it has
+// no users
+func (m *KeyedMutex) release(key string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	entry := m.entries[key]
+	entry.mutex.Unlock()
+	entry.users--
+	if entry.users == 0 {
+		delete(m.entries, key)
+	}
+}
+
+// Len returns the number of keys that are either locked or are
+// being waited upon
+func (m *KeyedMutex) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.entries)
+}
+
+// Limiter bounds the number of concurrent reconciliations
+type Limiter struct {
+	slots chan struct{}
+}
+
+// NewLimiter returns a new instance of Limiter that allows up to
+// max concurrent reconciliations. There is no bound if max is not
+// positive.
+func NewLimiter(max int) *Limiter {
+	if max <= 0 {
+		return &Limiter{}
+	}
+	return &Limiter{
+		slots: make(chan struct{}, max),
+	}
+}
+
+// Acquire waits till a reconciliation slot is available & returns
+// the function that releases this slot
+func (l *Limiter) Acquire() (release func()) {
+	if l.slots == nil {
+		return func() {}
+	}
+	l.slots <- struct{}{}
+	return func() {
+		<-l.slots
+	}
+}
+
+var (
+	// mutex is the keyed mutex shared by all the controllers of
+	// this process
+	mutex = NewKeyedMutex()
+
+	// reconciles bounds the concurrent reconciliations of all the
+	// controllers of this process
+	reconciles = NewLimiter(0)
+)
+
+// SetMaxConcurrentReconciles bounds the concurrent reconciliations
+// of all the controllers that make use of this package
+//
+// NOTE:
+//	This should be invoked before the controllers are started
+func SetMaxConcurrentReconciles(max int) {
+	reconciles = NewLimiter(max)
+}
+
+// Reconcile waits till a reconciliation slot is available & all
+// the given keys are locked. It returns the function that unlocks
+// these keys & releases the slot.
+//
+// NOTE:
+//	Slot is acquired before the keys. Hence, a reconciliation that
+// waits for its keys holds on to its slot.
+func Reconcile(keys ...string) (done func()) {
+	release := reconciles.Acquire()
+	unlock := mutex.Lock(keys...)
+	return func() {
+		unlock()
+		release()
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeyedMutexLock(t *testing.T) {
+	var tests = map[string]struct {
+		first       []string
+		second      []string
+		isSerialize bool
+	}{
+		"same key": {
+			first:       []string{"node/n1"},
+			second:      []string{"node/n1"},
+			isSerialize: true,
+		},
+		"different keys": {
+			first:  []string{"node/n1"},
+			second: []string{"node/n2"},
+		},
+		"overlapping keys in different order": {
+			first:       []string{"node/n1", "node/n2"},
+			second:      []string{"node/n2", "node/n3", "node/n1"},
+			isSerialize: true,
+		},
+		"duplicate keys": {
+			first:  []string{"node/n1", "node/n1"},
+			second: []string{"node/n2", "node/n2"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			m := NewKeyedMutex()
+			unlock := m.Lock(mock.first...)
+			locked := make(chan func())
+			go func() {
+				locked <- m.Lock(mock.second...)
+			}()
+			select {
+			case secondUnlock := <-locked:
+				if mock.isSerialize {
+					t.Fatalf("Expected second lock to wait got none")
+				}
+				secondUnlock()
+				unlock()
+			case <-time.After(100 * time.Millisecond):
+				if !mock.isSerialize {
+					t.Fatalf("Expected second lock to succeed got wait")
+				}
+				unlock()
+				(<-locked)()
+			}
+			if m.Len() != 0 {
+				t.Fatalf("Expected no keys after unlock got %d", m.Len())
+			}
+		})
+	}
+}
+
+func TestLimiterAcquire(t *testing.T) {
+	var tests = map[string]struct {
+		max          int
+		expectMaxRun int32
+	}{
+		"unbounded": {
+			max:          0,
+			expectMaxRun: 5,
+		},
+		"bounded to 1": {
+			max:          1,
+			expectMaxRun: 1,
+		},
+		"bounded to 2": {
+			max:          2,
+			expectMaxRun: 2,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			l := NewLimiter(mock.max)
+			var running, maxRun int32
+			var start, wg sync.WaitGroup
+			start.Add(1)
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					start.Wait()
+					release := l.Acquire()
+					defer release()
+					current := atomic.AddInt32(&running, 1)
+					for {
+						prev := atomic.LoadInt32(&maxRun)
+						if current <= prev ||
+							atomic.CompareAndSwapInt32(&maxRun, prev, current) {
+							break
+						}
+					}
+					time.Sleep(50 * time.Millisecond)
+					atomic.AddInt32(&running, -1)
+				}()
+			}
+			start.Done()
+			wg.Wait()
+			if maxRun != mock.expectMaxRun {
+				t.Fatalf(
+					"Expected max concurrent runs %d got %d",
+					mock.expectMaxRun,
+					maxRun,
+				)
+			}
+		})
+	}
+}
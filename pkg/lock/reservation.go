@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock
+
+import (
+	"sync"
+)
+
+// Reservations tracks the names of the block devices selected by
+// each owner e.g. a CStorClusterConfig UID
+//
+// NOTE:
+//	Locks are released once a sync returns its response. However,
+// the selected devices are claimed only after metac applies this
+// response. A reservation holds the selected devices till then &
+// hence these devices are not selected by other owners.
+type Reservations struct {
+	mutex  sync.Mutex
+	owners map[string]map[string]bool
+}
+
+// NewReservations returns a new instance of Reservations
+func NewReservations() *Reservations {
+	return &Reservations{
+		owners: map[string]map[string]bool{},
+	}
+}
+
+// Reserve sets the given names as the reservation of the given
+// owner. Names reserved earlier by this owner that are not given
+// are no longer reserved.
+func (r *Reservations) Reserve(owner string, names ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(names) == 0 {
+		delete(r.owners, owner)
+		return
+	}
+	reserved := map[string]bool{}
+	for _, name := range names {
+		reserved[name] = true
+	}
+	r.owners[owner] = reserved
+}
+
+// Release removes the given names from the reservations of all
+// the owners
+//
+// NOTE:
+//	This is invoked once the names are observed in the claims or
+// pools that hold them
+func (r *Reservations) Release(names ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for owner, reserved := range r.owners {
+		for _, name := range names {
+			delete(reserved, name)
+		}
+		if len(reserved) == 0 {
+			delete(r.owners, owner)
+		}
+	}
+}
+
+// ReservedByOthers returns the names reserved by the owners other
+// than the given owner
+func (r *Reservations) ReservedByOthers(owner string) map[string]bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	others := map[string]bool{}
+	for current, reserved := range r.owners {
+		if current == owner {
+			continue
+		}
+		for name := range reserved {
+			others[name] = true
+		}
+	}
+	return others
+}
+
+// reservations holds the block devices selected by all the
+// controllers of this process
+var reservations = NewReservations()
+
+// ReserveDevices sets the given block device names as the
+// reservation of the given owner
+func ReserveDevices(owner string, names ...string) {
+	reservations.Reserve(owner, names...)
+}
+
+// ReleaseDevices removes the given block device names from the
+// reservations of all the owners
+func ReleaseDevices(names ...string) {
+	reservations.Release(names...)
+}
+
+// DevicesReservedByOthers returns the block device names reserved
+// by the owners other than the given owner
+func DevicesReservedByOthers(owner string) map[string]bool {
+	return reservations.ReservedByOthers(owner)
+}
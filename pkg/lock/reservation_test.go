@@ -0,0 +1,105 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lock
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReservations(t *testing.T) {
+	type reservation struct {
+		owner string
+		names []string
+	}
+	var tests = map[string]struct {
+		reserve       []reservation
+		release       []string
+		owner         string
+		expectReserve map[string]bool
+	}{
+		"no reservations": {
+			owner:         "ccc-1",
+			expectReserve: map[string]bool{},
+		},
+		"own reservation is not reported": {
+			reserve: []reservation{
+				{"ccc-1", []string{"bd-1"}},
+			},
+			owner:         "ccc-1",
+			expectReserve: map[string]bool{},
+		},
+		"reservations of others are reported": {
+			reserve: []reservation{
+				{"ccc-1", []string{"bd-1"}},
+				{"ccc-2", []string{"bd-2", "bd-3"}},
+				{"ccc-3", []string{"bd-4"}},
+			},
+			owner: "ccc-1",
+			expectReserve: map[string]bool{
+				"bd-2": true,
+				"bd-3": true,
+				"bd-4": true,
+			},
+		},
+		"released names are not reported": {
+			reserve: []reservation{
+				{"ccc-2", []string{"bd-2", "bd-3"}},
+				{"ccc-3", []string{"bd-4"}},
+			},
+			release: []string{"bd-3", "bd-4"},
+			owner:   "ccc-1",
+			expectReserve: map[string]bool{
+				"bd-2": true,
+			},
+		},
+		"later reservation replaces the earlier one": {
+			reserve: []reservation{
+				{"ccc-2", []string{"bd-2", "bd-3"}},
+				{"ccc-2", []string{"bd-3"}},
+			},
+			owner: "ccc-1",
+			expectReserve: map[string]bool{
+				"bd-3": true,
+			},
+		},
+		"empty reservation clears the earlier one": {
+			reserve: []reservation{
+				{"ccc-2", []string{"bd-2"}},
+				{"ccc-2", nil},
+			},
+			owner:         "ccc-1",
+			expectReserve: map[string]bool{},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := NewReservations()
+			for _, res := range mock.reserve {
+				r.Reserve(res.owner, res.names...)
+			}
+			r.Release(mock.release...)
+			got := r.ReservedByOthers(mock.owner)
+			if diff := cmp.Diff(mock.expectReserve, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics holds the metrics recorded by the controllers.
+//
+// NOTE:
+//	These metrics are exposed as expvars at /debug/vars when this
+// operator is started with --debug-addr. Metrics are kept in memory
+// & are recorded again by the controllers after a restart.
+package metrics
+
+import (
+	"sync"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// nodeUtilizationRegistry holds the block device utilization of
+// nodes keyed by the namespace & name of CStorClusterConfig
+type nodeUtilizationRegistry struct {
+	sync.Mutex
+	configs map[string][]types.CStorClusterConfigNodeCapacity
+}
+
+var nodeUtilizationRegistryInstance = &nodeUtilizationRegistry{
+	configs: map[string][]types.CStorClusterConfigNodeCapacity{},
+}
+
+func getConfigKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// SetNodeBlockDeviceUtilization records the block device
+// utilization of the given nodes against the given config
+func SetNodeBlockDeviceUtilization(
+	namespace, name string, nodes []types.CStorClusterConfigNodeCapacity,
+) {
+	r := nodeUtilizationRegistryInstance
+	r.Lock()
+	defer r.Unlock()
+	if len(nodes) == 0 {
+		delete(r.configs, getConfigKey(namespace, name))
+		return
+	}
+	copied := make([]types.CStorClusterConfigNodeCapacity, len(nodes))
+	copy(copied, nodes)
+	r.configs[getConfigKey(namespace, name)] = copied
+}
+
+// DeleteNodeBlockDeviceUtilization removes the block device
+// utilization recorded against the given config
+func DeleteNodeBlockDeviceUtilization(namespace, name string) {
+	r := nodeUtilizationRegistryInstance
+	r.Lock()
+	defer r.Unlock()
+	delete(r.configs, getConfigKey(namespace, name))
+}
+
+// GetNodeBlockDeviceUtilization returns a copy of the block device
+// utilization of nodes keyed by <namespace>/<name> of config
+func GetNodeBlockDeviceUtilization() map[string][]types.CStorClusterConfigNodeCapacity {
+	r := nodeUtilizationRegistryInstance
+	r.Lock()
+	defer r.Unlock()
+	snapshot := map[string][]types.CStorClusterConfigNodeCapacity{}
+	for key, nodes := range r.configs {
+		copied := make([]types.CStorClusterConfigNodeCapacity, len(nodes))
+		copy(copied, nodes)
+		snapshot[key] = copied
+	}
+	return snapshot
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestNodeBlockDeviceUtilization(t *testing.T) {
+	nodes := []types.CStorClusterConfigNodeCapacity{
+		{
+			HostName:             "node-1",
+			BlockDeviceCount:     4,
+			PoolBlockDeviceCount: 3,
+			UtilizationPercent:   75,
+		},
+	}
+	SetNodeBlockDeviceUtilization("openebs", "config-1", nodes)
+	SetNodeBlockDeviceUtilization("openebs", "config-2", nodes)
+	// recorded nodes are not affected by the caller's changes
+	nodes[0].UtilizationPercent = 0
+	SetNodeBlockDeviceUtilization("openebs", "config-3", nil)
+	DeleteNodeBlockDeviceUtilization("openebs", "config-2")
+
+	expect := map[string][]types.CStorClusterConfigNodeCapacity{
+		"openebs/config-1": {
+			{
+				HostName:             "node-1",
+				BlockDeviceCount:     4,
+				PoolBlockDeviceCount: 3,
+				UtilizationPercent:   75,
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, GetNodeBlockDeviceUtilization()); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package operatorconfig loads the configuration of this operator
+// from a YAML file e.g. a mounted ConfigMap key & reloads it
+// whenever this file changes.
+//
+// NOTE:
+//	Settings of this file are applied as the values of their
+// command line flags. Flags set explicitly take precedence over
+// this file which in turn takes precedence over the flag defaults.
+package operatorconfig
+
+import (
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+)
+
+// LogLevelFlagName is the name of the flag that sets the log
+// verbosity
+const LogLevelFlagName string = "v"
+
+// RuntimeFlagNames are the names of the flags that are applied
+// again whenever the config file changes. Other flags are applied
+// only at startup.
+var RuntimeFlagNames = []string{LogLevelFlagName}
+
+// OperatorConfig is the configuration of this operator
+//
+// NOTE:
+//	Fields that are not set retain the values of their flags.
+type OperatorConfig struct {
+	// DebugAddr is the address of the metrics & debug endpoints
+	DebugAddr *string `json:"debugAddr,omitempty"`
+
+	// EnableControllers are the names of the controllers to be
+	// enabled
+	EnableControllers []string `json:"enableControllers,omitempty"`
+
+	// StorageNamespace is the namespace where Storages are created
+	StorageNamespace *string `json:"storageNamespace,omitempty"`
+
+	// StorageLabels are set against every Storage
+	StorageLabels map[string]string `json:"storageLabels,omitempty"`
+
+	// ReservedDeviceKeys are the label or annotation keys that
+	// reserve a block device for other consumers. An empty list
+	// disables reservations.
+	ReservedDeviceKeys []string `json:"reservedDeviceKeys,omitempty"`
+
+	// MaxConcurrentReconciles bounds the block device selecting
+	// reconciliations that run concurrently
+	MaxConcurrentReconciles *int `json:"maxConcurrentReconciles,omitempty"`
+
+	// SyncTimeout is the deadline of every controller sync
+	SyncTimeout *metav1.Duration `json:"syncTimeout,omitempty"`
+
+	// InventoryInterval is how often the inventory is refreshed
+	InventoryInterval *metav1.Duration `json:"inventoryInterval,omitempty"`
+
+	// OrphanAuditInterval is how often the orphaned children are
+	// audited
+	OrphanAuditInterval *metav1.Duration `json:"orphanAuditInterval,omitempty"`
+
+	// FeatureGates enable or disable the features keyed by their
+	// names
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+
+	// LogLevel is the log verbosity
+	//
+	// This is reloaded at runtime
+	LogLevel *int `json:"logLevel,omitempty"`
+
+	// ResyncAfter has the resync intervals keyed by controller
+	// names. These override the ones set via the resync config file
+	// but not the ones set via flags.
+	//
+	// This is reloaded at runtime
+	ResyncAfter map[string]metav1.Duration `json:"resyncAfter,omitempty"`
+
+	// MaxAPIThrottleLevel is the highest level the resyncs of all
+	// the controllers are throttled to when the API server is under
+	// pressure. Zero disables this throttle.
+	//
+	// This is reloaded at runtime
+	MaxAPIThrottleLevel *int `json:"maxAPIThrottleLevel,omitempty"`
+}
+
+// Parse parses the given YAML content into an OperatorConfig
+//
+// NOTE:
+//	Unknown fields are rejected to catch the typos early.
+func Parse(content []byte) (*OperatorConfig, error) {
+	config := &OperatorConfig{}
+	err := yaml.UnmarshalStrict(content, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid operator config")
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Load loads the OperatorConfig from the file at the given path
+func Load(path string) (*OperatorConfig, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't load operator config from %q", path)
+	}
+	config, err := Parse(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't load operator config from %q", path)
+	}
+	return config, nil
+}
+
+// Validate returns error if any of the settings is invalid
+func (c *OperatorConfig) Validate() error {
+	if c.MaxConcurrentReconciles != nil && *c.MaxConcurrentReconciles < 0 {
+		return errors.Errorf(
+			"Invalid maxConcurrentReconciles %d: Want a non negative value",
+			*c.MaxConcurrentReconciles,
+		)
+	}
+	if c.LogLevel != nil && *c.LogLevel < 0 {
+		return errors.Errorf(
+			"Invalid logLevel %d: Want a non negative value", *c.LogLevel,
+		)
+	}
+	if c.MaxAPIThrottleLevel != nil &&
+		(*c.MaxAPIThrottleLevel < 0 || *c.MaxAPIThrottleLevel > throttle.AdaptiveMaxLevel) {
+		return errors.Errorf(
+			"Invalid maxAPIThrottleLevel %d: Want value between 0 & %d",
+			*c.MaxAPIThrottleLevel,
+			throttle.AdaptiveMaxLevel,
+		)
+	}
+	durations := map[string]*metav1.Duration{
+		"syncTimeout":         c.SyncTimeout,
+		"inventoryInterval":   c.InventoryInterval,
+		"orphanAuditInterval": c.OrphanAuditInterval,
+	}
+	for name, interval := range c.ResyncAfter {
+		interval := interval
+		durations["resyncAfter."+name] = &interval
+	}
+	for name, duration := range durations {
+		if duration != nil && duration.Duration < 0 {
+			return errors.Errorf(
+				"Invalid %s %s: Want a non negative duration", name, duration.Duration,
+			)
+		}
+	}
+	return nil
+}
+
+// joinKeyValues returns the given map as sorted & comma separated
+// key=value pairs
+func joinKeyValues(m map[string]string) string {
+	var pairs []string
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// StartupFlags returns the values of the flags that are applied
+// only at startup keyed by the flag names. Flags whose settings are
+// not set are not returned.
+func (c *OperatorConfig) StartupFlags() map[string]string {
+	flags := map[string]string{}
+	if c.DebugAddr != nil {
+		flags["debug-addr"] = *c.DebugAddr
+	}
+	if c.EnableControllers != nil {
+		flags["enable-controllers"] = strings.Join(c.EnableControllers, ",")
+	}
+	if c.StorageNamespace != nil {
+		flags["storage-namespace"] = *c.StorageNamespace
+	}
+	if c.StorageLabels != nil {
+		flags["storage-labels"] = joinKeyValues(c.StorageLabels)
+	}
+	if c.ReservedDeviceKeys != nil {
+		flags["reserved-device-keys"] = strings.Join(c.ReservedDeviceKeys, ",")
+	}
+	if c.MaxConcurrentReconciles != nil {
+		flags["max-concurrent-reconciles"] = strconv.Itoa(*c.MaxConcurrentReconciles)
+	}
+	if c.SyncTimeout != nil {
+		flags["sync-timeout"] = c.SyncTimeout.Duration.String()
+	}
+	if c.InventoryInterval != nil {
+		flags["inventory-interval"] = c.InventoryInterval.Duration.String()
+	}
+	if c.OrphanAuditInterval != nil {
+		flags["orphan-audit-interval"] = c.OrphanAuditInterval.Duration.String()
+	}
+	if c.FeatureGates != nil {
+		gates := map[string]string{}
+		for name, enabled := range c.FeatureGates {
+			gates[name] = strconv.FormatBool(enabled)
+		}
+		flags["feature-gates"] = joinKeyValues(gates)
+	}
+	return flags
+}
+
+// RuntimeFlags returns the values of the flags that are applied
+// whenever the config file changes keyed by the flag names. Flags
+// whose settings are not set are not returned.
+func (c *OperatorConfig) RuntimeFlags() map[string]string {
+	flags := map[string]string{}
+	if c.LogLevel != nil {
+		flags[LogLevelFlagName] = strconv.Itoa(*c.LogLevel)
+	}
+	return flags
+}
+
+// GetResyncIntervals returns the resync intervals keyed by
+// controller names
+func (c *OperatorConfig) GetResyncIntervals() map[string]time.Duration {
+	intervals := map[string]time.Duration{}
+	for name, interval := range c.ResyncAfter {
+		intervals[name] = interval.Duration
+	}
+	return intervals
+}
+
+// GetMaxAPIThrottleLevel returns the highest level of the API
+// throttle
+func (c *OperatorConfig) GetMaxAPIThrottleLevel() int {
+	if c.MaxAPIThrottleLevel == nil {
+		return throttle.AdaptiveMaxLevel
+	}
+	return *c.MaxAPIThrottleLevel
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operatorconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	var tests = map[string]struct {
+		content        string
+		expectStartup  map[string]string
+		expectRuntime  map[string]string
+		expectResync   map[string]time.Duration
+		expectThrottle int
+		isErr          bool
+	}{
+		"empty config": {
+			expectStartup:  map[string]string{},
+			expectRuntime:  map[string]string{},
+			expectResync:   map[string]time.Duration{},
+			expectThrottle: 6,
+		},
+		"all settings": {
+			content: `
+debugAddr: ":9090"
+enableControllers: [blockdevice, localdevice]
+storageNamespace: storage
+storageLabels:
+  team: storage
+  app: cstor
+reservedDeviceKeys: []
+maxConcurrentReconciles: 4
+syncTimeout: 20s
+inventoryInterval: 1m
+orphanAuditInterval: 1h
+featureGates:
+  PerZoneCSPC: true
+  HybridDisks: false
+logLevel: 4
+resyncAfter:
+  blockdevice: 30s
+maxAPIThrottleLevel: 2
+`,
+			expectStartup: map[string]string{
+				"debug-addr":                ":9090",
+				"enable-controllers":        "blockdevice,localdevice",
+				"storage-namespace":         "storage",
+				"storage-labels":            "app=cstor,team=storage",
+				"reserved-device-keys":      "",
+				"max-concurrent-reconciles": "4",
+				"sync-timeout":              "20s",
+				"inventory-interval":        "1m0s",
+				"orphan-audit-interval":     "1h0m0s",
+				"feature-gates":             "HybridDisks=false,PerZoneCSPC=true",
+			},
+			expectRuntime: map[string]string{
+				"v": "4",
+			},
+			expectResync: map[string]time.Duration{
+				"blockdevice": 30 * time.Second,
+			},
+			expectThrottle: 2,
+		},
+		"unknown field": {
+			content: "logLevl: 4",
+			isErr:   true,
+		},
+		"negative log level": {
+			content: "logLevel: -1",
+			isErr:   true,
+		},
+		"throttle level beyond max": {
+			content: "maxAPIThrottleLevel: 7",
+			isErr:   true,
+		},
+		"negative resync interval": {
+			content: "resyncAfter:\n  blockdevice: -1s",
+			isErr:   true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := Parse([]byte(mock.content))
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expectStartup, got.StartupFlags()); diff != "" {
+				t.Fatalf("Expected no diff in startup flags got:\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectRuntime, got.RuntimeFlags()); diff != "" {
+				t.Fatalf("Expected no diff in runtime flags got:\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectResync, got.GetResyncIntervals()); diff != "" {
+				t.Fatalf("Expected no diff in resync intervals got:\n%s", diff)
+			}
+			if got.GetMaxAPIThrottleLevel() != mock.expectThrottle {
+				t.Fatalf(
+					"Expected max API throttle level %d got %d",
+					mock.expectThrottle, got.GetMaxAPIThrottleLevel(),
+				)
+			}
+		})
+	}
+}
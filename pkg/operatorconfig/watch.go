@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operatorconfig
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+)
+
+// watcher reloads the config file whenever it changes
+type watcher struct {
+	path     string
+	onChange func(*OperatorConfig)
+
+	// content of the config that was last loaded
+	content []byte
+}
+
+// reload loads the config file & invokes the change function if
+// the content of this file changed
+//
+// NOTE:
+//	Invalid content is logged & ignored. The last valid config
+// stays in effect till this file is fixed.
+func (w *watcher) reload() {
+	content, err := ioutil.ReadFile(w.path)
+	if err != nil {
+		glog.Errorf("Can't reload operator config from %q: %v", w.path, err)
+		return
+	}
+	if bytes.Equal(content, w.content) {
+		return
+	}
+	config, err := Parse(content)
+	if err != nil {
+		glog.Errorf("Can't reload operator config from %q: %v", w.path, err)
+		return
+	}
+	w.content = content
+	glog.Infof("Reloaded operator config from %q", w.path)
+	w.onChange(config)
+}
+
+// Watch invokes the given function with the reloaded config whenever
+// the file at the given path changes till the given channel is
+// closed
+//
+// NOTE:
+//	Directory of this file is watched instead of the file. A mounted
+// ConfigMap is updated by swapping the symlinks of its directory
+// which is not reported as a change to the file.
+func Watch(path string, onChange func(*OperatorConfig), stop <-chan struct{}) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "Can't watch operator config %q", path)
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrapf(err, "Can't watch operator config %q", path)
+	}
+	err = fsWatcher.Add(filepath.Dir(path))
+	if err != nil {
+		fsWatcher.Close()
+		return errors.Wrapf(err, "Can't watch operator config %q", path)
+	}
+	w := &watcher{
+		path:     path,
+		onChange: onChange,
+		content:  content,
+	}
+	go func() {
+		defer fsWatcher.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			case _, ok := <-fsWatcher.Events:
+				if !ok {
+					return
+				}
+				// content is compared since other files of this
+				// directory may have changed
+				w.reload()
+			case err, ok := <-fsWatcher.Errors:
+				if !ok {
+					return
+				}
+				glog.Errorf("Error watching operator config %q: %v", path, err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operatorconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operatorconfig")
+	if err != nil {
+		t.Fatalf("Can't create dir: %+v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+
+	var reloaded []*OperatorConfig
+	w := &watcher{
+		path: path,
+		onChange: func(config *OperatorConfig) {
+			reloaded = append(reloaded, config)
+		},
+	}
+	for idx, content := range []string{
+		"logLevel: 2",
+		// unchanged content is not reloaded
+		"logLevel: 2",
+		// invalid content is not reloaded
+		"logLevel: -2",
+		"logLevel: 4",
+	} {
+		err = ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("Can't write config %d: %+v", idx, err)
+		}
+		w.reload()
+	}
+	if len(reloaded) != 2 {
+		t.Fatalf("Expected 2 reloads got %d", len(reloaded))
+	}
+	if *reloaded[1].LogLevel != 4 {
+		t.Fatalf("Expected log level 4 got %d", *reloaded[1].LogLevel)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "operatorconfig")
+	if err != nil {
+		t.Fatalf("Can't create dir: %+v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(path, []byte("logLevel: 2"), 0644)
+	if err != nil {
+		t.Fatalf("Can't write config: %+v", err)
+	}
+
+	reloaded := make(chan *OperatorConfig, 10)
+	stop := make(chan struct{})
+	defer close(stop)
+	err = Watch(path, func(config *OperatorConfig) {
+		reloaded <- config
+	}, stop)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	err = ioutil.WriteFile(path, []byte("logLevel: 4"), 0644)
+	if err != nil {
+		t.Fatalf("Can't write config: %+v", err)
+	}
+	select {
+	case config := <-reloaded:
+		if *config.LogLevel != 4 {
+			t.Fatalf("Expected log level 4 got %d", *config.LogLevel)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected config to be reloaded")
+	}
+}
+
+func TestWatchMissingFile(t *testing.T) {
+	err := Watch(filepath.Join(os.TempDir(), "missing", "config.yaml"), nil, nil)
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+}
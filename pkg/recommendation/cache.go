@@ -0,0 +1,218 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"math"
+	"sort"
+
+	"mayadata.io/cstorpoolauto/common/blockdevice"
+	capacitymath "mayadata.io/cstorpoolauto/pkg/capacity"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// cacheDeviceAllocator allocates the write cache & read cache block
+// devices of the recommended pool instances
+//
+// NOTE:
+//	Cache devices of a pool instance are picked from the block devices
+// of its node that are not its data devices. Block devices of a kind
+// other than the kind of data devices are preferred e.g. SSDs are
+// preferred as cache devices of a pool on HDDs. Smallest block devices
+// that can form the min raid groups of a cache config are picked.
+type cacheDeviceAllocator struct {
+	// kind of the data devices e.g. disk-HDD
+	DataKind string
+
+	// nil config disables the corresponding cache
+	WriteCacheConfig *types.RaidGroupConfig
+	ReadCacheConfig  *types.RaidGroupConfig
+
+	// eligible block devices grouped by kind & node
+	DeviceTypeNodeBlockDeviceMap map[string]map[string][]blockdevice.MetaInfo
+
+	// raw capacity that can be recommended on each node
+	NodeAllowedCapacity map[string]int64
+}
+
+// isEnabled returns true if any of the caches is requested
+func (a *cacheDeviceAllocator) isEnabled() bool {
+	return a.WriteCacheConfig != nil || a.ReadCacheConfig != nil
+}
+
+// Allocate returns the given pool instances with their cache
+// devices. Pool instance whose node can't provide the requested
+// cache devices is not returned.
+func (a *cacheDeviceAllocator) Allocate(
+	poolInstances []types.PoolInstanceConfig,
+) []types.PoolInstanceConfig {
+	if !a.isEnabled() {
+		return poolInstances
+	}
+	var allocated []types.PoolInstanceConfig
+	for _, poolInstance := range poolInstances {
+		if a.allocatePoolInstance(&poolInstance) {
+			allocated = append(allocated, poolInstance)
+		}
+	}
+	return allocated
+}
+
+// allocatePoolInstance sets the cache devices against the given
+// pool instance. It returns false if these cache devices can't be
+// allocated.
+func (a *cacheDeviceAllocator) allocatePoolInstance(
+	poolInstance *types.PoolInstanceConfig,
+) bool {
+	nodeName := poolInstance.Node.Name
+	allowedCapacity, found := a.NodeAllowedCapacity[nodeName]
+	if !found {
+		allowedCapacity = math.MaxInt64
+	}
+	used := map[string]bool{}
+	for _, device := range poolInstance.BlockDevices.DataDevices {
+		used[device.Name] = true
+	}
+	candidates := a.listCandidates(nodeName)
+	for _, group := range candidates {
+		for _, candidate := range group {
+			if used[candidate.Identity.Name] {
+				allowedCapacity -= candidate.Capacity.Value()
+			}
+		}
+	}
+	if a.WriteCacheConfig != nil {
+		var devices []blockdevice.MetaInfo
+		devices, allowedCapacity = pickCacheDevices(
+			candidates, used, *a.WriteCacheConfig, allowedCapacity,
+		)
+		if len(devices) == 0 {
+			return false
+		}
+		poolInstance.BlockDevices.WriteCacheDevices = toReferences(devices)
+	}
+	if a.ReadCacheConfig != nil {
+		devices, _ := pickCacheDevices(
+			candidates, used, *a.ReadCacheConfig, allowedCapacity,
+		)
+		if len(devices) == 0 {
+			return false
+		}
+		poolInstance.BlockDevices.ReadCacheDevices = toReferences(devices)
+	}
+	return true
+}
+
+// listCandidates returns the block devices of the given node grouped
+// by their kind. Groups & the devices of each group are ordered by
+// their preference to be used as cache devices.
+func (a *cacheDeviceAllocator) listCandidates(nodeName string) [][]blockdevice.MetaInfo {
+	var kinds []string
+	for kind := range a.DeviceTypeNodeBlockDeviceMap {
+		kinds = append(kinds, kind)
+	}
+	// kind of data devices is the least preferred
+	sort.Slice(kinds, func(i, j int) bool {
+		if (kinds[i] == a.DataKind) != (kinds[j] == a.DataKind) {
+			return kinds[j] == a.DataKind
+		}
+		return kinds[i] < kinds[j]
+	})
+	var candidates [][]blockdevice.MetaInfo
+	for _, kind := range kinds {
+		devices := append(
+			[]blockdevice.MetaInfo{}, a.DeviceTypeNodeBlockDeviceMap[kind][nodeName]...,
+		)
+		sort.SliceStable(devices, func(i, j int) bool {
+			if cmp := devices[i].Capacity.Cmp(*devices[j].Capacity); cmp != 0 {
+				return cmp < 0
+			}
+			return devices[i].Identity.Name < devices[j].Identity.Name
+		})
+		candidates = append(candidates, devices)
+	}
+	return candidates
+}
+
+// pickCacheDevices picks the block devices of same kind & capacity
+// that form the min raid groups of the given config from the given
+// ordered candidates. Picked devices are marked as used. It returns
+// the picked devices along with the remaining allowed capacity.
+func pickCacheDevices(
+	candidates [][]blockdevice.MetaInfo,
+	used map[string]bool,
+	raidConfig types.RaidGroupConfig,
+	allowedCapacity int64,
+) ([]blockdevice.MetaInfo, int64) {
+	count := int(raidConfig.GroupDeviceCount * raidConfig.GetMinRaidGroupCount())
+	if count <= 0 {
+		return nil, allowedCapacity
+	}
+	for _, group := range candidates {
+		picks, rawCapacity := pickCacheDevicesFromGroup(
+			group, used, count, allowedCapacity,
+		)
+		if len(picks) != 0 {
+			return picks, allowedCapacity - rawCapacity
+		}
+	}
+	return nil, allowedCapacity
+}
+
+// pickCacheDevicesFromGroup picks the given count of block devices
+// of same capacity from the given group. It returns the picked
+// devices along with their raw capacity.
+func pickCacheDevicesFromGroup(
+	group []blockdevice.MetaInfo,
+	used map[string]bool,
+	count int,
+	allowedCapacity int64,
+) ([]blockdevice.MetaInfo, int64) {
+	for _, candidate := range group {
+		if used[candidate.Identity.Name] {
+			continue
+		}
+		var picks []blockdevice.MetaInfo
+		for _, other := range group {
+			if used[other.Identity.Name] || other.Capacity.Cmp(*candidate.Capacity) != 0 {
+				continue
+			}
+			picks = append(picks, other)
+			if len(picks) == count {
+				break
+			}
+		}
+		rawCapacity, err := capacitymath.Multiply(int64(count), candidate.Capacity.Value())
+		if err != nil || len(picks) != count || rawCapacity > allowedCapacity {
+			continue
+		}
+		for _, pick := range picks {
+			used[pick.Identity.Name] = true
+		}
+		return picks, rawCapacity
+	}
+	return nil, 0
+}
+
+// toReferences returns the identities of the given block devices
+func toReferences(devices []blockdevice.MetaInfo) []types.Reference {
+	var refs []types.Reference
+	for _, device := range devices {
+		refs = append(refs, *device.Identity)
+	}
+	return refs
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func TestGetRecommendationWithCacheDevices(t *testing.T) {
+	const gb int64 = 1073741824
+	poolCapacity, _ := resource.ParseQuantity(fmt.Sprintf("%d", 100*gb))
+	newBlockDevice := func(name, driveType string, capacity int64) unstructured.Unstructured {
+		bd := newTestBlockDevice(name, "node-1", capacity)
+		_ = unstructured.SetNestedField(bd.Object, driveType, "spec", "details", "driveType")
+		return bd
+	}
+	mirror := &types.RaidGroupConfig{
+		RAIDType:         types.PoolRAIDTypeMirror,
+		GroupDeviceCount: 2,
+	}
+	stripe := &types.RaidGroupConfig{
+		RAIDType:         types.PoolRAIDTypeStripe,
+		GroupDeviceCount: 1,
+	}
+	reserve := intstr.FromString(fmt.Sprintf("%dGi", 200))
+	var tests = map[string]struct {
+		blockDevices      []unstructured.Unstructured
+		writeCacheConfig  *types.RaidGroupConfig
+		readCacheConfig   *types.RaidGroupConfig
+		reservePerNode    *intstr.IntOrString
+		expectData        []string
+		expectWriteCache  []string
+		expectReadCache   []string
+		expectNoRecommend bool
+	}{
+		"no cache": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("hdd-1", "HDD", 100*gb),
+				newBlockDevice("hdd-2", "HDD", 100*gb),
+				newBlockDevice("ssd-1", "SSD", 10*gb),
+			},
+			expectData: []string{"hdd-1", "hdd-2"},
+		},
+		"write & read cache on ssd for data on hdd": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("hdd-1", "HDD", 100*gb),
+				newBlockDevice("hdd-2", "HDD", 100*gb),
+				newBlockDevice("ssd-3", "SSD", 20*gb),
+				newBlockDevice("ssd-2", "SSD", 10*gb),
+				newBlockDevice("ssd-1", "SSD", 10*gb),
+			},
+			writeCacheConfig: mirror,
+			readCacheConfig:  stripe,
+			expectData:       []string{"hdd-1", "hdd-2"},
+			expectWriteCache: []string{"ssd-1", "ssd-2"},
+			expectReadCache:  []string{"ssd-3"},
+		},
+		"cache on same kind as data": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("hdd-1", "HDD", 100*gb),
+				newBlockDevice("hdd-2", "HDD", 100*gb),
+				newBlockDevice("hdd-3", "HDD", 100*gb),
+			},
+			readCacheConfig: stripe,
+			expectData:      []string{"hdd-1", "hdd-2"},
+			expectReadCache: []string{"hdd-3"},
+		},
+		"not enough cache devices": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("hdd-1", "HDD", 100*gb),
+				newBlockDevice("hdd-2", "HDD", 100*gb),
+				newBlockDevice("ssd-1", "SSD", 10*gb),
+			},
+			writeCacheConfig:  mirror,
+			expectNoRecommend: true,
+		},
+		"cache devices of mixed capacity": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("hdd-1", "HDD", 100*gb),
+				newBlockDevice("hdd-2", "HDD", 100*gb),
+				newBlockDevice("ssd-1", "SSD", 10*gb),
+				newBlockDevice("ssd-2", "SSD", 20*gb),
+			},
+			writeCacheConfig:  mirror,
+			expectNoRecommend: true,
+		},
+		"cache devices eat into reserve": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("hdd-1", "HDD", 100*gb),
+				newBlockDevice("hdd-2", "HDD", 100*gb),
+				newBlockDevice("hdd-3", "HDD", 100*gb),
+				newBlockDevice("ssd-1", "SSD", 10*gb),
+				newBlockDevice("ssd-2", "SSD", 10*gb),
+			},
+			writeCacheConfig:  mirror,
+			reservePerNode:    &reserve,
+			expectNoRecommend: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := cStorPoolClusterRecommendationRequest{
+				Request: types.CStorPoolClusterRecommendationRequest{
+					Spec: types.CStorPoolClusterRecommendationRequestSpec{
+						PoolCapacity:     poolCapacity,
+						DataConfig:       *mirror,
+						WriteCacheConfig: mock.writeCacheConfig,
+						ReadCacheConfig:  mock.readCacheConfig,
+						ReservePerNode:   mock.reservePerNode,
+					},
+				},
+				Data: Data{
+					BlockDeviceList: &unstructured.UnstructuredList{Items: mock.blockDevices},
+				},
+			}
+			recommendations := r.GetRecommendation()
+			rec, found := recommendations["Unknown-HDD"]
+			if mock.expectNoRecommend {
+				if found {
+					t.Fatalf("Expected no recommendation got %+v", rec)
+				}
+				return
+			}
+			if !found || len(rec.Spec.PoolInstances) != 1 {
+				t.Fatalf("Expected 1 pool instance got %+v", recommendations)
+			}
+			names := func(refs []types.Reference) []string {
+				var got []string
+				for _, ref := range refs {
+					got = append(got, ref.Name)
+				}
+				return got
+			}
+			devices := rec.Spec.PoolInstances[0].BlockDevices
+			if got := names(devices.DataDevices); !reflect.DeepEqual(got, mock.expectData) {
+				t.Fatalf("Expected data devices %v got %v", mock.expectData, got)
+			}
+			if got := names(devices.WriteCacheDevices); !reflect.DeepEqual(got, mock.expectWriteCache) {
+				t.Fatalf("Expected write cache devices %v got %v", mock.expectWriteCache, got)
+			}
+			if got := names(devices.ReadCacheDevices); !reflect.DeepEqual(got, mock.expectReadCache) {
+				t.Fatalf("Expected read cache devices %v got %v", mock.expectReadCache, got)
+			}
+		})
+	}
+}
@@ -193,10 +193,11 @@ func (cc capacityCount) getCapacityRecommendation(
 		return result
 	}
 
+	minRaidGroupCount := raidConfig.GetMinRaidGroupCount()
 	for capacity, count := range cc {
-		// If device count is less than group device count
-		// then skip those block devices.
-		if count < raidConfig.GroupDeviceCount {
+		// If device count is less than the device count of the
+		// minimum raid groups then skip those block devices.
+		if count < raidConfig.GroupDeviceCount*minRaidGroupCount {
 			continue
 		}
 
@@ -204,7 +205,7 @@ func (cc capacityCount) getCapacityRecommendation(
 		// If min capacity in response 0 or greater than newMin then
 		// update the min to newMin.
 		newMin, err := resource.ParseQuantity(fmt.Sprintf("%d",
-			capacity*raidConfig.GetDataDeviceCount()))
+			capacity*minRaidGroupCount*raidConfig.GetDataDeviceCount()))
 		if err != nil {
 			glog.Warningf("Unable to parse size: Error %v", err)
 			continue
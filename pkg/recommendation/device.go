@@ -2,14 +2,19 @@ package recommendation
 
 import (
 	"fmt"
+	"math"
 	"sort"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"mayadata.io/cstorpoolauto/common/blockdevice"
+	"mayadata.io/cstorpoolauto/common/blockdeviceclaim"
+	"mayadata.io/cstorpoolauto/common/capacity"
+	capacitymath "mayadata.io/cstorpoolauto/pkg/capacity"
 	"mayadata.io/cstorpoolauto/types"
 )
 
@@ -23,6 +28,12 @@ type cStorPoolClusterRecommendationRequest struct {
 // Data is the input data requested for the specifix recommendation.
 type Data struct {
 	BlockDeviceList *unstructured.UnstructuredList
+
+	// Block devices used by these CStorPoolClusters or claimed by
+	// these BlockDeviceClaims are not recommended. These are
+	// optional.
+	CStorPoolClusterList *unstructured.UnstructuredList
+	BlockDeviceClaimList *unstructured.UnstructuredList
 }
 
 // NewRequestForDevice returns a device request object after validation.
@@ -47,6 +58,27 @@ func NewRequestForDevice(request *types.CStorPoolClusterRecommendationRequest, d
 	if err != nil {
 		return nil, errors.Wrap(err, "Unable to create capacity recommendation request")
 	}
+	if request.Spec.WriteCacheConfig != nil {
+		err = request.Spec.WriteCacheConfig.Validate()
+		if err != nil {
+			return nil, errors.Wrap(err,
+				"Unable to create device recommendation request: Invalid write cache config")
+		}
+	}
+	if request.Spec.ReadCacheConfig != nil {
+		err = request.Spec.ReadCacheConfig.Validate()
+		if err != nil {
+			return nil, errors.Wrap(err,
+				"Unable to create device recommendation request: Invalid read cache config")
+		}
+	}
+
+	if request.Spec.DevicePreference != "" &&
+		!types.SupportedDevicePreferences[request.Spec.DevicePreference] {
+		return nil, errors.Errorf(
+			"Unable to create device recommendation request: Invalid device preference %q",
+			request.Spec.DevicePreference)
+	}
 
 	cspcrr := cStorPoolClusterRecommendationRequest{
 		Request: *request,
@@ -70,10 +102,19 @@ func (r *cStorPoolClusterRecommendationRequest) GetRecommendation() map[string]t
 		return cStorPoolClusterRecommendation
 	}
 
+	inUse, freed, err := r.getInUseAndFreedDeviceNames()
+	if err != nil {
+		glog.Warningf("Got invalid cstor pool clusters or claims: %v", err)
+		return cStorPoolClusterRecommendation
+	}
+
 	availableBlockDeviceList := unstructured.UnstructuredList{}
 	availableBlockDeviceList.Object = r.Data.BlockDeviceList.Object
 	for _, bd := range r.Data.BlockDeviceList.Items {
-		isEligible, err := blockdevice.IsEligibleForCStorPool(bd)
+		if inUse[bd.GetName()] {
+			continue
+		}
+		isEligible, err := isEligibleForCStorPool(bd, freed[bd.GetName()])
 		if err == nil && isEligible {
 			availableBlockDeviceList.Items = append(availableBlockDeviceList.Items, bd)
 		}
@@ -88,6 +129,14 @@ func (r *cStorPoolClusterRecommendationRequest) GetRecommendation() map[string]t
 		return cStorPoolClusterRecommendation
 	}
 
+	nodeAllowedCapacity, err := getNodeAllowedCapacity(
+		r.Request.Spec.ReservePerNode, deviceTypeNodeBlockDeviceMap,
+	)
+	if err != nil {
+		glog.Warningf("Got invalid reserve per node: %v", err)
+		return cStorPoolClusterRecommendation
+	}
+
 	for kind, nodeBlockDeviceListMap := range deviceTypeNodeBlockDeviceMap {
 
 		nodeCapacityBlockDeviceMap := nodeCapacityBlockDevices{}
@@ -114,7 +163,18 @@ func (r *cStorPoolClusterRecommendationRequest) GetRecommendation() map[string]t
 			nodeCapacityBlockDeviceMap.update(nodeName, capacityBlockDevicesMap)
 		}
 
-		cStorPoolClusterRecommendationValue := nodeCapacityBlockDeviceMap.getDeviceRecommendation(r.Request.Spec.PoolCapacity, r.Request.Spec.DataConfig)
+		cStorPoolClusterRecommendationValue := nodeCapacityBlockDeviceMap.getDeviceRecommendation(r.Request.Spec.PoolCapacity, r.Request.Spec.DataConfig, nodeAllowedCapacity, r.Request.Spec.DevicePreference)
+
+		// cache devices are allocated per node after the data devices
+		cacheAllocator := &cacheDeviceAllocator{
+			DataKind:                     kind,
+			WriteCacheConfig:             r.Request.Spec.WriteCacheConfig,
+			ReadCacheConfig:              r.Request.Spec.ReadCacheConfig,
+			DeviceTypeNodeBlockDeviceMap: deviceTypeNodeBlockDeviceMap,
+			NodeAllowedCapacity:          nodeAllowedCapacity,
+		}
+		cStorPoolClusterRecommendationValue.Spec.PoolInstances =
+			cacheAllocator.Allocate(cStorPoolClusterRecommendationValue.Spec.PoolInstances)
 		cStorPoolClusterRecommendationValue.RequestSpec = r.Request.Spec
 		cStorPoolClusterRecommendationValue.ObjectMeta.Name = r.Request.ObjectMeta.Name
 		cStorPoolClusterRecommendationValue.ObjectMeta.Namespace = r.Request.ObjectMeta.Namespace
@@ -127,11 +187,138 @@ func (r *cStorPoolClusterRecommendationRequest) GetRecommendation() map[string]t
 	return cStorPoolClusterRecommendation
 }
 
+// getInUseAndFreedDeviceNames returns the names of the block devices
+// that are used or claimed by the existing CStorPoolClusters & the
+// ones that would be freed on deletion of the freed CStorPoolCluster
+func (r *cStorPoolClusterRecommendationRequest) getInUseAndFreedDeviceNames() (
+	map[string]bool, map[string]bool, error) {
+
+	freedName := r.Request.Spec.FreedCStorPoolClusterName
+	inUse := map[string]bool{}
+	freed := map[string]bool{}
+	if r.Data.CStorPoolClusterList != nil {
+		for idx := range r.Data.CStorPoolClusterList.Items {
+			cspc := &r.Data.CStorPoolClusterList.Items[idx]
+			names, err := blockdeviceclaim.GetCStorPoolClusterDeviceNames(cspc)
+			if err != nil {
+				return nil, nil, errors.Wrapf(
+					err, "Invalid cstor pool cluster %q", cspc.GetName(),
+				)
+			}
+			for _, name := range names {
+				if freedName != "" && cspc.GetName() == freedName {
+					freed[name] = true
+				} else {
+					inUse[name] = true
+				}
+			}
+		}
+	}
+	if r.Data.BlockDeviceClaimList != nil {
+		for idx := range r.Data.BlockDeviceClaimList.Items {
+			claim := &r.Data.BlockDeviceClaimList.Items[idx]
+			name, err := blockdeviceclaim.NewHelper(claim).GetBlockDeviceName()
+			if err != nil {
+				return nil, nil, errors.Wrapf(
+					err, "Invalid block device claim %q", claim.GetName(),
+				)
+			}
+			if freedName != "" && isClaimedByCStorPoolCluster(claim, freedName) {
+				freed[name] = true
+			} else {
+				inUse[name] = true
+			}
+		}
+	}
+	// a device used by the freed CStorPoolCluster is still in use
+	// if it is claimed by someone else
+	for name := range inUse {
+		delete(freed, name)
+	}
+	return inUse, freed, nil
+}
+
+// isClaimedByCStorPoolCluster returns true if the given claim was
+// made for the CStorPoolCluster with the given name
+func isClaimedByCStorPoolCluster(claim *unstructured.Unstructured, cspcName string) bool {
+	if claim.GetLabels()[capacity.LabelKeyCStorPoolCluster] == cspcName {
+		return true
+	}
+	for _, ref := range claim.GetOwnerReferences() {
+		if ref.Kind == string(types.KindCStorPoolCluster) && ref.Name == cspcName {
+			return true
+		}
+	}
+	return false
+}
+
+// isEligibleForCStorPool returns true if the given block device
+// can be used to create a cStor pool. A freed block device is
+// checked as if it was not claimed.
+func isEligibleForCStorPool(bd unstructured.Unstructured, isFreed bool) (bool, error) {
+	if !isFreed {
+		return blockdevice.IsEligibleForCStorPool(bd)
+	}
+	// only the claim state of a shallow copy is modified
+	unclaimed := unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range bd.Object {
+		unclaimed.Object[key] = value
+	}
+	status := map[string]interface{}{}
+	observedStatus, _ := bd.Object["status"].(map[string]interface{})
+	for key, value := range observedStatus {
+		status[key] = value
+	}
+	status["claimState"] = string(types.BlockDeviceUnclaimed)
+	unclaimed.Object["status"] = status
+	return blockdevice.IsEligibleForCStorPool(unclaimed)
+}
+
+// getNodeAllowedCapacity returns the raw capacity that can be
+// recommended on each node after leaving the reserved capacity.
+// Reserve is computed out of all the eligible block devices of
+// a node irrespective of their device type.
+func getNodeAllowedCapacity(
+	reservePerNode *intstr.IntOrString,
+	deviceTypeNodeBlockDeviceMap map[string]map[string][]blockdevice.MetaInfo,
+) (map[string]int64, error) {
+	nodeTotalCapacity := make(map[string]int64)
+	for _, nodeBlockDeviceListMap := range deviceTypeNodeBlockDeviceMap {
+		for nodeName, blockDeviceList := range nodeBlockDeviceListMap {
+			for _, blockDevice := range blockDeviceList {
+				deviceCapacity, found := blockDevice.Capacity.AsInt64()
+				if !found {
+					continue
+				}
+				total, err := capacitymath.Add(nodeTotalCapacity[nodeName], deviceCapacity)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Can't compute capacity of node %q", nodeName)
+				}
+				nodeTotalCapacity[nodeName] = total
+			}
+		}
+	}
+	nodeAllowedCapacity := make(map[string]int64)
+	for nodeName, total := range nodeTotalCapacity {
+		reserved, err := capacity.GetReservedBytes(reservePerNode, total)
+		if err != nil {
+			return nil, err
+		}
+		nodeAllowedCapacity[nodeName] = total - reserved
+	}
+	return nodeAllowedCapacity, nil
+}
+
 // nodeCapacityBlockDevice contains key with node name and value with capacityBlockDevice
 type nodeCapacityBlockDevices map[string]capacityBlockDevices
 
 // getDeviceRecommendation returns the recommended block devices on a node with the given configuration.
-func (ncb nodeCapacityBlockDevices) getDeviceRecommendation(requestedCapacity resource.Quantity, raidConfig types.RaidGroupConfig) types.CStorPoolClusterRecommendation {
+//
+// NOTE:
+//	Raw capacity recommended on a node does not exceed the allowed
+// capacity of that node. Node without any allowed capacity is not
+// limited.
+func (ncb nodeCapacityBlockDevices) getDeviceRecommendation(requestedCapacity resource.Quantity, raidConfig types.RaidGroupConfig, nodeAllowedCapacity map[string]int64, preference types.DevicePreference) types.CStorPoolClusterRecommendation {
 
 	requestedCapacityInt, ok := requestedCapacity.AsInt64()
 	if !ok {
@@ -142,7 +329,12 @@ func (ncb nodeCapacityBlockDevices) getDeviceRecommendation(requestedCapacity re
 
 	for nodeName, capacityBlockDevices := range ncb {
 
-		poolInstance := capacityBlockDevices.getPoolInstance(requestedCapacityInt, raidConfig)
+		allowedCapacity, found := nodeAllowedCapacity[nodeName]
+		if !found {
+			allowedCapacity = math.MaxInt64
+		}
+
+		poolInstance := capacityBlockDevices.getPoolInstance(requestedCapacityInt, raidConfig, allowedCapacity, preference)
 		poolInstance.Node.Name = nodeName
 
 		if len(poolInstance.BlockDevices.DataDevices) != 0 {
@@ -164,7 +356,34 @@ func (ncb nodeCapacityBlockDevices) getDeviceRecommendation(requestedCapacity re
 // value with all the block devices of that capacity.
 type capacityBlockDevices map[int64][]blockdevice.MetaInfo
 
-func (cbd capacityBlockDevices) getPoolInstance(requestedCapacity int64, raidConfig types.RaidGroupConfig) types.PoolInstanceConfig {
+// sortByPreference returns a copy of the given block devices of
+// same capacity sorted as per the given preference. Devices retain
+// their order if the preference is based on capacity.
+func sortByPreference(devices []blockdevice.MetaInfo, preference types.DevicePreference) []blockdevice.MetaInfo {
+	sorted := append([]blockdevice.MetaInfo{}, devices...)
+	switch preference {
+	case types.DevicePreferenceByPath:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].Path < sorted[j].Path
+		})
+	case types.DevicePreferenceByAge:
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].CreationTimestamp.Before(&sorted[j].CreationTimestamp)
+		})
+	}
+	return sorted
+}
+
+// getPoolInstance returns the pool instance built from the block
+// devices of a node
+//
+// NOTE:
+//	Smallest capacity that meets the requested capacity is used by
+// default. Largest capacity that meets the requested capacity is
+// used if the preference is LargestFirst.
+func (cbd capacityBlockDevices) getPoolInstance(requestedCapacity int64, raidConfig types.RaidGroupConfig, allowedCapacity int64, preference types.DevicePreference) types.PoolInstanceConfig {
+	isLargestFirst := preference == types.DevicePreferenceLargestFirst
+
 	// To sort map storing (ascending order) keys in a seperate data structure.
 	// Note: map cannot be sorted.
 	capacityKeys := make([]int64, 0, len(cbd))
@@ -172,6 +391,9 @@ func (cbd capacityBlockDevices) getPoolInstance(requestedCapacity int64, raidCon
 		capacityKeys = append(capacityKeys, capacity)
 	}
 	sort.SliceStable(capacityKeys, func(i, j int) bool {
+		if isLargestFirst {
+			return capacityKeys[i] > capacityKeys[j]
+		}
 		return capacityKeys[i] < capacityKeys[j]
 	})
 
@@ -180,16 +402,26 @@ func (cbd capacityBlockDevices) getPoolInstance(requestedCapacity int64, raidCon
 	for _, capacity := range capacityKeys {
 		dataDevices := []types.Reference{}
 
-		blockDevices := cbd[capacity]
+		blockDevices := sortByPreference(cbd[capacity], preference)
 		count := int64(len(blockDevices))
 
-		if count < raidConfig.GroupDeviceCount {
+		minRaidGroupCount := raidConfig.GetMinRaidGroupCount()
+		if count < raidConfig.GroupDeviceCount*minRaidGroupCount {
 			continue
 		}
 
 		raidGroupCount := count / raidConfig.GroupDeviceCount
 
-		maxCapacity := raidGroupCount * raidConfig.GetDataDeviceCount() * capacity
+		// capacities that overflow int64 exceed any requested
+		// capacity & hence are capped
+		groupCapacity, err := capacitymath.Multiply(raidConfig.GetDataDeviceCount(), capacity)
+		if err != nil {
+			groupCapacity = math.MaxInt64
+		}
+		maxCapacity, err := capacitymath.Multiply(raidGroupCount, groupCapacity)
+		if err != nil {
+			maxCapacity = math.MaxInt64
+		}
 
 		// If required pool capacity is greater than the max capacity of
 		// the current block devices then skip this device.
@@ -198,12 +430,24 @@ func (cbd capacityBlockDevices) getPoolInstance(requestedCapacity int64, raidCon
 		}
 
 		// Calculate the no of block devices to return to client.
-		rem := requestedCapacity % (raidConfig.GetDataDeviceCount() * capacity)
-		noOfRaidGroups := requestedCapacity / (raidConfig.GetDataDeviceCount() * capacity)
-		if rem != 0 {
-			noOfRaidGroups = noOfRaidGroups + 1
+		noOfRaidGroups, err := capacitymath.DivideCeil(requestedCapacity, groupCapacity)
+		if err != nil {
+			continue
+		}
+		// striped mirror needs more than one raid group even if
+		// a single raid group meets the requested capacity
+		if noOfRaidGroups < minRaidGroupCount {
+			noOfRaidGroups = minRaidGroupCount
 		}
 		noOfBlockDevices := noOfRaidGroups * raidConfig.GroupDeviceCount
+
+		// If the raw capacity of these block devices eats into the
+		// reserved capacity of the node then skip this device.
+		rawCapacity, err := capacitymath.Multiply(noOfBlockDevices, capacity)
+		if err != nil || rawCapacity > allowedCapacity {
+			continue
+		}
+
 		for i := 0; i < int(noOfBlockDevices); i++ {
 			dataDevices = append(dataDevices, *blockDevices[i].Identity)
 		}
@@ -211,8 +455,10 @@ func (cbd capacityBlockDevices) getPoolInstance(requestedCapacity int64, raidCon
 		// Lets say someone requested for a 100GB pool with mirror type and the node
 		// contains both 50GB and 100GB of block devices.
 		// This will break the loop once suitable block device is found and return the last block devices.
-		// In this case 100GB.
-		if len(prevDataDevices) != 0 && (requestedCapacity <= capacity) {
+		// In this case 100GB. Capacities are tried in descending order if
+		// largest devices are preferred & hence the first suitable one is
+		// returned.
+		if len(prevDataDevices) != 0 && (requestedCapacity <= capacity || isLargestFirst) {
 			break
 		}
 
@@ -224,19 +470,49 @@ func (cbd capacityBlockDevices) getPoolInstance(requestedCapacity int64, raidCon
 	}
 
 	// Calculate pool instance capacity
+	//
+	// NOTE:
+	//	Usable capacity deducts the parity or mirrored devices of
+	// every raid group from the raw capacity. i.e. one device per
+	// mirror & raidz group & two devices per raidz2 group.
 	noOfGroups := int64(len(prevDataDevices)) / raidConfig.GroupDeviceCount
-	instanceCapacity, err := resource.ParseQuantity(
-		fmt.Sprintf("%d", (noOfGroups * raidConfig.GetDataDeviceCount() * blockDeviceCapacity)))
+	instanceBytes, err := capacitymath.Multiply(
+		noOfGroups, raidConfig.GetDataDeviceCount(), blockDeviceCapacity,
+	)
+	if err != nil {
+		glog.Warningf("Unable to compute size: Error %v", err)
+		return types.PoolInstanceConfig{}
+	}
+	rawBytes, err := capacitymath.Multiply(int64(len(prevDataDevices)), blockDeviceCapacity)
+	if err != nil {
+		glog.Warningf("Unable to compute raw size: Error %v", err)
+		return types.PoolInstanceConfig{}
+	}
+	instanceCapacity, err := resource.ParseQuantity(fmt.Sprintf("%d", instanceBytes))
 	if err != nil {
 		glog.Warningf("Unable to parse size: Error %v", err)
 		return types.PoolInstanceConfig{}
 	}
+	rawCapacity, err := resource.ParseQuantity(fmt.Sprintf("%d", rawBytes))
+	if err != nil {
+		glog.Warningf("Unable to parse raw size: Error %v", err)
+		return types.PoolInstanceConfig{}
+	}
+	parityOverhead, err := resource.ParseQuantity(fmt.Sprintf("%d", rawBytes-instanceBytes))
+	if err != nil {
+		glog.Warningf("Unable to parse parity size: Error %v", err)
+		return types.PoolInstanceConfig{}
+	}
 
 	poolInstance := types.PoolInstanceConfig{
 		BlockDevices: types.BlockDeviceTopology{
 			DataDevices: prevDataDevices,
 		},
-		Capacity: instanceCapacity,
+		Capacity:       instanceCapacity,
+		RaidGroupCount: noOfGroups,
+		RawCapacity:    rawCapacity,
+		UsableCapacity: instanceCapacity,
+		ParityOverhead: parityOverhead,
 	}
 
 	return poolInstance
@@ -2,15 +2,20 @@ package recommendation
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"sort"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"mayadata.io/cstorpoolauto/common/blockdevice"
 	"mayadata.io/cstorpoolauto/types"
 )
 
 func TestGetRecommendation(t *testing.T) {
+	poolCapacity200GB, _ := resource.ParseQuantity(fmt.Sprintf("214748364800"))
 	poolCapacity100GB, _ := resource.ParseQuantity(fmt.Sprintf("107374182400"))
 	poolCapacity, _ := resource.ParseQuantity(fmt.Sprintf("53687091200"))
 	var tests = map[string]struct {
@@ -293,7 +298,11 @@ func TestGetRecommendation(t *testing.T) {
 								Node: types.Reference{
 									Name: "node-1",
 								},
-								Capacity: poolCapacity,
+								Capacity:       poolCapacity,
+								RaidGroupCount: 1,
+								RawCapacity:    poolCapacity100GB,
+								UsableCapacity: poolCapacity,
+								ParityOverhead: poolCapacity,
 								BlockDevices: types.BlockDeviceTopology{
 									DataDevices: []types.Reference{
 										{
@@ -595,7 +604,11 @@ func TestGetRecommendation(t *testing.T) {
 								Node: types.Reference{
 									Name: "node-1",
 								},
-								Capacity: poolCapacity100GB,
+								Capacity:       poolCapacity100GB,
+								RaidGroupCount: 1,
+								RawCapacity:    poolCapacity200GB,
+								UsableCapacity: poolCapacity100GB,
+								ParityOverhead: poolCapacity100GB,
 								BlockDevices: types.BlockDeviceTopology{
 									DataDevices: []types.Reference{
 										{
@@ -789,7 +802,11 @@ func TestGetRecommendation(t *testing.T) {
 								Node: types.Reference{
 									Name: "node-1",
 								},
-								Capacity: poolCapacity,
+								Capacity:       poolCapacity,
+								RaidGroupCount: 1,
+								RawCapacity:    poolCapacity100GB,
+								UsableCapacity: poolCapacity,
+								ParityOverhead: poolCapacity,
 								BlockDevices: types.BlockDeviceTopology{
 									DataDevices: []types.Reference{
 										{
@@ -863,6 +880,43 @@ func TestNewRequestForDevice(t *testing.T) {
 			},
 			isErr: true,
 		},
+		"invalid WriteCacheConfig": {
+			request: &types.CStorPoolClusterRecommendationRequest{
+				Spec: types.CStorPoolClusterRecommendationRequestSpec{
+					PoolCapacity: poolCapacity,
+					DataConfig: types.RaidGroupConfig{
+						RAIDType:         types.PoolRAIDTypeMirror,
+						GroupDeviceCount: 2,
+					},
+					WriteCacheConfig: &types.RaidGroupConfig{
+						RAIDType:         types.PoolRAIDTypeMirror,
+						GroupDeviceCount: 3,
+					},
+				},
+			},
+			data: &Data{
+				BlockDeviceList: &unstructured.UnstructuredList{},
+			},
+			isErr: true,
+		},
+		"invalid ReadCacheConfig": {
+			request: &types.CStorPoolClusterRecommendationRequest{
+				Spec: types.CStorPoolClusterRecommendationRequestSpec{
+					PoolCapacity: poolCapacity,
+					DataConfig: types.RaidGroupConfig{
+						RAIDType:         types.PoolRAIDTypeMirror,
+						GroupDeviceCount: 2,
+					},
+					ReadCacheConfig: &types.RaidGroupConfig{
+						RAIDType: "junk",
+					},
+				},
+			},
+			data: &Data{
+				BlockDeviceList: &unstructured.UnstructuredList{},
+			},
+			isErr: true,
+		},
 		"invalid RaidConfig": {
 			request: &types.CStorPoolClusterRecommendationRequest{
 				Spec: types.CStorPoolClusterRecommendationRequestSpec{
@@ -980,3 +1034,470 @@ func TestNewRequestForDevice(t *testing.T) {
 	}
 
 }
+
+func TestGetNodeAllowedCapacity(t *testing.T) {
+	newMetaInfo := func(capacity string) blockdevice.MetaInfo {
+		qty := resource.MustParse(capacity)
+		return blockdevice.MetaInfo{Capacity: &qty}
+	}
+	topology := map[string]map[string][]blockdevice.MetaInfo{
+		"HDD-disk": {
+			"node-1": {newMetaInfo("100"), newMetaInfo("100")},
+			"node-2": {newMetaInfo("100")},
+		},
+		"SSD-disk": {
+			"node-1": {newMetaInfo("200")},
+		},
+	}
+	var tests = map[string]struct {
+		reserve *intstr.IntOrString
+		expect  map[string]int64
+		isErr   bool
+	}{
+		"no reserve": {
+			expect: map[string]int64{"node-1": 400, "node-2": 100},
+		},
+		"quantity reserve": {
+			reserve: &intstr.IntOrString{Type: intstr.String, StrVal: "50"},
+			expect:  map[string]int64{"node-1": 350, "node-2": 50},
+		},
+		"percentage reserve": {
+			reserve: &intstr.IntOrString{Type: intstr.String, StrVal: "25%"},
+			expect:  map[string]int64{"node-1": 300, "node-2": 75},
+		},
+		"invalid reserve": {
+			reserve: &intstr.IntOrString{Type: intstr.String, StrVal: "junk"},
+			isErr:   true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := getNodeAllowedCapacity(mock.reserve, topology)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if !mock.isErr && !reflect.DeepEqual(got, mock.expect) {
+				t.Fatalf("Expected [%+v] got [%+v]", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestGetPoolInstanceWithAllowedCapacity(t *testing.T) {
+	newMetaInfo := func(name string) blockdevice.MetaInfo {
+		return blockdevice.MetaInfo{Identity: &types.Reference{Name: name}}
+	}
+	devices := capacityBlockDevices{
+		40: {
+			newMetaInfo("bd-40-1"), newMetaInfo("bd-40-2"),
+			newMetaInfo("bd-40-3"), newMetaInfo("bd-40-4"),
+			newMetaInfo("bd-40-5"), newMetaInfo("bd-40-6"),
+		},
+		100: {newMetaInfo("bd-100-1"), newMetaInfo("bd-100-2")},
+	}
+	mirror := types.RaidGroupConfig{
+		RAIDType:         types.PoolRAIDTypeMirror,
+		GroupDeviceCount: 2,
+	}
+	var tests = map[string]struct {
+		allowedCapacity int64
+		expectDevices   []string
+	}{
+		"no limit": {
+			allowedCapacity: math.MaxInt64,
+			expectDevices: []string{
+				"bd-40-1", "bd-40-2", "bd-40-3", "bd-40-4", "bd-40-5", "bd-40-6",
+			},
+		},
+		"limit skips the smaller devices": {
+			allowedCapacity: 200,
+			expectDevices:   []string{"bd-100-1", "bd-100-2"},
+		},
+		"limit is less than the raw capacity": {
+			allowedCapacity: 150,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := devices.getPoolInstance(100, mirror, mock.allowedCapacity, "")
+			var gotDevices []string
+			for _, device := range got.BlockDevices.DataDevices {
+				gotDevices = append(gotDevices, device.Name)
+			}
+			if !reflect.DeepEqual(gotDevices, mock.expectDevices) {
+				t.Fatalf("Expected %v got %v", mock.expectDevices, gotDevices)
+			}
+		})
+	}
+}
+
+func TestGetPoolInstanceStripedMirror(t *testing.T) {
+	newMetaInfo := func(name string) blockdevice.MetaInfo {
+		return blockdevice.MetaInfo{Identity: &types.Reference{Name: name}}
+	}
+	stripedMirror := types.RaidGroupConfig{
+		RAIDType:         types.PoolRAIDTypeStripedMirror,
+		GroupDeviceCount: 2,
+	}
+	var tests = map[string]struct {
+		devices        capacityBlockDevices
+		expectDevices  []string
+		expectCapacity string
+	}{
+		"2 devices are not enough": {
+			devices: capacityBlockDevices{
+				100: {newMetaInfo("bd-1"), newMetaInfo("bd-2")},
+			},
+			expectCapacity: "0",
+		},
+		"devices whose raw capacity overflows are skipped": {
+			devices: capacityBlockDevices{
+				math.MaxInt64 / 2: {
+					newMetaInfo("bd-1"), newMetaInfo("bd-2"),
+					newMetaInfo("bd-3"), newMetaInfo("bd-4"),
+				},
+			},
+			expectCapacity: "0",
+		},
+		"4 devices even if 1 raid group meets the capacity": {
+			devices: capacityBlockDevices{
+				100: {
+					newMetaInfo("bd-1"), newMetaInfo("bd-2"),
+					newMetaInfo("bd-3"), newMetaInfo("bd-4"),
+					newMetaInfo("bd-5"), newMetaInfo("bd-6"),
+				},
+			},
+			expectDevices:  []string{"bd-1", "bd-2", "bd-3", "bd-4"},
+			expectCapacity: "200",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.devices.getPoolInstance(100, stripedMirror, math.MaxInt64, "")
+			var gotDevices []string
+			for _, device := range got.BlockDevices.DataDevices {
+				gotDevices = append(gotDevices, device.Name)
+			}
+			if !reflect.DeepEqual(gotDevices, mock.expectDevices) {
+				t.Fatalf("Expected %v got %v", mock.expectDevices, gotDevices)
+			}
+			if got.Capacity.String() != mock.expectCapacity {
+				t.Fatalf("Expected capacity %s got %s", mock.expectCapacity, got.Capacity.String())
+			}
+		})
+	}
+}
+
+func TestGetPoolInstanceParityAwareCapacity(t *testing.T) {
+	var devices []blockdevice.MetaInfo
+	for i := 1; i <= 12; i++ {
+		devices = append(devices, blockdevice.MetaInfo{
+			Identity: &types.Reference{Name: fmt.Sprintf("bd-%d", i)},
+		})
+	}
+	var tests = map[string]struct {
+		raidConfig     types.RaidGroupConfig
+		expectGroups   int64
+		expectDevices  int
+		expectRaw      string
+		expectUsable   string
+		expectOverhead string
+	}{
+		"stripe": {
+			raidConfig: types.RaidGroupConfig{
+				RAIDType:         types.PoolRAIDTypeStripe,
+				GroupDeviceCount: 1,
+			},
+			expectGroups:   4,
+			expectDevices:  4,
+			expectRaw:      "400",
+			expectUsable:   "400",
+			expectOverhead: "0",
+		},
+		"raidz with 3 devices per group": {
+			raidConfig: types.RaidGroupConfig{
+				RAIDType:         types.PoolRAIDTypeRAIDZ,
+				GroupDeviceCount: 3,
+			},
+			expectGroups:   2,
+			expectDevices:  6,
+			expectRaw:      "600",
+			expectUsable:   "400",
+			expectOverhead: "200",
+		},
+		"raidz2 with 6 devices per group": {
+			raidConfig: types.RaidGroupConfig{
+				RAIDType:         types.PoolRAIDTypeRAIDZ2,
+				GroupDeviceCount: 6,
+			},
+			expectGroups:   1,
+			expectDevices:  6,
+			expectRaw:      "600",
+			expectUsable:   "400",
+			expectOverhead: "200",
+		},
+		"raidz2 with 4 devices per group": {
+			raidConfig: types.RaidGroupConfig{
+				RAIDType:         types.PoolRAIDTypeRAIDZ2,
+				GroupDeviceCount: 4,
+			},
+			expectGroups:   2,
+			expectDevices:  8,
+			expectRaw:      "800",
+			expectUsable:   "400",
+			expectOverhead: "400",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			cbd := capacityBlockDevices{100: devices}
+			got := cbd.getPoolInstance(350, mock.raidConfig, math.MaxInt64, "")
+			if len(got.BlockDevices.DataDevices) != mock.expectDevices {
+				t.Fatalf(
+					"Expected %d devices got %d",
+					mock.expectDevices, len(got.BlockDevices.DataDevices),
+				)
+			}
+			if got.RaidGroupCount != mock.expectGroups {
+				t.Fatalf("Expected %d raid groups got %d", mock.expectGroups, got.RaidGroupCount)
+			}
+			if got.RawCapacity.String() != mock.expectRaw {
+				t.Fatalf("Expected raw capacity %s got %s", mock.expectRaw, got.RawCapacity.String())
+			}
+			if got.UsableCapacity.String() != mock.expectUsable ||
+				got.Capacity.String() != mock.expectUsable {
+				t.Fatalf(
+					"Expected usable capacity %s got %s / %s",
+					mock.expectUsable, got.UsableCapacity.String(), got.Capacity.String(),
+				)
+			}
+			if got.ParityOverhead.String() != mock.expectOverhead {
+				t.Fatalf(
+					"Expected parity overhead %s got %s",
+					mock.expectOverhead, got.ParityOverhead.String(),
+				)
+			}
+		})
+	}
+}
+
+func TestGetPoolInstanceDevicePreference(t *testing.T) {
+	newDevice := func(name, path string, bytes int64) blockdevice.MetaInfo {
+		qty := resource.MustParse(fmt.Sprintf("%d", bytes))
+		return blockdevice.MetaInfo{
+			Identity: &types.Reference{Name: name},
+			Capacity: &qty,
+			Path:     path,
+		}
+	}
+	mirror := types.RaidGroupConfig{
+		RAIDType:         types.PoolRAIDTypeMirror,
+		GroupDeviceCount: 2,
+	}
+	cbd := capacityBlockDevices{
+		100: {
+			newDevice("bd-1", "/dev/sdd", 100),
+			newDevice("bd-2", "/dev/sdc", 100),
+			newDevice("bd-3", "/dev/sdb", 100),
+		},
+		200: {
+			newDevice("bd-4", "/dev/sde", 200),
+			newDevice("bd-5", "/dev/sdf", 200),
+		},
+		400: {
+			newDevice("bd-6", "/dev/sdg", 400),
+			newDevice("bd-7", "/dev/sdh", 400),
+		},
+	}
+	var tests = map[string]struct {
+		preference    types.DevicePreference
+		expectDevices []string
+	}{
+		"default preference": {
+			expectDevices: []string{"bd-1", "bd-2"},
+		},
+		"smallest first": {
+			preference:    types.DevicePreferenceSmallestFirst,
+			expectDevices: []string{"bd-1", "bd-2"},
+		},
+		"largest first": {
+			preference:    types.DevicePreferenceLargestFirst,
+			expectDevices: []string{"bd-6", "bd-7"},
+		},
+		"by path": {
+			preference:    types.DevicePreferenceByPath,
+			expectDevices: []string{"bd-3", "bd-2"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := cbd.getPoolInstance(100, mirror, math.MaxInt64, mock.preference)
+			var gotDevices []string
+			for _, device := range got.BlockDevices.DataDevices {
+				gotDevices = append(gotDevices, device.Name)
+			}
+			if !reflect.DeepEqual(gotDevices, mock.expectDevices) {
+				t.Fatalf("Expected %v got %v", mock.expectDevices, gotDevices)
+			}
+		})
+	}
+}
+
+func TestGetRecommendationHonorsClaimsAndPools(t *testing.T) {
+	const gb int64 = 1073741824
+	poolCapacity, _ := resource.ParseQuantity(fmt.Sprintf("%d", 100*gb))
+	newBlockDevice := func(name, claimState string) unstructured.Unstructured {
+		bd := newTestBlockDevice(name, "node-1", 100*gb)
+		_ = unstructured.SetNestedField(bd.Object, claimState, "status", "claimState")
+		return bd
+	}
+	newCSPC := func(name string, deviceNames ...string) unstructured.Unstructured {
+		var devices []interface{}
+		for _, deviceName := range deviceNames {
+			devices = append(devices, map[string]interface{}{
+				"blockDeviceName": deviceName,
+			})
+		}
+		return unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "openebs.io/v1alpha1",
+				"kind":       string(types.KindCStorPoolCluster),
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{
+					"pools": []interface{}{
+						map[string]interface{}{
+							"nodeSelector": map[string]interface{}{
+								"kubernetes.io/hostname": "node-1",
+							},
+							"raidGroups": []interface{}{
+								map[string]interface{}{
+									"type":         "mirror",
+									"blockDevices": devices,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	newClaim := func(name, deviceName, cspcName string) unstructured.Unstructured {
+		claim := unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "openebs.io/v1alpha1",
+				"kind":       string(types.KindBlockDeviceClaim),
+				"metadata": map[string]interface{}{
+					"name": name,
+				},
+				"spec": map[string]interface{}{
+					"blockDeviceName": deviceName,
+				},
+			},
+		}
+		if cspcName != "" {
+			claim.SetLabels(map[string]string{
+				"openebs.io/cstor-pool-cluster": cspcName,
+			})
+		}
+		return claim
+	}
+	var tests = map[string]struct {
+		blockDevices  []unstructured.Unstructured
+		cspcs         []unstructured.Unstructured
+		claims        []unstructured.Unstructured
+		freedCSPCName string
+		expectDevices []string
+	}{
+		"all devices are available": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("bd-1", "Unclaimed"), newBlockDevice("bd-2", "Unclaimed"),
+			},
+			expectDevices: []string{"bd-1", "bd-2"},
+		},
+		"device used by a cstor pool cluster": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("bd-1", "Unclaimed"), newBlockDevice("bd-2", "Unclaimed"),
+				newBlockDevice("bd-3", "Unclaimed"),
+			},
+			cspcs:         []unstructured.Unstructured{newCSPC("cspc-1", "bd-1")},
+			expectDevices: []string{"bd-2", "bd-3"},
+		},
+		"device claimed by a pending claim": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("bd-1", "Unclaimed"), newBlockDevice("bd-2", "Unclaimed"),
+			},
+			claims: []unstructured.Unstructured{newClaim("bdc-1", "bd-1", "")},
+		},
+		"devices freed by cstor pool cluster deletion": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("bd-1", "Claimed"), newBlockDevice("bd-2", "Claimed"),
+			},
+			cspcs: []unstructured.Unstructured{newCSPC("cspc-1", "bd-1", "bd-2")},
+			claims: []unstructured.Unstructured{
+				newClaim("bdc-1", "bd-1", "cspc-1"), newClaim("bdc-2", "bd-2", "cspc-1"),
+			},
+			freedCSPCName: "cspc-1",
+			expectDevices: []string{"bd-1", "bd-2"},
+		},
+		"freed device claimed by others": {
+			blockDevices: []unstructured.Unstructured{
+				newBlockDevice("bd-1", "Claimed"), newBlockDevice("bd-2", "Claimed"),
+			},
+			cspcs: []unstructured.Unstructured{newCSPC("cspc-1", "bd-1", "bd-2")},
+			claims: []unstructured.Unstructured{
+				newClaim("bdc-1", "bd-1", "cspc-1"), newClaim("bdc-2", "bd-2", "cspc-2"),
+			},
+			freedCSPCName: "cspc-1",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			r := cStorPoolClusterRecommendationRequest{
+				Request: types.CStorPoolClusterRecommendationRequest{
+					Spec: types.CStorPoolClusterRecommendationRequestSpec{
+						PoolCapacity: poolCapacity,
+						DataConfig: types.RaidGroupConfig{
+							RAIDType:         types.PoolRAIDTypeMirror,
+							GroupDeviceCount: 2,
+						},
+						FreedCStorPoolClusterName: mock.freedCSPCName,
+					},
+				},
+				Data: Data{
+					BlockDeviceList:      &unstructured.UnstructuredList{Items: mock.blockDevices},
+					CStorPoolClusterList: &unstructured.UnstructuredList{Items: mock.cspcs},
+					BlockDeviceClaimList: &unstructured.UnstructuredList{Items: mock.claims},
+				},
+			}
+			var gotDevices []string
+			for _, rec := range r.GetRecommendation() {
+				for _, instance := range rec.Spec.PoolInstances {
+					for _, device := range instance.BlockDevices.DataDevices {
+						gotDevices = append(gotDevices, device.Name)
+					}
+				}
+			}
+			sort.Strings(gotDevices)
+			if !reflect.DeepEqual(gotDevices, mock.expectDevices) {
+				t.Fatalf("Expected %v got %v", mock.expectDevices, gotDevices)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/blockdevice"
+	capacitymath "mayadata.io/cstorpoolauto/pkg/capacity"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// NodeCapacityEnvelope is the largest pool instance that can be
+// built on a node for a RAID type
+type NodeCapacityEnvelope struct {
+	NodeName string
+
+	// DeviceKind is the device type & block size of the block
+	// devices that form this pool instance
+	DeviceKind string
+
+	// Capacity is the usable capacity of this pool instance
+	Capacity resource.Quantity
+
+	// DeviceCount is the number of block devices consumed by this
+	// pool instance
+	DeviceCount int64
+}
+
+// CapacityEnvelope is the maximum pool capacity that can be built
+// for a RAID type on each node & across the cluster
+type CapacityEnvelope struct {
+	RAIDGroupConfig types.RaidGroupConfig
+
+	// Nodes are sorted by node name
+	Nodes []NodeCapacityEnvelope
+
+	// Capacity is the sum of usable capacities of all the nodes
+	Capacity resource.Quantity
+
+	// DeviceCount is the sum of block devices consumed on all the
+	// nodes
+	DeviceCount int64
+}
+
+// GetCapacityEnvelope returns the maximum pool capacity that can
+// be built for each supported RAID type out of the given block
+// devices. RAID types that can't form a pool on any node are not
+// part of the result.
+//
+// NOTE:
+//	A pool instance is built out of block devices of the same
+// device kind & capacity. Each RAID type uses its default raid
+// group device count.
+func GetCapacityEnvelope(
+	blockDeviceList *unstructured.UnstructuredList,
+) (map[types.PoolRAIDType]CapacityEnvelope, error) {
+	if blockDeviceList == nil {
+		return nil, errors.New(
+			"Unable to get capacity envelope: Got nil block device list")
+	}
+	result := make(map[types.PoolRAIDType]CapacityEnvelope)
+
+	eligibleBlockDeviceList := unstructured.UnstructuredList{}
+	eligibleBlockDeviceList.Object = blockDeviceList.Object
+	for _, bd := range blockDeviceList.Items {
+		isEligible, err := blockdevice.IsEligibleForCStorPool(bd)
+		if err == nil && isEligible {
+			eligibleBlockDeviceList.Items = append(eligibleBlockDeviceList.Items, bd)
+		}
+	}
+	if len(eligibleBlockDeviceList.Items) == 0 {
+		return result, nil
+	}
+
+	// kind to node to count of block devices per capacity
+	kindNodeCapacityCount := map[string]nodeCapacityCount{}
+	deviceTypeNodeBlockDeviceMap :=
+		blockdevice.GetTopologyMapGroupByDeviceTypeAndBlockSize(eligibleBlockDeviceList)
+	for kind, nodeBlockDeviceListMap := range deviceTypeNodeBlockDeviceMap {
+		nodeCapacityDeviceCountMap := nodeCapacityCount{}
+		for nodeName, blockDeviceList := range nodeBlockDeviceListMap {
+			capacityCountMap := nodeCapacityDeviceCountMap.getOrDefault(nodeName)
+			for _, blockDevice := range blockDeviceList {
+				capacity, found := blockDevice.Capacity.AsInt64()
+				if !found {
+					continue
+				}
+				capacityCountMap.update(capacity, capacityCountMap.getOrDefault(capacity)+1)
+			}
+		}
+		kindNodeCapacityCount[kind] = nodeCapacityDeviceCountMap
+	}
+
+	for raidType := range types.SupportedRAIDTypes {
+		raidConfig := types.RaidGroupConfig{RAIDType: raidType}
+		err := raidConfig.PopulateDefaultGroupDeviceCountIfNotPresent()
+		if err != nil {
+			return nil, errors.Wrap(err, "Unable to get capacity envelope")
+		}
+		envelope := getCapacityEnvelope(raidConfig, kindNodeCapacityCount)
+		if len(envelope.Nodes) != 0 {
+			result[raidType] = envelope
+		}
+	}
+	return result, nil
+}
+
+// getCapacityEnvelope returns the largest pool instance per node
+// for the given raid group config
+func getCapacityEnvelope(
+	raidConfig types.RaidGroupConfig, kindNodeCapacityCount map[string]nodeCapacityCount,
+) CapacityEnvelope {
+	nodeToMax := map[string]NodeCapacityEnvelope{}
+	for kind, ncc := range kindNodeCapacityCount {
+		for nodeName, cc := range ncc {
+			capacity, deviceCount := cc.getMaxPool(raidConfig)
+			if deviceCount == 0 {
+				continue
+			}
+			current, found := nodeToMax[nodeName]
+			// ties are broken by device kind to keep the result
+			// stable
+			if found && (current.Capacity.Value() > capacity ||
+				(current.Capacity.Value() == capacity && current.DeviceKind < kind)) {
+				continue
+			}
+			nodeToMax[nodeName] = NodeCapacityEnvelope{
+				NodeName:    nodeName,
+				DeviceKind:  kind,
+				Capacity:    *resource.NewQuantity(capacity, resource.BinarySI),
+				DeviceCount: deviceCount,
+			}
+		}
+	}
+	envelope := CapacityEnvelope{RAIDGroupConfig: raidConfig}
+	var total int64
+	for _, node := range nodeToMax {
+		envelope.Nodes = append(envelope.Nodes, node)
+		envelope.DeviceCount += node.DeviceCount
+		// sum of node capacities is capped instead of overflowing
+		sum, err := capacitymath.Add(total, node.Capacity.Value())
+		if err != nil {
+			sum = math.MaxInt64
+		}
+		total = sum
+	}
+	sort.Slice(envelope.Nodes, func(i, j int) bool {
+		return envelope.Nodes[i].NodeName < envelope.Nodes[j].NodeName
+	})
+	envelope.Capacity = *resource.NewQuantity(total, resource.BinarySI)
+	return envelope
+}
+
+// getMaxPool returns the usable capacity & the device count of the
+// largest pool instance that can be built for the given raid group
+// config. Zero device count is returned if no pool instance can be
+// built.
+func (cc capacityCount) getMaxPool(raidConfig types.RaidGroupConfig) (int64, int64) {
+	var maxCapacity, maxDeviceCount int64
+	minRaidGroupCount := raidConfig.GetMinRaidGroupCount()
+	for capacity, count := range cc {
+		raidGroupCount := count / raidConfig.GroupDeviceCount
+		if raidGroupCount < minRaidGroupCount {
+			continue
+		}
+		poolCapacity, err := capacitymath.Multiply(
+			capacity, raidGroupCount, raidConfig.GetDataDeviceCount(),
+		)
+		if err != nil {
+			// capacity beyond int64 is not representable
+			continue
+		}
+		deviceCount := raidGroupCount * raidConfig.GroupDeviceCount
+		// fewer devices are preferred for the same capacity
+		if poolCapacity > maxCapacity ||
+			(poolCapacity == maxCapacity && deviceCount < maxDeviceCount) {
+			maxCapacity = poolCapacity
+			maxDeviceCount = deviceCount
+		}
+	}
+	return maxCapacity, maxDeviceCount
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package recommendation
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// newTestBlockDevice returns an eligible block device of the given
+// capacity on the given node
+func newTestBlockDevice(name, nodeName string, capacity int64) unstructured.Unstructured {
+	return unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "openebs.io/v1alpha1",
+			"kind":       string(types.KindBlockDevice),
+			"metadata": map[string]interface{}{
+				"name": name,
+				"labels": map[string]interface{}{
+					"kubernetes.io/hostname":  nodeName,
+					"ndm.io/managed":          "false",
+					"ndm.io/blockdevice-type": "blockdevice",
+				},
+			},
+			"spec": map[string]interface{}{
+				"capacity": map[string]interface{}{
+					"storage":            capacity,
+					"physicalSectorSize": int32(512),
+					"logicalSectorSize":  int32(512),
+				},
+				"details": map[string]interface{}{
+					"deviceType": "",
+					"driveType":  "disk",
+				},
+				"nodeAttributes": map[string]interface{}{
+					"nodeName": nodeName,
+				},
+				"filesystem": map[string]interface{}{},
+			},
+			"status": map[string]interface{}{
+				"claimState": string("Unclaimed"),
+				"state":      string(types.BlockDeviceActive),
+			},
+		},
+	}
+}
+
+// newTestBlockDeviceList returns count block devices of the given
+// capacity per node
+func newTestBlockDeviceList(nodeToCount map[string]int, capacity int64) *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	for nodeName, count := range nodeToCount {
+		for i := 0; i < count; i++ {
+			list.Items = append(list.Items, newTestBlockDevice(
+				fmt.Sprintf("bd-%s-%d", nodeName, i), nodeName, capacity,
+			))
+		}
+	}
+	return list
+}
+
+func TestGetCapacityEnvelope(t *testing.T) {
+	const gb int64 = 1073741824
+	type nodeEnvelope struct {
+		capacity    int64
+		deviceCount int64
+	}
+	var tests = map[string]struct {
+		blockDevices *unstructured.UnstructuredList
+		expect       map[types.PoolRAIDType]map[string]nodeEnvelope
+		isErr        bool
+	}{
+		"nil block device list": {
+			isErr: true,
+		},
+		"empty block device list": {
+			blockDevices: &unstructured.UnstructuredList{},
+			expect:       map[types.PoolRAIDType]map[string]nodeEnvelope{},
+		},
+		"single device per node": {
+			blockDevices: newTestBlockDeviceList(
+				map[string]int{"node-1": 1, "node-2": 1}, 100*gb,
+			),
+			expect: map[types.PoolRAIDType]map[string]nodeEnvelope{
+				types.PoolRAIDTypeStripe: {
+					"node-1": {capacity: 100 * gb, deviceCount: 1},
+					"node-2": {capacity: 100 * gb, deviceCount: 1},
+				},
+			},
+		},
+		"five devices per node": {
+			blockDevices: newTestBlockDeviceList(
+				map[string]int{"node-1": 5}, 100*gb,
+			),
+			expect: map[types.PoolRAIDType]map[string]nodeEnvelope{
+				types.PoolRAIDTypeStripe: {
+					"node-1": {capacity: 500 * gb, deviceCount: 5},
+				},
+				types.PoolRAIDTypeMirror: {
+					"node-1": {capacity: 200 * gb, deviceCount: 4},
+				},
+				types.PoolRAIDTypeStripedMirror: {
+					"node-1": {capacity: 200 * gb, deviceCount: 4},
+				},
+				types.PoolRAIDTypeRAIDZ: {
+					"node-1": {capacity: 200 * gb, deviceCount: 3},
+				},
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := GetCapacityEnvelope(mock.blockDevices)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if len(got) != len(mock.expect) {
+				t.Fatalf("Expected %d raid types got %d: %+v", len(mock.expect), len(got), got)
+			}
+			for raidType, expectNodes := range mock.expect {
+				envelope, found := got[raidType]
+				if !found {
+					t.Fatalf("Expected raid type %q got none", raidType)
+				}
+				if len(envelope.Nodes) != len(expectNodes) {
+					t.Fatalf(
+						"Expected %d nodes for %q got %d",
+						len(expectNodes), raidType, len(envelope.Nodes),
+					)
+				}
+				var total, totalCount int64
+				for _, node := range envelope.Nodes {
+					expect := expectNodes[node.NodeName]
+					if node.Capacity.Value() != expect.capacity {
+						t.Fatalf(
+							"Expected capacity %d for %q on %q got %d",
+							expect.capacity, raidType, node.NodeName, node.Capacity.Value(),
+						)
+					}
+					if node.DeviceCount != expect.deviceCount {
+						t.Fatalf(
+							"Expected device count %d for %q on %q got %d",
+							expect.deviceCount, raidType, node.NodeName, node.DeviceCount,
+						)
+					}
+					total += expect.capacity
+					totalCount += expect.deviceCount
+				}
+				if envelope.Capacity.Value() != total || envelope.DeviceCount != totalCount {
+					t.Fatalf(
+						"Expected cluster capacity %d & device count %d for %q got %d & %d",
+						total, totalCount, raidType, envelope.Capacity.Value(), envelope.DeviceCount,
+					)
+				}
+			}
+		})
+	}
+}
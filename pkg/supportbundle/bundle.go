@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package supportbundle snapshots the inventory observed for a
+// CStorClusterConfig along with the latest sync decisions of the
+// controllers into a tarball that can be attached to a support
+// ticket.
+//
+// NOTE:
+//	Sync decisions are kept in memory & hence start afresh after
+// a restart of this operator.
+package supportbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+// MaxArchiveSize is the max size of a compressed bundle. This keeps
+// the bundle within the size limit of a ConfigMap.
+const MaxArchiveSize int = 900 * 1024
+
+// Bundle is the snapshot of the inventory observed for a
+// CStorClusterConfig
+type Bundle struct {
+	ClusterConfig     *unstructured.Unstructured
+	Nodes             []*unstructured.Unstructured
+	BlockDevices      []*unstructured.Unstructured
+	ClusterPlans      []*unstructured.Unstructured
+	StorageSets       []*unstructured.Unstructured
+	CStorPoolClusters []*unstructured.Unstructured
+	Decisions         []Decision
+
+	// CollectedAt is set as the modification time of the files of
+	// the archive
+	CollectedAt time.Time
+}
+
+// compact returns sorted copies of the given objects without the
+// fields that are of no use to debug the pools
+func compact(objs []*unstructured.Unstructured) []map[string]interface{} {
+	var copied []*unstructured.Unstructured
+	for _, obj := range objs {
+		if obj == nil {
+			continue
+		}
+		obj = obj.DeepCopy()
+		unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+		// images of a node are many & are not related to its disks
+		unstructured.RemoveNestedField(obj.Object, "status", "images")
+		copied = append(copied, obj)
+	}
+	// sorted to archive the objects in the same order across
+	// collections
+	sort.SliceStable(copied, func(i, j int) bool {
+		if copied[i].GetNamespace() != copied[j].GetNamespace() {
+			return copied[i].GetNamespace() < copied[j].GetNamespace()
+		}
+		return copied[i].GetName() < copied[j].GetName()
+	})
+	list := []map[string]interface{}{}
+	for _, obj := range copied {
+		list = append(list, obj.Object)
+	}
+	return list
+}
+
+// Archive returns the bundle as a gzipped tarball with a JSON file
+// per kind of the observed objects & a JSON file of sync decisions
+func (b Bundle) Archive() ([]byte, error) {
+	var configs []*unstructured.Unstructured
+	if b.ClusterConfig != nil {
+		configs = append(configs, b.ClusterConfig)
+	}
+	decisions := b.Decisions
+	if decisions == nil {
+		decisions = []Decision{}
+	}
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"cstorclusterconfig.json", compact(configs)},
+		{"nodes.json", compact(b.Nodes)},
+		{"blockdevices.json", compact(b.BlockDevices)},
+		{"cstorclusterplans.json", compact(b.ClusterPlans)},
+		{"cstorclusterstoragesets.json", compact(b.StorageSets)},
+		{"cstorpoolclusters.json", compact(b.CStorPoolClusters)},
+		{"decisions.json", decisions},
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range files {
+		raw, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return nil, errors.Wrapf(err, "Can't archive support bundle: File %q", file.name)
+		}
+		err = tw.WriteHeader(&tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(raw)),
+			ModTime: b.CollectedAt,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Can't archive support bundle: File %q", file.name)
+		}
+		_, err = tw.Write(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Can't archive support bundle: File %q", file.name)
+		}
+	}
+	err := tw.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't archive support bundle")
+	}
+	err = gz.Close()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't archive support bundle")
+	}
+	if buf.Len() > MaxArchiveSize {
+		return nil, errs.ValidationErrorf(
+			"Can't archive support bundle: Size %d exceeds %d bytes",
+			buf.Len(), MaxArchiveSize,
+		)
+	}
+	return buf.Bytes(), nil
+}
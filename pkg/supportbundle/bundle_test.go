@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supportbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newTestObj(kind, name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"kind": kind,
+			"metadata": map[string]interface{}{
+				"name": name,
+				"managedFields": []interface{}{
+					map[string]interface{}{"manager": "kubectl"},
+				},
+			},
+			"status": map[string]interface{}{
+				"images": []interface{}{"nginx"},
+			},
+		},
+	}
+}
+
+// untar returns the files of the given gzipped tarball keyed by
+// their names
+func untar(t *testing.T, archive []byte) map[string][]byte {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatalf("Can't read gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string][]byte{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Can't read tar: %v", err)
+		}
+		raw, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Can't read file %q: %v", hdr.Name, err)
+		}
+		files[hdr.Name] = raw
+	}
+	return files
+}
+
+func TestBundleArchive(t *testing.T) {
+	bundle := Bundle{
+		ClusterConfig: newTestObj("CStorClusterConfig", "my-config"),
+		Nodes: []*unstructured.Unstructured{
+			newTestObj("Node", "node-2"),
+			nil,
+			newTestObj("Node", "node-1"),
+		},
+		Decisions: []Decision{
+			{Controller: "localdevice", Outcome: OutcomeApplied},
+		},
+		CollectedAt: time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC),
+	}
+	archive, err := bundle.Archive()
+	if err != nil {
+		t.Fatalf("Expected no error got %v", err)
+	}
+	files := untar(t, archive)
+	if len(files) != 7 {
+		t.Fatalf("Expected 7 files got %d", len(files))
+	}
+	var nodes []map[string]interface{}
+	err = json.Unmarshal(files["nodes.json"], &nodes)
+	if err != nil {
+		t.Fatalf("Can't unmarshal nodes: %v", err)
+	}
+	expectNodes := []map[string]interface{}{
+		{
+			"kind":     "Node",
+			"metadata": map[string]interface{}{"name": "node-1"},
+			"status":   map[string]interface{}{},
+		},
+		{
+			"kind":     "Node",
+			"metadata": map[string]interface{}{"name": "node-2"},
+			"status":   map[string]interface{}{},
+		},
+	}
+	if diff := cmp.Diff(expectNodes, nodes); diff != "" {
+		t.Fatalf("Expected no diff in nodes got:\n%s", diff)
+	}
+	var decisions []Decision
+	err = json.Unmarshal(files["decisions.json"], &decisions)
+	if err != nil {
+		t.Fatalf("Can't unmarshal decisions: %v", err)
+	}
+	if diff := cmp.Diff(bundle.Decisions, decisions); diff != "" {
+		t.Fatalf("Expected no diff in decisions got:\n%s", diff)
+	}
+	if string(files["blockdevices.json"]) != "[]" {
+		t.Fatalf("Expected empty blockdevices got %s", files["blockdevices.json"])
+	}
+	// source objects are not modified
+	_, found, _ := unstructured.NestedSlice(
+		bundle.Nodes[0].Object, "metadata", "managedFields",
+	)
+	if !found {
+		t.Fatalf("Expected managedFields to be retained in the source")
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package supportbundle
+
+import (
+	"sync"
+	"time"
+
+	"openebs.io/metac/controller/generic"
+)
+
+// DefaultDecisionLimit is the default number of the latest sync
+// decisions retained in memory
+const DefaultDecisionLimit int = 100
+
+// Outcome of a sync of a controller
+type Outcome string
+
+const (
+	// OutcomeApplied implies the desired state was applied
+	OutcomeApplied Outcome = "Applied"
+
+	// OutcomeSkipped implies the reconciliation was skipped
+	OutcomeSkipped Outcome = "Skipped"
+
+	// OutcomeFailed implies the hook returned an error
+	OutcomeFailed Outcome = "Failed"
+)
+
+// Decision is the outcome of a sync of a controller
+type Decision struct {
+	Time       string  `json:"time"`
+	Controller string  `json:"controller"`
+	Watch      string  `json:"watch"`
+	Outcome    Outcome `json:"outcome"`
+	Reason     string  `json:"reason,omitempty"`
+	Message    string  `json:"message,omitempty"`
+}
+
+// skipNote is the reason of a skipped sync that is noted before
+// the sync completes
+type skipNote struct {
+	reason    string
+	message   string
+	isIgnored bool
+}
+
+// decisionRecorder holds the latest sync decisions of all the
+// controllers
+type decisionRecorder struct {
+	sync.Mutex
+	limit     int
+	decisions []Decision
+
+	// notes are keyed by the response of the ongoing sync
+	notes map[*generic.SyncHookResponse]skipNote
+}
+
+var decisionRecorderInstance = &decisionRecorder{
+	limit: DefaultDecisionLimit,
+	notes: map[*generic.SyncHookResponse]skipNote{},
+}
+
+// SetDecisionLimit sets the number of the latest sync decisions
+// that are retained. A value <= 0 disables the recording.
+func SetDecisionLimit(limit int) {
+	r := decisionRecorderInstance
+	r.Lock()
+	defer r.Unlock()
+	r.limit = limit
+	r.trim()
+}
+
+// trim drops the oldest decisions that exceed the limit
+func (r *decisionRecorder) trim() {
+	limit := r.limit
+	if limit < 0 {
+		limit = 0
+	}
+	if len(r.decisions) > limit {
+		r.decisions = append([]Decision(nil), r.decisions[len(r.decisions)-limit:]...)
+	}
+}
+
+// NoteSkip notes the reason of skipping the sync that responds via
+// the given response. This reason is recorded once the sync
+// completes.
+func NoteSkip(response *generic.SyncHookResponse, reason, message string) {
+	if response == nil {
+		return
+	}
+	r := decisionRecorderInstance
+	r.Lock()
+	defer r.Unlock()
+	if r.limit <= 0 {
+		return
+	}
+	r.notes[response] = skipNote{reason: reason, message: message}
+}
+
+// Ignore lets the sync that responds via the given response not be
+// recorded
+//
+// NOTE:
+//	Routine syncs e.g. the ones that find nothing changed would
+// otherwise push out the decisions that are worth a look
+func Ignore(response *generic.SyncHookResponse) {
+	if response == nil {
+		return
+	}
+	r := decisionRecorderInstance
+	r.Lock()
+	defer r.Unlock()
+	if r.limit <= 0 {
+		return
+	}
+	r.notes[response] = skipNote{isIgnored: true}
+}
+
+// RecordSync records the decision of a completed sync of the given
+// controller
+func RecordSync(
+	controller string,
+	request *generic.SyncHookRequest,
+	response *generic.SyncHookResponse,
+	err error,
+) {
+	r := decisionRecorderInstance
+	r.Lock()
+	defer r.Unlock()
+	note := r.notes[response]
+	delete(r.notes, response)
+	if r.limit <= 0 || note.isIgnored {
+		return
+	}
+	decision := Decision{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Controller: controller,
+		Outcome:    OutcomeApplied,
+	}
+	if request != nil && request.Watch != nil {
+		decision.Watch = request.Watch.GetKind() + " " +
+			request.Watch.GetNamespace() + "/" + request.Watch.GetName()
+	}
+	if err != nil {
+		decision.Outcome = OutcomeFailed
+		decision.Message = err.Error()
+	} else if response != nil && response.SkipReconcile {
+		// skips without a noted reason are due to errors that
+		// are reported against the status of the watch
+		decision.Outcome = OutcomeSkipped
+		decision.Reason = note.reason
+		decision.Message = note.message
+	}
+	r.decisions = append(r.decisions, decision)
+	r.trim()
+}
+
+// GetDecisions returns a copy of the latest sync decisions with
+// the oldest decision first
+func GetDecisions() []Decision {
+	r := decisionRecorderInstance
+	r.Lock()
+	defer r.Unlock()
+	return append([]Decision(nil), r.decisions...)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supportbundle
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+)
+
+func newTestRequest(name string) *generic.SyncHookRequest {
+	watch := &unstructured.Unstructured{}
+	watch.SetKind("CStorClusterConfig")
+	watch.SetNamespace("openebs")
+	watch.SetName(name)
+	return &generic.SyncHookRequest{Watch: watch}
+}
+
+// resetDecisions drops the recorded decisions & restores the
+// default limit
+func resetDecisions() {
+	SetDecisionLimit(0)
+	SetDecisionLimit(DefaultDecisionLimit)
+}
+
+func TestRecordSync(t *testing.T) {
+	resetDecisions()
+	defer resetDecisions()
+	SetDecisionLimit(2)
+
+	// ignored sync is not recorded
+	ignored := &generic.SyncHookResponse{SkipReconcile: true}
+	Ignore(ignored)
+	RecordSync("localdevice", newTestRequest("config-0"), ignored, nil)
+
+	skipped := &generic.SyncHookResponse{SkipReconcile: true}
+	NoteSkip(skipped, "Paused", "Reconciliation is paused")
+	RecordSync("localdevice", newTestRequest("config-1"), skipped, nil)
+	RecordSync(
+		"storageclass",
+		newTestRequest("config-2"),
+		&generic.SyncHookResponse{},
+		errors.New("boom"),
+	)
+	RecordSync(
+		"poolautoscaler",
+		newTestRequest("config-3"),
+		&generic.SyncHookResponse{},
+		nil,
+	)
+
+	got := GetDecisions()
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 decisions got %d", len(got))
+	}
+	if got[0].Outcome != OutcomeFailed || got[0].Message != "boom" ||
+		got[0].Watch != "CStorClusterConfig openebs/config-2" {
+		t.Fatalf("Expected failed decision of config-2 got %+v", got[0])
+	}
+	if got[1].Outcome != OutcomeApplied || got[1].Controller != "poolautoscaler" {
+		t.Fatalf("Expected applied decision of poolautoscaler got %+v", got[1])
+	}
+	if len(decisionRecorderInstance.notes) != 0 {
+		t.Fatalf("Expected no pending notes got %d", len(decisionRecorderInstance.notes))
+	}
+
+	SetDecisionLimit(1)
+	got = GetDecisions()
+	if len(got) != 1 || got[0].Controller != "poolautoscaler" {
+		t.Fatalf("Expected latest decision of poolautoscaler got %+v", got)
+	}
+
+	SetDecisionLimit(0)
+	RecordSync("localdevice", newTestRequest("config-4"), skipped, nil)
+	if len(GetDecisions()) != 0 {
+		t.Fatalf("Expected no decisions when recording is disabled")
+	}
+}
+
+func TestRecordSyncSkipped(t *testing.T) {
+	resetDecisions()
+	defer resetDecisions()
+
+	skipped := &generic.SyncHookResponse{SkipReconcile: true}
+	NoteSkip(skipped, "Paused", "Reconciliation is paused")
+	RecordSync("localdevice", newTestRequest("config-1"), skipped, nil)
+
+	got := GetDecisions()
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 decision got %d", len(got))
+	}
+	if got[0].Outcome != OutcomeSkipped || got[0].Reason != "Paused" ||
+		got[0].Message != "Reconciliation is paused" {
+		t.Fatalf("Expected skipped decision with reason got %+v", got[0])
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// AdaptiveRaiseErrorRatio is the ratio of failed API requests
+	// in a window at or above which the throttle level is raised
+	AdaptiveRaiseErrorRatio float64 = 0.2
+
+	// AdaptiveLowerErrorRatio is the ratio of failed API requests
+	// in a window at or below which the throttle level is lowered
+	AdaptiveLowerErrorRatio float64 = 0.05
+
+	// AdaptiveMaxLevel is the highest throttle level
+	AdaptiveMaxLevel int = 6
+
+	// AdaptiveBaseResyncAfterSeconds is the minimum resync interval
+	// at throttle level 1. Every next level doubles this interval.
+	AdaptiveBaseResyncAfterSeconds float64 = 5
+)
+
+// Adaptive raises the resync interval of all the syncs of this
+// process when a high ratio of the recent API requests failed e.g.
+// due to throttling (429) or server errors (5xx)
+//
+// NOTE:
+//	Failed requests are counted per window. The level is raised by
+// one if the ratio of failed requests of a window reaches the raise
+// ratio. It is lowered by one if this ratio stays below the lower
+// ratio. Level is retained for ratios in between. This avoids the
+// level from flapping. Windows without any requests lower the level.
+//
+// NOTE:
+//	Throttle is shared by all the controllers. Hence, controllers
+// back off together instead of per watch.
+type Adaptive struct {
+	window time.Duration
+
+	// now is the current time & is replaceable in tests
+	now func() time.Time
+
+	mutex       sync.Mutex
+	windowStart time.Time
+	requests    int64
+	failures    int64
+	level       int
+	maxLevel    int
+}
+
+// AdaptiveStats is the current state of an adaptive throttle
+type AdaptiveStats struct {
+	Level              int     `json:"level"`
+	ResyncAfterSeconds float64 `json:"resyncAfterSeconds"`
+
+	// requests & failures of the current window
+	WindowRequests int64 `json:"windowRequests"`
+	WindowFailures int64 `json:"windowFailures"`
+}
+
+// NewAdaptive returns a new instance of Adaptive that evaluates the
+// ratio of failed API requests once per the given window
+func NewAdaptive(window time.Duration) *Adaptive {
+	return &Adaptive{
+		window:   window,
+		now:      time.Now,
+		maxLevel: AdaptiveMaxLevel,
+	}
+}
+
+// API is the adaptive throttle shared by all the controllers
+var API = NewAdaptive(30 * time.Second)
+
+// roll evaluates the windows that have elapsed since the current
+// window started
+//
+// NOTE:
+//	This must be invoked with the lock held
+func (a *Adaptive) roll() {
+	now := a.now()
+	if a.windowStart.IsZero() {
+		a.windowStart = now
+		return
+	}
+	elapsed := int(now.Sub(a.windowStart) / a.window)
+	if elapsed <= 0 {
+		return
+	}
+	a.evaluate()
+	// remaining windows had no requests
+	for i := 1; i < elapsed && a.level > 0; i++ {
+		a.level--
+	}
+	a.windowStart = a.windowStart.Add(time.Duration(elapsed) * a.window)
+	a.requests = 0
+	a.failures = 0
+}
+
+// evaluate raises or lowers the level based on the ratio of failed
+// requests of the current window
+func (a *Adaptive) evaluate() {
+	var ratio float64
+	if a.requests > 0 {
+		ratio = float64(a.failures) / float64(a.requests)
+	}
+	if ratio >= AdaptiveRaiseErrorRatio && a.level < a.maxLevel {
+		a.level++
+		return
+	}
+	if ratio <= AdaptiveLowerErrorRatio && a.level > 0 {
+		a.level--
+	}
+}
+
+// SetMaxLevel sets the highest level this throttle can be raised
+// to. The current level is lowered to the given level if it is
+// higher. Zero disables this throttle.
+//
+// NOTE:
+//	Given level is capped at AdaptiveMaxLevel.
+func (a *Adaptive) SetMaxLevel(level int) {
+	if level < 0 {
+		level = 0
+	}
+	if level > AdaptiveMaxLevel {
+		level = AdaptiveMaxLevel
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.maxLevel = level
+	if a.level > level {
+		a.level = level
+	}
+}
+
+// Record adds an API request to the current window. A request that
+// was throttled or failed at the API server is counted as a failure.
+func (a *Adaptive) Record(isFailure bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.roll()
+	a.requests++
+	if isFailure {
+		a.failures++
+	}
+}
+
+// resyncAfterSeconds returns the minimum resync interval of the
+// current level
+func (a *Adaptive) resyncAfterSeconds() float64 {
+	if a.level == 0 {
+		return 0
+	}
+	return AdaptiveBaseResyncAfterSeconds * float64(int64(1)<<uint(a.level-1))
+}
+
+// ResyncAfterSeconds returns the given resync interval raised to the
+// minimum resync interval of the current level. Zero is returned as
+// is since the sync did not ask for a resync.
+func (a *Adaptive) ResyncAfterSeconds(desired float64) float64 {
+	if desired <= 0 {
+		return desired
+	}
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.roll()
+	if floor := a.resyncAfterSeconds(); desired < floor {
+		return floor
+	}
+	return desired
+}
+
+// Stats returns the current state of this throttle
+func (a *Adaptive) Stats() AdaptiveStats {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.roll()
+	return AdaptiveStats{
+		Level:              a.level,
+		ResyncAfterSeconds: a.resyncAfterSeconds(),
+		WindowRequests:     a.requests,
+		WindowFailures:     a.failures,
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLevel(t *testing.T) {
+	var start = time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+	// window is a window of requests followed by the expected level
+	type window struct {
+		requests    int
+		failures    int
+		after       time.Duration
+		expectLevel int
+	}
+	var tests = map[string]struct {
+		windows      []window
+		expectResync float64
+	}{
+		"no failures": {
+			windows: []window{
+				{requests: 10},
+				{requests: 10, after: 30 * time.Second},
+			},
+		},
+		"failures below raise ratio": {
+			windows: []window{
+				{requests: 10, failures: 1},
+				{requests: 10, after: 30 * time.Second},
+			},
+		},
+		"failures at raise ratio": {
+			windows: []window{
+				{requests: 10, failures: 2},
+				{requests: 1, after: 30 * time.Second, expectLevel: 1},
+			},
+			expectResync: 5,
+		},
+		"sustained failures": {
+			windows: []window{
+				{requests: 10, failures: 5},
+				{requests: 10, failures: 5, after: 30 * time.Second, expectLevel: 1},
+				{requests: 10, failures: 5, after: 60 * time.Second, expectLevel: 2},
+				{requests: 1, after: 90 * time.Second, expectLevel: 3},
+			},
+			expectResync: 20,
+		},
+		"level is retained between ratios": {
+			windows: []window{
+				{requests: 10, failures: 5},
+				{requests: 10, failures: 1, after: 30 * time.Second, expectLevel: 1},
+				{requests: 1, after: 60 * time.Second, expectLevel: 1},
+			},
+			expectResync: 5,
+		},
+		"level is lowered after recovery": {
+			windows: []window{
+				{requests: 10, failures: 5},
+				{requests: 10, failures: 5, after: 30 * time.Second, expectLevel: 1},
+				{requests: 10, after: 60 * time.Second, expectLevel: 2},
+				{requests: 1, after: 90 * time.Second, expectLevel: 1},
+			},
+			expectResync: 5,
+		},
+		"idle windows lower the level": {
+			windows: []window{
+				{requests: 10, failures: 5},
+				{requests: 10, failures: 5, after: 30 * time.Second, expectLevel: 1},
+				{requests: 1, after: 150 * time.Second},
+			},
+		},
+		"level does not exceed max": {
+			windows: func() []window {
+				var windows []window
+				for i := 0; i <= AdaptiveMaxLevel+2; i++ {
+					level := i
+					if level > AdaptiveMaxLevel {
+						level = AdaptiveMaxLevel
+					}
+					windows = append(windows, window{
+						requests:    1,
+						failures:    1,
+						after:       time.Duration(i) * 30 * time.Second,
+						expectLevel: level,
+					})
+				}
+				return windows
+			}(),
+			expectResync: 160,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			a := NewAdaptive(30 * time.Second)
+			var now time.Time
+			a.now = func() time.Time { return now }
+			for idx, w := range mock.windows {
+				now = start.Add(w.after)
+				for i := 0; i < w.requests; i++ {
+					a.Record(i < w.failures)
+				}
+				got := a.Stats()
+				if got.Level != w.expectLevel {
+					t.Fatalf(
+						"Window %d: Expected level %d got %d", idx, w.expectLevel, got.Level,
+					)
+				}
+			}
+			got := a.Stats().ResyncAfterSeconds
+			if got != mock.expectResync {
+				t.Fatalf("Expected resync %v got %v", mock.expectResync, got)
+			}
+		})
+	}
+}
+
+func TestAdaptiveResyncAfterSeconds(t *testing.T) {
+	var tests = map[string]struct {
+		level   int
+		desired float64
+		expect  float64
+	}{
+		"no resync at level 0": {},
+		"resync at level 0": {
+			desired: 1,
+			expect:  1,
+		},
+		"no resync at level 2": {
+			level: 2,
+		},
+		"resync below min at level 2": {
+			level:   2,
+			desired: 1,
+			expect:  10,
+		},
+		"resync above min at level 2": {
+			level:   2,
+			desired: 30,
+			expect:  30,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			a := NewAdaptive(30 * time.Second)
+			a.level = mock.level
+			got := a.ResyncAfterSeconds(mock.desired)
+			if got != mock.expect {
+				t.Fatalf("Expected %v got %v", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestAdaptiveSetMaxLevel(t *testing.T) {
+	var tests = map[string]struct {
+		level       int
+		maxLevel    int
+		expectLevel int
+		expectMax   int
+	}{
+		"level below max is retained": {
+			level:       1,
+			maxLevel:    2,
+			expectLevel: 1,
+			expectMax:   2,
+		},
+		"level above max is lowered": {
+			level:       4,
+			maxLevel:    2,
+			expectLevel: 2,
+			expectMax:   2,
+		},
+		"zero disables the throttle": {
+			level:     4,
+			expectMax: 0,
+		},
+		"max is capped": {
+			level:       4,
+			maxLevel:    AdaptiveMaxLevel + 1,
+			expectLevel: 4,
+			expectMax:   AdaptiveMaxLevel,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			a := NewAdaptive(30 * time.Second)
+			a.level = mock.level
+			a.SetMaxLevel(mock.maxLevel)
+			if a.level != mock.expectLevel {
+				t.Fatalf("Expected level %d got %d", mock.expectLevel, a.level)
+			}
+			if a.maxLevel != mock.expectMax {
+				t.Fatalf("Expected max level %d got %d", mock.expectMax, a.maxLevel)
+			}
+		})
+	}
+}
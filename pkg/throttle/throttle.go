@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package throttle limits how often a recurring event e.g. a
+// diagnostic log is emitted per key within this process. It also
+// slows down the resyncs of all the controllers while the API server
+// is under pressure.
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle allows an event of a key at most once per interval
+type Throttle struct {
+	interval time.Duration
+
+	// now is the current time & is replaceable in tests
+	now func() time.Time
+
+	mutex       sync.Mutex
+	lastAllowed map[string]time.Time
+}
+
+// New returns a new instance of Throttle that allows an event of
+// a key at most once per the given interval
+func New(interval time.Duration) *Throttle {
+	return &Throttle{
+		interval:    interval,
+		now:         time.Now,
+		lastAllowed: map[string]time.Time{},
+	}
+}
+
+// Allow returns true if the event of the given key was not allowed
+// within the interval
+//
+// NOTE:
+//	Keys whose interval has elapsed are forgotten. This keeps the
+// memory bounded by the keys that were allowed within the interval.
+func (t *Throttle) Allow(key string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	now := t.now()
+	for k, last := range t.lastAllowed {
+		if now.Sub(last) >= t.interval {
+			delete(t.lastAllowed, k)
+		}
+	}
+	if _, found := t.lastAllowed[key]; found {
+		return false
+	}
+	t.lastAllowed[key] = now
+	return true
+}
+
+// Len returns the number of keys that are throttled currently
+func (t *Throttle) Len() int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return len(t.lastAllowed)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrottleAllow(t *testing.T) {
+	var start = time.Date(2020, 4, 1, 10, 0, 0, 0, time.UTC)
+	type event struct {
+		key     string
+		after   time.Duration
+		isAllow bool
+	}
+	var tests = map[string]struct {
+		events      []event
+		expectCount int
+	}{
+		"first event": {
+			events: []event{
+				{key: "k1", isAllow: true},
+			},
+			expectCount: 1,
+		},
+		"repeated event within interval": {
+			events: []event{
+				{key: "k1", isAllow: true},
+				{key: "k1", after: 4 * time.Minute},
+			},
+			expectCount: 1,
+		},
+		"repeated event after interval": {
+			events: []event{
+				{key: "k1", isAllow: true},
+				{key: "k1", after: 5 * time.Minute, isAllow: true},
+			},
+			expectCount: 1,
+		},
+		"different keys within interval": {
+			events: []event{
+				{key: "k1", isAllow: true},
+				{key: "k2", after: time.Minute, isAllow: true},
+				{key: "k1", after: 2 * time.Minute},
+			},
+			expectCount: 2,
+		},
+		"elapsed keys are forgotten": {
+			events: []event{
+				{key: "k1", isAllow: true},
+				{key: "k2", after: 6 * time.Minute, isAllow: true},
+			},
+			expectCount: 1,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			throttle := New(5 * time.Minute)
+			for idx, e := range mock.events {
+				e := e
+				throttle.now = func() time.Time {
+					return start.Add(e.after)
+				}
+				got := throttle.Allow(e.key)
+				if got != e.isAllow {
+					t.Fatalf(
+						"Expected allow %t got %t for event %d", e.isAllow, got, idx,
+					)
+				}
+			}
+			if throttle.Len() != mock.expectCount {
+				t.Fatalf(
+					"Expected %d throttled keys got %d", mock.expectCount, throttle.Len(),
+				)
+			}
+		})
+	}
+}
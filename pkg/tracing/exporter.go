@@ -0,0 +1,330 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+)
+
+const (
+	// DefaultFlushInterval is how often the queued spans are sent
+	DefaultFlushInterval = 5 * time.Second
+
+	// DefaultMaxQueueSize is the number of spans that are queued
+	// before the oldest ones are dropped
+	DefaultMaxQueueSize = 2048
+
+	// DefaultBatchSize is the max number of spans per request
+	DefaultBatchSize = 512
+
+	// otlpTracesPath is the path of OTLP/HTTP traces endpoint
+	otlpTracesPath = "/v1/traces"
+
+	// otlp span kind & status codes
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+// Exporter sends the spans to an OTLP/HTTP endpoint e.g. of an
+// OpenTelemetry collector. It implements opencensus trace.Exporter.
+//
+// NOTE:
+//	Spans are queued & sent in batches as JSON. Oldest spans are
+// dropped if the endpoint can't keep up. This keeps the memory
+// bounded & the syncs unaffected by the endpoint.
+type Exporter struct {
+	// URL that receives the spans e.g. http://collector:4318/v1/traces
+	URL string
+
+	// ServiceName is set as the service.name of the resource
+	ServiceName string
+
+	Client        *http.Client
+	FlushInterval time.Duration
+	MaxQueueSize  int
+	BatchSize     int
+
+	mutex   sync.Mutex
+	queue   []*trace.SpanData
+	dropped int64
+}
+
+// NewExporter returns a new instance of Exporter that sends the
+// spans to the given endpoint. Traces path is appended to this
+// endpoint if it is not set.
+func NewExporter(endpoint, serviceName string) *Exporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, otlpTracesPath) {
+		url = url + otlpTracesPath
+	}
+	return &Exporter{
+		URL:           url,
+		ServiceName:   serviceName,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+		FlushInterval: DefaultFlushInterval,
+		MaxQueueSize:  DefaultMaxQueueSize,
+		BatchSize:     DefaultBatchSize,
+	}
+}
+
+// ExportSpan queues the given span to be sent later
+func (e *Exporter) ExportSpan(s *trace.SpanData) {
+	if s == nil {
+		return
+	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.queue = append(e.queue, s)
+	if e.MaxQueueSize > 0 && len(e.queue) > e.MaxQueueSize {
+		overflow := len(e.queue) - e.MaxQueueSize
+		e.queue = e.queue[overflow:]
+		e.dropped += int64(overflow)
+	}
+}
+
+// Dropped returns the number of spans that were dropped so far
+func (e *Exporter) Dropped() int64 {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.dropped
+}
+
+// Flush sends all the queued spans in batches
+func (e *Exporter) Flush() error {
+	e.mutex.Lock()
+	spans := e.queue
+	e.queue = nil
+	e.mutex.Unlock()
+
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = len(spans)
+	}
+	for len(spans) > 0 {
+		count := batchSize
+		if count > len(spans) {
+			count = len(spans)
+		}
+		err := e.send(spans[:count])
+		if err != nil {
+			// spans of a failed request are not retried
+			e.mutex.Lock()
+			e.dropped += int64(len(spans))
+			e.mutex.Unlock()
+			return err
+		}
+		spans = spans[count:]
+	}
+	return nil
+}
+
+// Start flushes the queued spans at the flush interval till the
+// given channel is closed. Queued spans are flushed once more before
+// returning.
+func (e *Exporter) Start(stop <-chan struct{}) {
+	interval := e.FlushInterval
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			if err := e.Flush(); err != nil {
+				glog.Errorf("Failed to export spans: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := e.Flush(); err != nil {
+				glog.Errorf("Failed to export spans: %v", err)
+			}
+		}
+	}
+}
+
+// send posts the given spans to the endpoint
+func (e *Exporter) send(spans []*trace.SpanData) error {
+	body, err := json.Marshal(e.toRequest(spans))
+	if err != nil {
+		return errors.Wrapf(err, "Can't encode %d span(s)", len(spans))
+	}
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(e.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "Can't send %d span(s) to %q", len(spans), e.URL)
+	}
+	defer resp.Body.Close()
+	// body is drained to reuse the connection
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf(
+			"Can't send %d span(s) to %q: Status %d",
+			len(spans), e.URL, resp.StatusCode,
+		)
+	}
+	return nil
+}
+
+// otlpRequest is the JSON encoding of OTLP ExportTraceServiceRequest
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue has one of its fields set. 64 bit integers are
+// encoded as strings in OTLP JSON.
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func toOTLPValue(value interface{}) (otlpAnyValue, bool) {
+	switch v := value.(type) {
+	case string:
+		return otlpAnyValue{StringValue: &v}, true
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpAnyValue{IntValue: &s}, true
+	case bool:
+		return otlpAnyValue{BoolValue: &v}, true
+	}
+	return otlpAnyValue{}, false
+}
+
+// toOTLPAttributes returns the given attributes in the order of
+// their keys
+func toOTLPAttributes(attrs map[string]interface{}) []otlpKeyValue {
+	var keys []string
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result []otlpKeyValue
+	for _, key := range keys {
+		value, ok := toOTLPValue(attrs[key])
+		if !ok {
+			continue
+		}
+		result = append(result, otlpKeyValue{Key: key, Value: value})
+	}
+	return result
+}
+
+func toOTLPSpan(s *trace.SpanData) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.TraceID[:]),
+		SpanID:            hex.EncodeToString(s.SpanID[:]),
+		Name:              s.Name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+		Attributes:        toOTLPAttributes(s.Attributes),
+	}
+	if s.ParentSpanID != (trace.SpanID{}) {
+		span.ParentSpanID = hex.EncodeToString(s.ParentSpanID[:])
+	}
+	if s.Code != trace.StatusCodeOK {
+		span.Status = otlpStatus{
+			Code:    otlpStatusCodeError,
+			Message: s.Message,
+		}
+	}
+	return span
+}
+
+// toRequest returns the OTLP request that has the given spans
+func (e *Exporter) toRequest(spans []*trace.SpanData) otlpRequest {
+	var otlpSpans []otlpSpan
+	for _, s := range spans {
+		otlpSpans = append(otlpSpans, toOTLPSpan(s))
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{
+					Attributes: toOTLPAttributes(map[string]interface{}{
+						"service.name": e.ServiceName,
+					}),
+				},
+				ScopeSpans: []otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: "mayadata.io/cstorpoolauto"},
+						Spans: otlpSpans,
+					},
+				},
+			},
+		},
+	}
+}
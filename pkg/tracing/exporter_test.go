@@ -0,0 +1,221 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/trace"
+)
+
+func newSpanData(name string, spanID byte) *trace.SpanData {
+	return &trace.SpanData{
+		SpanContext: trace.SpanContext{
+			TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+			SpanID:  trace.SpanID{0, 0, 0, 0, 0, 0, 0, spanID},
+		},
+		Name:      name,
+		StartTime: time.Unix(10, 0),
+		EndTime:   time.Unix(10, 500),
+		Attributes: map[string]interface{}{
+			"config.uid":        "uid-1",
+			"config.generation": int64(3),
+		},
+	}
+}
+
+func TestNewExporterURL(t *testing.T) {
+	var tests = map[string]struct {
+		endpoint string
+		expect   string
+	}{
+		"endpoint without path": {
+			endpoint: "http://collector:4318",
+			expect:   "http://collector:4318/v1/traces",
+		},
+		"endpoint with trailing slash": {
+			endpoint: "http://collector:4318/",
+			expect:   "http://collector:4318/v1/traces",
+		},
+		"endpoint with traces path": {
+			endpoint: "http://collector:4318/v1/traces",
+			expect:   "http://collector:4318/v1/traces",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := NewExporter(mock.endpoint, "test").URL
+			if got != mock.expect {
+				t.Fatalf("Expected URL %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestToOTLPSpan(t *testing.T) {
+	var tests = map[string]struct {
+		span   *trace.SpanData
+		expect string
+	}{
+		"root span": {
+			span: newSpanData("sync/localdevice", 1),
+			expect: `{"traceId":"0102030405060708090a0b0c0d0e0f10","spanId":"0000000000000001",` +
+				`"name":"sync/localdevice","kind":1,` +
+				`"startTimeUnixNano":"10000000000","endTimeUnixNano":"10000000500",` +
+				`"attributes":[{"key":"config.generation","value":{"intValue":"3"}},` +
+				`{"key":"config.uid","value":{"stringValue":"uid-1"}}],"status":{}}`,
+		},
+		"failed child span": {
+			span: func() *trace.SpanData {
+				s := newSpanData("localdevice.Reconciler.selectFromObservedBlockDevices", 2)
+				s.ParentSpanID = trace.SpanID{0, 0, 0, 0, 0, 0, 0, 1}
+				s.Attributes = nil
+				s.Status = trace.Status{Code: trace.StatusCodeUnknown, Message: "boom"}
+				return s
+			}(),
+			expect: `{"traceId":"0102030405060708090a0b0c0d0e0f10","spanId":"0000000000000002",` +
+				`"parentSpanId":"0000000000000001",` +
+				`"name":"localdevice.Reconciler.selectFromObservedBlockDevices","kind":1,` +
+				`"startTimeUnixNano":"10000000000","endTimeUnixNano":"10000000500",` +
+				`"status":{"code":2,"message":"boom"}}`,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := json.Marshal(toOTLPSpan(mock.span))
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, string(got)); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestExporterExportSpanDropsOldest(t *testing.T) {
+	e := NewExporter("http://collector:4318", "test")
+	e.MaxQueueSize = 2
+	e.ExportSpan(newSpanData("a", 1))
+	e.ExportSpan(newSpanData("b", 2))
+	e.ExportSpan(newSpanData("c", 3))
+	if e.Dropped() != 1 {
+		t.Fatalf("Expected dropped 1 got %d", e.Dropped())
+	}
+	var got []string
+	for _, s := range e.queue {
+		got = append(got, s.Name)
+	}
+	if diff := cmp.Diff([]string{"b", "c"}, got); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+}
+
+func TestExporterFlush(t *testing.T) {
+	var tests = map[string]struct {
+		status      int
+		spanCount   int
+		batchSize   int
+		expectPosts int
+		expectSpans int
+		expectDrops int64
+		isErr       bool
+	}{
+		"no spans": {
+			status:      http.StatusOK,
+			expectPosts: 0,
+		},
+		"spans in batches": {
+			status:      http.StatusOK,
+			spanCount:   5,
+			batchSize:   2,
+			expectPosts: 3,
+			expectSpans: 5,
+		},
+		"endpoint failure": {
+			status:      http.StatusServiceUnavailable,
+			spanCount:   5,
+			batchSize:   2,
+			expectPosts: 1,
+			expectSpans: 2,
+			expectDrops: 5,
+			isErr:       true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var posts, spans int
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/v1/traces" {
+						t.Errorf("Expected path /v1/traces got %q", r.URL.Path)
+					}
+					body, _ := ioutil.ReadAll(r.Body)
+					var req otlpRequest
+					if err := json.Unmarshal(body, &req); err != nil {
+						t.Errorf("Expected no error got [%+v]", err)
+					}
+					posts++
+					for _, rs := range req.ResourceSpans {
+						if len(rs.Resource.Attributes) != 1 ||
+							rs.Resource.Attributes[0].Key != "service.name" {
+							t.Errorf("Expected service.name got %+v", rs.Resource.Attributes)
+						}
+						for _, ss := range rs.ScopeSpans {
+							spans += len(ss.Spans)
+						}
+					}
+					w.WriteHeader(mock.status)
+				},
+			))
+			defer server.Close()
+			e := NewExporter(server.URL, "test")
+			e.BatchSize = mock.batchSize
+			for i := 0; i < mock.spanCount; i++ {
+				e.ExportSpan(newSpanData("span", byte(i)))
+			}
+			err := e.Flush()
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if posts != mock.expectPosts {
+				t.Fatalf("Expected posts %d got %d", mock.expectPosts, posts)
+			}
+			if spans != mock.expectSpans {
+				t.Fatalf("Expected spans %d got %d", mock.expectSpans, spans)
+			}
+			if e.Dropped() != mock.expectDrops {
+				t.Fatalf("Expected dropped %d got %d", mock.expectDrops, e.Dropped())
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracing records a span per sync of a controller & a
+// child span per phase of its reconciliation. Spans are exported
+// only if an exporter is registered & the sync is sampled.
+//
+// NOTE:
+//	The context of a sync carries its deadline if a sync timeout
+// is set. Reconcilers stop once this deadline is exceeded.
+package tracing
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// attribute keys that are set against the spans
+const (
+	AttrKeyController       string = "controller"
+	AttrKeyWatchKind        string = "watch.kind"
+	AttrKeyWatchNamespace   string = "watch.namespace"
+	AttrKeyWatchName        string = "watch.name"
+	AttrKeyConfigUID        string = "config.uid"
+	AttrKeyConfigGeneration string = "config.generation"
+	AttrKeySkipReconcile    string = "skipReconcile"
+)
+
+// attributesKey is the context key of the attributes that are set
+// against every span of a sync
+type attributesKey struct{}
+
+// registry holds the context of every sync in progress keyed by
+// its request
+//
+// NOTE:
+//	Inline hooks have no context argument. Hence, the context of a
+// sync is looked up via its request.
+type registry struct {
+	sync.Mutex
+	contexts map[*generic.SyncHookRequest]context.Context
+}
+
+var registryInstance = &registry{
+	contexts: map[*generic.SyncHookRequest]context.Context{},
+}
+
+func (r *registry) register(req *generic.SyncHookRequest, ctx context.Context) {
+	r.Lock()
+	defer r.Unlock()
+	r.contexts[req] = ctx
+}
+
+func (r *registry) unregister(req *generic.SyncHookRequest) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.contexts, req)
+}
+
+func (r *registry) get(req *generic.SyncHookRequest) context.Context {
+	r.Lock()
+	defer r.Unlock()
+	return r.contexts[req]
+}
+
+// syncTimeout is the deadline of every sync relative to its start.
+// Syncs have no deadline if this is zero.
+var syncTimeout time.Duration
+
+// SetSyncTimeout sets the deadline of every sync relative to its
+// start. Zero or a negative timeout removes the deadline.
+func SetSyncTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	syncTimeout = timeout
+}
+
+// ContextOf returns the context of the sync of the given request.
+// Nil is returned if this sync is not traced.
+func ContextOf(req *generic.SyncHookRequest) context.Context {
+	if req == nil {
+		return nil
+	}
+	return registryInstance.get(req)
+}
+
+// syncAttributes returns the attributes that identify the sync of
+// the given controller & watch
+//
+// NOTE:
+//	UID of CStorClusterConfig is set for watches other than
+// CStorClusterConfig if they are annotated with this UID. Generation
+// of CStorClusterConfig is known only if it is the watch.
+func syncAttributes(name string, req *generic.SyncHookRequest) map[string]interface{} {
+	attrs := map[string]interface{}{
+		AttrKeyController: name,
+	}
+	if req == nil || req.Watch == nil {
+		return attrs
+	}
+	watch := req.Watch
+	attrs[AttrKeyWatchKind] = watch.GetKind()
+	attrs[AttrKeyWatchNamespace] = watch.GetNamespace()
+	attrs[AttrKeyWatchName] = watch.GetName()
+	if watch.GetKind() == string(types.KindCStorClusterConfig) {
+		attrs[AttrKeyConfigUID] = string(watch.GetUID())
+		attrs[AttrKeyConfigGeneration] = watch.GetGeneration()
+		return attrs
+	}
+	if uid := watch.GetAnnotations()[types.AnnKeyCStorClusterConfigUID]; uid != "" {
+		attrs[AttrKeyConfigUID] = uid
+	}
+	return attrs
+}
+
+// toTraceAttributes returns the given attributes in the order of
+// their keys
+func toTraceAttributes(attrs map[string]interface{}) []trace.Attribute {
+	var keys []string
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var result []trace.Attribute
+	for _, key := range keys {
+		switch value := attrs[key].(type) {
+		case string:
+			result = append(result, trace.StringAttribute(key, value))
+		case int64:
+			result = append(result, trace.Int64Attribute(key, value))
+		case bool:
+			result = append(result, trace.BoolAttribute(key, value))
+		}
+	}
+	return result
+}
+
+// WithSync returns an inline hook whose invocations are traced as
+// spans named after the given controller. The context of this span
+// is available to the hook via ContextOf & is done once the sync
+// timeout if any elapses.
+func WithSync(name string, fn generic.InlineInvokeFn) generic.InlineInvokeFn {
+	return func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+		if req == nil {
+			return fn(req, resp)
+		}
+		parent := context.Background()
+		if syncTimeout > 0 {
+			var cancel context.CancelFunc
+			parent, cancel = context.WithTimeout(parent, syncTimeout)
+			defer cancel()
+		}
+		attrs := syncAttributes(name, req)
+		ctx, span := trace.StartSpan(parent, "sync/"+name)
+		span.AddAttributes(toTraceAttributes(attrs)...)
+		// phases of this sync carry the same attributes
+		delete(attrs, AttrKeyWatchKind)
+		delete(attrs, AttrKeyWatchNamespace)
+		delete(attrs, AttrKeyWatchName)
+		ctx = context.WithValue(ctx, attributesKey{}, attrs)
+
+		registryInstance.register(req, ctx)
+		defer registryInstance.unregister(req)
+
+		err := fn(req, resp)
+		if err != nil {
+			RecordError(ctx, err)
+		}
+		if resp != nil && resp.SkipReconcile {
+			span.AddAttributes(trace.BoolAttribute(AttrKeySkipReconcile, true))
+		}
+		span.End()
+		return err
+	}
+}
+
+// RecordError marks the span of the given context as failed with
+// the given error
+func RecordError(ctx context.Context, err error) {
+	if ctx == nil || err == nil {
+		return
+	}
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return
+	}
+	span.SetStatus(trace.Status{
+		Code:    trace.StatusCodeUnknown,
+		Message: err.Error(),
+	})
+}
+
+// StartSpan starts a child span of the given context with the given
+// name & attributes. It returns the context of this span & the
+// function that ends this span.
+//
+// NOTE:
+//	This is a no-op if the given context is nil i.e. the sync is not
+// traced
+func StartSpan(
+	ctx context.Context, name string, attrs map[string]string,
+) (context.Context, func()) {
+	if ctx == nil {
+		return nil, func() {}
+	}
+	ctx, span := trace.StartSpan(ctx, name)
+	inherited, _ := ctx.Value(attributesKey{}).(map[string]interface{})
+	span.AddAttributes(toTraceAttributes(inherited)...)
+	for _, key := range sortedKeys(attrs) {
+		span.AddAttributes(trace.StringAttribute(key, attrs[key]))
+	}
+	return ctx, span.End
+}
+
+// StartPhase starts a child span of the given context that is named
+// after the given function. It returns the function that ends this
+// span.
+//
+// NOTE:
+//	This is a no-op if the given context is nil i.e. the sync is not
+// traced
+func StartPhase(ctx context.Context, fn interface{}) func() {
+	if ctx == nil {
+		return func() {}
+	}
+	_, end := StartSpan(ctx, PhaseName(fn), nil)
+	return end
+}
+
+// PhaseName returns the name of the given function without its
+// import path e.g. localdevice.Reconciler.selectFromObservedBlockDevices
+func PhaseName(fn interface{}) string {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func {
+		return "unknown"
+	}
+	f := runtime.FuncForPC(value.Pointer())
+	if f == nil {
+		return "unknown"
+	}
+	name := f.Name()
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	// method values are suffixed with -fm
+	name = strings.TrimSuffix(name, "-fm")
+	name = strings.Replace(name, "(*", "", 1)
+	name = strings.Replace(name, ")", "", 1)
+	return name
+}
+
+func sortedKeys(attrs map[string]string) []string {
+	var keys []string
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
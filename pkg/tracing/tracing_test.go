@@ -0,0 +1,225 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// recorder is an exporter that holds the exported spans
+type recorder struct {
+	sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *recorder) ExportSpan(s *trace.SpanData) {
+	r.Lock()
+	defer r.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+type phaser struct{}
+
+func (p *phaser) selectDevices() {}
+
+func TestPhaseName(t *testing.T) {
+	p := &phaser{}
+	var tests = map[string]struct {
+		fn     interface{}
+		expect string
+	}{
+		"method value": {
+			fn:     p.selectDevices,
+			expect: "tracing.phaser.selectDevices",
+		},
+		"function": {
+			fn:     PhaseName,
+			expect: "tracing.PhaseName",
+		},
+		"not a function": {
+			fn:     "selectDevices",
+			expect: "unknown",
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := PhaseName(mock.fn)
+			if got != mock.expect {
+				t.Fatalf("Expected %q got %q", mock.expect, got)
+			}
+		})
+	}
+}
+
+func TestStartPhaseWithNilContext(t *testing.T) {
+	// must not panic
+	StartPhase(nil, TestStartPhaseWithNilContext)()
+	ctx, end := StartSpan(nil, "span", nil)
+	end()
+	if ctx != nil {
+		t.Fatalf("Expected nil context got %v", ctx)
+	}
+	RecordError(nil, errors.Errorf("boom"))
+}
+
+func TestWithSync(t *testing.T) {
+	rec := &recorder{}
+	trace.RegisterExporter(rec)
+	defer trace.UnregisterExporter(rec)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	defer trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+
+	watch := &unstructured.Unstructured{}
+	watch.SetKind(string(types.KindCStorClusterConfig))
+	watch.SetNamespace("openebs")
+	watch.SetName("my-config")
+	watch.SetUID("uid-1")
+	watch.SetGeneration(4)
+	req := &generic.SyncHookRequest{Watch: watch}
+	resp := &generic.SyncHookResponse{}
+
+	hook := WithSync("localdevice", func(
+		req *generic.SyncHookRequest, resp *generic.SyncHookResponse,
+	) error {
+		ctx := ContextOf(req)
+		if ctx == nil {
+			t.Fatalf("Expected context of request got none")
+		}
+		StartPhase(ctx, PhaseName)()
+		resp.SkipReconcile = true
+		return errors.Errorf("boom")
+	})
+	err := hook(req, resp)
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+	if ContextOf(req) != nil {
+		t.Fatalf("Expected context to be unregistered after sync")
+	}
+
+	rec.Lock()
+	defer rec.Unlock()
+	if len(rec.spans) != 2 {
+		t.Fatalf("Expected 2 spans got %d", len(rec.spans))
+	}
+	phase, root := rec.spans[0], rec.spans[1]
+	if root.Name != "sync/localdevice" {
+		t.Fatalf("Expected root span sync/localdevice got %q", root.Name)
+	}
+	if phase.Name != "tracing.PhaseName" {
+		t.Fatalf("Expected phase span tracing.PhaseName got %q", phase.Name)
+	}
+	if phase.ParentSpanID != root.SpanID || phase.TraceID != root.TraceID {
+		t.Fatalf("Expected phase span to be a child of root span")
+	}
+	if root.Code != trace.StatusCodeUnknown || root.Message != "boom" {
+		t.Fatalf("Expected error status got %+v", root.Status)
+	}
+	expectRoot := map[string]interface{}{
+		AttrKeyController:       "localdevice",
+		AttrKeyWatchKind:        string(types.KindCStorClusterConfig),
+		AttrKeyWatchNamespace:   "openebs",
+		AttrKeyWatchName:        "my-config",
+		AttrKeyConfigUID:        "uid-1",
+		AttrKeyConfigGeneration: int64(4),
+		AttrKeySkipReconcile:    true,
+	}
+	if diff := cmp.Diff(expectRoot, root.Attributes); diff != "" {
+		t.Fatalf("Expected no diff in root attributes got\n%s", diff)
+	}
+	expectPhase := map[string]interface{}{
+		AttrKeyController:       "localdevice",
+		AttrKeyConfigUID:        "uid-1",
+		AttrKeyConfigGeneration: int64(4),
+	}
+	if diff := cmp.Diff(expectPhase, phase.Attributes); diff != "" {
+		t.Fatalf("Expected no diff in phase attributes got\n%s", diff)
+	}
+}
+
+func TestWithSyncTimeout(t *testing.T) {
+	var tests = map[string]struct {
+		timeout        time.Duration
+		expectDeadline bool
+	}{
+		"no timeout": {},
+		"negative timeout": {
+			timeout: -time.Second,
+		},
+		"timeout": {
+			timeout:        time.Minute,
+			expectDeadline: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			SetSyncTimeout(mock.timeout)
+			defer SetSyncTimeout(0)
+			req := &generic.SyncHookRequest{Watch: &unstructured.Unstructured{}}
+			var gotDeadline bool
+			hook := WithSync("localdevice", func(
+				req *generic.SyncHookRequest, resp *generic.SyncHookResponse,
+			) error {
+				_, gotDeadline = ContextOf(req).Deadline()
+				return nil
+			})
+			err := hook(req, &generic.SyncHookResponse{})
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if gotDeadline != mock.expectDeadline {
+				t.Fatalf("Expected deadline %t got %t", mock.expectDeadline, gotDeadline)
+			}
+		})
+	}
+}
+
+func TestSyncAttributesOfNonConfigWatch(t *testing.T) {
+	watch := &unstructured.Unstructured{}
+	watch.SetKind(string(types.KindCStorClusterPlan))
+	watch.SetNamespace("openebs")
+	watch.SetName("my-plan")
+	watch.SetGeneration(2)
+	watch.SetAnnotations(map[string]string{
+		types.AnnKeyCStorClusterConfigUID: "uid-1",
+	})
+	got := syncAttributes("cstorclusterplan", &generic.SyncHookRequest{Watch: watch})
+	expect := map[string]interface{}{
+		AttrKeyController:     "cstorclusterplan",
+		AttrKeyWatchKind:      string(types.KindCStorClusterPlan),
+		AttrKeyWatchNamespace: "openebs",
+		AttrKeyWatchName:      "my-plan",
+		AttrKeyConfigUID:      "uid-1",
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+}
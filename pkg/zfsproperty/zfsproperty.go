@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package zfsproperty validates the ZFS properties that are set
+// against the pools of a CStorClusterConfig & maps these to the pool
+// config of CStorPoolCluster.
+//
+// NOTE:
+//	Only the whitelisted properties are accepted. A whitelisted
+// property is propagated to the pools of CStorPoolCluster only if the
+// schema of CStorPoolCluster supports it. Others are validated so
+// that they start getting propagated as is once CStorPoolCluster
+// supports them.
+package zfsproperty
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+// Property is a whitelisted ZFS property
+type Property struct {
+	// Values are the allowed values of this property. Pattern is
+	// used if no values are set.
+	Values []string
+
+	// Pattern matches the allowed values of this property
+	Pattern *regexp.Regexp
+
+	// PoolConfigKey is the key of this property in the pool config
+	// of CStorPoolCluster. This is empty if CStorPoolCluster does not
+	// support this property.
+	PoolConfigKey string
+}
+
+// isValid returns true if the given value is allowed
+func (p Property) isValid(value string) bool {
+	if p.Pattern != nil {
+		return p.Pattern.MatchString(value)
+	}
+	for _, allowed := range p.Values {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Whitelist maps the name of every supported ZFS property to its
+// allowed values
+var Whitelist = map[string]Property{
+	"compression": {
+		Values: []string{
+			"on", "off", "lz4", "lzjb", "zle", "gzip",
+			"gzip-1", "gzip-2", "gzip-3", "gzip-4", "gzip-5",
+			"gzip-6", "gzip-7", "gzip-8", "gzip-9",
+		},
+		PoolConfigKey: "compression",
+	},
+	"recordsize": {
+		// power of 2 from 512 bytes to 1M
+		Pattern: regexp.MustCompile(
+			`^(512|1K|2K|4K|8K|16K|32K|64K|128K|256K|512K|1M)$`,
+		),
+	},
+	"atime": {
+		Values: []string{"on", "off"},
+	},
+	"dedup": {
+		Values: []string{"on", "off"},
+	},
+	"sync": {
+		Values: []string{"standard", "always", "disabled"},
+	},
+	"logbias": {
+		Values: []string{"latency", "throughput"},
+	},
+	"primarycache": {
+		Values: []string{"all", "none", "metadata"},
+	},
+}
+
+// Validate returns validation error if any of the given properties
+// is not whitelisted or has a value that is not allowed
+func Validate(properties map[string]string) error {
+	var unsupported, invalid []string
+	for _, name := range sortedNames(properties) {
+		property, found := Whitelist[name]
+		if !found {
+			unsupported = append(unsupported, name)
+			continue
+		}
+		if !property.isValid(properties[name]) {
+			invalid = append(invalid, name+"="+properties[name])
+		}
+	}
+	if len(unsupported) != 0 {
+		return errs.ValidationErrorf(
+			"Unsupported zfs properties [%s]", strings.Join(unsupported, ", "),
+		)
+	}
+	if len(invalid) != 0 {
+		return errs.ValidationErrorf(
+			"Invalid values of zfs properties [%s]", strings.Join(invalid, ", "),
+		)
+	}
+	return nil
+}
+
+// ToPoolConfig returns the given properties keyed by their pool
+// config keys of CStorPoolCluster. Properties that are not supported
+// by CStorPoolCluster are left out.
+func ToPoolConfig(properties map[string]string) map[string]interface{} {
+	config := map[string]interface{}{}
+	for name, value := range properties {
+		key := Whitelist[name].PoolConfigKey
+		if key == "" {
+			continue
+		}
+		config[key] = value
+	}
+	return config
+}
+
+func sortedNames(properties map[string]string) []string {
+	var names []string
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package zfsproperty
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+)
+
+func TestValidate(t *testing.T) {
+	var tests = map[string]struct {
+		properties map[string]string
+		isErr      bool
+	}{
+		"nil properties": {},
+		"whitelisted properties": {
+			properties: map[string]string{
+				"compression":  "gzip-9",
+				"recordsize":   "1M",
+				"atime":        "off",
+				"dedup":        "off",
+				"sync":         "always",
+				"logbias":      "throughput",
+				"primarycache": "metadata",
+			},
+		},
+		"unsupported property": {
+			properties: map[string]string{
+				"atime":      "off",
+				"mountpoint": "/data",
+			},
+			isErr: true,
+		},
+		"invalid compression": {
+			properties: map[string]string{
+				"compression": "gzip-10",
+			},
+			isErr: true,
+		},
+		"recordsize not a power of 2": {
+			properties: map[string]string{
+				"recordsize": "96K",
+			},
+			isErr: true,
+		},
+		"recordsize above 1M": {
+			properties: map[string]string{
+				"recordsize": "2M",
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := Validate(mock.properties)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr && errs.TypeOf(err) != errs.TypeValidation {
+				t.Fatalf("Expected validation error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestToPoolConfig(t *testing.T) {
+	var tests = map[string]struct {
+		properties map[string]string
+		expect     map[string]interface{}
+	}{
+		"nil properties": {
+			expect: map[string]interface{}{},
+		},
+		"supported & not supported by cspc": {
+			properties: map[string]string{
+				"compression": "lz4",
+				"recordsize":  "64K",
+				"atime":       "off",
+			},
+			expect: map[string]interface{}{
+				"compression": "lz4",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := ToPoolConfig(mock.properties)
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"openebs.io/metac/apis/metacontroller/v1alpha1"
+)
+
+// Attachment refers to a resource that should be added to or
+// removed from the attachments of a GenericController
+type Attachment struct {
+	// name of the GenericController
+	Controller string
+
+	APIVersion string
+	Resource   string
+}
+
+// String implements Stringer interface
+func (a Attachment) String() string {
+	return a.Controller + ":" + a.APIVersion + "/" + a.Resource
+}
+
+// ParseAttachment parses the given value into an Attachment.
+// The value is expected to be of the form:
+//
+//	<controller-name>:<apiVersion>/<resource>
+//
+// e.g.
+//	sync-config:openebs.io/v1alpha1/cstorpoolinstances
+//	sync-config:v1/nodes
+func ParseAttachment(value string) (Attachment, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return Attachment{}, errors.Errorf(
+			"Invalid attachment %q: Want <controller-name>:<apiVersion>/<resource>",
+			value,
+		)
+	}
+	idx := strings.LastIndex(parts[1], "/")
+	if idx <= 0 || idx == len(parts[1])-1 {
+		return Attachment{}, errors.Errorf(
+			"Invalid attachment %q: Want <controller-name>:<apiVersion>/<resource>",
+			value,
+		)
+	}
+	return Attachment{
+		Controller: parts[0],
+		APIVersion: parts[1][:idx],
+		Resource:   parts[1][idx+1:],
+	}, nil
+}
+
+// AttachmentList is a list of Attachment(s) that can be set
+// via a repeatable command line flag
+type AttachmentList []Attachment
+
+// String implements flag.Value interface
+func (l *AttachmentList) String() string {
+	var values []string
+	for _, a := range *l {
+		values = append(values, a.String())
+	}
+	return strings.Join(values, ",")
+}
+
+// Set implements flag.Value interface. A comma separated
+// value results in multiple Attachment(s).
+func (l *AttachmentList) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		a, err := ParseAttachment(v)
+		if err != nil {
+			return err
+		}
+		*l = append(*l, a)
+	}
+	return nil
+}
+
+// AttachmentOverrides adds or removes attachments of the
+// GenericController(s) loaded from metac config
+//
+// NOTE:
+//	Metac triggers the sync of a watch whenever any of its
+// attachments change. Adding an attachment hence results in
+// immediate resync of the watch when the attached resource
+// is created, updated or deleted.
+type AttachmentOverrides struct {
+	Add    AttachmentList
+	Remove AttachmentList
+}
+
+// Apply adds & removes the attachments of the given controllers.
+// Controllers are updated in place.
+func (o AttachmentOverrides) Apply(gctls []*v1alpha1.GenericController) error {
+	for _, a := range o.Remove {
+		gctl := findGenericControllerByName(gctls, a.Controller)
+		if gctl == nil {
+			return errors.Errorf(
+				"Can't remove attachment %s: GenericController not found", a,
+			)
+		}
+		idx := findAttachmentIndex(gctl.Spec.Attachments, a)
+		if idx < 0 {
+			return errors.Errorf(
+				"Can't remove attachment %s: Attachment not found", a,
+			)
+		}
+		gctl.Spec.Attachments = append(
+			gctl.Spec.Attachments[:idx], gctl.Spec.Attachments[idx+1:]...,
+		)
+	}
+	for _, a := range o.Add {
+		gctl := findGenericControllerByName(gctls, a.Controller)
+		if gctl == nil {
+			return errors.Errorf(
+				"Can't add attachment %s: GenericController not found", a,
+			)
+		}
+		if findAttachmentIndex(gctl.Spec.Attachments, a) >= 0 {
+			// already attached
+			continue
+		}
+		gctl.Spec.Attachments = append(
+			gctl.Spec.Attachments,
+			v1alpha1.GenericControllerAttachment{
+				GenericControllerResource: v1alpha1.GenericControllerResource{
+					ResourceRule: v1alpha1.ResourceRule{
+						APIVersion: a.APIVersion,
+						Resource:   a.Resource,
+					},
+				},
+			},
+		)
+	}
+	return nil
+}
+
+// findGenericControllerByName returns the GenericController
+// with the given name
+func findGenericControllerByName(
+	gctls []*v1alpha1.GenericController, name string,
+) *v1alpha1.GenericController {
+	for _, gctl := range gctls {
+		if gctl != nil && gctl.GetName() == name {
+			return gctl
+		}
+	}
+	return nil
+}
+
+// findAttachmentIndex returns the index of the attachment that
+// matches the given apiVersion & resource. It returns -1 if no
+// such attachment is found.
+func findAttachmentIndex(
+	attachments []v1alpha1.GenericControllerAttachment, a Attachment,
+) int {
+	for idx, attachment := range attachments {
+		if attachment.APIVersion == a.APIVersion &&
+			attachment.Resource == a.Resource {
+			return idx
+		}
+	}
+	return -1
+}
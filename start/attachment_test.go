@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"openebs.io/metac/apis/metacontroller/v1alpha1"
+)
+
+func TestAttachmentListSet(t *testing.T) {
+	var tests = map[string]struct {
+		values  []string
+		expect  AttachmentList
+		isError bool
+	}{
+		"single value": {
+			values: []string{"sync-config:openebs.io/v1alpha1/cstorpoolinstances"},
+			expect: AttachmentList{
+				{
+					Controller: "sync-config",
+					APIVersion: "openebs.io/v1alpha1",
+					Resource:   "cstorpoolinstances",
+				},
+			},
+		},
+		"core resource": {
+			values: []string{"sync-config:v1/nodes"},
+			expect: AttachmentList{
+				{Controller: "sync-config", APIVersion: "v1", Resource: "nodes"},
+			},
+		},
+		"repeated & comma separated values": {
+			values: []string{
+				"sync-config:v1/nodes, sync-plan:v1/pods",
+				"sync-config:v1/pods",
+			},
+			expect: AttachmentList{
+				{Controller: "sync-config", APIVersion: "v1", Resource: "nodes"},
+				{Controller: "sync-plan", APIVersion: "v1", Resource: "pods"},
+				{Controller: "sync-config", APIVersion: "v1", Resource: "pods"},
+			},
+		},
+		"missing controller": {
+			values:  []string{"v1/nodes"},
+			isError: true,
+		},
+		"missing resource": {
+			values:  []string{"sync-config:v1/"},
+			isError: true,
+		},
+		"missing apiVersion": {
+			values:  []string{"sync-config:nodes"},
+			isError: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var got AttachmentList
+			var err error
+			for _, value := range mock.values {
+				err = got.Set(value)
+				if err != nil {
+					break
+				}
+			}
+			if mock.isError && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isError && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isError {
+				return
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func newTestGenericController(name string, resources ...string) *v1alpha1.GenericController {
+	gctl := &v1alpha1.GenericController{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	for _, resource := range resources {
+		gctl.Spec.Attachments = append(
+			gctl.Spec.Attachments,
+			v1alpha1.GenericControllerAttachment{
+				GenericControllerResource: v1alpha1.GenericControllerResource{
+					ResourceRule: v1alpha1.ResourceRule{
+						APIVersion: "openebs.io/v1alpha1",
+						Resource:   resource,
+					},
+				},
+			},
+		)
+	}
+	return gctl
+}
+
+func TestAttachmentOverridesApply(t *testing.T) {
+	var tests = map[string]struct {
+		overrides AttachmentOverrides
+		gctls     []*v1alpha1.GenericController
+		expect    map[string][]string
+		isError   bool
+	}{
+		"no overrides": {
+			gctls: []*v1alpha1.GenericController{
+				newTestGenericController("sync-config", "blockdevices"),
+			},
+			expect: map[string][]string{
+				"sync-config": {"blockdevices"},
+			},
+		},
+		"add attachment": {
+			overrides: AttachmentOverrides{
+				Add: AttachmentList{
+					{
+						Controller: "sync-config",
+						APIVersion: "openebs.io/v1alpha1",
+						Resource:   "cstorpoolinstances",
+					},
+				},
+			},
+			gctls: []*v1alpha1.GenericController{
+				newTestGenericController("sync-config", "blockdevices"),
+				newTestGenericController("sync-plan", "blockdevices"),
+			},
+			expect: map[string][]string{
+				"sync-config": {"blockdevices", "cstorpoolinstances"},
+				"sync-plan":   {"blockdevices"},
+			},
+		},
+		"add existing attachment": {
+			overrides: AttachmentOverrides{
+				Add: AttachmentList{
+					{
+						Controller: "sync-config",
+						APIVersion: "openebs.io/v1alpha1",
+						Resource:   "blockdevices",
+					},
+				},
+			},
+			gctls: []*v1alpha1.GenericController{
+				newTestGenericController("sync-config", "blockdevices"),
+			},
+			expect: map[string][]string{
+				"sync-config": {"blockdevices"},
+			},
+		},
+		"remove attachment": {
+			overrides: AttachmentOverrides{
+				Remove: AttachmentList{
+					{
+						Controller: "sync-config",
+						APIVersion: "openebs.io/v1alpha1",
+						Resource:   "blockdevices",
+					},
+				},
+			},
+			gctls: []*v1alpha1.GenericController{
+				newTestGenericController(
+					"sync-config", "blockdevices", "cstorpoolclusters",
+				),
+			},
+			expect: map[string][]string{
+				"sync-config": {"cstorpoolclusters"},
+			},
+		},
+		"remove missing attachment": {
+			overrides: AttachmentOverrides{
+				Remove: AttachmentList{
+					{
+						Controller: "sync-config",
+						APIVersion: "openebs.io/v1alpha1",
+						Resource:   "cstorpoolinstances",
+					},
+				},
+			},
+			gctls: []*v1alpha1.GenericController{
+				newTestGenericController("sync-config", "blockdevices"),
+			},
+			isError: true,
+		},
+		"add to missing controller": {
+			overrides: AttachmentOverrides{
+				Add: AttachmentList{
+					{
+						Controller: "sync-none",
+						APIVersion: "openebs.io/v1alpha1",
+						Resource:   "blockdevices",
+					},
+				},
+			},
+			gctls: []*v1alpha1.GenericController{
+				newTestGenericController("sync-config", "blockdevices"),
+			},
+			isError: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := mock.overrides.Apply(mock.gctls)
+			if mock.isError && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isError && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isError {
+				return
+			}
+			got := map[string][]string{}
+			for _, gctl := range mock.gctls {
+				for _, attachment := range gctl.Spec.Attachments {
+					got[gctl.GetName()] = append(
+						got[gctl.GetName()], attachment.Resource,
+					)
+				}
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
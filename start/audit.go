@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"mayadata.io/cstorpoolauto/pkg/audit"
+)
+
+var (
+	orphanAuditInterval = flag.Duration(
+		"orphan-audit-interval",
+		10*time.Minute,
+		`How often to audit the cluster for the children whose CStorClusterConfig
+		 or CStorClusterPlan no longer exists; 0 disables the audit`,
+	)
+	orphanAuditPolicy = flag.String(
+		"orphan-audit-policy",
+		string(audit.PolicyReport),
+		`What to do with the orphaned children found by the audit;
+		 Report or Delete`,
+	)
+)
+
+// newOrphanAuditor returns the auditor of orphaned children built
+// from the flags. It returns nil if the audit is disabled.
+func newOrphanAuditor(config *rest.Config) (*audit.Auditor, error) {
+	if *orphanAuditInterval <= 0 {
+		return nil, nil
+	}
+	policy, err := audit.ParsePolicy(*orphanAuditPolicy)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't build dynamic client for orphan audit")
+	}
+	return &audit.Auditor{
+		Client: client,
+		Policy: policy,
+	}, nil
+}
+
+// startOrphanAudit starts the audit of orphaned children in the
+// background till the given channel is closed
+func startOrphanAudit(config *rest.Config, stop <-chan struct{}) error {
+	auditor, err := newOrphanAuditor(config)
+	if err != nil {
+		return err
+	}
+	if auditor == nil {
+		glog.Info("Orphan audit is disabled")
+		return nil
+	}
+	glog.Infof(
+		"Orphan audit: Interval %v: Policy %s", *orphanAuditInterval, auditor.Policy,
+	)
+	go auditor.Run(*orphanAuditInterval, stop)
+	return nil
+}
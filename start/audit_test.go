@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"mayadata.io/cstorpoolauto/pkg/audit"
+)
+
+func TestNewOrphanAuditor(t *testing.T) {
+	var tests = map[string]struct {
+		interval     time.Duration
+		policy       string
+		expectNil    bool
+		expectPolicy audit.Policy
+		isErr        bool
+	}{
+		"disabled audit": {
+			interval:  0,
+			policy:    "Report",
+			expectNil: true,
+		},
+		"report policy": {
+			interval:     time.Minute,
+			policy:       "Report",
+			expectPolicy: audit.PolicyReport,
+		},
+		"delete policy": {
+			interval:     time.Minute,
+			policy:       "Delete",
+			expectPolicy: audit.PolicyDelete,
+		},
+		"invalid policy": {
+			interval: time.Minute,
+			policy:   "Retain",
+			isErr:    true,
+		},
+	}
+	oldInterval, oldPolicy := *orphanAuditInterval, *orphanAuditPolicy
+	defer func() {
+		*orphanAuditInterval, *orphanAuditPolicy = oldInterval, oldPolicy
+	}()
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			*orphanAuditInterval, *orphanAuditPolicy = mock.interval, mock.policy
+			got, err := newOrphanAuditor(&rest.Config{Host: "localhost"})
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if mock.expectNil != (got == nil) {
+				t.Fatalf("Expected nil auditor %t got %+v", mock.expectNil, got)
+			}
+			if got != nil && got.Policy != mock.expectPolicy {
+				t.Fatalf("Expected policy %q got %q", mock.expectPolicy, got.Policy)
+			}
+		})
+	}
+}
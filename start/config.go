@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+	"reflect"
+	"sort"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	"mayadata.io/cstorpoolauto/pkg/operatorconfig"
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+)
+
+var operatorConfigPath = flag.String(
+	"config",
+	"",
+	`Path to a YAML file e.g. a mounted ConfigMap key with the operator config;
+	 flags set explicitly take precedence over this file; log level, resync
+	 intervals & max API throttle level are reloaded when this file changes`,
+)
+
+// operatorConfig is the operator config that is in effect
+var operatorConfig = &operatorconfig.OperatorConfig{}
+
+// explicitFlags are the names of the flags that were set via the
+// command line
+var explicitFlags = map[string]bool{}
+
+// LoadOperatorConfig loads the operator config from the file set via
+// --config & applies its settings as the values of their flags. This
+// must be invoked after the flags are parsed & before their values
+// are used.
+//
+// NOTE:
+//	Flags that were set via the command line are not overridden by
+// this file.
+func LoadOperatorConfig() error {
+	flag.Visit(func(f *flag.Flag) {
+		explicitFlags[f.Name] = true
+	})
+	if *operatorConfigPath == "" {
+		return nil
+	}
+	config, err := operatorconfig.Load(*operatorConfigPath)
+	if err != nil {
+		return err
+	}
+	err = applyFlags(config.StartupFlags())
+	if err != nil {
+		return err
+	}
+	err = applyRuntimeFlags(config.RuntimeFlags())
+	if err != nil {
+		return err
+	}
+	throttle.API.SetMaxLevel(config.GetMaxAPIThrottleLevel())
+	operatorConfig = config
+	return nil
+}
+
+// applyFlags sets the given values against their flags unless
+// these flags were set via the command line
+func applyFlags(values map[string]string) error {
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	// sort to apply & log the flags in a stable order
+	sort.Strings(names)
+	for _, name := range names {
+		if explicitFlags[name] {
+			glog.V(2).Infof(
+				"Operator config of flag %q is ignored: Flag is set via command line", name,
+			)
+			continue
+		}
+		err := flag.Set(name, values[name])
+		if err != nil {
+			return errors.Wrapf(err, "Can't apply operator config of flag %q", name)
+		}
+	}
+	return nil
+}
+
+// applyRuntimeFlags sets the given values against the flags that
+// are reloaded at runtime. Flags without a value are reset to their
+// defaults unless these were set via the command line.
+func applyRuntimeFlags(values map[string]string) error {
+	all := map[string]string{}
+	for _, name := range operatorconfig.RuntimeFlagNames {
+		f := flag.Lookup(name)
+		if f == nil {
+			continue
+		}
+		all[name] = f.DefValue
+		if value, found := values[name]; found {
+			all[name] = value
+		}
+	}
+	return applyFlags(all)
+}
+
+// reloadOperatorConfig applies the runtime settings of the given
+// operator config
+//
+// NOTE:
+//	Startup settings that changed are logged since these need a
+// restart to take effect.
+func reloadOperatorConfig(config *operatorconfig.OperatorConfig) {
+	if !reflect.DeepEqual(operatorConfig.StartupFlags(), config.StartupFlags()) {
+		glog.Warningf(
+			"Operator config %q has changed startup settings: Restart to apply these",
+			*operatorConfigPath,
+		)
+	}
+	intervals, err := loadResyncIntervals(
+		registeredControllers,
+		*resyncAfterConfigPath,
+		config.GetResyncIntervals(),
+		resyncOverrides,
+	)
+	if err != nil {
+		glog.Errorf("Can't reload operator config %q: %v", *operatorConfigPath, err)
+		return
+	}
+	err = applyRuntimeFlags(config.RuntimeFlags())
+	if err != nil {
+		glog.Errorf("Can't reload operator config %q: %v", *operatorConfigPath, err)
+		return
+	}
+	setResyncIntervals(intervals)
+	throttle.API.SetMaxLevel(config.GetMaxAPIThrottleLevel())
+	operatorConfig = config
+	glog.Infof(
+		"Applied operator config %q: Resync intervals %s: Max API throttle level %d",
+		*operatorConfigPath,
+		intervals.String(),
+		config.GetMaxAPIThrottleLevel(),
+	)
+}
+
+// startConfigReload reloads the operator config whenever its file
+// changes till the given channel is closed
+func startConfigReload(stop <-chan struct{}) error {
+	if *operatorConfigPath == "" {
+		return nil
+	}
+	glog.Infof("Watching operator config %q", *operatorConfigPath)
+	return operatorconfig.Watch(*operatorConfigPath, reloadOperatorConfig, stop)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+	"testing"
+)
+
+var (
+	testFromConfig = flag.String("test-from-config", "default", "")
+	testFromFlag   = flag.String("test-from-flag", "default", "")
+	testCount      = flag.Int("test-count", 0, "")
+)
+
+func TestApplyFlags(t *testing.T) {
+	explicitFlags = map[string]bool{"test-from-flag": true}
+	defer func() {
+		explicitFlags = map[string]bool{}
+	}()
+	err := applyFlags(map[string]string{
+		"test-from-config": "config",
+		"test-from-flag":   "config",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	if *testFromConfig != "config" {
+		t.Fatalf("Expected flag to be set from config got %q", *testFromConfig)
+	}
+	if *testFromFlag != "default" {
+		t.Fatalf("Expected flag set via command line to be retained got %q", *testFromFlag)
+	}
+	err = applyFlags(map[string]string{"test-count": "many"})
+	if err == nil {
+		t.Fatalf("Expected error for invalid value got none")
+	}
+	if *testCount != 0 {
+		t.Fatalf("Expected invalid value to be ignored got %d", *testCount)
+	}
+}
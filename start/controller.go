@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"openebs.io/metac/apis/metacontroller/v1alpha1"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/pkg/tracing"
+)
+
+// Controller is a named set of inline hooks that get enabled
+// or disabled together
+type Controller struct {
+	Name string
+
+	// inline hook functions keyed by their function names
+	Hooks map[string]generic.InlineInvokeFn
+
+	// ResyncAfter is the default interval after which the watches
+	// of this controller are resynced. Zero implies no periodic
+	// resync other than the one set by the hooks themselves.
+	ResyncAfter time.Duration
+}
+
+// registeredControllers holds the controllers whose hooks were
+// registered
+var registeredControllers []Controller
+
+// disabledHooks holds the function names of inline hooks that
+// belong to disabled controllers
+var disabledHooks = map[string]bool{}
+
+// ParseControllerNames parses the given comma separated value
+// into a list of controller names
+func ParseControllerNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// RegisterControllers registers the inline hooks of enabled
+// controllers. Hooks of remaining controllers are not registered
+// & GenericController(s) that invoke only these hooks are not
+// started.
+//
+// NOTE:
+//	Resync intervals of controllers default to their ResyncAfter &
+// can be overridden via --resync-after-config-path & --resync-after
+// flags.
+func RegisterControllers(controllers []Controller, enabled []string) error {
+	isEnabled := map[string]bool{}
+	for _, name := range enabled {
+		isEnabled[name] = true
+	}
+	for _, name := range enabled {
+		if findControllerByName(controllers, name) == nil {
+			return errors.Errorf(
+				"Can't enable controller %q: Controller not found", name,
+			)
+		}
+	}
+	intervals, err := loadResyncIntervals(
+		controllers,
+		*resyncAfterConfigPath,
+		operatorConfig.GetResyncIntervals(),
+		resyncOverrides,
+	)
+	if err != nil {
+		return err
+	}
+	setResyncIntervals(intervals)
+	// resync intervals are loaded again when the operator config
+	// is reloaded
+	registeredControllers = controllers
+	for _, ctl := range controllers {
+		for funcName, fn := range ctl.Hooks {
+			if !isEnabled[ctl.Name] {
+				disabledHooks[funcName] = true
+				continue
+			}
+			// durations of hooks are recorded & traced per controller
+			// while hooks see CStorClusterConfig(s) in v1alpha1 shape
+			// only. Resyncs of all the hooks back off together when the
+			// API server is under pressure. Resync interval of the
+			// controller is set before the throttle raises it.
+			generic.AddToInlineRegistry(
+				funcName,
+				withSyncStats(
+					ctl.Name,
+					withAPIThrottle(
+						withResyncAfter(
+							resyncIntervalOf(ctl.Name),
+							tracing.WithSync(ctl.Name, withConfigVersions(fn)),
+						),
+					),
+				),
+			)
+		}
+	}
+	return nil
+}
+
+// findControllerByName returns the controller with the given name
+func findControllerByName(controllers []Controller, name string) *Controller {
+	for idx := range controllers {
+		if controllers[idx].Name == name {
+			return &controllers[idx]
+		}
+	}
+	return nil
+}
+
+// filterDisabledGenericControllers removes the GenericController(s)
+// whose inline hooks are all disabled
+func filterDisabledGenericControllers(
+	gctls []*v1alpha1.GenericController, disabled map[string]bool,
+) []*v1alpha1.GenericController {
+	var filtered []*v1alpha1.GenericController
+	for _, gctl := range gctls {
+		if gctl == nil || isGenericControllerDisabled(gctl, disabled) {
+			continue
+		}
+		filtered = append(filtered, gctl)
+	}
+	return filtered
+}
+
+// isGenericControllerDisabled returns true if all the inline hooks
+// of the given GenericController are disabled
+func isGenericControllerDisabled(
+	gctl *v1alpha1.GenericController, disabled map[string]bool,
+) bool {
+	if gctl.Spec.Hooks == nil {
+		return false
+	}
+	var funcNames []string
+	for _, hook := range []*v1alpha1.Hook{
+		gctl.Spec.Hooks.Sync,
+		gctl.Spec.Hooks.Finalize,
+	} {
+		if hook == nil {
+			continue
+		}
+		if hook.Inline == nil || hook.Inline.FuncName == nil {
+			// webhooks are never disabled
+			return false
+		}
+		funcNames = append(funcNames, *hook.Inline.FuncName)
+	}
+	if len(funcNames) == 0 {
+		return false
+	}
+	for _, funcName := range funcNames {
+		if !disabled[funcName] {
+			return false
+		}
+	}
+	return true
+}
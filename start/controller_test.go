@@ -0,0 +1,207 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"openebs.io/metac/apis/metacontroller/v1alpha1"
+	"openebs.io/metac/controller/generic"
+)
+
+func newTestInlineHook(funcName string) *v1alpha1.Hook {
+	return &v1alpha1.Hook{
+		Inline: &v1alpha1.Inline{
+			FuncName: &funcName,
+		},
+	}
+}
+
+func newTestHookedGenericController(
+	name string, sync, finalize *v1alpha1.Hook,
+) *v1alpha1.GenericController {
+	return &v1alpha1.GenericController{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1alpha1.GenericControllerSpec{
+			Hooks: &v1alpha1.GenericControllerHooks{
+				Sync:     sync,
+				Finalize: finalize,
+			},
+		},
+	}
+}
+
+func TestParseControllerNames(t *testing.T) {
+	var tests = map[string]struct {
+		value  string
+		expect []string
+	}{
+		"empty value": {
+			value: "",
+		},
+		"single name": {
+			value:  "localdevice",
+			expect: []string{"localdevice"},
+		},
+		"comma separated names with spaces": {
+			value:  "localdevice, blockdeviceclaim,,",
+			expect: []string{"localdevice", "blockdeviceclaim"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := ParseControllerNames(mock.value)
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegisterControllers(t *testing.T) {
+	noop := func(*generic.SyncHookRequest, *generic.SyncHookResponse) error {
+		return nil
+	}
+	controllers := []Controller{
+		{
+			Name: "test-enabled",
+			Hooks: map[string]generic.InlineInvokeFn{
+				"sync/test-enabled": noop,
+			},
+		},
+		{
+			Name: "test-disabled",
+			Hooks: map[string]generic.InlineInvokeFn{
+				"sync/test-disabled":     noop,
+				"finalize/test-disabled": noop,
+			},
+		},
+	}
+	var tests = map[string]struct {
+		enabled       []string
+		expectDisable []string
+		isErr         bool
+	}{
+		"unknown controller": {
+			enabled: []string{"junk"},
+			isErr:   true,
+		},
+		"one of two controllers is enabled": {
+			enabled:       []string{"test-enabled"},
+			expectDisable: []string{"finalize/test-disabled", "sync/test-disabled"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			disabledHooks = map[string]bool{}
+			defer func() { disabledHooks = map[string]bool{} }()
+
+			err := RegisterControllers(controllers, mock.enabled)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				if len(disabledHooks) != 0 {
+					t.Fatalf("Expected no disabled hooks got %v", disabledHooks)
+				}
+				return
+			}
+			for _, funcName := range mock.expectDisable {
+				if !disabledHooks[funcName] {
+					t.Fatalf("Expected hook %q to be disabled", funcName)
+				}
+			}
+			if len(mock.expectDisable) != len(disabledHooks) {
+				t.Fatalf(
+					"Expected disabled hooks %d got %d",
+					len(mock.expectDisable), len(disabledHooks),
+				)
+			}
+		})
+	}
+}
+
+func TestFilterDisabledGenericControllers(t *testing.T) {
+	disabled := map[string]bool{
+		"sync/localdevice":     true,
+		"finalize/localdevice": true,
+		"sync/blockdevice":     true,
+	}
+	var tests = map[string]struct {
+		gctls  []*v1alpha1.GenericController
+		expect []string
+	}{
+		"no controllers": {},
+		"all hooks are disabled": {
+			gctls: []*v1alpha1.GenericController{
+				newTestHookedGenericController(
+					"sync-localdevice", newTestInlineHook("sync/localdevice"), nil,
+				),
+				newTestHookedGenericController(
+					"finalize-localdevice", nil, newTestInlineHook("finalize/localdevice"),
+				),
+			},
+		},
+		"some hooks are disabled": {
+			gctls: []*v1alpha1.GenericController{
+				newTestHookedGenericController(
+					"sync-blockdevice", newTestInlineHook("sync/blockdevice"), nil,
+				),
+				newTestHookedGenericController(
+					"sync-blockdeviceclaim",
+					newTestInlineHook("sync/blockdeviceclaim"),
+					newTestInlineHook("finalize/localdevice"),
+				),
+			},
+			expect: []string{"sync-blockdeviceclaim"},
+		},
+		"webhooks & missing hooks are retained": {
+			gctls: []*v1alpha1.GenericController{
+				newTestHookedGenericController(
+					"webhook", &v1alpha1.Hook{Webhook: &v1alpha1.Webhook{}}, nil,
+				),
+				newTestHookedGenericController("no-hooks", nil, nil),
+				{ObjectMeta: metav1.ObjectMeta{Name: "nil-hooks"}},
+			},
+			expect: []string{"webhook", "no-hooks", "nil-hooks"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, gctl := range filterDisabledGenericControllers(mock.gctls, disabled) {
+				got = append(got, gctl.GetName())
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/types/v1beta1"
+)
+
+// isCStorClusterConfig returns true if the given object is a
+// CStorClusterConfig of any version
+func isCStorClusterConfig(obj *unstructured.Unstructured) bool {
+	if obj == nil || obj.Object == nil {
+		return false
+	}
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == types.GroupDAOMayaDataIO &&
+		gvk.Kind == string(types.KindCStorClusterConfig)
+}
+
+// configKey returns the key to identify the given CStorClusterConfig
+// across the request & response of a hook
+func configKey(obj *unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// withConfigVersions returns an inline hook that lets the given
+// hook work with the v1alpha1 shape of CStorClusterConfig only
+//
+// NOTE:
+//	CStorClusterConfig(s) observed in v1beta1 shape are converted
+// to v1alpha1 shape before invoking the hook. Desired state of these
+// configs is converted back to v1beta1 shape since the user prefers
+// this version. Other configs are written in v1alpha1 shape which
+// is the storage version.
+func withConfigVersions(fn generic.InlineInvokeFn) generic.InlineInvokeFn {
+	return func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+		if req == nil {
+			return fn(req, resp)
+		}
+		// keys of configs that were observed in v1beta1 shape
+		v1beta1Configs := map[string]bool{}
+		convert := func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+			if !isCStorClusterConfig(obj) || !v1beta1.IsV1Beta1Shaped(obj) {
+				return obj, nil
+			}
+			v1beta1Configs[configKey(obj)] = true
+			return v1beta1.ToV1Alpha1Unstructured(obj)
+		}
+		var err error
+		req.Watch, err = convert(req.Watch)
+		if err != nil {
+			return err
+		}
+		for _, group := range req.Attachments {
+			for name, obj := range group {
+				group[name], err = convert(obj)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		err = fn(req, resp)
+		if err != nil || resp == nil || len(v1beta1Configs) == 0 {
+			return err
+		}
+		for idx, obj := range resp.Attachments {
+			if !isCStorClusterConfig(obj) || !v1beta1Configs[configKey(obj)] {
+				continue
+			}
+			resp.Attachments[idx], err = v1beta1.FromV1Alpha1Unstructured(obj)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
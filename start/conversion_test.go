@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/controller/common"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+func newConfig(name, apiVersion string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": "openebs",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+func TestWithConfigVersions(t *testing.T) {
+	watch := newConfig("beta", types.APIVersionDAOMayaDataV1Beta1, map[string]interface{}{
+		"pools": map[string]interface{}{
+			"minCount": int64(3),
+		},
+	})
+	attachment := newConfig("alpha", types.APIVersionDAOMayaDataV1Alpha1, map[string]interface{}{
+		"minPoolCount": int64(2),
+	})
+	attachments := common.AnyUnstructRegistry{}
+	attachments.Insert(watch)
+	attachments.Insert(attachment)
+	req := &generic.SyncHookRequest{
+		Watch:       watch,
+		Attachments: attachments,
+	}
+	resp := &generic.SyncHookResponse{}
+	fn := withConfigVersions(
+		func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+			// hook sees only the v1alpha1 shape
+			for _, group := range req.Attachments {
+				for _, obj := range group {
+					count, _, _ := unstructured.NestedInt64(obj.Object, "spec", "minPoolCount")
+					if count == 0 {
+						t.Fatalf("Expected min pool count for %s got none", obj.GetName())
+					}
+				}
+			}
+			count, _, _ := unstructured.NestedInt64(req.Watch.Object, "spec", "minPoolCount")
+			if count != 3 {
+				t.Fatalf("Expected watch min pool count 3 got %d", count)
+			}
+			resp.Attachments = []*unstructured.Unstructured{
+				newConfig("beta", types.APIVersionDAOMayaDataV1Beta1, map[string]interface{}{
+					"driftPolicy": "Warn",
+				}),
+				newConfig("alpha", types.APIVersionDAOMayaDataV1Alpha1, map[string]interface{}{
+					"driftPolicy": "Warn",
+				}),
+			}
+			return nil
+		},
+	)
+	err := fn(req, resp)
+	if err != nil {
+		t.Fatalf("Expected no error got %+v", err)
+	}
+	// desired configs are written in the shape they were observed
+	policy, _, _ := unstructured.NestedString(
+		resp.Attachments[0].Object, "spec", "pools", "driftPolicy",
+	)
+	if policy != "Warn" {
+		t.Fatalf("Expected v1beta1 drift policy Warn got %q", policy)
+	}
+	policy, _, _ = unstructured.NestedString(
+		resp.Attachments[1].Object, "spec", "driftPolicy",
+	)
+	if policy != "Warn" {
+		t.Fatalf("Expected v1alpha1 drift policy Warn got %q", policy)
+	}
+	// observed watch is never mutated
+	_, found, _ := unstructured.NestedFieldNoCopy(watch.Object, "spec", "pools")
+	if !found {
+		t.Fatalf("Expected observed watch to be left as is")
+	}
+}
+
+func TestWithConfigVersionsNilRequest(t *testing.T) {
+	var isInvoked bool
+	fn := withConfigVersions(
+		func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+			isInvoked = true
+			return nil
+		},
+	)
+	err := fn(nil, nil)
+	if err != nil {
+		t.Fatalf("Expected no error got %+v", err)
+	}
+	if !isInvoked {
+		t.Fatalf("Expected hook to be invoked")
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"expvar"
+	"flag"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/pkg/audit"
+	"mayadata.io/cstorpoolauto/pkg/metrics"
+	"mayadata.io/cstorpoolauto/pkg/supportbundle"
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+)
+
+var debugLogInterval = flag.Duration(
+	"debug-log-interval",
+	5*time.Minute,
+	`How often to log the goroutine count & sync durations per controller;
+	 Needs debug-addr to be set`,
+)
+
+// SyncStats is the summary of the hook invocations of a controller
+type SyncStats struct {
+	Count  int64 `json:"count"`
+	Errors int64 `json:"errors"`
+
+	// durations are reported in milliseconds to keep expvar output
+	// readable
+	TotalMillis int64 `json:"totalMillis"`
+	MaxMillis   int64 `json:"maxMillis"`
+}
+
+// syncStatsRegistry holds the sync stats of every controller keyed
+// by controller name
+type syncStatsRegistry struct {
+	sync.Mutex
+	stats map[string]*SyncStats
+}
+
+var syncStatsRegistryInstance = &syncStatsRegistry{
+	stats: map[string]*SyncStats{},
+}
+
+// record adds the given hook invocation to the sync stats of the
+// given controller
+func (r *syncStatsRegistry) record(name string, duration time.Duration, err error) {
+	r.Lock()
+	defer r.Unlock()
+	stats := r.stats[name]
+	if stats == nil {
+		stats = &SyncStats{}
+		r.stats[name] = stats
+	}
+	millis := int64(duration / time.Millisecond)
+	stats.Count++
+	stats.TotalMillis += millis
+	if millis > stats.MaxMillis {
+		stats.MaxMillis = millis
+	}
+	if err != nil {
+		stats.Errors++
+	}
+}
+
+// snapshot returns a copy of the sync stats of all the controllers
+func (r *syncStatsRegistry) snapshot() map[string]SyncStats {
+	r.Lock()
+	defer r.Unlock()
+	snapshot := map[string]SyncStats{}
+	for name, stats := range r.stats {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
+
+// withSyncStats returns an inline hook that records its duration
+// against the sync stats of the given controller. Its decision is
+// recorded as well to be collected into support bundles.
+func withSyncStats(name string, fn generic.InlineInvokeFn) generic.InlineInvokeFn {
+	return func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+		start := time.Now()
+		err := fn(req, resp)
+		syncStatsRegistryInstance.record(name, time.Since(start), err)
+		supportbundle.RecordSync(name, req, resp, err)
+		return err
+	}
+}
+
+// publishExpvarsOnce ensures the expvars are published only once
+// since expvar panics on duplicate names
+var publishExpvarsOnce sync.Once
+
+// registerDebugHandlers registers pprof & expvar endpoints against
+// the given mux
+//
+// NOTE:
+//	expvar exposes the goroutine count, sync stats per controller,
+// block device utilization per node, the level of API throttle & the
+// orphaned children found by the latest audit at /debug/vars in
+// addition to the memstats of go runtime
+func registerDebugHandlers(mux *http.ServeMux) {
+	publishExpvarsOnce.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() interface{} {
+			return runtime.NumGoroutine()
+		}))
+		expvar.Publish("controllerSyncs", expvar.Func(func() interface{} {
+			return syncStatsRegistryInstance.snapshot()
+		}))
+		expvar.Publish("nodeBlockDeviceUtilization", expvar.Func(func() interface{} {
+			return metrics.GetNodeBlockDeviceUtilization()
+		}))
+		expvar.Publish("apiThrottle", expvar.Func(func() interface{} {
+			return throttle.API.Stats()
+		}))
+		expvar.Publish("orphanedChildren", expvar.Func(func() interface{} {
+			return audit.GetOrphans()
+		}))
+	})
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+}
+
+// logRuntimeStats logs the goroutine count & sync stats per
+// controller at the given interval till the given channel is
+// closed
+func logRuntimeStats(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			logRuntimeStatsOnce()
+		}
+	}
+}
+
+// logRuntimeStatsOnce logs the goroutine count, API throttle & sync
+// stats per controller
+func logRuntimeStatsOnce() {
+	glog.Infof("Goroutines %d", runtime.NumGoroutine())
+	apiThrottle := throttle.API.Stats()
+	glog.Infof(
+		"API throttle: Level %d: Min resync %vs",
+		apiThrottle.Level, apiThrottle.ResyncAfterSeconds,
+	)
+	snapshot := syncStatsRegistryInstance.snapshot()
+	// names are sorted to log the controllers in same order
+	var names []string
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		stats := snapshot[name]
+		glog.Infof(
+			"Controller %q: Syncs %d: Errors %d: Avg %dms: Max %dms",
+			name, stats.Count, stats.Errors,
+			stats.TotalMillis/stats.Count, stats.MaxMillis,
+		)
+	}
+}
+
+// isFlagSet returns true if the flag with the given name was set
+// via command line
+func isFlagSet(name string) bool {
+	var isSet bool
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			isSet = true
+		}
+	})
+	return isSet
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pkg/errors"
+	"openebs.io/metac/controller/generic"
+)
+
+func TestSyncStatsRegistryRecord(t *testing.T) {
+	r := &syncStatsRegistry{stats: map[string]*SyncStats{}}
+	r.record("localdevice", 20*time.Millisecond, nil)
+	r.record("localdevice", 40*time.Millisecond, errors.New("failed"))
+	r.record("blockdevice", 5*time.Millisecond, nil)
+	expect := map[string]SyncStats{
+		"localdevice": {Count: 2, Errors: 1, TotalMillis: 60, MaxMillis: 40},
+		"blockdevice": {Count: 1, TotalMillis: 5, MaxMillis: 5},
+	}
+	if diff := cmp.Diff(expect, r.snapshot()); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+}
+
+func TestWithSyncStats(t *testing.T) {
+	var isInvoked bool
+	fn := withSyncStats(
+		"test-with-sync-stats",
+		func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+			isInvoked = true
+			return errors.New("failed")
+		},
+	)
+	err := fn(nil, nil)
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+	if !isInvoked {
+		t.Fatalf("Expected hook to be invoked")
+	}
+	stats := syncStatsRegistryInstance.snapshot()["test-with-sync-stats"]
+	if stats.Count != 1 || stats.Errors != 1 {
+		t.Fatalf("Expected 1 sync & 1 error got %+v", stats)
+	}
+}
+
+func TestRegisterDebugHandlers(t *testing.T) {
+	mux := http.NewServeMux()
+	registerDebugHandlers(mux)
+	for _, path := range []string{"/debug/pprof/", "/debug/vars"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %q got %d", path, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	vars := map[string]interface{}{}
+	err := json.Unmarshal(rec.Body.Bytes(), &vars)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	for _, name := range []string{
+		"goroutines", "controllerSyncs", "nodeBlockDeviceUtilization", "apiThrottle",
+		"orphanedChildren",
+	} {
+		if _, found := vars[name]; !found {
+			t.Fatalf("Expected expvar %q got none", name)
+		}
+	}
+}
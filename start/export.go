@@ -0,0 +1,133 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/common/cspcexport"
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// Exit codes of export command
+const (
+	// ExportExitRendered implies all the CStorPoolCluster(s) were
+	// rendered
+	ExportExitRendered int = 0
+
+	// ExportExitFailed implies one or more CStorPoolCluster(s) could
+	// not be rendered
+	ExportExitFailed int = 1
+
+	// ExportExitUsage implies the command was not used properly
+	// e.g. invalid flags or manifests that can not be read
+	ExportExitUsage int = 2
+)
+
+// RunExport renders the CStorPoolCluster(s) of the manifest set via
+// -f flag in the format set via --format flag. Rendered documents
+// are written to out & the failures are written to errOut. It
+// returns the exit code of export command.
+//
+// NOTE:
+//	Manifest may have CStorPoolCluster(s) e.g. the output of
+// 'kubectl get cspc -o yaml' or the ConfigMap(s) that export the
+// CStorPoolCluster(s) of a CStorClusterConfig with Export output
+// mode. Documents are written in the order of the manifest.
+func RunExport(args []string, out, errOut io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	file := flags.String(
+		"f",
+		"",
+		"Path to the manifest with one or more CStorPoolCluster(s) or their export ConfigMap(s)",
+	)
+	format := flags.String(
+		"format",
+		string(cspcexport.FormatHelm),
+		"Format to render the CStorPoolCluster(s) in; helm or kustomize",
+	)
+	err := flags.Parse(args)
+	if err != nil {
+		return ExportExitUsage
+	}
+	if *file == "" {
+		fmt.Fprintln(errOut, "Missing manifest: -f is required")
+		flags.Usage()
+		return ExportExitUsage
+	}
+	fileName, found := cspcexport.FileNames[cspcexport.Format(*format)]
+	if !found {
+		fmt.Fprintf(
+			errOut, "Unsupported format %q: Supports %q or %q\n",
+			*format, cspcexport.FormatHelm, cspcexport.FormatKustomize,
+		)
+		return ExportExitUsage
+	}
+
+	objs, err := readManifest(*file)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return ExportExitUsage
+	}
+	var docs []string
+	var cspcCount, failedCount int
+	for _, obj := range objs {
+		var doc string
+		switch {
+		case obj.GetKind() == string(types.KindCStorPoolCluster):
+			raw, err := cspcexport.Render(obj, cspcexport.Format(*format))
+			if err != nil {
+				fmt.Fprintln(errOut, err)
+				failedCount++
+				continue
+			}
+			doc = string(raw)
+		case cspcexport.IsExport(obj):
+			doc, _, _ = unstructured.NestedString(obj.Object, "data", fileName)
+			if doc == "" {
+				fmt.Fprintf(
+					errOut, "ConfigMap %s: Missing data %q\n", configKey(obj), fileName,
+				)
+				failedCount++
+				continue
+			}
+		default:
+			continue
+		}
+		cspcCount++
+		docs = append(docs, doc)
+	}
+	if cspcCount == 0 && failedCount == 0 {
+		fmt.Fprintf(errOut, "No CStorPoolCluster found in %s\n", *file)
+		return ExportExitUsage
+	}
+	for idx, doc := range docs {
+		if idx != 0 {
+			fmt.Fprintln(out, "---")
+		}
+		fmt.Fprint(out, doc)
+	}
+	if failedCount != 0 {
+		return ExportExitFailed
+	}
+	return ExportExitRendered
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cspcYAML = `
+apiVersion: openebs.io/v1alpha1
+kind: CStorPoolCluster
+metadata:
+  name: my-cspc
+  namespace: openebs
+  resourceVersion: "101"
+spec:
+  pools:
+  - nodeSelector:
+      kubernetes.io/hostname: node-1
+status:
+  phase: Online
+`
+
+const cspcExportYAML = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-cspc-cspc-export
+  namespace: openebs
+  annotations:
+    dao.mayadata.io/cspc-export: my-cspc
+data:
+  values.yaml: |
+    cstorPoolCluster:
+      kind: CStorPoolCluster
+`
+
+func TestRunExport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		return path
+	}
+	cspc := write("cspc.yaml", cspcYAML)
+	exported := write("exported.yaml", cspcExportYAML)
+	mixed := write("mixed.yaml", cspcYAML+"---"+cspcExportYAML)
+	noCSPC := write("nocspc.yaml", nodesYAML)
+
+	var tests = map[string]struct {
+		args            []string
+		expectCode      int
+		expectOutput    []string
+		expectErrOutput []string
+		rejectOutput    []string
+	}{
+		"missing manifest flag": {
+			expectCode:      ExportExitUsage,
+			expectErrOutput: []string{"-f is required"},
+		},
+		"unsupported format": {
+			args:            []string{"-f", cspc, "--format", "jsonnet"},
+			expectCode:      ExportExitUsage,
+			expectErrOutput: []string{`Unsupported format "jsonnet"`},
+		},
+		"manifest without cspcs": {
+			args:            []string{"-f", noCSPC},
+			expectCode:      ExportExitUsage,
+			expectErrOutput: []string{"No CStorPoolCluster found"},
+		},
+		"cspc as helm values": {
+			args:       []string{"-f", cspc},
+			expectCode: ExportExitRendered,
+			expectOutput: []string{
+				"cstorPoolCluster:\n",
+				"    name: my-cspc\n",
+				"      kubernetes.io/hostname: node-1\n",
+			},
+			rejectOutput: []string{"resourceVersion", "status"},
+		},
+		"cspc as kustomize patch": {
+			args:       []string{"-f", cspc, "--format", "kustomize"},
+			expectCode: ExportExitRendered,
+			expectOutput: []string{
+				"kind: CStorPoolCluster\n",
+				"  name: my-cspc\n",
+			},
+			rejectOutput: []string{"cstorPoolCluster:"},
+		},
+		"exported cspc as helm values": {
+			args:         []string{"-f", exported},
+			expectCode:   ExportExitRendered,
+			expectOutput: []string{"cstorPoolCluster:\n  kind: CStorPoolCluster\n"},
+		},
+		"exported cspc without kustomize patch": {
+			args:            []string{"-f", exported, "--format", "kustomize"},
+			expectCode:      ExportExitFailed,
+			expectErrOutput: []string{`Missing data "cspc-patch.yaml"`},
+		},
+		"cspc & exported cspc": {
+			args:         []string{"-f", mixed},
+			expectCode:   ExportExitRendered,
+			expectOutput: []string{"node-1\n---\ncstorPoolCluster:"},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var out, errOut bytes.Buffer
+			code := RunExport(mock.args, &out, &errOut)
+			if code != mock.expectCode {
+				t.Fatalf("Expected exit code %d got %d: %s", mock.expectCode, code, errOut.String())
+			}
+			for _, expect := range mock.expectOutput {
+				if !strings.Contains(out.String(), expect) {
+					t.Fatalf("Expected output to contain %q got\n%s", expect, out.String())
+				}
+			}
+			for _, reject := range mock.rejectOutput {
+				if strings.Contains(out.String(), reject) {
+					t.Fatalf("Expected output to not contain %q got\n%s", reject, out.String())
+				}
+			}
+			for _, expect := range mock.expectErrOutput {
+				if !strings.Contains(errOut.String(), expect) {
+					t.Fatalf("Expected error output to contain %q got\n%s", expect, errOut.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"mayadata.io/cstorpoolauto/pkg/inventory"
+)
+
+var inventoryInterval = flag.Duration(
+	"inventory-interval",
+	5*time.Minute,
+	`How often to list every CStorClusterConfig of the cluster into the
+	 CStorPoolAutoInventory; 0 disables the inventory`,
+)
+
+// newInventoryAggregator returns the aggregator of the inventory
+// built from the flags. It returns nil if the inventory is disabled.
+func newInventoryAggregator(config *rest.Config) (*inventory.Aggregator, error) {
+	if *inventoryInterval <= 0 {
+		return nil, nil
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Can't build dynamic client for inventory")
+	}
+	return &inventory.Aggregator{
+		Client: client,
+	}, nil
+}
+
+// startInventory starts the aggregation of the inventory in the
+// background till the given channel is closed
+func startInventory(config *rest.Config, stop <-chan struct{}) error {
+	aggregator, err := newInventoryAggregator(config)
+	if err != nil {
+		return err
+	}
+	if aggregator == nil {
+		glog.Info("Inventory is disabled")
+		return nil
+	}
+	glog.Infof("Inventory: Interval %v", *inventoryInterval)
+	go aggregator.Run(*inventoryInterval, stop)
+	return nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestNewInventoryAggregator(t *testing.T) {
+	var tests = map[string]struct {
+		interval  time.Duration
+		expectNil bool
+	}{
+		"disabled inventory": {
+			interval:  0,
+			expectNil: true,
+		},
+		"enabled inventory": {
+			interval: time.Minute,
+		},
+	}
+	oldInterval := *inventoryInterval
+	defer func() {
+		*inventoryInterval = oldInterval
+	}()
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			*inventoryInterval = mock.interval
+			got, err := newInventoryAggregator(&rest.Config{Host: "localhost"})
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.expectNil != (got == nil) {
+				t.Fatalf("Expected nil aggregator %t got %+v", mock.expectNil, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"openebs.io/metac/controller/generic"
+)
+
+var resyncAfterConfigPath = flag.String(
+	"resync-after-config-path",
+	"",
+	`Path to a file e.g. a mounted ConfigMap key with one <controller-name>=<duration>
+	 per line that overrides the resync interval of the controller;
+	 --resync-after takes precedence over this file`,
+)
+
+// resyncOverrides holds the resync intervals set via flags
+var resyncOverrides = ResyncIntervals{}
+
+// resyncIntervals holds the effective resync interval of every
+// registered controller
+//
+// NOTE:
+//	These are replaced whenever the operator config is reloaded
+var (
+	resyncIntervals      = ResyncIntervals{}
+	resyncIntervalsMutex sync.RWMutex
+)
+
+// setResyncIntervals replaces the effective resync intervals
+func setResyncIntervals(intervals ResyncIntervals) {
+	resyncIntervalsMutex.Lock()
+	defer resyncIntervalsMutex.Unlock()
+	resyncIntervals = intervals
+}
+
+// getResyncIntervals returns the effective resync intervals
+func getResyncIntervals() ResyncIntervals {
+	resyncIntervalsMutex.RLock()
+	defer resyncIntervalsMutex.RUnlock()
+	return resyncIntervals
+}
+
+// resyncIntervalOf returns the function that returns the effective
+// resync interval of the given controller
+func resyncIntervalOf(name string) func() time.Duration {
+	return func() time.Duration {
+		return getResyncIntervals()[name]
+	}
+}
+
+func init() {
+	flag.Var(
+		&resyncOverrides,
+		"resync-after",
+		`Resync interval of a controller that overrides its default;
+		 Format is <controller-name>=<duration> e.g. blockdevice=30s;
+		 Can be repeated or comma separated; 0s disables the periodic resync`,
+	)
+}
+
+// ResyncIntervals are the resync intervals keyed by controller
+// names that can be set via a repeatable command line flag
+type ResyncIntervals map[string]time.Duration
+
+// String implements flag.Value interface
+func (r *ResyncIntervals) String() string {
+	var values []string
+	for name, interval := range *r {
+		values = append(values, name+"="+interval.String())
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// Set implements flag.Value interface. A comma separated
+// value results in multiple intervals.
+func (r *ResyncIntervals) Set(value string) error {
+	if *r == nil {
+		*r = ResyncIntervals{}
+	}
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return errors.Errorf(
+				"Invalid resync interval %q: Want <controller-name>=<duration>", v,
+			)
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+		if err != nil || interval < 0 {
+			return errors.Errorf(
+				"Invalid resync interval %q: Want a non negative duration", v,
+			)
+		}
+		(*r)[strings.TrimSpace(parts[0])] = interval
+	}
+	return nil
+}
+
+// ParseResyncIntervals parses the given content that has one
+// <controller-name>=<duration> per line. Empty lines & lines
+// starting with # are ignored.
+func ParseResyncIntervals(content string) (ResyncIntervals, error) {
+	intervals := ResyncIntervals{}
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		err := intervals.Set(line)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return intervals, nil
+}
+
+// loadResyncIntervals returns the resync interval of every given
+// controller. Defaults of the controllers are overridden by the
+// given config file, then by the given operator config intervals
+// & finally by the flags.
+func loadResyncIntervals(
+	controllers []Controller,
+	configPath string,
+	fromOperatorConfig ResyncIntervals,
+	overrides ResyncIntervals,
+) (ResyncIntervals, error) {
+	fromConfig := ResyncIntervals{}
+	if configPath != "" {
+		content, err := ioutil.ReadFile(configPath)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't load resync intervals from %q", configPath,
+			)
+		}
+		fromConfig, err = ParseResyncIntervals(string(content))
+		if err != nil {
+			return nil, errors.Wrapf(
+				err, "Can't load resync intervals from %q", configPath,
+			)
+		}
+	}
+	intervals := ResyncIntervals{}
+	for _, ctl := range controllers {
+		intervals[ctl.Name] = ctl.ResyncAfter
+	}
+	for _, given := range []ResyncIntervals{fromConfig, fromOperatorConfig, overrides} {
+		for name, interval := range given {
+			if _, found := intervals[name]; !found {
+				return nil, errors.Errorf(
+					"Can't set resync interval of %q: Controller not found", name,
+				)
+			}
+			intervals[name] = interval
+		}
+	}
+	return intervals, nil
+}
+
+// withResyncAfter returns an inline hook whose response is
+// resynced after the interval returned by the given function unless
+// the hook has set a resync interval of its own e.g. to retry an
+// error
+//
+// NOTE:
+//	Interval is evaluated per sync since it can change whenever the
+// operator config is reloaded.
+func withResyncAfter(
+	interval func() time.Duration, fn generic.InlineInvokeFn,
+) generic.InlineInvokeFn {
+	return func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+		err := fn(req, resp)
+		if resp != nil && resp.ResyncAfterSeconds == 0 && interval() > 0 {
+			resp.ResyncAfterSeconds = interval().Seconds()
+		}
+		return err
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"openebs.io/metac/controller/generic"
+)
+
+func TestResyncIntervalsSet(t *testing.T) {
+	var tests = map[string]struct {
+		values []string
+		expect ResyncIntervals
+		isErr  bool
+	}{
+		"repeated & comma separated values": {
+			values: []string{"blockdevice=30s, cstorclusterconfig=10m", "localdevice=0s"},
+			expect: ResyncIntervals{
+				"blockdevice":        30 * time.Second,
+				"cstorclusterconfig": 10 * time.Minute,
+				"localdevice":        0,
+			},
+		},
+		"later value wins": {
+			values: []string{"blockdevice=30s", "blockdevice=1m"},
+			expect: ResyncIntervals{"blockdevice": time.Minute},
+		},
+		"missing controller name": {
+			values: []string{"=30s"},
+			isErr:  true,
+		},
+		"missing duration": {
+			values: []string{"blockdevice"},
+			isErr:  true,
+		},
+		"invalid duration": {
+			values: []string{"blockdevice=30"},
+			isErr:  true,
+		},
+		"negative duration": {
+			values: []string{"blockdevice=-30s"},
+			isErr:  true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var got ResyncIntervals
+			var err error
+			for _, value := range mock.values {
+				err = got.Set(value)
+				if err != nil {
+					break
+				}
+			}
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadResyncIntervals(t *testing.T) {
+	dir, err := ioutil.TempDir("", "resync")
+	if err != nil {
+		t.Fatalf("Can't create temp dir: %+v", err)
+	}
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "resync-after")
+	err = ioutil.WriteFile(
+		configPath,
+		[]byte("# tuned for large clusters\nblockdevice=1m\n\nlocaldevice=2m\n"),
+		0644,
+	)
+	if err != nil {
+		t.Fatalf("Can't write config: %+v", err)
+	}
+
+	controllers := []Controller{
+		{Name: "blockdevice", ResyncAfter: 30 * time.Second},
+		{Name: "localdevice", ResyncAfter: time.Minute},
+		{Name: "supportbundle"},
+	}
+	var tests = map[string]struct {
+		configPath         string
+		fromOperatorConfig ResyncIntervals
+		overrides          ResyncIntervals
+		expect             ResyncIntervals
+		isErr              bool
+	}{
+		"defaults": {
+			expect: ResyncIntervals{
+				"blockdevice":   30 * time.Second,
+				"localdevice":   time.Minute,
+				"supportbundle": 0,
+			},
+		},
+		"config overrides defaults & flags override config": {
+			configPath: configPath,
+			overrides:  ResyncIntervals{"localdevice": 0},
+			expect: ResyncIntervals{
+				"blockdevice":   time.Minute,
+				"localdevice":   0,
+				"supportbundle": 0,
+			},
+		},
+		"operator config overrides config & flags override operator config": {
+			configPath: configPath,
+			fromOperatorConfig: ResyncIntervals{
+				"blockdevice":   2 * time.Minute,
+				"localdevice":   3 * time.Minute,
+				"supportbundle": time.Hour,
+			},
+			overrides: ResyncIntervals{"localdevice": 0},
+			expect: ResyncIntervals{
+				"blockdevice":   2 * time.Minute,
+				"localdevice":   0,
+				"supportbundle": time.Hour,
+			},
+		},
+		"missing config": {
+			configPath: filepath.Join(dir, "missing"),
+			isErr:      true,
+		},
+		"unknown controller": {
+			overrides: ResyncIntervals{"noop": time.Minute},
+			isErr:     true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got, err := loadResyncIntervals(
+				controllers, mock.configPath, mock.fromOperatorConfig, mock.overrides,
+			)
+			if mock.isErr {
+				if err == nil {
+					t.Fatalf("Expected error got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if diff := cmp.Diff(mock.expect, got); diff != "" {
+				t.Fatalf("Expected no diff got\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithResyncAfter(t *testing.T) {
+	var tests = map[string]struct {
+		interval     time.Duration
+		hookResync   float64
+		expectResync float64
+	}{
+		"default is set": {
+			interval:     2 * time.Minute,
+			expectResync: 120,
+		},
+		"hook's resync is retained": {
+			interval:     2 * time.Minute,
+			hookResync:   3,
+			expectResync: 3,
+		},
+		"no default": {
+			hookResync:   3,
+			expectResync: 3,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			fn := withResyncAfter(
+				func() time.Duration { return mock.interval },
+				func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+					resp.ResyncAfterSeconds = mock.hookResync
+					return nil
+				},
+			)
+			resp := &generic.SyncHookResponse{}
+			err := fn(&generic.SyncHookRequest{}, resp)
+			if err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if resp.ResyncAfterSeconds != mock.expectResync {
+				t.Fatalf(
+					"Expected resync after %v got %v",
+					mock.expectResync, resp.ResyncAfterSeconds,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/golang/glog"
+	"go.opencensus.io/stats/view"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"openebs.io/metac/apis/metacontroller/v1alpha1"
+	"openebs.io/metac/config"
+	"openebs.io/metac/server"
+)
+
+// NOTE:
+//	Flags are same as the ones exposed by metac's start package.
+// This keeps the existing deployments working as is.
+var (
+	discoveryInterval = flag.Duration(
+		"discovery-interval",
+		30*time.Second,
+		"How often to refresh discovery cache to pick up newly-installed resources",
+	)
+	informerRelist = flag.Duration(
+		"cache-flush-interval",
+		30*time.Minute,
+		"How often to flush local caches and relist objects from the API server",
+	)
+	debugAddr = flag.String(
+		"debug-addr",
+		":9999",
+		`The address to bind the debug http endpoints;
+		 pprof & expvar endpoints are served only if this is set explicitly`,
+	)
+	clientConfigPath = flag.String(
+		"client-config-path",
+		"",
+		`Path to kubeconfig file (same format as used by kubectl);
+		if not specified, uses in-cluster config`,
+	)
+	workerCount = flag.Int(
+		"workers-count",
+		5,
+		"How many workers to start per controller to process queued events",
+	)
+	clientGoQPS = flag.Float64(
+		"client-go-qps",
+		5,
+		"Number of queries per second client-go is allowed to make (default 5)",
+	)
+	clientGoBurst = flag.Int(
+		"client-go-burst",
+		10,
+		"Allowed burst queries for client-go (default 10)",
+	)
+	runAsLocal = flag.Bool(
+		"run-as-local",
+		false,
+		`When true it enables metac to run by looking up its config file;
+		 Metac will no longer be dependent on its CRDs and CRs`,
+	)
+	metacConfigPath = flag.String(
+		"metac-config-path",
+		"/etc/config/metac/",
+		`Path to metac config file to let metac run as a self contained binary;
+		 Needs run-as-local set to true`,
+	)
+)
+
+// overrides holds the attachments that get added to or removed
+// from the GenericController(s) loaded from metac config path
+var overrides AttachmentOverrides
+
+func init() {
+	flag.Var(
+		&overrides.Add,
+		"watch-attachment",
+		`Resource to be added as an attachment to a GenericController;
+		 Format is <controller-name>:<apiVersion>/<resource>;
+		 Can be repeated or comma separated; Needs run-as-local set to true`,
+	)
+	flag.Var(
+		&overrides.Remove,
+		"unwatch-attachment",
+		`Resource to be removed from the attachments of a GenericController;
+		 Format is <controller-name>:<apiVersion>/<resource>;
+		 Can be repeated or comma separated; Needs run-as-local set to true`,
+	)
+}
+
+// loadGenericControllers loads the GenericController(s) from
+// metac config path, applies the attachment overrides & removes
+// the ones that belong to disabled controllers
+func loadGenericControllers() ([]*v1alpha1.GenericController, error) {
+	configs, err := config.New(*metacConfigPath).Load()
+	if err != nil {
+		return nil, err
+	}
+	gctls, err := configs.ListGenericControllers()
+	if err != nil {
+		return nil, err
+	}
+	err = overrides.Apply(gctls)
+	if err != nil {
+		return nil, err
+	}
+	return filterDisabledGenericControllers(gctls, disabledHooks), nil
+}
+
+// Start starts this binary
+//
+// NOTE:
+//	This is based on metac's start package. Unlike metac, the
+// GenericController(s) are loaded via code. This lets the
+// attachments be customised via flags without the need to
+// modify metac config.
+func Start() {
+	flag.Parse()
+
+	glog.Infof("Discovery cache refresh interval: %v", *discoveryInterval)
+	glog.Infof("API server relist interval i.e. cache flush interval: %v", *informerRelist)
+	glog.Infof("Debug http server address: %v", *debugAddr)
+	glog.Infof("Run metac locally: %t", *runAsLocal)
+	glog.Infof("Add attachments: %s", overrides.Add.String())
+	glog.Infof("Remove attachments: %s", overrides.Remove.String())
+	intervals := getResyncIntervals()
+	glog.Infof("Resync intervals: %s", intervals.String())
+
+	stopTracing, err := startTracing()
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	var config *rest.Config
+	if *clientConfigPath != "" {
+		glog.Infof("Using kubeconfig %v", *clientConfigPath)
+		config, err = clientcmd.BuildConfigFromFlags("", *clientConfigPath)
+	} else {
+		glog.Info("No kubeconfig file specified: Trying in-cluster auto-config")
+		config, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		glog.Fatal(err)
+	}
+	config.QPS = float32(*clientGoQPS)
+	config.Burst = *clientGoBurst
+	// resyncs of all the controllers back off together when the API
+	// server throttles or fails the requests of this process
+	wrapWithAPIThrottle(config)
+
+	// declare the stop server function
+	var stopServer func()
+	// common server values
+	var mserver = server.Server{
+		Config:            config,
+		DiscoveryInterval: *discoveryInterval,
+		InformerRelist:    *informerRelist,
+	}
+	// start metac either as config based or CRD based
+	if *runAsLocal {
+		// run as local implies starting this binary by
+		// loading various MetaController resources via code
+		configServer := &server.ConfigServer{
+			Server:                        mserver,
+			GenericControllerConfigLoadFn: loadGenericControllers,
+		}
+		stopServer, err = configServer.Start(*workerCount)
+	} else {
+		if len(overrides.Add) != 0 || len(overrides.Remove) != 0 {
+			glog.Fatal("Attachment overrides need run-as-local set to true")
+		}
+		if len(disabledHooks) != 0 {
+			glog.Warning(
+				"GenericController(s) of disabled controllers are not skipped: Needs run-as-local set to true",
+			)
+		}
+		crdServer := &server.CRDServer{Server: mserver}
+		stopServer, err = crdServer.Start(*workerCount)
+	}
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	// audit runs independent of the controllers to find the
+	// children left behind by missed finalize hooks
+	stopAudit := make(chan struct{})
+	err = startOrphanAudit(config, stopAudit)
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	// inventory runs independent of the controllers since no
+	// controller observes the configs of all the namespaces
+	stopInventory := make(chan struct{})
+	err = startInventory(config, stopInventory)
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	// runtime settings of the operator config are applied as soon
+	// as its file changes
+	stopConfigReload := make(chan struct{})
+	err = startConfigReload(stopConfigReload)
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	exporter, err := prometheus.NewExporter(prometheus.Options{})
+	if err != nil {
+		glog.Fatalf("Can't create prometheus exporter: %v", err)
+	}
+	view.RegisterExporter(exporter)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	// profiling is off by default since it exposes the internals
+	// of this binary
+	stopLogging := make(chan struct{})
+	if isFlagSet("debug-addr") {
+		glog.Infof("Debug endpoints are enabled: Log interval %v", *debugLogInterval)
+		registerDebugHandlers(mux)
+		go logRuntimeStats(*debugLogInterval, stopLogging)
+	}
+	httpServer := &http.Server{
+		Addr:    *debugAddr,
+		Handler: mux,
+	}
+	go func() {
+		glog.Errorf(
+			"Error serving metrics endpoint: %v",
+			httpServer.ListenAndServe(),
+		)
+	}()
+
+	// On SIGTERM, stop all controllers gracefully.
+	sigchan := make(chan os.Signal, 2)
+	signal.Notify(sigchan, os.Interrupt, syscall.SIGTERM)
+	sig := <-sigchan
+	glog.Infof("Received %q signal. Shutting down...", sig)
+
+	close(stopLogging)
+	close(stopAudit)
+	close(stopInventory)
+	close(stopConfigReload)
+	stopServer()
+	stopTracing()
+	httpServer.Shutdown(context.Background())
+}
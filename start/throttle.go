@@ -0,0 +1,77 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"net/http"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+)
+
+// apiThrottleTransport records every response of the API server
+// against an adaptive throttle
+type apiThrottleTransport struct {
+	next     http.RoundTripper
+	throttle *throttle.Adaptive
+}
+
+// RoundTrip implements http.RoundTripper
+//
+// NOTE:
+//	Responses with status 429 i.e. throttled & 5xx i.e. server errors
+// are counted as failures. Requests that get no response e.g. due to
+// a cancelled context are not counted.
+func (t *apiThrottleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	t.throttle.Record(
+		resp.StatusCode == http.StatusTooManyRequests ||
+			resp.StatusCode >= http.StatusInternalServerError,
+	)
+	return resp, nil
+}
+
+// wrapWithAPIThrottle sets the given config to record the responses
+// of all its API requests against the shared adaptive throttle
+func wrapWithAPIThrottle(config *rest.Config) {
+	config.WrapTransport = transport.Wrappers(
+		config.WrapTransport,
+		func(rt http.RoundTripper) http.RoundTripper {
+			return &apiThrottleTransport{next: rt, throttle: throttle.API}
+		},
+	)
+}
+
+// withAPIThrottle returns an inline hook whose resync interval is
+// raised to the minimum interval of the current level of the shared
+// adaptive throttle
+func withAPIThrottle(fn generic.InlineInvokeFn) generic.InlineInvokeFn {
+	return func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+		err := fn(req, resp)
+		if resp != nil {
+			resp.ResyncAfterSeconds =
+				throttle.API.ResyncAfterSeconds(resp.ResyncAfterSeconds)
+		}
+		return err
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"openebs.io/metac/controller/generic"
+
+	"mayadata.io/cstorpoolauto/pkg/throttle"
+)
+
+func TestAPIThrottleTransport(t *testing.T) {
+	var tests = map[string]struct {
+		statusCodes    []int
+		expectRequests int64
+		expectFailures int64
+	}{
+		"successful responses": {
+			statusCodes:    []int{http.StatusOK, http.StatusCreated},
+			expectRequests: 2,
+		},
+		"client errors are not failures": {
+			statusCodes:    []int{http.StatusNotFound, http.StatusConflict},
+			expectRequests: 2,
+		},
+		"throttled responses": {
+			statusCodes:    []int{http.StatusOK, http.StatusTooManyRequests},
+			expectRequests: 2,
+			expectFailures: 1,
+		},
+		"server errors": {
+			statusCodes: []int{
+				http.StatusInternalServerError,
+				http.StatusServiceUnavailable,
+				http.StatusOK,
+			},
+			expectRequests: 3,
+			expectFailures: 2,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var next int
+			server := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(mock.statusCodes[next])
+					next++
+				},
+			))
+			defer server.Close()
+			adaptive := throttle.NewAdaptive(time.Minute)
+			client := &http.Client{
+				Transport: &apiThrottleTransport{
+					next:     http.DefaultTransport,
+					throttle: adaptive,
+				},
+			}
+			for range mock.statusCodes {
+				resp, err := client.Get(server.URL)
+				if err != nil {
+					t.Fatalf("Expected no error got [%+v]", err)
+				}
+				resp.Body.Close()
+			}
+			stats := adaptive.Stats()
+			if stats.WindowRequests != mock.expectRequests {
+				t.Fatalf(
+					"Expected requests %d got %d",
+					mock.expectRequests, stats.WindowRequests,
+				)
+			}
+			if stats.WindowFailures != mock.expectFailures {
+				t.Fatalf(
+					"Expected failures %d got %d",
+					mock.expectFailures, stats.WindowFailures,
+				)
+			}
+		})
+	}
+}
+
+func TestWithAPIThrottle(t *testing.T) {
+	fn := withAPIThrottle(
+		func(req *generic.SyncHookRequest, resp *generic.SyncHookResponse) error {
+			resp.ResyncAfterSeconds = 3
+			return nil
+		},
+	)
+	resp := &generic.SyncHookResponse{}
+	err := fn(&generic.SyncHookRequest{}, resp)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	// resync is raised to the min resync of the current level
+	expect := throttle.API.ResyncAfterSeconds(3)
+	if resp.ResyncAfterSeconds != expect {
+		t.Fatalf("Expected resync %v got %v", expect, resp.ResyncAfterSeconds)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+
+	"mayadata.io/cstorpoolauto/pkg/tracing"
+)
+
+var (
+	otlpTracesEndpoint = flag.String(
+		"otlp-traces-endpoint",
+		"",
+		`OTLP/HTTP endpoint e.g. http://otel-collector:4318 that receives the
+		 traces of controller syncs; Syncs are not traced if this is not set`,
+	)
+	traceSamplingRatio = flag.Float64(
+		"trace-sampling-ratio",
+		1,
+		`Fraction of controller syncs that are traced e.g. 0.1 traces one in
+		 ten syncs; Needs otlp-traces-endpoint to be set`,
+	)
+)
+
+// tracingServiceName is the service name of the exported traces
+const tracingServiceName = "cstorpoolauto"
+
+// startTracing starts exporting the traces of controller syncs if
+// an OTLP endpoint is set. It returns the function that flushes the
+// pending traces & stops the export.
+//
+// NOTE:
+//	Syncs are never sampled if the endpoint is not set. This avoids
+// the cost of recording spans that are not exported.
+func startTracing() (func(), error) {
+	if *otlpTracesEndpoint == "" {
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+		return func() {}, nil
+	}
+	if *traceSamplingRatio < 0 || *traceSamplingRatio > 1 {
+		return nil, errors.Errorf(
+			"Invalid trace-sampling-ratio %v: Want a value from 0 to 1",
+			*traceSamplingRatio,
+		)
+	}
+	trace.ApplyConfig(trace.Config{
+		DefaultSampler: trace.ProbabilitySampler(*traceSamplingRatio),
+	})
+	exporter := tracing.NewExporter(*otlpTracesEndpoint, tracingServiceName)
+	trace.RegisterExporter(exporter)
+	glog.Infof(
+		"Exporting traces to %q: Sampling ratio %v",
+		exporter.URL, *traceSamplingRatio,
+	)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		exporter.Start(stop)
+		close(done)
+	}()
+	return func() {
+		trace.UnregisterExporter(exporter)
+		close(stop)
+		// wait for the pending traces to be flushed
+		<-done
+	}, nil
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8s "openebs.io/metac/third_party/kubernetes"
+
+	"mayadata.io/cstorpoolauto/controller/cstorclusterconfig"
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
+	"mayadata.io/cstorpoolauto/types"
+	"mayadata.io/cstorpoolauto/types/v1beta1"
+)
+
+// Exit codes of validate command
+const (
+	// ValidateExitValid implies all the CStorClusterConfig(s) are valid
+	ValidateExitValid int = 0
+
+	// ValidateExitInvalid implies one or more CStorClusterConfig(s)
+	// failed the validations
+	ValidateExitInvalid int = 1
+
+	// ValidateExitUsage implies the command was not used properly
+	// e.g. invalid flags or manifests that can not be read
+	ValidateExitUsage int = 2
+)
+
+// RunValidate validates the CStorClusterConfig(s) of the manifest
+// set via -f flag & prints the failed checks to the given writer.
+// It returns the exit code of validate command.
+//
+// NOTE:
+//	Nodes of the target cluster can be set via --nodes flag e.g.
+// the output of 'kubectl get nodes -o yaml'. Pool counts are then
+// verified against the nodes eligible for pools.
+func RunValidate(args []string, out io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(out)
+	file := flags.String(
+		"f",
+		"",
+		"Path to the manifest with one or more CStorClusterConfig(s)",
+	)
+	nodesFile := flags.String(
+		"nodes",
+		"",
+		"Optional path to the manifest with the nodes of the target cluster",
+	)
+	err := flags.Parse(args)
+	if err != nil {
+		return ValidateExitUsage
+	}
+	if *file == "" {
+		fmt.Fprintln(out, "Missing manifest: -f is required")
+		flags.Usage()
+		return ValidateExitUsage
+	}
+
+	objs, err := readManifest(*file)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return ValidateExitUsage
+	}
+	var nodes []*unstructured.Unstructured
+	if *nodesFile != "" {
+		nodeObjs, err := readManifest(*nodesFile)
+		if err != nil {
+			fmt.Fprintln(out, err)
+			return ValidateExitUsage
+		}
+		// nodes are known & hence not nil even if there are none
+		nodes = []*unstructured.Unstructured{}
+		for _, obj := range nodeObjs {
+			if obj.GetKind() == string(types.KindNode) {
+				nodes = append(nodes, obj)
+			}
+		}
+	}
+
+	var configCount, invalidCount int
+	for _, obj := range objs {
+		if !isCStorClusterConfig(obj) {
+			continue
+		}
+		configCount++
+		if !validateConfig(obj, nodes, out) {
+			invalidCount++
+		}
+	}
+	if configCount == 0 {
+		fmt.Fprintf(out, "No CStorClusterConfig found in %s\n", *file)
+		return ValidateExitUsage
+	}
+	if invalidCount != 0 {
+		return ValidateExitInvalid
+	}
+	return ValidateExitValid
+}
+
+// readManifest returns the objects of the given yaml file
+func readManifest(path string) ([]*unstructured.Unstructured, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read %s: %v", path, err)
+	}
+	list, err := k8s.YAMLToUnstructuredSlice(raw)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %v", path, err)
+	}
+	var objs []*unstructured.Unstructured
+	for idx := range list {
+		objs = append(objs, &list[idx])
+	}
+	return objs, nil
+}
+
+// validateConfig prints the failed checks of the given
+// CStorClusterConfig & returns true if it is valid
+func validateConfig(
+	obj *unstructured.Unstructured,
+	nodes []*unstructured.Unstructured,
+	out io.Writer,
+) bool {
+	name := configKey(obj)
+	if v1beta1.IsV1Beta1Shaped(obj) {
+		converted, err := v1beta1.ToV1Alpha1Unstructured(obj)
+		if err != nil {
+			fmt.Fprintf(out, "CStorClusterConfig %s: invalid\n", name)
+			fmt.Fprintf(out, "  - schema: %s: %v\n", errs.TypeValidation, err)
+			return false
+		}
+		obj = converted
+	}
+	validator := &cstorclusterconfig.Validator{
+		ClusterConfig: obj,
+		Nodes:         nodes,
+	}
+	failures := validator.Validate()
+	if len(failures) == 0 {
+		fmt.Fprintf(out, "CStorClusterConfig %s: valid\n", name)
+		return true
+	}
+	fmt.Fprintf(out, "CStorClusterConfig %s: %d check(s) failed\n", name, len(failures))
+	for _, failure := range failures {
+		fmt.Fprintf(
+			out, "  - %s: %s: %v\n", failure.Check, errs.Reason(failure.Err), failure.Err,
+		)
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package start
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validConfigYAML = `
+apiVersion: dao.mayadata.io/v1alpha1
+kind: CStorClusterConfig
+metadata:
+  name: valid
+  namespace: openebs
+spec:
+  minPoolCount: 2
+  diskConfig:
+    external:
+      csiAttacherName: pd.csi.storage.gke.io
+      storageClassName: csi-gce-pd
+`
+
+const invalidConfigYAML = `
+apiVersion: dao.mayadata.io/v1alpha1
+kind: CStorClusterConfig
+metadata:
+  name: invalid
+  namespace: openebs
+spec:
+  diskConfig:
+    external:
+      csiAttacherName: pd.csi.storage.gke.io
+      storageClassName: csi-gce-pd
+  poolConfig:
+    raidType: raid5
+`
+
+const nodesYAML = `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Node
+  metadata:
+    name: node-1
+`
+
+func TestRunValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, []byte(content), 0644)
+		if err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+		return path
+	}
+	valid := write("valid.yaml", validConfigYAML)
+	mixed := write("mixed.yaml", validConfigYAML+"---"+invalidConfigYAML)
+	nodes := write("nodes.yaml", nodesYAML)
+	noConfig := write("noconfig.yaml", nodesYAML)
+
+	var tests = map[string]struct {
+		args         []string
+		expectCode   int
+		expectOutput []string
+	}{
+		"missing manifest flag": {
+			expectCode:   ValidateExitUsage,
+			expectOutput: []string{"-f is required"},
+		},
+		"manifest not found": {
+			args:         []string{"-f", filepath.Join(dir, "none.yaml")},
+			expectCode:   ValidateExitUsage,
+			expectOutput: []string{"Failed to read"},
+		},
+		"manifest without configs": {
+			args:         []string{"-f", noConfig},
+			expectCode:   ValidateExitUsage,
+			expectOutput: []string{"No CStorClusterConfig found"},
+		},
+		"valid config": {
+			args:         []string{"-f", valid},
+			expectCode:   ValidateExitValid,
+			expectOutput: []string{"CStorClusterConfig openebs/valid: valid"},
+		},
+		"valid & invalid configs": {
+			args:       []string{"-f", mixed},
+			expectCode: ValidateExitInvalid,
+			expectOutput: []string{
+				"CStorClusterConfig openebs/valid: valid",
+				"CStorClusterConfig openebs/invalid: 1 check(s) failed",
+				"  - raid type: ValidationError:",
+			},
+		},
+		"min pool count exceeds nodes": {
+			args:       []string{"-f", valid, "--nodes", nodes},
+			expectCode: ValidateExitInvalid,
+			expectOutput: []string{
+				"  - pool count: NotEnoughResourcesError:",
+			},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			code := RunValidate(mock.args, &out)
+			if code != mock.expectCode {
+				t.Fatalf("Expected exit code %d got %d: %s", mock.expectCode, code, out.String())
+			}
+			for _, expect := range mock.expectOutput {
+				if !strings.Contains(out.String(), expect) {
+					t.Fatalf("Expected output to contain %q got\n%s", expect, out.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+//go:build integration
+// +build integration
+
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"openebs.io/metac/test/integration/framework"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// TestCStorClusterConfigToCStorPoolCluster verifies the end to end
+// workflow starting from a CStorClusterConfig that results in a
+// CStorPoolCluster i.e.
+//
+//	CStorClusterConfig --> CStorClusterPlan --> CStorClusterStorageSet(s)
+//	--> Storage(s) --> BlockDevice(s) --> BlockDeviceClaim(s)
+//	--> CStorPoolCluster
+//
+// NOTE:
+//	Storage provisioner & NDM are not available in this test
+// environment. Hence, BlockDevice(s) are created & BlockDeviceClaim(s)
+// are bound by this test on behalf of these components.
+func TestCStorClusterConfigToCStorPoolCluster(t *testing.T) {
+	namespace := "cspauto-e2e"
+	nodeNames := []string{"node-1", "node-2"}
+
+	f := framework.NewFixture(t)
+	defer f.TearDown()
+
+	uninstallCRDs := installCRDs(t, f, projectCRDPath, dependentCRDPath)
+	defer uninstallCRDs()
+
+	stopMetac := startMetac(t, f)
+	defer stopMetac()
+
+	client := newDynamicClient(t)
+
+	f.CreateNamespace(namespace)
+	for _, name := range nodeNames {
+		_, err := f.GetTypedClientset().CoreV1().Nodes().Create(
+			&v1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+					Labels: map[string]string{
+						"kubernetes.io/hostname": name,
+					},
+				},
+			},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.GetTypedClientset().CoreV1().Nodes().Delete(name, nil)
+	}
+	// external disk config is valid only if its StorageClass exists
+	_, err := f.GetTypedClientset().StorageV1().StorageClasses().Create(
+		&storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "e2e-sc",
+			},
+			Provisioner: "e2e-csi-attacher",
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.GetTypedClientset().StorageV1().StorageClasses().Delete("e2e-sc", nil)
+
+	// -----------------------------------------------------
+	// Create CStorClusterConfig that triggers the workflow
+	// -----------------------------------------------------
+	config := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+			"kind":       string(types.KindCStorClusterConfig),
+			"metadata": map[string]interface{}{
+				"name":      "e2e",
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"minPoolCount": int64(len(nodeNames)),
+				// children are verified in the namespace of this config
+				"targetNamespace": namespace,
+				"diskConfig": map[string]interface{}{
+					"minCount": int64(1),
+					"external": map[string]interface{}{
+						"csiAttacherName":  "e2e-csi-attacher",
+						"storageClassName": "e2e-sc",
+					},
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": string(types.PoolRAIDTypeStripe),
+				},
+			},
+		},
+	}
+	_, err = client.Resource(gvrCStorClusterConfig).Namespace(namespace).Create(
+		config, metav1.CreateOptions{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// -----------------------------------------------------
+	// Verify CStorClusterPlan
+	// -----------------------------------------------------
+	var plan *unstructured.Unstructured
+	err = f.Wait(func() (bool, error) {
+		plan, err = client.Resource(gvrCStorClusterPlan).Namespace(namespace).Get(
+			"e2e", metav1.GetOptions{},
+		)
+		if err != nil {
+			return false, err
+		}
+		nodes, _, _ := unstructured.NestedSlice(plan.Object, "spec", "nodes")
+		if len(nodes) != len(nodeNames) {
+			return false, errors.Errorf(
+				"Want %d plan nodes got %d", len(nodeNames), len(nodes),
+			)
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("CStorClusterPlan was not planned: %v", err)
+	}
+
+	// -----------------------------------------------------
+	// Verify CStorClusterStorageSet(s)
+	// -----------------------------------------------------
+	var storageSets []unstructured.Unstructured
+	err = f.Wait(func() (bool, error) {
+		list, err := client.Resource(gvrCStorClusterStorageSet).Namespace(namespace).List(
+			metav1.ListOptions{},
+		)
+		if err != nil {
+			return false, err
+		}
+		if len(list.Items) != len(nodeNames) {
+			return false, errors.Errorf(
+				"Want %d storage sets got %d", len(nodeNames), len(list.Items),
+			)
+		}
+		storageSets = list.Items
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("CStorClusterStorageSet(s) were not created: %v", err)
+	}
+
+	// -----------------------------------------------------
+	// Create BlockDevice(s) on behalf of NDM
+	// -----------------------------------------------------
+	for _, storageSet := range storageSets {
+		nodeName, _, _ := unstructured.NestedString(
+			storageSet.Object, "spec", "node", "name",
+		)
+		device := &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": types.APIVersionOpenEBSV1Alpha1,
+				"kind":       string(types.KindBlockDevice),
+				"metadata": map[string]interface{}{
+					"name":      "bd-" + nodeName,
+					"namespace": namespace,
+					"labels": map[string]interface{}{
+						"kubernetes.io/hostname":              nodeName,
+						types.AnnKeyCStorClusterPlanUID:       string(plan.GetUID()),
+						types.AnnKeyCStorClusterStorageSetUID: string(storageSet.GetUID()),
+					},
+				},
+				"spec": map[string]interface{}{
+					"path": "/dev/sdb",
+				},
+				"status": map[string]interface{}{
+					"state":      string(types.BlockDeviceActive),
+					"claimState": string(types.BlockDeviceUnclaimed),
+				},
+			},
+		}
+		_, err := client.Resource(gvrBlockDevice).Namespace(namespace).Create(
+			device, metav1.CreateOptions{},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// -----------------------------------------------------
+	// Bind BlockDeviceClaim(s) on behalf of NDM
+	// -----------------------------------------------------
+	var claims []unstructured.Unstructured
+	err = f.Wait(func() (bool, error) {
+		list, err := client.Resource(gvrBlockDeviceClaim).Namespace(namespace).List(
+			metav1.ListOptions{},
+		)
+		if err != nil {
+			return false, err
+		}
+		if len(list.Items) != len(storageSets) {
+			return false, errors.Errorf(
+				"Want %d BlockDeviceClaim(s) got %d",
+				len(storageSets), len(list.Items),
+			)
+		}
+		claims = list.Items
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("BlockDeviceClaim(s) were not created: %v", err)
+	}
+	for _, claim := range claims {
+		claim := claim
+		err = unstructured.SetNestedField(
+			claim.Object, string(types.BlockDeviceClaimBound), "status", "phase",
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = client.Resource(gvrBlockDeviceClaim).Namespace(namespace).Update(
+			&claim, metav1.UpdateOptions{},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// -----------------------------------------------------
+	// Verify CStorPoolCluster
+	// -----------------------------------------------------
+	err = f.Wait(func() (bool, error) {
+		list, err := client.Resource(gvrCStorPoolCluster).Namespace(namespace).List(
+			metav1.ListOptions{},
+		)
+		if err != nil {
+			return false, err
+		}
+		if len(list.Items) != 1 {
+			return false, errors.Errorf(
+				"Want 1 CStorPoolCluster got %d", len(list.Items),
+			)
+		}
+		pools, _, _ := unstructured.NestedSlice(list.Items[0].Object, "spec", "pools")
+		if len(pools) != len(nodeNames) {
+			return false, errors.Errorf(
+				"Want %d pools got %d", len(nodeNames), len(pools),
+			)
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("CStorPoolCluster was not created: %v", err)
+	}
+	t.Logf("CStorPoolCluster was created from CStorClusterConfig successfully")
+}
@@ -0,0 +1,187 @@
+//go:build integration
+// +build integration
+
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	metacv1alpha1 "openebs.io/metac/apis/metacontroller/v1alpha1"
+	"openebs.io/metac/config"
+	"openebs.io/metac/test/integration/framework"
+	k8s "openebs.io/metac/third_party/kubernetes"
+)
+
+const (
+	// metacConfigPath is the path from the integration test
+	// binary's working dir to the metac config(s) of this project
+	metacConfigPath = "../../config/"
+
+	// projectCRDPath is the path from the integration test
+	// binary's working dir to this project's CRDs
+	projectCRDPath = "../../deploy/crd.yaml"
+
+	// dependentCRDPath refers to the CRDs that are owned by other
+	// projects but are used by this project
+	dependentCRDPath = "testdata/crd.yaml"
+)
+
+// Group version resources used by integration tests
+var (
+	gvrCStorClusterConfig = schema.GroupVersionResource{
+		Group: "dao.mayadata.io", Version: "v1alpha1", Resource: "cstorclusterconfigs",
+	}
+	gvrCStorClusterPlan = schema.GroupVersionResource{
+		Group: "dao.mayadata.io", Version: "v1alpha1", Resource: "cstorclusterplans",
+	}
+	gvrCStorClusterStorageSet = schema.GroupVersionResource{
+		Group: "dao.mayadata.io", Version: "v1alpha1", Resource: "cstorclusterstoragesets",
+	}
+	gvrBlockDevice = schema.GroupVersionResource{
+		Group: "openebs.io", Version: "v1alpha1", Resource: "blockdevices",
+	}
+	gvrBlockDeviceClaim = schema.GroupVersionResource{
+		Group: "openebs.io", Version: "v1alpha1", Resource: "blockdeviceclaims",
+	}
+	gvrCStorPoolCluster = schema.GroupVersionResource{
+		Group: "openebs.io", Version: "v1alpha1", Resource: "cstorpoolclusters",
+	}
+)
+
+// newDynamicClient returns a dynamic client that talks to the
+// kube-apiserver started for integration tests
+func newDynamicClient(t *testing.T) dynamic.Interface {
+	client, err := dynamic.NewForConfig(framework.ApiserverConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// installCRDs installs the CRDs found in the given files & waits
+// till these CRDs are served by kube-apiserver. It returns the
+// function that uninstalls these CRDs.
+//
+// NOTE:
+//	CRDs are installed as unstructured instances since this project's
+// CRDs are generated as apiextensions.k8s.io/v1 while the dependent
+// CRDs are still apiextensions.k8s.io/v1beta1.
+func installCRDs(t *testing.T, f *framework.Fixture, files ...string) (teardown func()) {
+	var crds []*unstructured.Unstructured
+	for _, file := range files {
+		contents, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs, err := k8s.YAMLToUnstructuredSlice(contents)
+		if err != nil {
+			t.Fatal(errors.Wrapf(err, "Can't load CRDs from %s", file))
+		}
+		for idx := range objs {
+			crds = append(crds, &objs[idx])
+		}
+	}
+	client := newDynamicClient(t)
+	// local function that returns the CRD resource of the given CRD
+	crdResource := func(crd *unstructured.Unstructured) dynamic.ResourceInterface {
+		return client.Resource(
+			schema.FromAPIVersionAndKind(crd.GetAPIVersion(), crd.GetKind()).
+				GroupVersion().WithResource("customresourcedefinitions"),
+		)
+	}
+	teardown = func() {
+		for _, crd := range crds {
+			err := crdResource(crd).Delete(crd.GetName(), nil)
+			if err != nil && !apierrors.IsNotFound(err) {
+				t.Logf("Can't delete CRD %s: %v", crd.GetName(), err)
+			}
+		}
+	}
+	for _, crd := range crds {
+		t.Logf("Creating CRD %s", crd.GetName())
+		_, err := crdResource(crd).Create(crd, metav1.CreateOptions{})
+		if err != nil {
+			teardown()
+			t.Fatal(err)
+		}
+	}
+	for _, crd := range crds {
+		gvr, err := getServedResource(crd)
+		if err != nil {
+			teardown()
+			t.Fatal(err)
+		}
+		err = f.Wait(func() (bool, error) {
+			_, err := client.Resource(gvr).List(metav1.ListOptions{})
+			return err == nil, err
+		})
+		if err != nil {
+			teardown()
+			t.Fatal(errors.Wrapf(err, "CRD %s is not served", crd.GetName()))
+		}
+	}
+	return teardown
+}
+
+// getServedResource returns the group version resource served due
+// to the given CRD. Both v1 & v1beta1 CRDs are supported.
+func getServedResource(crd *unstructured.Unstructured) (schema.GroupVersionResource, error) {
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	version, _, _ := unstructured.NestedString(crd.Object, "spec", "version")
+	if version == "" {
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+		if len(versions) != 0 {
+			version, _, _ = unstructured.NestedString(
+				versions[0].(map[string]interface{}), "name",
+			)
+		}
+	}
+	if group == "" || version == "" || plural == "" {
+		return schema.GroupVersionResource{},
+			errors.Errorf("Can't get served resource of CRD %s", crd.GetName())
+	}
+	return schema.GroupVersionResource{
+		Group:    group,
+		Version:  version,
+		Resource: plural,
+	}, nil
+}
+
+// startMetac starts metac in-process using the GenericController(s)
+// defined in this project's metac config(s). It returns the function
+// that stops metac.
+func startMetac(t *testing.T, f *framework.Fixture) (stop func()) {
+	return f.StartMetacFromGenericControllerConfig(
+		func() ([]*metacv1alpha1.GenericController, error) {
+			configs, err := config.New(metacConfigPath).Load()
+			if err != nil {
+				return nil, err
+			}
+			return configs.ListGenericControllers()
+		},
+	)
+}
@@ -0,0 +1,51 @@
+//go:build integration
+// +build integration
+
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package integration
+
+import (
+	"testing"
+
+	"openebs.io/metac/test/integration/framework"
+
+	"mayadata.io/cstorpoolauto/controller"
+	"mayadata.io/cstorpoolauto/start"
+)
+
+// TestMain will be run only once when go test is invoked against
+// this package. All the other Test* functions will be invoked via
+// m.Run call.
+//
+// NOTE:
+//	framework.TestWithConfigMetac starts etcd & kube-apiserver. Metac
+// is started in individual test functions from the metac config(s)
+// of this project. The hooks referred to in these configs are run
+// in-process i.e. within this test binary.
+//
+// NOTE:
+//	Hooks of all the controllers are registered from the same list
+// & in the same way as cmd/main.go
+func TestMain(m *testing.M) {
+	err := start.RegisterControllers(controller.All, controller.Names())
+	if err != nil {
+		panic(err)
+	}
+
+	framework.TestWithConfigMetac(m.Run)
+}
@@ -28,11 +28,22 @@ const (
 	// CStorClusterConfig UID
 	AnnKeyCStorClusterConfigUID string = AnnotationNamespace + "/cstorclusterconfig-uid"
 
+	// AnnKeyCStorClusterConfigNamespacedName is the annotation that
+	// refers to CStorClusterConfig as namespace/name. This lets the
+	// children created in a different namespace be traced back to
+	// their config.
+	AnnKeyCStorClusterConfigNamespacedName string = AnnotationNamespace + "/cstorclusterconfig"
+
 	// AnnKeyCStorClusterConfigLocalDisk is the annotation that implies
 	// if CStorClusterConfig reconciliation was due to local disks & not
 	// CSI managed disks
 	AnnKeyCStorClusterConfigLocalDisk string = AnnotationNamespace + "/cstorclusterconfig-localdisk"
 
+	// AnnKeyCStorPoolClusterFailureDomain is the annotation that
+	// refers to the failure domain of a CStorPoolCluster if the pools
+	// of local disks are sharded by failure domain
+	AnnKeyCStorPoolClusterFailureDomain string = AnnotationNamespace + "/failure-domain"
+
 	// AnnKeyCStorClusterPlanUID is the annotation that refers to
 	// CStorClusterPlan UID
 	AnnKeyCStorClusterPlanUID string = AnnotationNamespace + "/cstorclusterplan-uid"
@@ -41,6 +52,222 @@ const (
 	// CStorClusterStorageSet UID
 	AnnKeyCStorClusterStorageSetUID string = AnnotationNamespace + "/cstorclusterstorageset-uid"
 
+	// AnnKeyNodeDecommissionPool is the annotation set against a Node
+	// to decommission the cstor pool running on this node
+	AnnKeyNodeDecommissionPool string = AnnotationNamespace + "/decommission-pool"
+
+	// AnnKeyPinPool is the annotation set against a Node with value
+	// true to keep its cstor pool when the pool count is scaled down.
+	// This can be set against a CStorClusterPlan as well with value
+	// true to keep the pools of all its planned nodes or with comma
+	// separated names of the nodes whose pools are kept.
+	AnnKeyPinPool string = AnnotationNamespace + "/pin-pool"
+
+	// AnnKeyCStorPoolClusterDriftDetected is the annotation set
+	// against a CStorPoolCluster whose manual edits to pools are
+	// retained due to Warn drift policy. Removing this annotation
+	// lets the pools be managed again.
+	AnnKeyCStorPoolClusterDriftDetected string = AnnotationNamespace + "/drift-detected"
+
+	// AnnKeyCStorPoolClusterAdoptInto is the annotation set against a
+	// manually created CStorPoolCluster to let it be managed by the
+	// CStorClusterConfig of the given name. Value is either the name
+	// of this config in the namespace of the CStorPoolCluster or its
+	// namespace/name.
+	AnnKeyCStorPoolClusterAdoptInto string = AnnotationNamespace + "/adopt-into"
+
+	// AnnKeyCStorClusterConfigPersistDefaults is the annotation set
+	// against a CStorClusterConfig to let the resolved defaults be
+	// written back to its spec. This avoids the need for a defaulting
+	// webhook.
+	AnnKeyCStorClusterConfigPersistDefaults string = AnnotationNamespace + "/persist-defaults"
+
+	// AnnKeyCStorClusterConfigPaused is the annotation set against a
+	// CStorClusterConfig to pause the automation of all the resources
+	// derived from it. Removing this annotation or setting it to false
+	// resumes the automation.
+	AnnKeyCStorClusterConfigPaused string = AnnotationNamespace + "/paused"
+
+	// AnnKeyCStorClusterConfigAutoscalePoolCount is the annotation
+	// set against a CStorClusterConfig by the pool autoscaler to
+	// refer to the desired pool count. CStorClusterPlan is planned
+	// with this pool count if autoscale is enabled.
+	AnnKeyCStorClusterConfigAutoscalePoolCount string = AnnotationNamespace + "/autoscale-pool-count"
+
+	// AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince is the
+	// annotation set against a CStorClusterConfig by the older
+	// versions of pool autoscaler to refer to the time since when
+	// the utilization of pools is below the scale down threshold.
+	// This time is now persisted in the state of pool autoscaler &
+	// this annotation is removed once migrated.
+	AnnKeyCStorClusterConfigAutoscaleBelowThresholdSince string = AnnotationNamespace + "/autoscale-below-threshold-since"
+
+	// AnnKeyStateComponent is the annotation set against the
+	// ConfigMap that persists the state of an operator component for
+	// a CStorClusterConfig. Value is the name of this component.
+	AnnKeyStateComponent string = AnnotationNamespace + "/state-component"
+
+	// AnnKeyCStorClusterPlanNodesMissingSince is the annotation set
+	// against a CStorClusterPlan to refer to the time since when its
+	// planned nodes are no longer allowed. Value is a JSON object of
+	// node name to RFC3339 time.
+	AnnKeyCStorClusterPlanNodesMissingSince string = AnnotationNamespace + "/nodes-missing-since"
+
+	// AnnKeyCStorClusterPlanExplain is the annotation set against a
+	// CStorClusterPlan to explain the planning of its nodes. Value
+	// is a JSON form of CStorClusterPlanExplain.
+	AnnKeyCStorClusterPlanExplain string = AnnotationNamespace + "/plan-explain"
+
+	// AnnKeyCStorClusterConfigApproveRAIDTypeChange is the annotation
+	// set against a CStorClusterConfig to approve the change of raid
+	// type of its pools. Value is the raid type the pools should be
+	// changed to. Pools are re-created one node at a time once the
+	// value matches the raid type of the config.
+	AnnKeyCStorClusterConfigApproveRAIDTypeChange string = AnnotationNamespace + "/approve-raid-type-change"
+
+	// AnnKeyCStorPoolClusterOwner is the annotation set against a
+	// CStorPoolCluster to refer to the controller that owns its spec.
+	// Other controllers skip their syncs instead of applying this
+	// CStorPoolCluster.
+	AnnKeyCStorPoolClusterOwner string = AnnotationNamespace + "/cspc-owner"
+
+	// AnnKeyCStorClusterConfigApproveOwnerSwitch is the annotation set
+	// against a CStorClusterConfig to approve the switch of ownership
+	// of its CStorPoolCluster(s). Value is the controller that should
+	// own these CStorPoolCluster(s) after its disk config was switched.
+	AnnKeyCStorClusterConfigApproveOwnerSwitch string = AnnotationNamespace + "/approve-owner-switch"
+
+	// AnnKeyCStorClusterConfigCollectSupportBundle is the annotation
+	// set against a CStorClusterConfig to collect a support bundle.
+	// Value is any token e.g. a timestamp. A new bundle is collected
+	// whenever this token changes.
+	AnnKeyCStorClusterConfigCollectSupportBundle string = AnnotationNamespace + "/collect-support-bundle"
+
+	// AnnKeySupportBundleToken is the annotation set against the
+	// ConfigMap of a support bundle to refer to the token the bundle
+	// was collected for
+	AnnKeySupportBundleToken string = AnnotationNamespace + "/support-bundle-token"
+
+	// AnnKeyCStorPoolClusterPlacementHints is the annotation set
+	// against the ConfigMap that hints the preferred pool instances
+	// of a CStorPoolCluster. Value is the name of this CStorPoolCluster.
+	AnnKeyCStorPoolClusterPlacementHints string = AnnotationNamespace + "/placement-hints"
+
+	// AnnKeyCStorPoolClusterExport is the annotation set against the
+	// ConfigMap that exports a CStorPoolCluster as Helm values &
+	// Kustomize patch. Value is the name of this CStorPoolCluster.
+	AnnKeyCStorPoolClusterExport string = AnnotationNamespace + "/cspc-export"
+
+	// AnnKeySchemaVersion is the annotation set against the resources
+	// generated by this project e.g. CStorClusterPlan. It refers to
+	// the schema version these resources were generated with. This
+	// lets an upgraded operator migrate the older resources in place.
+	AnnKeySchemaVersion string = AnnotationNamespace + "/schema-version"
+
+	// SchemaVersionLegacy is the schema version of the resources that
+	// were generated before schema version annotation was introduced
+	SchemaVersionLegacy string = "1"
+
+	// SchemaVersionCurrent is the schema version of the resources
+	// generated by this release. Bump this whenever the shape of a
+	// generated resource changes.
+	SchemaVersionCurrent string = "2"
+
+	// AnnKeyLocalDeviceResolvedState is the annotation set against a
+	// CStorClusterConfig by the local device controller. It refers to
+	// the hash of the state this config was last synced with. Syncs
+	// that resolve to the same state are skipped.
+	AnnKeyLocalDeviceResolvedState string = AnnotationNamespace + "/localdevice-resolved-state"
+
+	// AnnKeyCStorClusterStorageSetResolvedState is the annotation
+	// set against a CStorClusterStorageSet. It refers to the hash of
+	// the state this storage set was last synced with. Syncs that
+	// resolve to the same state are skipped.
+	AnnKeyCStorClusterStorageSetResolvedState string = AnnotationNamespace + "/storageset-resolved-state"
+
+	// AnnKeyBlockDeviceSMARTStatus is the annotation set against a
+	// BlockDevice by SMART probes or burn-in jobs to report the health
+	// of the device. Supported values are Passed & Failed.
+	AnnKeyBlockDeviceSMARTStatus string = AnnotationNamespace + "/smart-status"
+
+	// BlockDeviceSMARTStatusPassed is the value of SMART status
+	// annotation of a healthy BlockDevice
+	BlockDeviceSMARTStatusPassed string = "Passed"
+
+	// BlockDeviceSMARTStatusFailed is the value of SMART status
+	// annotation of an unhealthy BlockDevice
+	BlockDeviceSMARTStatusFailed string = "Failed"
+
+	// LblKeyCStorPoolClusterName is the label set against a
+	// BlockDeviceClaim to refer to the CStorPoolCluster that uses
+	// the claimed BlockDevice
+	//
+	// NOTE:
+	//	This label is understood by cstor operator. cstor operator
+	// does not create a claim of its own if the claim with this label
+	// has already claimed the device.
+	LblKeyCStorPoolClusterName string = "openebs.io/cstor-pool-cluster"
+
+	// LblKeyCStorPoolClusterVersion is the label set against a
+	// CStorClusterConfig to refer to the version of CStorPoolCluster
+	// that gets built from local disks. CStorPoolCluster of
+	// openebs.io/v1alpha1 version is built if this label is not set.
+	LblKeyCStorPoolClusterVersion string = "cspc.openebs.io/version"
+
+	// LblKeyZone is the well known label set against a Node to refer
+	// to its topology zone. This is set against a CStorPoolCluster
+	// as well when pools are planned per zone.
+	LblKeyZone string = "topology.kubernetes.io/zone"
+
+	// LblKeyZoneDeprecated is the deprecated label that refers to
+	// the topology zone of a Node. This is used only if LblKeyZone
+	// is not set against the Node.
+	LblKeyZoneDeprecated string = "failure-domain.beta.kubernetes.io/zone"
+
+	// LblKeyNodeRoleControlPlane is the well known label as well as
+	// taint key set against the control plane Nodes
+	LblKeyNodeRoleControlPlane string = "node-role.kubernetes.io/control-plane"
+
+	// LblKeyNodeRoleMaster is the deprecated label as well as taint
+	// key set against the control plane Nodes
+	LblKeyNodeRoleMaster string = "node-role.kubernetes.io/master"
+
+	// LblKeyRetainedCStorClusterConfigUID is the label set against
+	// the PVCs that were retained after their node was removed from
+	// the plan. Its value is the UID of the CStorClusterConfig whose
+	// disks these PVCs were.
+	LblKeyRetainedCStorClusterConfigUID string = AnnotationNamespace + "/retained-cstorclusterconfig-uid"
+
+	// LblKeyPoolCStorClusterConfigUID is the label set against every
+	// BlockDevice selected for the pools of a CStorClusterConfig.
+	// Its value is the UID of this config.
+	//
+	// NOTE:
+	//	This is not the same as AnnKeyCStorClusterPlanUID label that
+	// is set only against the external BlockDevices of a plan.
+	LblKeyPoolCStorClusterConfigUID string = AnnotationNamespace + "/pool-config-uid"
+
+	// LblKeyPoolCStorClusterPlanUID is the label set against every
+	// selected BlockDevice to refer to the CStorClusterPlan of its node
+	LblKeyPoolCStorClusterPlanUID string = AnnotationNamespace + "/pool-plan-uid"
+
+	// LblKeyPoolNode is the label set against every selected
+	// BlockDevice to refer to the host name of its pool's node
+	LblKeyPoolNode string = AnnotationNamespace + "/pool-node"
+
+	// LblKeyPoolRole is the label set against every selected
+	// BlockDevice to refer to its role in the pool. Its value is
+	// either Data or Spare.
+	LblKeyPoolRole string = AnnotationNamespace + "/pool-role"
+
+	// PoolRoleData is the pool role of a BlockDevice that is used
+	// in a RAID group of a CStorPoolCluster
+	PoolRoleData string = "Data"
+
+	// PoolRoleSpare is the pool role of a BlockDevice that is claimed
+	// for a config but is not yet used in a CStorPoolCluster
+	PoolRoleSpare string = "Spare"
+
 	// StorageProvisionerAnnotationNamespace is the common namespace
 	// used across all the annotations supported in storage-provisioner project
 	StorageProvisionerAnnotationNamespace string = "storageprovisioner.dao.mayadata.io"
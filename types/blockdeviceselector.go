@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+)
+
+// BlockDeviceSelector selects the block devices that match any of
+// its terms
+type BlockDeviceSelector struct {
+	SelectorTerms []*BlockDeviceSelectorTerm `json:"selectorTerms"`
+}
+
+// BlockDeviceSelectorTerm extends metac's selector term with the
+// operators that are specific to block devices. All requirements of
+// a term are AND-ed.
+type BlockDeviceSelectorTerm struct {
+	metac.SelectorTerm `json:",inline"`
+
+	// MatchExpressions is a list of field requirements whose keys
+	// are the dot separated paths of block device fields e.g.
+	// spec.details.deviceType. Supported operators are In, NotIn,
+	// Exists & DoesNotExist. Unlike matchFieldExpressions, values of
+	// these fields need not be strings.
+	//
+	// This is optional
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+
+	// MatchNumericRange is a list of numeric range requirements e.g.
+	// spec.capacity.storage between 100Gi & 1Ti
+	//
+	// This is optional
+	MatchNumericRange []NumericRangeRequirement `json:"matchNumericRange,omitempty"`
+}
+
+// NumericRangeRequirement matches the block devices whose numeric
+// field is within the given bounds. Bounds are inclusive.
+//
+// NOTE:
+//	Devices without this field or with a non numeric value for this
+// field do not match.
+type NumericRangeRequirement struct {
+	// Key is the dot separated path of the field e.g.
+	// spec.capacity.logicalSectorSize
+	Key string `json:"key"`
+
+	// Min value of the field. No lower bound is applied if nil.
+	Min *resource.Quantity `json:"min,omitempty"`
+
+	// Max value of the field. No upper bound is applied if nil.
+	Max *resource.Quantity `json:"max,omitempty"`
+}
+
+// NewBlockDeviceSelector returns a block device selector with the
+// terms of the given metac selector
+func NewBlockDeviceSelector(selector metac.ResourceSelector) BlockDeviceSelector {
+	var terms []*BlockDeviceSelectorTerm
+	for _, term := range selector.SelectorTerms {
+		if term == nil {
+			continue
+		}
+		terms = append(terms, &BlockDeviceSelectorTerm{SelectorTerm: *term})
+	}
+	return BlockDeviceSelector{SelectorTerms: terms}
+}
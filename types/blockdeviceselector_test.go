@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+)
+
+func TestBlockDeviceSelectorUnmarshal(t *testing.T) {
+	raw := `{
+		"selectorTerms": [{
+			"matchLabels": {"kubernetes.io/hostname": "node-1"},
+			"matchExpressions": [{
+				"key": "spec.details.deviceType",
+				"operator": "In",
+				"values": ["disk"]
+			}],
+			"matchNumericRange": [{
+				"key": "spec.capacity.storage",
+				"min": "100Gi"
+			}]
+		}]
+	}`
+	var got BlockDeviceSelector
+	err := json.Unmarshal([]byte(raw), &got)
+	if err != nil {
+		t.Fatalf("Expected no error got [%+v]", err)
+	}
+	min := resource.MustParse("100Gi")
+	expect := BlockDeviceSelector{
+		SelectorTerms: []*BlockDeviceSelectorTerm{
+			{
+				SelectorTerm: metac.SelectorTerm{
+					MatchLabels: map[string]string{
+						"kubernetes.io/hostname": "node-1",
+					},
+				},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "spec.details.deviceType",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"disk"},
+					},
+				},
+				MatchNumericRange: []NumericRangeRequirement{
+					{Key: "spec.capacity.storage", Min: &min},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+	if diff := cmp.Diff(expect, *got.DeepCopy()); diff != "" {
+		t.Fatalf("Expected no diff in deep copy got\n%s", diff)
+	}
+}
+
+func TestNewBlockDeviceSelector(t *testing.T) {
+	got := NewBlockDeviceSelector(metac.ResourceSelector{
+		SelectorTerms: []*metac.SelectorTerm{
+			nil,
+			{
+				MatchFields: map[string]string{"spec.path": "/dev/sdb"},
+			},
+		},
+	})
+	expect := BlockDeviceSelector{
+		SelectorTerms: []*BlockDeviceSelectorTerm{
+			{
+				SelectorTerm: metac.SelectorTerm{
+					MatchFields: map[string]string{"spec.path": "/dev/sdb"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Fatalf("Expected no diff got\n%s", diff)
+	}
+}
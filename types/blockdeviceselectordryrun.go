@@ -0,0 +1,122 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BlockDeviceSelectorDryRun is a kubernetes custom resource that
+// evaluates a block device selector against the block devices of
+// the cluster & reports the devices that it matches right now.
+//
+// NOTE:
+//	Selectors can be iterated on safely via this resource before
+// these are set in a CStorClusterConfig. Nothing is claimed or
+// created by a dry run.
+//
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=blockdeviceselectordryruns,singular=blockdeviceselectordryrun,shortName=bdsdryrun,scope=Namespaced
+type BlockDeviceSelectorDryRun struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec   BlockDeviceSelectorDryRunSpec   `json:"spec"`
+	Status BlockDeviceSelectorDryRunStatus `json:"status"`
+}
+
+// BlockDeviceSelectorDryRunList is a list of
+// BlockDeviceSelectorDryRun resources
+//
+// +kubebuilder:object:root=true
+type BlockDeviceSelectorDryRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []BlockDeviceSelectorDryRun `json:"items"`
+}
+
+// BlockDeviceSelectorDryRunSpec has the selector to be evaluated.
+// These fields have the same meaning as the ones of the local disk
+// config of a CStorClusterConfig.
+type BlockDeviceSelectorDryRunSpec struct {
+	// BlockDeviceSelector selects the block devices that match any
+	// of its terms
+	BlockDeviceSelector BlockDeviceSelector `json:"blockDeviceSelector"`
+
+	// BlockDeviceExclude drops the selected block devices that match
+	// any of its terms
+	//
+	// This is optional
+	BlockDeviceExclude *BlockDeviceSelector `json:"blockDeviceExclude,omitempty"`
+}
+
+// BlockDeviceSelectorDryRunStatus has the block devices matched by
+// the selector
+type BlockDeviceSelectorDryRunStatus struct {
+	Phase BlockDeviceSelectorDryRunStatusPhase `json:"phase,omitempty"`
+
+	// Reason explains the phase if it is not Online
+	Reason string `json:"reason,omitempty"`
+
+	// ObservedGeneration is the generation of this dry run that was
+	// last evaluated
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// MatchCount is the number of block devices that are matched
+	MatchCount int `json:"matchCount"`
+
+	// Nodes have the matched block devices grouped by the hostname
+	// of their nodes & are sorted by hostname
+	Nodes []BlockDeviceSelectorDryRunNode `json:"nodes,omitempty"`
+
+	// BlockDeviceSelectionReport explains why the selector matched
+	// no block devices
+	BlockDeviceSelectionReport []string `json:"blockDeviceSelectionReport,omitempty"`
+}
+
+// BlockDeviceSelectorDryRunNode has the matched block devices of
+// a node
+type BlockDeviceSelectorDryRunNode struct {
+	HostName string `json:"hostName"`
+
+	// BlockDevices are sorted by name
+	BlockDevices []BlockDeviceSelectorDryRunDevice `json:"blockDevices"`
+}
+
+// BlockDeviceSelectorDryRunDevice has the details of a matched
+// block device
+type BlockDeviceSelectorDryRunDevice struct {
+	Name     string            `json:"name"`
+	Path     string            `json:"path,omitempty"`
+	Capacity resource.Quantity `json:"capacity"`
+}
+
+// BlockDeviceSelectorDryRunStatusPhase reports the phase of a dry run
+type BlockDeviceSelectorDryRunStatusPhase string
+
+const (
+	// BlockDeviceSelectorDryRunStatusPhaseError indicates the
+	// selector could not be evaluated e.g. an invalid selector
+	BlockDeviceSelectorDryRunStatusPhaseError BlockDeviceSelectorDryRunStatusPhase = "Error"
+
+	// BlockDeviceSelectorDryRunStatusPhaseOnline indicates the
+	// selector was evaluated
+	BlockDeviceSelectorDryRunStatusPhaseOnline BlockDeviceSelectorDryRunStatusPhase = "Online"
+)
@@ -0,0 +1,438 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	apiservervalidation "k8s.io/apiextensions-apiserver/pkg/apiserver/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/json"
+	k8s "openebs.io/metac/third_party/kubernetes"
+)
+
+// generatedCRDPath is the path to the CRDs generated from this
+// package
+const generatedCRDPath = "../deploy/crd.yaml"
+
+// loadGeneratedCRDSchemas returns the openapi schema of v1alpha1
+// version of every generated CRD mapped by its kind
+func loadGeneratedCRDSchemas(t *testing.T) map[string]*apiextensions.JSONSchemaProps {
+	return loadGeneratedCRDSchemasOfVersion(t, VersionV1Alpha1)
+}
+
+// loadGeneratedCRDSchemasOfVersion returns the openapi schema of the
+// given version of every generated CRD mapped by its kind. CRDs that
+// do not serve the given version are skipped.
+func loadGeneratedCRDSchemasOfVersion(
+	t *testing.T, version string,
+) map[string]*apiextensions.JSONSchemaProps {
+	contents, err := ioutil.ReadFile(generatedCRDPath)
+	if err != nil {
+		t.Fatalf("Can't read CRDs: %+v", err)
+	}
+	objs, err := k8s.YAMLToUnstructuredSlice(contents)
+	if err != nil {
+		t.Fatalf("Can't load CRDs: %+v", err)
+	}
+	schemas := map[string]*apiextensions.JSONSchemaProps{}
+	for _, obj := range objs {
+		// json is used since minimum is a float in CRD schema
+		raw, err := obj.MarshalJSON()
+		if err != nil {
+			t.Fatalf("Can't marshal CRD %s: %+v", obj.GetName(), err)
+		}
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		err = json.Unmarshal(raw, crd)
+		if err != nil {
+			t.Fatalf("Can't convert CRD %s: %+v", obj.GetName(), err)
+		}
+		var crdVersion *apiextensionsv1.CustomResourceDefinitionVersion
+		var storageVersionCount int
+		for idx := range crd.Spec.Versions {
+			if crd.Spec.Versions[idx].Storage {
+				storageVersionCount++
+			}
+			if crd.Spec.Versions[idx].Name == version {
+				crdVersion = &crd.Spec.Versions[idx]
+			}
+		}
+		if storageVersionCount != 1 {
+			t.Fatalf(
+				"Expected one storage version for CRD %s got %d",
+				crd.GetName(), storageVersionCount,
+			)
+		}
+		if crdVersion == nil {
+			continue
+		}
+		if crdVersion.Schema == nil {
+			t.Fatalf("Expected schema for CRD %s %s", crd.GetName(), version)
+		}
+		schema := &apiextensions.JSONSchemaProps{}
+		err = apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(
+			crdVersion.Schema.OpenAPIV3Schema, schema, nil,
+		)
+		if err != nil {
+			t.Fatalf("Can't convert schema of CRD %s: %+v", crd.GetName(), err)
+		}
+		schemas[crd.Spec.Names.Kind] = schema
+	}
+	return schemas
+}
+
+func TestGeneratedCRDsAreStructural(t *testing.T) {
+	schemas := loadGeneratedCRDSchemas(t)
+	for _, kind := range []Kind{
+		KindCStorClusterConfig,
+		KindCStorClusterPlan,
+		KindCStorClusterPlanRevision,
+		KindCStorClusterStorageSet,
+		KindCStorPoolAutoPolicy,
+		KindCStorPoolAutoInventory,
+		KindBlockDeviceSelectorDryRun,
+	} {
+		schema, found := schemas[string(kind)]
+		if !found {
+			t.Fatalf("Expected CRD for kind %q got none", kind)
+		}
+		structural, err := structuralschema.NewStructural(schema)
+		if err != nil {
+			t.Fatalf("Expected structural schema for kind %q: %+v", kind, err)
+		}
+		if errs := structuralschema.ValidateStructural(nil, structural); len(errs) != 0 {
+			t.Fatalf("Expected structural schema for kind %q: %+v", kind, errs)
+		}
+	}
+}
+
+func TestGeneratedCStorClusterConfigCRDValidation(t *testing.T) {
+	schema := loadGeneratedCRDSchemas(t)[string(KindCStorClusterConfig)]
+	validator, _, err := apiservervalidation.NewSchemaValidator(
+		&apiextensions.CustomResourceValidation{OpenAPIV3Schema: schema},
+	)
+	if err != nil {
+		t.Fatalf("Can't build validator: %+v", err)
+	}
+	var tests = map[string]struct {
+		spec  map[string]interface{}
+		isErr bool
+	}{
+		"empty spec": {
+			spec: map[string]interface{}{},
+		},
+		"external disks with mirror raid type": {
+			spec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"external": map[string]interface{}{
+						"csiAttacherName":  "pd.csi.storage.gke.io",
+						"storageClassName": "csi-gce-pd",
+					},
+				},
+				"poolConfig": map[string]interface{}{
+					"raidType": "mirror",
+				},
+			},
+		},
+		"local disks with partial selector & reserve": {
+			spec: map[string]interface{}{
+				"minPoolCount": "3",
+				"maxPoolCount": 5,
+				"diskConfig": map[string]interface{}{
+					"minCapacity":    "100Gi",
+					"reservePerNode": "20%",
+					"local": map[string]interface{}{
+						"blockDeviceSelector": map[string]interface{}{
+							"selectorTerms": []interface{}{
+								map[string]interface{}{
+									"matchLabels": map[string]interface{}{
+										"mirror-pool": "mysql",
+									},
+								},
+							},
+						},
+					},
+				},
+				"driftPolicy": "Warn",
+			},
+		},
+		"unknown raid type": {
+			spec: map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"raidType": "raid5",
+				},
+			},
+			isErr: true,
+		},
+		"zfs properties": {
+			spec: map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"zfsProperties": map[string]interface{}{
+						"compression": "lz4",
+						"recordsize":  "128K",
+					},
+				},
+			},
+		},
+		"zfs property with invalid value": {
+			spec: map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"zfsProperties": map[string]interface{}{
+						"mountpoint": "/data",
+					},
+				},
+			},
+			isErr: true,
+		},
+		"custom node selector key": {
+			spec: map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"nodeSelectorKey": "topology.gke.io/node",
+				},
+			},
+		},
+		"change budget": {
+			spec: map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"changeBudget": map[string]interface{}{
+						"maxAddPerHour":    int64(2),
+						"maxRemovePerHour": int64(1),
+					},
+				},
+			},
+		},
+		"negative change budget": {
+			spec: map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"changeBudget": map[string]interface{}{
+						"maxRemovePerHour": int64(-1),
+					},
+				},
+			},
+			isErr: true,
+		},
+		"hybrid disks with node overrides": {
+			spec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"external": map[string]interface{}{
+						"csiAttacherName":  "ebs.csi.aws.com",
+						"storageClassName": "gp2",
+					},
+					"local": map[string]interface{}{
+						"selectAll": true,
+					},
+					"nodeOverrides": []interface{}{
+						map[string]interface{}{
+							"nodeSelector": map[string]interface{}{
+								"selectorTerms": []interface{}{
+									map[string]interface{}{
+										"matchLabels": map[string]interface{}{
+											"disk-type": "nvme",
+										},
+									},
+								},
+							},
+							"source": "Local",
+						},
+					},
+				},
+			},
+		},
+		"node override with unknown source": {
+			spec: map[string]interface{}{
+				"diskConfig": map[string]interface{}{
+					"nodeOverrides": []interface{}{
+						map[string]interface{}{
+							"nodeSelector": map[string]interface{}{},
+							"source":       "Cloud",
+						},
+					},
+				},
+			},
+			isErr: true,
+		},
+		"export output mode": {
+			spec: map[string]interface{}{
+				"outputMode": "Export",
+			},
+		},
+		"unknown output mode": {
+			spec: map[string]interface{}{
+				"outputMode": "Print",
+			},
+			isErr: true,
+		},
+		"unknown drift policy": {
+			spec: map[string]interface{}{
+				"driftPolicy": "Revert",
+			},
+			isErr: true,
+		},
+		"invalid min pool count": {
+			spec: map[string]interface{}{
+				"minPoolCount": "three",
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "dao.mayadata.io/v1alpha1",
+					"kind":       string(KindCStorClusterConfig),
+					"metadata": map[string]interface{}{
+						"name":      "my-cstor-cluster",
+						"namespace": "openebs",
+					},
+					"spec": mock.spec,
+				},
+			}
+			errs := apiservervalidation.ValidateCustomResource(nil, obj.Object, validator)
+			if mock.isErr && len(errs) == 0 {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && len(errs) != 0 {
+				t.Fatalf("Expected no error got [%+v]", errs)
+			}
+		})
+	}
+}
+
+func TestGeneratedCStorClusterConfigCRDValidationOfTypedObject(t *testing.T) {
+	schema := loadGeneratedCRDSchemas(t)[string(KindCStorClusterConfig)]
+	validator, _, err := apiservervalidation.NewSchemaValidator(
+		&apiextensions.CustomResourceValidation{OpenAPIV3Schema: schema},
+	)
+	if err != nil {
+		t.Fatalf("Can't build validator: %+v", err)
+	}
+	// typed object is marshaled the way clientset does
+	raw, err := json.Marshal(&CStorClusterConfig{
+		Spec: CStorClusterConfigSpec{
+			PoolConfig: PoolConfig{
+				RAIDType: PoolRAIDTypeMirror,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Can't marshal: %+v", err)
+	}
+	obj := map[string]interface{}{}
+	err = json.Unmarshal(raw, &obj)
+	if err != nil {
+		t.Fatalf("Can't unmarshal: %+v", err)
+	}
+	errs := apiservervalidation.ValidateCustomResource(nil, obj, validator)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no error got [%+v]", errs)
+	}
+}
+
+func TestGeneratedCStorClusterConfigV1Beta1CRDValidation(t *testing.T) {
+	schema, found := loadGeneratedCRDSchemasOfVersion(
+		t, VersionV1Beta1,
+	)[string(KindCStorClusterConfig)]
+	if !found {
+		t.Fatalf("Expected v1beta1 CRD for kind %q got none", KindCStorClusterConfig)
+	}
+	structural, err := structuralschema.NewStructural(schema)
+	if err != nil {
+		t.Fatalf("Expected structural schema: %+v", err)
+	}
+	if errs := structuralschema.ValidateStructural(nil, structural); len(errs) != 0 {
+		t.Fatalf("Expected structural schema: %+v", errs)
+	}
+	validator, _, err := apiservervalidation.NewSchemaValidator(
+		&apiextensions.CustomResourceValidation{OpenAPIV3Schema: schema},
+	)
+	if err != nil {
+		t.Fatalf("Can't build validator: %+v", err)
+	}
+	var tests = map[string]struct {
+		spec  map[string]interface{}
+		isErr bool
+	}{
+		"empty spec": {
+			spec: map[string]interface{}{},
+		},
+		"grouped spec": {
+			spec: map[string]interface{}{
+				"pools": map[string]interface{}{
+					"minCount": 3,
+					"maxCount": "5",
+					"config": map[string]interface{}{
+						"raidType": "mirror",
+					},
+					"driftPolicy": "Warn",
+				},
+				"disks": map[string]interface{}{
+					"minCapacity": "100Gi",
+				},
+				"children": map[string]interface{}{
+					"targetNamespace": "storage",
+				},
+			},
+		},
+		"unknown raid type": {
+			spec: map[string]interface{}{
+				"pools": map[string]interface{}{
+					"config": map[string]interface{}{
+						"raidType": "raid5",
+					},
+				},
+			},
+			isErr: true,
+		},
+		"invalid min count": {
+			spec: map[string]interface{}{
+				"pools": map[string]interface{}{
+					"minCount": "three",
+				},
+			},
+			isErr: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			obj := map[string]interface{}{
+				"apiVersion": APIVersionDAOMayaDataV1Beta1,
+				"kind":       string(KindCStorClusterConfig),
+				"metadata": map[string]interface{}{
+					"name":      "my-cstor-cluster",
+					"namespace": "openebs",
+				},
+				"spec": mock.spec,
+			}
+			errs := apiservervalidation.ValidateCustomResource(nil, obj, validator)
+			if mock.isErr && len(errs) == 0 {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && len(errs) != 0 {
+				t.Fatalf("Expected no error got [%+v]", errs)
+			}
+		})
+	}
+}
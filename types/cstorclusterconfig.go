@@ -17,8 +17,12 @@ limitations under the License.
 package types
 
 import (
+	"time"
+
+	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
 )
@@ -42,22 +46,549 @@ type ResourceMap map[ResourceCategory]resource.Quantity
 //
 // NOTE:
 // 	This is a user facing custom resource
+//
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:storageversion
+// +kubebuilder:resource:path=cstorclusterconfigs,singular=cstorclusterconfig,shortName=cscconfig,scope=Namespaced
 type CStorClusterConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
-	Spec   CStorClusterConfigSpec   `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec CStorClusterConfigSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
 	Status CStorClusterConfigStatus `json:"status"`
 }
 
+// CStorClusterConfigList is a list of CStorClusterConfig resources
+//
+// +kubebuilder:object:root=true
+type CStorClusterConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CStorClusterConfig `json:"items"`
+}
+
 // CStorClusterConfigSpec defines the configuration required
 // to setup and manage cstor pool cluster
 type CStorClusterConfigSpec struct {
-	MinPoolCount resource.Quantity      `json:"minPoolCount"`
-	MaxPoolCount resource.Quantity      `json:"maxPoolCount"`
-	AllowedNodes metac.ResourceSelector `json:"allowedNodes"`
-	DiskConfig   DiskConfig             `json:"diskConfig"`
-	PoolConfig   PoolConfig             `json:"poolConfig"`
+	MinPoolCount resource.Quantity `json:"minPoolCount"`
+	MaxPoolCount resource.Quantity `json:"maxPoolCount"`
+
+	// NOTE:
+	//	Selectors are owned by metac & are validated by metac
+	// while selecting the resources. Hence these are not part
+	// of generated CRD schema.
+	//
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	AllowedNodes AllowedNodes `json:"allowedNodes"`
+
+	// AllowControlPlaneNodes when true lets the pools be planned on
+	// the control plane nodes i.e. the nodes with label or taint
+	// node-role.kubernetes.io/control-plane or master. Only worker
+	// nodes are planned if this is not set.
+	AllowControlPlaneNodes bool `json:"allowControlPlaneNodes,omitempty"`
+
+	DiskConfig DiskConfig `json:"diskConfig"`
+	PoolConfig PoolConfig `json:"poolConfig"`
+
+	// ChildMetadata has the labels & annotations that get
+	// propagated to every resource created due to this config
+	ChildMetadata *ChildMetadata `json:"childMetadata,omitempty"`
+
+	// DriftPolicy decides how manual edits to the pools of the
+	// generated CStorPoolCluster are handled. Defaults to Enforce.
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// Autoscale lets the pool count be scaled based on the
+	// utilization of pools. Pool count is bounded by min & max
+	// pool counts.
+	Autoscale *Autoscale `json:"autoscale,omitempty"`
+
+	// TargetNamespace is the namespace where CStorPoolCluster &
+	// Storage(s) of this config are created. Defaults to openebs.
+	//
+	// NOTE:
+	//	Children that were already created are never moved to a
+	// different namespace
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// Remediation lets the pool instances that stay offline or
+	// degraded be reported & optionally rebuilt. Pool instances are
+	// not remediated if this is not set.
+	Remediation *Remediation `json:"remediation,omitempty"`
+
+	// Rebalance lets the raid group count skew across the pool
+	// instances be detected. Skew is not checked if this is not set.
+	Rebalance *Rebalance `json:"rebalance,omitempty"`
+
+	// PlacementHints lets the pool instances with the most free
+	// capacity be hinted for the replicas of new volumes when the
+	// pool instances are imbalanced in free capacity. Hints are not
+	// emitted if this is not set.
+	PlacementHints *PlacementHints `json:"placementHints,omitempty"`
+
+	// StorageClass lets a cStor CSI StorageClass be created for the
+	// CStorPoolCluster of this config. StorageClass is not created
+	// if this is not set.
+	StorageClass *StorageClass `json:"storageClass,omitempty"`
+
+	// Naming lets the names of CStorClusterPlan, CStorPoolCluster &
+	// StorageClass of this config be customised. These children are
+	// named after this config if this is not set.
+	Naming *Naming `json:"naming,omitempty"`
+
+	// OutputMode decides if the generated CStorPoolCluster is applied
+	// or is exported into a ConfigMap as Helm values & Kustomize patch.
+	// Defaults to Apply.
+	OutputMode OutputMode `json:"outputMode,omitempty"`
+
+	// Reconcile lets some fields of the generated CStorPoolCluster
+	// be owned by the users. These fields are never overwritten.
+	Reconcile *Reconcile `json:"reconcile,omitempty"`
+
+	// Overlays are the patches applied to the generated children
+	// of this config. These let the fields that are not modelled by
+	// this config be set against the children.
+	Overlays []Overlay `json:"overlays,omitempty"`
+}
+
+// DefaultTargetNamespace is the namespace where the children of
+// CStorClusterConfig are created if its target namespace is not set
+const DefaultTargetNamespace string = "openebs"
+
+// Autoscale provides options to scale the pool count based on
+// the utilization of pools
+//
+// NOTE:
+//	Utilization is the percentage of used capacity w.r.t the total
+// capacity of all the pool instances of this config
+type Autoscale struct {
+	// ScaleUpThreshold is the utilization percentage at or above
+	// which a pool gets added on a new node
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	ScaleUpThreshold int64 `json:"scaleUpThreshold"`
+
+	// ScaleDownThreshold is the utilization percentage below which
+	// a pool gets removed. Scale down is disabled if this is not set.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	ScaleDownThreshold int64 `json:"scaleDownThreshold,omitempty"`
+
+	// ScaleDownStabilizationSeconds is the duration for which the
+	// utilization needs to stay below ScaleDownThreshold before a
+	// pool gets removed. Defaults to 3600.
+	ScaleDownStabilizationSeconds int64 `json:"scaleDownStabilizationSeconds,omitempty"`
+}
+
+// Remediation provides options to remediate the pool instances
+// that stay offline or degraded
+type Remediation struct {
+	// Timeout is the duration for which a pool instance needs to
+	// stay offline or degraded before it gets remediated. Defaults
+	// to 15m.
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Policy decides how an unhealthy pool instance is remediated.
+	// Defaults to Report.
+	Policy RemediationPolicy `json:"policy,omitempty"`
+}
+
+// DefaultRemediationTimeout is the duration after which an unhealthy
+// pool instance is remediated if no timeout was configured
+var DefaultRemediationTimeout = metav1.Duration{Duration: 15 * time.Minute}
+
+// RemediationPolicy represents the remediation of an unhealthy pool
+// instance
+//
+// +kubebuilder:validation:Enum=Report;Rebuild
+type RemediationPolicy string
+
+const (
+	// RemediationPolicyReport reports the unhealthy pool instance
+	// via events & Degraded condition of CStorClusterPlan
+	RemediationPolicyReport RemediationPolicy = "Report"
+
+	// RemediationPolicyRebuild reports the unhealthy pool instance
+	// & replaces the block devices of its pool with the spare block
+	// devices of its node
+	RemediationPolicyRebuild RemediationPolicy = "Rebuild"
+
+	// RemediationPolicyDefault represents the default remediation
+	// policy
+	RemediationPolicyDefault RemediationPolicy = RemediationPolicyReport
+)
+
+// SupportedRemediationPolicies lists the supported remediation
+// policies
+var SupportedRemediationPolicies = map[RemediationPolicy]bool{
+	RemediationPolicyReport:  true,
+	RemediationPolicyRebuild: true,
+}
+
+// Rebalance provides options to detect the pool instances whose
+// raid group counts skew from each other e.g. when block devices
+// get added to a few nodes only
+type Rebalance struct {
+	// MaxRAIDGroupSkew is the max difference allowed between the
+	// raid group counts of any two pool instances. Defaults to 1.
+	//
+	// +kubebuilder:validation:Minimum=1
+	MaxRAIDGroupSkew int64 `json:"maxRaidGroupSkew,omitempty"`
+
+	// Policy decides how a skew beyond MaxRAIDGroupSkew is handled.
+	// Defaults to Recommend.
+	Policy RebalancePolicy `json:"policy,omitempty"`
+}
+
+// PlacementHints provides options to hint the pool instances that
+// should be preferred for the replicas of new volumes
+//
+// NOTE:
+//	Hints are written into a ConfigMap per CStorPoolCluster to be
+// consumed by cStor CSI or by the tooling that places the replicas
+type PlacementHints struct {
+	// MaxFreeCapacitySkew is the max difference allowed between the
+	// free capacity percentages of any two pool instances. Pool
+	// instances are imbalanced beyond this skew. Defaults to 20.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MaxFreeCapacitySkew int64 `json:"maxFreeCapacitySkew,omitempty"`
+}
+
+// Naming provides options to derive the names of the children of
+// a CStorClusterConfig
+//
+// NOTE:
+//	Names are formed as <prefix>-<config name>-<namespace hash>-<zone>-<suffix>
+// where the absent elements are dropped. Names longer than 63
+// characters get their config name shortened & suffixed with a hash
+// of the full name.
+//
+// NOTE:
+//	Children that were already created retain their names since a
+// rename would delete & re-create them
+type Naming struct {
+	// Prefix is prepended to the names of the children
+	Prefix string `json:"prefix,omitempty"`
+
+	// Suffix is appended to the names of the children
+	Suffix string `json:"suffix,omitempty"`
+
+	// IncludeNamespaceHash when true adds a hash of the namespace
+	// of CStorClusterConfig to the names of the children. This
+	// avoids collisions between configs of same name that are in
+	// different namespaces.
+	IncludeNamespaceHash bool `json:"includeNamespaceHash,omitempty"`
+}
+
+// StorageClass provides options to create a cStor CSI StorageClass
+// that refers to the CStorPoolCluster of a CStorClusterConfig
+//
+// NOTE:
+//	Volumes of this StorageClass get their replicas on the pools
+// of this CStorPoolCluster. Hence replica count never exceeds the
+// pool count.
+type StorageClass struct {
+	// Create when true creates the StorageClass
+	//
+	// +kubebuilder:validation:Required
+	Create bool `json:"create"`
+
+	// Name of the StorageClass. Defaults to the name of the
+	// CStorClusterConfig as per its naming options.
+	Name string `json:"name,omitempty"`
+
+	// ReplicaCount is the number of replicas of every volume.
+	// Defaults to 3. This is capped at the pool count.
+	//
+	// +kubebuilder:validation:Minimum=1
+	ReplicaCount int64 `json:"replicaCount,omitempty"`
+
+	// Parameters are the additional parameters of the StorageClass.
+	// Parameters that refer to the CStorPoolCluster & replica count
+	// are set by this operator & can't be overridden.
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// DefaultStorageClassReplicaCount is the replica count of volumes
+// if no replica count was configured
+const DefaultStorageClassReplicaCount int64 = 3
+
+// DefaultMaxFreeCapacitySkew is the free capacity skew in percent
+// allowed if no max free capacity skew is set
+const DefaultMaxFreeCapacitySkew int64 = 20
+
+// DefaultMaxRAIDGroupSkew is the raid group skew allowed if no max
+// skew was configured
+const DefaultMaxRAIDGroupSkew int64 = 1
+
+// RebalancePolicy represents the handling of raid group count skew
+// across the pool instances
+//
+// +kubebuilder:validation:Enum=Recommend;Refuse
+type RebalancePolicy string
+
+const (
+	// RebalancePolicyRecommend reports the skew & the suggested
+	// block device moves via RebalanceRecommended condition
+	RebalancePolicyRecommend RebalancePolicy = "Recommend"
+
+	// RebalancePolicyRefuse reports the skew like Recommend & does
+	// not add the raid groups that cause this skew
+	RebalancePolicyRefuse RebalancePolicy = "Refuse"
+
+	// RebalancePolicyDefault represents the default rebalance policy
+	RebalancePolicyDefault RebalancePolicy = RebalancePolicyRecommend
+)
+
+// SupportedRebalancePolicies lists the supported rebalance policies
+var SupportedRebalancePolicies = map[RebalancePolicy]bool{
+	RebalancePolicyRecommend: true,
+	RebalancePolicyRefuse:    true,
+}
+
+// DriftPolicy represents the handling of manual edits made to
+// the pools of the generated CStorPoolCluster
+//
+// +kubebuilder:validation:Enum=Enforce;Warn;Ignore
+type DriftPolicy string
+
+const (
+	// DriftPolicyEnforce reverts the manual edits
+	DriftPolicyEnforce DriftPolicy = "Enforce"
+
+	// DriftPolicyWarn retains the manual edits & reports these
+	// edits via DriftDetected condition
+	DriftPolicyWarn DriftPolicy = "Warn"
+
+	// DriftPolicyIgnore stops managing the pools once the
+	// CStorPoolCluster is created
+	DriftPolicyIgnore DriftPolicy = "Ignore"
+
+	// DriftPolicyDefault represents the default drift policy
+	DriftPolicyDefault DriftPolicy = DriftPolicyEnforce
+)
+
+// SupportedDriftPolicies lists the supported drift policies
+var SupportedDriftPolicies = map[DriftPolicy]bool{
+	DriftPolicyEnforce: true,
+	DriftPolicyWarn:    true,
+	DriftPolicyIgnore:  true,
+}
+
+// OutputMode represents how the generated CStorPoolCluster is
+// delivered to the cluster
+//
+// +kubebuilder:validation:Enum=Apply;Export
+type OutputMode string
+
+const (
+	// OutputModeApply creates & updates the CStorPoolCluster
+	OutputModeApply OutputMode = "Apply"
+
+	// OutputModeExport writes the CStorPoolCluster into a ConfigMap
+	// formatted as Helm values & Kustomize patch. CStorPoolCluster
+	// is applied by the team's own tooling e.g. GitOps.
+	//
+	// NOTE:
+	//	CStorPoolCluster that was already applied is retained as is
+	OutputModeExport OutputMode = "Export"
+
+	// OutputModeDefault represents the default output mode
+	OutputModeDefault OutputMode = OutputModeApply
+)
+
+// SupportedOutputModes lists the supported output modes
+var SupportedOutputModes = map[OutputMode]bool{
+	OutputModeApply:  true,
+	OutputModeExport: true,
+}
+
+// AllowedNodes selects the nodes eligible to form the pools
+//
+// NOTE:
+//	Nodes can be selected by selector terms, by a machine pool or by
+// both. Selector terms are applied to the nodes of the machine pool
+// if both are set.
+type AllowedNodes struct {
+	metac.ResourceSelector `json:",inline"`
+
+	// MachinePool when set allows only the nodes of the machines of
+	// this MachineSet or MachinePool
+	MachinePool *MachinePoolReference `json:"machinePool,omitempty"`
+}
+
+// MachinePoolKind represents the kind of machine resource that
+// forms the boundary of planned nodes
+type MachinePoolKind string
+
+const (
+	// MachinePoolKindMachineSet refers to a MachineSet e.g. of
+	// OpenShift. Its nodes are the ones referred to by the Machines
+	// owned by this MachineSet.
+	MachinePoolKindMachineSet MachinePoolKind = "MachineSet"
+
+	// MachinePoolKindMachinePool refers to a MachinePool e.g. of
+	// Cluster API. Its nodes are the ones referred to by its status.
+	MachinePoolKindMachinePool MachinePoolKind = "MachinePool"
+)
+
+// SupportedMachinePoolKinds is the set of machine resources that
+// can be referred to by AllowedNodes
+var SupportedMachinePoolKinds = map[MachinePoolKind]bool{
+	MachinePoolKindMachineSet:  true,
+	MachinePoolKindMachinePool: true,
+}
+
+// MachinePoolReference refers to a MachineSet or a MachinePool by
+// its GVK & name
+type MachinePoolReference struct {
+	// APIVersion of the machine resource e.g.
+	// machine.openshift.io/v1beta1
+	APIVersion string `json:"apiVersion"`
+
+	Kind MachinePoolKind `json:"kind"`
+	Name string          `json:"name"`
+
+	// Namespace of the machine resource; machine resources of any
+	// namespace with this name are considered if this is not set
+	Namespace string `json:"namespace,omitempty"`
+
+	// FollowReplicas when true keeps the pool count in sync with
+	// the replica count of the machine resource. Pool count stays
+	// bounded by min & max pool counts.
+	FollowReplicas bool `json:"followReplicas,omitempty"`
+}
+
+// Reconcile provides the options to tune the reconciliation of the
+// generated CStorPoolCluster
+type Reconcile struct {
+	// IgnoreFields are the field paths of CStorPoolCluster whose
+	// observed values are retained e.g.
+	// spec.pools[*].poolConfig.resources
+	//
+	// NOTE:
+	//	Structural fields i.e. node selectors, raid groups & raid
+	// types of the pools can't be ignored
+	IgnoreFields []string `json:"ignoreFields,omitempty"`
+}
+
+// Overlay is a patch that is applied to every generated child of
+// the target kind
+//
+// NOTE:
+//	Overlays are applied after this operator builds the child &
+// hence win over the fields set by this operator. Name, namespace,
+// kind & apiVersion of the child can't be patched.
+type Overlay struct {
+	// TargetKind is the kind of the children that get patched
+	//
+	// +kubebuilder:validation:Required
+	TargetKind OverlayTargetKind `json:"targetKind"`
+
+	// PatchType decides how the patch is applied. Defaults to
+	// strategic.
+	PatchType OverlayPatchType `json:"patchType,omitempty"`
+
+	// Patch is the YAML or JSON patch e.g.
+	// {"spec":{"pools":[...]}} for a strategic patch or
+	// [{"op":"add","path":"/metadata/labels/team","value":"db"}]
+	// for a json patch
+	//
+	// +kubebuilder:validation:Required
+	Patch string `json:"patch"`
+}
+
+// OverlayTargetKind represents the kind of the generated children
+// that can be patched via overlays
+//
+// +kubebuilder:validation:Enum=CStorPoolCluster;CStorClusterStorageSet;PersistentVolumeClaim
+type OverlayTargetKind string
+
+const (
+	// OverlayTargetKindCStorPoolCluster patches the generated
+	// CStorPoolCluster(s)
+	OverlayTargetKindCStorPoolCluster OverlayTargetKind = OverlayTargetKind(KindCStorPoolCluster)
+
+	// OverlayTargetKindCStorClusterStorageSet patches the generated
+	// CStorClusterStorageSet(s)
+	OverlayTargetKindCStorClusterStorageSet OverlayTargetKind = OverlayTargetKind(KindCStorClusterStorageSet)
+
+	// OverlayTargetKindPersistentVolumeClaim patches the PVCs that
+	// are adopted by the Storages of this config
+	OverlayTargetKindPersistentVolumeClaim OverlayTargetKind = OverlayTargetKind(KindPersistentVolumeClaim)
+)
+
+// SupportedOverlayTargetKinds lists the supported overlay target kinds
+var SupportedOverlayTargetKinds = map[OverlayTargetKind]bool{
+	OverlayTargetKindCStorPoolCluster:       true,
+	OverlayTargetKindCStorClusterStorageSet: true,
+	OverlayTargetKindPersistentVolumeClaim:  true,
+}
+
+// OverlayPatchType represents how the patch of an overlay is applied
+//
+// +kubebuilder:validation:Enum=strategic;json
+type OverlayPatchType string
+
+const (
+	// OverlayPatchTypeStrategic merges the patch into the child.
+	// Lists of custom resources are replaced as a whole since these
+	// have no merge keys.
+	OverlayPatchTypeStrategic OverlayPatchType = "strategic"
+
+	// OverlayPatchTypeJSON applies the patch as a RFC 6902 json
+	// patch i.e. a list of operations
+	OverlayPatchTypeJSON OverlayPatchType = "json"
+
+	// OverlayPatchTypeDefault represents the default patch type
+	OverlayPatchTypeDefault OverlayPatchType = OverlayPatchTypeStrategic
+)
+
+// SupportedOverlayPatchTypes lists the supported overlay patch types
+var SupportedOverlayPatchTypes = map[OverlayPatchType]bool{
+	OverlayPatchTypeStrategic: true,
+	OverlayPatchTypeJSON:      true,
+}
+
+// ChildMetadata defines the labels & annotations that should be
+// set against the children i.e. resources created by this operator
+//
+// NOTE:
+//	Labels & annotations that are owned by this operator e.g.
+// cstorclusterconfig-uid are never overridden by these
+type ChildMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// MakeMapOfChildMetadata returns a map representation of the
+// given ChildMetadata that is suitable to be set against an
+// unstructured instance
+func MakeMapOfChildMetadata(given *ChildMetadata) map[string]interface{} {
+	if given == nil {
+		return nil
+	}
+	toMap := func(pairs map[string]string) map[string]interface{} {
+		result := map[string]interface{}{}
+		for k, v := range pairs {
+			result[k] = v
+		}
+		return result
+	}
+	return map[string]interface{}{
+		"labels":      toMap(given.Labels),
+		"annotations": toMap(given.Annotations),
+	}
 }
 
 // DiskConfig has disk information related to
@@ -67,6 +598,139 @@ type DiskConfig struct {
 	MinCapacity        resource.Quantity   `json:"minCapacity"`
 	ExternalDiskConfig *ExternalDiskConfig `json:"external,omitempty"`
 	LocalDiskConfig    *LocalDiskConfig    `json:"local,omitempty"`
+
+	// ReservePerNode is the raw capacity of local disks that is
+	// left unclaimed on every node for other consumers e.g. local
+	// PV provisioner. This is either a quantity e.g. 100Gi or a
+	// percentage e.g. 20% of the capacity of all disks of a node.
+	ReservePerNode *intstr.IntOrString `json:"reservePerNode,omitempty"`
+
+	// HealthCheck gates the local disks that can participate in
+	// building cstor pool instances
+	HealthCheck *DiskHealthCheck `json:"healthCheck,omitempty"`
+
+	// DevicePreference decides which of the matching local disks
+	// get consumed first when more disks match than are needed.
+	// Defaults to SmallestFirst.
+	DevicePreference DevicePreference `json:"devicePreference,omitempty"`
+
+	// WearLeveling prefers the local disks e.g. SSDs with lower wear
+	// when more disks match than are needed & avoids raid groups
+	// whose disks are all highly worn
+	WearLeveling *DeviceWearLeveling `json:"wearLeveling,omitempty"`
+
+	// NodeOverrides select the source of disks of every node when
+	// both local & external configs are set i.e. a hybrid config.
+	// The first override that matches a node decides its source.
+	// Nodes that match none of these use external disks. Pool of
+	// every node is built from exactly one source.
+	NodeOverrides []DiskNodeOverride `json:"nodeOverrides,omitempty"`
+}
+
+// DiskSource represents the source of disks of a node's pool
+//
+// +kubebuilder:validation:Enum=Local;External
+type DiskSource string
+
+const (
+	// DiskSourceLocal builds the node's pool from the local disks
+	// selected by LocalDiskConfig
+	DiskSourceLocal DiskSource = "Local"
+
+	// DiskSourceExternal builds the node's pool from the disks
+	// provisioned by ExternalDiskConfig
+	DiskSourceExternal DiskSource = "External"
+)
+
+// DiskNodeOverride sets the source of disks of the matching nodes
+// in a hybrid disk config
+type DiskNodeOverride struct {
+	// NodeSelector selects the nodes of this override. All nodes
+	// are selected if this has no selector terms.
+	//
+	// NOTE:
+	//	Selectors are owned by metac & are validated by metac
+	// while selecting the resources. Hence these are not part
+	// of generated CRD schema.
+	//
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Required
+	NodeSelector metac.ResourceSelector `json:"nodeSelector"`
+
+	// Source of disks of the selected nodes
+	//
+	// +kubebuilder:validation:Required
+	Source DiskSource `json:"source"`
+}
+
+// DevicePreference represents the order in which the matching
+// block devices get consumed
+//
+// +kubebuilder:validation:Enum=LargestFirst;SmallestFirst;ByPath;ByAge
+type DevicePreference string
+
+const (
+	// DevicePreferenceLargestFirst consumes the block devices with
+	// the largest capacity first
+	DevicePreferenceLargestFirst DevicePreference = "LargestFirst"
+
+	// DevicePreferenceSmallestFirst consumes the block devices with
+	// the smallest capacity first
+	DevicePreferenceSmallestFirst DevicePreference = "SmallestFirst"
+
+	// DevicePreferenceByPath consumes the block devices in the
+	// lexical order of their spec.path e.g. /dev/sdb before /dev/sdc
+	DevicePreferenceByPath DevicePreference = "ByPath"
+
+	// DevicePreferenceByAge consumes the block devices that were
+	// discovered earliest first
+	DevicePreferenceByAge DevicePreference = "ByAge"
+
+	// DevicePreferenceDefault represents the default device
+	// preference
+	DevicePreferenceDefault DevicePreference = DevicePreferenceSmallestFirst
+)
+
+// SupportedDevicePreferences lists the supported device preferences
+var SupportedDevicePreferences = map[DevicePreference]bool{
+	DevicePreferenceLargestFirst:  true,
+	DevicePreferenceSmallestFirst: true,
+	DevicePreferenceByPath:        true,
+	DevicePreferenceByAge:         true,
+}
+
+// DefaultHighWearPercent is the wear at or beyond which a disk is
+// considered highly worn if none was configured
+const DefaultHighWearPercent int64 = 80
+
+// DeviceWearLeveling scores local disks by their wear as reported
+// against their BlockDevice(s) e.g. by NDM or a SMART probe
+type DeviceWearLeveling struct {
+	// FieldPaths of BlockDevice that report the percentage of its
+	// rated endurance e.g. TBW used so far. The value of the first
+	// path that is set is used e.g. status.wear.percentUsed or
+	// metadata.annotations[example.io/ssd-wear]. Disks without any
+	// of these are preferred after the disks that are not highly
+	// worn.
+	//
+	// +kubebuilder:validation:Required
+	FieldPaths []string `json:"fieldPaths"`
+
+	// HighWearPercent is the wear at or beyond which a disk is
+	// considered highly worn. Defaults to 80.
+	HighWearPercent *int64 `json:"highWearPercent,omitempty"`
+}
+
+// DiskHealthCheck has the health checks that a local disk should
+// pass before it participates in building cstor pool instances
+type DiskHealthCheck struct {
+	// RequireSMARTPass when true lets only those block devices that
+	// are active & are annotated with dao.mayadata.io/smart-status
+	// set to Passed to participate in building cstor pool instances.
+	// This annotation is set by SMART probes or burn-in jobs.
+	RequireSMARTPass bool `json:"requireSMARTPass,omitempty"`
 }
 
 // ExternalDiskConfig has the details required to provision
@@ -75,13 +739,215 @@ type DiskConfig struct {
 type ExternalDiskConfig struct {
 	CSIAttacherName  string `json:"csiAttacherName"`
 	StorageClassName string `json:"storageClassName"`
+
+	// Parameters tune every provisioned disk e.g. iops, throughput
+	// or disk type. These are passed as annotations to the storage
+	// provisioner for CSI drivers that support per volume tuning.
+	// Parameters supported by known CSI drivers are listed at
+	// CSIAttacherToSupportedParameters.
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// StorageNamespace is the namespace where Storage(s) are created.
+	// This overrides the operator's --storage-namespace flag as well
+	// as the target namespace. Storage(s) that exist already are
+	// retained in their namespace.
+	StorageNamespace string `json:"storageNamespace,omitempty"`
+
+	// StorageLabels are set against every Storage. These take
+	// precedence over the operator's --storage-labels flag in case
+	// of same keys.
+	StorageLabels map[string]string `json:"storageLabels,omitempty"`
+
+	// StorageClasses provision the disks from multiple StorageClasses
+	// e.g. a mix of premium & standard EBS. Disks of every node are
+	// distributed across these in proportion to their weights. These
+	// are used instead of CSIAttacherName & StorageClassName if set.
+	StorageClasses []ExternalStorageClass `json:"storageClasses,omitempty"`
+
+	// ReclaimPolicy decides if the PVCs of the disks of a node are
+	// deleted once this node is removed from the plan. PVCs that are
+	// retained are labeled with the UID of this config. Defaults to
+	// Retain.
+	ReclaimPolicy ExternalDiskReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
+// ExternalDiskReclaimPolicy represents the handling of the PVCs of
+// a node's disks once this node is removed from the plan
+//
+// +kubebuilder:validation:Enum=Retain;Delete
+type ExternalDiskReclaimPolicy string
+
+const (
+	// ExternalDiskReclaimPolicyRetain keeps the PVCs & labels them
+	// for a later cleanup
+	ExternalDiskReclaimPolicyRetain ExternalDiskReclaimPolicy = "Retain"
+
+	// ExternalDiskReclaimPolicyDelete deletes the PVCs
+	ExternalDiskReclaimPolicyDelete ExternalDiskReclaimPolicy = "Delete"
+
+	// ExternalDiskReclaimPolicyDefault represents the default
+	// reclaim policy
+	ExternalDiskReclaimPolicyDefault ExternalDiskReclaimPolicy = ExternalDiskReclaimPolicyRetain
+)
+
+// SupportedExternalDiskReclaimPolicies lists the supported reclaim
+// policies
+var SupportedExternalDiskReclaimPolicies = map[ExternalDiskReclaimPolicy]bool{
+	ExternalDiskReclaimPolicyRetain: true,
+	ExternalDiskReclaimPolicyDelete: true,
+}
+
+// GetReclaimPolicy returns the reclaim policy or its default
+func (c ExternalDiskConfig) GetReclaimPolicy() ExternalDiskReclaimPolicy {
+	if c.ReclaimPolicy == "" {
+		return ExternalDiskReclaimPolicyDefault
+	}
+	return c.ReclaimPolicy
+}
+
+// ExternalStorageClass refers to a StorageClass that provisions a
+// share of the disks of an external disk config
+type ExternalStorageClass struct {
+	// +kubebuilder:validation:Required
+	CSIAttacherName string `json:"csiAttacherName"`
+	// +kubebuilder:validation:Required
+	StorageClassName string `json:"storageClassName"`
+
+	// Weight is the share of disks provisioned from this
+	// StorageClass relative to the other StorageClasses. This
+	// defaults to 1.
+	Weight int64 `json:"weight,omitempty"`
+}
+
+// GetWeight returns the weight of this StorageClass or its default
+func (c ExternalStorageClass) GetWeight() int64 {
+	if c.Weight == 0 {
+		return 1
+	}
+	return c.Weight
+}
+
+// MakeListMapOfExternalStorageClasses returns a list of maps
+// representation of the given StorageClasses that is suitable to
+// be set against an unstructured instance
+func MakeListMapOfExternalStorageClasses(given []ExternalStorageClass) []interface{} {
+	var listMap []interface{}
+	for _, class := range given {
+		classMap := map[string]interface{}{
+			"csiAttacherName":  class.CSIAttacherName,
+			"storageClassName": class.StorageClassName,
+		}
+		if class.Weight != 0 {
+			classMap["weight"] = class.Weight
+		}
+		listMap = append(listMap, classMap)
+	}
+	return listMap
+}
+
+// GetStorageClasses returns the StorageClasses that provision the
+// disks. Single StorageClass formed out of CSIAttacherName &
+// StorageClassName is returned if StorageClasses are not set.
+func (c ExternalDiskConfig) GetStorageClasses() []ExternalStorageClass {
+	if len(c.StorageClasses) != 0 {
+		return c.StorageClasses
+	}
+	return []ExternalStorageClass{
+		{
+			CSIAttacherName:  c.CSIAttacherName,
+			StorageClassName: c.StorageClassName,
+		},
+	}
+}
+
+const (
+	// CSIAttacherNameAWSEBS refers to the CSI driver of AWS EBS
+	CSIAttacherNameAWSEBS string = "ebs.csi.aws.com"
+
+	// CSIAttacherNameGCEPD refers to the CSI driver of GCE PD
+	CSIAttacherNameGCEPD string = "pd.csi.storage.gke.io"
+)
+
+// CSIAttacherToSupportedParameters maps the known CSI drivers to
+// the disk parameters that can be tuned per volume
+//
+// NOTE:
+//	Parameters of CSI drivers that are not listed here are not
+// validated
+var CSIAttacherToSupportedParameters = map[string]map[string]bool{
+	CSIAttacherNameAWSEBS: {
+		"type":       true,
+		"iops":       true,
+		"throughput": true,
+	},
+	CSIAttacherNameGCEPD: {
+		"type":                             true,
+		"provisioned-iops-on-create":       true,
+		"provisioned-throughput-on-create": true,
+	},
 }
 
 // LocalDiskConfig refers to local disks details that should be
 // available & is eligible to participate in building cstor
 // pool instace.
 type LocalDiskConfig struct {
-	BlockDeviceSelector metac.ResourceSelector `json:"blockDeviceSelector"`
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	BlockDeviceSelector BlockDeviceSelector `json:"blockDeviceSelector"`
+
+	// BlockDeviceExclude is evaluated after BlockDeviceSelector.
+	// Block devices that match these terms never participate in
+	// building cstor pool instances e.g. OS disks.
+	//
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	BlockDeviceExclude *BlockDeviceSelector `json:"blockDeviceExclude,omitempty"`
+
+	// SelectAll when set to true selects all the Active & Unclaimed
+	// block devices of the allowed nodes. BlockDeviceSelector must
+	// not be set if this is set.
+	SelectAll bool `json:"selectAll,omitempty"`
+
+	// MinDeviceCapacity when set drops the block devices whose
+	// spec.capacity.storage is less than this value e.g. boot
+	// partitions
+	MinDeviceCapacity *resource.Quantity `json:"minDeviceCapacity,omitempty"`
+
+	// MaxDeviceCapacity when set drops the block devices whose
+	// spec.capacity.storage is more than this value e.g. archive
+	// disks
+	MaxDeviceCapacity *resource.Quantity `json:"maxDeviceCapacity,omitempty"`
+
+	// FailureDomainKey when set shards the pools into one
+	// CStorPoolCluster per failure domain e.g. rack or zone. Nodes
+	// are grouped into failure domains by the value of this label
+	// key. Nodes without this label do not get any pool. All pools
+	// belong to a single CStorPoolCluster if this is not set.
+	//
+	// NOTE:
+	//	This can't be changed once the CStorPoolCluster(s) of this
+	// config are created
+	FailureDomainKey string `json:"failureDomainKey,omitempty"`
+
+	// ExplicitDeviceMap pins the listed block devices to the pools
+	// of the listed nodes. Block devices of the listed nodes are not
+	// selected via BlockDeviceSelector. Block devices of other nodes
+	// continue to be selected via BlockDeviceSelector.
+	ExplicitDeviceMap []ExplicitDeviceMapping `json:"explicitDeviceMap,omitempty"`
+}
+
+// ExplicitDeviceMapping pins the given block devices to the pool of
+// the given node
+type ExplicitDeviceMapping struct {
+	// NodeName is matched against spec.nodeAttributes.nodeName as
+	// well as the kubernetes.io/hostname label of block devices
+	//
+	// +kubebuilder:validation:Required
+	NodeName string `json:"nodeName"`
+	// +kubebuilder:validation:Required
+	BlockDeviceNames []string `json:"blockDeviceNames"`
 }
 
 // PoolConfig defines various options to configure a
@@ -90,24 +956,189 @@ type PoolConfig struct {
 	PoolExpansion    PoolExpansion    `json:"poolExpansion"`
 	ComputeResources ComputeResources `json:"computeResources"`
 	RAIDType         PoolRAIDType     `json:"raidType"`
+
+	// PerZoneCSPC when set to true plans one CStorPoolCluster per
+	// topology zone of the allowed nodes. Min & max pool counts are
+	// applied to each zone.
+	PerZoneCSPC bool `json:"perZoneCSPC,omitempty"`
+
+	// NodeStabilityWindow is the duration for which a node needs to
+	// be continuously present & ready before it gets planned in.
+	// Similarly, a planned node is removed only after it is missing
+	// for this duration. This smoothens the churn of plans when
+	// nodes flap during upgrades. Defaults to 5m. Set to 0s to
+	// disable.
+	NodeStabilityWindow *metav1.Duration `json:"nodeStabilityWindow,omitempty"`
+
+	// ZFSProperties are set against every pool e.g. recordsize &
+	// atime. Only whitelisted properties are accepted. These are
+	// propagated to the pools of CStorPoolCluster if its schema
+	// supports them.
+	ZFSProperties map[string]ZFSPropertyValue `json:"zfsProperties,omitempty"`
+
+	// NodeSelectorKey is the node label used by the pools of
+	// CStorPoolCluster to select their nodes e.g. a custom hostname
+	// label. Block devices are grouped into pools by the value of
+	// this label of their nodes. Defaults to kubernetes.io/hostname.
+	NodeSelectorKey string `json:"nodeSelectorKey,omitempty"`
+
+	// ChangeBudget limits the number of pools that are added &
+	// removed per hour. Changes beyond this budget are deferred till
+	// the budget is available again. Changes are not limited if this
+	// is not set.
+	ChangeBudget *ChangeBudget `json:"changeBudget,omitempty"`
+
+	// PartialPlacementPolicy decides if the pools are created on the
+	// nodes that have enough block devices when other nodes do not.
+	// Defaults to Strict.
+	PartialPlacementPolicy PartialPlacementPolicy `json:"partialPlacementPolicy,omitempty"`
+
+	// RAIDGroupConfig overrides the sizing of the raid groups of the
+	// pools. Raid groups are sized as per the raid type if this is
+	// not set.
+	RAIDGroupConfig *PoolRAIDGroupConfig `json:"raidGroupConfig,omitempty"`
+
+	// MinReadyNodesBeforeCreate is the number of nodes that need to
+	// have all their block devices ready before the CStorPoolCluster
+	// is created. This avoids skewed data placement when mirror or
+	// raidz pools get added one at a time. Pools that are added after
+	// the CStorPoolCluster is created are not held back. Defaults to
+	// 0 i.e. the CStorPoolCluster is created without waiting.
+	//
+	// +kubebuilder:validation:Minimum=0
+	MinReadyNodesBeforeCreate int64 `json:"minReadyNodesBeforeCreate,omitempty"`
+}
+
+// PoolRAIDGroupConfig sizes the raid groups of the pools
+//
+// NOTE:
+//	This is independent of spec.diskConfig.minCount which is the
+// number of block devices per node. Latter should be a multiple of
+// the group device count.
+type PoolRAIDGroupConfig struct {
+	// GroupDeviceCount is the number of block devices per raid
+	// group. This should be even for mirror & striped mirror, at
+	// least 3 for raidz & at least 6 for raidz2. Defaults to 1 for
+	// stripe, 2 for mirror & striped mirror, 3 for raidz & 6 for
+	// raidz2.
+	//
+	// +kubebuilder:validation:Minimum=1
+	GroupDeviceCount int64 `json:"groupDeviceCount,omitempty"`
+}
+
+// PartialPlacementPolicy represents the handling of nodes whose block
+// devices do not satisfy the raid type of pools
+//
+// +kubebuilder:validation:Enum=Strict;BestEffort
+type PartialPlacementPolicy string
+
+const (
+	// PartialPlacementPolicyStrict fails the reconciliation if any
+	// node does not have enough block devices
+	PartialPlacementPolicyStrict PartialPlacementPolicy = "Strict"
+
+	// PartialPlacementPolicyBestEffort creates the pools on the nodes
+	// that have enough block devices. Other nodes are reported as
+	// skipped & are retried till they have enough block devices.
+	//
+	// NOTE:
+	//	Nodes whose pools exist already are never skipped since their
+	// pools would be removed otherwise
+	PartialPlacementPolicyBestEffort PartialPlacementPolicy = "BestEffort"
+
+	// PartialPlacementPolicyDefault represents the default partial
+	// placement policy
+	PartialPlacementPolicyDefault PartialPlacementPolicy = PartialPlacementPolicyStrict
+)
+
+// SupportedPartialPlacementPolicies lists the supported partial
+// placement policies
+var SupportedPartialPlacementPolicies = map[PartialPlacementPolicy]bool{
+	PartialPlacementPolicyStrict:     true,
+	PartialPlacementPolicyBestEffort: true,
+}
+
+// GetPartialPlacementPolicy returns the partial placement policy or
+// its default
+func (c PoolConfig) GetPartialPlacementPolicy() PartialPlacementPolicy {
+	if c.PartialPlacementPolicy == "" {
+		return PartialPlacementPolicyDefault
+	}
+	return c.PartialPlacementPolicy
+}
+
+// ChangeBudget limits the rate at which the nodes of pools are
+// planned in & out
+//
+// NOTE:
+//	This protects the pools from runaway automation e.g. node
+// selectors or node labels that are edited live
+type ChangeBudget struct {
+	// MaxAddPerHour is the max number of pools added in the last
+	// hour; no limit if not set
+	//
+	// +kubebuilder:validation:Minimum=0
+	MaxAddPerHour *int64 `json:"maxAddPerHour,omitempty"`
+
+	// MaxRemovePerHour is the max number of pools removed in the
+	// last hour; no limit if not set
+	//
+	// +kubebuilder:validation:Minimum=0
+	MaxRemovePerHour *int64 `json:"maxRemovePerHour,omitempty"`
+}
+
+// ZFSPropertyValue is the value of a ZFS property
+//
+// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9.-]+$`
+type ZFSPropertyValue string
+
+// GetZFSProperties returns the ZFS properties of the pools
+func (c PoolConfig) GetZFSProperties() map[string]string {
+	if len(c.ZFSProperties) == 0 {
+		return nil
+	}
+	properties := map[string]string{}
+	for name, value := range c.ZFSProperties {
+		properties[name] = string(value)
+	}
+	return properties
+}
+
+// DefaultNodeSelectorKey is the node label used by the pools to
+// select their nodes if no key was configured
+const DefaultNodeSelectorKey string = "kubernetes.io/hostname"
+
+// GetNodeSelectorKey returns the node label used by the pools to
+// select their nodes
+func (c PoolConfig) GetNodeSelectorKey() string {
+	if c.NodeSelectorKey == "" {
+		return DefaultNodeSelectorKey
+	}
+	return c.NodeSelectorKey
 }
 
+// DefaultNodeStabilityWindow is the node stability window used if
+// no window was configured
+var DefaultNodeStabilityWindow = metav1.Duration{Duration: 5 * time.Minute}
+
 // PoolExpansion provides options to trigger expansion
 // of any cstor pool instance
 type PoolExpansion struct {
-	Disable   *bool       `json:"disable"`
-	Threshold ResourceMap `json:"capacityThreshold"`
+	Disable   *bool       `json:"disable,omitempty"`
+	Threshold ResourceMap `json:"capacityThreshold,omitempty"`
 }
 
 // ComputeResources defines the resources required to run one
 // cstor pool instance
 type ComputeResources struct {
-	Requests ResourceMap `json:"requests"`
-	Limits   ResourceMap `json:"limits"`
+	Requests ResourceMap `json:"requests,omitempty"`
+	Limits   ResourceMap `json:"limits,omitempty"`
 }
 
 // PoolRAIDType represents the supported pool type for all cstor
 // pool instances
+//
+// +kubebuilder:validation:Enum=stripe;mirror;striped-mirror;raidz;raidz2
 type PoolRAIDType string
 
 const (
@@ -117,6 +1148,10 @@ const (
 	// PoolRAIDTypeMirror represents a mirror pool type
 	PoolRAIDTypeMirror PoolRAIDType = "mirror"
 
+	// PoolRAIDTypeStripedMirror represents a pool type that stripes
+	// across mirrored raid groups i.e. RAID10
+	PoolRAIDTypeStripedMirror PoolRAIDType = "striped-mirror"
+
 	// PoolRAIDTypeRAIDZ represents a raidz pool type
 	PoolRAIDTypeRAIDZ PoolRAIDType = "raidz"
 
@@ -129,26 +1164,358 @@ const (
 
 // SupportedRAIDTypes lists the supported raid types
 var SupportedRAIDTypes = map[PoolRAIDType]bool{
-	PoolRAIDTypeStripe: true,
-	PoolRAIDTypeMirror: true,
-	PoolRAIDTypeRAIDZ:  true,
-	PoolRAIDTypeRAIDZ2: true,
+	PoolRAIDTypeStripe:        true,
+	PoolRAIDTypeMirror:        true,
+	PoolRAIDTypeStripedMirror: true,
+	PoolRAIDTypeRAIDZ:         true,
+	PoolRAIDTypeRAIDZ2:        true,
 }
 
 // RAIDTypeToDefaultMinDiskCount maps pool instance's raid type
 // to its default minimum disk count
 var RAIDTypeToDefaultMinDiskCount = map[PoolRAIDType]int64{
-	PoolRAIDTypeStripe: 1,
-	PoolRAIDTypeMirror: 2,
-	PoolRAIDTypeRAIDZ:  3,
-	PoolRAIDTypeRAIDZ2: 6,
+	PoolRAIDTypeStripe:        1,
+	PoolRAIDTypeMirror:        2,
+	PoolRAIDTypeStripedMirror: 4,
+	PoolRAIDTypeRAIDZ:         3,
+	PoolRAIDTypeRAIDZ2:        6,
+}
+
+// RAIDTypeToRAIDGroupDiskCount maps pool instance's raid type
+// to the disk count of each of its raid groups
+var RAIDTypeToRAIDGroupDiskCount = map[PoolRAIDType]int64{
+	PoolRAIDTypeStripe:        1,
+	PoolRAIDTypeMirror:        2,
+	PoolRAIDTypeStripedMirror: 2,
+	PoolRAIDTypeRAIDZ:         3,
+	PoolRAIDTypeRAIDZ2:        6,
+}
+
+// RAIDTypeToMinRAIDGroupCount maps pool instance's raid type to the
+// min number of its raid groups
+var RAIDTypeToMinRAIDGroupCount = map[PoolRAIDType]int64{
+	PoolRAIDTypeStripe:        1,
+	PoolRAIDTypeMirror:        1,
+	PoolRAIDTypeStripedMirror: 2,
+	PoolRAIDTypeRAIDZ:         1,
+	PoolRAIDTypeRAIDZ2:        1,
+}
+
+// GetRAIDGroupDiskCount returns the disk count of each raid group
+// of the given raid type. Group device count of the given config if
+// set overrides the default of the raid type.
+func GetRAIDGroupDiskCount(raidType PoolRAIDType, config *PoolRAIDGroupConfig) int64 {
+	if config != nil && config.GroupDeviceCount > 0 {
+		return config.GroupDeviceCount
+	}
+	return RAIDTypeToRAIDGroupDiskCount[raidType]
+}
+
+// GetMinDiskCount returns the min disk count of a pool instance of
+// the given raid type & raid group config
+func GetMinDiskCount(raidType PoolRAIDType, config *PoolRAIDGroupConfig) int64 {
+	return GetRAIDGroupDiskCount(raidType, config) *
+		RAIDTypeToMinRAIDGroupCount[raidType]
+}
+
+// ValidateRAIDGroupConfig verifies if the group device count of the
+// given config can form a raid group of the given raid type
+func ValidateRAIDGroupConfig(raidType PoolRAIDType, config *PoolRAIDGroupConfig) error {
+	if config == nil || config.GroupDeviceCount == 0 {
+		return nil
+	}
+	count := config.GroupDeviceCount
+	if count < 0 {
+		return errors.Errorf(
+			"Invalid group device count %d: Want positive value", count,
+		)
+	}
+	switch raidType {
+	case PoolRAIDTypeMirror, PoolRAIDTypeStripedMirror:
+		if count%2 != 0 {
+			return errors.Errorf(
+				"Invalid group device count %d for RAID type %q: Want even count",
+				count, raidType,
+			)
+		}
+	case PoolRAIDTypeRAIDZ, PoolRAIDTypeRAIDZ2:
+		if count < RAIDTypeToRAIDGroupDiskCount[raidType] {
+			return errors.Errorf(
+				"Invalid group device count %d for RAID type %q: Want at least %d",
+				count, raidType, RAIDTypeToRAIDGroupDiskCount[raidType],
+			)
+		}
+	case PoolRAIDTypeStripe:
+	default:
+		return errors.Errorf(
+			"Can't validate group device count %d: Unsupported RAID type %q",
+			count, raidType,
+		)
+	}
+	return nil
+}
+
+// RAIDTypeToRAIDGroupType maps pool instance's raid type to the
+// raid group type understood by CStorPoolCluster
+//
+// NOTE:
+//	CStorPoolCluster has no striped mirror type. A pool instance
+// stripes across all its raid groups. Hence, striped mirror is
+// formed from mirror raid groups.
+var RAIDTypeToRAIDGroupType = map[PoolRAIDType]PoolRAIDType{
+	PoolRAIDTypeStripe:        PoolRAIDTypeStripe,
+	PoolRAIDTypeMirror:        PoolRAIDTypeMirror,
+	PoolRAIDTypeStripedMirror: PoolRAIDTypeMirror,
+	PoolRAIDTypeRAIDZ:         PoolRAIDTypeRAIDZ,
+	PoolRAIDTypeRAIDZ2:        PoolRAIDTypeRAIDZ2,
+}
+
+// IsDiskCountValidForRAIDType returns true if the given disk count
+// can form a pool instance of the given raid type
+//
+// NOTE:
+//	Disk count should be a multiple of raid group disk count &
+// should not be less than the minimum disk count. For example,
+// striped mirror needs an even disk count of at least 4.
+func IsDiskCountValidForRAIDType(raidType PoolRAIDType, count int64) bool {
+	return IsDiskCountValidForRAIDGroup(raidType, nil, count)
+}
+
+// IsDiskCountValidForRAIDGroup returns true if the given disk count
+// can form a pool instance of the given raid type whose raid groups
+// are sized as per the given config
+func IsDiskCountValidForRAIDGroup(
+	raidType PoolRAIDType, config *PoolRAIDGroupConfig, count int64,
+) bool {
+	groupDiskCount := GetRAIDGroupDiskCount(raidType, config)
+	if groupDiskCount <= 0 || count <= 0 {
+		return false
+	}
+	return count >= GetMinDiskCount(raidType, config) &&
+		count%groupDiskCount == 0
 }
 
 // CStorClusterConfigStatus represents the current state of
 // CStorClusterConfig
 type CStorClusterConfigStatus struct {
 	Phase      CStorClusterConfigStatusPhase       `json:"phase"`
-	Conditions []CStorClusterConfigStatusCondition `json:"conditions"`
+	Conditions []CStorClusterConfigStatusCondition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of this CStorClusterConfig
+	// that was last processed. Spec edits are yet to be processed if
+	// this is less than metadata.generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Capacity is aggregated from the pools & block devices
+	// managed by this CStorClusterConfig
+	Capacity *CStorClusterConfigCapacity `json:"capacity,omitempty"`
+
+	// RejectedBlockDevices lists the selected block devices that
+	// were kept out of pools since they failed health checks
+	RejectedBlockDevices []CStorClusterConfigRejectedBlockDevice `json:"rejectedBlockDevices,omitempty"`
+
+	// PoolTopology reports the nodes, raid groups & block devices
+	// that make up the CStorPoolCluster managed by this config
+	PoolTopology *CStorClusterConfigPoolTopology `json:"poolTopology,omitempty"`
+
+	// BlockDeviceSelectionReport explains why the local disk config
+	// did not select any block device e.g. "term 1 rejected 12 of
+	// 12 devices: 8 by field spec.path, 4 by label app"
+	BlockDeviceSelectionReport []string `json:"blockDeviceSelectionReport,omitempty"`
+
+	// SkippedNodes lists the nodes whose pools were not created since
+	// their block devices did not satisfy the raid type. This is set
+	// only if the partial placement policy is BestEffort.
+	SkippedNodes []CStorClusterConfigSkippedNode `json:"skippedNodes,omitempty"`
+
+	// BlockDeviceClaims reports the claims of the block devices of
+	// this config. This applies to local as well as external disks.
+	BlockDeviceClaims *CStorClusterConfigBlockDeviceClaims `json:"blockDeviceClaims,omitempty"`
+
+	// RejectedNodes lists the nodes that were not eligible for pools
+	// along with the reason of rejection. This is bounded to the
+	// first 50 nodes sorted by name.
+	RejectedNodes []CStorClusterConfigRejectedNode `json:"rejectedNodes,omitempty"`
+
+	// RejectedNodeCount is the total number of rejected nodes. This
+	// is more than the length of RejectedNodes if the latter is
+	// bounded.
+	RejectedNodeCount int `json:"rejectedNodeCount,omitempty"`
+}
+
+// MaxRejectedNodes is the max number of rejected nodes reported in
+// the status of a CStorClusterConfig
+const MaxRejectedNodes int = 50
+
+// CStorClusterConfigRejectedNode reports a node that was not
+// eligible for a pool
+type CStorClusterConfigRejectedNode struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+const (
+	// RejectedNodeReasonPoolDecommission is used when the pool of
+	// a node is requested to be decommissioned
+	RejectedNodeReasonPoolDecommission string = "Pool decommission requested"
+
+	// RejectedNodeReasonOtherZone is used when a node does not
+	// belong to the zone being planned
+	RejectedNodeReasonOtherZone string = "Node of other zone"
+
+	// RejectedNodeReasonNoZone is used when pools are planned per
+	// zone but a node does not have the zone label
+	RejectedNodeReasonNoZone string = "Node without zone label"
+
+	// RejectedNodeReasonOtherMachinePool is used when a node is not
+	// a machine of the allowed machine pool
+	RejectedNodeReasonOtherMachinePool string = "Node of other machine pool"
+
+	// RejectedNodeReasonControlPlane is used when a node has the
+	// control plane label or taint & control plane nodes are not
+	// allowed
+	RejectedNodeReasonControlPlane string = "Control plane node"
+
+	// RejectedNodeReasonSelectorMismatch is used when a node does
+	// not match the selector terms of allowed nodes
+	RejectedNodeReasonSelectorMismatch string = "Selector terms mismatch"
+
+	// RejectedNodeReasonLocalDisks is used when a node uses local
+	// disks of a hybrid disk config
+	RejectedNodeReasonLocalDisks string = "Node uses local disks"
+
+	// RejectedNodeReasonUnschedulable is used when a node is
+	// cordoned
+	RejectedNodeReasonUnschedulable string = "Node unschedulable"
+
+	// RejectedNodeReasonUnstable is used when a node was not present
+	// & ready for the node stability window
+	RejectedNodeReasonUnstable string = "Node not stable within stability window"
+)
+
+// CStorClusterConfigBlockDeviceClaims reports the state of the
+// claims of the block devices that form the pools
+type CStorClusterConfigBlockDeviceClaims struct {
+	// DesiredCount is the number of block devices that need to be
+	// claimed
+	DesiredCount int `json:"desiredCount"`
+
+	// ReadyCount is the number of block devices whose claims are
+	// bound or that are already used by the CStorPoolCluster
+	ReadyCount int `json:"readyCount"`
+
+	// PendingBlockDevices lists the block devices whose claims are
+	// yet to be bound
+	PendingBlockDevices []string `json:"pendingBlockDevices,omitempty"`
+
+	// SkippedBlockDevices lists the selected block devices that are
+	// not claimed since these are claimed elsewhere
+	SkippedBlockDevices []CStorClusterConfigRejectedBlockDevice `json:"skippedBlockDevices,omitempty"`
+}
+
+// CStorClusterConfigSkippedNode reports a node whose pool was not
+// created
+type CStorClusterConfigSkippedNode struct {
+	HostName string `json:"hostName"`
+	Reason   string `json:"reason"`
+}
+
+// CStorClusterConfigPoolTopology reports the layout of pools of a
+// CStorPoolCluster
+//
+// NOTE:
+//	This lets external schedulers & UIs make placement decisions
+// without parsing the CStorPoolCluster
+//
+// NOTE:
+//	CStorPoolClusterName is empty if the pools are sharded by failure
+// domain. Each pool reports its CStorPoolCluster in this case.
+type CStorClusterConfigPoolTopology struct {
+	CStorPoolClusterName string                               `json:"cstorPoolClusterName"`
+	Pools                []CStorClusterConfigPoolTopologyPool `json:"pools,omitempty"`
+}
+
+// CStorClusterConfigPoolTopologyPool reports the layout of a single
+// pool of a CStorPoolCluster
+type CStorClusterConfigPoolTopologyPool struct {
+	HostName      string                                    `json:"hostName"`
+	RAIDGroupType string                                    `json:"raidGroupType,omitempty"`
+	RAIDGroups    []CStorClusterConfigPoolTopologyRAIDGroup `json:"raidGroups,omitempty"`
+
+	// CStorPoolClusterName & FailureDomain are set only if the pools
+	// are sharded by failure domain
+	CStorPoolClusterName string `json:"cstorPoolClusterName,omitempty"`
+	FailureDomain        string `json:"failureDomain,omitempty"`
+}
+
+// CStorClusterConfigPoolTopologyRAIDGroup reports the block devices
+// of a single raid group
+type CStorClusterConfigPoolTopologyRAIDGroup struct {
+	BlockDeviceNames []string `json:"blockDeviceNames"`
+}
+
+// CStorClusterConfigRejectedBlockDevice reports a block device
+// that failed health checks
+type CStorClusterConfigRejectedBlockDevice struct {
+	Name     string `json:"name"`
+	HostName string `json:"hostName,omitempty"`
+	Reason   string `json:"reason"`
+
+	// StableDevLink identifies the device across node restarts
+	// e.g. /dev/disk/by-id/<serial>
+	StableDevLink string `json:"stableDevLink,omitempty"`
+}
+
+// CStorClusterConfigCapacity reports the capacity aggregated
+// from the pool instances & block devices that are managed by
+// a CStorClusterConfig
+//
+// NOTE:
+//	Total, used & free are aggregated only from those pool
+// instances whose capacity is observable
+type CStorClusterConfigCapacity struct {
+	Total resource.Quantity                `json:"total"`
+	Used  resource.Quantity                `json:"used"`
+	Free  resource.Quantity                `json:"free"`
+	Pools []CStorClusterConfigPoolCapacity `json:"pools,omitempty"`
+	Nodes []CStorClusterConfigNodeCapacity `json:"nodes,omitempty"`
+
+	// MaxNodeUtilizationPercent is the highest utilization percent
+	// of block devices amongst the nodes
+	MaxNodeUtilizationPercent int64 `json:"maxNodeUtilizationPercent,omitempty"`
+
+	// MaxUtilizedHostName is the node with the highest utilization
+	// percent of block devices i.e. the node that is nearest to
+	// exhaustion
+	MaxUtilizedHostName string `json:"maxUtilizedHostName,omitempty"`
+}
+
+// CStorClusterConfigPoolCapacity reports the capacity of a
+// single pool instance
+type CStorClusterConfigPoolCapacity struct {
+	Name     string            `json:"name"`
+	HostName string            `json:"hostName"`
+	Total    resource.Quantity `json:"total"`
+	Used     resource.Quantity `json:"used"`
+	Free     resource.Quantity `json:"free"`
+}
+
+// CStorClusterConfigNodeCapacity reports the block device
+// counts of a single node
+type CStorClusterConfigNodeCapacity struct {
+	HostName string `json:"hostName"`
+
+	// BlockDeviceCount is the number of observed block devices
+	// available on this node
+	BlockDeviceCount int64 `json:"blockDeviceCount"`
+
+	// PoolBlockDeviceCount is the number of block devices of this
+	// node that are part of the pool
+	PoolBlockDeviceCount int64 `json:"poolBlockDeviceCount"`
+
+	// UtilizationPercent is the percentage of block devices of this
+	// node that are part of the pool
+	UtilizationPercent int64 `json:"utilizationPercent"`
 }
 
 // CStorClusterConfigStatusPhase reports the current phase of
@@ -171,5 +1538,10 @@ type CStorClusterConfigStatusCondition struct {
 	Type             ConditionType  `json:"type"`
 	Status           ConditionState `json:"status"`
 	Reason           string         `json:"reason,omitempty"`
+	Message          string         `json:"message,omitempty"`
 	LastObservedTime string         `json:"lastObservedTime"`
+
+	// LastTransitionTime is the time when the status of this
+	// condition last changed
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
 }
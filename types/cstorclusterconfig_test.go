@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import "testing"
+
+func TestIsDiskCountValidForRAIDType(t *testing.T) {
+	var tests = map[string]struct {
+		raidType PoolRAIDType
+		count    int64
+		isValid  bool
+	}{
+		"stripe with 1 disk": {
+			raidType: PoolRAIDTypeStripe,
+			count:    1,
+			isValid:  true,
+		},
+		"stripe with 0 disks": {
+			raidType: PoolRAIDTypeStripe,
+			count:    0,
+		},
+		"mirror with 2 disks": {
+			raidType: PoolRAIDTypeMirror,
+			count:    2,
+			isValid:  true,
+		},
+		"mirror with 3 disks": {
+			raidType: PoolRAIDTypeMirror,
+			count:    3,
+		},
+		"striped mirror with 2 disks": {
+			raidType: PoolRAIDTypeStripedMirror,
+			count:    2,
+		},
+		"striped mirror with 4 disks": {
+			raidType: PoolRAIDTypeStripedMirror,
+			count:    4,
+			isValid:  true,
+		},
+		"striped mirror with 5 disks": {
+			raidType: PoolRAIDTypeStripedMirror,
+			count:    5,
+		},
+		"striped mirror with 6 disks": {
+			raidType: PoolRAIDTypeStripedMirror,
+			count:    6,
+			isValid:  true,
+		},
+		"raidz2 with 12 disks": {
+			raidType: PoolRAIDTypeRAIDZ2,
+			count:    12,
+			isValid:  true,
+		},
+		"invalid raid type": {
+			raidType: PoolRAIDType("junk"),
+			count:    2,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsDiskCountValidForRAIDType(mock.raidType, mock.count)
+			if got != mock.isValid {
+				t.Fatalf("Expected valid %t got %t", mock.isValid, got)
+			}
+		})
+	}
+}
+
+func TestValidateRAIDGroupConfig(t *testing.T) {
+	var tests = map[string]struct {
+		raidType PoolRAIDType
+		count    int64
+		isErr    bool
+	}{
+		"mirror with 2 disks":       {raidType: PoolRAIDTypeMirror, count: 2},
+		"mirror with 3 disks":       {raidType: PoolRAIDTypeMirror, count: 3, isErr: true},
+		"mirror with 4 disks":       {raidType: PoolRAIDTypeMirror, count: 4},
+		"raidz with 2 disks":        {raidType: PoolRAIDTypeRAIDZ, count: 2, isErr: true},
+		"raidz with 3 disks":        {raidType: PoolRAIDTypeRAIDZ, count: 3},
+		"raidz with 5 disks":        {raidType: PoolRAIDTypeRAIDZ, count: 5},
+		"raidz2 with 5 disks":       {raidType: PoolRAIDTypeRAIDZ2, count: 5, isErr: true},
+		"raidz2 with 8 disks":       {raidType: PoolRAIDTypeRAIDZ2, count: 8},
+		"stripe with 3 disks":       {raidType: PoolRAIDTypeStripe, count: 3},
+		"unset count":               {raidType: PoolRAIDTypeMirror},
+		"invalid raid with 2 disks": {raidType: PoolRAIDType("junk"), count: 2, isErr: true},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			err := ValidateRAIDGroupConfig(
+				mock.raidType,
+				&PoolRAIDGroupConfig{GroupDeviceCount: mock.count},
+			)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+		})
+	}
+}
+
+func TestIsDiskCountValidForRAIDGroup(t *testing.T) {
+	var tests = map[string]struct {
+		raidType PoolRAIDType
+		config   *PoolRAIDGroupConfig
+		count    int64
+		isValid  bool
+	}{
+		"raidz with no config & 6 disks": {
+			raidType: PoolRAIDTypeRAIDZ,
+			count:    6,
+			isValid:  true,
+		},
+		"raidz with 4 disks per group & 6 disks": {
+			raidType: PoolRAIDTypeRAIDZ,
+			config:   &PoolRAIDGroupConfig{GroupDeviceCount: 4},
+			count:    6,
+		},
+		"raidz with 4 disks per group & 8 disks": {
+			raidType: PoolRAIDTypeRAIDZ,
+			config:   &PoolRAIDGroupConfig{GroupDeviceCount: 4},
+			count:    8,
+			isValid:  true,
+		},
+		"mirror with 4 disks per group & 2 disks": {
+			raidType: PoolRAIDTypeMirror,
+			config:   &PoolRAIDGroupConfig{GroupDeviceCount: 4},
+			count:    2,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsDiskCountValidForRAIDGroup(mock.raidType, mock.config, mock.count)
+			if got != mock.isValid {
+				t.Fatalf("Expected valid %t got %t", mock.isValid, got)
+			}
+		})
+	}
+}
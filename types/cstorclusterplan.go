@@ -23,23 +23,45 @@ import (
 
 // CStorClusterPlan is a kubernetes custom resource that plans
 // the resources especially nodes to form the CStorPoolCluster
+//
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=cstorclusterplans,singular=cstorclusterplan,shortName=cscplan,scope=Namespaced
 type CStorClusterPlan struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
-	Spec   CStorClusterPlanSpec   `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec CStorClusterPlanSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
 	Status CStorClusterPlanStatus `json:"status"`
 }
 
+// CStorClusterPlanList is a list of CStorClusterPlan resources
+//
+// +kubebuilder:object:root=true
+type CStorClusterPlanList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CStorClusterPlan `json:"items"`
+}
+
 // CStorClusterPlanSpec has the plan details required to form
 // CStorPoolCluster
 type CStorClusterPlanSpec struct {
 	Nodes []CStorClusterPlanNode `json:"nodes"`
+
+	// Zone is the topology zone of all the nodes of this plan. This
+	// is set only if pools are planned per zone.
+	Zone string `json:"zone,omitempty"`
 }
 
 // CStorClusterPlanNode has the node details that is used to
 // form CStorPoolCluster
 type CStorClusterPlanNode struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
 	Name string    `json:"name"`
 	UID  types.UID `json:"uid"`
 }
@@ -54,7 +76,113 @@ type CStorClusterConfigReference struct {
 // CStorClusterPlan
 type CStorClusterPlanStatus struct {
 	Phase      CStorClusterPlanStatusPhase       `json:"phase"`
-	Conditions []CStorClusterPlanStatusCondition `json:"conditions"`
+	Conditions []CStorClusterPlanStatusCondition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of this CStorClusterPlan
+	// that was last processed. Spec edits are yet to be processed if
+	// this is less than metadata.generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// UnhealthyPools lists the pool instances of the planned
+	// CStorPoolCluster that are offline or degraded
+	UnhealthyPools []CStorClusterPlanUnhealthyPool `json:"unhealthyPools,omitempty"`
+
+	// ReplacedBlockDeviceNames are the block devices that were
+	// replaced by spare block devices while rebuilding unhealthy
+	// pools. These are no longer used to plan pools.
+	ReplacedBlockDeviceNames []string `json:"replacedBlockDeviceNames,omitempty"`
+
+	// AdoptedPools are the pools of a manually created
+	// CStorPoolCluster that was adopted by this plan. These pools
+	// are retained with their block devices even if their nodes are
+	// not planned.
+	AdoptedPools []CStorClusterPlanAdoptedPool `json:"adoptedPools,omitempty"`
+}
+
+// CStorClusterPlanAdoptedPool reports a pool of an adopted
+// CStorPoolCluster
+type CStorClusterPlanAdoptedPool struct {
+	HostName         string   `json:"hostName"`
+	BlockDeviceNames []string `json:"blockDeviceNames"`
+}
+
+// CStorClusterPlanExplain explains the planning of nodes of a
+// CStorClusterPlan. This is set as a JSON annotation against the
+// CStorClusterPlan to verify the planning without access to logs.
+type CStorClusterPlanExplain struct {
+	// EligibleNodeCount is the number of allowed nodes that are
+	// stable & hence can be planned in
+	EligibleNodeCount int64 `json:"eligibleNodeCount"`
+
+	// Min & max pool counts used to plan the nodes. These are
+	// same as the pool count set by the pool autoscaler if
+	// autoscale is enabled.
+	MinPoolCount int64 `json:"minPoolCount"`
+	MaxPoolCount int64 `json:"maxPoolCount"`
+
+	// ObservedNodes are the names of the nodes that were planned
+	// during previous reconciliations
+	ObservedNodes []string `json:"observedNodes,omitempty"`
+
+	// Kept, Added & Removed are the nodes that were retained,
+	// planned in & planned out respectively
+	Kept    []CStorClusterPlanExplainNode `json:"kept,omitempty"`
+	Added   []CStorClusterPlanExplainNode `json:"added,omitempty"`
+	Removed []CStorClusterPlanExplainNode `json:"removed,omitempty"`
+
+	// Deferred are the nodes that were not planned in since the
+	// change budget of the pools was used up
+	Deferred []CStorClusterPlanExplainNode `json:"deferred,omitempty"`
+}
+
+// CStorClusterPlanExplainNode refers to a node along with the
+// reason it was kept, added or removed
+type CStorClusterPlanExplainNode struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+const (
+	// PlanExplainReasonNodeAllowed is used when a planned node
+	// is kept since it is still allowed
+	PlanExplainReasonNodeAllowed string = "Node allowed"
+
+	// PlanExplainReasonNodeMissing is used when a planned node
+	// is kept though it is no longer allowed since it went missing
+	// within the node stability window
+	PlanExplainReasonNodeMissing string = "Node missing within stability window"
+
+	// PlanExplainReasonNodeCordoned is used when a planned node
+	// is kept though it is cordoned since its pool should survive
+	// the maintenance of this node
+	PlanExplainReasonNodeCordoned string = "Node cordoned"
+
+	// PlanExplainReasonAddDeferred is used when a node is not
+	// planned in since the pools added in the last hour have used
+	// up the change budget
+	PlanExplainReasonAddDeferred string = "Addition deferred by change budget"
+
+	// PlanExplainReasonRemoveDeferred is used when a planned node
+	// is kept though it should be planned out since the pools
+	// removed in the last hour have used up the change budget
+	PlanExplainReasonRemoveDeferred string = "Removal deferred by change budget"
+)
+
+// CStorClusterPlanUnhealthyPool reports a pool instance that is
+// offline or degraded
+type CStorClusterPlanUnhealthyPool struct {
+	// Name of the CStorPoolInstance
+	Name     string `json:"name"`
+	HostName string `json:"hostName,omitempty"`
+	Phase    string `json:"phase"`
+
+	// Since is the RFC3339 time at which this pool instance was
+	// first observed to be unhealthy
+	Since string `json:"since"`
+
+	// IsRebuilt is true if the block devices of this pool were
+	// replaced by spare block devices
+	IsRebuilt bool `json:"isRebuilt,omitempty"`
 }
 
 // CStorClusterPlanStatusPhase reports the current phase of
@@ -77,7 +205,12 @@ type CStorClusterPlanStatusCondition struct {
 	Type             ConditionType  `json:"type"`
 	Status           ConditionState `json:"status"`
 	Reason           string         `json:"reason,omitempty"`
+	Message          string         `json:"message,omitempty"`
 	LastObservedTime string         `json:"lastObservedTime"`
+
+	// LastTransitionTime is the time when the status of this
+	// condition last changed
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
 }
 
 // MakeListMapOfPlanNodes returns a slice of maps from
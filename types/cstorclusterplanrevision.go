@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CStorClusterPlanRevision is a kubernetes custom resource that
+// records a single change made to a CStorClusterPlan. These
+// revisions form the audit trail of a CStorClusterPlan.
+//
+// +genclient
+// +genclient:noStatus
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=cstorclusterplanrevisions,singular=cstorclusterplanrevision,shortName=cscplanrev,scope=Namespaced
+type CStorClusterPlanRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec CStorClusterPlanRevisionSpec `json:"spec"`
+}
+
+// CStorClusterPlanRevisionList is a list of CStorClusterPlanRevision resources
+//
+// +kubebuilder:object:root=true
+type CStorClusterPlanRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CStorClusterPlanRevision `json:"items"`
+}
+
+// CStorClusterPlanRevisionSpec has the details of the change
+// made to CStorClusterPlan
+type CStorClusterPlanRevisionSpec struct {
+	// Revision is a monotonically increasing number starting
+	// from 1 for a CStorClusterPlan
+	//
+	// +kubebuilder:validation:Minimum=1
+	Revision int64 `json:"revision"`
+
+	// ConfigGeneration is the generation of CStorClusterConfig
+	// that was observed when this change was made
+	//
+	// +kubebuilder:validation:Minimum=0
+	ConfigGeneration int64 `json:"configGeneration"`
+
+	// Changes lists the nodes that were added to or removed
+	// from CStorClusterPlan
+	Changes []CStorClusterPlanNodeChange `json:"changes"`
+
+	// Nodes are the nodes of CStorClusterPlan after this change
+	Nodes []CStorClusterPlanNode `json:"nodes"`
+
+	// Zone is the topology zone of CStorClusterPlan. This is set
+	// only if pools are planned per zone.
+	Zone string `json:"zone,omitempty"`
+}
+
+// CStorClusterPlanNodeAction represents the action taken
+// against a node of CStorClusterPlan
+//
+// +kubebuilder:validation:Enum=Add;Remove
+type CStorClusterPlanNodeAction string
+
+const (
+	// CStorClusterPlanNodeActionAdd implies the node was added
+	// to CStorClusterPlan
+	CStorClusterPlanNodeActionAdd CStorClusterPlanNodeAction = "Add"
+
+	// CStorClusterPlanNodeActionRemove implies the node was
+	// removed from CStorClusterPlan
+	CStorClusterPlanNodeActionRemove CStorClusterPlanNodeAction = "Remove"
+)
+
+// CStorClusterPlanNodeChange represents a node that was either
+// added to or removed from CStorClusterPlan along with the reason
+type CStorClusterPlanNodeChange struct {
+	Node   CStorClusterPlanNode       `json:"node"`
+	Action CStorClusterPlanNodeAction `json:"action"`
+	Reason string                     `json:"reason"`
+}
+
+const (
+	// PlanRevisionReasonInitialPlan is used when nodes are added
+	// while building the CStorClusterPlan for the first time
+	PlanRevisionReasonInitialPlan string = "Initial plan"
+
+	// PlanRevisionReasonBelowMinPoolCount is used when nodes are
+	// added to satisfy the min pool count
+	PlanRevisionReasonBelowMinPoolCount string = "Below min pool count"
+
+	// PlanRevisionReasonAboveMaxPoolCount is used when nodes are
+	// removed to satisfy the max pool count
+	PlanRevisionReasonAboveMaxPoolCount string = "Above max pool count"
+
+	// PlanRevisionReasonNodeNotFound is used when a planned node
+	// is no more found in the cluster
+	PlanRevisionReasonNodeNotFound string = "Node not found"
+
+	// PlanRevisionReasonNodeNotAllowed is used when a planned node
+	// no more matches the allowed nodes selector
+	PlanRevisionReasonNodeNotAllowed string = "Node not allowed"
+
+	// PlanRevisionReasonNodeReplaced is used when a planned node is
+	// re-created with the same name & a different uid. Old uid is
+	// removed & new uid is added with this reason.
+	PlanRevisionReasonNodeReplaced string = "Node replaced"
+
+	// PlanRevisionReasonPoolDecommission is used when a planned
+	// node is marked for pool decommission
+	PlanRevisionReasonPoolDecommission string = "Pool decommission requested"
+
+	// PlanRevisionReasonAutoscaleUp is used when nodes are added
+	// since the utilization of pools is above the scale up threshold
+	PlanRevisionReasonAutoscaleUp string = "Autoscaled up"
+
+	// PlanRevisionReasonAutoscaleDown is used when nodes are removed
+	// since the utilization of pools is below the scale down threshold
+	PlanRevisionReasonAutoscaleDown string = "Autoscaled down"
+)
+
+// MakeListMapOfPlanNodeChanges returns a slice of maps from
+// the given slice of CStorClusterPlanNodeChange
+func MakeListMapOfPlanNodeChanges(given []CStorClusterPlanNodeChange) []interface{} {
+	var listMap []interface{}
+	for _, change := range given {
+		listMap = append(listMap,
+			map[string]interface{}{
+				"node": map[string]interface{}{
+					"name": change.Node.Name,
+					"uid":  string(change.Node.UID),
+				},
+				"action": string(change.Action),
+				"reason": change.Reason,
+			},
+		)
+	}
+	return listMap
+}
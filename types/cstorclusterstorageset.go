@@ -23,20 +23,50 @@ import (
 
 // CStorClusterStorageSet is a kubernetes custom resource
 // that provisions storage w.r.t. a cstor cluster pool
+//
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=cstorclusterstoragesets,singular=cstorclusterstorageset,shortName=cscstorageset,scope=Namespaced
 type CStorClusterStorageSet struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
 
-	Spec   CStorClusterStorageSetSpec   `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec CStorClusterStorageSetSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
 	Status CStorClusterStorageSetStatus `json:"status"`
 }
 
+// CStorClusterStorageSetList is a list of CStorClusterStorageSet resources
+//
+// +kubebuilder:object:root=true
+type CStorClusterStorageSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CStorClusterStorageSet `json:"items"`
+}
+
 // CStorClusterStorageSetSpec has the storage details required
 // to form CStorPoolCluster's pool capacity
 type CStorClusterStorageSetSpec struct {
 	Node               CStorClusterPlanNode       `json:"node"`
 	Disk               CStorClusterStorageSetDisk `json:"disk"`
 	ExternalDiskConfig ExternalDiskConfig         `json:"externalDiskConfig"`
+	ChildMetadata      *ChildMetadata             `json:"childMetadata,omitempty"`
+
+	// BlockDeviceExclude is copied from CStorClusterConfig's
+	// local disk config
+	//
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	BlockDeviceExclude *BlockDeviceSelector `json:"blockDeviceExclude,omitempty"`
+
+	// TargetNamespace is copied from CStorClusterConfig. Storage(s)
+	// are created in the namespace of this storage set if this is
+	// not set.
+	TargetNamespace string `json:"targetNamespace,omitempty"`
 }
 
 // CStorClusterStorageSetDisk represents storage disk properties
@@ -51,7 +81,59 @@ type CStorClusterStorageSetDisk struct {
 // CStorClusterStorageSet
 type CStorClusterStorageSetStatus struct {
 	Phase      CStorClusterStorageSetStatusPhase       `json:"phase"`
-	Conditions []CStorClusterStorageSetStatusCondition `json:"conditions"`
+	Conditions []CStorClusterStorageSetStatusCondition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the generation of this CStorClusterStorageSet
+	// that was last processed. Spec edits are yet to be processed if
+	// this is less than metadata.generation.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// DesiredDiskCount is the number of disks that should be
+	// attached to the node
+	DesiredDiskCount int64 `json:"desiredDiskCount"`
+
+	// BoundCount is the number of Storage(s) whose PVC is bound
+	// to a PV
+	BoundCount int64 `json:"boundCount"`
+
+	// PendingCount is the number of Storage(s) whose PVC is either
+	// not created or is not yet bound to a PV
+	PendingCount int64 `json:"pendingCount"`
+
+	// Storages reports the progress of each desired Storage
+	Storages []CStorClusterStorageSetStorageStatus `json:"storages,omitempty"`
+
+	// Node reports the disks that got attached to the node
+	Node CStorClusterStorageSetNodeStatus `json:"node"`
+}
+
+// CStorClusterStorageSetStorageStatus represents the current
+// state of a Storage that belongs to a CStorClusterStorageSet
+type CStorClusterStorageSetStorageStatus struct {
+	Name    string `json:"name"`
+	PVCName string `json:"pvcName,omitempty"`
+	PVName  string `json:"pvName,omitempty"`
+	IsBound bool   `json:"isBound"`
+
+	// StorageClassName is the StorageClass that provisioned the PVC
+	// of this Storage. This is the StorageClass desired for this
+	// Storage if its PVC is not yet created.
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// CStorClusterStorageSetNodeStatus represents the disks that
+// are attached to the node of a CStorClusterStorageSet
+//
+// NOTE:
+//	A disk is considered attached once its BlockDevice is
+// associated with the CStorClusterStorageSet. IsReady is true
+// if the attached disk count is at least the desired disk count.
+// This is the same check CStorPoolCluster is formed with.
+type CStorClusterStorageSetNodeStatus struct {
+	Name                     string   `json:"name"`
+	AttachedDiskCount        int64    `json:"attachedDiskCount"`
+	AttachedBlockDeviceNames []string `json:"attachedBlockDeviceNames,omitempty"`
+	IsReady                  bool     `json:"isReady"`
 }
 
 // CStorClusterStorageSetStatusPhase reports the current phase of
@@ -74,5 +156,10 @@ type CStorClusterStorageSetStatusCondition struct {
 	Type             ConditionType  `json:"type"`
 	Status           ConditionState `json:"status"`
 	Reason           string         `json:"reason,omitempty"`
+	Message          string         `json:"message,omitempty"`
 	LastObservedTime string         `json:"lastObservedTime"`
+
+	// LastTransitionTime is the time when the status of this
+	// condition last changed
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
 }
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// CStorPoolAutoInventoryName is the name of the only
+// CStorPoolAutoInventory of the cluster
+const CStorPoolAutoInventoryName string = "cstorpoolauto"
+
+// CStorPoolAutoInventory is a cluster scoped kubernetes custom
+// resource that lists every CStorClusterConfig of the cluster along
+// with its nodes, CStorPoolCluster(s), capacity & health.
+//
+// NOTE:
+//	This is a singleton named CStorPoolAutoInventoryName. It is
+// created & updated by the operator in the background & is not meant
+// to be edited.
+//
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=cstorpoolautoinventories,singular=cstorpoolautoinventory,shortName=cspautoinventory,scope=Cluster
+type CStorPoolAutoInventory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Status CStorPoolAutoInventoryStatus `json:"status"`
+}
+
+// CStorPoolAutoInventoryList is a list of CStorPoolAutoInventory
+// resources
+//
+// +kubebuilder:object:root=true
+type CStorPoolAutoInventoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CStorPoolAutoInventory `json:"items"`
+}
+
+// CStorPoolAutoInventoryStatus has the CStorClusterConfig(s) of the
+// cluster
+type CStorPoolAutoInventoryStatus struct {
+	// ConfigCount is the number of CStorClusterConfig(s)
+	ConfigCount int `json:"configCount"`
+
+	// Total is the total capacity of all the configs
+	Total resource.Quantity `json:"total"`
+
+	// Used is the used capacity of all the configs
+	Used resource.Quantity `json:"used"`
+
+	// Configs are sorted by namespace & name
+	Configs []CStorPoolAutoInventoryConfig `json:"configs,omitempty"`
+
+	// LastUpdateTime is the time when this status last changed
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// CStorPoolAutoInventoryConfig has the details of a
+// CStorClusterConfig
+type CStorPoolAutoInventoryConfig struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+
+	// Nodes are the names of the nodes planned for pools across
+	// the CStorClusterPlan(s) of this config
+	Nodes []string `json:"nodes,omitempty"`
+
+	// CStorPoolClusters are the names of the CStorPoolCluster(s)
+	// of this config
+	CStorPoolClusters []string `json:"cstorPoolClusters,omitempty"`
+
+	// Total is the total capacity of the pool instances
+	Total resource.Quantity `json:"total"`
+
+	// Used is the used capacity of the pool instances
+	Used resource.Quantity `json:"used"`
+
+	Health CStorPoolAutoInventoryHealth `json:"health"`
+
+	// Reason explains the health if it is not Healthy
+	Reason string `json:"reason,omitempty"`
+}
+
+// CStorPoolAutoInventoryHealth reports the health of a
+// CStorClusterConfig
+type CStorPoolAutoInventoryHealth string
+
+const (
+	// CStorPoolAutoInventoryHealthHealthy implies the config & its
+	// plans are online & none of the pools is unhealthy
+	CStorPoolAutoInventoryHealthHealthy CStorPoolAutoInventoryHealth = "Healthy"
+
+	// CStorPoolAutoInventoryHealthDegraded implies some of the pools
+	// of the config are offline or degraded
+	CStorPoolAutoInventoryHealthDegraded CStorPoolAutoInventoryHealth = "Degraded"
+
+	// CStorPoolAutoInventoryHealthError implies the config or any of
+	// its plans failed to reconcile
+	CStorPoolAutoInventoryHealthError CStorPoolAutoInventoryHealth = "Error"
+
+	// CStorPoolAutoInventoryHealthUnknown implies the config is not
+	// reconciled yet
+	CStorPoolAutoInventoryHealthUnknown CStorPoolAutoInventoryHealth = "Unknown"
+)
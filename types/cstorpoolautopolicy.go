@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// CStorPoolAutoPolicy is a cluster scoped kubernetes custom resource
+// that carries the defaults of every CStorClusterConfig it selects.
+// These defaults are set against the fields that are not set in
+// CStorClusterConfig.
+//
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=cstorpoolautopolicies,singular=cstorpoolautopolicy,shortName=cspautopolicy,scope=Cluster
+type CStorPoolAutoPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec CStorPoolAutoPolicySpec `json:"spec"`
+}
+
+// CStorPoolAutoPolicyList is a list of CStorPoolAutoPolicy resources
+//
+// +kubebuilder:object:root=true
+type CStorPoolAutoPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CStorPoolAutoPolicy `json:"items"`
+}
+
+// CStorPoolAutoPolicySpec has the defaults of CStorClusterConfig
+//
+// NOTE:
+//	A CStorClusterConfig is defaulted by the most specific policy
+// i.e. the one with the most labels in its config selector. Policies
+// that are equally specific result in an error.
+type CStorPoolAutoPolicySpec struct {
+	// ConfigSelector selects the CStorClusterConfig(s) whose labels
+	// match all of these labels. All the configs are selected if this
+	// is not set.
+	ConfigSelector map[string]string `json:"configSelector,omitempty"`
+
+	// RAIDType is the default pool RAID type
+	RAIDType PoolRAIDType `json:"raidType,omitempty"`
+
+	// ZFSProperties are the default ZFS properties e.g. compression.
+	// Each property is defaulted on its own.
+	ZFSProperties map[string]ZFSPropertyValue `json:"zfsProperties,omitempty"`
+
+	// ReservePerNode is the default capacity of local disks that is
+	// left unclaimed on every node. This is set only against local
+	// disk configs.
+	ReservePerNode *intstr.IntOrString `json:"reservePerNode,omitempty"`
+
+	// Naming is the default naming of the children of config
+	Naming *Naming `json:"naming,omitempty"`
+
+	// ReclaimPolicy is the default reclaim policy of external disks.
+	// This is set only against external disk configs.
+	ReclaimPolicy ExternalDiskReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package types has the custom resources of dao.mayadata.io group
+// & the helpers to work with these resources.
+//
+// NOTE:
+//	CRDs of these custom resources are generated from the markers
+// set in this package. Run 'make manifests' after changing these
+// structures. Fields are optional unless marked otherwise since
+// controllers set defaults for the fields that are not set.
+//
+// NOTE:
+//	Deepcopy functions of this package & the client library at
+// pkg/client are generated as well. Run 'make generate' after
+// changing these structures.
+//
+// +groupName=dao.mayadata.io
+// +versionName=v1alpha1
+// +kubebuilder:validation:Optional
+// +kubebuilder:object:generate=true
+package types
@@ -33,6 +33,10 @@ const (
 	// custom resources used here
 	VersionV1Alpha1 string = "v1alpha1"
 
+	// VersionV1Beta1 refers to v1beta1 version of the
+	// custom resources used here
+	VersionV1Beta1 string = "v1beta1"
+
 	// VersionV1 refers to v1version of the custom resources
 	// used here
 	VersionV1 string = "v1"
@@ -41,6 +45,10 @@ const (
 	// version of DAO based custom resources
 	APIVersionDAOMayaDataV1Alpha1 string = GroupDAOMayaDataIO + "/" + VersionV1Alpha1
 
+	// APIVersionDAOMayaDataV1Beta1 refers to v1beta1 api
+	// version of DAO based custom resources
+	APIVersionDAOMayaDataV1Beta1 string = GroupDAOMayaDataIO + "/" + VersionV1Beta1
+
 	// APIVersionOpenEBSV1Alpha1 refers to v1alpha1 api
 	// version of openebs based custom resources
 	APIVersionOpenEBSV1Alpha1 string = GroupOpenEBSIO + "/" + VersionV1Alpha1
@@ -63,6 +71,10 @@ const (
 	// kind CStorClusterPlan
 	KindCStorClusterPlan Kind = "CStorClusterPlan"
 
+	// KindCStorClusterPlanRevision refers to custom resource with
+	// kind CStorClusterPlanRevision
+	KindCStorClusterPlanRevision Kind = "CStorClusterPlanRevision"
+
 	// KindCStorClusterStorageSet refers to custom resource with
 	// kind CStorClusterStorageSet
 	KindCStorClusterStorageSet Kind = "CStorClusterStorageSet"
@@ -71,12 +83,28 @@ const (
 	// kind CStorClusterConfig
 	KindCStorClusterConfig Kind = "CStorClusterConfig"
 
+	// KindCStorPoolAutoPolicy refers to custom resource with
+	// kind CStorPoolAutoPolicy
+	KindCStorPoolAutoPolicy Kind = "CStorPoolAutoPolicy"
+
+	// KindCStorPoolAutoInventory refers to custom resource with
+	// kind CStorPoolAutoInventory
+	KindCStorPoolAutoInventory Kind = "CStorPoolAutoInventory"
+
+	// KindBlockDeviceSelectorDryRun refers to custom resource with
+	// kind BlockDeviceSelectorDryRun
+	KindBlockDeviceSelectorDryRun Kind = "BlockDeviceSelectorDryRun"
+
 	// KindStorage refers to custom resource with kind Storage
 	KindStorage Kind = "Storage"
 
 	// KindBlockDevice refers to custom resource with kind BlockDevice
 	KindBlockDevice Kind = "BlockDevice"
 
+	// KindBlockDeviceClaim refers to custom resource with kind
+	// BlockDeviceClaim
+	KindBlockDeviceClaim Kind = "BlockDeviceClaim"
+
 	// KindPersistentVolumeClaim refers to custom resource with kind
 	// PersistentVolumeClaim
 	KindPersistentVolumeClaim Kind = "PersistentVolumeClaim"
@@ -84,4 +112,15 @@ const (
 	// KindCStorPoolCluster refers to custom resource with kind
 	// CStorPoolCluster
 	KindCStorPoolCluster Kind = "CStorPoolCluster"
+
+	// KindCStorPoolInstance refers to custom resource with kind
+	// CStorPoolInstance
+	KindCStorPoolInstance Kind = "CStorPoolInstance"
+
+	// KindEvent refers to kubernetes event (a native resource)
+	KindEvent Kind = "Event"
+
+	// KindStorageClass refers to kubernetes storage class (a native
+	// resource) kind value
+	KindStorageClass Kind = "StorageClass"
 )
@@ -22,13 +22,16 @@ import "github.com/pkg/errors"
 // count for a raid group
 type RaidGroupConfig struct {
 	// Type is the raid group type
-	// Supported values are : stripe, mirror, raidz and raidz2
+	// Supported values are : stripe, mirror, striped-mirror, raidz and raidz2
 	RAIDType PoolRAIDType `json:"raidType"`
 	// GroupDeviceCount contains device count in a raid group
 	// -- for stripe DeviceCount = 1
 	// -- for mirror DeviceCount = 2
+	// -- for striped-mirror DeviceCount = 2 with at least 2 raid groups
 	// -- for raidz DeviceCount = (2^n + 1) default is (2 + 1)
 	// -- for raidz2 DeviceCount = (2^n + 2) default is (4 + 2)
+	//
+	// +kubebuilder:validation:Minimum=0
 	GroupDeviceCount int64 `json:"groupDeviceCount"`
 }
 
@@ -49,10 +52,11 @@ func (rgc *RaidGroupConfig) PopulateDefaultGroupDeviceCountIfNotPresent() error
 	if rgc.GroupDeviceCount != 0 {
 		return nil
 	}
-	dc, ok := RAIDTypeToDefaultMinDiskCount[rgc.RAIDType]
+	dc, ok := RAIDTypeToRAIDGroupDiskCount[rgc.RAIDType]
 	if !ok {
-		return errors.Errorf("Invalid RAID type %q: Supports %q, %q, %q or %q.",
-			rgc.RAIDType, PoolRAIDTypeStripe, PoolRAIDTypeMirror, PoolRAIDTypeRAIDZ, PoolRAIDTypeRAIDZ2)
+		return errors.Errorf("Invalid RAID type %q: Supports %q, %q, %q, %q or %q.",
+			rgc.RAIDType, PoolRAIDTypeStripe, PoolRAIDTypeMirror, PoolRAIDTypeStripedMirror,
+			PoolRAIDTypeRAIDZ, PoolRAIDTypeRAIDZ2)
 	}
 	rgc.GroupDeviceCount = dc
 	return nil
@@ -62,7 +66,7 @@ func (rgc *RaidGroupConfig) PopulateDefaultGroupDeviceCountIfNotPresent() error
 // This is helpfull to calculate pool capacity.
 func (rgc *RaidGroupConfig) GetDataDeviceCount() int64 {
 	switch rgc.RAIDType {
-	case PoolRAIDTypeMirror:
+	case PoolRAIDTypeMirror, PoolRAIDTypeStripedMirror:
 		return rgc.GroupDeviceCount / 2
 	// For stripe pool data device count in is n.
 	// where n block devices present in raid group config
@@ -81,6 +85,27 @@ func (rgc *RaidGroupConfig) GetDataDeviceCount() int64 {
 	}
 }
 
+// GetParityDeviceCount returns the count of devices of one raid
+// group that hold the parity or the mirrored copies of the data.
+// These devices do not add to the usable capacity of a pool.
+func (rgc *RaidGroupConfig) GetParityDeviceCount() int64 {
+	dataDeviceCount := rgc.GetDataDeviceCount()
+	if dataDeviceCount == 0 {
+		return 0
+	}
+	return rgc.GroupDeviceCount - dataDeviceCount
+}
+
+// GetMinRaidGroupCount returns the minimum number of raid groups
+// that form one pool instance of this raid group configuration
+func (rgc *RaidGroupConfig) GetMinRaidGroupCount() int64 {
+	// striped mirror stripes across at least 2 mirrored raid groups
+	if rgc.RAIDType == PoolRAIDTypeStripedMirror {
+		return 2
+	}
+	return 1
+}
+
 // Validate validates RaidGroupConfig
 func (rgc *RaidGroupConfig) Validate() error {
 	// If we got any -ve number or 0 then it an invalid device count.
@@ -89,10 +114,11 @@ func (rgc *RaidGroupConfig) Validate() error {
 			rgc.GroupDeviceCount, rgc.RAIDType)
 	}
 
-	minDeviceCount, ok := RAIDTypeToDefaultMinDiskCount[PoolRAIDType(rgc.RAIDType)]
+	minDeviceCount, ok := RAIDTypeToRAIDGroupDiskCount[PoolRAIDType(rgc.RAIDType)]
 	if !ok {
-		return errors.Errorf("Invalid RAID type %q: Supports %q, %q, %q or %q.",
-			rgc.RAIDType, PoolRAIDTypeStripe, PoolRAIDTypeMirror, PoolRAIDTypeRAIDZ, PoolRAIDTypeRAIDZ2)
+		return errors.Errorf("Invalid RAID type %q: Supports %q, %q, %q, %q or %q.",
+			rgc.RAIDType, PoolRAIDTypeStripe, PoolRAIDTypeMirror, PoolRAIDTypeStripedMirror,
+			PoolRAIDTypeRAIDZ, PoolRAIDTypeRAIDZ2)
 	}
 
 	// If device count is less than min device count then that is not a valid
@@ -105,8 +131,8 @@ func (rgc *RaidGroupConfig) Validate() error {
 	}
 
 	switch rgc.RAIDType {
-	// For mirror pool device count in one vdev is 2
-	case PoolRAIDTypeMirror:
+	// For mirror & striped mirror pools device count in one vdev is 2
+	case PoolRAIDTypeMirror, PoolRAIDTypeStripedMirror:
 		{
 			if rgc.GroupDeviceCount != 2 {
 				return errors.Errorf("Invalid device count %d for RAID type %q: Want 2.",
@@ -146,8 +172,9 @@ func (rgc *RaidGroupConfig) Validate() error {
 		}
 	default:
 		{
-			return errors.Errorf("Invalid RAID type %q: Supports %q, %q, %q or %q.",
-				rgc.RAIDType, PoolRAIDTypeStripe, PoolRAIDTypeMirror, PoolRAIDTypeRAIDZ, PoolRAIDTypeRAIDZ2)
+			return errors.Errorf("Invalid RAID type %q: Supports %q, %q, %q, %q or %q.",
+				rgc.RAIDType, PoolRAIDTypeStripe, PoolRAIDTypeMirror, PoolRAIDTypeStripedMirror,
+				PoolRAIDTypeRAIDZ, PoolRAIDTypeRAIDZ2)
 		}
 	}
 	return nil
@@ -75,6 +75,13 @@ func TestPopulateDefaultGroupDeviceCount(t *testing.T) {
 			expectedGroupDeviceCount: RAIDTypeToDefaultMinDiskCount[PoolRAIDTypeMirror],
 			isErr:                    false,
 		},
+		"striped mirror pool and device count not set": {
+			src: &RaidGroupConfig{
+				RAIDType: PoolRAIDTypeStripedMirror,
+			},
+			expectedGroupDeviceCount: 2,
+			isErr:                    false,
+		},
 		"mirror pool and device count set": {
 			src: &RaidGroupConfig{
 				RAIDType:         PoolRAIDTypeMirror,
@@ -167,6 +174,13 @@ func TestGetDataDeviceCount(t *testing.T) {
 			devicecount:     []int64{2, 4, 6, 8, 10, 12, 14, 16, 18},
 			datadevicecount: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9},
 		},
+		"striped mirror pool": {
+			src: &RaidGroupConfig{
+				RAIDType: PoolRAIDTypeStripedMirror,
+			},
+			devicecount:     []int64{2, 4, 6},
+			datadevicecount: []int64{1, 2, 3},
+		},
 		"raidz pool": {
 			src: &RaidGroupConfig{
 				RAIDType: PoolRAIDTypeRAIDZ,
@@ -200,6 +214,59 @@ func TestGetDataDeviceCount(t *testing.T) {
 	}
 }
 
+func TestGetParityDeviceCount(t *testing.T) {
+	var tests = map[string]struct {
+		src    *RaidGroupConfig
+		expect int64
+	}{
+		"stripe pool": {
+			src: &RaidGroupConfig{
+				RAIDType:         PoolRAIDTypeStripe,
+				GroupDeviceCount: 4,
+			},
+			expect: 0,
+		},
+		"mirror pool": {
+			src: &RaidGroupConfig{
+				RAIDType:         PoolRAIDTypeMirror,
+				GroupDeviceCount: 2,
+			},
+			expect: 1,
+		},
+		"raidz pool": {
+			src: &RaidGroupConfig{
+				RAIDType:         PoolRAIDTypeRAIDZ,
+				GroupDeviceCount: 5,
+			},
+			expect: 1,
+		},
+		"raidz2 pool": {
+			src: &RaidGroupConfig{
+				RAIDType:         PoolRAIDTypeRAIDZ2,
+				GroupDeviceCount: 10,
+			},
+			expect: 2,
+		},
+		"invalid raid type": {
+			src: &RaidGroupConfig{
+				RAIDType:         "raidz3",
+				GroupDeviceCount: 5,
+			},
+			expect: 0,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.src.GetParityDeviceCount()
+			if got != mock.expect {
+				t.Fatalf("Expected parity device count %d got %d", mock.expect, got)
+			}
+		})
+	}
+}
+
 func TestValidate(t *testing.T) {
 	var tests = map[string]struct {
 		src   *RaidGroupConfig
@@ -254,6 +321,20 @@ func TestValidate(t *testing.T) {
 			},
 			isErr: true,
 		},
+		"striped mirror pool and invalid device count": {
+			src: &RaidGroupConfig{
+				RAIDType:         PoolRAIDTypeStripedMirror,
+				GroupDeviceCount: 4,
+			},
+			isErr: true,
+		},
+		"striped mirror pool and valid device count": {
+			src: &RaidGroupConfig{
+				RAIDType:         PoolRAIDTypeStripedMirror,
+				GroupDeviceCount: 2,
+			},
+			isErr: false,
+		},
 		"mirror pool and valid device count": {
 			src: &RaidGroupConfig{
 				RAIDType:         PoolRAIDTypeMirror,
@@ -331,3 +412,45 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMinRaidGroupCount(t *testing.T) {
+	var tests = map[string]struct {
+		src    *RaidGroupConfig
+		expect int64
+	}{
+		"stripe pool": {
+			src: &RaidGroupConfig{
+				RAIDType: PoolRAIDTypeStripe,
+			},
+			expect: 1,
+		},
+		"mirror pool": {
+			src: &RaidGroupConfig{
+				RAIDType: PoolRAIDTypeMirror,
+			},
+			expect: 1,
+		},
+		"striped mirror pool": {
+			src: &RaidGroupConfig{
+				RAIDType: PoolRAIDTypeStripedMirror,
+			},
+			expect: 2,
+		},
+		"raidz pool": {
+			src: &RaidGroupConfig{
+				RAIDType: PoolRAIDTypeRAIDZ,
+			},
+			expect: 1,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := mock.src.GetMinRaidGroupCount()
+			if got != mock.expect {
+				t.Fatalf("Expected min raid group count %d got %d", mock.expect, got)
+			}
+		})
+	}
+}
@@ -48,6 +48,18 @@ type PoolInstanceConfig struct {
 	Node Reference `json:"node"`
 	// Capacity represents capacity for one pool instance
 	Capacity resource.Quantity `json:"capacity"`
+	// RaidGroupCount is the number of raid groups formed out of
+	// the data devices
+	RaidGroupCount int64 `json:"raidGroupCount"`
+	// RawCapacity is the total capacity of the data devices
+	RawCapacity resource.Quantity `json:"rawCapacity"`
+	// UsableCapacity is the capacity left for data after deducting
+	// the parity or mirrored copies of every raid group. This is
+	// same as Capacity.
+	UsableCapacity resource.Quantity `json:"usableCapacity"`
+	// ParityOverhead is the capacity taken by the parity or mirrored
+	// copies of every raid group i.e. raw minus usable capacity
+	ParityOverhead resource.Quantity `json:"parityOverhead"`
 	// DataDevices contains list of data, read-cache and
 	// write-cache block devices associated with the node.
 	BlockDevices BlockDeviceTopology `json:"blockDevices"`
@@ -59,12 +71,10 @@ type BlockDeviceTopology struct {
 	// DataDevices contains list of block devices associated
 	// with the node which will be used for data device.
 	DataDevices []Reference `json:"dataDevices"`
-	// DataDevices contains list of block devices associated
+	// WriteCacheDevices contains list of block devices associated
 	// with the node which will be used for write cache.
 	WriteCacheDevices []Reference `json:"writeCacheDevices"`
-	// Commented it out for now as we - don't introduce a variable
-	// if you are planing to use it in future
-	// DataDevices contains list of block devices associated
+	// ReadCacheDevices contains list of block devices associated
 	// with the node which will be used for read cache.
-	// ReadCacheDevices []Reference `json:"readCacheDevices"`
+	ReadCacheDevices []Reference `json:"readCacheDevices"`
 }
@@ -19,6 +19,7 @@ package types
 import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // CStorPoolClusterRecommendationRequest is a kubernetes custom
@@ -41,12 +42,22 @@ type CStorPoolClusterRecommendationRequestSpec struct {
 	PoolCapacity resource.Quantity `json:"poolCapacity"`
 	// DataConfig represents raid configuration for data devices.
 	DataConfig RaidGroupConfig `json:"dataConfig"`
+	// ReservePerNode represents the raw capacity that is not
+	// recommended on any node. This is either a quantity e.g. 100Gi
+	// or a percentage e.g. 20% of the capacity of all eligible block
+	// devices of a node.
+	ReservePerNode *intstr.IntOrString `json:"reservePerNode,omitempty"`
+	// FreedCStorPoolClusterName is the name of a CStorPoolCluster
+	// that is going to be deleted. Block devices used or claimed by
+	// this CStorPoolCluster are recommended as if they were free.
+	FreedCStorPoolClusterName string `json:"freedCStorPoolClusterName,omitempty"`
+	// DevicePreference decides which of the eligible block devices
+	// are recommended first. Defaults to SmallestFirst.
+	DevicePreference DevicePreference `json:"devicePreference,omitempty"`
 	// WriteCacheConfig represents raid configuration for write cache devices.
 	// If this field is nil then write cache is disabled.
 	WriteCacheConfig *RaidGroupConfig `json:"writeCacheConfig"`
 	// ReadCacheConfig represents raid configuration for read cache devices.
 	// If this field is nil then read cache is disabled.
-	// Commented it out for now as we - don't introduce a variable
-	// if you are planing to use it in future
-	// ReadCacheConfig *RaidGroupConfig `json:"readCacheConfig"`
+	ReadCacheConfig *RaidGroupConfig `json:"readCacheConfig"`
 }
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SchemeGroupVersion is the group version used to register
+// the custom resources of this package
+var SchemeGroupVersion = schema.GroupVersion{
+	Group:   GroupDAOMayaDataIO,
+	Version: VersionV1Alpha1,
+}
+
+var (
+	// SchemeBuilder collects the functions that add the custom
+	// resources of this package to a scheme
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+
+	// AddToScheme adds the custom resources of this package
+	// to the given scheme
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+// Resource takes an unqualified resource and returns a group
+// qualified GroupResource
+func Resource(resource string) schema.GroupResource {
+	return SchemeGroupVersion.WithResource(resource).GroupResource()
+}
+
+// addKnownTypes adds the list of known types to the given scheme
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(
+		SchemeGroupVersion,
+		&CStorClusterConfig{},
+		&CStorClusterConfigList{},
+		&CStorClusterPlan{},
+		&CStorClusterPlanList{},
+		&CStorClusterPlanRevision{},
+		&CStorClusterPlanRevisionList{},
+		&CStorClusterStorageSet{},
+		&CStorClusterStorageSetList{},
+		&CStorPoolAutoPolicy{},
+		&CStorPoolAutoPolicyList{},
+		&CStorPoolAutoInventory{},
+		&CStorPoolAutoInventoryList{},
+		&BlockDeviceSelectorDryRun{},
+		&BlockDeviceSelectorDryRunList{},
+	)
+	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
+	return nil
+}
@@ -18,6 +18,8 @@ package types
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	errs "mayadata.io/cstorpoolauto/pkg/errors"
 )
 
 // TODO(@amitkumardas):
@@ -53,6 +55,46 @@ const (
 	// presence or absence of error while reconciling
 	// the CStorClusterPlan with CStorPoolCluster
 	CStorClusterPlanCSPCApplyErrorCondition ConditionType = "CStorClusterPlanCSPCApplyError"
+
+	// CStorClusterConfigPoolDecommissionCondition is used to indicate
+	// presence or absence of an on-going pool decommission against
+	// one of the nodes that form the CStorClusterConfig
+	CStorClusterConfigPoolDecommissionCondition ConditionType = "CStorClusterConfigPoolDecommission"
+
+	// CStorPoolClusterDriftDetectedCondition is used to indicate
+	// presence or absence of manual edits to the pools of the
+	// generated CStorPoolCluster
+	CStorPoolClusterDriftDetectedCondition ConditionType = "DriftDetected"
+
+	// CStorClusterConfigPausedCondition is used to indicate presence
+	// or absence of a pause in automation of the resources derived
+	// from a CStorClusterConfig
+	CStorClusterConfigPausedCondition ConditionType = "Paused"
+
+	// CStorClusterPlanDegradedCondition is used to indicate presence
+	// or absence of pool instances that stayed offline or degraded
+	// beyond the remediation timeout
+	CStorClusterPlanDegradedCondition ConditionType = "Degraded"
+
+	// CStorClusterPlanRebalanceRecommendedCondition is used to
+	// indicate presence or absence of a raid group count skew across
+	// the pool instances beyond the max raid group skew
+	CStorClusterPlanRebalanceRecommendedCondition ConditionType = "RebalanceRecommended"
+
+	// CStorPoolClusterRAIDTypeChangeRequestedCondition is used to
+	// indicate presence or absence of pools of the generated
+	// CStorPoolCluster whose raid type differs from the desired one
+	CStorPoolClusterRAIDTypeChangeRequestedCondition ConditionType = "RaidTypeChangeRequested"
+
+	// CStorPoolClusterLastSyncDiffCondition is used to indicate
+	// presence or absence of changes that were applied against the
+	// generated CStorPoolCluster by the last sync
+	CStorPoolClusterLastSyncDiffCondition ConditionType = "LastSyncDiff"
+
+	// ReconcileSkippedCondition is used to indicate presence or
+	// absence of a controller that skipped the reconciliation of its
+	// watch. Each controller sets its own condition of this type.
+	ReconcileSkippedCondition ConditionType = "ReconcileSkipped"
 )
 
 // ConditionState is a custom datatype that
@@ -101,10 +143,53 @@ const (
 	BlockDeviceInactive DeviceState = "Inactive"
 )
 
+// DevLinkKind defines the kind of device links i.e. spec.devlinks
+// of BlockDevice
+type DevLinkKind string
+
+const (
+	// DevLinkKindByID represents links based on the serial of
+	// the device i.e. /dev/disk/by-id
+	DevLinkKindByID DevLinkKind = "by-id"
+
+	// DevLinkKindByUUID represents links based on the filesystem
+	// UUID of the device i.e. /dev/disk/by-uuid
+	DevLinkKindByUUID DevLinkKind = "by-uuid"
+
+	// DevLinkKindByPath represents links based on the hardware
+	// path of the device i.e. /dev/disk/by-path
+	DevLinkKindByPath DevLinkKind = "by-path"
+)
+
+// StableDevLinkKinds are the kinds of device links that remain
+// same across node restarts in their order of preference
+var StableDevLinkKinds = []DevLinkKind{
+	DevLinkKindByID,
+	DevLinkKindByUUID,
+}
+
+// BlockDeviceClaimPhase defines the observed phase of
+// BlockDeviceClaim
+type BlockDeviceClaimPhase string
+
+const (
+	// BlockDeviceClaimPending represents a BlockDeviceClaim that
+	// is yet to be bound to its BlockDevice
+	BlockDeviceClaimPending BlockDeviceClaimPhase = "Pending"
+
+	// BlockDeviceClaimBound represents a BlockDeviceClaim that
+	// is bound to its BlockDevice
+	BlockDeviceClaimBound BlockDeviceClaimPhase = "Bound"
+)
+
+// ConditionTimeLayout is the layout of lastObservedTime of the
+// conditions set by this operator
+const ConditionTimeLayout = "2006-01-02 15:04:05.000000"
+
 // now returns the current time in following format
 // 2006-01-02 15:04:05.000000
 func now() string {
-	return metav1.Now().Format("2006-01-02 15:04:05.000000")
+	return metav1.Now().Format(ConditionTimeLayout)
 }
 
 // MakeCStorClusterConfigReconcileErrCond builds a new
@@ -114,7 +199,8 @@ func MakeCStorClusterConfigReconcileErrCond(err error) map[string]interface{} {
 	return map[string]interface{}{
 		"type":             CStorClusterConfigReconcileErrorCondition,
 		"status":           ConditionIsPresent,
-		"reason":           err.Error(),
+		"reason":           errs.Reason(err),
+		"message":          err.Error(),
 		"lastObservedTime": now(),
 	}
 }
@@ -126,7 +212,8 @@ func MakeCStorClusterPlanReconcileErrCond(err error) map[string]interface{} {
 	return map[string]interface{}{
 		"type":             CStorClusterPlanReconcileErrorCondition,
 		"status":           ConditionIsPresent,
-		"reason":           err.Error(),
+		"reason":           errs.Reason(err),
+		"message":          err.Error(),
 		"lastObservedTime": now(),
 	}
 }
@@ -138,7 +225,8 @@ func MakeCStorClusterStorageSetReconcileErrCond(err error) map[string]interface{
 	return map[string]interface{}{
 		"type":             CStorClusterStorageSetReconcileErrorCondition,
 		"status":           ConditionIsPresent,
-		"reason":           err.Error(),
+		"reason":           errs.Reason(err),
+		"message":          err.Error(),
 		"lastObservedTime": now(),
 	}
 }
@@ -150,7 +238,8 @@ func MakeCStorClusterPlanCSPCApplyErrCond(err error) map[string]interface{} {
 	return map[string]interface{}{
 		"type":             CStorClusterPlanCSPCApplyErrorCondition,
 		"status":           ConditionIsPresent,
-		"reason":           err.Error(),
+		"reason":           errs.Reason(err),
+		"message":          err.Error(),
 		"lastObservedTime": now(),
 	}
 }
@@ -162,7 +251,162 @@ func MakeStorageToBlockDeviceAssociationErrCond(err error) map[string]interface{
 	return map[string]interface{}{
 		"type":             StorageToBlockDeviceAssociationErrorCondition,
 		"status":           ConditionIsPresent,
-		"reason":           err.Error(),
+		"reason":           errs.Reason(err),
+		"message":          err.Error(),
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeCStorClusterConfigPoolDecommissionCond builds a new
+// CStorClusterConfigPoolDecommissionCondition suitable to be
+// used in API status.conditions
+//
+// NOTE:
+//	Condition is present till the pool is decommissioned from the
+// given node & is absent once the decommission completes
+func MakeCStorClusterConfigPoolDecommissionCond(
+	nodeName string, isPending bool,
+) map[string]interface{} {
+	var status = ConditionIsAbsent
+	var reason = "Pool decommissioned from node " + nodeName
+	if isPending {
+		status = ConditionIsPresent
+		reason = "Pool decommission in progress for node " + nodeName
+	}
+	return map[string]interface{}{
+		"type":             string(CStorClusterConfigPoolDecommissionCondition),
+		"status":           string(status),
+		"reason":           reason,
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeCStorPoolClusterDriftDetectedCond builds a new
+// CStorPoolClusterDriftDetectedCondition suitable to be used
+// in API status.conditions
+func MakeCStorPoolClusterDriftDetectedCond(
+	isDrifted bool, reason string,
+) map[string]interface{} {
+	var status = ConditionIsAbsent
+	if isDrifted {
+		status = ConditionIsPresent
+	}
+	return map[string]interface{}{
+		"type":             string(CStorPoolClusterDriftDetectedCondition),
+		"status":           string(status),
+		"reason":           reason,
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeCStorPoolClusterLastSyncDiffCond builds a new
+// CStorPoolClusterLastSyncDiffCondition suitable to be used in API
+// status.conditions
+func MakeCStorPoolClusterLastSyncDiffCond(
+	hasDiff bool, reason string,
+) map[string]interface{} {
+	var status = ConditionIsAbsent
+	if hasDiff {
+		status = ConditionIsPresent
+	}
+	return map[string]interface{}{
+		"type":             string(CStorPoolClusterLastSyncDiffCondition),
+		"status":           string(status),
+		"reason":           reason,
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeCStorClusterPlanDegradedCond builds a new
+// CStorClusterPlanDegradedCondition suitable to be used in API
+// status.conditions
+func MakeCStorClusterPlanDegradedCond(
+	isDegraded bool, reason string,
+) map[string]interface{} {
+	var status = ConditionIsAbsent
+	if isDegraded {
+		status = ConditionIsPresent
+	}
+	return map[string]interface{}{
+		"type":             string(CStorClusterPlanDegradedCondition),
+		"status":           string(status),
+		"reason":           reason,
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeCStorClusterPlanRebalanceRecommendedCond builds a new
+// CStorClusterPlanRebalanceRecommendedCondition suitable to be
+// used in API status.conditions
+func MakeCStorClusterPlanRebalanceRecommendedCond(
+	isSkewed bool, reason string,
+) map[string]interface{} {
+	var status = ConditionIsAbsent
+	if isSkewed {
+		status = ConditionIsPresent
+	}
+	return map[string]interface{}{
+		"type":             string(CStorClusterPlanRebalanceRecommendedCondition),
+		"status":           string(status),
+		"reason":           reason,
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeCStorPoolClusterRAIDTypeChangeRequestedCond builds a new
+// CStorPoolClusterRAIDTypeChangeRequestedCondition suitable to be
+// used in API status.conditions
+func MakeCStorPoolClusterRAIDTypeChangeRequestedCond(
+	isRequested bool, reason string,
+) map[string]interface{} {
+	var status = ConditionIsAbsent
+	if isRequested {
+		status = ConditionIsPresent
+	}
+	return map[string]interface{}{
+		"type":             string(CStorPoolClusterRAIDTypeChangeRequestedCondition),
+		"status":           string(status),
+		"reason":           reason,
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeCStorClusterConfigPausedCond builds a new
+// CStorClusterConfigPausedCondition suitable to be used in API
+// status.conditions
+//
+// NOTE:
+//	Condition is present while the automation is paused & is absent
+// once the automation is resumed
+func MakeCStorClusterConfigPausedCond(isPaused bool) map[string]interface{} {
+	var status = ConditionIsAbsent
+	var reason = "Automation resumed"
+	if isPaused {
+		status = ConditionIsPresent
+		reason = "Automation paused via annotation " + AnnKeyCStorClusterConfigPaused
+	}
+	return map[string]interface{}{
+		"type":             string(CStorClusterConfigPausedCondition),
+		"status":           string(status),
+		"reason":           reason,
+		"lastObservedTime": now(),
+	}
+}
+
+// MakeReconcileSkippedCond builds a new ReconcileSkippedCondition
+// suitable to be used in API status.conditions
+//
+// NOTE:
+//	Reason is a machine readable code while message is meant for
+// humans. Condition is present while the given controller skips its
+// reconciliation & is removed once this controller reconciles.
+func MakeReconcileSkippedCond(controller, reason, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":             string(ReconcileSkippedCondition),
+		"status":           string(ConditionIsPresent),
+		"controller":       controller,
+		"reason":           reason,
+		"message":          message,
 		"lastObservedTime": now(),
 	}
 }
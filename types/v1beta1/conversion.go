@@ -0,0 +1,247 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// ConvertToV1Alpha1 converts the given v1beta1 CStorClusterConfig
+// into the given v1alpha1 CStorClusterConfig
+func ConvertToV1Alpha1(in *CStorClusterConfig, out *types.CStorClusterConfig) {
+	in = in.DeepCopy()
+	out.TypeMeta = in.TypeMeta
+	if out.APIVersion != "" {
+		out.APIVersion = types.APIVersionDAOMayaDataV1Alpha1
+	}
+	out.ObjectMeta = in.ObjectMeta
+	out.Spec = types.CStorClusterConfigSpec{
+		MinPoolCount:    in.Spec.Pools.MinCount,
+		MaxPoolCount:    in.Spec.Pools.MaxCount,
+		AllowedNodes:    in.Spec.Nodes.Allowed,
+		DiskConfig:      in.Spec.Disks,
+		PoolConfig:      in.Spec.Pools.Config,
+		ChildMetadata:   in.Spec.Children.Metadata,
+		DriftPolicy:     in.Spec.Pools.DriftPolicy,
+		Autoscale:       in.Spec.Pools.Autoscale,
+		TargetNamespace: in.Spec.Children.TargetNamespace,
+		Remediation:     in.Spec.Pools.Remediation,
+		Rebalance:       in.Spec.Pools.Rebalance,
+		PlacementHints:  in.Spec.Pools.PlacementHints,
+		StorageClass:    in.Spec.Children.StorageClass,
+		Naming:          in.Spec.Children.Naming,
+		OutputMode:      in.Spec.Children.OutputMode,
+		Reconcile:       in.Spec.Children.Reconcile,
+		Overlays:        in.Spec.Children.Overlays,
+
+		AllowControlPlaneNodes: in.Spec.Nodes.AllowControlPlane,
+	}
+	out.Status = in.Status
+}
+
+// ConvertFromV1Alpha1 converts the given v1alpha1 CStorClusterConfig
+// into the given v1beta1 CStorClusterConfig
+func ConvertFromV1Alpha1(in *types.CStorClusterConfig, out *CStorClusterConfig) {
+	in = in.DeepCopy()
+	out.TypeMeta = in.TypeMeta
+	if out.APIVersion != "" {
+		out.APIVersion = types.APIVersionDAOMayaDataV1Beta1
+	}
+	out.ObjectMeta = in.ObjectMeta
+	out.Spec = CStorClusterConfigSpec{
+		Pools: Pools{
+			MinCount:    in.Spec.MinPoolCount,
+			MaxCount:    in.Spec.MaxPoolCount,
+			Config:      in.Spec.PoolConfig,
+			DriftPolicy: in.Spec.DriftPolicy,
+			Autoscale:   in.Spec.Autoscale,
+			Remediation: in.Spec.Remediation,
+			Rebalance:   in.Spec.Rebalance,
+
+			PlacementHints: in.Spec.PlacementHints,
+		},
+		Nodes: Nodes{
+			Allowed:           in.Spec.AllowedNodes,
+			AllowControlPlane: in.Spec.AllowControlPlaneNodes,
+		},
+		Disks: in.Spec.DiskConfig,
+		Children: Children{
+			Metadata:        in.Spec.ChildMetadata,
+			TargetNamespace: in.Spec.TargetNamespace,
+			StorageClass:    in.Spec.StorageClass,
+			Naming:          in.Spec.Naming,
+			OutputMode:      in.Spec.OutputMode,
+			Reconcile:       in.Spec.Reconcile,
+			Overlays:        in.Spec.Overlays,
+		},
+	}
+	out.Status = in.Status
+}
+
+// fieldPath maps a spec field of v1alpha1 to the same field of
+// v1beta1
+type fieldPath struct {
+	v1alpha1 []string
+	v1beta1  []string
+}
+
+// fieldPaths has every spec field that is placed differently in
+// v1alpha1 & v1beta1
+//
+// NOTE:
+//	This needs to be in sync with the conversion of typed objects.
+// Round trip tests verify the same.
+var fieldPaths = []fieldPath{
+	{[]string{"spec", "minPoolCount"}, []string{"spec", "pools", "minCount"}},
+	{[]string{"spec", "maxPoolCount"}, []string{"spec", "pools", "maxCount"}},
+	{[]string{"spec", "poolConfig"}, []string{"spec", "pools", "config"}},
+	{[]string{"spec", "driftPolicy"}, []string{"spec", "pools", "driftPolicy"}},
+	{[]string{"spec", "autoscale"}, []string{"spec", "pools", "autoscale"}},
+	{[]string{"spec", "remediation"}, []string{"spec", "pools", "remediation"}},
+	{[]string{"spec", "rebalance"}, []string{"spec", "pools", "rebalance"}},
+	{[]string{"spec", "placementHints"}, []string{"spec", "pools", "placementHints"}},
+	{[]string{"spec", "allowedNodes"}, []string{"spec", "nodes", "allowed"}},
+	{[]string{"spec", "allowControlPlaneNodes"}, []string{"spec", "nodes", "allowControlPlane"}},
+	{[]string{"spec", "diskConfig"}, []string{"spec", "disks"}},
+	{[]string{"spec", "childMetadata"}, []string{"spec", "children", "metadata"}},
+	{[]string{"spec", "targetNamespace"}, []string{"spec", "children", "targetNamespace"}},
+	{[]string{"spec", "storageClass"}, []string{"spec", "children", "storageClass"}},
+	{[]string{"spec", "naming"}, []string{"spec", "children", "naming"}},
+	{[]string{"spec", "outputMode"}, []string{"spec", "children", "outputMode"}},
+	{[]string{"spec", "reconcile"}, []string{"spec", "children", "reconcile"}},
+	{[]string{"spec", "overlays"}, []string{"spec", "children", "overlays"}},
+}
+
+// groupKeys are the spec fields of v1beta1 that group the spec
+// fields of v1alpha1
+var groupKeys = []string{"pools", "nodes", "disks", "children"}
+
+// IsV1Beta1Shaped returns true if the spec of the given unstructured
+// CStorClusterConfig is in the shape of v1beta1
+//
+// NOTE:
+//	CRD does not make use of a conversion webhook. API server hence
+// serves the stored object under either version. Shape of the spec
+// & not the api version decides the version of an object.
+func IsV1Beta1Shaped(obj *unstructured.Unstructured) bool {
+	if obj == nil {
+		return false
+	}
+	spec, ok := obj.Object["spec"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, key := range groupKeys {
+		if _, found := spec[key]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// ToV1Alpha1Unstructured returns a copy of the given unstructured
+// CStorClusterConfig with its spec in the shape of v1alpha1
+//
+// NOTE:
+//	Spec fields that are not known to this version are left as is.
+// Api version is left as is as well since it is the version the
+// object was served with.
+func ToV1Alpha1Unstructured(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if obj == nil {
+		return nil, errors.Errorf("Can't convert to v1alpha1: Nil CStorClusterConfig")
+	}
+	converted := obj.DeepCopy()
+	for _, path := range fieldPaths {
+		err := moveField(converted.Object, path.v1beta1, path.v1alpha1)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err,
+				"Can't convert CStorClusterConfig %s %s to v1alpha1",
+				obj.GetNamespace(), obj.GetName(),
+			)
+		}
+	}
+	// groups that have no unknown fields left are removed
+	spec, _ := converted.Object["spec"].(map[string]interface{})
+	for _, key := range groupKeys {
+		group, ok := spec[key].(map[string]interface{})
+		if ok && len(group) == 0 {
+			delete(spec, key)
+		}
+	}
+	return converted, nil
+}
+
+// FromV1Alpha1Unstructured returns a copy of the given unstructured
+// CStorClusterConfig with its spec in the shape of v1beta1
+//
+// NOTE:
+//	Given object may have only a few of its spec fields set e.g.
+// a desired state that gets merged with the observed state. Only
+// the fields that are set get converted.
+func FromV1Alpha1Unstructured(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if obj == nil {
+		return nil, errors.Errorf("Can't convert to v1beta1: Nil CStorClusterConfig")
+	}
+	converted := obj.DeepCopy()
+	for _, path := range fieldPaths {
+		err := moveField(converted.Object, path.v1alpha1, path.v1beta1)
+		if err != nil {
+			return nil, errors.Wrapf(
+				err,
+				"Can't convert CStorClusterConfig %s %s to v1beta1",
+				obj.GetNamespace(), obj.GetName(),
+			)
+		}
+	}
+	return converted, nil
+}
+
+// moveField moves the value at the given source path to the given
+// destination path. Nothing is done if source path is not set.
+func moveField(obj map[string]interface{}, from, to []string) error {
+	val, found, err := unstructured.NestedFieldNoCopy(obj, from...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return nil
+	}
+	unstructured.RemoveNestedField(obj, from...)
+	// value is not copied since it was removed from source path
+	parent := obj
+	for idx, key := range to[:len(to)-1] {
+		child, found := parent[key]
+		if !found || child == nil {
+			child = map[string]interface{}{}
+			parent[key] = child
+		}
+		childMap, ok := child.(map[string]interface{})
+		if !ok {
+			return errors.Errorf(
+				"Can't set %v: Expected map at %v got %T",
+				to, to[:idx+1], child,
+			)
+		}
+		parent = childMap
+	}
+	parent[to[len(to)-1]] = val
+	return nil
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// fuzzIterations is the number of random objects verified by
+// each round trip test
+const fuzzIterations = 500
+
+// newFuzzer returns a fuzzer that fills the objects with values
+// that survive json marshaling
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	return fuzz.NewWithSeed(seed).NilChance(0.2).NumElements(0, 3).Funcs(
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = *resource.NewQuantity(c.Int63n(1000), resource.DecimalSI)
+		},
+		func(t *metav1.Time, c fuzz.Continue) {
+			*t = metav1.Unix(c.Int63n(1<<31), 0).Rfc3339Copy()
+		},
+		func(v *intstr.IntOrString, c fuzz.Continue) {
+			if c.RandBool() {
+				*v = intstr.FromInt(c.Intn(100))
+			} else {
+				*v = intstr.FromString(c.RandString())
+			}
+		},
+		func(m *metav1.ObjectMeta, c fuzz.Continue) {
+			// metadata is copied as is by conversions
+			m.Name = c.RandString()
+			m.Namespace = c.RandString()
+			c.Fuzz(&m.Labels)
+			c.Fuzz(&m.Annotations)
+		},
+	)
+}
+
+func TestConvertV1Alpha1RoundTrip(t *testing.T) {
+	f := newFuzzer(1)
+	for i := 0; i < fuzzIterations; i++ {
+		original := &types.CStorClusterConfig{}
+		f.Fuzz(original)
+		original.TypeMeta = metav1.TypeMeta{
+			APIVersion: types.APIVersionDAOMayaDataV1Alpha1,
+			Kind:       string(types.KindCStorClusterConfig),
+		}
+		beta := &CStorClusterConfig{}
+		ConvertFromV1Alpha1(original, beta)
+		if beta.APIVersion != types.APIVersionDAOMayaDataV1Beta1 {
+			t.Fatalf("Expected api version %q got %q",
+				types.APIVersionDAOMayaDataV1Beta1, beta.APIVersion)
+		}
+		got := &types.CStorClusterConfig{}
+		ConvertToV1Alpha1(beta, got)
+		if !equality.Semantic.DeepEqual(original, got) {
+			t.Fatalf("Expected round trip of v1alpha1 to be lossless:\nwant %+v\ngot  %+v", original, got)
+		}
+	}
+}
+
+func TestConvertV1Beta1RoundTrip(t *testing.T) {
+	f := newFuzzer(2)
+	for i := 0; i < fuzzIterations; i++ {
+		original := &CStorClusterConfig{}
+		f.Fuzz(original)
+		original.TypeMeta = metav1.TypeMeta{
+			APIVersion: types.APIVersionDAOMayaDataV1Beta1,
+			Kind:       string(types.KindCStorClusterConfig),
+		}
+		alpha := &types.CStorClusterConfig{}
+		ConvertToV1Alpha1(original, alpha)
+		if alpha.APIVersion != types.APIVersionDAOMayaDataV1Alpha1 {
+			t.Fatalf("Expected api version %q got %q",
+				types.APIVersionDAOMayaDataV1Alpha1, alpha.APIVersion)
+		}
+		got := &CStorClusterConfig{}
+		ConvertFromV1Alpha1(alpha, got)
+		if !equality.Semantic.DeepEqual(original, got) {
+			t.Fatalf("Expected round trip of v1beta1 to be lossless:\nwant %+v\ngot  %+v", original, got)
+		}
+	}
+}
+
+func TestConvertDoesNotShareMemory(t *testing.T) {
+	alpha := &types.CStorClusterConfig{
+		Spec: types.CStorClusterConfigSpec{
+			Autoscale: &types.Autoscale{ScaleUpThreshold: 80},
+		},
+	}
+	beta := &CStorClusterConfig{}
+	ConvertFromV1Alpha1(alpha, beta)
+	beta.Spec.Pools.Autoscale.ScaleUpThreshold = 90
+	if alpha.Spec.Autoscale.ScaleUpThreshold != 80 {
+		t.Fatalf("Expected source to be left as is got threshold %d",
+			alpha.Spec.Autoscale.ScaleUpThreshold)
+	}
+}
+
+// toUnstructured marshals the given typed object the way clientset
+// does & returns it as unstructured
+func toUnstructured(t *testing.T, obj interface{}) *unstructured.Unstructured {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatalf("Can't marshal: %+v", err)
+	}
+	u := &unstructured.Unstructured{}
+	err = u.UnmarshalJSON(raw)
+	if err != nil {
+		t.Fatalf("Can't unmarshal: %+v", err)
+	}
+	return u
+}
+
+// fromUnstructured unmarshals the given unstructured object into
+// the given typed object
+func fromUnstructured(t *testing.T, u *unstructured.Unstructured, obj interface{}) {
+	raw, err := u.MarshalJSON()
+	if err != nil {
+		t.Fatalf("Can't marshal: %+v", err)
+	}
+	err = json.Unmarshal(raw, obj)
+	if err != nil {
+		t.Fatalf("Can't unmarshal: %+v", err)
+	}
+}
+
+func TestUnstructuredConversionMatchesTypedConversion(t *testing.T) {
+	f := newFuzzer(3)
+	for i := 0; i < fuzzIterations; i++ {
+		alpha := &types.CStorClusterConfig{}
+		f.Fuzz(alpha)
+		alpha.TypeMeta = metav1.TypeMeta{
+			APIVersion: types.APIVersionDAOMayaDataV1Alpha1,
+			Kind:       string(types.KindCStorClusterConfig),
+		}
+		want := &CStorClusterConfig{}
+		ConvertFromV1Alpha1(alpha, want)
+
+		converted, err := FromV1Alpha1Unstructured(toUnstructured(t, alpha))
+		if err != nil {
+			t.Fatalf("Expected no error got %+v", err)
+		}
+		if !IsV1Beta1Shaped(converted) {
+			t.Fatalf("Expected v1beta1 shape got %+v", converted.Object["spec"])
+		}
+		got := &CStorClusterConfig{}
+		fromUnstructured(t, converted, got)
+		// unstructured conversion leaves the api version as is
+		got.APIVersion = want.APIVersion
+		if !equality.Semantic.DeepEqual(want, got) {
+			t.Fatalf("Expected v1beta1 conversions to match:\nwant %+v\ngot  %+v", want, got)
+		}
+
+		reverted, err := ToV1Alpha1Unstructured(converted)
+		if err != nil {
+			t.Fatalf("Expected no error got %+v", err)
+		}
+		if IsV1Beta1Shaped(reverted) {
+			t.Fatalf("Expected v1alpha1 shape got %+v", reverted.Object["spec"])
+		}
+		gotAlpha := &types.CStorClusterConfig{}
+		fromUnstructured(t, reverted, gotAlpha)
+		if !equality.Semantic.DeepEqual(alpha, gotAlpha) {
+			t.Fatalf("Expected v1alpha1 conversions to match:\nwant %+v\ngot  %+v", alpha, gotAlpha)
+		}
+	}
+}
+
+func TestFromV1Alpha1Unstructured(t *testing.T) {
+	var tests = map[string]struct {
+		spec   map[string]interface{}
+		expect map[string]interface{}
+	}{
+		"partial spec": {
+			spec: map[string]interface{}{
+				"poolConfig": map[string]interface{}{
+					"raidType": "mirror",
+				},
+				"driftPolicy":     "Warn",
+				"targetNamespace": "storage",
+			},
+			expect: map[string]interface{}{
+				"pools": map[string]interface{}{
+					"config": map[string]interface{}{
+						"raidType": "mirror",
+					},
+					"driftPolicy": "Warn",
+				},
+				"children": map[string]interface{}{
+					"targetNamespace": "storage",
+				},
+			},
+		},
+		"unknown fields are left as is": {
+			spec: map[string]interface{}{
+				"minPoolCount": int64(3),
+				"cacheDevices": "nvme",
+			},
+			expect: map[string]interface{}{
+				"pools": map[string]interface{}{
+					"minCount": int64(3),
+				},
+				"cacheDevices": "nvme",
+			},
+		},
+		"empty spec": {
+			spec:   map[string]interface{}{},
+			expect: map[string]interface{}{},
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": types.APIVersionDAOMayaDataV1Beta1,
+					"kind":       string(types.KindCStorClusterConfig),
+					"spec":       mock.spec,
+				},
+			}
+			got, err := FromV1Alpha1Unstructured(obj)
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if !equality.Semantic.DeepEqual(got.Object["spec"], mock.expect) {
+				t.Fatalf("Expected spec %+v got %+v", mock.expect, got.Object["spec"])
+			}
+			reverted, err := ToV1Alpha1Unstructured(got)
+			if err != nil {
+				t.Fatalf("Expected no error got %+v", err)
+			}
+			if !equality.Semantic.DeepEqual(reverted.Object["spec"], mock.spec) {
+				t.Fatalf("Expected reverted spec %+v got %+v", mock.spec, reverted.Object["spec"])
+			}
+		})
+	}
+}
+
+func TestFromV1Alpha1UnstructuredInvalidGroup(t *testing.T) {
+	obj := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"pools":        "three",
+				"minPoolCount": int64(3),
+			},
+		},
+	}
+	_, err := FromV1Alpha1Unstructured(obj)
+	if err == nil {
+		t.Fatalf("Expected error got none")
+	}
+}
+
+func TestIsV1Beta1Shaped(t *testing.T) {
+	var tests = map[string]struct {
+		obj    *unstructured.Unstructured
+		expect bool
+	}{
+		"nil object": {},
+		"no spec": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{}},
+		},
+		"v1alpha1 spec": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"spec": map[string]interface{}{
+						"diskConfig": map[string]interface{}{},
+					},
+				},
+			},
+		},
+		"v1beta1 spec served as v1alpha1": {
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": types.APIVersionDAOMayaDataV1Alpha1,
+					"spec": map[string]interface{}{
+						"disks": map[string]interface{}{},
+					},
+				},
+			},
+			expect: true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			got := IsV1Beta1Shaped(mock.obj)
+			if got != mock.expect {
+				t.Fatalf("Expected %t got %t", mock.expect, got)
+			}
+		})
+	}
+}
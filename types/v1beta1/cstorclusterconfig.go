@@ -0,0 +1,163 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"mayadata.io/cstorpoolauto/types"
+)
+
+// CStorClusterConfig is a kubernetes custom resource that defines
+// the specifications to manage CStorPoolCluster (i.e. CSPC)
+//
+// NOTE:
+// 	This is a user facing custom resource. Its spec groups the
+// options of v1alpha1 by the resources these options apply to.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=cstorclusterconfigs,singular=cstorclusterconfig,shortName=cscconfig,scope=Namespaced
+type CStorClusterConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec CStorClusterConfigSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Status types.CStorClusterConfigStatus `json:"status"`
+}
+
+// CStorClusterConfigList is a list of CStorClusterConfig resources
+//
+// +kubebuilder:object:root=true
+type CStorClusterConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []CStorClusterConfig `json:"items"`
+}
+
+// CStorClusterConfigSpec defines the configuration required
+// to setup and manage cstor pool cluster
+type CStorClusterConfigSpec struct {
+	// Pools has the options to size, configure & heal the pools
+	Pools Pools `json:"pools"`
+
+	// Nodes has the options to select the nodes of the pools
+	Nodes Nodes `json:"nodes"`
+
+	// Disks has the options to select the disks of the pools
+	Disks types.DiskConfig `json:"disks"`
+
+	// Children has the options to name & place the resources
+	// created due to this config
+	Children Children `json:"children"`
+}
+
+// Pools provides the options to size, configure & heal the pools
+// of a CStorClusterConfig
+type Pools struct {
+	MinCount resource.Quantity `json:"minCount"`
+	MaxCount resource.Quantity `json:"maxCount"`
+
+	Config types.PoolConfig `json:"config"`
+
+	// DriftPolicy decides how manual edits to the pools of the
+	// generated CStorPoolCluster are handled. Defaults to Enforce.
+	DriftPolicy types.DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// Autoscale lets the pool count be scaled based on the
+	// utilization of pools. Pool count is bounded by min & max
+	// pool counts.
+	Autoscale *types.Autoscale `json:"autoscale,omitempty"`
+
+	// Remediation lets the pool instances that stay offline or
+	// degraded be reported & optionally rebuilt. Pool instances are
+	// not remediated if this is not set.
+	Remediation *types.Remediation `json:"remediation,omitempty"`
+
+	// Rebalance lets the raid group count skew across the pool
+	// instances be detected. Skew is not checked if this is not set.
+	Rebalance *types.Rebalance `json:"rebalance,omitempty"`
+
+	// PlacementHints lets the pool instances with the most free
+	// capacity be hinted for the replicas of new volumes when the
+	// pool instances are imbalanced in free capacity. Hints are not
+	// emitted if this is not set.
+	PlacementHints *types.PlacementHints `json:"placementHints,omitempty"`
+}
+
+// Nodes provides the options to select the nodes of the pools
+// of a CStorClusterConfig
+type Nodes struct {
+	// NOTE:
+	//	Selectors are owned by metac & are validated by metac
+	// while selecting the resources. Hence these are not part
+	// of generated CRD schema.
+	//
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Allowed types.AllowedNodes `json:"allowed"`
+
+	// AllowControlPlane when true lets the pools be planned on the
+	// control plane nodes i.e. the nodes with label or taint
+	// node-role.kubernetes.io/control-plane or master. Only worker
+	// nodes are planned if this is not set.
+	AllowControlPlane bool `json:"allowControlPlane,omitempty"`
+}
+
+// Children provides the options to name & place the resources
+// created due to a CStorClusterConfig
+type Children struct {
+	// Metadata has the labels & annotations that get propagated
+	// to every resource created due to this config
+	Metadata *types.ChildMetadata `json:"metadata,omitempty"`
+
+	// TargetNamespace is the namespace where CStorPoolCluster &
+	// Storage(s) of this config are created. Defaults to openebs.
+	//
+	// NOTE:
+	//	Children that were already created are never moved to a
+	// different namespace
+	TargetNamespace string `json:"targetNamespace,omitempty"`
+
+	// StorageClass lets a cStor CSI StorageClass be created for the
+	// CStorPoolCluster of this config. StorageClass is not created
+	// if this is not set.
+	StorageClass *types.StorageClass `json:"storageClass,omitempty"`
+
+	// Naming lets the names of CStorClusterPlan, CStorPoolCluster &
+	// StorageClass of this config be customised. These children are
+	// named after this config if this is not set.
+	Naming *types.Naming `json:"naming,omitempty"`
+
+	// OutputMode decides if the generated CStorPoolCluster is applied
+	// or is exported into a ConfigMap as Helm values & Kustomize patch.
+	// Defaults to Apply.
+	OutputMode types.OutputMode `json:"outputMode,omitempty"`
+
+	// Reconcile lets some fields of the generated CStorPoolCluster
+	// be owned by the users. These fields are never overwritten.
+	Reconcile *types.Reconcile `json:"reconcile,omitempty"`
+
+	// Overlays are the patches applied to the generated children
+	// of this config. These let the fields that are not modelled by
+	// this config be set against the children.
+	Overlays []types.Overlay `json:"overlays,omitempty"`
+}
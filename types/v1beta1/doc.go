@@ -0,0 +1,34 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1beta1 has the v1beta1 version of CStorClusterConfig
+// & the functions to convert it to & from v1alpha1.
+//
+// NOTE:
+//	v1alpha1 at package types is the hub version. Controllers work
+// with the v1alpha1 shape of CStorClusterConfig only & convert
+// v1beta1 objects to this shape before processing them.
+//
+// NOTE:
+//	v1beta1 regroups the spec fields of v1alpha1 without adding or
+// removing any field. Conversion between these versions is hence
+// lossless.
+//
+// +groupName=dao.mayadata.io
+// +versionName=v1beta1
+// +kubebuilder:validation:Optional
+// +kubebuilder:object:generate=true
+package v1beta1
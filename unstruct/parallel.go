@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unstruct
+
+import (
+	"runtime"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+	"openebs.io/metac/controller/common/selector"
+)
+
+var (
+	// SelectWorkerCount is the number of workers used to
+	// evaluate selector terms in parallel
+	SelectWorkerCount = runtime.NumCPU()
+
+	// SelectParallelThreshold is the minimum number of objects
+	// that need to be evaluated for the evaluation to run in
+	// parallel. Smaller lists are evaluated sequentially since
+	// the cost of workers outweighs the gain.
+	SelectParallelThreshold = 256
+)
+
+// SelectAllParallel evaluates the selector terms against each of
+// the given objects via a pool of workers. The order of objects is
+// preserved in the resulting matches & nomatches. Nil objects are
+// ignored.
+//
+// NOTE:
+//	An error is returned if evaluation fails for any of the objects.
+// The error of the object with the lowest index is returned in such
+// cases to keep the results deterministic.
+func SelectAllParallel(
+	terms metac.ResourceSelector, objs []*unstructured.Unstructured,
+) (matches, nomatches []*unstructured.Unstructured, err error) {
+	return MatchAllParallel(func(obj *unstructured.Unstructured) (bool, error) {
+		return selector.Evaluation{
+			Target: obj,
+			Terms:  terms.SelectorTerms,
+		}.RunMatch()
+	}, objs)
+}
+
+// MatchAllParallel evaluates the given match function against each
+// of the given objects via a pool of workers. This is similar to
+// SelectAllParallel & is meant for the selectors that are evaluated
+// beyond metac's selector terms.
+func MatchAllParallel(
+	match func(*unstructured.Unstructured) (bool, error),
+	objs []*unstructured.Unstructured,
+) (matches, nomatches []*unstructured.Unstructured, err error) {
+	var valid []*unstructured.Unstructured
+	for _, obj := range objs {
+		if obj == nil || obj.UnstructuredContent() == nil {
+			// accept only non nil instances
+			continue
+		}
+		valid = append(valid, obj)
+	}
+	objs = valid
+	if len(objs) == 0 {
+		return nil, nil, nil
+	}
+	isMatch := make([]bool, len(objs))
+	errs := make([]error, len(objs))
+	eval := func(idx int) {
+		isMatch[idx], errs[idx] = match(objs[idx])
+	}
+	workers := SelectWorkerCount
+	if len(objs) < SelectParallelThreshold || workers <= 1 {
+		workers = 1
+	}
+	if workers > len(objs) {
+		workers = len(objs)
+	}
+	// each worker evaluates a contiguous chunk of objects
+	chunk := (len(objs) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(objs); start += chunk {
+		end := start + chunk
+		if end > len(objs) {
+			end = len(objs)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for idx := start; idx < end; idx++ {
+				eval(idx)
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	for idx, obj := range objs {
+		if errs[idx] != nil {
+			return nil, nil, errs[idx]
+		}
+		if isMatch[idx] {
+			matches = append(matches, obj)
+		} else {
+			nomatches = append(nomatches, obj)
+		}
+	}
+	return matches, nomatches, nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2020 The MayaData Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package unstruct
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	metac "openebs.io/metac/apis/metacontroller/v1alpha1"
+)
+
+func newTestLabelledObjs(count int) []*unstructured.Unstructured {
+	var objs []*unstructured.Unstructured
+	for i := 0; i < count; i++ {
+		objs = append(objs, &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name": fmt.Sprintf("obj-%d", i),
+					"labels": map[string]interface{}{
+						"parity": fmt.Sprintf("%t", i%2 == 0),
+					},
+				},
+			},
+		})
+	}
+	return objs
+}
+
+func newTestParitySelector(isEven bool) metac.ResourceSelector {
+	return metac.ResourceSelector{
+		SelectorTerms: []*metac.SelectorTerm{
+			&metac.SelectorTerm{
+				MatchLabels: map[string]string{
+					"parity": fmt.Sprintf("%t", isEven),
+				},
+			},
+		},
+	}
+}
+
+func getNames(objs []*unstructured.Unstructured) []string {
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetName())
+	}
+	return names
+}
+
+func TestSelectAllParallel(t *testing.T) {
+	var tests = map[string]struct {
+		terms         metac.ResourceSelector
+		objs          []*unstructured.Unstructured
+		workers       int
+		threshold     int
+		expectMatches []string
+		expectNoMatch []string
+		isErr         bool
+	}{
+		"no objects": {
+			terms:     newTestParitySelector(true),
+			workers:   4,
+			threshold: 1,
+		},
+		"sequential evaluation below threshold": {
+			terms:         newTestParitySelector(true),
+			objs:          newTestLabelledObjs(4),
+			workers:       4,
+			threshold:     100,
+			expectMatches: []string{"obj-0", "obj-2"},
+			expectNoMatch: []string{"obj-1", "obj-3"},
+		},
+		"parallel evaluation preserves order": {
+			terms:         newTestParitySelector(false),
+			objs:          newTestLabelledObjs(7),
+			workers:       3,
+			threshold:     1,
+			expectMatches: []string{"obj-1", "obj-3", "obj-5"},
+			expectNoMatch: []string{"obj-0", "obj-2", "obj-4", "obj-6"},
+		},
+		"more workers than objects": {
+			terms:         newTestParitySelector(true),
+			objs:          newTestLabelledObjs(2),
+			workers:       16,
+			threshold:     1,
+			expectMatches: []string{"obj-0"},
+			expectNoMatch: []string{"obj-1"},
+		},
+		"nil objects are ignored": {
+			terms:         newTestParitySelector(true),
+			objs:          append(newTestLabelledObjs(2), nil),
+			workers:       2,
+			threshold:     1,
+			expectMatches: []string{"obj-0"},
+			expectNoMatch: []string{"obj-1"},
+		},
+		"no selector terms matches all": {
+			objs:          newTestLabelledObjs(3),
+			workers:       2,
+			threshold:     1,
+			expectMatches: []string{"obj-0", "obj-1", "obj-2"},
+		},
+		"invalid selector terms": {
+			terms: metac.ResourceSelector{
+				SelectorTerms: []*metac.SelectorTerm{
+					&metac.SelectorTerm{
+						MatchLabels: map[string]string{
+							"invalid key!": "true",
+						},
+					},
+				},
+			},
+			objs:      newTestLabelledObjs(3),
+			workers:   2,
+			threshold: 1,
+			isErr:     true,
+		},
+	}
+	for name, mock := range tests {
+		name := name
+		mock := mock
+		t.Run(name, func(t *testing.T) {
+			oldWorkers, oldThreshold := SelectWorkerCount, SelectParallelThreshold
+			defer func() {
+				SelectWorkerCount, SelectParallelThreshold = oldWorkers, oldThreshold
+			}()
+			SelectWorkerCount, SelectParallelThreshold = mock.workers, mock.threshold
+
+			matches, nomatches, err := SelectAllParallel(mock.terms, mock.objs)
+			if mock.isErr && err == nil {
+				t.Fatalf("Expected error got none")
+			}
+			if !mock.isErr && err != nil {
+				t.Fatalf("Expected no error got [%+v]", err)
+			}
+			if mock.isErr {
+				return
+			}
+			if diff := cmp.Diff(mock.expectMatches, getNames(matches)); diff != "" {
+				t.Fatalf("Expected no diff in matches got\n%s", diff)
+			}
+			if diff := cmp.Diff(mock.expectNoMatch, getNames(nomatches)); diff != "" {
+				t.Fatalf("Expected no diff in nomatches got\n%s", diff)
+			}
+		})
+	}
+}
+
+func benchmarkSelectAll(b *testing.B, count int, isParallel bool) {
+	objs := newTestLabelledObjs(count)
+	terms := newTestParitySelector(true)
+	oldThreshold := SelectParallelThreshold
+	defer func() { SelectParallelThreshold = oldThreshold }()
+	if isParallel {
+		SelectParallelThreshold = 1
+	} else {
+		SelectParallelThreshold = count + 1
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, err := SelectAllParallel(terms, objs)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSelectAllSequential1000(b *testing.B) {
+	benchmarkSelectAll(b, 1000, false)
+}
+
+func BenchmarkSelectAllParallel1000(b *testing.B) {
+	benchmarkSelectAll(b, 1000, true)
+}
+
+func BenchmarkSelectAllSequential10000(b *testing.B) {
+	benchmarkSelectAll(b, 10000, false)
+}
+
+func BenchmarkSelectAllParallel10000(b *testing.B) {
+	benchmarkSelectAll(b, 10000, true)
+}
+
+func BenchmarkListSelectorList10000(b *testing.B) {
+	objs := newTestLabelledObjs(10000)
+	terms := newTestParitySelector(true)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ListSelector(terms, objs...).List()
+	}
+}